@@ -27,6 +27,23 @@ func (a *Agent) aclAccessorID(secretID string) string {
 	return ident.AccessorID()
 }
 
+// anonymousTokenScoped returns true if the anonymous token has scopes, in
+// which case the servers authorize the queries made without a token with the
+// scope matching the address of the agent.
+func (a *Agent) anonymousTokenScoped() bool {
+	if !a.config.ACLsEnabled {
+		return false
+	}
+
+	ident, err := a.delegate.ResolveTokenAndDefaultMeta("", nil, nil)
+	if err != nil {
+		a.logger.Debug("failed to resolve the anonymous token scopes", "error", err)
+		return false
+	}
+	anonymous, ok := ident.ACLIdentity.(*structs.ACLToken)
+	return ok && len(anonymous.AnonymousScopes) > 0
+}
+
 // vetServiceRegister makes sure the service registration action is allowed by
 // the given token.
 func (a *Agent) vetServiceRegister(token string, service *structs.NodeService) error {
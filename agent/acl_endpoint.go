@@ -179,6 +179,41 @@ func (s *HTTPHandlers) ACLPolicyReadByID(resp http.ResponseWriter, req *http.Req
 	return s.ACLPolicyRead(resp, req, policyID, "")
 }
 
+func (s *HTTPHandlers) ACLPolicyEvaluate(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if s.checkACLDisabled() {
+		return nil, aclDisabled
+	}
+
+	var args structs.ACLPolicyEvaluateRequest
+	if err := decodeBody(req.Body, &args); err != nil {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Failed to decode request body: %v", err)}
+	}
+
+	args.Datacenter = s.agent.config.Datacenter
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+	if err := s.parseEntMeta(req, &args.EnterpriseMeta); err != nil {
+		return nil, err
+	}
+
+	if args.SecretID == "" && len(args.Policies) == 0 && args.Rules == "" {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: "At least one of SecretID, Policies or Rules must be set"}
+	}
+
+	var out structs.ACLPolicyEvaluateResponse
+	defer setMeta(resp, &out.QueryMeta)
+	if err := s.agent.RPC("ACL.PolicyEvaluate", &args, &out); err != nil {
+		return nil, err
+	}
+
+	if out.Results == nil {
+		out.Results = make([]structs.ACLPolicyEvaluateResult, 0)
+	}
+
+	return out.Results, nil
+}
+
 func (s *HTTPHandlers) ACLPolicyCreate(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	if s.checkACLDisabled() {
 		return nil, aclDisabled
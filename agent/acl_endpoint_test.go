@@ -57,6 +57,7 @@ func TestACL_Disabled_Response(t *testing.T) {
 		{"ACLPolicyList", a.srv.ACLPolicyList},
 		{"ACLPolicyCRUD", a.srv.ACLPolicyCRUD},
 		{"ACLPolicyCreate", a.srv.ACLPolicyCreate},
+		{"ACLPolicyEvaluate", a.srv.ACLPolicyEvaluate},
 		{"ACLTokenList", a.srv.ACLTokenList},
 		{"ACLTokenCreate", a.srv.ACLTokenCreate},
 		{"ACLTokenSelf", a.srv.ACLTokenSelf},
@@ -407,6 +408,47 @@ func TestACL_HTTP(t *testing.T) {
 			require.True(t, ok)
 			require.Equal(t, policyMap[idMap["policy-"+policyName]], policy)
 		})
+
+		t.Run("Evaluate", func(t *testing.T) {
+			evalInput := &structs.ACLPolicyEvaluateRequest{
+				Policies: []structs.ACLTokenPolicyLink{{Name: "read-all-nodes"}},
+				Rules:    `service "web" { policy = "write" }`,
+				Requests: []structs.ACLAuthorizationRequest{
+					{Resource: "node", Segment: "foo", Access: "read"},
+					{Resource: "service", Segment: "web", Access: "write"},
+					{Resource: "key", Segment: "foo", Access: "read"},
+				},
+			}
+
+			req, _ := http.NewRequest("POST", "/v1/acl/policy/evaluate?token=root", jsonBody(evalInput))
+			resp := httptest.NewRecorder()
+			raw, err := a.srv.ACLPolicyEvaluate(resp, req)
+			require.NoError(t, err)
+			results, ok := raw.([]structs.ACLPolicyEvaluateResult)
+			require.True(t, ok)
+			require.Len(t, results, 3)
+
+			require.True(t, results[0].Allow)
+			require.Equal(t, idMap["policy-read-all-nodes"], results[0].PolicyID)
+			require.True(t, results[1].Allow)
+			require.Equal(t, structs.ACLPolicyEvaluateRulesName, results[1].PolicyName)
+			require.False(t, results[2].Allow)
+			require.True(t, results[2].DefaultPolicy)
+		})
+
+		t.Run("Evaluate Nothing", func(t *testing.T) {
+			evalInput := &structs.ACLPolicyEvaluateRequest{
+				Requests: []structs.ACLAuthorizationRequest{
+					{Resource: "node", Segment: "foo", Access: "read"},
+				},
+			}
+
+			req, _ := http.NewRequest("POST", "/v1/acl/policy/evaluate?token=root", jsonBody(evalInput))
+			resp := httptest.NewRecorder()
+			_, err := a.srv.ACLPolicyEvaluate(resp, req)
+			require.Error(t, err)
+			require.True(t, isHTTPBadRequest(err))
+		})
 	})
 
 	t.Run("Role", func(t *testing.T) {
@@ -7,6 +7,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/armon/go-metrics"
+	"github.com/armon/go-metrics/prometheus"
 	"github.com/hashicorp/go-hclog"
 
 	"github.com/hashicorp/consul/lib"
@@ -26,6 +28,17 @@ import (
 // CoordinateUpdatePeriod and CoordinateUpdateMaxBatchSize.
 const scaleThreshold = 128
 
+var Summaries = []prometheus.SummaryDefinition{
+	{
+		Name: []string{"agent", "anti_entropy", "sync", "full"},
+		Help: "Measures the time it takes to run a full anti-entropy sync of the local state with the catalog.",
+	},
+	{
+		Name: []string{"agent", "anti_entropy", "sync", "changes"},
+		Help: "Measures the time it takes to run a partial anti-entropy sync of the local changes to the catalog.",
+	},
+}
+
 // scaleFactor returns a factor by which the next sync run should be delayed to
 // avoid saturation of the cluster. The larger the cluster grows the farther
 // the sync runs should be spread apart.
@@ -58,6 +71,10 @@ type StateSyncer struct {
 	// Interval is the time between two full sync runs.
 	Interval time.Duration
 
+	// Jitter is the maximum random delay added to Interval, before it is
+	// scaled with the cluster size. When zero, Interval is used.
+	Jitter time.Duration
+
 	// ShutdownCh is closed when the application is shutting down.
 	ShutdownCh chan struct{}
 
@@ -175,7 +192,10 @@ func (s *StateSyncer) nextFSMState(fs fsmState) fsmState {
 			return retryFullSyncState
 		}
 
-		if err := s.State.SyncFull(); err != nil {
+		start := time.Now()
+		err := s.State.SyncFull()
+		metrics.MeasureSince([]string{"agent", "anti_entropy", "sync", "full"}, start)
+		if err != nil {
 			s.Logger.Error("failed to sync remote state", "error", err)
 			return retryFullSyncState
 		}
@@ -204,7 +224,9 @@ func (s *StateSyncer) nextFSMState(fs fsmState) fsmState {
 				return partialSyncState
 			}
 
+			start := time.Now()
 			err := s.State.SyncChanges()
+			metrics.MeasureSince([]string{"agent", "anti_entropy", "sync", "changes"}, start)
 			if err != nil {
 				s.Logger.Error("failed to sync changes", "error", err)
 			}
@@ -297,7 +319,11 @@ func (s *StateSyncer) syncChangesEventFn() event {
 // Call this function everytime a full sync is performed.
 func (s *StateSyncer) resetNextFullSyncCh() {
 	if s.stagger != nil {
-		s.nextFullSyncCh = time.After(s.Interval + s.stagger(s.Interval))
+		jitter := s.Jitter
+		if jitter <= 0 {
+			jitter = s.Interval
+		}
+		s.nextFullSyncCh = time.After(s.Interval + s.stagger(jitter))
 	} else {
 		s.nextFullSyncCh = time.After(s.Interval)
 	}
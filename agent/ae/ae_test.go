@@ -379,6 +379,26 @@ func TestAE_SyncChangesEvent(t *testing.T) {
 	})
 }
 
+func TestAE_resetNextFullSyncCh_Jitter(t *testing.T) {
+	l := testSyncer(t)
+	var staggered []time.Duration
+	l.stagger = func(d time.Duration) time.Duration {
+		staggered = append(staggered, d)
+		return 0
+	}
+
+	// The interval is used when no jitter is configured.
+	l.Interval = 10 * time.Millisecond
+	l.resetNextFullSyncCh()
+
+	l.Jitter = 5 * time.Millisecond
+	l.resetNextFullSyncCh()
+
+	if got, want := staggered, []time.Duration{10 * time.Millisecond, 5 * time.Millisecond}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got stagger %v want %v", got, want)
+	}
+}
+
 type mock struct {
 	seq                   []string
 	syncFull, syncChanges func() error
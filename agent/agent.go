@@ -55,6 +55,7 @@ import (
 	"github.com/hashicorp/consul/lib/file"
 	"github.com/hashicorp/consul/lib/mutex"
 	"github.com/hashicorp/consul/lib/routine"
+	"github.com/hashicorp/consul/lib/stringslice"
 	"github.com/hashicorp/consul/logging"
 	"github.com/hashicorp/consul/proto/pbpeering"
 	"github.com/hashicorp/consul/tlsutil"
@@ -263,6 +264,14 @@ type Agent struct {
 	// checkAliases maps the check ID to an associated Alias checks
 	checkAliases map[structs.CheckID]*checks.CheckAlias
 
+	// checkMeshReadiness maps the check ID to an associated mesh readiness
+	// check of a connect proxy
+	checkMeshReadiness map[structs.CheckID]*meshReadinessCheck
+
+	// checkMeshProbes maps the check ID to an associated mesh probe check of
+	// a connect proxy
+	checkMeshProbes map[structs.CheckID]*meshProbeCheck
+
 	// exposedPorts tracks listener ports for checks exposed through a proxy
 	exposedPorts map[string]int
 
@@ -374,9 +383,17 @@ type Agent struct {
 	// changed
 	configFileWatcher config.Watcher
 
+	// tlsFileWatcher reports events when a TLS certificate, key or CA file
+	// changed so they can be reloaded when auto_reload_certificates is set.
+	tlsFileWatcher config.Watcher
+
 	// xdsServer serves the XDS protocol for configuring Envoy proxies.
 	xdsServer *xds.Server
 
+	// serviceMetrics accumulates the requests reported by the local connect
+	// proxies with builtin metrics enabled.
+	serviceMetrics *serviceMetricsCollector
+
 	// enterpriseAgent embeds fields that we only access in consul-enterprise builds
 	enterpriseAgent
 }
@@ -398,23 +415,26 @@ type Agent struct {
 //     resolving the configuration
 func New(bd BaseDeps) (*Agent, error) {
 	a := Agent{
-		checkReapAfter:  make(map[structs.CheckID]time.Duration),
-		checkMonitors:   make(map[structs.CheckID]*checks.CheckMonitor),
-		checkTTLs:       make(map[structs.CheckID]*checks.CheckTTL),
-		checkHTTPs:      make(map[structs.CheckID]*checks.CheckHTTP),
-		checkH2PINGs:    make(map[structs.CheckID]*checks.CheckH2PING),
-		checkTCPs:       make(map[structs.CheckID]*checks.CheckTCP),
-		checkUDPs:       make(map[structs.CheckID]*checks.CheckUDP),
-		checkGRPCs:      make(map[structs.CheckID]*checks.CheckGRPC),
-		checkDockers:    make(map[structs.CheckID]*checks.CheckDocker),
-		checkAliases:    make(map[structs.CheckID]*checks.CheckAlias),
-		eventCh:         make(chan serf.UserEvent, 1024),
-		eventBuf:        make([]*UserEvent, 256),
-		joinLANNotifier: &systemd.Notifier{},
-		retryJoinCh:     make(chan error),
-		shutdownCh:      make(chan struct{}),
-		endpoints:       make(map[string]string),
-		stateLock:       mutex.New(),
+		checkReapAfter:     make(map[structs.CheckID]time.Duration),
+		checkMonitors:      make(map[structs.CheckID]*checks.CheckMonitor),
+		checkTTLs:          make(map[structs.CheckID]*checks.CheckTTL),
+		checkHTTPs:         make(map[structs.CheckID]*checks.CheckHTTP),
+		checkH2PINGs:       make(map[structs.CheckID]*checks.CheckH2PING),
+		checkTCPs:          make(map[structs.CheckID]*checks.CheckTCP),
+		checkUDPs:          make(map[structs.CheckID]*checks.CheckUDP),
+		checkGRPCs:         make(map[structs.CheckID]*checks.CheckGRPC),
+		checkDockers:       make(map[structs.CheckID]*checks.CheckDocker),
+		checkAliases:       make(map[structs.CheckID]*checks.CheckAlias),
+		checkMeshReadiness: make(map[structs.CheckID]*meshReadinessCheck),
+		checkMeshProbes:    make(map[structs.CheckID]*meshProbeCheck),
+		eventCh:            make(chan serf.UserEvent, 1024),
+		eventBuf:           make([]*UserEvent, 256),
+		joinLANNotifier:    &systemd.Notifier{},
+		retryJoinCh:        make(chan error),
+		shutdownCh:         make(chan struct{}),
+		endpoints:          make(map[string]string),
+		stateLock:          mutex.New(),
+		serviceMetrics:     newServiceMetricsCollector(),
 
 		baseDeps:        bd,
 		tokens:          bd.Tokens,
@@ -441,7 +461,8 @@ func New(bd BaseDeps) (*Agent, error) {
 			Logger: bd.Logger.Named("rpcclient.health"),
 		},
 		UseStreamingBackend: a.config.UseStreamingBackend,
-		QueryOptionDefaults: config.ApplyDefaultQueryOptions(a.config),
+		QueryOptionDefaults:  config.ApplyDefaultQueryOptions(a.config),
+		AnonymousTokenScoped: a.anonymousTokenScoped,
 	}
 
 	a.rpcClientPeering = pbpeering.NewPeeringServiceClient(conn)
@@ -453,6 +474,9 @@ func New(bd BaseDeps) (*Agent, error) {
 	// delegate to some of the cache registrations. Now we just
 	// pass the agent itself so its safe to move here.
 	a.registerCache()
+	if err := a.cache.Restore(); err != nil {
+		a.logger.Warn("failed to restore persisted cache entries", "error", err)
+	}
 
 	// TODO: why do we ignore failure to load persisted tokens?
 	_ = a.tokens.Load(bd.RuntimeConfig.ACLTokens, a.logger)
@@ -481,10 +505,34 @@ func New(bd BaseDeps) (*Agent, error) {
 		}
 		a.configFileWatcher = w
 	}
+	if tlsFiles := tlsWatchedFiles(a.baseDeps.RuntimeConfig.TLS); a.baseDeps.RuntimeConfig.TLS.AutoReloadCertificates && len(tlsFiles) > 0 {
+		w, err := config.NewRateLimitedFileWatcher(tlsFiles, a.baseDeps.Logger, a.baseDeps.RuntimeConfig.AutoReloadConfigCoalesceInterval)
+		if err != nil {
+			return nil, err
+		}
+		a.tlsFileWatcher = w
+	}
 
 	return &a, nil
 }
 
+// tlsWatchedFiles returns the certificate, key and CA files of all the
+// protocols, without duplicates.
+func tlsWatchedFiles(cfg tlsutil.Config) []string {
+	var files []string
+	seen := make(map[string]struct{})
+	for _, p := range []tlsutil.ProtocolConfig{cfg.InternalRPC, cfg.GRPC, cfg.HTTPS} {
+		for _, f := range []string{p.CertFile, p.KeyFile, p.CAFile} {
+			if _, ok := seen[f]; ok || f == "" {
+				continue
+			}
+			seen[f] = struct{}{}
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
 // GetConfig retrieves the agents config
 // TODO make export the config field and get rid of this method
 // This is here for now to simplify the work I am doing and make
@@ -498,14 +546,17 @@ func (a *Agent) GetConfig() *config.RuntimeConfig {
 // LocalConfig takes a config.RuntimeConfig and maps the fields to a local.Config
 func LocalConfig(cfg *config.RuntimeConfig) local.Config {
 	lc := local.Config{
-		AdvertiseAddr:       cfg.AdvertiseAddrLAN.String(),
-		CheckUpdateInterval: cfg.CheckUpdateInterval,
-		Datacenter:          cfg.Datacenter,
-		DiscardCheckOutput:  cfg.DiscardCheckOutput,
-		NodeID:              cfg.NodeID,
-		NodeName:            cfg.NodeName,
-		Partition:           cfg.PartitionOrDefault(),
-		TaggedAddresses:     map[string]string{},
+		AdvertiseAddr:          cfg.AdvertiseAddrLAN.String(),
+		CheckSyncFlushInterval: cfg.CheckSyncFlushInterval,
+		CheckUpdateInterval:    cfg.CheckUpdateInterval,
+		CheckUpdateOutputHash:  cfg.CheckUpdateTrigger == config.CheckUpdateTriggerHash,
+		CheckUpdateStatusOnly:  cfg.CheckUpdateTrigger == config.CheckUpdateTriggerStatus,
+		Datacenter:             cfg.Datacenter,
+		DiscardCheckOutput:     cfg.DiscardCheckOutput,
+		NodeID:                 cfg.NodeID,
+		NodeName:               cfg.NodeName,
+		Partition:              cfg.PartitionOrDefault(),
+		TaggedAddresses:        map[string]string{},
 	}
 	for k, v := range cfg.TaggedAddresses {
 		lc.TaggedAddresses[k] = v
@@ -545,11 +596,14 @@ func (a *Agent) Start(ctx context.Context) error {
 	}
 
 	// create the local state
-	a.State = local.NewState(LocalConfig(c), a.logger, a.tokens)
+	localConfig := LocalConfig(c)
+	localConfig.NodeIdentity = a.baseDeps.AutoConfig.NodeIdentity()
+	a.State = local.NewState(localConfig, a.logger, a.tokens)
 
 	// create the state synchronization manager which performs
 	// regular and on-demand state synchronizations (anti-entropy).
 	a.sync = ae.NewStateSyncer(a.State, c.AEInterval, a.shutdownCh, a.logger)
+	a.sync.Jitter = c.AEJitter
 
 	// create the config for the rpc server/client
 	consulCfg, err := newConsulConfig(a.config, a.logger)
@@ -673,6 +727,9 @@ func (a *Agent) Start(ctx context.Context) error {
 		return err
 	}
 
+	a.startMeshReadinessChecks()
+	a.startMeshProbeChecks()
+
 	go localproxycfg.Sync(
 		&lib.StopChannelContext{StopCh: a.shutdownCh},
 		localproxycfg.SyncConfig{
@@ -696,6 +753,9 @@ func (a *Agent) Start(ctx context.Context) error {
 		go a.sendCoordinate()
 	}
 
+	// Start sending the requests reported by the local proxies to the server.
+	go a.sendServiceMetrics()
+
 	// Write out the PID file if necessary.
 	if err := a.storePid(); err != nil {
 		return err
@@ -750,6 +810,10 @@ func (a *Agent) Start(ctx context.Context) error {
 		go m.Monitor(&lib.StopChannelContext{StopCh: a.shutdownCh})
 	}
 
+	if a.config.EnableExpiryChecks {
+		go a.runExpiryChecks()
+	}
+
 	// consul version metric with labels
 	metrics.SetGaugeWithLabels([]string{"version"}, 1, []metrics.Label{
 		{Name: "version", Value: a.config.VersionWithMetadata()},
@@ -771,6 +835,37 @@ func (a *Agent) Start(ctx context.Context) error {
 		}()
 	}
 
+	// start a go routine to reload config when the auto-config snippets change
+	go func() {
+		for {
+			select {
+			case <-a.shutdownCh:
+				return
+			case <-a.baseDeps.AutoConfig.ConfigSnippetsUpdated():
+				a.baseDeps.Logger.Debug("reloading config after an auto-config snippets update")
+				if err := a.ReloadConfig(); err != nil {
+					a.baseDeps.Logger.Error("error loading config", "error", err)
+				}
+			}
+		}
+	}()
+
+	// start a go routine to reload the TLS certificates based on file watcher events
+	if a.tlsFileWatcher != nil {
+		a.baseDeps.Logger.Debug("starting TLS file watcher")
+		a.tlsFileWatcher.Start(context.Background())
+		go func() {
+			for event := range a.tlsFileWatcher.EventsCh() {
+				a.baseDeps.Logger.Debug("auto-reload TLS certificates triggered", "num-events", len(event.Filenames))
+				if err := a.tlsConfigurator.ReloadCertificates(); err != nil {
+					a.baseDeps.Logger.Error("error reloading TLS certificates", "error", err)
+					continue
+				}
+				a.baseDeps.Logger.Info("reloaded TLS certificates", "files", event.Filenames)
+			}
+		}()
+	}
+
 	return nil
 }
 
@@ -830,6 +925,9 @@ func (a *Agent) listenAndServeGRPC() error {
 		},
 		a,
 	)
+	a.xdsServer.StatusReporter = a.State
+	a.xdsServer.MetricsRecorder = a.serviceMetrics
+	a.xdsServer.ProtocolRecorder = a
 	a.xdsServer.Register(a.publicGRPCServer)
 
 	ln, err := a.startListeners(a.config.GRPCAddrs)
@@ -903,7 +1001,8 @@ func (a *Agent) startListeners(addrs []net.Addr) ([]net.Listener, error) {
 
 		switch x := addr.(type) {
 		case *net.UnixAddr:
-			l, err = a.listenSocket(x.Name)
+			user, group, mode := a.config.UnixSocketUser, a.config.UnixSocketGroup, a.config.UnixSocketMode
+			l, err = a.listenSocket(x.Name, user, group, mode)
 			if err != nil {
 				return nil, err
 			}
@@ -915,6 +1014,12 @@ func (a *Agent) startListeners(addrs []net.Addr) ([]net.Listener, error) {
 			}
 			l = &tcpKeepAliveListener{l.(*net.TCPListener)}
 
+		case *config.NamedPipeAddr:
+			l, err = listenNamedPipe(x.Name)
+			if err != nil {
+				return nil, err
+			}
+
 		default:
 			return nil, fmt.Errorf("unsupported address type %T", addr)
 		}
@@ -942,6 +1047,45 @@ func (a *Agent) listenHTTP() ([]apiServer, error) {
 	var ln []net.Listener
 	var servers []apiServer
 
+	serve := func(proto string, l net.Listener, allowlist *Allowlist) error {
+		var tlscfg *tls.Config
+		_, isTCP := l.(*tcpKeepAliveListener)
+		if isTCP && proto == "https" {
+			tlscfg = a.tlsConfigurator.IncomingHTTPSConfig()
+			l = tls.NewListener(l, tlscfg)
+		}
+
+		srv := &HTTPHandlers{
+			agent:     a,
+			denylist:  NewDenylist(a.config.HTTPBlockEndpoints),
+			allowlist: allowlist,
+		}
+		a.configReloaders = append(a.configReloaders, srv.ReloadConfig)
+		if allowlist == nil {
+			a.httpHandlers = srv
+		}
+		httpServer := &http.Server{
+			Addr:           l.Addr().String(),
+			TLSConfig:      tlscfg,
+			Handler:        srv.handler(a.config.EnableDebug),
+			MaxHeaderBytes: a.config.HTTPMaxHeaderBytes,
+		}
+
+		// Load the connlimit helper into the server
+		connLimitFn := a.httpConnLimiter.HTTPConnStateFuncWithDefault429Handler(10 * time.Millisecond)
+
+		if proto == "https" {
+			if err := setupHTTPS(httpServer, connLimitFn, a.config.HTTPSHandshakeTimeout); err != nil {
+				return err
+			}
+		} else {
+			httpServer.ConnState = connLimitFn
+		}
+
+		servers = append(servers, newAPIServerHTTP(proto, l, httpServer))
+		return nil
+	}
+
 	start := func(proto string, addrs []net.Addr) error {
 		listeners, err := a.startListeners(addrs)
 		if err != nil {
@@ -950,38 +1094,30 @@ func (a *Agent) listenHTTP() ([]apiServer, error) {
 		ln = append(ln, listeners...)
 
 		for _, l := range listeners {
-			var tlscfg *tls.Config
-			_, isTCP := l.(*tcpKeepAliveListener)
-			if isTCP && proto == "https" {
-				tlscfg = a.tlsConfigurator.IncomingHTTPSConfig()
-				l = tls.NewListener(l, tlscfg)
+			if err := serve(proto, l, nil); err != nil {
+				return err
 			}
+		}
+		return nil
+	}
 
-			srv := &HTTPHandlers{
-				agent:    a,
-				denylist: NewDenylist(a.config.HTTPBlockEndpoints),
-			}
-			a.configReloaders = append(a.configReloaders, srv.ReloadConfig)
-			a.httpHandlers = srv
-			httpServer := &http.Server{
-				Addr:           l.Addr().String(),
-				TLSConfig:      tlscfg,
-				Handler:        srv.handler(a.config.EnableDebug),
-				MaxHeaderBytes: a.config.HTTPMaxHeaderBytes,
+	// startSockets starts the additional unix sockets, which have their own
+	// file permissions and may only serve some of the endpoints.
+	startSockets := func(sockets []config.HTTPUnixSocket) error {
+		for _, sock := range sockets {
+			l, err := a.listenSocket(sock.Path, sock.User, sock.Group, sock.Mode)
+			if err != nil {
+				return err
 			}
+			ln = append(ln, l)
 
-			// Load the connlimit helper into the server
-			connLimitFn := a.httpConnLimiter.HTTPConnStateFuncWithDefault429Handler(10 * time.Millisecond)
-
-			if proto == "https" {
-				if err := setupHTTPS(httpServer, connLimitFn, a.config.HTTPSHandshakeTimeout); err != nil {
-					return err
-				}
-			} else {
-				httpServer.ConnState = connLimitFn
+			var allowlist *Allowlist
+			if len(sock.AllowEndpoints) > 0 {
+				allowlist = NewAllowlist(sock.AllowEndpoints)
+			}
+			if err := serve("http", l, allowlist); err != nil {
+				return err
 			}
-
-			servers = append(servers, newAPIServerHTTP(proto, l, httpServer))
 		}
 		return nil
 	}
@@ -994,6 +1130,10 @@ func (a *Agent) listenHTTP() ([]apiServer, error) {
 		closeListeners(ln)
 		return nil, err
 	}
+	if err := startSockets(a.config.HTTPUnixSockets); err != nil {
+		closeListeners(ln)
+		return nil, err
+	}
 	return servers, nil
 }
 
@@ -1046,7 +1186,7 @@ func (ln tcpKeepAliveListener) Accept() (c net.Conn, err error) {
 	return tc, nil
 }
 
-func (a *Agent) listenSocket(path string) (net.Listener, error) {
+func (a *Agent) listenSocket(path, user, group, mode string) (net.Listener, error) {
 	if _, err := os.Stat(path); !os.IsNotExist(err) {
 		a.logger.Warn("Replacing socket", "path", path)
 	}
@@ -1057,7 +1197,6 @@ func (a *Agent) listenSocket(path string) (net.Listener, error) {
 	if err != nil {
 		return nil, err
 	}
-	user, group, mode := a.config.UnixSocketUser, a.config.UnixSocketGroup, a.config.UnixSocketMode
 	if err := setFilePermissions(path, user, group, mode); err != nil {
 		return nil, fmt.Errorf("Failed setting up socket: %s", err)
 	}
@@ -1171,6 +1310,8 @@ func newConsulConfig(runtimeCfg *config.RuntimeConfig, logger hclog.Logger) (*co
 	cfg.CoordinateUpdateMaxBatches = runtimeCfg.ConsulCoordinateUpdateMaxBatches
 	cfg.CoordinateUpdatePeriod = runtimeCfg.ConsulCoordinateUpdatePeriod
 	cfg.CheckOutputMaxSize = runtimeCfg.CheckOutputMaxSize
+	cfg.CatalogCheckOutputMaxSize = runtimeCfg.CatalogCheckOutputMaxSize
+	cfg.CatalogCheckOutputCompression = runtimeCfg.CatalogCheckOutputCompression
 
 	cfg.RaftConfig.HeartbeatTimeout = runtimeCfg.ConsulRaftHeartbeatTimeout
 	cfg.RaftConfig.LeaderLeaseTimeout = runtimeCfg.ConsulRaftLeaderLeaseTimeout
@@ -1190,6 +1331,7 @@ func newConsulConfig(runtimeCfg *config.RuntimeConfig, logger hclog.Logger) (*co
 	cfg.SerfLANConfig.MemberlistConfig.ProbeTimeout = runtimeCfg.GossipLANProbeTimeout
 	cfg.SerfLANConfig.MemberlistConfig.SuspicionMult = runtimeCfg.GossipLANSuspicionMult
 	cfg.SerfLANConfig.MemberlistConfig.RetransmitMult = runtimeCfg.GossipLANRetransmitMult
+	cfg.SerfLANTCPOnly = runtimeCfg.GossipLANTCPOnly
 	if runtimeCfg.ReconnectTimeoutLAN != 0 {
 		cfg.SerfLANConfig.ReconnectTimeout = runtimeCfg.ReconnectTimeoutLAN
 	}
@@ -1207,6 +1349,7 @@ func newConsulConfig(runtimeCfg *config.RuntimeConfig, logger hclog.Logger) (*co
 		cfg.SerfWANConfig.MemberlistConfig.ProbeTimeout = runtimeCfg.GossipWANProbeTimeout
 		cfg.SerfWANConfig.MemberlistConfig.SuspicionMult = runtimeCfg.GossipWANSuspicionMult
 		cfg.SerfWANConfig.MemberlistConfig.RetransmitMult = runtimeCfg.GossipWANRetransmitMult
+		cfg.SerfWANTCPOnly = runtimeCfg.GossipWANTCPOnly
 		if runtimeCfg.ReconnectTimeoutWAN != 0 {
 			cfg.SerfWANConfig.ReconnectTimeout = runtimeCfg.ReconnectTimeoutWAN
 		}
@@ -1252,6 +1395,7 @@ func newConsulConfig(runtimeCfg *config.RuntimeConfig, logger hclog.Logger) (*co
 	if runtimeCfg.RaftSnapshotInterval != 0 {
 		cfg.RaftConfig.SnapshotInterval = runtimeCfg.RaftSnapshotInterval
 	}
+	cfg.RaftSnapshotAutoTune = runtimeCfg.RaftSnapshotAutoTune
 	if runtimeCfg.RaftTrailingLogs != 0 {
 		cfg.RaftConfig.TrailingLogs = uint64(runtimeCfg.RaftTrailingLogs)
 	}
@@ -1301,6 +1445,11 @@ func newConsulConfig(runtimeCfg *config.RuntimeConfig, logger hclog.Logger) (*co
 	if runtimeCfg.RPCMaxConnsPerClient > 0 {
 		cfg.RPCMaxConnsPerClient = runtimeCfg.RPCMaxConnsPerClient
 	}
+	cfg.RPCMaxConcurrentRequestsPerClient = runtimeCfg.RPCMaxConcurrentRequestsPerClient
+	cfg.RPCMaxInFlightBytesPerClient = runtimeCfg.RPCMaxInFlightBytesPerClient
+	if runtimeCfg.MaxPerPage > 0 {
+		cfg.MaxPerPage = runtimeCfg.MaxPerPage
+	}
 
 	// RPC-related performance configs. We allow explicit zero value to disable so
 	// copy it whatever the value.
@@ -1332,10 +1481,14 @@ func newConsulConfig(runtimeCfg *config.RuntimeConfig, logger hclog.Logger) (*co
 
 	cfg.AutoEncryptAllowTLS = runtimeCfg.AutoEncryptAllowTLS
 
+	cfg.TrafficShiftPrometheusAddresses = runtimeCfg.ConnectTrafficShiftPrometheusAddresses
+
 	// Copy the Connect CA bootstrap runtimeCfg
 	if runtimeCfg.ConnectEnabled {
 		cfg.ConnectEnabled = true
 		cfg.ConnectMeshGatewayWANFederationEnabled = runtimeCfg.ConnectMeshGatewayWANFederationEnabled
+		cfg.ConnectCARootsWebhook = runtimeCfg.ConnectCARootsWebhook
+		cfg.ConnectCARootsWebhookSecret = runtimeCfg.ConnectCARootsWebhookSecret
 
 		ca, err := runtimeCfg.ConnectCAConfiguration()
 		if err != nil {
@@ -1356,6 +1509,8 @@ func newConsulConfig(runtimeCfg *config.RuntimeConfig, logger hclog.Logger) (*co
 	cfg.AutoConfigAuthzAuthMethod = runtimeCfg.AutoConfig.Authorizer.AuthMethod
 	cfg.AutoConfigAuthzClaimAssertions = runtimeCfg.AutoConfig.Authorizer.ClaimAssertions
 	cfg.AutoConfigAuthzAllowReuse = runtimeCfg.AutoConfig.Authorizer.AllowReuse
+	cfg.AutoConfigAuthzVerifiedNodeMeta = runtimeCfg.AutoConfig.Authorizer.VerifiedNodeMeta
+	cfg.AutoConfigSnippets = runtimeCfg.AutoConfig.ConfigSnippets
 
 	// This will set up the LAN keyring, as well as the WAN and any segments
 	// for servers.
@@ -1451,8 +1606,10 @@ func (a *Agent) RPC(method string, args interface{}, reply interface{}) error {
 	return a.delegate.RPC(method, args, reply)
 }
 
-// Leave is used to prepare the agent for a graceful shutdown
+// Leave is used to prepare the agent for a graceful shutdown. The local
+// services are drained first, as configured by graceful_shutdown.
 func (a *Agent) Leave() error {
+	a.drainServices()
 	return a.delegate.Leave()
 }
 
@@ -1475,6 +1632,11 @@ func (a *Agent) ShutdownAgent() error {
 		a.configFileWatcher.Stop()
 	}
 
+	// Stop TLS file watcher
+	if a.tlsFileWatcher != nil {
+		a.tlsFileWatcher.Stop()
+	}
+
 	a.stopLicenseManager()
 
 	// this would be cancelled anyways (by the closing of the shutdown ch) but
@@ -1517,6 +1679,17 @@ func (a *Agent) ShutdownAgent() error {
 	for _, chk := range a.checkH2PINGs {
 		chk.Stop()
 	}
+	for _, chk := range a.checkMeshReadiness {
+		chk.Stop()
+	}
+	for _, chk := range a.checkMeshProbes {
+		chk.Stop()
+	}
+
+	// Stop the delayed sync of check updates
+	if a.State != nil {
+		a.State.Stop()
+	}
 
 	// Stop gRPC
 	a.publicGRPCServer.Stop()
@@ -1941,6 +2114,37 @@ func (a *Agent) persistService(service *structs.NodeService, source configSource
 	return file.WriteAtomic(svcPath, encoded)
 }
 
+// persistServiceMeta sets a metadata key in the persisted definition of a
+// service, if the service was persisted, so that it is restored with the
+// service when the agent restarts.
+func (a *Agent) persistServiceMeta(serviceID structs.ServiceID, key, value string) error {
+	svcPath := a.makeServiceFilePath(serviceID)
+	buf, err := ioutil.ReadFile(svcPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var p persistedService
+	if err := json.Unmarshal(buf, &p); err != nil {
+		return err
+	}
+	if p.Service == nil {
+		// The definitions persisted by older versions are replaced with the
+		// current format on startup, and are left alone until then.
+		return nil
+	}
+	p.Service.Meta = withServiceMeta(p.Service.Meta, key, value)
+
+	encoded, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return file.WriteAtomic(svcPath, encoded)
+}
+
 // purgeService removes a persisted service definition file from the data dir
 func (a *Agent) purgeService(serviceID structs.ServiceID) error {
 	svcPath := a.makeServiceFilePath(serviceID)
@@ -2124,6 +2328,12 @@ func (a *Agent) addServiceLocked(req addServiceLockedRequest) error {
 		return err
 	}
 
+	chkTypes, err := a.renderServiceCheckTemplates(req.Service, req.chkTypes)
+	if err != nil {
+		return err
+	}
+	req.chkTypes = chkTypes
+
 	if a.config.EnableCentralServiceConfig && (req.Service.IsSidecarProxy() || req.Service.IsGateway()) {
 		return a.serviceManager.AddService(req)
 	}
@@ -2265,6 +2475,12 @@ func (a *Agent) addServiceInternal(req addServiceInternalRequest) error {
 		if chkType.Status != "" {
 			check.Status = chkType.Status
 		}
+		if chkType.IsAlias() {
+			// Expose the target of alias checks in the catalog, so that the
+			// alias checks of other agents can detect loops.
+			check.Definition.AliasNode = chkType.AliasNode
+			check.Definition.AliasService = chkType.AliasService
+		}
 
 		// Restore the fields from the snapshot.
 		prev, ok := req.checkStateSnapshot[cid]
@@ -2285,6 +2501,13 @@ func (a *Agent) addServiceInternal(req addServiceInternalRequest) error {
 	sid := service.CompoundServiceID()
 	if s := a.State.Service(sid); s == nil {
 		cleanupServices = append(cleanupServices, sid)
+	} else {
+		// Re-registering a service doesn't change its traffic, so the
+		// protocols detected by its proxy are kept.
+		service.Meta = keepDetectedProtocols(service.Meta, s.Meta)
+		if req.persistService != nil {
+			req.persistService.Meta = keepDetectedProtocols(req.persistService.Meta, s.Meta)
+		}
 	}
 
 	for _, check := range checks {
@@ -2358,6 +2581,36 @@ func (a *Agent) addServiceInternal(req addServiceInternalRequest) error {
 		}
 	}
 
+	if a.config.ConnectMeshReadinessCheckEnabled && service.Kind == structs.ServiceKindConnectProxy {
+		existingChecks[meshReadinessCheckID(sid)] = true
+		if err := a.addMeshReadinessCheckLocked(service, req.token); err != nil {
+			a.cleanupRegistration(cleanupServices, cleanupChecks)
+			return err
+		}
+	}
+
+	if a.config.ConnectMeshProbesEnabled && service.Kind == structs.ServiceKindConnectProxy {
+		cfg, err := parseMeshProbeConfig(service.Proxy.Config)
+		if err != nil {
+			a.cleanupRegistration(cleanupServices, cleanupChecks)
+			return err
+		}
+		cid := meshProbeCheckID(sid)
+		if cfg != nil {
+			existingChecks[cid] = true
+			if err := a.addMeshProbeCheckLocked(service, *cfg, req.token); err != nil {
+				a.cleanupRegistration(cleanupServices, cleanupChecks)
+				return err
+			}
+		} else if _, ok := a.checkMeshProbes[cid]; ok {
+			// The proxy no longer probes its upstreams.
+			if err := a.removeCheckLocked(cid, persist); err != nil {
+				a.cleanupRegistration(cleanupServices, cleanupChecks)
+				return err
+			}
+		}
+	}
+
 	if req.replaceExistingChecks {
 		for checkID, keep := range existingChecks {
 			if !keep {
@@ -2607,6 +2860,21 @@ func (a *Agent) addCheckLocked(check *structs.HealthCheck, chkType *structs.Chec
 		if service == nil {
 			return fmt.Errorf("ServiceID %q does not exist", cid.String())
 		}
+
+		if chkType != nil && chkType.Templated {
+			data := a.checkTemplateData(service)
+			rendered, err := renderCheckTemplates(chkType, data)
+			if err != nil {
+				return err
+			}
+			chkType = rendered
+			if check.Name, err = renderCheckTemplate("Name", check.Name, data); err != nil {
+				return err
+			}
+			if check.Notes, err = renderCheckTemplate("Notes", check.Notes, data); err != nil {
+				return err
+			}
+		}
 	}
 
 	// Extra validations
@@ -2614,6 +2882,11 @@ func (a *Agent) addCheckLocked(check *structs.HealthCheck, chkType *structs.Chec
 		return err
 	}
 
+	if chkType != nil && chkType.IsAlias() {
+		check.Definition.AliasNode = chkType.AliasNode
+		check.Definition.AliasService = chkType.AliasService
+	}
+
 	// snapshot the current state of the health check to avoid potential flapping
 	cid := check.CompoundCheckID()
 	existing := a.State.Check(cid)
@@ -2720,6 +2993,7 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 				Logger:        a.logger,
 				OutputMaxSize: maxOutputSize,
 			}
+			ttl.Expired, ttl.Renewed = a.ttlExpiryHandlers(check, chkType, token)
 
 			// Restore persisted state, if any
 			if err := a.loadCheckState(check); err != nil {
@@ -2993,13 +3267,16 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 
 			aliasServiceID := structs.NewServiceID(chkType.AliasService, &check.EnterpriseMeta)
 			chkImpl := &checks.CheckAlias{
-				Notify:         a.State,
-				RPC:            a.delegate,
-				RPCReq:         rpcReq,
-				CheckID:        cid,
-				Node:           chkType.AliasNode,
-				ServiceID:      aliasServiceID,
-				EnterpriseMeta: check.EnterpriseMeta,
+				Notify:           a.State,
+				RPC:              a.delegate,
+				RPCReq:           rpcReq,
+				CheckID:          cid,
+				Node:             chkType.AliasNode,
+				ServiceID:        aliasServiceID,
+				LocalNode:        a.config.NodeName,
+				LocalServiceID:   sid,
+				PropagationDelay: chkType.AliasPropagationDelay,
+				EnterpriseMeta:   check.EnterpriseMeta,
 			}
 			chkImpl.Start()
 			a.checkAliases[cid] = chkImpl
@@ -3148,6 +3425,14 @@ func (a *Agent) cancelCheckMonitors(checkID structs.CheckID) {
 		check.Stop()
 		delete(a.checkH2PINGs, checkID)
 	}
+	if check, ok := a.checkMeshReadiness[checkID]; ok {
+		check.Stop()
+		delete(a.checkMeshReadiness, checkID)
+	}
+	if check, ok := a.checkMeshProbes[checkID]; ok {
+		check.Stop()
+		delete(a.checkMeshProbes, checkID)
+	}
 
 }
 
@@ -3801,6 +4086,100 @@ func (a *Agent) DisableServiceMaintenance(serviceID structs.ServiceID) error {
 	return nil
 }
 
+// SetServiceDetectedProtocol records the protocol a sidecar proxy detected on
+// the inbound traffic of the given service in the service's metadata, so that
+// it is synced to the catalog.
+func (a *Agent) SetServiceDetectedProtocol(serviceID structs.ServiceID, protocol string) error {
+	if !structs.IsDetectableProtocol(protocol) {
+		return fmt.Errorf("Invalid protocol %q", protocol)
+	}
+
+	updated, err := a.updateServiceMeta(serviceID, structs.MetaDetectedProtocol, func(string) string {
+		return protocol
+	})
+	if err != nil || !updated {
+		return err
+	}
+	a.logger.Debug("Service protocol detected", "service", serviceID.String(), "protocol", protocol)
+	return nil
+}
+
+// SetUpstreamDetectedProtocol records the protocol a connect proxy detected
+// on the traffic to one of its upstreams in the proxy's metadata, so that it
+// is synced to the catalog.
+func (a *Agent) SetUpstreamDetectedProtocol(proxyID structs.ServiceID, upstream structs.ServiceName, protocol string) error {
+	if !structs.IsDetectableProtocol(protocol) {
+		return fmt.Errorf("Invalid protocol %q", protocol)
+	}
+
+	updated, err := a.updateServiceMeta(proxyID, structs.MetaDetectedUpstreamProtocols, func(value string) string {
+		protocols := structs.ParseDetectedUpstreamProtocols(value)
+		protocols[upstream.String()] = protocol
+		return structs.FormatDetectedUpstreamProtocols(protocols)
+	})
+	if err != nil || !updated {
+		return err
+	}
+	a.logger.Debug("Upstream protocol detected", "service", proxyID.String(), "upstream", upstream.String(), "protocol", protocol)
+	return nil
+}
+
+// updateServiceMeta updates a metadata key of a local service, both in the
+// local state and in its persisted definition. It returns whether the value
+// changed.
+func (a *Agent) updateServiceMeta(serviceID structs.ServiceID, key string, update func(value string) string) (bool, error) {
+	// Hold the state lock so that a concurrent registration of the service
+	// isn't overwritten with the definition read here.
+	a.stateLock.Lock()
+	defer a.stateLock.Unlock()
+
+	state := a.State.ServiceState(serviceID)
+	if state == nil {
+		return false, fmt.Errorf("No service registered with ID %q", serviceID.String())
+	}
+	value := update(state.Service.Meta[key])
+	if state.Service.Meta[key] == value {
+		return false, nil
+	}
+
+	// The service held by the local state is shared with its readers and
+	// must not be modified, update a copy.
+	svc := *state.Service
+	service := &svc
+	service.Meta = withServiceMeta(service.Meta, key, value)
+
+	if err := a.State.AddService(service, state.Token); err != nil {
+		return false, err
+	}
+	if err := a.persistServiceMeta(serviceID, key, value); err != nil {
+		a.logger.Warn("failed to persist service metadata", "service", serviceID.String(), "key", key, "error", err)
+	}
+	return true, nil
+}
+
+// keepDetectedProtocols returns the metadata of a new definition of a service
+// with the detected protocols of its previous definition, unless the new
+// definition sets them.
+func keepDetectedProtocols(meta, prev map[string]string) map[string]string {
+	for _, key := range []string{structs.MetaDetectedProtocol, structs.MetaDetectedUpstreamProtocols} {
+		if _, ok := meta[key]; ok || prev[key] == "" {
+			continue
+		}
+		meta = withServiceMeta(meta, key, prev[key])
+	}
+	return meta
+}
+
+// withServiceMeta returns a copy of meta with the given key set.
+func withServiceMeta(meta map[string]string, key, value string) map[string]string {
+	result := make(map[string]string, len(meta)+1)
+	for k, v := range meta {
+		result[k] = v
+	}
+	result[key] = value
+	return result
+}
+
 // EnableNodeMaintenance places a node into maintenance mode.
 func (a *Agent) EnableNodeMaintenance(reason, token string) {
 	// Ensure node maintenance is not already enabled
@@ -3896,9 +4275,33 @@ func (a *Agent) reloadConfig(autoReload bool) error {
 		a.logger.Warn("DEPRECATED Backwards compatibility with pre-1.9 metrics enabled. These metrics will be removed in Consul 1.13. Consider not using this flag and rework instrumentation for 1.10 style http metrics.")
 	}
 
+	if a.tlsFileWatcher != nil {
+		a.replaceTLSWatchedFiles(a.config.TLS, newCfg.TLS)
+	}
+
 	return a.reloadConfigInternal(newCfg)
 }
 
+// replaceTLSWatchedFiles updates the TLS file watcher with the certificate,
+// key and CA files of the new configuration.
+func (a *Agent) replaceTLSWatchedFiles(oldCfg, newCfg tlsutil.Config) {
+	oldFiles := tlsWatchedFiles(oldCfg)
+	newFiles := tlsWatchedFiles(newCfg)
+	for _, f := range oldFiles {
+		if !stringslice.Contains(newFiles, f) {
+			a.tlsFileWatcher.Remove(f)
+		}
+	}
+	for _, f := range newFiles {
+		if stringslice.Contains(oldFiles, f) {
+			continue
+		}
+		if err := a.tlsFileWatcher.Add(f); err != nil {
+			a.logger.Error("failed to watch TLS file", "file", f, "error", err)
+		}
+	}
+}
+
 func revertStaticConfig(oldCfg tlsutil.ProtocolConfig, newCfg tlsutil.ProtocolConfig) bool {
 	newNewCfg := oldCfg
 	newNewCfg.CertFile = newCfg.CertFile
@@ -3912,17 +4315,33 @@ func revertStaticConfig(oldCfg tlsutil.ProtocolConfig, newCfg tlsutil.ProtocolCo
 	return false
 }
 
+// setLogLevel sets the level of the loggers which don't belong to a subsystem
+// with its own level.
+func (a *Agent) setLogLevel(level hclog.Level) {
+	if a.baseDeps.LogLevels != nil {
+		a.baseDeps.LogLevels.SetBase(level)
+		return
+	}
+	a.logger.SetLevel(level)
+}
+
 // reloadConfigInternal is mainly needed for some unit tests. Instead of parsing
 // the configuration using CLI flags and on disk config, this just takes a
 // runtime configuration and applies it.
 func (a *Agent) reloadConfigInternal(newCfg *config.RuntimeConfig) error {
 	// Change the log level and update it
 	if logging.ValidateLogLevel(newCfg.Logging.LogLevel) {
-		a.logger.SetLevel(logging.LevelFromString(newCfg.Logging.LogLevel))
+		a.setLogLevel(logging.LevelFromString(newCfg.Logging.LogLevel))
 	} else {
 		a.logger.Warn("Invalid log level in new configuration", "level", newCfg.Logging.LogLevel)
 		newCfg.Logging.LogLevel = a.config.Logging.LogLevel
 	}
+	if a.baseDeps.LogLevels != nil {
+		if err := a.baseDeps.LogLevels.SetSubsystems(newCfg.Logging.LogLevels); err != nil {
+			a.logger.Warn("Invalid subsystem log levels in new configuration", "error", err)
+			newCfg.Logging.LogLevels = a.config.Logging.LogLevels
+		}
+	}
 
 	// Bulk update the services and checks
 	a.PauseSync()
@@ -3985,15 +4404,19 @@ func (a *Agent) reloadConfigInternal(newCfg *config.RuntimeConfig) error {
 	}
 
 	cc := consul.ReloadableConfig{
-		RPCRateLimit:          newCfg.RPCRateLimit,
-		RPCMaxBurst:           newCfg.RPCMaxBurst,
-		RPCMaxConnsPerClient:  newCfg.RPCMaxConnsPerClient,
-		ConfigEntryBootstrap:  newCfg.ConfigEntryBootstrap,
-		RaftSnapshotThreshold: newCfg.RaftSnapshotThreshold,
-		RaftSnapshotInterval:  newCfg.RaftSnapshotInterval,
-		HeartbeatTimeout:      newCfg.ConsulRaftHeartbeatTimeout,
-		ElectionTimeout:       newCfg.ConsulRaftElectionTimeout,
-		RaftTrailingLogs:      newCfg.RaftTrailingLogs,
+		RPCRateLimit:                      newCfg.RPCRateLimit,
+		RPCMaxBurst:                       newCfg.RPCMaxBurst,
+		RPCMaxConnsPerClient:              newCfg.RPCMaxConnsPerClient,
+		RPCMaxConcurrentRequestsPerClient: newCfg.RPCMaxConcurrentRequestsPerClient,
+		RPCMaxInFlightBytesPerClient:      newCfg.RPCMaxInFlightBytesPerClient,
+		ConfigEntryBootstrap:              newCfg.ConfigEntryBootstrap,
+		RaftSnapshotThreshold:             newCfg.RaftSnapshotThreshold,
+		RaftSnapshotInterval:              newCfg.RaftSnapshotInterval,
+		RaftSnapshotAutoTune:              newCfg.RaftSnapshotAutoTune,
+		HeartbeatTimeout:                  newCfg.ConsulRaftHeartbeatTimeout,
+		ElectionTimeout:                   newCfg.ConsulRaftElectionTimeout,
+		RaftTrailingLogs:                  newCfg.RaftTrailingLogs,
+		AutoConfigSnippets:                newCfg.AutoConfig.ConfigSnippets,
 	}
 	if err := a.delegate.ReloadConfig(cc); err != nil {
 		return err
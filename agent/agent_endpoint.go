@@ -3,6 +3,7 @@ package agent
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -20,10 +21,13 @@ import (
 
 	"github.com/hashicorp/consul/acl"
 	cachetype "github.com/hashicorp/consul/agent/cache-types"
+	"github.com/hashicorp/consul/agent/config"
 	"github.com/hashicorp/consul/agent/consul"
 	"github.com/hashicorp/consul/agent/debug"
+	"github.com/hashicorp/consul/agent/proxycfg"
 	"github.com/hashicorp/consul/agent/structs"
 	token_store "github.com/hashicorp/consul/agent/token"
+	"github.com/hashicorp/consul/agent/xds"
 	"github.com/hashicorp/consul/agent/xds/proxysupport"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/ipaddr"
@@ -40,7 +44,8 @@ type Self struct {
 	Member      serf.Member
 	Stats       map[string]map[string]string
 	Meta        map[string]string
-	XDS         *XDSSelf `json:"xDS,omitempty"`
+	XDS         *XDSSelf                    `json:"xDS,omitempty"`
+	Connect     *structs.ConnectTrustDomain `json:",omitempty"`
 }
 
 type XDSSelf struct {
@@ -82,6 +87,15 @@ func (s *HTTPHandlers) AgentSelf(resp http.ResponseWriter, req *http.Request) (i
 		}
 	}
 
+	var trustDomain *structs.ConnectTrustDomain
+	if s.agent.config.ConnectEnabled {
+		if trustDomain, err = s.agent.connectTrustDomain(req.Context()); err != nil {
+			// The rest of the output doesn't depend on the servers, so the
+			// trust domain is left out rather than failing.
+			s.agent.logger.Debug("failed to read the Connect trust domain", "error", err)
+		}
+	}
+
 	config := struct {
 		Datacenter        string
 		PrimaryDatacenter string
@@ -110,6 +124,7 @@ func (s *HTTPHandlers) AgentSelf(resp http.ResponseWriter, req *http.Request) (i
 		Stats:       s.agent.Stats(),
 		Meta:        s.agent.State.Metadata(),
 		XDS:         xds,
+		Connect:     trustDomain,
 	}, nil
 }
 
@@ -192,11 +207,28 @@ func (s *HTTPHandlers) AgentMetricsStream(resp http.ResponseWriter, req *http.Re
 		return nil, err
 	}
 
+	window := lib.LiveMetricsInterval
+	if v := req.URL.Query().Get("window"); v != "" {
+		window, err = time.ParseDuration(v)
+		if err != nil || window <= 0 {
+			return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Invalid window: %q", v)}
+		}
+		if window > lib.LiveMetricsMaxWindow {
+			return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Window must be at most %s", lib.LiveMetricsMaxWindow)}
+		}
+	}
+
 	flusher, ok := resp.(http.Flusher)
 	if !ok {
 		return nil, fmt.Errorf("streaming not supported")
 	}
 
+	sse := strings.Contains(req.Header.Get("Accept"), "text/event-stream")
+	if sse {
+		resp.Header().Set("Content-Type", "text/event-stream")
+		resp.Header().Set("Cache-Control", "no-cache")
+	}
+
 	resp.WriteHeader(http.StatusOK)
 
 	// 0 byte write is needed before the Flush call so that if we are using
@@ -209,8 +241,18 @@ func (s *HTTPHandlers) AgentMetricsStream(resp http.ResponseWriter, req *http.Re
 		encoder: json.NewEncoder(resp),
 		flusher: flusher,
 	}
-	enc.encoder.SetIndent("", "    ")
-	s.agent.baseDeps.MetricsConfig.Handler.Stream(req.Context(), enc)
+	if sse {
+		enc.sse = resp
+	} else {
+		enc.encoder.SetIndent("", "    ")
+	}
+
+	metricsConfig := s.agent.baseDeps.MetricsConfig
+	if metricsConfig.Live == nil {
+		metricsConfig.Handler.Stream(req.Context(), enc)
+		return nil, nil
+	}
+	metricsConfig.Live.StreamWindow(req.Context(), window, enc)
 	return nil, nil
 }
 
@@ -218,10 +260,24 @@ type metricsEncoder struct {
 	logger  hclog.Logger
 	encoder *json.Encoder
 	flusher http.Flusher
+
+	// sse is set when the summaries are sent as server-sent events.
+	sse io.Writer
 }
 
 func (m metricsEncoder) Encode(summary interface{}) error {
-	if err := m.encoder.Encode(summary); err != nil {
+	var err error
+	if m.sse != nil {
+		// Each event must fit on a single data line.
+		if _, err = io.WriteString(m.sse, "data: "); err == nil {
+			if err = m.encoder.Encode(summary); err == nil {
+				_, err = io.WriteString(m.sse, "\n")
+			}
+		}
+	} else {
+		err = m.encoder.Encode(summary)
+	}
+	if err != nil {
 		m.logger.Error("failed to encode metrics summary", "error", err)
 		return err
 	}
@@ -1086,6 +1142,37 @@ func (s *HTTPHandlers) AgentRegisterService(resp http.ResponseWriter, req *http.
 		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Request decode failed: %v", err)}
 	}
 
+	if !s.validateRequestPartition(resp, &args.EnterpriseMeta) {
+		return nil, nil
+	}
+
+	var token string
+	s.parseToken(req, &token)
+
+	addReqs, err := s.prepareServiceRegistration(&args, token, replaceExistingChecks(req))
+	if err != nil {
+		return nil, err
+	}
+	for _, addReq := range addReqs {
+		if err := s.agent.AddService(addReq); err != nil {
+			return nil, err
+		}
+	}
+	s.syncChanges()
+	return nil, nil
+}
+
+// replaceExistingChecks returns whether the replace-existing-checks query
+// parameter is set.
+func replaceExistingChecks(req *http.Request) bool {
+	query := req.URL.Query()
+	return len(query["replace-existing-checks"]) > 0 && (query.Get("replace-existing-checks") == "" || query.Get("replace-existing-checks") == "true")
+}
+
+// prepareServiceRegistration validates the registration of a service and
+// vets it against the ACL policies of the token. It returns the requests to
+// add the service, followed by its sidecar service if it has one.
+func (s *HTTPHandlers) prepareServiceRegistration(args *structs.ServiceDefinition, token string, replaceExistingChecks bool) ([]AddServiceRequest, error) {
 	// Verify the service has a name.
 	if args.Name == "" {
 		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: "Missing service name"}
@@ -1097,18 +1184,11 @@ func (s *HTTPHandlers) AgentRegisterService(resp http.ResponseWriter, req *http.
 		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: "Invalid service address"}
 	}
 
-	var token string
-	s.parseToken(req, &token)
-
 	authz, err := s.agent.delegate.ResolveTokenAndDefaultMeta(token, &args.EnterpriseMeta, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	if !s.validateRequestPartition(resp, &args.EnterpriseMeta) {
-		return nil, nil
-	}
-
 	// Get the node service.
 	ns := args.NodeService()
 	if ns.Weights != nil {
@@ -1125,6 +1205,9 @@ func (s *HTTPHandlers) AgentRegisterService(resp http.ResponseWriter, req *http.
 	if err := ns.Validate(); err != nil {
 		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Validation failed: %v", err.Error())}
 	}
+	if err := ns.Proxy.Upstreams.ValidateLocalBindSockets(); err != nil {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Validation failed: %v", err.Error())}
+	}
 
 	// Verify the check type.
 	chkTypes, err := args.CheckTypes()
@@ -1164,6 +1247,9 @@ func (s *HTTPHandlers) AgentRegisterService(resp http.ResponseWriter, req *http.
 		if err := sidecar.Validate(); err != nil {
 			return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Failed Validation: %v", err.Error())}
 		}
+		if err := sidecar.Proxy.Upstreams.ValidateLocalBindSockets(); err != nil {
+			return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Failed Validation: %v", err.Error())}
+		}
 		// Make sure we are allowed to register the sidecar using the token
 		// specified (might be specific to sidecar or the same one as the overall
 		// request).
@@ -1177,41 +1263,25 @@ func (s *HTTPHandlers) AgentRegisterService(resp http.ResponseWriter, req *http.
 		ns.Connect.SidecarService = nil
 	}
 
-	// Add the service.
-	replaceExistingChecks := false
-
-	query := req.URL.Query()
-	if len(query["replace-existing-checks"]) > 0 && (query.Get("replace-existing-checks") == "" || query.Get("replace-existing-checks") == "true") {
-		replaceExistingChecks = true
-	}
-
-	addReq := AddServiceRequest{
+	addReqs := []AddServiceRequest{{
 		Service:               ns,
 		chkTypes:              chkTypes,
 		persist:               true,
 		token:                 token,
 		Source:                ConfigSourceRemote,
 		replaceExistingChecks: replaceExistingChecks,
-	}
-	if err := s.agent.AddService(addReq); err != nil {
-		return nil, err
-	}
-
+	}}
 	if sidecar != nil {
-		addReq := AddServiceRequest{
+		addReqs = append(addReqs, AddServiceRequest{
 			Service:               sidecar,
 			chkTypes:              sidecarChecks,
 			persist:               true,
 			token:                 sidecarToken,
 			Source:                ConfigSourceRemote,
 			replaceExistingChecks: replaceExistingChecks,
-		}
-		if err := s.agent.AddService(addReq); err != nil {
-			return nil, err
-		}
+		})
 	}
-	s.syncChanges()
-	return nil, nil
+	return addReqs, nil
 }
 
 func (s *HTTPHandlers) AgentDeregisterService(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
@@ -1249,6 +1319,140 @@ func (s *HTTPHandlers) AgentDeregisterService(resp http.ResponseWriter, req *htt
 	return nil, nil
 }
 
+// agentServiceBatch is a batch of service registrations and deregistrations.
+type agentServiceBatch struct {
+	Register   []*structs.ServiceDefinition
+	Deregister []string
+}
+
+// AgentServiceBatch registers and deregisters many services at once. All the
+// operations are validated and vetted against the ACL policies first, and
+// none of them is applied if any of them fails. The deregistrations are then
+// applied, followed by the registrations, and the operations failing to apply
+// are reported without rolling back the others.
+func (s *HTTPHandlers) AgentServiceBatch(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var entMeta acl.EnterpriseMeta
+	if err := s.parseEntMetaNoWildcard(req, &entMeta); err != nil {
+		return nil, err
+	}
+
+	var batch agentServiceBatch
+	if err := decodeBody(req.Body, &batch); err != nil {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Request decode failed: %v", err)}
+	}
+	size := len(batch.Register) + len(batch.Deregister)
+	if size == 0 {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: "Batch contains no operations"}
+	}
+	if size > maxCatalogTxnOps {
+		return nil, HTTPError{
+			StatusCode: http.StatusRequestEntityTooLarge,
+			Reason:     fmt.Sprintf("Batch contains too many operations (%d > %d)", size, maxCatalogTxnOps),
+		}
+	}
+
+	var token string
+	s.parseToken(req, &token)
+
+	var out api.AgentServiceBatchResponse
+	fail := func(op string, index int, id string, err error) {
+		out.Errors = append(out.Errors, &api.AgentServiceBatchError{
+			Op:        op,
+			OpIndex:   index,
+			ServiceID: id,
+			What:      err.Error(),
+		})
+	}
+
+	// A service can only be part of a single operation, since they are not
+	// applied in the order of the request.
+	seen := make(map[structs.ServiceID]struct{}, size)
+	unique := func(sid structs.ServiceID) error {
+		if _, ok := seen[sid]; ok {
+			return fmt.Errorf("Service ID %q is part of several operations", sid.ID)
+		}
+		seen[sid] = struct{}{}
+		return nil
+	}
+
+	// Validate all the operations before applying any of them.
+	sids := make([]structs.ServiceID, len(batch.Deregister))
+	for i, id := range batch.Deregister {
+		sid := structs.ServiceID{ID: id, EnterpriseMeta: entMeta}
+		authz, err := s.agent.delegate.ResolveTokenAndDefaultMeta(token, &sid.EnterpriseMeta, nil)
+		if err == nil {
+			sid.Normalize()
+			err = s.agent.vetServiceUpdateWithAuthorizer(authz, sid)
+		}
+		if err == nil {
+			err = unique(sid)
+		}
+		if err != nil {
+			fail(api.AgentServiceBatchDeregister, i, id, err)
+		}
+		sids[i] = sid
+	}
+
+	replace := replaceExistingChecks(req)
+	addReqs := make([][]AddServiceRequest, len(batch.Register))
+	for i, def := range batch.Register {
+		if def == nil {
+			fail(api.AgentServiceBatchRegister, i, "", fmt.Errorf("Missing service definition"))
+			continue
+		}
+		def.EnterpriseMeta.Merge(&entMeta)
+
+		reqs, err := s.prepareServiceRegistration(def, token, replace)
+		for _, addReq := range reqs {
+			if err == nil {
+				err = unique(addReq.Service.CompoundServiceID())
+			}
+		}
+		if err != nil {
+			id := def.ID
+			if id == "" {
+				id = def.Name
+			}
+			fail(api.AgentServiceBatchRegister, i, id, err)
+		}
+		addReqs[i] = reqs
+	}
+
+	if len(out.Errors) == 0 {
+		for i, sid := range sids {
+			if err := s.agent.RemoveService(sid); err != nil {
+				fail(api.AgentServiceBatchDeregister, i, sid.ID, err)
+				continue
+			}
+			out.Deregistered = append(out.Deregistered, sid.ID)
+		}
+		for i, reqs := range addReqs {
+			for _, addReq := range reqs {
+				if err := s.agent.AddService(addReq); err != nil {
+					fail(api.AgentServiceBatchRegister, i, addReq.Service.ID, err)
+					break
+				}
+				out.Registered = append(out.Registered, addReq.Service.ID)
+			}
+		}
+		s.syncChanges()
+	}
+
+	// If an operation failed return the response object but set a special
+	// status code, as for transactions.
+	if len(out.Errors) > 0 {
+		buf, err := s.marshalJSON(req, out)
+		if err != nil {
+			return nil, err
+		}
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(http.StatusConflict)
+		resp.Write(buf)
+		return nil, nil
+	}
+	return out, nil
+}
+
 func (s *HTTPHandlers) AgentServiceMaintenance(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	// Ensure we have a service ID
 	serviceID := strings.TrimPrefix(req.URL.Path, "/v1/agent/service/maintenance/")
@@ -1307,6 +1511,57 @@ func (s *HTTPHandlers) AgentServiceMaintenance(resp http.ResponseWriter, req *ht
 	return nil, nil
 }
 
+// AgentServiceDetectedProtocol records the protocol a sidecar proxy detected
+// on the inbound traffic of a local service.
+// PUT /v1/agent/service/detected-protocol/:service_id
+func (s *HTTPHandlers) AgentServiceDetectedProtocol(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	serviceID := strings.TrimPrefix(req.URL.Path, "/v1/agent/service/detected-protocol/")
+	sid := structs.NewServiceID(serviceID, nil)
+
+	if sid.ID == "" {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: "Missing service ID"}
+	}
+
+	var args struct {
+		Protocol string
+	}
+	if err := decodeBody(req.Body, &args); err != nil {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Request decode failed: %v", err)}
+	}
+	if !structs.IsDetectableProtocol(args.Protocol) {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Invalid protocol %q", args.Protocol)}
+	}
+
+	// Get the provided token, if any, and vet against any ACL policies.
+	var token string
+	s.parseToken(req, &token)
+
+	if err := s.parseEntMetaNoWildcard(req, &sid.EnterpriseMeta); err != nil {
+		return nil, err
+	}
+
+	authz, err := s.agent.delegate.ResolveTokenAndDefaultMeta(token, &sid.EnterpriseMeta, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sid.Normalize()
+
+	if !s.validateRequestPartition(resp, &sid.EnterpriseMeta) {
+		return nil, nil
+	}
+
+	if err := s.agent.vetServiceUpdateWithAuthorizer(authz, sid); err != nil {
+		return nil, err
+	}
+
+	if err := s.agent.SetServiceDetectedProtocol(sid, args.Protocol); err != nil {
+		return nil, HTTPError{StatusCode: http.StatusNotFound, Reason: err.Error()}
+	}
+	s.syncChanges()
+	return nil, nil
+}
+
 func (s *HTTPHandlers) AgentNodeMaintenance(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	// Ensure we have some action
 	params := req.URL.Query()
@@ -1387,9 +1642,15 @@ func (s *HTTPHandlers) AgentMonitor(resp http.ResponseWriter, req *http.Request)
 			Level:      logging.LevelFromString(logLevel),
 			JSONFormat: logJSON,
 		},
+		Loggers: req.URL.Query()["logger"],
 	})
 	logsCh := monitor.Start()
 
+	// JSON logs are streamed one object per line.
+	if logJSON {
+		resp.Header().Set("Content-Type", "application/x-ndjson")
+	}
+
 	// Send header so client can start streaming body
 	resp.WriteHeader(http.StatusOK)
 
@@ -1413,7 +1674,7 @@ func (s *HTTPHandlers) AgentMonitor(resp http.ResponseWriter, req *http.Request)
 			return nil, nil
 
 		case log := <-logsCh:
-			fmt.Fprint(resp, string(log))
+			fmt.Fprint(resp, s.agent.baseDeps.Redactor.Redact(string(log)))
 
 		case <-flushTicker.C:
 			flusher.Flush()
@@ -1421,6 +1682,79 @@ func (s *HTTPHandlers) AgentMonitor(resp http.ResponseWriter, req *http.Request)
 	}
 }
 
+// LogLevels are the log levels of the agent returned by the loglevel
+// endpoint.
+type LogLevels struct {
+	// Level is the level of the loggers which don't belong to a subsystem
+	// with its own level.
+	Level string
+
+	// Subsystems are the levels of the subsystems which have their own.
+	Subsystems map[string]string
+}
+
+// AgentLogLevel returns or changes the log levels of the agent. Changes only
+// last until the configuration is reloaded.
+func (s *HTTPHandlers) AgentLogLevel(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	// Fetch the ACL token, if any, and enforce agent policy.
+	var token string
+	s.parseToken(req, &token)
+	authz, err := s.agent.delegate.ResolveTokenAndDefaultMeta(token, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Authorize using the agent's own enterprise meta, not the token.
+	var authzContext acl.AuthorizerContext
+	s.agent.AgentEnterpriseMeta().FillAuthzContext(&authzContext)
+
+	levels := s.agent.baseDeps.LogLevels
+	if levels == nil {
+		return nil, HTTPError{StatusCode: http.StatusNotImplemented, Reason: "Log levels can't be changed on this agent"}
+	}
+
+	if req.Method == "PUT" {
+		if err := authz.ToAllowAuthorizer().AgentWriteAllowed(s.agent.config.NodeName, &authzContext); err != nil {
+			return nil, err
+		}
+
+		level := req.URL.Query().Get("level")
+		subsystem := req.URL.Query().Get("subsystem")
+		if subsystem == "" {
+			if !logging.ValidateLogLevel(level) {
+				return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Unknown log level: %s", level)}
+			}
+			levels.SetBase(logging.LevelFromString(level))
+		} else {
+			// The subsystem is reset to the base level without a level.
+			parsed := hclog.NoLevel
+			if level != "" && level != "default" {
+				if !logging.ValidateLogLevel(level) {
+					return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Unknown log level: %s", level)}
+				}
+				parsed = logging.LevelFromString(level)
+			}
+			if err := levels.SetSubsystem(subsystem, parsed); err != nil {
+				return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: err.Error()}
+			}
+		}
+		s.agent.logger.Info("Changed log level", "subsystem", subsystem, "level", level)
+	} else {
+		if err := authz.ToAllowAuthorizer().AgentReadAllowed(s.agent.config.NodeName, &authzContext); err != nil {
+			return nil, err
+		}
+	}
+
+	out := LogLevels{
+		Level:      levels.Base().String(),
+		Subsystems: make(map[string]string),
+	}
+	for subsystem, level := range levels.Subsystems() {
+		out.Subsystems[subsystem] = level.String()
+	}
+	return out, nil
+}
+
 func (s *HTTPHandlers) AgentToken(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	if s.checkACLDisabled() {
 		return nil, HTTPError{StatusCode: http.StatusUnauthorized, Reason: "ACL support disabled"}
@@ -1569,6 +1903,91 @@ func (s *HTTPHandlers) AgentConnectCALeafCert(resp http.ResponseWriter, req *htt
 	return reply, nil
 }
 
+// GET /v1/agent/connect/routing/:proxy_service_id
+//
+// Returns the gateways the traffic from a local sidecar proxy to each of its
+// upstreams traverses, as currently configured in the proxy.
+func (s *HTTPHandlers) AgentConnectProxyRouting(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	// Get the proxy ID. Note that this is the ID of a proxy's service instance.
+	id := strings.TrimPrefix(req.URL.Path, "/v1/agent/connect/routing/")
+	if id == "" {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: "Missing proxy service ID"}
+	}
+	upstream := req.URL.Query().Get("upstream")
+
+	var token string
+	s.parseToken(req, &token)
+
+	var entMeta acl.EnterpriseMeta
+	if err := s.parseEntMetaNoWildcard(req, &entMeta); err != nil {
+		return nil, err
+	}
+
+	authz, err := s.agent.delegate.ResolveTokenAndDefaultMeta(token, &entMeta, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.validateRequestPartition(resp, &entMeta) {
+		return nil, nil
+	}
+
+	sid := structs.NewServiceID(id, &entMeta)
+	svcState := s.agent.State.ServiceState(sid)
+	if svcState == nil || svcState.Service.Kind != structs.ServiceKindConnectProxy {
+		return nil, HTTPError{StatusCode: http.StatusNotFound, Reason: fmt.Sprintf("unknown proxy service ID: %s", sid.String())}
+	}
+
+	var authzContext acl.AuthorizerContext
+	svcState.Service.FillAuthzContext(&authzContext)
+	if err := authz.ToAllowAuthorizer().ServiceReadAllowed(svcState.Service.Service, &authzContext); err != nil {
+		return nil, err
+	}
+
+	var snap *proxycfg.ConfigSnapshot
+	if s.agent.proxyConfig != nil {
+		for _, candidate := range s.agent.proxyConfig.Snapshots() {
+			if candidate.ProxyID.ServiceID.Matches(sid) {
+				snap = candidate
+				break
+			}
+		}
+	}
+	if snap == nil {
+		return nil, HTTPError{StatusCode: http.StatusNotFound, Reason: fmt.Sprintf("configuration of proxy %s is not ready yet", sid.String())}
+	}
+
+	// Only report the targets of upstreams the token can read.
+	out := make([]proxycfg.UpstreamRouting, 0)
+	for _, routing := range snap.UpstreamRouting() {
+		if upstream != "" && routing.DestinationName != upstream && routing.Upstream != upstream {
+			continue
+		}
+		targets := make([]proxycfg.TargetRouting, 0, len(routing.Targets))
+		for _, target := range routing.Targets {
+			var targetAuthzContext acl.AuthorizerContext
+			targetMeta := acl.NewEnterpriseMetaWithPartition(target.Partition, target.Namespace)
+			targetMeta.FillAuthzContext(&targetAuthzContext)
+
+			var allowed acl.EnforcementDecision
+			if routing.DestinationType == structs.UpstreamDestTypePreparedQuery {
+				allowed = authz.PreparedQueryRead(target.Service, &targetAuthzContext)
+			} else {
+				allowed = authz.ServiceRead(target.Service, &targetAuthzContext)
+			}
+			if allowed == acl.Allow {
+				targets = append(targets, target)
+			}
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		routing.Targets = targets
+		out = append(out, routing)
+	}
+	return out, nil
+}
+
 // AgentConnectAuthorize
 //
 // POST /v1/agent/connect/authorize
@@ -1615,6 +2034,104 @@ type connectAuthorizeResp struct {
 	Reason     string // Reason for the Authorized value (whether true or false)
 }
 
+// AgentReady
+//
+// GET /v1/agent/ready
+//
+// Reports whether the agent meets its readiness criteria, as configured with
+// agent_probes.readiness. Responds with a 503 status when it doesn't, so that
+// it can be used directly as an orchestrator probe. Requires an agent:read
+// ACL token as the failures describe the local services.
+func (s *HTTPHandlers) AgentReady(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	return s.agentProbe(req, s.agent.config.AgentReadinessCriteria)
+}
+
+// AgentLive
+//
+// GET /v1/agent/live
+//
+// Reports whether the agent meets its liveness criteria, as configured with
+// agent_probes.liveness. Responds with a 503 status when it doesn't. The
+// agent is always live when no criteria are configured. Requires an
+// agent:read ACL token.
+func (s *HTTPHandlers) AgentLive(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	return s.agentProbe(req, s.agent.config.AgentLivenessCriteria)
+}
+
+func (s *HTTPHandlers) agentProbe(req *http.Request, criteria []string) (interface{}, error) {
+	// Fetch the ACL token, if any, and enforce agent policy.
+	var token string
+	s.parseToken(req, &token)
+	authz, err := s.agent.delegate.ResolveTokenAndDefaultMeta(token, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Authorize using the agent's own enterprise meta, not the token.
+	var authzContext acl.AuthorizerContext
+	s.agent.AgentEnterpriseMeta().FillAuthzContext(&authzContext)
+	if err := authz.ToAllowAuthorizer().AgentReadAllowed(s.agent.config.NodeName, &authzContext); err != nil {
+		return nil, err
+	}
+
+	out := &api.AgentProbe{
+		Status:   api.HealthPassing,
+		Criteria: make([]api.AgentProbeCriterion, 0, len(criteria)),
+	}
+	for _, name := range criteria {
+		result := api.AgentProbeCriterion{Name: name, Passing: true}
+		if err := s.checkProbeCriterion(name); err != nil {
+			result.Passing = false
+			result.Output = err.Error()
+			out.Status = api.HealthCritical
+		}
+		out.Criteria = append(out.Criteria, result)
+	}
+	if out.Status != api.HealthPassing {
+		return out, CodeWithPayloadError{
+			StatusCode:  http.StatusServiceUnavailable,
+			Reason:      "agent probe failed",
+			ContentType: "application/json",
+		}
+	}
+	return out, nil
+}
+
+// checkProbeCriterion returns an error describing why the agent doesn't meet
+// the named probe criterion, if it doesn't.
+func (s *HTTPHandlers) checkProbeCriterion(name string) error {
+	switch name {
+	case config.ProbeCriterionSerf:
+		if status := s.agent.delegate.AgentLocalMember().Status; status != serf.StatusAlive {
+			return fmt.Errorf("agent is %s in the LAN gossip pool", status)
+		}
+	case config.ProbeCriterionServers:
+		stats := s.agent.delegate.Stats()["consul"]
+		if s.agent.config.ServerMode {
+			if stats["leader_addr"] == "" {
+				return fmt.Errorf("no known cluster leader")
+			}
+		} else if stats["known_servers"] == "" || stats["known_servers"] == "0" {
+			return fmt.Errorf("no known Consul servers")
+		}
+	case config.ProbeCriterionACLs:
+		if !s.agent.config.ACLsEnabled {
+			return nil
+		}
+		if _, err := s.agent.delegate.ResolveTokenAndDefaultMeta(s.agent.tokens.AgentToken(), nil, nil); err != nil {
+			return fmt.Errorf("failed to resolve the agent token: %w", err)
+		}
+	case config.ProbeCriterionProxyConfig:
+		if s.agent.proxyConfig == nil {
+			return fmt.Errorf("proxy configuration manager is not running")
+		}
+		return s.agent.proxyConfig.Ready()
+	default:
+		return fmt.Errorf("unknown criterion")
+	}
+	return nil
+}
+
 // AgentHost
 //
 // GET /v1/agent/host
@@ -1638,3 +2155,74 @@ func (s *HTTPHandlers) AgentHost(resp http.ResponseWriter, req *http.Request) (i
 
 	return debug.CollectHostInfo(), nil
 }
+
+// AgentCache returns a summary of the entries of the agent cache and how
+// they are used, for each cache type.
+func (s *HTTPHandlers) AgentCache(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	// Fetch the ACL token, if any, and enforce agent policy.
+	var token string
+	s.parseToken(req, &token)
+	authz, err := s.agent.delegate.ResolveTokenAndDefaultMeta(token, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Authorize using the agent's own enterprise meta, not the token.
+	var authzContext acl.AuthorizerContext
+	s.agent.AgentEnterpriseMeta().FillAuthzContext(&authzContext)
+	if err := authz.ToAllowAuthorizer().AgentReadAllowed(s.agent.config.NodeName, &authzContext); err != nil {
+		return nil, err
+	}
+
+	return s.agent.cache.Stats(), nil
+}
+
+// ProxiesDebugInfo holds the state of the proxies served by the agent, for
+// debug bundles.
+type ProxiesDebugInfo struct {
+	Proxies    []ProxyDebugInfo
+	XDSStreams []xds.StreamState
+}
+
+// ProxyDebugInfo holds the current proxycfg snapshot of a proxy, with the
+// secrets redacted.
+type ProxyDebugInfo struct {
+	ProxyID  string
+	NodeName string
+	Kind     structs.ServiceKind
+	Snapshot map[string]interface{}
+}
+
+func (s *HTTPHandlers) AgentDebugProxies(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	// Fetch the ACL token, if any, and enforce agent policy.
+	var token string
+	s.parseToken(req, &token)
+	authz, err := s.agent.delegate.ResolveTokenAndDefaultMeta(token, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The snapshots contain data about services all over the cluster.
+	if err := authz.ToAllowAuthorizer().OperatorReadAllowed(nil); err != nil {
+		return nil, err
+	}
+
+	out := ProxiesDebugInfo{
+		Proxies:    make([]ProxyDebugInfo, 0),
+		XDSStreams: make([]xds.StreamState, 0),
+	}
+	if s.agent.proxyConfig != nil {
+		for _, snap := range s.agent.proxyConfig.Snapshots() {
+			out.Proxies = append(out.Proxies, ProxyDebugInfo{
+				ProxyID:  snap.ProxyID.ServiceID.String(),
+				NodeName: snap.ProxyID.NodeName,
+				Kind:     snap.Kind,
+				Snapshot: snap.Sanitized(),
+			})
+		}
+	}
+	if s.agent.xdsServer != nil {
+		out.XDSStreams = append(out.XDSStreams, s.agent.xdsServer.StreamStates()...)
+	}
+	return out, nil
+}
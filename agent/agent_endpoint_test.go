@@ -28,12 +28,15 @@ import (
 	"golang.org/x/time/rate"
 
 	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/cache"
+	cachetype "github.com/hashicorp/consul/agent/cache-types"
 	"github.com/hashicorp/consul/agent/config"
 	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/connect/ca"
 	"github.com/hashicorp/consul/agent/consul"
 	"github.com/hashicorp/consul/agent/debug"
 	"github.com/hashicorp/consul/agent/local"
+	"github.com/hashicorp/consul/agent/proxycfg"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/agent/token"
 	tokenStore "github.com/hashicorp/consul/agent/token"
@@ -1490,6 +1493,12 @@ func TestAgent_Self(t *testing.T) {
 			} else {
 				require.Nil(t, val.XDS, "xds component should be missing when gRPC is disabled")
 			}
+
+			require.Equal(t, &structs.ConnectTrustDomain{
+				TrustDomain:    connect.TestTrustDomain,
+				ClusterID:      connect.TestClusterID,
+				SpiffeIDFormat: connect.SpiffeIDServiceFormat,
+			}, val.Connect)
 		})
 	}
 }
@@ -1560,6 +1569,40 @@ func TestAgent_Metrics_ACLDeny(t *testing.T) {
 	})
 }
 
+func TestAgent_Cache(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, TestACLConfig())
+	defer a.Shutdown()
+
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+	t.Run("no token", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/agent/cache", nil)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("read-only token", func(t *testing.T) {
+		ro := createACLTokenWithAgentReadPolicy(t, a.srv)
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/v1/agent/cache?token=%s", ro), nil)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var stats []cache.TypeStats
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&stats))
+		var names []string
+		for _, s := range stats {
+			names = append(names, s.Type)
+		}
+		require.Contains(t, names, cachetype.ConnectCARootName)
+	})
+}
+
 func TestHTTPHandlers_AgentMetricsStream_ACLDeny(t *testing.T) {
 	bd := BaseDeps{}
 	bd.Tokens = new(tokenStore.Store)
@@ -1640,6 +1683,88 @@ func TestHTTPHandlers_AgentMetricsStream(t *testing.T) {
 	require.Equal(t, expected, summary.Gauges)
 }
 
+func TestHTTPHandlers_AgentMetricsStream_Window(t *testing.T) {
+	bd := BaseDeps{}
+	bd.Tokens = new(tokenStore.Store)
+	live := lib.NewLiveMetrics(20 * time.Millisecond)
+	bd.MetricsConfig = &lib.MetricsConfig{
+		Handler: metrics.NewInmemSink(time.Minute, time.Minute),
+		Live:    live,
+	}
+	d := fakeResolveTokenDelegate{authorizer: acl.ManageAll()}
+	agent := &Agent{
+		baseDeps: bd,
+		delegate: d,
+		tokens:   bd.Tokens,
+		config:   &config.RuntimeConfig{NodeName: "the-node"},
+		logger:   hclog.NewInterceptLogger(nil),
+	}
+	h := HTTPHandlers{agent: agent, denylist: NewDenylist(nil)}
+	handle := h.handler(false)
+
+	t.Run("invalid window", func(t *testing.T) {
+		for _, window := range []string{"nope", "-1s", "1h"} {
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, "/v1/agent/metrics/stream?window="+window, nil)
+			require.NoError(t, err)
+			handle.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusBadRequest, resp.Code, "window %s", window)
+		}
+	})
+
+	stream := func(t *testing.T, accept string) *httptest.ResponseRecorder {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		// produce some metrics
+		go func() {
+			for ctx.Err() == nil {
+				live.IncrCounter([]string{"the-counter"}, 1)
+				time.Sleep(5 * time.Millisecond)
+			}
+		}()
+
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/v1/agent/metrics/stream?window=10s", nil)
+		require.NoError(t, err)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		handle.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		return resp
+	}
+
+	t.Run("json", func(t *testing.T) {
+		resp := stream(t, "")
+
+		decoder := json.NewDecoder(resp.Body)
+		var first, second lib.LiveMetricsSummary
+		require.NoError(t, decoder.Decode(&first))
+		require.NoError(t, decoder.Decode(&second))
+
+		require.Equal(t, "10s", second.Window)
+		require.Len(t, second.Counters, 1)
+		require.Equal(t, "the-counter", second.Counters[0].Name)
+		// Counters are aggregated over the whole window.
+		require.Greater(t, second.Counters[0].Count, first.Counters[0].Count)
+	})
+
+	t.Run("sse", func(t *testing.T) {
+		resp := stream(t, "text/event-stream")
+		require.Equal(t, "text/event-stream", resp.Header().Get("Content-Type"))
+
+		events := strings.Split(strings.TrimSpace(resp.Body.String()), "\n\n")
+		require.GreaterOrEqual(t, len(events), 2)
+		for _, event := range events {
+			require.True(t, strings.HasPrefix(event, "data: "), event)
+			var summary lib.LiveMetricsSummary
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(event, "data: ")), &summary))
+			require.Equal(t, "10s", summary.Window)
+		}
+	})
+}
+
 type fakeResolveTokenDelegate struct {
 	delegate
 	authorizer acl.Authorizer
@@ -5287,6 +5412,44 @@ func testAgent_RegisterService_UnmanagedConnectProxyInvalid(t *testing.T, extraH
 	assert.Nil(t, a.State.Service(structs.NewServiceID("connect-proxy", nil)))
 }
 
+func TestAgent_RegisterService_UpstreamSocketModeInvalid(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	args := &structs.ServiceDefinition{
+		Kind: structs.ServiceKindConnectProxy,
+		Name: "connect-proxy",
+		Port: 8000,
+		Proxy: &structs.ConnectProxyConfig{
+			DestinationServiceName: "web",
+			Upstreams: structs.Upstreams{{
+				DestinationType:     structs.UpstreamDestTypeService,
+				DestinationName:     "db",
+				LocalBindSocketPath: "/tmp/db.sock",
+				LocalBindSocketMode: "600",
+			}},
+		},
+	}
+
+	req, _ := http.NewRequest("PUT", "/v1/agent/service/register", jsonReader(args))
+	resp := httptest.NewRecorder()
+	a.srv.h.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	require.Contains(t, resp.Body.String(), "invalid socket mode")
+	require.Nil(t, a.State.Service(structs.NewServiceID("connect-proxy", nil)))
+
+	// The same upstream is still accepted when it was registered before the
+	// mode was checked, so that it keeps syncing.
+	ns := args.NodeService()
+	require.NoError(t, ns.Validate())
+}
+
 // Tests agent registration of a service that is connect native.
 func TestAgent_RegisterService_ConnectNative(t *testing.T) {
 	if testing.Short() {
@@ -5471,6 +5634,85 @@ func TestAgent_DeregisterService(t *testing.T) {
 	assert.Nil(t, a.State.Check(structs.NewCheckID("test", nil)), "have test check")
 }
 
+func TestAgent_ServiceBatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	require.NoError(t, a.AddService(AddServiceRequest{
+		Service: &structs.NodeService{ID: "old", Service: "old"},
+		Source:  ConfigSourceLocal,
+	}))
+
+	batch := func(t *testing.T, body string) (*httptest.ResponseRecorder, api.AgentServiceBatchResponse) {
+		req, _ := http.NewRequest("PUT", "/v1/agent/service/batch", strings.NewReader(body))
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+
+		var out api.AgentServiceBatchResponse
+		if resp.Code == http.StatusOK || resp.Code == http.StatusConflict {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		}
+		return resp, out
+	}
+
+	t.Run("invalid operations are not applied", func(t *testing.T) {
+		resp, out := batch(t, `{
+			"Register": [
+				{"Name": "web", "Port": 8080},
+				{"Port": 8081},
+				{"ID": "old", "Name": "old"}
+			],
+			"Deregister": ["old", "missing"]
+		}`)
+		require.Equal(t, http.StatusConflict, resp.Code)
+		require.Empty(t, out.Registered)
+		require.Empty(t, out.Deregistered)
+		require.Len(t, out.Errors, 3)
+
+		require.Equal(t, api.AgentServiceBatchDeregister, out.Errors[0].Op)
+		require.Equal(t, 1, out.Errors[0].OpIndex)
+		require.Equal(t, "missing", out.Errors[0].ServiceID)
+		require.Contains(t, out.Errors[0].What, "Unknown service ID")
+
+		require.Equal(t, api.AgentServiceBatchRegister, out.Errors[1].Op)
+		require.Equal(t, 1, out.Errors[1].OpIndex)
+		require.Equal(t, "Missing service name", out.Errors[1].What)
+
+		require.Equal(t, api.AgentServiceBatchRegister, out.Errors[2].Op)
+		require.Equal(t, 2, out.Errors[2].OpIndex)
+		require.Contains(t, out.Errors[2].What, "part of several operations")
+
+		require.Nil(t, a.State.Service(structs.NewServiceID("web", nil)))
+		require.NotNil(t, a.State.Service(structs.NewServiceID("old", nil)))
+	})
+
+	t.Run("valid operations are applied", func(t *testing.T) {
+		var regs []string
+		for i := 0; i < 100; i++ {
+			regs = append(regs, fmt.Sprintf(`{"ID": "web-%d", "Name": "web", "Port": %d}`, i, 8000+i))
+		}
+		resp, out := batch(t, fmt.Sprintf(`{"Register": [%s], "Deregister": ["old"]}`, strings.Join(regs, ",")))
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Len(t, out.Registered, 100)
+		require.Equal(t, []string{"old"}, out.Deregistered)
+		require.Empty(t, out.Errors)
+
+		require.Len(t, a.State.ServicesByName(structs.NewServiceName("web", nil)), 100)
+		require.Nil(t, a.State.Service(structs.NewServiceID("old", nil)))
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		resp, _ := batch(t, `{}`)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
 func TestAgent_DeregisterService_ACLDeny(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -5676,6 +5918,56 @@ func TestAgent_ServiceMaintenance_ACLDeny(t *testing.T) {
 	})
 }
 
+func TestAgent_ServiceDetectedProtocol(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	serviceReq := AddServiceRequest{
+		Service: &structs.NodeService{
+			ID:      "test",
+			Service: "test",
+		},
+		Source: ConfigSourceLocal,
+	}
+	require.NoError(t, a.AddService(serviceReq))
+
+	t.Run("invalid protocol", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/v1/agent/service/detected-protocol/test", jsonReader(map[string]string{"Protocol": "smtp"}))
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("unknown service", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/v1/agent/service/detected-protocol/nope", jsonReader(map[string]string{"Protocol": "http"}))
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("records the protocol", func(t *testing.T) {
+		before := a.State.Service(structs.NewServiceID("test", nil))
+
+		req, _ := http.NewRequest("PUT", "/v1/agent/service/detected-protocol/test", jsonReader(map[string]string{"Protocol": "grpc"}))
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		svc := a.State.Service(structs.NewServiceID("test", nil))
+		require.NotNil(t, svc)
+		require.Equal(t, "grpc", svc.Meta[structs.MetaDetectedProtocol])
+
+		// The definition previously read from the local state is unchanged.
+		require.Empty(t, before.Meta[structs.MetaDetectedProtocol])
+	})
+}
+
 func TestAgent_NodeMaintenance_BadRequest(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -6060,6 +6352,75 @@ func TestAgent_Monitor_ACLDeny(t *testing.T) {
 	// here.
 }
 
+func TestAgent_LogLevel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, TestACLConfig())
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	t.Run("read denied", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/agent/loglevel", nil)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("write denied", func(t *testing.T) {
+		ro := createACLTokenWithAgentReadPolicy(t, a.srv)
+		req, _ := http.NewRequest("PUT", "/v1/agent/loglevel?level=debug&token="+ro, nil)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("unknown subsystem", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/v1/agent/loglevel?subsystem=http&level=debug&token=root", nil)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+		require.Contains(t, resp.Body.String(), "Unknown log subsystem")
+	})
+
+	t.Run("unknown log level", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/v1/agent/loglevel?subsystem=xds&level=loud&token=root", nil)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+		require.Contains(t, resp.Body.String(), "Unknown log level")
+	})
+
+	t.Run("set and reset", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/v1/agent/loglevel?subsystem=xds&level=trace&token=root", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.AgentLogLevel(resp, req)
+		require.NoError(t, err)
+		levels := obj.(LogLevels)
+		require.Equal(t, map[string]string{"xds": "trace"}, levels.Subsystems)
+
+		req, _ = http.NewRequest("PUT", "/v1/agent/loglevel?level=warn&token=root", nil)
+		obj, err = a.srv.AgentLogLevel(resp, req)
+		require.NoError(t, err)
+		levels = obj.(LogLevels)
+		require.Equal(t, "warn", levels.Level)
+		require.Equal(t, map[string]string{"xds": "trace"}, levels.Subsystems)
+
+		req, _ = http.NewRequest("PUT", "/v1/agent/loglevel?subsystem=xds&level=default&token=root", nil)
+		_, err = a.srv.AgentLogLevel(resp, req)
+		require.NoError(t, err)
+
+		req, _ = http.NewRequest("GET", "/v1/agent/loglevel?token=root", nil)
+		obj, err = a.srv.AgentLogLevel(resp, req)
+		require.NoError(t, err)
+		levels = obj.(LogLevels)
+		require.Equal(t, "warn", levels.Level)
+		require.Empty(t, levels.Subsystems)
+	})
+}
+
 func TestAgent_TokenTriggersFullSync(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -7929,6 +8290,120 @@ func TestAgent_Host(t *testing.T) {
 	assert.Empty(t, obj.Errors)
 }
 
+func TestAgent_Ready(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	probe := func(t *testing.T, a *TestAgent, path string) (int, api.AgentProbe) {
+		t.Helper()
+		req, _ := http.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+
+		var out api.AgentProbe
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		return resp.Code, out
+	}
+
+	t.Run("server", func(t *testing.T) {
+		a := NewTestAgent(t, "")
+		defer a.Shutdown()
+		testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+		code, out := probe(t, a, "/v1/agent/ready")
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, api.AgentProbe{
+			Status: api.HealthPassing,
+			Criteria: []api.AgentProbeCriterion{
+				{Name: "serf", Passing: true},
+				{Name: "servers", Passing: true},
+			},
+		}, out)
+
+		// No liveness criteria are configured.
+		code, out = probe(t, a, "/v1/agent/live")
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, api.AgentProbe{
+			Status:   api.HealthPassing,
+			Criteria: []api.AgentProbeCriterion{},
+		}, out)
+	})
+
+	t.Run("client without servers", func(t *testing.T) {
+		a := NewTestAgent(t, `
+			server = false
+			bootstrap = false
+			enable_central_service_config = false
+			agent_probes {
+				liveness = ["serf", "proxycfg"]
+			}
+		`)
+		defer a.Shutdown()
+
+		code, out := probe(t, a, "/v1/agent/ready")
+		require.Equal(t, http.StatusServiceUnavailable, code)
+		require.Equal(t, api.AgentProbe{
+			Status: api.HealthCritical,
+			Criteria: []api.AgentProbeCriterion{
+				{Name: "serf", Passing: true},
+				{Name: "servers", Output: "no known Consul servers"},
+			},
+		}, out)
+
+		code, out = probe(t, a, "/v1/agent/live")
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, api.HealthPassing, out.Status)
+
+		// Without servers, the configuration of a local proxy can't be
+		// completed.
+		proxy := structs.TestNodeServiceProxy(t)
+		require.NoError(t, a.addServiceFromSource(proxy, nil, false, "", ConfigSourceLocal))
+		retry.Run(t, func(r *retry.R) {
+			code, out := probe(t, a, "/v1/agent/live")
+			require.Equal(r, http.StatusServiceUnavailable, code)
+			require.Equal(r, api.AgentProbeCriterion{
+				Name:   "proxycfg",
+				Output: "configuration of proxies web-proxy is not yet complete",
+			}, out.Criteria[1])
+		})
+	})
+
+	t.Run("unresolvable agent token", func(t *testing.T) {
+		a := NewTestAgent(t, `
+			primary_datacenter = "dc1"
+			agent_probes {
+				readiness = ["acls"]
+			}
+			acl {
+				enabled = true
+				default_policy = "deny"
+				tokens {
+					initial_management = "root"
+					agent = "5e52954d-b2a9-4ba6-b4d7-d5e4d0d2f7b1"
+				}
+			}
+		`)
+		defer a.Shutdown()
+		testrpc.WaitForLeader(t, a.RPC, "dc1", testrpc.WithToken("root"))
+
+		// The probes require agent:read.
+		req, _ := http.NewRequest("GET", "/v1/agent/ready", nil)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusForbidden, resp.Code)
+
+		code, out := probe(t, a, "/v1/agent/ready?token=root")
+		require.Equal(t, http.StatusServiceUnavailable, code)
+		require.Equal(t, api.HealthCritical, out.Status)
+		require.Len(t, out.Criteria, 1)
+		require.False(t, out.Criteria[0].Passing)
+		require.Contains(t, out.Criteria[0].Output, "failed to resolve the agent token")
+	})
+}
+
 func TestAgent_HostBadACL(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -7962,6 +8437,163 @@ func TestAgent_HostBadACL(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.Code)
 }
 
+func TestAgent_DebugProxies(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, `
+		services {
+			name = "web"
+			port = 8080
+			connect { sidecar_service {} }
+		}
+	`)
+	defer a.Shutdown()
+
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	retry.Run(t, func(r *retry.R) {
+		req, _ := http.NewRequest("GET", "/v1/agent/debug/proxies", nil)
+		resp := httptest.NewRecorder()
+		respRaw, err := a.srv.AgentDebugProxies(resp, req)
+		require.NoError(r, err)
+
+		obj := respRaw.(ProxiesDebugInfo)
+		require.Len(r, obj.Proxies, 1)
+		proxy := obj.Proxies[0]
+		require.Equal(r, "web-sidecar-proxy", proxy.ProxyID)
+		require.Equal(r, structs.ServiceKindConnectProxy, proxy.Kind)
+
+		leaf, ok := proxy.Snapshot["ConnectProxy"].(map[string]interface{})["Leaf"].(map[string]interface{})
+		require.True(r, ok, "leaf is not set yet")
+		require.Equal(r, "hidden", leaf["PrivateKeyPEM"])
+	})
+}
+
+func TestAgent_DebugProxies_ACLDeny(t *testing.T) {
+	bd := BaseDeps{}
+	bd.Tokens = new(tokenStore.Store)
+	d := fakeResolveTokenDelegate{authorizer: acl.DenyAll()}
+	agent := &Agent{
+		baseDeps: bd,
+		delegate: d,
+		tokens:   bd.Tokens,
+		config:   &config.RuntimeConfig{NodeName: "the-node"},
+		logger:   hclog.NewInterceptLogger(nil),
+	}
+	h := HTTPHandlers{agent: agent, denylist: NewDenylist(nil)}
+
+	resp := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/v1/agent/debug/proxies", nil)
+	require.NoError(t, err)
+	h.handler(false).ServeHTTP(resp, req)
+	require.Equal(t, http.StatusForbidden, resp.Code)
+	require.Contains(t, resp.Body.String(), "Permission denied")
+}
+
+func TestAgent_ConnectProxyRouting(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, `
+		services {
+			name = "web"
+			port = 8080
+			connect {
+				sidecar_service {
+					proxy {
+						upstreams = [
+							{
+								destination_name = "db"
+								local_bind_port = 9191
+							},
+							{
+								destination_name = "cache"
+								local_bind_port = 9192
+							}
+						]
+					}
+				}
+			}
+		}
+	`)
+	defer a.Shutdown()
+
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	t.Run("unknown proxy", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/agent/connect/routing/nope", nil)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("not a proxy", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/agent/connect/routing/web", nil)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("all upstreams", func(t *testing.T) {
+		retry.Run(t, func(r *retry.R) {
+			req, _ := http.NewRequest("GET", "/v1/agent/connect/routing/web-sidecar-proxy", nil)
+			resp := httptest.NewRecorder()
+			obj, err := a.srv.AgentConnectProxyRouting(resp, req)
+			require.NoError(r, err)
+
+			routing := obj.([]proxycfg.UpstreamRouting)
+			require.Len(r, routing, 2)
+			require.Equal(r, "cache", routing[0].Upstream)
+			require.Equal(r, "db", routing[1].Upstream)
+			require.Len(r, routing[1].Targets, 1)
+			require.Equal(r, "db.default.default.dc1", routing[1].Targets[0].ID)
+			require.Empty(r, routing[1].Targets[0].Hops)
+		})
+	})
+
+	t.Run("single upstream", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/agent/connect/routing/web-sidecar-proxy?upstream=db", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.AgentConnectProxyRouting(resp, req)
+		require.NoError(t, err)
+
+		routing := obj.([]proxycfg.UpstreamRouting)
+		require.Len(t, routing, 1)
+		require.Equal(t, "db", routing[0].DestinationName)
+	})
+}
+
+func TestAgent_ConnectProxyRouting_ACLDeny(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, TestACLConfig()+`
+		services {
+			name = "web"
+			port = 8080
+			connect { sidecar_service {} }
+		}
+	`)
+	defer a.Shutdown()
+
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	req, _ := http.NewRequest("GET", "/v1/agent/connect/routing/web-sidecar-proxy", nil)
+	resp := httptest.NewRecorder()
+	_, err := a.srv.AgentConnectProxyRouting(resp, req)
+	require.True(t, acl.IsErrPermissionDenied(err))
+}
+
 // Thie tests that a proxy with an ExposeConfig is returned as expected.
 func TestAgent_Services_ExposeConfig(t *testing.T) {
 	if testing.Short() {
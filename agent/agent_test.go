@@ -2222,6 +2222,52 @@ func TestAgent_PersistService(t *testing.T) {
 	})
 }
 
+func TestAgent_DetectedProtocols(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+
+	newService := func() *structs.NodeService {
+		return &structs.NodeService{
+			ID:      "web",
+			Service: "web",
+			Port:    8000,
+		}
+	}
+	sid := structs.NewServiceID("web", nil)
+	require.NoError(t, a.addServiceFromSource(newService(), nil, true, "", ConfigSourceRemote))
+
+	require.NoError(t, a.SetServiceDetectedProtocol(sid, "http"))
+	require.NoError(t, a.SetUpstreamDetectedProtocol(sid, structs.NewServiceName("db", nil), "http2"))
+	require.NoError(t, a.SetUpstreamDetectedProtocol(sid, structs.NewServiceName("api", nil), "http"))
+
+	expected := map[string]string{
+		structs.MetaDetectedProtocol:          "http",
+		structs.MetaDetectedUpstreamProtocols: "api=http,db=http2",
+	}
+	require.Equal(t, expected, a.State.Service(sid).Meta)
+
+	// The detected protocols are persisted with the service.
+	buf, err := ioutil.ReadFile(a.makeServiceFilePath(sid))
+	require.NoError(t, err)
+	var p persistedService
+	require.NoError(t, json.Unmarshal(buf, &p))
+	require.Equal(t, expected, p.Service.Meta)
+
+	// And kept when the service is registered again.
+	require.NoError(t, a.addServiceFromSource(newService(), nil, true, "", ConfigSourceRemote))
+	require.Equal(t, expected, a.State.Service(sid).Meta)
+
+	buf, err = ioutil.ReadFile(a.makeServiceFilePath(sid))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(buf, &p))
+	require.Equal(t, expected, p.Service.Meta)
+}
+
 func testAgent_PersistService(t *testing.T, extraHCL string) {
 	t.Helper()
 
@@ -5898,3 +5944,13 @@ func assertDeepEqual(t *testing.T, x, y interface{}, opts ...cmp.Option) {
 		t.Fatalf("assertion failed: values are not equal\n--- expected\n+++ actual\n%v", diff)
 	}
 }
+
+func TestTLSWatchedFiles(t *testing.T) {
+	cfg := tlsutil.Config{
+		InternalRPC: tlsutil.ProtocolConfig{CAFile: "ca.pem", CertFile: "server.pem", KeyFile: "server.key"},
+		GRPC:        tlsutil.ProtocolConfig{CAFile: "ca.pem", CertFile: "grpc.pem", KeyFile: "grpc.key"},
+		HTTPS:       tlsutil.ProtocolConfig{CAFile: "ca.pem"},
+	}
+	require.Equal(t, []string{"server.pem", "server.key", "ca.pem", "grpc.pem", "grpc.key"}, tlsWatchedFiles(cfg))
+	require.Empty(t, tlsWatchedFiles(tlsutil.Config{}))
+}
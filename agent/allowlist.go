@@ -0,0 +1,38 @@
+package agent
+
+import (
+	"path"
+
+	"github.com/armon/go-radix"
+)
+
+// Allowlist implements an HTTP endpoint allowlist based on a list of endpoint
+// prefixes which are the only ones served.
+type Allowlist struct {
+	tree *radix.Tree
+}
+
+// NewAllowlist returns an allowlist for the given list of prefixes. An empty
+// list allows all the endpoints.
+func NewAllowlist(prefixes []string) *Allowlist {
+	tree := radix.New()
+	for _, prefix := range prefixes {
+		tree.Insert(prefix, nil)
+	}
+	return &Allowlist{tree}
+}
+
+// Allow will return true if the given path is included among any of the
+// allowed prefixes. The path is cleaned first so that dot segments can't be
+// used to reach other endpoints.
+func (a *Allowlist) Allow(p string) bool {
+	if a.tree.Len() == 0 {
+		return true
+	}
+	for _, candidate := range []string{p, path.Clean(p)} {
+		if _, _, ok := a.tree.LongestPrefix(candidate); !ok {
+			return false
+		}
+	}
+	return true
+}
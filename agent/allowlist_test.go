@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"testing"
+)
+
+func TestAllowlist(t *testing.T) {
+	t.Parallel()
+
+	complex := []string{
+		"/a",
+		"/b/c",
+		"/d/",
+	}
+
+	tests := []struct {
+		desc     string
+		prefixes []string
+		path     string
+		allow    bool
+	}{
+		{"everything allowed root", nil, "/", true},
+		{"everything allowed path", nil, "/a", true},
+		{"exact match 1", complex, "/a", true},
+		{"exact match 2", complex, "/b/c", true},
+		{"subpath", complex, "/a/b", true},
+		{"longer prefix", complex, "/apple", true},
+		{"longer subpath", complex, "/b/c/d", true},
+		{"trailing slash subpath", complex, "/d/e", true},
+		{"partial prefix", complex, "/b/d", false},
+		{"no match", complex, "/c", false},
+		{"root", complex, "/", false},
+		{"dot segments", complex, "/b/c/../../c", false},
+		{"dot segments within prefix", complex, "/a/b/../c", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			allowlist := NewAllowlist(tt.prefixes)
+			if got, want := allowlist.Allow(tt.path), tt.allow; got != want {
+				t.Fatalf("got %v want %v", got, want)
+			}
+		})
+	}
+}
@@ -53,6 +53,10 @@ type AutoConfig struct {
 	// events from the token store when the Agent
 	// token is updated.
 	tokenUpdates token.Notifier
+
+	// snippetsUpdated receives a value whenever the config snippets
+	// distributed by the servers change.
+	snippetsUpdated chan struct{}
 }
 
 // New creates a new AutoConfig object for providing automatic Consul configuration.
@@ -76,6 +80,9 @@ func New(config Config) (*AutoConfig, error) {
 	if config.FallbackRetry == 0 {
 		config.FallbackRetry = time.Minute
 	}
+	if config.ConfigSnippetsRefreshInterval == 0 {
+		config.ConfigSnippetsRefreshInterval = 5 * time.Minute
+	}
 
 	logger := config.Logger
 	if logger == nil {
@@ -97,8 +104,9 @@ func New(config Config) (*AutoConfig, error) {
 	}
 
 	return &AutoConfig{
-		acConfig: config,
-		logger:   logger,
+		acConfig:        config,
+		logger:          logger,
+		snippetsUpdated: make(chan struct{}, 1),
 	}, nil
 }
 
@@ -231,7 +239,7 @@ func (ac *AutoConfig) recordInitialConfiguration(resp *pbautoconf.AutoConfigResp
 
 	ac.autoConfigSource = config.LiteralSource{
 		Name:   autoConfigFileName,
-		Config: translateConfig(resp.Config),
+		Config: ac.applyConfigSnippets(translateConfig(resp.Config), resp.ConfigSnippets),
 	}
 
 	// we need to re-read the configuration to determine what the correct ACL
@@ -264,6 +272,113 @@ func (ac *AutoConfig) recordInitialConfiguration(resp *pbautoconf.AutoConfigResp
 	return ac.persistAutoConfig(resp)
 }
 
+// applyConfigSnippets merges the config snippets distributed by the servers
+// into the auto-config configuration. Only the snippets setting the top level
+// keys listed in auto_config.accept_snippet_keys are applied, the others are
+// ignored entirely.
+func (ac *AutoConfig) applyConfigSnippets(conf config.Config, snippets []*pbautoconf.ConfigSnippet) config.Config {
+	accepted := make(map[string]struct{})
+	for _, key := range ac.config.AutoConfig.AcceptSnippetKeys {
+		accepted[key] = struct{}{}
+	}
+
+	for _, snippet := range snippets {
+		snippetConf, keys, err := config.ParseConfigSnippet(snippet.Name, snippet.Config)
+		if err != nil {
+			ac.logger.Warn("ignoring invalid auto-config snippet", "snippet", snippet.Name, "error", err)
+			continue
+		}
+
+		var rejected []string
+		for _, key := range keys {
+			if _, ok := accepted[key]; !ok {
+				rejected = append(rejected, key)
+			}
+		}
+		if len(rejected) > 0 {
+			ac.logger.Warn("ignoring auto-config snippet setting keys not accepted by auto_config.accept_snippet_keys",
+				"snippet", snippet.Name,
+				"keys", rejected,
+			)
+			continue
+		}
+
+		ac.logger.Info("applying auto-config snippet", "snippet", snippet.Name)
+		conf = config.Merge(conf, snippetConf)
+	}
+	return conf
+}
+
+// refreshConfigSnippets retrieves the config snippets targeting the agent
+// from one of the servers, and applies them if they changed.
+func (ac *AutoConfig) refreshConfigSnippets() error {
+	server := ac.acConfig.ServerProvider.FindLANServer()
+	if server == nil {
+		return fmt.Errorf("no known servers to retrieve the config snippets from")
+	}
+
+	request := pbautoconf.AutoConfigRequest{
+		Datacenter:  ac.config.Datacenter,
+		Node:        ac.config.NodeName,
+		Segment:     ac.config.SegmentName,
+		Partition:   ac.config.PartitionOrEmpty(),
+		ConsulToken: ac.acConfig.Tokens.AgentToken(),
+	}
+	var resp pbautoconf.AutoConfigResponse
+	if err := ac.acConfig.DirectRPC.RPC(ac.config.Datacenter, server.ShortName, server.Addr, "AutoConfig.ConfigSnippets", &request, &resp); err != nil {
+		return fmt.Errorf("failed to retrieve the config snippets: %w", err)
+	}
+
+	return ac.updateConfigSnippets(resp.ConfigSnippets)
+}
+
+// updateConfigSnippets applies the config snippets if they differ from the
+// current ones, persists them, and notifies ConfigSnippetsUpdated. They take
+// effect once the configuration is reloaded.
+func (ac *AutoConfig) updateConfigSnippets(snippets []*pbautoconf.ConfigSnippet) error {
+	ac.Lock()
+	defer ac.Unlock()
+
+	if ac.autoConfigResponse == nil || configSnippetsEqual(ac.autoConfigResponse.ConfigSnippets, snippets) {
+		return nil
+	}
+
+	ac.logger.Info("the auto-config snippets changed")
+	ac.autoConfigResponse.ConfigSnippets = snippets
+	ac.autoConfigSource = config.LiteralSource{
+		Name:   autoConfigFileName,
+		Config: ac.applyConfigSnippets(translateConfig(ac.autoConfigResponse.Config), snippets),
+	}
+	if err := ac.persistAutoConfig(ac.autoConfigResponse); err != nil {
+		return err
+	}
+
+	select {
+	case ac.snippetsUpdated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func configSnippetsEqual(a, b []*pbautoconf.ConfigSnippet) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || a[i].Config != b[i].Config {
+			return false
+		}
+	}
+	return true
+}
+
+// ConfigSnippetsUpdated returns a channel receiving a value whenever the config
+// snippets distributed by the servers change while the agent is running. The
+// configuration must be reloaded for the new snippets to take effect.
+func (ac *AutoConfig) ConfigSnippetsUpdated() <-chan struct{} {
+	return ac.snippetsUpdated
+}
+
 // getInitialConfigurationOnce will perform full server to TCPAddr resolution and
 // loop through each host trying to make the AutoConfig.InitialConfiguration RPC call. When
 // successful the bool return will be true and the err value will indicate whether we
@@ -402,6 +517,14 @@ func (ac *AutoConfig) Done() <-chan struct{} {
 	return done
 }
 
+// NodeIdentity returns the node identity signed by the servers in the
+// auto-config response, if any.
+func (ac *AutoConfig) NodeIdentity() string {
+	ac.Lock()
+	defer ac.Unlock()
+	return ac.autoConfigResponse.GetNodeIdentity()
+}
+
 func (ac *AutoConfig) IsRunning() bool {
 	ac.Lock()
 	defer ac.Unlock()
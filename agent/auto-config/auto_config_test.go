@@ -574,6 +574,10 @@ func TestGoRoutineManagement(t *testing.T) {
 		ctxLock.Unlock()
 	})
 
+	// the config snippets are refreshed when starting, there is no known
+	// server in this test
+	mcfg.serverProvider.On("FindLANServer").Return(nil).Maybe()
+
 	// we will start/stop things twice
 	mcfg.tokens.On("Notify", token.TokenKindAgent).Return(token.Notifier{}).Times(2)
 	mcfg.tokens.On("StopNotify", token.Notifier{}).Times(2)
@@ -734,6 +738,14 @@ func startedAutoConfig(t *testing.T, autoEncrypt bool) testAutoConfig {
 	mcfg.serverProvider.On("FindLANServer").Once().Return(&metadata.Server{
 		Addr: &net.TCPAddr{IP: net.IPv4(198, 18, 0, 1), Port: 8300},
 	})
+	// the config snippets are refreshed when starting, the tests using
+	// startedAutoConfig don't care about them
+	snippetsRefreshed := make(chan struct{})
+	if !autoEncrypt {
+		mcfg.serverProvider.On("FindLANServer").Return(nil).Once().Run(func(mock.Arguments) {
+			close(snippetsRefreshed)
+		})
+	}
 
 	indexedRoots, cert, extraCerts := mcfg.setupInitialTLS(t, "autoconf", "dc1", originalToken)
 
@@ -827,6 +839,9 @@ func startedAutoConfig(t *testing.T, autoEncrypt bool) testAutoConfig {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	require.NoError(t, ac.Start(ctx))
+	if !autoEncrypt {
+		require.True(t, waitForChans(100*time.Millisecond, snippetsRefreshed), "config snippets were not refreshed")
+	}
 	t.Cleanup(func() {
 		done := ac.Done()
 		cancel()
@@ -1192,3 +1207,99 @@ func TestIntroToken(t *testing.T) {
 	}
 
 }
+
+func TestApplyConfigSnippets(t *testing.T) {
+	ac := &AutoConfig{
+		logger: testutil.Logger(t),
+		config: &config.RuntimeConfig{
+			AutoConfig: config.AutoConfig{
+				AcceptSnippetKeys: []string{"telemetry", "recursors"},
+			},
+		},
+	}
+
+	snippets := []*pbautoconf.ConfigSnippet{
+		{Name: "telemetry", Config: `telemetry { statsd_address = "198.18.0.1:8125" }`},
+		{Name: "recursors", Config: `{"recursors": ["198.18.0.2"]}`},
+		// sets a key that is not accepted
+		{Name: "acl", Config: `recursors = ["198.18.0.3"]
+acl { default_policy = "allow" }`},
+		{Name: "invalid", Config: `not_a_key = true`},
+	}
+
+	base := config.Config{Datacenter: stringPointer("dc1")}
+	actual := ac.applyConfigSnippets(base, snippets)
+
+	expected := config.Config{
+		Datacenter:   stringPointer("dc1"),
+		Telemetry:    config.Telemetry{StatsdAddr: stringPointer("198.18.0.1:8125")},
+		DNSRecursors: []string{"198.18.0.2"},
+	}
+	require.Equal(t, expected, actual)
+
+	// no snippet is applied without accepted keys
+	ac.config.AutoConfig.AcceptSnippetKeys = nil
+	require.Equal(t, base, ac.applyConfigSnippets(base, snippets))
+}
+
+func TestRefreshConfigSnippets(t *testing.T) {
+	mcfg := newMockedConfig(t)
+	loader := setupRuntimeConfig(t)
+	loader.addConfigHCL(`
+		auto_config = {
+			enabled = true
+			intro_token = "blarg"
+			server_addresses = ["127.0.0.1:8300"]
+			accept_snippet_keys = ["log_level"]
+		}
+		verify_outgoing = true
+	`)
+	mcfg.Config.Loader = loader.Load
+
+	ac, err := New(mcfg.Config)
+	require.NoError(t, err)
+	_, err = ac.ReadConfig()
+	require.NoError(t, err)
+	ac.autoConfigResponse = &pbautoconf.AutoConfigResponse{Config: &pbconfig.Config{}}
+
+	addr := &net.TCPAddr{IP: net.IPv4(198, 18, 0, 1), Port: 8300}
+	mcfg.serverProvider.On("FindLANServer").Return(&metadata.Server{ShortName: "server1", Addr: addr})
+	mcfg.tokens.On("AgentToken").Return("secret")
+
+	snippets := []*pbautoconf.ConfigSnippet{
+		{Name: "debug", Config: `log_level = "debug"`},
+	}
+	expectedRequest := pbautoconf.AutoConfigRequest{
+		Datacenter:  "dc1",
+		Node:        "autoconf",
+		ConsulToken: "secret",
+	}
+	mcfg.directRPC.On(
+		"RPC",
+		"dc1",
+		"server1",
+		addr,
+		"AutoConfig.ConfigSnippets",
+		&expectedRequest,
+		&pbautoconf.AutoConfigResponse{}).Return(nil).Run(func(args mock.Arguments) {
+		resp, ok := args.Get(5).(*pbautoconf.AutoConfigResponse)
+		require.True(t, ok)
+		resp.ConfigSnippets = snippets
+	})
+
+	require.NoError(t, ac.refreshConfigSnippets())
+	requireChanReady(t, ac.ConfigSnippetsUpdated())
+
+	cfg, err := ac.ReadConfig()
+	require.NoError(t, err)
+	require.Equal(t, "debug", cfg.Logging.LogLevel)
+
+	resp, err := ac.readPersistedAutoConfig()
+	require.NoError(t, err)
+	require.Len(t, resp.ConfigSnippets, 1)
+	require.Equal(t, "debug", resp.ConfigSnippets[0].Name)
+
+	// unchanged snippets do not require a reload
+	require.NoError(t, ac.refreshConfigSnippets())
+	requireChanNotReady(t, ac.ConfigSnippetsUpdated())
+}
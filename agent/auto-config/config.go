@@ -100,6 +100,11 @@ type Config struct {
 	// fallback routine returns an error. If not set this will default to 1m.
 	FallbackRetry time.Duration
 
+	// ConfigSnippetsRefreshInterval is the duration between the retrievals of
+	// the config snippets distributed by the servers. If not set this will
+	// default to 5m.
+	ConfigSnippetsRefreshInterval time.Duration
+
 	// Tokens is the shared token store. It is used to retrieve the current
 	// agent token as well as getting notifications when that token is updated.
 	// This field is required.
@@ -130,12 +130,21 @@ func (ac *AutoConfig) run(ctx context.Context, exit chan struct{}) {
 	}
 	fallbackTimer := time.NewTimer(calcFallbackInterval())
 
+	// The config snippets persisted when the agent last ran may be outdated,
+	// they are refreshed right away and then periodically.
+	var snippetsCh <-chan time.Time
+	snippetsTimer := time.NewTimer(0)
+	if ac.config.AutoConfig.Enabled {
+		snippetsCh = snippetsTimer.C
+	}
+
 	// cleanup for once we are stopped
 	defer func() {
 		// cancel the go routines performing the cache watches
 		ac.cancelWatches()
 		// ensure we don't leak the timers go routine
 		fallbackTimer.Stop()
+		snippetsTimer.Stop()
 		// stop receiving notifications for token updates
 		ac.acConfig.Tokens.StopNotify(ac.tokenUpdates)
 
@@ -171,6 +180,11 @@ func (ac *AutoConfig) run(ctx context.Context, exit chan struct{}) {
 			// reset the fallback timer as the certificate may have been updated
 			fallbackTimer.Stop()
 			fallbackTimer = time.NewTimer(calcFallbackInterval())
+		case <-snippetsCh:
+			if err := ac.refreshConfigSnippets(); err != nil {
+				ac.logger.Error("error when refreshing the config snippets", "error", err)
+			}
+			snippetsTimer.Reset(ac.acConfig.ConfigSnippetsRefreshInterval)
 		case <-fallbackTimer.C:
 			// This is a safety net in case the cert doesn't get renewed
 			// in time. The agent would be stuck in that case because the watches
@@ -47,8 +47,21 @@ const ConnectCALeafName = "connect-ca-leaf"
 // fewer rate limited requests. See the full simulation this is based on at
 // https://github.com/banks/sim-rate-limit-backoff/blob/master/README.md for
 // more detail.
+//
+// This is the minimum window: servers widen it through the LeafRotationWindow
+// of the roots when they know their CSR rate limit can't sign the certs of
+// every service instance of the datacenter within it. See jitterWindow.
 const caChangeJitterWindow = 30 * time.Second
 
+// jitterWindow returns the window over which to spread each round of retries
+// when attempting to get a new certificate following a rotation to roots.
+func jitterWindow(roots *structs.IndexedCARoots) time.Duration {
+	if roots != nil && roots.LeafRotationWindow > caChangeJitterWindow {
+		return roots.LeafRotationWindow
+	}
+	return caChangeJitterWindow
+}
+
 // ConnectCALeaf supports fetching and generating Connect leaf
 // certificates.
 type ConnectCALeaf struct {
@@ -302,6 +315,26 @@ func calculateSoftExpiry(now time.Time, cert *structs.IssuedCert) (min time.Time
 	return softRenewTime, hardRenewTime
 }
 
+// NewValue implements cache.PersistentType.
+func (c *ConnectCALeaf) NewValue() interface{} {
+	return &structs.IssuedCert{}
+}
+
+// RestoreState implements cache.StateRestorer. The state of a restored leaf
+// is the one of a successful fetch, so it isn't regenerated when the servers
+// are reached again unless the roots changed.
+func (c *ConnectCALeaf) RestoreState(value interface{}) interface{} {
+	issued, ok := value.(*structs.IssuedCert)
+	if !ok {
+		return nil
+	}
+	cert, err := connect.ParseCert(issued.CertPEM)
+	if err != nil {
+		return nil
+	}
+	return ConnectCALeafSuccess(connect.EncodeSigningKeyID(cert.AuthorityKeyId))
+}
+
 func (c *ConnectCALeaf) Fetch(opts cache.FetchOptions, req cache.Request) (cache.FetchResult, error) {
 	var result cache.FetchResult
 
@@ -462,7 +495,7 @@ func (c *ConnectCALeaf) Fetch(opts cache.FetchOptions, req cache.Request) (cache
 
 			// CA root changed. We add some jitter here to avoid a thundering herd.
 			// See docs on caChangeJitterWindow const.
-			delay := lib.RandomStagger(caChangeJitterWindow)
+			delay := lib.RandomStagger(jitterWindow(roots))
 			if c.TestOverrideCAChangeInitialDelay > 0 {
 				delay = c.TestOverrideCAChangeInitialDelay
 			}
@@ -615,7 +648,7 @@ func (c *ConnectCALeaf) generateNewLeaf(req *ConnectCALeafRequest,
 			// Increment the errors in the state
 			state.consecutiveRateLimitErrs++
 
-			delay := lib.RandomStagger(caChangeJitterWindow)
+			delay := lib.RandomStagger(jitterWindow(roots))
 			if c.TestOverrideCAChangeInitialDelay > 0 {
 				delay = c.TestOverrideCAChangeInitialDelay
 			}
@@ -1130,3 +1130,10 @@ func TestConnectCALeaf_Key(t *testing.T) {
 	r := ConnectCALeafRequest{Agent: "abc"}
 	require.Equal(t, "agent:abc", r.Key())
 }
+
+func TestJitterWindow(t *testing.T) {
+	require.Equal(t, caChangeJitterWindow, jitterWindow(nil))
+	require.Equal(t, caChangeJitterWindow, jitterWindow(&structs.IndexedCARoots{}))
+	require.Equal(t, caChangeJitterWindow, jitterWindow(&structs.IndexedCARoots{LeafRotationWindow: time.Second}))
+	require.Equal(t, 5*time.Minute, jitterWindow(&structs.IndexedCARoots{LeafRotationWindow: 5 * time.Minute}))
+}
@@ -46,3 +46,8 @@ func (c *ConnectCARoot) Fetch(opts cache.FetchOptions, req cache.Request) (cache
 	result.Index = reply.QueryMeta.Index
 	return result, nil
 }
+
+// NewValue implements cache.PersistentType.
+func (c *ConnectCARoot) NewValue() interface{} {
+	return &structs.IndexedCARoots{}
+}
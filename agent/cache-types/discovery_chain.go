@@ -51,3 +51,8 @@ func (c *CompiledDiscoveryChain) Fetch(opts cache.FetchOptions, req cache.Reques
 	result.Index = reply.QueryMeta.Index
 	return result, nil
 }
+
+// NewValue implements cache.PersistentType.
+func (c *CompiledDiscoveryChain) NewValue() interface{} {
+	return &structs.DiscoveryChainResponse{}
+}
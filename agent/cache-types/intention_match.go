@@ -44,3 +44,8 @@ func (c *IntentionMatch) Fetch(opts cache.FetchOptions, req cache.Request) (cach
 	result.Index = reply.Index
 	return result, nil
 }
+
+// NewValue implements cache.PersistentType.
+func (c *IntentionMatch) NewValue() interface{} {
+	return &structs.IndexedIntentionMatches{}
+}
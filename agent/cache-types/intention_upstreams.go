@@ -50,3 +50,8 @@ func (i *IntentionUpstreams) Fetch(opts cache.FetchOptions, req cache.Request) (
 	result.Index = reply.QueryMeta.Index
 	return result, nil
 }
+
+// NewValue implements cache.PersistentType.
+func (i *IntentionUpstreams) NewValue() interface{} {
+	return &structs.IndexedServiceList{}
+}
@@ -61,6 +61,14 @@ var Counters = []prometheus.CounterDefinition{
 		Name: []string{"consul", "cache", "evict_expired"},
 		Help: "Counts the number of expired entries that are evicted.",
 	},
+	{
+		Name: []string{"consul", "cache", "evict_max_entries"},
+		Help: "Counts the number of entries evicted because their type reached its maximum number of entries.",
+	},
+	{
+		Name: []string{"consul", "cache", "restored"},
+		Help: "Counts the number of entries restored from disk when the agent starts.",
+	},
 }
 
 // Constants related to refresh backoff. We probably don't ever need to
@@ -130,6 +138,10 @@ type Cache struct {
 	options          Options
 	rateLimitContext context.Context
 	rateLimitCancel  context.CancelFunc
+
+	// persistDoneCh is closed once the entries were persisted a last time
+	// after Close was called. It is nil when persistence is disabled.
+	persistDoneCh chan struct{}
 }
 
 // typeEntry is a single type that is registered with a Cache.
@@ -138,6 +150,15 @@ type typeEntry struct {
 	Name string
 	Type Type
 	Opts *RegisterOptions
+
+	// stats counts how the entries of the type are used.
+	stats *typeStats
+
+	// expiryHeap orders the entries of the type by expiry, which is the order
+	// in which they were last requested, to find the one to evict when
+	// MaxEntries is reached. It is nil when the type has no MaxEntries and
+	// must be protected by entriesLock.
+	expiryHeap *ttlcache.ExpiryHeap
 }
 
 // ResultMeta is returned from Get calls along with the value and can be used
@@ -177,6 +198,29 @@ type Options struct {
 	EntryFetchMaxBurst int
 	// EntryFetchRate represents the max calls/sec for a single cache entry
 	EntryFetchRate rate.Limit
+
+	// PersistDir is the directory the entries of the PersistentType types are
+	// persisted to, encrypted. Persistence is disabled when it is empty. It
+	// can't be changed with ReloadOptions.
+	PersistDir string
+	// PersistKey is the base64 encoded AES-256 key the persisted entries are
+	// encrypted with. It is never written to PersistDir, so reading the data
+	// dir is not enough to decrypt the certificates and tokens it holds.
+	PersistKey string
+
+	// Types overrides the RegisterOptions of the types registered with the
+	// given names. It can't be changed with ReloadOptions.
+	Types map[string]TypeOptions
+}
+
+// TypeOptions override the RegisterOptions of a type. Zero values keep the
+// ones of the type.
+type TypeOptions struct {
+	// LastGetTTL overrides RegisterOptions.LastGetTTL.
+	LastGetTTL time.Duration
+
+	// MaxEntries overrides RegisterOptions.MaxEntries.
+	MaxEntries int
 }
 
 // Equal return true if both options are equivalent
@@ -260,6 +304,11 @@ type RegisterOptions struct {
 	// operation. It is set as FetchOptions.Timeout so that cache.Type
 	// implementations can use it as the MaxQueryTime.
 	QueryTimeout time.Duration
+
+	// MaxEntries is the maximum number of entries of this type kept in the
+	// cache. When it is reached, the least recently requested entry is
+	// evicted to make room for a new one. Zero means no limit.
+	MaxEntries int
 }
 
 // RegisterType registers a cacheable type.
@@ -268,13 +317,25 @@ type RegisterOptions struct {
 // any prefetching. In order to populate the cache, Get must be called.
 func (c *Cache) RegisterType(n string, typ Type) {
 	opts := typ.RegisterOptions()
+	if override, ok := c.options.Types[n]; ok {
+		if override.LastGetTTL > 0 {
+			opts.LastGetTTL = override.LastGetTTL
+		}
+		if override.MaxEntries > 0 {
+			opts.MaxEntries = override.MaxEntries
+		}
+	}
 	if opts.LastGetTTL == 0 {
 		opts.LastGetTTL = 72 * time.Hour // reasonable default is days
 	}
 
 	c.typesLock.Lock()
 	defer c.typesLock.Unlock()
-	c.types[n] = typeEntry{Name: n, Type: typ, Opts: &opts}
+	tEntry := typeEntry{Name: n, Type: typ, Opts: &opts, stats: &typeStats{}}
+	if opts.MaxEntries > 0 {
+		tEntry.expiryHeap = ttlcache.NewExpiryHeap()
+	}
+	c.types[n] = tEntry
 }
 
 // ReloadOptions updates the cache with the new options
@@ -438,6 +499,9 @@ RETRY_GET:
 		// least still cares about the value!
 		c.entriesLock.Lock()
 		c.entriesExpiryHeap.Update(entry.Expiry.Index(), r.TypeEntry.Opts.LastGetTTL)
+		if entry.TypeExpiry != nil && r.TypeEntry.expiryHeap != nil {
+			r.TypeEntry.expiryHeap.Update(entry.TypeExpiry.Index(), r.TypeEntry.Opts.LastGetTTL)
+		}
 		c.entriesLock.Unlock()
 	}
 
@@ -445,6 +509,7 @@ RETRY_GET:
 		meta := ResultMeta{Index: entry.Index}
 		if first {
 			metrics.IncrCounter([]string{"consul", "cache", r.TypeEntry.Name, "hit"}, 1)
+			r.TypeEntry.stats.hit()
 			meta.Hit = true
 		}
 
@@ -498,6 +563,7 @@ RETRY_GET:
 			missKey = "miss_new"
 		}
 		metrics.IncrCounter([]string{"consul", "cache", r.TypeEntry.Name, missKey}, 1)
+		r.TypeEntry.stats.miss()
 	}
 
 	// Set our timeout channel if we must
@@ -574,6 +640,7 @@ func (c *Cache) fetch(key string, r getOptions, allowNew bool, attempt uint, ign
 	// If we don't have an entry, then create it. The entry must be marked
 	// as invalid so that it isn't returned as a valid value for a zero index.
 	if !ok {
+		c.evictForNewEntryLocked(r.TypeEntry)
 		entry = cacheEntry{
 			Valid:  false,
 			Waiter: make(chan struct{}),
@@ -697,6 +764,7 @@ func (c *Cache) fetch(key string, r getOptions, allowNew bool, attempt uint, ign
 			// TODO(kit): move tEntry.Name to a label on the first write here and deprecate the second write
 			metrics.IncrCounterWithLabels([]string{"consul", "cache", "fetch_success"}, 1, labels)
 			metrics.IncrCounterWithLabels([]string{"consul", "cache", tEntry.Name, "fetch_success"}, 1, labels)
+			tEntry.stats.fetchSuccess()
 
 			if result.Index > 0 {
 				// Reset the attempts counter so we don't have any backoff
@@ -731,6 +799,7 @@ func (c *Cache) fetch(key string, r getOptions, allowNew bool, attempt uint, ign
 			// TODO(kit): Add tEntry.Name to label on fetch_error and deprecate second write
 			metrics.IncrCounterWithLabels([]string{"consul", "cache", "fetch_error"}, 1, labels)
 			metrics.IncrCounterWithLabels([]string{"consul", "cache", tEntry.Name, "fetch_error"}, 1, labels)
+			tEntry.stats.fetchError()
 
 			// Increment attempt counter
 			attempt++
@@ -772,6 +841,7 @@ func (c *Cache) fetch(key string, r getOptions, allowNew bool, attempt uint, ign
 		if newEntry.Expiry == nil || newEntry.Expiry.Index() == ttlcache.NotIndexed {
 			newEntry.Expiry = c.entriesExpiryHeap.Add(key, tEntry.Opts.LastGetTTL)
 		}
+		c.addTypeExpiryLocked(tEntry, key, &newEntry, tEntry.Opts.LastGetTTL)
 
 		c.entries[key] = newEntry
 		c.entriesLock.Unlock()
@@ -850,13 +920,17 @@ func (c *Cache) runExpiryLoop() {
 			c.entriesLock.Lock()
 
 			entry := timer.Entry
-			if closer, ok := c.entries[entry.Key()].State.(io.Closer); ok {
+			cacheEntry := c.entries[entry.Key()]
+			if closer, ok := cacheEntry.State.(io.Closer); ok {
 				closer.Close()
 			}
 
 			// Entry expired! Remove it.
 			delete(c.entries, entry.Key())
 			c.entriesExpiryHeap.Remove(entry.Index())
+			tEntry := c.typeEntryForKeyLocked(entry.Key())
+			c.removeTypeExpiryLocked(tEntry, cacheEntry)
+			tEntry.stats.evict()
 
 			// Set some metrics
 			metrics.IncrCounter([]string{"consul", "cache", "evict_expired"}, 1)
@@ -879,6 +953,9 @@ func (c *Cache) Close() error {
 		// First time only, close stop chan
 		close(c.stopCh)
 		c.rateLimitCancel()
+		if c.persistDoneCh != nil {
+			<-c.persistDoneCh
+		}
 	}
 	return nil
 }
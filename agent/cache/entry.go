@@ -35,6 +35,10 @@ type cacheEntry struct {
 	// ExpiryHeap as well.
 	Expiry *ttlcache.Entry
 
+	// TypeExpiry is the entry of this entry in the expiry heap of its type,
+	// which is only set when the type has a MaxEntries.
+	TypeExpiry *ttlcache.Entry
+
 	// FetchedAt stores the time the cache entry was retrieved for determining
 	// it's age later.
 	FetchedAt time.Time
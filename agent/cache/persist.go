@@ -0,0 +1,266 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul-net-rpc/go-msgpack/codec"
+	"golang.org/x/time/rate"
+
+	"github.com/hashicorp/consul/lib/file"
+)
+
+const (
+	// persistEntriesFile is the name of the file holding the persisted
+	// entries in Options.PersistDir.
+	persistEntriesFile = "entries.bin"
+
+	// persistInterval is how often the persisted entries are written.
+	persistInterval = 30 * time.Second
+)
+
+// PersistentType is implemented by the types whose values are persisted to
+// disk when Options.PersistDir is set. Their entries are restored when the
+// agent restarts, so they can still be served if the servers can't be reached.
+type PersistentType interface {
+	Type
+
+	// NewValue returns a pointer to an empty value of the type returned by
+	// Fetch, which the persisted values are decoded into.
+	NewValue() interface{}
+}
+
+// StateRestorer is optionally implemented by a PersistentType that needs to
+// rebuild the State of restored entries, since it is not persisted.
+type StateRestorer interface {
+	RestoreState(value interface{}) interface{}
+}
+
+// persistedEntry is the representation of a cache entry on disk.
+type persistedEntry struct {
+	Key       string
+	Type      string
+	Value     []byte
+	Index     uint64
+	FetchedAt time.Time
+}
+
+// persistMsgpackHandle matches the handle used to encode RPC payloads so
+// that the values round-trip the same way.
+var persistMsgpackHandle = &codec.MsgpackHandle{
+	RawToString: true,
+	BasicHandle: codec.BasicHandle{
+		DecodeOptions: codec.DecodeOptions{
+			MapType: reflect.TypeOf(map[string]interface{}{}),
+		},
+	},
+}
+
+// Restore loads the entries persisted in Options.PersistDir, and starts
+// persisting the entries of the PersistentType types periodically and when
+// the cache is closed. It must be called once all the types are registered,
+// and does nothing if Options.PersistDir is empty.
+//
+// Restored entries are served like any other, and are refreshed from the
+// servers as soon as they are requested with a blocking index.
+func (c *Cache) Restore() error {
+	if c.options.PersistDir == "" {
+		return nil
+	}
+
+	err := c.restore()
+	c.persistDoneCh = make(chan struct{})
+	go c.runPersistLoop()
+	return err
+}
+
+func (c *Cache) restore() error {
+	aead, err := c.persistCipher()
+	if err != nil {
+		return err
+	}
+
+	raw, err := os.ReadFile(filepath.Join(c.options.PersistDir, persistEntriesFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read persisted cache entries: %w", err)
+	}
+	if len(raw) < aead.NonceSize() {
+		return errors.New("failed to decrypt persisted cache entries: file is truncated")
+	}
+	plain, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], nil)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt persisted cache entries: %w", err)
+	}
+
+	var persisted []persistedEntry
+	if err := codec.NewDecoder(bytes.NewReader(plain), persistMsgpackHandle).Decode(&persisted); err != nil {
+		return fmt.Errorf("failed to decode persisted cache entries: %w", err)
+	}
+
+	restored := 0
+	for _, p := range persisted {
+		c.typesLock.RLock()
+		tEntry, ok := c.types[p.Type]
+		c.typesLock.RUnlock()
+		if !ok {
+			continue
+		}
+		pt, ok := tEntry.Type.(PersistentType)
+		if !ok || time.Since(p.FetchedAt) > tEntry.Opts.LastGetTTL {
+			continue
+		}
+
+		value := pt.NewValue()
+		if err := codec.NewDecoder(bytes.NewReader(p.Value), persistMsgpackHandle).Decode(value); err != nil {
+			c.options.Logger.Warn("failed to decode persisted cache entry",
+				"cache_type", p.Type,
+				"error", err,
+			)
+			continue
+		}
+
+		entry := cacheEntry{
+			Valid:     true,
+			Value:     value,
+			Index:     p.Index,
+			FetchedAt: p.FetchedAt,
+			Waiter:    make(chan struct{}),
+			// Report the age of the value since it was fetched until the
+			// servers are reached again.
+			RefreshLostContact: p.FetchedAt,
+			FetchRateLimiter: rate.NewLimiter(
+				c.options.EntryFetchRate,
+				c.options.EntryFetchMaxBurst,
+			),
+		}
+		if sr, ok := pt.(StateRestorer); ok {
+			entry.State = sr.RestoreState(value)
+		}
+
+		// Restored entries expire like the fetched ones if they are not
+		// requested, for what is left of their TTL.
+		ttl := tEntry.Opts.LastGetTTL - time.Since(p.FetchedAt)
+
+		c.entriesLock.Lock()
+		if _, ok := c.entries[p.Key]; !ok {
+			c.evictForNewEntryLocked(tEntry)
+			entry.Expiry = c.entriesExpiryHeap.Add(p.Key, ttl)
+			c.addTypeExpiryLocked(tEntry, p.Key, &entry, ttl)
+			c.entries[p.Key] = entry
+			restored++
+		}
+		c.entriesLock.Unlock()
+	}
+
+	metrics.IncrCounter([]string{"consul", "cache", "restored"}, float32(restored))
+	c.options.Logger.Info("restored persisted cache entries", "entries", restored)
+	return nil
+}
+
+// runPersistLoop persists the entries periodically until the cache is
+// closed, and a last time after that.
+func (c *Cache) runPersistLoop() {
+	ticker := time.NewTicker(persistInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopCh:
+			if err := c.persist(); err != nil {
+				c.options.Logger.Warn("failed to persist cache entries", "error", err)
+			}
+			close(c.persistDoneCh)
+			return
+		case <-ticker.C:
+			if err := c.persist(); err != nil {
+				c.options.Logger.Warn("failed to persist cache entries", "error", err)
+			}
+		}
+	}
+}
+
+// persist writes the valid entries of the PersistentType types to
+// Options.PersistDir.
+func (c *Cache) persist() error {
+	aead, err := c.persistCipher()
+	if err != nil {
+		return err
+	}
+
+	c.typesLock.RLock()
+	types := make(map[string]typeEntry, len(c.types))
+	for name, tEntry := range c.types {
+		if _, ok := tEntry.Type.(PersistentType); ok {
+			types[name] = tEntry
+		}
+	}
+	c.typesLock.RUnlock()
+
+	var persisted []persistedEntry
+	c.entriesLock.RLock()
+	for key, entry := range c.entries {
+		name := entryKeyType(key)
+		tEntry, ok := types[name]
+		if !ok || !entry.Valid || entry.Value == nil {
+			continue
+		}
+		if time.Since(entry.FetchedAt) > tEntry.Opts.LastGetTTL {
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := codec.NewEncoder(&buf, persistMsgpackHandle).Encode(entry.Value); err != nil {
+			c.entriesLock.RUnlock()
+			return fmt.Errorf("failed to encode cache entry of type %q: %w", name, err)
+		}
+		persisted = append(persisted, persistedEntry{
+			Key:       key,
+			Type:      name,
+			Value:     buf.Bytes(),
+			Index:     entry.Index,
+			FetchedAt: entry.FetchedAt,
+		})
+	}
+	c.entriesLock.RUnlock()
+
+	var buf bytes.Buffer
+	if err := codec.NewEncoder(&buf, persistMsgpackHandle).Encode(persisted); err != nil {
+		return fmt.Errorf("failed to encode cache entries: %w", err)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	sealed := aead.Seal(nonce, nonce, buf.Bytes(), nil)
+	return file.WriteAtomic(filepath.Join(c.options.PersistDir, persistEntriesFile), sealed)
+}
+
+// persistCipher returns the cipher used to encrypt the persisted entries with
+// Options.PersistKey.
+func (c *Cache) persistCipher() (cipher.AEAD, error) {
+	key, err := base64.StdEncoding.DecodeString(c.options.PersistKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache encryption key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
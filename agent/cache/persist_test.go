@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+)
+
+func TestCache_PersistRestore(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	value := &fakePersistedValue{Name: "web", Secret: "s3cr3t-private-key"}
+
+	c := New(Options{PersistDir: dir, PersistKey: testPersistKey})
+	c.RegisterType("p", &fakePersistentType{})
+	c.RegisterType("t", &fakeType{index: 5})
+	require.NoError(t, c.Restore())
+	require.NoError(t, c.Prepopulate("p", FetchResult{Value: value, Index: 7}, "dc1", "", "token", "v1"))
+	require.NoError(t, c.Prepopulate("t", FetchResult{Value: 10, Index: 5}, "dc1", "", "token", "v1"))
+	require.NoError(t, c.Close())
+
+	// The entries are encrypted, and the key is not stored next to them.
+	raw, err := os.ReadFile(filepath.Join(dir, persistEntriesFile))
+	require.NoError(t, err)
+	require.NotContains(t, string(raw), value.Secret)
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	c = New(Options{PersistDir: dir, PersistKey: testPersistKey})
+	c.RegisterType("p", &fakePersistentType{})
+	c.RegisterType("t", &fakeType{index: 5})
+	require.NoError(t, c.Restore())
+	t.Cleanup(func() { c.Close() })
+
+	// Only the entries of persistent types are restored, and they are
+	// served even though they can't be fetched.
+	c.entriesLock.RLock()
+	require.Len(t, c.entries, 1)
+	entry := c.entries[makeEntryKey("p", "dc1", "", "token", "v1")]
+	c.entriesLock.RUnlock()
+	require.Equal(t, "restored web", entry.State)
+	require.NotNil(t, entry.Expiry)
+
+	req := fakeRequest{info: RequestInfo{Key: "v1", Token: "token", Datacenter: "dc1"}}
+	result, meta, err := c.Get(context.Background(), "p", req)
+	require.NoError(t, err)
+	require.Equal(t, value, result)
+	require.True(t, meta.Hit)
+	require.Equal(t, uint64(7), meta.Index)
+}
+
+func TestCache_RestoreWrongKey(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	c := New(Options{PersistDir: dir, PersistKey: testPersistKey})
+	c.RegisterType("p", &fakePersistentType{})
+	require.NoError(t, c.Restore())
+	require.NoError(t, c.Prepopulate("p", FetchResult{Value: &fakePersistedValue{Name: "web"}, Index: 7}, "dc1", "", "token", "v1"))
+	require.NoError(t, c.Close())
+
+	c = New(Options{PersistDir: dir, PersistKey: base64.StdEncoding.EncodeToString(make([]byte, 32))})
+	c.RegisterType("p", &fakePersistentType{})
+	t.Cleanup(func() { c.Close() })
+	require.Error(t, c.Restore())
+
+	c.entriesLock.RLock()
+	defer c.entriesLock.RUnlock()
+	require.Empty(t, c.entries)
+}
+
+func TestCache_RestoreExpires(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	typ := &fakePersistentType{ttl: 200 * time.Millisecond}
+	c := New(Options{PersistDir: dir, PersistKey: testPersistKey})
+	c.RegisterType("p", typ)
+	require.NoError(t, c.Restore())
+	require.NoError(t, c.Prepopulate("p", FetchResult{Value: &fakePersistedValue{Name: "web"}, Index: 7}, "dc1", "", "token", "v1"))
+	require.NoError(t, c.Close())
+
+	c = New(Options{PersistDir: dir, PersistKey: testPersistKey})
+	c.RegisterType("p", typ)
+	require.NoError(t, c.Restore())
+	t.Cleanup(func() { c.Close() })
+
+	// The restored entry is evicted once its TTL elapses without requests.
+	retry.Run(t, func(r *retry.R) {
+		c.entriesLock.RLock()
+		defer c.entriesLock.RUnlock()
+		require.Empty(r, c.entries)
+	})
+}
+
+// testPersistKey is a base64 encoded 32 byte key.
+const testPersistKey = "mNtRkmRSSAF5V6/sVZeZzdOVFdOK0G1ZgN7SScJVtDw="
+
+type fakePersistedValue struct {
+	Name   string
+	Secret string
+}
+
+// fakePersistentType can't reach the servers.
+type fakePersistentType struct {
+	ttl time.Duration
+}
+
+func (f *fakePersistentType) Fetch(_ FetchOptions, _ Request) (FetchResult, error) {
+	return FetchResult{}, errors.New("no servers")
+}
+
+func (f *fakePersistentType) RegisterOptions() RegisterOptions {
+	return RegisterOptions{SupportsBlocking: true, LastGetTTL: f.ttl}
+}
+
+func (f *fakePersistentType) NewValue() interface{} {
+	return &fakePersistedValue{}
+}
+
+func (f *fakePersistentType) RestoreState(value interface{}) interface{} {
+	return "restored " + value.(*fakePersistedValue).Name
+}
+
+var _ StateRestorer = (*fakePersistentType)(nil)
+var _ PersistentType = (*fakePersistentType)(nil)
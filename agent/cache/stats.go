@@ -0,0 +1,204 @@
+package cache
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/armon/go-metrics"
+
+	"github.com/hashicorp/consul/lib/ttlcache"
+)
+
+// typeStats counts how the entries of a type are used. A nil *typeStats
+// counts nothing.
+type typeStats struct {
+	hits           uint64
+	misses         uint64
+	fetchSuccesses uint64
+	fetchErrors    uint64
+	evictions      uint64
+}
+
+func (s *typeStats) hit() {
+	if s != nil {
+		atomic.AddUint64(&s.hits, 1)
+	}
+}
+
+func (s *typeStats) miss() {
+	if s != nil {
+		atomic.AddUint64(&s.misses, 1)
+	}
+}
+
+func (s *typeStats) fetchSuccess() {
+	if s != nil {
+		atomic.AddUint64(&s.fetchSuccesses, 1)
+	}
+}
+
+func (s *typeStats) fetchError() {
+	if s != nil {
+		atomic.AddUint64(&s.fetchErrors, 1)
+	}
+}
+
+func (s *typeStats) evict() {
+	if s != nil {
+		atomic.AddUint64(&s.evictions, 1)
+	}
+}
+
+// TypeStats summarizes the entries of a registered type and how they were
+// used since the agent started.
+type TypeStats struct {
+	// Type is the name the type was registered with.
+	Type string
+
+	// Entries is the number of entries of the type, and ValidEntries the
+	// number of those that hold a value.
+	Entries      int
+	ValidEntries int
+
+	// Hits and Misses count the Get calls that were answered from the cache
+	// and the ones that had to wait for a fetch, HitRate is the ratio of hits
+	// among them.
+	Hits    uint64
+	Misses  uint64
+	HitRate float64
+
+	// FetchSuccesses and FetchErrors count the fetches made to the servers,
+	// FetchErrorRate is the ratio of errors among them.
+	FetchSuccesses uint64
+	FetchErrors    uint64
+	FetchErrorRate float64
+
+	// Evictions counts the entries that expired or were evicted because
+	// MaxEntries was reached.
+	Evictions uint64
+
+	// LastGetTTL and MaxEntries are the RegisterOptions of the type, after
+	// applying the overrides of Options.Types.
+	LastGetTTL time.Duration
+	MaxEntries int
+}
+
+// Stats returns a summary of the entries of each registered type, sorted by
+// type name.
+func (c *Cache) Stats() []TypeStats {
+	c.typesLock.RLock()
+	stats := make(map[string]*TypeStats, len(c.types))
+	for name, tEntry := range c.types {
+		s := &TypeStats{
+			Type:       name,
+			LastGetTTL: tEntry.Opts.LastGetTTL,
+			MaxEntries: tEntry.Opts.MaxEntries,
+		}
+		if tEntry.stats != nil {
+			s.Hits = atomic.LoadUint64(&tEntry.stats.hits)
+			s.Misses = atomic.LoadUint64(&tEntry.stats.misses)
+			s.FetchSuccesses = atomic.LoadUint64(&tEntry.stats.fetchSuccesses)
+			s.FetchErrors = atomic.LoadUint64(&tEntry.stats.fetchErrors)
+			s.Evictions = atomic.LoadUint64(&tEntry.stats.evictions)
+		}
+		if total := s.Hits + s.Misses; total > 0 {
+			s.HitRate = float64(s.Hits) / float64(total)
+		}
+		if total := s.FetchSuccesses + s.FetchErrors; total > 0 {
+			s.FetchErrorRate = float64(s.FetchErrors) / float64(total)
+		}
+		stats[name] = s
+	}
+	c.typesLock.RUnlock()
+
+	c.entriesLock.RLock()
+	for key, entry := range c.entries {
+		s, ok := stats[entryKeyType(key)]
+		if !ok {
+			continue
+		}
+		s.Entries++
+		if entry.Valid {
+			s.ValidEntries++
+		}
+	}
+	c.entriesLock.RUnlock()
+
+	result := make([]TypeStats, 0, len(stats))
+	for _, s := range stats {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Type < result[j].Type
+	})
+	return result
+}
+
+// entryKeyType returns the name of the type of the entry with the given key.
+func entryKeyType(key string) string {
+	if i := strings.Index(key, "/"); i >= 0 {
+		return key[:i]
+	}
+	return key
+}
+
+// typeEntryForKeyLocked returns the type of the entry with the given key. It
+// must be called with entriesLock held.
+func (c *Cache) typeEntryForKeyLocked(key string) typeEntry {
+	c.typesLock.RLock()
+	defer c.typesLock.RUnlock()
+	return c.types[entryKeyType(key)]
+}
+
+// addTypeExpiryLocked adds the entry to the expiry heap of its type, if the
+// type has one and the entry isn't in it yet. It must be called with
+// entriesLock held.
+func (c *Cache) addTypeExpiryLocked(tEntry typeEntry, key string, entry *cacheEntry, ttl time.Duration) {
+	if tEntry.expiryHeap == nil {
+		return
+	}
+	if entry.TypeExpiry == nil || entry.TypeExpiry.Index() == ttlcache.NotIndexed {
+		entry.TypeExpiry = tEntry.expiryHeap.Add(key, ttl)
+	}
+}
+
+// removeTypeExpiryLocked removes the entry from the expiry heap of its type.
+// It must be called with entriesLock held.
+func (c *Cache) removeTypeExpiryLocked(tEntry typeEntry, entry cacheEntry) {
+	if tEntry.expiryHeap == nil || entry.TypeExpiry == nil || entry.TypeExpiry.Index() == ttlcache.NotIndexed {
+		return
+	}
+	tEntry.expiryHeap.Remove(entry.TypeExpiry.Index())
+}
+
+// evictForNewEntryLocked evicts the least recently requested entries of the
+// type when it has reached its MaxEntries, to make room for a new entry. The
+// entries which are fetched for the first time are not in the expiry heap of
+// the type yet, so they are neither counted nor evicted. It must be called
+// with entriesLock held.
+func (c *Cache) evictForNewEntryLocked(tEntry typeEntry) {
+	if tEntry.expiryHeap == nil {
+		return
+	}
+
+	for tEntry.expiryHeap.Len() >= tEntry.Opts.MaxEntries {
+		oldest := tEntry.expiryHeap.First()
+		key := oldest.Key()
+		entry := c.entries[key]
+		if closer, ok := entry.State.(io.Closer); ok {
+			closer.Close()
+		}
+		delete(c.entries, key)
+		tEntry.expiryHeap.Remove(oldest.Index())
+		if entry.Expiry != nil && entry.Expiry.Index() != ttlcache.NotIndexed {
+			c.entriesExpiryHeap.Remove(entry.Expiry.Index())
+		}
+		tEntry.stats.evict()
+
+		metrics.IncrCounter([]string{"consul", "cache", "evict_max_entries"}, 1)
+		metrics.IncrCounter([]string{"consul", "cache", tEntry.Name, "evict_max_entries"}, 1)
+	}
+}
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Stats(t *testing.T) {
+	t.Parallel()
+
+	typ := TestTypeNonBlocking(t)
+	defer typ.AssertExpectations(t)
+	c := New(Options{Types: map[string]TypeOptions{"t": {LastGetTTL: time.Hour}}})
+	c.RegisterType("t", typ)
+	c.RegisterType("other", TestType(t))
+
+	typ.Static(FetchResult{Value: 42, Index: 1}, nil).Once()
+	typ.Static(FetchResult{}, errors.New("no servers"))
+
+	req := TestRequest(t, RequestInfo{Key: "hello"})
+	_, _, err := c.Get(context.Background(), "t", req)
+	require.NoError(t, err)
+	_, _, err = c.Get(context.Background(), "t", req)
+	require.NoError(t, err)
+
+	_, _, err = c.Get(context.Background(), "t", TestRequest(t, RequestInfo{Key: "goodbye"}))
+	require.Error(t, err)
+
+	stats := c.Stats()
+	require.Len(t, stats, 2)
+	require.Equal(t, TypeStats{Type: "other", LastGetTTL: 72 * time.Hour}, stats[0])
+	require.Equal(t, TypeStats{
+		Type:           "t",
+		Entries:        2,
+		ValidEntries:   1,
+		Hits:           1,
+		Misses:         2,
+		HitRate:        1.0 / 3,
+		FetchSuccesses: 1,
+		FetchErrors:    1,
+		FetchErrorRate: 0.5,
+		LastGetTTL:     time.Hour,
+	}, stats[1])
+}
+
+func TestCache_MaxEntries(t *testing.T) {
+	t.Parallel()
+
+	typ := TestTypeNonBlocking(t)
+	defer typ.AssertExpectations(t)
+	c := New(Options{Types: map[string]TypeOptions{"t": {MaxEntries: 2}}})
+	c.RegisterType("t", typ)
+
+	typ.Static(FetchResult{Value: 42, Index: 1}, nil)
+
+	get := func(key string) {
+		_, _, err := c.Get(context.Background(), "t", TestRequest(t, RequestInfo{Key: key}))
+		require.NoError(t, err)
+	}
+	get("a")
+	get("b")
+
+	// Requesting "a" again makes "b" the least recently requested entry.
+	get("a")
+	get("c")
+
+	c.entriesLock.RLock()
+	_, okA := c.entries[makeEntryKey("t", "", "", "", "a")]
+	_, okB := c.entries[makeEntryKey("t", "", "", "", "b")]
+	_, okC := c.entries[makeEntryKey("t", "", "", "", "c")]
+	require.Len(t, c.entries, 2)
+	require.Equal(t, 2, c.types["t"].expiryHeap.Len())
+	c.entriesLock.RUnlock()
+	require.True(t, okA)
+	require.False(t, okB)
+	require.True(t, okC)
+
+	stats := c.Stats()
+	require.Equal(t, uint64(1), stats[0].Evictions)
+	require.Equal(t, 2, stats[0].MaxEntries)
+	typ.AssertNumberOfCalls(t, "Fetch", 3)
+}
@@ -240,6 +240,9 @@ func (s *HTTPHandlers) CatalogNodes(resp http.ResponseWriter, req *http.Request)
 			s.nodeMetricsLabels())
 		return nil, nil
 	}
+	if err := parsePagination(req, &args.QueryOptions); err != nil {
+		return nil, err
+	}
 
 	var out structs.IndexedNodes
 	defer setMeta(resp, &out.QueryMeta)
@@ -262,7 +265,7 @@ RETRY_ONCE:
 	}
 	metrics.IncrCounterWithLabels([]string{"client", "api", "success", "catalog_nodes"}, 1,
 		s.nodeMetricsLabels())
-	return out.Nodes, nil
+	return selectFields(req, out.Nodes)
 }
 
 func (s *HTTPHandlers) CatalogServices(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
@@ -278,6 +281,9 @@ func (s *HTTPHandlers) CatalogServices(resp http.ResponseWriter, req *http.Reque
 	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
 		return nil, nil
 	}
+	if err := parsePagination(req, &args.QueryOptions); err != nil {
+		return nil, err
+	}
 	var out structs.IndexedServices
 	defer setMeta(resp, &out.QueryMeta)
 
@@ -415,7 +421,7 @@ func (s *HTTPHandlers) catalogServiceNodes(resp http.ResponseWriter, req *http.R
 	}
 	metrics.IncrCounterWithLabels([]string{"client", "api", "success", "catalog_service_nodes"}, 1,
 		s.nodeMetricsLabels())
-	return out.ServiceNodes, nil
+	return selectFields(req, out.ServiceNodes)
 }
 
 func (s *HTTPHandlers) CatalogNodeServices(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
@@ -476,7 +482,7 @@ RETRY_ONCE:
 	}
 	metrics.IncrCounterWithLabels([]string{"client", "api", "success", "catalog_node_services"}, 1,
 		s.nodeMetricsLabels())
-	return out.NodeServices, nil
+	return selectFields(req, out.NodeServices)
 }
 
 func (s *HTTPHandlers) CatalogNodeServiceList(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
@@ -524,7 +530,7 @@ RETRY_ONCE:
 	}
 	metrics.IncrCounterWithLabels([]string{"client", "api", "success", "catalog_node_service_list"}, 1,
 		s.nodeMetricsLabels())
-	return &out.NodeServices, nil
+	return selectFields(req, &out.NodeServices)
 }
 
 func (s *HTTPHandlers) CatalogGatewayServices(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
@@ -178,6 +178,50 @@ func TestCatalogNodes_MetaFilter(t *testing.T) {
 	}
 }
 
+func TestCatalogNodes_SegmentFilter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// Register a node in a network segment
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		NodeMeta: map[string]string{
+			structs.MetaSegmentKey: "alpha",
+		},
+	}
+
+	var out struct{}
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+
+	// Only the node in the requested segment is returned
+	req, _ := http.NewRequest("GET", "/v1/catalog/nodes?segment=alpha", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.CatalogNodes(resp, req)
+	require.NoError(t, err)
+
+	nodes := obj.(structs.Nodes)
+	require.Len(t, nodes, 1)
+	require.Equal(t, "foo", nodes[0].Node)
+
+	// An empty segment selects the nodes in the default segment
+	req, _ = http.NewRequest("GET", "/v1/catalog/nodes?segment=", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.CatalogNodes(resp, req)
+	require.NoError(t, err)
+
+	nodes = obj.(structs.Nodes)
+	require.Len(t, nodes, 1)
+	require.Equal(t, a.Config.NodeName, nodes[0].Node)
+}
+
 func TestCatalogNodes_Filter(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// checkTemplateData is the data available to the templates of the checks
+// bound to a service, such as
+//
+//	http = "http://{{ .Address }}:{{ .Port }}/health"
+type checkTemplateData struct {
+	// ID and Name are the ID and name of the service.
+	ID   string
+	Name string
+
+	// Address is the address of the service, or the LAN address of the agent
+	// when the service doesn't have one.
+	Address string
+	Port    int
+
+	Tags            []string
+	Meta            map[string]string
+	TaggedAddresses map[string]structs.ServiceAddress
+
+	// NodeName is the name of the agent's node.
+	NodeName string
+}
+
+func (a *Agent) checkTemplateData(service *structs.NodeService) *checkTemplateData {
+	addr := service.Address
+	if addr == "" && a.config.AdvertiseAddrLAN != nil {
+		addr = a.config.AdvertiseAddrLAN.String()
+	}
+	return &checkTemplateData{
+		ID:              service.ID,
+		Name:            service.Service,
+		Address:         addr,
+		Port:            service.Port,
+		Tags:            service.Tags,
+		Meta:            service.Meta,
+		TaggedAddresses: service.TaggedAddresses,
+		NodeName:        a.config.NodeName,
+	}
+}
+
+// renderServiceCheckTemplates returns the checks of service with their
+// templates evaluated. The checks which aren't templated are returned as is,
+// the others are copied so that the definitions given by the caller are left
+// untouched.
+func (a *Agent) renderServiceCheckTemplates(service *structs.NodeService, chkTypes []*structs.CheckType) ([]*structs.CheckType, error) {
+	if len(chkTypes) == 0 {
+		return chkTypes, nil
+	}
+
+	data := a.checkTemplateData(service)
+	out := make([]*structs.CheckType, 0, len(chkTypes))
+	for _, chkType := range chkTypes {
+		rendered, err := renderCheckTemplates(chkType, data)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rendered)
+	}
+	return out, nil
+}
+
+// renderCheckTemplates evaluates the templates found in the fields of
+// chkType describing the check target when it is templated. The copy returned
+// isn't templated anymore, so that rendered values containing template
+// delimiters, like service metadata, aren't evaluated again when the check is
+// persisted and restored.
+func renderCheckTemplates(chkType *structs.CheckType, data *checkTemplateData) (*structs.CheckType, error) {
+	if chkType == nil || !chkType.Templated {
+		return chkType, nil
+	}
+
+	out := *chkType
+	out.Templated = false
+	var err error
+	render := func(field string, text *string) {
+		if err != nil {
+			return
+		}
+		*text, err = renderCheckTemplate(field, *text, data)
+	}
+
+	render("Name", &out.Name)
+	render("Notes", &out.Notes)
+	render("HTTP", &out.HTTP)
+	render("Body", &out.Body)
+	render("TCP", &out.TCP)
+	render("UDP", &out.UDP)
+	render("H2PING", &out.H2PING)
+	render("GRPC", &out.GRPC)
+	render("TLSServerName", &out.TLSServerName)
+
+	if chkType.ScriptArgs != nil {
+		out.ScriptArgs = make([]string, len(chkType.ScriptArgs))
+		copy(out.ScriptArgs, chkType.ScriptArgs)
+		for i := range out.ScriptArgs {
+			render(fmt.Sprintf("Args[%d]", i), &out.ScriptArgs[i])
+		}
+	}
+	if chkType.Header != nil {
+		out.Header = make(map[string][]string, len(chkType.Header))
+		for k, vs := range chkType.Header {
+			out.Header[k] = make([]string, len(vs))
+			copy(out.Header[k], vs)
+			for i := range out.Header[k] {
+				render(fmt.Sprintf("Header[%q]", k), &out.Header[k][i])
+			}
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func renderCheckTemplate(field, text string, data *checkTemplateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New(field).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("Check %s is not a valid template: %v", field, err)
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("Check %s could not be rendered: %v", field, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,152 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+)
+
+func TestRenderCheckTemplates(t *testing.T) {
+	data := &checkTemplateData{
+		ID:       "web-1",
+		Name:     "web",
+		Address:  "10.0.0.1",
+		Port:     8080,
+		Meta:     map[string]string{"health_path": "/healthz"},
+		NodeName: "node1",
+	}
+
+	t.Run("not templated", func(t *testing.T) {
+		// Checks which don't opt in are left as is, even with delimiters.
+		chkType := &structs.CheckType{HTTP: "http://localhost:8080/health?q={{x}}", Interval: time.Second}
+		out, err := renderCheckTemplates(chkType, data)
+		require.NoError(t, err)
+		require.Same(t, chkType, out)
+	})
+
+	t.Run("rendered once", func(t *testing.T) {
+		data := *data
+		data.Meta = map[string]string{"health_path": "/{{ .Port }}"}
+		chkType := &structs.CheckType{HTTP: "http://{{ .Address }}{{ .Meta.health_path }}", Interval: time.Second, Templated: true}
+		out, err := renderCheckTemplates(chkType, &data)
+		require.NoError(t, err)
+		require.Equal(t, "http://10.0.0.1/{{ .Port }}", out.HTTP)
+		require.False(t, out.Templated)
+
+		// The rendered check, as persisted, isn't rendered again.
+		again, err := renderCheckTemplates(out, &data)
+		require.NoError(t, err)
+		require.Same(t, out, again)
+	})
+
+	t.Run("templates", func(t *testing.T) {
+		chkType := &structs.CheckType{
+			Name:       "{{ .Name }} on {{ .NodeName }}",
+			HTTP:       "http://{{ .Address }}:{{ .Port }}{{ .Meta.health_path }}",
+			Header:     map[string][]string{"X-Instance": {"{{ .ID }}"}},
+			ScriptArgs: []string{"/bin/check", "-port", "{{ .Port }}"},
+			Interval:   time.Second,
+			Templated:  true,
+		}
+		out, err := renderCheckTemplates(chkType, data)
+		require.NoError(t, err)
+		require.Equal(t, "web on node1", out.Name)
+		require.Equal(t, "http://10.0.0.1:8080/healthz", out.HTTP)
+		require.Equal(t, []string{"web-1"}, out.Header["X-Instance"])
+		require.Equal(t, []string{"/bin/check", "-port", "8080"}, out.ScriptArgs)
+		require.Equal(t, time.Second, out.Interval)
+
+		// The definition is left untouched so it can be rendered again.
+		require.Equal(t, "http://{{ .Address }}:{{ .Port }}{{ .Meta.health_path }}", chkType.HTTP)
+		require.Equal(t, []string{"{{ .ID }}"}, chkType.Header["X-Instance"])
+		require.Equal(t, "{{ .Port }}", chkType.ScriptArgs[2])
+	})
+
+	t.Run("missing meta key", func(t *testing.T) {
+		chkType := &structs.CheckType{TCP: "{{ .Address }}:{{ .Meta.port }}", Interval: time.Second, Templated: true}
+		_, err := renderCheckTemplates(chkType, data)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Check TCP could not be rendered")
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		chkType := &structs.CheckType{GRPC: "{{ .Address ", Interval: time.Second, Templated: true}
+		_, err := renderCheckTemplates(chkType, data)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Check GRPC is not a valid template")
+	})
+}
+
+func TestAgent_AddService_CheckTemplates(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	chkType := &structs.CheckType{
+		HTTP:      "http://{{ .Address }}:{{ .Port }}/health",
+		Interval:  time.Minute,
+		Templated: true,
+	}
+	for id, port := range map[string]int{"web-1": 8081, "web-2": 8082} {
+		srv := &structs.NodeService{
+			ID:      id,
+			Service: "web",
+			Address: "127.0.0.2",
+			Port:    port,
+		}
+		require.NoError(t, a.addServiceFromSource(srv, []*structs.CheckType{chkType}, false, "", ConfigSourceLocal))
+	}
+
+	require.Equal(t, "http://127.0.0.2:8081/health", a.checkHTTPs[structs.NewCheckID("service:web-1", nil)].HTTP)
+	require.Equal(t, "http://127.0.0.2:8082/health", a.checkHTTPs[structs.NewCheckID("service:web-2", nil)].HTTP)
+
+	// Checks registered on their own are rendered with the service they are
+	// bound to.
+	check := &structs.HealthCheck{
+		Node:      a.Config.NodeName,
+		CheckID:   "web-1-tcp",
+		Name:      "TCP on {{ .Port }}",
+		ServiceID: "web-1",
+	}
+	tcp := &structs.CheckType{TCP: "{{ .Address }}:{{ .Port }}", Interval: time.Minute, Templated: true}
+	require.NoError(t, a.AddCheck(check, tcp, false, "", ConfigSourceLocal))
+	require.Equal(t, "127.0.0.2:8081", a.checkTCPs[structs.NewCheckID("web-1-tcp", nil)].TCP)
+	require.Equal(t, "TCP on 8081", a.State.Check(structs.NewCheckID("web-1-tcp", nil)).Name)
+}
+
+func TestAgent_AddCheck_NotTemplated(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	srv := &structs.NodeService{ID: "web-1", Service: "web", Port: 8081}
+	require.NoError(t, a.addServiceFromSource(srv, nil, false, "", ConfigSourceLocal))
+
+	// Checks which don't opt into templating keep their delimiters.
+	check := &structs.HealthCheck{
+		Node:      a.Config.NodeName,
+		CheckID:   "web-1-http",
+		Name:      "{{ literal }}",
+		ServiceID: "web-1",
+	}
+	chkType := &structs.CheckType{HTTP: "http://127.0.0.1:8081/health?q={{x}}", Interval: time.Minute}
+	require.NoError(t, a.AddCheck(check, chkType, false, "", ConfigSourceLocal))
+	require.Equal(t, "http://127.0.0.1:8081/health?q={{x}}", a.checkHTTPs[structs.NewCheckID("web-1-http", nil)].HTTP)
+	require.Equal(t, "{{ literal }}", a.State.Check(structs.NewCheckID("web-1-http", nil)).Name)
+}
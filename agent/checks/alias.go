@@ -17,6 +17,15 @@ const (
 	checkAliasBackoffMaxWait = 1 * time.Minute // maximum backoff wait time
 )
 
+// checkAliasMaxDepth is the maximum number of alias checks followed when
+// looking for a loop.
+const checkAliasMaxDepth = 8
+
+// checkAliasRemoteChecksTTL is how long the checks of the other nodes followed
+// when looking for a loop are reused, so that they aren't queried again on
+// every change of the aliased node or service.
+const checkAliasRemoteChecksTTL = 1 * time.Minute
+
 // CheckAlias is a check type that aliases the health of another service
 // instance or node. If the service aliased has any critical health checks, then
 // this check is critical. If the service has no critical but warnings,
@@ -31,6 +40,26 @@ type CheckAlias struct {
 	RPCReq  structs.NodeSpecificRequest // Base request
 	Notify  AliasNotifier               // For updating the check state
 
+	// LocalNode and LocalServiceID are the node and service this check is
+	// registered on. They are used to detect loops of alias checks, where
+	// the aliased node or service depends back on this check.
+	LocalNode      string
+	LocalServiceID structs.ServiceID
+
+	// PropagationDelay is how long the health of the aliased node or service
+	// must remain the same before this check reflects it. Changes are
+	// propagated immediately when zero.
+	PropagationDelay time.Duration
+
+	statusLock    sync.Mutex
+	status        string      // last status propagated
+	pendingTimer  *time.Timer // pending propagation of a new status
+	pendingSerial uint64      // identifies the pending propagation
+
+	// remoteChecks caches the checks of the other nodes followed when looking
+	// for a loop. It is only used by the goroutine running the check.
+	remoteChecks map[string]cachedNodeChecks
+
 	stop     bool
 	stopCh   chan struct{}
 	stopLock sync.Mutex
@@ -69,6 +98,14 @@ func (c *CheckAlias) Stop() {
 	}
 	c.stopLock.Unlock()
 
+	c.statusLock.Lock()
+	if c.pendingTimer != nil {
+		c.pendingTimer.Stop()
+		c.pendingTimer = nil
+	}
+	c.pendingSerial++
+	c.statusLock.Unlock()
+
 	// Wait until the associated goroutine is definitely complete before
 	// returning to the caller. This is to prevent the new and old checks from
 	// both updating the state of the alias check using possibly stale
@@ -117,6 +154,11 @@ func (c *CheckAlias) runLocal(stopCh chan struct{}) {
 		}
 		c.processChecks(checksList, func(serviceID *structs.ServiceID) bool {
 			return c.Notify.ServiceExists(*serviceID)
+		}, func(node string) ([]*structs.HealthCheck, error) {
+			if strings.EqualFold(node, c.LocalNode) {
+				return checksList, nil
+			}
+			return c.remoteNodeChecks(node)
 		})
 		extendRefreshTimer()
 	}
@@ -210,7 +252,7 @@ func (c *CheckAlias) runQuery(stopCh chan struct{}) {
 		if err := c.RPC.RPC("Health.NodeChecks", &args, &out); err != nil {
 			attempt++
 			if attempt > 1 {
-				c.Notify.UpdateCheck(c.CheckID, api.HealthCritical,
+				c.updateCheck(api.HealthCritical,
 					fmt.Sprintf("Failure checking aliased node or service: %s", err))
 			}
 
@@ -236,14 +278,57 @@ func (c *CheckAlias) runQuery(stopCh chan struct{}) {
 				return true
 			}
 			return ret
+		}, func(node string) ([]*structs.HealthCheck, error) {
+			if strings.EqualFold(node, c.Node) {
+				return out.HealthChecks, nil
+			}
+			return c.remoteNodeChecks(node)
 		})
 	}
 }
 
+// cachedNodeChecks are the checks of a node fetched from the servers.
+type cachedNodeChecks struct {
+	checks    []*structs.HealthCheck
+	fetchedAt time.Time
+}
+
+// remoteNodeChecks returns the checks of a node, as known by the servers. They
+// are cached for checkAliasRemoteChecksTTL.
+func (c *CheckAlias) remoteNodeChecks(node string) ([]*structs.HealthCheck, error) {
+	key := strings.ToLower(node)
+	if cached, ok := c.remoteChecks[key]; ok && time.Since(cached.fetchedAt) < checkAliasRemoteChecksTTL {
+		return cached.checks, nil
+	}
+
+	args := c.RPCReq
+	args.Node = node
+	args.AllowStale = true
+	args.EnterpriseMeta = c.EnterpriseMeta
+	args.MaxStaleDuration = 15 * time.Second
+
+	var out structs.IndexedHealthChecks
+	if err := c.RPC.RPC("Health.NodeChecks", &args, &out); err != nil {
+		return nil, err
+	}
+
+	if c.remoteChecks == nil {
+		c.remoteChecks = make(map[string]cachedNodeChecks)
+	}
+	c.remoteChecks[key] = cachedNodeChecks{checks: out.HealthChecks, fetchedAt: time.Now()}
+	return out.HealthChecks, nil
+}
+
 // processChecks is a common helper for taking a set of health checks and
 // using them to update our alias. This is abstracted since the checks can
-// come from both the remote server as well as local state.
-func (c *CheckAlias) processChecks(checks []*structs.HealthCheck, CheckIfServiceIDExists CheckIfServiceIDExists) {
+// come from both the remote server as well as local state. nodeChecks returns
+// the checks of the other nodes involved in chains of alias checks.
+func (c *CheckAlias) processChecks(checks []*structs.HealthCheck, CheckIfServiceIDExists CheckIfServiceIDExists, nodeChecks func(node string) ([]*structs.HealthCheck, error)) {
+	if loop := c.findLoop(checks, nodeChecks); loop != "" {
+		c.updateCheck(api.HealthCritical, fmt.Sprintf("Alias loop detected: %s", loop))
+		return
+	}
+
 	health := api.HealthPassing
 	msg := "No checks found."
 	serviceFound := false
@@ -251,6 +336,9 @@ func (c *CheckAlias) processChecks(checks []*structs.HealthCheck, CheckIfService
 		if c.Node != "" && !strings.EqualFold(c.Node, chk.Node) {
 			continue
 		}
+		if c.isSelf(chk) {
+			continue
+		}
 		serviceMatch := c.ServiceID.Matches(chk.CompoundServiceID())
 		if chk.ServiceID != "" && !serviceMatch {
 			continue
@@ -282,5 +370,134 @@ func (c *CheckAlias) processChecks(checks []*structs.HealthCheck, CheckIfService
 			health = api.HealthCritical
 		}
 	}
-	c.Notify.UpdateCheck(c.CheckID, health, msg)
+	c.updateCheck(health, msg)
+}
+
+// updateCheck propagates the status of the aliased node or service, once it
+// remained the same for PropagationDelay.
+func (c *CheckAlias) updateCheck(status, output string) {
+	c.statusLock.Lock()
+	defer c.statusLock.Unlock()
+
+	if c.pendingTimer != nil {
+		c.pendingTimer.Stop()
+		c.pendingTimer = nil
+	}
+	c.pendingSerial++
+
+	// The first status is propagated right away so that the check doesn't
+	// remain in its initial state for the delay.
+	if c.PropagationDelay <= 0 || c.status == "" || c.status == status {
+		c.status = status
+		c.Notify.UpdateCheck(c.CheckID, status, output)
+		return
+	}
+
+	serial := c.pendingSerial
+	c.pendingTimer = time.AfterFunc(c.PropagationDelay, func() {
+		c.statusLock.Lock()
+		defer c.statusLock.Unlock()
+
+		// A newer status superseded this one while the timer fired.
+		if serial != c.pendingSerial {
+			return
+		}
+		c.pendingTimer = nil
+		c.status = status
+		c.Notify.UpdateCheck(c.CheckID, status, output)
+	})
+}
+
+// aliasTarget is a node or service aliased by an alias check.
+type aliasTarget struct {
+	node      string
+	serviceID structs.ServiceID
+}
+
+func (t aliasTarget) String() string {
+	if t.serviceID.ID == "" {
+		return fmt.Sprintf("node %q", t.node)
+	}
+	return fmt.Sprintf("service %q on node %q", t.serviceID.ID, t.node)
+}
+
+// affects returns whether the health of the target depends on the checks
+// registered on node for serviceID. Like for the alias checks, the health of a
+// service depends on the checks of the service and of its node, and the health
+// of a node on its node checks.
+func (t aliasTarget) affects(node string, serviceID structs.ServiceID) bool {
+	if !strings.EqualFold(t.node, node) {
+		return false
+	}
+	return serviceID.ID == "" || t.serviceID.Matches(serviceID)
+}
+
+// isSelf returns whether chk is this check.
+func (c *CheckAlias) isSelf(chk *structs.HealthCheck) bool {
+	return c.LocalNode != "" && strings.EqualFold(chk.Node, c.LocalNode) &&
+		c.CheckID.ID == chk.CheckID && c.CheckID.EnterpriseMeta.IsSame(&chk.EnterpriseMeta)
+}
+
+// findLoop follows the alias checks the aliased node or service depends on,
+// and the ones their own targets depend on in turn, and returns a description
+// of the chain leading back to this check if there is one. checks are the
+// checks of the node aliased.
+func (c *CheckAlias) findLoop(checks []*structs.HealthCheck, nodeChecks func(node string) ([]*structs.HealthCheck, error)) string {
+	if c.LocalNode == "" {
+		return ""
+	}
+
+	node := c.Node
+	if node == "" {
+		node = c.LocalNode
+	}
+	start := aliasTarget{node: node, serviceID: c.ServiceID}
+
+	cache := map[string][]*structs.HealthCheck{strings.ToLower(node): checks}
+	visited := map[aliasTarget]bool{}
+
+	var walk func(target aliasTarget, path []string) string
+	walk = func(target aliasTarget, path []string) string {
+		if len(path) > checkAliasMaxDepth || visited[target] {
+			return ""
+		}
+		visited[target] = true
+
+		key := strings.ToLower(target.node)
+		targetChecks, ok := cache[key]
+		if !ok {
+			var err error
+			if targetChecks, err = nodeChecks(target.node); err != nil {
+				// Don't report a loop that can't be confirmed.
+				return ""
+			}
+			cache[key] = targetChecks
+		}
+
+		for _, chk := range targetChecks {
+			if chk.Definition.AliasNode == "" && chk.Definition.AliasService == "" {
+				continue
+			}
+			if !target.affects(chk.Node, chk.CompoundServiceID()) || c.isSelf(chk) {
+				continue
+			}
+
+			next := aliasTarget{
+				node:      chk.Definition.AliasNode,
+				serviceID: structs.NewServiceID(chk.Definition.AliasService, &chk.EnterpriseMeta),
+			}
+			if next.node == "" {
+				next.node = chk.Node
+			}
+			nextPath := append(path[:len(path):len(path)], next.String())
+			if next.affects(c.LocalNode, c.LocalServiceID) {
+				return strings.Join(nextPath, " -> ")
+			}
+			if loop := walk(next, nextPath); loop != "" {
+				return loop
+			}
+		}
+		return ""
+	}
+	return walk(start, []string{start.String()})
 }
@@ -3,6 +3,7 @@ package checks
 import (
 	"fmt"
 	"reflect"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -634,3 +635,199 @@ func TestCheckAlias_localInitialStatusShouldFailBecauseNoService(t *testing.T) {
 		}
 	})
 }
+
+// nodeChecksRPC answers Health.NodeChecks with the checks of the node queried.
+type nodeChecksRPC map[string][]*structs.HealthCheck
+
+func (m nodeChecksRPC) RPC(method string, args interface{}, reply interface{}) error {
+	// We don't adhere to blocking queries, so this helps prevent
+	// too much CPU usage on the check loop.
+	time.Sleep(10 * time.Millisecond)
+
+	switch method {
+	case "Health.NodeChecks":
+		req := args.(*structs.NodeSpecificRequest)
+		reply.(*structs.IndexedHealthChecks).HealthChecks = m[req.Node]
+		return nil
+	case "Catalog.NodeServices":
+		return nil
+	}
+	return fmt.Errorf("No Such Method: %s", method)
+}
+
+func TestCheckAlias_remoteLoop(t *testing.T) {
+	t.Parallel()
+
+	run := func(t *testing.T, rpc nodeChecksRPC, wantStatus, wantOutput string) {
+		notify := newMockAliasNotify()
+		chkID := structs.NewCheckID(types.CheckID("web-a-alias"), nil)
+		chk := &CheckAlias{
+			Node:           "b",
+			ServiceID:      structs.ServiceID{ID: "web-b"},
+			CheckID:        chkID,
+			Notify:         notify,
+			RPC:            rpc,
+			LocalNode:      "a",
+			LocalServiceID: structs.ServiceID{ID: "web-a"},
+		}
+
+		chk.Start()
+		defer chk.Stop()
+
+		retry.Run(t, func(r *retry.R) {
+			if got := notify.State(chkID); got != wantStatus {
+				r.Fatalf("got state %q want %q", got, wantStatus)
+			}
+			if got := notify.Output(chkID); got != wantOutput {
+				r.Fatalf("got output %q want %q", got, wantOutput)
+			}
+		})
+	}
+
+	t.Run("loop", func(t *testing.T) {
+		rpc := nodeChecksRPC{
+			"b": {{
+				Node:       "b",
+				CheckID:    "web-b-alias",
+				ServiceID:  "web-b",
+				Status:     api.HealthPassing,
+				Definition: structs.HealthCheckDefinition{AliasNode: "c", AliasService: "web-c"},
+			}},
+			"c": {{
+				Node:       "c",
+				CheckID:    "web-c-alias",
+				ServiceID:  "web-c",
+				Status:     api.HealthPassing,
+				Definition: structs.HealthCheckDefinition{AliasNode: "a", AliasService: "web-a"},
+			}},
+		}
+		run(t, rpc, api.HealthCritical,
+			`Alias loop detected: service "web-b" on node "b" -> service "web-c" on node "c" -> service "web-a" on node "a"`)
+	})
+
+	t.Run("chain", func(t *testing.T) {
+		rpc := nodeChecksRPC{
+			"b": {{
+				Node:       "b",
+				CheckID:    "web-b-alias",
+				ServiceID:  "web-b",
+				Status:     api.HealthPassing,
+				Definition: structs.HealthCheckDefinition{AliasNode: "c", AliasService: "web-c"},
+			}},
+			"c": {{
+				Node:      "c",
+				CheckID:   "web-c-http",
+				ServiceID: "web-c",
+				Status:    api.HealthPassing,
+			}},
+		}
+		run(t, rpc, api.HealthPassing, "All checks passing.")
+	})
+}
+
+// countingRPC counts the Health.NodeChecks queries of each node.
+type countingRPC struct {
+	rpc RPC
+
+	lock  sync.Mutex
+	calls map[string]int
+}
+
+func (c *countingRPC) RPC(method string, args interface{}, reply interface{}) error {
+	if method == "Health.NodeChecks" {
+		c.lock.Lock()
+		c.calls[args.(*structs.NodeSpecificRequest).Node]++
+		c.lock.Unlock()
+	}
+	return c.rpc.RPC(method, args, reply)
+}
+
+func (c *countingRPC) Calls(node string) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.calls[node]
+}
+
+func TestCheckAlias_remoteLoopCache(t *testing.T) {
+	t.Parallel()
+
+	rpc := &countingRPC{
+		rpc: nodeChecksRPC{
+			"b": {{
+				Node:       "b",
+				CheckID:    "web-b-alias",
+				ServiceID:  "web-b",
+				Status:     api.HealthPassing,
+				Definition: structs.HealthCheckDefinition{AliasNode: "c", AliasService: "web-c"},
+			}},
+			"c": {{
+				Node:      "c",
+				CheckID:   "web-c-http",
+				ServiceID: "web-c",
+				Status:    api.HealthPassing,
+			}},
+		},
+		calls: make(map[string]int),
+	}
+	notify := newMockAliasNotify()
+	chkID := structs.NewCheckID(types.CheckID("web-a-alias"), nil)
+	chk := &CheckAlias{
+		Node:           "b",
+		ServiceID:      structs.ServiceID{ID: "web-b"},
+		CheckID:        chkID,
+		Notify:         notify,
+		RPC:            rpc,
+		LocalNode:      "a",
+		LocalServiceID: structs.ServiceID{ID: "web-a"},
+	}
+	chk.Start()
+	defer chk.Stop()
+
+	// The fake RPC doesn't block, so node b is queried over and over, while
+	// the checks of node c followed when looking for a loop are reused.
+	retry.Run(t, func(r *retry.R) {
+		if got := rpc.Calls("b"); got < 5 {
+			r.Fatalf("got %d queries of node b", got)
+		}
+	})
+	if got := rpc.Calls("c"); got != 1 {
+		t.Fatalf("got %d queries of node c, want 1", got)
+	}
+}
+
+func TestCheckAlias_propagationDelay(t *testing.T) {
+	t.Parallel()
+
+	notify := newMockAliasNotify()
+	chkID := structs.NewCheckID(types.CheckID("foo"), nil)
+	chk := &CheckAlias{
+		ServiceID:        structs.ServiceID{ID: "web"},
+		CheckID:          chkID,
+		Notify:           notify,
+		PropagationDelay: 100 * time.Millisecond,
+	}
+
+	// The first status is propagated right away.
+	chk.updateCheck(api.HealthPassing, "All checks passing.")
+	if got, want := notify.State(chkID), api.HealthPassing; got != want {
+		t.Fatalf("got state %q want %q", got, want)
+	}
+
+	// A failure that recovers within the delay isn't propagated.
+	chk.updateCheck(api.HealthCritical, "failing")
+	chk.updateCheck(api.HealthPassing, "All checks passing.")
+	time.Sleep(200 * time.Millisecond)
+	if got, want := notify.State(chkID), api.HealthPassing; got != want {
+		t.Fatalf("got state %q want %q", got, want)
+	}
+
+	chk.updateCheck(api.HealthCritical, "failing")
+	if got, want := notify.State(chkID), api.HealthPassing; got != want {
+		t.Fatalf("got state %q want %q", got, want)
+	}
+	retry.Run(t, func(r *retry.R) {
+		if got, want := notify.State(chkID), api.HealthCritical; got != want {
+			r.Fatalf("got state %q want %q", got, want)
+		}
+	})
+}
@@ -239,8 +239,21 @@ type CheckTTL struct {
 	TTL       time.Duration
 	Logger    hclog.Logger
 
+	// Expired, if set, is called every time the TTL elapses without the check
+	// being updated, with the number of consecutive expirations. The TTL is
+	// restarted after each expiration so that the count keeps increasing
+	// until the check is updated.
+	Expired func(expirations int)
+
+	// Renewed, if set, is called when the check is updated after its TTL
+	// expired.
+	Renewed func()
+
 	timer *time.Timer
 
+	expirations     int
+	expirationsLock sync.Mutex
+
 	lastOutput     string
 	lastOutputLock sync.RWMutex
 
@@ -285,6 +298,16 @@ func (c *CheckTTL) run() {
 			)
 			c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, c.getExpiredOutput())
 
+			if c.Expired != nil {
+				c.expirationsLock.Lock()
+				c.expirations++
+				expirations := c.expirations
+				c.expirationsLock.Unlock()
+
+				c.timer.Reset(c.TTL)
+				c.Expired(expirations)
+			}
+
 		case <-c.stopCh:
 			return
 		}
@@ -324,6 +347,14 @@ func (c *CheckTTL) SetStatus(status, output string) string {
 	c.lastOutputLock.Unlock()
 
 	c.timer.Reset(c.TTL)
+
+	c.expirationsLock.Lock()
+	expired := c.expirations > 0
+	c.expirations = 0
+	c.expirationsLock.Unlock()
+	if expired && c.Renewed != nil {
+		c.Renewed()
+	}
 	return output
 }
 
@@ -276,6 +276,66 @@ func TestCheckTTL(t *testing.T) {
 	}
 }
 
+func TestCheckTTL_Expired(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	notif := mock.NewNotify()
+	cid := structs.NewCheckID("foo", nil)
+
+	var lock sync.Mutex
+	var expirations []int
+	renewed := 0
+	check := &CheckTTL{
+		Notify:  notif,
+		CheckID: cid,
+		TTL:     50 * time.Millisecond,
+		Logger:  testutil.Logger(t),
+		Expired: func(n int) {
+			lock.Lock()
+			defer lock.Unlock()
+			expirations = append(expirations, n)
+		},
+		Renewed: func() {
+			lock.Lock()
+			defer lock.Unlock()
+			renewed++
+		},
+	}
+	check.Start()
+	defer check.Stop()
+
+	// The TTL keeps expiring until the check is updated.
+	retry.Run(t, func(r *retry.R) {
+		lock.Lock()
+		defer lock.Unlock()
+		if len(expirations) < 3 {
+			r.Fatalf("got %d expirations want at least 3", len(expirations))
+		}
+		require.Equal(r, []int{1, 2, 3}, expirations[:3])
+	})
+	require.Equal(t, api.HealthCritical, notif.State(cid))
+
+	check.SetStatus(api.HealthPassing, "ok")
+	lock.Lock()
+	require.Equal(t, 1, renewed)
+	expirations = nil
+	lock.Unlock()
+
+	// The count starts over after an update.
+	retry.Run(t, func(r *retry.R) {
+		lock.Lock()
+		defer lock.Unlock()
+		if len(expirations) < 1 {
+			r.Fatalf("got no expiration")
+		}
+		require.Equal(r, 1, expirations[0])
+	})
+}
+
 func TestCheckHTTP(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -23,15 +23,18 @@ import (
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/go-sockaddr/template"
+	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/memberlist"
 	"golang.org/x/time/rate"
 
 	"github.com/hashicorp/consul/agent/cache"
 	"github.com/hashicorp/consul/agent/checks"
+	"github.com/hashicorp/consul/agent/configentry"
 	"github.com/hashicorp/consul/agent/connect/ca"
 	"github.com/hashicorp/consul/agent/consul"
 	"github.com/hashicorp/consul/agent/consul/authmethod/ssoauth"
 	"github.com/hashicorp/consul/agent/dns"
+	"github.com/hashicorp/consul/agent/router"
 	"github.com/hashicorp/consul/agent/rpc/middleware"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/agent/token"
@@ -302,6 +305,68 @@ func formatFromFileExtension(name string) string {
 	}
 }
 
+// configEntriesFromDir decodes the config entries in the .hcl and .json files
+// of dir, one entry per file, in the order of their names.
+func (b *builder) configEntriesFromDir(dir string) ([]structs.ConfigEntry, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("config_entries.bootstrap_dir: %s", err)
+	}
+
+	var entries []structs.ConfigEntry
+	for _, fi := range fis {
+		fp := filepath.Join(dir, fi.Name())
+		// check for a symlink and resolve the path
+		if fi.Mode()&os.ModeSymlink > 0 {
+			fp, err = filepath.EvalSymlinks(fp)
+			if err != nil {
+				return nil, fmt.Errorf("config_entries.bootstrap_dir: %s", err)
+			}
+			fi, err = os.Stat(fp)
+			if err != nil {
+				return nil, fmt.Errorf("config_entries.bootstrap_dir: %s", err)
+			}
+		}
+		// do not recurse into sub dirs
+		if fi.IsDir() {
+			continue
+		}
+
+		format := formatFromFileExtension(fp)
+		if format == "" {
+			b.warn("skipping config entry file %v, extension must be .hcl or .json", fp)
+			continue
+		}
+		data, err := ioutil.ReadFile(fp)
+		if err != nil {
+			return nil, fmt.Errorf("config_entries.bootstrap_dir: failed to read %s: %s", fp, err)
+		}
+
+		var raw map[string]interface{}
+		if format == "json" {
+			err = json.Unmarshal(data, &raw)
+		} else {
+			err = hcl.Decode(&raw, string(data))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("config_entries.bootstrap_dir: failed to parse %s: %s", fp, err)
+		}
+
+		entry, err := structs.DecodeConfigEntry(raw)
+		if err != nil {
+			return nil, fmt.Errorf("config_entries.bootstrap_dir: %s: %s", fp, err)
+		}
+		if err := entry.Normalize(); err != nil {
+			return nil, fmt.Errorf("config_entries.bootstrap_dir: %s: %s", fp, err)
+		}
+		if err := entry.Validate(); err != nil {
+			return nil, fmt.Errorf("config_entries.bootstrap_dir: %s: %w", fp, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
 type byName []os.FileInfo
 
 func (a byName) Len() int           { return len(a) }
@@ -590,6 +655,22 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 		}
 	}
 
+	dnsRecursorZones := map[string][]string{}
+	for zone, recursors := range c.DNS.RecursorZones {
+		for _, r := range recursors {
+			x, err := template.Parse(r)
+			if err != nil {
+				return RuntimeConfig{}, fmt.Errorf("Invalid DNS recursor template %q for zone %q: %s", r, zone, err)
+			}
+			for _, addr := range strings.Fields(x) {
+				if strings.HasPrefix(addr, "unix://") {
+					return RuntimeConfig{}, fmt.Errorf("DNS Recursors cannot be unix sockets: %s", addr)
+				}
+				dnsRecursorZones[zone] = append(dnsRecursorZones[zone], addr)
+			}
+		}
+	}
+
 	datacenter := strings.ToLower(stringVal(c.Datacenter))
 	altDomain := stringVal(c.DNSAltDomain)
 
@@ -645,6 +726,7 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 
 	// Parse the metric filters
 	telemetryAllowedPrefixes, telemetryBlockedPrefixes := b.parsePrefixFilter(&c.Telemetry)
+	prometheusAllowedLabels, prometheusBlockedLabels := b.parseLabelFilter(&c.Telemetry)
 
 	// raft performance scaling
 	performanceRaftMultiplier := intVal(c.Performance.RaftMultiplier)
@@ -660,6 +742,8 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 	connectCAProvider := stringVal(c.Connect.CAProvider)
 	connectCAConfig := c.Connect.CAConfig
 	serverlessPluginEnabled := boolVal(c.Connect.EnableServerlessPlugin)
+	meshReadinessCheckEnabled := boolVal(c.Connect.EnableMeshReadinessCheck)
+	meshProbesEnabled := boolVal(c.Connect.EnableMeshProbes)
 
 	// autoEncrypt and autoConfig implicitly turns on connect which is why
 	// they need to be above other settings that rely on connect.
@@ -717,6 +801,7 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 			// Common CA config
 			"leaf_cert_ttl":      "LeafCertTTL",
 			"csr_max_per_second": "CSRMaxPerSecond",
+			"csr_max_burst":      "CSRMaxBurst",
 			"csr_max_concurrent": "CSRMaxConcurrent",
 			"private_key_type":   "PrivateKeyType",
 			"private_key_bits":   "PrivateKeyBits",
@@ -757,6 +842,27 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 		}
 	}
 
+	if dir := stringVal(c.ConfigEntries.BootstrapDir); dir != "" {
+		dirEntries, err := b.configEntriesFromDir(dir)
+		if err != nil {
+			return RuntimeConfig{}, err
+		}
+		// Only the first of several entries with the same kind and name would
+		// be created, so reject them rather than silently dropping some.
+		seen := make(map[configentry.KindName]struct{})
+		for _, entry := range configEntries {
+			seen[configentry.NewKindNameForEntry(entry)] = struct{}{}
+		}
+		for _, entry := range dirEntries {
+			kn := configentry.NewKindNameForEntry(entry)
+			if _, ok := seen[kn]; ok {
+				return RuntimeConfig{}, fmt.Errorf("config_entries.bootstrap_dir: duplicate config entry %q / %q", entry.GetKind(), entry.GetName())
+			}
+			seen[kn] = struct{}{}
+			configEntries = append(configEntries, entry)
+		}
+	}
+
 	serfAllowedCIDRSLAN, err := memberlist.ParseCIDRs(c.SerfAllowedCIDRsLAN)
 	if err != nil {
 		return RuntimeConfig{}, fmt.Errorf("serf_lan_allowed_cidrs: %s", err)
@@ -792,9 +898,39 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 	// build runtime config
 	//
 	dataDir := stringVal(c.DataDir)
+	var cachePersistDir string
+	if boolVal(c.Cache.Persist) && dataDir != "" {
+		cachePersistDir = filepath.Join(dataDir, "cache")
+	}
+	var cacheTypes map[string]cache.TypeOptions
+	for name, t := range c.Cache.Types {
+		if cacheTypes == nil {
+			cacheTypes = make(map[string]cache.TypeOptions)
+		}
+		cacheTypes[name] = cache.TypeOptions{
+			LastGetTTL: b.durationVal(fmt.Sprintf("cache.types[%q].last_get_ttl", name), t.LastGetTTL),
+			MaxEntries: intVal(t.MaxEntries),
+		}
+	}
+	var drainHooks []RuntimeDrainHook
+	for i, hook := range c.GracefulShutdown.DrainHooks {
+		name := fmt.Sprintf("graceful_shutdown.drain_hooks[%d].timeout", i)
+		drainHooks = append(drainHooks, RuntimeDrainHook{
+			Args:    hook.Args,
+			Timeout: b.durationValWithDefault(name, hook.Timeout, 30*time.Second),
+		})
+	}
+
+	agentReadinessCriteria := c.AgentProbes.Readiness
+	if len(agentReadinessCriteria) == 0 {
+		agentReadinessCriteria = []string{ProbeCriterionSerf, ProbeCriterionServers}
+	}
+
+	aeInterval := b.durationValWithDefault("anti_entropy.sync_interval", c.AntiEntropy.SyncInterval, b.durationVal("ae_interval", c.AEInterval))
+
 	rt = RuntimeConfig{
 		// non-user configurable values
-		AEInterval:                 b.durationVal("ae_interval", c.AEInterval),
+		AEInterval:                 aeInterval,
 		CheckDeregisterIntervalMin: b.durationVal("check_deregister_interval_min", c.CheckDeregisterIntervalMin),
 		CheckReapInterval:          b.durationVal("check_reap_interval", c.CheckReapInterval),
 		Revision:                   stringVal(c.Revision),
@@ -814,6 +950,9 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 		ConsulRaftLeaderLeaseTimeout:     consulRaftLeaderLeaseTimeout,
 		ConsulServerHealthInterval:       b.durationVal("consul.server.health_interval", c.Consul.Server.HealthInterval),
 
+		// anti-entropy
+		AEJitter: b.durationValWithDefault("anti_entropy.sync_jitter", c.AntiEntropy.SyncJitter, aeInterval),
+
 		// gossip configuration
 		GossipLANGossipInterval: b.durationVal("gossip_lan..gossip_interval", c.GossipLAN.GossipInterval),
 		GossipLANGossipNodes:    intVal(c.GossipLAN.GossipNodes),
@@ -821,12 +960,19 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 		GossipLANProbeTimeout:   b.durationVal("gossip_lan..probe_timeout", c.GossipLAN.ProbeTimeout),
 		GossipLANSuspicionMult:  intVal(c.GossipLAN.SuspicionMult),
 		GossipLANRetransmitMult: intVal(c.GossipLAN.RetransmitMult),
+		GossipLANTCPOnly:        boolVal(c.GossipLAN.TCPOnly),
 		GossipWANGossipInterval: b.durationVal("gossip_wan..gossip_interval", c.GossipWAN.GossipInterval),
 		GossipWANGossipNodes:    intVal(c.GossipWAN.GossipNodes),
 		GossipWANProbeInterval:  b.durationVal("gossip_wan..probe_interval", c.GossipWAN.ProbeInterval),
 		GossipWANProbeTimeout:   b.durationVal("gossip_wan..probe_timeout", c.GossipWAN.ProbeTimeout),
 		GossipWANSuspicionMult:  intVal(c.GossipWAN.SuspicionMult),
 		GossipWANRetransmitMult: intVal(c.GossipWAN.RetransmitMult),
+		GossipWANTCPOnly:        boolVal(c.GossipWAN.TCPOnly),
+
+		// graceful shutdown
+		GracefulShutdownDrainDelay:         b.durationVal("graceful_shutdown.drain_delay", c.GracefulShutdown.DrainDelay),
+		GracefulShutdownDrainHooks:         drainHooks,
+		GracefulShutdownDeregisterServices: boolVal(c.GracefulShutdown.DeregisterServices),
 
 		// ACL
 		ACLsEnabled: aclsEnabled,
@@ -866,26 +1012,31 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 		AutopilotUpgradeVersionTag:       stringVal(c.Autopilot.UpgradeVersionTag),
 
 		// DNS
-		DNSAddrs:              dnsAddrs,
-		DNSAllowStale:         boolVal(c.DNS.AllowStale),
-		DNSARecordLimit:       intVal(c.DNS.ARecordLimit),
-		DNSDisableCompression: boolVal(c.DNS.DisableCompression),
-		DNSDomain:             stringVal(c.DNSDomain),
-		DNSAltDomain:          altDomain,
-		DNSEnableTruncate:     boolVal(c.DNS.EnableTruncate),
-		DNSMaxStale:           b.durationVal("dns_config.max_stale", c.DNS.MaxStale),
-		DNSNodeTTL:            b.durationVal("dns_config.node_ttl", c.DNS.NodeTTL),
-		DNSOnlyPassing:        boolVal(c.DNS.OnlyPassing),
-		DNSPort:               dnsPort,
-		DNSRecursorStrategy:   b.dnsRecursorStrategyVal(stringVal(c.DNS.RecursorStrategy)),
-		DNSRecursorTimeout:    b.durationVal("recursor_timeout", c.DNS.RecursorTimeout),
-		DNSRecursors:          dnsRecursors,
-		DNSServiceTTL:         dnsServiceTTL,
-		DNSSOA:                soa,
-		DNSUDPAnswerLimit:     intVal(c.DNS.UDPAnswerLimit),
-		DNSNodeMetaTXT:        boolValWithDefault(c.DNS.NodeMetaTXT, true),
-		DNSUseCache:           boolVal(c.DNS.UseCache),
-		DNSCacheMaxAge:        b.durationVal("dns_config.cache_max_age", c.DNS.CacheMaxAge),
+		DNSAddrs:                 dnsAddrs,
+		DNSAllowStale:            boolVal(c.DNS.AllowStale),
+		DNSARecordLimit:          intVal(c.DNS.ARecordLimit),
+		DNSDisableCompression:    boolVal(c.DNS.DisableCompression),
+		DNSDomain:                stringVal(c.DNSDomain),
+		DNSAltDomain:             altDomain,
+		DNSEnableTruncate:        boolVal(c.DNS.EnableTruncate),
+		DNSMaxStale:              b.durationVal("dns_config.max_stale", c.DNS.MaxStale),
+		DNSNodeTTL:               b.durationVal("dns_config.node_ttl", c.DNS.NodeTTL),
+		DNSOnlyPassing:           boolVal(c.DNS.OnlyPassing),
+		DNSOnlyLocalSegment:      boolVal(c.DNS.OnlyLocalSegment),
+		DNSPreferClientSubnet:    boolVal(c.DNS.PreferClientSubnet),
+		DNSPort:                  dnsPort,
+		DNSRecursorStrategy:      b.dnsRecursorStrategyVal(stringVal(c.DNS.RecursorStrategy)),
+		DNSRecursorTimeout:       b.durationVal("recursor_timeout", c.DNS.RecursorTimeout),
+		DNSRecursors:             dnsRecursors,
+		DNSRecursorZones:         dnsRecursorZones,
+		DNSAllowRecursionFrom:    b.cidrsVal("dns_config.allow_recursion_from", c.DNS.AllowRecursionFrom),
+		DNSServiceTTL:            dnsServiceTTL,
+		DNSSOA:                   soa,
+		DNSUDPAnswerLimit:        intVal(c.DNS.UDPAnswerLimit),
+		DNSServiceUDPAnswerLimit: c.DNS.ServiceUDPAnswerLimit,
+		DNSNodeMetaTXT:           boolValWithDefault(c.DNS.NodeMetaTXT, true),
+		DNSUseCache:              boolVal(c.DNS.UseCache),
+		DNSCacheMaxAge:           b.durationVal("dns_config.cache_max_age", c.DNS.CacheMaxAge),
 
 		// HTTP
 		HTTPPort:            httpPort,
@@ -895,6 +1046,7 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 		HTTPBlockEndpoints:  c.HTTPConfig.BlockEndpoints,
 		HTTPMaxHeaderBytes:  intVal(c.HTTPConfig.MaxHeaderBytes),
 		HTTPResponseHeaders: c.HTTPConfig.ResponseHeaders,
+		HTTPUnixSockets:     b.httpUnixSocketsVal(c),
 		AllowWriteHTTPFrom:  b.cidrsVal("allow_write_http_from", c.HTTPConfig.AllowWriteHTTPFrom),
 		HTTPUseCache:        boolValWithDefault(c.HTTPConfig.UseCache, true),
 
@@ -928,15 +1080,22 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 				Expiration: b.durationVal("prometheus_retention_time", c.Telemetry.PrometheusRetentionTime),
 				Name:       stringVal(c.Telemetry.MetricsPrefix),
 			},
+			PrometheusHistograms:       boolVal(c.Telemetry.PrometheusHistograms),
+			PrometheusNativeHistograms: boolVal(c.Telemetry.PrometheusNativeHistograms),
+			PrometheusAllowedLabels:    prometheusAllowedLabels,
+			PrometheusBlockedLabels:    prometheusBlockedLabels,
 		},
 
 		// Agent
+		AgentLivenessCriteria:     c.AgentProbes.Liveness,
+		AgentReadinessCriteria:    agentReadinessCriteria,
 		AdvertiseAddrLAN:          advertiseAddrLAN,
 		AdvertiseAddrWAN:          advertiseAddrWAN,
 		AdvertiseReconnectTimeout: b.durationVal("advertise_reconnect_timeout", c.AdvertiseReconnectTimeout),
 		BindAddr:                  bindAddr,
 		Bootstrap:                 boolVal(c.Bootstrap),
 		BootstrapExpect:           intVal(c.BootstrapExpect),
+		BoundedStaleMaxLag:        intVal(c.BoundedStaleMaxLag),
 		Cache: cache.Options{
 			EntryFetchRate: rate.Limit(
 				float64ValWithDefault(c.Cache.EntryFetchRate, float64(cache.DefaultEntryFetchRate)),
@@ -944,9 +1103,16 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 			EntryFetchMaxBurst: intValWithDefault(
 				c.Cache.EntryFetchMaxBurst, cache.DefaultEntryFetchMaxBurst,
 			),
+			PersistDir: cachePersistDir,
+			PersistKey: stringVal(c.Cache.PersistKey),
+			Types:      cacheTypes,
 		},
 		AutoReloadConfig:                       boolVal(c.AutoReloadConfig),
+		CheckSyncFlushInterval:                 b.durationVal("check_sync_flush_interval", c.CheckSyncFlushInterval),
 		CheckUpdateInterval:                    b.durationVal("check_update_interval", c.CheckUpdateInterval),
+		CheckUpdateTrigger:                     stringValWithDefault(c.CheckUpdateTrigger, CheckUpdateTriggerOutput),
+		CatalogCheckOutputMaxSize:              intVal(c.CatalogCheckOutputMaxSize),
+		CatalogCheckOutputCompression:          boolVal(c.CatalogCheckOutputCompression),
 		CheckOutputMaxSize:                     intValWithDefault(c.CheckOutputMaxSize, 4096),
 		Checks:                                 checks,
 		ClientAddrs:                            clientAddrs,
@@ -960,7 +1126,12 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 		ConnectCAProvider:                      connectCAProvider,
 		ConnectCAConfig:                        connectCAConfig,
 		ConnectMeshGatewayWANFederationEnabled: connectMeshGatewayWANFederationEnabled,
+		ConnectCARootsWebhook:                  stringVal(c.Connect.CARootsWebhook),
+		ConnectCARootsWebhookSecret:            stringVal(c.Connect.CARootsWebhookSecret),
+		ConnectTrafficShiftPrometheusAddresses: c.Connect.TrafficShiftPrometheusAddresses,
 		ConnectServerlessPluginEnabled:         serverlessPluginEnabled,
+		ConnectMeshReadinessCheckEnabled:       meshReadinessCheckEnabled,
+		ConnectMeshProbesEnabled:               meshProbesEnabled,
 		ConnectSidecarMinPort:                  sidecarMinPort,
 		ConnectSidecarMaxPort:                  sidecarMaxPort,
 		ConnectTestCALeafRootChangeSpread:      b.durationVal("connect.test_ca_leaf_root_change_spread", c.Connect.TestCALeafRootChangeSpread),
@@ -983,6 +1154,7 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 		EnableAgentTLSForChecks:    boolVal(c.EnableAgentTLSForChecks),
 		EnableCentralServiceConfig: boolVal(c.EnableCentralServiceConfig),
 		EnableDebug:                boolVal(c.EnableDebug),
+		EnableExpiryChecks:         boolVal(c.EnableExpiryChecks),
 		EnableRemoteScriptChecks:   enableRemoteScriptChecks,
 		EnableLocalScriptChecks:    enableLocalScriptChecks,
 		EncryptKey:                 stringVal(c.EncryptKey),
@@ -991,6 +1163,7 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 		HTTPMaxConnsPerClient:      intVal(c.Limits.HTTPMaxConnsPerClient),
 		HTTPSHandshakeTimeout:      b.durationVal("limits.https_handshake_timeout", c.Limits.HTTPSHandshakeTimeout),
 		KVMaxValueSize:             uint64Val(c.Limits.KVMaxValueSize),
+		MaxPerPage:                 intVal(c.Limits.MaxPerPage),
 		LeaveDrainTime:             b.durationVal("performance.leave_drain_time", c.Performance.LeaveDrainTime),
 		LeaveOnTerm:                leaveOnTerm,
 		StaticRuntimeConfig: StaticRuntimeConfig{
@@ -1000,6 +1173,7 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 
 		Logging: logging.Config{
 			LogLevel:          stringVal(c.LogLevel),
+			LogLevels:         c.LogLevels,
 			LogJSON:           boolVal(c.LogJSON),
 			LogFilePath:       stringVal(c.LogFile),
 			EnableSyslog:      boolVal(c.EnableSyslog),
@@ -1007,66 +1181,73 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 			LogRotateDuration: b.durationVal("log_rotate_duration", c.LogRotateDuration),
 			LogRotateBytes:    intVal(c.LogRotateBytes),
 			LogRotateMaxFiles: intVal(c.LogRotateMaxFiles),
+			RedactSecrets:     boolVal(c.RedactSecrets),
+			RedactPatterns:    c.RedactPatterns,
 		},
-		MaxQueryTime:                     b.durationVal("max_query_time", c.MaxQueryTime),
-		NodeID:                           types.NodeID(stringVal(c.NodeID)),
-		NodeMeta:                         c.NodeMeta,
-		NodeName:                         b.nodeName(c.NodeName),
-		ReadReplica:                      boolVal(c.ReadReplica),
-		PidFile:                          stringVal(c.PidFile),
-		PrimaryDatacenter:                primaryDatacenter,
-		PrimaryGateways:                  b.expandAllOptionalAddrs("primary_gateways", c.PrimaryGateways),
-		PrimaryGatewaysInterval:          b.durationVal("primary_gateways_interval", c.PrimaryGatewaysInterval),
-		RPCAdvertiseAddr:                 rpcAdvertiseAddr,
-		RPCBindAddr:                      rpcBindAddr,
-		RPCHandshakeTimeout:              b.durationVal("limits.rpc_handshake_timeout", c.Limits.RPCHandshakeTimeout),
-		RPCHoldTimeout:                   b.durationVal("performance.rpc_hold_timeout", c.Performance.RPCHoldTimeout),
-		RPCMaxBurst:                      intVal(c.Limits.RPCMaxBurst),
-		RPCMaxConnsPerClient:             intVal(c.Limits.RPCMaxConnsPerClient),
-		RPCProtocol:                      intVal(c.RPCProtocol),
-		RPCRateLimit:                     rate.Limit(float64Val(c.Limits.RPCRate)),
-		RPCConfig:                        consul.RPCConfig{EnableStreaming: boolValWithDefault(c.RPC.EnableStreaming, serverMode)},
-		RaftProtocol:                     intVal(c.RaftProtocol),
-		RaftSnapshotThreshold:            intVal(c.RaftSnapshotThreshold),
-		RaftSnapshotInterval:             b.durationVal("raft_snapshot_interval", c.RaftSnapshotInterval),
-		RaftTrailingLogs:                 intVal(c.RaftTrailingLogs),
-		ReconnectTimeoutLAN:              b.durationVal("reconnect_timeout", c.ReconnectTimeoutLAN),
-		ReconnectTimeoutWAN:              b.durationVal("reconnect_timeout_wan", c.ReconnectTimeoutWAN),
-		RejoinAfterLeave:                 boolVal(c.RejoinAfterLeave),
-		RetryJoinIntervalLAN:             b.durationVal("retry_interval", c.RetryJoinIntervalLAN),
-		RetryJoinIntervalWAN:             b.durationVal("retry_interval_wan", c.RetryJoinIntervalWAN),
-		RetryJoinLAN:                     b.expandAllOptionalAddrs("retry_join", c.RetryJoinLAN),
-		RetryJoinMaxAttemptsLAN:          intVal(c.RetryJoinMaxAttemptsLAN),
-		RetryJoinMaxAttemptsWAN:          intVal(c.RetryJoinMaxAttemptsWAN),
-		RetryJoinWAN:                     b.expandAllOptionalAddrs("retry_join_wan", c.RetryJoinWAN),
-		SegmentName:                      stringVal(c.SegmentName),
-		Segments:                         segments,
-		SegmentLimit:                     intVal(c.SegmentLimit),
-		SerfAdvertiseAddrLAN:             serfAdvertiseAddrLAN,
-		SerfAdvertiseAddrWAN:             serfAdvertiseAddrWAN,
-		SerfAllowedCIDRsLAN:              serfAllowedCIDRSLAN,
-		SerfAllowedCIDRsWAN:              serfAllowedCIDRSWAN,
-		SerfBindAddrLAN:                  serfBindAddrLAN,
-		SerfBindAddrWAN:                  serfBindAddrWAN,
-		SerfPortLAN:                      serfPortLAN,
-		SerfPortWAN:                      serfPortWAN,
-		ServerMode:                       serverMode,
-		ServerName:                       stringVal(c.ServerName),
-		ServerPort:                       serverPort,
-		Services:                         services,
-		SessionTTLMin:                    b.durationVal("session_ttl_min", c.SessionTTLMin),
-		SkipLeaveOnInt:                   skipLeaveOnInt,
-		StartJoinAddrsLAN:                b.expandAllOptionalAddrs("start_join", c.StartJoinAddrsLAN),
-		StartJoinAddrsWAN:                b.expandAllOptionalAddrs("start_join_wan", c.StartJoinAddrsWAN),
-		TaggedAddresses:                  c.TaggedAddresses,
-		TranslateWANAddrs:                boolVal(c.TranslateWANAddrs),
-		TxnMaxReqLen:                     uint64Val(c.Limits.TxnMaxReqLen),
-		UIConfig:                         b.uiConfigVal(c.UIConfig),
-		UnixSocketGroup:                  stringVal(c.UnixSocket.Group),
-		UnixSocketMode:                   stringVal(c.UnixSocket.Mode),
-		UnixSocketUser:                   stringVal(c.UnixSocket.User),
-		Watches:                          c.Watches,
-		AutoReloadConfigCoalesceInterval: 1 * time.Second,
+		MaxQueryTime:                      b.durationVal("max_query_time", c.MaxQueryTime),
+		NodeID:                            types.NodeID(stringVal(c.NodeID)),
+		NodeMeta:                          c.NodeMeta,
+		NodeName:                          b.nodeName(c.NodeName),
+		ReadReplica:                       boolVal(c.ReadReplica),
+		PidFile:                           stringVal(c.PidFile),
+		PrimaryDatacenter:                 primaryDatacenter,
+		PrimaryGateways:                   b.expandAllOptionalAddrs("primary_gateways", c.PrimaryGateways),
+		PrimaryGatewaysInterval:           b.durationVal("primary_gateways_interval", c.PrimaryGatewaysInterval),
+		RPCAdvertiseAddr:                  rpcAdvertiseAddr,
+		RPCBindAddr:                       rpcBindAddr,
+		RPCHandshakeTimeout:               b.durationVal("limits.rpc_handshake_timeout", c.Limits.RPCHandshakeTimeout),
+		RPCHoldTimeout:                    b.durationVal("performance.rpc_hold_timeout", c.Performance.RPCHoldTimeout),
+		RPCMaxBurst:                       intVal(c.Limits.RPCMaxBurst),
+		RPCMaxConnsPerClient:              intVal(c.Limits.RPCMaxConnsPerClient),
+		RPCMaxConcurrentRequestsPerClient: intVal(c.Limits.RPCMaxConcurrentRequestsPerClient),
+		RPCMaxInFlightBytesPerClient:      intVal(c.Limits.RPCMaxInFlightBytesPerClient),
+		RPCProtocol:                       intVal(c.RPCProtocol),
+		RPCRateLimit:                      rate.Limit(float64Val(c.Limits.RPCRate)),
+		RPCConfig:                         consul.RPCConfig{EnableStreaming: boolValWithDefault(c.RPC.EnableStreaming, serverMode)},
+		RPCServerSelection:                stringValWithDefault(c.RPC.ServerSelection, router.ServerSelectionRandom),
+		RPCServerSlowStart:                b.durationVal("rpc.server_slow_start", c.RPC.ServerSlowStart),
+		RaftProtocol:                      intVal(c.RaftProtocol),
+		RaftSnapshotThreshold:             intVal(c.RaftSnapshotThreshold),
+		RaftSnapshotInterval:              b.durationVal("raft_snapshot_interval", c.RaftSnapshotInterval),
+		RaftSnapshotAutoTune:              boolVal(c.RaftSnapshotAutoTune),
+		RaftTrailingLogs:                  intVal(c.RaftTrailingLogs),
+		ReconnectTimeoutLAN:               b.durationVal("reconnect_timeout", c.ReconnectTimeoutLAN),
+		ReconnectTimeoutWAN:               b.durationVal("reconnect_timeout_wan", c.ReconnectTimeoutWAN),
+		RejoinAfterLeave:                  boolVal(c.RejoinAfterLeave),
+		RetryJoinIntervalLAN:              b.durationVal("retry_interval", c.RetryJoinIntervalLAN),
+		RetryJoinIntervalWAN:              b.durationVal("retry_interval_wan", c.RetryJoinIntervalWAN),
+		RetryJoinLAN:                      b.expandAllOptionalAddrs("retry_join", c.RetryJoinLAN),
+		RetryJoinMaxAttemptsLAN:           intVal(c.RetryJoinMaxAttemptsLAN),
+		RetryJoinMaxAttemptsWAN:           intVal(c.RetryJoinMaxAttemptsWAN),
+		RetryJoinWAN:                      b.expandAllOptionalAddrs("retry_join_wan", c.RetryJoinWAN),
+		SegmentName:                       stringVal(c.SegmentName),
+		Segments:                          segments,
+		SegmentLimit:                      intVal(c.SegmentLimit),
+		SerfAdvertiseAddrLAN:              serfAdvertiseAddrLAN,
+		SerfAdvertiseAddrWAN:              serfAdvertiseAddrWAN,
+		SerfAllowedCIDRsLAN:               serfAllowedCIDRSLAN,
+		SerfAllowedCIDRsWAN:               serfAllowedCIDRSWAN,
+		SerfBindAddrLAN:                   serfBindAddrLAN,
+		SerfBindAddrWAN:                   serfBindAddrWAN,
+		SerfPortLAN:                       serfPortLAN,
+		SerfPortWAN:                       serfPortWAN,
+		ServerMode:                        serverMode,
+		ServerName:                        stringVal(c.ServerName),
+		ServerPort:                        serverPort,
+		Services:                          services,
+		SessionTTLMin:                     b.durationVal("session_ttl_min", c.SessionTTLMin),
+		SkipLeaveOnInt:                    skipLeaveOnInt,
+		StartJoinAddrsLAN:                 b.expandAllOptionalAddrs("start_join", c.StartJoinAddrsLAN),
+		StartJoinAddrsWAN:                 b.expandAllOptionalAddrs("start_join_wan", c.StartJoinAddrsWAN),
+		TaggedAddresses:                   c.TaggedAddresses,
+		TranslateWANAddrs:                 boolVal(c.TranslateWANAddrs),
+		TxnMaxReqLen:                      uint64Val(c.Limits.TxnMaxReqLen),
+		UIConfig:                          b.uiConfigVal(c.UIConfig),
+		UnixSocketGroup:                   stringVal(c.UnixSocket.Group),
+		UnixSocketMode:                    stringVal(c.UnixSocket.Mode),
+		UnixSocketUser:                    stringVal(c.UnixSocket.User),
+		Watches:                           c.Watches,
+		AutoReloadConfigCoalesceInterval:  1 * time.Second,
 	}
 
 	rt.TLS, err = b.buildTLSConfig(rt, c.TLS)
@@ -1086,6 +1267,17 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 	if rt.Cache.EntryFetchRate <= 0 {
 		return RuntimeConfig{}, fmt.Errorf("cache.entry_fetch_rate must be strictly positive, was: %v", rt.Cache.EntryFetchRate)
 	}
+	for name, t := range rt.Cache.Types {
+		if t.LastGetTTL < 0 {
+			return RuntimeConfig{}, fmt.Errorf("cache.types[%q].last_get_ttl cannot be negative, was: %v", name, t.LastGetTTL)
+		}
+		if t.MaxEntries < 0 {
+			return RuntimeConfig{}, fmt.Errorf("cache.types[%q].max_entries cannot be negative, was: %v", name, t.MaxEntries)
+		}
+	}
+	if rt.BoundedStaleMaxLag <= 0 {
+		return RuntimeConfig{}, fmt.Errorf("bounded_stale_max_lag must be strictly positive, was: %v", rt.BoundedStaleMaxLag)
+	}
 
 	if rt.UIConfig.MetricsProvider == "prometheus" {
 		// Handle defaulting for the built-in version of prometheus.
@@ -1169,6 +1361,35 @@ func (b *builder) validate(rt RuntimeConfig) error {
 		return fmt.Errorf("data_dir cannot be empty")
 	}
 
+	if rt.GracefulShutdownDrainDelay < 0 {
+		return fmt.Errorf("graceful_shutdown.drain_delay cannot be negative")
+	}
+	for i, hook := range rt.GracefulShutdownDrainHooks {
+		if len(hook.Args) == 0 {
+			return fmt.Errorf("graceful_shutdown.drain_hooks[%d].args cannot be empty", i)
+		}
+		if hook.Timeout <= 0 {
+			return fmt.Errorf("graceful_shutdown.drain_hooks[%d].timeout must be positive", i)
+		}
+	}
+
+	for _, criteria := range []struct {
+		name  string
+		value []string
+	}{
+		{"agent_probes.liveness", rt.AgentLivenessCriteria},
+		{"agent_probes.readiness", rt.AgentReadinessCriteria},
+	} {
+		for _, criterion := range criteria.value {
+			switch criterion {
+			case ProbeCriterionSerf, ProbeCriterionServers, ProbeCriterionACLs, ProbeCriterionProxyConfig:
+			default:
+				return fmt.Errorf("%s: invalid criterion %q, must be one of %q, %q, %q or %q", criteria.name, criterion,
+					ProbeCriterionSerf, ProbeCriterionServers, ProbeCriterionACLs, ProbeCriterionProxyConfig)
+			}
+		}
+	}
+
 	if !validContentPath.MatchString(rt.UIConfig.ContentPath) {
 		return fmt.Errorf("ui-content-path can only contain alphanumeric, -, _, or /. received: %q", rt.UIConfig.ContentPath)
 	}
@@ -1199,6 +1420,25 @@ func (b *builder) validate(rt RuntimeConfig) error {
 				rt.UIConfig.MetricsProxy.BaseURL)
 		}
 	}
+	if rt.ConnectCARootsWebhook != "" {
+		u, err := url.Parse(rt.ConnectCARootsWebhook)
+		if err != nil || !(u.Scheme == "http" || u.Scheme == "https") {
+			return fmt.Errorf("connect.ca_roots_webhook must be a valid http"+
+				" or https URL. received: %q",
+				rt.ConnectCARootsWebhook)
+		}
+		if rt.ConnectCARootsWebhookSecret == "" {
+			return fmt.Errorf("connect.ca_roots_webhook_secret must be set to sign the" +
+				" requests to connect.ca_roots_webhook")
+		}
+	}
+	for _, addr := range rt.ConnectTrafficShiftPrometheusAddresses {
+		u, err := url.Parse(addr)
+		if err != nil || !(u.Scheme == "http" || u.Scheme == "https") {
+			return fmt.Errorf("connect.traffic_shift_prometheus_addresses must contain valid http"+
+				" or https URLs. received: %q", addr)
+		}
+	}
 	for _, allowedPath := range rt.UIConfig.MetricsProxy.PathAllowlist {
 		if err := validateAbsoluteURLPath(allowedPath); err != nil {
 			return fmt.Errorf("ui_config.metrics_proxy.path_allowlist: %v", err)
@@ -1216,6 +1456,21 @@ func (b *builder) validate(rt RuntimeConfig) error {
 		}
 	}
 
+	for subsystem, level := range rt.Logging.LogLevels {
+		if err := logging.ValidateSubsystem(subsystem); err != nil {
+			return fmt.Errorf("log_levels: %v", err)
+		}
+		if !logging.ValidateLogLevel(level) {
+			return fmt.Errorf("log_levels: invalid log level %q for subsystem %q", level, subsystem)
+		}
+	}
+
+	for _, p := range rt.Logging.RedactPatterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("redact_patterns: invalid pattern %q: %v", p, err)
+		}
+	}
+
 	if !rt.DevMode {
 		fi, err := os.Stat(rt.DataDir)
 		switch {
@@ -1258,6 +1513,16 @@ func (b *builder) validate(rt RuntimeConfig) error {
 			return fmt.Errorf("DNS recursor address cannot be 0.0.0.0, :: or [::]")
 		}
 	}
+	for zone, recursors := range rt.DNSRecursorZones {
+		if len(recursors) == 0 {
+			return fmt.Errorf("dns_config.recursor_zones[%q] must have at least one recursor", zone)
+		}
+		for _, a := range recursors {
+			if ipaddr.IsAny(a) {
+				return fmt.Errorf("DNS recursor address cannot be 0.0.0.0, :: or [::]")
+			}
+		}
+	}
 	if !isValidAltDomain(rt.DNSAltDomain, rt.Datacenter) {
 		return fmt.Errorf("alt_domain cannot start with {service,connect,node,query,addr,%s}", rt.Datacenter)
 	}
@@ -1279,8 +1544,39 @@ func (b *builder) validate(rt RuntimeConfig) error {
 	if rt.CheckOutputMaxSize < 1 {
 		return fmt.Errorf("check_output_max_size must be positive, to discard check output use the discard_check_output flag")
 	}
+	// The truncated outputs must end with the suffix for the agents to tell
+	// them apart from changed outputs.
+	if n := rt.CatalogCheckOutputMaxSize; n != 0 && n < len(structs.CheckOutputTruncatedSuffix) {
+		return fmt.Errorf("catalog_check_output_max_size cannot be %d. Must be zero or at least %d", n, len(structs.CheckOutputTruncatedSuffix))
+	}
+	switch rt.CheckUpdateTrigger {
+	case CheckUpdateTriggerOutput, CheckUpdateTriggerHash, CheckUpdateTriggerStatus:
+	default:
+		return fmt.Errorf("check_update_trigger must be %q, %q or %q, got %q",
+			CheckUpdateTriggerOutput, CheckUpdateTriggerHash, CheckUpdateTriggerStatus, rt.CheckUpdateTrigger)
+	}
+	switch rt.RPCServerSelection {
+	case router.ServerSelectionRandom, router.ServerSelectionHealth:
+	default:
+		return fmt.Errorf("rpc.server_selection must be %q or %q, got %q", router.ServerSelectionRandom, router.ServerSelectionHealth, rt.RPCServerSelection)
+	}
+	if rt.RPCServerSlowStart < 0 {
+		return fmt.Errorf("rpc.server_slow_start cannot be %s. Must be positive", rt.RPCServerSlowStart)
+	}
+	if rt.RPCMaxConcurrentRequestsPerClient < 0 {
+		return fmt.Errorf("limits.rpc_max_concurrent_requests_per_client cannot be %d. Must be positive", rt.RPCMaxConcurrentRequestsPerClient)
+	}
+	if rt.RPCMaxInFlightBytesPerClient < 0 {
+		return fmt.Errorf("limits.rpc_max_inflight_bytes_per_client cannot be %d. Must be positive", rt.RPCMaxInFlightBytesPerClient)
+	}
+	if rt.MaxPerPage <= 0 {
+		return fmt.Errorf("limits.max_per_page cannot be %d. Must be greater than 0", rt.MaxPerPage)
+	}
 	if rt.AEInterval <= 0 {
-		return fmt.Errorf("ae_interval cannot be %s. Must be positive", rt.AEInterval)
+		return fmt.Errorf("anti_entropy.sync_interval cannot be %s. Must be positive", rt.AEInterval)
+	}
+	if rt.AEJitter < 0 {
+		return fmt.Errorf("anti_entropy.sync_jitter cannot be %s. Must be positive", rt.AEJitter)
 	}
 	if rt.AutopilotMaxTrailingLogs < 0 {
 		return fmt.Errorf("autopilot.max_trailing_logs cannot be %d. Must be greater than or equal to zero", rt.AutopilotMaxTrailingLogs)
@@ -1301,6 +1597,11 @@ func (b *builder) validate(rt RuntimeConfig) error {
 	if rt.DNSARecordLimit < 0 {
 		return fmt.Errorf("dns_config.a_record_limit cannot be %d. Must be greater than or equal to zero", rt.DNSARecordLimit)
 	}
+	for service, limit := range rt.DNSServiceUDPAnswerLimit {
+		if limit < 0 {
+			return fmt.Errorf("dns_config.service_udp_answer_limit[%q] cannot be %d. Must be greater than or equal to zero", service, limit)
+		}
+	}
 	if err := structs.ValidateNodeMetadata(rt.NodeMeta, false); err != nil {
 		return fmt.Errorf("node_meta invalid: %v", err)
 	}
@@ -1309,6 +1610,14 @@ func (b *builder) validate(rt RuntimeConfig) error {
 			return fmt.Errorf("encrypt has invalid key: %s", err)
 		}
 	}
+	if rt.Cache.PersistDir != "" && rt.Cache.PersistKey == "" {
+		return fmt.Errorf("cache.persist requires cache.persist_key to be set")
+	}
+	if rt.Cache.PersistKey != "" {
+		if key, err := decodeBytes(rt.Cache.PersistKey); err != nil || len(key) != 32 {
+			return fmt.Errorf("cache.persist_key must be a base64 encoded 32 byte key")
+		}
+	}
 
 	if rt.ConnectMeshGatewayWANFederationEnabled && !rt.ServerMode {
 		return fmt.Errorf("'connect.enable_mesh_gateway_wan_federation = true' requires 'server = true'")
@@ -1363,6 +1672,20 @@ func (b *builder) validate(rt RuntimeConfig) error {
 	if err := addrsUnique(inuse, "HTTPS", rt.HTTPSAddrs); err != nil {
 		return err
 	}
+	for i, sock := range rt.HTTPUnixSockets {
+		if sock.Path == "" {
+			return fmt.Errorf("http_config.unix_sockets[%d].path is required", i)
+		}
+		addr := &net.UnixAddr{Name: sock.Path, Net: "unix"}
+		if err := addrUnique(inuse, fmt.Sprintf("http_config.unix_sockets[%d]", i), addr); err != nil {
+			return err
+		}
+		for _, e := range sock.AllowEndpoints {
+			if !strings.HasPrefix(e, "/") {
+				return fmt.Errorf("http_config.unix_sockets[%d].allow_endpoints: %q must start with a /", i, e)
+			}
+		}
+	}
 	if err := addrUnique(inuse, "RPC Advertise", rt.RPCAdvertiseAddr); err != nil {
 		return err
 	}
@@ -1504,6 +1827,22 @@ func addrsUnique(inuse map[string]string, name string, addrs []net.Addr) error {
 	return nil
 }
 
+// httpUnixSocketsVal returns the additional unix sockets of the HTTP API,
+// whose file permissions default to the ones of the unix_sockets config.
+func (b *builder) httpUnixSocketsVal(c Config) []HTTPUnixSocket {
+	var sockets []HTTPUnixSocket
+	for _, s := range c.HTTPConfig.UnixSockets {
+		sockets = append(sockets, HTTPUnixSocket{
+			Path:           stringVal(s.Path),
+			AllowEndpoints: s.AllowEndpoints,
+			Group:          stringValWithDefault(s.Group, stringVal(c.UnixSocket.Group)),
+			Mode:           stringValWithDefault(s.Mode, stringVal(c.UnixSocket.Mode)),
+			User:           stringValWithDefault(s.User, stringVal(c.UnixSocket.User)),
+		})
+	}
+	return sockets
+}
+
 // splitSlicesAndValues moves all slice values defined in c to 'slices'
 // and all other values to 'values'.
 func splitSlicesAndValues(c Config) (slices, values Config) {
@@ -1562,11 +1901,16 @@ func (b *builder) checkVal(v *CheckDefinition) *structs.CheckDefinition {
 		TLSSkipVerify:                  boolVal(v.TLSSkipVerify),
 		AliasNode:                      stringVal(v.AliasNode),
 		AliasService:                   stringVal(v.AliasService),
+		AliasPropagationDelay:          b.durationVal(fmt.Sprintf("check[%s].alias_propagation_delay", id), v.AliasPropagationDelay),
 		Timeout:                        b.durationVal(fmt.Sprintf("check[%s].timeout", id), v.Timeout),
 		TTL:                            b.durationVal(fmt.Sprintf("check[%s].ttl", id), v.TTL),
 		SuccessBeforePassing:           intVal(v.SuccessBeforePassing),
 		FailuresBeforeCritical:         intVal(v.FailuresBeforeCritical),
 		FailuresBeforeWarning:          intValWithDefault(v.FailuresBeforeWarning, intVal(v.FailuresBeforeCritical)),
+		TTLExpiryDeregisterAfter:       intVal(v.TTLExpiryDeregisterAfter),
+		TTLExpiryWebhook:               stringVal(v.TTLExpiryWebhook),
+		TTLExpiryMaintenance:           boolVal(v.TTLExpiryMaintenance),
+		Templated:                      boolVal(v.Templated),
 		H2PING:                         stringVal(v.H2PING),
 		H2PingUseTLS:                   H2PingUseTLSVal,
 		DeregisterCriticalServiceAfter: b.durationVal(fmt.Sprintf("check[%s].deregister_critical_service_after", id), v.DeregisterCriticalServiceAfter),
@@ -1669,6 +2013,8 @@ func (b *builder) serviceKindVal(v *string) structs.ServiceKind {
 		return structs.ServiceKindTerminatingGateway
 	case string(structs.ServiceKindIngressGateway):
 		return structs.ServiceKindIngressGateway
+	case string(structs.ServiceKindEgressGateway):
+		return structs.ServiceKindEgressGateway
 	default:
 		return structs.ServiceKindTypical
 	}
@@ -2052,7 +2398,7 @@ func (b *builder) nodeName(v *string) string {
 }
 
 // expandAddrs expands the go-sockaddr template in s and returns the
-// result as a list of *net.IPAddr and *net.UnixAddr.
+// result as a list of *net.IPAddr, *net.UnixAddr and *NamedPipeAddr.
 func (b *builder) expandAddrs(name string, s *string) []net.Addr {
 	if s == nil || *s == "" {
 		return nil
@@ -2069,6 +2415,15 @@ func (b *builder) expandAddrs(name string, s *string) []net.Addr {
 		switch {
 		case strings.HasPrefix(a, "unix://"):
 			addrs = append(addrs, &net.UnixAddr{Name: a[len("unix://"):], Net: "unix"})
+		case strings.HasPrefix(a, "npipe://"):
+			// Named pipes are only served by the gRPC listener, so that the
+			// proxies of Windows workloads can fetch their xDS config without
+			// going through loopback TCP.
+			if name != "addresses.grpc" {
+				b.err = multierror.Append(b.err, fmt.Errorf("%s cannot be a named pipe", name))
+				return nil
+			}
+			addrs = append(addrs, &NamedPipeAddr{Name: a[len("npipe://"):]})
 		default:
 			// net.ParseIP does not like '[::]'
 			ip := net.ParseIP(a)
@@ -2237,6 +2592,8 @@ func (b *builder) makeAddr(pri, sec net.Addr, port int) net.Addr {
 		return &net.TCPAddr{IP: a.IP, Port: port}
 	case *net.UnixAddr:
 		return a
+	case *NamedPipeAddr:
+		return a
 	default:
 		panic(fmt.Sprintf("invalid address type %T", a))
 	}
@@ -2294,6 +2651,16 @@ func (b *builder) autoConfigVal(raw AutoConfigRaw, agentPartition string) AutoCo
 
 	val.Authorizer = b.autoConfigAuthorizerVal(raw.Authorization, agentPartition)
 
+	for _, snippet := range raw.ConfigSnippets {
+		val.ConfigSnippets = append(val.ConfigSnippets, consul.AutoConfigSnippet{
+			Name:              stringVal(snippet.Name),
+			Config:            stringVal(snippet.Config),
+			Segments:          snippet.Segments,
+			RolloutPercentage: intValWithDefault(snippet.RolloutPercentage, 100),
+		})
+	}
+	val.AcceptSnippetKeys = raw.AcceptSnippetKeys
+
 	return val
 }
 
@@ -2311,6 +2678,7 @@ func (b *builder) autoConfigAuthorizerVal(raw AutoConfigAuthorizationRaw, agentP
 	val.Enabled = boolValWithDefault(raw.Enabled, false)
 	val.ClaimAssertions = raw.Static.ClaimAssertions
 	val.AllowReuse = boolValWithDefault(raw.Static.AllowReuse, false)
+	val.VerifiedNodeMeta = raw.Static.VerifiedNodeMeta
 	val.AuthMethod = structs.ACLAuthMethod{
 		Name:           "Auto Config Authorizer",
 		Type:           "jwt",
@@ -2342,6 +2710,10 @@ func (b *builder) validateAutoConfig(rt RuntimeConfig) error {
 		return err
 	}
 
+	if err := validateAutoConfigSnippets(rt); err != nil {
+		return err
+	}
+
 	if !autoconf.Enabled {
 		return nil
 	}
@@ -2373,6 +2745,49 @@ func (b *builder) validateAutoConfig(rt RuntimeConfig) error {
 	return nil
 }
 
+func validateAutoConfigSnippets(rt RuntimeConfig) error {
+	autoconf := rt.AutoConfig
+
+	for _, key := range autoconf.AcceptSnippetKeys {
+		if key == "auto_config" {
+			return fmt.Errorf("auto_config.accept_snippet_keys cannot contain auto_config")
+		}
+	}
+
+	if len(autoconf.ConfigSnippets) == 0 {
+		return nil
+	}
+
+	// Config snippets are distributed by the servers
+	if !rt.ServerMode {
+		return fmt.Errorf("auto_config.config_snippets cannot be set for client agents")
+	}
+
+	names := make(map[string]struct{})
+	for _, snippet := range autoconf.ConfigSnippets {
+		if snippet.Name == "" {
+			return fmt.Errorf("auto_config.config_snippets: name is required")
+		}
+		if _, ok := names[snippet.Name]; ok {
+			return fmt.Errorf("auto_config.config_snippets: duplicate snippet name %q", snippet.Name)
+		}
+		names[snippet.Name] = struct{}{}
+
+		if snippet.RolloutPercentage < 0 || snippet.RolloutPercentage > 100 {
+			return fmt.Errorf("auto_config.config_snippets[%s]: rollout_percentage must be between 0 and 100", snippet.Name)
+		}
+
+		_, keys, err := ParseConfigSnippet(snippet.Name, snippet.Config)
+		if err != nil {
+			return fmt.Errorf("auto_config.config_snippets[%s]: invalid config: %v", snippet.Name, err)
+		}
+		if len(keys) == 0 {
+			return fmt.Errorf("auto_config.config_snippets[%s]: config is empty", snippet.Name)
+		}
+	}
+	return nil
+}
+
 func validateAutoConfigAuthorizer(rt RuntimeConfig) error {
 	authz := rt.AutoConfig.Authorizer
 
@@ -2429,6 +2844,22 @@ func validateAutoConfigAuthorizer(rt RuntimeConfig) error {
 			return fmt.Errorf("auto_config.authorization.static.claim_assertion %q is invalid: %v", raw, err)
 		}
 	}
+
+	// the verified node metadata is taken from the mapped claims.
+	mapped := make(map[string]bool)
+	if claimMappings, ok := authz.AuthMethod.Config["ClaimMappings"].(map[string]string); ok {
+		for _, name := range claimMappings {
+			mapped[name] = true
+		}
+	}
+	for _, name := range authz.VerifiedNodeMeta {
+		if !mapped[name] {
+			return fmt.Errorf("auto_config.authorization.static.verified_node_meta %q is not a claim mapped by auto_config.authorization.static.claim_mappings", name)
+		}
+		if err := structs.ValidateNodeMetadata(map[string]string{structs.MetaVerifiedPrefix + name: ""}, true); err != nil {
+			return fmt.Errorf("auto_config.authorization.static.verified_node_meta %q is invalid: %v", name, err)
+		}
+	}
 	return nil
 }
 
@@ -2521,9 +2952,20 @@ func (b *builder) buildTLSConfig(rt RuntimeConfig, t TLS) (tlsutil.Config, error
 		return c, errors.New("verify_server_hostname is only valid in the tls.internal_rpc stanza")
 	}
 
+	// Server SPIFFE IDs are only used to authorize server connections on the
+	// internal RPC port.
+	if len(t.Defaults.ServerSPIFFEIDs) > 0 || len(t.GRPC.ServerSPIFFEIDs) > 0 || len(t.HTTPS.ServerSPIFFEIDs) > 0 {
+		return c, errors.New("server_spiffe_ids is only valid in the tls.internal_rpc stanza")
+	}
+	for _, id := range t.InternalRPC.ServerSPIFFEIDs {
+		if !strings.HasPrefix(id, "spiffe://") {
+			return c, fmt.Errorf("tls.internal_rpc.server_spiffe_ids: %q is not a SPIFFE ID", id)
+		}
+	}
+
 	// TLS is only enabled on the gRPC listener if there's an HTTPS port configured
 	// for historic and backwards-compatibility reasons.
-	if rt.HTTPSPort <= 0 && (t.GRPC != TLSProtocolConfig{} && t.GRPCModifiedByDeprecatedConfig == nil) {
+	if rt.HTTPSPort <= 0 && (!reflect.DeepEqual(t.GRPC, TLSProtocolConfig{}) && t.GRPCModifiedByDeprecatedConfig == nil) {
 		b.warn("tls.grpc was provided but TLS will NOT be enabled on the gRPC listener without an HTTPS listener configured (e.g. via ports.https)")
 	}
 
@@ -2570,6 +3012,7 @@ func (b *builder) buildTLSConfig(rt RuntimeConfig, t TLS) (tlsutil.Config, error
 
 	mapCommon("internal_rpc", t.InternalRPC, &c.InternalRPC)
 	c.InternalRPC.VerifyServerHostname = boolVal(t.InternalRPC.VerifyServerHostname)
+	c.InternalRPC.ServerSPIFFEIDs = t.InternalRPC.ServerSPIFFEIDs
 
 	// Setting only verify_server_hostname is documented to imply verify_outgoing.
 	// If it doesn't then we risk sending communication over plain TCP when we
@@ -2587,10 +3030,32 @@ func (b *builder) buildTLSConfig(rt RuntimeConfig, t TLS) (tlsutil.Config, error
 	c.Domain = rt.DNSDomain
 	c.EnableAgentTLSForChecks = rt.EnableAgentTLSForChecks
 	c.AutoTLS = rt.AutoEncryptTLS || rt.AutoConfig.Enabled
+	c.AutoReloadCertificates = boolVal(t.AutoReloadCertificates)
 
 	return c, nil
 }
 
+// parseLabelFilter returns the names of the labels allowed and blocked by
+// the rules of prometheus_label_filter.
+func (b *builder) parseLabelFilter(telemetry *Telemetry) ([]string, []string) {
+	var allowedLabels, blockedLabels []string
+	for _, rule := range telemetry.PrometheusLabelFilter {
+		if len(rule) < 2 {
+			b.warn("Invalid filter rule in prometheus_label_filter: %q", rule)
+			continue
+		}
+		switch rule[0] {
+		case '+':
+			allowedLabels = append(allowedLabels, rule[1:])
+		case '-':
+			blockedLabels = append(blockedLabels, rule[1:])
+		default:
+			b.warn("Filter rule must begin with either '+' or '-': %q", rule)
+		}
+	}
+	return allowedLabels, blockedLabels
+}
+
 func (b *builder) parsePrefixFilter(telemetry *Telemetry) ([]string, []string) {
 	var telemetryAllowedPrefixes, telemetryBlockedPrefixes []string
 
@@ -27,18 +27,6 @@ func TestValidateEnterpriseConfigKeys(t *testing.T) {
 			},
 			badKeys: []string{"read_replica (or the deprecated non_voting_server)"},
 		},
-		"segment": {
-			config: Config{
-				SegmentName: &stringVal,
-			},
-			badKeys: []string{"segment"},
-		},
-		"segments": {
-			config: Config{
-				Segments: []Segment{{Name: &stringVal}},
-			},
-			badKeys: []string{"segments"},
-		},
 		"autopilot.redundancy_zone_tag": {
 			config: Config{
 				Autopilot: Autopilot{
@@ -117,7 +105,7 @@ func TestValidateEnterpriseConfigKeys(t *testing.T) {
 					},
 				},
 			},
-			badKeys: []string{"read_replica (or the deprecated non_voting_server)", "segment"},
+			badKeys: []string{"read_replica (or the deprecated non_voting_server)"},
 		},
 	}
 
@@ -3,6 +3,8 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/hashicorp/consul/agent/consul"
 
@@ -109,6 +111,33 @@ func (l LiteralSource) Parse() (Config, Metadata, error) {
 	return l.Config, Metadata{}, nil
 }
 
+// ParseConfigSnippet parses an auto_config snippet in either HCL or JSON format
+// and returns its configuration along with the top level keys it sets.
+func ParseConfigSnippet(name, data string) (Config, []string, error) {
+	c, md, err := FileSource{Name: name, Format: "hcl", Data: data}.Parse()
+	if err != nil {
+		return Config{}, nil, err
+	}
+	if len(md.Unused) > 0 {
+		return Config{}, nil, fmt.Errorf("invalid config keys: %s", strings.Join(md.Unused, ", "))
+	}
+
+	var keys []string
+	seen := make(map[string]struct{})
+	for _, k := range md.Keys {
+		if i := strings.IndexAny(k, ".["); i >= 0 {
+			k = k[:i]
+		}
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return c, keys, nil
+}
+
 type decodeTarget struct {
 	DeprecatedConfig `mapstructure:",squash"`
 	Config           `mapstructure:",squash"`
@@ -120,6 +149,23 @@ type Cache struct {
 	EntryFetchMaxBurst *int `mapstructure:"entry_fetch_max_burst"`
 	// EntryFetchRate represents the max calls/sec for a single cache entry
 	EntryFetchRate *float64 `mapstructure:"entry_fetch_rate"`
+	// Persist enables persisting the Connect entries of the cache in the
+	// data dir, so they are restored when the agent restarts
+	Persist *bool `mapstructure:"persist"`
+	// PersistKey is the base64 encoded key the persisted entries are
+	// encrypted with. It is required when Persist is enabled.
+	PersistKey *string `mapstructure:"persist_key"`
+	// Types overrides the options of the cache types with the given names
+	Types map[string]CacheType `mapstructure:"types"`
+}
+
+// CacheType overrides the options of a cache type.
+type CacheType struct {
+	// LastGetTTL is how long the entries of the type are kept after they were
+	// last requested
+	LastGetTTL *string `mapstructure:"last_get_ttl"`
+	// MaxEntries is the maximum number of entries of the type
+	MaxEntries *int `mapstructure:"max_entries"`
 }
 
 // Config defines the format of a configuration file in either JSON or
@@ -141,15 +187,22 @@ type Config struct {
 	AdvertiseAddrWANIPv4             *string             `mapstructure:"advertise_addr_wan_ipv4"`
 	AdvertiseAddrWANIPv6             *string             `mapstructure:"advertise_addr_wan_ipv6"`
 	AdvertiseReconnectTimeout        *string             `mapstructure:"advertise_reconnect_timeout"`
+	AgentProbes                      AgentProbes         `mapstructure:"agent_probes"`
+	AntiEntropy                      AntiEntropy         `mapstructure:"anti_entropy"`
 	AutoConfig                       AutoConfigRaw       `mapstructure:"auto_config"`
 	Autopilot                        Autopilot           `mapstructure:"autopilot"`
 	BindAddr                         *string             `mapstructure:"bind_addr"`
 	Bootstrap                        *bool               `mapstructure:"bootstrap"`
 	BootstrapExpect                  *int                `mapstructure:"bootstrap_expect"`
+	BoundedStaleMaxLag               *int                `mapstructure:"bounded_stale_max_lag"`
 	Cache                            Cache               `mapstructure:"cache"`
+	CatalogCheckOutputCompression    *bool               `mapstructure:"catalog_check_output_compression"`
+	CatalogCheckOutputMaxSize        *int                `mapstructure:"catalog_check_output_max_size"`
 	Check                            *CheckDefinition    `mapstructure:"check"` // needs to be a pointer to avoid partial merges
 	CheckOutputMaxSize               *int                `mapstructure:"check_output_max_size"`
+	CheckSyncFlushInterval           *string             `mapstructure:"check_sync_flush_interval"`
 	CheckUpdateInterval              *string             `mapstructure:"check_update_interval"`
+	CheckUpdateTrigger               *string             `mapstructure:"check_update_trigger"`
 	Checks                           []CheckDefinition   `mapstructure:"checks"`
 	ClientAddr                       *string             `mapstructure:"client_addr"`
 	ConfigEntries                    ConfigEntries       `mapstructure:"config_entries"`
@@ -174,6 +227,7 @@ type Config struct {
 	EnableAgentTLSForChecks          *bool               `mapstructure:"enable_agent_tls_for_checks"`
 	EnableCentralServiceConfig       *bool               `mapstructure:"enable_central_service_config"`
 	EnableDebug                      *bool               `mapstructure:"enable_debug"`
+	EnableExpiryChecks               *bool               `mapstructure:"enable_expiry_checks"`
 	EnableScriptChecks               *bool               `mapstructure:"enable_script_checks"`
 	EnableLocalScriptChecks          *bool               `mapstructure:"enable_local_script_checks"`
 	EnableSyslog                     *bool               `mapstructure:"enable_syslog"`
@@ -182,11 +236,13 @@ type Config struct {
 	EncryptVerifyOutgoing            *bool               `mapstructure:"encrypt_verify_outgoing"`
 	GossipLAN                        GossipLANConfig     `mapstructure:"gossip_lan"`
 	GossipWAN                        GossipWANConfig     `mapstructure:"gossip_wan"`
+	GracefulShutdown                 GracefulShutdown    `mapstructure:"graceful_shutdown"`
 	HTTPConfig                       HTTPConfig          `mapstructure:"http_config"`
 	LeaveOnTerm                      *bool               `mapstructure:"leave_on_terminate"`
 	LicensePath                      *string             `mapstructure:"license_path"`
 	Limits                           Limits              `mapstructure:"limits"`
 	LogLevel                         *string             `mapstructure:"log_level"`
+	LogLevels                        map[string]string   `mapstructure:"log_levels"`
 	LogJSON                          *bool               `mapstructure:"log_json"`
 	LogFile                          *string             `mapstructure:"log_file"`
 	LogRotateDuration                *string             `mapstructure:"log_rotate_duration"`
@@ -206,7 +262,10 @@ type Config struct {
 	RaftProtocol                     *int                `mapstructure:"raft_protocol"`
 	RaftSnapshotThreshold            *int                `mapstructure:"raft_snapshot_threshold"`
 	RaftSnapshotInterval             *string             `mapstructure:"raft_snapshot_interval"`
+	RaftSnapshotAutoTune             *bool               `mapstructure:"raft_snapshot_auto_tune"`
 	RaftTrailingLogs                 *int                `mapstructure:"raft_trailing_logs"`
+	RedactPatterns                   []string            `mapstructure:"redact_patterns"`
+	RedactSecrets                    *bool               `mapstructure:"redact_secrets"`
 	ReconnectTimeoutLAN              *string             `mapstructure:"reconnect_timeout"`
 	ReconnectTimeoutWAN              *string             `mapstructure:"reconnect_timeout_wan"`
 	RejoinAfterLeave                 *bool               `mapstructure:"rejoin_after_leave"`
@@ -274,15 +333,14 @@ type Config struct {
 	VersionPrerelease          *string  `mapstructure:"version_prerelease"`
 	VersionMetadata            *string  `mapstructure:"version_metadata"`
 
+	SegmentName *string   `mapstructure:"segment"`
+	Segments    []Segment `mapstructure:"segments"`
+
 	// Enterprise Only
 	Audit Audit `mapstructure:"audit"`
 	// Enterprise Only
 	ReadReplica *bool `mapstructure:"read_replica" alias:"non_voting_server"`
 	// Enterprise Only
-	SegmentName *string `mapstructure:"segment"`
-	// Enterprise Only
-	Segments []Segment `mapstructure:"segments"`
-	// Enterprise Only
 	Partition *string `mapstructure:"partition"`
 
 	// Enterprise Only - not user configurable
@@ -299,6 +357,7 @@ type GossipLANConfig struct {
 	ProbeTimeout   *string `mapstructure:"probe_timeout"`
 	SuspicionMult  *int    `mapstructure:"suspicion_mult"`
 	RetransmitMult *int    `mapstructure:"retransmit_mult"`
+	TCPOnly        *bool   `mapstructure:"tcp_only"`
 }
 
 type GossipWANConfig struct {
@@ -308,6 +367,21 @@ type GossipWANConfig struct {
 	ProbeTimeout   *string `mapstructure:"probe_timeout"`
 	SuspicionMult  *int    `mapstructure:"suspicion_mult"`
 	RetransmitMult *int    `mapstructure:"retransmit_mult"`
+	TCPOnly        *bool   `mapstructure:"tcp_only"`
+}
+
+// GracefulShutdown configures how the agent takes its services out of
+// rotation before leaving the cluster gracefully.
+type GracefulShutdown struct {
+	DrainDelay         *string     `mapstructure:"drain_delay"`
+	DrainHooks         []DrainHook `mapstructure:"drain_hooks"`
+	DeregisterServices *bool       `mapstructure:"deregister_services"`
+}
+
+// DrainHook is a command run when the agent drains its services.
+type DrainHook struct {
+	Args    []string `mapstructure:"args"`
+	Timeout *string  `mapstructure:"timeout"`
 }
 
 type Consul struct {
@@ -413,6 +487,7 @@ type CheckDefinition struct {
 	TLSSkipVerify                  *bool               `mapstructure:"tls_skip_verify" alias:"tlsskipverify"`
 	AliasNode                      *string             `mapstructure:"alias_node"`
 	AliasService                   *string             `mapstructure:"alias_service"`
+	AliasPropagationDelay          *string             `mapstructure:"alias_propagation_delay"`
 	Timeout                        *string             `mapstructure:"timeout"`
 	TTL                            *string             `mapstructure:"ttl"`
 	H2PING                         *string             `mapstructure:"h2ping"`
@@ -420,6 +495,10 @@ type CheckDefinition struct {
 	SuccessBeforePassing           *int                `mapstructure:"success_before_passing"`
 	FailuresBeforeWarning          *int                `mapstructure:"failures_before_warning"`
 	FailuresBeforeCritical         *int                `mapstructure:"failures_before_critical"`
+	TTLExpiryDeregisterAfter       *int                `mapstructure:"ttl_expiry_deregister_after"`
+	TTLExpiryWebhook               *string             `mapstructure:"ttl_expiry_webhook"`
+	TTLExpiryMaintenance           *bool               `mapstructure:"ttl_expiry_maintenance"`
+	Templated                      *bool               `mapstructure:"templated"`
 	DeregisterCriticalServiceAfter *string             `mapstructure:"deregister_critical_service_after" alias:"deregistercriticalserviceafter"`
 
 	EnterpriseMeta `mapstructure:",squash"`
@@ -607,6 +686,11 @@ type Connect struct {
 	CAConfig                        map[string]interface{} `mapstructure:"ca_config"`
 	MeshGatewayWANFederationEnabled *bool                  `mapstructure:"enable_mesh_gateway_wan_federation"`
 	EnableServerlessPlugin          *bool                  `mapstructure:"enable_serverless_plugin"`
+	EnableMeshReadinessCheck        *bool                  `mapstructure:"enable_mesh_readiness_check"`
+	EnableMeshProbes                *bool                  `mapstructure:"enable_mesh_probes"`
+	CARootsWebhook                  *string                `mapstructure:"ca_roots_webhook"`
+	CARootsWebhookSecret            *string                `mapstructure:"ca_roots_webhook_secret"`
+	TrafficShiftPrometheusAddresses []string               `mapstructure:"traffic_shift_prometheus_addresses"`
 
 	// TestCALeafRootChangeSpread controls how long after a CA roots change before new leaft certs will be generated.
 	// This is only tuned in tests, generally set to 1ns to make tests deterministic with when to expect updated leaf
@@ -623,32 +707,47 @@ type SOA struct {
 }
 
 type DNS struct {
-	AllowStale         *bool             `mapstructure:"allow_stale"`
-	ARecordLimit       *int              `mapstructure:"a_record_limit"`
-	DisableCompression *bool             `mapstructure:"disable_compression"`
-	EnableTruncate     *bool             `mapstructure:"enable_truncate"`
-	MaxStale           *string           `mapstructure:"max_stale"`
-	NodeTTL            *string           `mapstructure:"node_ttl"`
-	OnlyPassing        *bool             `mapstructure:"only_passing"`
-	RecursorStrategy   *string           `mapstructure:"recursor_strategy"`
-	RecursorTimeout    *string           `mapstructure:"recursor_timeout"`
-	ServiceTTL         map[string]string `mapstructure:"service_ttl"`
-	UDPAnswerLimit     *int              `mapstructure:"udp_answer_limit"`
-	NodeMetaTXT        *bool             `mapstructure:"enable_additional_node_meta_txt"`
-	SOA                *SOA              `mapstructure:"soa"`
-	UseCache           *bool             `mapstructure:"use_cache"`
-	CacheMaxAge        *string           `mapstructure:"cache_max_age"`
+	AllowStale            *bool               `mapstructure:"allow_stale"`
+	ARecordLimit          *int                `mapstructure:"a_record_limit"`
+	DisableCompression    *bool               `mapstructure:"disable_compression"`
+	EnableTruncate        *bool               `mapstructure:"enable_truncate"`
+	MaxStale              *string             `mapstructure:"max_stale"`
+	NodeTTL               *string             `mapstructure:"node_ttl"`
+	OnlyPassing           *bool               `mapstructure:"only_passing"`
+	OnlyLocalSegment      *bool               `mapstructure:"only_local_segment"`
+	PreferClientSubnet    *bool               `mapstructure:"prefer_client_subnet"`
+	RecursorStrategy      *string             `mapstructure:"recursor_strategy"`
+	RecursorTimeout       *string             `mapstructure:"recursor_timeout"`
+	RecursorZones         map[string][]string `mapstructure:"recursor_zones"`
+	AllowRecursionFrom    []string            `mapstructure:"allow_recursion_from"`
+	ServiceTTL            map[string]string   `mapstructure:"service_ttl"`
+	UDPAnswerLimit        *int                `mapstructure:"udp_answer_limit"`
+	ServiceUDPAnswerLimit map[string]int      `mapstructure:"service_udp_answer_limit"`
+	NodeMetaTXT           *bool               `mapstructure:"enable_additional_node_meta_txt"`
+	SOA                   *SOA                `mapstructure:"soa"`
+	UseCache              *bool               `mapstructure:"use_cache"`
+	CacheMaxAge           *string             `mapstructure:"cache_max_age"`
 
 	// Enterprise Only
 	PreferNamespace *bool `mapstructure:"prefer_namespace"`
 }
 
 type HTTPConfig struct {
-	BlockEndpoints     []string          `mapstructure:"block_endpoints"`
-	AllowWriteHTTPFrom []string          `mapstructure:"allow_write_http_from"`
-	ResponseHeaders    map[string]string `mapstructure:"response_headers"`
-	UseCache           *bool             `mapstructure:"use_cache"`
-	MaxHeaderBytes     *int              `mapstructure:"max_header_bytes"`
+	BlockEndpoints     []string            `mapstructure:"block_endpoints"`
+	AllowWriteHTTPFrom []string            `mapstructure:"allow_write_http_from"`
+	ResponseHeaders    map[string]string   `mapstructure:"response_headers"`
+	UseCache           *bool               `mapstructure:"use_cache"`
+	MaxHeaderBytes     *int                `mapstructure:"max_header_bytes"`
+	UnixSockets        []RawHTTPUnixSocket `mapstructure:"unix_sockets"`
+}
+
+// RawHTTPUnixSocket is an additional unix socket serving the HTTP API.
+type RawHTTPUnixSocket struct {
+	Path           *string  `mapstructure:"path"`
+	AllowEndpoints []string `mapstructure:"allow_endpoints"`
+	Group          *string  `mapstructure:"group"`
+	Mode           *string  `mapstructure:"mode"`
+	User           *string  `mapstructure:"user"`
 }
 
 type Performance struct {
@@ -680,6 +779,9 @@ type Telemetry struct {
 	PrefixFilter                       []string `mapstructure:"prefix_filter"`
 	MetricsPrefix                      *string  `mapstructure:"metrics_prefix"`
 	PrometheusRetentionTime            *string  `mapstructure:"prometheus_retention_time"`
+	PrometheusHistograms               *bool    `mapstructure:"prometheus_histograms"`
+	PrometheusNativeHistograms         *bool    `mapstructure:"prometheus_native_histograms"`
+	PrometheusLabelFilter              []string `mapstructure:"prometheus_label_filter"`
 	StatsdAddr                         *string  `mapstructure:"statsd_address"`
 	StatsiteAddr                       *string  `mapstructure:"statsite_address"`
 }
@@ -707,14 +809,17 @@ type UnixSocket struct {
 }
 
 type Limits struct {
-	HTTPMaxConnsPerClient *int     `mapstructure:"http_max_conns_per_client"`
-	HTTPSHandshakeTimeout *string  `mapstructure:"https_handshake_timeout"`
-	RPCHandshakeTimeout   *string  `mapstructure:"rpc_handshake_timeout"`
-	RPCMaxBurst           *int     `mapstructure:"rpc_max_burst"`
-	RPCMaxConnsPerClient  *int     `mapstructure:"rpc_max_conns_per_client"`
-	RPCRate               *float64 `mapstructure:"rpc_rate"`
-	KVMaxValueSize        *uint64  `mapstructure:"kv_max_value_size"`
-	TxnMaxReqLen          *uint64  `mapstructure:"txn_max_req_len"`
+	HTTPMaxConnsPerClient             *int     `mapstructure:"http_max_conns_per_client"`
+	HTTPSHandshakeTimeout             *string  `mapstructure:"https_handshake_timeout"`
+	RPCHandshakeTimeout               *string  `mapstructure:"rpc_handshake_timeout"`
+	RPCMaxBurst                       *int     `mapstructure:"rpc_max_burst"`
+	RPCMaxConnsPerClient              *int     `mapstructure:"rpc_max_conns_per_client"`
+	RPCMaxConcurrentRequestsPerClient *int     `mapstructure:"rpc_max_concurrent_requests_per_client"`
+	RPCMaxInFlightBytesPerClient      *int     `mapstructure:"rpc_max_inflight_bytes_per_client"`
+	RPCRate                           *float64 `mapstructure:"rpc_rate"`
+	KVMaxValueSize                    *uint64  `mapstructure:"kv_max_value_size"`
+	MaxPerPage                        *int     `mapstructure:"max_per_page"`
+	TxnMaxReqLen                      *uint64  `mapstructure:"txn_max_req_len"`
 }
 
 type Segment struct {
@@ -774,6 +879,24 @@ type ConfigEntries struct {
 	// need to figure out the right concrete type before we can decode it
 	// unabiguously.
 	Bootstrap []map[string]interface{} `mapstructure:"bootstrap"`
+
+	// BootstrapDir is a directory of files each containing a config entry,
+	// which are persisted on initial startup of a new leader like Bootstrap.
+	BootstrapDir *string `mapstructure:"bootstrap_dir"`
+}
+
+// AgentProbes configures the criteria the agent must meet for its liveness
+// and readiness endpoints to succeed.
+type AgentProbes struct {
+	Liveness  []string `mapstructure:"liveness"`
+	Readiness []string `mapstructure:"readiness"`
+}
+
+// AntiEntropy tunes the synchronization of the local state of the agent with
+// the catalog.
+type AntiEntropy struct {
+	SyncInterval *string `mapstructure:"sync_interval"`
+	SyncJitter   *string `mapstructure:"sync_jitter"`
 }
 
 // Audit allows us to enable and define destinations for auditing
@@ -802,6 +925,16 @@ type AutoConfigRaw struct {
 	DNSSANs         []string                   `mapstructure:"dns_sans"`
 	IPSANs          []string                   `mapstructure:"ip_sans"`
 	Authorization   AutoConfigAuthorizationRaw `mapstructure:"authorization"`
+
+	ConfigSnippets    []AutoConfigSnippetRaw `mapstructure:"config_snippets"`
+	AcceptSnippetKeys []string               `mapstructure:"accept_snippet_keys"`
+}
+
+type AutoConfigSnippetRaw struct {
+	Name              *string  `mapstructure:"name"`
+	Config            *string  `mapstructure:"config"`
+	Segments          []string `mapstructure:"segments"`
+	RolloutPercentage *int     `mapstructure:"rollout_percentage"`
 }
 
 type AutoConfigAuthorizationRaw struct {
@@ -810,8 +943,9 @@ type AutoConfigAuthorizationRaw struct {
 }
 
 type AutoConfigAuthorizerRaw struct {
-	ClaimAssertions []string `mapstructure:"claim_assertions"`
-	AllowReuse      *bool    `mapstructure:"allow_reuse"`
+	ClaimAssertions  []string `mapstructure:"claim_assertions"`
+	AllowReuse       *bool    `mapstructure:"allow_reuse"`
+	VerifiedNodeMeta []string `mapstructure:"verified_node_meta"`
 
 	// Fields to be shared with the JWT Auth Method
 	JWTSupportedAlgs     []string          `mapstructure:"jwt_supported_algs"`
@@ -852,7 +986,9 @@ type RawUIMetricsProxyAddHeader struct {
 }
 
 type RPC struct {
-	EnableStreaming *bool `mapstructure:"enable_streaming"`
+	EnableStreaming *bool   `mapstructure:"enable_streaming"`
+	ServerSelection *string `mapstructure:"server_selection"`
+	ServerSlowStart *string `mapstructure:"server_slow_start"`
 }
 
 type TLSProtocolConfig struct {
@@ -865,6 +1001,8 @@ type TLSProtocolConfig struct {
 	VerifyIncoming       *bool   `mapstructure:"verify_incoming"`
 	VerifyOutgoing       *bool   `mapstructure:"verify_outgoing"`
 	VerifyServerHostname *bool   `mapstructure:"verify_server_hostname"`
+
+	ServerSPIFFEIDs []string `mapstructure:"server_spiffe_ids"`
 }
 
 type TLS struct {
@@ -873,6 +1011,8 @@ type TLS struct {
 	HTTPS       TLSProtocolConfig `mapstructure:"https"`
 	GRPC        TLSProtocolConfig `mapstructure:"grpc"`
 
+	AutoReloadCertificates *bool `mapstructure:"auto_reload_certificates"`
+
 	// GRPCModifiedByDeprecatedConfig is a flag used to indicate that GRPC was
 	// modified by the deprecated field mapping (as apposed to a user-provided
 	// a grpc stanza). This prevents us from emitting a warning about an
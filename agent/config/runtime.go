@@ -22,6 +22,38 @@ import (
 	"github.com/hashicorp/consul/types"
 )
 
+// Values of check_update_trigger.
+const (
+	CheckUpdateTriggerOutput = "output"
+	CheckUpdateTriggerHash   = "hash"
+	CheckUpdateTriggerStatus = "status"
+)
+
+// Criteria the agent can be required to meet for its liveness and readiness
+// endpoints to succeed.
+const (
+	// ProbeCriterionSerf requires the agent to be an alive member of its LAN
+	// gossip pool.
+	ProbeCriterionSerf = "serf"
+	// ProbeCriterionServers requires clients to know a server to send RPCs
+	// to and servers to know the raft leader.
+	ProbeCriterionServers = "servers"
+	// ProbeCriterionACLs requires the agent token to be resolved when ACLs
+	// are enabled.
+	ProbeCriterionACLs = "acls"
+	// ProbeCriterionProxyConfig requires the manager of the configuration of
+	// the local proxies to be running, with a complete configuration for
+	// every proxy it manages.
+	ProbeCriterionProxyConfig = "proxycfg"
+)
+
+// RuntimeDrainHook is a command run when the agent drains its services on a
+// graceful leave.
+type RuntimeDrainHook struct {
+	Args    []string
+	Timeout time.Duration
+}
+
 type RuntimeSOAConfig struct {
 	Refresh uint32 // 3600 by default
 	Retry   uint32 // 600
@@ -50,6 +82,8 @@ type StaticRuntimeConfig struct {
 // from files, flags and/or environment variables.
 type RuntimeConfig struct {
 	// non-user configurable values
+	//
+	// AEInterval can be overridden by users with anti_entropy.sync_interval.
 	AEInterval time.Duration
 
 	CheckDeregisterIntervalMin time.Duration
@@ -72,6 +106,13 @@ type RuntimeConfig struct {
 	ConsulRaftLeaderLeaseTimeout     time.Duration
 	ConsulServerHealthInterval       time.Duration
 
+	// AEJitter is the maximum random delay added to AEInterval between two
+	// full anti-entropy syncs, before it is scaled with the size of the
+	// cluster. Defaults to AEInterval.
+	//
+	// hcl: anti_entropy { sync_jitter = "duration" }
+	AEJitter time.Duration
+
 	// ACLsEnabled is used to determine whether ACLs should be enabled
 	//
 	// hcl: acl.enabled = boolean
@@ -235,6 +276,19 @@ type RuntimeConfig struct {
 	// hcl: dns_config { only_passing = (true|false) }
 	DNSOnlyPassing bool
 
+	// DNSOnlyLocalSegment is used to determine whether to filter node and
+	// service lookups down to the nodes in the agent's own network segment.
+	//
+	// hcl: dns_config { only_local_segment = (true|false) }
+	DNSOnlyLocalSegment bool
+
+	// DNSPreferClientSubnet is used to determine whether the instances of a
+	// service whose address is within the EDNS client subnet of a query are
+	// returned first. The responses are then only valid for that subnet.
+	//
+	// hcl: dns_config { prefer_client_subnet = (true|false) }
+	DNSPreferClientSubnet bool
+
 	// DNSRecursorStrategy controls the order in which DNS recursors are queried.
 	// 'sequential' queries recursors in the order they are listed under `recursors`.
 	// 'random' causes random selection of recursors which has the effect of
@@ -268,6 +322,13 @@ type RuntimeConfig struct {
 	// hcl: dns_config { udp_answer_limit = int }
 	DNSUDPAnswerLimit int
 
+	// DNSServiceUDPAnswerLimit overrides DNSUDPAnswerLimit for the given
+	// services. A name ending with the "*" wildcard sets the limit of all
+	// the services with that prefix.
+	//
+	// hcl: dns_config { service_udp_answer_limit = map[string]int }
+	DNSServiceUDPAnswerLimit map[string]int
+
 	// DNSNodeMetaTXT controls whether DNS queries will synthesize
 	// TXT records for the node metadata and add them when not specifically
 	// request (query type = TXT). If unset this will default to true
@@ -280,6 +341,20 @@ type RuntimeConfig struct {
 	// flag: -recursor string [-recursor string]
 	DNSRecursors []string
 
+	// DNSRecursorZones maps DNS zones to the recursors that resolve them,
+	// instead of DNSRecursors. The recursors of the longest zone matching
+	// a query are used.
+	//
+	// hcl: dns_config { recursor_zones = map[string][]string }
+	DNSRecursorZones map[string][]string
+
+	// DNSAllowRecursionFrom restricts the recursive queries to the clients
+	// of the given networks. Recursive queries from other clients are
+	// refused. An empty slice means no restriction.
+	//
+	// hcl: dns_config { allow_recursion_from = []string }
+	DNSAllowRecursionFrom []*net.IPNet
+
 	// DNSUseCache whether or not to use cache for dns queries
 	//
 	// hcl: dns_config { use_cache = (true|false) }
@@ -315,6 +390,14 @@ type RuntimeConfig struct {
 	// hcl: http_config { response_headers = map[string]string }
 	HTTPResponseHeaders map[string]string
 
+	// HTTPUnixSockets are additional unix sockets serving the HTTP API, each
+	// with its own file permissions and, optionally, a list of the endpoint
+	// prefixes it allows. They can be used to give local workloads access to
+	// a subset of the API only, like the service registration.
+	//
+	// hcl: http_config { unix_sockets = [ { path = string allow_endpoints = []string group = string mode = string user = string } ] }
+	HTTPUnixSockets []HTTPUnixSocket
+
 	// Embed Telemetry Config
 	Telemetry lib.TelemetryConfig
 
@@ -346,6 +429,19 @@ type RuntimeConfig struct {
 	// flag: -node string
 	NodeName string
 
+	// AgentLivenessCriteria are the criteria the agent must meet for the
+	// /v1/agent/live endpoint to succeed, among the ProbeCriterion values.
+	//
+	// hcl: agent_probes { liveness = []string }
+	AgentLivenessCriteria []string
+
+	// AgentReadinessCriteria are the criteria the agent must meet for the
+	// /v1/agent/ready endpoint to succeed, among the ProbeCriterion values.
+	// Defaults to serf and servers.
+	//
+	// hcl: agent_probes { readiness = []string }
+	AgentReadinessCriteria []string
+
 	// AdvertiseAddrLAN is the address we use for advertising our Serf, and
 	// Consul RPC IP. The address can be specified as an ip address or as a
 	// go-sockaddr template which resolves to a single ip address. If not
@@ -391,9 +487,40 @@ type RuntimeConfig struct {
 	// flag: -bootstrap-expect=int
 	BootstrapExpect int
 
+	// BoundedStaleMaxLag is the number of Raft log entries a server may have
+	// left to apply and still serve reads using the bounded_stale consistency
+	// mode, when the request doesn't specify it. Defaults to 100.
+	//
+	// hcl: bounded_stale_max_lag = int
+	BoundedStaleMaxLag int
+
 	// Cache represent cache configuration of agent
 	Cache cache.Options
 
+	// CatalogCheckOutputCompression makes the servers compress the large
+	// outputs of the health checks in the Raft log. It must only be enabled
+	// once all the servers support it.
+	//
+	// hcl: catalog_check_output_compression = (true|false)
+	CatalogCheckOutputCompression bool
+
+	// CatalogCheckOutputMaxSize is the maximum size of the output of a health
+	// check stored in the catalog by the servers. Longer outputs are truncated.
+	// Zero means no limit.
+	//
+	// hcl: catalog_check_output_max_size = int
+	CatalogCheckOutputMaxSize int
+
+	// CheckSyncFlushInterval controls how long the status changes of health
+	// checks are accumulated before they are sent to the servers. The changes
+	// accumulated during the interval are sent together in as few requests as
+	// possible, which reduces the write load on the leader when many checks
+	// change at once, for example during the outage of a shared dependency.
+	// Zero sends every status change right away.
+	//
+	// hcl: check_sync_flush_interval = "duration"
+	CheckSyncFlushInterval time.Duration
+
 	// CheckUpdateInterval controls the interval on which the output of a health check
 	// is updated if there is no change to the state. For example, a check in a steady
 	// state may run every 5 second generating a unique output (timestamp, etc), forcing
@@ -405,6 +532,15 @@ type RuntimeConfig struct {
 	// hcl: check_update_interval = "duration"
 	CheckUpdateInterval time.Duration
 
+	// CheckUpdateTrigger controls which changes of a health check are sent to
+	// the servers. With "output", the default, any change of the status or
+	// output is. With "hash", output changes are only sent if the hash of the
+	// output differs from the one of the output the servers have. With
+	// "status", only status changes are, along with the output at that time.
+	//
+	// hcl: check_update_trigger = ("output"|"hash"|"status")
+	CheckUpdateTrigger string
+
 	// Maximum size for the output of a healtcheck
 	// hcl check_output_max_size int
 	// flag: -check_output_max_size int
@@ -492,6 +628,19 @@ type RuntimeConfig struct {
 	// service like any other upstream.
 	ConnectServerlessPluginEnabled bool
 
+	// ConnectMeshReadinessCheckEnabled opts the agent into registering a
+	// synthetic health check on every local connect proxy service. The check
+	// is only passing once the proxy's configuration snapshot is complete and
+	// its leaf certificate is valid, so the proxy can actually route traffic.
+	ConnectMeshReadinessCheckEnabled bool
+
+	// ConnectMeshProbesEnabled lets local connect proxies opt into probing
+	// their upstreams with the mesh_probe key of their proxy config. A
+	// synthetic health check on the proxy reports whether mTLS handshakes
+	// from the proxy's identity to a sample of its upstreams, and connections
+	// through its upstream listeners, succeed.
+	ConnectMeshProbesEnabled bool
+
 	// ConnectSidecarMinPort is the inclusive start of the range of ports
 	// allocated to the agent for asigning to sidecar services where no port is
 	// specified.
@@ -520,6 +669,24 @@ type RuntimeConfig struct {
 	// datacenters should exclusively traverse mesh gateways.
 	ConnectMeshGatewayWANFederationEnabled bool
 
+	// ConnectCARootsWebhook is the URL to which the leader posts the changes
+	// to the certificates trusted by the Connect CA.
+	//
+	// hcl: connect { ca_roots_webhook = string }
+	ConnectCARootsWebhook string
+
+	// ConnectCARootsWebhookSecret is the key of the HMAC-SHA256 signing the
+	// changes posted to ConnectCARootsWebhook.
+	//
+	// hcl: connect { ca_roots_webhook_secret = string }
+	ConnectCARootsWebhookSecret string
+
+	// ConnectTrafficShiftPrometheusAddresses are the base URLs of the
+	// Prometheus HTTP APIs which traffic-shift checks are allowed to query.
+	//
+	// hcl: connect { traffic_shift_prometheus_addresses = []string }
+	ConnectTrafficShiftPrometheusAddresses []string
+
 	// ConnectTestCALeafRootChangeSpread is used to control how long the CA leaf
 	// cache with spread CSRs over when a root change occurs. For now we don't
 	// expose this in public config intentionally but could later with a rename.
@@ -647,6 +814,13 @@ type RuntimeConfig struct {
 	// hcl: enable_debug = (true|false)
 	EnableDebug bool
 
+	// EnableExpiryChecks enables the checks which warn about the agent TLS
+	// certificate and the Connect CA expiring, and about incomplete gossip
+	// keyring rotations.
+	//
+	// hcl: enable_expiry_checks = (true|false)
+	EnableExpiryChecks bool
+
 	// EnableLocalScriptChecks controls whether health checks declared from the local
 	// config file which execute scripts are enabled. This includes regular script
 	// checks and Docker checks.
@@ -676,14 +850,15 @@ type RuntimeConfig struct {
 	// flags: -grpc-port int
 	GRPCPort int
 
-	// GRPCAddrs contains the list of TCP addresses and UNIX sockets the gRPC
-	// server will bind to. If the gRPC endpoint is disabled (ports.grpc <= 0)
-	// the list is empty.
+	// GRPCAddrs contains the list of TCP addresses, UNIX sockets and Windows
+	// named pipes the gRPC server will bind to. If the gRPC endpoint is
+	// disabled (ports.grpc <= 0) the list is empty.
 	//
 	// The addresses are taken from 'addresses.grpc' which should contain a
-	// space separated list of ip addresses, UNIX socket paths and/or
-	// go-sockaddr templates. UNIX socket paths must be written as
-	// 'unix://<full path>', e.g. 'unix:///var/run/consul-grpc.sock'.
+	// space separated list of ip addresses, UNIX socket paths, named pipes
+	// and/or go-sockaddr templates. UNIX socket paths must be written as
+	// 'unix://<full path>', e.g. 'unix:///var/run/consul-grpc.sock', and
+	// named pipes as 'npipe://<pipe name>', e.g. 'npipe://\\.\pipe\consul-grpc'.
 	//
 	// If 'addresses.grpc' was not provided the 'client_addr' addresses are
 	// used.
@@ -761,6 +936,12 @@ type RuntimeConfig struct {
 	// hcl: limits { kv_max_value_size = uint64 }
 	KVMaxValueSize uint64
 
+	// MaxPerPage is the maximum number of results returned in a page of the
+	// paginated list endpoints. Larger per_page values are lowered to it.
+	//
+	// hcl: limits { max_per_page = int }
+	MaxPerPage int
+
 	// LeaveDrainTime is used to wait after a server has left the LAN Serf
 	// pool for RPCs to drain and new requests to be sent to other servers.
 	//
@@ -887,6 +1068,20 @@ type RuntimeConfig struct {
 	// hcl: limits{ rpc_max_conns_per_client = 100 }
 	RPCMaxConnsPerClient int
 
+	// RPCMaxConcurrentRequestsPerClient limits the number of RPC requests the
+	// server processes at once for any single source IP address other than
+	// the servers of the datacenter. Zero means no limit.
+	//
+	// hcl: limits{ rpc_max_concurrent_requests_per_client = 50 }
+	RPCMaxConcurrentRequestsPerClient int
+
+	// RPCMaxInFlightBytesPerClient limits the total size of the RPC requests
+	// the server processes at once for any single source IP address other
+	// than the servers of the datacenter. Zero means no limit.
+	//
+	// hcl: limits{ rpc_max_inflight_bytes_per_client = 10485760 }
+	RPCMaxInFlightBytesPerClient int
+
 	// RPCProtocol is the Consul protocol version to use.
 	//
 	// hcl: protocol = int
@@ -894,6 +1089,19 @@ type RuntimeConfig struct {
 
 	RPCConfig consul.RPCConfig
 
+	// RPCServerSelection is the policy used to pick the server the agent
+	// sends its RPCs to, either "random" or "health".
+	//
+	// hcl: rpc { server_selection = ("random"|"health") }
+	RPCServerSelection string
+
+	// RPCServerSlowStart is the duration over which the share of the RPCs
+	// sent to a newly seen server ramps up with the "health" server
+	// selection.
+	//
+	// hcl: rpc { server_slow_start = "duration" }
+	RPCServerSlowStart time.Duration
+
 	// UseStreamingBackend enables streaming as a replacement for agent/cache
 	// in the client agent for endpoints which support streaming.
 	UseStreamingBackend bool
@@ -915,6 +1123,13 @@ type RuntimeConfig struct {
 	// hcl: raft_snapshot_threshold = int
 	RaftSnapshotInterval time.Duration
 
+	// RaftSnapshotAutoTune enables tuning the snapshot threshold and interval
+	// based on the log growth rate and the time taken to write snapshots, using
+	// RaftSnapshotThreshold and RaftSnapshotInterval as the baseline.
+	//
+	// hcl: raft_snapshot_auto_tune = (true|false)
+	RaftSnapshotAutoTune bool
+
 	// RaftTrailingLogs sets the number of log entries that will be left in the
 	// log store after a snapshot. This must be large enough that a follower can
 	// transfer and restore an entire snapshot of the state before this many new
@@ -1012,7 +1227,6 @@ type RuntimeConfig struct {
 	RetryJoinWAN []string
 
 	// SegmentName is the network segment for this client to join.
-	// (Enterprise-only)
 	//
 	// hcl: segment = string
 	SegmentName string
@@ -1178,6 +1392,16 @@ type RuntimeConfig struct {
 	// hcl: gossip_lan { retransmit_mult = int }
 	GossipLANRetransmitMult int
 
+	// GossipLANTCPOnly makes the LAN gossip pool send the packets over TCP
+	// streams instead of UDP, for networks where UDP is blocked between the
+	// agents. The probe timeout and interval are raised to at least 1s and
+	// 2s respectively to account for the TCP round trips.
+	//
+	// The default is: false
+	//
+	// hcl: gossip_lan { tcp_only = (true|false) }
+	GossipLANTCPOnly bool
+
 	// GossipWANGossipInterval  is the interval between sending messages that need
 	// to be gossiped that haven't been able to piggyback on probing messages.
 	// If this is set to zero, non-piggyback gossip is disabled. By lowering
@@ -1255,6 +1479,35 @@ type RuntimeConfig struct {
 	// hcl: gossip_wan { retransmit_mult = int }
 	GossipWANRetransmitMult int
 
+	// GossipWANTCPOnly makes the WAN gossip pool send the packets over TCP
+	// streams instead of UDP.
+	//
+	// The default is: false
+	//
+	// hcl: gossip_wan { tcp_only = (true|false) }
+	GossipWANTCPOnly bool
+
+	// GracefulShutdownDrainDelay is the time the agent waits after marking its
+	// services critical on a graceful leave, so that proxies and DNS clients
+	// stop routing traffic to them before they are deregistered.
+	//
+	// hcl: graceful_shutdown { drain_delay = "duration" }
+	GracefulShutdownDrainDelay time.Duration
+
+	// GracefulShutdownDrainHooks are the commands run on a graceful leave
+	// once the drain delay has elapsed, before the services are deregistered.
+	//
+	// hcl: graceful_shutdown { drain_hooks = [ { args = []string timeout = "duration" } ] }
+	GracefulShutdownDrainHooks []RuntimeDrainHook
+
+	// GracefulShutdownDeregisterServices makes the agent deregister its
+	// services from the catalog on a graceful leave, before leaving the
+	// gossip pool. They are still restored from the local state when the
+	// agent restarts.
+	//
+	// hcl: graceful_shutdown { deregister_services = (true|false) }
+	GracefulShutdownDeregisterServices bool
+
 	// ServerMode controls if this agent acts like a Consul server,
 	// or merely as a client. Servers have more state, take part
 	// in leader election, etc.
@@ -1415,6 +1668,15 @@ type AutoConfig struct {
 	DNSSANs         []string
 	IPSANs          []net.IP
 	Authorizer      AutoConfigAuthorizer
+
+	// ConfigSnippets are the configuration fragments a server distributes to
+	// the agents it configures.
+	ConfigSnippets []consul.AutoConfigSnippet
+
+	// AcceptSnippetKeys are the top level configuration keys an agent accepts
+	// from the config snippets distributed by the servers. A snippet setting
+	// any other key is ignored.
+	AcceptSnippetKeys []string
 }
 
 type AutoConfigAuthorizer struct {
@@ -1423,6 +1685,26 @@ type AutoConfigAuthorizer struct {
 	// AuthMethodConfig ssoauth.Config
 	ClaimAssertions []string
 	AllowReuse      bool
+
+	// VerifiedNodeMeta are the claims, as named by the claim mappings, the
+	// servers attest as node metadata of the agents they configure.
+	VerifiedNodeMeta []string
+}
+
+// HTTPUnixSocket is an additional unix socket serving the HTTP API.
+type HTTPUnixSocket struct {
+	// Path is the path of the socket file.
+	Path string
+
+	// AllowEndpoints is the list of the endpoint prefixes served by the
+	// socket. An empty list allows all the endpoints.
+	AllowEndpoints []string
+
+	// Group, Mode and User are the file permissions of the socket. They
+	// default to the ones of the unix_sockets config.
+	Group string
+	Mode  string
+	User  string
 }
 
 type UIConfig struct {
@@ -1480,6 +1762,15 @@ func (c *RuntimeConfig) apiAddresses(maxPerType int) (unixAddrs, httpAddrs, http
 	return
 }
 
+// NamedPipeAddr is the address of a Windows named pipe, such as
+// \\.\pipe\consul-grpc.
+type NamedPipeAddr struct {
+	Name string
+}
+
+func (a *NamedPipeAddr) Network() string { return "npipe" }
+func (a *NamedPipeAddr) String() string  { return a.Name }
+
 func (c *RuntimeConfig) ClientAddress() (unixAddr, httpAddr, httpsAddr string) {
 	unixAddrs, httpAddrs, httpsAddrs := c.apiAddresses(0)
 
@@ -1687,6 +1978,8 @@ func sanitize(name string, v reflect.Value) reflect.Value {
 			return reflect.ValueOf("udp://" + x.String())
 		case *net.UnixAddr:
 			return reflect.ValueOf("unix://" + x.String())
+		case *NamedPipeAddr:
+			return reflect.ValueOf("npipe://" + x.String())
 		case *net.IPAddr:
 			return reflect.ValueOf(x.IP.String())
 		case *net.IPNet:
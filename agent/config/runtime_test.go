@@ -694,6 +694,61 @@ func TestLoad_IntegrationWithFlags(t *testing.T) {
 		},
 		expectedErr: "raft_protocol version 2 is not supported by this version of Consul",
 	})
+	run(t, testCase{
+		desc: "redact_patterns",
+		args: []string{`-data-dir=` + dataDir},
+		json: []string{`{ "redact_patterns": ["ak-[0-9a-f]+"] }`},
+		hcl:  []string{`redact_patterns = ["ak-[0-9a-f]+"]`},
+		expected: func(rt *RuntimeConfig) {
+			rt.Logging.RedactPatterns = []string{"ak-[0-9a-f]+"}
+			rt.DataDir = dataDir
+		},
+	})
+	run(t, testCase{
+		desc: "log_levels",
+		args: []string{`-data-dir=` + dataDir},
+		json: []string{`{ "log_levels": { "xds": "debug", "raft": "warn" } }`},
+		hcl:  []string{`log_levels { xds = "debug" raft = "warn" }`},
+		expected: func(rt *RuntimeConfig) {
+			rt.Logging.LogLevels = map[string]string{"xds": "debug", "raft": "warn"}
+			rt.DataDir = dataDir
+		},
+	})
+	run(t, testCase{
+		desc:        "log_levels unknown subsystem",
+		args:        []string{`-data-dir=` + dataDir},
+		json:        []string{`{ "log_levels": { "http": "debug" } }`},
+		hcl:         []string{`log_levels { http = "debug" }`},
+		expectedErr: `log_levels: Unknown log subsystem "http"`,
+	})
+	run(t, testCase{
+		desc:        "log_levels invalid level",
+		args:        []string{`-data-dir=` + dataDir},
+		json:        []string{`{ "log_levels": { "xds": "loud" } }`},
+		hcl:         []string{`log_levels { xds = "loud" }`},
+		expectedErr: `log_levels: invalid log level "loud" for subsystem "xds"`,
+	})
+	run(t, testCase{
+		desc:        "redact_patterns invalid",
+		args:        []string{`-data-dir=` + dataDir},
+		json:        []string{`{ "redact_patterns": ["ak-[0-9"] }`},
+		hcl:         []string{`redact_patterns = ["ak-[0-9"]`},
+		expectedErr: `redact_patterns: invalid pattern "ak-[0-9"`,
+	})
+	run(t, testCase{
+		desc:        "bounded_stale_max_lag invalid",
+		args:        []string{`-data-dir=` + dataDir},
+		json:        []string{`{ "bounded_stale_max_lag": 0 }`},
+		hcl:         []string{`bounded_stale_max_lag = 0`},
+		expectedErr: `bounded_stale_max_lag must be strictly positive, was: 0`,
+	})
+	run(t, testCase{
+		desc:        "cache type max_entries invalid",
+		args:        []string{`-data-dir=` + dataDir},
+		json:        []string{`{ "cache": { "types": { "health-services": { "max_entries": -1 } } } }`},
+		hcl:         []string{`cache { types { "health-services" { max_entries = -1 } } }`},
+		expectedErr: `cache.types["health-services"].max_entries cannot be negative, was: -1`,
+	})
 	run(t, testCase{
 		desc: "-recursor",
 		args: []string{
@@ -1681,6 +1736,62 @@ func TestLoad_IntegrationWithFlags(t *testing.T) {
 		expectedWarnings: []string{`config key "acl_enforce_version_8" is deprecated and should be removed`},
 	})
 
+	run(t, testCase{
+		desc: "agent_probes defaults",
+		args: []string{`-data-dir=` + dataDir},
+		expected: func(rt *RuntimeConfig) {
+			rt.DataDir = dataDir
+			rt.AgentLivenessCriteria = nil
+			rt.AgentReadinessCriteria = []string{"serf", "servers"}
+		},
+	})
+	run(t, testCase{
+		desc: "agent_probes custom criteria",
+		args: []string{`-data-dir=` + dataDir},
+		json: []string{`{ "agent_probes": { "liveness": ["serf"], "readiness": ["serf", "acls", "proxycfg"] } }`},
+		hcl:  []string{`agent_probes { liveness = ["serf"] readiness = ["serf", "acls", "proxycfg"] }`},
+		expected: func(rt *RuntimeConfig) {
+			rt.DataDir = dataDir
+			rt.AgentLivenessCriteria = []string{"serf"}
+			rt.AgentReadinessCriteria = []string{"serf", "acls", "proxycfg"}
+		},
+	})
+	run(t, testCase{
+		desc:        "agent_probes invalid criterion",
+		args:        []string{`-data-dir=` + dataDir},
+		json:        []string{`{ "agent_probes": { "liveness": ["raft"] } }`},
+		hcl:         []string{`agent_probes { liveness = ["raft"] }`},
+		expectedErr: `agent_probes.liveness: invalid criterion "raft", must be one of "serf", "servers", "acls" or "proxycfg"`,
+	})
+
+	run(t, testCase{
+		desc: "graceful_shutdown drain hook default timeout",
+		args: []string{`-data-dir=` + dataDir},
+		json: []string{`{ "graceful_shutdown": { "drain_delay": "5s", "drain_hooks": [ { "args": ["drain"] } ] } }`},
+		hcl:  []string{`graceful_shutdown { drain_delay = "5s" drain_hooks = [ { args = ["drain"] } ] }`},
+		expected: func(rt *RuntimeConfig) {
+			rt.DataDir = dataDir
+			rt.GracefulShutdownDrainDelay = 5 * time.Second
+			rt.GracefulShutdownDrainHooks = []RuntimeDrainHook{
+				{Args: []string{"drain"}, Timeout: 30 * time.Second},
+			}
+		},
+	})
+	run(t, testCase{
+		desc:        "graceful_shutdown drain hook without args",
+		args:        []string{`-data-dir=` + dataDir},
+		json:        []string{`{ "graceful_shutdown": { "drain_hooks": [ { "timeout": "5s" } ] } }`},
+		hcl:         []string{`graceful_shutdown { drain_hooks = [ { timeout = "5s" } ] }`},
+		expectedErr: "graceful_shutdown.drain_hooks[0].args cannot be empty",
+	})
+	run(t, testCase{
+		desc:        "graceful_shutdown negative drain delay",
+		args:        []string{`-data-dir=` + dataDir},
+		json:        []string{`{ "graceful_shutdown": { "drain_delay": "-5s" } }`},
+		hcl:         []string{`graceful_shutdown { drain_delay = "-5s" }`},
+		expectedErr: "graceful_shutdown.drain_delay cannot be negative",
+	})
+
 	run(t, testCase{
 		desc: "advertise address detect fails v4",
 		args: []string{`-data-dir=` + dataDir},
@@ -1763,6 +1874,82 @@ func TestLoad_IntegrationWithFlags(t *testing.T) {
 			rt.AEInterval = time.Minute
 		},
 	})
+	run(t, testCase{
+		desc: "anti_entropy sync interval and jitter",
+		args: []string{`-data-dir=` + dataDir},
+		json: []string{`{ "anti_entropy": { "sync_interval": "5m" } }`},
+		hcl:  []string{`anti_entropy { sync_interval = "5m" }`},
+		expected: func(rt *RuntimeConfig) {
+			rt.DataDir = dataDir
+			rt.AEInterval = 5 * time.Minute
+			rt.AEJitter = 5 * time.Minute
+		},
+	})
+	run(t, testCase{
+		desc: "anti_entropy sync jitter",
+		args: []string{`-data-dir=` + dataDir},
+		json: []string{`{ "anti_entropy": { "sync_jitter": "10s" } }`},
+		hcl:  []string{`anti_entropy { sync_jitter = "10s" }`},
+		expected: func(rt *RuntimeConfig) {
+			rt.DataDir = dataDir
+			rt.AEJitter = 10 * time.Second
+		},
+	})
+	run(t, testCase{
+		desc:        "anti_entropy sync interval must be positive",
+		args:        []string{`-data-dir=` + dataDir},
+		json:        []string{`{ "anti_entropy": { "sync_interval": "-1s" } }`},
+		hcl:         []string{`anti_entropy { sync_interval = "-1s" }`},
+		expectedErr: "anti_entropy.sync_interval cannot be -1s. Must be positive",
+	})
+	run(t, testCase{
+		desc:        "anti_entropy sync jitter must be positive",
+		args:        []string{`-data-dir=` + dataDir},
+		json:        []string{`{ "anti_entropy": { "sync_jitter": "-1s" } }`},
+		hcl:         []string{`anti_entropy { sync_jitter = "-1s" }`},
+		expectedErr: "anti_entropy.sync_jitter cannot be -1s. Must be positive",
+	})
+	run(t, testCase{
+		desc:        "catalog_check_output_max_size must not be negative",
+		args:        []string{`-data-dir=` + dataDir},
+		json:        []string{`{ "catalog_check_output_max_size": -1 }`},
+		hcl:         []string{`catalog_check_output_max_size = -1`},
+		expectedErr: "catalog_check_output_max_size cannot be -1. Must be zero or at least 23",
+	})
+	run(t, testCase{
+		desc:        "catalog_check_output_max_size too small for the truncation suffix",
+		args:        []string{`-data-dir=` + dataDir},
+		json:        []string{`{ "catalog_check_output_max_size": 10 }`},
+		hcl:         []string{`catalog_check_output_max_size = 10`},
+		expectedErr: "catalog_check_output_max_size cannot be 10. Must be zero or at least 23",
+	})
+	run(t, testCase{
+		desc: "check_update_trigger hash",
+		args: []string{`-data-dir=` + dataDir},
+		json: []string{`{ "check_update_trigger": "hash" }`},
+		hcl:  []string{`check_update_trigger = "hash"`},
+		expected: func(rt *RuntimeConfig) {
+			rt.DataDir = dataDir
+			rt.CheckUpdateTrigger = CheckUpdateTriggerHash
+		},
+	})
+	run(t, testCase{
+		desc: "check_update_trigger status",
+		args: []string{`-data-dir=` + dataDir},
+		json: []string{`{ "check_update_trigger": "status" }`},
+		hcl:  []string{`check_update_trigger = "status"`},
+		expected: func(rt *RuntimeConfig) {
+			rt.DataDir = dataDir
+			rt.CheckUpdateTrigger = CheckUpdateTriggerStatus
+		},
+	})
+	run(t, testCase{
+		desc:        "check_update_trigger invalid",
+		args:        []string{`-data-dir=` + dataDir},
+		json:        []string{`{ "check_update_trigger": "sha" }`},
+		hcl:         []string{`check_update_trigger = "sha"`},
+		expectedErr: `check_update_trigger must be "output", "hash" or "status", got "sha"`,
+	})
 	run(t, testCase{
 		desc: "primary_datacenter invalid",
 		args: []string{
@@ -1968,6 +2155,27 @@ func TestLoad_IntegrationWithFlags(t *testing.T) {
 		hcl:         []string{`addresses = { dns = "unix:///foo" }`},
 		expectedErr: "DNS address cannot be a unix socket",
 	})
+	run(t, testCase{
+		desc: "grpc named pipe",
+		args: []string{`-data-dir=` + dataDir},
+		json: []string{`{ "addresses": { "grpc": "npipe://\\\\.\\pipe\\consul-grpc" }, "ports": { "grpc": 8502 } }`},
+		hcl:  []string{`addresses = { grpc = "npipe://\\\\.\\pipe\\consul-grpc" } ports = { grpc = 8502 }`},
+		expected: func(rt *RuntimeConfig) {
+			rt.GRPCPort = 8502
+			rt.GRPCAddrs = []net.Addr{&NamedPipeAddr{Name: `\\.\pipe\consul-grpc`}}
+			rt.DataDir = dataDir
+		},
+	})
+	run(t, testCase{
+		desc: "http does not allow named pipe",
+		args: []string{
+			`-datacenter=a`,
+			`-data-dir=` + dataDir,
+		},
+		json:        []string{`{ "addresses": { "http": "npipe://\\\\.\\pipe\\consul-http" } }`},
+		hcl:         []string{`addresses = { http = "npipe://\\\\.\\pipe\\consul-http" }`},
+		expectedErr: "addresses.http cannot be a named pipe",
+	})
 	run(t, testCase{
 		desc: "ui enabled and dir specified",
 		args: []string{
@@ -2156,6 +2364,76 @@ func TestLoad_IntegrationWithFlags(t *testing.T) {
 				`},
 		expectedErr: "HTTPS address 1.2.3.4:1000 already configured for HTTP",
 	})
+	run(t, testCase{
+		desc: "unique listeners http vs http unix socket",
+		args: []string{
+			`-data-dir=` + dataDir,
+		},
+		json: []string{`{
+					"addresses": { "http": "unix:///tmp/consul.sock" },
+					"http_config": { "unix_sockets": [{ "path": "/tmp/consul.sock" }] }
+				}`},
+		hcl: []string{`
+					addresses = { http = "unix:///tmp/consul.sock" }
+					http_config = { unix_sockets = [{ path = "/tmp/consul.sock" }] }
+				`},
+		expectedErr: "http_config.unix_sockets[0] address /tmp/consul.sock already configured for HTTP",
+	})
+	run(t, testCase{
+		desc: "http unix socket without path",
+		args: []string{
+			`-data-dir=` + dataDir,
+		},
+		json: []string{`{
+					"http_config": { "unix_sockets": [{ "allow_endpoints": ["/v1/agent/service/register"] }] }
+				}`},
+		hcl: []string{`
+					http_config = { unix_sockets = [{ allow_endpoints = ["/v1/agent/service/register"] }] }
+				`},
+		expectedErr: "http_config.unix_sockets[0].path is required",
+	})
+	run(t, testCase{
+		desc: "http unix socket with relative endpoint",
+		args: []string{
+			`-data-dir=` + dataDir,
+		},
+		json: []string{`{
+					"http_config": { "unix_sockets": [{ "path": "/tmp/consul.sock", "allow_endpoints": ["v1/agent/service/register"] }] }
+				}`},
+		hcl: []string{`
+					http_config = { unix_sockets = [{ path = "/tmp/consul.sock" allow_endpoints = ["v1/agent/service/register"] }] }
+				`},
+		expectedErr: `http_config.unix_sockets[0].allow_endpoints: "v1/agent/service/register" must start with a /`,
+	})
+	run(t, testCase{
+		desc: "http unix sockets default permissions",
+		args: []string{
+			`-data-dir=` + dataDir,
+		},
+		json: []string{`{
+					"unix_sockets": { "user": "1000", "mode": "0600" },
+					"http_config": { "unix_sockets": [
+						{ "path": "/tmp/operators.sock" },
+						{ "path": "/tmp/workloads.sock", "allow_endpoints": ["/v1/agent/service/"], "mode": "0666" }
+					] }
+				}`},
+		hcl: []string{`
+					unix_sockets = { user = "1000" mode = "0600" }
+					http_config = { unix_sockets = [
+						{ path = "/tmp/operators.sock" },
+						{ path = "/tmp/workloads.sock" allow_endpoints = ["/v1/agent/service/"] mode = "0666" },
+					] }
+				`},
+		expected: func(rt *RuntimeConfig) {
+			rt.DataDir = dataDir
+			rt.UnixSocketUser = "1000"
+			rt.UnixSocketMode = "0600"
+			rt.HTTPUnixSockets = []HTTPUnixSocket{
+				{Path: "/tmp/operators.sock", User: "1000", Mode: "0600"},
+				{Path: "/tmp/workloads.sock", AllowEndpoints: []string{"/v1/agent/service/"}, User: "1000", Mode: "0666"},
+			}
+		},
+	})
 	run(t, testCase{
 		desc: "unique advertise addresses HTTP vs RPC",
 		args: []string{
@@ -2351,6 +2629,24 @@ func TestLoad_IntegrationWithFlags(t *testing.T) {
 		hcl:         []string{` encrypt = "this is not a valid key" `},
 		expectedErr: "encrypt has invalid key: illegal base64 data at input byte 4",
 	})
+	run(t, testCase{
+		desc: "cache persist without key",
+		args: []string{
+			`-data-dir=` + dataDir,
+		},
+		json:        []string{`{ "cache": { "persist": true } }`},
+		hcl:         []string{` cache { persist = true } `},
+		expectedErr: "cache.persist requires cache.persist_key to be set",
+	})
+	run(t, testCase{
+		desc: "cache persist key invalid",
+		args: []string{
+			`-data-dir=` + dataDir,
+		},
+		json:        []string{`{ "cache": { "persist": true, "persist_key": "c2hvcnQ=" } }`},
+		hcl:         []string{` cache { persist = true persist_key = "c2hvcnQ=" } `},
+		expectedErr: "cache.persist_key must be a base64 encoded 32 byte key",
+	})
 	run(t, testCase{
 		desc: "multiple check files",
 		args: []string{
@@ -2611,6 +2907,52 @@ func TestLoad_IntegrationWithFlags(t *testing.T) {
 		`},
 		expectedErr: "verify_server_hostname is only valid in the tls.internal_rpc stanza",
 	})
+	run(t, testCase{
+		desc: "server_spiffe_ids in the defaults stanza",
+		args: []string{
+			`-data-dir=` + dataDir,
+		},
+		hcl: []string{`
+			tls {
+				defaults {
+					server_spiffe_ids = ["spiffe://example.org/server"]
+				}
+			}
+		`},
+		json: []string{`
+			{
+				"tls": {
+					"defaults": {
+						"server_spiffe_ids": ["spiffe://example.org/server"]
+					}
+				}
+			}
+		`},
+		expectedErr: "server_spiffe_ids is only valid in the tls.internal_rpc stanza",
+	})
+	run(t, testCase{
+		desc: "server_spiffe_ids with an invalid ID",
+		args: []string{
+			`-data-dir=` + dataDir,
+		},
+		hcl: []string{`
+			tls {
+				internal_rpc {
+					server_spiffe_ids = ["example.org/server"]
+				}
+			}
+		`},
+		json: []string{`
+			{
+				"tls": {
+					"internal_rpc": {
+						"server_spiffe_ids": ["example.org/server"]
+					}
+				}
+			}
+		`},
+		expectedErr: `tls.internal_rpc.server_spiffe_ids: "example.org/server" is not a SPIFFE ID`,
+	})
 	run(t, testCase{
 		desc: "translated keys",
 		args: []string{
@@ -3145,6 +3487,20 @@ func TestLoad_IntegrationWithFlags(t *testing.T) {
 			rt.RPCConfig.EnableStreaming = true
 		},
 	})
+	run(t, testCase{
+		desc:        "rpc.server_selection invalid",
+		args:        []string{`-data-dir=` + dataDir},
+		json:        []string{`{ "rpc": { "server_selection": "fastest" } }`},
+		hcl:         []string{`rpc { server_selection = "fastest" }`},
+		expectedErr: `rpc.server_selection must be "random" or "health", got "fastest"`,
+	})
+	run(t, testCase{
+		desc:        "limits.rpc_max_inflight_bytes_per_client negative",
+		args:        []string{`-data-dir=` + dataDir},
+		json:        []string{`{ "limits": { "rpc_max_inflight_bytes_per_client": -1 } }`},
+		hcl:         []string{`limits { rpc_max_inflight_bytes_per_client = -1 }`},
+		expectedErr: `limits.rpc_max_inflight_bytes_per_client cannot be -1. Must be positive`,
+	})
 	run(t, testCase{
 		desc: "rpc.enable_streaming = true has no effect when not running in server mode",
 		args: []string{
@@ -3679,6 +4035,122 @@ func TestLoad_IntegrationWithFlags(t *testing.T) {
 			}`},
 		expectedErr: "config_entries.bootstrap[0]: 1 error occurred:\n\t* invalid config key \"made_up_key\"\n\n",
 	})
+	run(t, testCase{
+		desc: "ConfigEntry bootstrap dir",
+		args: []string{`-data-dir=` + dataDir},
+		json: []string{`{
+				"config_entries": {
+					"bootstrap": [
+						{
+							"kind": "service-defaults",
+							"name": "web",
+							"protocol": "http"
+						}
+					],
+					"bootstrap_dir": "` + filepath.Join(dataDir, "entries") + `"
+				}
+			}`},
+		hcl: []string{`
+			config_entries {
+				bootstrap {
+					kind = "service-defaults"
+					name = "web"
+					protocol = "http"
+				}
+				bootstrap_dir = "` + filepath.Join(dataDir, "entries") + `"
+			}`},
+		setup: func() {
+			writeFile(filepath.Join(dataDir, "entries/b-mesh.json"), []byte(`{
+				"Kind": "mesh",
+				"TransparentProxy": {"MeshDestinationsOnly": true}
+			}`))
+			writeFile(filepath.Join(dataDir, "entries/a-proxy-defaults.hcl"), []byte(`
+				Kind = "proxy-defaults"
+				Name = "global"
+				Config {
+					protocol = "http"
+				}
+			`))
+			writeFile(filepath.Join(dataDir, "entries/README.md"), []byte(`not a config entry`))
+			writeFile(filepath.Join(dataDir, "entries/nested/c.hcl"), []byte(`not parsed`))
+		},
+		expected: func(rt *RuntimeConfig) {
+			rt.DataDir = dataDir
+			rt.ConfigEntryBootstrap = []structs.ConfigEntry{
+				&structs.ServiceConfigEntry{
+					Kind:           structs.ServiceDefaults,
+					Name:           "web",
+					Protocol:       "http",
+					EnterpriseMeta: *defaultEntMeta,
+				},
+				&structs.ProxyConfigEntry{
+					Kind:           structs.ProxyDefaults,
+					Name:           structs.ProxyConfigGlobal,
+					EnterpriseMeta: *defaultEntMeta,
+					Config: map[string]interface{}{
+						"protocol": "http",
+					},
+				},
+				&structs.MeshConfigEntry{
+					TransparentProxy: structs.TransparentProxyMeshConfig{
+						MeshDestinationsOnly: true,
+					},
+					EnterpriseMeta: *defaultEntMeta,
+				},
+			}
+		},
+		expectedWarnings: []string{
+			"skipping config entry file " + filepath.Join(dataDir, "entries/README.md") + ", extension must be .hcl or .json",
+		},
+	})
+	run(t, testCase{
+		desc: "ConfigEntry bootstrap dir invalid entry",
+		args: []string{`-data-dir=` + dataDir},
+		json: []string{`{ "config_entries": { "bootstrap_dir": "` + filepath.Join(dataDir, "entries") + `" } }`},
+		hcl:  []string{`config_entries { bootstrap_dir = "` + filepath.Join(dataDir, "entries") + `" }`},
+		setup: func() {
+			writeFile(filepath.Join(dataDir, "entries/web.hcl"), []byte(`
+				Kind = "service-defaults"
+				Name = "web"
+				made_up_key = "blah"
+			`))
+		},
+		expectedErr: "config_entries.bootstrap_dir: " + filepath.Join(dataDir, "entries/web.hcl") + ": 1 error occurred:\n\t* invalid config key \"made_up_key\"\n\n",
+	})
+	run(t, testCase{
+		desc: "ConfigEntry bootstrap dir duplicate entry",
+		args: []string{`-data-dir=` + dataDir},
+		json: []string{`{
+				"config_entries": {
+					"bootstrap": [
+						{
+							"kind": "service-defaults",
+							"name": "web"
+						}
+					],
+					"bootstrap_dir": "` + filepath.Join(dataDir, "entries") + `"
+				}
+			}`},
+		hcl: []string{`
+			config_entries {
+				bootstrap {
+					kind = "service-defaults"
+					name = "web"
+				}
+				bootstrap_dir = "` + filepath.Join(dataDir, "entries") + `"
+			}`},
+		setup: func() {
+			writeFile(filepath.Join(dataDir, "entries/web.json"), []byte(`{"Kind": "service-defaults", "Name": "web"}`))
+		},
+		expectedErr: `config_entries.bootstrap_dir: duplicate config entry "service-defaults" / "web"`,
+	})
+	run(t, testCase{
+		desc:        "ConfigEntry bootstrap dir missing",
+		args:        []string{`-data-dir=` + dataDir},
+		json:        []string{`{ "config_entries": { "bootstrap_dir": "` + filepath.Join(dataDir, "nope") + `" } }`},
+		hcl:         []string{`config_entries { bootstrap_dir = "` + filepath.Join(dataDir, "nope") + `" }`},
+		expectedErr: "config_entries.bootstrap_dir: open " + filepath.Join(dataDir, "nope") + ": no such file or directory",
+	})
 	run(t, testCase{
 		desc: "ConfigEntry bootstrap proxy-defaults (snake-case)",
 		args: []string{`-data-dir=` + dataDir},
@@ -4793,6 +5265,97 @@ func TestLoad_IntegrationWithFlags(t *testing.T) {
 		expectedErr: "auto_config.authorization.enabled cannot be set to true for client agents",
 	})
 
+	run(t, testCase{
+		desc: "auto config snippets client not allowed",
+		args: []string{
+			`-data-dir=` + dataDir,
+		},
+		hcl: []string{`
+				auto_config {
+					config_snippets = [
+						{
+							name = "dns"
+							config = "recursors = [\"198.18.0.1\"]"
+						}
+					]
+				}
+			`},
+		json: []string{`
+			{
+				"auto_config": {
+					"config_snippets": [
+						{
+							"name": "dns",
+							"config": "recursors = [\"198.18.0.1\"]"
+						}
+					]
+				}
+			}`},
+		expectedErr: "auto_config.config_snippets cannot be set for client agents",
+	})
+
+	run(t, testCase{
+		desc: "auto config snippets invalid config",
+		args: []string{
+			`-data-dir=` + dataDir,
+			`-server`,
+		},
+		hcl: []string{`
+				auto_config {
+					config_snippets = [
+						{
+							name = "dns"
+							config = "not_a_key = true"
+						}
+					]
+				}
+			`},
+		json: []string{`
+			{
+				"auto_config": {
+					"config_snippets": [
+						{
+							"name": "dns",
+							"config": "not_a_key = true"
+						}
+					]
+				}
+			}`},
+		expectedErr: "auto_config.config_snippets[dns]: invalid config: invalid config keys: not_a_key",
+	})
+
+	run(t, testCase{
+		desc: "auto config snippets rollout percentage out of range",
+		args: []string{
+			`-data-dir=` + dataDir,
+			`-server`,
+		},
+		hcl: []string{`
+				auto_config {
+					config_snippets = [
+						{
+							name = "dns"
+							config = "recursors = [\"198.18.0.1\"]"
+							rollout_percentage = 101
+						}
+					]
+				}
+			`},
+		json: []string{`
+			{
+				"auto_config": {
+					"config_snippets": [
+						{
+							"name": "dns",
+							"config": "recursors = [\"198.18.0.1\"]",
+							"rollout_percentage": 101
+						}
+					]
+				}
+			}`},
+		expectedErr: "auto_config.config_snippets[dns]: rollout_percentage must be between 0 and 100",
+	})
+
 	run(t, testCase{
 		desc: "auto config authorizer invalid config",
 		args: []string{
@@ -4964,6 +5527,53 @@ func TestLoad_IntegrationWithFlags(t *testing.T) {
 			}`},
 		expectedErr: `auto_config.authorization.static.claim_assertion "values.node == ${node}" is invalid: Selector "values" is not valid`,
 	})
+	run(t, testCase{
+		desc: "auto config authorizer verified node meta not mapped",
+		args: []string{
+			`-data-dir=` + dataDir,
+			`-server`,
+		},
+		hcl: []string{`
+				auto_config {
+					authorization {
+						enabled = true
+						static {
+							jwt_validation_pub_keys = ["-----BEGIN PUBLIC KEY-----\nMFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAERVchfCZng4mmdvQz1+sJHRN40snC\nYt8NjYOnbnScEXMkyoUmASr88gb7jaVAVt3RYASAbgBjB2Z+EUizWkx5Tg==\n-----END PUBLIC KEY-----"]
+							claim_mappings = {
+								"/google/compute_engine/zone" = "zone"
+							}
+							verified_node_meta = ["zone", "instance_id"]
+						}
+					}
+				}
+				tls {
+					internal_rpc {
+						cert_file = "foo"
+					}
+				}
+			`},
+		json: []string{`
+			{
+				"auto_config": {
+					"authorization": {
+						"enabled": true,
+						"static": {
+							"jwt_validation_pub_keys": ["-----BEGIN PUBLIC KEY-----\nMFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAERVchfCZng4mmdvQz1+sJHRN40snC\nYt8NjYOnbnScEXMkyoUmASr88gb7jaVAVt3RYASAbgBjB2Z+EUizWkx5Tg==\n-----END PUBLIC KEY-----"],
+							"claim_mappings": {
+								"/google/compute_engine/zone": "zone"
+							},
+							"verified_node_meta": ["zone", "instance_id"]
+						}
+					}
+				},
+				"tls": {
+					"internal_rpc": {
+						"cert_file": "foo"
+					}
+				}
+			}`},
+		expectedErr: `auto_config.authorization.static.verified_node_meta "instance_id" is not a claim mapped by auto_config.authorization.static.claim_mappings`,
+	})
 	run(t, testCase{
 		desc: "auto config authorizer ok",
 		args: []string{
@@ -5335,6 +5945,36 @@ func TestLoad_IntegrationWithFlags(t *testing.T) {
 			`},
 		expectedErr: `ui_config.metrics_proxy.base_url must be a valid http or https URL.`,
 	})
+	run(t, testCase{
+		desc: "connect.ca_roots_webhook http(s)",
+		args: []string{`-data-dir=` + dataDir},
+		json: []string{`{
+				"connect": {
+					"ca_roots_webhook": "localhost:1234"
+				}
+			}`},
+		hcl: []string{`
+			connect {
+				ca_roots_webhook = "localhost:1234"
+			}
+			`},
+		expectedErr: `connect.ca_roots_webhook must be a valid http or https URL.`,
+	})
+	run(t, testCase{
+		desc: "connect.ca_roots_webhook without secret",
+		args: []string{`-data-dir=` + dataDir},
+		json: []string{`{
+				"connect": {
+					"ca_roots_webhook": "https://ca-hook.example.com/roots"
+				}
+			}`},
+		hcl: []string{`
+			connect {
+				ca_roots_webhook = "https://ca-hook.example.com/roots"
+			}
+			`},
+		expectedErr: `connect.ca_roots_webhook_secret must be set to sign the requests to connect.ca_roots_webhook`,
+	})
 	run(t, testCase{
 		desc: "dashboard_url_templates key format",
 		args: []string{`-data-dir=` + dataDir},
@@ -5650,7 +6290,7 @@ func TestLoad_FullConfig(t *testing.T) {
 	nodeEntMeta := structs.NodeEnterpriseMetaInDefaultPartition()
 	expected := &RuntimeConfig{
 		// non-user configurable values
-		AEInterval:                 time.Minute,
+		AEInterval:                 221 * time.Second,
 		CheckDeregisterIntervalMin: time.Minute,
 		CheckReapInterval:          30 * time.Second,
 		SegmentNameLimit:           64,
@@ -5675,13 +6315,23 @@ func TestLoad_FullConfig(t *testing.T) {
 		GossipLANProbeTimeout:            102 * time.Millisecond,
 		GossipLANSuspicionMult:           1235,
 		GossipLANRetransmitMult:          1234,
+		GossipLANTCPOnly:                 true,
 		GossipWANGossipInterval:          6966 * time.Second,
 		GossipWANGossipNodes:             2,
 		GossipWANProbeInterval:           103 * time.Millisecond,
 		GossipWANProbeTimeout:            104 * time.Millisecond,
 		GossipWANSuspicionMult:           16385,
 		GossipWANRetransmitMult:          16384,
-		ConsulServerHealthInterval:       2 * time.Second,
+		GossipWANTCPOnly:                 true,
+		GracefulShutdownDrainDelay:       7 * time.Second,
+		GracefulShutdownDrainHooks: []RuntimeDrainHook{
+			{Args: []string{"/usr/local/bin/drain", "-q"}, Timeout: 12 * time.Second},
+			{Args: []string{"true"}, Timeout: 30 * time.Second},
+		},
+		GracefulShutdownDeregisterServices: true,
+		ConsulServerHealthInterval:         2 * time.Second,
+
+		AEJitter: 137 * time.Second,
 
 		// user configurable values
 
@@ -5713,6 +6363,8 @@ func TestLoad_FullConfig(t *testing.T) {
 		AdvertiseAddrLAN:                 ipAddr("17.99.29.16"),
 		AdvertiseAddrWAN:                 ipAddr("78.63.37.19"),
 		AdvertiseReconnectTimeout:        0 * time.Second,
+		AgentLivenessCriteria:            []string{"serf"},
+		AgentReadinessCriteria:           []string{"serf", "servers", "acls"},
 		AutopilotCleanupDeadServers:      true,
 		AutopilotDisableUpgradeMigration: true,
 		AutopilotLastContactThreshold:    12705 * time.Second,
@@ -5723,9 +6375,15 @@ func TestLoad_FullConfig(t *testing.T) {
 		AutopilotUpgradeVersionTag:       "W9pDwFAL",
 		BindAddr:                         ipAddr("16.99.34.17"),
 		BootstrapExpect:                  53,
+		BoundedStaleMaxLag:               250,
 		Cache: cache.Options{
 			EntryFetchMaxBurst: 42,
 			EntryFetchRate:     0.334,
+			PersistDir:         filepath.Join(dataDir, "cache"),
+			PersistKey:         "L9ZaRwBqxpoHfQsVH5UVkZKVPVsDpS7uUBeeUGBkjAo=",
+			Types: map[string]cache.TypeOptions{
+				"health-services": {LastGetTTL: 2 * time.Hour, MaxEntries: 500},
+			},
 		},
 		CheckOutputMaxSize: checks.DefaultBufSize,
 		Checks: []*structs.CheckDefinition{
@@ -5814,8 +6472,12 @@ func TestLoad_FullConfig(t *testing.T) {
 				DeregisterCriticalServiceAfter: 13209 * time.Second,
 			},
 		},
-		CheckUpdateInterval: 16507 * time.Second,
-		ClientAddrs:         []*net.IPAddr{ipAddr("93.83.18.19")},
+		CatalogCheckOutputCompression: true,
+		CatalogCheckOutputMaxSize:     3817,
+		CheckSyncFlushInterval:        24810 * time.Second,
+		CheckUpdateInterval:           16507 * time.Second,
+		CheckUpdateTrigger:            "status",
+		ClientAddrs:                   []*net.IPAddr{ipAddr("93.83.18.19")},
 		ConfigEntryBootstrap: []structs.ConfigEntry{
 			&structs.ProxyConfigEntry{
 				Kind:           structs.ProxyDefaults,
@@ -5840,9 +6502,10 @@ func TestLoad_FullConfig(t *testing.T) {
 			IPSANs:          []net.IP{net.IPv4(198, 18, 99, 99)},
 			ServerAddresses: []string{"198.18.100.1"},
 			Authorizer: AutoConfigAuthorizer{
-				Enabled:         true,
-				AllowReuse:      true,
-				ClaimAssertions: []string{"value.node == \"${node}\""},
+				Enabled:          true,
+				AllowReuse:       true,
+				ClaimAssertions:  []string{"value.node == \"${node}\""},
+				VerifiedNodeMeta: []string{"node"},
 				AuthMethod: structs.ACLAuthMethod{
 					Name:           "Auto Config Authorizer",
 					Type:           "jwt",
@@ -5868,6 +6531,15 @@ func TestLoad_FullConfig(t *testing.T) {
 					},
 				},
 			},
+			ConfigSnippets: []consul.AutoConfigSnippet{
+				{
+					Name:              "9vOzjOdP",
+					Config:            `{"log_level": "debug"}`,
+					Segments:          []string{"lB2xXQmk"},
+					RolloutPercentage: 40,
+				},
+			},
+			AcceptSnippetKeys: []string{"telemetry"},
 		},
 		ConnectEnabled:        true,
 		ConnectSidecarMinPort: 8888,
@@ -5880,10 +6552,16 @@ func TestLoad_FullConfig(t *testing.T) {
 			"LeafCertTTL":         "1h",
 			"RootCertTTL":         "96360h",
 			"CSRMaxPerSecond":     float64(100),
+			"CSRMaxBurst":         float64(10),
 			"CSRMaxConcurrent":    float64(2),
 		},
+		ConnectCARootsWebhook:                  "https://ca-hook.example.com/roots",
+		ConnectCARootsWebhookSecret:            "Ohng4eeM",
+		ConnectTrafficShiftPrometheusAddresses: []string{"http://prometheus.example.com:9090"},
 		ConnectMeshGatewayWANFederationEnabled: false,
 		ConnectServerlessPluginEnabled:         true,
+		ConnectMeshReadinessCheckEnabled:       true,
+		ConnectMeshProbesEnabled:               true,
 		DNSAddrs:                               []net.Addr{tcpAddr("93.95.95.81:7001"), udpAddr("93.95.95.81:7001")},
 		DNSARecordLimit:                        29907,
 		DNSAllowStale:                          true,
@@ -5894,6 +6572,8 @@ func TestLoad_FullConfig(t *testing.T) {
 		DNSMaxStale:                            29685 * time.Second,
 		DNSNodeTTL:                             7084 * time.Second,
 		DNSOnlyPassing:                         true,
+		DNSOnlyLocalSegment:                    true,
+		DNSPreferClientSubnet:                  true,
 		DNSPort:                                7001,
 		DNSRecursorStrategy:                    "sequential",
 		DNSRecursorTimeout:                     4427 * time.Second,
@@ -5901,6 +6581,9 @@ func TestLoad_FullConfig(t *testing.T) {
 		DNSSOA:                                 RuntimeSOAConfig{Refresh: 3600, Retry: 600, Expire: 86400, Minttl: 0},
 		DNSServiceTTL:                          map[string]time.Duration{"*": 32030 * time.Second},
 		DNSUDPAnswerLimit:                      29909,
+		DNSRecursorZones:                       map[string][]string{"corp.example.com": {"10.64.0.53", "10.64.0.54:8600"}},
+		DNSAllowRecursionFrom:                  []*net.IPNet{cidr("10.0.0.0/8")},
+		DNSServiceUDPAnswerLimit:               map[string]int{"db-*": 1},
 		DNSNodeMetaTXT:                         true,
 		DNSUseCache:                            true,
 		DNSCacheMaxAge:                         5 * time.Minute,
@@ -5919,6 +6602,7 @@ func TestLoad_FullConfig(t *testing.T) {
 		EnableAgentTLSForChecks:                true,
 		EnableCentralServiceConfig:             false,
 		EnableDebug:                            true,
+		EnableExpiryChecks:                     true,
 		EnableRemoteScriptChecks:               true,
 		EnableLocalScriptChecks:                true,
 		EncryptKey:                             "A4wELWqH",
@@ -5939,52 +6623,70 @@ func TestLoad_FullConfig(t *testing.T) {
 		HTTPMaxHeaderBytes:    10,
 		HTTPSHandshakeTimeout: 2391 * time.Millisecond,
 		HTTPSPort:             15127,
-		HTTPUseCache:          false,
-		KVMaxValueSize:        1234567800,
-		LeaveDrainTime:        8265 * time.Second,
-		LeaveOnTerm:           true,
+		HTTPUnixSockets: []HTTPUnixSocket{
+			{
+				Path:           "/var/run/consul/workloads.sock",
+				AllowEndpoints: []string{"/v1/agent/service/register", "/v1/agent/service/deregister/"},
+				Group:          "8pFodrV8",
+				Mode:           "0666",
+				User:           "E0nB1DwA",
+			},
+		},
+		HTTPUseCache:   false,
+		KVMaxValueSize: 1234567800,
+		MaxPerPage:     2891,
+		LeaveDrainTime: 8265 * time.Second,
+		LeaveOnTerm:    true,
 		Logging: logging.Config{
 			LogLevel:       "k1zo9Spt",
+			LogLevels:      map[string]string{"xds": "debug"},
 			LogJSON:        true,
 			EnableSyslog:   true,
 			SyslogFacility: "hHv79Uia",
-		},
-		MaxQueryTime:            18237 * time.Second,
-		NodeID:                  types.NodeID("AsUIlw99"),
-		NodeMeta:                map[string]string{"5mgGQMBk": "mJLtVMSG", "A7ynFMJB": "0Nx6RGab"},
-		NodeName:                "otlLxGaI",
-		ReadReplica:             true,
-		PidFile:                 "43xN80Km",
-		PrimaryGateways:         []string{"aej8eeZo", "roh2KahS"},
-		PrimaryGatewaysInterval: 18866 * time.Second,
-		RPCAdvertiseAddr:        tcpAddr("17.99.29.16:3757"),
-		RPCBindAddr:             tcpAddr("16.99.34.17:3757"),
-		RPCHandshakeTimeout:     1932 * time.Millisecond,
-		RPCHoldTimeout:          15707 * time.Second,
-		RPCProtocol:             30793,
-		RPCRateLimit:            12029.43,
-		RPCMaxBurst:             44848,
-		RPCMaxConnsPerClient:    2954,
-		RaftProtocol:            3,
-		RaftSnapshotThreshold:   16384,
-		RaftSnapshotInterval:    30 * time.Second,
-		RaftTrailingLogs:        83749,
-		ReconnectTimeoutLAN:     23739 * time.Second,
-		ReconnectTimeoutWAN:     26694 * time.Second,
-		RejoinAfterLeave:        true,
-		RetryJoinIntervalLAN:    8067 * time.Second,
-		RetryJoinIntervalWAN:    28866 * time.Second,
-		RetryJoinLAN:            []string{"pbsSFY7U", "l0qLtWij"},
-		RetryJoinMaxAttemptsLAN: 913,
-		RetryJoinMaxAttemptsWAN: 23160,
-		RetryJoinWAN:            []string{"PFsR02Ye", "rJdQIhER"},
-		RPCConfig:               consul.RPCConfig{EnableStreaming: true},
-		SegmentLimit:            123,
-		SerfPortLAN:             8301,
-		SerfPortWAN:             8302,
-		ServerMode:              true,
-		ServerName:              "Oerr9n1G",
-		ServerPort:              3757,
+			RedactSecrets:  true,
+			RedactPatterns: []string{"nq3Ht[0-9]+"},
+		},
+		MaxQueryTime:                      18237 * time.Second,
+		NodeID:                            types.NodeID("AsUIlw99"),
+		NodeMeta:                          map[string]string{"5mgGQMBk": "mJLtVMSG", "A7ynFMJB": "0Nx6RGab"},
+		NodeName:                          "otlLxGaI",
+		ReadReplica:                       true,
+		PidFile:                           "43xN80Km",
+		PrimaryGateways:                   []string{"aej8eeZo", "roh2KahS"},
+		PrimaryGatewaysInterval:           18866 * time.Second,
+		RPCAdvertiseAddr:                  tcpAddr("17.99.29.16:3757"),
+		RPCBindAddr:                       tcpAddr("16.99.34.17:3757"),
+		RPCHandshakeTimeout:               1932 * time.Millisecond,
+		RPCHoldTimeout:                    15707 * time.Second,
+		RPCProtocol:                       30793,
+		RPCRateLimit:                      12029.43,
+		RPCMaxBurst:                       44848,
+		RPCMaxConnsPerClient:              2954,
+		RPCMaxConcurrentRequestsPerClient: 2342,
+		RPCMaxInFlightBytesPerClient:      77312,
+		RaftProtocol:                      3,
+		RaftSnapshotThreshold:             16384,
+		RaftSnapshotInterval:              30 * time.Second,
+		RaftSnapshotAutoTune:              true,
+		RaftTrailingLogs:                  83749,
+		ReconnectTimeoutLAN:               23739 * time.Second,
+		ReconnectTimeoutWAN:               26694 * time.Second,
+		RejoinAfterLeave:                  true,
+		RetryJoinIntervalLAN:              8067 * time.Second,
+		RetryJoinIntervalWAN:              28866 * time.Second,
+		RetryJoinLAN:                      []string{"pbsSFY7U", "l0qLtWij"},
+		RetryJoinMaxAttemptsLAN:           913,
+		RetryJoinMaxAttemptsWAN:           23160,
+		RetryJoinWAN:                      []string{"PFsR02Ye", "rJdQIhER"},
+		RPCConfig:                         consul.RPCConfig{EnableStreaming: true},
+		RPCServerSelection:                "health",
+		RPCServerSlowStart:                41 * time.Second,
+		SegmentLimit:                      123,
+		SerfPortLAN:                       8301,
+		SerfPortWAN:                       8302,
+		ServerMode:                        true,
+		ServerName:                        "Oerr9n1G",
+		ServerPort:                        3757,
 		Services: []*structs.ServiceDefinition{
 			{
 				ID:      "wI1dzxS4",
@@ -6317,6 +7019,10 @@ func TestLoad_FullConfig(t *testing.T) {
 				Expiration: 15 * time.Second,
 				Name:       "ftO6DySn", // notice this is the same as the metrics prefix
 			},
+			PrometheusHistograms:       true,
+			PrometheusNativeHistograms: true,
+			PrometheusAllowedLabels:    []string{"service"},
+			PrometheusBlockedLabels:    []string{"peer"},
 		},
 		TLS: tlsutil.Config{
 			InternalRPC: tlsutil.ProtocolConfig{
@@ -6329,6 +7035,7 @@ func TestLoad_FullConfig(t *testing.T) {
 				CipherSuites:         []types.TLSCipherSuite{types.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256, types.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA},
 				VerifyOutgoing:       true,
 				VerifyServerHostname: true,
+				ServerSPIFFEIDs:      []string{"spiffe://bT9kd2Qf.consul/agent/server/dc/rzo029wg"},
 			},
 			GRPC: tlsutil.ProtocolConfig{
 				VerifyIncoming: true,
@@ -6353,6 +7060,7 @@ func TestLoad_FullConfig(t *testing.T) {
 			ServerName:              "Oerr9n1G",
 			Domain:                  "7W1xXSqd",
 			EnableAgentTLSForChecks: true,
+			AutoReloadCertificates:  true,
 		},
 		TaggedAddresses: map[string]string{
 			"7MYgHrYH": "dALJAhLD",
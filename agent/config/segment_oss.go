@@ -4,15 +4,65 @@
 package config
 
 import (
-	"github.com/hashicorp/consul/agent/structs"
+	"fmt"
+	"regexp"
 )
 
+// validSegmentName matches the names that are safe to use both as a serf tag
+// suffix and as part of the segment's snapshot file name.
+var validSegmentName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
 func (b *builder) validateSegments(rt RuntimeConfig) error {
 	if rt.SegmentName != "" {
-		return structs.ErrSegmentsNotSupported
+		if rt.ServerMode {
+			return fmt.Errorf("Segment option can only be set on clients")
+		}
+		if err := validateSegmentName(rt.SegmentName, rt.SegmentNameLimit); err != nil {
+			return err
+		}
+	}
+
+	if len(rt.Segments) == 0 {
+		return nil
+	}
+	if !rt.ServerMode {
+		return fmt.Errorf("Segments can only be configured on servers")
+	}
+	if len(rt.Segments) > rt.SegmentLimit {
+		return fmt.Errorf("Cannot exceed network segment limit of %d", rt.SegmentLimit)
+	}
+
+	takenPorts := make(map[int]string, len(rt.Segments)+1)
+	if rt.SerfPortLAN > 0 {
+		takenPorts[rt.SerfPortLAN] = "<default>"
+	}
+	names := make(map[string]struct{}, len(rt.Segments))
+	for _, s := range rt.Segments {
+		if err := validateSegmentName(s.Name, rt.SegmentNameLimit); err != nil {
+			return err
+		}
+		if _, ok := names[s.Name]; ok {
+			return fmt.Errorf("Segment name %q is defined more than once", s.Name)
+		}
+		names[s.Name] = struct{}{}
+
+		if other, ok := takenPorts[s.Bind.Port]; ok {
+			return fmt.Errorf("Segment %q port %d overlaps with segment %q", s.Name, s.Bind.Port, other)
+		}
+		takenPorts[s.Bind.Port] = s.Name
+	}
+	return nil
+}
+
+func validateSegmentName(name string, limit int) error {
+	if name == "" {
+		return fmt.Errorf("Segment name cannot be blank")
+	}
+	if len(name) > limit {
+		return fmt.Errorf("Segment name %q exceeds maximum length of %d", name, limit)
 	}
-	if len(rt.Segments) > 0 {
-		return structs.ErrSegmentsNotSupported
+	if !validSegmentName.MatchString(name) {
+		return fmt.Errorf("Segment name %q is invalid: only alphanumeric characters, dashes and underscores are allowed", name)
 	}
 	return nil
 }
@@ -5,6 +5,7 @@ package config
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/consul/sdk/testutil"
@@ -15,16 +16,43 @@ func TestSegments(t *testing.T) {
 
 	tests := []testCase{
 		{
-			desc: "segment name not in OSS",
+			desc: "segment name on a client",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "segment": "a" }`},
+			hcl:  []string{` segment = "a" `},
+			expected: func(rt *RuntimeConfig) {
+				rt.DataDir = dataDir
+				rt.SegmentName = "a"
+			},
+		},
+		{
+			desc: "segment name on a server",
 			args: []string{
 				`-data-dir=` + dataDir,
 			},
 			json:        []string{`{ "server": true, "segment": "a" }`},
 			hcl:         []string{` server = true segment = "a" `},
-			expectedErr: `Network segments are not supported in this version of Consul`,
-			expectedWarnings: []string{
-				enterpriseConfigKeyError{key: "segment"}.Error(),
+			expectedErr: `Segment option can only be set on clients`,
+		},
+		{
+			desc: "segment name too long",
+			args: []string{
+				`-data-dir=` + dataDir,
 			},
+			json:        []string{`{ "segment": "` + strings.Repeat("a", 65) + `" }`},
+			hcl:         []string{` segment = "` + strings.Repeat("a", 65) + `" `},
+			expectedErr: `exceeds maximum length of 64`,
+		},
+		{
+			desc: "segment name invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json:        []string{`{ "segment": "a/b" }`},
+			hcl:         []string{` segment = "a/b" `},
+			expectedErr: `Segment name "a/b" is invalid`,
 		},
 		{
 			desc: "segment port must be set",
@@ -34,21 +62,69 @@ func TestSegments(t *testing.T) {
 			json:        []string{`{ "segments":[{ "name":"x" }] }`},
 			hcl:         []string{`segments = [{ name = "x" }]`},
 			expectedErr: `Port for segment "x" cannot be <= 0`,
-			expectedWarnings: []string{
-				enterpriseConfigKeyError{key: "segments"}.Error(),
-			},
 		},
 		{
-			desc: "segments not in OSS",
+			desc: "segments on a client",
 			args: []string{
 				`-data-dir=` + dataDir,
 			},
 			json:        []string{`{ "segments":[{ "name":"x", "port": 123 }] }`},
 			hcl:         []string{`segments = [{ name = "x" port = 123 }]`},
-			expectedErr: `Network segments are not supported in this version of Consul`,
-			expectedWarnings: []string{
-				enterpriseConfigKeyError{key: "segments"}.Error(),
+			expectedErr: `Segments can only be configured on servers`,
+		},
+		{
+			desc: "segment limit exceeded",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "server": true, "segment_limit": 1, "segments":[
+				{ "name":"x", "port": 123 },
+				{ "name":"y", "port": 124 }
+			] }`},
+			hcl: []string{`server = true segment_limit = 1 segments = [
+				{ name = "x" port = 123 },
+				{ name = "y" port = 124 }
+			]`},
+			expectedErr: `Cannot exceed network segment limit of 1`,
+		},
+		{
+			desc: "segment name duplicated",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "server": true, "segments":[
+				{ "name":"x", "port": 123 },
+				{ "name":"x", "port": 124 }
+			] }`},
+			hcl: []string{`server = true segments = [
+				{ name = "x" port = 123 },
+				{ name = "x" port = 124 }
+			]`},
+			expectedErr: `Segment name "x" is defined more than once`,
+		},
+		{
+			desc: "segment port overlaps with another segment",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "server": true, "segments":[
+				{ "name":"x", "port": 123 },
+				{ "name":"y", "port": 123 }
+			] }`},
+			hcl: []string{`server = true segments = [
+				{ name = "x" port = 123 },
+				{ name = "y" port = 123 }
+			]`},
+			expectedErr: `Segment "y" port 123 overlaps with segment "x"`,
+		},
+		{
+			desc: "segment port overlaps with the default segment",
+			args: []string{
+				`-data-dir=` + dataDir,
 			},
+			json:        []string{`{ "server": true, "segments":[{ "name":"x", "port": 8301 }] }`},
+			hcl:         []string{`server = true segments = [{ name = "x" port = 8301 }]`},
+			expectedErr: `Segment "x" port 8301 overlaps with segment "<default>"`,
 		},
 	}
 
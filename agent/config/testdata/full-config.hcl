@@ -48,6 +48,14 @@ addresses = {
 advertise_addr = "17.99.29.16"
 advertise_addr_wan = "78.63.37.19"
 advertise_reconnect_timeout = "0s"
+agent_probes = {
+    liveness = ["serf"]
+    readiness = ["serf", "servers", "acls"]
+}
+anti_entropy = {
+    sync_interval = "3m41s"
+    sync_jitter = "2m17s"
+}
 audit = {
     enabled = true
 }
@@ -71,9 +79,19 @@ auto_config = {
             bound_issuer = "consul"
             bound_audiences = ["consul-cluster-1"]
             claim_assertions = ["value.node == \"${node}\""]
+            verified_node_meta = ["node"]
             jwt_validation_pub_keys = ["-----BEGIN PUBLIC KEY-----\nMFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAERVchfCZng4mmdvQz1+sJHRN40snC\nYt8NjYOnbnScEXMkyoUmASr88gb7jaVAVt3RYASAbgBjB2Z+EUizWkx5Tg==\n-----END PUBLIC KEY-----"]
         }
     }
+    config_snippets = [
+        {
+            name = "9vOzjOdP"
+            config = "{\"log_level\": \"debug\"}"
+            segments = ["lB2xXQmk"]
+            rollout_percentage = 40
+        }
+    ]
+    accept_snippet_keys = ["telemetry"]
 }
 autopilot = {
     cleanup_dead_servers = true
@@ -87,9 +105,18 @@ autopilot = {
 }
 bind_addr = "16.99.34.17"
 bootstrap_expect = 53
+bounded_stale_max_lag = 250
 cache = {
     entry_fetch_max_burst = 42
     entry_fetch_rate = 0.334
+    persist = true
+    persist_key = "L9ZaRwBqxpoHfQsVH5UVkZKVPVsDpS7uUBeeUGBkjAo="
+    types {
+        "health-services" {
+            last_get_ttl = "2h"
+            max_entries = 500
+        }
+    }
 },
 use_streaming_backend = true
 ca_file = "erA7T0PM"
@@ -181,7 +208,11 @@ checks = [
         deregister_critical_service_after = "2366s"
     }
 ]
+catalog_check_output_compression = true
+catalog_check_output_max_size = 3817
+check_sync_flush_interval = "24810s"
 check_update_interval = "16507s"
+check_update_trigger = "status"
 client_addr = "93.83.18.19"
 config_entries {
     # This is using the repeated block-to-array HCL magic
@@ -209,11 +240,17 @@ connect {
         # hack float since json parses numbers as float and we have to
         # assert against the same thing
         csr_max_per_second = 100.0
+        csr_max_burst = 10.0
         csr_max_concurrent = 2.0
     }
+    ca_roots_webhook = "https://ca-hook.example.com/roots"
+    ca_roots_webhook_secret = "Ohng4eeM"
+    traffic_shift_prometheus_addresses = ["http://prometheus.example.com:9090"]
     enable_mesh_gateway_wan_federation = false
     enabled = true
     enable_serverless_plugin = true
+    enable_mesh_readiness_check = true
+    enable_mesh_probes = true
 }
 gossip_lan {
     gossip_nodes    = 6
@@ -222,6 +259,7 @@ gossip_lan {
     suspicion_mult  = 1235
     probe_interval  = "101ms"
     probe_timeout   = "102ms"
+    tcp_only        = true
 }
 gossip_wan {
     gossip_nodes    = 2
@@ -230,6 +268,15 @@ gossip_wan {
     suspicion_mult  = 16385
     probe_interval  = "103ms"
     probe_timeout   = "104ms"
+    tcp_only        = true
+}
+graceful_shutdown {
+    drain_delay = "7s"
+    drain_hooks = [
+        { args = ["/usr/local/bin/drain", "-q"] timeout = "12s" },
+        { args = ["true"] },
+    ]
+    deregister_services = true
 }
 datacenter = "rzo029wg"
 default_query_time = "16743s"
@@ -252,11 +299,20 @@ dns_config {
     max_stale = "29685s"
     node_ttl = "7084s"
     only_passing = true
+    only_local_segment = true
+    prefer_client_subnet = true
     recursor_timeout = "4427s"
+    recursor_zones = {
+        "corp.example.com" = [ "10.64.0.53", "10.64.0.54:8600" ]
+    }
+    allow_recursion_from = [ "10.0.0.0/8" ]
     service_ttl = {
         "*" = "32030s"
     }
     udp_answer_limit = 29909
+    service_udp_answer_limit = {
+        "db-*" = 1
+    }
     use_cache = true
     cache_max_age = "5m"
     prefer_namespace = true
@@ -265,6 +321,7 @@ enable_acl_replication = true
 enable_agent_tls_for_checks = true
 enable_central_service_config = false
 enable_debug = true
+enable_expiry_checks = true
 enable_script_checks = true
 enable_local_script_checks = true
 enable_syslog = true
@@ -280,6 +337,13 @@ http_config {
     }
     use_cache = false
     max_header_bytes = 10
+    unix_sockets = [
+        {
+            path = "/var/run/consul/workloads.sock"
+            allow_endpoints = [ "/v1/agent/service/register", "/v1/agent/service/deregister/" ]
+            mode = "0666"
+        },
+    ]
 }
 key_file = "IEkkwgIA"
 leave_on_terminate = true
@@ -291,7 +355,10 @@ limits {
     rpc_rate = 12029.43
     rpc_max_burst = 44848
     rpc_max_conns_per_client = 2954
+    rpc_max_concurrent_requests_per_client = 2342
+    rpc_max_inflight_bytes_per_client = 77312
     kv_max_value_size = 1234567800
+    max_per_page = 2891
     txn_max_req_len = 567800000
 }
 log_level = "k1zo9Spt"
@@ -331,7 +398,13 @@ primary_gateways_interval = "18866s"
 raft_protocol = 3
 raft_snapshot_threshold = 16384
 raft_snapshot_interval = "30s"
+raft_snapshot_auto_tune = true
 raft_trailing_logs = 83749
+redact_patterns = ["nq3Ht[0-9]+"]
+log_levels {
+    xds = "debug"
+}
+redact_secrets = true
 raft_boltdb {
     NoFreelistSync = true
 }
@@ -348,6 +421,8 @@ retry_max = 913
 retry_max_wan = 23160
 rpc {
     enable_streaming = true
+    server_selection = "health"
+    server_slow_start = "41s"
 }
 segment_limit = 123
 serf_lan = "99.43.63.15"
@@ -652,6 +727,9 @@ telemetry {
     prefix_filter = [ "+oJotS8XJ","-cazlEhGn" ]
     metrics_prefix = "ftO6DySn"
     prometheus_retention_time = "15s"
+    prometheus_histograms = true
+    prometheus_native_histograms = true
+    prometheus_label_filter = [ "+service", "-peer" ]
     statsd_address = "drce87cy"
     statsite_address = "HpFwKB8R"
     disable_compat_1.9 = true
@@ -677,6 +755,7 @@ tls {
         verify_incoming = true
         verify_outgoing = true
         verify_server_hostname = true
+        server_spiffe_ids = ["spiffe://bT9kd2Qf.consul/agent/server/dc/rzo029wg"]
     }
     https {
         ca_file = "7Yu1PolM"
@@ -696,6 +775,7 @@ tls {
         tls_min_version = "TLSv1_0"
         verify_incoming = true
     }
+    auto_reload_certificates = true
 }
 tls_cipher_suites = "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"
 tls_min_version = "tls11"
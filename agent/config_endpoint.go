@@ -61,6 +61,9 @@ func (s *HTTPHandlers) configGet(resp http.ResponseWriter, req *http.Request) (i
 		if err := s.parseEntMeta(req, &args.EnterpriseMeta); err != nil {
 			return nil, err
 		}
+		if err := parsePagination(req, &args.QueryOptions); err != nil {
+			return nil, err
+		}
 		// Only kind provided, list entries.
 		args.Kind = pathArgs[0]
 
@@ -167,6 +170,72 @@ func (s *HTTPHandlers) ConfigApply(resp http.ResponseWriter, req *http.Request)
 	return reply, nil
 }
 
+// ResolvedServiceConfig is the configuration of a service resolved from its
+// config entries, as sidecar proxies and gateways receive it.
+type ResolvedServiceConfig struct {
+	ProxyConfig       map[string]interface{}
+	UpstreamIDConfigs structs.OpaqueUpstreamConfigs
+	MeshGateway       structs.MeshGatewayConfig      `json:",omitempty"`
+	Expose            structs.ExposeConfig           `json:",omitempty"`
+	TransparentProxy  structs.TransparentProxyConfig `json:",omitempty"`
+	Mode              structs.ProxyMode              `json:",omitempty"`
+	Meta              map[string]string              `json:",omitempty"`
+}
+
+// ConfigResolvedServiceConfig returns the configuration of a service merged
+// from the proxy-defaults and service-defaults config entries. When a source
+// service is given, it returns the configuration of the source service
+// instead, including the configuration of the service as one of its upstreams.
+//
+// GET /v1/config/resolved-service-config/:service?source=:source
+func (s *HTTPHandlers) ConfigResolvedServiceConfig(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.ServiceConfigRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+	if err := s.parseEntMetaNoWildcard(req, &args.EnterpriseMeta); err != nil {
+		return nil, err
+	}
+
+	service := strings.TrimPrefix(req.URL.Path, "/v1/config/resolved-service-config/")
+	if service == "" {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: "Missing service name"}
+	}
+
+	args.Name = service
+	if source := req.URL.Query().Get("source"); source != "" {
+		// The upstream configuration of the service is only returned if the
+		// token can read it, on top of the source service the RPC checks.
+		var authzContext acl.AuthorizerContext
+		authz, err := s.agent.delegate.ResolveTokenAndDefaultMeta(args.Token, &args.EnterpriseMeta, &authzContext)
+		if err != nil {
+			return nil, err
+		}
+		if err := authz.ToAllowAuthorizer().ServiceReadAllowed(service, &authzContext); err != nil {
+			return nil, err
+		}
+
+		args.Name = source
+		args.UpstreamIDs = []structs.ServiceID{structs.NewServiceID(service, &args.EnterpriseMeta)}
+	}
+
+	var reply structs.ServiceConfigResponse
+	if err := s.agent.RPC("ConfigEntry.ResolveServiceConfig", &args, &reply); err != nil {
+		return nil, err
+	}
+	setMeta(resp, &reply.QueryMeta)
+
+	return ResolvedServiceConfig{
+		ProxyConfig:       reply.ProxyConfig,
+		UpstreamIDConfigs: reply.UpstreamIDConfigs,
+		MeshGateway:       reply.MeshGateway,
+		Expose:            reply.Expose,
+		TransparentProxy:  reply.TransparentProxy,
+		Mode:              reply.Mode,
+		Meta:              reply.Meta,
+	}, nil
+}
+
 func (s *HTTPHandlers) parseEntMetaForConfigEntryKind(kind string, req *http.Request, entMeta *acl.EnterpriseMeta) error {
 	if kind == structs.ServiceIntentions {
 		return s.parseEntMeta(req, entMeta)
@@ -143,6 +143,104 @@ func TestConfig_Get(t *testing.T) {
 	})
 }
 
+func TestConfig_ResolvedServiceConfig(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	reqs := []structs.ConfigEntryRequest{
+		{
+			Datacenter: "dc1",
+			Entry: &structs.ProxyConfigEntry{
+				Name: structs.ProxyConfigGlobal,
+				Config: map[string]interface{}{
+					"protocol": "http",
+				},
+			},
+		},
+		{
+			Datacenter: "dc1",
+			Entry: &structs.ServiceConfigEntry{
+				Name:     "db",
+				Protocol: "grpc",
+			},
+		},
+		{
+			Datacenter: "dc1",
+			Entry: &structs.ServiceConfigEntry{
+				Name: "web",
+				MeshGateway: structs.MeshGatewayConfig{
+					Mode: structs.MeshGatewayModeLocal,
+				},
+				UpstreamConfig: &structs.UpstreamConfiguration{
+					Overrides: []*structs.UpstreamConfig{
+						{
+							Name:             "db",
+							ConnectTimeoutMs: 2500,
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, req := range reqs {
+		out := false
+		require.NoError(t, a.RPC("ConfigEntry.Apply", &req, &out))
+	}
+
+	t.Run("service", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/config/resolved-service-config/db", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.ConfigResolvedServiceConfig(resp, req)
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.Header().Get("X-Consul-Index"))
+
+		require.Equal(t, ResolvedServiceConfig{
+			ProxyConfig: map[string]interface{}{
+				"protocol": "grpc",
+			},
+		}, obj)
+	})
+
+	t.Run("service as an upstream of a source", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/config/resolved-service-config/db?source=web", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.ConfigResolvedServiceConfig(resp, req)
+		require.NoError(t, err)
+
+		require.Equal(t, ResolvedServiceConfig{
+			ProxyConfig: map[string]interface{}{
+				"protocol": "http",
+			},
+			UpstreamIDConfigs: structs.OpaqueUpstreamConfigs{
+				{
+					Upstream: structs.NewServiceID("db", nil),
+					Config: map[string]interface{}{
+						"connect_timeout_ms": 2500,
+						"protocol":           "grpc",
+					},
+				},
+			},
+			MeshGateway: structs.MeshGatewayConfig{
+				Mode: structs.MeshGatewayModeLocal,
+			},
+		}, obj)
+	})
+
+	t.Run("missing service", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/config/resolved-service-config/", nil)
+		resp := httptest.NewRecorder()
+		a.srv.h.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
 func TestConfig_Delete(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -1,19 +1,26 @@
 package configentry
 
 import (
+	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/structs"
 )
 
 // DiscoveryChainSet is a wrapped set of raw cross-referenced config entries
 // necessary for the DiscoveryChain.Get RPC process.
 //
-// None of these are defaulted.
+// None of these are defaulted. ProxyDefaults are keyed by the ProxyDefaultsKey
+// of their scope.
 type DiscoveryChainSet struct {
 	Routers       map[structs.ServiceID]*structs.ServiceRouterConfigEntry
 	Splitters     map[structs.ServiceID]*structs.ServiceSplitterConfigEntry
 	Resolvers     map[structs.ServiceID]*structs.ServiceResolverConfigEntry
 	Services      map[structs.ServiceID]*structs.ServiceConfigEntry
 	ProxyDefaults map[string]*structs.ProxyConfigEntry
+
+	// DynamicSubsets are the distinct values of the metadata key of the
+	// resolvers setting DynamicSubsets, as registered by the instances of
+	// their service.
+	DynamicSubsets map[structs.ServiceID][]string
 }
 
 func NewDiscoveryChainSet() *DiscoveryChainSet {
@@ -23,6 +30,8 @@ func NewDiscoveryChainSet() *DiscoveryChainSet {
 		Resolvers:     make(map[structs.ServiceID]*structs.ServiceResolverConfigEntry),
 		Services:      make(map[structs.ServiceID]*structs.ServiceConfigEntry),
 		ProxyDefaults: make(map[string]*structs.ProxyConfigEntry),
+
+		DynamicSubsets: make(map[structs.ServiceID][]string),
 	}
 }
 
@@ -54,13 +63,21 @@ func (e *DiscoveryChainSet) GetService(sid structs.ServiceID) *structs.ServiceCo
 	return nil
 }
 
-func (e *DiscoveryChainSet) GetProxyDefaults(partition string) *structs.ProxyConfigEntry {
-	if e.ProxyDefaults != nil {
-		return e.ProxyDefaults[partition]
+// GetDynamicSubsets returns the metadata values registered by the instances
+// of a service whose resolver sets DynamicSubsets.
+func (e *DiscoveryChainSet) GetDynamicSubsets(sid structs.ServiceID) []string {
+	if e.DynamicSubsets != nil {
+		return e.DynamicSubsets[sid]
 	}
 	return nil
 }
 
+// GetProxyDefaults returns the effective proxy-defaults of entMeta, layering
+// the proxy-defaults of its broader scopes.
+func (e *DiscoveryChainSet) GetProxyDefaults(entMeta *acl.EnterpriseMeta) *structs.ProxyConfigEntry {
+	return layerProxyDefaults(e.ProxyDefaults, entMeta)
+}
+
 // AddRouters adds router configs. Convenience function for testing.
 func (e *DiscoveryChainSet) AddRouters(entries ...*structs.ServiceRouterConfigEntry) {
 	if e.Routers == nil {
@@ -107,8 +124,17 @@ func (e *DiscoveryChainSet) AddProxyDefaults(entries ...*structs.ProxyConfigEntr
 		e.ProxyDefaults = make(map[string]*structs.ProxyConfigEntry)
 	}
 	for _, entry := range entries {
-		e.ProxyDefaults[entry.PartitionOrDefault()] = entry
+		e.ProxyDefaults[ProxyDefaultsKey(&entry.EnterpriseMeta)] = entry
+	}
+}
+
+// AddDynamicSubsets adds the metadata values registered by the instances of a
+// service. Convenience function for testing.
+func (e *DiscoveryChainSet) AddDynamicSubsets(sid structs.ServiceID, values ...string) {
+	if e.DynamicSubsets == nil {
+		e.DynamicSubsets = make(map[structs.ServiceID][]string)
 	}
+	e.DynamicSubsets[sid] = append(e.DynamicSubsets[sid], values...)
 }
 
 // AddEntries adds generic configs. Convenience function for testing. Panics on
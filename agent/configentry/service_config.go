@@ -1,13 +1,15 @@
 package configentry
 
 import (
+	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/structs"
 )
 
 // ResolvedServiceConfigSet is a wrapped set of raw cross-referenced config
 // entries necessary for the ConfigEntry.ResolveServiceConfig RPC process.
 //
-// None of these are defaulted.
+// None of these are defaulted. ProxyDefaults are keyed by the ProxyDefaultsKey
+// of their scope.
 type ResolvedServiceConfigSet struct {
 	ServiceDefaults map[structs.ServiceID]*structs.ServiceConfigEntry
 	ProxyDefaults   map[string]*structs.ProxyConfigEntry
@@ -24,11 +26,10 @@ func (r *ResolvedServiceConfigSet) GetServiceDefaults(sid structs.ServiceID) *st
 	return r.ServiceDefaults[sid]
 }
 
-func (r *ResolvedServiceConfigSet) GetProxyDefaults(partition string) *structs.ProxyConfigEntry {
-	if r.ProxyDefaults == nil {
-		return nil
-	}
-	return r.ProxyDefaults[partition]
+// GetProxyDefaults returns the effective proxy-defaults of entMeta, layering
+// the proxy-defaults of its broader scopes.
+func (r *ResolvedServiceConfigSet) GetProxyDefaults(entMeta *acl.EnterpriseMeta) *structs.ProxyConfigEntry {
+	return layerProxyDefaults(r.ProxyDefaults, entMeta)
 }
 
 func (r *ResolvedServiceConfigSet) AddServiceDefaults(entry *structs.ServiceConfigEntry) {
@@ -53,5 +54,23 @@ func (r *ResolvedServiceConfigSet) AddProxyDefaults(entry *structs.ProxyConfigEn
 		r.ProxyDefaults = make(map[string]*structs.ProxyConfigEntry)
 	}
 
-	r.ProxyDefaults[entry.PartitionOrDefault()] = entry
+	r.ProxyDefaults[ProxyDefaultsKey(&entry.EnterpriseMeta)] = entry
+}
+
+// ProxyDefaultsKey returns the key of the proxy-defaults of the scope of
+// entMeta in the ProxyDefaults of config entry sets.
+func ProxyDefaultsKey(entMeta *acl.EnterpriseMeta) string {
+	return entMeta.PartitionOrDefault() + "/" + entMeta.NamespaceOrDefault()
+}
+
+func layerProxyDefaults(entries map[string]*structs.ProxyConfigEntry, entMeta *acl.EnterpriseMeta) *structs.ProxyConfigEntry {
+	if len(entries) == 0 {
+		return nil
+	}
+	scopes := structs.ProxyDefaultsScopes(entMeta)
+	layers := make([]*structs.ProxyConfigEntry, 0, len(scopes))
+	for _, scope := range scopes {
+		layers = append(layers, entries[ProxyDefaultsKey(scope)])
+	}
+	return structs.MergeProxyConfigEntries(layers...)
 }
@@ -111,6 +111,13 @@ func CalculateCertFingerprint(cert []byte) string {
 	return HexString(hash[:])
 }
 
+// CalculateCertSHA256Fingerprint calculates the SHA-256 fingerprint from the
+// cert bytes.
+func CalculateCertSHA256Fingerprint(cert []byte) string {
+	hash := sha256.Sum256(cert)
+	return HexString(hash[:])
+}
+
 // ParseSigner parses a crypto.Signer from a PEM-encoded key. The private key
 // is expected to be the first block in the PEM value.
 func ParseSigner(pemValue string) (crypto.Signer, error) {
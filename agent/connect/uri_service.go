@@ -7,6 +7,10 @@ import (
 	"github.com/hashicorp/consul/acl"
 )
 
+// SpiffeIDServiceFormat describes the format of the SPIFFE IDs of services.
+// The partition segment is omitted for the default partition.
+const SpiffeIDServiceFormat = "spiffe://<trust domain>[/ap/<partition>]/ns/<namespace>/dc/<datacenter>/svc/<service>"
+
 // SpiffeIDService is the structure to represent the SPIFFE ID for a service.
 type SpiffeIDService struct {
 	Host       string
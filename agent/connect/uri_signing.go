@@ -65,3 +65,13 @@ func (id SpiffeIDSigning) CanSign(cu CertURI) bool {
 func SpiffeIDSigningForCluster(clusterID string) *SpiffeIDSigning {
 	return &SpiffeIDSigning{ClusterID: clusterID, Domain: "consul"}
 }
+
+// ClusterIDFromTrustDomain returns the cluster ID of a trust domain built by
+// SpiffeIDSigningForCluster, or false if trustDomain isn't one.
+func ClusterIDFromTrustDomain(trustDomain string) (string, bool) {
+	const suffix = ".consul"
+	if !strings.HasSuffix(trustDomain, suffix) || len(trustDomain) == len(suffix) {
+		return "", false
+	}
+	return strings.TrimSuffix(trustDomain, suffix), true
+}
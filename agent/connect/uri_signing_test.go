@@ -104,3 +104,14 @@ func TestSpiffeIDSigning_CanSign(t *testing.T) {
 		})
 	}
 }
+
+func TestClusterIDFromTrustDomain(t *testing.T) {
+	id, ok := ClusterIDFromTrustDomain(TestTrustDomain)
+	assert.True(t, ok)
+	assert.Equal(t, TestClusterID, id)
+
+	for _, td := range []string{"", ".consul", "example.com"} {
+		_, ok := ClusterIDFromTrustDomain(td)
+		assert.False(t, ok, td)
+	}
+}
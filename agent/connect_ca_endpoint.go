@@ -1,10 +1,13 @@
 package agent
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
 
+	cachetype "github.com/hashicorp/consul/agent/cache-types"
+	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/consul"
 	"github.com/hashicorp/consul/agent/structs"
 )
@@ -51,6 +54,72 @@ func (s *HTTPHandlers) ConnectCARoots(resp http.ResponseWriter, req *http.Reques
 	return nil, nil
 }
 
+// GET /v1/connect/ca/trust-domain
+func (s *HTTPHandlers) ConnectCATrustDomain(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.DCSpecificRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var reply structs.IndexedCARoots
+	defer setMeta(resp, &reply.QueryMeta)
+	if err := s.agent.RPC("ConnectCA.Roots", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return trustDomainOf(reply.TrustDomain)
+}
+
+// connectTrustDomain returns the cluster identity of the Connect CA from the
+// CA roots in the cache. The roots are only fetched from the servers the first
+// time, after which they are kept up to date by the cache.
+func (a *Agent) connectTrustDomain(ctx context.Context) (*structs.ConnectTrustDomain, error) {
+	req := &structs.DCSpecificRequest{
+		Datacenter:   a.config.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: a.tokens.AgentToken()},
+	}
+
+	raw, _, err := a.cache.Get(ctx, cachetype.ConnectCARootName, req)
+	if err != nil {
+		return nil, err
+	}
+	roots, ok := raw.(*structs.IndexedCARoots)
+	if !ok {
+		return nil, fmt.Errorf("invalid type for roots response: %T", raw)
+	}
+	return trustDomainOf(roots.TrustDomain)
+}
+
+// trustDomainOf returns the cluster identity for the trust domain of the CA
+// roots.
+func trustDomainOf(trustDomain string) (*structs.ConnectTrustDomain, error) {
+	clusterID, ok := connect.ClusterIDFromTrustDomain(trustDomain)
+	if !ok {
+		return nil, fmt.Errorf("invalid trust domain of the Connect CA: %q", trustDomain)
+	}
+	return &structs.ConnectTrustDomain{
+		TrustDomain:    trustDomain,
+		ClusterID:      clusterID,
+		SpiffeIDFormat: connect.SpiffeIDServiceFormat,
+	}, nil
+}
+
+// GET /v1/connect/ca/certificates
+func (s *HTTPHandlers) ConnectCACertificates(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.DCSpecificRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var reply structs.IndexedIssuedCertRecords
+	defer setMeta(resp, &reply.QueryMeta)
+	if err := s.agent.RPC("ConnectCA.Certificates", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return reply.Certs, nil
+}
+
 // /v1/connect/ca/configuration
 func (s *HTTPHandlers) ConnectCAConfiguration(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	switch req.Method {
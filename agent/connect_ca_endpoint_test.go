@@ -69,6 +69,62 @@ func TestConnectCARoots_list(t *testing.T) {
 	}
 }
 
+func TestConnectCATrustDomain(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	req, _ := http.NewRequest("GET", "/v1/connect/ca/trust-domain", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.ConnectCATrustDomain(resp, req)
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Header().Get("X-Consul-Index"))
+
+	require.Equal(t, &structs.ConnectTrustDomain{
+		TrustDomain:    connect.TestTrustDomain,
+		ClusterID:      connect.TestClusterID,
+		SpiffeIDFormat: connect.SpiffeIDServiceFormat,
+	}, obj)
+}
+
+func TestConnectCACertificates(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	csr, _ := connect.TestCSR(t, connect.TestSpiffeIDService(t, "web"))
+	var issued structs.IssuedCert
+	require.NoError(t, a.RPC("ConnectCA.Sign", &structs.CASignRequest{Datacenter: "dc1", CSR: csr}, &issued))
+
+	req, _ := http.NewRequest("GET", "/v1/connect/ca/certificates", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.ConnectCACertificates(resp, req)
+	require.NoError(t, err)
+
+	value := obj.(structs.IssuedCertRecords)
+	var found bool
+	for _, r := range value {
+		if r.SerialNumber == issued.SerialNumber {
+			found = true
+			require.Equal(t, "web", r.Service)
+			require.Equal(t, issued.ServiceURI, r.ServiceURI)
+		}
+	}
+	require.True(t, found)
+}
+
 func TestConnectCAConfig(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
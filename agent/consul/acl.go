@@ -27,6 +27,10 @@ var ACLCounters = []prometheus.CounterDefinition{
 		Name: []string{"acl", "token", "cache_miss"},
 		Help: "Increments if Consul cannot resolve a token's identity, or a legacy token, from the cache.",
 	},
+	{
+		Name: []string{"acl", "token", "usage_dropped"},
+		Help: "Increments when a server drops the usage of a token because too many tokens were used since it last updated the usage of tokens.",
+	},
 }
 
 var ACLSummaries = []prometheus.SummaryDefinition{
@@ -1233,6 +1237,51 @@ func (f *aclFilter) filterHealthChecks(checks *structs.HealthChecks) bool {
 	return removed
 }
 
+// filterDeregistrations is used to filter the history of deregistrations
+// based on the configured ACL rules for a token. The records are copied
+// rather than modified as they come from the state store, and the definitions
+// of their checks are always removed since they may hold secrets such as HTTP
+// headers. Returns true if any elements were removed.
+func (f *aclFilter) filterDeregistrations(deregistrations *structs.Deregistrations) bool {
+	var authzContext acl.AuthorizerContext
+	var removed bool
+
+	ret := make(structs.Deregistrations, 0, len(*deregistrations))
+	for _, d := range *deregistrations {
+		d.FillAuthzContext(&authzContext)
+		if !f.allowNode(d.Node, &authzContext) || !f.allowService(d.ServiceName, &authzContext) {
+			f.logger.Debug("dropping deregistration from result due to ACLs", "node", d.Node, "service", d.ServiceID)
+			removed = true
+			continue
+		}
+
+		filtered := *d
+		filtered.Services = nil
+		for _, name := range d.Services {
+			if f.allowService(name, &authzContext) {
+				filtered.Services = append(filtered.Services, name)
+			} else {
+				removed = true
+			}
+		}
+
+		filtered.Checks = nil
+		for _, check := range d.Checks {
+			check.FillAuthzContext(&authzContext)
+			if !f.allowService(check.ServiceName, &authzContext) {
+				removed = true
+				continue
+			}
+			c := *check
+			c.Definition = structs.HealthCheckDefinition{}
+			filtered.Checks = append(filtered.Checks, &c)
+		}
+		ret = append(ret, &filtered)
+	}
+	*deregistrations = ret
+	return removed
+}
+
 // filterServices is used to filter a set of services based on ACLs. Returns
 // true if any elements were removed.
 func (f *aclFilter) filterServices(services structs.Services, entMeta *acl.EnterpriseMeta) bool {
@@ -1875,6 +1924,9 @@ func filterACLWithAuthorizer(logger hclog.Logger, authorizer acl.Authorizer, sub
 	case *structs.IndexedHealthChecks:
 		v.QueryMeta.ResultsFilteredByACLs = filt.filterHealthChecks(&v.HealthChecks)
 
+	case *structs.IndexedDeregistrations:
+		v.QueryMeta.ResultsFilteredByACLs = filt.filterDeregistrations(&v.Deregistrations)
+
 	case *structs.IndexedIntentions:
 		v.QueryMeta.ResultsFilteredByACLs = filt.filterIntentions(&v.Intentions)
 
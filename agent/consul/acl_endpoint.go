@@ -33,67 +33,55 @@ const (
 var ACLEndpointSummaries = []prometheus.SummaryDefinition{
 	{
 		Name: []string{"acl", "token", "clone"},
-		Help: "",
+		Help: "Measures the time it takes to clone an ACL token.",
 	},
 	{
 		Name: []string{"acl", "token", "upsert"},
-		Help: "",
+		Help: "Measures the time it takes to create or update an ACL token.",
 	},
 	{
 		Name: []string{"acl", "token", "delete"},
-		Help: "",
+		Help: "Measures the time it takes to delete an ACL token.",
 	},
 	{
 		Name: []string{"acl", "policy", "upsert"},
-		Help: "",
+		Help: "Measures the time it takes to create or update an ACL policy.",
 	},
 	{
 		Name: []string{"acl", "policy", "delete"},
-		Help: "",
-	},
-	{
-		Name: []string{"acl", "policy", "delete"},
-		Help: "",
+		Help: "Measures the time it takes to delete an ACL policy.",
 	},
 	{
 		Name: []string{"acl", "role", "upsert"},
-		Help: "",
+		Help: "Measures the time it takes to create or update an ACL role.",
 	},
 	{
 		Name: []string{"acl", "role", "delete"},
-		Help: "",
+		Help: "Measures the time it takes to delete an ACL role.",
 	},
 	{
 		Name: []string{"acl", "bindingrule", "upsert"},
-		Help: "",
+		Help: "Measures the time it takes to create or update an ACL binding rule.",
 	},
 	{
 		Name: []string{"acl", "bindingrule", "delete"},
-		Help: "",
+		Help: "Measures the time it takes to delete an ACL binding rule.",
 	},
 	{
 		Name: []string{"acl", "authmethod", "upsert"},
-		Help: "",
+		Help: "Measures the time it takes to create or update an ACL auth method.",
 	},
 	{
 		Name: []string{"acl", "authmethod", "delete"},
-		Help: "",
-	},
-	{
-		Name: []string{"acl", "login"},
-		Help: "",
+		Help: "Measures the time it takes to delete an ACL auth method.",
 	},
 	{
 		Name: []string{"acl", "login"},
-		Help: "",
+		Help: "Measures the time it takes to complete an ACL login.",
 	},
 	{
 		Name: []string{"acl", "logout"},
-		Help: "",
-	},
-	{
-		Name: []string{"acl", "logout"},
-		Help: "",
+		Help: "Measures the time it takes to complete an ACL logout.",
 	},
 }
 
@@ -307,6 +295,20 @@ func (a *ACL) TokenRead(args *structs.ACLTokenGetRequest, reply *structs.ACLToke
 				return err
 			}
 
+			if token != nil {
+				// usage changes with every request made with the token, it is
+				// not watched so that it doesn't wake up blocking queries
+				_, usage, err := state.ACLTokenUsageGet(nil, token.AccessorID)
+				if err != nil {
+					return err
+				}
+				if usage != nil {
+					// the token is owned by the state store and must not be modified
+					token = token.Clone()
+					token.Usage = usage
+				}
+			}
+
 			reply.Index, reply.Token = index, token
 			reply.SourceDatacenter = args.Datacenter
 			if token == nil {
@@ -668,7 +670,11 @@ func (a *ACL) TokenList(args *structs.ACLTokenListRequest, reply *structs.ACLTok
 				if token.IsExpired(now) {
 					continue
 				}
-				stubs = append(stubs, token.Stub())
+				stub := token.Stub()
+				if _, stub.Usage, err = state.ACLTokenUsageGet(nil, token.AccessorID); err != nil {
+					return err
+				}
+				stubs = append(stubs, stub)
 			}
 
 			// filter down to just the tokens that the requester has permissions to read
@@ -679,6 +685,24 @@ func (a *ACL) TokenList(args *structs.ACLTokenListRequest, reply *structs.ACLTok
 		})
 }
 
+// TokenUsageUpdate adds the usage of tokens recorded by a server to the state
+// store. It is only called by the servers of the datacenter.
+func (a *ACL) TokenUsageUpdate(args *structs.ACLTokenUsageUpdateRequest, reply *struct{}) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+	if args.FromAgent {
+		return acl.ErrPermissionDenied
+	}
+
+	if done, err := a.srv.ForwardRPC("ACL.TokenUsageUpdate", args, reply); done {
+		return err
+	}
+
+	_, err := a.srv.raftApply(structs.ACLTokenUsageUpdateType|structs.IgnoreUnknownTypeFlag, args)
+	return err
+}
+
 func (a *ACL) TokenBatchRead(args *structs.ACLTokenBatchGetRequest, reply *structs.ACLTokenBatchResponse) error {
 	if err := a.aclPreCheck(); err != nil {
 		return err
@@ -1632,8 +1656,11 @@ func (a *ACL) BindingRuleSet(args *structs.ACLBindingRuleSetRequest, reply *stru
 		return fmt.Errorf("Invalid Binding Rule: no BindType is set")
 	}
 
-	if rule.BindName == "" {
+	switch {
+	case rule.BindName == "" && rule.BindNameExpression == "":
 		return fmt.Errorf("Invalid Binding Rule: no BindName is set")
+	case rule.BindName != "" && rule.BindNameExpression != "":
+		return fmt.Errorf("Invalid Binding Rule: BindName and BindNameExpression cannot both be set")
 	}
 
 	switch rule.BindType {
@@ -1644,7 +1671,11 @@ func (a *ACL) BindingRuleSet(args *structs.ACLBindingRuleSetRequest, reply *stru
 		return fmt.Errorf("Invalid Binding Rule: unknown BindType %q", rule.BindType)
 	}
 
-	if valid, err := auth.IsValidBindName(rule.BindType, rule.BindName, blankID.ProjectedVarNames()); err != nil {
+	if rule.BindNameExpression != "" {
+		if _, err := auth.CompileBindNameExpression(rule.BindNameExpression); err != nil {
+			return fmt.Errorf("Invalid Binding Rule: invalid BindNameExpression: %v", err)
+		}
+	} else if valid, err := auth.IsValidBindName(rule.BindType, rule.BindName, blankID.ProjectedVarNames()); err != nil {
 		return fmt.Errorf("Invalid Binding Rule: invalid BindName: %v", err)
 	} else if !valid {
 		return fmt.Errorf("Invalid Binding Rule: invalid BindName")
@@ -2117,3 +2148,97 @@ func (a *ACL) Authorize(args *structs.RemoteACLAuthorizationRequest, reply *[]st
 	*reply = responses
 	return nil
 }
+
+// PolicyEvaluate evaluates authorization requests against the policies of a
+// token, a set of existing policies or policy rules that have not been written
+// yet, reporting the policy and rule that decided each request. It allows
+// operators to check the effect of policy changes before rolling them out.
+//
+// Evaluating the policies of any token but the caller's own requires acl:write
+// since it reveals whether the SecretID exists. Evaluating existing policies
+// or rules requires acl:read.
+func (a *ACL) PolicyEvaluate(args *structs.ACLPolicyEvaluateRequest, reply *structs.ACLPolicyEvaluateResponse) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	if err := a.srv.validateEnterpriseRequest(&args.EnterpriseMeta, false); err != nil {
+		return err
+	}
+
+	if done, err := a.srv.ForwardRPC("ACL.PolicyEvaluate", args, reply); done {
+		return err
+	}
+
+	if args.SecretID == "" && len(args.Policies) == 0 && args.Rules == "" {
+		return fmt.Errorf("At least one of SecretID, Policies or Rules must be set")
+	}
+
+	var authzContext acl.AuthorizerContext
+	authz, err := a.srv.ResolveTokenAndDefaultMeta(args.Token, &args.EnterpriseMeta, &authzContext)
+	if err != nil {
+		return err
+	}
+	if args.SecretID != "" && args.SecretID != args.Token {
+		if err := authz.ToAllowAuthorizer().ACLWriteAllowed(&authzContext); err != nil {
+			return err
+		}
+	} else if len(args.Policies) > 0 || args.Rules != "" {
+		if err := authz.ToAllowAuthorizer().ACLReadAllowed(&authzContext); err != nil {
+			return err
+		}
+	}
+
+	var policies structs.ACLPolicies
+	if args.SecretID != "" {
+		_, tokenPolicies, err := a.srv.ACLResolver.resolveTokenToIdentityAndPolicies(args.SecretID)
+		if err != nil {
+			return err
+		}
+		policies = append(policies, tokenPolicies...)
+	}
+
+	state := a.srv.fsm.State()
+	for _, link := range args.Policies {
+		var (
+			policy *structs.ACLPolicy
+			err    error
+		)
+		if link.ID != "" {
+			_, policy, err = state.ACLPolicyGetByID(nil, link.ID, &args.EnterpriseMeta)
+		} else {
+			_, policy, err = state.ACLPolicyGetByName(nil, link.Name, &args.EnterpriseMeta)
+		}
+		if err != nil {
+			return err
+		}
+		if policy == nil {
+			return fmt.Errorf("cannot find policy %q", link.ID+link.Name)
+		}
+		policies = append(policies, policy)
+	}
+
+	if args.Rules != "" {
+		policies = append(policies, &structs.ACLPolicy{
+			Name:           structs.ACLPolicyEvaluateRulesName,
+			Rules:          args.Rules,
+			EnterpriseMeta: args.EnterpriseMeta,
+		})
+	}
+
+	var conf acl.Config
+	if a.srv.ACLResolver.aclConf != nil {
+		conf = *a.srv.ACLResolver.aclConf
+	}
+	setEnterpriseConf(&args.EnterpriseMeta, &conf)
+
+	defaultAuthz := acl.RootAuthorizer(a.srv.config.ACLResolverSettings.ACLDefaultPolicy)
+	results, err := structs.EvaluateACLPolicies(policies, defaultAuthz, &conf, args.Requests)
+	if err != nil {
+		return err
+	}
+
+	reply.Results = results
+	a.srv.setQueryMeta(&reply.QueryMeta, args.Token)
+	return nil
+}
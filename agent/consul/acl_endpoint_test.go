@@ -3,6 +3,7 @@ package consul
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
@@ -2233,6 +2234,315 @@ func TestACLEndpoint_PolicyDelete_globalManagement(t *testing.T) {
 	require.EqualError(t, err, "Delete operation not permitted on the builtin global-management policy")
 }
 
+func TestACLEndpoint_TokenRead_Usage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	_, srv, codec := testACLServerWithConfig(t, nil, false)
+	waitForLeaderEstablishment(t, srv)
+
+	token, err := upsertTestTokenWithPolicyRules(codec, TestDefaultInitialManagementToken, "dc1", `node_prefix "" { policy = "read" }`)
+	require.NoError(t, err)
+
+	aclEp := ACL{srv: srv}
+
+	readToken := func() *structs.ACLToken {
+		req := structs.ACLTokenGetRequest{
+			Datacenter:   "dc1",
+			TokenID:      token.AccessorID,
+			TokenIDType:  structs.ACLTokenAccessor,
+			QueryOptions: structs.QueryOptions{Token: TestDefaultInitialManagementToken},
+		}
+		resp := structs.ACLTokenResponse{}
+		require.NoError(t, aclEp.TokenRead(&req, &resp))
+		return resp.Token
+	}
+
+	// the token has not been used yet
+	require.Nil(t, readToken().Usage)
+
+	before := time.Now()
+	for i := 0; i < 3; i++ {
+		req := structs.DCSpecificRequest{
+			Datacenter:   "dc1",
+			QueryOptions: structs.QueryOptions{Token: token.SecretID},
+		}
+		var resp structs.IndexedNodes
+		require.NoError(t, srv.RPC("Catalog.ListNodes", &req, &resp))
+	}
+
+	// usage is only read from the state store once flushed
+	require.Nil(t, readToken().Usage)
+	require.NoError(t, srv.flushTokenUsage())
+
+	read := readToken()
+	require.NotNil(t, read.Usage)
+	require.Equal(t, uint64(3), read.Usage.RequestCount)
+	require.False(t, read.Usage.LastUsed.Before(before))
+	require.Len(t, read.Usage.Sources, 1)
+	require.Equal(t, srv.config.RPCAdvertise.IP.String(), read.Usage.Sources[0].Address)
+	require.Equal(t, uint64(3), read.Usage.Sources[0].RequestCount)
+
+	// usage is not stored with the token
+	_, stored, err := srv.fsm.State().ACLTokenGetByAccessor(nil, token.AccessorID, nil)
+	require.NoError(t, err)
+	require.Nil(t, stored.Usage)
+
+	listReq := structs.ACLTokenListRequest{
+		Datacenter:    "dc1",
+		IncludeLocal:  true,
+		IncludeGlobal: true,
+		QueryOptions:  structs.QueryOptions{Token: TestDefaultInitialManagementToken},
+	}
+	listResp := structs.ACLTokenListResponse{}
+	require.NoError(t, aclEp.TokenList(&listReq, &listResp))
+
+	var found bool
+	for _, stub := range listResp.Tokens {
+		if stub.AccessorID == token.AccessorID {
+			found = true
+			require.NotNil(t, stub.Usage)
+			require.Equal(t, uint64(3), stub.Usage.RequestCount)
+		}
+	}
+	require.True(t, found)
+
+	// only servers may update the usage of tokens
+	updateReq := structs.ACLTokenUsageUpdateRequest{
+		Datacenter: "dc1",
+		Usage: map[string]structs.ACLTokenUsage{
+			token.AccessorID: {LastUsed: time.Now(), RequestCount: 100},
+		},
+	}
+	err = srv.RPC("ACL.TokenUsageUpdate", &updateReq, &struct{}{})
+	require.True(t, acl.IsErrPermissionDenied(err), "unexpected error: %v", err)
+	require.Equal(t, uint64(3), readToken().Usage.RequestCount)
+}
+
+func TestACLEndpoint_TokenSet_AnonymousScopes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	_, srv, codec := testACLServerWithConfig(t, nil, false)
+	waitForLeaderEstablishment(t, srv)
+
+	policy, err := upsertTestPolicyWithRules(codec, TestDefaultInitialManagementToken, "dc1", `node_prefix "" { policy = "read" }`)
+	require.NoError(t, err)
+
+	req := structs.ACLTokenSetRequest{
+		Datacenter: "dc1",
+		ACLToken: structs.ACLToken{
+			AccessorID: structs.ACLTokenAnonymousID,
+			AnonymousScopes: []*structs.ACLAnonymousScope{
+				{
+					Name:        "lan",
+					SourceCIDRs: []string{"10.0.0.0/8"},
+					Policies:    []structs.ACLTokenPolicyLink{{ID: policy.ID}},
+				},
+			},
+		},
+		WriteRequest: structs.WriteRequest{Token: TestDefaultInitialManagementToken},
+	}
+	var resp structs.ACLToken
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.TokenSet", &req, &resp))
+	require.Len(t, resp.AnonymousScopes, 1)
+
+	// the anonymous token itself is not granted the policy of the scope
+	authz, err := srv.ResolveToken("")
+	require.NoError(t, err)
+	require.Equal(t, acl.Deny, authz.NodeRead("foo", nil))
+
+	// the scope is set by the server from the source of the request, the
+	// one sent by the caller is ignored
+	scopeFrom := func(ip string, scope string) string {
+		args := structs.DCSpecificRequest{
+			Datacenter:   "dc1",
+			QueryOptions: structs.QueryOptions{AnonymousScope: scope},
+		}
+		srv.setAnonymousScope(&args, &net.TCPAddr{IP: net.ParseIP(ip), Port: 4321})
+		return args.AnonymousScope
+	}
+	require.Equal(t, "lan", scopeFrom("10.1.2.3", ""))
+	require.Equal(t, "", scopeFrom("192.168.1.1", "lan"))
+
+	// requests with a token have no scope
+	args := structs.DCSpecificRequest{
+		Datacenter:   "dc1",
+		QueryOptions: structs.QueryOptions{Token: TestDefaultInitialManagementToken},
+	}
+	srv.setAnonymousScope(&args, &net.TCPAddr{IP: net.ParseIP("10.1.2.3")})
+	require.Empty(t, args.AnonymousScope)
+
+	listNodes := func(token, scope string) (structs.Nodes, error) {
+		args := structs.DCSpecificRequest{
+			Datacenter:   "dc1",
+			QueryOptions: structs.QueryOptions{Token: token, AnonymousScope: scope},
+		}
+		var out structs.IndexedNodes
+		err := msgpackrpc.CallWithCodec(codec, "Catalog.ListNodes", &args, &out)
+		return out.Nodes, err
+	}
+
+	nodes, err := listNodes("", "")
+	require.NoError(t, err)
+	require.Empty(t, nodes)
+
+	// the scope set by the servers of the datacenter forwarding requests is
+	// used
+	nodes, err = listNodes("", "lan")
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+
+	// unknown scopes get the permissions of the anonymous token
+	nodes, err = listNodes("", "wan")
+	require.NoError(t, err)
+	require.Empty(t, nodes)
+
+	// callers can't provide the secrets used for the scopes
+	_, err = listNodes(srv.anonymousScopePrefix+"lan", "")
+	require.True(t, acl.IsErrNotFound(err), "unexpected error: %v", err)
+
+	_, err = srv.ResolveToken("anonymous-scope:lan")
+	require.True(t, acl.IsErrNotFound(err), "unexpected error: %v", err)
+
+	// the requests of the server's own agent are in the scope of the
+	// server's address
+	local := structs.ACLTokenSetRequest{
+		Datacenter: "dc1",
+		ACLToken: structs.ACLToken{
+			AccessorID: structs.ACLTokenAnonymousID,
+			AnonymousScopes: []*structs.ACLAnonymousScope{
+				{
+					Name:        "local",
+					SourceCIDRs: []string{"127.0.0.0/8"},
+					Policies:    []structs.ACLTokenPolicyLink{{ID: policy.ID}},
+				},
+			},
+		},
+		WriteRequest: structs.WriteRequest{Token: TestDefaultInitialManagementToken},
+	}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.TokenSet", &local, &resp))
+
+	var out structs.IndexedNodes
+	require.NoError(t, srv.RPC("Catalog.ListNodes", &structs.DCSpecificRequest{Datacenter: "dc1"}, &out))
+	require.Len(t, out.Nodes, 1)
+}
+
+func TestACLEndpoint_PolicyEvaluate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	_, srv, codec := testACLServerWithConfig(t, nil, false)
+	waitForLeaderEstablishment(t, srv)
+
+	token, err := upsertTestTokenWithPolicyRules(codec, TestDefaultInitialManagementToken, "dc1", `service "web" { policy = "write" }`)
+	require.NoError(t, err)
+
+	policy, err := upsertTestPolicyWithRules(codec, TestDefaultInitialManagementToken, "dc1", `service_prefix "" { policy = "read" }`)
+	require.NoError(t, err)
+
+	aclEp := ACL{srv: srv}
+
+	requests := []structs.ACLAuthorizationRequest{
+		{Resource: acl.ResourceService, Segment: "web", Access: "write"},
+		{Resource: acl.ResourceService, Segment: "db", Access: "read"},
+	}
+
+	t.Run("token", func(t *testing.T) {
+		req := structs.ACLPolicyEvaluateRequest{
+			Datacenter:   "dc1",
+			SecretID:     token.SecretID,
+			Requests:     requests,
+			QueryOptions: structs.QueryOptions{Token: TestDefaultInitialManagementToken},
+		}
+		var resp structs.ACLPolicyEvaluateResponse
+		require.NoError(t, aclEp.PolicyEvaluate(&req, &resp))
+		require.Len(t, resp.Results, 2)
+
+		require.True(t, resp.Results[0].Allow)
+		require.Equal(t, token.Policies[0].ID, resp.Results[0].PolicyID)
+		require.False(t, resp.Results[1].Allow)
+		require.True(t, resp.Results[1].DefaultPolicy)
+	})
+
+	t.Run("policies and rules", func(t *testing.T) {
+		req := structs.ACLPolicyEvaluateRequest{
+			Datacenter:   "dc1",
+			Policies:     []structs.ACLTokenPolicyLink{{Name: policy.Name}},
+			Rules:        `service "web" { policy = "write" }`,
+			Requests:     requests,
+			QueryOptions: structs.QueryOptions{Token: TestDefaultInitialManagementToken},
+		}
+		var resp structs.ACLPolicyEvaluateResponse
+		require.NoError(t, aclEp.PolicyEvaluate(&req, &resp))
+		require.Len(t, resp.Results, 2)
+
+		require.True(t, resp.Results[0].Allow)
+		require.Equal(t, structs.ACLPolicyEvaluateRulesName, resp.Results[0].PolicyName)
+		require.True(t, resp.Results[1].Allow)
+		require.Equal(t, policy.ID, resp.Results[1].PolicyID)
+	})
+
+	t.Run("missing policy", func(t *testing.T) {
+		req := structs.ACLPolicyEvaluateRequest{
+			Datacenter:   "dc1",
+			Policies:     []structs.ACLTokenPolicyLink{{Name: "does-not-exist"}},
+			Requests:     requests,
+			QueryOptions: structs.QueryOptions{Token: TestDefaultInitialManagementToken},
+		}
+		var resp structs.ACLPolicyEvaluateResponse
+		require.Error(t, aclEp.PolicyEvaluate(&req, &resp))
+	})
+
+	t.Run("requires acl read", func(t *testing.T) {
+		req := structs.ACLPolicyEvaluateRequest{
+			Datacenter:   "dc1",
+			Rules:        `service "web" { policy = "write" }`,
+			Requests:     requests,
+			QueryOptions: structs.QueryOptions{Token: token.SecretID},
+		}
+		var resp structs.ACLPolicyEvaluateResponse
+		err := aclEp.PolicyEvaluate(&req, &resp)
+		require.True(t, acl.IsErrPermissionDenied(err))
+	})
+
+	t.Run("own token", func(t *testing.T) {
+		req := structs.ACLPolicyEvaluateRequest{
+			Datacenter:   "dc1",
+			SecretID:     token.SecretID,
+			Requests:     requests,
+			QueryOptions: structs.QueryOptions{Token: token.SecretID},
+		}
+		var resp structs.ACLPolicyEvaluateResponse
+		require.NoError(t, aclEp.PolicyEvaluate(&req, &resp))
+		require.True(t, resp.Results[0].Allow)
+		require.Equal(t, `service "web"`, resp.Results[0].Rule)
+	})
+
+	t.Run("other tokens require acl write", func(t *testing.T) {
+		reader, err := upsertTestTokenWithPolicyRules(codec, TestDefaultInitialManagementToken, "dc1", `acl = "read"`)
+		require.NoError(t, err)
+
+		for _, secretID := range []string{token.SecretID, "6c4e2e2c-5ff0-4bd8-9e0c-5ac1b1b5b0d4"} {
+			req := structs.ACLPolicyEvaluateRequest{
+				Datacenter:   "dc1",
+				SecretID:     secretID,
+				Requests:     requests,
+				QueryOptions: structs.QueryOptions{Token: reader.SecretID},
+			}
+			var resp structs.ACLPolicyEvaluateResponse
+			err = aclEp.PolicyEvaluate(&req, &resp)
+			require.True(t, acl.IsErrPermissionDenied(err), "unexpected error: %v", err)
+		}
+	})
+}
+
 func TestACLEndpoint_PolicyList(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -3673,6 +3983,28 @@ func TestACLEndpoint_BindingRuleSet(t *testing.T) {
 		reqRule.BindName = "method-${serviceaccount.name}:blah-"
 		requireSetErrors(t, reqRule)
 	})
+
+	t.Run("Create with bind name expression", func(t *testing.T) {
+		reqRule := newRule()
+		reqRule.BindName = ""
+		reqRule.BindNameExpression = `vars["serviceaccount.name"].lowerAscii()`
+		rule := requireOK(t, reqRule)
+		require.Equal(t, reqRule.BindNameExpression, rule.BindNameExpression)
+		require.Empty(t, rule.BindName)
+	})
+
+	t.Run("Create fails; bind name and bind name expression", func(t *testing.T) {
+		reqRule := newRule()
+		reqRule.BindNameExpression = `vars["serviceaccount.name"]`
+		requireSetErrors(t, reqRule)
+	})
+
+	t.Run("Create fails; invalid bind name expression", func(t *testing.T) {
+		reqRule := newRule()
+		reqRule.BindName = ""
+		reqRule.BindNameExpression = `vars["serviceaccount.name"] == "abc"`
+		requireSetErrors(t, reqRule)
+	})
 }
 
 func TestACLEndpoint_BindingRuleDelete(t *testing.T) {
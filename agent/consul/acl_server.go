@@ -2,6 +2,8 @@ package consul
 
 import (
 	"fmt"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/consul/acl"
@@ -130,16 +132,120 @@ func (s *Server) ResolveIdentityFromToken(token string) (bool, structs.ACLIdenti
 		return false, nil, nil
 	}
 
+	scope, scoped := s.anonymousScopeFromToken(token)
+	if scoped {
+		token = anonymousToken
+	}
+
 	index, aclToken, err := s.fsm.State().ACLTokenGetBySecret(nil, token, nil)
 	if err != nil {
 		return true, nil, err
 	} else if aclToken != nil && !aclToken.IsExpired(time.Now()) {
+		if scoped {
+			return true, aclToken.AnonymousScopeToken(scope), nil
+		}
 		return true, aclToken, nil
 	}
 
 	return s.InPrimaryDatacenter() || index > 0, nil, acl.ErrNotFound
 }
 
+// anonymousScopeRequest is implemented by the requests that may be made in a
+// scope of the anonymous token.
+type anonymousScopeRequest interface {
+	GetAnonymousScope() string
+	SetAnonymousScope(string)
+}
+
+// setAnonymousScope sets the scope of the anonymous token of a request
+// received from the source address, replacing the one sent by the caller.
+// It isn't called for the requests forwarded by the servers of the
+// datacenter, which set the scope from the source of the original request.
+func (s *Server) setAnonymousScope(args interface{}, source net.Addr) {
+	req, ok := args.(anonymousScopeRequest)
+	if !ok {
+		return
+	}
+	scope := ""
+	if info, ok := args.(structs.RPCInfo); ok && info.TokenSecret() == "" {
+		scope = s.anonymousScopeForSource(source)
+	}
+	req.SetAnonymousScope(scope)
+}
+
+// anonymousScopeForSource returns the name of the first scope of the anonymous
+// token matching the address of an agent, or of any other caller, and the
+// network segment of the agent.
+func (s *Server) anonymousScopeForSource(source net.Addr) string {
+	if !s.config.ACLsEnabled {
+		return ""
+	}
+	tcpAddr, ok := source.(*net.TCPAddr)
+	if !ok || tcpAddr == nil {
+		return ""
+	}
+	_, anonymous, err := s.fsm.State().ACLTokenGetBySecret(nil, anonymousToken, nil)
+	if err != nil || anonymous == nil {
+		return ""
+	}
+
+	segment, segmentKnown := "", false
+	for _, scope := range anonymous.AnonymousScopes {
+		if len(scope.Segments) > 0 && !segmentKnown {
+			segment, segmentKnown = s.lanSegmentOf(tcpAddr.IP), true
+		}
+		if scope.Matches(tcpAddr.IP, segment) {
+			return scope.Name
+		}
+	}
+	return ""
+}
+
+// lanSegmentOf returns the network segment of the LAN member with the given
+// address, or an empty string if there is none.
+func (s *Server) lanSegmentOf(ip net.IP) string {
+	for _, m := range s.LANMembersInAgentPartition() {
+		if m.Addr.Equal(ip) {
+			return m.Tags["segment"]
+		}
+	}
+	return ""
+}
+
+// setAnonymousScopeToken is called when a request is handled by this server.
+// If the request has no token and is in a scope of the anonymous token, its
+// token is replaced with a secret that only this server resolves to the
+// scope, so that the endpoint authorizes it with the policies of the scope.
+// Callers can't provide such a secret themselves.
+func (s *Server) setAnonymousScopeToken(info structs.RPCInfo) error {
+	token := info.TokenSecret()
+	if _, ok := s.anonymousScopeFromToken(token); ok {
+		return acl.ErrNotFound
+	}
+
+	req, ok := info.(anonymousScopeRequest)
+	if !ok || token != "" || req.GetAnonymousScope() == "" || !s.config.ACLsEnabled {
+		return nil
+	}
+	// Tokens are resolved by the primary datacenter when they aren't
+	// replicated, the secret is unknown there.
+	if !s.InPrimaryDatacenter() && !s.config.ACLTokenReplication {
+		return nil
+	}
+
+	info.SetTokenSecret(s.anonymousScopePrefix + req.GetAnonymousScope())
+	return nil
+}
+
+// anonymousScopeFromToken returns the scope of the anonymous token of a secret
+// set by setAnonymousScopeToken.
+func (s *Server) anonymousScopeFromToken(token string) (string, bool) {
+	if s.anonymousScopePrefix == "" || !strings.HasPrefix(token, s.anonymousScopePrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(token, s.anonymousScopePrefix), true
+}
+
 func (s *serverACLResolverBackend) ResolvePolicyFromID(policyID string) (bool, *structs.ACLPolicy, error) {
 	index, policy, err := s.fsm.State().ACLPolicyGetByID(nil, policyID, nil)
 	if err != nil {
@@ -0,0 +1,162 @@
+package consul
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+const (
+	// aclTokenUsageMaxTokens bounds the number of tokens whose usage a server
+	// records between two flushes. The usage of other tokens is dropped until
+	// the next flush.
+	aclTokenUsageMaxTokens = 16384
+
+	// aclTokenUsageFlushInterval is how often servers add the usage they
+	// recorded to the state store.
+	aclTokenUsageFlushInterval = time.Minute
+)
+
+// aclTokenUsageRecorder records the usage of tokens by the requests received
+// by a server until it is flushed to the state store.
+type aclTokenUsageRecorder struct {
+	lock sync.Mutex
+	// pending is the usage recorded since the last flush, by token secret.
+	// Requests only carry the secret, the accessor is looked up on flush.
+	pending map[string]structs.ACLTokenUsage
+}
+
+func newACLTokenUsageRecorder() *aclTokenUsageRecorder {
+	return &aclTokenUsageRecorder{pending: make(map[string]structs.ACLTokenUsage)}
+}
+
+// Record counts a request made with the token from the source address.
+func (r *aclTokenUsageRecorder) Record(secretID, source string, now time.Time) {
+	if secretID == "" {
+		return
+	}
+	r.merge(secretID, structs.ACLTokenUsage{
+		LastUsed:     now,
+		RequestCount: 1,
+		Sources: []structs.ACLTokenUsageSource{
+			{Address: source, LastUsed: now, RequestCount: 1},
+		},
+	})
+}
+
+func (r *aclTokenUsageRecorder) merge(secretID string, usage structs.ACLTokenUsage) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	pending, ok := r.pending[secretID]
+	if !ok && len(r.pending) >= aclTokenUsageMaxTokens {
+		metrics.IncrCounter([]string{"acl", "token", "usage_dropped"}, 1)
+		return
+	}
+	pending.Merge(usage)
+	r.pending[secretID] = pending
+}
+
+// drain returns the usage recorded since the last call and resets it.
+func (r *aclTokenUsageRecorder) drain() map[string]structs.ACLTokenUsage {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	pending := r.pending
+	r.pending = make(map[string]structs.ACLTokenUsage)
+	return pending
+}
+
+// recordTokenUsage records the usage of the token of a request received from
+// source by the server, unless the request was forwarded by another server of
+// the datacenter. Token usage updates can only be sent by servers, those
+// received from anything else are flagged to be rejected.
+func (s *Server) recordTokenUsage(args interface{}, source net.Addr) {
+	if req, ok := args.(*structs.ACLTokenUsageUpdateRequest); ok {
+		req.FromAgent = true
+		return
+	}
+	if !s.config.ACLsEnabled {
+		return
+	}
+	info, ok := args.(structs.RPCInfo)
+	if !ok {
+		return
+	}
+	s.tokenUsage.Record(info.TokenSecret(), tokenUsageSourceAddress(source), time.Now())
+}
+
+func tokenUsageSourceAddress(source net.Addr) string {
+	if tcpAddr, ok := source.(*net.TCPAddr); ok && tcpAddr != nil {
+		return tcpAddr.IP.String()
+	}
+	if source == nil {
+		return ""
+	}
+	return source.String()
+}
+
+// runTokenUsageFlush periodically adds the usage of tokens recorded by the
+// server to the state store.
+func (s *Server) runTokenUsageFlush() {
+	if !s.config.ACLsEnabled {
+		return
+	}
+
+	ticker := time.NewTicker(aclTokenUsageFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.shutdownCh:
+			return
+		case <-ticker.C:
+			if err := s.flushTokenUsage(); err != nil {
+				s.logger.Warn("failed to update the usage of ACL tokens", "error", err)
+			}
+		}
+	}
+}
+
+// flushTokenUsage sends the usage of tokens recorded since the last flush to
+// the leader. The usage is kept for the next flush if it fails.
+func (s *Server) flushTokenUsage() error {
+	pending := s.tokenUsage.drain()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	state := s.fsm.State()
+	req := structs.ACLTokenUsageUpdateRequest{
+		Datacenter: s.config.Datacenter,
+		Usage:      make(map[string]structs.ACLTokenUsage, len(pending)),
+	}
+	for secretID, usage := range pending {
+		_, token, err := state.ACLTokenGetBySecret(nil, secretID, nil)
+		if err != nil || token == nil {
+			// Unknown tokens, or tokens resolved by the primary datacenter
+			// which aren't replicated.
+			continue
+		}
+		if u, ok := req.Usage[token.AccessorID]; ok {
+			usage.Merge(u)
+		}
+		req.Usage[token.AccessorID] = usage
+	}
+	if len(req.Usage) == 0 {
+		return nil
+	}
+
+	endpoint := ACL{srv: s}
+	if err := endpoint.TokenUsageUpdate(&req, &struct{}{}); err != nil {
+		for secretID, usage := range pending {
+			s.tokenUsage.merge(secretID, usage)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package consul
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestACLTokenUsageRecorder(t *testing.T) {
+	recorder := newACLTokenUsageRecorder()
+
+	now := time.Now()
+	recorder.Record("a", "10.0.0.1", now)
+	recorder.Record("a", "10.0.0.2", now.Add(time.Second))
+	recorder.Record("a", "10.0.0.1", now.Add(2*time.Second))
+	recorder.Record("b", "10.0.0.1", now)
+	// Requests without a token are made with the anonymous token.
+	recorder.Record("", "10.0.0.1", now)
+
+	pending := recorder.drain()
+	require.Len(t, pending, 2)
+
+	a := pending["a"]
+	require.Equal(t, uint64(3), a.RequestCount)
+	require.Equal(t, now.Add(2*time.Second), a.LastUsed)
+	require.Len(t, a.Sources, 2)
+	require.Equal(t, "10.0.0.1", a.Sources[0].Address)
+	require.Equal(t, uint64(2), a.Sources[0].RequestCount)
+	require.Equal(t, "10.0.0.2", a.Sources[1].Address)
+	require.Equal(t, uint64(1), pending["b"].RequestCount)
+
+	// The usage is reset once drained.
+	require.Empty(t, recorder.drain())
+
+	// The usage of new tokens is dropped once too many are pending.
+	for i := 0; i < aclTokenUsageMaxTokens; i++ {
+		recorder.Record(string(rune(i+1)), "10.0.0.1", now)
+	}
+	recorder.Record("dropped", "10.0.0.1", now)
+	recorder.Record(string(rune(1)), "10.0.0.1", now)
+	pending = recorder.drain()
+	require.Len(t, pending, aclTokenUsageMaxTokens)
+	require.NotContains(t, pending, "dropped")
+	require.Equal(t, uint64(2), pending[string(rune(1))].RequestCount)
+}
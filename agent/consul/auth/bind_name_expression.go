@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	"github.com/google/cel-go/ext"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// bindNameExpressionCacheSize bounds the number of compiled bind name
+// expressions kept by a server.
+const bindNameExpressionCacheSize = 256
+
+// bindNameExpressionVars is the name of the variable holding the verified
+// identity's projected variables in bind name expressions.
+const bindNameExpressionVars = "vars"
+
+var (
+	bindNameExpressionEnv   = newBindNameExpressionEnv()
+	bindNameExpressionCache = newBindNameExpressionCache()
+)
+
+func newBindNameExpressionEnv() *cel.Env {
+	env, err := cel.NewEnv(
+		cel.Declarations(
+			decls.NewVar(bindNameExpressionVars, decls.NewMapType(decls.String, decls.String)),
+		),
+		ext.Strings(),
+	)
+	if err != nil {
+		panic(fmt.Sprintf("failed to create the bind name expression environment: %v", err))
+	}
+	return env
+}
+
+func newBindNameExpressionCache() *lru.Cache {
+	cache, err := lru.New(bindNameExpressionCacheSize)
+	if err != nil {
+		panic(fmt.Sprintf("failed to create the bind name expression cache: %v", err))
+	}
+	return cache
+}
+
+// CompileBindNameExpression parses and type checks a CEL bind name expression.
+// The expression has access to the projected variables of the verified
+// identity as the map "vars" and must evaluate to a string.
+func CompileBindNameExpression(expression string) (cel.Program, error) {
+	if prg, ok := bindNameExpressionCache.Get(expression); ok {
+		return prg.(cel.Program), nil
+	}
+
+	ast, issues := bindNameExpressionEnv.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if !proto.Equal(ast.ResultType(), decls.String) {
+		return nil, fmt.Errorf("expression must evaluate to a string")
+	}
+
+	prg, err := bindNameExpressionEnv.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	bindNameExpressionCache.Add(expression, prg)
+	return prg, nil
+}
+
+// evaluateBindNameExpression computes a bind name from a CEL expression and the
+// projected variables of the verified identity. An empty name means the rule
+// does not apply to the identity.
+func evaluateBindNameExpression(expression string, projectedVars map[string]string) (string, error) {
+	prg, err := CompileBindNameExpression(expression)
+	if err != nil {
+		return "", err
+	}
+
+	out, _, err := prg.Eval(map[string]interface{}{
+		bindNameExpressionVars: projectedVars,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	name, ok := out.Value().(string)
+	if !ok {
+		return "", fmt.Errorf("expression evaluated to %v instead of a string", out.Value())
+	}
+	return name, nil
+}
@@ -84,12 +84,24 @@ func (b *Binder) Bind(authMethod *structs.ACLAuthMethod, verifiedIdentity *authm
 	}
 
 	// Compute role, service identity, or node identity names by interpolating
-	// the identity's projected variables into the rule BindName templates.
+	// the identity's projected variables into the rule BindName templates, or
+	// by evaluating the rule BindNameExpression.
 	for _, rule := range matchingRules {
-		bindName, valid, err := computeBindName(rule.BindType, rule.BindName, verifiedIdentity.ProjectedVars)
+		var (
+			bindName string
+			valid    bool
+		)
+		if rule.BindNameExpression != "" {
+			bindName, valid, err = computeBindNameFromExpression(rule.BindType, rule.BindNameExpression, verifiedIdentity.ProjectedVars)
+		} else {
+			bindName, valid, err = computeBindName(rule.BindType, rule.BindName, verifiedIdentity.ProjectedVars)
+		}
 		switch {
 		case err != nil:
 			return nil, fmt.Errorf("cannot compute %q bind name for bind target: %w", rule.BindType, err)
+		case rule.BindNameExpression != "" && bindName == "":
+			// The expression excluded the identity from this rule.
+			continue
 		case !valid:
 			return nil, fmt.Errorf("computed %q bind name for bind target is invalid: %q", rule.BindType, bindName)
 		}
@@ -154,19 +166,44 @@ func computeBindName(bindType, bindName string, projectedVars map[string]string)
 		return "", false, err
 	}
 
-	var valid bool
+	valid, err := isValidBindNameForType(bindType, bindName)
+	if err != nil {
+		return "", false, err
+	}
+	return bindName, valid, nil
+}
+
+// computeBindNameFromExpression evaluates the CEL bind name expression for the
+// provided bind type using the projected variables. It follows the same
+// conventions as computeBindName, except that an empty computed name is
+// returned as ("", false, nil) and means the rule does not apply.
+func computeBindNameFromExpression(bindType, expression string, projectedVars map[string]string) (string, bool, error) {
+	bindName, err := evaluateBindNameExpression(expression, projectedVars)
+	if err != nil {
+		return "", false, err
+	}
+	if bindName == "" {
+		return "", false, nil
+	}
+
+	valid, err := isValidBindNameForType(bindType, bindName)
+	if err != nil {
+		return "", false, err
+	}
+	return bindName, valid, nil
+}
+
+func isValidBindNameForType(bindType, bindName string) (bool, error) {
 	switch bindType {
 	case structs.BindingRuleBindTypeService:
-		valid = acl.IsValidServiceIdentityName(bindName)
+		return acl.IsValidServiceIdentityName(bindName), nil
 	case structs.BindingRuleBindTypeNode:
-		valid = acl.IsValidNodeIdentityName(bindName)
+		return acl.IsValidNodeIdentityName(bindName), nil
 	case structs.BindingRuleBindTypeRole:
-		valid = acl.IsValidRoleName(bindName)
+		return acl.IsValidRoleName(bindName), nil
 	default:
-		return "", false, fmt.Errorf("unknown binding rule bind type: %s", bindType)
+		return false, fmt.Errorf("unknown binding rule bind type: %s", bindType)
 	}
-
-	return bindName, valid, nil
 }
 
 // doesSelectorMatch checks that a single selector matches the provided vars.
@@ -255,6 +255,108 @@ func TestBinder_NodeIdentities_NameValidation(t *testing.T) {
 	require.Contains(t, err.Error(), "bind name for bind target is invalid")
 }
 
+func TestBinder_BindNameExpression(t *testing.T) {
+	store := testStateStore(t)
+	binder := &Binder{store: store}
+
+	authMethod := &structs.ACLAuthMethod{
+		Name: "test-auth-method",
+		Type: "testing",
+	}
+	require.NoError(t, store.ACLAuthMethodSet(0, authMethod))
+
+	adminRole := &structs.ACLRole{
+		ID:   generateID(t),
+		Name: "admin",
+	}
+	require.NoError(t, store.ACLRoleSet(0, adminRole))
+
+	bindingRules := structs.ACLBindingRules{
+		{
+			ID:                 generateID(t),
+			BindType:           structs.BindingRuleBindTypeService,
+			BindNameExpression: `vars.name.startsWith("svc-") ? vars.name.substring(4).lowerAscii() : vars.name.lowerAscii()`,
+			AuthMethod:         authMethod.Name,
+		},
+		{
+			ID:                 generateID(t),
+			BindType:           structs.BindingRuleBindTypeRole,
+			BindNameExpression: `vars.team == "ops" ? "admin" : ""`,
+			AuthMethod:         authMethod.Name,
+		},
+	}
+	require.NoError(t, store.ACLBindingRuleBatchSet(0, bindingRules))
+
+	result, err := binder.Bind(&structs.ACLAuthMethod{}, &authmethod.Identity{
+		ProjectedVars: map[string]string{
+			"name": "svc-Billing",
+			"team": "ops",
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*structs.ACLServiceIdentity{
+		{ServiceName: "billing"},
+	}, result.ServiceIdentities)
+	require.Equal(t, []structs.ACLTokenRoleLink{
+		{ID: adminRole.ID},
+	}, result.Roles)
+
+	// The role rule does not apply when its expression evaluates to "".
+	result, err = binder.Bind(&structs.ACLAuthMethod{}, &authmethod.Identity{
+		ProjectedVars: map[string]string{
+			"name": "Web",
+			"team": "dev",
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*structs.ACLServiceIdentity{
+		{ServiceName: "web"},
+	}, result.ServiceIdentities)
+	require.Empty(t, result.Roles)
+}
+
+func TestBinder_BindNameExpression_NameValidation(t *testing.T) {
+	store := testStateStore(t)
+	binder := &Binder{store: store}
+
+	authMethod := &structs.ACLAuthMethod{
+		Name: "test-auth-method",
+		Type: "testing",
+	}
+	require.NoError(t, store.ACLAuthMethodSet(0, authMethod))
+
+	bindingRules := structs.ACLBindingRules{
+		{
+			ID:                 generateID(t),
+			BindType:           structs.BindingRuleBindTypeService,
+			BindNameExpression: `vars.name + "!"`,
+			AuthMethod:         authMethod.Name,
+		},
+	}
+	require.NoError(t, store.ACLBindingRuleBatchSet(0, bindingRules))
+
+	_, err := binder.Bind(&structs.ACLAuthMethod{}, &authmethod.Identity{
+		ProjectedVars: map[string]string{"name": "web"},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "bind name for bind target is invalid")
+}
+
+func TestCompileBindNameExpression(t *testing.T) {
+	_, err := CompileBindNameExpression(`vars["serviceaccount.name"].lowerAscii()`)
+	require.NoError(t, err)
+
+	_, err = CompileBindNameExpression(`vars.name.`)
+	require.Error(t, err)
+
+	_, err = CompileBindNameExpression(`vars.name == "web"`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "must evaluate to a string")
+
+	_, err = CompileBindNameExpression(`unknown.name`)
+	require.Error(t, err)
+}
+
 func Test_IsValidBindName(t *testing.T) {
 	type testcase struct {
 		name     string
@@ -3,6 +3,7 @@ package auth
 import (
 	"errors"
 	"fmt"
+	"net"
 	"time"
 
 	"github.com/hashicorp/go-memdb"
@@ -282,6 +283,9 @@ func (w *TokenWriter) tokenIDInUse(id string) (bool, error) {
 }
 
 func (w *TokenWriter) write(token, existing *structs.ACLToken, fromLogin bool) (*structs.ACLToken, error) {
+	// Usage is stored apart from the token and only updated by servers.
+	token.Usage = nil
+
 	roles, err := w.normalizeRoleLinks(token.Roles, &token.EnterpriseMeta)
 	if err != nil {
 		return nil, err
@@ -306,6 +310,12 @@ func (w *TokenWriter) write(token, existing *structs.ACLToken, fromLogin bool) (
 	}
 	token.NodeIdentities = nodeIdentities
 
+	anonymousScopes, err := w.normalizeAnonymousScopes(token)
+	if err != nil {
+		return nil, err
+	}
+	token.AnonymousScopes = anonymousScopes
+
 	if token.Rules != "" {
 		return nil, errors.New("Rules cannot be specified for this token")
 	}
@@ -447,3 +457,50 @@ func (w *TokenWriter) normalizeNodeIdentities(nodeIDs structs.ACLNodeIdentities)
 	}
 	return nodeIDs.Deduplicate(), nil
 }
+
+func (w *TokenWriter) normalizeAnonymousScopes(token *structs.ACLToken) ([]*structs.ACLAnonymousScope, error) {
+	if len(token.AnonymousScopes) == 0 {
+		return nil, nil
+	}
+	if token.AccessorID != structs.ACLTokenAnonymousID {
+		return nil, errors.New("Anonymous scopes can only be set on the anonymous token")
+	}
+
+	names := make(map[string]struct{})
+	for _, scope := range token.AnonymousScopes {
+		if scope.Name == "" {
+			return nil, errors.New("Anonymous scope is missing the name field")
+		}
+		if _, ok := names[scope.Name]; ok {
+			return nil, fmt.Errorf("Anonymous scope %q is defined more than once", scope.Name)
+		}
+		names[scope.Name] = struct{}{}
+
+		if len(scope.SourceCIDRs) == 0 && len(scope.Segments) == 0 {
+			return nil, fmt.Errorf("Anonymous scope %q must set SourceCIDRs or Segments", scope.Name)
+		}
+		for _, cidr := range scope.SourceCIDRs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return nil, fmt.Errorf("Anonymous scope %q has an invalid source CIDR %q: %w", scope.Name, cidr, err)
+			}
+		}
+		for _, segment := range scope.Segments {
+			if segment == "" {
+				return nil, fmt.Errorf("Anonymous scope %q has an empty segment", scope.Name)
+			}
+		}
+
+		policies, err := w.normalizePolicyLinks(scope.Policies, &token.EnterpriseMeta)
+		if err != nil {
+			return nil, fmt.Errorf("Anonymous scope %q: %w", scope.Name, err)
+		}
+		scope.Policies = policies
+
+		roles, err := w.normalizeRoleLinks(scope.Roles, &token.EnterpriseMeta)
+		if err != nil {
+			return nil, fmt.Errorf("Anonymous scope %q: %w", scope.Name, err)
+		}
+		scope.Roles = roles
+	}
+	return token.AnonymousScopes, nil
+}
@@ -362,6 +362,90 @@ func TestTokenWriter_NodeIdentities(t *testing.T) {
 	}
 }
 
+func TestTokenWriter_AnonymousScopes(t *testing.T) {
+	aclCache := &MockACLCache{}
+	aclCache.On("RemoveIdentityWithSecretToken", mock.Anything)
+
+	store := testStateStore(t)
+
+	policy := &structs.ACLPolicy{
+		ID:   generateID(t),
+		Name: generateID(t),
+	}
+	require.NoError(t, store.ACLPolicySet(0, policy))
+
+	anonymous := &structs.ACLToken{
+		AccessorID: structs.ACLTokenAnonymousID,
+		SecretID:   "anonymous",
+	}
+	require.NoError(t, store.ACLTokenSet(0, anonymous))
+
+	writer := buildTokenWriter(store, aclCache)
+
+	testCases := map[string]struct {
+		input         []*structs.ACLAnonymousScope
+		output        []*structs.ACLAnonymousScope
+		errorContains string
+	}{
+		"valid scope": {
+			input: []*structs.ACLAnonymousScope{
+				{Name: "lan", SourceCIDRs: []string{"10.0.0.0/8"}, Policies: []structs.ACLTokenPolicyLink{{Name: policy.Name}}},
+			},
+			output: []*structs.ACLAnonymousScope{
+				{Name: "lan", SourceCIDRs: []string{"10.0.0.0/8"}, Policies: []structs.ACLTokenPolicyLink{{ID: policy.ID}}},
+			},
+		},
+		"missing name": {
+			input:         []*structs.ACLAnonymousScope{{SourceCIDRs: []string{"10.0.0.0/8"}}},
+			errorContains: "missing the name field",
+		},
+		"duplicate name": {
+			input: []*structs.ACLAnonymousScope{
+				{Name: "lan", SourceCIDRs: []string{"10.0.0.0/8"}},
+				{Name: "lan", Segments: []string{"alpha"}},
+			},
+			errorContains: "defined more than once",
+		},
+		"no source": {
+			input:         []*structs.ACLAnonymousScope{{Name: "lan"}},
+			errorContains: "must set SourceCIDRs or Segments",
+		},
+		"invalid CIDR": {
+			input:         []*structs.ACLAnonymousScope{{Name: "lan", SourceCIDRs: []string{"10.0.0.0"}}},
+			errorContains: "invalid source CIDR",
+		},
+		"invalid policy": {
+			input: []*structs.ACLAnonymousScope{
+				{Name: "lan", Segments: []string{"alpha"}, Policies: []structs.ACLTokenPolicyLink{{ID: generateID(t)}}},
+			},
+			errorContains: "No such ACL policy with ID",
+		},
+	}
+	for desc, tc := range testCases {
+		t.Run(desc, func(t *testing.T) {
+			updated, err := writer.Update(&structs.ACLToken{
+				AccessorID:      structs.ACLTokenAnonymousID,
+				AnonymousScopes: tc.input,
+			})
+			if tc.errorContains == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.output, updated.AnonymousScopes)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.errorContains)
+			}
+		})
+	}
+
+	t.Run("not the anonymous token", func(t *testing.T) {
+		_, err := writer.Create(&structs.ACLToken{
+			AnonymousScopes: []*structs.ACLAnonymousScope{{Name: "lan", Segments: []string{"alpha"}}},
+		}, false)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "can only be set on the anonymous token")
+	})
+}
+
 func TestTokenWriter_Create_Expiration(t *testing.T) {
 	aclCache := &MockACLCache{}
 	aclCache.On("RemoveIdentityWithSecretToken", mock.Anything)
@@ -25,6 +25,10 @@ func (b autoConfigBackend) SignCertificate(csr *x509.CertificateRequest, id conn
 	return b.Server.caManager.SignCertificate(csr, id)
 }
 
+func (b autoConfigBackend) ResolveTokenAndDefaultMeta(token string, entMeta *acl.EnterpriseMeta, authzContext *acl.AuthorizerContext) (ACLResolveResult, error) {
+	return b.Server.ResolveTokenAndDefaultMeta(token, entMeta, authzContext)
+}
+
 // GetCARoots returns the CA roots.
 func (b autoConfigBackend) GetCARoots() (*structs.IndexedCARoots, error) {
 	return b.Server.getCARoots(nil, b.Server.fsm.State())
@@ -101,3 +105,16 @@ func (b autoConfigBackend) CreateACLToken(template *structs.ACLToken) (*structs.
 	_, token, err := b.Server.fsm.State().ACLTokenGetByAccessor(nil, newToken.AccessorID, &newToken.EnterpriseMeta)
 	return token, err
 }
+
+// NodeIdentitySigningKey returns the key signing the node identities. The key
+// is created by the leader when it establishes its leadership.
+func (b autoConfigBackend) NodeIdentitySigningKey() ([]byte, error) {
+	key, err := b.Server.nodeIdentitySigningKey()
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, fmt.Errorf("the node identity signing key has not been created yet")
+	}
+	return key, nil
+}
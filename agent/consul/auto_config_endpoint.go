@@ -5,6 +5,9 @@ import (
 	"crypto/x509"
 	"encoding/base64"
 	"fmt"
+	"hash/fnv"
+	"sync/atomic"
+	"time"
 
 	"github.com/hashicorp/consul/acl"
 
@@ -27,6 +30,10 @@ type AutoConfigOptions struct {
 
 	CSR      *x509.CertificateRequest
 	SpiffeID *connect.SpiffeIDAgent
+
+	// VerifiedNodeMeta is the node metadata taken from the claims of the
+	// intro token, which the servers attest.
+	VerifiedNodeMeta map[string]string
 }
 
 func (opts AutoConfigOptions) PartitionOrDefault() string {
@@ -46,9 +53,10 @@ func (_ *disabledAuthorizer) Authorize(_ *pbautoconf.AutoConfigRequest) (AutoCon
 }
 
 type jwtAuthorizer struct {
-	validator       *ssoauth.Validator
-	allowReuse      bool
-	claimAssertions []string
+	validator        *ssoauth.Validator
+	allowReuse       bool
+	claimAssertions  []string
+	verifiedNodeMeta []string
 }
 
 func (a *jwtAuthorizer) Authorize(req *pbautoconf.AutoConfigRequest) (AutoConfigOptions, error) {
@@ -93,6 +101,17 @@ func (a *jwtAuthorizer) Authorize(req *pbautoconf.AutoConfigRequest) (AutoConfig
 		Partition:   req.Partition,
 	}
 
+	for _, name := range a.verifiedNodeMeta {
+		value := identity.ProjectedVars["value."+name]
+		if value == "" {
+			continue
+		}
+		if opts.VerifiedNodeMeta == nil {
+			opts.VerifiedNodeMeta = make(map[string]string)
+		}
+		opts.VerifiedNodeMeta[name] = value
+	}
+
 	if req.CSR != "" {
 		csr, id, err := parseAutoConfigCSR(req.CSR)
 		if err != nil {
@@ -119,17 +138,64 @@ type AutoConfigBackend interface {
 	DatacenterJoinAddresses(partition, segment string) ([]string, error)
 	ForwardRPC(method string, info structs.RPCInfo, reply interface{}) (bool, error)
 	GetCARoots() (*structs.IndexedCARoots, error)
+	ResolveTokenAndDefaultMeta(token string, entMeta *acl.EnterpriseMeta, authzContext *acl.AuthorizerContext) (ACLResolveResult, error)
 	SignCertificate(csr *x509.CertificateRequest, id connect.CertURI) (*structs.IssuedCert, error)
+	NodeIdentitySigningKey() ([]byte, error)
+}
+
+// AutoConfigSnippet is a fragment of agent configuration distributed to the
+// agents using auto_config.
+type AutoConfigSnippet struct {
+	// Name identifies the snippet, it is also used to select the agents of a
+	// partial rollout.
+	Name string
+
+	// Config is the HCL or JSON agent configuration of the snippet.
+	Config string
+
+	// Segments restricts the snippet to the agents of these network segments.
+	// The snippet targets all the agents when it is empty.
+	Segments []string
+
+	// RolloutPercentage is the percentage of the targeted agents that receive
+	// the snippet, from 0 to 100. The agents are selected with a hash of the
+	// snippet and node names so a given agent keeps receiving the snippet as
+	// the percentage is raised.
+	RolloutPercentage int
+}
+
+// appliesTo returns whether the snippet should be sent to the agent.
+func (s AutoConfigSnippet) appliesTo(opts AutoConfigOptions) bool {
+	if len(s.Segments) > 0 {
+		found := false
+		for _, segment := range s.Segments {
+			if segment == opts.SegmentName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(s.Name + "/" + printNodeName(opts.NodeName, opts.Partition)))
+	return int(h.Sum32()%100) < s.RolloutPercentage
 }
 
 // AutoConfig endpoint is used for cluster auto configuration operations
 type AutoConfig struct {
-	// currently AutoConfig does not support pushing down any configuration that would be reloadable on the servers
-	// (outside of some TLS settings such as the configured CA certs which are retrieved via the TLS configurator)
-	// If that changes then we will need to change this to use an atomic.Value and provide means of reloading it.
+	// Outside of the config snippets, AutoConfig does not support pushing down any configuration that would be
+	// reloadable on the servers (outside of some TLS settings such as the configured CA certs which are retrieved via
+	// the TLS configurator).
 	config          *Config
 	tlsConfigurator *tlsutil.Configurator
 
+	// snippets holds the []AutoConfigSnippet to distribute, it is updated
+	// when the server configuration is reloaded.
+	snippets atomic.Value
+
 	backend    AutoConfigBackend
 	authorizer AutoConfigAuthorizer
 }
@@ -139,12 +205,19 @@ func NewAutoConfig(conf *Config, tlsConfigurator *tlsutil.Configurator, backend
 		conf = DefaultConfig()
 	}
 
-	return &AutoConfig{
+	ac := &AutoConfig{
 		config:          conf,
 		tlsConfigurator: tlsConfigurator,
 		backend:         backend,
 		authorizer:      authz,
 	}
+	ac.setConfigSnippets(conf.AutoConfigSnippets)
+	return ac
+}
+
+// setConfigSnippets replaces the config snippets distributed to the agents.
+func (ac *AutoConfig) setConfigSnippets(snippets []AutoConfigSnippet) {
+	ac.snippets.Store(snippets)
 }
 
 // updateTLSCertificatesInConfig will ensure that the TLS settings regarding how an agent is
@@ -286,6 +359,45 @@ func (ac *AutoConfig) updateTLSSettingsInConfig(_ AutoConfigOptions, resp *pbaut
 	return err
 }
 
+// updateConfigSnippetsInConfig adds the config snippets targeting the agent
+// to the response.
+func (ac *AutoConfig) updateConfigSnippetsInConfig(opts AutoConfigOptions, resp *pbautoconf.AutoConfigResponse) error {
+	snippets, _ := ac.snippets.Load().([]AutoConfigSnippet)
+	for _, snippet := range snippets {
+		if !snippet.appliesTo(opts) {
+			continue
+		}
+		resp.ConfigSnippets = append(resp.ConfigSnippets, &pbautoconf.ConfigSnippet{
+			Name:   snippet.Name,
+			Config: snippet.Config,
+		})
+	}
+	return nil
+}
+
+// updateNodeIdentityInConfig signs the node metadata verified when
+// authorizing the request, for the agent to present it when registering its
+// node.
+func (ac *AutoConfig) updateNodeIdentityInConfig(opts AutoConfigOptions, resp *pbautoconf.AutoConfigResponse) error {
+	if len(opts.VerifiedNodeMeta) == 0 {
+		return nil
+	}
+
+	key, err := ac.backend.NodeIdentitySigningKey()
+	if err != nil {
+		return fmt.Errorf("Failed to get the node identity signing key: %w", err)
+	}
+
+	resp.NodeIdentity, err = structs.SignNodeIdentity(key, &structs.NodeIdentity{
+		Datacenter: ac.config.Datacenter,
+		Partition:  opts.Partition,
+		Node:       opts.NodeName,
+		Meta:       opts.VerifiedNodeMeta,
+		IssuedAt:   time.Now().UTC(),
+	})
+	return err
+}
+
 // baseConfig will populate the configuration with some base settings such as the
 // datacenter names, node name etc.
 func (ac *AutoConfig) baseConfig(opts AutoConfigOptions, resp *pbautoconf.AutoConfigResponse) error {
@@ -315,6 +427,8 @@ var (
 		(*AutoConfig).updateTLSSettingsInConfig,
 		(*AutoConfig).updateACLsInConfig,
 		(*AutoConfig).updateTLSCertificatesInConfig,
+		(*AutoConfig).updateConfigSnippetsInConfig,
+		(*AutoConfig).updateNodeIdentityInConfig,
 	}
 )
 
@@ -365,6 +479,39 @@ func (ac *AutoConfig) InitialConfiguration(req *pbautoconf.AutoConfigRequest, re
 	return nil
 }
 
+// ConfigSnippets returns the config snippets targeting an agent that is
+// already auto configured, for it to pick up the snippets that changed since.
+// The token of the request must have node:write on the agent's node.
+func (ac *AutoConfig) ConfigSnippets(req *pbautoconf.AutoConfigRequest, resp *pbautoconf.AutoConfigResponse) error {
+	if req.Datacenter == "" {
+		req.Datacenter = ac.config.Datacenter
+	}
+
+	if req.Datacenter != ac.config.Datacenter {
+		return fmt.Errorf("invalid datacenter %q - agent auto configuration cannot target a remote datacenter", req.Datacenter)
+	}
+
+	if ac.backend == nil {
+		return fmt.Errorf("No Auto Config backend is configured")
+	}
+
+	var authzContext acl.AuthorizerContext
+	authz, err := ac.backend.ResolveTokenAndDefaultMeta(req.ConsulToken, structs.NodeEnterpriseMetaInPartition(req.Partition), &authzContext)
+	if err != nil {
+		return err
+	}
+	if err := authz.ToAllowAuthorizer().NodeWriteAllowed(req.Node, &authzContext); err != nil {
+		return err
+	}
+
+	opts := AutoConfigOptions{
+		NodeName:    req.Node,
+		SegmentName: req.Segment,
+		Partition:   req.Partition,
+	}
+	return ac.updateConfigSnippetsInConfig(opts, resp)
+}
+
 func parseAutoConfigCSR(csr string) (*x509.CertificateRequest, *connect.SpiffeIDAgent, error) {
 	// Parse the CSR string into the x509 CertificateRequest struct
 	x509CSR, err := connect.ParseCSR(csr)
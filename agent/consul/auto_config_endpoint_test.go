@@ -16,6 +16,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/internal/go-sso/oidcauth/oidcauthtest"
@@ -59,12 +60,23 @@ func (m *mockAutoConfigBackend) GetCARoots() (*structs.IndexedCARoots, error) {
 	return roots, ret.Error(1)
 }
 
+func (m *mockAutoConfigBackend) ResolveTokenAndDefaultMeta(token string, entMeta *acl.EnterpriseMeta, authzContext *acl.AuthorizerContext) (ACLResolveResult, error) {
+	ret := m.Called(token, entMeta, authzContext)
+	return ret.Get(0).(ACLResolveResult), ret.Error(1)
+}
+
 func (m *mockAutoConfigBackend) SignCertificate(csr *x509.CertificateRequest, id connect.CertURI) (*structs.IssuedCert, error) {
 	ret := m.Called(csr, id)
 	cert, _ := ret.Get(0).(*structs.IssuedCert)
 	return cert, ret.Error(1)
 }
 
+func (m *mockAutoConfigBackend) NodeIdentitySigningKey() ([]byte, error) {
+	ret := m.Called()
+	key, _ := ret.Get(0).([]byte)
+	return key, ret.Error(1)
+}
+
 func testJWTStandardClaims() jwt.Claims {
 	now := time.Now()
 
@@ -850,3 +862,110 @@ func TestAutoConfig_updateJoinAddressesInConfig(t *testing.T) {
 
 	backend.AssertExpectations(t)
 }
+
+func TestAutoConfig_updateNodeIdentityInConfig(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	backend := &mockAutoConfigBackend{}
+	backend.On("NodeIdentitySigningKey").Return(key, nil).Once()
+
+	ac := AutoConfig{config: &Config{Datacenter: "dc1"}, backend: backend}
+
+	// nothing to attest
+	var resp pbautoconf.AutoConfigResponse
+	require.NoError(t, ac.updateNodeIdentityInConfig(AutoConfigOptions{NodeName: "node1"}, &resp))
+	require.Empty(t, resp.NodeIdentity)
+
+	opts := AutoConfigOptions{
+		NodeName:         "node1",
+		VerifiedNodeMeta: map[string]string{"zone": "us-east-1a"},
+	}
+	require.NoError(t, ac.updateNodeIdentityInConfig(opts, &resp))
+
+	id, err := structs.VerifyNodeIdentity(key, resp.NodeIdentity)
+	require.NoError(t, err)
+	require.Equal(t, "dc1", id.Datacenter)
+	require.Equal(t, "node1", id.Node)
+	require.Equal(t, map[string]string{"consul-verified-zone": "us-east-1a"}, id.VerifiedMeta())
+
+	backend.AssertExpectations(t)
+}
+
+func TestAutoConfig_updateConfigSnippetsInConfig(t *testing.T) {
+	ac := NewAutoConfig(&Config{
+		AutoConfigSnippets: []AutoConfigSnippet{
+			{Name: "all", Config: `log_level = "debug"`, RolloutPercentage: 100},
+			{Name: "alpha", Config: `recursors = ["198.18.0.1"]`, Segments: []string{"alpha"}, RolloutPercentage: 100},
+			{Name: "none", Config: `log_level = "trace"`, RolloutPercentage: 0},
+		},
+	}, nil, nil, nil)
+
+	snippetNames := func(opts AutoConfigOptions) []string {
+		var resp pbautoconf.AutoConfigResponse
+		require.NoError(t, ac.updateConfigSnippetsInConfig(opts, &resp))
+
+		var names []string
+		for _, snippet := range resp.ConfigSnippets {
+			names = append(names, snippet.Name)
+		}
+		return names
+	}
+
+	require.Equal(t, []string{"all"}, snippetNames(AutoConfigOptions{NodeName: "node1"}))
+	require.Equal(t, []string{"all", "alpha"}, snippetNames(AutoConfigOptions{NodeName: "node1", SegmentName: "alpha"}))
+
+	// the snippets are updated on reload
+	ac.setConfigSnippets(nil)
+	require.Empty(t, snippetNames(AutoConfigOptions{NodeName: "node1", SegmentName: "alpha"}))
+}
+
+func TestAutoConfigSnippet_appliesTo_RolloutPercentage(t *testing.T) {
+	snippet := AutoConfigSnippet{Name: "telemetry", RolloutPercentage: 30}
+
+	selected := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		node := fmt.Sprintf("node-%d", i)
+		if snippet.appliesTo(AutoConfigOptions{NodeName: node}) {
+			selected[node] = true
+		}
+	}
+	// the selection is only roughly proportional to the percentage
+	require.InDelta(t, 300, len(selected), 60)
+
+	// raising the percentage keeps the agents already selected
+	snippet.RolloutPercentage = 60
+	for node := range selected {
+		require.True(t, snippet.appliesTo(AutoConfigOptions{NodeName: node}), "node %s is not selected anymore", node)
+	}
+}
+
+func TestAutoConfig_ConfigSnippets(t *testing.T) {
+	backend := &mockAutoConfigBackend{}
+	ac := NewAutoConfig(&Config{
+		Datacenter: "dc1",
+		AutoConfigSnippets: []AutoConfigSnippet{
+			{Name: "all", Config: `log_level = "debug"`, RolloutPercentage: 100},
+		},
+	}, nil, backend, nil)
+
+	req := pbautoconf.AutoConfigRequest{
+		Node:        "node1",
+		ConsulToken: "agent-token",
+	}
+
+	backend.On("ResolveTokenAndDefaultMeta", "agent-token", mock.Anything, mock.Anything).
+		Return(ACLResolveResult{Authorizer: acl.ManageAll()}, nil).Once()
+	var resp pbautoconf.AutoConfigResponse
+	require.NoError(t, ac.ConfigSnippets(&req, &resp))
+	require.Len(t, resp.ConfigSnippets, 1)
+	require.Equal(t, "all", resp.ConfigSnippets[0].Name)
+
+	// the token must have node:write on the node of the agent
+	backend.On("ResolveTokenAndDefaultMeta", "agent-token", mock.Anything, mock.Anything).
+		Return(ACLResolveResult{Authorizer: acl.DenyAll()}, nil).Once()
+	resp = pbautoconf.AutoConfigResponse{}
+	err := ac.ConfigSnippets(&req, &resp)
+	require.True(t, acl.IsErrPermissionDenied(err), "unexpected error: %v", err)
+	require.Empty(t, resp.ConfigSnippets)
+
+	backend.AssertExpectations(t)
+}
@@ -112,6 +112,9 @@ func (c *Catalog) Register(args *structs.RegisterRequest, reply *struct{}) error
 		if err := servicePreApply(args.Service, authz, args.Service.FillAuthzContext); err != nil {
 			return err
 		}
+		if err := vetRegistrationPolicy(state, args.Service); err != nil {
+			return err
+		}
 	}
 
 	// Move the old format single check into the slice, and fixup IDs.
@@ -123,7 +126,7 @@ func (c *Catalog) Register(args *structs.RegisterRequest, reply *struct{}) error
 		if check.Node == "" {
 			check.Node = args.Node
 		}
-		checkPreApply(check)
+		checkPreApply(check, c.srv.config.CatalogCheckOutputMaxSize)
 
 		// Populate check type for cases when a check is registered in the catalog directly
 		// and not via anti-entropy
@@ -133,6 +136,11 @@ func (c *Catalog) Register(args *structs.RegisterRequest, reply *struct{}) error
 		}
 	}
 
+	// Only the servers can set the verified node metadata.
+	if err := c.srv.applyVerifiedNodeMeta(args); err != nil {
+		return err
+	}
+
 	// Check the complete register request against the given ACL policy.
 	_, ns, err := state.NodeServices(nil, args.Node, entMeta, args.PeerName)
 	if err != nil {
@@ -142,6 +150,12 @@ func (c *Catalog) Register(args *structs.RegisterRequest, reply *struct{}) error
 		return err
 	}
 
+	if c.srv.config.CatalogCheckOutputCompression {
+		if err := args.CompressCheckOutputs(); err != nil {
+			return err
+		}
+	}
+
 	_, err = c.srv.raftApply(structs.RegisterRequestType, args)
 	return err
 }
@@ -209,10 +223,11 @@ func servicePreApply(service *structs.NodeService, authz ACLResolveResult, authz
 }
 
 // checkPreApply does the verification of a check before it is applied to Raft.
-func checkPreApply(check *structs.HealthCheck) {
+func checkPreApply(check *structs.HealthCheck, maxOutputSize int) {
 	if check.CheckID == "" && check.Name != "" {
 		check.CheckID = types.CheckID(check.Name)
 	}
+	check.Output = structs.TruncateCheckOutput(check.Output, maxOutputSize)
 }
 
 // vetRegisterWithACL applies the given ACL's policy to the catalog update and
@@ -386,6 +401,12 @@ func (c *Catalog) Deregister(args *structs.DeregisterRequest, reply *struct{}) e
 		return err
 	}
 
+	// Record who removed the node or service in the deregistration history,
+	// ignoring what the client may have set.
+	args.DeregisteredBy = authz.AccessorID()
+	args.DeregisterReason = ""
+	args.DeregisteredAt = time.Now().UTC()
+
 	_, err = c.srv.raftApply(structs.DeregisterRequestType, args)
 	return err
 }
@@ -481,6 +502,11 @@ func (c *Catalog) ListNodes(args *structs.DCSpecificRequest, reply *structs.Inde
 		return err
 	}
 
+	// Pages are ordered by node name, they can't be sorted by distance.
+	if isPaginated(&args.QueryOptions) && args.Source.Node != "" {
+		return fmt.Errorf("cannot paginate nodes sorted by distance")
+	}
+
 	return c.srv.blockingQuery(
 		&args.QueryOptions,
 		&reply.QueryMeta,
@@ -513,6 +539,21 @@ func (c *Catalog) ListNodes(args *structs.DCSpecificRequest, reply *structs.Inde
 				return err
 			}
 
+			if isPaginated(&args.QueryOptions) {
+				// Node names are unique regardless of their case.
+				sort.SliceStable(reply.Nodes, func(i, j int) bool {
+					return strings.ToLower(reply.Nodes[i].Node) < strings.ToLower(reply.Nodes[j].Node)
+				})
+				start, end, err := paginate(&args.QueryOptions, &reply.QueryMeta, c.srv.config.MaxPerPage, len(reply.Nodes), func(i int) []string {
+					return []string{strings.ToLower(reply.Nodes[i].Node)}
+				})
+				if err != nil {
+					return err
+				}
+				reply.Nodes = reply.Nodes[start:end]
+				return nil
+			}
+
 			return c.srv.sortNodesByDistanceFrom(args.Source, reply.Nodes)
 		})
 }
@@ -561,6 +602,25 @@ func (c *Catalog) ListServices(args *structs.DCSpecificRequest, reply *structs.I
 			}
 
 			c.srv.filterACLWithAuthorizer(authz, reply)
+
+			if isPaginated(&args.QueryOptions) {
+				names := make([]string, 0, len(reply.Services))
+				for name := range reply.Services {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				start, end, err := paginate(&args.QueryOptions, &reply.QueryMeta, c.srv.config.MaxPerPage, len(names), func(i int) []string {
+					return []string{names[i]}
+				})
+				if err != nil {
+					return err
+				}
+				page := make(structs.Services, end-start)
+				for _, name := range names[start:end] {
+					page[name] = reply.Services[name]
+				}
+				reply.Services = page
+			}
 			return nil
 		})
 }
@@ -664,12 +724,25 @@ func (c *Catalog) ServiceNodes(args *structs.ServiceSpecificRequest, reply *stru
 		return err
 	}
 
+	// Identical blocking queries, typically from the many agents watching a
+	// service, share a single result.
+	keyArgs := *args
+	keyArgs.Token = ""
+	keyArgs.MinQueryIndex = 0
+	coalesceKey, err := coalescedQueryKeyFor("Catalog.ServiceNodes", &keyArgs)
+	if err != nil {
+		return err
+	}
+
 	var (
 		priorMergeHash uint64
 		ranMergeOnce   bool
 	)
 
-	err = c.srv.blockingQuery(
+	err = c.srv.coalescedBlockingQuery(
+		coalesceKey,
+		args.Token,
+		reply,
 		&args.QueryOptions,
 		&reply.QueryMeta,
 		func(ws memdb.WatchSet, state *state.Store) error {
@@ -739,13 +812,10 @@ func (c *Catalog) ServiceNodes(args *structs.ServiceSpecificRequest, reply *stru
 			}
 			reply.ServiceNodes = raw.(structs.ServiceNodes)
 
-			// Note: we filter the results with ACLs *after* applying the user-supplied
-			// bexpr filter, to ensure QueryMeta.ResultsFilteredByACLs does not include
-			// results that would be filtered out even if the user did have permission.
-			if err := c.srv.filterACL(args.Token, reply); err != nil {
-				return err
-			}
-
+			// Note: the results are filtered with ACLs once the query returns,
+			// *after* applying the user-supplied bexpr filter, to ensure
+			// QueryMeta.ResultsFilteredByACLs does not include results that
+			// would be filtered out even if the user did have permission.
 			return c.srv.sortNodesByDistanceFrom(args.Source, reply.ServiceNodes)
 		})
 
@@ -813,7 +883,22 @@ func (c *Catalog) NodeServices(args *structs.NodeSpecificRequest, reply *structs
 		return err
 	}
 
-	return c.srv.blockingQuery(
+	keyArgs := *args
+	keyArgs.Token = ""
+	keyArgs.MinQueryIndex = 0
+	coalesceKey, err := coalescedQueryKeyFor("Catalog.NodeServices", &keyArgs)
+	if err != nil {
+		return err
+	}
+
+	// Note: the results are filtered with ACLs once the query returns, *after*
+	// applying the user-supplied bexpr filter, to ensure
+	// QueryMeta.ResultsFilteredByACLs does not include results that would be
+	// filtered out even if the user did have permission.
+	return c.srv.coalescedBlockingQuery(
+		coalesceKey,
+		args.Token,
+		reply,
 		&args.QueryOptions,
 		&reply.QueryMeta,
 		func(ws memdb.WatchSet, state *state.Store) error {
@@ -831,13 +916,6 @@ func (c *Catalog) NodeServices(args *structs.NodeSpecificRequest, reply *structs
 				reply.NodeServices.Services = raw.(map[string]*structs.NodeService)
 			}
 
-			// Note: we filter the results with ACLs *after* applying the user-supplied
-			// bexpr filter, to ensure QueryMeta.ResultsFilteredByACLs does not include
-			// results that would be filtered out even if the user did have permission.
-			if err := c.srv.filterACL(args.Token, reply); err != nil {
-				return err
-			}
-
 			return nil
 		})
 }
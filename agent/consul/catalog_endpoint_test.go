@@ -3,11 +3,13 @@ package consul
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/raft"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -177,6 +179,209 @@ func TestCatalog_Register_NodeID(t *testing.T) {
 	}
 }
 
+func TestCatalog_Register_VerifiedNodeMeta(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	_, s1 := testServer(t)
+	codec := rpcClient(t, s1)
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// The key is created when establishing leadership and never replaced.
+	key, err := s1.nodeIdentitySigningKey()
+	require.NoError(t, err)
+	require.Len(t, key, nodeIdentityKeySize)
+	require.NoError(t, s1.initializeNodeIdentitySigningKey())
+	again, err := s1.nodeIdentitySigningKey()
+	require.NoError(t, err)
+	require.Equal(t, key, again)
+
+	register := func(meta map[string]string, identity string) map[string]string {
+		arg := structs.RegisterRequest{
+			Datacenter:   "dc1",
+			Node:         "foo",
+			Address:      "127.0.0.1",
+			NodeMeta:     meta,
+			NodeIdentity: identity,
+		}
+		var out struct{}
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "Catalog.Register", &arg, &out))
+
+		_, node, err := s1.fsm.State().GetNode("foo", nil, "")
+		require.NoError(t, err)
+		return node.Meta
+	}
+
+	// Verified metadata can't be set by the clients.
+	meta := register(map[string]string{"consul-verified-zone": "spoofed", "rack": "1"}, "")
+	require.Equal(t, map[string]string{"rack": "1"}, meta)
+
+	// Nor with an identity signed with another key.
+	identity, err := structs.SignNodeIdentity([]byte("not the key"), &structs.NodeIdentity{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Meta:       map[string]string{"zone": "spoofed"},
+	})
+	require.NoError(t, err)
+	meta = register(map[string]string{"rack": "1"}, identity)
+	require.Equal(t, map[string]string{"rack": "1"}, meta)
+
+	// Nor with an identity issued to another node.
+	identity, err = structs.SignNodeIdentity(key, &structs.NodeIdentity{
+		Datacenter: "dc1",
+		Node:       "bar",
+		Meta:       map[string]string{"zone": "spoofed"},
+	})
+	require.NoError(t, err)
+	meta = register(map[string]string{"rack": "1"}, identity)
+	require.Equal(t, map[string]string{"rack": "1"}, meta)
+
+	// The metadata attested by the servers is applied.
+	identity, err = structs.SignNodeIdentity(key, &structs.NodeIdentity{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Meta:       map[string]string{"zone": "us-east-1a"},
+	})
+	require.NoError(t, err)
+	meta = register(map[string]string{"consul-verified-zone": "spoofed", "rack": "1"}, identity)
+	require.Equal(t, map[string]string{"consul-verified-zone": "us-east-1a", "rack": "1"}, meta)
+
+	// And kept by later registrations.
+	meta = register(map[string]string{"consul-verified-zone": "spoofed", "rack": "2"}, "")
+	require.Equal(t, map[string]string{"consul-verified-zone": "us-east-1a", "rack": "2"}, meta)
+}
+
+func TestCatalog_Register_RegistrationPolicy(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	_, s1 := testServer(t)
+	codec := rpcClient(t, s1)
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	policy := structs.ConfigEntryRequest{
+		Datacenter: "dc1",
+		Entry: &structs.RegistrationPolicyConfigEntry{
+			Name:               structs.RegistrationPolicyGlobal,
+			DeniedServiceNames: []string{"consul", "*-internal"},
+		},
+	}
+	var ok bool
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &policy, &ok))
+	require.True(t, ok)
+
+	register := func(service string) error {
+		arg := structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       "foo",
+			Address:    "127.0.0.1",
+			Service: &structs.NodeService{
+				Service: service,
+				Port:    8000,
+			},
+		}
+		var out struct{}
+		return msgpackrpc.CallWithCodec(codec, "Catalog.Register", &arg, &out)
+	}
+
+	err := register("Consul")
+	require.True(t, structs.IsErrServiceNameDenied(err), "unexpected error: %v", err)
+	err = register("gateway-internal")
+	require.True(t, structs.IsErrServiceNameDenied(err), "unexpected error: %v", err)
+	require.NoError(t, register("web"))
+
+	_, services, err := s1.fsm.State().NodeServices(nil, "foo", nil, "")
+	require.NoError(t, err)
+	require.Len(t, services.Services, 1)
+	require.Contains(t, services.Services, "web")
+}
+
+func TestCatalog_Register_TruncatesCheckOutput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.CatalogCheckOutputMaxSize = 64
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	output := strings.Repeat("x", 100)
+	arg := structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Checks: structs.HealthChecks{
+			{CheckID: "long", Output: output},
+			{CheckID: "short", Output: "ok"},
+		},
+	}
+	var out struct{}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Catalog.Register", &arg, &out))
+
+	_, checks, err := s1.fsm.State().NodeChecks(nil, "foo", nil, "")
+	require.NoError(t, err)
+	require.Len(t, checks, 2)
+	for _, check := range checks {
+		switch check.CheckID {
+		case "long":
+			require.Len(t, check.Output, 64)
+			require.True(t, structs.IsTruncatedCheckOutput(output, check.Output))
+		case "short":
+			require.Equal(t, "ok", check.Output)
+		}
+	}
+}
+
+func TestCatalog_Register_CompressesCheckOutput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.CatalogCheckOutputCompression = true
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	output := strings.Repeat("x", 64*1024)
+	arg := structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Check:      &structs.HealthCheck{CheckID: "long", Output: output},
+	}
+	var out struct{}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Catalog.Register", &arg, &out))
+
+	// The output is compressed in the Raft log...
+	last, err := s1.raftLog.LastIndex()
+	require.NoError(t, err)
+	var log raft.Log
+	require.NoError(t, s1.raftLog.GetLog(last, &log))
+	require.Equal(t, structs.RegisterRequestType, structs.MessageType(log.Data[0]))
+	require.Less(t, len(log.Data), len(output)/10)
+
+	// ...but not in the catalog.
+	_, checks, err := s1.fsm.State().NodeChecks(nil, "foo", nil, "")
+	require.NoError(t, err)
+	require.Len(t, checks, 1)
+	require.Equal(t, output, checks[0].Output)
+}
+
 func TestCatalog_Register_ACLDeny(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -1454,6 +1659,112 @@ func TestCatalog_ListServices(t *testing.T) {
 	})
 }
 
+func TestCatalog_ListServices_Paginated(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	require.NoError(t, s1.fsm.State().EnsureNode(1, &structs.Node{Node: "foo", Address: "127.0.0.1"}))
+	for i, name := range []string{"web", "api", "db"} {
+		require.NoError(t, s1.fsm.State().EnsureService(uint64(2+i), "foo", &structs.NodeService{ID: name, Service: name, Tags: []string{name}}))
+	}
+
+	// The consul service is registered too.
+	var pages [][]string
+	args := structs.DCSpecificRequest{
+		Datacenter:   "dc1",
+		QueryOptions: structs.QueryOptions{PerPage: 3},
+	}
+	for {
+		var out structs.IndexedServices
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "Catalog.ListServices", &args, &out))
+
+		var names []string
+		for name, tags := range out.Services {
+			if name != "consul" {
+				require.Equal(t, []string{name}, tags)
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		pages = append(pages, names)
+
+		if out.NextToken == "" {
+			break
+		}
+		args.NextToken = out.NextToken
+	}
+	require.Equal(t, [][]string{{"api", "consul", "db"}, {"web"}}, pages)
+}
+
+func TestCatalog_ListNodes_Paginated(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	for i, name := range []string{"node-c", "Node-A", "node-b"} {
+		require.NoError(t, s1.fsm.State().EnsureNode(uint64(1+i), &structs.Node{
+			Node:    name,
+			Address: "127.0.0.1",
+			Meta:    map[string]string{"group": "paginated"},
+		}))
+	}
+
+	// Nodes are ordered by name regardless of its case.
+	var pages [][]string
+	args := structs.DCSpecificRequest{
+		Datacenter:      "dc1",
+		NodeMetaFilters: map[string]string{"group": "paginated"},
+		QueryOptions:    structs.QueryOptions{PerPage: 2},
+	}
+	for {
+		var out structs.IndexedNodes
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "Catalog.ListNodes", &args, &out))
+
+		var names []string
+		for _, node := range out.Nodes {
+			names = append(names, node.Node)
+		}
+		pages = append(pages, names)
+
+		if out.NextToken == "" {
+			break
+		}
+		args.NextToken = out.NextToken
+	}
+	require.Equal(t, [][]string{{"Node-A", "node-b"}, {"node-c"}}, pages)
+
+	t.Run("sorted by distance", func(t *testing.T) {
+		args := structs.DCSpecificRequest{
+			Datacenter:   "dc1",
+			Source:       structs.QuerySource{Datacenter: "dc1", Node: "node-b"},
+			QueryOptions: structs.QueryOptions{PerPage: 2},
+		}
+		var out structs.IndexedNodes
+		err := msgpackrpc.CallWithCodec(codec, "Catalog.ListNodes", &args, &out)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "cannot paginate nodes sorted by distance")
+	})
+}
+
 func TestCatalog_ListServices_NodeMetaFilter(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
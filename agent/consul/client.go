@@ -1,9 +1,11 @@
 package consul
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -14,6 +16,8 @@ import (
 	"github.com/hashicorp/serf/serf"
 	"golang.org/x/time/rate"
 
+	"github.com/hashicorp/consul-net-rpc/net/rpc"
+
 	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/pool"
 	"github.com/hashicorp/consul/agent/router"
@@ -285,13 +289,22 @@ TRY:
 	}
 
 	// Make the request.
+	start := time.Now()
 	rpcErr := c.connPool.RPC(c.config.Datacenter, server.ShortName, server.Addr, method, args, reply)
 	if rpcErr == nil {
+		// Blocking queries don't tell anything about the latency of the server.
+		if q, ok := args.(interface{ GetMinQueryIndex() uint64 }); !ok || q.GetMinQueryIndex() == 0 {
+			manager.NotifyServerRTT(server, time.Since(start))
+		}
 		return nil
 	}
 
-	// Move off to another server, and see if we can retry.
-	manager.NotifyFailedServer(server)
+	// Move off to another server if it couldn't serve the request, and see
+	// if we can retry. The errors returned by the server for the request
+	// itself, such as ACL errors, say nothing about its health.
+	if isServerAvailabilityError(rpcErr) {
+		manager.NotifyFailedServer(server)
+	}
 
 	// Use the zero value for RPCInfo if the request doesn't implement RPCInfo
 	info, _ := args.(structs.RPCInfo)
@@ -321,6 +334,21 @@ TRY:
 	return rpcErr
 }
 
+// isServerAvailabilityError returns true if err tells that the server couldn't
+// be reached or couldn't serve the request, rather than that it rejected the
+// request.
+func isServerAvailabilityError(err error) bool {
+	var serverErr rpc.ServerError
+	if !errors.As(err, &serverErr) {
+		// The errors which didn't come from the server are from the transport.
+		return true
+	}
+	return structs.IsErrNoLeader(err) ||
+		structs.IsErrRPCRateExceeded(err) ||
+		structs.IsErrRPCSourceLimitExceeded(err) ||
+		strings.Contains(err.Error(), ErrChunkingResubmit.Error())
+}
+
 // SnapshotRPC sends the snapshot request to one of the servers, reading from
 // the streaming input and writing to the streaming output depending on the
 // operation.
@@ -338,9 +366,12 @@ func (c *Client) SnapshotRPC(args *structs.SnapshotRequest, in io.Reader, out io
 		return structs.ErrRPCRateExceeded
 	}
 
-	// Request the operation.
+	// Request the operation, compressing the streams if the server supports
+	// it.
+	hopArgs := *args
+	hopArgs.Compression = snapshotCompression(server)
 	var reply structs.SnapshotResponse
-	snap, err := SnapshotRPC(c.connPool, c.config.Datacenter, server.ShortName, server.Addr, args, in, &reply)
+	snap, err := SnapshotRPC(c.connPool, c.config.Datacenter, server.ShortName, server.Addr, &hopArgs, in, &reply)
 	if err != nil {
 		manager.NotifyFailedServer(server)
 		return err
@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/serf/serf"
 
+	"github.com/hashicorp/consul/agent/consul/tcpgossip"
 	"github.com/hashicorp/consul/agent/metadata"
 	"github.com/hashicorp/consul/lib"
 	libserf "github.com/hashicorp/consul/lib/serf"
@@ -57,6 +58,12 @@ func (c *Client) setupSerf(conf *serf.Config, ch chan serf.Event, path string) (
 		partition: c.config.AgentEnterpriseMeta().PartitionOrDefault(),
 	}
 
+	if c.config.SerfLANTCPOnly {
+		if _, err := tcpgossip.Configure(conf.MemberlistConfig, c.logger.Named(logging.LAN)); err != nil {
+			return nil, err
+		}
+	}
+
 	conf.SnapshotPath = filepath.Join(c.config.DataDir, path)
 	if err := lib.EnsurePath(conf.SnapshotPath, false); err != nil {
 		return nil, err
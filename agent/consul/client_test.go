@@ -3,6 +3,7 @@ package consul
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"strings"
@@ -16,7 +17,10 @@ import (
 	"golang.org/x/time/rate"
 
 	msgpackrpc "github.com/hashicorp/consul-net-rpc/net-rpc-msgpackrpc"
+	"github.com/hashicorp/consul-net-rpc/net/rpc"
 
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/tcpgossip"
 	grpc "github.com/hashicorp/consul/agent/grpc/private"
 	"github.com/hashicorp/consul/agent/grpc/private/resolver"
 	"github.com/hashicorp/consul/agent/pool"
@@ -132,6 +136,42 @@ func TestClient_JoinLAN(t *testing.T) {
 	})
 }
 
+func TestClient_JoinLAN_TCPOnly(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.SerfLANTCPOnly = true
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	dir2, c1 := testClientWithConfig(t, func(c *Config) {
+		c.SerfLANTCPOnly = true
+	})
+	defer os.RemoveAll(dir2)
+	defer c1.Shutdown()
+
+	require.IsType(t, &tcpgossip.Transport{}, s1.config.SerfLANConfig.MemberlistConfig.Transport)
+	require.IsType(t, &tcpgossip.Transport{}, c1.config.SerfLANConfig.MemberlistConfig.Transport)
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	joinLAN(t, c1, s1)
+	testrpc.WaitForTestAgent(t, c1.RPC, "dc1")
+	retry.Run(t, func(r *retry.R) {
+		if got, want := c1.router.GetLANManager().NumServers(), 1; got != want {
+			r.Fatalf("got %d servers want %d", got, want)
+		}
+		for _, m := range s1.LANMembersInAgentPartition() {
+			if m.Status != serf.StatusAlive {
+				r.Fatalf("member %s is %s", m.Name, m.Status)
+			}
+		}
+	})
+}
+
 func TestClient_LANReap(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -338,6 +378,18 @@ func TestClient_RPC_Retry(t *testing.T) {
 	}
 }
 
+func TestIsServerAvailabilityError(t *testing.T) {
+	serverErr := func(err error) error {
+		return fmt.Errorf("rpc error making call: %w", rpc.ServerError(err.Error()))
+	}
+
+	require.True(t, isServerAvailabilityError(fmt.Errorf("rpc error getting client: %w", io.EOF)))
+	require.True(t, isServerAvailabilityError(serverErr(structs.ErrNoLeader)))
+	require.True(t, isServerAvailabilityError(serverErr(structs.ErrRPCRateExceeded)))
+	require.False(t, isServerAvailabilityError(serverErr(acl.ErrPermissionDenied)))
+	require.False(t, isServerAvailabilityError(serverErr(fmt.Errorf("Unknown check ID"))))
+}
+
 func TestClient_RPC_Pool(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
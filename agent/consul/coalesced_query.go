@@ -0,0 +1,135 @@
+package consul
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul-net-rpc/go-msgpack/codec"
+	hashstructure_v2 "github.com/mitchellh/hashstructure/v2"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// coalescedQueries shares the result of a blocking query between all the
+// identical blocking queries waiting for it, so that when many agents watch
+// the same thing the query and filtering run once per change rather than once
+// per watcher. The result is shared before the ACLs are enforced, so the
+// watchers don't need to use the same token, and each of them filters its own
+// copy with its own token.
+type coalescedQueries struct {
+	lock    sync.Mutex
+	queries map[coalescedQueryKey]*coalescedQuery
+}
+
+// coalescedQueryKey identifies the blocking queries that can share a result.
+// Only the queries waiting on the same index are shared so that each watcher
+// still gets the first result past the index it has seen.
+type coalescedQueryKey struct {
+	key           string
+	minQueryIndex uint64
+}
+
+// coalescedQuery is a blocking query in flight.
+type coalescedQuery struct {
+	// done is closed once result and err are set.
+	done chan struct{}
+
+	// watchers is the number of queries waiting for the result besides the
+	// one running it.
+	watchers int
+
+	// result is the encoded reply, only set when there are watchers.
+	result []byte
+	err    error
+}
+
+func newCoalescedQueries() *coalescedQueries {
+	return &coalescedQueries{queries: make(map[coalescedQueryKey]*coalescedQuery)}
+}
+
+// run calls query to fill reply unless an identical blocking query is already
+// in flight, in which case reply is filled with its result instead. Either way
+// filterACL is then called to enforce the ACLs on reply. An empty key disables
+// the coalescing.
+func (c *coalescedQueries) run(key string, opts blockingQueryOptions, reply interface{}, query, filterACL func() error) error {
+	if key == "" || opts.GetMinQueryIndex() == 0 || opts.GetRequireConsistent() {
+		if err := query(); err != nil {
+			return err
+		}
+		return filterACL()
+	}
+	k := coalescedQueryKey{key: key, minQueryIndex: opts.GetMinQueryIndex()}
+
+	c.lock.Lock()
+	if q, ok := c.queries[k]; ok {
+		q.watchers++
+		c.lock.Unlock()
+
+		metrics.IncrCounter([]string{"rpc", "query", "coalesced"}, 1)
+		<-q.done
+		if q.err != nil {
+			return q.err
+		}
+		if err := codec.NewDecoderBytes(q.result, structs.MsgpackHandle).Decode(reply); err != nil {
+			return err
+		}
+		return filterACL()
+	}
+	q := &coalescedQuery{done: make(chan struct{})}
+	c.queries[k] = q
+	c.lock.Unlock()
+
+	err := query()
+
+	c.lock.Lock()
+	delete(c.queries, k)
+	watchers := q.watchers
+	c.lock.Unlock()
+
+	q.err = err
+	if err == nil && watchers > 0 {
+		var buf []byte
+		if encErr := codec.NewEncoderBytes(&buf, structs.MsgpackHandle).Encode(reply); encErr != nil {
+			q.err = fmt.Errorf("failed to encode the result of a coalesced query: %w", encErr)
+		}
+		q.result = buf
+	}
+	close(q.done)
+	if err != nil {
+		return err
+	}
+	return filterACL()
+}
+
+// coalescedBlockingQuery is like blockingQuery, but shares the result with the
+// identical queries coalesced under key. reply is the value holding
+// responseMeta and filled by query, which must not enforce the ACLs: the ACLs
+// of token are enforced on reply once the query returns.
+func (s *Server) coalescedBlockingQuery(
+	key string,
+	token string,
+	reply interface{},
+	opts blockingQueryOptions,
+	responseMeta blockingQueryResponseMeta,
+	query queryFn,
+) error {
+	return s.coalescedQueries.run(key, opts, reply,
+		func() error {
+			return s.blockingQuery(opts, responseMeta, query)
+		},
+		func() error {
+			return s.filterACL(token, reply)
+		})
+}
+
+// coalescedQueryKeyFor returns the key under which the blocking query for req
+// is coalesced. req must not hold the token nor the index of the query, which
+// don't change the shared result.
+func coalescedQueryKeyFor(endpoint string, req interface{}) (string, error) {
+	reqHash, err := hashstructure_v2.Hash(req, hashstructure_v2.FormatV2, nil)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%x", endpoint, reqHash), nil
+}
@@ -0,0 +1,274 @@
+package consul
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	msgpackrpc "github.com/hashicorp/consul-net-rpc/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+)
+
+func TestCoalescedQueries_run(t *testing.T) {
+	t.Parallel()
+
+	c := newCoalescedQueries()
+	opts := &structs.QueryOptions{MinQueryIndex: 5}
+
+	var runs int32
+	unblock := make(chan struct{})
+	leader := func(reply *structs.IndexedCheckServiceNodes) func() error {
+		return func() error {
+			atomic.AddInt32(&runs, 1)
+			<-unblock
+			reply.Index = 7
+			reply.Nodes = structs.CheckServiceNodes{{Node: &structs.Node{Node: "foo"}}}
+			return nil
+		}
+	}
+
+	var leaderReply structs.IndexedCheckServiceNodes
+	leaderErr := make(chan error, 1)
+	go func() {
+		leaderErr <- c.run("key", opts, &leaderReply, leader(&leaderReply), noFilterACL)
+	}()
+	retryUntilInFlight(t, c, 1)
+
+	// Identical queries wait for the result of the first one, and then each
+	// enforce their own ACLs on it.
+	var wg sync.WaitGroup
+	replies := make([]structs.IndexedCheckServiceNodes, 3)
+	for i := range replies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			filterACL := func() error {
+				if i == 0 {
+					replies[i].Nodes = nil
+					replies[i].ResultsFilteredByACLs = true
+				}
+				return nil
+			}
+			require.NoError(t, c.run("key", opts, &replies[i], leader(&replies[i]), filterACL))
+		}(i)
+	}
+
+	// Queries for another key or index are not coalesced.
+	var other structs.IndexedCheckServiceNodes
+	require.NoError(t, c.run("other", opts, &other, func() error {
+		other.Index = 1
+		return nil
+	}, noFilterACL))
+	require.NoError(t, c.run("key", &structs.QueryOptions{MinQueryIndex: 6}, &other, func() error {
+		other.Index = 2
+		return nil
+	}, noFilterACL))
+	require.Equal(t, uint64(2), other.Index)
+
+	retryUntilWatchers(t, c, 3)
+	close(unblock)
+	require.NoError(t, <-leaderErr)
+	wg.Wait()
+
+	require.Equal(t, int32(1), atomic.LoadInt32(&runs))
+	require.Equal(t, uint64(7), replies[0].Index)
+	require.Empty(t, replies[0].Nodes)
+	require.True(t, replies[0].ResultsFilteredByACLs)
+	for _, reply := range replies[1:] {
+		require.Equal(t, uint64(7), reply.Index)
+		require.False(t, reply.ResultsFilteredByACLs)
+		require.Len(t, reply.Nodes, 1)
+		require.Equal(t, "foo", reply.Nodes[0].Node.Node)
+		// each watcher gets its own copy of the result
+		require.NotSame(t, leaderReply.Nodes[0].Node, reply.Nodes[0].Node)
+	}
+	require.Empty(t, c.queries)
+}
+
+func TestCoalescedQueries_run_Error(t *testing.T) {
+	t.Parallel()
+
+	c := newCoalescedQueries()
+	opts := &structs.QueryOptions{MinQueryIndex: 5}
+	errFailed := errors.New("failed")
+
+	unblock := make(chan struct{})
+	leaderErr := make(chan error, 1)
+	go func() {
+		var reply structs.IndexedCheckServiceNodes
+		leaderErr <- c.run("key", opts, &reply, func() error {
+			<-unblock
+			return errFailed
+		}, noFilterACL)
+	}()
+	retryUntilInFlight(t, c, 1)
+
+	watcherErr := make(chan error, 1)
+	go func() {
+		var reply structs.IndexedCheckServiceNodes
+		watcherErr <- c.run("key", opts, &reply, func() error {
+			return nil
+		}, noFilterACL)
+	}()
+	retryUntilWatchers(t, c, 1)
+	close(unblock)
+
+	require.Equal(t, errFailed, <-leaderErr)
+	require.Equal(t, errFailed, <-watcherErr)
+}
+
+func TestCoalescedQueries_run_NotBlocking(t *testing.T) {
+	t.Parallel()
+
+	c := newCoalescedQueries()
+	// Queries that don't block or require a consistent read run on their own,
+	// and so do queries without a key.
+	for _, opts := range []*structs.QueryOptions{
+		{},
+		{MinQueryIndex: 5, RequireConsistent: true},
+	} {
+		var ran, filtered bool
+		var reply structs.IndexedCheckServiceNodes
+		require.NoError(t, c.run("key", opts, &reply, func() error {
+			require.Empty(t, c.queries)
+			ran = true
+			return nil
+		}, func() error {
+			filtered = true
+			return nil
+		}))
+		require.True(t, ran)
+		require.True(t, filtered)
+	}
+
+	var reply structs.IndexedCheckServiceNodes
+	require.NoError(t, c.run("", &structs.QueryOptions{MinQueryIndex: 5}, &reply, func() error {
+		require.Empty(t, c.queries)
+		return nil
+	}, noFilterACL))
+}
+
+func noFilterACL() error {
+	return nil
+}
+
+func retryUntilInFlight(t *testing.T, c *coalescedQueries, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		return len(c.queries) == n
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func retryUntilWatchers(t *testing.T, c *coalescedQueries, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		var watchers int
+		for _, q := range c.queries {
+			watchers += q.watchers
+		}
+		return watchers == n
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestHealth_ServiceNodes_CoalescedFilterACL(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	_, s1 := testServerWithConfig(t, func(c *Config) {
+		c.PrimaryDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLInitialManagementToken = "root"
+		c.ACLResolverSettings.ACLDefaultPolicy = "deny"
+	})
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1", testrpc.WithToken("root"))
+
+	register := func(node string) {
+		t.Helper()
+		req := structs.RegisterRequest{
+			Datacenter:   "dc1",
+			Node:         node,
+			Address:      "127.0.0.1",
+			Service:      &structs.NodeService{Service: "web"},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var out struct{}
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "Catalog.Register", &req, &out))
+	}
+	register("node1")
+	register("node2")
+
+	// Agent tokens only read the nodes they are named after.
+	tokens := make(map[string]string)
+	for _, node := range []string{"node1", "node2"} {
+		token, err := upsertTestToken(codec, "root", "dc1", func(token *structs.ACLToken) {
+			token.NodeIdentities = []*structs.ACLNodeIdentity{{NodeName: node, Datacenter: "dc1"}}
+		})
+		require.NoError(t, err)
+		tokens[node] = token.SecretID
+	}
+
+	req := structs.ServiceSpecificRequest{
+		Datacenter:   "dc1",
+		ServiceName:  "web",
+		QueryOptions: structs.QueryOptions{Token: "root"},
+	}
+	var initial structs.IndexedCheckServiceNodes
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Health.ServiceNodes", &req, &initial))
+	require.Len(t, initial.Nodes, 2)
+
+	type result struct {
+		reply structs.IndexedCheckServiceNodes
+		err   error
+	}
+	results := make(map[string]chan result)
+	for node, token := range tokens {
+		ch := make(chan result, 1)
+		results[node] = ch
+		go func(token string) {
+			watcherCodec := rpcClient(t, s1)
+			defer watcherCodec.Close()
+
+			req := structs.ServiceSpecificRequest{
+				Datacenter:  "dc1",
+				ServiceName: "web",
+				QueryOptions: structs.QueryOptions{
+					Token:         token,
+					MinQueryIndex: initial.Index,
+					MaxQueryTime:  10 * time.Second,
+				},
+			}
+			var res result
+			res.err = msgpackrpc.CallWithCodec(watcherCodec, "Health.ServiceNodes", &req, &res.reply)
+			ch <- res
+		}(token)
+	}
+
+	// The two agents share the query despite their different tokens...
+	retryUntilWatchers(t, s1.coalescedQueries, 1)
+	register("node3")
+
+	// ...but each only gets the nodes its token can read.
+	for node, ch := range results {
+		res := <-ch
+		require.NoError(t, res.err)
+		require.Greater(t, res.reply.Index, initial.Index)
+		require.Len(t, res.reply.Nodes, 1)
+		require.Equal(t, node, res.reply.Nodes[0].Node.Node)
+		require.True(t, res.reply.ResultsFilteredByACLs)
+	}
+}
@@ -52,8 +52,8 @@ func init() {
 	}
 }
 
-// (Enterprise-only) NetworkSegment is the address and port configuration
-// for a network segment.
+// NetworkSegment is the address and port configuration for a network
+// segment.
 type NetworkSegment struct {
 	Name       string
 	Bind       string
@@ -107,6 +107,10 @@ type Config struct {
 	// RaftConfig is the configuration used for Raft in the local DC
 	RaftConfig *raft.Config
 
+	// RaftSnapshotAutoTune enables tuning the snapshot threshold and interval
+	// of RaftConfig based on the log growth rate and the cost of snapshots.
+	RaftSnapshotAutoTune bool
+
 	// (Enterprise-only) ReadReplica is used to prevent this server from being added
 	// as a voting member of the Raft cluster.
 	ReadReplica bool
@@ -130,11 +134,10 @@ type Config struct {
 	// RPCSrcAddr is the source address for outgoing RPC connections.
 	RPCSrcAddr *net.TCPAddr
 
-	// (Enterprise-only) The network segment this agent is part of.
+	// Segment is the network segment this agent is part of.
 	Segment string
 
-	// (Enterprise-only) Segments is a list of network segments for a server to
-	// bind on.
+	// Segments is a list of network segments for a server to bind on.
 	Segments []NetworkSegment
 
 	// SerfLANConfig is the configuration for the intra-dc serf
@@ -143,6 +146,14 @@ type Config struct {
 	// SerfWANConfig is the configuration for the cross-dc serf
 	SerfWANConfig *serf.Config
 
+	// SerfLANTCPOnly makes the LAN gossip pools, including the ones of the
+	// network segments, send their packets over TCP instead of UDP.
+	SerfLANTCPOnly bool
+
+	// SerfWANTCPOnly makes the WAN gossip pool send its packets over TCP
+	// instead of UDP.
+	SerfWANTCPOnly bool
+
 	// SerfFloodInterval controls how often we attempt to flood local Serf
 	// Consul servers into the global areas (WAN and user-defined areas in
 	// Consul Enterprise).
@@ -222,6 +233,15 @@ type Config struct {
 	AutoConfigAuthzClaimAssertions []string
 	AutoConfigAuthzAllowReuse      bool
 
+	// AutoConfigAuthzVerifiedNodeMeta are the mapped claims of the intro
+	// tokens attested as node metadata, see structs.NodeIdentity.
+	AutoConfigAuthzVerifiedNodeMeta []string
+
+	// AutoConfigSnippets are the configuration fragments pushed to the agents
+	// configured through auto_config. They can be updated with a reload, see
+	// ReloadableConfig.
+	AutoConfigSnippets []AutoConfigSnippet
+
 	// TombstoneTTL is used to control how long KV tombstones are retained.
 	// This provides a window of time where the X-Consul-Index is monotonic.
 	// Outside this window, the index may not be monotonic. This is a result
@@ -312,6 +332,15 @@ type Config struct {
 	// CheckOutputMaxSize control the max size of output of checks
 	CheckOutputMaxSize int
 
+	// CatalogCheckOutputMaxSize is the max size of the output of the checks
+	// stored in the catalog. Larger outputs are truncated by the servers.
+	// Zero means no limit.
+	CatalogCheckOutputMaxSize int
+
+	// CatalogCheckOutputCompression compresses the large outputs of the
+	// checks in the Raft log. All the servers must support it.
+	CatalogCheckOutputCompression bool
+
 	// RPCHandshakeTimeout limits how long we will wait for the initial magic byte
 	// on an RPC client connection. It also governs how long we will wait for a
 	// TLS handshake when TLS is configured however the timout applies separately
@@ -340,6 +369,16 @@ type Config struct {
 	// allowed from a single source IP.
 	RPCMaxConnsPerClient int
 
+	// MaxPerPage is the maximum number of results returned in a page of the
+	// paginated list endpoints.
+	MaxPerPage int
+
+	// RPCMaxConcurrentRequestsPerClient and RPCMaxInFlightBytesPerClient limit
+	// the number and size of the RPC requests processed at once for a single
+	// source IP other than the servers of the datacenter. Zero means no limit.
+	RPCMaxConcurrentRequestsPerClient int
+	RPCMaxInFlightBytesPerClient      int
+
 	// LeaveDrainTime is used to wait after a server has left the LAN Serf
 	// pool for RPCs to drain and new requests to be sent to other servers.
 	LeaveDrainTime time.Duration
@@ -368,6 +407,18 @@ type Config struct {
 	// datacenters should exclusively traverse mesh gateways.
 	ConnectMeshGatewayWANFederationEnabled bool
 
+	// ConnectCARootsWebhook is the URL to which the leader posts the changes
+	// to the certificates trusted by the Connect CA, if set.
+	ConnectCARootsWebhook string
+
+	// ConnectCARootsWebhookSecret is the key of the HMAC signing the changes
+	// posted to ConnectCARootsWebhook.
+	ConnectCARootsWebhookSecret string
+
+	// TrafficShiftPrometheusAddresses are the base URLs of the Prometheus
+	// HTTP APIs which traffic-shift checks are allowed to query.
+	TrafficShiftPrometheusAddresses []string
+
 	// DisableFederationStateAntiEntropy solely exists for use in unit tests to
 	// disable a background routine.
 	DisableFederationStateAntiEntropy bool
@@ -508,6 +559,7 @@ func DefaultConfig() *Config {
 		AutopilotInterval:        10 * time.Second,
 		DefaultQueryTime:         300 * time.Second,
 		MaxQueryTime:             600 * time.Second,
+		MaxPerPage:               1000,
 
 		EnterpriseConfig: DefaultEnterpriseConfig(),
 	}
@@ -597,15 +649,19 @@ type RPCConfig struct {
 // ReloadableConfig is the configuration that is passed to ReloadConfig when
 // application config is reloaded.
 type ReloadableConfig struct {
-	RPCRateLimit          rate.Limit
-	RPCMaxBurst           int
-	RPCMaxConnsPerClient  int
-	ConfigEntryBootstrap  []structs.ConfigEntry
-	RaftSnapshotThreshold int
-	RaftSnapshotInterval  time.Duration
-	RaftTrailingLogs      int
-	HeartbeatTimeout      time.Duration
-	ElectionTimeout       time.Duration
+	RPCRateLimit                      rate.Limit
+	RPCMaxBurst                       int
+	RPCMaxConnsPerClient              int
+	RPCMaxConcurrentRequestsPerClient int
+	RPCMaxInFlightBytesPerClient      int
+	ConfigEntryBootstrap              []structs.ConfigEntry
+	RaftSnapshotThreshold             int
+	RaftSnapshotInterval              time.Duration
+	RaftTrailingLogs                  int
+	RaftSnapshotAutoTune              bool
+	HeartbeatTimeout                  time.Duration
+	ElectionTimeout                   time.Duration
+	AutoConfigSnippets                []AutoConfigSnippet
 }
 
 type RaftBoltDBConfig struct {
@@ -3,6 +3,7 @@ package consul
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"time"
 
 	metrics "github.com/armon/go-metrics"
@@ -19,27 +20,27 @@ import (
 var ConfigSummaries = []prometheus.SummaryDefinition{
 	{
 		Name: []string{"config_entry", "apply"},
-		Help: "",
+		Help: "Measures the time it takes to complete an update to the config entry store.",
 	},
 	{
 		Name: []string{"config_entry", "get"},
-		Help: "",
+		Help: "Measures the time it takes to respond to a config entry get request.",
 	},
 	{
 		Name: []string{"config_entry", "list"},
-		Help: "",
+		Help: "Measures the time it takes to respond to a config entry list request.",
 	},
 	{
 		Name: []string{"config_entry", "listAll"},
-		Help: "",
+		Help: "Measures the time it takes to respond to a config entry listAll request.",
 	},
 	{
 		Name: []string{"config_entry", "delete"},
-		Help: "",
+		Help: "Measures the time it takes to delete a config entry.",
 	},
 	{
 		Name: []string{"config_entry", "resolve_service_config"},
-		Help: "",
+		Help: "Measures the time it takes to resolve the service config of a service.",
 	},
 }
 
@@ -79,6 +80,12 @@ func (c *ConfigEntry) Apply(args *structs.ConfigEntryRequest, reply *bool) error
 		return err
 	}
 
+	// The status of a traffic shift is only written by the leader, a user
+	// writing the entry starts the shift over.
+	if shift, ok := args.Entry.(*structs.TrafficShiftConfigEntry); ok {
+		shift.Status = nil
+	}
+
 	// Normalize and validate the incoming config entry as if it came from a user.
 	if err := args.Entry.Normalize(); err != nil {
 		return err
@@ -268,13 +275,28 @@ func (c *ConfigEntry) List(args *structs.ConfigEntryQuery, reply *structs.Indexe
 				filteredEntries = append(filteredEntries, entry)
 			}
 
+			if isPaginated(&args.QueryOptions) {
+				sort.SliceStable(filteredEntries, func(i, j int) bool {
+					return comparePageKeys(configEntryPageKey(filteredEntries[i]), configEntryPageKey(filteredEntries[j])) < 0
+				})
+				start, end, err := paginate(&args.QueryOptions, &reply.QueryMeta, c.srv.config.MaxPerPage, len(filteredEntries), func(i int) []string {
+					return configEntryPageKey(filteredEntries[i])
+				})
+				if err != nil {
+					return err
+				}
+				filteredEntries = filteredEntries[start:end]
+			}
+
 			reply.Kind = args.Kind
 			reply.Index = index
 			reply.Entries = filteredEntries
 
 			// Generate a hash of the content driving this response. Use it to
-			// determine if the response is identical to a prior wakeup.
-			newHash, err := hashstructure_v2.Hash(filteredEntries, hashstructure_v2.FormatV2, nil)
+			// determine if the response is identical to a prior wakeup. The
+			// next token is part of it since the following page may have been
+			// emptied or filled.
+			newHash, err := hashstructure_v2.Hash([]interface{}{filteredEntries, reply.NextToken}, hashstructure_v2.FormatV2, nil)
 			if err != nil {
 				return fmt.Errorf("error hashing reply for spurious wakeup suppression: %w", err)
 			}
@@ -295,6 +317,13 @@ func (c *ConfigEntry) List(args *structs.ConfigEntryQuery, reply *structs.Indexe
 		})
 }
 
+// configEntryPageKey returns the fields the pages of config entries are
+// ordered by.
+func configEntryPageKey(entry structs.ConfigEntry) []string {
+	entMeta := entry.GetEnterpriseMeta()
+	return []string{entry.GetKind(), entMeta.PartitionOrDefault(), entMeta.NamespaceOrDefault(), entry.GetName()}
+}
+
 var configEntryKindsFromConsul_1_8_0 = []string{
 	structs.ServiceDefaults,
 	structs.ProxyDefaults,
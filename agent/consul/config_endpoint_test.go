@@ -1075,6 +1075,116 @@ func TestConfigEntry_ResolveServiceConfig(t *testing.T) {
 	require.Equal(t, map[string]interface{}{"foo": 1}, proxyConf.Config)
 }
 
+func TestConfigEntry_ResolveServiceConfig_LocalAppHTTP2(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	_, s1 := testServer(t)
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	state := s1.fsm.State()
+	require.NoError(t, state.EnsureConfigEntry(1, &structs.ServiceConfigEntry{
+		Kind:     structs.ServiceDefaults,
+		Name:     "foo",
+		Protocol: "http",
+		LocalAppHTTP2: &structs.LocalAppHTTP2Config{
+			PriorKnowledge:       true,
+			KeepaliveIntervalMs:  30000,
+			MaxConcurrentStreams: 100,
+		},
+	}))
+
+	args := structs.ServiceConfigRequest{
+		Name:       "foo",
+		Datacenter: s1.config.Datacenter,
+	}
+	var out structs.ServiceConfigResponse
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConfigEntry.ResolveServiceConfig", &args, &out))
+
+	expected := map[string]interface{}{
+		"protocol":                               "http",
+		"local_app_http2_prior_knowledge":        true,
+		"local_app_http2_keepalive_interval_ms":  uint64(30000),
+		"local_app_http2_max_concurrent_streams": int64(100),
+	}
+	require.Equal(t, expected, out.ProxyConfig)
+}
+
+func TestConfigEntry_ResolveServiceConfig_InboundLimits(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	_, s1 := testServer(t)
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	state := s1.fsm.State()
+	require.NoError(t, state.EnsureConfigEntry(1, &structs.ServiceConfigEntry{
+		Kind:                              structs.ServiceDefaults,
+		Name:                              "foo",
+		MaxInboundConnections:             100,
+		InboundConnectionBufferLimitBytes: 32768,
+	}))
+
+	args := structs.ServiceConfigRequest{
+		Name:       "foo",
+		Datacenter: s1.config.Datacenter,
+	}
+	var out structs.ServiceConfigResponse
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConfigEntry.ResolveServiceConfig", &args, &out))
+
+	expected := map[string]interface{}{
+		"max_inbound_connections":               int64(100),
+		"inbound_connection_buffer_limit_bytes": uint64(32768),
+	}
+	require.Equal(t, expected, out.ProxyConfig)
+}
+
+func TestConfigEntry_ResolveServiceConfig_RequestNormalization(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	_, s1 := testServer(t)
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	mergeSlashes := false
+	state := s1.fsm.State()
+	require.NoError(t, state.EnsureConfigEntry(1, &structs.ServiceConfigEntry{
+		Kind:     structs.ServiceDefaults,
+		Name:     "foo",
+		Protocol: "http",
+		RequestNormalization: &structs.RequestNormalizationConfig{
+			MergeSlashes:                 &mergeSlashes,
+			HeadersWithUnderscoresAction: "DROP_HEADER",
+		},
+	}))
+
+	args := structs.ServiceConfigRequest{
+		Name:       "foo",
+		Datacenter: s1.config.Datacenter,
+	}
+	var out structs.ServiceConfigResponse
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConfigEntry.ResolveServiceConfig", &args, &out))
+
+	expected := map[string]interface{}{
+		"protocol":                        "http",
+		"merge_slashes":                   false,
+		"headers_with_underscores_action": "DROP_HEADER",
+	}
+	require.Equal(t, expected, out.ProxyConfig)
+}
+
 func TestConfigEntry_ResolveServiceConfig_TransparentProxy(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -1255,6 +1365,78 @@ func TestConfigEntry_ResolveServiceConfig_Upstreams(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "peered upstream config entries from PeeredUpstreams and service-defaults",
+			entries: []structs.ConfigEntry{
+				&structs.ProxyConfigEntry{
+					Kind: structs.ProxyDefaults,
+					Name: structs.ProxyConfigGlobal,
+					Config: map[string]interface{}{
+						"protocol": "grpc",
+					},
+				},
+				&structs.ServiceConfigEntry{
+					Kind: structs.ServiceDefaults,
+					Name: "mysql",
+					// The protocol of the local mysql doesn't apply to the imported one.
+					Protocol: "tcp",
+				},
+				&structs.ServiceConfigEntry{
+					Kind: structs.ServiceDefaults,
+					Name: "api",
+					UpstreamConfig: &structs.UpstreamConfiguration{
+						Overrides: []*structs.UpstreamConfig{
+							{
+								Name:             "mysql",
+								ConnectTimeoutMs: 1000,
+							},
+							{
+								Name:             "mysql",
+								Peer:             "cluster-02",
+								ConnectTimeoutMs: 10000,
+								MeshGateway:      structs.MeshGatewayConfig{Mode: structs.MeshGatewayModeLocal},
+							},
+						},
+					},
+				},
+			},
+			request: structs.ServiceConfigRequest{
+				Name:       "api",
+				Datacenter: "dc1",
+				UpstreamIDs: []structs.ServiceID{
+					mysql,
+				},
+				PeeredUpstreams: []structs.PeeredServiceName{
+					{
+						Peer:        "cluster-02",
+						ServiceName: structs.NewServiceName("mysql", structs.DefaultEnterpriseMetaInDefaultPartition()),
+					},
+				},
+			},
+			expect: structs.ServiceConfigResponse{
+				ProxyConfig: map[string]interface{}{
+					"protocol": "grpc",
+				},
+				UpstreamIDConfigs: structs.OpaqueUpstreamConfigs{
+					{
+						Upstream: mysql,
+						Config: map[string]interface{}{
+							"protocol":           "tcp",
+							"connect_timeout_ms": uint64(1000),
+						},
+					},
+					{
+						Upstream: mysql,
+						Peer:     "cluster-02",
+						Config: map[string]interface{}{
+							"protocol":           "grpc",
+							"connect_timeout_ms": uint64(10000),
+							"mesh_gateway":       map[string]interface{}{"Mode": "local"},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "upstream config entries from UpstreamIDs and service-defaults",
 			entries: []structs.ConfigEntry{
@@ -1596,7 +1778,11 @@ func TestConfigEntry_ResolveServiceConfig_Upstreams(t *testing.T) {
 
 			// Order of this slice is also not deterministic since it's populated from a map
 			sort.SliceStable(out.UpstreamIDConfigs, func(i, j int) bool {
-				return out.UpstreamIDConfigs[i].Upstream.String() < out.UpstreamIDConfigs[j].Upstream.String()
+				a, b := out.UpstreamIDConfigs[i], out.UpstreamIDConfigs[j]
+				if a.Upstream.String() != b.Upstream.String() {
+					return a.Upstream.String() < b.Upstream.String()
+				}
+				return a.Peer < b.Peer
 			})
 
 			require.Equal(t, tc.expect, out)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/hashicorp/go-bexpr"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-memdb"
 
@@ -131,6 +132,56 @@ func (s *ConnectCA) Roots(
 	)
 }
 
+// Certificates returns the records of the unexpired leaf certificates signed
+// by the CA.
+func (s *ConnectCA) Certificates(
+	args *structs.DCSpecificRequest,
+	reply *structs.IndexedIssuedCertRecords) error {
+	// Exit early if Connect hasn't been enabled.
+	if !s.srv.config.ConnectEnabled {
+		return ErrConnectNotEnabled
+	}
+
+	if done, err := s.srv.ForwardRPC("ConnectCA.Certificates", args, reply); done {
+		return err
+	}
+
+	// The inventory covers every identity of the mesh, so this requires
+	// operator read access.
+	authz, err := s.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if err := authz.ToAllowAuthorizer().OperatorReadAllowed(nil); err != nil {
+		return err
+	}
+
+	filter, err := bexpr.CreateFilter(args.Filter, nil, reply.Certs)
+	if err != nil {
+		return err
+	}
+
+	return s.srv.blockingQuery(
+		&args.QueryOptions, &reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, certs, err := state.CAIssuedCerts(ws, time.Now())
+			if err != nil {
+				return err
+			}
+			if certs == nil {
+				certs = make(structs.IssuedCertRecords, 0)
+			}
+
+			raw, err := filter.Execute(certs)
+			if err != nil {
+				return err
+			}
+			reply.Index, reply.Certs = index, raw.(structs.IssuedCertRecords)
+			return nil
+		},
+	)
+}
+
 // Sign signs a certificate for a service.
 func (s *ConnectCA) Sign(
 	args *structs.CASignRequest,
@@ -3,6 +3,7 @@ package consul
 import (
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"os"
 	"sync"
@@ -1170,3 +1171,128 @@ func TestConnectCASignValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestConnectCACertificates(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.PrimaryDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLInitialManagementToken = TestDefaultInitialManagementToken
+		c.ACLResolverSettings.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	var serials []string
+	for _, service := range []string{"web", "db"} {
+		csr, _ := connect.TestCSR(t, connect.TestSpiffeIDService(t, service))
+		args := &structs.CASignRequest{
+			Datacenter:   "dc1",
+			CSR:          csr,
+			WriteRequest: structs.WriteRequest{Token: TestDefaultInitialManagementToken},
+		}
+		var reply structs.IssuedCert
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConnectCA.Sign", args, &reply))
+		serials = append(serials, reply.SerialNumber)
+	}
+
+	var roots structs.IndexedCARoots
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConnectCA.Roots", &structs.DCSpecificRequest{Datacenter: "dc1"}, &roots))
+	active := roots.Active()
+	require.NotNil(t, active)
+
+	opReadToken, err := upsertTestTokenWithPolicyRules(
+		codec, TestDefaultInitialManagementToken, "dc1", `operator = "read"`)
+	require.NoError(t, err)
+
+	t.Run("deny", func(t *testing.T) {
+		args := &structs.DCSpecificRequest{Datacenter: "dc1"}
+		var reply structs.IndexedIssuedCertRecords
+		err := msgpackrpc.CallWithCodec(codec, "ConnectCA.Certificates", args, &reply)
+		require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		args := &structs.DCSpecificRequest{
+			Datacenter:   "dc1",
+			QueryOptions: structs.QueryOptions{Token: opReadToken.SecretID},
+		}
+		var reply structs.IndexedIssuedCertRecords
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConnectCA.Certificates", args, &reply))
+		require.Len(t, reply.Certs, 2)
+
+		bySerial := make(map[string]*structs.IssuedCertRecord)
+		for _, r := range reply.Certs {
+			bySerial[r.SerialNumber] = r
+		}
+		web := bySerial[serials[0]]
+		require.NotNil(t, web)
+		require.Equal(t, "web", web.Service)
+		require.Equal(t, connect.TestSpiffeIDService(t, "web").URI().String(), web.ServiceURI)
+		require.Equal(t, active.ID, web.RootID)
+		require.Equal(t, active.SigningKeyID, web.SigningKeyID)
+		require.True(t, web.ValidBefore.After(time.Now()))
+		require.NotNil(t, bySerial[serials[1]])
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		args := &structs.DCSpecificRequest{
+			Datacenter:   "dc1",
+			QueryOptions: structs.QueryOptions{Token: opReadToken.SecretID, Filter: `Service == "db"`},
+		}
+		var reply structs.IndexedIssuedCertRecords
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConnectCA.Certificates", args, &reply))
+		require.Len(t, reply.Certs, 1)
+		require.Equal(t, serials[1], reply.Certs[0].SerialNumber)
+	})
+}
+
+func TestLeafRotationWindow(t *testing.T) {
+	require.Zero(t, leafRotationWindow(0, 50))
+	require.Zero(t, leafRotationWindow(1000, 0))
+	require.Equal(t, 20*time.Second, leafRotationWindow(1000, 50))
+	require.Equal(t, 200*time.Second, leafRotationWindow(10000, 50))
+	require.Equal(t, maxLeafRotationWindow, leafRotationWindow(1000000, 1))
+}
+
+func TestLeafRotationWindowCache(t *testing.T) {
+	var c leafRotationWindowCache
+	var computed int
+	compute := func(window time.Duration) func() (time.Duration, error) {
+		return func() (time.Duration, error) {
+			computed++
+			return window, nil
+		}
+	}
+
+	window, err := c.get(5, compute(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, time.Second, window)
+
+	// The window is reused until the index changes.
+	window, err = c.get(5, compute(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, time.Second, window)
+	require.Equal(t, 1, computed)
+
+	window, err = c.get(6, compute(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, window)
+	require.Equal(t, 2, computed)
+
+	// Errors aren't cached.
+	_, err = c.get(7, func() (time.Duration, error) { return 0, errors.New("failed") })
+	require.EqualError(t, err, "failed")
+	window, err = c.get(7, compute(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, window)
+}
@@ -2,6 +2,7 @@ package discoverychain
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -172,6 +173,11 @@ type compiler struct {
 	// This is an OUTPUT field.
 	startNode string
 
+	// dynamicSubsetNodes are the resolver nodes of the dynamic subsets
+	// discovered from the instances of the services of the chain. They are
+	// kept even when no other node references them.
+	dynamicSubsetNodes []string
+
 	// nodes is computed inside of compile()
 	//
 	// This is an OUTPUT field.
@@ -220,7 +226,7 @@ func (c *compiler) recordServiceProtocol(sid structs.ServiceID) error {
 	if serviceDefault := c.entries.GetService(sid); serviceDefault != nil {
 		return c.recordProtocol(sid, serviceDefault.Protocol)
 	}
-	if proxyDefault := c.entries.GetProxyDefaults(sid.PartitionOrDefault()); proxyDefault != nil {
+	if proxyDefault := c.entries.GetProxyDefaults(&sid.EnterpriseMeta); proxyDefault != nil {
 		var cfg proxyConfig
 		// Ignore errors and fallback on defaults if it does happen.
 		_ = mapstructure.WeakDecode(proxyDefault.Config, &cfg)
@@ -262,6 +268,10 @@ func (c *compiler) compile() (*structs.CompiledDiscoveryChain, error) {
 		return nil, err
 	}
 
+	if err := c.addDynamicSubsetNodes(); err != nil {
+		return nil, err
+	}
+
 	// We don't need these intermediates anymore.
 	c.splitterNodes = nil
 	c.resolveNodes = nil
@@ -489,6 +499,9 @@ func (c *compiler) removeUnusedNodes() error {
 	)
 
 	todo[c.startNode] = struct{}{}
+	for _, node := range c.dynamicSubsetNodes {
+		todo[node] = struct{}{}
+	}
 
 	getNext := func() string {
 		if len(todo) == 0 {
@@ -659,6 +672,42 @@ func (c *compiler) assembleChain() error {
 	return nil
 }
 
+// addDynamicSubsetNodes adds a resolver node to the chain for each metadata
+// value registered by the instances of the services whose resolver sets
+// DynamicSubsets, so that every dynamic subset is addressable without being
+// referenced by the other config entries.
+func (c *compiler) addDynamicSubsetNodes() error {
+	sids := make([]structs.ServiceID, 0, len(c.entries.DynamicSubsets))
+	for sid := range c.entries.DynamicSubsets {
+		sids = append(sids, sid)
+	}
+	sort.Slice(sids, func(i, j int) bool {
+		return sids[i].String() < sids[j].String()
+	})
+
+	for _, sid := range sids {
+		resolver := c.resolvers[sid]
+		if resolver == nil || resolver.DynamicSubsets == nil {
+			continue
+		}
+		for _, value := range c.entries.GetDynamicSubsets(sid) {
+			// Values which aren't valid subset names can't be addressed.
+			if _, ok := resolver.GetSubset(value); !ok {
+				continue
+			}
+			node, err := c.getResolverNode(
+				c.newTarget(sid.ID, value, sid.NamespaceOrDefault(), sid.PartitionOrDefault(), ""),
+				false,
+			)
+			if err != nil {
+				return err
+			}
+			c.dynamicSubsetNodes = append(c.dynamicSubsetNodes, node.MapKey())
+		}
+	}
+	return nil
+}
+
 func newDefaultServiceRoute(serviceName, namespace, partition string) *structs.ServiceRoute {
 	return &structs.ServiceRoute{
 		Match: &structs.ServiceRouteMatch{
@@ -943,7 +992,7 @@ RESOLVE_AGAIN:
 		LoadBalancer: resolver.LoadBalancer,
 	}
 
-	target.Subset = resolver.Subsets[target.ServiceSubset]
+	target.Subset, _ = resolver.GetSubset(target.ServiceSubset)
 
 	if serviceDefault := c.entries.GetService(targetID); serviceDefault != nil && serviceDefault.ExternalSNI != "" {
 		// Override the default SNI value.
@@ -951,6 +1000,10 @@ RESOLVE_AGAIN:
 		target.External = true
 	}
 
+	if serviceDefault := c.entries.GetService(targetID); serviceDefault != nil {
+		target.IgnoreWeights = serviceDefault.IgnoreCatalogWeights
+	}
+
 	// If using external SNI the service is fundamentally external.
 	if target.External {
 		if resolver.Redirect != nil {
@@ -990,7 +1043,7 @@ RESOLVE_AGAIN:
 		if serviceDefault := c.entries.GetService(targetID); serviceDefault != nil {
 			target.MeshGateway = serviceDefault.MeshGateway
 		}
-		proxyDefault := c.entries.GetProxyDefaults(targetID.PartitionOrDefault())
+		proxyDefault := c.entries.GetProxyDefaults(&targetID.EnterpriseMeta)
 		if proxyDefault != nil && target.MeshGateway.Mode == structs.MeshGatewayModeDefault {
 			target.MeshGateway.Mode = proxyDefault.MeshGateway.Mode
 		}
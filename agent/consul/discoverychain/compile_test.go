@@ -1,6 +1,7 @@
 package discoverychain
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -48,7 +49,10 @@ func TestCompile(t *testing.T) {
 		"datacenter failover with mesh gateways":           testcase_DatacenterFailover_WithMeshGateways(),
 		"noop split to resolver with default subset":       testcase_NoopSplit_WithDefaultSubset(),
 		"resolver with default subset":                     testcase_Resolve_WithDefaultSubset(),
+		"resolver with dynamic subsets":                    testcase_Resolve_WithDynamicSubsets(),
+		"resolver with discovered dynamic subsets":         testcase_Resolve_WithDiscoveredDynamicSubsets(),
 		"default resolver with external sni":               testcase_DefaultResolver_ExternalSNI(),
+		"default resolver ignoring catalog weights":        testcase_DefaultResolver_IgnoreCatalogWeights(),
 		"resolver with no entries and inferring defaults":  testcase_DefaultResolver(),
 		"default resolver with proxy defaults":             testcase_DefaultResolver_WithProxyDefaults(),
 		"loadbalancer splitter and resolver":               testcase_LBSplitterAndResolver(),
@@ -1668,6 +1672,104 @@ func testcase_Resolve_WithDefaultSubset() compileTestCase {
 	return compileTestCase{entries: entries, expect: expect}
 }
 
+func testcase_Resolve_WithDynamicSubsets() compileTestCase {
+	entries := newEntries()
+	entries.AddResolvers(
+		&structs.ServiceResolverConfigEntry{
+			Kind:          "service-resolver",
+			Name:          "main",
+			DefaultSubset: "v3",
+			Subsets: map[string]structs.ServiceResolverSubset{
+				"v1": {Filter: "Service.Meta.version == 1"},
+			},
+			DynamicSubsets: &structs.ServiceResolverDynamicSubsets{
+				MetaKey:     "version",
+				OnlyPassing: true,
+			},
+		},
+	)
+
+	expect := &structs.CompiledDiscoveryChain{
+		Protocol:  "tcp",
+		StartNode: "resolver:v3.main.default.default.dc1",
+		Nodes: map[string]*structs.DiscoveryGraphNode{
+			"resolver:v3.main.default.default.dc1": {
+				Type: structs.DiscoveryGraphNodeTypeResolver,
+				Name: "v3.main.default.default.dc1",
+				Resolver: &structs.DiscoveryResolver{
+					ConnectTimeout: 5 * time.Second,
+					Target:         "v3.main.default.default.dc1",
+				},
+			},
+		},
+		Targets: map[string]*structs.DiscoveryTarget{
+			"v3.main.default.default.dc1": newTarget("main", "v3", "default", "default", "dc1", func(t *structs.DiscoveryTarget) {
+				t.Subset = structs.ServiceResolverSubset{
+					Filter:      `Service.Meta["version"] == "v3"`,
+					OnlyPassing: true,
+				}
+			}),
+		},
+	}
+	return compileTestCase{entries: entries, expect: expect}
+}
+
+func testcase_Resolve_WithDiscoveredDynamicSubsets() compileTestCase {
+	entries := newEntries()
+	entries.AddResolvers(
+		&structs.ServiceResolverConfigEntry{
+			Kind:          "service-resolver",
+			Name:          "main",
+			DefaultSubset: "v3",
+			Subsets: map[string]structs.ServiceResolverSubset{
+				"v1": {Filter: "Service.Meta.version == 1"},
+			},
+			DynamicSubsets: &structs.ServiceResolverDynamicSubsets{
+				MetaKey: "version",
+			},
+		},
+	)
+	// Values which aren't valid subset names are left out.
+	entries.AddDynamicSubsets(structs.NewServiceID("main", nil), "v1", "v2", "v3", "Not A Subset")
+
+	resolverNode := func(subset string) *structs.DiscoveryGraphNode {
+		return &structs.DiscoveryGraphNode{
+			Type: structs.DiscoveryGraphNodeTypeResolver,
+			Name: subset + ".main.default.default.dc1",
+			Resolver: &structs.DiscoveryResolver{
+				ConnectTimeout: 5 * time.Second,
+				Target:         subset + ".main.default.default.dc1",
+			},
+		}
+	}
+	dynamicTarget := func(subset string) *structs.DiscoveryTarget {
+		return newTarget("main", subset, "default", "default", "dc1", func(t *structs.DiscoveryTarget) {
+			t.Subset = structs.ServiceResolverSubset{
+				Filter: fmt.Sprintf(`Service.Meta["version"] == %q`, subset),
+			}
+		})
+	}
+
+	expect := &structs.CompiledDiscoveryChain{
+		Protocol:  "tcp",
+		StartNode: "resolver:v3.main.default.default.dc1",
+		Nodes: map[string]*structs.DiscoveryGraphNode{
+			"resolver:v1.main.default.default.dc1": resolverNode("v1"),
+			"resolver:v2.main.default.default.dc1": resolverNode("v2"),
+			"resolver:v3.main.default.default.dc1": resolverNode("v3"),
+		},
+		Targets: map[string]*structs.DiscoveryTarget{
+			// Explicit subsets take precedence.
+			"v1.main.default.default.dc1": newTarget("main", "v1", "default", "default", "dc1", func(t *structs.DiscoveryTarget) {
+				t.Subset = structs.ServiceResolverSubset{Filter: "Service.Meta.version == 1"}
+			}),
+			"v2.main.default.default.dc1": dynamicTarget("v2"),
+			"v3.main.default.default.dc1": dynamicTarget("v3"),
+		},
+	}
+	return compileTestCase{entries: entries, expect: expect}
+}
+
 func testcase_DefaultResolver_ExternalSNI() compileTestCase {
 	entries := newEntries()
 	entries.AddServices(&structs.ServiceConfigEntry{
@@ -1701,6 +1803,38 @@ func testcase_DefaultResolver_ExternalSNI() compileTestCase {
 	return compileTestCase{entries: entries, expect: expect}
 }
 
+func testcase_DefaultResolver_IgnoreCatalogWeights() compileTestCase {
+	entries := newEntries()
+	entries.AddServices(&structs.ServiceConfigEntry{
+		Kind:                 structs.ServiceDefaults,
+		Name:                 "main",
+		IgnoreCatalogWeights: true,
+	})
+
+	expect := &structs.CompiledDiscoveryChain{
+		Protocol:  "tcp",
+		Default:   true,
+		StartNode: "resolver:main.default.default.dc1",
+		Nodes: map[string]*structs.DiscoveryGraphNode{
+			"resolver:main.default.default.dc1": {
+				Type: structs.DiscoveryGraphNodeTypeResolver,
+				Name: "main.default.default.dc1",
+				Resolver: &structs.DiscoveryResolver{
+					Default:        true,
+					ConnectTimeout: 5 * time.Second,
+					Target:         "main.default.default.dc1",
+				},
+			},
+		},
+		Targets: map[string]*structs.DiscoveryTarget{
+			"main.default.default.dc1": newTarget("main", "", "default", "default", "dc1", func(t *structs.DiscoveryTarget) {
+				t.IgnoreWeights = true
+			}),
+		},
+	}
+	return compileTestCase{entries: entries, expect: expect}
+}
+
 func testcase_Resolver_ExternalSNI_FailoverNotAllowed() compileTestCase {
 	entries := newEntries()
 	entries.AddServices(&structs.ServiceConfigEntry{
@@ -46,7 +46,11 @@ func (s *Server) handleEnterpriseNativeTLSConn(alpnProto string, conn net.Conn)
 }
 
 func (s *Server) handleEnterpriseLeave() {
-	return
+	for name, segment := range s.segmentLAN {
+		if err := segment.Leave(); err != nil {
+			s.logger.Error("failed to leave LAN Serf cluster for segment", "segment", name, "error", err)
+		}
+	}
 }
 
 func (s *Server) establishEnterpriseLeadership(_ context.Context) error {
@@ -108,6 +112,9 @@ func (s *Server) shutdownSerfLAN() {
 	if s.serfLAN != nil {
 		s.serfLAN.Shutdown()
 	}
+	for _, segment := range s.segmentLAN {
+		segment.Shutdown()
+	}
 }
 
 func addEnterpriseSerfTags(_ map[string]string, _ *acl.EnterpriseMeta) {
@@ -16,19 +16,19 @@ import (
 var FederationStateSummaries = []prometheus.SummaryDefinition{
 	{
 		Name: []string{"federation_state", "apply"},
-		Help: "",
+		Help: "Measures the time it takes to apply a federation state operation.",
 	},
 	{
 		Name: []string{"federation_state", "get"},
-		Help: "",
+		Help: "Measures the time it takes to get a federation state.",
 	},
 	{
 		Name: []string{"federation_state", "list"},
-		Help: "",
+		Help: "Measures the time it takes to list the federation states.",
 	},
 	{
 		Name: []string{"federation_state", "list_mesh_gateways"},
-		Help: "",
+		Help: "Measures the time it takes to list the mesh gateways of the federation states.",
 	},
 }
 
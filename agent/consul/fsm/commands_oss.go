@@ -141,6 +141,9 @@ func init() {
 	registerCommand(structs.PeeringTerminateByIDType, (*FSM).applyPeeringTerminate)
 	registerCommand(structs.PeeringTrustBundleWriteType, (*FSM).applyPeeringTrustBundleWrite)
 	registerCommand(structs.PeeringTrustBundleDeleteType, (*FSM).applyPeeringTrustBundleDelete)
+	registerCommand(structs.PeeringIntentionsWriteType, (*FSM).applyPeeringIntentionsWrite)
+	registerCommand(structs.PeeringIntentionsDeleteType, (*FSM).applyPeeringIntentionsDelete)
+	registerCommand(structs.ACLTokenUsageUpdateType, (*FSM).applyACLTokenUsageUpdate)
 }
 
 func (c *FSM) applyRegister(buf []byte, index uint64) interface{} {
@@ -149,6 +152,10 @@ func (c *FSM) applyRegister(buf []byte, index uint64) interface{} {
 	if err := structs.Decode(buf, &req); err != nil {
 		panic(fmt.Errorf("failed to decode request: %v", err))
 	}
+	if err := req.DecompressCheckOutputs(); err != nil {
+		c.logger.Warn("EnsureRegistration failed", "error", err)
+		return err
+	}
 
 	// Apply all updates in a single transaction
 	if err := c.state.EnsureRegistration(index, &req); err != nil {
@@ -169,7 +176,7 @@ func (c *FSM) applyDeregister(buf []byte, index uint64) interface{} {
 	// here is also baked into vetDeregisterWithACL() in acl.go, so if you
 	// make changes here, be sure to also adjust the code over there.
 	if req.ServiceID != "" {
-		if err := c.state.DeleteService(index, req.Node, req.ServiceID, &req.EnterpriseMeta, req.PeerName); err != nil {
+		if err := c.state.DeleteServiceAndRecord(index, req.Node, req.ServiceID, &req.EnterpriseMeta, req.PeerName, req.Deregistration()); err != nil {
 			c.logger.Warn("DeleteNodeService failed", "error", err)
 			return err
 		}
@@ -179,7 +186,7 @@ func (c *FSM) applyDeregister(buf []byte, index uint64) interface{} {
 			return err
 		}
 	} else {
-		if err := c.state.DeleteNode(index, req.Node, &req.EnterpriseMeta, req.PeerName); err != nil {
+		if err := c.state.DeleteNodeAndRecord(index, req.Node, &req.EnterpriseMeta, req.PeerName, req.Deregistration()); err != nil {
 			c.logger.Warn("DeleteNode failed", "error", err)
 			return err
 		}
@@ -316,7 +323,7 @@ func (c *FSM) applyTxn(buf []byte, index uint64) interface{} {
 		panic(fmt.Errorf("failed to decode request: %v", err))
 	}
 	defer metrics.MeasureSince([]string{"fsm", "txn"}, time.Now())
-	results, errors := c.state.TxnRW(index, req.Ops)
+	results, errors := c.state.TxnRWAndRecord(index, req.Ops, req.Deregistration())
 	return structs.TxnResponse{
 		Results: results,
 		Errors:  errors,
@@ -470,6 +477,11 @@ func (c *FSM) applyConnectCALeafOperation(buf []byte, index uint64) interface{}
 		if err := c.state.CALeafSetIndex(index, index); err != nil {
 			return err
 		}
+		if req.IssuedCert != nil {
+			if err := c.state.CAIssuedCertSet(index, req.IssuedCert); err != nil {
+				return err
+			}
+		}
 		return index
 	default:
 		c.logger.Warn("Invalid CA Leaf operation", "operation", req.Op)
@@ -505,6 +517,17 @@ func (c *FSM) applyACLTokenDeleteOperation(buf []byte, index uint64) interface{}
 	return c.state.ACLTokenBatchDelete(index, req.TokenIDs)
 }
 
+func (c *FSM) applyACLTokenUsageUpdate(buf []byte, index uint64) interface{} {
+	var req structs.ACLTokenUsageUpdateRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+	defer metrics.MeasureSinceWithLabels([]string{"fsm", "acl", "token"}, time.Now(),
+		[]metrics.Label{{Name: "op", Value: "usage"}})
+
+	return c.state.ACLTokenUsageUpdate(index, req.Usage)
+}
+
 func (c *FSM) applyACLTokenBootstrap(buf []byte, index uint64) interface{} {
 	var req structs.ACLTokenBootstrapRequest
 	if err := structs.Decode(buf, &req); err != nil {
@@ -681,6 +704,14 @@ func (c *FSM) applySystemMetadataOperation(buf []byte, index uint64) interface{}
 			return err
 		}
 		return true
+	case structs.SystemMetadataCAS:
+		defer metrics.MeasureSinceWithLabels([]string{"fsm", "system_metadata"}, time.Now(),
+			[]metrics.Label{{Name: "op", Value: "cas"}})
+		set, err := c.state.SystemMetadataSetCAS(index, req.Entry)
+		if err != nil {
+			return err
+		}
+		return set
 	case structs.SystemMetadataDelete:
 		defer metrics.MeasureSinceWithLabels([]string{"fsm", "system_metadata"}, time.Now(),
 			[]metrics.Label{{Name: "op", Value: "delete"}})
@@ -759,3 +790,33 @@ func (c *FSM) applyPeeringTrustBundleDelete(buf []byte, index uint64) interface{
 	}
 	return c.state.PeeringTrustBundleDelete(index, q)
 }
+
+func (c *FSM) applyPeeringIntentionsWrite(buf []byte, index uint64) interface{} {
+	var req pbpeering.PeeringIntentionsWriteRequest
+	if err := structs.DecodeProto(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode peering intentions write request: %v", err))
+	}
+
+	defer metrics.MeasureSinceWithLabels([]string{"fsm", "peering_intentions"}, time.Now(),
+		[]metrics.Label{{Name: "op", Value: "write"}})
+
+	return c.state.PeeringIntentionsWrite(index, req.PeeringIntentions)
+}
+
+func (c *FSM) applyPeeringIntentionsDelete(buf []byte, index uint64) interface{} {
+	var req pbpeering.PeeringIntentionsDeleteRequest
+	if err := structs.DecodeProto(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode peering intentions delete request: %v", err))
+	}
+
+	defer metrics.MeasureSinceWithLabels([]string{"fsm", "peering_intentions"}, time.Now(),
+		[]metrics.Label{{Name: "op", Value: "delete"}})
+
+	pi := &pbpeering.PeeringIntentions{
+		PeerName:    req.PeerName,
+		Partition:   req.Partition,
+		Namespace:   req.Namespace,
+		ServiceName: req.ServiceName,
+	}
+	return c.state.PeeringIntentionsDelete(index, pi.PeeredServiceName())
+}
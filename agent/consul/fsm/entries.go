@@ -0,0 +1,89 @@
+package fsm
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/armon/go-metrics/prometheus"
+	"github.com/hashicorp/raft"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+var ApplySummaries = []prometheus.SummaryDefinition{
+	{
+		Name: []string{"fsm", "apply"},
+		Help: "Measures the time it takes to apply a Raft log entry to the FSM, labeled by message type.",
+	},
+	{
+		Name: []string{"fsm", "apply", "size"},
+		Help: "Measures the size in bytes of the Raft log entries applied to the FSM, labeled by message type.",
+	},
+}
+
+// recentEntriesSize is the number of applied entries kept around to find the
+// largest recent ones.
+const recentEntriesSize = 1024
+
+// recentEntries is a ring buffer of the last entries applied to the FSM.
+type recentEntries struct {
+	mu      sync.Mutex
+	entries []structs.RaftEntry
+	next    int
+}
+
+func (r *recentEntries) add(entry structs.RaftEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.entries) < recentEntriesSize {
+		r.entries = append(r.entries, entry)
+		return
+	}
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % recentEntriesSize
+}
+
+// largest returns up to limit entries, sorted by decreasing size.
+func (r *recentEntries) largest(limit int) []structs.RaftEntry {
+	r.mu.Lock()
+	entries := make([]structs.RaftEntry, len(r.entries))
+	copy(entries, r.entries)
+	r.mu.Unlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Size == entries[j].Size {
+			return entries[i].Index > entries[j].Index
+		}
+		return entries[i].Size > entries[j].Size
+	})
+	if limit >= 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// observeApply records the metrics of a log entry applied to the FSM.
+func (c *FSM) observeApply(msgType structs.MessageType, log *raft.Log, start time.Time) {
+	elapsed := time.Since(start)
+	labels := []metrics.Label{{Name: "type", Value: msgType.String()}}
+	metrics.MeasureSinceWithLabels([]string{"fsm", "apply"}, start, labels)
+	metrics.AddSampleWithLabels([]string{"fsm", "apply", "size"}, float32(len(log.Data)), labels)
+
+	c.recent.add(structs.RaftEntry{
+		Index:     log.Index,
+		Type:      msgType.String(),
+		Size:      len(log.Data),
+		ApplyTime: elapsed,
+		AppliedAt: start,
+	})
+}
+
+// LargestRecentEntries returns up to limit of the largest entries among the
+// last ones applied to the FSM, sorted by decreasing size. It is meant for
+// diagnosing slow applies.
+func (c *FSM) LargestRecentEntries(limit int) []structs.RaftEntry {
+	return c.recent.largest(limit)
+}
@@ -0,0 +1,77 @@
+package fsm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil"
+)
+
+func TestFSM_LargestRecentEntries(t *testing.T) {
+	t.Parallel()
+	logger := testutil.Logger(t)
+	fsm, err := New(nil, logger)
+	require.NoError(t, err)
+
+	require.Empty(t, fsm.LargestRecentEntries(10))
+
+	apply := func(index uint64, msgType structs.MessageType, msg interface{}) int {
+		buf, err := structs.Encode(msgType, msg)
+		require.NoError(t, err)
+		log := makeLog(buf)
+		log.Index = index
+		require.Nil(t, fsm.Apply(log))
+		return len(buf)
+	}
+
+	registerSize := apply(1, structs.RegisterRequestType, structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+	})
+	largeSize := apply(2, structs.KVSRequestType, structs.KVSRequest{
+		Datacenter: "dc1",
+		Op:         api.KVSet,
+		DirEnt:     structs.DirEntry{Key: "large", Value: []byte(strings.Repeat("x", 4096))},
+	})
+	smallSize := apply(3, structs.KVSRequestType, structs.KVSRequest{
+		Datacenter: "dc1",
+		Op:         api.KVSet,
+		DirEnt:     structs.DirEntry{Key: "small", Value: []byte("x")},
+	})
+
+	entries := fsm.LargestRecentEntries(2)
+	require.Len(t, entries, 2)
+	require.Equal(t, uint64(2), entries[0].Index)
+	require.Equal(t, "KVS", entries[0].Type)
+	require.Equal(t, largeSize, entries[0].Size)
+	require.False(t, entries[0].AppliedAt.IsZero())
+
+	require.Equal(t, uint64(1), entries[1].Index)
+	require.Equal(t, "Register", entries[1].Type)
+	require.Equal(t, registerSize, entries[1].Size)
+	require.Greater(t, registerSize, smallSize)
+
+	require.Len(t, fsm.LargestRecentEntries(10), 3)
+}
+
+func TestRecentEntries_Wraps(t *testing.T) {
+	var r recentEntries
+	for i := 0; i < recentEntriesSize+10; i++ {
+		r.add(structs.RaftEntry{Index: uint64(i), Size: i})
+	}
+
+	require.Len(t, r.largest(-1), recentEntriesSize)
+
+	// The oldest entries were dropped, the largest ones are the newest.
+	entries := r.largest(3)
+	require.Equal(t, []uint64{recentEntriesSize + 9, recentEntriesSize + 8, recentEntriesSize + 7},
+		[]uint64{entries[0].Index, entries[1].Index, entries[2].Index})
+
+	smallest := r.largest(-1)[recentEntriesSize-1]
+	require.Equal(t, uint64(10), smallest.Index)
+}
@@ -59,6 +59,13 @@ type FSM struct {
 	state     *state.Store
 
 	publisher *stream.EventPublisher
+
+	// recent tracks the last entries applied, to expose the largest ones.
+	recent recentEntries
+
+	// lastPersist holds the stats of the last snapshot persisted.
+	lastPersistLock sync.Mutex
+	lastPersist     PersistStats
 }
 
 // New is used to construct a new FSM with a blank state.
@@ -139,6 +146,7 @@ func (c *FSM) Apply(log *raft.Log) interface{} {
 
 	// Apply based on the dispatch table, if possible.
 	if fn := c.apply[msgType]; fn != nil {
+		defer c.observeApply(msgType, log, time.Now())
 		return fn(buf[1:], log.Index)
 	}
 
@@ -164,9 +172,22 @@ func (c *FSM) Snapshot() (raft.FSMSnapshot, error) {
 	return &snapshot{
 		state:      c.state.Snapshot(),
 		chunkState: chunkState,
+		persisted: func(stats PersistStats) {
+			c.lastPersistLock.Lock()
+			c.lastPersist = stats
+			c.lastPersistLock.Unlock()
+		},
 	}, nil
 }
 
+// LastPersist returns the stats of the last snapshot persisted by the FSM,
+// or the zero value if there was none since the server started.
+func (c *FSM) LastPersist() PersistStats {
+	c.lastPersistLock.Lock()
+	defer c.lastPersistLock.Unlock()
+	return c.lastPersist
+}
+
 // Restore streams in the snapshot and replaces the current state store with a
 // new one based on the snapshot if all goes OK during the restore.
 func (c *FSM) Restore(old io.ReadCloser) error {
@@ -227,6 +248,7 @@ func (c *FSM) Restore(old io.ReadCloser) error {
 		c.deps.Publisher.RefreshTopic(state.EventTopicServiceHealth)
 		c.deps.Publisher.RefreshTopic(state.EventTopicServiceHealthConnect)
 		c.deps.Publisher.RefreshTopic(state.EventTopicCARoots)
+		c.deps.Publisher.RefreshTopic(state.EventTopicCARootsChange)
 	}
 	c.stateLock.Unlock()
 
@@ -295,4 +317,11 @@ func (c *FSM) registerStreamSnapshotHandlers() {
 	if err != nil {
 		panic(fmt.Errorf("fatal error encountered registering streaming snapshot handlers: %w", err))
 	}
+
+	err = c.deps.Publisher.RegisterHandler(state.EventTopicCARootsChange, func(req stream.SubscribeRequest, buf stream.SnapshotAppender) (uint64, error) {
+		return c.State().CARootsChangeSnapshot(req, buf)
+	})
+	if err != nil {
+		panic(fmt.Errorf("fatal error encountered registering streaming snapshot handlers: %w", err))
+	}
 }
@@ -26,6 +26,33 @@ var SnapshotSummaries = []prometheus.SummaryDefinition{
 type snapshot struct {
 	state      *state.Snapshot
 	chunkState *raftchunking.State
+
+	// persisted, if set, is called with the stats of a successful Persist.
+	persisted func(PersistStats)
+}
+
+// PersistStats describes the last FSM snapshot persisted to disk.
+type PersistStats struct {
+	// Bytes is the size of the snapshot.
+	Bytes int64
+
+	// Duration is how long it took to write the snapshot.
+	Duration time.Duration
+
+	// At is when the snapshot was persisted.
+	At time.Time
+}
+
+// countingSink counts the bytes written to a snapshot sink.
+type countingSink struct {
+	raft.SnapshotSink
+	n int64
+}
+
+func (s *countingSink) Write(p []byte) (int, error) {
+	n, err := s.SnapshotSink.Write(p)
+	s.n += int64(n)
+	return n, err
 }
 
 // SnapshotHeader is the first entry in our snapshot
@@ -67,7 +94,20 @@ func registerRestorer(msg structs.MessageType, fn restorer) {
 
 // Persist saves the FSM snapshot out to the given sink.
 func (s *snapshot) Persist(sink raft.SnapshotSink) error {
-	defer metrics.MeasureSince([]string{"fsm", "persist"}, time.Now())
+	start := time.Now()
+	defer metrics.MeasureSince([]string{"fsm", "persist"}, start)
+
+	counter := &countingSink{SnapshotSink: sink}
+	if err := s.persist(counter); err != nil {
+		return err
+	}
+	if s.persisted != nil {
+		s.persisted(PersistStats{Bytes: counter.n, Duration: time.Since(start), At: start})
+	}
+	return nil
+}
+
+func (s *snapshot) persist(sink raft.SnapshotSink) error {
 
 	// Write the header
 	header := SnapshotHeader{
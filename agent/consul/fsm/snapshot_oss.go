@@ -25,6 +25,8 @@ func init() {
 	registerRestorer(structs.ConnectCARequestType, restoreConnectCA)
 	registerRestorer(structs.ConnectCAProviderStateType, restoreConnectCAProviderState)
 	registerRestorer(structs.ConnectCAConfigType, restoreConnectCAConfig)
+	registerRestorer(structs.ConnectCAIssuedCertType, restoreConnectCAIssuedCert)
+	registerRestorer(structs.DeregistrationType, restoreDeregistration)
 	registerRestorer(structs.IndexRequestType, restoreIndex)
 	registerRestorer(structs.ACLTokenSetRequestType, restoreToken)
 	registerRestorer(structs.ACLPolicySetRequestType, restorePolicy)
@@ -38,6 +40,8 @@ func init() {
 	registerRestorer(structs.FreeVirtualIPRequestType, restoreFreeVirtualIP)
 	registerRestorer(structs.PeeringWriteType, restorePeering)
 	registerRestorer(structs.PeeringTrustBundleWriteType, restorePeeringTrustBundle)
+	registerRestorer(structs.PeeringIntentionsWriteType, restorePeeringIntentions)
+	registerRestorer(structs.ACLTokenUsageUpdateType, restoreACLTokenUsage)
 }
 
 func persistOSS(s *snapshot, sink raft.SnapshotSink, encoder *codec.Encoder) error {
@@ -77,6 +81,12 @@ func persistOSS(s *snapshot, sink raft.SnapshotSink, encoder *codec.Encoder) err
 	if err := s.persistConnectCAConfig(sink, encoder); err != nil {
 		return err
 	}
+	if err := s.persistConnectCAIssuedCerts(sink, encoder); err != nil {
+		return err
+	}
+	if err := s.persistDeregistrations(sink, encoder); err != nil {
+		return err
+	}
 	if err := s.persistConfigEntries(sink, encoder); err != nil {
 		return err
 	}
@@ -95,6 +105,12 @@ func persistOSS(s *snapshot, sink raft.SnapshotSink, encoder *codec.Encoder) err
 	if err := s.persistPeeringTrustBundles(sink, encoder); err != nil {
 		return err
 	}
+	if err := s.persistPeeringIntentions(sink, encoder); err != nil {
+		return err
+	}
+	if err := s.persistACLTokenUsage(sink, encoder); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -410,6 +426,42 @@ func (s *snapshot) persistConnectCAProviderState(sink raft.SnapshotSink,
 	return nil
 }
 
+func (s *snapshot) persistConnectCAIssuedCerts(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	certs, err := s.state.CAIssuedCerts()
+	if err != nil {
+		return err
+	}
+
+	for _, r := range certs {
+		if _, err := sink.Write([]byte{byte(structs.ConnectCAIssuedCertType)}); err != nil {
+			return err
+		}
+		if err := encoder.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *snapshot) persistDeregistrations(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	deregistrations, err := s.state.Deregistrations()
+	if err != nil {
+		return err
+	}
+
+	for _, d := range deregistrations {
+		if _, err := sink.Write([]byte{byte(structs.DeregistrationType)}); err != nil {
+			return err
+		}
+		if err := encoder.Encode(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *snapshot) persistLegacyIntentions(sink raft.SnapshotSink,
 	encoder *codec.Encoder) error {
 	//nolint:staticcheck
@@ -582,6 +634,42 @@ func (s *snapshot) persistPeeringTrustBundles(sink raft.SnapshotSink, encoder *c
 	return nil
 }
 
+func (s *snapshot) persistPeeringIntentions(sink raft.SnapshotSink, encoder *codec.Encoder) error {
+	pis, err := s.state.PeeringIntentions()
+	if err != nil {
+		return err
+	}
+
+	for entry := pis.Next(); entry != nil; entry = pis.Next() {
+		if _, err := sink.Write([]byte{byte(structs.PeeringIntentionsWriteType)}); err != nil {
+			return err
+		}
+		if err := encoder.Encode(entry.(*pbpeering.PeeringIntentions)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *snapshot) persistACLTokenUsage(sink raft.SnapshotSink, encoder *codec.Encoder) error {
+	entries, err := s.state.ACLTokenUsage()
+	if err != nil {
+		return err
+	}
+
+	for entry := entries.Next(); entry != nil; entry = entries.Next() {
+		if _, err := sink.Write([]byte{byte(structs.ACLTokenUsageUpdateType)}); err != nil {
+			return err
+		}
+		if err := encoder.Encode(entry.(*structs.ACLTokenUsageEntry)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func restoreRegistration(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.RegisterRequest
 	if err := decoder.Decode(&req); err != nil {
@@ -768,6 +856,28 @@ func restoreConnectCAProviderState(header *SnapshotHeader, restore *state.Restor
 	return nil
 }
 
+func restoreConnectCAIssuedCert(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+	var req structs.IssuedCertRecord
+	if err := decoder.Decode(&req); err != nil {
+		return err
+	}
+	if err := restore.CAIssuedCert(&req); err != nil {
+		return err
+	}
+	return nil
+}
+
+func restoreDeregistration(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+	var req structs.Deregistration
+	if err := decoder.Decode(&req); err != nil {
+		return err
+	}
+	if err := restore.Deregistration(&req); err != nil {
+		return err
+	}
+	return nil
+}
+
 func restoreConnectCAConfig(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.CAConfiguration
 	if err := decoder.Decode(&req); err != nil {
@@ -906,3 +1016,25 @@ func restorePeeringTrustBundle(header *SnapshotHeader, restore *state.Restore, d
 	}
 	return nil
 }
+
+func restorePeeringIntentions(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+	var req pbpeering.PeeringIntentions
+	if err := decoder.Decode(&req); err != nil {
+		return err
+	}
+	if err := restore.PeeringIntentions(&req); err != nil {
+		return err
+	}
+	return nil
+}
+
+func restoreACLTokenUsage(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+	var req structs.ACLTokenUsageEntry
+	if err := decoder.Decode(&req); err != nil {
+		return err
+	}
+	if err := restore.ACLTokenUsage(&req); err != nil {
+		return err
+	}
+	return nil
+}
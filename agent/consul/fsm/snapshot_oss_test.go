@@ -215,6 +215,19 @@ func TestFSM_SnapshotRestore_OSS(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, ok)
 
+	// Issued leaf certificates
+	require.NoError(t, fsm.state.CAIssuedCertSet(16, &structs.IssuedCertRecord{
+		SerialNumber: "01:02",
+		Service:      "web",
+		RootID:       roots[0].ID,
+		ValidAfter:   time.Now().Add(-time.Hour),
+		ValidBefore:  time.Now().Add(time.Hour),
+	}))
+
+	// Deregistration history
+	require.NoError(t, fsm.state.EnsureService(16, "baz", &structs.NodeService{ID: "gone", Service: "gone", Port: 80}))
+	require.NoError(t, fsm.state.DeleteServiceAndRecord(16, "baz", "gone", nil, "", &structs.Deregistration{DeregisteredBy: "accessor"}))
+
 	// CA Config
 	caConfig := &structs.CAConfiguration{
 		ClusterID: "foo",
@@ -486,6 +499,27 @@ func TestFSM_SnapshotRestore_OSS(t *testing.T) {
 		RootPEMs:    []string{"qux certificate bundle"},
 	}))
 
+	// Peering Intentions
+	require.NoError(t, fsm.state.PeeringIntentionsWrite(33, &pbpeering.PeeringIntentions{
+		PeerName:    "qux",
+		ServiceName: "api",
+		Sources: []*pbpeering.PeeringIntentionSource{
+			{Name: "web", Namespace: "default", Action: "allow", Precedence: 9},
+		},
+	}))
+
+	// ACL token usage
+	usage := structs.ACLTokenUsage{
+		LastUsed:     time.Date(2022, 6, 1, 9, 12, 45, 0, time.UTC),
+		RequestCount: 3,
+		Sources: []structs.ACLTokenUsageSource{
+			{Address: "10.0.0.1", LastUsed: time.Date(2022, 6, 1, 9, 12, 45, 0, time.UTC), RequestCount: 3},
+		},
+	}
+	require.NoError(t, fsm.state.ACLTokenUsageUpdate(34, map[string]structs.ACLTokenUsage{
+		token.AccessorID: usage,
+	}))
+
 	// Snapshot
 	snap, err := fsm.Snapshot()
 	require.NoError(t, err)
@@ -717,6 +751,20 @@ func TestFSM_SnapshotRestore_OSS(t *testing.T) {
 	require.Equal(t, "foo", provider.PrivateKey)
 	require.Equal(t, "bar", provider.RootCert)
 
+	// Verify issued certificate records are restored.
+	_, certs, err := fsm2.state.CAIssuedCerts(nil, time.Now())
+	require.NoError(t, err)
+	require.Len(t, certs, 1)
+	require.Equal(t, "01:02", certs[0].SerialNumber)
+	require.Equal(t, "web", certs[0].Service)
+
+	// Verify the deregistration history is restored.
+	_, deregistrations, err := fsm2.state.Deregistrations(nil)
+	require.NoError(t, err)
+	require.Len(t, deregistrations, 1)
+	require.Equal(t, "gone", deregistrations[0].ServiceID)
+	require.Equal(t, "accessor", deregistrations[0].DeregisteredBy)
+
 	// Verify CA configuration is restored.
 	_, caConf, err := fsm2.state.CAConfig(nil)
 	require.NoError(t, err)
@@ -802,6 +850,23 @@ func TestFSM_SnapshotRestore_OSS(t *testing.T) {
 	require.Len(t, ptbRestored.RootPEMs, 1)
 	require.Equal(t, "qux certificate bundle", ptbRestored.RootPEMs[0])
 
+	// Verify peering intentions are restored
+	idx, piRestored, err := fsm2.state.PeeringIntentionsRead(nil, structs.PeeredServiceName{
+		ServiceName: structs.NewServiceName("api", nil),
+		Peer:        "qux",
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(33), idx)
+	require.NotNil(t, piRestored)
+	require.Len(t, piRestored.Sources, 1)
+	require.Equal(t, "web", piRestored.Sources[0].Name)
+
+	// Verify ACL token usage is restored
+	idx, usageRestored, err := fsm2.state.ACLTokenUsageGet(nil, token.AccessorID)
+	require.NoError(t, err)
+	require.Equal(t, uint64(34), idx)
+	require.Equal(t, &usage, usageRestored)
+
 	// Snapshot
 	snap, err = fsm2.Snapshot()
 	require.NoError(t, err)
@@ -3,6 +3,7 @@ package consul
 import (
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/armon/go-metrics"
 	bexpr "github.com/hashicorp/go-bexpr"
@@ -42,7 +43,22 @@ func (h *Health) ChecksInState(args *structs.ChecksInStateRequest,
 		return err
 	}
 
-	return h.srv.blockingQuery(
+	keyArgs := *args
+	keyArgs.Token = ""
+	keyArgs.MinQueryIndex = 0
+	coalesceKey, err := coalescedQueryKeyFor("Health.ChecksInState", &keyArgs)
+	if err != nil {
+		return err
+	}
+
+	// Note: the results are filtered with ACLs once the query returns, *after*
+	// applying the user-supplied bexpr filter, to ensure
+	// QueryMeta.ResultsFilteredByACLs does not include results that would be
+	// filtered out even if the user did have permission.
+	return h.srv.coalescedBlockingQuery(
+		coalesceKey,
+		args.Token,
+		reply,
 		&args.QueryOptions,
 		&reply.QueryMeta,
 		func(ws memdb.WatchSet, state *state.Store) error {
@@ -65,13 +81,6 @@ func (h *Health) ChecksInState(args *structs.ChecksInStateRequest,
 			}
 			reply.HealthChecks = raw.(structs.HealthChecks)
 
-			// Note: we filter the results with ACLs *after* applying the user-supplied
-			// bexpr filter, to ensure QueryMeta.ResultsFilteredByACLs does not include
-			// results that would be filtered out even if the user did have permission.
-			if err := h.srv.filterACL(args.Token, reply); err != nil {
-				return err
-			}
-
 			return h.srv.sortNodesByDistanceFrom(args.Source, reply.HealthChecks)
 		})
 }
@@ -97,7 +106,22 @@ func (h *Health) NodeChecks(args *structs.NodeSpecificRequest,
 		return err
 	}
 
-	return h.srv.blockingQuery(
+	keyArgs := *args
+	keyArgs.Token = ""
+	keyArgs.MinQueryIndex = 0
+	coalesceKey, err := coalescedQueryKeyFor("Health.NodeChecks", &keyArgs)
+	if err != nil {
+		return err
+	}
+
+	// Note: the results are filtered with ACLs once the query returns, *after*
+	// applying the user-supplied bexpr filter, to ensure
+	// QueryMeta.ResultsFilteredByACLs does not include results that would be
+	// filtered out even if the user did have permission.
+	return h.srv.coalescedBlockingQuery(
+		coalesceKey,
+		args.Token,
+		reply,
 		&args.QueryOptions,
 		&reply.QueryMeta,
 		func(ws memdb.WatchSet, state *state.Store) error {
@@ -113,13 +137,6 @@ func (h *Health) NodeChecks(args *structs.NodeSpecificRequest,
 			}
 			reply.HealthChecks = raw.(structs.HealthChecks)
 
-			// Note: we filter the results with ACLs *after* applying the user-supplied
-			// bexpr filter, to ensure QueryMeta.ResultsFilteredByACLs does not include
-			// results that would be filtered out even if the user did have permission.
-			if err := h.srv.filterACL(args.Token, reply); err != nil {
-				return err
-			}
-
 			return nil
 		})
 }
@@ -152,7 +169,22 @@ func (h *Health) ServiceChecks(args *structs.ServiceSpecificRequest,
 		return err
 	}
 
-	return h.srv.blockingQuery(
+	keyArgs := *args
+	keyArgs.Token = ""
+	keyArgs.MinQueryIndex = 0
+	coalesceKey, err := coalescedQueryKeyFor("Health.ServiceChecks", &keyArgs)
+	if err != nil {
+		return err
+	}
+
+	// Note: the results are filtered with ACLs once the query returns, *after*
+	// applying the user-supplied bexpr filter, to ensure
+	// QueryMeta.ResultsFilteredByACLs does not include results that would be
+	// filtered out even if the user did have permission.
+	return h.srv.coalescedBlockingQuery(
+		coalesceKey,
+		args.Token,
+		reply,
 		&args.QueryOptions,
 		&reply.QueryMeta,
 		func(ws memdb.WatchSet, state *state.Store) error {
@@ -175,13 +207,6 @@ func (h *Health) ServiceChecks(args *structs.ServiceSpecificRequest,
 			}
 			reply.HealthChecks = raw.(structs.HealthChecks)
 
-			// Note: we filter the results with ACLs *after* applying the user-supplied
-			// bexpr filter, to ensure QueryMeta.ResultsFilteredByACLs does not include
-			// results that would be filtered out even if the user did have permission.
-			if err := h.srv.filterACL(args.Token, reply); err != nil {
-				return err
-			}
-
 			return h.srv.sortNodesByDistanceFrom(args.Source, reply.HealthChecks)
 		})
 }
@@ -235,12 +260,25 @@ func (h *Health) ServiceNodes(args *structs.ServiceSpecificRequest, reply *struc
 		return err
 	}
 
+	// Identical blocking queries, typically from the many agents watching a
+	// service, share a single result.
+	keyArgs := *args
+	keyArgs.Token = ""
+	keyArgs.MinQueryIndex = 0
+	coalesceKey, err := coalescedQueryKeyFor("Health.ServiceNodes", &keyArgs)
+	if err != nil {
+		return err
+	}
+
 	var (
 		priorMergeHash uint64
 		ranMergeOnce   bool
 	)
 
-	err = h.srv.blockingQuery(
+	err = h.srv.coalescedBlockingQuery(
+		coalesceKey,
+		args.Token,
+		reply,
 		&args.QueryOptions,
 		&reply.QueryMeta,
 		func(ws memdb.WatchSet, state *state.Store) error {
@@ -299,15 +337,16 @@ func (h *Health) ServiceNodes(args *structs.ServiceSpecificRequest, reply *struc
 			}
 			thisReply.Nodes = raw.(structs.CheckServiceNodes)
 
-			// Note: we filter the results with ACLs *after* applying the user-supplied
-			// bexpr filter, to ensure QueryMeta.ResultsFilteredByACLs does not include
-			// results that would be filtered out even if the user did have permission.
-			if err := h.srv.filterACL(args.Token, &thisReply); err != nil {
+			// Note: the results are filtered with ACLs once the query returns,
+			// *after* applying the user-supplied bexpr filter, to ensure
+			// QueryMeta.ResultsFilteredByACLs does not include results that
+			// would be filtered out even if the user did have permission.
+			if err := h.srv.sortNodesByDistanceFrom(args.Source, thisReply.Nodes); err != nil {
 				return err
 			}
 
-			if err := h.srv.sortNodesByDistanceFrom(args.Source, thisReply.Nodes); err != nil {
-				return err
+			if args.ProxyStatus {
+				setProxyStatus(thisReply.Nodes, time.Now())
 			}
 
 			*reply = thisReply
@@ -377,3 +416,14 @@ func (h *Health) serviceNodesTagFilter(ws memdb.WatchSet, s *state.Store, args *
 func (h *Health) serviceNodesDefault(ws memdb.WatchSet, s *state.Store, args *structs.ServiceSpecificRequest) (uint64, structs.CheckServiceNodes, error) {
 	return s.CheckServiceNodes(ws, args.ServiceName, &args.EnterpriseMeta, args.PeerName)
 }
+
+// setProxyStatus derives the status of the connect proxy instances from the
+// reports of their agents, carried by a check of the proxy service.
+func setProxyStatus(nodes structs.CheckServiceNodes, now time.Time) {
+	for i := range nodes {
+		if svc := nodes[i].Service; svc != nil && svc.Kind == structs.ServiceKindConnectProxy {
+			report := structs.ProxyStatusReportFromChecks(svc, nodes[i].Checks)
+			nodes[i].ProxyStatus = report.Status(now)
+		}
+	}
+}
@@ -1135,6 +1135,70 @@ node "foo" {
 	assert.Len(t, resp.Nodes, 1)
 }
 
+func TestHealth_ServiceNodes_ProxyStatus(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+
+	var out struct{}
+	validBefore := time.Now().Add(time.Hour)
+	{
+		// A proxy which reported its status
+		args := structs.TestRegisterRequestProxy(t)
+		args.Service.ID = "web-proxy-1"
+		report := &structs.ProxyStatusReport{
+			XDSConnected:    true,
+			ConfigAccepted:  true,
+			CertValidBefore: validBefore,
+		}
+		check, err := report.HealthCheck(args.Service)
+		require.NoError(t, err)
+		args.Check = check
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "Catalog.Register", &args, &out))
+
+		// A proxy which didn't
+		args = structs.TestRegisterRequestProxy(t)
+		args.Service.ID = "web-proxy-2"
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "Catalog.Register", &args, &out))
+	}
+
+	req := structs.ServiceSpecificRequest{
+		Datacenter:  "dc1",
+		ServiceName: "web-proxy",
+	}
+	var resp structs.IndexedCheckServiceNodes
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Health.ServiceNodes", &req, &resp))
+	require.Len(t, resp.Nodes, 2)
+	for _, node := range resp.Nodes {
+		require.Nil(t, node.ProxyStatus)
+	}
+
+	req.ProxyStatus = true
+	resp = structs.IndexedCheckServiceNodes{}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Health.ServiceNodes", &req, &resp))
+	require.Len(t, resp.Nodes, 2)
+
+	statuses := make(map[string]*structs.ProxyStatus)
+	for _, node := range resp.Nodes {
+		statuses[node.Service.ID] = node.ProxyStatus
+	}
+	require.Equal(t, map[string]*structs.ProxyStatus{
+		"web-proxy-1": {XDSConnected: true, ConfigAccepted: true, CertValid: true, Ready: true},
+		"web-proxy-2": {},
+	}, statuses)
+}
+
 func TestHealth_ServiceNodes_Gateway(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
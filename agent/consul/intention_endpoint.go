@@ -3,6 +3,8 @@ package consul
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"time"
 
 	"github.com/armon/go-metrics"
@@ -13,6 +15,7 @@ import (
 	hashstructure_v2 "github.com/mitchellh/hashstructure/v2"
 
 	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/consul/state"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/lib"
@@ -21,11 +24,15 @@ import (
 var IntentionSummaries = []prometheus.SummaryDefinition{
 	{
 		Name: []string{"consul", "intention", "apply"},
-		Help: "",
+		Help: "Deprecated - use intention_apply instead",
 	},
 	{
 		Name: []string{"intention", "apply"},
-		Help: "",
+		Help: "Measures the time it takes to complete an update to the intention store.",
+	},
+	{
+		Name: []string{"intention", "bulk_apply"},
+		Help: "Measures the time it takes to replace the intentions of a destination.",
 	},
 }
 
@@ -556,11 +563,42 @@ func (s *Intention) List(args *structs.IntentionListRequest, reply *structs.Inde
 				return err
 			}
 
+			if isPaginated(&args.QueryOptions) {
+				sort.Sort(structs.IntentionPrecedenceSorter(reply.Intentions))
+				start, end, err := paginate(&args.QueryOptions, &reply.QueryMeta, s.srv.config.MaxPerPage, len(reply.Intentions), func(i int) []string {
+					return intentionPageKey(reply.Intentions[i])
+				})
+				if err != nil {
+					return err
+				}
+				reply.Intentions = reply.Intentions[start:end]
+			}
+
 			return nil
 		},
 	)
 }
 
+// intentionPageKey returns the fields the pages of intentions are ordered by.
+// They match the order of structs.IntentionPrecedenceSorter, the precedence
+// is inverted and padded so that higher precedences sort first.
+func intentionPageKey(ixn *structs.Intention) []string {
+	return []string{
+		fmt.Sprintf("%05d", intentionMaxPagePrecedence-ixn.Precedence),
+		ixn.SourcePeer,
+		ixn.SourcePartition,
+		ixn.SourceNS,
+		ixn.SourceName,
+		ixn.DestinationPartition,
+		ixn.DestinationNS,
+		ixn.DestinationName,
+	}
+}
+
+// intentionMaxPagePrecedence is larger than any precedence computed by
+// structs.Intention.UpdatePrecedence.
+const intentionMaxPagePrecedence = 99999
+
 // Match returns the set of intentions that match the given source/destination.
 func (s *Intention) Match(args *structs.IntentionQueryRequest, reply *structs.IndexedIntentionMatches) error {
 	// Exit early if Connect hasn't been enabled.
@@ -743,6 +781,28 @@ func (s *Intention) Check(args *structs.IntentionQueryRequest, reply *structs.In
 		}
 	}
 
+	store := s.srv.fsm.State()
+
+	// Services imported from a peer are authorized by the peer. Answer from
+	// the decisions it replicated to us, so that the check keeps working
+	// while the peer's servers are unreachable.
+	if query.DestinationPeer != "" {
+		destEntMeta := acl.NewEnterpriseMetaWithPartition(query.DestinationPartition, query.DestinationNS)
+		psn := structs.PeeredServiceName{
+			Peer:        query.DestinationPeer,
+			ServiceName: structs.NewServiceName(query.DestinationName, &destEntMeta),
+		}
+		_, pi, err := store.PeeringIntentionsRead(nil, psn)
+		if err != nil {
+			return fmt.Errorf("failed to read intentions imported from peer %q: %w", query.DestinationPeer, err)
+		}
+		if pi == nil {
+			return fmt.Errorf("no intentions were imported for service %q from peer %q", query.DestinationName, query.DestinationPeer)
+		}
+		reply.Allowed, _ = pi.Decision(query.SourceName, query.SourceNS, false)
+		return nil
+	}
+
 	// Note: the default intention policy is like an intention with a
 	// wildcarded destination in that it is limited to L4-only.
 
@@ -757,8 +817,6 @@ func (s *Intention) Check(args *structs.IntentionQueryRequest, reply *structs.In
 	// which is much more important.
 	defaultDecision := authz.IntentionDefaultAllow(nil)
 
-	store := s.srv.fsm.State()
-
 	entry := structs.IntentionMatchEntry{
 		Namespace: query.SourceNS,
 		Partition: query.SourcePartition,
@@ -788,6 +846,307 @@ func (s *Intention) Check(args *structs.IntentionQueryRequest, reply *structs.In
 	return nil
 }
 
+// BulkApply replaces all the intentions of a destination with the ones of a
+// service-intentions config entry in a single write, and reports the
+// intentions it creates, updates and deletes. In preview mode the changes are
+// only reported.
+func (s *Intention) BulkApply(args *structs.IntentionBulkApplyRequest, reply *structs.IntentionBulkApplyResponse) error {
+	// Exit early if Connect hasn't been enabled.
+	if !s.srv.config.ConnectEnabled {
+		return ErrConnectNotEnabled
+	}
+
+	if args.Entry == nil {
+		return fmt.Errorf("Must provide the intentions of a destination")
+	}
+
+	// Ensure that all service-intentions config entry writes go to the primary
+	// datacenter. These will then be replicated to all the other datacenters.
+	args.Datacenter = s.srv.config.PrimaryDatacenter
+
+	if done, err := s.srv.ForwardRPC("Intention.BulkApply", args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"intention", "bulk_apply"}, time.Now())
+
+	if err := s.legacyUpgradeCheck(); err != nil {
+		return err
+	}
+
+	entry := args.Entry
+	authz, err := s.srv.ResolveTokenAndDefaultMeta(args.Token, &entry.EnterpriseMeta, nil)
+	if err != nil {
+		return err
+	}
+	if err := s.srv.validateEnterpriseRequest(&entry.EnterpriseMeta, true); err != nil {
+		return err
+	}
+
+	if err := entry.Normalize(); err != nil {
+		return err
+	}
+	if len(entry.Sources) > 0 {
+		if err := entry.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := entry.CanWrite(authz); err != nil {
+		s.logger.Warn("Intention bulk apply denied due to ACLs",
+			"destination", entry.DestinationServiceName().String(),
+			"accessorID", authz.AccessorID())
+		return err
+	}
+
+	_, raw, err := s.srv.fsm.State().ConfigEntry(nil, structs.ServiceIntentions, entry.Name, &entry.EnterpriseMeta)
+	if err != nil {
+		return fmt.Errorf("Intention lookup failed: %v", err)
+	}
+	prev, _ := raw.(*structs.ServiceIntentionsConfigEntry)
+
+	diffIntentionSources(prev, entry, reply)
+	if args.Preview || (len(reply.Created) == 0 && len(reply.Updated) == 0 && len(reply.Deleted) == 0) {
+		return nil
+	}
+
+	// The write only goes through if the intentions didn't change since they
+	// were diffed, so that the reported changes are the ones applied.
+	req := &structs.ConfigEntryRequest{
+		Datacenter: args.Datacenter,
+		Op:         structs.ConfigEntryUpsertCAS,
+		Entry:      entry,
+	}
+	entry.ModifyIndex = 0
+	if prev != nil {
+		entry.ModifyIndex = prev.ModifyIndex
+	}
+	if len(entry.Sources) == 0 {
+		req.Op = structs.ConfigEntryDeleteCAS
+	}
+
+	resp, err := s.srv.raftApply(structs.ConfigEntryRequestType, req)
+	if err != nil {
+		return err
+	}
+	if applied, ok := resp.(bool); !ok || !applied {
+		return fmt.Errorf("intentions of %q were modified concurrently, retry the request", entry.Name)
+	}
+	reply.Applied = true
+	return nil
+}
+
+// intentionSourceKey identifies a source of a service-intentions config
+// entry.
+type intentionSourceKey struct {
+	peer string
+	name structs.ServiceName
+}
+
+// diffIntentionSources fills reply with the intentions created, updated and
+// deleted when the sources of prev, which may be nil, are replaced by the
+// ones of next.
+func diffIntentionSources(prev, next *structs.ServiceIntentionsConfigEntry, reply *structs.IntentionBulkApplyResponse) {
+	prevSources := make(map[intentionSourceKey]*structs.SourceIntention)
+	if prev != nil {
+		for _, src := range prev.Sources {
+			prevSources[intentionSourceKey{peer: src.Peer, name: src.SourceServiceName()}] = src
+		}
+	}
+
+	reply.Created = make(structs.Intentions, 0)
+	reply.Updated = make(structs.Intentions, 0)
+	reply.Deleted = make(structs.Intentions, 0)
+	for _, src := range next.Sources {
+		key := intentionSourceKey{peer: src.Peer, name: src.SourceServiceName()}
+		prevSrc, ok := prevSources[key]
+		delete(prevSources, key)
+		switch {
+		case !ok:
+			reply.Created = append(reply.Created, next.ToIntention(src))
+		case !sourceIntentionsEqual(prevSrc, src):
+			reply.Updated = append(reply.Updated, next.ToIntention(src))
+		default:
+			reply.Unchanged++
+		}
+	}
+	if prev != nil {
+		for _, src := range prev.Sources {
+			if _, ok := prevSources[intentionSourceKey{peer: src.Peer, name: src.SourceServiceName()}]; ok {
+				reply.Deleted = append(reply.Deleted, prev.ToIntention(src))
+			}
+		}
+	}
+}
+
+// sourceIntentionsEqual returns whether the two sources grant the same
+// access, ignoring the fields computed by Consul.
+func sourceIntentionsEqual(a, b *structs.SourceIntention) bool {
+	a, b = a.Clone(), b.Clone()
+	for _, src := range []*structs.SourceIntention{a, b} {
+		src.Precedence = 0
+		src.LegacyID = ""
+		src.LegacyMeta = nil
+		src.LegacyCreateTime = nil
+		src.LegacyUpdateTime = nil
+		if len(src.Permissions) == 0 {
+			src.Permissions = nil
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// maxIntentionSimulateFlows is the maximum number of flows of a simulation.
+const maxIntentionSimulateFlows = 10000
+
+// Simulate reports the flows of a traffic sample that would be denied or
+// allowed by a proposed change of the intentions, without applying it.
+func (s *Intention) Simulate(args *structs.IntentionSimulateRequest, reply *structs.IntentionSimulateResponse) error {
+	// Exit early if Connect hasn't been enabled.
+	if !s.srv.config.ConnectEnabled {
+		return ErrConnectNotEnabled
+	}
+
+	if done, err := s.srv.ForwardRPC("Intention.Simulate", args, reply); done {
+		return err
+	}
+
+	if len(args.Flows) > maxIntentionSimulateFlows {
+		return fmt.Errorf("cannot simulate more than %d flows", maxIntentionSimulateFlows)
+	}
+
+	var entMeta acl.EnterpriseMeta
+	authz, err := s.srv.ResolveTokenAndDefaultMeta(args.Token, &entMeta, nil)
+	if err != nil {
+		return err
+	}
+
+	// The simulation discloses which intentions apply to the destinations of
+	// the flows and of the entries, so the token must be able to read them.
+	for i := range args.Flows {
+		flow := &args.Flows[i]
+		if flow.SourceName == "" || flow.DestinationName == "" {
+			return fmt.Errorf("flow %d must have a source and a destination", i)
+		}
+		if flow.SourceNS == "" {
+			flow.SourceNS = entMeta.NamespaceOrDefault()
+		}
+		if flow.DestinationNS == "" {
+			flow.DestinationNS = entMeta.NamespaceOrDefault()
+		}
+		if flow.DestinationPartition == "" {
+			flow.DestinationPartition = entMeta.PartitionOrEmpty()
+		}
+		if flow.SourcePartition == "" {
+			flow.SourcePartition = entMeta.PartitionOrEmpty()
+		}
+
+		var authzContext acl.AuthorizerContext
+		dstMeta := acl.NewEnterpriseMetaWithPartition(flow.DestinationPartition, flow.DestinationNS)
+		dstMeta.FillAuthzContext(&authzContext)
+		if err := authz.ToAllowAuthorizer().IntentionReadAllowed(flow.DestinationName, &authzContext); err != nil {
+			return err
+		}
+	}
+
+	proposed := make(map[structs.ServiceName]structs.Intentions, len(args.Entries))
+	for _, entry := range args.Entries {
+		if err := entry.Normalize(); err != nil {
+			return fmt.Errorf("invalid intentions for %q: %v", entry.Name, err)
+		}
+		if err := entry.Validate(); err != nil {
+			return fmt.Errorf("invalid intentions for %q: %v", entry.Name, err)
+		}
+
+		var authzContext acl.AuthorizerContext
+		entry.FillAuthzContext(&authzContext)
+		if err := authz.ToAllowAuthorizer().IntentionReadAllowed(entry.Name, &authzContext); err != nil {
+			return err
+		}
+		proposed[entry.DestinationServiceName()] = entry.ToIntentions()
+	}
+
+	store := s.srv.fsm.State()
+	index, current, _, err := store.Intentions(nil, acl.WildcardEnterpriseMeta())
+	if err != nil {
+		return err
+	}
+
+	// Replace the intentions of the destinations of the entries.
+	var next structs.Intentions
+	for _, ixn := range current {
+		if _, ok := proposed[ixn.DestinationServiceName()]; !ok {
+			next = append(next, ixn)
+		}
+	}
+	for _, ixns := range proposed {
+		next = append(next, ixns...)
+	}
+	sort.Sort(structs.IntentionPrecedenceSorter(next))
+
+	reply.Index = index
+	reply.DefaultAllow = authz.IntentionDefaultAllow(nil) == acl.Allow
+	reply.ProposedDefaultAllow = reply.DefaultAllow
+	if args.DefaultAllow != nil {
+		reply.ProposedDefaultAllow = *args.DefaultAllow
+	}
+	reply.NewlyDenied = make([]*structs.IntentionSimulateResult, 0)
+	reply.NewlyAllowed = make([]*structs.IntentionSimulateResult, 0)
+
+	for _, flow := range args.Flows {
+		result := &structs.IntentionSimulateResult{Flow: flow}
+		result.Allowed, result.Intention = simulateIntentionDecision(current, flow, reply.DefaultAllow)
+		result.ProposedAllowed, result.ProposedIntention = simulateIntentionDecision(next, flow, reply.ProposedDefaultAllow)
+
+		switch {
+		case result.Allowed && !result.ProposedAllowed:
+			reply.NewlyDenied = append(reply.NewlyDenied, result)
+		case !result.Allowed && result.ProposedAllowed:
+			reply.NewlyAllowed = append(reply.NewlyAllowed, result)
+		default:
+			reply.Unchanged++
+		}
+	}
+	sortIntentionSimulateResults(reply.NewlyDenied)
+	sortIntentionSimulateResults(reply.NewlyAllowed)
+
+	return nil
+}
+
+// simulateIntentionDecision returns whether the flow is allowed by the
+// intentions, which must be sorted by precedence, and the intention deciding
+// it if any. Intentions with L7 permissions allow the connection since the
+// requests are then authorized by the permissions.
+func simulateIntentionDecision(ixns structs.Intentions, flow structs.IntentionSimulateFlow, defaultAllow bool) (bool, *structs.ExportedIntention) {
+	for _, ixn := range ixns {
+		if ixn.SourcePeer != flow.SourcePeer {
+			continue
+		}
+		if _, ok := connect.AuthorizeIntentionTarget(flow.SourceName, flow.SourceNS, flow.SourcePartition, ixn, structs.IntentionMatchSource); !ok {
+			continue
+		}
+		allowed, ok := connect.AuthorizeIntentionTarget(flow.DestinationName, flow.DestinationNS, flow.DestinationPartition, ixn, structs.IntentionMatchDestination)
+		if !ok {
+			continue
+		}
+		return allowed || len(ixn.Permissions) > 0, structs.NewExportedIntention(ixn)
+	}
+	return defaultAllow, nil
+}
+
+// sortIntentionSimulateResults sorts the results by decreasing count of the
+// flows, and then by source and destination.
+func sortIntentionSimulateResults(results []*structs.IntentionSimulateResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i].Flow, results[j].Flow
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.SourceName != b.SourceName {
+			return a.SourceName < b.SourceName
+		}
+		return a.DestinationName < b.DestinationName
+	})
+}
+
 func (s *Intention) validateEnterpriseIntention(ixn *structs.Intention) error {
 	if err := s.srv.validateEnterpriseIntentionPartition(ixn.SourcePartition); err != nil {
 		return fmt.Errorf("Invalid source partition %q: %v", ixn.SourcePartition, err)
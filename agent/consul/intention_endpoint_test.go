@@ -12,6 +12,7 @@ import (
 
 	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbpeering"
 	"github.com/hashicorp/consul/sdk/testutil"
 )
 
@@ -2141,6 +2142,59 @@ func TestIntentionCheck_match(t *testing.T) {
 	}
 }
 
+func TestIntentionCheck_peerDestination(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	waitForLeaderEstablishment(t, s1)
+
+	// Intentions replicated by the peer exporting "api".
+	require.NoError(t, s1.fsm.State().PeeringIntentionsWrite(100, &pbpeering.PeeringIntentions{
+		PeerName:    "my-peer",
+		ServiceName: "api",
+		Sources: []*pbpeering.PeeringIntentionSource{
+			{Name: "web", Namespace: "default", Action: "allow"},
+			{Name: "*", Namespace: "default", Action: "deny"},
+		},
+		DefaultAllow: true,
+	}))
+
+	check := func(t *testing.T, source, destination string) (bool, error) {
+		req := &structs.IntentionQueryRequest{
+			Datacenter: "dc1",
+			Check: &structs.IntentionQueryCheck{
+				SourceName:      source,
+				DestinationName: destination,
+				DestinationPeer: "my-peer",
+				SourceType:      structs.IntentionSourceConsul,
+			},
+		}
+		var resp structs.IntentionQueryCheckResponse
+		err := msgpackrpc.CallWithCodec(codec, "Intention.Check", req, &resp)
+		return resp.Allowed, err
+	}
+
+	allowed, err := check(t, "web", "api")
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	allowed, err = check(t, "db", "api")
+	require.NoError(t, err)
+	require.False(t, allowed)
+
+	_, err = check(t, "web", "unknown")
+	testutil.RequireErrorContains(t, err, `no intentions were imported for service "unknown" from peer "my-peer"`)
+}
+
 func TestEqualStringMaps(t *testing.T) {
 	m1 := map[string]string{
 		"foo": "a",
@@ -2181,3 +2235,264 @@ func TestEqualStringMaps(t *testing.T) {
 		}
 	}
 }
+
+func TestIntentionSimulate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	waitForLeaderEstablishment(t, s1)
+
+	{
+		args := structs.ConfigEntryRequest{
+			Datacenter: "dc1",
+			Entry: &structs.ServiceIntentionsConfigEntry{
+				Kind: structs.ServiceIntentions,
+				Name: "db",
+				Sources: []*structs.SourceIntention{
+					{Name: "web", Action: structs.IntentionActionAllow},
+					{Name: "legacy", Action: structs.IntentionActionDeny},
+				},
+			},
+		}
+		var out bool
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &args, &out))
+	}
+
+	// Migrate to default deny, and only allow legacy to call the v2 API.
+	defaultAllow := false
+	req := &structs.IntentionSimulateRequest{
+		Datacenter: "dc1",
+		Flows: []structs.IntentionSimulateFlow{
+			{SourceName: "web", DestinationName: "db", Count: 10},
+			{SourceName: "api", DestinationName: "db", Count: 5},
+			{SourceName: "legacy", DestinationName: "db", Count: 1},
+			{SourceName: "api", DestinationName: "cache", Count: 20},
+		},
+		Entries: []*structs.ServiceIntentionsConfigEntry{
+			{
+				Kind: structs.ServiceIntentions,
+				Name: "db",
+				Sources: []*structs.SourceIntention{
+					{Name: "web", Action: structs.IntentionActionAllow},
+					{
+						Name: "legacy",
+						Permissions: []*structs.IntentionPermission{{
+							Action: structs.IntentionActionAllow,
+							HTTP:   &structs.IntentionHTTPPermission{PathPrefix: "/v2"},
+						}},
+					},
+				},
+			},
+		},
+		DefaultAllow: &defaultAllow,
+	}
+	var resp structs.IntentionSimulateResponse
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Intention.Simulate", req, &resp))
+
+	require.True(t, resp.DefaultAllow)
+	require.False(t, resp.ProposedDefaultAllow)
+	require.Equal(t, 1, resp.Unchanged)
+
+	require.Len(t, resp.NewlyDenied, 2)
+	require.Equal(t, "cache", resp.NewlyDenied[0].Flow.DestinationName)
+	require.Nil(t, resp.NewlyDenied[0].Intention)
+	require.Nil(t, resp.NewlyDenied[0].ProposedIntention)
+	require.Equal(t, "api", resp.NewlyDenied[1].Flow.SourceName)
+	require.Equal(t, "db", resp.NewlyDenied[1].Flow.DestinationName)
+
+	require.Len(t, resp.NewlyAllowed, 1)
+	allowed := resp.NewlyAllowed[0]
+	require.Equal(t, "legacy", allowed.Flow.SourceName)
+	require.False(t, allowed.Allowed)
+	require.True(t, allowed.ProposedAllowed)
+	require.Equal(t, structs.IntentionActionDeny, allowed.Intention.Action)
+	require.Len(t, allowed.ProposedIntention.Permissions, 1)
+
+	// The intentions are unchanged.
+	var ixns structs.IndexedIntentions
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Intention.List", &structs.IntentionListRequest{Datacenter: "dc1"}, &ixns))
+	require.Len(t, ixns.Intentions, 2)
+	for _, ixn := range ixns.Intentions {
+		require.Empty(t, ixn.Permissions)
+	}
+}
+
+func TestIntentionSimulate_ACLDeny(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.PrimaryDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLInitialManagementToken = "root"
+		c.ACLResolverSettings.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	waitForLeaderEstablishment(t, s1)
+
+	req := &structs.IntentionSimulateRequest{
+		Datacenter: "dc1",
+		Flows:      []structs.IntentionSimulateFlow{{SourceName: "web", DestinationName: "db"}},
+	}
+	var resp structs.IntentionSimulateResponse
+	err := msgpackrpc.CallWithCodec(codec, "Intention.Simulate", req, &resp)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	req.Token = "root"
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Intention.Simulate", req, &resp))
+	require.False(t, resp.DefaultAllow)
+	require.Equal(t, 1, resp.Unchanged)
+}
+
+func TestIntentionBulkApply(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	waitForLeaderEstablishment(t, s1)
+
+	{
+		args := structs.ConfigEntryRequest{
+			Datacenter: "dc1",
+			Entry: &structs.ServiceIntentionsConfigEntry{
+				Kind: structs.ServiceIntentions,
+				Name: "db",
+				Sources: []*structs.SourceIntention{
+					{Name: "web", Action: structs.IntentionActionAllow},
+					{Name: "api", Action: structs.IntentionActionAllow},
+					{Name: "legacy", Action: structs.IntentionActionAllow},
+				},
+			},
+		}
+		var out bool
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &args, &out))
+	}
+
+	sourceNames := func(ixns structs.Intentions) []string {
+		names := make([]string, 0, len(ixns))
+		for _, ixn := range ixns {
+			names = append(names, ixn.SourceName)
+		}
+		return names
+	}
+	bulkApply := func(preview bool) *structs.IntentionBulkApplyResponse {
+		req := &structs.IntentionBulkApplyRequest{
+			Datacenter: "dc1",
+			Preview:    preview,
+			Entry: &structs.ServiceIntentionsConfigEntry{
+				Kind: structs.ServiceIntentions,
+				Name: "db",
+				Sources: []*structs.SourceIntention{
+					{Name: "web", Action: structs.IntentionActionAllow},
+					{Name: "api", Action: structs.IntentionActionDeny},
+					{Name: "batch", Action: structs.IntentionActionAllow},
+				},
+			},
+		}
+		var resp structs.IntentionBulkApplyResponse
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "Intention.BulkApply", req, &resp))
+		return &resp
+	}
+	currentSources := func() []string {
+		_, entry, err := s1.fsm.State().ConfigEntry(nil, structs.ServiceIntentions, "db", nil)
+		require.NoError(t, err)
+		if entry == nil {
+			return nil
+		}
+		var names []string
+		for _, src := range entry.(*structs.ServiceIntentionsConfigEntry).Sources {
+			names = append(names, src.Name+"="+string(src.Action))
+		}
+		return names
+	}
+
+	// The preview reports the changes without applying them.
+	resp := bulkApply(true)
+	require.False(t, resp.Applied)
+	require.Equal(t, []string{"batch"}, sourceNames(resp.Created))
+	require.Equal(t, []string{"api"}, sourceNames(resp.Updated))
+	require.Equal(t, []string{"legacy"}, sourceNames(resp.Deleted))
+	require.Equal(t, 1, resp.Unchanged)
+	require.ElementsMatch(t, []string{"web=allow", "api=allow", "legacy=allow"}, currentSources())
+
+	resp = bulkApply(false)
+	require.True(t, resp.Applied)
+	require.Equal(t, []string{"batch"}, sourceNames(resp.Created))
+	require.ElementsMatch(t, []string{"web=allow", "api=deny", "batch=allow"}, currentSources())
+
+	// Applying the same set again changes nothing.
+	resp = bulkApply(false)
+	require.False(t, resp.Applied)
+	require.Equal(t, 3, resp.Unchanged)
+
+	// An empty set deletes all the intentions of the destination.
+	req := &structs.IntentionBulkApplyRequest{
+		Datacenter: "dc1",
+		Entry:      &structs.ServiceIntentionsConfigEntry{Name: "db"},
+	}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Intention.BulkApply", req, resp))
+	require.True(t, resp.Applied)
+	require.Len(t, resp.Deleted, 3)
+	require.Nil(t, currentSources())
+}
+
+func TestIntentionBulkApply_ACLDeny(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.PrimaryDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLInitialManagementToken = "root"
+		c.ACLResolverSettings.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	waitForLeaderEstablishment(t, s1)
+
+	req := &structs.IntentionBulkApplyRequest{
+		Datacenter: "dc1",
+		Entry: &structs.ServiceIntentionsConfigEntry{
+			Name:    "db",
+			Sources: []*structs.SourceIntention{{Name: "web", Action: structs.IntentionActionAllow}},
+		},
+	}
+	var resp structs.IntentionBulkApplyResponse
+	err := msgpackrpc.CallWithCodec(codec, "Intention.BulkApply", req, &resp)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	req.Token = "root"
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Intention.BulkApply", req, &resp))
+	require.True(t, resp.Applied)
+	require.Len(t, resp.Created, 1)
+}
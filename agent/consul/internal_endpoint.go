@@ -2,6 +2,7 @@ package consul
 
 import (
 	"fmt"
+	"time"
 
 	bexpr "github.com/hashicorp/go-bexpr"
 	"github.com/hashicorp/go-hclog"
@@ -489,6 +490,76 @@ func (m *Internal) ExportedPeeredServices(args *structs.DCSpecificRequest, reply
 		})
 }
 
+// ServiceMetricsReport records the traffic between services seen by the
+// connect proxies of an agent. Reports are aggregated by the leader. The
+// token must be able to write every source service of the samples, like the
+// tokens of the proxies which reported them.
+func (m *Internal) ServiceMetricsReport(args *structs.ServiceMetricsReportRequest, reply *struct{}) error {
+	if done, err := m.srv.ForwardRPC("Internal.ServiceMetricsReport", args, reply); done {
+		return err
+	}
+
+	var authzContext acl.AuthorizerContext
+	authz, err := m.srv.ResolveTokenAndDefaultMeta(args.Token, &args.EnterpriseMeta, &authzContext)
+	if err != nil {
+		return err
+	}
+	if err := m.srv.validateEnterpriseRequest(&args.EnterpriseMeta, true); err != nil {
+		return err
+	}
+	for _, sample := range args.Samples {
+		var sampleAuthzContext acl.AuthorizerContext
+		sample.Source.EnterpriseMeta.FillAuthzContext(&sampleAuthzContext)
+		if err := authz.ToAllowAuthorizer().ServiceWriteAllowed(sample.Source.Name, &sampleAuthzContext); err != nil {
+			return err
+		}
+	}
+
+	m.srv.serviceMetrics.record(args, time.Now())
+	return nil
+}
+
+// ServiceMetrics returns the traffic of a service with its upstreams and
+// downstreams over the last minute, as reported by the agents.
+func (m *Internal) ServiceMetrics(args *structs.ServiceSpecificRequest, reply *structs.IndexedServiceMetrics) error {
+	// The metrics are only held by the leader.
+	args.AllowStale = false
+	if done, err := m.srv.ForwardRPC("Internal.ServiceMetrics", args, reply); done {
+		return err
+	}
+	if args.ServiceName == "" {
+		return fmt.Errorf("Must provide a service name")
+	}
+
+	var authzContext acl.AuthorizerContext
+	authz, err := m.srv.ResolveTokenAndDefaultMeta(args.Token, &args.EnterpriseMeta, &authzContext)
+	if err != nil {
+		return err
+	}
+	if err := m.srv.validateEnterpriseRequest(&args.EnterpriseMeta, false); err != nil {
+		return err
+	}
+	if err := authz.ToAllowAuthorizer().ServiceReadAllowed(args.ServiceName, &authzContext); err != nil {
+		return err
+	}
+
+	service := structs.NewServiceName(args.ServiceName, &args.EnterpriseMeta)
+	allowed := func(other structs.ServiceName) bool {
+		var otherContext acl.AuthorizerContext
+		other.FillAuthzContext(&otherContext)
+		if authz.ServiceRead(other.Name, &otherContext) == acl.Allow {
+			return true
+		}
+		reply.QueryMeta.ResultsFilteredByACLs = true
+		return false
+	}
+
+	reply.Window = serviceMetricsWindow
+	reply.Upstreams, reply.Downstreams = m.srv.serviceMetrics.summarize(service, time.Now(), allowed)
+	m.srv.setQueryMeta(&reply.QueryMeta, args.Token)
+	return nil
+}
+
 // EventFire is a bit of an odd endpoint, but it allows for a cross-DC RPC
 // call to fire an event. The primary use case is to enable user events being
 // triggered in a remote DC.
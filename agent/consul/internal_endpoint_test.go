@@ -2609,3 +2609,108 @@ func TestInternal_CatalogOverview_ACLDeny(t *testing.T) {
 	arg.Token = opReadToken.SecretID
 	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Internal.CatalogOverview", &arg, &out))
 }
+
+func TestInternal_ServiceMetrics_ACL(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.PrimaryDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLInitialManagementToken = TestDefaultInitialManagementToken
+		c.ACLResolverSettings.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	var (
+		web   = structs.NewServiceName("web", structs.DefaultEnterpriseMetaInDefaultPartition())
+		api   = structs.NewServiceName("api", structs.DefaultEnterpriseMetaInDefaultPartition())
+		redis = structs.NewServiceName("redis", structs.DefaultEnterpriseMetaInDefaultPartition())
+	)
+	report := structs.ServiceMetricsReportRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Interval:   10 * time.Second,
+		Samples: []structs.ServiceMetricsSample{
+			{Source: web, Destination: api, ServiceMetricsCounters: structs.ServiceMetricsCounters{Requests: 100, Errors: 5}},
+			{Source: api, Destination: redis, ServiceMetricsCounters: structs.ServiceMetricsCounters{Requests: 20}},
+		},
+	}
+
+	t.Run("node write is not enough to report", func(t *testing.T) {
+		token, err := upsertTestTokenWithPolicyRules(codec, TestDefaultInitialManagementToken, "dc1", `
+node "foo" { policy = "write" }
+`)
+		require.NoError(t, err)
+
+		report.Token = token.SecretID
+		var out struct{}
+		err = msgpackrpc.CallWithCodec(codec, "Internal.ServiceMetricsReport", &report, &out)
+		require.True(t, acl.IsErrPermissionDenied(err))
+	})
+
+	t.Run("report requires service write for every source", func(t *testing.T) {
+		token, err := upsertTestTokenWithPolicyRules(codec, TestDefaultInitialManagementToken, "dc1", `
+service "web" { policy = "write" }
+service "api" { policy = "read" }
+`)
+		require.NoError(t, err)
+
+		report.Token = token.SecretID
+		var out struct{}
+		err = msgpackrpc.CallWithCodec(codec, "Internal.ServiceMetricsReport", &report, &out)
+		require.True(t, acl.IsErrPermissionDenied(err))
+	})
+
+	proxyToken, err := upsertTestTokenWithPolicyRules(codec, TestDefaultInitialManagementToken, "dc1", `
+service "web" { policy = "write" }
+service "api" { policy = "write" }
+`)
+	require.NoError(t, err)
+	report.Token = proxyToken.SecretID
+	var reportOut struct{}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Internal.ServiceMetricsReport", &report, &reportOut))
+
+	userToken, err := upsertTestTokenWithPolicyRules(codec, TestDefaultInitialManagementToken, "dc1", `
+service "api" { policy = "read" }
+service "web" { policy = "read" }
+`)
+	require.NoError(t, err)
+
+	t.Run("api can't read redis", func(t *testing.T) {
+		args := structs.ServiceSpecificRequest{
+			Datacenter:   "dc1",
+			ServiceName:  "api",
+			QueryOptions: structs.QueryOptions{Token: userToken.SecretID},
+		}
+		var out structs.IndexedServiceMetrics
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "Internal.ServiceMetrics", &args, &out))
+
+		require.True(t, out.QueryMeta.ResultsFilteredByACLs)
+		require.Equal(t, time.Minute, out.Window)
+		require.Empty(t, out.Upstreams)
+		require.Len(t, out.Downstreams, 1)
+		require.Equal(t, web, out.Downstreams[0].Source)
+		require.Equal(t, uint64(100), out.Downstreams[0].Requests)
+		require.Equal(t, 0.05, out.Downstreams[0].ErrorRate)
+	})
+
+	t.Run("can't read redis", func(t *testing.T) {
+		args := structs.ServiceSpecificRequest{
+			Datacenter:   "dc1",
+			ServiceName:  "redis",
+			QueryOptions: structs.QueryOptions{Token: userToken.SecretID},
+		}
+		var out structs.IndexedServiceMetrics
+		err := msgpackrpc.CallWithCodec(codec, "Internal.ServiceMetrics", &args, &out)
+		require.True(t, acl.IsErrPermissionDenied(err))
+	})
+}
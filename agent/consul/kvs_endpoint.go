@@ -2,6 +2,7 @@ package consul
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -206,6 +207,17 @@ func (k *KVS) List(args *structs.KeyRequest, reply *structs.IndexedDirEntries) e
 			ent = FilterDirEnt(authz, ent)
 			reply.QueryMeta.ResultsFilteredByACLs = total != len(ent)
 
+			if isPaginated(&args.QueryOptions) {
+				// The entries are sorted by key.
+				start, end, err := paginate(&args.QueryOptions, &reply.QueryMeta, k.srv.config.MaxPerPage, len(ent), func(i int) []string {
+					return []string{ent[i].Key}
+				})
+				if err != nil {
+					return err
+				}
+				ent = ent[start:end]
+			}
+
 			if len(ent) == 0 {
 				// Must provide non-zero index to prevent blocking
 				// Index 1 is impossible anyways (due to Raft internals)
@@ -297,6 +309,18 @@ func (k *KVS) ListKeys(args *structs.KeyListRequest, reply *structs.IndexedKeyLi
 					keys = append(keys, e.Key)
 				}
 			}
+
+			if isPaginated(&args.QueryOptions) {
+				// The keys cut at the separator may not be in order anymore.
+				sort.Strings(keys)
+				start, end, err := paginate(&args.QueryOptions, &reply.QueryMeta, k.srv.config.MaxPerPage, len(keys), func(i int) []string {
+					return []string{keys[i]}
+				})
+				if err != nil {
+					return err
+				}
+				keys = keys[start:end]
+			}
 			reply.Keys = keys
 			return nil
 		})
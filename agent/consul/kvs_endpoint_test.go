@@ -676,6 +676,110 @@ func TestKVSEndpoint_ListKeys(t *testing.T) {
 	}
 }
 
+func TestKVSEndpoint_ListKeys_Paginated(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+
+	for _, key := range []string{"test/a", "test/b-x", "test/b/1", "test/b/2", "test/c"} {
+		arg := structs.KVSRequest{
+			Datacenter: "dc1",
+			Op:         api.KVSet,
+			DirEnt:     structs.DirEntry{Key: key},
+		}
+		var out bool
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out))
+	}
+
+	// The keys cut at the separator are paginated in order too.
+	var pages [][]string
+	args := structs.KeyListRequest{
+		Datacenter:   "dc1",
+		Prefix:       "test/",
+		Seperator:    "/",
+		QueryOptions: structs.QueryOptions{PerPage: 2},
+	}
+	for {
+		var out structs.IndexedKeyList
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.ListKeys", &args, &out))
+		pages = append(pages, out.Keys)
+		if out.NextToken == "" {
+			break
+		}
+		args.NextToken = out.NextToken
+	}
+	require.Equal(t, [][]string{{"test/a", "test/b-x"}, {"test/b/", "test/c"}}, pages)
+
+	// Recursive listings are paginated by key.
+	listArgs := structs.KeyRequest{
+		Datacenter:   "dc1",
+		Key:          "test/",
+		QueryOptions: structs.QueryOptions{PerPage: 3},
+	}
+	var out structs.IndexedDirEntries
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.List", &listArgs, &out))
+	require.Len(t, out.Entries, 3)
+	require.Equal(t, "test/b/1", out.Entries[2].Key)
+	require.NotEmpty(t, out.NextToken)
+
+	listArgs.NextToken = out.NextToken
+	out = structs.IndexedDirEntries{}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.List", &listArgs, &out))
+	require.Len(t, out.Entries, 2)
+	require.Equal(t, "test/b/2", out.Entries[0].Key)
+	require.Equal(t, "test/c", out.Entries[1].Key)
+	require.Empty(t, out.NextToken)
+}
+
+func TestKVSEndpoint_List_UnpaginatedAboveMaxPerPage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.MaxPerPage = 2
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+
+	for _, key := range []string{"test/a", "test/b", "test/c", "test/d", "test/e"} {
+		arg := structs.KVSRequest{
+			Datacenter: "dc1",
+			Op:         api.KVSet,
+			DirEnt:     structs.DirEntry{Key: key},
+		}
+		var out bool
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out))
+	}
+
+	// The page size cap only applies when a page is requested.
+	listArgs := structs.KeyRequest{Datacenter: "dc1", Key: "test/"}
+	var out structs.IndexedDirEntries
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.List", &listArgs, &out))
+	require.Len(t, out.Entries, 5)
+	require.Empty(t, out.NextToken)
+
+	keysArgs := structs.KeyListRequest{Datacenter: "dc1", Prefix: "test/"}
+	var keys structs.IndexedKeyList
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.ListKeys", &keysArgs, &keys))
+	require.Len(t, keys.Keys, 5)
+	require.Empty(t, keys.NextToken)
+}
+
 func TestKVSEndpoint_ListKeys_ACLDeny(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
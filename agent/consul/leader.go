@@ -302,6 +302,10 @@ func (s *Server) establishLeadership(ctx context.Context) error {
 		return err
 	}
 
+	if err := s.initializeNodeIdentitySigningKey(); err != nil {
+		return err
+	}
+
 	s.getOrCreateAutopilotConfig()
 	s.autopilot.EnableReconciliation()
 
@@ -313,6 +317,12 @@ func (s *Server) establishLeadership(ctx context.Context) error {
 
 	s.startPeeringStreamSync(ctx)
 
+	s.startTrafficShiftController(ctx)
+
+	s.startMaintenanceWindowController(ctx)
+
+	s.startProtocolDetection(ctx)
+
 	if err := s.startConnectLeader(ctx); err != nil {
 		return err
 	}
@@ -352,6 +362,12 @@ func (s *Server) revokeLeadership() {
 
 	s.stopPeeringStreamSync()
 
+	s.stopTrafficShiftController()
+
+	s.stopMaintenanceWindowController()
+
+	s.stopProtocolDetection()
+
 	s.stopConnectLeader()
 
 	s.stopACLTokenReaping()
@@ -1245,9 +1261,11 @@ func (s *Server) handleDeregisterMember(reason string, member serf.Member, nodeE
 		"reason", reason,
 	)
 	req := structs.DeregisterRequest{
-		Datacenter:     s.config.Datacenter,
-		Node:           member.Name,
-		EnterpriseMeta: *nodeEntMeta,
+		Datacenter:       s.config.Datacenter,
+		Node:             member.Name,
+		DeregisterReason: "member " + reason,
+		DeregisteredAt:   time.Now().UTC(),
+		EnterpriseMeta:   *nodeEntMeta,
 	}
 	_, err = s.raftApply(structs.DeregisterRequestType, &req)
 	return err
@@ -43,7 +43,7 @@ type caServerDelegate interface {
 
 	State() *state.Store
 	IsLeader() bool
-	ApplyCALeafRequest() (uint64, error)
+	ApplyCALeafRequest(issued *structs.IssuedCertRecord) (uint64, error)
 
 	forwardDC(method, dc string, args interface{}, reply interface{}) error
 	generateCASignRequest(csr string) *structs.CASignRequest
@@ -96,19 +96,15 @@ func (c *caDelegateWithState) ApplyCARequest(req *structs.CARequest) (interface{
 	return c.Server.raftApplyMsgpack(structs.ConnectCARequestType, req)
 }
 
-func (c *caDelegateWithState) ApplyCALeafRequest() (uint64, error) {
-	// TODO(banks): when we implement IssuedCerts table we can use the insert to
-	// that as the raft index to return in response.
-	//
-	// UPDATE(mkeeler): The original implementation relied on updating the CAConfig
-	// and using its index as the ModifyIndex for certs. This was buggy. The long
-	// term goal is still to insert some metadata into raft about the certificates
-	// and use that raft index for the ModifyIndex. This is a partial step in that
-	// direction except that we only are setting an index and not storing the
-	// metadata.
+func (c *caDelegateWithState) ApplyCALeafRequest(issued *structs.IssuedCertRecord) (uint64, error) {
+	// The original implementation relied on updating the CAConfig and using
+	// its index as the ModifyIndex for certs. This was buggy. The index is now
+	// incremented along with storing the record of the issued certificate, and
+	// that raft index is used as the ModifyIndex.
 	req := structs.CALeafRequest{
 		Op:         structs.CALeafOpIncrementIndex,
 		Datacenter: c.Server.config.Datacenter,
+		IssuedCert: issued,
 	}
 	resp, err := c.Server.raftApplyMsgpack(structs.ConnectCALeafRequestType|structs.IgnoreUnknownTypeFlag, &req)
 	if err != nil {
@@ -1341,7 +1337,8 @@ type connectSignRateLimiter struct {
 	csrConcurrencyLimiter semaphore.Dynamic
 }
 
-// getCSRRateLimiterWithLimit returns a rate.Limiter with the desired limit set.
+// getCSRRateLimiterWithLimit returns a rate.Limiter with the desired limit and
+// burst set.
 // It uses the shared server-wide limiter unless the limit has been changed in
 // config or the limiter has not been setup yet in which case it just-in-time
 // configures the new limiter. We assume that limit changes are relatively rare
@@ -1354,14 +1351,18 @@ type connectSignRateLimiter struct {
 // assume this is fine. If we observe strange behavior because of it, we could
 // add hysteresis that prevents changes too soon after a previous change but
 // that seems unnecessary for now.
-func (l *connectSignRateLimiter) getCSRRateLimiterWithLimit(limit rate.Limit) *rate.Limiter {
+func (l *connectSignRateLimiter) getCSRRateLimiterWithLimit(limit rate.Limit, burst int) *rate.Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+
 	l.csrRateLimiterMu.RLock()
 	lim := l.csrRateLimiter
 	l.csrRateLimiterMu.RUnlock()
 
 	// If there is a current limiter with the same limit, return it. This should
 	// be the common case.
-	if lim != nil && lim.Limit() == limit {
+	if lim != nil && lim.Limit() == limit && lim.Burst() == burst {
 		return lim
 	}
 
@@ -1369,11 +1370,12 @@ func (l *connectSignRateLimiter) getCSRRateLimiterWithLimit(limit rate.Limit) *r
 	l.csrRateLimiterMu.Lock()
 	defer l.csrRateLimiterMu.Unlock()
 	// No limiter yet, or limit changed in CA config, reconfigure a new limiter.
-	// We use burst of 1 for a hard limit. Note that either bursting or waiting is
-	// necessary to get expected behavior in fact of random arrival times, but we
-	// don't need both and we use Wait with a small delay to smooth noise. See
+	// We use burst of 1 for a hard limit unless configured otherwise. Note that
+	// either bursting or waiting is necessary to get expected behavior in fact
+	// of random arrival times, but we don't need both and we use Wait with a
+	// small delay to smooth noise. See
 	// https://github.com/banks/sim-rate-limit-backoff/blob/master/README.md.
-	l.csrRateLimiter = rate.NewLimiter(limit, 1)
+	l.csrRateLimiter = rate.NewLimiter(limit, burst)
 	return l.csrRateLimiter
 }
 
@@ -1478,7 +1480,7 @@ func (c *CAManager) SignCertificate(csr *x509.CertificateRequest, spiffeID conne
 		return nil, err
 	}
 	if commonCfg.CSRMaxPerSecond > 0 {
-		lim := c.caLeafLimiter.getCSRRateLimiterWithLimit(rate.Limit(commonCfg.CSRMaxPerSecond))
+		lim := c.caLeafLimiter.getCSRRateLimiterWithLimit(rate.Limit(commonCfg.CSRMaxPerSecond), commonCfg.CSRMaxBurst)
 		// Wait up to the small threshold we allow for a token.
 		ctx, cancel := context.WithTimeout(context.Background(), csrLimitWait)
 		defer cancel()
@@ -1526,12 +1528,29 @@ func (c *CAManager) SignCertificate(csr *x509.CertificateRequest, spiffeID conne
 		pem = pem + lib.EnsureTrailingNewline(p)
 	}
 
-	modIdx, err := c.delegate.ApplyCALeafRequest()
+	cert, err := connect.ParseCert(pem)
 	if err != nil {
 		return nil, err
 	}
 
-	cert, err := connect.ParseCert(pem)
+	// Record the certificate so it can be inventoried until it expires.
+	issued := &structs.IssuedCertRecord{
+		SerialNumber:   connect.EncodeSerialNumber(cert.SerialNumber),
+		RootID:         caRoot.ID,
+		SigningKeyID:   connect.EncodeSigningKeyID(cert.AuthorityKeyId),
+		ValidAfter:     cert.NotBefore,
+		ValidBefore:    cert.NotAfter,
+		EnterpriseMeta: entMeta,
+	}
+	if isService {
+		issued.Service = serviceID.Service
+		issued.ServiceURI = cert.URIs[0].String()
+	} else if isAgent {
+		issued.Agent = agentID.Agent
+		issued.AgentURI = cert.URIs[0].String()
+	}
+
+	modIdx, err := c.delegate.ApplyCALeafRequest(issued)
 	if err != nil {
 		return nil, err
 	}
@@ -187,7 +187,7 @@ func (m *mockCAServerDelegate) ServersSupportMultiDCConnectCA() error {
 	return nil
 }
 
-func (m *mockCAServerDelegate) ApplyCALeafRequest() (uint64, error) {
+func (m *mockCAServerDelegate) ApplyCALeafRequest(_ *structs.IssuedCertRecord) (uint64, error) {
 	return 3, nil
 }
 
@@ -1043,3 +1043,18 @@ func setupPrimaryCA(t *testing.T, client *vaultapi.Client, path string, rootPEM
 	require.NoError(t, err, "failed to set signed intermediate")
 	return lib.EnsureTrailingNewline(buf.String())
 }
+
+func TestConnectSignRateLimiter_Burst(t *testing.T) {
+	var l connectSignRateLimiter
+
+	lim := l.getCSRRateLimiterWithLimit(1, 0)
+	require.Equal(t, 1, lim.Burst())
+	require.Same(t, lim, l.getCSRRateLimiterWithLimit(1, 1))
+
+	// Changing the burst replaces the limiter, which starts full.
+	lim = l.getCSRRateLimiterWithLimit(1, 5)
+	require.Equal(t, 5, lim.Burst())
+	now := time.Now()
+	require.True(t, lim.AllowN(now, 5))
+	require.False(t, lim.AllowN(now, 1))
+}
@@ -0,0 +1,287 @@
+package consul
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/consul/stream"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/logging"
+)
+
+var (
+	// caRootsWebhookTimeout is the timeout of each request to the webhook.
+	caRootsWebhookTimeout = 10 * time.Second
+
+	// caRootsWebhookRetryWait is the time waited before posting a change
+	// again, doubled after each attempt up to caRootsWebhookMaxRetryWait.
+	caRootsWebhookRetryWait    = time.Second
+	caRootsWebhookMaxRetryWait = time.Minute
+)
+
+// caRootsWebhookSignatureHeader is the header holding the HMAC-SHA256 of the
+// body of the requests to the webhook, keyed with the configured secret.
+const caRootsWebhookSignatureHeader = "X-Consul-Signature"
+
+// caRootsWebhookCursor is the last change posted to the webhook. It is stored
+// in the system metadata so that a new leader resumes from there, and posts
+// the changes made while there was no leader as one.
+type caRootsWebhookCursor struct {
+	// Index is the Raft index of the last change posted.
+	Index uint64
+
+	// Fingerprints are the certificates trusted after the last change.
+	Fingerprints []structs.CACertFingerprint
+}
+
+// runCARootsWebhook posts the changes to the certificates trusted by the
+// Connect CA to the configured webhook, so that external systems pinning them
+// can update their trust stores. Each change is posted at least once.
+func (s *Server) runCARootsWebhook(ctx context.Context) error {
+	logger := s.loggers.Named(logging.Connect)
+
+	for {
+		err := s.postCARootsChanges(ctx, logger)
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, stream.ErrSubForceClosed):
+			logger.Trace("CA roots change subscription force-closed, resuming")
+		default:
+			logger.Error("failed to watch CA roots changes", "error", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(caRootsWebhookRetryWait):
+			}
+		}
+	}
+}
+
+func (s *Server) postCARootsChanges(ctx context.Context, logger hclog.Logger) error {
+	cursor, err := s.getCARootsWebhookCursor()
+	if err != nil {
+		return err
+	}
+
+	// The snapshot holds every current certificate, which is compared to the
+	// last ones posted to catch up with the changes made since.
+	sub, err := s.publisher.Subscribe(&stream.SubscribeRequest{
+		Topic:   state.EventTopicCARootsChange,
+		Subject: stream.SubjectNone,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to CA roots changes: %w", err)
+	}
+	defer sub.Unsubscribe()
+
+	inSnapshot := true
+	for {
+		event, err := sub.Next(ctx)
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil
+		case err != nil:
+			return err
+		}
+
+		switch {
+		case event.IsNewSnapshotToFollow():
+			inSnapshot = true
+			continue
+		case event.IsEndOfSnapshot():
+			inSnapshot = false
+			continue
+		}
+
+		payload, ok := event.Payload.(state.EventPayloadCARootsChange)
+		if !ok {
+			return fmt.Errorf("unexpected event payload type: %T", event.Payload)
+		}
+
+		change := payload.Change
+		switch {
+		case cursor == nil:
+			// Nothing was ever posted, so the current certificates are the
+			// baseline of the changes to come.
+			cursor = &caRootsWebhookCursor{Index: change.Index, Fingerprints: change.Added}
+			if err := s.setCARootsWebhookCursor(cursor); err != nil {
+				return err
+			}
+			continue
+		case change.Index <= cursor.Index:
+			continue
+		case inSnapshot:
+			change.Added, change.Removed = diffCACertFingerprints(cursor.Fingerprints, payload.Change.Added)
+			if len(change.Added) == 0 && len(change.Removed) == 0 {
+				continue
+			}
+		}
+
+		if err := postCARootsChange(ctx, s.config.ConnectCARootsWebhook, s.config.ConnectCARootsWebhookSecret, change, logger); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		logger.Debug("posted CA roots change to webhook", "index", change.Index)
+
+		cursor = &caRootsWebhookCursor{
+			Index:        change.Index,
+			Fingerprints: applyCARootsChange(cursor.Fingerprints, change),
+		}
+		if err := s.setCARootsWebhookCursor(cursor); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) getCARootsWebhookCursor() (*caRootsWebhookCursor, error) {
+	raw, err := s.getSystemMetadata(structs.SystemMetadataCARootsWebhookCursorKey)
+	if err != nil || raw == "" {
+		return nil, err
+	}
+
+	var cursor caRootsWebhookCursor
+	if err := json.Unmarshal([]byte(raw), &cursor); err != nil {
+		return nil, fmt.Errorf("failed to decode the CA roots webhook cursor: %w", err)
+	}
+	return &cursor, nil
+}
+
+func (s *Server) setCARootsWebhookCursor(cursor *caRootsWebhookCursor) error {
+	raw, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	if err := s.setSystemMetadataKey(structs.SystemMetadataCARootsWebhookCursorKey, string(raw)); err != nil {
+		return fmt.Errorf("failed to store the CA roots webhook cursor: %w", err)
+	}
+	return nil
+}
+
+// diffCACertFingerprints returns the sorted fingerprints added to and removed
+// from before to get after.
+func diffCACertFingerprints(before, after []structs.CACertFingerprint) (added, removed []structs.CACertFingerprint) {
+	inBefore := make(map[structs.CACertFingerprint]struct{}, len(before))
+	for _, fp := range before {
+		inBefore[fp] = struct{}{}
+	}
+	inAfter := make(map[structs.CACertFingerprint]struct{}, len(after))
+	for _, fp := range after {
+		inAfter[fp] = struct{}{}
+		if _, ok := inBefore[fp]; !ok {
+			added = append(added, fp)
+		}
+	}
+	for _, fp := range before {
+		if _, ok := inAfter[fp]; !ok {
+			removed = append(removed, fp)
+		}
+	}
+	sortCACertFingerprints(added)
+	sortCACertFingerprints(removed)
+	return added, removed
+}
+
+// applyCARootsChange returns the sorted fingerprints trusted after change.
+func applyCARootsChange(fingerprints []structs.CACertFingerprint, change structs.CARootsChange) []structs.CACertFingerprint {
+	removed := make(map[structs.CACertFingerprint]struct{}, len(change.Removed))
+	for _, fp := range change.Removed {
+		removed[fp] = struct{}{}
+	}
+
+	var out []structs.CACertFingerprint
+	for _, fp := range fingerprints {
+		if _, ok := removed[fp]; !ok {
+			out = append(out, fp)
+		}
+	}
+	out = append(out, change.Added...)
+	sortCACertFingerprints(out)
+	return out
+}
+
+func sortCACertFingerprints(fps []structs.CACertFingerprint) {
+	sort.Slice(fps, func(i, j int) bool {
+		if fps[i].RootID != fps[j].RootID {
+			return fps[i].RootID < fps[j].RootID
+		}
+		if fps[i].Intermediate != fps[j].Intermediate {
+			return !fps[i].Intermediate
+		}
+		return fps[i].SHA256 < fps[j].SHA256
+	})
+}
+
+// postCARootsChange posts the JSON encoded change to the webhook, signed with
+// secret. Failures are retried with a backoff until the change is posted, or
+// ctx is cancelled.
+func postCARootsChange(ctx context.Context, webhook, secret string, change structs.CARootsChange, logger hclog.Logger) error {
+	body, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	client := cleanhttp.DefaultClient()
+	client.Timeout = caRootsWebhookTimeout
+
+	wait := caRootsWebhookRetryWait
+	for {
+		err = postCARootsChangeOnce(ctx, client, webhook, signature, body)
+		if err == nil {
+			return nil
+		}
+		logger.Error("failed to post CA roots change to webhook, retrying",
+			"index", change.Index,
+			"retry_in", wait,
+			"error", err,
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > caRootsWebhookMaxRetryWait {
+			wait = caRootsWebhookMaxRetryWait
+		}
+	}
+}
+
+func postCARootsChangeOnce(ctx context.Context, client *http.Client, webhook, signature string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(caRootsWebhookSignatureHeader, signature)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package consul
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	msgpackrpc "github.com/hashicorp/consul-net-rpc/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/connect"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/sdk/testutil"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	"github.com/hashicorp/consul/testrpc"
+)
+
+func TestPostCARootsChange(t *testing.T) {
+	t.Parallel()
+
+	change := structs.CARootsChange{
+		Index:        5,
+		ActiveRootID: "root",
+		Added: []structs.CACertFingerprint{
+			{RootID: "root", SHA256: "aa:bb"},
+		},
+	}
+
+	logger := testutil.Logger(t)
+
+	t.Run("success", func(t *testing.T) {
+		var got structs.CARootsChange
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodPost, r.Method)
+			require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			mac := hmac.New(sha256.New, []byte("secret"))
+			mac.Write(body)
+			require.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), r.Header.Get(caRootsWebhookSignatureHeader))
+			require.NoError(t, json.Unmarshal(body, &got))
+		}))
+		defer srv.Close()
+
+		require.NoError(t, postCARootsChange(context.Background(), srv.URL, "secret", change, logger))
+		require.Equal(t, change, got)
+	})
+
+	t.Run("retries failures", func(t *testing.T) {
+		var calls int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) < 2 {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}))
+		defer srv.Close()
+
+		require.NoError(t, postCARootsChange(context.Background(), srv.URL, "secret", change, logger))
+		require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("retries until cancelled", func(t *testing.T) {
+		var calls int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer srv.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2500*time.Millisecond)
+		defer cancel()
+		err := postCARootsChange(ctx, srv.URL, "secret", change, logger)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestDiffCACertFingerprints(t *testing.T) {
+	a := structs.CACertFingerprint{RootID: "a", SHA256: "aa"}
+	aInter := structs.CACertFingerprint{RootID: "a", Intermediate: true, SHA256: "ab"}
+	b := structs.CACertFingerprint{RootID: "b", SHA256: "bb"}
+
+	added, removed := diffCACertFingerprints([]structs.CACertFingerprint{a, aInter}, []structs.CACertFingerprint{b, a})
+	require.Equal(t, []structs.CACertFingerprint{b}, added)
+	require.Equal(t, []structs.CACertFingerprint{aInter}, removed)
+
+	fps := applyCARootsChange([]structs.CACertFingerprint{a, aInter}, structs.CARootsChange{
+		Added:   added,
+		Removed: removed,
+	})
+	require.Equal(t, []structs.CACertFingerprint{a, b}, fps)
+}
+
+func TestLeader_CARootsWebhook(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	changes := make(chan structs.CARootsChange, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var change structs.CARootsChange
+		if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		changes <- change
+	}))
+	defer webhook.Close()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ConnectCARootsWebhook = webhook.URL
+		c.ConnectCARootsWebhookSecret = "secret"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+
+	var roots structs.IndexedCARoots
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConnectCA.Roots", &structs.DCSpecificRequest{
+		Datacenter: "dc1",
+	}, &roots))
+	require.Len(t, roots.Roots, 1)
+	oldRoot := roots.Roots[0]
+
+	// Rotating to a new private key adds a new root.
+	_, newKey, err := connect.GeneratePrivateKey()
+	require.NoError(t, err)
+	var reply interface{}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ConnectCA.ConfigurationSet", &structs.CARequest{
+		Datacenter: "dc1",
+		Config: &structs.CAConfiguration{
+			Provider: "consul",
+			Config: map[string]interface{}{
+				"PrivateKey": newKey,
+			},
+		},
+	}, &reply))
+
+	var change structs.CARootsChange
+	select {
+	case change = <-changes:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the webhook")
+	}
+
+	require.NotEqual(t, oldRoot.ID, change.ActiveRootID)
+	require.Empty(t, change.Removed)
+	require.Len(t, change.Added, 2)
+	for _, fp := range change.Added {
+		require.Equal(t, change.ActiveRootID, fp.RootID)
+	}
+
+	// The change posted is stored for the next leader to resume from.
+	retry.Run(t, func(r *retry.R) {
+		cursor, err := s1.getCARootsWebhookCursor()
+		require.NoError(r, err)
+		require.NotNil(r, cursor)
+		require.Equal(r, change.Index, cursor.Index)
+		require.Len(r, cursor.Fingerprints, 3)
+	})
+}
+
+func TestLeader_CARootsWebhook_CatchesUp(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	changes := make(chan structs.CARootsChange, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var change structs.CARootsChange
+		if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		changes <- change
+	}))
+	defer webhook.Close()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ConnectCARootsWebhook = webhook.URL
+		c.ConnectCARootsWebhookSecret = "secret"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+
+	// The first leader only records the certificates trusted at the time.
+	var cursor *caRootsWebhookCursor
+	retry.Run(t, func(r *retry.R) {
+		var err error
+		cursor, err = s1.getCARootsWebhookCursor()
+		require.NoError(r, err)
+		require.NotNil(r, cursor)
+	})
+	require.Len(t, cursor.Fingerprints, 1)
+
+	// A leader finding a certificate it doesn't know of posts it as added.
+	s1.leaderRoutineManager.Stop(caRootsWebhookRoutineName)
+	stale := structs.CACertFingerprint{RootID: "gone", SHA256: "00:11"}
+	require.NoError(t, s1.setCARootsWebhookCursor(&caRootsWebhookCursor{
+		Index:        cursor.Index - 1,
+		Fingerprints: []structs.CACertFingerprint{stale},
+	}))
+	s1.leaderRoutineManager.Start(context.Background(), caRootsWebhookRoutineName, s1.runCARootsWebhook)
+	defer s1.leaderRoutineManager.Stop(caRootsWebhookRoutineName)
+
+	var change structs.CARootsChange
+	select {
+	case change = <-changes:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the webhook")
+	}
+	require.Equal(t, cursor.Fingerprints, change.Added)
+	require.Equal(t, []structs.CACertFingerprint{stale}, change.Removed)
+}
@@ -0,0 +1,200 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/logging"
+	"github.com/hashicorp/consul/types"
+)
+
+// startMaintenanceWindowController starts the leader routine applying the
+// maintenance-window config entries. Config entries are replicated to all the
+// datacenters, so every datacenter only applies the windows targeting it.
+func (s *Server) startMaintenanceWindowController(ctx context.Context) {
+	s.leaderRoutineManager.Start(ctx, maintenanceWindowRoutineName, s.runMaintenanceWindowController)
+}
+
+func (s *Server) stopMaintenanceWindowController() {
+	// will be a no-op when not started
+	s.leaderRoutineManager.Stop(maintenanceWindowRoutineName)
+}
+
+// runMaintenanceWindowController reconciles the checks of the windows whenever
+// a maintenance-window config entry changes, a window starts or ends, or the
+// node or service instance of a window is registered.
+func (s *Server) runMaintenanceWindowController(ctx context.Context) error {
+	logger := s.loggers.Named(logging.MaintenanceWindow)
+
+	// The checks of the windows deleted while this server was not the leader
+	// are cleared by the first reconciliation. After that there is nothing
+	// to reconcile while there are no windows.
+	hadEntries := true
+	for {
+		state := s.fsm.State()
+
+		ws := memdb.NewWatchSet()
+		ws.Add(state.AbandonCh())
+		ws.Add(ctx.Done())
+
+		var next <-chan time.Time
+		_, entries, err := state.ConfigEntriesByKind(ws, structs.MaintenanceWindow, structs.WildcardEnterpriseMetaInPartition(structs.WildcardSpecifier))
+		switch {
+		case err != nil:
+			logger.Error("failed to list maintenance windows", "error", err)
+			next = time.After(time.Second)
+		case len(entries) == 0 && !hadEntries:
+		default:
+			now := time.Now()
+			if err := s.reconcileMaintenanceWindows(logger, ws, entries, now); err != nil {
+				logger.Error("failed to reconcile maintenance windows", "error", err)
+				next = time.After(time.Second)
+				break
+			}
+			hadEntries = len(entries) > 0
+			if t := s.nextMaintenanceWindowTransition(entries, now); !t.IsZero() {
+				next = time.After(t.Sub(now))
+			}
+		}
+
+		ws.Watch(next)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+	}
+}
+
+// maintenanceCheckKey identifies a maintenance window check in the catalog.
+type maintenanceCheckKey struct {
+	partition string
+	node      string
+	checkID   types.CheckID
+}
+
+// nextMaintenanceWindowTransition returns the first time after now at which a
+// window of this datacenter starts or ends, or the zero time if none will.
+func (s *Server) nextMaintenanceWindowTransition(entries []structs.ConfigEntry, now time.Time) time.Time {
+	var next time.Time
+	for _, raw := range entries {
+		entry, ok := raw.(*structs.MaintenanceWindowConfigEntry)
+		if !ok || entry.Datacenter != s.config.Datacenter {
+			continue
+		}
+		if t := entry.NextTransition(now); !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	return next
+}
+
+// reconcileMaintenanceWindows registers the checks of the windows of this
+// datacenter in effect at now and deregisters the ones of the windows that
+// ended or were deleted. The node and service instances of the windows in
+// effect which are not registered yet are added to ws.
+func (s *Server) reconcileMaintenanceWindows(logger hclog.Logger, ws memdb.WatchSet, entries []structs.ConfigEntry, now time.Time) error {
+	state := s.fsm.State()
+
+	var merr error
+	desired := make(map[maintenanceCheckKey]*structs.HealthCheck)
+	for _, raw := range entries {
+		entry, ok := raw.(*structs.MaintenanceWindowConfigEntry)
+		if !ok || entry.Datacenter != s.config.Datacenter || !entry.ActiveAt(now) {
+			continue
+		}
+		check, err := maintenanceWindowCheck(ws, state, entry)
+		if err != nil {
+			merr = multierror.Append(merr, fmt.Errorf("maintenance window %q: %w", entry.Name, err))
+			continue
+		}
+		if check == nil {
+			// The node or service isn't registered yet.
+			continue
+		}
+		desired[maintenanceCheckKey{check.PartitionOrDefault(), check.Node, check.CheckID}] = check
+	}
+
+	_, existing, err := state.ChecksInState(nil, api.HealthAny, structs.WildcardEnterpriseMetaInPartition(structs.WildcardSpecifier), structs.DefaultPeerKeyword)
+	if err != nil {
+		return err
+	}
+	for _, check := range existing {
+		if !structs.IsMaintenanceWindowCheckID(check.CheckID) {
+			continue
+		}
+		key := maintenanceCheckKey{check.PartitionOrDefault(), check.Node, check.CheckID}
+		if want, ok := desired[key]; ok {
+			if check.ServiceID == want.ServiceID && check.Notes == want.Notes && check.Status == want.Status {
+				delete(desired, key)
+			}
+			continue
+		}
+
+		logger.Info("maintenance window ended", "node", check.Node, "check", check.CheckID)
+		req := structs.DeregisterRequest{
+			Datacenter:     s.config.Datacenter,
+			Node:           check.Node,
+			CheckID:        check.CheckID,
+			EnterpriseMeta: check.EnterpriseMeta,
+		}
+		if _, err := s.leaderRaftApply("Catalog.Deregister", structs.DeregisterRequestType, &req); err != nil {
+			merr = multierror.Append(merr, fmt.Errorf("failed to deregister check %q of node %q: %w", check.CheckID, check.Node, err))
+		}
+	}
+
+	for _, check := range desired {
+		logger.Info("maintenance window started", "node", check.Node, "service", check.ServiceID, "check", check.CheckID)
+		req := structs.RegisterRequest{
+			Datacenter:     s.config.Datacenter,
+			Node:           check.Node,
+			SkipNodeUpdate: true,
+			Check:          check,
+			EnterpriseMeta: *structs.NodeEnterpriseMetaInPartition(check.PartitionOrDefault()),
+		}
+		if _, err := s.leaderRaftApply("Catalog.Register", structs.RegisterRequestType, &req); err != nil {
+			merr = multierror.Append(merr, fmt.Errorf("failed to register check %q of node %q: %w", check.CheckID, check.Node, err))
+		}
+	}
+	return merr
+}
+
+// maintenanceWindowCheck returns the check to register for a window in effect,
+// or nil when the node or service it applies to is not registered.
+func maintenanceWindowCheck(ws memdb.WatchSet, state *state.Store, entry *structs.MaintenanceWindowConfigEntry) (*structs.HealthCheck, error) {
+	// Watch the services of the node, which also fires when the node is
+	// registered.
+	entMeta := acl.NewEnterpriseMetaWithPartition(entry.PartitionOrDefault(), entry.NamespaceOrDefault())
+	_, services, err := state.NodeServices(ws, entry.Node, &entMeta, structs.DefaultPeerKeyword)
+	if err != nil {
+		return nil, err
+	}
+	if services == nil {
+		return nil, nil
+	}
+
+	if entry.ServiceID == "" {
+		return entry.HealthCheck(), nil
+	}
+
+	svc, ok := services.Services[entry.ServiceID]
+	if !ok {
+		return nil, nil
+	}
+	// ACLs were enforced on the service of the entry, the instance must
+	// belong to it.
+	if svc.Service != entry.Service {
+		return nil, fmt.Errorf("service instance %q is an instance of %q, not %q", entry.ServiceID, svc.Service, entry.Service)
+	}
+	return entry.HealthCheck(), nil
+}
@@ -0,0 +1,199 @@
+package consul
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	"github.com/hashicorp/consul/testrpc"
+)
+
+func TestLeader_MaintenanceWindow(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	_, s1 := testServer(t)
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Drive the reconciliation by hand rather than racing the leader routine.
+	s1.stopMaintenanceWindowController()
+
+	req := structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "node1",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			ID:      "web-1",
+			Service: "web",
+			Port:    8080,
+		},
+	}
+	var out struct{}
+	require.NoError(t, s1.RPC("Catalog.Register", &req, &out))
+
+	start := time.Now().Truncate(time.Second)
+	entries := []structs.ConfigEntry{
+		&structs.MaintenanceWindowConfigEntry{
+			Kind:       structs.MaintenanceWindow,
+			Name:       "node",
+			Datacenter: "dc1",
+			Node:       "node1",
+			Reason:     "kernel upgrade",
+			Start:      start,
+			End:        start.Add(time.Hour),
+		},
+		&structs.MaintenanceWindowConfigEntry{
+			Kind:       structs.MaintenanceWindow,
+			Name:       "service",
+			Datacenter: "dc1",
+			Node:       "node1",
+			ServiceID:  "web-1",
+			Service:    "web",
+			Start:      start.Add(30 * time.Minute),
+			End:        start.Add(2 * time.Hour),
+		},
+		&structs.MaintenanceWindowConfigEntry{
+			Kind:       structs.MaintenanceWindow,
+			Name:       "unregistered",
+			Datacenter: "dc1",
+			Node:       "node2",
+			Start:      start,
+			End:        start.Add(time.Hour),
+		},
+		// Windows of other datacenters don't apply, even to nodes with the
+		// same name.
+		&structs.MaintenanceWindowConfigEntry{
+			Kind:       structs.MaintenanceWindow,
+			Name:       "elsewhere",
+			Datacenter: "dc2",
+			Node:       "node1",
+			Start:      start,
+			End:        start.Add(time.Hour),
+		},
+	}
+	for _, entry := range entries {
+		require.NoError(t, entry.Normalize())
+	}
+
+	checks := func() map[string]*structs.HealthCheck {
+		_, checks, err := s1.fsm.State().NodeChecks(nil, "node1", nil, structs.DefaultPeerKeyword)
+		require.NoError(t, err)
+		out := make(map[string]*structs.HealthCheck)
+		for _, check := range checks {
+			out[string(check.CheckID)] = check
+		}
+		return out
+	}
+	reconcile := func(now time.Time) {
+		require.NoError(t, s1.reconcileMaintenanceWindows(hclog.NewNullLogger(), nil, entries, now))
+	}
+
+	reconcile(start)
+	got := checks()
+	require.Len(t, got, 1)
+	check := got["_maintenance_window:node"]
+	require.NotNil(t, check)
+	require.Equal(t, api.HealthCritical, check.Status)
+	require.Equal(t, "kernel upgrade", check.Notes)
+
+	// Reconciling again doesn't rewrite the check.
+	reconcile(start.Add(time.Minute))
+	require.Equal(t, check.ModifyIndex, checks()["_maintenance_window:node"].ModifyIndex)
+
+	reconcile(start.Add(45 * time.Minute))
+	got = checks()
+	require.Len(t, got, 2)
+	require.Equal(t, "web-1", got["_maintenance_window:service"].ServiceID)
+	require.Equal(t, "web", got["_maintenance_window:service"].ServiceName)
+
+	reconcile(start.Add(90 * time.Minute))
+	got = checks()
+	require.Len(t, got, 1)
+	require.Contains(t, got, "_maintenance_window:service")
+
+	// The instance must belong to the service of the window.
+	mismatch := &structs.MaintenanceWindowConfigEntry{
+		Kind:       structs.MaintenanceWindow,
+		Name:       "mismatch",
+		Datacenter: "dc1",
+		Node:       "node1",
+		ServiceID:  "web-1",
+		Service:    "db",
+		Start:      start,
+		End:        start.Add(time.Hour),
+	}
+	err := s1.reconcileMaintenanceWindows(hclog.NewNullLogger(), nil, []structs.ConfigEntry{mismatch}, start)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `service instance "web-1" is an instance of "web", not "db"`)
+	require.NotContains(t, checks(), "_maintenance_window:mismatch")
+
+	// Deleted windows are cleared too.
+	require.NoError(t, s1.reconcileMaintenanceWindows(hclog.NewNullLogger(), nil, nil, start.Add(45*time.Minute)))
+	require.Empty(t, checks())
+}
+
+func TestLeader_MaintenanceWindowController(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	_, s1 := testServer(t)
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	start := time.Now().Add(-time.Minute).Truncate(time.Second)
+	entry := &structs.MaintenanceWindowConfigEntry{
+		Kind:       structs.MaintenanceWindow,
+		Name:       "node",
+		Datacenter: "dc1",
+		Node:       "node1",
+		Start:      start,
+		End:        start.Add(time.Hour),
+	}
+	var applied bool
+	require.NoError(t, s1.RPC("ConfigEntry.Apply", &structs.ConfigEntryRequest{
+		Datacenter: "dc1",
+		Entry:      entry,
+	}, &applied))
+	require.True(t, applied)
+
+	hasCheck := func() bool {
+		_, check, err := s1.fsm.State().NodeCheck("node1", "_maintenance_window:node", nil, structs.DefaultPeerKeyword)
+		require.NoError(t, err)
+		return check != nil
+	}
+
+	// The window is applied once the node is registered.
+	var out struct{}
+	require.NoError(t, s1.RPC("Catalog.Register", &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "node1",
+		Address:    "127.0.0.1",
+	}, &out))
+	retry.Run(t, func(r *retry.R) {
+		if !hasCheck() {
+			r.Fatal("maintenance window check not registered")
+		}
+	})
+
+	// And cleared once it is deleted.
+	require.NoError(t, s1.RPC("ConfigEntry.Delete", &structs.ConfigEntryRequest{
+		Op:         structs.ConfigEntryDelete,
+		Datacenter: "dc1",
+		Entry:      entry,
+	}, &structs.ConfigEntryDeleteResponse{}))
+	retry.Run(t, func(r *retry.R) {
+		if hasCheck() {
+			r.Fatal("maintenance window check not deregistered")
+		}
+	})
+}
@@ -7,13 +7,13 @@ import (
 	"crypto/x509"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-memdb"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/go-uuid"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
 
 	"github.com/hashicorp/consul/agent/pool"
 	"github.com/hashicorp/consul/agent/rpc/peering"
@@ -21,6 +21,10 @@ import (
 	"github.com/hashicorp/consul/proto/pbpeering"
 )
 
+// peeringDialTimeout is how long a peer address is dialed before the next one
+// is tried.
+const peeringDialTimeout = 10 * time.Second
+
 func (s *Server) startPeeringStreamSync(ctx context.Context) {
 	s.leaderRoutineManager.Start(ctx, peeringStreamsRoutineName, s.runPeeringSync)
 }
@@ -179,7 +183,7 @@ func (s *Server) syncPeeringsAndBlock(ctx context.Context, logger hclog.Logger,
 }
 
 func (s *Server) establishStream(ctx context.Context, logger hclog.Logger, peer *pbpeering.Peering, cancelFns map[string]context.CancelFunc) error {
-	tlsOption := grpc.WithInsecure()
+	var tlsConfig *tls.Config
 	if len(peer.PeerCAPems) > 0 {
 		var haveCerts bool
 		pool := x509.NewCertPool()
@@ -194,11 +198,10 @@ func (s *Server) establishStream(ctx context.Context, logger hclog.Logger, peer
 		if !haveCerts {
 			return fmt.Errorf("failed to build cert pool from peer CA pems")
 		}
-		cfg := tls.Config{
+		tlsConfig = &tls.Config{
 			ServerName: peer.PeerServerName,
 			RootCAs:    pool,
 		}
-		tlsOption = grpc.WithTransportCredentials(credentials.NewTLS(&cfg))
 	}
 
 	// Create a ring buffer to cycle through peer addresses in the retry loop below.
@@ -225,13 +228,19 @@ func (s *Server) establishStream(ctx context.Context, logger hclog.Logger, peer
 		}
 
 		logger.Trace("dialing peer", "peer_id", peer.ID, "addr", addr)
-		conn, err := grpc.DialContext(retryCtx, addr,
-			grpc.WithContextDialer(newPeerDialer(addr)),
+
+		// Give up on an address which can't be reached, such as a mesh
+		// gateway which went away, to dial the next one.
+		dialCtx, dialCancel := context.WithTimeout(retryCtx, peeringDialTimeout)
+		defer dialCancel()
+		conn, err := grpc.DialContext(dialCtx, addr,
+			grpc.WithContextDialer(newPeerDialer(addr, tlsConfig)),
 			grpc.WithBlock(),
-			tlsOption,
+			// The connection is secured by the dialer when the peer has a CA.
+			grpc.WithInsecure(),
 		)
 		if err != nil {
-			return fmt.Errorf("failed to dial: %w", err)
+			return fmt.Errorf("failed to dial %s: %w", addr, err)
 		}
 		defer conn.Close()
 
@@ -263,7 +272,12 @@ func (s *Server) establishStream(ctx context.Context, logger hclog.Logger, peer
 	return nil
 }
 
-func newPeerDialer(peerAddr string) func(context.Context, string) (net.Conn, error) {
+// newPeerDialer returns the dialer of the peering streams. Without TLS, the
+// streams go through the type-byte system of the RPC port. With TLS, they use
+// native TLS so that mesh gateways in front of the peer servers can route
+// them by SNI, and are told apart by their ALPN protocol as peers don't
+// present a client certificate.
+func newPeerDialer(peerAddr string, tlsConfig *tls.Config) func(context.Context, string) (net.Conn, error) {
 	return func(ctx context.Context, addr string) (net.Conn, error) {
 		d := net.Dialer{}
 		conn, err := d.DialContext(ctx, "tcp", peerAddr)
@@ -271,8 +285,17 @@ func newPeerDialer(peerAddr string) func(context.Context, string) (net.Conn, err
 			return nil, err
 		}
 
-		// TODO(peering): This is going to need to be revisited. This type uses the TLS settings configured on the agent, but
-		//                for peering we never want mutual TLS because the client peer doesn't share its CA cert.
+		if tlsConfig != nil {
+			cfg := tlsConfig.Clone()
+			cfg.NextProtos = []string{pool.ALPN_RPCPeering}
+			tlsConn := tls.Client(conn, cfg)
+			if err := tlsConn.HandshakeContext(ctx); err != nil {
+				conn.Close()
+				return nil, err
+			}
+			return tlsConn, nil
+		}
+
 		_, err = conn.Write([]byte{byte(pool.RPCGRPC)})
 		if err != nil {
 			conn.Close()
@@ -0,0 +1,221 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/go-multierror"
+	"golang.org/x/time/rate"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/logging"
+)
+
+var (
+	// protocolDetectionInterval is the maximum time between two passes
+	// populating detected protocols. Passes also run when the catalog or the
+	// relevant config entries change, but no more often than
+	// protocolDetectionRateLimit.
+	protocolDetectionInterval  = 5 * time.Minute
+	protocolDetectionRateLimit = rate.Every(10 * time.Second)
+)
+
+// startProtocolDetection starts the leader routine writing the protocols
+// detected by sidecar proxies to service-defaults config entries when enabled
+// in the mesh config entry. Config entries are only written in the primary
+// datacenter, so the routine doesn't run anywhere else.
+func (s *Server) startProtocolDetection(ctx context.Context) {
+	if s.config.PrimaryDatacenter != "" && s.config.PrimaryDatacenter != s.config.Datacenter {
+		return
+	}
+	s.leaderRoutineManager.Start(ctx, protocolDetectionRoutineName, s.runProtocolDetection)
+}
+
+func (s *Server) stopProtocolDetection() {
+	// will be a no-op when not started
+	s.leaderRoutineManager.Stop(protocolDetectionRoutineName)
+}
+
+func (s *Server) runProtocolDetection(ctx context.Context) error {
+	logger := s.loggers.Named(logging.ConfigEntry)
+	limiter := rate.NewLimiter(protocolDetectionRateLimit, 1)
+
+	for {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil
+		}
+
+		ws := memdb.NewWatchSet()
+		ws.Add(s.fsm.State().AbandonCh())
+		ws.Add(ctx.Done())
+
+		if err := s.populateDetectedProtocols(ws); err != nil {
+			logger.Error("failed to populate detected protocols", "error", err)
+		}
+
+		ws.Watch(time.After(protocolDetectionInterval))
+
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+	}
+}
+
+// populateDetectedProtocols writes a service-defaults protocol for every
+// service without a configured protocol whose instances and downstream
+// proxies all agree on the detected protocol.
+func (s *Server) populateDetectedProtocols(ws memdb.WatchSet) error {
+	state := s.fsm.State()
+	entMeta := structs.DefaultEnterpriseMetaInDefaultPartition()
+
+	_, raw, err := state.ConfigEntry(ws, structs.MeshConfig, structs.MeshConfigMesh, entMeta)
+	if err != nil {
+		return err
+	}
+	mesh, ok := raw.(*structs.MeshConfigEntry)
+	if !ok || mesh.ProtocolDetection == nil || !mesh.ProtocolDetection.AutoPopulate {
+		return nil
+	}
+
+	// A protocol in the proxy-defaults applies to every service which makes
+	// it configured everywhere.
+	_, proxyDefaults, err := state.ProxyDefaults(ws, entMeta)
+	if err != nil {
+		return err
+	}
+	if proxyDefaults != nil && proxyDefaults.Config["protocol"] != nil {
+		return nil
+	}
+
+	_, nodes, err := state.ServiceDump(ws, structs.ServiceKindTypical, true, structs.WildcardEnterpriseMetaInPartition(structs.WildcardSpecifier), structs.DefaultPeerKeyword)
+	if err != nil {
+		return err
+	}
+
+	// Collect the protocols reported by the proxies of the instances of every
+	// service.
+	reports := make(map[structs.ServiceName]*protocolReports)
+	byName := make(map[string]structs.ServiceName)
+	for _, csn := range nodes {
+		sn := csn.Service.CompoundServiceName()
+		r, ok := reports[sn]
+		if !ok {
+			r = &protocolReports{protocols: make(map[string]struct{})}
+			reports[sn] = r
+			byName[sn.String()] = sn
+		}
+		if reported := csn.Service.Meta[structs.MetaDetectedProtocol]; reported != "" {
+			r.protocols[reported] = struct{}{}
+		} else {
+			r.unreportedInstance = true
+		}
+	}
+
+	// And the protocols reported by the proxies of their downstreams.
+	_, proxies, err := state.ServiceDump(ws, structs.ServiceKindConnectProxy, true, structs.WildcardEnterpriseMetaInPartition(structs.WildcardSpecifier), structs.DefaultPeerKeyword)
+	if err != nil {
+		return err
+	}
+	for _, csn := range proxies {
+		value := csn.Service.Meta[structs.MetaDetectedUpstreamProtocols]
+		if value == "" {
+			continue
+		}
+		for name, protocol := range structs.ParseDetectedUpstreamProtocols(value) {
+			sn, ok := byName[name]
+			if !ok {
+				continue
+			}
+			reports[sn].protocols[protocol] = struct{}{}
+			reports[sn].downstreamReports = true
+		}
+	}
+
+	var merr error
+	for sn, r := range reports {
+		protocol := r.protocol()
+		if protocol == "" {
+			continue
+		}
+		if err := s.populateServiceProtocol(sn, protocol); err != nil {
+			merr = multierror.Append(merr, fmt.Errorf("service %q: %w", sn.String(), err))
+		}
+	}
+	return merr
+}
+
+// protocolReports are the protocols detected for a service by the proxies of
+// its instances and of its downstreams.
+type protocolReports struct {
+	protocols map[string]struct{}
+
+	// unreportedInstance is set when the proxy of an instance of the service
+	// didn't report a protocol.
+	unreportedInstance bool
+
+	// downstreamReports is set when the proxy of a downstream reported a
+	// protocol.
+	downstreamReports bool
+}
+
+// protocol returns the protocol all the reports agree on, or an empty string
+// when they disagree or are incomplete. Envoy proxies only detect the
+// protocol of the traffic to their upstreams, so instances proxied by Envoy
+// don't report a protocol themselves, which is then only complete when their
+// downstreams reported one.
+func (r *protocolReports) protocol() string {
+	if len(r.protocols) != 1 || (r.unreportedInstance && !r.downstreamReports) {
+		return ""
+	}
+	for protocol := range r.protocols {
+		return protocol
+	}
+	return ""
+}
+
+// populateServiceProtocol sets the protocol of the service-defaults of the
+// given service, unless a protocol is already configured.
+func (s *Server) populateServiceProtocol(sn structs.ServiceName, protocol string) error {
+	_, raw, err := s.fsm.State().ConfigEntry(nil, structs.ServiceDefaults, sn.Name, &sn.EnterpriseMeta)
+	if err != nil {
+		return err
+	}
+
+	var entry *structs.ServiceConfigEntry
+	if existing, ok := raw.(*structs.ServiceConfigEntry); ok {
+		if existing.Protocol != "" {
+			return nil
+		}
+		entry = existing.Clone()
+	} else {
+		entry = &structs.ServiceConfigEntry{
+			Kind:           structs.ServiceDefaults,
+			Name:           sn.Name,
+			EnterpriseMeta: sn.EnterpriseMeta,
+		}
+	}
+	entry.Protocol = protocol
+
+	if err := entry.Normalize(); err != nil {
+		return err
+	}
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	req := structs.ConfigEntryRequest{
+		Op:         structs.ConfigEntryUpsertCAS,
+		Datacenter: s.config.Datacenter,
+		Entry:      entry,
+	}
+	if _, err := s.leaderRaftApply("ConfigEntry.Apply", structs.ConfigEntryRequestType, &req); err != nil {
+		return err
+	}
+	s.loggers.Named(logging.ConfigEntry).Info("populated service protocol from detected protocol",
+		"service", sn.String(), "protocol", protocol)
+	return nil
+}
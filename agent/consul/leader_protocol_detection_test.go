@@ -0,0 +1,117 @@
+package consul
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+)
+
+func TestLeader_ProtocolDetection(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	_, s1 := testServer(t)
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Run the passes by hand rather than racing the leader routine.
+	s1.stopProtocolDetection()
+
+	registerInstance := func(id, service, protocol string) {
+		meta := map[string]string{}
+		if protocol != "" {
+			meta[structs.MetaDetectedProtocol] = protocol
+		}
+		req := structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       "node-" + id,
+			Address:    "127.0.0.1",
+			Service: &structs.NodeService{
+				ID:      id,
+				Service: service,
+				Port:    8080,
+				Meta:    meta,
+			},
+		}
+		var out struct{}
+		require.NoError(t, s1.RPC("Catalog.Register", &req, &out))
+	}
+	applyEntry := func(entry structs.ConfigEntry) {
+		req := structs.ConfigEntryRequest{Datacenter: "dc1", Entry: entry}
+		var out bool
+		require.NoError(t, s1.RPC("ConfigEntry.Apply", &req, &out))
+		require.True(t, out)
+	}
+	getProtocol := func(service string) string {
+		_, entry, err := s1.fsm.State().ConfigEntry(nil, structs.ServiceDefaults, service, nil)
+		require.NoError(t, err)
+		if entry == nil {
+			return ""
+		}
+		return entry.(*structs.ServiceConfigEntry).Protocol
+	}
+
+	registerInstance("web-1", "web", "http")
+	registerInstance("web-2", "web", "http")
+	registerInstance("api-1", "api", "grpc")
+	registerInstance("api-2", "api", "http2")
+	registerInstance("db-1", "db", "tcp")
+	registerInstance("db-2", "db", "")
+	registerInstance("billing-1", "billing", "http")
+	// The instances of cache and queue are proxied by Envoy which only
+	// reports the protocols of the traffic to its upstreams.
+	registerInstance("cache-1", "cache", "")
+	registerInstance("cache-2", "cache", "")
+	registerInstance("queue-1", "queue", "")
+
+	req := structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "node-frontend",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			Kind:    structs.ServiceKindConnectProxy,
+			ID:      "frontend-sidecar-proxy",
+			Service: "frontend-sidecar-proxy",
+			Port:    21000,
+			Proxy: structs.ConnectProxyConfig{
+				DestinationServiceName: "frontend",
+			},
+			Meta: map[string]string{
+				structs.MetaDetectedUpstreamProtocols: "api=http2,cache=http",
+			},
+		},
+	}
+	var out struct{}
+	require.NoError(t, s1.RPC("Catalog.Register", &req, &out))
+	applyEntry(&structs.ServiceConfigEntry{
+		Kind:     structs.ServiceDefaults,
+		Name:     "billing",
+		Protocol: "tcp",
+	})
+
+	// Nothing is written until enabled in the mesh config entry.
+	require.NoError(t, s1.populateDetectedProtocols(memdb.NewWatchSet()))
+	require.Equal(t, "", getProtocol("web"))
+
+	applyEntry(&structs.MeshConfigEntry{
+		ProtocolDetection: &structs.MeshProtocolDetectionConfig{AutoPopulate: true},
+	})
+	require.NoError(t, s1.populateDetectedProtocols(memdb.NewWatchSet()))
+
+	require.Equal(t, "http", getProtocol("web"))
+	// Instances disagree.
+	require.Equal(t, "", getProtocol("api"))
+	// Not all instances reported a protocol.
+	require.Equal(t, "", getProtocol("db"))
+	require.Equal(t, "", getProtocol("queue"))
+	// A downstream reported the protocol of instances proxied by Envoy.
+	require.Equal(t, "http", getProtocol("cache"))
+	// Configured protocols are never overwritten.
+	require.Equal(t, "tcp", getProtocol("billing"))
+}
@@ -0,0 +1,374 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-bexpr"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/logging"
+)
+
+var (
+	// trafficShiftInterval is the maximum time between two evaluations of
+	// the traffic-shift config entries. Entries are also evaluated whenever
+	// one of them changes.
+	trafficShiftInterval = 10 * time.Second
+)
+
+// errTrafficShiftCheckPending is wrapped by the errors of checks which can't
+// be evaluated yet. The shift then waits for the next evaluation instead of
+// rolling back.
+var errTrafficShiftCheckPending = errors.New("check pending")
+
+// trafficShiftCheck is run before every step of a traffic shift. Returning an
+// error aborts the shift and sends all traffic back to the FromSubset, unless
+// it wraps errTrafficShiftCheckPending.
+type trafficShiftCheck interface {
+	Check(ctx context.Context, entry *structs.TrafficShiftConfigEntry) error
+}
+
+// trafficShiftCheckFactory builds a trafficShiftCheck from a
+// structs.TrafficShiftCheck.
+type trafficShiftCheckFactory func(s *Server, config structs.TrafficShiftCheck) (trafficShiftCheck, error)
+
+// trafficShiftCheckTypes maps the Type of a structs.TrafficShiftCheck to its
+// implementation.
+var trafficShiftCheckTypes = map[string]trafficShiftCheckFactory{
+	structs.TrafficShiftCheckHealth:     newTrafficShiftHealthCheck,
+	structs.TrafficShiftCheckPrometheus: newTrafficShiftPrometheusCheck,
+}
+
+// startTrafficShiftController starts the leader routine driving traffic-shift
+// config entries. Config entries are only written in the primary datacenter,
+// so the controller doesn't run anywhere else.
+func (s *Server) startTrafficShiftController(ctx context.Context) {
+	if s.config.PrimaryDatacenter != "" && s.config.PrimaryDatacenter != s.config.Datacenter {
+		return
+	}
+	s.leaderRoutineManager.Start(ctx, trafficShiftRoutineName, s.runTrafficShiftController)
+}
+
+func (s *Server) stopTrafficShiftController() {
+	// will be a no-op when not started
+	s.leaderRoutineManager.Stop(trafficShiftRoutineName)
+}
+
+func (s *Server) runTrafficShiftController(ctx context.Context) error {
+	logger := s.loggers.Named(logging.TrafficShift)
+	evals := newTrafficShiftEvaluations()
+
+	for {
+		state := s.fsm.State()
+
+		ws := memdb.NewWatchSet()
+		ws.Add(state.AbandonCh())
+		ws.Add(ctx.Done())
+		ws.Add(evals.doneCh)
+
+		_, entries, err := state.ConfigEntriesByKind(ws, structs.TrafficShift, structs.WildcardEnterpriseMetaInPartition(structs.WildcardSpecifier))
+		if err != nil {
+			logger.Error("failed to list traffic shifts", "error", err)
+		} else if err := s.reconcileTrafficShifts(ctx, logger, evals, entries, time.Now()); err != nil {
+			logger.Error("failed to reconcile traffic shifts", "error", err)
+		}
+
+		ws.Watch(time.After(trafficShiftInterval))
+
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+	}
+}
+
+// reconcileTrafficShifts advances every running traffic shift whose step
+// interval has elapsed and whose checks have been evaluated.
+func (s *Server) reconcileTrafficShifts(ctx context.Context, logger hclog.Logger, evals *trafficShiftEvaluations, entries []structs.ConfigEntry, now time.Time) error {
+	var merr error
+	for _, raw := range entries {
+		entry, ok := raw.(*structs.TrafficShiftConfigEntry)
+		if !ok {
+			continue
+		}
+		if err := s.stepTrafficShift(ctx, logger, evals, entry, now); err != nil {
+			merr = multierror.Append(merr, fmt.Errorf("traffic shift %q: %w", entry.Name, err))
+		}
+	}
+	return merr
+}
+
+// stepTrafficShift moves one step forward in the given traffic shift, or rolls
+// it back if any of its checks fail. The checks run in the background and the
+// step is only taken once they have completed. The resulting weight is
+// written to the service-splitter of the service before the status of the
+// shift is updated.
+func (s *Server) stepTrafficShift(ctx context.Context, logger hclog.Logger, evals *trafficShiftEvaluations, entry *structs.TrafficShiftConfigEntry, now time.Time) error {
+	if entry.Status.Done() {
+		return nil
+	}
+
+	var status structs.TrafficShiftStatus
+	if entry.Status != nil {
+		if now.Sub(entry.Status.LastStep) < entry.StepInterval {
+			return nil
+		}
+		status = *entry.Status
+	}
+
+	ok, checkErr := evals.result(entry, func() error {
+		return s.runTrafficShiftChecks(ctx, entry)
+	})
+	if !ok {
+		return nil
+	}
+	if errors.Is(checkErr, errTrafficShiftCheckPending) {
+		logger.Debug("waiting for traffic shift checks", "service", entry.Name, "reason", checkErr)
+		return s.recordTrafficShiftReason(entry, checkErr.Error())
+	}
+
+	status.LastStep = now
+	status.Reason = ""
+	if err := checkErr; err != nil {
+		status.State = structs.TrafficShiftStateRolledBack
+		status.Weight = 0
+		status.Reason = err.Error()
+		logger.Warn("rolling back traffic shift", "service", entry.Name, "reason", err)
+	} else {
+		status.State = structs.TrafficShiftStateRunning
+		status.Weight += entry.StepWeight
+		if status.Weight >= 100 {
+			status.Weight = 100
+			status.State = structs.TrafficShiftStateCompleted
+		}
+		logger.Debug("shifting traffic", "service", entry.Name, "weight", status.Weight)
+	}
+
+	if err := s.applyTrafficShiftSplitter(entry, status.Weight); err != nil {
+		return s.setTrafficShiftReason(entry, fmt.Sprintf("failed to write service-splitter: %v", err))
+	}
+
+	updated := *entry
+	updated.Status = &status
+	return s.applyTrafficShift(&updated)
+}
+
+// applyTrafficShiftSplitter replaces the service-splitter of the service with
+// one sending weight percent of traffic to the ToSubset. It is written with a
+// check-and-set against the splitter it replaces so that a concurrent change
+// isn't silently overwritten.
+func (s *Server) applyTrafficShiftSplitter(entry *structs.TrafficShiftConfigEntry, weight float32) error {
+	splitter := entry.Splitter(weight)
+	if err := splitter.Normalize(); err != nil {
+		return err
+	}
+	if err := splitter.Validate(); err != nil {
+		return err
+	}
+
+	_, existing, err := s.fsm.State().ConfigEntry(nil, structs.ServiceSplitter, entry.Name, &entry.EnterpriseMeta)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		splitter.ModifyIndex = existing.GetRaftIndex().ModifyIndex
+	}
+
+	req := structs.ConfigEntryRequest{
+		Op:         structs.ConfigEntryUpsertCAS,
+		Datacenter: s.config.Datacenter,
+		Entry:      splitter,
+	}
+	resp, err := s.leaderRaftApply("ConfigEntry.Apply", structs.ConfigEntryRequestType, &req)
+	if err != nil {
+		return err
+	}
+	if ok, _ := resp.(bool); !ok {
+		return fmt.Errorf("the service-splitter was modified concurrently")
+	}
+	return nil
+}
+
+// setTrafficShiftReason records why a traffic shift couldn't make progress
+// without otherwise changing its status, and returns the reason as an error.
+func (s *Server) setTrafficShiftReason(entry *structs.TrafficShiftConfigEntry, reason string) error {
+	if err := s.recordTrafficShiftReason(entry, reason); err != nil {
+		return err
+	}
+	return fmt.Errorf("%s", reason)
+}
+
+// recordTrafficShiftReason updates the Reason of a traffic shift if it
+// changed, leaving the rest of its status alone.
+func (s *Server) recordTrafficShiftReason(entry *structs.TrafficShiftConfigEntry, reason string) error {
+	status := structs.TrafficShiftStatus{State: structs.TrafficShiftStateRunning}
+	if entry.Status != nil {
+		status = *entry.Status
+	}
+	if status.Reason == reason {
+		return nil
+	}
+	status.Reason = reason
+
+	updated := *entry
+	updated.Status = &status
+	return s.applyTrafficShift(&updated)
+}
+
+// trafficShiftEvaluations runs the checks of traffic shifts in the background
+// so that a slow check doesn't hold up the controller. Results are keyed by
+// the ModifyIndex of the evaluated entry so that the result of an evaluation
+// is dropped if the entry changed in the meantime.
+type trafficShiftEvaluations struct {
+	// doneCh receives a value whenever an evaluation completes.
+	doneCh chan struct{}
+
+	lock    sync.Mutex
+	running map[structs.ServiceName]bool
+	results map[structs.ServiceName]trafficShiftEvaluation
+}
+
+type trafficShiftEvaluation struct {
+	index uint64
+	err   error
+}
+
+func newTrafficShiftEvaluations() *trafficShiftEvaluations {
+	return &trafficShiftEvaluations{
+		doneCh:  make(chan struct{}, 1),
+		running: make(map[structs.ServiceName]bool),
+		results: make(map[structs.ServiceName]trafficShiftEvaluation),
+	}
+}
+
+// result returns true and the outcome of the checks of entry once they have
+// completed. Otherwise it starts evaluating them with run, unless that is
+// already in progress, and returns false.
+func (e *trafficShiftEvaluations) result(entry *structs.TrafficShiftConfigEntry, run func() error) (bool, error) {
+	sn := structs.NewServiceName(entry.Name, &entry.EnterpriseMeta)
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if res, ok := e.results[sn]; ok {
+		delete(e.results, sn)
+		if res.index == entry.ModifyIndex {
+			return true, res.err
+		}
+	}
+	if e.running[sn] {
+		return false, nil
+	}
+
+	e.running[sn] = true
+	index := entry.ModifyIndex
+	go func() {
+		err := run()
+
+		e.lock.Lock()
+		delete(e.running, sn)
+		e.results[sn] = trafficShiftEvaluation{index: index, err: err}
+		e.lock.Unlock()
+
+		select {
+		case e.doneCh <- struct{}{}:
+		default:
+		}
+	}()
+	return false, nil
+}
+
+// applyTrafficShift writes the traffic shift back using a check-and-set so
+// that concurrent changes made by users aren't overwritten.
+func (s *Server) applyTrafficShift(entry *structs.TrafficShiftConfigEntry) error {
+	req := structs.ConfigEntryRequest{
+		Op:         structs.ConfigEntryUpsertCAS,
+		Datacenter: s.config.Datacenter,
+		Entry:      entry,
+	}
+	_, err := s.leaderRaftApply("ConfigEntry.Apply", structs.ConfigEntryRequestType, &req)
+	return err
+}
+
+func (s *Server) runTrafficShiftChecks(ctx context.Context, entry *structs.TrafficShiftConfigEntry) error {
+	for _, c := range entry.Checks {
+		factory, ok := trafficShiftCheckTypes[c.Type]
+		if !ok {
+			return fmt.Errorf("unknown check type %q", c.Type)
+		}
+		check, err := factory(s, c)
+		if err != nil {
+			return fmt.Errorf("invalid %s check: %w", c.Type, err)
+		}
+		if err := check.Check(ctx, entry); err != nil {
+			return fmt.Errorf("%s check failed: %w", c.Type, err)
+		}
+	}
+	return nil
+}
+
+// trafficShiftHealthCheck fails when the ToSubset of a traffic shift has no
+// instances or any of them is unhealthy.
+type trafficShiftHealthCheck struct {
+	structs.TrafficShiftHealthCheckConfig
+
+	srv *Server
+}
+
+func newTrafficShiftHealthCheck(s *Server, c structs.TrafficShiftCheck) (trafficShiftCheck, error) {
+	config, err := c.HealthConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &trafficShiftHealthCheck{TrafficShiftHealthCheckConfig: *config, srv: s}, nil
+}
+
+func (c *trafficShiftHealthCheck) Check(_ context.Context, entry *structs.TrafficShiftConfigEntry) error {
+	state := c.srv.fsm.State()
+
+	_, nodes, err := state.CheckServiceNodes(nil, entry.Name, &entry.EnterpriseMeta, structs.DefaultPeerKeyword)
+	if err != nil {
+		return err
+	}
+
+	_, raw, err := state.ConfigEntry(nil, structs.ServiceResolver, entry.Name, &entry.EnterpriseMeta)
+	if err != nil {
+		return err
+	}
+	resolver, ok := raw.(*structs.ServiceResolverConfigEntry)
+	if !ok {
+		return fmt.Errorf("service %q has no service-resolver", entry.Name)
+	}
+	subset, ok := resolver.GetSubset(entry.ToSubset)
+	if !ok {
+		return fmt.Errorf("subset %q is not defined for service %q", entry.ToSubset, entry.Name)
+	}
+	if subset.Filter != "" {
+		filter, err := bexpr.CreateFilter(subset.Filter, nil, nodes)
+		if err != nil {
+			return err
+		}
+		filtered, err := filter.Execute(nodes)
+		if err != nil {
+			return err
+		}
+		nodes = filtered.(structs.CheckServiceNodes)
+	}
+
+	total := len(nodes)
+	if total == 0 {
+		return fmt.Errorf("subset %q has no instances", entry.ToSubset)
+	}
+	if healthy := len(nodes.Filter(c.OnlyPassing || subset.OnlyPassing)); healthy < total {
+		return fmt.Errorf("%d of %d instances in subset %q are unhealthy", total-healthy, total, entry.ToSubset)
+	}
+	return nil
+}
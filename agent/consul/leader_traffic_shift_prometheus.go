@@ -0,0 +1,203 @@
+package consul
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+const (
+	// maxPrometheusResponseSize bounds the size of the query responses read
+	// by the leader.
+	maxPrometheusResponseSize = 4 << 20
+)
+
+// prometheusCheckClient is used by the Prometheus checks instead of the
+// default client so that a hung server can't hold up the check past its
+// maximum timeout. Redirects aren't followed as they could lead the leader
+// away from the allowed addresses.
+var prometheusCheckClient = &http.Client{
+	Timeout: structs.MaxTrafficShiftPrometheusTimeout,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// trafficShiftPrometheusCheck runs a query against a Prometheus compatible
+// HTTP API and fails when the result is outside of the configured bounds. It
+// is typically used to compare the error rate or latency of the ToSubset
+// against an SLO between the steps of a traffic shift.
+type trafficShiftPrometheusCheck struct {
+	structs.TrafficShiftPrometheusCheckConfig
+
+	// query is the parsed Query, rendered with the fields of
+	// trafficShiftQueryData.
+	query *template.Template
+}
+
+// trafficShiftQueryData is passed to the query template of a Prometheus check.
+type trafficShiftQueryData struct {
+	Service    string
+	FromSubset string
+	ToSubset   string
+	Namespace  string
+	Partition  string
+}
+
+func newTrafficShiftPrometheusCheck(s *Server, c structs.TrafficShiftCheck) (trafficShiftCheck, error) {
+	config, err := c.PrometheusConfig()
+	if err != nil {
+		return nil, err
+	}
+	if !prometheusAddressAllowed(s.config.TrafficShiftPrometheusAddresses, config.Address) {
+		return nil, fmt.Errorf("Address %q is not one of connect.traffic_shift_prometheus_addresses", config.Address)
+	}
+	query, err := config.QueryTemplate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid Query: %w", err)
+	}
+	return &trafficShiftPrometheusCheck{TrafficShiftPrometheusCheckConfig: *config, query: query}, nil
+}
+
+func (c *trafficShiftPrometheusCheck) Check(ctx context.Context, entry *structs.TrafficShiftConfigEntry) error {
+	var query bytes.Buffer
+	err := c.query.Execute(&query, trafficShiftQueryData{
+		Service:    entry.Name,
+		FromSubset: entry.FromSubset,
+		ToSubset:   entry.ToSubset,
+		Namespace:  entry.NamespaceOrDefault(),
+		Partition:  entry.PartitionOrDefault(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render query: %w", err)
+	}
+
+	values, err := c.run(ctx, query.String())
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		// Nothing has been sent to the ToSubset before the first step so
+		// there is nothing to measure yet. Later on the metrics may simply
+		// not have been scraped, so the shift waits rather than rolls back.
+		if entry.Status == nil || entry.Status.Weight == 0 {
+			return nil
+		}
+		return fmt.Errorf("%w: query returned no data", errTrafficShiftCheckPending)
+	}
+	for _, v := range values {
+		if math.IsNaN(v) {
+			return fmt.Errorf("query result is NaN")
+		}
+		if c.Min != nil && v < *c.Min {
+			return fmt.Errorf("query result %v is below the minimum of %v", v, *c.Min)
+		}
+		if c.Max != nil && v > *c.Max {
+			return fmt.Errorf("query result %v is above the maximum of %v", v, *c.Max)
+		}
+	}
+	return nil
+}
+
+// prometheusQueryResponse is the response of the /api/v1/query endpoint.
+type prometheusQueryResponse struct {
+	Status string
+	Error  string
+	Data   struct {
+		ResultType string
+		Result     json.RawMessage
+	}
+}
+
+// run executes an instant query and returns the values of the resulting
+// vector or scalar.
+func (c *trafficShiftPrometheusCheck) run(ctx context.Context, query string) ([]float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	u := strings.TrimSuffix(c.Address, "/") + "/api/v1/query?" + url.Values{"query": {query}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := prometheusCheckClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out prometheusQueryResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxPrometheusResponseSize)).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode query response (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if out.Status != "success" {
+		return nil, fmt.Errorf("query failed: %s", out.Error)
+	}
+
+	switch out.Data.ResultType {
+	case "scalar":
+		var sample []interface{}
+		if err := json.Unmarshal(out.Data.Result, &sample); err != nil {
+			return nil, err
+		}
+		v, err := prometheusSampleValue(sample)
+		if err != nil {
+			return nil, err
+		}
+		return []float64{v}, nil
+
+	case "vector":
+		var series []struct {
+			Value []interface{}
+		}
+		if err := json.Unmarshal(out.Data.Result, &series); err != nil {
+			return nil, err
+		}
+		values := make([]float64, 0, len(series))
+		for _, s := range series {
+			v, err := prometheusSampleValue(s.Value)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported query result type %q", out.Data.ResultType)
+	}
+}
+
+// prometheusAddressAllowed returns whether address is one of the allowed
+// Prometheus addresses, ignoring trailing slashes.
+func prometheusAddressAllowed(allowed []string, address string) bool {
+	address = strings.TrimSuffix(address, "/")
+	for _, a := range allowed {
+		if strings.TrimSuffix(a, "/") == address {
+			return true
+		}
+	}
+	return false
+}
+
+// prometheusSampleValue parses a [timestamp, "value"] sample.
+func prometheusSampleValue(sample []interface{}) (float64, error) {
+	if len(sample) != 2 {
+		return 0, fmt.Errorf("malformed sample %v", sample)
+	}
+	s, ok := sample[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("malformed sample value %v", sample[1])
+	}
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,141 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestTrafficShiftPrometheusCheck(t *testing.T) {
+	var lastQuery string
+	var response string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/query", r.URL.Path)
+		lastQuery = r.URL.Query().Get("query")
+		fmt.Fprint(w, response)
+	}))
+	defer srv.Close()
+
+	entry := &structs.TrafficShiftConfigEntry{
+		Name:       "web",
+		FromSubset: "v1",
+		ToSubset:   "v2",
+		Status:     &structs.TrafficShiftStatus{State: structs.TrafficShiftStateRunning, Weight: 20},
+	}
+	s := &Server{config: &Config{TrafficShiftPrometheusAddresses: []string{srv.URL + "/"}}}
+
+	vector := func(values ...string) string {
+		result := ""
+		for i, v := range values {
+			if i > 0 {
+				result += ","
+			}
+			result += fmt.Sprintf(`{"metric":{},"value":[1658312400,%q]}`, v)
+		}
+		return `{"status":"success","data":{"resultType":"vector","result":[` + result + `]}}`
+	}
+
+	cases := map[string]struct {
+		config   map[string]interface{}
+		response string
+		err      string
+	}{
+		"within bounds": {
+			config:   map[string]interface{}{"Max": 0.05},
+			response: vector("0.01", "0.02"),
+		},
+		"above maximum": {
+			config:   map[string]interface{}{"Max": "0.05"},
+			response: vector("0.01", "0.2"),
+			err:      "query result 0.2 is above the maximum of 0.05",
+		},
+		"below minimum": {
+			config:   map[string]interface{}{"Min": 0.99},
+			response: `{"status":"success","data":{"resultType":"scalar","result":[1658312400,"0.9"]}}`,
+			err:      "query result 0.9 is below the minimum of 0.99",
+		},
+		"no data": {
+			config:   map[string]interface{}{"Max": 0.05},
+			response: vector(),
+			err:      "check pending: query returned no data",
+		},
+		"NaN": {
+			config:   map[string]interface{}{"Max": 0.05},
+			response: vector("NaN"),
+			err:      "query result is NaN",
+		},
+		"query error": {
+			config:   map[string]interface{}{"Max": 0.05},
+			response: `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+			err:      "query failed: parse error",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			config := map[string]interface{}{
+				"Address": srv.URL,
+				"Query":   `sum(rate(errors{service="{{.Service}}",subset="{{.ToSubset}}"}[1m]))`,
+				"Timeout": "5s",
+			}
+			for k, v := range tc.config {
+				config[k] = v
+			}
+			check, err := newTrafficShiftPrometheusCheck(s, prometheusCheck(config))
+			require.NoError(t, err)
+
+			response = tc.response
+			err = check.Check(context.Background(), entry)
+			require.Equal(t, `sum(rate(errors{service="web",subset="v2"}[1m]))`, lastQuery)
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.err)
+			}
+		})
+	}
+
+	t.Run("no data before the first step", func(t *testing.T) {
+		check, err := newTrafficShiftPrometheusCheck(s, prometheusCheck(map[string]interface{}{"Address": srv.URL, "Query": "up", "Max": 1}))
+		require.NoError(t, err)
+
+		response = vector()
+		require.NoError(t, check.Check(context.Background(), &structs.TrafficShiftConfigEntry{Name: "web"}))
+	})
+
+	t.Run("redirects are not followed", func(t *testing.T) {
+		redirect := httptest.NewServer(http.RedirectHandler(srv.URL+"/api/v1/query", http.StatusFound))
+		defer redirect.Close()
+		s := &Server{config: &Config{TrafficShiftPrometheusAddresses: []string{redirect.URL}}}
+
+		check, err := newTrafficShiftPrometheusCheck(s, prometheusCheck(map[string]interface{}{"Address": redirect.URL, "Query": "up", "Max": 1}))
+		require.NoError(t, err)
+		err = check.Check(context.Background(), entry)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to decode query response (HTTP 302)")
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := newTrafficShiftPrometheusCheck(s, prometheusCheck(map[string]interface{}{"Address": srv.URL, "Query": "up"}))
+		require.EqualError(t, err, "at least one of Min or Max is required")
+
+		_, err = newTrafficShiftPrometheusCheck(s, prometheusCheck(map[string]interface{}{"Query": "up", "Max": 1}))
+		require.EqualError(t, err, "Address is required")
+
+		_, err = newTrafficShiftPrometheusCheck(s, prometheusCheck(map[string]interface{}{"Address": "http://169.254.169.254", "Query": "up", "Max": 1}))
+		require.EqualError(t, err, `Address "http://169.254.169.254" is not one of connect.traffic_shift_prometheus_addresses`)
+
+		_, err = newTrafficShiftPrometheusCheck(s, prometheusCheck(map[string]interface{}{"Address": srv.URL, "Query": "up", "Max": 1, "Timeout": "1h"}))
+		require.EqualError(t, err, "Timeout must be positive and at most 1m0s")
+	})
+}
+
+func prometheusCheck(config map[string]interface{}) structs.TrafficShiftCheck {
+	return structs.TrafficShiftCheck{Type: structs.TrafficShiftCheckPrometheus, Config: config}
+}
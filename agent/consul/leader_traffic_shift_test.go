@@ -0,0 +1,221 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+)
+
+func TestLeader_TrafficShift(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	var promResponse string
+	prom := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, promResponse)
+	}))
+	defer prom.Close()
+
+	_, s1 := testServerWithConfig(t, func(c *Config) {
+		c.TrafficShiftPrometheusAddresses = []string{prom.URL}
+	})
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Drive the steps by hand rather than racing the leader routine.
+	s1.stopTrafficShiftController()
+
+	registerInstance := func(id, version, status string) {
+		req := structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       "node-" + id,
+			Address:    "127.0.0.1",
+			Service: &structs.NodeService{
+				ID:      id,
+				Service: "web",
+				Port:    8080,
+				Meta:    map[string]string{"version": version},
+			},
+			Check: &structs.HealthCheck{
+				Node:      "node-" + id,
+				CheckID:   "web-check",
+				Name:      "web-check",
+				Status:    status,
+				ServiceID: id,
+			},
+		}
+		var out struct{}
+		require.NoError(t, s1.RPC("Catalog.Register", &req, &out))
+	}
+	applyEntry := func(entry structs.ConfigEntry) {
+		req := structs.ConfigEntryRequest{Datacenter: "dc1", Entry: entry}
+		var out bool
+		require.NoError(t, s1.RPC("ConfigEntry.Apply", &req, &out))
+		require.True(t, out)
+	}
+	getShift := func() *structs.TrafficShiftConfigEntry {
+		_, entry, err := s1.fsm.State().ConfigEntry(nil, structs.TrafficShift, "web", nil)
+		require.NoError(t, err)
+		return entry.(*structs.TrafficShiftConfigEntry)
+	}
+	getWeights := func() []float32 {
+		_, entry, err := s1.fsm.State().ConfigEntry(nil, structs.ServiceSplitter, "web", nil)
+		require.NoError(t, err)
+		require.NotNil(t, entry)
+		splitter := entry.(*structs.ServiceSplitterConfigEntry)
+		var weights []float32
+		for _, split := range splitter.Splits {
+			weights = append(weights, split.Weight)
+		}
+		return weights
+	}
+	evals := newTrafficShiftEvaluations()
+	reconcile := func(now time.Time) {
+		require.NoError(t, s1.reconcileTrafficShifts(context.Background(), hclog.NewNullLogger(),
+			evals, []structs.ConfigEntry{getShift()}, now))
+	}
+	// step reconciles the traffic shift and, if that started evaluating its
+	// checks, waits for them and reconciles it again to apply the result.
+	step := func(now time.Time) {
+		reconcile(now)
+
+		evals.lock.Lock()
+		running := len(evals.running) > 0
+		evals.lock.Unlock()
+		if !running {
+			return
+		}
+		select {
+		case <-evals.doneCh:
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for the traffic shift checks")
+		}
+		reconcile(now)
+	}
+
+	registerInstance("web-v1", "v1", api.HealthPassing)
+	registerInstance("web-v2", "v2", api.HealthPassing)
+
+	applyEntry(&structs.ServiceConfigEntry{Kind: structs.ServiceDefaults, Name: "web", Protocol: "http"})
+	applyEntry(&structs.ServiceResolverConfigEntry{
+		Kind: structs.ServiceResolver,
+		Name: "web",
+		Subsets: map[string]structs.ServiceResolverSubset{
+			"v1": {Filter: `Service.Meta.version == v1`},
+			"v2": {Filter: `Service.Meta.version == v2`},
+		},
+	})
+	applyEntry(&structs.TrafficShiftConfigEntry{
+		Name:         "web",
+		FromSubset:   "v1",
+		ToSubset:     "v2",
+		StepWeight:   40,
+		StepInterval: time.Minute,
+		Checks:       []structs.TrafficShiftCheck{{Type: structs.TrafficShiftCheckHealth}},
+	})
+
+	start := time.Now()
+
+	step(start)
+	require.Equal(t, []float32{60, 40}, getWeights())
+	require.Equal(t, structs.TrafficShiftStateRunning, getShift().Status.State)
+
+	// Nothing happens until the step interval has elapsed.
+	step(start.Add(30 * time.Second))
+	require.Equal(t, []float32{60, 40}, getWeights())
+
+	step(start.Add(time.Minute))
+	require.Equal(t, []float32{20, 80}, getWeights())
+
+	step(start.Add(2 * time.Minute))
+	require.Equal(t, []float32{0, 100}, getWeights())
+	status := getShift().Status
+	require.Equal(t, structs.TrafficShiftStateCompleted, status.State)
+	require.Equal(t, float32(100), status.Weight)
+
+	// Completed shifts are left alone.
+	step(start.Add(time.Hour))
+	require.Equal(t, []float32{0, 100}, getWeights())
+
+	t.Run("rollback", func(t *testing.T) {
+		// Rewriting the entry restarts the shift.
+		applyEntry(&structs.TrafficShiftConfigEntry{
+			Name:         "web",
+			FromSubset:   "v1",
+			ToSubset:     "v2",
+			StepWeight:   40,
+			StepInterval: time.Minute,
+			Checks:       []structs.TrafficShiftCheck{{Type: structs.TrafficShiftCheckHealth}},
+		})
+
+		step(start)
+		require.Equal(t, []float32{60, 40}, getWeights())
+
+		registerInstance("web-v2", "v2", api.HealthCritical)
+
+		step(start.Add(time.Minute))
+		require.Equal(t, []float32{100, 0}, getWeights())
+		status := getShift().Status
+		require.Equal(t, structs.TrafficShiftStateRolledBack, status.State)
+		require.Equal(t, float32(0), status.Weight)
+		require.Contains(t, status.Reason, `1 of 1 instances in subset "v2" are unhealthy`)
+	})
+
+	t.Run("status is only set by the leader", func(t *testing.T) {
+		applyEntry(&structs.TrafficShiftConfigEntry{
+			Name:       "web",
+			FromSubset: "v1",
+			ToSubset:   "v2",
+			Status:     &structs.TrafficShiftStatus{State: structs.TrafficShiftStateCompleted, Weight: 100},
+		})
+		require.Nil(t, getShift().Status)
+	})
+
+	t.Run("pending", func(t *testing.T) {
+		applyEntry(&structs.TrafficShiftConfigEntry{
+			Name:         "web",
+			FromSubset:   "v1",
+			ToSubset:     "v2",
+			StepWeight:   40,
+			StepInterval: time.Minute,
+			Checks: []structs.TrafficShiftCheck{{
+				Type: structs.TrafficShiftCheckPrometheus,
+				Config: map[string]interface{}{
+					"Address": prom.URL,
+					"Query":   "errors",
+					"Max":     0.05,
+				},
+			}},
+		})
+		noData := `{"status":"success","data":{"resultType":"vector","result":[]}}`
+
+		// There is no traffic to measure before the first step.
+		promResponse = noData
+		step(start)
+		require.Equal(t, []float32{60, 40}, getWeights())
+
+		// Missing data after that holds the shift rather than rolling it back.
+		step(start.Add(time.Minute))
+		require.Equal(t, []float32{60, 40}, getWeights())
+		status := getShift().Status
+		require.Equal(t, structs.TrafficShiftStateRunning, status.State)
+		require.Contains(t, status.Reason, "query returned no data")
+
+		promResponse = `{"status":"success","data":{"resultType":"scalar","result":[1658312400,"0.01"]}}`
+		step(start.Add(2 * time.Minute))
+		require.Equal(t, []float32{20, 80}, getWeights())
+		require.Empty(t, getShift().Status.Reason)
+	})
+}
@@ -53,7 +53,29 @@ func TestMerge_OSS_LAN(t *testing.T) {
 					segment: "alpha",
 				}),
 			},
-			expect: `Member 'node1' part of segment 'alpha'; Network Segments are a Consul Enterprise feature`,
+			expect: `Member 'node1' part of wrong segment 'alpha' (expected '')`,
+		},
+		"node in the same segment": {
+			segment: "alpha",
+			members: []*serf.Member{
+				makeTestNode(t, testMember{
+					dc:      "dc1",
+					name:    "node1",
+					build:   "0.7.5",
+					segment: "alpha",
+				}),
+			},
+		},
+		"node in the default segment joining a segment": {
+			segment: "alpha",
+			members: []*serf.Member{
+				makeTestNode(t, testMember{
+					dc:    "dc1",
+					name:  "node1",
+					build: "0.7.5",
+				}),
+			},
+			expect: `Member 'node1' part of wrong segment '' (expected 'alpha')`,
 		},
 		"node in a partition": {
 			members: []*serf.Member{
@@ -24,7 +24,10 @@ func mergeNodeServiceWithCentralConfig(
 	logger hclog.Logger) (uint64, *structs.NodeService, error) {
 
 	serviceName := ns.Service
-	var upstreams []structs.ServiceID
+	var (
+		upstreams       []structs.ServiceID
+		peeredUpstreams []structs.PeeredServiceName
+	)
 	if ns.IsSidecarProxy() {
 		// This is a sidecar proxy, ignore the proxy service's config since we are
 		// managed by the target service config.
@@ -36,19 +39,27 @@ func mergeNodeServiceWithCentralConfig(
 			if us.DestinationType == "" || us.DestinationType == structs.UpstreamDestTypeService {
 				sid := us.DestinationID()
 				sid.EnterpriseMeta.Merge(&ns.EnterpriseMeta)
+				if us.DestinationPeer != "" {
+					peeredUpstreams = append(peeredUpstreams, structs.PeeredServiceName{
+						Peer:        us.DestinationPeer,
+						ServiceName: structs.NewServiceName(sid.ID, &sid.EnterpriseMeta),
+					})
+					continue
+				}
 				upstreams = append(upstreams, sid)
 			}
 		}
 	}
 
 	configReq := &structs.ServiceConfigRequest{
-		Name:           serviceName,
-		Datacenter:     args.Datacenter,
-		QueryOptions:   args.QueryOptions,
-		MeshGateway:    ns.Proxy.MeshGateway,
-		Mode:           ns.Proxy.Mode,
-		UpstreamIDs:    upstreams,
-		EnterpriseMeta: ns.EnterpriseMeta,
+		Name:            serviceName,
+		Datacenter:      args.Datacenter,
+		QueryOptions:    args.QueryOptions,
+		MeshGateway:     ns.Proxy.MeshGateway,
+		Mode:            ns.Proxy.Mode,
+		UpstreamIDs:     upstreams,
+		PeeredUpstreams: peeredUpstreams,
+		EnterpriseMeta:  ns.EnterpriseMeta,
 	}
 
 	// prefer using this vs directly calling the ConfigEntry.ResolveServiceConfig RPC
@@ -103,7 +114,7 @@ func computeResolvedServiceConfig(
 	// blocking query, this function will be rerun and these state store lookups will both be current.
 	// We use the default enterprise meta to look up the global proxy defaults because they are not namespaced.
 	var proxyConfGlobalProtocol string
-	proxyConf := entries.GetProxyDefaults(args.PartitionOrDefault())
+	proxyConf := entries.GetProxyDefaults(&args.EnterpriseMeta)
 	if proxyConf != nil {
 		// Apply the proxy defaults to the sidecar's proxy config
 		mapCopy, err := copystructure.Copy(proxyConf.Config)
@@ -146,6 +157,40 @@ func computeResolvedServiceConfig(
 			}
 			thisReply.ProxyConfig["protocol"] = serviceConf.Protocol
 		}
+		if serviceConf.MaxInboundConnections > 0 {
+			if thisReply.ProxyConfig == nil {
+				thisReply.ProxyConfig = make(map[string]interface{})
+			}
+			thisReply.ProxyConfig["max_inbound_connections"] = serviceConf.MaxInboundConnections
+		}
+		if serviceConf.InboundConnectionBufferLimitBytes > 0 {
+			if thisReply.ProxyConfig == nil {
+				thisReply.ProxyConfig = make(map[string]interface{})
+			}
+			thisReply.ProxyConfig["inbound_connection_buffer_limit_bytes"] = serviceConf.InboundConnectionBufferLimitBytes
+		}
+		if serviceConf.MaxRequestBodyBytes > 0 {
+			if thisReply.ProxyConfig == nil {
+				thisReply.ProxyConfig = make(map[string]interface{})
+			}
+			thisReply.ProxyConfig["max_request_body_bytes"] = serviceConf.MaxRequestBodyBytes
+		}
+		if serviceConf.LocalAppHTTP2 != nil {
+			if thisReply.ProxyConfig == nil {
+				thisReply.ProxyConfig = make(map[string]interface{})
+			}
+			for k, v := range serviceConf.LocalAppHTTP2.ProxyConfig() {
+				thisReply.ProxyConfig[k] = v
+			}
+		}
+		if serviceConf.RequestNormalization != nil {
+			if thisReply.ProxyConfig == nil {
+				thisReply.ProxyConfig = make(map[string]interface{})
+			}
+			for k, v := range serviceConf.RequestNormalization.ProxyConfig() {
+				thisReply.ProxyConfig[k] = v
+			}
+		}
 		if serviceConf.TransparentProxy.OutboundListenerPort != 0 {
 			thisReply.TransparentProxy.OutboundListenerPort = serviceConf.TransparentProxy.OutboundListenerPort
 		}
@@ -165,8 +210,12 @@ func computeResolvedServiceConfig(
 	// - Implicitly from centralized upstream config in service-defaults
 	seenUpstreams := map[structs.ServiceID]struct{}{}
 
+	// Upstreams imported from peers are tracked apart from the local ones since
+	// only the overrides targeting their peer apply to them.
+	seenPeeredUpstreams := map[structs.PeeredServiceName]struct{}{}
+
 	var (
-		noUpstreamArgs = len(upstreamIDs) == 0 && len(args.Upstreams) == 0
+		noUpstreamArgs = len(upstreamIDs) == 0 && len(args.Upstreams) == 0 && len(args.PeeredUpstreams) == 0
 
 		// Check the args and the resolved value. If it was exclusively set via a config entry, then args.Mode
 		// will never be transparent because the service config request does not use the resolved value.
@@ -186,13 +235,19 @@ func computeResolvedServiceConfig(
 			seenUpstreams[sid] = struct{}{}
 		}
 	}
+	for _, psn := range args.PeeredUpstreams {
+		seenPeeredUpstreams[psn] = struct{}{}
+	}
 
 	// Then store upstreams inferred from service-defaults and mapify the overrides.
 	var (
-		upstreamConfigs  = make(map[structs.ServiceID]*structs.UpstreamConfig)
-		upstreamDefaults *structs.UpstreamConfig
+		upstreamConfigs       = make(map[structs.ServiceID]*structs.UpstreamConfig)
+		peeredUpstreamConfigs = make(map[structs.PeeredServiceName]*structs.UpstreamConfig)
+		upstreamDefaults      *structs.UpstreamConfig
 		// usConfigs stores the opaque config map for each upstream and is keyed on the upstream's ID.
 		usConfigs = make(map[structs.ServiceID]map[string]interface{})
+		// peeredUSConfigs stores the opaque config map for each upstream imported from a peer.
+		peeredUSConfigs = make(map[structs.PeeredServiceName]map[string]interface{})
 	)
 	if serviceConf != nil && serviceConf.UpstreamConfig != nil {
 		for i, override := range serviceConf.UpstreamConfig.Overrides {
@@ -206,6 +261,11 @@ func computeResolvedServiceConfig(
 				)
 				continue // skip this impossible condition
 			}
+			if override.Peer != "" {
+				seenPeeredUpstreams[override.PeeredServiceName()] = struct{}{}
+				peeredUpstreamConfigs[override.PeeredServiceName()] = override
+				continue
+			}
 			seenUpstreams[override.ServiceID()] = struct{}{}
 			upstreamConfigs[override.ServiceID()] = override
 		}
@@ -271,8 +331,32 @@ func computeResolvedServiceConfig(
 		}
 	}
 
+	for upstream := range seenPeeredUpstreams {
+		resolvedCfg := make(map[string]interface{})
+
+		// The service-defaults of an imported upstream are managed by the
+		// exporting cluster, so its protocol is only defaulted from the
+		// proxy-defaults and the upstream config of the downstream.
+		if proxyConfGlobalProtocol != "" {
+			resolvedCfg["protocol"] = proxyConfGlobalProtocol
+		}
+		if upstreamDefaults != nil {
+			upstreamDefaults.MergeInto(resolvedCfg)
+		}
+		if !args.MeshGateway.IsZero() {
+			resolvedCfg["mesh_gateway"] = args.MeshGateway
+		}
+		if peeredUpstreamConfigs[upstream] != nil {
+			peeredUpstreamConfigs[upstream].MergeInto(resolvedCfg)
+		}
+
+		if len(resolvedCfg) > 0 {
+			peeredUSConfigs[upstream] = resolvedCfg
+		}
+	}
+
 	// don't allocate the slices just to not fill them
-	if len(usConfigs) == 0 {
+	if len(usConfigs) == 0 && len(peeredUSConfigs) == 0 {
 		return &thisReply, nil
 	}
 
@@ -285,12 +369,20 @@ func computeResolvedServiceConfig(
 		}
 
 	} else {
-		thisReply.UpstreamIDConfigs = make(structs.OpaqueUpstreamConfigs, 0, len(usConfigs))
+		thisReply.UpstreamIDConfigs = make(structs.OpaqueUpstreamConfigs, 0, len(usConfigs)+len(peeredUSConfigs))
 
 		for us, conf := range usConfigs {
 			thisReply.UpstreamIDConfigs = append(thisReply.UpstreamIDConfigs,
 				structs.OpaqueUpstreamConfig{Upstream: us, Config: conf})
 		}
+		for us, conf := range peeredUSConfigs {
+			thisReply.UpstreamIDConfigs = append(thisReply.UpstreamIDConfigs,
+				structs.OpaqueUpstreamConfig{
+					Upstream: structs.NewServiceID(us.ServiceName.Name, &us.ServiceName.EnterpriseMeta),
+					Peer:     us.Peer,
+					Config:   conf,
+				})
+		}
 	}
 
 	return &thisReply, nil
@@ -335,7 +427,9 @@ func MergeServiceConfig(defaults *structs.ServiceConfigResponse, service *struct
 	}
 
 	// remoteUpstreams contains synthetic Upstreams generated from central config (service-defaults.UpstreamConfigs).
-	remoteUpstreams := make(map[structs.ServiceID]structs.Upstream)
+	// They are keyed on the upstream's name and peer, so that the config of an upstream imported from a peer
+	// isn't merged into the local upstream of the same name.
+	remoteUpstreams := make(map[structs.PeeredServiceName]structs.Upstream)
 
 	for _, us := range defaults.UpstreamIDConfigs {
 		parsed, err := structs.ParseUpstreamConfigNoDefaults(us.Config)
@@ -343,7 +437,7 @@ func MergeServiceConfig(defaults *structs.ServiceConfigResponse, service *struct
 			return nil, fmt.Errorf("failed to parse upstream config map for %s: %v", us.Upstream.String(), err)
 		}
 
-		remoteUpstreams[us.Upstream] = structs.Upstream{
+		remote := structs.Upstream{
 			DestinationNamespace: us.Upstream.NamespaceOrDefault(),
 			DestinationPartition: us.Upstream.PartitionOrDefault(),
 			DestinationName:      us.Upstream.ID,
@@ -351,12 +445,18 @@ func MergeServiceConfig(defaults *structs.ServiceConfigResponse, service *struct
 			MeshGateway:          parsed.MeshGateway,
 			CentrallyConfigured:  true,
 		}
+		if us.Peer != "" {
+			// Imported upstreams are addressed through the peering rather than a partition.
+			remote.DestinationPartition = ""
+			remote.DestinationPeer = us.Peer
+		}
+		remoteUpstreams[upstreamKey(us.Peer, us.Upstream)] = remote
 	}
 
 	// localUpstreams stores the upstreams seen from the local registration so that we can merge in the synthetic entries.
 	// In transparent proxy mode ns.Proxy.Upstreams will likely be empty because users do not need to define upstreams explicitly.
 	// So to store upstream-specific flags from central config, we add entries to ns.Proxy.Upstream with those values.
-	localUpstreams := make(map[structs.ServiceID]struct{})
+	localUpstreams := make(map[structs.PeeredServiceName]struct{})
 
 	// Merge upstream defaults into the local registration
 	for i := range ns.Proxy.Upstreams {
@@ -365,9 +465,10 @@ func MergeServiceConfig(defaults *structs.ServiceConfigResponse, service *struct
 		if us.DestinationType != "" && us.DestinationType != structs.UpstreamDestTypeService {
 			continue
 		}
-		localUpstreams[us.DestinationID()] = struct{}{}
+		key := upstreamKey(us.DestinationPeer, us.DestinationID())
+		localUpstreams[key] = struct{}{}
 
-		remoteCfg, ok := remoteUpstreams[us.DestinationID()]
+		remoteCfg, ok := remoteUpstreams[key]
 		if !ok {
 			// No config defaults to merge
 			continue
@@ -406,3 +507,12 @@ func MergeServiceConfig(defaults *structs.ServiceConfigResponse, service *struct
 
 	return ns, err
 }
+
+// upstreamKey returns the key identifying an upstream by name and by the peer
+// it is imported from, if any.
+func upstreamKey(peer string, sid structs.ServiceID) structs.PeeredServiceName {
+	return structs.PeeredServiceName{
+		Peer:        peer,
+		ServiceName: structs.NewServiceName(sid.ID, &sid.EnterpriseMeta),
+	}
+}
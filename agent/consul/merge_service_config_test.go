@@ -259,6 +259,84 @@ func Test_MergeServiceConfig_UpstreamOverrides(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "peered upstream config is only merged into the upstream from the same peer",
+			args: args{
+				defaults: &structs.ServiceConfigResponse{
+					UpstreamIDConfigs: structs.OpaqueUpstreamConfigs{
+						{
+							Upstream: structs.ServiceID{
+								ID:             "zap",
+								EnterpriseMeta: *structs.DefaultEnterpriseMetaInDefaultPartition(),
+							},
+							Config: map[string]interface{}{
+								"connect_timeout_ms": 1000,
+							},
+						},
+						{
+							Upstream: structs.ServiceID{
+								ID:             "zap",
+								EnterpriseMeta: *structs.DefaultEnterpriseMetaInDefaultPartition(),
+							},
+							Peer: "cluster-02",
+							Config: map[string]interface{}{
+								"connect_timeout_ms": 10000,
+							},
+						},
+					},
+				},
+				service: &structs.NodeService{
+					ID:      "foo-proxy",
+					Service: "foo-proxy",
+					Proxy: structs.ConnectProxyConfig{
+						DestinationServiceName: "foo",
+						DestinationServiceID:   "foo",
+						Upstreams: structs.Upstreams{
+							structs.Upstream{
+								DestinationNamespace: "default",
+								DestinationPartition: "default",
+								DestinationName:      "zap",
+								LocalBindPort:        8080,
+							},
+							structs.Upstream{
+								DestinationNamespace: "default",
+								DestinationPeer:      "cluster-02",
+								DestinationName:      "zap",
+								LocalBindPort:        8081,
+							},
+						},
+					},
+				},
+			},
+			want: &structs.NodeService{
+				ID:      "foo-proxy",
+				Service: "foo-proxy",
+				Proxy: structs.ConnectProxyConfig{
+					DestinationServiceName: "foo",
+					DestinationServiceID:   "foo",
+					Upstreams: structs.Upstreams{
+						structs.Upstream{
+							DestinationNamespace: "default",
+							DestinationPartition: "default",
+							DestinationName:      "zap",
+							LocalBindPort:        8080,
+							Config: map[string]interface{}{
+								"connect_timeout_ms": 1000,
+							},
+						},
+						structs.Upstream{
+							DestinationNamespace: "default",
+							DestinationPeer:      "cluster-02",
+							DestinationName:      "zap",
+							LocalBindPort:        8081,
+							Config: map[string]interface{}{
+								"connect_timeout_ms": 10000,
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "remote upstream config not added to local upstream list outside of transparent mode",
 			args: args{
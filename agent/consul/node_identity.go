@@ -0,0 +1,108 @@
+package consul
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// nodeIdentityKeySize is the size of the key signing the node identities.
+const nodeIdentityKeySize = 32
+
+// nodeIdentitySigningKey returns the key the servers sign the node identities
+// with, or nil when it wasn't created yet.
+func (s *Server) nodeIdentitySigningKey() ([]byte, error) {
+	encoded, err := s.getSystemMetadata(structs.SystemMetadataNodeIdentityKey)
+	if err != nil || encoded == "" {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(encoded)
+}
+
+// initializeNodeIdentitySigningKey creates the key the servers sign the node
+// identities with if it doesn't exist yet. It is called when establishing
+// leadership, and the key is only written if it still doesn't exist when the
+// write is applied so that a key already handed out is never replaced.
+func (s *Server) initializeNodeIdentitySigningKey() error {
+	key, err := s.nodeIdentitySigningKey()
+	if err != nil || key != nil {
+		return err
+	}
+
+	key = make([]byte, nodeIdentityKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	if _, err := s.setSystemMetadataKeyCAS(structs.SystemMetadataNodeIdentityKey, base64.StdEncoding.EncodeToString(key), 0); err != nil {
+		return fmt.Errorf("failed to store the node identity signing key: %w", err)
+	}
+	return nil
+}
+
+// applyVerifiedNodeMeta replaces the verified node metadata of a registration
+// with the one attested by its node identity. Without a valid node identity
+// the verified metadata already in the catalog is kept, so that it can only
+// be set by the servers.
+func (s *Server) applyVerifiedNodeMeta(args *structs.RegisterRequest) error {
+	identity := args.NodeIdentity
+	args.NodeIdentity = ""
+
+	if identity != "" {
+		verified, err := s.verifyNodeIdentity(args, identity)
+		if err == nil {
+			args.NodeMeta = structs.WithVerifiedNodeMeta(args.NodeMeta, verified.VerifiedMeta())
+			return nil
+		}
+		s.logger.Warn("ignoring invalid node identity",
+			"node", args.Node,
+			"partition", args.PartitionOrDefault(),
+			"error", err,
+		)
+	}
+
+	meta, err := s.keepVerifiedNodeMeta(args.Node, args.NodeMeta, args.GetEnterpriseMeta(), args.PeerName)
+	if err != nil {
+		return err
+	}
+	args.NodeMeta = meta
+	return nil
+}
+
+// keepVerifiedNodeMeta returns a copy of meta where the verified metadata is
+// replaced by the one of the node in the catalog.
+func (s *Server) keepVerifiedNodeMeta(node string, meta map[string]string, entMeta *acl.EnterpriseMeta, peerName string) (map[string]string, error) {
+	_, existing, err := s.fsm.State().GetNode(node, entMeta, peerName)
+	if err != nil {
+		return nil, err
+	}
+	var verified map[string]string
+	if existing != nil {
+		verified = structs.VerifiedNodeMeta(existing.Meta)
+	}
+	return structs.WithVerifiedNodeMeta(meta, verified), nil
+}
+
+func (s *Server) verifyNodeIdentity(args *structs.RegisterRequest, identity string) (*structs.NodeIdentity, error) {
+	key, err := s.nodeIdentitySigningKey()
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, fmt.Errorf("no node identity signing key")
+	}
+
+	verified, err := structs.VerifyNodeIdentity(key, identity)
+	if err != nil {
+		return nil, err
+	}
+	if verified.Datacenter != s.config.Datacenter ||
+		verified.Node != args.Node ||
+		verified.PartitionOrDefault() != args.PartitionOrDefault() ||
+		args.PeerName != "" {
+		return nil, fmt.Errorf("node identity was issued to node %q in datacenter %q", verified.Node, verified.Datacenter)
+	}
+	return verified, nil
+}
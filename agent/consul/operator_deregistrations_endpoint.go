@@ -0,0 +1,52 @@
+package consul
+
+import (
+	bexpr "github.com/hashicorp/go-bexpr"
+	"github.com/hashicorp/go-memdb"
+
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// Deregistrations returns the history of the nodes and service instances
+// recently removed from the catalog, most recent first.
+func (op *Operator) Deregistrations(args *structs.DeregistrationsRequest, reply *structs.IndexedDeregistrations) error {
+	if done, err := op.srv.ForwardRPC("Operator.Deregistrations", args, reply); done {
+		return err
+	}
+
+	// The history covers the whole catalog, this action requires operator
+	// read access. The records are then filtered by node and service read
+	// access like the catalog.
+	authz, err := op.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if err := authz.ToAllowAuthorizer().OperatorReadAllowed(nil); err != nil {
+		return err
+	}
+
+	filter, err := bexpr.CreateFilter(args.Filter, nil, reply.Deregistrations)
+	if err != nil {
+		return err
+	}
+
+	return op.srv.blockingQuery(
+		&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, deregistrations, err := state.Deregistrations(ws)
+			if err != nil {
+				return err
+			}
+			reply.Index = index
+
+			raw, err := filter.Execute(deregistrations)
+			if err != nil {
+				return err
+			}
+			reply.Deregistrations = raw.(structs.Deregistrations)
+			op.srv.filterACLWithAuthorizer(authz, reply)
+			return nil
+		})
+}
@@ -0,0 +1,116 @@
+package consul
+
+import (
+	"os"
+	"testing"
+
+	msgpackrpc "github.com/hashicorp/consul-net-rpc/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+)
+
+func TestOperator_Deregistrations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.PrimaryDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLInitialManagementToken = "root"
+		c.ACLResolverSettings.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1", testrpc.WithToken("root"))
+
+	reg := structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Service:    &structs.NodeService{ID: "web1", Service: "web", Port: 80},
+		Check: &structs.HealthCheck{
+			CheckID:   "web-check",
+			Name:      "web check",
+			ServiceID: "web1",
+			Status:    api.HealthCritical,
+			Definition: structs.HealthCheckDefinition{
+				HTTP:   "http://127.0.0.1/health",
+				Header: map[string][]string{"Authorization": {"secret"}},
+			},
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var out struct{}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Catalog.Register", &reg, &out))
+
+	writer := createTokenFull(t, codec, `node "foo" { policy = "write" } service "web" { policy = "write" }`)
+	dereg := structs.DeregisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		ServiceID:  "web1",
+		// Set by the servers, whatever the client sends.
+		DeregisteredBy: "spoofed",
+		WriteRequest:   structs.WriteRequest{Token: writer.SecretID},
+	}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Catalog.Deregister", &dereg, &out))
+
+	// The history requires operator read access.
+	arg := structs.DeregistrationsRequest{Datacenter: "dc1"}
+	var reply structs.IndexedDeregistrations
+	err := msgpackrpc.CallWithCodec(codec, "Operator.Deregistrations", &arg, &reply)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	// The records are filtered by node and service read access.
+	arg.Token = createTokenWithPolicyNameFull(t, codec, "operator", `operator = "read"`, "root").SecretID
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.Deregistrations", &arg, &reply))
+	require.NotZero(t, reply.Index)
+	require.Empty(t, reply.Deregistrations)
+	require.True(t, reply.QueryMeta.ResultsFilteredByACLs)
+
+	arg.Token = createTokenWithPolicyNameFull(t, codec, "operator-node", `operator = "read" node "foo" { policy = "read" }`, "root").SecretID
+	reply = structs.IndexedDeregistrations{}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.Deregistrations", &arg, &reply))
+	require.Empty(t, reply.Deregistrations)
+	require.True(t, reply.QueryMeta.ResultsFilteredByACLs)
+
+	arg.Token = createTokenWithPolicyNameFull(t, codec, "operator-web", `
+		operator = "read"
+		node "foo" { policy = "read" }
+		service "web" { policy = "read" }
+	`, "root").SecretID
+	reply = structs.IndexedDeregistrations{}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.Deregistrations", &arg, &reply))
+	require.False(t, reply.QueryMeta.ResultsFilteredByACLs)
+	require.Len(t, reply.Deregistrations, 1)
+
+	d := reply.Deregistrations[0]
+	require.Equal(t, "foo", d.Node)
+	require.Equal(t, "web1", d.ServiceID)
+	require.Equal(t, "web", d.ServiceName)
+	require.Equal(t, writer.AccessorID, d.DeregisteredBy)
+	require.False(t, d.DeregisteredAt.IsZero())
+	require.Equal(t, api.HealthCritical, d.Status)
+	require.Len(t, d.Checks, 1)
+	// The definitions of the checks may hold secrets.
+	require.Equal(t, structs.HealthCheckDefinition{}, d.Checks[0].Definition)
+
+	// The records of the state store are left untouched.
+	_, history, err := s1.fsm.State().Deregistrations(nil)
+	require.NoError(t, err)
+	require.Equal(t, "http://127.0.0.1/health", history[0].Checks[0].Definition.HTTP)
+
+	// Deregistrations can be filtered.
+	arg.Filter = `ServiceName == "db"`
+	reply = structs.IndexedDeregistrations{}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.Deregistrations", &arg, &reply))
+	require.Empty(t, reply.Deregistrations)
+}
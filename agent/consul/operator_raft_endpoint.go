@@ -3,6 +3,9 @@ package consul
 import (
 	"fmt"
 	"net"
+	"sort"
+	"strconv"
+	"sync"
 
 	"github.com/hashicorp/raft"
 	"github.com/hashicorp/serf/serf"
@@ -69,6 +72,210 @@ func (op *Operator) RaftGetConfiguration(args *structs.DCSpecificRequest, reply
 	return nil
 }
 
+// defaultRaftLargestEntries is the number of entries returned by
+// RaftLargestEntries when no limit is given.
+const defaultRaftLargestEntries = 10
+
+// maxRaftLogUsageEntries is the number of most recent entries scanned to
+// report the usage of the Raft log, to bound the time a report takes on
+// servers with a long log.
+const maxRaftLogUsageEntries = 65536
+
+// RaftLargestEntries returns the largest Raft log entries recently applied to
+// the FSM, to help diagnosing servers suffering from slow applies. Stale
+// requests are answered by the server receiving them.
+func (op *Operator) RaftLargestEntries(args *structs.RaftLargestEntriesRequest, reply *structs.RaftLargestEntriesResponse) error {
+	if done, err := op.srv.ForwardRPC("Operator.RaftLargestEntries", args, reply); done {
+		return err
+	}
+
+	// This action requires operator read access.
+	authz, err := op.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if err := authz.ToAllowAuthorizer().OperatorReadAllowed(nil); err != nil {
+		return err
+	}
+
+	limit := args.Limit
+	if limit <= 0 {
+		limit = defaultRaftLargestEntries
+	}
+	reply.Server = op.srv.config.NodeName
+	reply.Entries = op.srv.fsm.LargestRecentEntries(limit)
+	return nil
+}
+
+// RaftCompactionReport reports on the Raft log of the server, with the share
+// taken by each type of entry, and on the snapshot policy used to compact it.
+// Stale requests are answered by the server receiving them.
+func (op *Operator) RaftCompactionReport(args *structs.RaftCompactionRequest, reply *structs.RaftCompactionReport) error {
+	if done, err := op.srv.ForwardRPC("Operator.RaftCompactionReport", args, reply); done {
+		return err
+	}
+
+	// This action requires operator read access.
+	authz, err := op.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if err := authz.ToAllowAuthorizer().OperatorReadAllowed(nil); err != nil {
+		return err
+	}
+
+	return op.srv.raftCompactionReport(reply)
+}
+
+// RaftCompact takes a snapshot on every server, which compacts their Raft log
+// down to the trailing logs, and reports on the log of the leader afterwards
+// along with the logs of the other servers. Stale requests only compact the
+// server receiving them, the others are run by the leader which fans them out
+// to the other servers.
+func (op *Operator) RaftCompact(args *structs.RaftCompactionRequest, reply *structs.RaftCompactionReport) error {
+	if done, err := op.srv.ForwardRPC("Operator.RaftCompact", args, reply); done {
+		return err
+	}
+
+	// This action requires operator write access.
+	authz, err := op.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if err := authz.ToAllowAuthorizer().OperatorWriteAllowed(nil); err != nil {
+		return err
+	}
+
+	if err := op.srv.raft.Snapshot().Error(); err != nil && err != raft.ErrNothingNewToSnapshot {
+		return fmt.Errorf("failed to take snapshot: %w", err)
+	}
+	op.srv.logger.Info("compacted Raft log on operator request")
+
+	if err := op.srv.raftCompactionReport(reply); err != nil {
+		return err
+	}
+	if !args.AllowStale {
+		reply.Servers = op.srv.raftCompactOtherServers(args)
+	}
+	return nil
+}
+
+// raftCompactOtherServers compacts the Raft log of the servers other than this
+// one, and returns their reports. The servers which couldn't be compacted are
+// reported with the error.
+func (s *Server) raftCompactOtherServers(args *structs.RaftCompactionRequest) []structs.RaftCompactionReport {
+	var others []*metadata.Server
+	for _, server := range s.serverLookup.Servers() {
+		if server.ID != string(s.config.NodeID) {
+			others = append(others, server)
+		}
+	}
+
+	reports := make([]structs.RaftCompactionReport, len(others))
+	var wg sync.WaitGroup
+	for i, server := range others {
+		wg.Add(1)
+		go func(i int, server *metadata.Server) {
+			defer wg.Done()
+
+			// Stale compactions only run on the server receiving them.
+			req := *args
+			req.AllowStale = true
+			err := s.connPool.RPC(s.config.Datacenter, server.ShortName, server.Addr,
+				"Operator.RaftCompact", &req, &reports[i])
+			if err != nil {
+				s.logger.Warn("failed to compact the Raft log of server", "server", server.Name, "error", err)
+				reports[i] = structs.RaftCompactionReport{Server: server.Name, Error: err.Error()}
+			}
+		}(i, server)
+	}
+	wg.Wait()
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Server < reports[j].Server
+	})
+	return reports
+}
+
+// raftCompactionReport fills report from the Raft log of the server.
+func (s *Server) raftCompactionReport(report *structs.RaftCompactionReport) error {
+	first, err := s.raftLog.FirstIndex()
+	if err != nil {
+		return err
+	}
+	last, err := s.raftLog.LastIndex()
+	if err != nil {
+		return err
+	}
+	usageFirst := first
+	if last >= maxRaftLogUsageEntries && last-maxRaftLogUsageEntries+1 > usageFirst {
+		usageFirst = last - maxRaftLogUsageEntries + 1
+	}
+	usage, err := raftLogUsage(s.raftLog, usageFirst, last)
+	if err != nil {
+		return err
+	}
+
+	enabled, cfg, growthRate := s.snapshotTuner.status()
+	persist := s.fsm.LastPersist()
+
+	*report = structs.RaftCompactionReport{
+		Server:               s.config.NodeName,
+		FirstIndex:           first,
+		LastIndex:            last,
+		AutoTune:             enabled,
+		SnapshotThreshold:    cfg.SnapshotThreshold,
+		SnapshotInterval:     cfg.SnapshotInterval,
+		TrailingLogs:         cfg.TrailingLogs,
+		LogGrowthRate:        growthRate,
+		LastSnapshotBytes:    persist.Bytes,
+		LastSnapshotDuration: persist.Duration,
+		UsageFirstIndex:      usageFirst,
+		Usage:                usage,
+	}
+	if idx, err := strconv.ParseUint(s.raft.Stats()["last_snapshot_index"], 10, 64); err == nil {
+		report.LastSnapshotIndex = idx
+	}
+	return nil
+}
+
+// raftLogUsage returns the share of the entries between first and last taken
+// by each type of entry, sorted by decreasing size.
+func raftLogUsage(store raft.LogStore, first, last uint64) ([]structs.RaftLogTypeUsage, error) {
+	byType := make(map[string]*structs.RaftLogTypeUsage)
+	for index := first; index != 0 && index <= last; index++ {
+		var log raft.Log
+		if err := store.GetLog(index, &log); err != nil {
+			if err == raft.ErrLogNotFound {
+				// The log may have been compacted while scanning it.
+				continue
+			}
+			return nil, err
+		}
+
+		typ := raftLogType(&log)
+		usage, ok := byType[typ]
+		if !ok {
+			usage = &structs.RaftLogTypeUsage{Type: typ}
+			byType[typ] = usage
+		}
+		usage.Entries++
+		usage.Bytes += int64(len(log.Data))
+	}
+
+	result := make([]structs.RaftLogTypeUsage, 0, len(byType))
+	for _, usage := range byType {
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Bytes == result[j].Bytes {
+			return result[i].Type < result[j].Type
+		}
+		return result[i].Bytes > result[j].Bytes
+	})
+	return result, nil
+}
+
 // RaftRemovePeerByAddress is used to kick a stale peer (one that it in the Raft
 // quorum but no longer known to Serf or the catalog) by address in the form of
 // "IP:port". The reply argument is not used, but it required to fulfill the RPC
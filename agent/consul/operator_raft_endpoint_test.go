@@ -14,7 +14,9 @@ import (
 
 	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/sdk/freeport"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
 	"github.com/hashicorp/consul/testrpc"
 )
 
@@ -338,3 +340,162 @@ func TestOperator_RaftRemovePeerByID_ACLDeny(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 }
+
+func TestOperator_RaftLargestEntries(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.PrimaryDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLInitialManagementToken = "root"
+		c.ACLResolverSettings.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1", testrpc.WithToken("root"))
+
+	// Write a KV entry larger than anything applied so far.
+	kv := structs.KVSRequest{
+		Datacenter: "dc1",
+		Op:         api.KVSet,
+		DirEnt: structs.DirEntry{
+			Key:   "large",
+			Value: []byte(strings.Repeat("x", 64*1024)),
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var out bool
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.Apply", &kv, &out))
+
+	// Make a request with no token to make sure it gets denied.
+	arg := structs.RaftLargestEntriesRequest{
+		Datacenter: "dc1",
+		Limit:      2,
+	}
+	var reply structs.RaftLargestEntriesResponse
+	err := msgpackrpc.CallWithCodec(codec, "Operator.RaftLargestEntries", &arg, &reply)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	// Create an ACL with operator read permissions.
+	arg.Token = createToken(t, codec, `operator = "read"`)
+
+	// Now it should go through.
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.RaftLargestEntries", &arg, &reply))
+	require.Equal(t, s1.config.NodeName, reply.Server)
+	require.Len(t, reply.Entries, 2)
+	require.Equal(t, "KVS", reply.Entries[0].Type)
+	require.Greater(t, reply.Entries[0].Size, 64*1024)
+	require.GreaterOrEqual(t, reply.Entries[0].Size, reply.Entries[1].Size)
+}
+
+func TestOperator_RaftCompaction(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.PrimaryDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLInitialManagementToken = "root"
+		c.ACLResolverSettings.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1", testrpc.WithToken("root"))
+
+	for i := 0; i < 10; i++ {
+		kv := structs.KVSRequest{
+			Datacenter: "dc1",
+			Op:         api.KVSet,
+			DirEnt: structs.DirEntry{
+				Key:   fmt.Sprintf("key%d", i),
+				Value: []byte(strings.Repeat("x", 1024)),
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var out bool
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.Apply", &kv, &out))
+	}
+
+	// Reports require operator read access.
+	arg := structs.RaftCompactionRequest{Datacenter: "dc1"}
+	var report structs.RaftCompactionReport
+	err := msgpackrpc.CallWithCodec(codec, "Operator.RaftCompactionReport", &arg, &report)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	arg.Token = createToken(t, codec, `operator = "read"`)
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.RaftCompactionReport", &arg, &report))
+	require.Equal(t, s1.config.NodeName, report.Server)
+	require.Equal(t, uint64(1), report.FirstIndex)
+	require.Equal(t, s1.raft.LastIndex(), report.LastIndex)
+	require.Equal(t, s1.config.RaftConfig.SnapshotThreshold, report.SnapshotThreshold)
+	require.False(t, report.AutoTune)
+
+	// The KV entries are the largest share of the log.
+	require.NotEmpty(t, report.Usage)
+	require.Equal(t, "KVS", report.Usage[0].Type)
+	require.Equal(t, 10, report.Usage[0].Entries)
+	require.Greater(t, report.Usage[0].Bytes, int64(10*1024))
+
+	// Compacting requires operator write access.
+	err = msgpackrpc.CallWithCodec(codec, "Operator.RaftCompact", &arg, &report)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	arg.Token = createTokenWithPolicyNameFull(t, codec, "operator-write", `operator = "write"`, "root").SecretID
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.RaftCompact", &arg, &report))
+	require.Equal(t, report.LastIndex, report.LastSnapshotIndex)
+	require.Greater(t, report.LastSnapshotBytes, int64(10*1024))
+}
+
+func TestOperator_RaftCompact_AllServers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	dir2, s2 := testServerDCBootstrap(t, "dc1", false)
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+
+	joinLAN(t, s2, s1)
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	retry.Run(t, func(r *retry.R) {
+		r.Check(wantPeers(s1, 2))
+		r.Check(wantPeers(s2, 2))
+	})
+
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	// The leader compacts its own log and the one of the follower, once the
+	// configuration adding the follower is applied.
+	arg := structs.RaftCompactionRequest{Datacenter: "dc1"}
+	var report structs.RaftCompactionReport
+	retry.Run(t, func(r *retry.R) {
+		report = structs.RaftCompactionReport{}
+		require.NoError(r, msgpackrpc.CallWithCodec(codec, "Operator.RaftCompact", &arg, &report))
+		require.Len(r, report.Servers, 1)
+		require.Empty(r, report.Servers[0].Error)
+	})
+	require.Equal(t, s1.config.NodeName, report.Server)
+	require.Equal(t, report.LastIndex, report.LastSnapshotIndex)
+	require.Len(t, report.Servers, 1)
+	require.Equal(t, s2.config.NodeName, report.Servers[0].Server)
+	require.Empty(t, report.Servers[0].Error)
+	require.NotZero(t, report.Servers[0].LastSnapshotIndex)
+	require.Empty(t, report.Servers[0].Servers)
+}
@@ -0,0 +1,374 @@
+package consul
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-raftchunking"
+	"github.com/hashicorp/raft"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/fsm"
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/metadata"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+const (
+	// defaultRaftLogsLimit is the number of entries returned by RaftLogs
+	// when no limit is given, and maxRaftLogsLimit the largest limit allowed.
+	defaultRaftLogsLimit = 100
+	maxRaftLogsLimit     = 1000
+
+	// maxRaftLogsReplay is the largest number of entries replayed at once by
+	// RaftLogsReplay, to bound the time and memory used by the scratch FSM.
+	maxRaftLogsReplay = 10000
+)
+
+// RaftLogs pages through the Raft log on the disk of a server, from the most
+// recent entries applied to the FSM to the older ones. Stale requests are
+// answered by the server receiving them.
+//
+// The entries are summarized only for operators with write access, and only
+// if they can also read the resources named in the summary, since it
+// discloses keys, nodes, services and sessions.
+func (op *Operator) RaftLogs(args *structs.RaftLogsRequest, reply *structs.RaftLogsResponse) error {
+	if done, err := op.srv.ForwardRPC("Operator.RaftLogs", args, reply); done {
+		return err
+	}
+
+	// This action requires operator read access.
+	authz, err := op.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if err := authz.ToAllowAuthorizer().OperatorReadAllowed(nil); err != nil {
+		return err
+	}
+
+	limit := args.Limit
+	if limit <= 0 {
+		limit = defaultRaftLogsLimit
+	}
+	if limit > maxRaftLogsLimit {
+		limit = maxRaftLogsLimit
+	}
+
+	first, err := op.srv.raftLog.FirstIndex()
+	if err != nil {
+		return err
+	}
+	applied := op.srv.raft.AppliedIndex()
+	summarize := authz.OperatorWrite(nil) == acl.Allow
+
+	*reply = structs.RaftLogsResponse{
+		Server:       op.srv.config.NodeName,
+		FirstIndex:   first,
+		AppliedIndex: applied,
+	}
+
+	// Only return the entries which are known to be committed.
+	index := args.Index
+	if index == 0 || index > applied {
+		index = applied
+	}
+	for ; index >= first && index > 0 && len(reply.Entries) < limit; index-- {
+		var log raft.Log
+		if err := op.srv.raftLog.GetLog(index, &log); err != nil {
+			if err == raft.ErrLogNotFound {
+				// The log may have been compacted while paging through it.
+				return nil
+			}
+			return err
+		}
+		entry := structs.RaftLogEntry{
+			Index:      log.Index,
+			Term:       log.Term,
+			Type:       raftLogType(&log),
+			Size:       len(log.Data),
+			AppendedAt: log.AppendedAt,
+		}
+		if summarize {
+			entry.Summary = raftLogSummary(&log, authz)
+		}
+		reply.Entries = append(reply.Entries, entry)
+	}
+	if index >= first {
+		reply.NextIndex = index
+	}
+	return nil
+}
+
+// RaftLogsReplay replays a range of the Raft log on the disk of a server into
+// a scratch FSM, and reports on the resulting state. The state of the server
+// is left untouched. Stale requests are answered by the server receiving
+// them, the others are handed over by the leader to a follower when there is
+// one, so that the replay doesn't compete with the work of the leader.
+func (op *Operator) RaftLogsReplay(args *structs.RaftLogsReplayRequest, reply *structs.RaftLogsReplayResponse) error {
+	if done, err := op.srv.ForwardRPC("Operator.RaftLogsReplay", args, reply); done {
+		return err
+	}
+
+	// This action requires operator write access.
+	authz, err := op.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if err := authz.ToAllowAuthorizer().OperatorWriteAllowed(nil); err != nil {
+		return err
+	}
+
+	if !args.AllowStale {
+		if follower := op.srv.raftLogsReplayFollower(); follower != nil {
+			args.AllowStale = true
+			return op.srv.connPool.RPC(op.srv.config.Datacenter, follower.ShortName, follower.Addr,
+				"Operator.RaftLogsReplay", args, reply)
+		}
+	}
+
+	if !op.srv.raftLogsReplayLock.TryLock() {
+		return fmt.Errorf("a replay is already running on server %q", op.srv.config.NodeName)
+	}
+	defer op.srv.raftLogsReplayLock.Unlock()
+
+	first, err := op.srv.raftLog.FirstIndex()
+	if err != nil {
+		return err
+	}
+	applied := op.srv.raft.AppliedIndex()
+
+	start, end := args.StartIndex, args.EndIndex
+	if end == 0 || end > applied {
+		end = applied
+	}
+	switch {
+	case start == 0:
+		return fmt.Errorf("a start index is required")
+	case start < first:
+		return fmt.Errorf("start index %d was compacted, the first index on disk is %d", start, first)
+	case start > end:
+		return fmt.Errorf("start index %d is after the end index %d", start, end)
+	case end-start >= maxRaftLogsReplay:
+		return fmt.Errorf("cannot replay more than %d entries at once", maxRaftLogsReplay)
+	}
+
+	op.srv.logger.Info("replaying Raft log into a scratch FSM", "start", start, "end", end)
+	return replayRaftLogs(op.srv.raftLog, start, end, op.srv.config.NodeName, reply)
+}
+
+// raftLogsReplayFollower returns a server other than this one to hand a
+// replay over to, or nil if there is none.
+func (s *Server) raftLogsReplayFollower() *metadata.Server {
+	for _, server := range s.serverLookup.Servers() {
+		if server.ID != string(s.config.NodeID) {
+			return server
+		}
+	}
+	return nil
+}
+
+// replayRaftLogs applies the commands between start and end of the store to a
+// scratch FSM, and fills reply with the resulting state.
+func replayRaftLogs(store raft.LogStore, start, end uint64, server string, reply *structs.RaftLogsReplayResponse) error {
+	scratch := fsm.NewFromDeps(fsm.Deps{
+		Logger: hclog.NewNullLogger(),
+		NewStateStore: func() *state.Store {
+			return state.NewStateStore(nil)
+		},
+	})
+
+	*reply = structs.RaftLogsReplayResponse{
+		Server:     server,
+		StartIndex: start,
+		EndIndex:   end,
+	}
+	for index := start; index <= end; index++ {
+		var log raft.Log
+		if err := store.GetLog(index, &log); err != nil {
+			if err == raft.ErrLogNotFound {
+				return fmt.Errorf("entry %d was compacted during the replay", index)
+			}
+			return err
+		}
+		if log.Type != raft.LogCommand || len(log.Data) == 0 {
+			continue
+		}
+
+		reply.Applied++
+		if err := replayRaftLog(scratch.ChunkingFSM(), &log); err != nil {
+			reply.Errors = append(reply.Errors, structs.RaftLogReplayError{
+				Index: log.Index,
+				Type:  raftLogType(&log),
+				Error: err.Error(),
+			})
+		}
+	}
+
+	st := scratch.State()
+	snap := st.Snapshot()
+	defer snap.Close()
+	iter, err := snap.Indexes()
+	if err != nil {
+		return err
+	}
+	reply.Indexes = make(map[string]uint64)
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		idx := raw.(*state.IndexEntry)
+		reply.Indexes[idx.Key] = idx.Value
+	}
+
+	if _, usage, err := st.NodeUsage(); err == nil {
+		reply.Nodes = usage.Nodes
+	}
+	if _, usage, err := st.ServiceUsage(); err == nil {
+		reply.Services = usage.Services
+		reply.ServiceInstances = usage.ServiceInstances
+	}
+	if _, usage, err := st.KVUsage(); err == nil {
+		reply.KVEntries = usage.KVCount
+	}
+	return nil
+}
+
+// replayRaftLog applies a log to the scratch FSM and returns the error it
+// failed with, if any. The FSM panics on unknown commands, which is reported
+// as an error instead of crashing the server.
+func replayRaftLog(f *raftchunking.ChunkingFSM, log *raft.Log) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	resp := f.Apply(log)
+	if chunked, ok := resp.(raftchunking.ChunkingSuccess); ok {
+		resp = chunked.Response
+	}
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+	return nil
+}
+
+// raftLogType returns the message type of a Raft log entry. Entries that
+// aren't FSM commands are reported by their Raft log type, and the parts of
+// a chunked command as "Chunk".
+func raftLogType(log *raft.Log) string {
+	switch {
+	case log.Type != raft.LogCommand:
+		return log.Type.String()
+	case len(log.Extensions) > 0:
+		// Only the first part of a chunked command holds its type.
+		return "Chunk"
+	case len(log.Data) > 0:
+		return (structs.MessageType(log.Data[0]) &^ structs.IgnoreUnknownTypeFlag).String()
+	}
+	return log.Type.String()
+}
+
+// raftLogSummary returns a short description of the most common FSM
+// commands, or an empty string for the others and for the commands on
+// resources authz can't read.
+func raftLogSummary(log *raft.Log, authz acl.Authorizer) string {
+	if log.Type != raft.LogCommand || len(log.Extensions) > 0 || len(log.Data) == 0 {
+		return ""
+	}
+
+	buf := log.Data[1:]
+	var authzContext acl.AuthorizerContext
+	switch structs.MessageType(log.Data[0]) &^ structs.IgnoreUnknownTypeFlag {
+	case structs.RegisterRequestType:
+		var req structs.RegisterRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return ""
+		}
+		req.FillAuthzContext(&authzContext)
+		if authz.NodeRead(req.Node, &authzContext) != acl.Allow {
+			return ""
+		}
+		if req.Service != nil && authz.ServiceRead(req.Service.Service, &authzContext) != acl.Allow {
+			return ""
+		}
+		summary := "node=" + req.Node
+		if req.Service != nil {
+			summary += " service=" + req.Service.ID
+		}
+		checks := len(req.Checks)
+		if req.Check != nil {
+			checks++
+		}
+		if checks > 0 {
+			summary += fmt.Sprintf(" checks=%d", checks)
+		}
+		return summary
+
+	case structs.DeregisterRequestType:
+		var req structs.DeregisterRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return ""
+		}
+		req.FillAuthzContext(&authzContext)
+		if authz.NodeRead(req.Node, &authzContext) != acl.Allow {
+			return ""
+		}
+		// Only the ID of the service is known, not its name.
+		if req.ServiceID != "" && authz.ServiceReadAll(&authzContext) != acl.Allow {
+			return ""
+		}
+		summary := "node=" + req.Node
+		if req.ServiceID != "" {
+			summary += " service=" + req.ServiceID
+		}
+		if req.CheckID != "" {
+			summary += " check=" + string(req.CheckID)
+		}
+		return summary
+
+	case structs.KVSRequestType:
+		var req structs.KVSRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return ""
+		}
+		req.DirEnt.FillAuthzContext(&authzContext)
+		if authz.KeyRead(req.DirEnt.Key, &authzContext) != acl.Allow {
+			return ""
+		}
+		return fmt.Sprintf("op=%s key=%s", req.Op, req.DirEnt.Key)
+
+	case structs.SessionRequestType:
+		var req structs.SessionRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return ""
+		}
+		req.Session.FillAuthzContext(&authzContext)
+		if authz.SessionRead(req.Session.Node, &authzContext) != acl.Allow {
+			return ""
+		}
+		return fmt.Sprintf("op=%s session=%s", req.Op, req.Session.ID)
+
+	case structs.TxnRequestType:
+		var req structs.TxnRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return ""
+		}
+		return fmt.Sprintf("ops=%d", len(req.Ops))
+
+	case structs.ConfigEntryRequestType:
+		var req structs.ConfigEntryRequest
+		if err := structs.Decode(buf, &req); err != nil || req.Entry == nil {
+			return ""
+		}
+		if req.Entry.CanRead(authz) != nil {
+			return ""
+		}
+		return fmt.Sprintf("op=%s kind=%s name=%s", req.Op, req.Entry.GetKind(), req.Entry.GetName())
+
+	case structs.CoordinateBatchUpdateType:
+		var updates structs.Coordinates
+		if err := structs.Decode(buf, &updates); err != nil {
+			return ""
+		}
+		return fmt.Sprintf("coordinates=%d", len(updates))
+	}
+	return ""
+}
@@ -0,0 +1,233 @@
+package consul
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+
+	msgpackrpc "github.com/hashicorp/consul-net-rpc/net-rpc-msgpackrpc"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	"github.com/hashicorp/consul/testrpc"
+)
+
+func TestOperator_RaftLogs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.PrimaryDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLInitialManagementToken = "root"
+		c.ACLResolverSettings.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1", testrpc.WithToken("root"))
+	token := createToken(t, codec, `operator = "read"`)
+
+	for i := 0; i < 3; i++ {
+		kv := structs.KVSRequest{
+			Datacenter: "dc1",
+			Op:         api.KVSet,
+			DirEnt: structs.DirEntry{
+				Key:   fmt.Sprintf("key%d", i),
+				Value: []byte("value"),
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var out bool
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.Apply", &kv, &out))
+	}
+
+	// Make a request with no token to make sure it gets denied.
+	arg := structs.RaftLogsRequest{
+		Datacenter: "dc1",
+		Limit:      2,
+	}
+	var reply structs.RaftLogsResponse
+	err := msgpackrpc.CallWithCodec(codec, "Operator.RaftLogs", &arg, &reply)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	// Use an ACL with operator read permissions.
+	arg.Token = token
+
+	// The most recent entries come first.
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.RaftLogs", &arg, &reply))
+	require.Equal(t, s1.config.NodeName, reply.Server)
+	require.Equal(t, uint64(1), reply.FirstIndex)
+	require.Len(t, reply.Entries, 2)
+	require.Equal(t, reply.AppliedIndex, reply.Entries[0].Index)
+	require.Equal(t, reply.Entries[0].Index-1, reply.Entries[1].Index)
+	require.Equal(t, reply.Entries[1].Index-1, reply.NextIndex)
+
+	require.Equal(t, "KVS", reply.Entries[0].Type)
+	require.NotZero(t, reply.Entries[0].Term)
+
+	// The entries are only summarized for operators with write access who
+	// can read the resources.
+	require.Empty(t, reply.Entries[0].Summary)
+	require.Empty(t, reply.Entries[1].Summary)
+
+	arg.Token = createTokenWithPolicyName(t, codec, "operator-write", `
+		operator = "write"
+		key "key2" {
+			policy = "read"
+		}`, "root")
+	arg.Index = reply.Entries[0].Index
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.RaftLogs", &arg, &reply))
+	require.Len(t, reply.Entries, 2)
+	require.Equal(t, "op=set key=key2", reply.Entries[0].Summary)
+	require.Empty(t, reply.Entries[1].Summary)
+
+	// Page through the rest of the log.
+	var types []string
+	arg.Limit = 1000
+	arg.Index = reply.NextIndex
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.RaftLogs", &arg, &reply))
+	require.Equal(t, uint64(0), reply.NextIndex)
+	require.Equal(t, uint64(1), reply.Entries[len(reply.Entries)-1].Index)
+	for _, entry := range reply.Entries {
+		types = append(types, entry.Type)
+	}
+	require.Contains(t, types, raft.LogConfiguration.String())
+}
+
+func TestOperator_RaftLogsReplay(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.PrimaryDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLInitialManagementToken = "root"
+		c.ACLResolverSettings.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1", testrpc.WithToken("root"))
+	readToken := createTokenWithPolicyName(t, codec, "read", `operator = "read"`, "root")
+	token := createTokenWithPolicyName(t, codec, "write", `operator = "write"`, "root")
+
+	start := s1.raft.AppliedIndex() + 1
+	for i := 0; i < 3; i++ {
+		kv := structs.KVSRequest{
+			Datacenter: "dc1",
+			Op:         api.KVSet,
+			DirEnt: structs.DirEntry{
+				Key:   fmt.Sprintf("key%d", i),
+				Value: []byte("value"),
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var out bool
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.Apply", &kv, &out))
+	}
+
+	// Make a request with no token to make sure it gets denied.
+	arg := structs.RaftLogsReplayRequest{
+		Datacenter: "dc1",
+		StartIndex: start,
+	}
+	var reply structs.RaftLogsReplayResponse
+	err := msgpackrpc.CallWithCodec(codec, "Operator.RaftLogsReplay", &arg, &reply)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	// Replays require operator write access.
+	arg.Token = readToken
+	err = msgpackrpc.CallWithCodec(codec, "Operator.RaftLogsReplay", &arg, &reply)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	arg.Token = token
+
+	// Only the KV entries written since start are in the scratch FSM.
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.RaftLogsReplay", &arg, &reply))
+	require.Equal(t, s1.config.NodeName, reply.Server)
+	require.Equal(t, start, reply.StartIndex)
+	require.Equal(t, s1.raft.AppliedIndex(), reply.EndIndex)
+	require.GreaterOrEqual(t, reply.Applied, 3)
+	require.Empty(t, reply.Errors)
+	require.Equal(t, 3, reply.KVEntries)
+	require.NotZero(t, reply.Indexes["kvs"])
+
+	// The state of the server is untouched.
+	_, entries, err := s1.fsm.State().KVSList(nil, "", nil)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	// Invalid ranges are rejected.
+	arg.StartIndex = 0
+	err = msgpackrpc.CallWithCodec(codec, "Operator.RaftLogsReplay", &arg, &reply)
+	require.EqualError(t, err, "a start index is required")
+
+	arg.StartIndex = reply.EndIndex + 10
+	arg.EndIndex = reply.EndIndex
+	err = msgpackrpc.CallWithCodec(codec, "Operator.RaftLogsReplay", &arg, &reply)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is after the end index")
+
+	// Only one replay runs at a time on a server.
+	s1.raftLogsReplayLock.Lock()
+	arg.StartIndex = start
+	arg.EndIndex = 0
+	err = msgpackrpc.CallWithCodec(codec, "Operator.RaftLogsReplay", &arg, &reply)
+	s1.raftLogsReplayLock.Unlock()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "a replay is already running")
+}
+
+func TestOperator_RaftLogsReplay_Follower(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	dir1, s1 := testServerDCBootstrap(t, "dc1", true)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	dir2, s2 := testServerDCBootstrap(t, "dc1", false)
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+	joinLAN(t, s2, s1)
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	start := s1.raft.AppliedIndex()
+	retry.Run(t, func(r *retry.R) {
+		if s2.raft.AppliedIndex() < start {
+			r.Fatalf("follower has only applied %d", s2.raft.AppliedIndex())
+		}
+	})
+
+	// The leader hands the replay over to the follower.
+	arg := structs.RaftLogsReplayRequest{
+		Datacenter: "dc1",
+		StartIndex: start,
+	}
+	var reply structs.RaftLogsReplayResponse
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.RaftLogsReplay", &arg, &reply))
+	require.Equal(t, s2.config.NodeName, reply.Server)
+
+	// Stale requests are answered by the server receiving them.
+	arg.AllowStale = true
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.RaftLogsReplay", &arg, &reply))
+	require.Equal(t, s1.config.NodeName, reply.Server)
+}
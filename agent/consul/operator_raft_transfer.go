@@ -0,0 +1,171 @@
+package consul
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-version"
+	autopilot "github.com/hashicorp/raft-autopilot"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// RaftTransferLeader transfers the leadership of the cluster to another voter,
+// once it passed the pre-flight checks of its health, replication lag and
+// version. With DryRun set only the checks are run.
+func (op *Operator) RaftTransferLeader(args *structs.RaftTransferLeaderRequest, reply *structs.RaftTransferLeaderResponse) error {
+	if done, err := op.srv.ForwardRPC("Operator.RaftTransferLeader", args, reply); done {
+		return err
+	}
+
+	// This action requires operator write access.
+	authz, err := op.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if err := authz.ToAllowAuthorizer().OperatorWriteAllowed(nil); err != nil {
+		return err
+	}
+
+	state := op.srv.autopilot.GetState()
+	if state == nil {
+		return fmt.Errorf("the health of the servers is not known yet, try again later")
+	}
+	leader, ok := state.Servers[state.Leader]
+	if !ok {
+		return fmt.Errorf("the leader is not known to autopilot yet, try again later")
+	}
+	maxLag := op.srv.getAutopilotConfigOrDefault().MaxTrailingLogs
+
+	var candidate *autopilot.ServerState
+	if args.ID != "" {
+		candidate, ok = state.Servers[args.ID]
+		switch {
+		case !ok:
+			return fmt.Errorf("server %q is not in the Raft configuration", args.ID)
+		case args.ID == state.Leader:
+			return fmt.Errorf("server %q is already the leader", args.ID)
+		case !candidate.HasVotingRights():
+			return fmt.Errorf("server %q is not a voter", args.ID)
+		}
+	} else {
+		candidate = bestTransferCandidate(state, leader, maxLag)
+		if candidate == nil {
+			return fmt.Errorf("there is no other voter to transfer the leadership to")
+		}
+	}
+
+	*reply = structs.RaftTransferLeaderResponse{
+		ID:      candidate.Server.ID,
+		Node:    candidate.Server.Name,
+		Address: candidate.Server.Address,
+		Checks:  raftTransferChecks(leader, candidate, maxLag),
+		Passed:  true,
+	}
+	for _, check := range reply.Checks {
+		reply.Passed = reply.Passed && check.Passed
+	}
+	if args.DryRun || (!reply.Passed && !args.Force) {
+		return nil
+	}
+
+	op.srv.logger.Info("transferring leadership on operator request",
+		"id", candidate.Server.ID,
+		"node", candidate.Server.Name,
+		"checks_passed", reply.Passed,
+	)
+	future := op.srv.raft.LeadershipTransferToServer(candidate.Server.ID, candidate.Server.Address)
+	if err := future.Error(); err != nil {
+		return fmt.Errorf("failed to transfer leadership: %w", err)
+	}
+	reply.Transferred = true
+	return nil
+}
+
+// bestTransferCandidate returns the most up-to-date voter other than the
+// leader, preferring the ones passing all the pre-flight checks.
+func bestTransferCandidate(state *autopilot.State, leader *autopilot.ServerState, maxLag uint64) *autopilot.ServerState {
+	var voters []*autopilot.ServerState
+	for id, srv := range state.Servers {
+		if id != state.Leader && srv.HasVotingRights() {
+			voters = append(voters, srv)
+		}
+	}
+	sort.Slice(voters, func(i, j int) bool {
+		if voters[i].Stats.LastIndex == voters[j].Stats.LastIndex {
+			return voters[i].Server.ID < voters[j].Server.ID
+		}
+		return voters[i].Stats.LastIndex > voters[j].Stats.LastIndex
+	})
+
+	for _, srv := range voters {
+		passed := true
+		for _, check := range raftTransferChecks(leader, srv, maxLag) {
+			passed = passed && check.Passed
+		}
+		if passed {
+			return srv
+		}
+	}
+	if len(voters) > 0 {
+		return voters[0]
+	}
+	return nil
+}
+
+// raftTransferChecks runs the pre-flight checks of a leadership transfer to
+// candidate.
+func raftTransferChecks(leader, candidate *autopilot.ServerState, maxLag uint64) []structs.RaftTransferCheck {
+	health := structs.RaftTransferCheck{Name: "Health", Passed: candidate.Health.Healthy}
+	if health.Passed {
+		health.Output = "Server is healthy"
+	} else {
+		health.Output = fmt.Sprintf("Server is unhealthy, its last contact with the leader was %s ago", candidate.Stats.LastContact)
+	}
+
+	var lag uint64
+	if leader.Stats.LastIndex > candidate.Stats.LastIndex {
+		lag = leader.Stats.LastIndex - candidate.Stats.LastIndex
+	}
+	replication := structs.RaftTransferCheck{
+		Name:   "Replication",
+		Passed: lag <= maxLag,
+		Output: fmt.Sprintf("Server is %d entries behind the leader, at most %d are allowed", lag, maxLag),
+	}
+
+	return []structs.RaftTransferCheck{
+		health,
+		replication,
+		raftTransferVersionCheck(leader.Server.Version, candidate.Server.Version),
+	}
+}
+
+// raftTransferVersionCheck checks the candidate runs at least the version of
+// the leader, so that the leadership doesn't move back to an older server
+// during an upgrade.
+func raftTransferVersionCheck(leaderVersion, candidateVersion string) structs.RaftTransferCheck {
+	check := structs.RaftTransferCheck{Name: "Version"}
+
+	lv, err := version.NewVersion(leaderVersion)
+	if err != nil {
+		check.Output = fmt.Sprintf("Failed to parse the version %q of the leader: %v", leaderVersion, err)
+		return check
+	}
+	cv, err := version.NewVersion(candidateVersion)
+	if err != nil {
+		check.Output = fmt.Sprintf("Failed to parse the version %q of the server: %v", candidateVersion, err)
+		return check
+	}
+
+	switch {
+	case cv.Equal(lv):
+		check.Passed = true
+		check.Output = fmt.Sprintf("Server runs the same version as the leader, %s", cv)
+	case cv.GreaterThan(lv):
+		check.Passed = true
+		check.Output = fmt.Sprintf("Server runs version %s, newer than the version %s of the leader", cv, lv)
+	default:
+		check.Output = fmt.Sprintf("Server runs version %s, older than the version %s of the leader", cv, lv)
+	}
+	return check
+}
@@ -0,0 +1,137 @@
+package consul
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+
+	msgpackrpc "github.com/hashicorp/consul-net-rpc/net-rpc-msgpackrpc"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	"github.com/hashicorp/consul/testrpc"
+)
+
+func TestOperator_RaftTransferLeader(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	conf := func(bootstrap bool) func(c *Config) {
+		return func(c *Config) {
+			c.Datacenter = "dc1"
+			c.Bootstrap = bootstrap
+			c.PrimaryDatacenter = "dc1"
+			c.ACLsEnabled = true
+			c.ACLInitialManagementToken = "root"
+			c.ACLResolverSettings.ACLDefaultPolicy = "deny"
+			c.AutopilotConfig.ServerStabilizationTime = 200 * time.Millisecond
+			c.ServerHealthInterval = 100 * time.Millisecond
+			c.AutopilotInterval = 100 * time.Millisecond
+		}
+	}
+	dir1, s1 := testServerWithConfig(t, conf(true))
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	dir2, s2 := testServerWithConfig(t, conf(false))
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+	dir3, s3 := testServerWithConfig(t, conf(false))
+	defer os.RemoveAll(dir3)
+	defer s3.Shutdown()
+
+	servers := []*Server{s1, s2, s3}
+	for _, s := range servers[1:] {
+		joinLAN(t, s, s1)
+	}
+	for _, s := range servers {
+		retry.Run(t, func(r *retry.R) { r.Check(wantPeers(s, 3)) })
+	}
+	testrpc.WaitForLeader(t, s1.RPC, "dc1", testrpc.WithToken("root"))
+
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	// Make a request with no token to make sure it gets denied.
+	arg := structs.RaftTransferLeaderRequest{
+		Datacenter: "dc1",
+		DryRun:     true,
+	}
+	var reply structs.RaftTransferLeaderResponse
+	err := msgpackrpc.CallWithCodec(codec, "Operator.RaftTransferLeader", &arg, &reply)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	// An operator read token isn't enough either.
+	arg.Token = createToken(t, codec, `operator = "read"`)
+	err = msgpackrpc.CallWithCodec(codec, "Operator.RaftTransferLeader", &arg, &reply)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+	arg.Token = "root"
+
+	// Without an ID, a healthy follower is picked once autopilot has seen
+	// the servers as stable.
+	retry.Run(t, func(r *retry.R) {
+		reply = structs.RaftTransferLeaderResponse{}
+		if err := msgpackrpc.CallWithCodec(codec, "Operator.RaftTransferLeader", &arg, &reply); err != nil {
+			r.Fatal(err)
+		}
+		if !reply.Passed {
+			r.Fatalf("checks failed: %v", reply.Checks)
+		}
+	})
+	require.NotEqual(t, raft.ServerID(s1.config.NodeID), reply.ID)
+	require.False(t, reply.Transferred)
+	require.Len(t, reply.Checks, 3)
+	require.Equal(t, "Health", reply.Checks[0].Name)
+	require.Equal(t, "Replication", reply.Checks[1].Name)
+	require.Equal(t, "Version", reply.Checks[2].Name)
+	require.True(t, s1.IsLeader())
+
+	// The leader and unknown servers are rejected.
+	arg.ID = raft.ServerID(s1.config.NodeID)
+	err = msgpackrpc.CallWithCodec(codec, "Operator.RaftTransferLeader", &arg, &reply)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is already the leader")
+
+	arg.ID = "nope"
+	err = msgpackrpc.CallWithCodec(codec, "Operator.RaftTransferLeader", &arg, &reply)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is not in the Raft configuration")
+
+	// Transfer the leadership to s2.
+	arg.ID = raft.ServerID(s2.config.NodeID)
+	arg.DryRun = false
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.RaftTransferLeader", &arg, &reply))
+	require.Equal(t, arg.ID, reply.ID)
+	require.Equal(t, s2.config.NodeName, reply.Node)
+	require.True(t, reply.Passed)
+	require.True(t, reply.Transferred)
+
+	retry.Run(t, func(r *retry.R) {
+		if !s2.IsLeader() {
+			r.Fatal("s2 is not the leader")
+		}
+	})
+}
+
+func TestOperator_raftTransferVersionCheck(t *testing.T) {
+	cases := []struct {
+		leader, candidate string
+		passed            bool
+	}{
+		{"1.13.0", "1.13.0", true},
+		{"1.13.0", "1.13.1", true},
+		{"1.13.1", "1.13.0", false},
+		{"1.13.0", "", false},
+		{"bogus", "1.13.0", false},
+	}
+	for _, tc := range cases {
+		check := raftTransferVersionCheck(tc.leader, tc.candidate)
+		require.Equal(t, "Version", check.Name)
+		require.Equal(t, tc.passed, check.Passed, "%s => %s: %s", tc.leader, tc.candidate, check.Output)
+	}
+}
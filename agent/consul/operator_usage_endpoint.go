@@ -0,0 +1,172 @@
+package consul
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/armon/go-metrics/prometheus"
+
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+var TableUsageGauges = []prometheus.GaugeDefinition{
+	{
+		Name: []string{"state", "table", "rows"},
+		Help: "Measures the number of rows of each table of the state store, labeled by table.",
+	},
+	{
+		Name: []string{"state", "table", "bytes"},
+		Help: "Measures the estimated memory used by the rows of each table of the state store, labeled by table.",
+	},
+}
+
+// tableUsageInterval is how often the leader computes the usage of the state
+// store tables. Computing it walks the whole state store, so it is kept much
+// slower than the other usage metrics.
+const tableUsageInterval = 5 * time.Minute
+
+// tableUsageCache holds the last computed usage of the state store tables.
+type tableUsageCache struct {
+	// updateLock serializes the updates, so that the row sizes of an update
+	// are reused by the next one.
+	updateLock sync.Mutex
+	rowSizes   state.TableRowSizes
+
+	mu        sync.Mutex
+	tables    []structs.TableUsage
+	updatedAt time.Time
+}
+
+func (c *tableUsageCache) set(tables []structs.TableUsage, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tables = tables
+	c.updatedAt = now
+}
+
+func (c *tableUsageCache) get() ([]structs.TableUsage, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tables, c.updatedAt
+}
+
+// updateTableUsage computes the usage of the state store tables, caches it
+// and emits it as metrics.
+func (s *Server) updateTableUsage() ([]structs.TableUsage, time.Time, error) {
+	s.tableUsage.updateLock.Lock()
+	defer s.tableUsage.updateLock.Unlock()
+
+	now := time.Now()
+	_, tables, rowSizes, err := s.fsm.State().TableUsage(s.tableUsage.rowSizes)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	s.tableUsage.rowSizes = rowSizes
+	s.tableUsage.set(tables, now)
+
+	for _, table := range tables {
+		labels := []metrics.Label{{Name: "table", Value: table.Table}}
+		metrics.SetGaugeWithLabels([]string{"state", "table", "rows"}, float32(table.Rows), labels)
+		metrics.SetGaugeWithLabels([]string{"state", "table", "bytes"}, float32(table.Bytes), labels)
+	}
+	return tables, now, nil
+}
+
+// releaseRowSizes drops the row sizes kept for the next update.
+func (c *tableUsageCache) releaseRowSizes() {
+	c.updateLock.Lock()
+	defer c.updateLock.Unlock()
+	c.rowSizes = nil
+}
+
+// runTableUsage periodically computes the usage of the state store tables
+// while the server is the leader, until it shuts down. The other servers only
+// compute it when it is requested.
+func (s *Server) runTableUsage() {
+	ticker := time.NewTicker(tableUsageInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.shutdownCh:
+			return
+		case <-ticker.C:
+			if !s.IsLeader() {
+				s.tableUsage.releaseRowSizes()
+				continue
+			}
+			if _, _, err := s.updateTableUsage(); err != nil {
+				s.logger.Warn("failed to compute state store table usage", "error", err)
+			}
+		}
+	}
+}
+
+// Usage returns the number of nodes, services, KV entries and config entries
+// in the state store of the server. Detailed requests also report the number
+// of rows and the estimated memory used by each table.
+func (op *Operator) Usage(args *structs.OperatorUsageRequest, reply *structs.OperatorUsageResponse) error {
+	if done, err := op.srv.ForwardRPC("Operator.Usage", args, reply); done {
+		return err
+	}
+
+	// This action requires operator read access.
+	authz, err := op.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if err := authz.ToAllowAuthorizer().OperatorReadAllowed(nil); err != nil {
+		return err
+	}
+
+	state := op.srv.fsm.State()
+	nodesIdx, nodes, err := state.NodeUsage()
+	if err != nil {
+		return err
+	}
+	servicesIdx, services, err := state.ServiceUsage()
+	if err != nil {
+		return err
+	}
+	kvIdx, kvs, err := state.KVUsage()
+	if err != nil {
+		return err
+	}
+	configIdx, configEntries, err := state.ConfigEntryUsage()
+	if err != nil {
+		return err
+	}
+
+	reply.Server = op.srv.config.NodeName
+	reply.Nodes = nodes.Nodes
+	reply.Services = services.Services
+	reply.ServiceInstances = services.ServiceInstances
+	reply.KVEntries = kvs.KVCount
+	reply.ConfigEntries = configEntries.ConfigByKind
+
+	reply.Index = nodesIdx
+	for _, idx := range []uint64{servicesIdx, kvIdx, configIdx} {
+		if idx > reply.Index {
+			reply.Index = idx
+		}
+	}
+	op.srv.setQueryMeta(&reply.QueryMeta, args.Token)
+
+	if !args.Detailed {
+		return nil
+	}
+
+	// Table usage is computed periodically by the leader, only compute it on
+	// demand when this server hasn't done so recently.
+	reply.Tables, reply.TablesUpdatedAt = op.srv.tableUsage.get()
+	if time.Since(reply.TablesUpdatedAt) > tableUsageInterval {
+		reply.Tables, reply.TablesUpdatedAt, err = op.srv.updateTableUsage()
+		if err != nil {
+			return fmt.Errorf("failed to compute table usage: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package consul
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	msgpackrpc "github.com/hashicorp/consul-net-rpc/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+)
+
+func TestOperator_Usage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.PrimaryDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLInitialManagementToken = "root"
+		c.ACLResolverSettings.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1", testrpc.WithToken("root"))
+
+	for i := 0; i < 3; i++ {
+		kv := structs.KVSRequest{
+			Datacenter:   "dc1",
+			Op:           api.KVSet,
+			DirEnt:       structs.DirEntry{Key: fmt.Sprintf("key%d", i), Value: []byte("value")},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var out bool
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.Apply", &kv, &out))
+	}
+
+	// Usage requires operator read access.
+	arg := structs.OperatorUsageRequest{Datacenter: "dc1"}
+	var reply structs.OperatorUsageResponse
+	err := msgpackrpc.CallWithCodec(codec, "Operator.Usage", &arg, &reply)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	arg.Token = createToken(t, codec, `operator = "read"`)
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.Usage", &arg, &reply))
+	require.Equal(t, s1.config.NodeName, reply.Server)
+	require.Equal(t, 1, reply.Nodes)
+	require.Equal(t, 3, reply.KVEntries)
+	require.NotZero(t, reply.Index)
+	require.Empty(t, reply.Tables)
+
+	// Detailed requests compute the table usage on demand when it is missing
+	// or outdated.
+	arg.Detailed = true
+	reply = structs.OperatorUsageResponse{}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.Usage", &arg, &reply))
+	require.False(t, reply.TablesUpdatedAt.IsZero())
+
+	byTable := make(map[string]structs.TableUsage)
+	for _, table := range reply.Tables {
+		byTable[table.Table] = table
+	}
+	require.Equal(t, 3, byTable["kvs"].Rows)
+	require.Greater(t, byTable["kvs"].Bytes, int64(0))
+	require.Equal(t, 1, byTable["nodes"].Rows)
+
+	// Later requests are answered from the cache.
+	updatedAt := reply.TablesUpdatedAt
+	reply = structs.OperatorUsageResponse{}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.Usage", &arg, &reply))
+	require.True(t, updatedAt.Equal(reply.TablesUpdatedAt))
+}
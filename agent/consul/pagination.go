@@ -0,0 +1,64 @@
+package consul
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// paginate returns the bounds of the page of the n results selected by the
+// PerPage and NextToken query options, and sets the NextToken of the reply
+// when results are left after it. key returns the fields identifying the
+// result at index i; the results must be sorted by it in ascending order so
+// that the pages stay consistent when results are added or removed between
+// two requests. Pages are at most maxPerPage results long, whatever the
+// PerPage asked for.
+func paginate(opts *structs.QueryOptions, meta *structs.QueryMeta, maxPerPage, n int, key func(i int) []string) (int, int, error) {
+	// Blocking queries run this for each wake up, don't keep the token of a
+	// previous run.
+	meta.NextToken = ""
+
+	if opts.PerPage < 0 {
+		return 0, 0, fmt.Errorf("invalid per page value %d, it must not be negative", opts.PerPage)
+	}
+
+	start := 0
+	if opts.NextToken != "" {
+		after, err := structs.DecodePageToken(opts.NextToken)
+		if err != nil {
+			return 0, 0, err
+		}
+		start = sort.Search(n, func(i int) bool {
+			return comparePageKeys(key(i), after) > 0
+		})
+	}
+
+	perPage := opts.PerPage
+	if perPage == 0 || (maxPerPage > 0 && perPage > maxPerPage) {
+		perPage = maxPerPage
+	}
+
+	end := n
+	if perPage > 0 && n-start > perPage {
+		end = start + perPage
+		meta.NextToken = structs.EncodePageToken(key(end - 1))
+	}
+	return start, end, nil
+}
+
+// isPaginated returns true if the query asked for a page of the results.
+func isPaginated(opts *structs.QueryOptions) bool {
+	return opts.PerPage > 0 || opts.NextToken != ""
+}
+
+// comparePageKeys compares the keys field by field.
+func comparePageKeys(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := strings.Compare(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
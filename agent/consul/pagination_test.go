@@ -0,0 +1,103 @@
+package consul
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestPaginate(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e"}
+	key := func(i int) []string { return []string{items[i]} }
+
+	var pages [][]string
+	opts := structs.QueryOptions{PerPage: 2}
+	for {
+		var meta structs.QueryMeta
+		start, end, err := paginate(&opts, &meta, 0, len(items), key)
+		require.NoError(t, err)
+		pages = append(pages, items[start:end])
+		if meta.NextToken == "" {
+			break
+		}
+		opts.NextToken = meta.NextToken
+	}
+	require.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, pages)
+
+	t.Run("no pagination", func(t *testing.T) {
+		var meta structs.QueryMeta
+		start, end, err := paginate(&structs.QueryOptions{}, &meta, 0, len(items), key)
+		require.NoError(t, err)
+		require.Equal(t, 0, start)
+		require.Equal(t, len(items), end)
+		require.Empty(t, meta.NextToken)
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		meta := structs.QueryMeta{NextToken: "stale"}
+		start, end, err := paginate(&structs.QueryOptions{PerPage: 5}, &meta, 0, len(items), key)
+		require.NoError(t, err)
+		require.Equal(t, 0, start)
+		require.Equal(t, len(items), end)
+		require.Empty(t, meta.NextToken)
+	})
+
+	t.Run("result of the token removed", func(t *testing.T) {
+		// The page starts after the removed result rather than at an offset.
+		opts := structs.QueryOptions{PerPage: 2, NextToken: structs.EncodePageToken([]string{"b"})}
+		remaining := []string{"a", "c", "d", "e"}
+		var meta structs.QueryMeta
+		start, end, err := paginate(&opts, &meta, 0, len(remaining), func(i int) []string {
+			return []string{remaining[i]}
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"c", "d"}, remaining[start:end])
+		require.NotEmpty(t, meta.NextToken)
+	})
+
+	t.Run("token past the end", func(t *testing.T) {
+		opts := structs.QueryOptions{PerPage: 2, NextToken: structs.EncodePageToken([]string{"z"})}
+		var meta structs.QueryMeta
+		start, end, err := paginate(&opts, &meta, 0, len(items), key)
+		require.NoError(t, err)
+		require.Equal(t, start, end)
+		require.Empty(t, meta.NextToken)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		opts := structs.QueryOptions{PerPage: 2, NextToken: "not a token"}
+		_, _, err := paginate(&opts, &structs.QueryMeta{}, 0, len(items), key)
+		require.Equal(t, structs.ErrInvalidNextToken, err)
+	})
+
+	t.Run("per page above the maximum", func(t *testing.T) {
+		var meta structs.QueryMeta
+		start, end, err := paginate(&structs.QueryOptions{PerPage: 100}, &meta, 2, len(items), key)
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, items[start:end])
+		require.NotEmpty(t, meta.NextToken)
+	})
+
+	t.Run("next token without per page", func(t *testing.T) {
+		opts := structs.QueryOptions{NextToken: structs.EncodePageToken([]string{"a"})}
+		var meta structs.QueryMeta
+		start, end, err := paginate(&opts, &meta, 3, len(items), key)
+		require.NoError(t, err)
+		require.Equal(t, []string{"b", "c", "d"}, items[start:end])
+		require.NotEmpty(t, meta.NextToken)
+	})
+
+	t.Run("negative per page", func(t *testing.T) {
+		_, _, err := paginate(&structs.QueryOptions{PerPage: -1}, &structs.QueryMeta{}, 0, len(items), key)
+		require.Error(t, err)
+	})
+}
+
+func TestComparePageKeys(t *testing.T) {
+	require.Equal(t, 0, comparePageKeys([]string{"a", "b"}, []string{"a", "b"}))
+	require.Less(t, comparePageKeys([]string{"a", "b"}, []string{"a", "c"}), 0)
+	require.Greater(t, comparePageKeys([]string{"b"}, []string{"a", "c"}), 0)
+	require.Less(t, comparePageKeys([]string{"a"}, []string{"a", "b"}), 0)
+}
@@ -50,9 +50,19 @@ func (b *peeringBackend) GetAgentCACertificates() ([]string, error) {
 	return b.srv.tlsConfigurator.ManualCAPems(), nil
 }
 
-// GetServerAddresses looks up server node addresses from the state store.
+// GetServerAddresses looks up the addresses peers dial to establish peering
+// streams from the state store: the addresses of the mesh gateways when the
+// streams go through them, or else the addresses of the servers.
 func (b *peeringBackend) GetServerAddresses() ([]string, error) {
 	state := b.srv.fsm.State()
+	_, entry, err := state.ConfigEntry(nil, structs.MeshConfig, structs.MeshConfigMesh, structs.DefaultEnterpriseMetaInDefaultPartition())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mesh config entry: %w", err)
+	}
+	if mesh, ok := entry.(*structs.MeshConfigEntry); ok && mesh.PeerThroughMeshGateways() {
+		return b.getMeshGatewayAddresses()
+	}
+
 	_, nodes, err := state.ServiceNodes(nil, "consul", structs.DefaultEnterpriseMetaInDefaultPartition(), structs.DefaultPeerKeyword)
 	if err != nil {
 		return nil, err
@@ -64,6 +74,32 @@ func (b *peeringBackend) GetServerAddresses() ([]string, error) {
 	return addrs, nil
 }
 
+// getMeshGatewayAddresses looks up the WAN addresses of the healthy mesh
+// gateways from the state store. Peers dial them with native TLS, so that
+// they can be routed to the servers by SNI.
+func (b *peeringBackend) getMeshGatewayAddresses() ([]string, error) {
+	if !b.srv.tlsConfigurator.MutualTLSCapable() {
+		return nil, fmt.Errorf("peering through mesh gateways requires TLS to be configured on the servers")
+	}
+
+	state := b.srv.fsm.State()
+	_, nodes, err := state.ServiceDump(nil, structs.ServiceKindMeshGateway, true, structs.DefaultEnterpriseMetaInDefaultPartition(), structs.DefaultPeerKeyword)
+	if err != nil {
+		return nil, err
+	}
+	nodes = nodes.Filter(false)
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("peering through mesh gateways is enabled but there are no healthy mesh gateways")
+	}
+
+	var addrs []string
+	for _, node := range nodes {
+		_, addr, port := node.BestAddress(true)
+		addrs = append(addrs, addr+":"+strconv.Itoa(port))
+	}
+	return addrs, nil
+}
+
 // GetServerName returns the SNI to be returned in the peering token data which
 // will be used by peers when establishing peering connections over TLS.
 func (b *peeringBackend) GetServerName() string {
@@ -164,6 +200,16 @@ func (a *peeringApply) PeeringTrustBundleWrite(req *pbpeering.PeeringTrustBundle
 	return err
 }
 
+func (a *peeringApply) PeeringIntentionsWrite(req *pbpeering.PeeringIntentionsWriteRequest) error {
+	_, err := a.srv.raftApplyProtobuf(structs.PeeringIntentionsWriteType, req)
+	return err
+}
+
+func (a *peeringApply) PeeringIntentionsDelete(req *pbpeering.PeeringIntentionsDeleteRequest) error {
+	_, err := a.srv.raftApplyProtobuf(structs.PeeringIntentionsDeleteType, req)
+	return err
+}
+
 func (a *peeringApply) CatalogRegister(req *structs.RegisterRequest) error {
 	_, err := a.srv.leaderRaftApply("Catalog.Register", structs.RegisterRequestType, req)
 	return err
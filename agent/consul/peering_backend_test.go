@@ -2,14 +2,20 @@ package consul
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"net"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/hashicorp/consul/agent/pool"
+	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/proto/pbpeering"
 	"github.com/hashicorp/consul/sdk/testutil"
 	"github.com/hashicorp/consul/testrpc"
@@ -97,6 +103,95 @@ func TestPeeringBackend_ForwardToLeader(t *testing.T) {
 	})
 }
 
+func TestPeeringBackend_PeerThroughMeshGateways(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	_, s1 := testServerWithConfig(t, func(c *Config) {
+		c.TLSConfig.Domain = "consul"
+		c.TLSConfig.InternalRPC.CAFile = "../../test/hostname/CertAuth.crt"
+		c.TLSConfig.InternalRPC.CertFile = "../../test/hostname/Bob.crt"
+		c.TLSConfig.InternalRPC.KeyFile = "../../test/hostname/Bob.key"
+		c.TLSConfig.InternalRPC.VerifyIncoming = true
+	})
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	state := s1.fsm.State()
+	require.NoError(t, state.EnsureRegistration(1, &structs.RegisterRequest{
+		Node:    "gateway",
+		Address: "10.0.0.1",
+		Service: &structs.NodeService{
+			Kind:    structs.ServiceKindMeshGateway,
+			Service: "mesh-gateway",
+			Port:    8443,
+			TaggedAddresses: map[string]structs.ServiceAddress{
+				structs.TaggedAddressWAN: {Address: "198.18.0.1", Port: 443},
+			},
+		},
+	}))
+
+	backend := NewPeeringBackend(s1, nil)
+
+	testutil.RunStep(t, "servers are advertised by default", func(t *testing.T) {
+		addrs, err := backend.GetServerAddresses()
+		require.NoError(t, err)
+		require.Equal(t, []string{s1.config.RPCAddr.String()}, addrs)
+	})
+
+	testutil.RunStep(t, "gateways are advertised when enabled", func(t *testing.T) {
+		require.NoError(t, state.EnsureConfigEntry(2, &structs.MeshConfigEntry{
+			Peering: &structs.PeeringMeshConfig{PeerThroughMeshGateways: true},
+		}))
+
+		addrs, err := backend.GetServerAddresses()
+		require.NoError(t, err)
+		require.Equal(t, []string{"198.18.0.1:443"}, addrs)
+	})
+
+	testutil.RunStep(t, "peers dial with native TLS without a client certificate", func(t *testing.T) {
+		caPEM, err := os.ReadFile("../../test/hostname/CertAuth.crt")
+		require.NoError(t, err)
+		caPool := x509.NewCertPool()
+		require.True(t, caPool.AppendCertsFromPEM(caPEM))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		t.Cleanup(cancel)
+
+		addr := s1.config.RPCAddr.String()
+		conn, err := gogrpc.DialContext(ctx, addr,
+			gogrpc.WithContextDialer(newPeerDialer(addr, &tls.Config{
+				RootCAs:    caPool,
+				ServerName: "server.dc1.consul",
+			})),
+			gogrpc.WithInsecure(),
+			gogrpc.WithBlock())
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		client := pbpeering.NewPeeringServiceClient(conn)
+
+		// Only the streams are served to peers.
+		_, err = client.GenerateToken(ctx, &pbpeering.GenerateTokenRequest{PeerName: "peer1"})
+		require.Equal(t, codes.Unimplemented, status.Code(err))
+
+		stream, err := client.StreamResources(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&pbpeering.ReplicationMessage{
+			Payload: &pbpeering.ReplicationMessage_Request_{
+				Request: &pbpeering.ReplicationMessage_Request{
+					PeerID:      "63b60245-c475-426b-b314-4588d210859d",
+					ResourceURL: pbpeering.TypeURLService,
+				},
+			},
+		}))
+		_, err = stream.Recv()
+		require.Error(t, err)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
 func newServerDialer(serverAddr string) func(context.Context, string) (net.Conn, error) {
 	return func(ctx context.Context, addr string) (net.Conn, error) {
 		d := net.Dialer{}
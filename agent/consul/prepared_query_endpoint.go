@@ -191,6 +191,19 @@ func parseService(svc *structs.ServiceQuery) error {
 		return fmt.Errorf("Bad NearestN '%d', must be >= 0", svc.Failover.NearestN)
 	}
 
+	// Failover targets replace the datacenter options.
+	if len(svc.Failover.Targets) > 0 && (svc.Failover.NearestN != 0 || len(svc.Failover.Datacenters) > 0) {
+		return fmt.Errorf("Failover Targets cannot be combined with NearestN or Datacenters")
+	}
+	for i, target := range svc.Failover.Targets {
+		if target.Peer != "" && target.Datacenter != "" {
+			return fmt.Errorf("Bad Failover Target %d, cannot set both Peer and Datacenter", i)
+		}
+		if target.Peer == "" && target.Datacenter == "" && target.Partition == "" && target.Namespace == "" {
+			return fmt.Errorf("Bad Failover Target %d, must set a Peer, Datacenter, Partition or Namespace", i)
+		}
+	}
+
 	// Make sure the metadata filters are valid
 	if err := structs.ValidateNodeMetadata(svc.NodeMeta, true); err != nil {
 		return err
@@ -651,6 +664,7 @@ type queryServer interface {
 	GetLogger() hclog.Logger
 	GetOtherDatacentersByDistance() ([]string, error)
 	ForwardDC(method, dc string, args interface{}, reply interface{}) error
+	ExecuteRemote(args *structs.PreparedQueryExecuteRemoteRequest, reply *structs.PreparedQueryExecuteResponse) error
 }
 
 // queryServerWrapper applies the queryServer interface to a Server.
@@ -687,8 +701,15 @@ func (q *queryServerWrapper) ForwardDC(method, dc string, args interface{}, repl
 	return q.srv.forwardDC(method, dc, args, reply)
 }
 
-// queryFailover runs an algorithm to determine which DCs to try and then calls
-// them to try to locate alternative services.
+// ExecuteRemote runs the query in the local DC without any failover.
+func (q *queryServerWrapper) ExecuteRemote(args *structs.PreparedQueryExecuteRemoteRequest, reply *structs.PreparedQueryExecuteResponse) error {
+	args.Datacenter = q.srv.config.Datacenter
+	pq := &PreparedQuery{srv: q.srv, logger: q.GetLogger()}
+	return pq.ExecuteRemote(args, reply)
+}
+
+// queryFailover runs an algorithm to determine which DCs, partitions and
+// peers to try and then calls them to try to locate alternative services.
 func queryFailover(q queryServer, query *structs.PreparedQuery,
 	args *structs.PreparedQueryExecuteRequest,
 	reply *structs.PreparedQueryExecuteResponse) error {
@@ -706,9 +727,20 @@ func queryFailover(q queryServer, query *structs.PreparedQuery,
 		known[dc] = struct{}{}
 	}
 
-	// Build a candidate list of DCs to try, starting with the nearest N
-	// from RTTs.
-	var dcs []string
+	// Build a candidate list of targets to try. Explicit targets are tried
+	// in the given order.
+	var targets []structs.QueryFailoverTarget
+	for _, target := range query.Service.Failover.Targets {
+		if target.Datacenter != "" {
+			if _, ok := known[target.Datacenter]; !ok {
+				q.GetLogger().Debug("Skipping unknown datacenter in prepared query", "datacenter", target.Datacenter)
+				continue
+			}
+		}
+		targets = append(targets, target)
+	}
+
+	// Otherwise start with the nearest N DCs from RTTs.
 	index := make(map[string]struct{})
 	if query.Service.Failover.NearestN > 0 {
 		for i, dc := range nearest {
@@ -716,7 +748,7 @@ func queryFailover(q queryServer, query *structs.PreparedQuery,
 				break
 			}
 
-			dcs = append(dcs, dc)
+			targets = append(targets, structs.QueryFailoverTarget{Datacenter: dc})
 			index[dc] = struct{}{}
 		}
 	}
@@ -733,13 +765,13 @@ func queryFailover(q queryServer, query *structs.PreparedQuery,
 		// This will make sure we don't re-try something that fails
 		// from the NearestN list.
 		if _, ok := index[dc]; !ok {
-			dcs = append(dcs, dc)
+			targets = append(targets, structs.QueryFailoverTarget{Datacenter: dc})
 		}
 	}
 
-	// Now try the selected DCs in priority order.
+	// Now try the selected targets in priority order.
 	failovers := 0
-	for _, dc := range dcs {
+	for _, target := range targets {
 		// This keeps track of how many iterations we actually run.
 		failovers++
 
@@ -756,16 +788,31 @@ func queryFailover(q queryServer, query *structs.PreparedQuery,
 		// mode information and token we were given, so that applies to
 		// the remote query as well.
 		remote := &structs.PreparedQueryExecuteRemoteRequest{
-			Datacenter:   dc,
+			Datacenter:   target.Datacenter,
 			Query:        *query,
 			Limit:        args.Limit,
 			QueryOptions: args.QueryOptions,
 			Connect:      args.Connect,
 		}
-		if err := q.ForwardDC("PreparedQuery.ExecuteRemote", dc, remote, reply); err != nil {
-			q.GetLogger().Warn("Failed querying for service in datacenter",
+
+		// Explicit targets query the service in their partition and
+		// namespace, among the services imported from their peer if any.
+		if len(query.Service.Failover.Targets) > 0 {
+			remote.Query.Service.EnterpriseMeta = *target.GetEnterpriseMeta(&query.Service.EnterpriseMeta)
+			remote.Query.Service.PeerName = target.Peer
+		}
+
+		if target.Datacenter == "" {
+			err = q.ExecuteRemote(remote, reply)
+		} else {
+			err = q.ForwardDC("PreparedQuery.ExecuteRemote", target.Datacenter, remote, reply)
+		}
+		if err != nil {
+			q.GetLogger().Warn("Failed querying for service in failover target",
 				"service", query.Service.Service,
-				"datacenter", dc,
+				"datacenter", target.Datacenter,
+				"peer", target.Peer,
+				"partition", remote.Query.Service.PartitionOrDefault(),
 				"error", err,
 			)
 			continue
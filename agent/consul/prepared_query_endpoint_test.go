@@ -592,6 +592,30 @@ func TestPreparedQuery_parseQuery(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
+	query.Service.Failover.Targets = []structs.QueryFailoverTarget{{Peer: "peer1"}}
+	err = parseQuery(query)
+	if err == nil || !strings.Contains(err.Error(), "cannot be combined with NearestN") {
+		t.Fatalf("bad: %v", err)
+	}
+
+	query.Service.Failover.NearestN = 0
+	query.Service.Failover.Targets = []structs.QueryFailoverTarget{{Peer: "peer1", Datacenter: "dc2"}}
+	err = parseQuery(query)
+	if err == nil || !strings.Contains(err.Error(), "cannot set both Peer and Datacenter") {
+		t.Fatalf("bad: %v", err)
+	}
+
+	query.Service.Failover.Targets = []structs.QueryFailoverTarget{{Peer: "peer1"}, {}}
+	err = parseQuery(query)
+	if err == nil || !strings.Contains(err.Error(), "Bad Failover Target 1") {
+		t.Fatalf("bad: %v", err)
+	}
+
+	query.Service.Failover.Targets = []structs.QueryFailoverTarget{{Peer: "peer1"}, {Datacenter: "dc2"}}
+	if err := parseQuery(query); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
 	query.DNS.TTL = "two fortnights"
 	err = parseQuery(query)
 	if err == nil || !strings.Contains(err.Error(), "Bad DNS TTL") {
@@ -2782,6 +2806,15 @@ func (m *mockQueryServer) ForwardDC(method, dc string, args interface{}, reply i
 	return nil
 }
 
+func (m *mockQueryServer) ExecuteRemote(args *structs.PreparedQueryExecuteRemoteRequest, reply *structs.PreparedQueryExecuteResponse) error {
+	peerName := args.Query.Service.PeerName
+	m.QueryLog = append(m.QueryLog, fmt.Sprintf("peer:%s", peerName))
+	if m.QueryFn != nil {
+		return m.QueryFn(peerName, args, reply)
+	}
+	return nil
+}
+
 func TestPreparedQuery_queryFailover(t *testing.T) {
 	t.Parallel()
 	query := &structs.PreparedQuery{
@@ -3123,4 +3156,115 @@ func TestPreparedQuery_queryFailover(t *testing.T) {
 			t.Fatalf("bad: %s", queries)
 		}
 	}
+
+	// Fail over to the explicit targets in order, skipping the unknown
+	// datacenters.
+	query.Service.Failover.Datacenters = nil
+	query.Service.Failover.Targets = []structs.QueryFailoverTarget{
+		{Peer: "peer1"},
+		{Datacenter: "nope"},
+		{Datacenter: "dc2"},
+		{Peer: "peer2"},
+	}
+	{
+		mock := &mockQueryServer{
+			Datacenters: []string{"dc1", "dc2", "dc3", "xxx", "dc4"},
+			QueryFn: func(dc string, args interface{}, reply interface{}) error {
+				inp := args.(*structs.PreparedQueryExecuteRemoteRequest)
+				ret := reply.(*structs.PreparedQueryExecuteResponse)
+				require.Equal(t, 5, inp.Limit)
+				if dc == "peer2" {
+					require.Equal(t, "peer2", inp.Query.Service.PeerName)
+					ret.Nodes = nodes()
+				}
+				return nil
+			},
+		}
+
+		var reply structs.PreparedQueryExecuteResponse
+		err := queryFailover(mock, query, &structs.PreparedQueryExecuteRequest{Limit: 5}, &reply)
+		require.NoError(t, err)
+		require.Equal(t, nodes(), reply.Nodes)
+		require.Equal(t, 3, reply.Failovers)
+		require.Equal(t, "peer:peer1|dc2:PreparedQuery.ExecuteRemote|peer:peer2", mock.JoinQueryLog())
+		require.Contains(t, mock.LogBuffer.String(), "Skipping unknown datacenter")
+	}
+}
+
+func TestPreparedQuery_Execute_PeerFailover(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Register an unhealthy local instance, and a healthy one imported
+	// from a peer.
+	req := structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "node1",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			Service: "foo",
+			Port:    8000,
+		},
+		Check: &structs.HealthCheck{
+			Name:      "failing",
+			Status:    api.HealthCritical,
+			ServiceID: "foo",
+		},
+	}
+	var out struct{}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Catalog.Register", &req, &out))
+
+	require.NoError(t, s1.fsm.State().EnsureRegistration(100, &structs.RegisterRequest{
+		Node:     "peer-node",
+		Address:  "10.0.0.1",
+		PeerName: "peer1",
+		Service: &structs.NodeService{
+			Service:  "foo",
+			Port:     8000,
+			PeerName: "peer1",
+		},
+	}))
+
+	query := structs.PreparedQueryRequest{
+		Datacenter: "dc1",
+		Op:         structs.PreparedQueryCreate,
+		Query: &structs.PreparedQuery{
+			Name: "test",
+			Service: structs.ServiceQuery{
+				Service:     "foo",
+				OnlyPassing: true,
+				Failover: structs.QueryDatacenterOptions{
+					Targets: []structs.QueryFailoverTarget{
+						{Peer: "peer2"},
+						{Peer: "peer1"},
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "PreparedQuery.Apply", &query, &query.Query.ID))
+
+	execReq := structs.PreparedQueryExecuteRequest{
+		Datacenter:    "dc1",
+		QueryIDOrName: query.Query.ID,
+	}
+	var reply structs.PreparedQueryExecuteResponse
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "PreparedQuery.Execute", &execReq, &reply))
+
+	require.Len(t, reply.Nodes, 1)
+	require.Equal(t, "peer-node", reply.Nodes[0].Node.Node)
+	require.Equal(t, "peer1", reply.Nodes[0].Node.PeerName)
+	require.Equal(t, "dc1", reply.Datacenter)
+	require.Equal(t, 2, reply.Failovers)
 }
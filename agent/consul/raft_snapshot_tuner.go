@@ -0,0 +1,214 @@
+package consul
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/armon/go-metrics/prometheus"
+	"github.com/hashicorp/raft"
+
+	"github.com/hashicorp/consul/agent/consul/fsm"
+)
+
+var SnapshotTunerGauges = []prometheus.GaugeDefinition{
+	{
+		Name: []string{"raft", "snapshot", "threshold"},
+		Help: "The Raft snapshot threshold currently used, which is tuned when raft_snapshot_auto_tune is enabled.",
+	},
+	{
+		Name: []string{"raft", "snapshot", "interval"},
+		Help: "The Raft snapshot interval currently used in milliseconds, which is tuned when raft_snapshot_auto_tune is enabled.",
+	},
+}
+
+const (
+	// snapshotTuneInterval is how often the snapshot policy is re-evaluated.
+	snapshotTuneInterval = time.Minute
+
+	// snapshotMaxDutyCycle is the largest fraction of the time that should be
+	// spent writing snapshots.
+	snapshotMaxDutyCycle = 0.1
+
+	// snapshotTuneMinFactor and snapshotTuneMaxFactor bound the tuned
+	// threshold and interval relative to the configured ones.
+	snapshotTuneMinFactor = 4
+	snapshotTuneMaxFactor = 8
+
+	// snapshotGrowthSmoothing is the weight of the latest sample in the
+	// moving average of the log growth rate.
+	snapshotGrowthSmoothing = 0.5
+)
+
+// snapshotTuner adapts the Raft snapshot threshold and interval to the rate
+// at which the log grows and to how long snapshots take to write, instead of
+// relying on the static values alone.
+//
+// Writing snapshots too often wastes disk throughput when the state is large,
+// while writing them too rarely lets the log grow and slows down restores. The
+// tuner picks a threshold such that snapshots take at most
+// snapshotMaxDutyCycle of the time at the current growth rate, and an interval
+// short enough to notice when it is reached. The configured values are used as
+// the baseline the tuned values are bounded around.
+type snapshotTuner struct {
+	mu sync.Mutex
+
+	enabled bool
+
+	// base is the configuration computed from the agent config.
+	base raft.ReloadableConfig
+
+	// current is the configuration last accepted by Raft.
+	current raft.ReloadableConfig
+
+	lastIndex  uint64
+	lastSample time.Time
+	growthRate float64
+
+	// cost is how long the last snapshot took to write.
+	cost time.Duration
+}
+
+func newSnapshotTuner(base raft.ReloadableConfig, enabled bool) *snapshotTuner {
+	return &snapshotTuner{enabled: enabled, base: base, current: base}
+}
+
+// reload replaces the configured values, and returns the configuration to
+// hand to Raft, which keeps the tuned values when tuning is enabled. It must
+// be passed to applied once Raft accepted it.
+func (t *snapshotTuner) reload(base raft.ReloadableConfig, enabled bool) raft.ReloadableConfig {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.base = base
+	t.enabled = enabled
+	next := base
+	if enabled && !t.lastSample.IsZero() {
+		next.SnapshotThreshold, next.SnapshotInterval = computeSnapshotTuning(base, t.growthRate, t.cost)
+	}
+	return next
+}
+
+// applied records the configuration accepted by Raft.
+func (t *snapshotTuner) applied(cfg raft.ReloadableConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.current = cfg
+}
+
+// sample records the last index of the log, and returns the tuned
+// configuration and true if it differs from the current one. It must be
+// passed to applied once Raft accepted it.
+func (t *snapshotTuner) sample(lastIndex uint64, now time.Time, persist fsm.PersistStats) (raft.ReloadableConfig, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.lastSample.IsZero() && lastIndex >= t.lastIndex {
+		elapsed := now.Sub(t.lastSample).Seconds()
+		if elapsed > 0 {
+			rate := float64(lastIndex-t.lastIndex) / elapsed
+			if t.growthRate == 0 {
+				t.growthRate = rate
+			} else {
+				t.growthRate = snapshotGrowthSmoothing*rate + (1-snapshotGrowthSmoothing)*t.growthRate
+			}
+		}
+	}
+	t.lastIndex = lastIndex
+	t.lastSample = now
+	if persist.Duration > 0 {
+		t.cost = persist.Duration
+	}
+
+	if !t.enabled {
+		return t.current, false
+	}
+
+	next := t.current
+	next.SnapshotThreshold, next.SnapshotInterval = computeSnapshotTuning(t.base, t.growthRate, t.cost)
+	if next == t.current {
+		return t.current, false
+	}
+	return next, true
+}
+
+// status returns whether tuning is enabled, the configuration handed to Raft
+// and the log growth rate in entries per second.
+func (t *snapshotTuner) status() (bool, raft.ReloadableConfig, float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.enabled, t.current, t.growthRate
+}
+
+// computeSnapshotTuning returns the snapshot threshold and interval for a log
+// growing by growthRate entries per second, when writing a snapshot takes
+// snapshotCost. A zero snapshotCost means it is not known yet, the configured
+// threshold is then kept.
+func computeSnapshotTuning(base raft.ReloadableConfig, growthRate float64, snapshotCost time.Duration) (uint64, time.Duration) {
+	minThreshold := base.SnapshotThreshold / snapshotTuneMinFactor
+	if minThreshold == 0 {
+		minThreshold = 1
+	}
+	maxThreshold := base.SnapshotThreshold * snapshotTuneMaxFactor
+
+	threshold := base.SnapshotThreshold
+	if snapshotCost > 0 {
+		// Leave enough time between snapshots for them to only take
+		// snapshotMaxDutyCycle of the time.
+		minPeriod := snapshotCost.Seconds() / snapshotMaxDutyCycle
+		threshold = uint64(math.Ceil(growthRate * minPeriod))
+	}
+	if threshold < minThreshold {
+		threshold = minThreshold
+	}
+	if threshold > maxThreshold {
+		threshold = maxThreshold
+	}
+
+	minInterval := base.SnapshotInterval / snapshotTuneMinFactor
+	maxInterval := base.SnapshotInterval * snapshotTuneMaxFactor
+	interval := maxInterval
+	if growthRate > 0 {
+		// Check twice as often as the threshold is expected to be reached.
+		seconds := float64(threshold) / growthRate / 2
+		if seconds < maxInterval.Seconds() {
+			interval = time.Duration(seconds * float64(time.Second)).Round(time.Second)
+		}
+	}
+	if interval < minInterval {
+		interval = minInterval
+	}
+	return threshold, interval
+}
+
+// runSnapshotTuner periodically tunes the Raft snapshot policy until the
+// server shuts down.
+func (s *Server) runSnapshotTuner() {
+	ticker := time.NewTicker(snapshotTuneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.shutdownCh:
+			return
+		case now := <-ticker.C:
+			cfg, changed := s.snapshotTuner.sample(s.raft.LastIndex(), now, s.fsm.LastPersist())
+			if changed {
+				if err := s.raft.ReloadConfig(cfg); err != nil {
+					s.logger.Warn("failed to apply tuned Raft snapshot policy", "error", err)
+				} else {
+					s.snapshotTuner.applied(cfg)
+					s.logger.Debug("tuned Raft snapshot policy",
+						"threshold", cfg.SnapshotThreshold,
+						"interval", cfg.SnapshotInterval,
+					)
+				}
+			}
+
+			_, cfg, _ = s.snapshotTuner.status()
+			metrics.SetGauge([]string{"raft", "snapshot", "threshold"}, float32(cfg.SnapshotThreshold))
+			metrics.SetGauge([]string{"raft", "snapshot", "interval"}, float32(cfg.SnapshotInterval.Milliseconds()))
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package consul
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/consul/fsm"
+)
+
+func TestComputeSnapshotTuning(t *testing.T) {
+	base := raft.ReloadableConfig{
+		SnapshotThreshold: 16384,
+		SnapshotInterval:  30 * time.Second,
+	}
+
+	cases := map[string]struct {
+		growthRate        float64
+		cost              time.Duration
+		expectedThreshold uint64
+		expectedInterval  time.Duration
+	}{
+		"idle without snapshot": {
+			growthRate:        0,
+			cost:              0,
+			expectedThreshold: 16384,
+			expectedInterval:  4 * time.Minute,
+		},
+		"idle with cheap snapshots": {
+			growthRate:        0,
+			cost:              time.Second,
+			expectedThreshold: 4096,
+			expectedInterval:  4 * time.Minute,
+		},
+		"busy without snapshot": {
+			growthRate:        1000,
+			cost:              0,
+			expectedThreshold: 16384,
+			expectedInterval:  8 * time.Second,
+		},
+		"busy with cheap snapshots": {
+			growthRate:        100,
+			cost:              5 * time.Second,
+			expectedThreshold: 5000,
+			expectedInterval:  25 * time.Second,
+		},
+		"busy with expensive snapshots": {
+			growthRate:        500,
+			cost:              20 * time.Second,
+			expectedThreshold: 100000,
+			expectedInterval:  100 * time.Second,
+		},
+		"threshold capped": {
+			growthRate:        5000,
+			cost:              time.Minute,
+			expectedThreshold: 16384 * 8,
+			expectedInterval:  13 * time.Second,
+		},
+		"interval floored": {
+			growthRate:        100000,
+			cost:              0,
+			expectedThreshold: 16384,
+			expectedInterval:  7500 * time.Millisecond,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			threshold, interval := computeSnapshotTuning(base, tc.growthRate, tc.cost)
+			require.Equal(t, tc.expectedThreshold, threshold)
+			require.Equal(t, tc.expectedInterval, interval)
+		})
+	}
+}
+
+func TestSnapshotTuner(t *testing.T) {
+	base := raft.ReloadableConfig{
+		TrailingLogs:      10240,
+		SnapshotThreshold: 16384,
+		SnapshotInterval:  30 * time.Second,
+	}
+	start := time.Now()
+	persist := fsm.PersistStats{Bytes: 1024, Duration: 20 * time.Second}
+
+	t.Run("disabled", func(t *testing.T) {
+		tuner := newSnapshotTuner(base, false)
+		tuner.sample(0, start, persist)
+		cfg, changed := tuner.sample(30000, start.Add(time.Minute), persist)
+		require.False(t, changed)
+		require.Equal(t, base, cfg)
+
+		enabled, current, rate := tuner.status()
+		require.False(t, enabled)
+		require.Equal(t, base, current)
+		require.Equal(t, 500.0, rate)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		tuner := newSnapshotTuner(base, true)
+
+		// The first sample has no growth rate yet.
+		cfg, changed := tuner.sample(0, start, persist)
+		require.True(t, changed)
+		require.Equal(t, uint64(4096), cfg.SnapshotThreshold)
+		tuner.applied(cfg)
+
+		cfg, changed = tuner.sample(30000, start.Add(time.Minute), persist)
+		require.True(t, changed)
+		require.Equal(t, uint64(100000), cfg.SnapshotThreshold)
+		require.Equal(t, 100*time.Second, cfg.SnapshotInterval)
+		require.Equal(t, base.TrailingLogs, cfg.TrailingLogs)
+
+		// Until Raft accepts it, the previous config is still current and
+		// the tuned one is proposed again.
+		_, current, _ := tuner.status()
+		require.Equal(t, uint64(4096), current.SnapshotThreshold)
+		cfg, changed = tuner.sample(60000, start.Add(2*time.Minute), persist)
+		require.True(t, changed)
+		require.Equal(t, uint64(100000), cfg.SnapshotThreshold)
+		tuner.applied(cfg)
+
+		// A steady rate doesn't change anything.
+		_, changed = tuner.sample(90000, start.Add(3*time.Minute), persist)
+		require.False(t, changed)
+
+		// Reloading keeps the tuned values, based on the new config.
+		newBase := base
+		newBase.SnapshotThreshold = 8192
+		cfg = tuner.reload(newBase, true)
+		require.Equal(t, uint64(8192*8), cfg.SnapshotThreshold)
+
+		// Until tuning is disabled.
+		cfg = tuner.reload(newBase, false)
+		require.Equal(t, newBase, cfg)
+
+		// The current config is only replaced once applied.
+		_, current, _ = tuner.status()
+		require.Equal(t, uint64(100000), current.SnapshotThreshold)
+		tuner.applied(cfg)
+		_, current, _ = tuner.status()
+		require.Equal(t, newBase, current)
+	})
+}
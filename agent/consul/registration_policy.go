@@ -0,0 +1,32 @@
+package consul
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// vetRegistrationPolicy returns an error wrapping
+// structs.ErrServiceNameDenied when the registration-policy config entry of
+// the partition of svc denies its registration.
+func vetRegistrationPolicy(store *state.Store, svc *structs.NodeService) error {
+	if svc == nil {
+		return nil
+	}
+
+	entMeta := structs.DefaultEnterpriseMetaInPartition(svc.PartitionOrDefault())
+	_, raw, err := store.ConfigEntry(nil, structs.RegistrationPolicy, structs.RegistrationPolicyGlobal, entMeta)
+	if err != nil {
+		return fmt.Errorf("failed to read the registration policy: %w", err)
+	}
+	policy, ok := raw.(*structs.RegistrationPolicyConfigEntry)
+	if !ok {
+		return nil
+	}
+
+	if pattern := policy.DeniedPattern(svc.Service, svc.NamespaceOrDefault()); pattern != "" {
+		return fmt.Errorf("%w: service %q matches %q", structs.ErrServiceNameDenied, svc.Service, pattern)
+	}
+	return nil
+}
@@ -24,6 +24,7 @@ import (
 	"google.golang.org/grpc"
 
 	msgpackrpc "github.com/hashicorp/consul-net-rpc/net-rpc-msgpackrpc"
+	"github.com/hashicorp/consul-net-rpc/net/rpc"
 
 	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/consul/state"
@@ -61,6 +62,18 @@ var RPCCounters = []prometheus.CounterDefinition{
 		Name: []string{"rpc", "query"},
 		Help: "Increments when a server receives a read request, indicating the rate of new read queries.",
 	},
+	{
+		Name: []string{"rpc", "query", "coalesced"},
+		Help: "Increments when a server answers a blocking query with the result of an identical query already in flight.",
+	},
+	{
+		Name: []string{"rpc", "source_limit", "rejected"},
+		Help: "Increments when a server rejects an RPC request because its source exceeds the concurrency or in-flight bytes limit.",
+	},
+	{
+		Name: []string{"rpc", "bounded_stale", "forwarded"},
+		Help: "Increments when a server forwards a bounded_stale read to the leader because it lags too far behind.",
+	},
 }
 
 var RPCGauges = []prometheus.GaugeDefinition{
@@ -259,6 +272,16 @@ func (s *Server) handleNativeTLS(conn net.Conn) {
 	)
 
 	tlscfg := s.tlsConfigurator.IncomingALPNRPCConfig(pool.RPCNextProtos)
+
+	// Peers don't have a client certificate, so their streams are told apart
+	// by their protocol and are only allowed on the peering gRPC server.
+	getConfigForClient := tlscfg.GetConfigForClient
+	tlscfg.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		if len(hello.SupportedProtos) == 1 && hello.SupportedProtos[0] == pool.ALPN_RPCPeering {
+			return s.tlsConfigurator.IncomingPeeringConfig([]string{pool.ALPN_RPCPeering}), nil
+		}
+		return getConfigForClient(hello)
+	}
 	tlsConn := tls.Server(conn, tlscfg)
 
 	// Force the handshake to conclude.
@@ -309,6 +332,9 @@ func (s *Server) handleNativeTLS(conn net.Conn) {
 	case pool.ALPN_RPCGRPC:
 		s.grpcHandler.Handle(tlsConn)
 
+	case pool.ALPN_RPCPeering:
+		s.peeringGRPCHandler.Handle(tlsConn)
+
 	case pool.ALPN_WANGossipPacket:
 		if err := s.handleALPN_WANGossipPacketStream(tlsConn); err != nil && err != io.EOF {
 			s.rpcLogger().Error(
@@ -408,7 +434,8 @@ func (s *Server) handleMultiplexV2(conn net.Conn) {
 // handleConsulConn is used to service a single Consul RPC connection
 func (s *Server) handleConsulConn(conn net.Conn) {
 	defer conn.Close()
-	rpcCodec := msgpackrpc.NewCodecFromHandle(true, true, conn, structs.MsgpackHandle)
+	rpcCodec := s.newConsulServerCodec(conn)
+	defer rpcCodec.releaseRequest()
 	for {
 		select {
 		case <-s.shutdownCh:
@@ -417,6 +444,16 @@ func (s *Server) handleConsulConn(conn net.Conn) {
 		}
 
 		if err := s.rpcServer.ServeRequest(rpcCodec); err != nil {
+			// The rejected request was answered with the error, and the
+			// connection can serve the next one unless the request could
+			// only be partially read.
+			if errors.Is(err, structs.ErrRPCSourceLimitExceeded) {
+				s.rpcLogger().Debug("rejected RPC request", "conn", logConn(conn), "error", err)
+				if rpcCodec.broken {
+					return
+				}
+				continue
+			}
 			if err != io.EOF && !strings.Contains(err.Error(), "closed") {
 				s.rpcLogger().Error("RPC error",
 					"conn", logConn(conn),
@@ -430,6 +467,43 @@ func (s *Server) handleConsulConn(conn net.Conn) {
 	}
 }
 
+// newConsulServerCodec returns the codec for the RPC requests of conn, which
+// enforces the per source limits and sets the scope of the anonymous token of
+// the requests from their source, unless conn comes from a server of the
+// datacenter, for instance forwarding the requests of its own clients.
+func (s *Server) newConsulServerCodec(conn net.Conn) *limitedServerCodec {
+	fromServer := s.isLocalServerAddr(conn.RemoteAddr())
+	limiter := s.rpcSourceLimiter
+	if fromServer {
+		limiter = nil
+	}
+	codec := newLimitedServerCodec(conn, limiter, func(rwc io.ReadWriteCloser) rpc.ServerCodec {
+		return msgpackrpc.NewCodecFromHandle(false, true, rwc, structs.MsgpackHandle)
+	})
+	if !fromServer {
+		codec.prepare = func(body interface{}) {
+			s.setAnonymousScope(body, conn.RemoteAddr())
+			s.recordTokenUsage(body, conn.RemoteAddr())
+		}
+	}
+	return codec
+}
+
+// isLocalServerAddr returns whether addr is the address of a server of the
+// datacenter.
+func (s *Server) isLocalServerAddr(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	for _, srv := range s.serverLookup.Servers() {
+		if srvAddr, ok := srv.Addr.(*net.TCPAddr); ok && srvAddr.IP.Equal(tcpAddr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
 // handleInsecureConsulConn is used to service a single Consul INSECURERPC connection
 func (s *Server) handleInsecureConn(conn net.Conn) {
 	defer conn.Close()
@@ -570,6 +644,12 @@ func canRetry(info structs.RPCInfo, err error, start time.Time, config *Config)
 		return true
 	}
 
+	// Requests rejected because of the per source limits of the server were
+	// not processed so they are safe to retry.
+	if structs.IsErrRPCSourceLimitExceeded(err) {
+		return true
+	}
+
 	// If we are chunking and it doesn't seem to have completed, try again.
 	if err != nil && strings.Contains(err.Error(), ErrChunkingResubmit.Error()) {
 		return true
@@ -646,11 +726,17 @@ func (s *Server) forwardRPC(
 
 	// See if we should let this server handle the read request without
 	// shipping the request to the leader.
-	if s.canServeReadRequest(info) {
-		return false, nil
+	if !s.canServeReadRequest(info) {
+		if handled, err := s.forwardRequestToLeader(info, forwardToLeader); handled || err != nil {
+			return handled, err
+		}
 	}
 
-	return s.forwardRequestToLeader(info, forwardToLeader)
+	// The request is handled by this server.
+	if err := s.setAnonymousScopeToken(info); err != nil {
+		return true, err
+	}
+	return false, nil
 }
 
 // forwardRequestToOtherDatacenter is an implementation detail of forwardRPC.
@@ -693,11 +779,34 @@ func (s *Server) forwardRequestToOtherDatacenter(info structs.RPCInfo, forwardTo
 	return false, nil
 }
 
+// boundedStaleRequest is implemented by requests that support the
+// bounded_stale consistency mode.
+type boundedStaleRequest interface {
+	GetMaxIndexLag() uint64
+}
+
 // canServeReadRequest determines if the request is a stale read request and
 // the current node can safely process that request.
 func (s *Server) canServeReadRequest(info structs.RPCInfo) bool {
 	// Check if we can allow a stale read, ensure our local DB is initialized
-	return info.IsRead() && info.AllowStaleRead() && !s.raft.LastContact().IsZero()
+	if !info.IsRead() || !info.AllowStaleRead() || s.raft.LastContact().IsZero() {
+		return false
+	}
+
+	// Bounded stale reads are only served when this server heard from the
+	// leader recently and is close enough to the last index of its log, they
+	// are forwarded to it otherwise.
+	if req, ok := info.(boundedStaleRequest); ok && req.GetMaxIndexLag() > 0 {
+		s.leaderIndex.requested(time.Now())
+		lag, known := s.raftIndexLag()
+		if s.raft.Leader() == "" ||
+			time.Since(s.raft.LastContact()) > s.config.RaftConfig.HeartbeatTimeout ||
+			!known || lag > req.GetMaxIndexLag() {
+			metrics.IncrCounter([]string{"rpc", "bounded_stale", "forwarded"}, 1)
+			return false
+		}
+	}
+	return true
 }
 
 // forwardRequestToLeader is an implementation detail of forwardRPC.
@@ -781,6 +890,17 @@ func (s *Server) getLeader() (bool, *metadata.Server, error) {
 
 // forwardDC is used to forward an RPC call to a remote DC, or fail if no servers
 func (s *Server) forwardDC(method, dc string, args interface{}, reply interface{}) error {
+	// The secret of a scope of the anonymous token is only known to this
+	// server, the other datacenter resolves the scope of the request itself.
+	if info, ok := args.(structs.RPCInfo); ok {
+		if token := info.TokenSecret(); token != "" {
+			if _, scoped := s.anonymousScopeFromToken(token); scoped {
+				info.SetTokenSecret("")
+				defer info.SetTokenSecret(token)
+			}
+		}
+	}
+
 	manager, server, ok := s.router.FindRoute(dc)
 	if !ok {
 		if s.router.HasDatacenter(dc) {
@@ -1111,6 +1231,12 @@ var (
 	errNotChanged = fmt.Errorf("data did not change for query")
 )
 
+// indexLagSetter is implemented by response metadata that reports how far
+// behind the leader the server answering the query was.
+type indexLagSetter interface {
+	SetIndexLag(uint64)
+}
+
 // setQueryMeta is used to populate the QueryMeta data for an RPC call
 //
 // Note: This method must be called *after* filtering query results with ACLs.
@@ -1121,6 +1247,10 @@ func (s *Server) setQueryMeta(m blockingQueryResponseMeta, token string) {
 	} else {
 		m.SetLastContact(time.Since(s.raft.LastContact()))
 		m.SetKnownLeader(s.raft.Leader() != "")
+		if v, ok := m.(indexLagSetter); ok {
+			lag, _ := s.raftIndexLag()
+			v.SetIndexLag(lag)
+		}
 	}
 	maskResultsFilteredByACLs(token, m)
 
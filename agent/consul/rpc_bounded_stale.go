@@ -0,0 +1,105 @@
+package consul
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+const (
+	// leaderIndexInterval is how often a follower fetches the last index of
+	// the leader's Raft log while bounded stale reads are requested.
+	leaderIndexInterval = time.Second
+
+	// leaderIndexMaxAge is how old the last index of the leader can be for
+	// a follower to serve bounded stale reads.
+	leaderIndexMaxAge = 3 * leaderIndexInterval
+
+	// boundedStaleIdleTimeout is how long after the last bounded stale read
+	// a follower stops fetching the last index of the leader.
+	boundedStaleIdleTimeout = time.Minute
+)
+
+// leaderIndexTracker tracks the last index of the leader's Raft log on a
+// follower, for the bounded_stale consistency mode to measure how far behind
+// the leader it is. The times are stored as Unix nanoseconds. It must be
+// allocated on its own for the fields to be aligned for atomic operations.
+type leaderIndexTracker struct {
+	index       uint64
+	fetchedAt   int64
+	requestedAt int64
+}
+
+// requested records that a bounded stale read was requested at now.
+func (t *leaderIndexTracker) requested(now time.Time) {
+	atomic.StoreInt64(&t.requestedAt, now.UnixNano())
+}
+
+// active returns whether bounded stale reads were requested recently enough
+// for the index of the leader to be fetched.
+func (t *leaderIndexTracker) active(now time.Time) bool {
+	return now.Sub(time.Unix(0, atomic.LoadInt64(&t.requestedAt))) < boundedStaleIdleTimeout
+}
+
+// set records the last index of the leader fetched at now.
+func (t *leaderIndexTracker) set(index uint64, now time.Time) {
+	atomic.StoreUint64(&t.index, index)
+	atomic.StoreInt64(&t.fetchedAt, now.UnixNano())
+}
+
+// get returns the last known index of the leader, and whether it is recent
+// enough to serve bounded stale reads.
+func (t *leaderIndexTracker) get(now time.Time) (uint64, bool) {
+	fetchedAt := atomic.LoadInt64(&t.fetchedAt)
+	index := atomic.LoadUint64(&t.index)
+	return index, fetchedAt != 0 && now.Sub(time.Unix(0, fetchedAt)) <= leaderIndexMaxAge
+}
+
+// raftIndexLag returns the number of entries of the leader's Raft log that
+// this server has not applied to its state store yet, and whether the index of
+// the leader is known recently enough for the lag to be accurate. The index of
+// the leader is only fetched while bounded stale reads are requested, the
+// entries replicated to this server are used otherwise.
+func (s *Server) raftIndexLag() (uint64, bool) {
+	leaderIndex, known := s.leaderIndex.get(time.Now())
+	if last := s.raft.LastIndex(); last > leaderIndex {
+		leaderIndex = last
+	}
+	applied := s.raft.AppliedIndex()
+	if applied >= leaderIndex {
+		return 0, known
+	}
+	return leaderIndex - applied, known
+}
+
+// runLeaderIndexTracker periodically fetches the last index of the leader's
+// Raft log while this server is a follower serving bounded stale reads, until
+// the server shuts down.
+func (s *Server) runLeaderIndexTracker() {
+	ticker := time.NewTicker(leaderIndexInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.shutdownCh:
+			return
+		case now := <-ticker.C:
+			if !s.leaderIndex.active(now) {
+				continue
+			}
+			isLeader, leader, err := s.getLeader()
+			if isLeader || err != nil {
+				continue
+			}
+
+			var stats structs.RaftStats
+			err = s.connPool.RPC(s.config.Datacenter, leader.ShortName, leader.Addr, "Status.RaftStats", &EmptyReadRequest{}, &stats)
+			if err != nil {
+				s.rpcLogger().Debug("failed to fetch the last index of the leader", "leader", leader.ShortName, "error", err)
+				continue
+			}
+			s.leaderIndex.set(stats.LastIndex, now)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package consul
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul-net-rpc/net/rpc"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// rpcSourceLimiter limits the RPC requests being processed for each source
+// address, so that a single misbehaving agent, for instance one stuck in an
+// anti-entropy loop, can't monopolize the resources of a server.
+type rpcSourceLimiter struct {
+	lock sync.Mutex
+
+	// maxConcurrent is the maximum number of requests processed at once for
+	// a source, zero for no limit.
+	maxConcurrent int
+
+	// maxInFlightBytes is the maximum size of the requests processed at
+	// once for a source, zero for no limit.
+	maxInFlightBytes int
+
+	// sources tracks the requests being processed for each source.
+	sources map[string]*rpcSourceUsage
+}
+
+type rpcSourceUsage struct {
+	requests int
+	bytes    int
+}
+
+func newRPCSourceLimiter() *rpcSourceLimiter {
+	return &rpcSourceLimiter{sources: make(map[string]*rpcSourceUsage)}
+}
+
+// SetConfig updates the limits, which apply to the requests accepted
+// afterwards.
+func (l *rpcSourceLimiter) SetConfig(maxConcurrent, maxInFlightBytes int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.maxConcurrent = maxConcurrent
+	l.maxInFlightBytes = maxInFlightBytes
+}
+
+// acquire accounts for a request of size bytes from source, or returns an
+// error wrapping structs.ErrRPCSourceLimitExceeded when this would exceed
+// one of the limits. The returned func must be called once the request has
+// been processed.
+func (l *rpcSourceLimiter) acquire(source string, size int) (func(), error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	usage, ok := l.sources[source]
+	if !ok {
+		usage = &rpcSourceUsage{}
+	}
+
+	if l.maxConcurrent > 0 && usage.requests >= l.maxConcurrent {
+		metrics.IncrCounterWithLabels([]string{"rpc", "source_limit", "rejected"}, 1,
+			[]metrics.Label{{Name: "limit", Value: "concurrency"}})
+		return nil, fmt.Errorf("%w: %d concurrent requests from %s (rpc_max_concurrent_requests_per_client = %d)",
+			structs.ErrRPCSourceLimitExceeded, usage.requests, source, l.maxConcurrent)
+	}
+	// A request larger than the limit is still accepted when it is the only
+	// one of its source, otherwise it could never be processed.
+	if l.maxInFlightBytes > 0 && usage.requests > 0 && usage.bytes+size > l.maxInFlightBytes {
+		metrics.IncrCounterWithLabels([]string{"rpc", "source_limit", "rejected"}, 1,
+			[]metrics.Label{{Name: "limit", Value: "bytes"}})
+		return nil, fmt.Errorf("%w: %d bytes of requests in flight from %s (rpc_max_inflight_bytes_per_client = %d)",
+			structs.ErrRPCSourceLimitExceeded, usage.bytes+size, source, l.maxInFlightBytes)
+	}
+
+	usage.requests++
+	usage.bytes += size
+	l.sources[source] = usage
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { l.release(source, size) })
+	}, nil
+}
+
+// bytesBudget returns the size of the next request of source above which it
+// would exceed the in-flight bytes limit, and false when there is no such
+// size because no limit is set or no request of the source is in flight.
+func (l *rpcSourceLimiter) bytesBudget(source string) (int, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	usage, ok := l.sources[source]
+	if l.maxInFlightBytes <= 0 || !ok || usage.requests == 0 {
+		return 0, false
+	}
+	return l.maxInFlightBytes - usage.bytes, true
+}
+
+func (l *rpcSourceLimiter) release(source string, size int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	usage, ok := l.sources[source]
+	if !ok {
+		return
+	}
+	usage.requests--
+	usage.bytes -= size
+	if usage.requests <= 0 {
+		delete(l.sources, source)
+	}
+}
+
+// errRPCRequestTooLarge is returned by a countingReader reading past its
+// limit.
+var errRPCRequestTooLarge = errors.New("request exceeds the in-flight bytes limit")
+
+// countingReader counts the bytes read from the underlying reader, and fails
+// reads past limit when limited is set.
+type countingReader struct {
+	io.Reader
+	read int
+
+	limited bool
+	limit   int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	if r.limited {
+		if r.read >= r.limit {
+			return 0, errRPCRequestTooLarge
+		}
+		if len(p) > r.limit-r.read {
+			p = p[:r.limit-r.read]
+		}
+	}
+	n, err := r.Reader.Read(p)
+	r.read += n
+	return n, err
+}
+
+// blockingRequest is implemented by the requests supporting blocking queries.
+type blockingRequest interface {
+	GetMinQueryIndex() uint64
+}
+
+// limitedServerCodec enforces the limits of a rpcSourceLimiter on the
+// requests read from a connection. Requests exceeding the concurrency limit
+// are read and answered with an error without being processed. Requests
+// exceeding the in-flight bytes limit are rejected while being decoded, so
+// they are never held in memory, and the connection is then closed as the
+// rest of the request can't be skipped. Blocking queries are not counted as
+// they spend most of their time waiting rather than using the resources of
+// the server. No limit is enforced without a limiter.
+type limitedServerCodec struct {
+	rpc.ServerCodec
+	limiter *rpcSourceLimiter
+	source  string
+	reader  *countingReader
+
+	// consumed is the number of bytes read by the previous requests.
+	consumed int
+
+	// release frees the request being processed from the limiter.
+	release func()
+
+	// broken is set once a request was only partially read, after which the
+	// connection can't serve other requests.
+	broken bool
+
+	// prepare, if set, is called with the body of each request before it is
+	// processed.
+	prepare func(body interface{})
+}
+
+// newLimitedServerCodec wraps conn in a codec enforcing the limits of the
+// limiter for the source address of the connection. The reads from conn are
+// buffered, so newCodec should not buffer them again as it would read ahead
+// of the request being decoded.
+func newLimitedServerCodec(conn net.Conn, limiter *rpcSourceLimiter, newCodec func(io.ReadWriteCloser) rpc.ServerCodec) *limitedServerCodec {
+	source := conn.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(source); err == nil {
+		source = host
+	}
+	reader := &countingReader{Reader: bufio.NewReader(conn)}
+	return &limitedServerCodec{
+		ServerCodec: newCodec(struct {
+			io.Reader
+			io.Writer
+			io.Closer
+		}{reader, conn, conn}),
+		limiter: limiter,
+		source:  source,
+		reader:  reader,
+	}
+}
+
+func (c *limitedServerCodec) ReadRequestBody(body interface{}) error {
+	var budget int
+	if c.limiter != nil && body != nil {
+		if b, ok := c.limiter.bytesBudget(c.source); ok {
+			budget = b
+			c.reader.limited = true
+			c.reader.limit = c.reader.read + budget
+		}
+	}
+	err := c.ServerCodec.ReadRequestBody(body)
+	c.reader.limited = false
+	size := c.reader.read - c.consumed
+	c.consumed = c.reader.read
+	if errors.Is(err, errRPCRequestTooLarge) {
+		c.broken = true
+		metrics.IncrCounterWithLabels([]string{"rpc", "source_limit", "rejected"}, 1,
+			[]metrics.Label{{Name: "limit", Value: "bytes"}})
+		return fmt.Errorf("%w: request from %s is larger than the %d bytes left by rpc_max_inflight_bytes_per_client",
+			structs.ErrRPCSourceLimitExceeded, c.source, budget)
+	}
+	if err == nil && body != nil && c.prepare != nil {
+		c.prepare(body)
+	}
+	if err != nil || body == nil || c.limiter == nil {
+		return err
+	}
+	if req, ok := body.(blockingRequest); ok && req.GetMinQueryIndex() > 0 {
+		return nil
+	}
+
+	release, err := c.limiter.acquire(c.source, size)
+	if err != nil {
+		return err
+	}
+	c.release = release
+	return nil
+}
+
+func (c *limitedServerCodec) WriteResponse(resp *rpc.Response, body interface{}) error {
+	err := c.ServerCodec.WriteResponse(resp, body)
+	c.releaseRequest()
+	return err
+}
+
+func (c *limitedServerCodec) Close() error {
+	c.releaseRequest()
+	return c.ServerCodec.Close()
+}
+
+func (c *limitedServerCodec) releaseRequest() {
+	if c.release != nil {
+		c.release()
+		c.release = nil
+	}
+}
@@ -0,0 +1,136 @@
+package consul
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	msgpackrpc "github.com/hashicorp/consul-net-rpc/net-rpc-msgpackrpc"
+	"github.com/hashicorp/consul-net-rpc/net/rpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestRPCSourceLimiter(t *testing.T) {
+	t.Run("no limits", func(t *testing.T) {
+		l := newRPCSourceLimiter()
+		for i := 0; i < 100; i++ {
+			_, err := l.acquire("10.0.0.1", 1<<20)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("concurrency", func(t *testing.T) {
+		l := newRPCSourceLimiter()
+		l.SetConfig(2, 0)
+
+		release1, err := l.acquire("10.0.0.1", 10)
+		require.NoError(t, err)
+		_, err = l.acquire("10.0.0.1", 10)
+		require.NoError(t, err)
+
+		_, err = l.acquire("10.0.0.1", 10)
+		require.True(t, structs.IsErrRPCSourceLimitExceeded(err))
+
+		// other sources are not affected
+		_, err = l.acquire("10.0.0.2", 10)
+		require.NoError(t, err)
+
+		// releasing twice only frees a single request
+		release1()
+		release1()
+		_, err = l.acquire("10.0.0.1", 10)
+		require.NoError(t, err)
+		_, err = l.acquire("10.0.0.1", 10)
+		require.True(t, structs.IsErrRPCSourceLimitExceeded(err))
+	})
+
+	t.Run("in-flight bytes", func(t *testing.T) {
+		l := newRPCSourceLimiter()
+		l.SetConfig(0, 100)
+
+		release1, err := l.acquire("10.0.0.1", 60)
+		require.NoError(t, err)
+		_, err = l.acquire("10.0.0.1", 60)
+		require.True(t, structs.IsErrRPCSourceLimitExceeded(err))
+		_, err = l.acquire("10.0.0.1", 40)
+		require.NoError(t, err)
+
+		release1()
+		_, err = l.acquire("10.0.0.1", 60)
+		require.NoError(t, err)
+	})
+
+	t.Run("a single large request is accepted", func(t *testing.T) {
+		l := newRPCSourceLimiter()
+		l.SetConfig(0, 100)
+
+		release, err := l.acquire("10.0.0.1", 1000)
+		require.NoError(t, err)
+		_, err = l.acquire("10.0.0.1", 1)
+		require.True(t, structs.IsErrRPCSourceLimitExceeded(err))
+
+		release()
+		require.Empty(t, l.sources)
+	})
+}
+
+func TestLimitedServerCodec(t *testing.T) {
+	newCodecs := func(t *testing.T, l *rpcSourceLimiter) (*limitedServerCodec, rpc.ClientCodec) {
+		server, client := net.Pipe()
+		t.Cleanup(func() {
+			server.Close()
+			client.Close()
+		})
+		codec := newLimitedServerCodec(server, l, func(rwc io.ReadWriteCloser) rpc.ServerCodec {
+			return msgpackrpc.NewCodecFromHandle(false, true, rwc, structs.MsgpackHandle)
+		})
+		return codec, msgpackrpc.NewCodecFromHandle(true, true, client, structs.MsgpackHandle)
+	}
+	send := func(client rpc.ClientCodec, body interface{}) {
+		go client.WriteRequest(&rpc.Request{ServiceMethod: "Health.ServiceNodes", Seq: 1}, body)
+	}
+	read := func(codec *limitedServerCodec) error {
+		var req rpc.Request
+		require.NoError(t, codec.ReadRequestHeader(&req))
+		return codec.ReadRequestBody(&structs.ServiceSpecificRequest{})
+	}
+
+	t.Run("blocking queries are not counted", func(t *testing.T) {
+		l := newRPCSourceLimiter()
+		l.SetConfig(1, 0)
+		codec, client := newCodecs(t, l)
+
+		send(client, &structs.ServiceSpecificRequest{
+			ServiceName:  "web",
+			QueryOptions: structs.QueryOptions{MinQueryIndex: 10},
+		})
+		require.NoError(t, read(codec))
+		require.Empty(t, l.sources)
+
+		send(client, &structs.ServiceSpecificRequest{ServiceName: "web"})
+		require.NoError(t, read(codec))
+		require.Len(t, l.sources, 1)
+		codec.releaseRequest()
+		require.Empty(t, l.sources)
+	})
+
+	t.Run("in-flight bytes are limited while decoding", func(t *testing.T) {
+		l := newRPCSourceLimiter()
+		l.SetConfig(0, 100)
+		codec, client := newCodecs(t, l)
+
+		// A request of the same source is already in flight.
+		release, err := l.acquire(codec.source, 60)
+		require.NoError(t, err)
+		defer release()
+
+		send(client, &structs.ServiceSpecificRequest{ServiceName: strings.Repeat("a", 1000)})
+		err = read(codec)
+		require.True(t, structs.IsErrRPCSourceLimitExceeded(err), "unexpected error: %v", err)
+		require.True(t, codec.broken)
+		require.LessOrEqual(t, codec.reader.read, 200)
+	})
+}
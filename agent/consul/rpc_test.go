@@ -210,6 +210,64 @@ func TestRPC_getLeader_ErrLeaderNotTracked(t *testing.T) {
 	require.False(t, isLeader)
 }
 
+func TestRPC_BoundedStaleRead(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	cluster := newTestCluster(t, &testClusterConfig{
+		Datacenter: "dc1",
+		Servers:    3,
+	})
+
+	followerIdx := -1
+	for i, srv := range cluster.Servers {
+		if !srv.IsLeader() {
+			followerIdx = i
+			break
+		}
+	}
+	require.NotEqual(t, -1, followerIdx)
+	follower, codec := cluster.Servers[followerIdx], cluster.ServerCodecs[followerIdx]
+
+	stale := &structs.KeyRequest{
+		Datacenter:   "dc1",
+		Key:          "foo",
+		QueryOptions: structs.QueryOptions{AllowStale: true},
+	}
+	bounded := &structs.KeyRequest{
+		Datacenter:   "dc1",
+		Key:          "foo",
+		QueryOptions: structs.QueryOptions{AllowStale: true, MaxIndexLag: 100},
+	}
+	require.Equal(t, "bounded_stale", bounded.ConsistencyLevel())
+
+	// The follower is up to date, it serves bounded stale reads itself.
+	retry.Run(t, func(r *retry.R) {
+		require.True(r, follower.canServeReadRequest(bounded))
+		lag, known := follower.raftIndexLag()
+		require.True(r, known)
+		require.Zero(r, lag)
+	})
+	var out structs.IndexedDirEntries
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.Get", bounded, &out))
+	require.True(t, out.KnownLeader)
+	require.LessOrEqual(t, out.IndexLag, bounded.MaxIndexLag)
+
+	// Without a leader the follower can't know how far behind it is, only
+	// the unbounded stale reads are still served.
+	for _, srv := range cluster.Servers {
+		if srv != follower {
+			srv.Shutdown()
+		}
+	}
+	retry.Run(t, func(r *retry.R) {
+		require.Empty(r, follower.raft.Leader())
+	})
+	require.True(t, follower.canServeReadRequest(stale))
+	require.False(t, follower.canServeReadRequest(bounded))
+}
+
 type MockSink struct {
 	*bytes.Buffer
 	cancel bool
@@ -1792,3 +1850,27 @@ func rpcBlockingQueryTestHarness(
 
 	require.Equal(t, 1, count, "if this fails, then the timer likely needs to be increased above")
 }
+
+func TestLeaderIndexTracker(t *testing.T) {
+	tracker := &leaderIndexTracker{}
+	now := time.Now()
+
+	// Nothing is known before the index of the leader is fetched.
+	require.False(t, tracker.active(now))
+	_, known := tracker.get(now)
+	require.False(t, known)
+
+	tracker.requested(now)
+	require.True(t, tracker.active(now))
+	require.False(t, tracker.active(now.Add(boundedStaleIdleTimeout)))
+
+	tracker.set(42, now)
+	index, known := tracker.get(now.Add(leaderIndexMaxAge))
+	require.True(t, known)
+	require.Equal(t, uint64(42), index)
+
+	// An index fetched too long ago can't be relied on.
+	index, known = tracker.get(now.Add(leaderIndexMaxAge + time.Second))
+	require.False(t, known)
+	require.Equal(t, uint64(42), index)
+}
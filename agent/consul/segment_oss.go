@@ -4,11 +4,13 @@
 package consul
 
 import (
+	"fmt"
 	"net"
 
 	"github.com/armon/go-metrics/prometheus"
+	"github.com/hashicorp/serf/serf"
 
-	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/metadata"
 )
 
 var SegmentOSSSummaries = []prometheus.SummaryDefinition{
@@ -18,31 +20,102 @@ var SegmentOSSSummaries = []prometheus.SummaryDefinition{
 	},
 }
 
+// segmentLANSnapshot returns the path of the Serf snapshot for the given
+// segment, relative to the data dir.
+func segmentLANSnapshot(name string) string {
+	return fmt.Sprintf("serf/segment-%s.snapshot", name)
+}
+
 // LANSegmentAddr is used to return the address used for the given LAN segment.
 func (s *Server) LANSegmentAddr(name string) string {
-	return ""
+	segment, ok := s.segmentLAN[name]
+	if !ok {
+		return ""
+	}
+	conf := segment.Memberlist().LocalNode()
+	return net.JoinHostPort(conf.Addr.String(), fmt.Sprintf("%d", conf.Port))
 }
 
-// setupSegmentRPC returns an error if any segments are defined since the OSS
-// version of Consul doesn't support them.
+// setupSegmentRPC binds the extra RPC listeners for any segments that were
+// configured with a separate RPC listener.
 func (s *Server) setupSegmentRPC() (map[string]net.Listener, error) {
-	if len(s.config.Segments) > 0 {
-		return nil, structs.ErrSegmentsNotSupported
-	}
+	listeners := make(map[string]net.Listener)
+	for _, segment := range s.config.Segments {
+		if segment.RPCAddr == nil {
+			continue
+		}
 
-	return nil, nil
+		ln, err := net.ListenTCP("tcp", segment.RPCAddr)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("failed to bind RPC listener for segment %q: %w", segment.Name, err)
+		}
+		listeners[segment.Name] = ln
+		s.logger.Info("Segment RPC listener bound", "segment", segment.Name, "address", ln.Addr().String())
+	}
+	s.segmentListeners = listeners
+	return listeners, nil
 }
 
-// setupSegments returns an error if any segments are defined since the OSS
-// version of Consul doesn't support them.
+// setupSegments creates the LAN Serf pool for each network segment and
+// publishes the segment addresses as tags on the default LAN Serf config so
+// that other servers can flood-join the segment pools.
 func (s *Server) setupSegments(config *Config, rpcListeners map[string]net.Listener) error {
-	if len(config.Segments) > 0 {
-		return structs.ErrSegmentsNotSupported
-	}
+	segments := make(map[string]*serf.Serf, len(config.Segments))
+	for _, segment := range config.Segments {
+		listener := s.Listener
+		if ln, ok := rpcListeners[segment.Name]; ok {
+			listener = ln
+		}
+
+		cluster, _, err := s.setupSerf(setupSerfOptions{
+			Config:       segment.SerfConfig,
+			EventCh:      s.eventChLAN,
+			SnapshotPath: segmentLANSnapshot(segment.Name),
+			Listener:     listener,
+			WAN:          false,
+			Segment:      segment.Name,
+			Partition:    "",
+		})
+		if err != nil {
+			for _, c := range segments {
+				c.Shutdown()
+			}
+			return fmt.Errorf("failed to start LAN Serf for segment %q: %w", segment.Name, err)
+		}
+		segments[segment.Name] = cluster
 
+		// The memberlist config is updated with the actual bind port when
+		// binding to a dynamic port.
+		addr := net.JoinHostPort(segment.Advertise,
+			fmt.Sprintf("%d", segment.SerfConfig.MemberlistConfig.BindPort))
+		config.SerfLANConfig.Init()
+		config.SerfLANConfig.Tags["sl_"+segment.Name] = addr
+	}
+	s.segmentLAN = segments
 	return nil
 }
 
-// floodSegments is a NOP in the OSS version of Consul.
+// floodSegments starts a flooder for each segment so that all the servers
+// known in the default LAN pool also join the segment's pool.
 func (s *Server) floodSegments(config *Config) {
+	for _, segment := range config.Segments {
+		name := segment.Name
+		pool, ok := s.segmentLAN[name]
+		if !ok {
+			continue
+		}
+
+		addrFn := func(srv *metadata.Server) (string, error) {
+			addr, ok := srv.SegmentAddrs[name]
+			if !ok {
+				return "", fmt.Errorf("server %s has no address for segment %q", srv.Name, name)
+			}
+			port := srv.SegmentPorts[name]
+			return net.JoinHostPort(addr, fmt.Sprintf("%d", port)), nil
+		}
+		go s.Flood(addrFn, pool)
+	}
 }
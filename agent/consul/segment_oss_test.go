@@ -0,0 +1,97 @@
+//go:build !consulent
+// +build !consulent
+
+package consul
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/serf/serf"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/sdk/freeport"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	"github.com/hashicorp/consul/testrpc"
+)
+
+func TestServer_Segments(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		port := freeport.GetOne(t)
+		serfConf := DefaultConfig().SerfLANConfig
+		serfConf.MemberlistConfig.BindAddr = "127.0.0.1"
+		serfConf.MemberlistConfig.BindPort = port
+		serfConf.MemberlistConfig.AdvertiseAddr = "127.0.0.1"
+		serfConf.MemberlistConfig.AdvertisePort = port
+		serfConf.MemberlistConfig.ProbeTimeout = 50 * time.Millisecond
+		serfConf.MemberlistConfig.ProbeInterval = 100 * time.Millisecond
+		serfConf.MemberlistConfig.GossipInterval = 100 * time.Millisecond
+
+		c.Segments = []NetworkSegment{{
+			Name:       "alpha",
+			Bind:       "127.0.0.1",
+			Advertise:  "127.0.0.1",
+			Port:       port,
+			SerfConfig: serfConf,
+		}}
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	dir2, c1 := testClientWithConfig(t, func(c *Config) {
+		c.Segment = "alpha"
+	})
+	defer os.RemoveAll(dir2)
+	defer c1.Shutdown()
+
+	dir3, c2 := testClient(t)
+	defer os.RemoveAll(dir3)
+	defer c2.Shutdown()
+
+	joinLAN(t, c1, s1)
+	joinLAN(t, c2, s1)
+	testrpc.WaitForTestAgent(t, c1.RPC, "dc1")
+	testrpc.WaitForTestAgent(t, c2.RPC, "dc1")
+
+	retry.Run(t, func(r *retry.R) {
+		if got, want := c1.router.GetLANManager().NumServers(), 1; got != want {
+			r.Fatalf("got %d servers want %d", got, want)
+		}
+	})
+
+	memberNames := func(members []serf.Member) []string {
+		var names []string
+		for _, m := range members {
+			names = append(names, m.Name)
+		}
+		return names
+	}
+
+	// Each segment pool only contains its own clients and the server.
+	defaultMembers, err := s1.LANMembers(LANMemberFilter{})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{s1.config.NodeName, c2.config.NodeName}, memberNames(defaultMembers))
+
+	alphaMembers, err := s1.LANMembers(LANMemberFilter{Segment: "alpha"})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{s1.config.NodeName, c1.config.NodeName}, memberNames(alphaMembers))
+	require.ElementsMatch(t, []string{s1.config.NodeName, c1.config.NodeName}, memberNames(c1.LANMembersInAgentPartition()))
+
+	allMembers, err := s1.LANMembers(LANMemberFilter{AllSegments: true})
+	require.NoError(t, err)
+	require.Len(t, allMembers, 4)
+
+	_, err = s1.LANMembers(LANMemberFilter{Segment: "beta"})
+	require.EqualError(t, err, `segment "beta" not found`)
+
+	// A client in the default segment is rejected by the segment's pool.
+	_, err = c2.JoinLAN([]string{s1.LANSegmentAddr("alpha")}, nil)
+	require.Error(t, err)
+}
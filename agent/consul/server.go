@@ -20,6 +20,7 @@ import (
 	connlimit "github.com/hashicorp/go-connlimit"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/raft"
 	autopilot "github.com/hashicorp/raft-autopilot"
@@ -44,6 +45,7 @@ import (
 	aclgrpc "github.com/hashicorp/consul/agent/grpc/public/services/acl"
 	"github.com/hashicorp/consul/agent/grpc/public/services/connectca"
 	"github.com/hashicorp/consul/agent/grpc/public/services/dataplane"
+	"github.com/hashicorp/consul/agent/grpc/public/services/discovery"
 	"github.com/hashicorp/consul/agent/grpc/public/services/serverdiscovery"
 	"github.com/hashicorp/consul/agent/metadata"
 	"github.com/hashicorp/consul/agent/pool"
@@ -116,6 +118,7 @@ const (
 	caRootPruningRoutineName              = "CA root pruning"
 	caRootMetricRoutineName               = "CA root expiration metric"
 	caSigningMetricRoutineName            = "CA signing expiration metric"
+	caRootsWebhookRoutineName             = "CA roots webhook"
 	configReplicationRoutineName          = "config entry replication"
 	federationStateReplicationRoutineName = "federation state replication"
 	federationStateAntiEntropyRoutineName = "federation state anti-entropy"
@@ -126,6 +129,9 @@ const (
 	backgroundCAInitializationRoutineName = "CA initialization"
 	virtualIPCheckRoutineName             = "virtual IP version check"
 	peeringStreamsRoutineName             = "streaming peering resources"
+	trafficShiftRoutineName               = "traffic shift controller"
+	maintenanceWindowRoutineName          = "maintenance window controller"
+	protocolDetectionRoutineName          = "protocol detection"
 )
 
 var (
@@ -154,6 +160,15 @@ type Server struct {
 
 	aclAuthMethodValidators authmethod.Cache
 
+	// anonymousScopePrefix prefixes the secrets this server uses for requests
+	// in the scopes of the anonymous token. It is random and never leaves the
+	// server.
+	anonymousScopePrefix string
+
+	// autoConfig is the AutoConfig endpoint, it is kept to update the config
+	// snippets it distributes on reload.
+	autoConfig *AutoConfig
+
 	// autopilot is the Autopilot instance for this server.
 	autopilot *autopilot.Autopilot
 
@@ -163,6 +178,9 @@ type Server struct {
 	// rate limiter to use when signing leaf certificates
 	caLeafLimiter connectSignRateLimiter
 
+	// caLeafRotationWindow caches the leaf rotation window of the CA roots
+	caLeafRotationWindow leafRotationWindowCache
+
 	// Consul configuration
 	config *Config
 
@@ -222,6 +240,24 @@ type Server struct {
 	raftTransport *raft.NetworkTransport
 	raftInmem     *raft.InmemStore
 
+	// raftLog is the log store given to Raft, used to report on the log.
+	raftLog raft.LogStore
+
+	// raftLogsReplayLock is held while the Raft log is replayed into a
+	// scratch FSM, so that a server runs one replay at a time.
+	raftLogsReplayLock sync.Mutex
+
+	// snapshotTuner tunes the Raft snapshot policy when enabled.
+	snapshotTuner *snapshotTuner
+
+	// tableUsage caches the usage of the state store tables, which is
+	// computed periodically.
+	tableUsage tableUsageCache
+
+	// leaderIndex tracks the last index of the leader's Raft log while this
+	// server is a follower serving bounded stale reads.
+	leaderIndex *leaderIndexTracker
+
 	// raftNotifyCh is set up by setupRaft() and ensures that we get reliable leader
 	// transition notifications from the Raft layer.
 	raftNotifyCh <-chan bool
@@ -266,11 +302,31 @@ type Server struct {
 	// rpcConnLimiter limits the number of RPC connections from a single source IP
 	rpcConnLimiter connlimit.Limiter
 
+	// rpcSourceLimiter limits the RPC requests processed at once for a single
+	// source IP
+	rpcSourceLimiter *rpcSourceLimiter
+
+	// coalescedQueries shares the results of identical blocking queries
+	coalescedQueries *coalescedQueries
+
+	// tokenUsage records the usage of tokens by the requests received by the
+	// server until it is added to the state store.
+	tokenUsage *aclTokenUsageRecorder
+
+	// serviceMetrics aggregates the traffic between services reported by
+	// the agents while this server is the leader.
+	serviceMetrics *serviceMetrics
+
 	// Listener is used to listen for incoming connections
 	Listener    net.Listener
 	grpcHandler connHandler
 	rpcServer   *rpc.Server
 
+	// peeringGRPCHandler serves the peering streams established over native
+	// TLS, such as through mesh gateways, which don't present a client
+	// certificate.
+	peeringGRPCHandler connHandler
+
 	// insecureRPCServer is a RPC server that is configure with
 	// IncomingInsecureRPCConfig to allow clients to call AutoEncrypt.Sign
 	// to request client certificates. At this point a client doesn't have
@@ -296,6 +352,14 @@ type Server struct {
 	//
 	serfLAN *serf.Serf
 
+	// segmentLAN maps segment names to their LAN Serf pools. The default
+	// segment is not included; it is always served by serfLAN.
+	segmentLAN map[string]*serf.Serf
+
+	// segmentListeners holds the extra RPC listeners bound for segments
+	// configured with rpc_listener.
+	segmentListeners map[string]net.Listener
+
 	// serfWAN is the Serf cluster maintained between DC's
 	// which SHOULD only consist of Consul servers
 	serfWAN                *serf.Serf
@@ -396,6 +460,11 @@ func NewServer(config *Config, flat Deps, publicGRPCServer *grpc.Server) (*Serve
 		return nil, err
 	}
 
+	anonymousScopeID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
 	// Create the shutdown channel - this is closed but never written to.
 	shutdownCh := make(chan struct{})
 
@@ -419,6 +488,7 @@ func NewServer(config *Config, flat Deps, publicGRPCServer *grpc.Server) (*Serve
 		connPool:                flat.ConnPool,
 		grpcConnPool:            flat.GRPCConnPool,
 		eventChLAN:              make(chan serf.Event, serfEventChSize),
+		leaderIndex:             &leaderIndexTracker{},
 		eventChWAN:              make(chan serf.Event, serfEventChSize),
 		logger:                  serverLogger,
 		loggers:                 loggers,
@@ -431,9 +501,14 @@ func NewServer(config *Config, flat Deps, publicGRPCServer *grpc.Server) (*Serve
 		sessionTimers:           NewSessionTimers(),
 		tombstoneGC:             gc,
 		serverLookup:            NewServerLookup(),
+		rpcSourceLimiter:        newRPCSourceLimiter(),
+		coalescedQueries:        newCoalescedQueries(),
+		tokenUsage:              newACLTokenUsageRecorder(),
+		serviceMetrics:          newServiceMetrics(serviceMetricsWindow),
 		shutdownCh:              shutdownCh,
 		leaderRoutineManager:    routine.NewManager(logger.Named(logging.Leader)),
 		aclAuthMethodValidators: authmethod.NewCache(),
+		anonymousScopePrefix:    anonymousScopeID + ":",
 		fsm:                     fsm.NewFromDeps(fsmDeps),
 		publisher:               eventPublisher,
 	}
@@ -718,11 +793,18 @@ func NewServer(config *Config, flat Deps, publicGRPCServer *grpc.Server) (*Serve
 		Logger:      logger.Named("grpc-api.server-discovery"),
 	}).Register(s.publicGRPCServer)
 
+	discovery.NewServer(discovery.Config{
+		GetStore:    func() discovery.StateStore { return s.FSM().State() },
+		ACLResolver: plainACLResolver{s.ACLResolver},
+		Logger:      logger.Named("grpc-api.discovery"),
+	}).Register(s.publicGRPCServer)
+
 	// Initialize private gRPC server.
 	//
 	// Note: some "public" gRPC services are also exposed on the private gRPC server
 	// to enable RPC forwarding.
 	s.grpcHandler = newGRPCHandlerFromConfig(flat, config, s)
+	s.peeringGRPCHandler = newPeeringGRPCHandler(flat, config, s)
 	s.grpcLeaderForwarder = flat.LeaderForwarder
 	go s.trackLeaderChanges()
 
@@ -740,6 +822,11 @@ func NewServer(config *Config, flat Deps, publicGRPCServer *grpc.Server) (*Serve
 			s.logger.Error("gRPC server failed", "error", err)
 		}
 	}()
+	go func() {
+		if err := s.peeringGRPCHandler.Run(); err != nil {
+			s.logger.Error("peering gRPC server failed", "error", err)
+		}
+	}()
 	go s.listen(s.Listener)
 
 	// Start listeners for any segments with separate RPC listeners.
@@ -754,6 +841,18 @@ func NewServer(config *Config, flat Deps, publicGRPCServer *grpc.Server) (*Serve
 	// Start the metrics handlers.
 	go s.updateMetrics()
 
+	// Start tuning the snapshot policy.
+	go s.runSnapshotTuner()
+
+	// Start computing the usage of the state store tables.
+	go s.runTableUsage()
+
+	// Start tracking the index of the leader for bounded stale reads.
+	go s.runLeaderIndexTracker()
+
+	// Start adding the usage of tokens to the state store.
+	go s.runTokenUsageFlush()
+
 	return s, nil
 }
 
@@ -763,6 +862,8 @@ func newGRPCHandlerFromConfig(deps Deps, config *Config, s *Server) connHandler
 		peering.Config{
 			Datacenter:     config.Datacenter,
 			ConnectEnabled: config.ConnectEnabled,
+			// Without ACLs all the connections are allowed.
+			IntentionDefaultAllow: !config.ACLsEnabled || config.ACLResolverSettings.ACLDefaultPolicy == "allow",
 		},
 		NewPeeringBackend(s, deps.GRPCConnPool),
 	)
@@ -786,6 +887,26 @@ func newGRPCHandlerFromConfig(deps Deps, config *Config, s *Server) connHandler
 	return agentgrpc.NewHandler(deps.Logger, config.RPCAddr, register)
 }
 
+// newPeeringGRPCHandler returns the gRPC server of the peering streams which
+// don't present a client certificate. It only serves the streams, as the other
+// peering endpoints must not be reachable by peers.
+func newPeeringGRPCHandler(deps Deps, config *Config, s *Server) connHandler {
+	register := func(srv *grpc.Server) {
+		pbpeering.RegisterPeeringServiceServer(srv, peeringStreamServer{service: s.peeringService})
+	}
+	return agentgrpc.NewHandler(deps.Logger, config.RPCAddr, register)
+}
+
+// peeringStreamServer only implements the streams of the peering service.
+type peeringStreamServer struct {
+	pbpeering.UnimplementedPeeringServiceServer
+	service *peering.Service
+}
+
+func (s peeringStreamServer) StreamResources(stream pbpeering.PeeringService_StreamResourcesServer) error {
+	return s.service.StreamResources(stream)
+}
+
 func (s *Server) connectCARootsMonitor(ctx context.Context) {
 	for {
 		ws := memdb.NewWatchSet()
@@ -979,7 +1100,18 @@ func (s *Server) setupRaft() error {
 	// Setup the Raft store.
 	var err error
 	s.raft, err = raft.NewRaft(s.config.RaftConfig, s.fsm.ChunkingFSM(), log, stable, snap, trans)
-	return err
+	if err != nil {
+		return err
+	}
+	s.raftLog = log
+	s.snapshotTuner = newSnapshotTuner(raft.ReloadableConfig{
+		TrailingLogs:      s.config.RaftConfig.TrailingLogs,
+		SnapshotInterval:  s.config.RaftConfig.SnapshotInterval,
+		SnapshotThreshold: s.config.RaftConfig.SnapshotThreshold,
+		HeartbeatTimeout:  s.config.RaftConfig.HeartbeatTimeout,
+		ElectionTimeout:   s.config.RaftConfig.ElectionTimeout,
+	}, s.config.RaftSnapshotAutoTune)
+	return nil
 }
 
 // endpointFactory is a function that returns an RPC endpoint bound to the given
@@ -999,6 +1131,7 @@ func (s *Server) setupRPC() error {
 	s.rpcConnLimiter.SetConfig(connlimit.Config{
 		MaxConnsPerClientIP: s.config.RPCMaxConnsPerClient,
 	})
+	s.rpcSourceLimiter.SetConfig(s.config.RPCMaxConcurrentRequestsPerClient, s.config.RPCMaxInFlightBytesPerClient)
 
 	for _, fn := range endpoints {
 		s.rpcServer.Register(fn(s))
@@ -1019,16 +1152,18 @@ func (s *Server) setupRPC() error {
 		}
 
 		authz = &jwtAuthorizer{
-			validator:       validator,
-			allowReuse:      s.config.AutoConfigAuthzAllowReuse,
-			claimAssertions: s.config.AutoConfigAuthzClaimAssertions,
+			validator:        validator,
+			allowReuse:       s.config.AutoConfigAuthzAllowReuse,
+			claimAssertions:  s.config.AutoConfigAuthzClaimAssertions,
+			verifiedNodeMeta: s.config.AutoConfigAuthzVerifiedNodeMeta,
 		}
 	} else {
 		// This authorizer always returns that the endpoint is disabled
 		authz = &disabledAuthorizer{}
 	}
 	// now register with the insecure RPC server
-	s.insecureRPCServer.Register(NewAutoConfig(s.config, s.tlsConfigurator, autoConfigBackend{Server: s}, authz))
+	s.autoConfig = NewAutoConfig(s.config, s.tlsConfigurator, autoConfigBackend{Server: s}, authz)
+	s.insecureRPCServer.Register(s.autoConfig)
 
 	ln, err := net.ListenTCP("tcp", s.config.RPCAddr)
 	if err != nil {
@@ -1110,12 +1245,20 @@ func (s *Server) Shutdown() error {
 	if s.Listener != nil {
 		s.Listener.Close()
 	}
+	for _, ln := range s.segmentListeners {
+		ln.Close()
+	}
 
 	if s.grpcHandler != nil {
 		if err := s.grpcHandler.Shutdown(); err != nil {
 			s.logger.Warn("failed to stop gRPC server", "error", err)
 		}
 	}
+	if s.peeringGRPCHandler != nil {
+		if err := s.peeringGRPCHandler.Shutdown(); err != nil {
+			s.logger.Warn("failed to stop peering gRPC server", "error", err)
+		}
+	}
 
 	// Close the connection pool
 	if s.connPool != nil {
@@ -1436,6 +1579,9 @@ func (s *Server) RPC(method string, args interface{}, reply interface{}) error {
 		metrics.IncrCounter([]string{"client", "rpc", "exceeded"}, 1)
 		return structs.ErrRPCRateExceeded
 	}
+	// The requests of the local agent come from the server itself.
+	s.setAnonymousScope(args, s.config.RPCAdvertise)
+	s.recordTokenUsage(args, s.config.RPCAdvertise)
 	if err := s.rpcServer.ServeRequest(codec); err != nil {
 		return err
 	}
@@ -1570,15 +1716,20 @@ func (s *Server) agentSegmentName() string {
 func (s *Server) ReloadConfig(config ReloadableConfig) error {
 	// Reload raft config first before updating any other state since it could
 	// error if the new config is invalid.
-	raftCfg := computeRaftReloadableConfig(config)
+	raftCfg := s.snapshotTuner.reload(computeRaftReloadableConfig(config), config.RaftSnapshotAutoTune)
 	if err := s.raft.ReloadConfig(raftCfg); err != nil {
 		return err
 	}
+	s.snapshotTuner.applied(raftCfg)
 
 	s.rpcLimiter.Store(rate.NewLimiter(config.RPCRateLimit, config.RPCMaxBurst))
 	s.rpcConnLimiter.SetConfig(connlimit.Config{
 		MaxConnsPerClientIP: config.RPCMaxConnsPerClient,
 	})
+	s.rpcSourceLimiter.SetConfig(config.RPCMaxConcurrentRequestsPerClient, config.RPCMaxInFlightBytesPerClient)
+	if s.autoConfig != nil {
+		s.autoConfig.setConfigSnippets(config.AutoConfigSnippets)
+	}
 
 	if s.IsLeader() {
 		// only bootstrap the config entries if we are the leader
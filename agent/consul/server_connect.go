@@ -2,6 +2,8 @@ package consul
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/go-memdb"
 
@@ -72,5 +74,74 @@ func (s *Server) getCARoots(ws memdb.WatchSet, state *state.Store) (*structs.Ind
 		}
 	}
 
+	// The window is only computed again when the roots or the CA config
+	// change, which is when the clients need it. The service usage isn't
+	// added to the watch set as it changes far more often than the roots,
+	// and the window only needs to be an estimate.
+	window, err := s.caLeafRotationWindow.get(index, func() (time.Duration, error) {
+		_, usage, err := state.ServiceUsage()
+		if err != nil {
+			return 0, err
+		}
+		commonCfg, err := config.GetCommonConfig()
+		if err != nil {
+			return 0, err
+		}
+		instances := 0
+		for _, count := range usage.ConnectServiceInstances {
+			instances += count
+		}
+		return leafRotationWindow(instances, commonCfg.CSRMaxPerSecond), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	indexedRoots.LeafRotationWindow = window
+
 	return indexedRoots, nil
 }
+
+// leafRotationWindowCache holds the leaf rotation window computed for the CA
+// roots and config at a given index.
+type leafRotationWindowCache struct {
+	lock   sync.Mutex
+	valid  bool
+	index  uint64
+	window time.Duration
+}
+
+// get returns the window computed for index, calling compute if there is
+// none yet.
+func (c *leafRotationWindowCache) get(index uint64, compute func() (time.Duration, error)) (time.Duration, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.valid && c.index == index {
+		return c.window, nil
+	}
+	window, err := compute()
+	if err != nil {
+		return 0, err
+	}
+	c.valid, c.index, c.window = true, index, window
+	return window, nil
+}
+
+// maxLeafRotationWindow caps the window over which clients spread the renewal
+// of their leaf certificates, so that rotations complete in reasonable time
+// even when the CSR rate limit is low for the size of the datacenter.
+const maxLeafRotationWindow = time.Hour
+
+// leafRotationWindow returns the time the servers need to sign a leaf
+// certificate for each of the given number of instances at the given rate, or
+// zero if the rate isn't limited.
+func leafRotationWindow(instances int, csrMaxPerSecond float32) time.Duration {
+	if instances <= 0 || csrMaxPerSecond <= 0 {
+		return 0
+	}
+	window := time.Duration(float64(instances) / float64(csrMaxPerSecond) * float64(time.Second))
+	if window > maxLeafRotationWindow {
+		return maxLeafRotationWindow
+	}
+	return window
+}
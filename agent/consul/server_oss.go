@@ -77,10 +77,14 @@ func (s *Server) removeFailedNode(
 	return nil
 }
 
-// lanPoolAllMembers only returns our own segment or partition's members, because
-// OSS servers can't be in multiple segments or partitions.
+// lanPoolAllMembers returns the members of the default LAN pool along with
+// the members of every network segment this server serves.
 func (s *Server) lanPoolAllMembers() ([]serf.Member, error) {
-	return s.LANMembersInAgentPartition(), nil
+	members := s.LANMembersInAgentPartition()
+	for _, segment := range s.segmentLAN {
+		members = append(members, segment.Members()...)
+	}
+	return members, nil
 }
 
 // LANMembers returns the LAN members for one of:
@@ -94,17 +98,38 @@ func (s *Server) LANMembers(filter LANMemberFilter) ([]serf.Member, error) {
 	if err := filter.Validate(); err != nil {
 		return nil, err
 	}
+	if filter.AllSegments {
+		return s.lanPoolAllMembers()
+	}
 	if filter.Segment != "" {
-		return nil, structs.ErrSegmentsNotSupported
+		segment, ok := s.segmentLAN[filter.Segment]
+		if !ok {
+			return nil, fmt.Errorf("segment %q not found", filter.Segment)
+		}
+		return segment.Members(), nil
 	}
 	return s.LANMembersInAgentPartition(), nil
 }
 
-func (s *Server) GetMatchingLANCoordinate(_, _ string) (*coordinate.Coordinate, error) {
+func (s *Server) GetMatchingLANCoordinate(_, segment string) (*coordinate.Coordinate, error) {
+	if segment != "" {
+		pool, ok := s.segmentLAN[segment]
+		if !ok {
+			return nil, fmt.Errorf("segment %q not found", segment)
+		}
+		return pool.GetCoordinate()
+	}
 	return s.serfLAN.GetCoordinate()
 }
 
 func (s *Server) addEnterpriseLANCoordinates(cs lib.CoordinateSet) error {
+	for name, segment := range s.segmentLAN {
+		c, err := segment.GetCoordinate()
+		if err != nil {
+			return err
+		}
+		cs[name] = c
+	}
 	return nil
 }
 
@@ -113,6 +138,11 @@ func (s *Server) LANSendUserEvent(name string, payload []byte, coalesce bool) er
 	if err != nil {
 		return fmt.Errorf("error broadcasting event: %w", err)
 	}
+	for segmentName, segment := range s.segmentLAN {
+		if err := segment.UserEvent(name, payload, coalesce); err != nil {
+			return fmt.Errorf("error broadcasting event to segment %q: %w", segmentName, err)
+		}
+	}
 	return nil
 }
 
@@ -127,6 +157,11 @@ func (s *Server) DoWithLANSerfs(
 	if err != nil {
 		return errorFn("", "", err)
 	}
+	for name, segment := range s.segmentLAN {
+		if err := fn(name, PoolKindSegment, segment); err != nil {
+			return errorFn(name, PoolKindSegment, err)
+		}
+	}
 	return nil
 }
 
@@ -137,7 +172,10 @@ func (s *Server) DoWithLANSerfs(
 func (s *Server) reconcile() (err error) {
 	defer metrics.MeasureSince([]string{"leader", "reconcile"}, time.Now())
 
-	members := s.serfLAN.Members()
+	members, err := s.lanPoolAllMembers()
+	if err != nil {
+		return err
+	}
 	knownMembers := make(map[string]struct{})
 	for _, member := range members {
 		memberName := strings.ToLower(member.Name)
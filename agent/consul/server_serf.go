@@ -13,6 +13,7 @@ import (
 	"github.com/hashicorp/raft"
 	"github.com/hashicorp/serf/serf"
 
+	"github.com/hashicorp/consul/agent/consul/tcpgossip"
 	"github.com/hashicorp/consul/agent/consul/wanfed"
 	"github.com/hashicorp/consul/agent/metadata"
 	"github.com/hashicorp/consul/agent/structs"
@@ -133,6 +134,9 @@ func (s *Server) setupSerfConfig(opts setupSerfOptions) (*serf.Config, error) {
 	// feature flag: advertise support for service-intentions
 	conf.Tags["ft_si"] = "1"
 
+	// feature flag: advertise support for compressed snapshot streams
+	conf.Tags["ft_sc"] = "1"
+
 	var subLoggerName string
 	if opts.WAN {
 		subLoggerName = logging.WAN
@@ -172,12 +176,25 @@ func (s *Server) setupSerfConfig(opts setupSerfOptions) (*serf.Config, error) {
 		}
 	}
 
+	tcpOnly := s.config.SerfLANTCPOnly
+	if opts.WAN {
+		tcpOnly = s.config.SerfWANTCPOnly
+	}
+
 	if opts.WAN {
-		nt, err := memberlist.NewNetTransport(&memberlist.NetTransportConfig{
-			BindAddrs: []string{conf.MemberlistConfig.BindAddr},
-			BindPort:  conf.MemberlistConfig.BindPort,
-			Logger:    conf.MemberlistConfig.Logger,
-		})
+		var (
+			nt  wanfed.IngestionAwareTransport
+			err error
+		)
+		if tcpOnly {
+			nt, err = tcpgossip.Configure(conf.MemberlistConfig, s.logger.Named(logging.WAN))
+		} else {
+			nt, err = memberlist.NewNetTransport(&memberlist.NetTransportConfig{
+				BindAddrs: []string{conf.MemberlistConfig.BindAddr},
+				BindPort:  conf.MemberlistConfig.BindPort,
+				Logger:    conf.MemberlistConfig.Logger,
+			})
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -197,6 +214,10 @@ func (s *Server) setupSerfConfig(opts setupSerfOptions) (*serf.Config, error) {
 		} else {
 			conf.MemberlistConfig.Transport = nt
 		}
+	} else if tcpOnly {
+		if _, err := tcpgossip.Configure(conf.MemberlistConfig, s.logger.Named(logging.LAN)); err != nil {
+			return nil, err
+		}
 	}
 
 	// Until Consul supports this fully, we disable automatic resolution.
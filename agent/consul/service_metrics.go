@@ -0,0 +1,146 @@
+package consul
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// serviceMetricsWindow is the period the traffic between services is
+// summarized over.
+const serviceMetricsWindow = time.Minute
+
+// serviceMetrics aggregates the traffic between services reported by the
+// agents. Reports are sent to the leader and only kept in memory, so the
+// summaries start over when the leadership changes.
+type serviceMetrics struct {
+	lock   sync.Mutex
+	window time.Duration
+
+	// samples are ordered by the time they were received.
+	samples []serviceMetricsSample
+}
+
+type serviceMetricsSample struct {
+	// start and end are the period covered by the sample.
+	start time.Time
+	end   time.Time
+	structs.ServiceMetricsSample
+}
+
+func newServiceMetrics(window time.Duration) *serviceMetrics {
+	return &serviceMetrics{window: window}
+}
+
+// record adds the samples of a report received at the given time.
+func (m *serviceMetrics) record(report *structs.ServiceMetricsReportRequest, now time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.pruneLocked(now)
+	start := now.Add(-report.Interval)
+	for _, sample := range report.Samples {
+		m.samples = append(m.samples, serviceMetricsSample{
+			start:                start,
+			end:                  now,
+			ServiceMetricsSample: sample,
+		})
+	}
+}
+
+// pruneLocked drops the samples which ended before the window.
+func (m *serviceMetrics) pruneLocked(now time.Time) {
+	cutoff := now.Add(-m.window)
+	i := sort.Search(len(m.samples), func(i int) bool {
+		return m.samples[i].end.After(cutoff)
+	})
+	if i == 0 {
+		return
+	}
+	m.samples = append(m.samples[:0], m.samples[i:]...)
+}
+
+// summarize returns the traffic from and to the given service over the
+// window, sorted by the name of the other service. Pairs involving a service
+// for which allowed returns false are left out.
+func (m *serviceMetrics) summarize(service structs.ServiceName, now time.Time, allowed func(structs.ServiceName) bool) (upstreams, downstreams []structs.ServiceMetricsSummary) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.pruneLocked(now)
+
+	type pair struct {
+		source      structs.ServiceName
+		destination structs.ServiceName
+	}
+	type totals struct {
+		structs.ServiceMetricsCounters
+		since time.Time
+	}
+	byPair := make(map[pair]*totals)
+	for _, sample := range m.samples {
+		if sample.Source != service && sample.Destination != service {
+			continue
+		}
+		p := pair{source: sample.Source, destination: sample.Destination}
+		t, ok := byPair[p]
+		if !ok {
+			t = &totals{since: sample.start}
+			byPair[p] = t
+		}
+		t.Add(sample.ServiceMetricsCounters)
+		if sample.start.Before(t.since) {
+			t.since = sample.start
+		}
+	}
+
+	windowStart := now.Add(-m.window)
+	for p, t := range byPair {
+		var other structs.ServiceName
+		if p.source == service {
+			other = p.destination
+		} else {
+			other = p.source
+		}
+		if !allowed(other) {
+			continue
+		}
+
+		summary := structs.ServiceMetricsSummary{
+			Source:                 p.source,
+			Destination:            p.destination,
+			ServiceMetricsCounters: t.ServiceMetricsCounters,
+		}
+		since := t.since
+		if since.Before(windowStart) {
+			since = windowStart
+		}
+		if covered := now.Sub(since).Seconds(); covered > 0 {
+			summary.RequestsPerSecond = float64(t.Requests) / covered
+		}
+		if t.Requests > 0 {
+			summary.ErrorRate = float64(t.Errors) / float64(t.Requests)
+		}
+		if t.DurationSamples > 0 {
+			summary.MeanDuration = t.Duration / time.Duration(t.DurationSamples)
+		}
+
+		// A service calling itself is both an upstream and a downstream.
+		if p.source == service {
+			upstreams = append(upstreams, summary)
+		}
+		if p.destination == service {
+			downstreams = append(downstreams, summary)
+		}
+	}
+
+	sort.Slice(upstreams, func(i, j int) bool {
+		return upstreams[i].Destination.String() < upstreams[j].Destination.String()
+	})
+	sort.Slice(downstreams, func(i, j int) bool {
+		return downstreams[i].Source.String() < downstreams[j].Source.String()
+	})
+	return upstreams, downstreams
+}
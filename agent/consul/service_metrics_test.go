@@ -0,0 +1,104 @@
+package consul
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestServiceMetrics(t *testing.T) {
+	t.Parallel()
+
+	web := structs.NewServiceName("web", nil)
+	api := structs.NewServiceName("api", nil)
+	db := structs.NewServiceName("db", nil)
+	sample := func(source, destination structs.ServiceName, requests, errors uint64) structs.ServiceMetricsSample {
+		return structs.ServiceMetricsSample{
+			Source:      source,
+			Destination: destination,
+			ServiceMetricsCounters: structs.ServiceMetricsCounters{
+				Requests: requests,
+				Errors:   errors,
+			},
+		}
+	}
+	allowAll := func(structs.ServiceName) bool { return true }
+
+	m := newServiceMetrics(time.Minute)
+	start := time.Now()
+
+	// Two instances of web report their requests to api.
+	m.record(&structs.ServiceMetricsReportRequest{
+		Interval: 10 * time.Second,
+		Samples:  []structs.ServiceMetricsSample{sample(web, api, 100, 10)},
+	}, start)
+	m.record(&structs.ServiceMetricsReportRequest{
+		Interval: 10 * time.Second,
+		Samples: []structs.ServiceMetricsSample{
+			sample(web, api, 100, 0),
+			sample(api, db, 50, 50),
+			// Durations are reported separately from the requests.
+			{
+				Source:      web,
+				Destination: api,
+				ServiceMetricsCounters: structs.ServiceMetricsCounters{
+					Duration:        3 * time.Second,
+					DurationSamples: 150,
+				},
+			},
+		},
+	}, start.Add(10*time.Second))
+
+	upstreams, downstreams := m.summarize(api, start.Add(10*time.Second), allowAll)
+	require.Equal(t, []structs.ServiceMetricsSummary{{
+		Source:                 api,
+		Destination:            db,
+		ServiceMetricsCounters: structs.ServiceMetricsCounters{Requests: 50, Errors: 50},
+		RequestsPerSecond:      5,
+		ErrorRate:              1,
+	}}, upstreams)
+	require.Equal(t, []structs.ServiceMetricsSummary{{
+		Source:      web,
+		Destination: api,
+		ServiceMetricsCounters: structs.ServiceMetricsCounters{
+			Requests:        200,
+			Errors:          10,
+			Duration:        3 * time.Second,
+			DurationSamples: 150,
+		},
+		RequestsPerSecond: 10,
+		ErrorRate:         0.05,
+		MeanDuration:      20 * time.Millisecond,
+	}}, downstreams)
+
+	t.Run("acl filtering", func(t *testing.T) {
+		upstreams, downstreams := m.summarize(api, start.Add(10*time.Second), func(sn structs.ServiceName) bool {
+			return sn != web
+		})
+		require.Len(t, upstreams, 1)
+		require.Empty(t, downstreams)
+	})
+
+	t.Run("rates are computed over the window", func(t *testing.T) {
+		m.record(&structs.ServiceMetricsReportRequest{
+			Interval: 10 * time.Second,
+			Samples:  []structs.ServiceMetricsSample{sample(web, api, 400, 0)},
+		}, start.Add(65*time.Second))
+
+		// The first sample ended more than a minute ago.
+		_, downstreams := m.summarize(api, start.Add(65*time.Second), allowAll)
+		require.Len(t, downstreams, 1)
+		require.Equal(t, uint64(500), downstreams[0].Requests)
+		require.InDelta(t, 500.0/60, downstreams[0].RequestsPerSecond, 0.001)
+	})
+
+	t.Run("samples expire", func(t *testing.T) {
+		upstreams, downstreams := m.summarize(api, start.Add(10*time.Minute), allowAll)
+		require.Empty(t, upstreams)
+		require.Empty(t, downstreams)
+		require.Empty(t, m.samples)
+	})
+}
@@ -17,7 +17,9 @@ import (
 	"time"
 
 	"github.com/hashicorp/consul-net-rpc/go-msgpack/codec"
+	"github.com/klauspost/compress/zstd"
 
+	"github.com/hashicorp/consul/agent/metadata"
 	"github.com/hashicorp/consul/agent/pool"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/snapshot"
@@ -37,6 +39,7 @@ func (s *Server) dispatchSnapshotRequest(args *structs.SnapshotRequest, in io.Re
 			return nil, structs.ErrNoDCPath
 		}
 
+		args.Compression = snapshotCompression(server)
 		snap, err := SnapshotRPC(s.connPool, dc, server.ShortName, server.Addr, args, in, reply)
 		if err != nil {
 			manager.NotifyFailedServer(server)
@@ -52,6 +55,7 @@ func (s *Server) dispatchSnapshotRequest(args *structs.SnapshotRequest, in io.Re
 			if err != nil {
 				return nil, err
 			}
+			args.Compression = snapshotCompression(server)
 			return SnapshotRPC(s.connPool, args.Datacenter, server.ShortName, server.Addr, args, in, reply)
 		}
 	}
@@ -155,8 +159,26 @@ func (s *Server) handleSnapshotRequest(conn net.Conn) error {
 		return fmt.Errorf("failed to decode request: %v", err)
 	}
 
+	// The compression only applies to this hop, the request is sent
+	// uncompressed if it's forwarded to a server that doesn't support it.
+	compression := args.Compression
+	args.Compression = ""
+	var in io.Reader = conn
+	switch compression {
+	case "":
+	case structs.SnapshotCompressionZstd:
+		dec, err := zstd.NewReader(conn)
+		if err != nil {
+			return fmt.Errorf("failed to decompress request: %v", err)
+		}
+		defer dec.Close()
+		in = dec
+	default:
+		return fmt.Errorf("unsupported snapshot compression %q", compression)
+	}
+
 	var reply structs.SnapshotResponse
-	snap, err := s.dispatchSnapshotRequest(&args, conn, &reply)
+	snap, err := s.dispatchSnapshotRequest(&args, in, &reply)
 	if err != nil {
 		reply.Error = err.Error()
 		goto RESPOND
@@ -173,7 +195,7 @@ RESPOND:
 		return fmt.Errorf("failed to encode response: %v", err)
 	}
 	if snap != nil {
-		if _, err := io.Copy(conn, snap); err != nil {
+		if err := copySnapshotStream(conn, snap, compression); err != nil {
 			return fmt.Errorf("failed to stream snapshot: %v", err)
 		}
 	}
@@ -181,6 +203,48 @@ RESPOND:
 	return nil
 }
 
+// snapshotCompression returns the compression to use for the streams of a
+// snapshot request sent to server.
+func snapshotCompression(server *metadata.Server) string {
+	if server.FeatureFlags["sc"] == 1 {
+		return structs.SnapshotCompressionZstd
+	}
+	return ""
+}
+
+// copySnapshotStream copies the streaming data of a snapshot request to w,
+// compressing it if needed.
+func copySnapshotStream(w io.Writer, r io.Reader, compression string) error {
+	if compression != structs.SnapshotCompressionZstd {
+		_, err := io.Copy(w, r)
+		return err
+	}
+
+	// Snapshots are already gzipped, use the fastest level to only pay for
+	// the savings that come cheap.
+	enc, err := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(enc, r); err != nil {
+		enc.Close()
+		return err
+	}
+	return enc.Close()
+}
+
+// zstdSnapshotReader decompresses the streaming data of a snapshot response
+// and closes the connection it is read from.
+type zstdSnapshotReader struct {
+	*zstd.Decoder
+	conn net.Conn
+}
+
+func (r *zstdSnapshotReader) Close() error {
+	r.Decoder.Close()
+	return r.conn.Close()
+}
+
 // SnapshotRPC is a streaming client function for performing a snapshot RPC
 // request to a remote server. It will create a fresh connection for each
 // request, send the request header, and then stream in any data from the
@@ -219,7 +283,7 @@ func SnapshotRPC(
 	if err := enc.Encode(&args); err != nil {
 		return nil, fmt.Errorf("failed to encode request: %v", err)
 	}
-	if _, err := io.Copy(conn, in); err != nil {
+	if err := copySnapshotStream(conn, in, args.Compression); err != nil {
 		return nil, fmt.Errorf("failed to copy snapshot in: %v", err)
 	}
 
@@ -246,5 +310,13 @@ func SnapshotRPC(
 	}
 
 	keep = true
+	if args.Compression == structs.SnapshotCompressionZstd {
+		dec, err := zstd.NewReader(conn)
+		if err != nil {
+			keep = false
+			return nil, fmt.Errorf("failed to decompress response: %v", err)
+		}
+		return &zstdSnapshotReader{Decoder: dec, conn: conn}, nil
+	}
 	return conn, nil
 }
@@ -21,6 +21,12 @@ import (
 
 // verifySnapshot is a helper that does a snapshot and restore.
 func verifySnapshot(t *testing.T, s *Server, dc, token string) {
+	verifySnapshotWithCompression(t, s, dc, token, "")
+}
+
+// verifySnapshotWithCompression does a snapshot and restore with the given
+// compression of the streams.
+func verifySnapshotWithCompression(t *testing.T, s *Server, dc, token, compression string) {
 	codec := rpcClient(t, s)
 	defer codec.Close()
 
@@ -45,9 +51,10 @@ func verifySnapshot(t *testing.T, s *Server, dc, token string) {
 
 	// Take a snapshot.
 	args := structs.SnapshotRequest{
-		Datacenter: dc,
-		Token:      token,
-		Op:         structs.SnapshotSave,
+		Datacenter:  dc,
+		Token:       token,
+		Op:          structs.SnapshotSave,
+		Compression: compression,
 	}
 	var reply structs.SnapshotResponse
 	snap, err := SnapshotRPC(s.connPool, s.config.Datacenter, s.config.NodeName, s.config.RPCAddr,
@@ -176,6 +183,20 @@ func TestSnapshot(t *testing.T) {
 	require.Equal(t, autopilot.Running, apstatus)
 }
 
+func TestSnapshot_Compressed(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	verifySnapshotWithCompression(t, s1, "dc1", "", structs.SnapshotCompressionZstd)
+}
+
 func TestSnapshot_LeaderState(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -78,6 +78,9 @@ func aclTokenDeleteWithToken(tx WriteTxn, token *structs.ACLToken, idx uint64) e
 	if err := tx.Delete(tableACLTokens, token); err != nil {
 		return fmt.Errorf("failed deleting acl token: %v", err)
 	}
+	if err := aclTokenUsageDeleteTxn(tx, idx, token.AccessorID); err != nil {
+		return err
+	}
 
 	// update the overall acl-tokens index
 	if err := indexUpdateMaxTxn(tx, idx, tableACLTokens); err != nil {
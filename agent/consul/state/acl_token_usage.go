@@ -0,0 +1,133 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-memdb"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+const tableACLTokenUsage = "acl-token-usage"
+
+func tokenUsageTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: tableACLTokenUsage,
+		Indexes: map[string]*memdb.IndexSchema{
+			indexID: {
+				Name:         indexID,
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: indexerSingle{
+					readIndex:  readIndex(indexFromUUIDString),
+					writeIndex: writeIndex(indexAccessorIDFromACLTokenUsage),
+				},
+			},
+		},
+	}
+}
+
+func indexAccessorIDFromACLTokenUsage(raw interface{}) ([]byte, error) {
+	e, ok := raw.(*structs.ACLTokenUsageEntry)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T for structs.ACLTokenUsageEntry index", raw)
+	}
+
+	if e.AccessorID == "" {
+		return nil, errMissingValueForIndex
+	}
+	return indexFromUUIDString(e.AccessorID)
+}
+
+// ACLTokenUsageUpdate adds the usage recorded by a server to the usage of the
+// tokens. The usage of tokens which don't exist anymore is dropped.
+func (s *Store) ACLTokenUsageUpdate(idx uint64, usage map[string]structs.ACLTokenUsage) error {
+	tx := s.db.WriteTxn(idx)
+	defer tx.Abort()
+
+	updated := false
+	for accessorID, u := range usage {
+		token, err := tx.First(tableACLTokens, indexAccessor, accessorID)
+		if err != nil {
+			return fmt.Errorf("failed acl token lookup: %v", err)
+		}
+		if token == nil {
+			continue
+		}
+
+		entry := &structs.ACLTokenUsageEntry{
+			AccessorID: accessorID,
+			RaftIndex:  structs.RaftIndex{CreateIndex: idx},
+		}
+		existing, err := tx.First(tableACLTokenUsage, indexID, accessorID)
+		if err != nil {
+			return fmt.Errorf("failed acl token usage lookup: %v", err)
+		}
+		if existing != nil {
+			prev := existing.(*structs.ACLTokenUsageEntry)
+			entry.Usage = prev.Usage
+			entry.CreateIndex = prev.CreateIndex
+		}
+		entry.Usage.Merge(u)
+		entry.ModifyIndex = idx
+
+		if err := tx.Insert(tableACLTokenUsage, entry); err != nil {
+			return fmt.Errorf("failed inserting acl token usage: %v", err)
+		}
+		updated = true
+	}
+	if !updated {
+		return nil
+	}
+	if err := indexUpdateMaxTxn(tx, idx, tableACLTokenUsage); err != nil {
+		return fmt.Errorf("failed updating acl token usage index: %v", err)
+	}
+	return tx.Commit()
+}
+
+// ACLTokenUsageGet returns the usage of a token, or nil if it was never used.
+func (s *Store) ACLTokenUsageGet(ws memdb.WatchSet, accessorID string) (uint64, *structs.ACLTokenUsage, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	watchCh, existing, err := tx.FirstWatch(tableACLTokenUsage, indexID, accessorID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed acl token usage lookup: %v", err)
+	}
+	ws.Add(watchCh)
+
+	idx := maxIndexTxn(tx, tableACLTokenUsage)
+	if existing == nil {
+		return idx, nil, nil
+	}
+	usage := existing.(*structs.ACLTokenUsageEntry).Usage
+	return idx, &usage, nil
+}
+
+// aclTokenUsageDeleteTxn deletes the usage of a token along with the token.
+func aclTokenUsageDeleteTxn(tx WriteTxn, idx uint64, accessorID string) error {
+	if accessorID == "" {
+		return nil
+	}
+	existing, err := tx.First(tableACLTokenUsage, indexID, accessorID)
+	if err != nil || existing == nil {
+		return err
+	}
+	if err := tx.Delete(tableACLTokenUsage, existing); err != nil {
+		return fmt.Errorf("failed deleting acl token usage: %v", err)
+	}
+	return indexUpdateMaxTxn(tx, idx, tableACLTokenUsage)
+}
+
+// ACLTokenUsage is used to pull all the token usage entries for the snapshot.
+func (s *Snapshot) ACLTokenUsage() (memdb.ResultIterator, error) {
+	return s.tx.Get(tableACLTokenUsage, indexID)
+}
+
+// ACLTokenUsage is used when restoring from a snapshot.
+func (s *Restore) ACLTokenUsage(entry *structs.ACLTokenUsageEntry) error {
+	if err := s.tx.Insert(tableACLTokenUsage, entry); err != nil {
+		return fmt.Errorf("failed restoring acl token usage: %v", err)
+	}
+	return indexUpdateMaxTxn(s.tx, entry.ModifyIndex, tableACLTokenUsage)
+}
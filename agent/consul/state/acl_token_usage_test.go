@@ -0,0 +1,71 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestStateStore_ACLTokenUsage(t *testing.T) {
+	s := testACLStateStore(t)
+
+	const (
+		accessorID = "f1093997-b6c7-496d-bfb8-6b1b1895641b"
+		unknownID  = "a0bfe8d4-b2f3-4b48-b387-f28afb820eab"
+	)
+	token := &structs.ACLToken{
+		AccessorID: accessorID,
+		SecretID:   "ed3ce1a5-2c1c-4b50-a3b4-6c1c5d2e8c31",
+		Policies:   []structs.ACLTokenPolicyLink{{ID: structs.ACLPolicyGlobalManagementID}},
+	}
+	require.NoError(t, s.ACLTokenSet(2, token))
+
+	now := time.Now().UTC()
+	require.NoError(t, s.ACLTokenUsageUpdate(3, map[string]structs.ACLTokenUsage{
+		accessorID: {
+			LastUsed:     now,
+			RequestCount: 2,
+			Sources:      []structs.ACLTokenUsageSource{{Address: "10.0.0.1", LastUsed: now, RequestCount: 2}},
+		},
+		// The usage of tokens which don't exist is dropped.
+		unknownID: {LastUsed: now, RequestCount: 1},
+	}))
+
+	later := now.Add(time.Minute)
+	require.NoError(t, s.ACLTokenUsageUpdate(4, map[string]structs.ACLTokenUsage{
+		accessorID: {
+			LastUsed:     later,
+			RequestCount: 3,
+			Sources: []structs.ACLTokenUsageSource{
+				{Address: "10.0.0.1", LastUsed: now.Add(time.Second), RequestCount: 1},
+				{Address: "10.0.0.2", LastUsed: later, RequestCount: 2},
+			},
+		},
+	}))
+
+	idx, usage, err := s.ACLTokenUsageGet(nil, accessorID)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), idx)
+	require.Equal(t, &structs.ACLTokenUsage{
+		LastUsed:     later,
+		RequestCount: 5,
+		Sources: []structs.ACLTokenUsageSource{
+			{Address: "10.0.0.2", LastUsed: later, RequestCount: 2},
+			{Address: "10.0.0.1", LastUsed: now.Add(time.Second), RequestCount: 3},
+		},
+	}, usage)
+
+	_, usage, err = s.ACLTokenUsageGet(nil, unknownID)
+	require.NoError(t, err)
+	require.Nil(t, usage)
+
+	// The usage is deleted along with the token.
+	require.NoError(t, s.ACLTokenDeleteByAccessor(5, accessorID, nil))
+	idx, usage, err = s.ACLTokenUsageGet(nil, accessorID)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), idx)
+	require.Nil(t, usage)
+}
@@ -3901,6 +3901,7 @@ func (s *Store) ServiceTopology(
 		fullyTransparent bool
 		hasTransparent   bool
 		connectNative    bool
+		peeredUpstreams  []structs.PeeredServiceName
 	)
 	switch kind {
 	case structs.ServiceKindIngressGateway:
@@ -3953,6 +3954,7 @@ func (s *Store) ServiceTopology(
 				connectNative = true
 			}
 		}
+		peeredUpstreams = peeredUpstreamsFromProxies(proxies)
 
 	default:
 		return 0, nil, fmt.Errorf("unsupported kind %q", kind)
@@ -4059,12 +4061,38 @@ func (s *Store) ServiceTopology(
 		foundUpstreams[csn.Service.CompoundServiceName()] = struct{}{}
 	}
 
-	// Check upstream names that had no service instances to see if they are routing config.
+	upstreamProtocols := make(map[string]string)
+	for _, un := range upstreamNames {
+		idx, protocol, err := protocolForService(tx, ws, un)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to fetch protocol for service %s: %v", un.String(), err)
+		}
+		if idx > maxIdx {
+			maxIdx = idx
+		}
+		upstreamProtocols[un.String()] = protocol
+	}
+
+	// Check upstream names that had no service instances to see if they are
+	// reached through a terminating gateway or are routing config.
+	upstreamGateways := make(map[string]structs.ServiceName)
 	for _, un := range upstreamNames {
 		if _, ok := foundUpstreams[un]; ok {
 			continue
 		}
 
+		idx, gateway, err := terminatingGatewayForServiceTxn(tx, ws, un)
+		if err != nil {
+			return 0, nil, err
+		}
+		if idx > maxIdx {
+			maxIdx = idx
+		}
+		if gateway != nil {
+			upstreamGateways[un.String()] = *gateway
+			continue
+		}
+
 		for _, kind := range serviceGraphKinds {
 			idx, entry, err := configEntryTxn(tx, ws, kind, un.Name, &un.EnterpriseMeta)
 			if err != nil {
@@ -4079,6 +4107,34 @@ func (s *Store) ServiceTopology(
 		}
 	}
 
+	// Upstreams imported from peers are explicitly defined in the proxy
+	// registrations. Their intentions are enforced by the exporting peer, so
+	// their decision is the one of the intentions it replicated to us, and
+	// their protocol the one it replicated with the service.
+	for _, pu := range peeredUpstreams {
+		idx, csn, err := s.combinedServiceNodesTxn(tx, ws, []structs.ServiceName{pu.ServiceName}, pu.Peer)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to get peered upstream %q for %q: %v", pu.String(), sn.String(), err)
+		}
+		if idx > maxIdx {
+			maxIdx = idx
+		}
+		upstreams = append(upstreams, csn...)
+		upstreamSources[pu.String()] = structs.TopologySourceRegistration
+		upstreamProtocols[pu.String()] = peeredServiceProtocol(csn)
+
+		idx, pi, err := peeringIntentionsReadTxn(tx, ws, pu)
+		if err != nil {
+			return 0, nil, err
+		}
+		if idx > maxIdx {
+			maxIdx = idx
+		}
+		if pi != nil {
+			upstreamDecisions[pu.String()] = pi.DecisionSummary(sn.Name, sn.NamespaceOrDefault(), false)
+		}
+	}
+
 	idx, downstreamNames, err := s.downstreamsForServiceTxn(tx, ws, dc, sn)
 	if err != nil {
 		return 0, nil, err
@@ -4186,10 +4242,70 @@ func (s *Store) ServiceTopology(
 		DownstreamDecisions: downstreamDecisions,
 		UpstreamSources:     upstreamSources,
 		DownstreamSources:   downstreamSources,
+		UpstreamProtocols:   upstreamProtocols,
+		UpstreamGateways:    upstreamGateways,
 	}
 	return maxIdx, resp, nil
 }
 
+// peeredUpstreamsFromProxies returns the unique upstreams imported from
+// peers that are defined in the given proxy registrations.
+func peeredUpstreamsFromProxies(proxies structs.ServiceNodes) []structs.PeeredServiceName {
+	var (
+		seen = make(map[structs.PeeredServiceName]struct{})
+		resp []structs.PeeredServiceName
+	)
+	for _, proxy := range proxies {
+		for _, u := range proxy.ServiceProxy.Upstreams {
+			if u.DestinationPeer == "" || u.DestinationType == structs.UpstreamDestTypePreparedQuery {
+				continue
+			}
+			upstreamMeta := acl.NewEnterpriseMetaWithPartition(proxy.PartitionOrDefault(), u.DestinationNamespace)
+			psn := structs.PeeredServiceName{
+				ServiceName: structs.NewServiceName(u.DestinationName, &upstreamMeta),
+				Peer:        u.DestinationPeer,
+			}
+			if _, ok := seen[psn]; ok {
+				continue
+			}
+			seen[psn] = struct{}{}
+			resp = append(resp, psn)
+		}
+	}
+	return resp
+}
+
+// peeredServiceProtocol returns the protocol of a service imported from a peer,
+// which the peer replicated with its sidecar proxies. It defaults to tcp like
+// the protocol of local services.
+func peeredServiceProtocol(nodes structs.CheckServiceNodes) string {
+	for _, csn := range nodes {
+		if peerMeta := csn.Service.Connect.PeerMeta; peerMeta != nil && peerMeta.Protocol != "" {
+			return peerMeta.Protocol
+		}
+	}
+	return "tcp"
+}
+
+// terminatingGatewayForServiceTxn returns the terminating gateway linked to
+// the given service, if there is one.
+func terminatingGatewayForServiceTxn(tx ReadTxn, ws memdb.WatchSet, sn structs.ServiceName) (uint64, *structs.ServiceName, error) {
+	iter, err := tx.Get(tableGatewayServices, indexService, sn)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed gateway lookup: %s", err)
+	}
+	ws.Add(iter.WatchCh())
+
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		mapping := raw.(*structs.GatewayService)
+		if mapping.GatewayKind == structs.ServiceKindTerminatingGateway {
+			gateway := mapping.Gateway
+			return mapping.ModifyIndex, &gateway, nil
+		}
+	}
+	return 0, nil, nil
+}
+
 // combinedServiceNodesTxn returns typical and connect endpoints for a list of services.
 // This enabled aggregating checks statuses across both.
 func (s *Store) combinedServiceNodesTxn(tx ReadTxn, ws memdb.WatchSet, names []structs.ServiceName, peerName string) (uint64, structs.CheckServiceNodes, error) {
@@ -4328,6 +4444,13 @@ func updateMeshTopology(tx WriteTxn, idx uint64, node string, svc *structs.NodeS
 			continue
 		}
 
+		// Upstreams imported from a peer are not local services, so tracking
+		// them here would attribute the edge to a local service of the same
+		// name. ServiceTopology reads them from the proxy registrations.
+		if u.DestinationPeer != "" {
+			continue
+		}
+
 		// TODO (freddy): Account for upstream datacenter
 		upstreamMeta := acl.NewEnterpriseMetaWithPartition(svc.PartitionOrDefault(), u.DestinationNamespace)
 		upstream := structs.NewServiceName(u.DestinationName, &upstreamMeta)
@@ -503,7 +503,7 @@ func connectEventsByServiceKind(tx ReadTxn, origEvent stream.Event) ([]stream.Ev
 	event := origEvent // shallow copy the event
 	event.Topic = EventTopicServiceHealthConnect
 
-	if node.Service.Connect.Native {
+	if node.Service.Connect.Native || node.Service.Kind == structs.ServiceKindEgressGateway {
 		return []stream.Event{event}, nil
 	}
 
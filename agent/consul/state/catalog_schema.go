@@ -278,6 +278,11 @@ func connectNameFromServiceNode(sn *structs.ServiceNode) (string, bool) {
 		// For native, this service supports Connect directly
 		return sn.ServiceName, true
 
+	case sn.ServiceKind == structs.ServiceKindEgressGateway:
+		// Egress gateways are reached directly with Connect, like native
+		// services, by the services using them as an upstream
+		return sn.ServiceName, true
+
 	default:
 		// Doesn't support Connect at all
 		return "", false
@@ -15,9 +15,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/lib/stringslice"
+	"github.com/hashicorp/consul/proto/pbpeering"
 	"github.com/hashicorp/consul/sdk/testutil"
 	"github.com/hashicorp/consul/types"
 )
@@ -2741,6 +2743,26 @@ func TestStateStore_ConnectServiceNodes_Gateways(t *testing.T) {
 	assert.Equal(t, 8000, nodes[0].ServicePort)
 }
 
+func TestStateStore_ConnectServiceNodes_EgressGateway(t *testing.T) {
+	s := testStateStore(t)
+
+	require.NoError(t, s.EnsureNode(10, &structs.Node{Node: "foo", Address: "127.0.0.1"}))
+	require.NoError(t, s.EnsureService(11, "foo", &structs.NodeService{Kind: structs.ServiceKindEgressGateway, ID: "egress", Service: "egress", Port: 8443}))
+	require.NoError(t, s.EnsureService(12, "foo", &structs.NodeService{ID: "web", Service: "web", Port: 5000}))
+
+	// Egress gateways are reached directly with Connect under their own name.
+	ws := memdb.NewWatchSet()
+	idx, nodes, err := s.ConnectServiceNodes(ws, "egress", nil, "")
+	require.NoError(t, err)
+	require.Equal(t, uint64(11), idx)
+	require.Len(t, nodes, 1)
+	require.Equal(t, structs.ServiceKindEgressGateway, nodes[0].ServiceKind)
+
+	_, nodes, err = s.ConnectServiceNodes(ws, "web", nil, "")
+	require.NoError(t, err)
+	require.Len(t, nodes, 0)
+}
+
 func TestStateStore_Service_Snapshot(t *testing.T) {
 	s := testStateStore(t)
 
@@ -8258,3 +8280,118 @@ func setVirtualIPFlags(t *testing.T, s *Store) {
 		Value: "true",
 	}))
 }
+
+func TestCatalog_ServiceTopology_PeeredAndGatewayUpstreams(t *testing.T) {
+	s := testStateStore(t)
+	require.NoError(t, s.CASetConfig(0, &structs.CAConfiguration{Provider: "consul"}))
+
+	require.NoError(t, s.EnsureNode(1, &structs.Node{Node: "foo", Address: "127.0.0.1"}))
+	require.NoError(t, s.EnsureRegistration(2, &structs.RegisterRequest{
+		Node:     "bar",
+		Address:  "10.0.0.1",
+		PeerName: "peer1",
+		Service: &structs.NodeService{
+			ID:       "api-1",
+			Service:  "api",
+			PeerName: "peer1",
+		},
+	}))
+
+	// A local service sharing a name with the peered upstream must not be
+	// reported as an upstream.
+	require.NoError(t, s.EnsureService(3, "foo", &structs.NodeService{
+		ID:      "api-local",
+		Service: "api",
+	}))
+
+	require.NoError(t, s.EnsureService(4, "foo", &structs.NodeService{
+		Kind:    structs.ServiceKindTerminatingGateway,
+		ID:      "tgw",
+		Service: "tgw",
+	}))
+	require.NoError(t, s.EnsureConfigEntry(5, &structs.TerminatingGatewayConfigEntry{
+		Kind: structs.TerminatingGateway,
+		Name: "tgw",
+		Services: []structs.LinkedService{
+			{Name: "billing"},
+		},
+	}))
+	require.NoError(t, s.EnsureConfigEntry(6, &structs.ServiceConfigEntry{
+		Kind:     structs.ServiceDefaults,
+		Name:     "billing",
+		Protocol: "http",
+	}))
+
+	require.NoError(t, s.EnsureService(7, "foo", &structs.NodeService{
+		ID:      "web",
+		Service: "web",
+	}))
+	require.NoError(t, s.EnsureService(8, "foo", &structs.NodeService{
+		Kind:    structs.ServiceKindConnectProxy,
+		ID:      "web-proxy",
+		Service: "web-proxy",
+		Proxy: structs.ConnectProxyConfig{
+			DestinationServiceName: "web",
+			Upstreams: structs.Upstreams{
+				{DestinationName: "api", DestinationPeer: "peer1"},
+				{DestinationName: "billing"},
+			},
+		},
+	}))
+
+	// The peer replicates the protocol of its service with the sidecar
+	// proxies, and the intentions it enforces for it.
+	require.NoError(t, s.EnsureRegistration(9, &structs.RegisterRequest{
+		Node:     "bar",
+		Address:  "10.0.0.1",
+		PeerName: "peer1",
+		Service: &structs.NodeService{
+			Kind:     structs.ServiceKindConnectProxy,
+			ID:       "api-sidecar-proxy",
+			Service:  "api-sidecar-proxy",
+			PeerName: "peer1",
+			Proxy: structs.ConnectProxyConfig{
+				DestinationServiceName: "api",
+			},
+			Connect: structs.ServiceConnect{
+				PeerMeta: &structs.PeeringServiceMeta{Protocol: "http"},
+			},
+		},
+	}))
+	require.NoError(t, s.PeeringIntentionsWrite(10, &pbpeering.PeeringIntentions{
+		PeerName:    "peer1",
+		ServiceName: "api",
+		Sources: []*pbpeering.PeeringIntentionSource{
+			{Name: "web", Namespace: "default", Action: "deny"},
+		},
+		DefaultAllow: true,
+	}))
+
+	ws := memdb.NewWatchSet()
+	idx, topo, err := s.ServiceTopology(ws, "dc1", "web", structs.ServiceKindTypical, acl.Allow, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), idx)
+
+	peered := structs.PeeredServiceName{
+		ServiceName: structs.NewServiceName("api", nil),
+		Peer:        "peer1",
+	}
+	billing := structs.NewServiceName("billing", nil)
+
+	require.Len(t, topo.Upstreams, 2)
+	for _, csn := range topo.Upstreams {
+		require.Equal(t, "peer1", csn.Service.PeerName)
+	}
+	require.Equal(t, map[string]string{
+		peered.String():  structs.TopologySourceRegistration,
+		billing.String(): structs.TopologySourceRegistration,
+	}, topo.UpstreamSources)
+	require.Equal(t, "http", topo.UpstreamProtocols[peered.String()])
+	require.Equal(t, structs.IntentionDecisionSummary{HasExact: true}, topo.UpstreamDecisions[peered.String()])
+
+	require.Equal(t, map[string]structs.ServiceName{
+		billing.String(): structs.NewServiceName("tgw", nil),
+	}, topo.UpstreamGateways)
+	require.Equal(t, "http", topo.UpstreamProtocols[billing.String()])
+	require.True(t, topo.UpstreamDecisions[billing.String()].Allowed)
+}
@@ -3,6 +3,7 @@ package state
 import (
 	"errors"
 	"fmt"
+	"sort"
 
 	memdb "github.com/hashicorp/go-memdb"
 
@@ -103,6 +104,19 @@ func (s *Store) ConfigEntry(ws memdb.WatchSet, kind, name string, entMeta *acl.E
 	return configEntryTxn(tx, ws, kind, name, entMeta)
 }
 
+// ProxyDefaults returns the effective proxy-defaults of entMeta, layering the
+// proxy-defaults entries of its broader scopes.
+func (s *Store) ProxyDefaults(ws memdb.WatchSet, entMeta *acl.EnterpriseMeta) (uint64, *structs.ProxyConfigEntry, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	idx, layers, err := getProxyDefaultsLayersTxn(tx, ws, nil, entMeta)
+	if err != nil {
+		return 0, nil, err
+	}
+	return idx, structs.MergeProxyConfigEntries(layers...), nil
+}
+
 func configEntryTxn(tx ReadTxn, ws memdb.WatchSet, kind, name string, entMeta *acl.EnterpriseMeta) (uint64, structs.ConfigEntry, error) {
 	// Get the index
 	idx := maxIndexTxn(tx, tableConfigEntries)
@@ -467,18 +481,26 @@ func validateProposedConfigEntryInGraph(
 	case structs.ServiceSplitter:
 	case structs.ServiceResolver:
 	case structs.IngressGateway:
-		err := checkGatewayClash(tx, kindName, structs.TerminatingGateway)
+		err := checkGatewayClash(tx, kindName, structs.TerminatingGateway, structs.EgressGateway)
 		if err != nil {
 			return err
 		}
 	case structs.TerminatingGateway:
-		err := checkGatewayClash(tx, kindName, structs.IngressGateway)
+		err := checkGatewayClash(tx, kindName, structs.IngressGateway, structs.EgressGateway)
+		if err != nil {
+			return err
+		}
+	case structs.EgressGateway:
+		err := checkGatewayClash(tx, kindName, structs.IngressGateway, structs.TerminatingGateway)
 		if err != nil {
 			return err
 		}
 	case structs.ServiceIntentions:
 	case structs.MeshConfig:
 	case structs.ExportedServices:
+	case structs.TrafficShift:
+	case structs.MaintenanceWindow:
+	case structs.RegistrationPolicy:
 	default:
 		return fmt.Errorf("unhandled kind %q during validation of %q", kindName.Kind, kindName.Name)
 	}
@@ -486,14 +508,16 @@ func validateProposedConfigEntryInGraph(
 	return validateProposedConfigEntryInServiceGraph(tx, kindName, newEntry)
 }
 
-func checkGatewayClash(tx ReadTxn, kindName configentry.KindName, otherKind string) error {
-	_, entry, err := configEntryTxn(tx, nil, otherKind, kindName.Name, &kindName.EnterpriseMeta)
-	if err != nil {
-		return err
-	}
-	if entry != nil {
-		return fmt.Errorf("cannot create a %q config entry with name %q, "+
-			"a %q config entry with that name already exists", kindName.Kind, kindName.Name, otherKind)
+func checkGatewayClash(tx ReadTxn, kindName configentry.KindName, otherKinds ...string) error {
+	for _, otherKind := range otherKinds {
+		_, entry, err := configEntryTxn(tx, nil, otherKind, kindName.Name, &kindName.EnterpriseMeta)
+		if err != nil {
+			return err
+		}
+		if entry != nil {
+			return fmt.Errorf("cannot create a %q config entry with name %q, "+
+				"a %q config entry with that name already exists", kindName.Kind, kindName.Name, otherKind)
+		}
 	}
 	return nil
 }
@@ -611,8 +635,12 @@ func validateProposedConfigEntryInServiceGraph(
 	wildcardEntMeta := kindName.WithWildcardNamespace()
 
 	switch kindName.Kind {
-	case structs.ExportedServices, structs.MeshConfig:
-		// Exported services and mesh config do not influence discovery chains.
+	case structs.ExportedServices, structs.MeshConfig, structs.TrafficShift, structs.EgressGateway,
+		structs.MaintenanceWindow, structs.RegistrationPolicy:
+		// Exported services, mesh config, traffic shifts, egress gateways,
+		// maintenance windows and registration policies do not influence
+		// discovery chains. Traffic shifts only do so through the splitters
+		// the leader writes on their behalf.
 		return nil
 
 	case structs.ProxyDefaults:
@@ -921,20 +949,16 @@ func (s *Store) ReadResolvedServiceConfigEntries(
 	// definitions.
 	var inferredProxyMode structs.ProxyMode
 
-	index, proxyEntry, err := configEntryTxn(tx, ws, structs.ProxyDefaults, structs.ProxyConfigGlobal, entMeta)
+	index, proxyLayers, err := getProxyDefaultsLayersTxn(tx, ws, nil, entMeta)
 	if err != nil {
 		return 0, nil, err
 	}
 	maxIndex := index
 
-	if proxyEntry != nil {
-		var ok bool
-		proxyConf, ok := proxyEntry.(*structs.ProxyConfigEntry)
-		if !ok {
-			return 0, nil, fmt.Errorf("invalid proxy config type %T", proxyEntry)
-		}
+	for _, proxyConf := range proxyLayers {
 		res.AddProxyDefaults(proxyConf)
-
+	}
+	if proxyConf := structs.MergeProxyConfigEntries(proxyLayers...); proxyConf != nil {
 		inferredProxyMode = proxyConf.Mode
 	}
 
@@ -993,6 +1017,9 @@ func (s *Store) ReadResolvedServiceConfigEntries(
 			if override.Name == "" {
 				continue // skip this impossible condition
 			}
+			if override.Peer != "" {
+				continue // the service-defaults of imported upstreams are not local
+			}
 			seenUpstreams[override.ServiceID()] = struct{}{}
 		}
 	}
@@ -1175,6 +1202,17 @@ func readDiscoveryChainConfigEntriesTxn(
 
 		res.Resolvers[resolverID] = resolver
 
+		if resolver.DynamicSubsets != nil {
+			idx, values, err := dynamicSubsetValuesTxn(tx, ws, resolverID, resolver.DynamicSubsets.MetaKey)
+			if err != nil {
+				return 0, nil, err
+			}
+			if idx > maxIdx {
+				maxIdx = idx
+			}
+			res.DynamicSubsets[resolverID] = values
+		}
+
 		for _, svc := range resolver.ListRelatedServices() {
 			todoResolvers[svc] = struct{}{}
 		}
@@ -1191,17 +1229,15 @@ func readDiscoveryChainConfigEntriesTxn(
 			continue // already fetched
 		}
 
-		if _, ok := res.ProxyDefaults[svcID.PartitionOrDefault()]; !ok {
-			idx, proxy, err := getProxyConfigEntryTxn(tx, ws, structs.ProxyConfigGlobal, overrides, &svcID.EnterpriseMeta)
+		if _, ok := res.ProxyDefaults[configentry.ProxyDefaultsKey(&svcID.EnterpriseMeta)]; !ok {
+			idx, proxies, err := getProxyDefaultsLayersTxn(tx, ws, overrides, &svcID.EnterpriseMeta)
 			if err != nil {
 				return 0, nil, err
 			}
 			if idx > maxIdx {
 				maxIdx = idx
 			}
-			if proxy != nil {
-				res.ProxyDefaults[proxy.PartitionOrDefault()] = proxy
-			}
+			res.AddProxyDefaults(proxies...)
 		}
 
 		idx, entry, err := getServiceConfigEntryTxn(tx, ws, svcID.ID, overrides, &svcID.EnterpriseMeta)
@@ -1244,6 +1280,41 @@ func readDiscoveryChainConfigEntriesTxn(
 	return maxIdx, res, nil
 }
 
+// dynamicSubsetValuesTxn returns the distinct values of metaKey registered by
+// the instances of a service, which name the dynamic subsets of its resolver.
+func dynamicSubsetValuesTxn(tx ReadTxn, ws memdb.WatchSet, sid structs.ServiceID, metaKey string) (uint64, []string, error) {
+	services, err := tx.Get(tableServices, indexService, Query{
+		Value:          sid.ID,
+		EnterpriseMeta: sid.EnterpriseMeta,
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed service lookup: %s", err)
+	}
+	ws.Add(services.WatchCh())
+
+	var exists bool
+	seen := make(map[string]struct{})
+	var values []string
+	for raw := services.Next(); raw != nil; raw = services.Next() {
+		exists = true
+		value, ok := raw.(*structs.ServiceNode).ServiceMeta[metaKey]
+		if !ok {
+			continue
+		}
+		if _, ok := seen[value]; !ok {
+			seen[value] = struct{}{}
+			values = append(values, value)
+		}
+	}
+	sort.Strings(values)
+
+	idx, ch := maxIndexAndWatchChForService(tx, sid.ID, exists, false, &sid.EnterpriseMeta, structs.DefaultPeerKeyword)
+	if ch != nil {
+		ws.Add(ch)
+	}
+	return idx, values, nil
+}
+
 // anyKey returns any key from the provided map if any exist. Useful for using
 // a map as a simple work queue of sorts.
 func anyKey(m map[structs.ServiceID]struct{}) (structs.ServiceID, bool) {
@@ -1281,6 +1352,34 @@ func getProxyConfigEntryTxn(
 	return idx, proxy, nil
 }
 
+// getProxyDefaultsLayersTxn fetches the proxy-defaults entries layered into
+// the proxy-defaults of entMeta, from the broadest to the narrowest scope.
+// Scopes without a proxy-defaults entry are omitted.
+func getProxyDefaultsLayersTxn(
+	tx ReadTxn,
+	ws memdb.WatchSet,
+	overrides map[configentry.KindName]structs.ConfigEntry,
+	entMeta *acl.EnterpriseMeta,
+) (uint64, []*structs.ProxyConfigEntry, error) {
+	var (
+		maxIdx uint64
+		layers []*structs.ProxyConfigEntry
+	)
+	for _, scope := range structs.ProxyDefaultsScopes(entMeta) {
+		idx, proxy, err := getProxyConfigEntryTxn(tx, ws, structs.ProxyConfigGlobal, overrides, scope)
+		if err != nil {
+			return 0, nil, err
+		}
+		if idx > maxIdx {
+			maxIdx = idx
+		}
+		if proxy != nil {
+			layers = append(layers, proxy)
+		}
+	}
+	return maxIdx, layers, nil
+}
+
 // getServiceConfigEntryTxn is a convenience method for fetching a
 // service-defaults kind of config entry.
 //
@@ -1433,8 +1532,8 @@ func protocolForService(
 	ws memdb.WatchSet,
 	svc structs.ServiceName,
 ) (uint64, string, error) {
-	// Get the global proxy defaults (for default protocol)
-	maxIdx, proxyConfig, err := configEntryTxn(tx, ws, structs.ProxyDefaults, structs.ProxyConfigGlobal, &svc.EnterpriseMeta)
+	// Get the proxy defaults (for default protocol)
+	maxIdx, proxyLayers, err := getProxyDefaultsLayersTxn(tx, ws, nil, &svc.EnterpriseMeta)
 	if err != nil {
 		return 0, "", err
 	}
@@ -1446,9 +1545,7 @@ func protocolForService(
 	maxIdx = lib.MaxUint64(maxIdx, idx)
 
 	entries := configentry.NewDiscoveryChainSet()
-	if proxyConfig != nil {
-		entries.AddEntries(proxyConfig)
-	}
+	entries.AddProxyDefaults(proxyLayers...)
 	if serviceDefaults != nil {
 		entries.AddEntries(serviceDefaults)
 	}
@@ -260,6 +260,38 @@ func TestStore_ConfigEntry_UpdateOver(t *testing.T) {
 	})
 }
 
+func TestStore_ProxyDefaults(t *testing.T) {
+	s := testConfigStateStore(t)
+
+	ws := memdb.NewWatchSet()
+	idx, proxyDefaults, err := s.ProxyDefaults(ws, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), idx)
+	require.Nil(t, proxyDefaults)
+
+	global := &structs.ProxyConfigEntry{
+		Kind: structs.ProxyDefaults,
+		Name: structs.ProxyConfigGlobal,
+		Config: map[string]interface{}{
+			"protocol": "http",
+		},
+		Mode: structs.ProxyModeTransparent,
+	}
+	require.NoError(t, s.EnsureConfigEntry(1, global))
+	require.True(t, watchFired(ws))
+
+	idx, proxyDefaults, err = s.ProxyDefaults(nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), idx)
+	require.Equal(t, global, proxyDefaults)
+
+	// The resolved service config set layers the same entries.
+	idx, entries, err := s.ReadResolvedServiceConfigEntries(nil, "web", nil, nil, structs.ProxyModeDefault)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), idx)
+	require.Equal(t, global, entries.GetProxyDefaults(structs.DefaultEnterpriseMetaInDefaultPartition()))
+}
+
 func TestStore_ConfigEntries(t *testing.T) {
 	s := testConfigStateStore(t)
 
@@ -1928,6 +1960,45 @@ func TestStore_ReadDiscoveryChainConfigEntries_SubsetSplit(t *testing.T) {
 	require.Len(t, entrySet.Services, 1)
 }
 
+func TestStore_ReadDiscoveryChainConfigEntries_DynamicSubsets(t *testing.T) {
+	s := testConfigStateStore(t)
+
+	require.NoError(t, s.EnsureConfigEntry(1, &structs.ServiceResolverConfigEntry{
+		Kind:           structs.ServiceResolver,
+		Name:           "main",
+		DynamicSubsets: &structs.ServiceResolverDynamicSubsets{MetaKey: "version"},
+	}))
+
+	testRegisterNode(t, s, 2, "node1")
+	register := func(idx uint64, id, version string) {
+		svc := &structs.NodeService{ID: id, Service: "main", Port: 8080}
+		if version != "" {
+			svc.Meta = map[string]string{"version": version}
+		}
+		require.NoError(t, s.EnsureService(idx, "node1", svc))
+	}
+	register(3, "main-1", "v1")
+	register(4, "main-2", "v2")
+	register(5, "main-3", "v1")
+	register(6, "main-4", "")
+
+	sid := structs.NewServiceID("main", nil)
+	ws := memdb.NewWatchSet()
+	idx, entrySet, err := s.readDiscoveryChainConfigEntries(ws, "main", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), idx)
+	require.Equal(t, []string{"v1", "v2"}, entrySet.GetDynamicSubsets(sid))
+
+	// New values are discovered as instances register.
+	register(7, "main-5", "v3")
+	require.True(t, watchFired(ws))
+
+	idx, entrySet, err = s.readDiscoveryChainConfigEntries(nil, "main", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), idx)
+	require.Equal(t, []string{"v1", "v2", "v3"}, entrySet.GetDynamicSubsets(sid))
+}
+
 // TODO(rb): add ServiceIntentions tests
 
 func TestStore_ValidateGatewayNamesCannotBeShared(t *testing.T) {
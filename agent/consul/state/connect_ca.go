@@ -2,6 +2,7 @@ package state
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/go-memdb"
 	"github.com/pkg/errors"
@@ -15,6 +16,9 @@ const (
 	tableConnectCAConfig        = "connect-ca-config"
 	tableConnectCARoots         = "connect-ca-roots"
 	tableConnectCALeafCerts     = "connect-ca-leaf-certs"
+	tableConnectCAIssuedCerts   = "connect-ca-issued-certs"
+
+	indexExpires = "expires"
 )
 
 // caBuiltinProviderTableSchema returns a new table schema used for storing
@@ -36,6 +40,44 @@ func caBuiltinProviderTableSchema() *memdb.TableSchema {
 	}
 }
 
+// caIssuedCertTableSchema returns a new table schema used for storing the
+// records of the unexpired leaf certificates signed by the CA.
+func caIssuedCertTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: tableConnectCAIssuedCerts,
+		Indexes: map[string]*memdb.IndexSchema{
+			indexID: {
+				Name:         indexID,
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "SerialNumber",
+				},
+			},
+			indexExpires: {
+				Name:         indexExpires,
+				AllowMissing: false,
+				Unique:       false,
+				Indexer: indexerSingle{
+					readIndex:  readIndex(indexFromTimeQuery),
+					writeIndex: writeIndex(indexExpiresFromIssuedCertRecord),
+				},
+			},
+		},
+	}
+}
+
+func indexExpiresFromIssuedCertRecord(raw interface{}) ([]byte, error) {
+	r, ok := raw.(*structs.IssuedCertRecord)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T for structs.IssuedCertRecord index", raw)
+	}
+
+	var b indexBuilder
+	b.Time(r.ValidBefore)
+	return b.Bytes(), nil
+}
+
 // caConfigTableSchema returns a new table schema used for storing
 // the CA config for Connect.
 func caConfigTableSchema() *memdb.TableSchema {
@@ -440,6 +482,95 @@ func (s *Store) CADeleteProviderState(idx uint64, id string) error {
 	return tx.Commit()
 }
 
+// CAIssuedCerts is used to pull the issued certificate records from the
+// snapshot.
+func (s *Snapshot) CAIssuedCerts() (structs.IssuedCertRecords, error) {
+	iter, err := s.tx.Get(tableConnectCAIssuedCerts, indexID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret structs.IssuedCertRecords
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		ret = append(ret, raw.(*structs.IssuedCertRecord))
+	}
+	return ret, nil
+}
+
+// CAIssuedCert is used when restoring from a snapshot.
+func (s *Restore) CAIssuedCert(r *structs.IssuedCertRecord) error {
+	if err := s.tx.Insert(tableConnectCAIssuedCerts, r); err != nil {
+		return fmt.Errorf("failed restoring issued cert record: %s", err)
+	}
+	if err := indexUpdateMaxTxn(s.tx, r.ModifyIndex, tableConnectCAIssuedCerts); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
+
+// CAIssuedCertSet records a leaf certificate signed by the CA. The records
+// of the certificates which expired before it was issued are removed, so
+// the table only grows with the number of valid certificates.
+func (s *Store) CAIssuedCertSet(idx uint64, r *structs.IssuedCertRecord) error {
+	tx := s.db.WriteTxn(idx)
+	defer tx.Abort()
+
+	iter, err := tx.Get(tableConnectCAIssuedCerts, indexExpires)
+	if err != nil {
+		return fmt.Errorf("failed issued cert records lookup: %s", err)
+	}
+	var expired structs.IssuedCertRecords
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		existing := raw.(*structs.IssuedCertRecord)
+		if !existing.ValidBefore.Before(r.ValidAfter) {
+			break
+		}
+		expired = append(expired, existing)
+	}
+	for _, existing := range expired {
+		if err := tx.Delete(tableConnectCAIssuedCerts, existing); err != nil {
+			return fmt.Errorf("failed deleting issued cert record: %s", err)
+		}
+	}
+
+	r.CreateIndex = idx
+	r.ModifyIndex = idx
+	if err := tx.Insert(tableConnectCAIssuedCerts, r); err != nil {
+		return fmt.Errorf("failed inserting issued cert record: %s", err)
+	}
+	if err := indexUpdateMaxTxn(tx, idx, tableConnectCAIssuedCerts); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return tx.Commit()
+}
+
+// CAIssuedCerts returns the records of the leaf certificates signed by the
+// CA which are still valid at the given time, sorted by expiry.
+func (s *Store) CAIssuedCerts(ws memdb.WatchSet, now time.Time) (uint64, structs.IssuedCertRecords, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	// Iterating from a lower bound doesn't provide a precise watch channel, so
+	// watch the index of the table instead.
+	idx := maxIndexWatchTxn(tx, ws, tableConnectCAIssuedCerts)
+
+	iter, err := tx.LowerBound(tableConnectCAIssuedCerts, indexExpires, &TimeQuery{Value: now})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed issued cert records lookup: %s", err)
+	}
+
+	var ret structs.IssuedCertRecords
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		r := raw.(*structs.IssuedCertRecord)
+		// The index has a one second granularity.
+		if !r.ValidBefore.After(now) {
+			continue
+		}
+		ret = append(ret, r)
+	}
+	return idx, ret, nil
+}
+
 func (s *Store) CALeafSetIndex(idx uint64, index uint64) error {
 	tx := s.db.WriteTxn(idx)
 	defer tx.Abort()
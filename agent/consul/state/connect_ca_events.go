@@ -1,7 +1,10 @@
 package state
 
 import (
+	"sort"
+
 	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/consul/stream"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/proto/pbsubscribe"
@@ -34,6 +37,30 @@ func (e EventPayloadCARoots) ToSubscriptionEvent(idx uint64) *pbsubscribe.Event
 	panic("EventPayloadCARoots does not implement ToSubscriptionEvent")
 }
 
+// EventTopicCARootsChange is the streaming topic to which events will be
+// published when certificates are added to or removed from the CA roots or
+// their intermediates. Each event payload contains the fingerprints of the
+// certificates which changed. The snapshot contains every current certificate
+// as added.
+//
+// Note: like EventTopicCARoots, this topic isn't currently available via the
+// Subscribe endpoint.
+const EventTopicCARootsChange stream.StringTopic = "CARootsChange"
+
+type EventPayloadCARootsChange struct {
+	Change structs.CARootsChange
+}
+
+func (e EventPayloadCARootsChange) Subject() stream.Subject { return stream.SubjectNone }
+
+func (e EventPayloadCARootsChange) HasReadPermission(authz acl.Authorizer) bool {
+	return EventPayloadCARoots{}.HasReadPermission(authz)
+}
+
+func (e EventPayloadCARootsChange) ToSubscriptionEvent(idx uint64) *pbsubscribe.Event {
+	panic("EventPayloadCARootsChange does not implement ToSubscriptionEvent")
+}
+
 // caRootsChangeEvents returns an event on EventTopicCARoots whenever the list
 // of active CA Roots changes.
 func caRootsChangeEvents(tx ReadTxn, changes Changes) ([]stream.Event, error) {
@@ -82,3 +109,122 @@ func (s *Store) CARootsSnapshot(_ stream.SubscribeRequest, buf stream.SnapshotAp
 	})
 	return idx, nil
 }
+
+// caRootsFingerprintChangeEvents returns an event on EventTopicCARootsChange
+// whenever a root or intermediate certificate is added or removed.
+func caRootsFingerprintChangeEvents(tx ReadTxn, changes Changes) ([]stream.Event, error) {
+	before := make(map[structs.CACertFingerprint]struct{})
+	after := make(map[structs.CACertFingerprint]struct{})
+	for _, c := range changes.Changes {
+		if c.Table != tableConnectCARoots {
+			continue
+		}
+		if c.Before != nil {
+			addCACertFingerprints(before, c.Before.(*structs.CARoot))
+		}
+		if c.After != nil {
+			addCACertFingerprints(after, c.After.(*structs.CARoot))
+		}
+	}
+
+	change := structs.CARootsChange{
+		Index:   changes.Index,
+		Added:   fingerprintsMissingFrom(after, before),
+		Removed: fingerprintsMissingFrom(before, after),
+	}
+	if len(change.Added) == 0 && len(change.Removed) == 0 {
+		return nil, nil
+	}
+
+	_, roots, err := caRootsTxn(tx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if active := roots.Active(); active != nil {
+		change.ActiveRootID = active.ID
+	}
+
+	return []stream.Event{
+		{
+			Topic:   EventTopicCARootsChange,
+			Index:   changes.Index,
+			Payload: EventPayloadCARootsChange{Change: change},
+		},
+	}, nil
+}
+
+// CARootsChangeSnapshot returns a stream.SnapshotFunc that provides every
+// current root and intermediate certificate as added.
+func (s *Store) CARootsChangeSnapshot(_ stream.SubscribeRequest, buf stream.SnapshotAppender) (uint64, error) {
+	tx := s.db.ReadTxn()
+	defer tx.Abort()
+
+	idx, roots, err := caRootsTxn(tx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	current := make(map[structs.CACertFingerprint]struct{})
+	for _, root := range roots {
+		addCACertFingerprints(current, root)
+	}
+	change := structs.CARootsChange{
+		Index: idx,
+		Added: fingerprintsMissingFrom(current, nil),
+	}
+	if active := roots.Active(); active != nil {
+		change.ActiveRootID = active.ID
+	}
+
+	buf.Append([]stream.Event{
+		{
+			Topic:   EventTopicCARootsChange,
+			Index:   idx,
+			Payload: EventPayloadCARootsChange{Change: change},
+		},
+	})
+	return idx, nil
+}
+
+// addCACertFingerprints adds the fingerprints of the root certificate and
+// intermediates of root to m. Certificates which can't be parsed are skipped
+// as they are validated before being stored.
+func addCACertFingerprints(m map[structs.CACertFingerprint]struct{}, root *structs.CARoot) {
+	add := func(certPEM string, intermediate bool) {
+		cert, err := connect.ParseCert(certPEM)
+		if err != nil {
+			return
+		}
+		m[structs.CACertFingerprint{
+			RootID:       root.ID,
+			Intermediate: intermediate,
+			SHA256:       connect.CalculateCertSHA256Fingerprint(cert.Raw),
+		}] = struct{}{}
+	}
+
+	add(root.RootCert, false)
+	for _, intermediate := range root.IntermediateCerts {
+		add(intermediate, true)
+	}
+}
+
+// fingerprintsMissingFrom returns the sorted fingerprints of a which are not
+// in b.
+func fingerprintsMissingFrom(a, b map[structs.CACertFingerprint]struct{}) []structs.CACertFingerprint {
+	var out []structs.CACertFingerprint
+	for fp := range a {
+		if _, ok := b[fp]; !ok {
+			out = append(out, fp)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].RootID != out[j].RootID {
+			return out[i].RootID < out[j].RootID
+		}
+		if out[i].Intermediate != out[j].Intermediate {
+			return !out[i].Intermediate
+		}
+		return out[i].SHA256 < out[j].SHA256
+	})
+	return out
+}
@@ -115,3 +115,146 @@ func TestEventPayloadCARoots_HasReadPermission(t *testing.T) {
 		require.True(t, hasRead)
 	})
 }
+
+func TestCARootsFingerprintChangeEvents(t *testing.T) {
+	store := testStateStore(t)
+	rootA := connect.TestCA(t, nil)
+
+	_, err := store.CARootSetCAS(1, 0, structs.CARoots{rootA})
+	require.NoError(t, err)
+
+	rootB := connect.TestCA(t, nil)
+	oldRootA := rootA.Clone()
+	oldRootA.Active = false
+
+	t.Run("root added", func(t *testing.T) {
+		tx := store.db.WriteTxn(2)
+		defer tx.Abort()
+
+		err = caRootSetCASTxn(tx, 2, 1, structs.CARoots{oldRootA, rootB})
+		require.NoError(t, err)
+
+		events, err := caRootsFingerprintChangeEvents(tx, Changes{Index: 2, Changes: tx.Changes()})
+		require.NoError(t, err)
+		require.Equal(t, []stream.Event{
+			{
+				Topic: EventTopicCARootsChange,
+				Index: 2,
+				Payload: EventPayloadCARootsChange{
+					Change: structs.CARootsChange{
+						Index:        2,
+						ActiveRootID: rootB.ID,
+						Added:        []structs.CACertFingerprint{testCACertFingerprint(t, rootB)},
+					},
+				},
+			},
+		}, events)
+	})
+
+	t.Run("root removed", func(t *testing.T) {
+		_, err := store.CARootSetCAS(2, 1, structs.CARoots{oldRootA, rootB})
+		require.NoError(t, err)
+
+		tx := store.db.WriteTxn(3)
+		defer tx.Abort()
+
+		err = caRootSetCASTxn(tx, 3, 2, structs.CARoots{rootB})
+		require.NoError(t, err)
+
+		events, err := caRootsFingerprintChangeEvents(tx, Changes{Index: 3, Changes: tx.Changes()})
+		require.NoError(t, err)
+		require.Equal(t, []stream.Event{
+			{
+				Topic: EventTopicCARootsChange,
+				Index: 3,
+				Payload: EventPayloadCARootsChange{
+					Change: structs.CARootsChange{
+						Index:        3,
+						ActiveRootID: rootB.ID,
+						Removed:      []structs.CACertFingerprint{testCACertFingerprint(t, rootA)},
+					},
+				},
+			},
+		}, events)
+	})
+
+	t.Run("certificates unchanged", func(t *testing.T) {
+		tx := store.db.WriteTxn(3)
+		defer tx.Abort()
+
+		// Only the active flag changes, which doesn't change the trusted
+		// certificates.
+		rootA := oldRootA.Clone()
+		rootA.Active = true
+		oldRootB := rootB.Clone()
+		oldRootB.Active = false
+		err = caRootSetCASTxn(tx, 3, 2, structs.CARoots{rootA, oldRootB})
+		require.NoError(t, err)
+
+		events, err := caRootsFingerprintChangeEvents(tx, Changes{Index: 3, Changes: tx.Changes()})
+		require.NoError(t, err)
+		require.Empty(t, events)
+	})
+}
+
+func TestCARootsChangeSnapshot(t *testing.T) {
+	store := testStateStore(t)
+
+	var req stream.SubscribeRequest
+
+	t.Run("no roots", func(t *testing.T) {
+		buf := &snapshotAppender{}
+
+		idx, err := store.CARootsChangeSnapshot(req, buf)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), idx)
+
+		require.Len(t, buf.events, 1)
+		require.Len(t, buf.events[0], 1)
+
+		payload := buf.events[0][0].Payload.(EventPayloadCARootsChange)
+		require.Empty(t, payload.Change.Added)
+		require.Empty(t, payload.Change.ActiveRootID)
+	})
+
+	t.Run("with roots", func(t *testing.T) {
+		buf := &snapshotAppender{}
+
+		root := connect.TestCA(t, nil)
+
+		_, err := store.CARootSetCAS(1, 0, structs.CARoots{root})
+		require.NoError(t, err)
+
+		idx, err := store.CARootsChangeSnapshot(req, buf)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), idx)
+
+		require.Equal(t, buf.events, [][]stream.Event{
+			{
+				{
+					Topic: EventTopicCARootsChange,
+					Index: 1,
+					Payload: EventPayloadCARootsChange{
+						Change: structs.CARootsChange{
+							Index:        1,
+							ActiveRootID: root.ID,
+							Added:        []structs.CACertFingerprint{testCACertFingerprint(t, root)},
+						},
+					},
+				},
+			},
+		})
+	})
+}
+
+func testCACertFingerprint(t *testing.T, root *structs.CARoot) structs.CACertFingerprint {
+	t.Helper()
+
+	cert, err := connect.ParseCert(root.RootCert)
+	require.NoError(t, err)
+
+	return structs.CACertFingerprint{
+		RootID: root.ID,
+		SHA256: connect.CalculateCertSHA256Fingerprint(cert.Raw),
+	}
+}
@@ -3,6 +3,7 @@ package state
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/proto/prototest"
 	"github.com/hashicorp/consul/sdk/testutil"
@@ -492,3 +493,70 @@ func TestStore_CABuiltinProvider_Snapshot_Restore(t *testing.T) {
 		assert.Equal(t, state, res)
 	}
 }
+
+func TestStore_CAIssuedCerts(t *testing.T) {
+	s := testStateStore(t)
+	now := time.Now()
+
+	record := func(serial string, validAfter, validBefore time.Time) *structs.IssuedCertRecord {
+		return &structs.IssuedCertRecord{
+			SerialNumber: serial,
+			Service:      "web",
+			ValidAfter:   validAfter,
+			ValidBefore:  validBefore,
+		}
+	}
+
+	require.NoError(t, s.CAIssuedCertSet(1, record("01", now.Add(-3*time.Hour), now.Add(-time.Hour))))
+	require.NoError(t, s.CAIssuedCertSet(2, record("02", now.Add(-2*time.Hour), now.Add(2*time.Hour))))
+	require.NoError(t, s.CAIssuedCertSet(3, record("03", now.Add(-2*time.Hour), now.Add(time.Hour))))
+
+	ws := memdb.NewWatchSet()
+	idx, certs, err := s.CAIssuedCerts(ws, now)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), idx)
+	require.Len(t, certs, 2)
+	require.Equal(t, "03", certs[0].SerialNumber)
+	require.Equal(t, "02", certs[1].SerialNumber)
+	require.Equal(t, uint64(3), certs[0].CreateIndex)
+
+	// The expired record is kept until a certificate is issued after it
+	// expired.
+	tx := s.db.Txn(false)
+	raw, err := tx.First(tableConnectCAIssuedCerts, indexID, "01")
+	tx.Abort()
+	require.NoError(t, err)
+	require.NotNil(t, raw)
+
+	require.NoError(t, s.CAIssuedCertSet(4, record("04", now, now.Add(3*time.Hour))))
+	require.True(t, watchFired(ws))
+
+	tx = s.db.Txn(false)
+	raw, err = tx.First(tableConnectCAIssuedCerts, indexID, "01")
+	tx.Abort()
+	require.NoError(t, err)
+	require.Nil(t, raw)
+
+	_, certs, err = s.CAIssuedCerts(nil, now)
+	require.NoError(t, err)
+	require.Len(t, certs, 3)
+
+	// Snapshot and restore.
+	snap := s.Snapshot()
+	defer snap.Close()
+	snapped, err := snap.CAIssuedCerts()
+	require.NoError(t, err)
+	require.Len(t, snapped, 3)
+
+	s2 := testStateStore(t)
+	restore := s2.Restore()
+	for _, r := range snapped {
+		require.NoError(t, restore.CAIssuedCert(r))
+	}
+	require.NoError(t, restore.Commit())
+
+	idx, certs, err = s2.CAIssuedCerts(nil, now)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), idx)
+	require.Len(t, certs, 3)
+}
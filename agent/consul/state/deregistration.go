@@ -0,0 +1,243 @@
+package state
+
+import (
+	"fmt"
+
+	memdb "github.com/hashicorp/go-memdb"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+const tableDeregistrations = "deregistrations"
+
+// deregistrationHistoryLimit is the number of deregistrations kept in the
+// history, the oldest ones being removed first.
+var deregistrationHistoryLimit = 256
+
+func deregistrationsTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: tableDeregistrations,
+		Indexes: map[string]*memdb.IndexSchema{
+			indexID: {
+				Name:         indexID,
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.UintFieldIndex{
+					Field: "ID",
+				},
+			},
+		},
+	}
+}
+
+// Deregistrations is used to pull the deregistration history for the
+// snapshot.
+func (s *Snapshot) Deregistrations() (structs.Deregistrations, error) {
+	iter, err := s.tx.Get(tableDeregistrations, indexID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret structs.Deregistrations
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		ret = append(ret, raw.(*structs.Deregistration))
+	}
+	return ret, nil
+}
+
+// Deregistration is used when restoring from a snapshot.
+func (s *Restore) Deregistration(d *structs.Deregistration) error {
+	if err := s.tx.Insert(tableDeregistrations, d); err != nil {
+		return fmt.Errorf("failed restoring deregistration: %s", err)
+	}
+	if err := indexUpdateMaxTxn(s.tx, d.ID, tableDeregistrations); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
+
+// DeleteNodeAndRecord deletes a node like DeleteNode, and records its removal
+// in the deregistration history. The caller sets who deregistered the node
+// and when in d, the other fields are filled from the catalog.
+func (s *Store) DeleteNodeAndRecord(idx uint64, nodeName string, entMeta *acl.EnterpriseMeta, peerName string, d *structs.Deregistration) error {
+	tx := s.db.WriteTxn(idx)
+	defer tx.Abort()
+
+	if err := recordNodeDeregistrationTxn(tx, idx, nodeName, entMeta, peerName, d); err != nil {
+		return err
+	}
+	if err := s.deleteNodeTxn(tx, idx, nodeName, entMeta, peerName); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteServiceAndRecord deletes a service instance like DeleteService, and
+// records its removal in the deregistration history. The caller sets who
+// deregistered the service and when in d, the other fields are filled from
+// the catalog.
+func (s *Store) DeleteServiceAndRecord(idx uint64, nodeName, serviceID string, entMeta *acl.EnterpriseMeta, peerName string, d *structs.Deregistration) error {
+	tx := s.db.WriteTxn(idx)
+	defer tx.Abort()
+
+	if err := recordServiceDeregistrationTxn(tx, idx, nodeName, serviceID, entMeta, peerName, d); err != nil {
+		return err
+	}
+	if err := s.deleteServiceTxn(tx, idx, nodeName, serviceID, entMeta, peerName); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// recordNodeDeregistrationTxn records the removal of a node, about to be
+// deleted, in the deregistration history. d is used as a template and isn't
+// modified. Nothing is recorded if the node doesn't exist.
+func recordNodeDeregistrationTxn(tx WriteTxn, idx uint64, nodeName string, entMeta *acl.EnterpriseMeta, peerName string, d *structs.Deregistration) error {
+	// Only the local catalog is recorded, peered data is managed by the
+	// peering replication.
+	if peerName != structs.DefaultPeerKeyword {
+		return nil
+	}
+	if entMeta == nil {
+		entMeta = structs.NodeEnterpriseMetaInDefaultPartition()
+	}
+
+	node, err := tx.First(tableNodes, indexID, Query{
+		Value:          nodeName,
+		EnterpriseMeta: *entMeta,
+	})
+	if err != nil {
+		return fmt.Errorf("node lookup failed: %s", err)
+	}
+	if node == nil {
+		return nil
+	}
+
+	record := *d
+	record.Node = node.(*structs.Node).Node
+	record.Services = nil
+
+	services, err := catalogServiceListByNode(tx, nodeName, entMeta, peerName, true)
+	if err != nil {
+		return fmt.Errorf("failed service lookup: %s", err)
+	}
+	for svc := services.Next(); svc != nil; svc = services.Next() {
+		record.Services = append(record.Services, svc.(*structs.ServiceNode).ServiceName)
+	}
+
+	if record.Checks, err = deregistrationChecksTxn(tx, nodeName, "", entMeta); err != nil {
+		return err
+	}
+	return recordDeregistrationTxn(tx, idx, &record)
+}
+
+// recordServiceDeregistrationTxn records the removal of a service instance,
+// about to be deleted, in the deregistration history. d is used as a template
+// and isn't modified. Nothing is recorded if the service doesn't exist.
+func recordServiceDeregistrationTxn(tx WriteTxn, idx uint64, nodeName, serviceID string, entMeta *acl.EnterpriseMeta, peerName string, d *structs.Deregistration) error {
+	if peerName != structs.DefaultPeerKeyword {
+		return nil
+	}
+	if entMeta == nil {
+		entMeta = structs.DefaultEnterpriseMetaInDefaultPartition()
+	}
+
+	svc, err := getNodeServiceTxn(tx, nil, nodeName, serviceID, entMeta, peerName)
+	if err != nil {
+		return fmt.Errorf("failed service lookup: %s", err)
+	}
+	if svc == nil {
+		return nil
+	}
+
+	record := *d
+	record.Node = nodeName
+	record.ServiceID = svc.ID
+	record.ServiceName = svc.Service
+	record.EnterpriseMeta = svc.EnterpriseMeta
+
+	if record.Checks, err = deregistrationChecksTxn(tx, nodeName, svc.ID, entMeta); err != nil {
+		return err
+	}
+	return recordDeregistrationTxn(tx, idx, &record)
+}
+
+// deregistrationChecksTxn returns the checks of a node, or the ones of one of
+// its service instances along with the node checks when serviceID is set.
+func deregistrationChecksTxn(tx ReadTxn, nodeName, serviceID string, entMeta *acl.EnterpriseMeta) (structs.HealthChecks, error) {
+	iter, err := catalogListChecksByNode(tx, Query{
+		Value:          nodeName,
+		EnterpriseMeta: *structs.NodeEnterpriseMetaInPartition(entMeta.PartitionOrDefault()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed check lookup: %s", err)
+	}
+
+	var checks structs.HealthChecks
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		check := raw.(*structs.HealthCheck)
+		if serviceID == "" || check.ServiceID == "" || check.ServiceID == serviceID {
+			checks = append(checks, check)
+		}
+	}
+	return checks, nil
+}
+
+// recordDeregistrationTxn adds d to the deregistration history, and removes
+// the oldest entries past deregistrationHistoryLimit.
+func recordDeregistrationTxn(tx WriteTxn, idx uint64, d *structs.Deregistration) error {
+	iter, err := tx.Get(tableDeregistrations, indexID)
+	if err != nil {
+		return fmt.Errorf("failed deregistration lookup: %s", err)
+	}
+	var all []interface{}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		all = append(all, raw)
+	}
+
+	// A transaction can remove several nodes or services at the same index,
+	// the following ones get the next free IDs.
+	d.ID = idx
+	if len(all) > 0 {
+		if last := all[len(all)-1].(*structs.Deregistration).ID; last >= d.ID {
+			d.ID = last + 1
+		}
+	}
+	d.Status = d.Checks.AggregatedStatus()
+	if err := tx.Insert(tableDeregistrations, d); err != nil {
+		return fmt.Errorf("failed inserting deregistration: %s", err)
+	}
+	all = append(all, d)
+
+	for i := 0; i < len(all)-deregistrationHistoryLimit; i++ {
+		if err := tx.Delete(tableDeregistrations, all[i]); err != nil {
+			return fmt.Errorf("failed removing deregistration: %s", err)
+		}
+	}
+
+	if err := indexUpdateMaxTxn(tx, idx, tableDeregistrations); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
+
+// Deregistrations returns the deregistration history, most recent first.
+func (s *Store) Deregistrations(ws memdb.WatchSet) (uint64, structs.Deregistrations, error) {
+	tx := s.db.ReadTxn()
+	defer tx.Abort()
+
+	idx := maxIndexTxn(tx, tableDeregistrations)
+
+	iter, err := tx.GetReverse(tableDeregistrations, indexID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed deregistration lookup: %s", err)
+	}
+	ws.Add(iter.WatchCh())
+
+	var results structs.Deregistrations
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		results = append(results, raw.(*structs.Deregistration))
+	}
+	return idx, results, nil
+}
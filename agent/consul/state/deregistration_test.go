@@ -0,0 +1,139 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+)
+
+func TestStore_Deregistrations(t *testing.T) {
+	s := testStateStore(t)
+
+	testRegisterNode(t, s, 1, "node1")
+	testRegisterService(t, s, 2, "node1", "web")
+	testRegisterService(t, s, 3, "node1", "db")
+	testRegisterCheck(t, s, 4, "node1", "", "serfHealth", api.HealthPassing)
+	testRegisterCheck(t, s, 5, "node1", "web", "web-check", api.HealthCritical)
+	testRegisterCheck(t, s, 6, "node1", "db", "db-check", api.HealthWarning)
+
+	ws := memdb.NewWatchSet()
+	idx, deregistrations, err := s.Deregistrations(ws)
+	require.NoError(t, err)
+	require.Zero(t, idx)
+	require.Empty(t, deregistrations)
+
+	now := time.Now().UTC()
+	req := &structs.DeregisterRequest{Node: "node1", ServiceID: "web", DeregisteredBy: "accessor", DeregisteredAt: now}
+	require.NoError(t, s.DeleteServiceAndRecord(7, req.Node, req.ServiceID, nil, "", req.Deregistration()))
+	require.True(t, watchFired(ws))
+
+	// Deregistering an unknown service isn't recorded.
+	req = &structs.DeregisterRequest{Node: "node1", ServiceID: "nope"}
+	require.NoError(t, s.DeleteServiceAndRecord(8, req.Node, req.ServiceID, nil, "", req.Deregistration()))
+
+	req = &structs.DeregisterRequest{Node: "node1", DeregisterReason: "member reaped", DeregisteredAt: now}
+	require.NoError(t, s.DeleteNodeAndRecord(9, req.Node, nil, "", req.Deregistration()))
+
+	idx, deregistrations, err = s.Deregistrations(nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(9), idx)
+	require.Len(t, deregistrations, 2)
+
+	node := deregistrations[0]
+	require.Equal(t, uint64(9), node.ID)
+	require.Equal(t, "node1", node.Node)
+	require.Empty(t, node.ServiceID)
+	require.Equal(t, []string{"db"}, node.Services)
+	require.Equal(t, "member reaped", node.Reason)
+	require.Equal(t, api.HealthWarning, node.Status)
+	require.Len(t, node.Checks, 2)
+
+	svc := deregistrations[1]
+	require.Equal(t, uint64(7), svc.ID)
+	require.Equal(t, "web", svc.ServiceID)
+	require.Equal(t, "web", svc.ServiceName)
+	require.Equal(t, "accessor", svc.DeregisteredBy)
+	require.Equal(t, now, svc.DeregisteredAt)
+	require.Equal(t, api.HealthCritical, svc.Status)
+	require.Len(t, svc.Checks, 2)
+
+	// The service and node are gone.
+	_, nodes, err := s.Nodes(nil, nil, "")
+	require.NoError(t, err)
+	require.Empty(t, nodes)
+}
+
+func TestStore_Deregistrations_Limit(t *testing.T) {
+	s := testStateStore(t)
+
+	old := deregistrationHistoryLimit
+	deregistrationHistoryLimit = 3
+	t.Cleanup(func() { deregistrationHistoryLimit = old })
+
+	idx := uint64(1)
+	for i := 0; i < 5; i++ {
+		testRegisterNode(t, s, idx, "node1")
+		idx++
+		req := &structs.DeregisterRequest{Node: "node1"}
+		require.NoError(t, s.DeleteNodeAndRecord(idx, req.Node, nil, "", req.Deregistration()))
+		idx++
+	}
+
+	_, deregistrations, err := s.Deregistrations(nil)
+	require.NoError(t, err)
+	require.Len(t, deregistrations, 3)
+	require.Equal(t, uint64(10), deregistrations[0].ID)
+	require.Equal(t, uint64(6), deregistrations[2].ID)
+}
+
+func TestStore_Deregistrations_Txn(t *testing.T) {
+	s := testStateStore(t)
+
+	testRegisterNode(t, s, 1, "node1")
+	testRegisterService(t, s, 2, "node1", "web")
+	testRegisterService(t, s, 3, "node1", "db")
+	testRegisterNode(t, s, 4, "node2")
+
+	now := time.Now().UTC()
+	req := &structs.TxnRequest{
+		Ops: structs.TxnOps{
+			{Service: &structs.TxnServiceOp{Verb: api.ServiceDelete, Node: "node1", Service: structs.NodeService{ID: "web"}}},
+			{Service: &structs.TxnServiceOp{Verb: api.ServiceDelete, Node: "node1", Service: structs.NodeService{ID: "db"}}},
+			{Node: &structs.TxnNodeOp{Verb: api.NodeDelete, Node: structs.Node{Node: "node2"}}},
+		},
+		DeregisteredBy: "accessor",
+		DeregisteredAt: now,
+	}
+	_, errs := s.TxnRWAndRecord(5, req.Ops, req.Deregistration())
+	require.Empty(t, errs)
+
+	// A failed transaction doesn't record anything.
+	req.Ops = structs.TxnOps{
+		{Node: &structs.TxnNodeOp{Verb: api.NodeDeleteCAS, Node: structs.Node{Node: "node1", RaftIndex: structs.RaftIndex{ModifyIndex: 1000}}}},
+	}
+	_, errs = s.TxnRWAndRecord(6, req.Ops, req.Deregistration())
+	require.Len(t, errs, 1)
+
+	idx, deregistrations, err := s.Deregistrations(nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), idx)
+	require.Len(t, deregistrations, 3)
+
+	// Each removal gets its own ID even though they share the Raft index.
+	require.Equal(t, uint64(7), deregistrations[0].ID)
+	require.Equal(t, "node2", deregistrations[0].Node)
+	require.Empty(t, deregistrations[0].ServiceID)
+	require.Equal(t, uint64(6), deregistrations[1].ID)
+	require.Equal(t, "db", deregistrations[1].ServiceID)
+	require.Equal(t, uint64(5), deregistrations[2].ID)
+	require.Equal(t, "web", deregistrations[2].ServiceID)
+	for _, d := range deregistrations {
+		require.Equal(t, "accessor", d.DeregisteredBy)
+		require.Equal(t, now, d.DeregisteredAt)
+	}
+}
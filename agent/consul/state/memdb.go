@@ -187,6 +187,7 @@ func processDBChanges(tx ReadTxn, changes Changes) ([]stream.Event, error) {
 	fns := []func(tx ReadTxn, changes Changes) ([]stream.Event, error){
 		aclChangeUnsubscribeEvent,
 		caRootsChangeEvents,
+		caRootsFingerprintChangeEvents,
 		ServiceHealthEventsFromChanges,
 		// TODO: add other table handlers here.
 	}
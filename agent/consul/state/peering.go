@@ -17,6 +17,7 @@ import (
 const (
 	tablePeering             = "peering"
 	tablePeeringTrustBundles = "peering-trust-bundles"
+	tablePeeringIntentions   = "peering-intentions"
 )
 
 func peeringTableSchema() *memdb.TableSchema {
@@ -63,6 +64,24 @@ func peeringTrustBundlesTableSchema() *memdb.TableSchema {
 	}
 }
 
+func peeringIntentionsTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: tablePeeringIntentions,
+		Indexes: map[string]*memdb.IndexSchema{
+			indexID: {
+				Name:         indexID,
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: indexerSingleWithPrefix{
+					readIndex:   indexPeeringIntentionsFromPeeredServiceName,
+					writeIndex:  indexFromPeeringIntentions,
+					prefixIndex: prefixIndexFromQuery,
+				},
+			},
+		},
+	}
+}
+
 func indexIDFromPeering(raw interface{}) ([]byte, error) {
 	p, ok := raw.(*pbpeering.Peering)
 	if !ok {
@@ -252,6 +271,12 @@ func (s *Store) PeeringDelete(idx uint64, q Query) error {
 	if err := updatePeeringTableIndexes(tx, idx, q.PartitionOrDefault()); err != nil {
 		return err
 	}
+
+	// The intentions of the peer are only used while it is peered, so
+	// they don't wait for the deferred deletion.
+	if err := peeringIntentionsDeleteAllTxn(tx, idx, q); err != nil {
+		return err
+	}
 	return tx.Commit()
 }
 
@@ -427,6 +452,7 @@ func (s *Store) exportedServicesForPeerTxn(ws memdb.WatchSet, tx ReadTxn, peerin
 	structs.ServiceList(disco).Sort()
 
 	serviceProtocols := make(map[structs.ServiceName]string)
+	var ignoreWeights map[structs.ServiceName]bool
 	populateProtocol := func(svc structs.ServiceName) error {
 		if _, ok := serviceProtocols[svc]; ok {
 			return nil // already processed
@@ -442,6 +468,17 @@ func (s *Store) exportedServicesForPeerTxn(ws memdb.WatchSet, tx ReadTxn, peerin
 		}
 
 		serviceProtocols[svc] = protocol
+
+		_, entry, err := configEntryTxn(tx, ws, structs.ServiceDefaults, svc.Name, &svc.EnterpriseMeta)
+		if err != nil {
+			return fmt.Errorf("failed to get service defaults for service: %w", err)
+		}
+		if defaults, ok := entry.(*structs.ServiceConfigEntry); ok && defaults.IgnoreCatalogWeights {
+			if ignoreWeights == nil {
+				ignoreWeights = make(map[structs.ServiceName]bool)
+			}
+			ignoreWeights[svc] = true
+		}
 		return nil
 	}
 	for _, svc := range normal {
@@ -459,6 +496,8 @@ func (s *Store) exportedServicesForPeerTxn(ws memdb.WatchSet, tx ReadTxn, peerin
 		Services:        normal,
 		DiscoChains:     disco,
 		ConnectProtocol: serviceProtocols,
+
+		IgnoreCatalogWeights: ignoreWeights,
 	}
 
 	return maxIdx, list, nil
@@ -626,6 +665,125 @@ func (s *Store) PeeringTrustBundleDelete(idx uint64, q Query) error {
 	return tx.Commit()
 }
 
+// PeeringIntentionsRead returns the intentions imported from the given peer
+// for a service.
+func (s *Store) PeeringIntentionsRead(ws memdb.WatchSet, psn structs.PeeredServiceName) (uint64, *pbpeering.PeeringIntentions, error) {
+	tx := s.db.ReadTxn()
+	defer tx.Abort()
+
+	return peeringIntentionsReadTxn(tx, ws, psn)
+}
+
+func peeringIntentionsReadTxn(tx ReadTxn, ws memdb.WatchSet, psn structs.PeeredServiceName) (uint64, *pbpeering.PeeringIntentions, error) {
+	watchCh, raw, err := tx.FirstWatch(tablePeeringIntentions, indexID, psn)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed peering intentions lookup: %w", err)
+	}
+
+	pi, ok := raw.(*pbpeering.PeeringIntentions)
+	if raw != nil && !ok {
+		return 0, nil, fmt.Errorf("invalid type %T", raw)
+	}
+	ws.Add(watchCh)
+
+	if pi == nil {
+		// Return the tables index so caller can watch it for changes if the intentions don't exist
+		return maxIndexWatchTxn(tx, ws, partitionedIndexEntryName(tablePeeringIntentions, psn.ServiceName.PartitionOrDefault())), nil, nil
+	}
+	return pi.ModifyIndex, pi, nil
+}
+
+// PeeringIntentionsList returns the intentions imported from the peer given
+// as the query value, for all of its services.
+func (s *Store) PeeringIntentionsList(ws memdb.WatchSet, q Query) (uint64, []*pbpeering.PeeringIntentions, error) {
+	tx := s.db.ReadTxn()
+	defer tx.Abort()
+
+	iter, err := tx.Get(tablePeeringIntentions, indexID+"_prefix", q)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed peering intentions lookup: %w", err)
+	}
+	ws.Add(iter.WatchCh())
+
+	var result []*pbpeering.PeeringIntentions
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		result = append(result, raw.(*pbpeering.PeeringIntentions))
+	}
+	idx := maxIndexWatchTxn(tx, ws, partitionedIndexEntryName(tablePeeringIntentions, q.PartitionOrDefault()))
+	return idx, result, nil
+}
+
+// PeeringIntentionsWrite writes the intentions imported from a peer for a
+// service to the state store, overwriting the existing ones.
+func (s *Store) PeeringIntentionsWrite(idx uint64, pi *pbpeering.PeeringIntentions) error {
+	tx := s.db.WriteTxn(idx)
+	defer tx.Abort()
+
+	existingRaw, err := tx.First(tablePeeringIntentions, indexID, pi.PeeredServiceName())
+	if err != nil {
+		return fmt.Errorf("failed peering intentions lookup: %w", err)
+	}
+
+	existing, ok := existingRaw.(*pbpeering.PeeringIntentions)
+	if existingRaw != nil && !ok {
+		return fmt.Errorf("invalid type %T", existingRaw)
+	}
+
+	if existing != nil {
+		pi.CreateIndex = existing.CreateIndex
+	} else {
+		pi.CreateIndex = idx
+	}
+	pi.ModifyIndex = idx
+
+	if err := tx.Insert(tablePeeringIntentions, pi); err != nil {
+		return fmt.Errorf("failed inserting peering intentions: %w", err)
+	}
+
+	if err := updatePeeringIntentionsTableIndexes(tx, idx, pi.PartitionOrDefault()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PeeringIntentionsDelete deletes the intentions imported from a peer for a
+// service.
+func (s *Store) PeeringIntentionsDelete(idx uint64, psn structs.PeeredServiceName) error {
+	tx := s.db.WriteTxn(idx)
+	defer tx.Abort()
+
+	existing, err := tx.First(tablePeeringIntentions, indexID, psn)
+	if err != nil {
+		return fmt.Errorf("failed peering intentions lookup: %v", err)
+	}
+
+	if existing == nil {
+		return nil
+	}
+
+	if err := tx.Delete(tablePeeringIntentions, existing); err != nil {
+		return fmt.Errorf("failed deleting peering intentions: %v", err)
+	}
+
+	if err := updatePeeringIntentionsTableIndexes(tx, idx, psn.ServiceName.PartitionOrDefault()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// peeringIntentionsDeleteAllTxn deletes all the intentions imported from the
+// peer given as the query value.
+func peeringIntentionsDeleteAllTxn(tx WriteTxn, idx uint64, q Query) error {
+	n, err := tx.DeleteAll(tablePeeringIntentions, indexID+"_prefix", q)
+	if err != nil {
+		return fmt.Errorf("failed deleting peering intentions: %v", err)
+	}
+	if n == 0 {
+		return nil
+	}
+	return updatePeeringIntentionsTableIndexes(tx, idx, q.PartitionOrDefault())
+}
+
 func (s *Snapshot) Peerings() (memdb.ResultIterator, error) {
 	return s.tx.Get(tablePeering, indexName)
 }
@@ -634,6 +792,10 @@ func (s *Snapshot) PeeringTrustBundles() (memdb.ResultIterator, error) {
 	return s.tx.Get(tablePeeringTrustBundles, indexID)
 }
 
+func (s *Snapshot) PeeringIntentions() (memdb.ResultIterator, error) {
+	return s.tx.Get(tablePeeringIntentions, indexID)
+}
+
 func (r *Restore) Peering(p *pbpeering.Peering) error {
 	if err := r.tx.Insert(tablePeering, p); err != nil {
 		return fmt.Errorf("failed restoring peering: %w", err)
@@ -656,6 +818,16 @@ func (r *Restore) PeeringTrustBundle(ptb *pbpeering.PeeringTrustBundle) error {
 	return nil
 }
 
+func (r *Restore) PeeringIntentions(pi *pbpeering.PeeringIntentions) error {
+	if err := r.tx.Insert(tablePeeringIntentions, pi); err != nil {
+		return fmt.Errorf("failed restoring peering intentions: %w", err)
+	}
+	if err := updatePeeringIntentionsTableIndexes(r.tx, pi.ModifyIndex, pi.PartitionOrDefault()); err != nil {
+		return err
+	}
+	return nil
+}
+
 // peersForServiceTxn returns the names of all peers that a service is exported to.
 func peersForServiceTxn(
 	tx ReadTxn,
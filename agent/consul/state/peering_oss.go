@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/proto/pbpeering"
 )
 
@@ -51,6 +52,34 @@ func indexFromPeeringTrustBundle(raw interface{}) ([]byte, error) {
 	return b.Bytes(), nil
 }
 
+func indexPeeringIntentionsFromPeeredServiceName(raw interface{}) ([]byte, error) {
+	psn, ok := raw.(structs.PeeredServiceName)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T for structs.PeeredServiceName index", raw)
+	}
+
+	var b indexBuilder
+	b.String(strings.ToLower(psn.Peer))
+	b.String(strings.ToLower(psn.ServiceName.Name))
+	return b.Bytes(), nil
+}
+
+func indexFromPeeringIntentions(raw interface{}) ([]byte, error) {
+	pi, ok := raw.(*pbpeering.PeeringIntentions)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T for pbpeering.PeeringIntentions index", raw)
+	}
+
+	if pi.PeerName == "" || pi.ServiceName == "" {
+		return nil, errMissingValueForIndex
+	}
+
+	var b indexBuilder
+	b.String(strings.ToLower(pi.PeerName))
+	b.String(strings.ToLower(pi.ServiceName))
+	return b.Bytes(), nil
+}
+
 func updatePeeringTableIndexes(tx WriteTxn, idx uint64, _ string) error {
 	if err := tx.Insert(tableIndex, &IndexEntry{Key: tablePeering, Value: idx}); err != nil {
 		return fmt.Errorf("failed updating table index: %w", err)
@@ -64,3 +93,10 @@ func updatePeeringTrustBundlesTableIndexes(tx WriteTxn, idx uint64, _ string) er
 	}
 	return nil
 }
+
+func updatePeeringIntentionsTableIndexes(tx WriteTxn, idx uint64, _ string) error {
+	if err := tx.Insert(tableIndex, &IndexEntry{Key: tablePeeringIntentions, Value: idx}); err != nil {
+		return fmt.Errorf("failed updating table index: %w", err)
+	}
+	return nil
+}
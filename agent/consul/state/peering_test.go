@@ -624,6 +624,94 @@ func TestStore_PeeringTrustBundleDelete(t *testing.T) {
 	require.Nil(t, ptb)
 }
 
+func TestStore_PeeringIntentions(t *testing.T) {
+	s := NewStateStore(nil)
+	insertTestPeerings(t, s)
+
+	psn := func(peer, name string) structs.PeeredServiceName {
+		return structs.PeeredServiceName{
+			Peer:        peer,
+			ServiceName: structs.NewServiceName(name, nil),
+		}
+	}
+
+	testutil.RunStep(t, "write", func(t *testing.T) {
+		require.NoError(t, s.PeeringIntentionsWrite(10, &pbpeering.PeeringIntentions{
+			PeerName:    "foo",
+			ServiceName: "api",
+			Sources: []*pbpeering.PeeringIntentionSource{
+				{Name: "web", Namespace: "default", Action: "allow"},
+			},
+		}))
+		require.NoError(t, s.PeeringIntentionsWrite(11, &pbpeering.PeeringIntentions{
+			PeerName:     "foo",
+			ServiceName:  "db",
+			DefaultAllow: true,
+		}))
+		require.NoError(t, s.PeeringIntentionsWrite(12, &pbpeering.PeeringIntentions{
+			PeerName:    "bar",
+			ServiceName: "api",
+		}))
+
+		require.Error(t, s.PeeringIntentionsWrite(13, &pbpeering.PeeringIntentions{
+			PeerName: "foo",
+		}))
+	})
+
+	testutil.RunStep(t, "read", func(t *testing.T) {
+		idx, pi, err := s.PeeringIntentionsRead(nil, psn("foo", "api"))
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), idx)
+		require.NotNil(t, pi)
+		require.Len(t, pi.Sources, 1)
+		require.Equal(t, uint64(10), pi.CreateIndex)
+
+		_, pi, err = s.PeeringIntentionsRead(nil, psn("foo", "unknown"))
+		require.NoError(t, err)
+		require.Nil(t, pi)
+	})
+
+	testutil.RunStep(t, "update preserves the create index", func(t *testing.T) {
+		require.NoError(t, s.PeeringIntentionsWrite(14, &pbpeering.PeeringIntentions{
+			PeerName:     "foo",
+			ServiceName:  "api",
+			DefaultAllow: true,
+		}))
+
+		_, pi, err := s.PeeringIntentionsRead(nil, psn("foo", "api"))
+		require.NoError(t, err)
+		require.Empty(t, pi.Sources)
+		require.Equal(t, uint64(10), pi.CreateIndex)
+		require.Equal(t, uint64(14), pi.ModifyIndex)
+	})
+
+	testutil.RunStep(t, "list", func(t *testing.T) {
+		_, list, err := s.PeeringIntentionsList(nil, Query{Value: "foo"})
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+	})
+
+	testutil.RunStep(t, "delete", func(t *testing.T) {
+		require.NoError(t, s.PeeringIntentionsDelete(15, psn("foo", "db")))
+
+		_, pi, err := s.PeeringIntentionsRead(nil, psn("foo", "db"))
+		require.NoError(t, err)
+		require.Nil(t, pi)
+	})
+
+	testutil.RunStep(t, "deleting the peering deletes its intentions", func(t *testing.T) {
+		require.NoError(t, s.PeeringDelete(16, Query{Value: "foo"}))
+
+		_, list, err := s.PeeringIntentionsList(nil, Query{Value: "foo"})
+		require.NoError(t, err)
+		require.Empty(t, list)
+
+		_, list, err = s.PeeringIntentionsList(nil, Query{Value: "bar"})
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+	})
+}
+
 func TestStateStore_ExportedServicesForPeer(t *testing.T) {
 	s := NewStateStore(nil)
 
@@ -891,6 +979,28 @@ func TestStateStore_ExportedServicesForPeer(t *testing.T) {
 		require.Equal(t, expect, got)
 	})
 
+	testutil.RunStep(t, "service defaults that ignore catalog weights are included", func(t *testing.T) {
+		ensureConfigEntry(t, &structs.ServiceConfigEntry{
+			Kind:                 structs.ServiceDefaults,
+			Name:                 "payments",
+			Protocol:             "http",
+			IgnoreCatalogWeights: true,
+			EnterpriseMeta:       *defaultEntMeta,
+		})
+
+		require.True(t, watchFired(ws))
+		ws = memdb.NewWatchSet()
+
+		idx, got, err := s.ExportedServicesForPeer(ws, id)
+		require.NoError(t, err)
+		require.Equal(t, lastIdx, idx)
+		require.Equal(t, map[structs.ServiceName]bool{newSN("payments"): true}, got.IgnoreCatalogWeights)
+		require.Equal(t, structs.ExportedDiscoveryChainInfo{
+			Protocol:             "http",
+			IgnoreCatalogWeights: true,
+		}, got.ListAllDiscoveryChains()[newSN("payments")])
+	})
+
 	testutil.RunStep(t, "deleting the config entry clears exported services", func(t *testing.T) {
 		expect := &structs.ExportedServiceList{}
 
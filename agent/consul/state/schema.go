@@ -17,10 +17,12 @@ func newDBSchema() *memdb.DBSchema {
 		bindingRulesTableSchema,
 		caBuiltinProviderTableSchema,
 		caConfigTableSchema,
+		caIssuedCertTableSchema,
 		caRootTableSchema,
 		checksTableSchema,
 		configTableSchema,
 		coordinatesTableSchema,
+		deregistrationsTableSchema,
 		federationStateTableSchema,
 		freeVirtualIPTableSchema,
 		gatewayServicesTableSchema,
@@ -32,6 +34,7 @@ func newDBSchema() *memdb.DBSchema {
 		nodesTableSchema,
 		peeringTableSchema,
 		peeringTrustBundlesTableSchema,
+		peeringIntentionsTableSchema,
 		policiesTableSchema,
 		preparedQueriesTableSchema,
 		rolesTableSchema,
@@ -41,6 +44,7 @@ func newDBSchema() *memdb.DBSchema {
 		sessionsTableSchema,
 		systemMetadataTableSchema,
 		tokensTableSchema,
+		tokenUsageTableSchema,
 		tombstonesTableSchema,
 		usageTableSchema,
 	)
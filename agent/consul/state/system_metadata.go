@@ -67,6 +67,33 @@ func (s *Store) SystemMetadataSet(idx uint64, entry *structs.SystemMetadataEntry
 	return tx.Commit()
 }
 
+// SystemMetadataSetCAS upserts a system metadata entry only if its
+// ModifyIndex matches the one of the existing entry. A ModifyIndex of 0 sets
+// the entry only if it doesn't exist yet. It returns whether it was set.
+func (s *Store) SystemMetadataSetCAS(idx uint64, entry *structs.SystemMetadataEntry) (bool, error) {
+	tx := s.db.WriteTxn(idx)
+	defer tx.Abort()
+
+	existing, err := tx.First(tableSystemMetadata, "id", entry.Key)
+	if err != nil {
+		return false, fmt.Errorf("failed system metadata lookup: %s", err)
+	}
+	if entry.ModifyIndex == 0 && existing != nil {
+		return false, nil
+	}
+	if entry.ModifyIndex != 0 {
+		e, ok := existing.(*structs.SystemMetadataEntry)
+		if !ok || e.ModifyIndex != entry.ModifyIndex {
+			return false, nil
+		}
+	}
+
+	if err := systemMetadataSetTxn(tx, idx, entry); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
 // systemMetadataSetTxn upserts a system metadata inside of a transaction.
 func systemMetadataSetTxn(tx WriteTxn, idx uint64, entry *structs.SystemMetadataEntry) error {
 	// The only validation we care about is non-empty keys.
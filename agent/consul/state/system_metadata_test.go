@@ -94,3 +94,39 @@ func TestStore_SystemMetadata(t *testing.T) {
 	})
 
 }
+
+func TestStore_SystemMetadataSetCAS(t *testing.T) {
+	s := testStateStore(t)
+
+	get := func() *structs.SystemMetadataEntry {
+		_, entry, err := s.SystemMetadataGet(nil, "key")
+		require.NoError(t, err)
+		return entry
+	}
+
+	// A ModifyIndex of 0 only sets missing keys.
+	set, err := s.SystemMetadataSetCAS(1, &structs.SystemMetadataEntry{Key: "key", Value: "val1"})
+	require.NoError(t, err)
+	require.True(t, set)
+
+	set, err = s.SystemMetadataSetCAS(2, &structs.SystemMetadataEntry{Key: "key", Value: "val2"})
+	require.NoError(t, err)
+	require.False(t, set)
+	require.Equal(t, "val1", get().Value)
+
+	// Otherwise the ModifyIndex must match.
+	set, err = s.SystemMetadataSetCAS(3, &structs.SystemMetadataEntry{
+		Key: "key", Value: "val3", RaftIndex: structs.RaftIndex{ModifyIndex: 2},
+	})
+	require.NoError(t, err)
+	require.False(t, set)
+
+	set, err = s.SystemMetadataSetCAS(4, &structs.SystemMetadataEntry{
+		Key: "key", Value: "val4", RaftIndex: structs.RaftIndex{ModifyIndex: 1},
+	})
+	require.NoError(t, err)
+	require.True(t, set)
+	entry := get()
+	require.Equal(t, "val4", entry.Value)
+	require.Equal(t, uint64(4), entry.ModifyIndex)
+}
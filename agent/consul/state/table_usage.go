@@ -0,0 +1,152 @@
+package state
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// mapEntryOverhead is a rough estimate of the bookkeeping memory used by each
+// entry of a Go map, on top of the key and value themselves.
+const mapEntryOverhead = 8
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// TableRowSizes holds the estimated memory used by the rows of the state
+// store tables, by table and row. Rows are never modified in place, so the
+// estimate of a row stored as a pointer holds for as long as it is in its
+// table.
+type TableRowSizes map[string]map[interface{}]int64
+
+// TableUsage returns the number of rows of every table of the state store,
+// along with an estimate of the memory they use, sorted by table name. The
+// estimate only accounts for the objects stored in the tables and not for the
+// radix trees of their indexes.
+//
+// The sizes returned by the previous call are passed as prev so that only the
+// rows written since are estimated. The returned sizes only hold the rows
+// currently in the tables and are passed to the next call. Walking the tables
+// is still proportional to their number of rows, so this should not be
+// called on a hot path.
+func (s *Store) TableUsage(prev TableRowSizes) (uint64, []structs.TableUsage, TableRowSizes, error) {
+	snap := s.Snapshot()
+	defer snap.Close()
+
+	usage := make([]structs.TableUsage, 0, len(s.schema.Tables))
+	sizes := make(TableRowSizes, len(s.schema.Tables))
+	for name := range s.schema.Tables {
+		iter, err := snap.tx.Get(name, indexID)
+		if err != nil {
+			return 0, nil, nil, fmt.Errorf("failed walking table %q: %w", name, err)
+		}
+
+		prevSizes := prev[name]
+		tableSizes := make(map[interface{}]int64, len(prevSizes))
+		table := structs.TableUsage{Table: name}
+		for item := iter.Next(); item != nil; item = iter.Next() {
+			table.Rows++
+
+			// Only the rows stored as pointers can be told apart.
+			size, cached := int64(0), false
+			cacheable := reflect.TypeOf(item).Kind() == reflect.Ptr
+			if cacheable {
+				size, cached = prevSizes[item]
+			}
+			if !cached {
+				// Objects referenced several times by a row are only
+				// counted once.
+				size = estimateSize(reflect.ValueOf(&item).Elem(), make(map[uintptr]struct{}))
+			}
+			if cacheable {
+				tableSizes[item] = size
+			}
+			table.Bytes += size
+		}
+		usage = append(usage, table)
+		sizes[name] = tableSizes
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].Table < usage[j].Table
+	})
+	return snap.LastIndex(), usage, sizes, nil
+}
+
+// estimateSize returns an estimate of the heap memory referenced by v, not
+// including the memory used by v itself. Pointers found in seen are not
+// followed, so each object is counted once.
+func estimateSize(v reflect.Value, seen map[uintptr]struct{}) int64 {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return 0
+		}
+		if _, ok := seen[v.Pointer()]; ok {
+			return 0
+		}
+		seen[v.Pointer()] = struct{}{}
+		elem := v.Elem()
+		return int64(elem.Type().Size()) + estimateSize(elem, seen)
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		elem := v.Elem()
+		if elem.Kind() == reflect.Ptr {
+			return estimateSize(elem, seen)
+		}
+		return int64(elem.Type().Size()) + estimateSize(elem, seen)
+
+	case reflect.String:
+		return int64(v.Len())
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return 0
+		}
+		size := int64(v.Cap()) * int64(v.Type().Elem().Size())
+		for i := 0; i < v.Len(); i++ {
+			size += estimateSize(v.Index(i), seen)
+		}
+		return size
+
+	case reflect.Array:
+		var size int64
+		for i := 0; i < v.Len(); i++ {
+			size += estimateSize(v.Index(i), seen)
+		}
+		return size
+
+	case reflect.Map:
+		if v.IsNil() {
+			return 0
+		}
+		entrySize := int64(v.Type().Key().Size()+v.Type().Elem().Size()) + mapEntryOverhead
+		size := int64(v.Len()) * entrySize
+		iter := v.MapRange()
+		for iter.Next() {
+			size += estimateSize(iter.Key(), seen)
+			size += estimateSize(iter.Value(), seen)
+		}
+		return size
+
+	case reflect.Struct:
+		// The location of times is shared with many other objects, like
+		// time.Local, and isn't counted.
+		if v.Type() == timeType {
+			return 0
+		}
+		var size int64
+		for i := 0; i < v.NumField(); i++ {
+			size += estimateSize(v.Field(i), seen)
+		}
+		return size
+
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,105 @@
+package state
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestStateStore_TableUsage(t *testing.T) {
+	s := testStateStore(t)
+
+	testRegisterNode(t, s, 1, "node1")
+	testRegisterNode(t, s, 2, "node2")
+	testSetKey(t, s, 3, "small", "x", nil)
+
+	idx, usage, sizes, err := s.TableUsage(nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), idx)
+	require.Len(t, usage, len(s.schema.Tables))
+
+	byTable := make(map[string]structs.TableUsage)
+	for i, table := range usage {
+		if i > 0 {
+			require.Less(t, usage[i-1].Table, table.Table)
+		}
+		byTable[table.Table] = table
+	}
+	require.Equal(t, 2, byTable[tableNodes].Rows)
+	require.Equal(t, 1, byTable["kvs"].Rows)
+	require.Zero(t, byTable[tableServices].Rows)
+	require.Zero(t, byTable[tableServices].Bytes)
+
+	small := byTable["kvs"].Bytes
+	require.Greater(t, small, int64(0))
+
+	require.Len(t, sizes[tableNodes], 2)
+
+	// Rows which weren't modified aren't estimated again.
+	for node := range sizes[tableNodes] {
+		sizes[tableNodes][node] = 1
+	}
+	_, usage, sizes, err = s.TableUsage(sizes)
+	require.NoError(t, err)
+	for _, table := range usage {
+		if table.Table == tableNodes {
+			require.Equal(t, int64(2), table.Bytes)
+		}
+	}
+
+	// The estimate grows with the size of the values.
+	testSetKey(t, s, 4, "small", strings.Repeat("x", 4096), nil)
+	_, usage, sizes, err = s.TableUsage(sizes)
+	require.NoError(t, err)
+	for _, table := range usage {
+		if table.Table == "kvs" {
+			require.Greater(t, table.Bytes, small+4000)
+		}
+	}
+	require.Len(t, sizes["kvs"], 1)
+
+	// The sizes of deleted rows are dropped.
+	require.NoError(t, s.DeleteNode(5, "node1", nil, ""))
+	_, _, sizes, err = s.TableUsage(sizes)
+	require.NoError(t, err)
+	require.Len(t, sizes[tableNodes], 1)
+}
+
+func TestEstimateSize(t *testing.T) {
+	type inner struct {
+		Name string
+	}
+	type outer struct {
+		ID     string
+		Tags   []string
+		Meta   map[string]string
+		Inner  *inner
+		Shared *inner
+		Any    interface{}
+	}
+
+	shared := &inner{Name: "shared"}
+	v := &outer{
+		ID:     "abcd",
+		Tags:   make([]string, 1, 2),
+		Meta:   map[string]string{"k": "vv"},
+		Inner:  shared,
+		Shared: shared,
+		Any:    "xyz",
+	}
+	v.Tags[0] = "tag"
+
+	stringSize := int64(reflect.TypeOf("").Size())
+	expected := int64(reflect.TypeOf(outer{}).Size()) +
+		4 + // ID
+		2*stringSize + 3 + // Tags
+		2*stringSize + mapEntryOverhead + 1 + 2 + // Meta
+		int64(reflect.TypeOf(inner{}).Size()) + 6 + // Inner, Shared is only counted once
+		stringSize + 3 // Any
+
+	require.Equal(t, expected, estimateSize(reflect.ValueOf(v), make(map[uintptr]struct{})))
+}
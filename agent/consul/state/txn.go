@@ -145,7 +145,7 @@ func txnLegacyIntention(tx WriteTxn, idx uint64, op *structs.TxnIntentionOp) err
 }
 
 // txnNode handles all Node-related operations.
-func (s *Store) txnNode(tx WriteTxn, idx uint64, op *structs.TxnNodeOp) (structs.TxnResults, error) {
+func (s *Store) txnNode(tx WriteTxn, idx uint64, op *structs.TxnNodeOp, d *structs.Deregistration) (structs.TxnResults, error) {
 	var entry *structs.Node
 	var err error
 
@@ -182,9 +182,17 @@ func (s *Store) txnNode(tx WriteTxn, idx uint64, op *structs.TxnNodeOp) (structs
 		entry, err = getNode()
 
 	case api.NodeDelete:
-		err = s.deleteNodeTxn(tx, idx, op.Node.Node, op.Node.GetEnterpriseMeta(), op.Node.PeerName)
+		err = recordNodeDeregistrationTxn(tx, idx, op.Node.Node, op.Node.GetEnterpriseMeta(), op.Node.PeerName, d)
+		if err == nil {
+			err = s.deleteNodeTxn(tx, idx, op.Node.Node, op.Node.GetEnterpriseMeta(), op.Node.PeerName)
+		}
 
 	case api.NodeDeleteCAS:
+		// The record is discarded along with the transaction if the
+		// deletion fails.
+		if err = recordNodeDeregistrationTxn(tx, idx, op.Node.Node, op.Node.GetEnterpriseMeta(), op.Node.PeerName, d); err != nil {
+			break
+		}
 		var ok bool
 		ok, err = s.deleteNodeCASTxn(tx, idx, op.Node.ModifyIndex, op.Node.Node, op.Node.GetEnterpriseMeta(), op.Node.PeerName)
 		if !ok && err == nil {
@@ -216,7 +224,7 @@ func (s *Store) txnNode(tx WriteTxn, idx uint64, op *structs.TxnNodeOp) (structs
 }
 
 // txnService handles all Service-related operations.
-func (s *Store) txnService(tx WriteTxn, idx uint64, op *structs.TxnServiceOp) (structs.TxnResults, error) {
+func (s *Store) txnService(tx WriteTxn, idx uint64, op *structs.TxnServiceOp, d *structs.Deregistration) (structs.TxnResults, error) {
 	switch op.Verb {
 	case api.ServiceGet:
 		entry, err := getNodeServiceTxn(tx, nil, op.Node, op.Service.ID, &op.Service.EnterpriseMeta, op.Service.PeerName)
@@ -250,10 +258,18 @@ func (s *Store) txnService(tx WriteTxn, idx uint64, op *structs.TxnServiceOp) (s
 		return newTxnResultFromNodeServiceEntry(entry), err
 
 	case api.ServiceDelete:
+		if err := recordServiceDeregistrationTxn(tx, idx, op.Node, op.Service.ID, &op.Service.EnterpriseMeta, op.Service.PeerName, d); err != nil {
+			return nil, err
+		}
 		err := s.deleteServiceTxn(tx, idx, op.Node, op.Service.ID, &op.Service.EnterpriseMeta, op.Service.PeerName)
 		return nil, err
 
 	case api.ServiceDeleteCAS:
+		// The record is discarded along with the transaction if the
+		// deletion fails.
+		if err := recordServiceDeregistrationTxn(tx, idx, op.Node, op.Service.ID, &op.Service.EnterpriseMeta, op.Service.PeerName, d); err != nil {
+			return nil, err
+		}
 		ok, err := s.deleteServiceCASTxn(tx, idx, op.Service.ModifyIndex, op.Node, op.Service.ID, &op.Service.EnterpriseMeta, op.Service.PeerName)
 		if !ok && err == nil {
 			return nil, fmt.Errorf("failed to delete service %q on node %q, index is stale", op.Service.ID, op.Node)
@@ -340,7 +356,9 @@ func (s *Store) txnCheck(tx WriteTxn, idx uint64, op *structs.TxnCheckOp) (struc
 }
 
 // txnDispatch runs the given operations inside the state store transaction.
-func (s *Store) txnDispatch(tx WriteTxn, idx uint64, ops structs.TxnOps) (structs.TxnResults, structs.TxnErrors) {
+// The nodes and services deleted are recorded in the deregistration history
+// with d as a template.
+func (s *Store) txnDispatch(tx WriteTxn, idx uint64, ops structs.TxnOps, d *structs.Deregistration) (structs.TxnResults, structs.TxnErrors) {
 	results := make(structs.TxnResults, 0, len(ops))
 	errors := make(structs.TxnErrors, 0, len(ops))
 	for i, op := range ops {
@@ -352,9 +370,9 @@ func (s *Store) txnDispatch(tx WriteTxn, idx uint64, ops structs.TxnOps) (struct
 		case op.KV != nil:
 			ret, err = s.txnKVS(tx, idx, op.KV)
 		case op.Node != nil:
-			ret, err = s.txnNode(tx, idx, op.Node)
+			ret, err = s.txnNode(tx, idx, op.Node, d)
 		case op.Service != nil:
-			ret, err = s.txnService(tx, idx, op.Service)
+			ret, err = s.txnService(tx, idx, op.Service, d)
 		case op.Check != nil:
 			ret, err = s.txnCheck(tx, idx, op.Check)
 		case op.Session != nil:
@@ -392,10 +410,17 @@ func (s *Store) txnDispatch(tx WriteTxn, idx uint64, ops structs.TxnOps) (struct
 // is done in a full write transaction on the state store, so reads and writes
 // are possible
 func (s *Store) TxnRW(idx uint64, ops structs.TxnOps) (structs.TxnResults, structs.TxnErrors) {
+	return s.TxnRWAndRecord(idx, ops, &structs.Deregistration{})
+}
+
+// TxnRWAndRecord runs the given operations like TxnRW, and records the nodes
+// and services deleted in the deregistration history. The caller sets who
+// deleted them and when in d, the other fields are filled from the catalog.
+func (s *Store) TxnRWAndRecord(idx uint64, ops structs.TxnOps, d *structs.Deregistration) (structs.TxnResults, structs.TxnErrors) {
 	tx := s.db.WriteTxn(idx)
 	defer tx.Abort()
 
-	results, errors := s.txnDispatch(tx, idx, ops)
+	results, errors := s.txnDispatch(tx, idx, ops, d)
 	if len(errors) > 0 {
 		return nil, errors
 	}
@@ -416,7 +441,7 @@ func (s *Store) TxnRO(ops structs.TxnOps) (structs.TxnResults, structs.TxnErrors
 	tx := s.db.Txn(false)
 	defer tx.Abort()
 
-	results, errors := s.txnDispatch(tx, 0, ops)
+	results, errors := s.txnDispatch(tx, 0, ops, &structs.Deregistration{})
 	if len(errors) > 0 {
 		return nil, errors
 	}
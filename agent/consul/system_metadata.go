@@ -28,6 +28,27 @@ func (s *Server) setSystemMetadataKey(key, val string) error {
 	return err
 }
 
+// setSystemMetadataKeyCAS sets the key only if it was last modified at
+// index, or only if it doesn't exist when index is 0. It returns whether the
+// key was set.
+func (s *Server) setSystemMetadataKeyCAS(key, val string, index uint64) (bool, error) {
+	args := &structs.SystemMetadataRequest{
+		Op: structs.SystemMetadataCAS,
+		Entry: &structs.SystemMetadataEntry{
+			Key:       key,
+			Value:     val,
+			RaftIndex: structs.RaftIndex{ModifyIndex: index},
+		},
+	}
+
+	resp, err := s.leaderRaftApply("SystemMetadata.CAS", structs.SystemMetadataRequestType, args)
+	if err != nil {
+		return false, err
+	}
+	set, _ := resp.(bool)
+	return set, nil
+}
+
 func (s *Server) deleteSystemMetadataKey(key string) error {
 	args := &structs.SystemMetadataRequest{
 		Op:    structs.SystemMetadataDelete,
@@ -33,6 +33,10 @@ func TestLeader_SystemMetadata_CRUD(t *testing.T) {
 
 	state := srv.fsm.State()
 
+	// The leader creates the node identity signing key, remove it to start
+	// from scratch.
+	require.NoError(t, srv.deleteSystemMetadataKey(structs.SystemMetadataNodeIdentityKey))
+
 	// Initially has no entries
 	_, entries, err := state.SystemMetadataList(nil)
 	require.NoError(t, err)
@@ -73,4 +77,16 @@ func TestLeader_SystemMetadata_CRUD(t *testing.T) {
 		"key2": "val2",
 		"key3": "val3",
 	}, mapify(entries))
+
+	// Check-and-set only creates missing keys when the index is 0.
+	set, err := srv.setSystemMetadataKeyCAS("key2", "other", 0)
+	require.NoError(t, err)
+	require.False(t, set)
+	set, err = srv.setSystemMetadataKeyCAS("key4", "val4", 0)
+	require.NoError(t, err)
+	require.True(t, set)
+
+	_, entry, err := state.SystemMetadataGet(nil, "key2")
+	require.NoError(t, err)
+	require.Equal(t, "val2", entry.Value)
 }
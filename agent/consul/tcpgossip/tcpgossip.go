@@ -0,0 +1,388 @@
+// Package tcpgossip provides a memberlist transport that carries the gossip
+// packet traffic over TCP streams, for networks where UDP is blocked between
+// the agents.
+package tcpgossip
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/memberlist"
+)
+
+const (
+	// packetStreamMarker is the first byte sent on a stream that carries
+	// gossip packets instead of a regular memberlist stream. It is chosen to
+	// never collide with a memberlist message type, the encryption version
+	// header or the label header.
+	packetStreamMarker byte = 0xfa
+
+	// PacketMaxIdleTime controls how long an idle packet stream is kept
+	// open to a peer.
+	PacketMaxIdleTime = 2 * time.Minute
+
+	// PacketMaxByteSize is the maximum allowed size of a packet carried over
+	// a stream. This is way bigger than what serf or memberlist allow
+	// practically so it should never be hit in practice.
+	PacketMaxByteSize = 4 * 1024 * 1024
+
+	// MinProbeTimeout and MinProbeInterval are the lower bounds applied to
+	// the probe timing by TuneConfig, since every probe has to go through a
+	// TCP round trip and potentially a connection setup.
+	MinProbeTimeout  = 1 * time.Second
+	MinProbeInterval = 2 * time.Second
+
+	// dialTimeout bounds how long opening a packet stream can take.
+	dialTimeout = 10 * time.Second
+
+	// writeTimeout bounds how long writing a packet can take, so that a peer
+	// which stopped reading its stream doesn't block the writers and the
+	// reaper waiting for it.
+	writeTimeout = 10 * time.Second
+
+	// peekTimeout bounds how long an accepted stream can take to send its
+	// first byte before it is dropped.
+	peekTimeout = 10 * time.Second
+)
+
+// IngestionAwareTransport is the underlying transport wrapped by Transport.
+// It is satisfied by *memberlist.NetTransport.
+type IngestionAwareTransport interface {
+	memberlist.NodeAwareTransport
+	IngestPacket(conn net.Conn, addr net.Addr, now time.Time, shouldClose bool) error
+	IngestStream(conn net.Conn) error
+}
+
+// TuneConfig adjusts the memberlist timing for a pool that gossips over TCP
+// only. The fallback TCP pings are disabled because the regular probes are
+// already sent over TCP.
+func TuneConfig(conf *memberlist.Config) {
+	conf.DisableTcpPings = true
+	if conf.ProbeTimeout < MinProbeTimeout {
+		conf.ProbeTimeout = MinProbeTimeout
+	}
+	if conf.ProbeInterval < MinProbeInterval {
+		conf.ProbeInterval = MinProbeInterval
+	}
+}
+
+// Configure sets up the given memberlist config to gossip over TCP only. It
+// binds a memberlist.NetTransport on the configured address, wraps it in a
+// Transport that becomes the config's transport, and tunes the probe timing.
+func Configure(conf *memberlist.Config, logger hclog.Logger) (*Transport, error) {
+	nt, err := memberlist.NewNetTransport(&memberlist.NetTransportConfig{
+		BindAddrs: []string{conf.BindAddr},
+		BindPort:  conf.BindPort,
+		Logger:    conf.Logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := NewTransport(nt, logger)
+	if err != nil {
+		nt.Shutdown()
+		return nil, err
+	}
+
+	// memberlist only picks up the dynamic bind port for the transports it
+	// creates itself.
+	if conf.BindPort == 0 {
+		conf.BindPort = nt.GetAutoBindPort()
+	}
+	conf.Transport = t
+	TuneConfig(conf)
+	return t, nil
+}
+
+// Transport wraps a memberlist transport and sends the packets over pooled
+// TCP streams instead of UDP. Incoming packet streams are recognized by their
+// first byte and fed to the underlying transport as packets, every other
+// stream is handed to memberlist unchanged.
+type Transport struct {
+	IngestionAwareTransport
+
+	logger       hclog.Logger
+	streamCh     chan net.Conn
+	writeTimeout time.Duration
+
+	mu       sync.Mutex
+	conns    map[string]*packetConn
+	shutdown bool
+
+	shutdownCh chan struct{}
+	wg         sync.WaitGroup
+}
+
+var _ memberlist.NodeAwareTransport = (*Transport)(nil)
+
+// NewTransport returns a Transport wrapping the given transport.
+func NewTransport(transport IngestionAwareTransport, logger hclog.Logger) (*Transport, error) {
+	if transport == nil {
+		return nil, errors.New("tcpgossip: transport is nil")
+	}
+	if logger == nil {
+		logger = hclog.NewNullLogger()
+	}
+
+	t := &Transport{
+		IngestionAwareTransport: transport,
+		logger:                  logger,
+		streamCh:                make(chan net.Conn),
+		writeTimeout:            writeTimeout,
+		conns:                   make(map[string]*packetConn),
+		shutdownCh:              make(chan struct{}),
+	}
+
+	t.wg.Add(2)
+	go t.acceptStreams()
+	go t.reap()
+
+	return t, nil
+}
+
+// WriteTo implements memberlist.Transport.
+func (t *Transport) WriteTo(b []byte, addr string) (time.Time, error) {
+	return t.WriteToAddress(b, memberlist.Address{Addr: addr})
+}
+
+// WriteToAddress implements memberlist.NodeAwareTransport.
+func (t *Transport) WriteToAddress(b []byte, addr memberlist.Address) (time.Time, error) {
+	pc, err := t.acquire(addr)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	pc.mu.Lock()
+	err = pc.conn.SetWriteDeadline(time.Now().Add(t.writeTimeout))
+	if err == nil {
+		// Send the length first.
+		err = binary.Write(pc.conn, binary.BigEndian, uint32(len(b)))
+	}
+	if err == nil {
+		_, err = pc.conn.Write(b)
+	}
+	now := time.Now()
+	pc.lastUsed = now
+	pc.mu.Unlock()
+
+	if err != nil {
+		t.discard(addr.Addr, pc)
+		return time.Time{}, err
+	}
+	return now, nil
+}
+
+// StreamCh implements memberlist.Transport. It only returns the regular
+// memberlist streams, the packet streams are consumed by the transport.
+func (t *Transport) StreamCh() <-chan net.Conn {
+	return t.streamCh
+}
+
+// Shutdown implements memberlist.Transport.
+func (t *Transport) Shutdown() error {
+	t.mu.Lock()
+	if t.shutdown {
+		t.mu.Unlock()
+		return nil
+	}
+	t.shutdown = true
+	for key, pc := range t.conns {
+		pc.conn.Close()
+		delete(t.conns, key)
+	}
+	close(t.shutdownCh)
+	t.mu.Unlock()
+
+	err := t.IngestionAwareTransport.Shutdown()
+	t.wg.Wait()
+	return err
+}
+
+type packetConn struct {
+	// mu serializes the writes of the packets on the stream. It may be
+	// acquired while holding Transport.mu, but never the other way around.
+	mu       sync.Mutex
+	conn     net.Conn
+	lastUsed time.Time
+}
+
+var errShutdown = errors.New("tcpgossip: transport is shut down")
+
+// acquire returns the packet stream to the given address, dialing it if there
+// is none open yet.
+func (t *Transport) acquire(addr memberlist.Address) (*packetConn, error) {
+	t.mu.Lock()
+	if t.shutdown {
+		t.mu.Unlock()
+		return nil, errShutdown
+	}
+	if pc, ok := t.conns[addr.Addr]; ok {
+		t.mu.Unlock()
+		return pc, nil
+	}
+	t.mu.Unlock()
+
+	conn, err := t.IngestionAwareTransport.DialAddressTimeout(addr, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.SetWriteDeadline(time.Now().Add(t.writeTimeout)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if _, err := conn.Write([]byte{packetStreamMarker}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.shutdown {
+		conn.Close()
+		return nil, errShutdown
+	}
+	// Another writer may have raced us to open the stream.
+	if pc, ok := t.conns[addr.Addr]; ok {
+		conn.Close()
+		return pc, nil
+	}
+	pc := &packetConn{conn: conn, lastUsed: time.Now()}
+	t.conns[addr.Addr] = pc
+	return pc, nil
+}
+
+// discard closes a failed packet stream so the next write dials a new one.
+func (t *Transport) discard(key string, pc *packetConn) {
+	pc.conn.Close()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conns[key] == pc {
+		delete(t.conns, key)
+	}
+}
+
+// reap periodically closes the packet streams that have not been used
+// recently.
+func (t *Transport) reap() {
+	defer t.wg.Done()
+	for {
+		select {
+		case <-t.shutdownCh:
+			return
+		case <-time.After(time.Second):
+		}
+
+		t.mu.Lock()
+		for key, pc := range t.conns {
+			pc.mu.Lock()
+			if time.Since(pc.lastUsed) > PacketMaxIdleTime {
+				pc.conn.Close()
+				delete(t.conns, key)
+			}
+			pc.mu.Unlock()
+		}
+		t.mu.Unlock()
+	}
+}
+
+// acceptStreams dispatches the streams accepted by the underlying transport.
+func (t *Transport) acceptStreams() {
+	defer t.wg.Done()
+	streamCh := t.IngestionAwareTransport.StreamCh()
+	for {
+		select {
+		case <-t.shutdownCh:
+			return
+		case conn := <-streamCh:
+			go t.handleStream(conn)
+		}
+	}
+}
+
+func (t *Transport) handleStream(conn net.Conn) {
+	br := bufio.NewReader(conn)
+
+	if err := conn.SetReadDeadline(time.Now().Add(peekTimeout)); err != nil {
+		conn.Close()
+		return
+	}
+	first, err := br.Peek(1)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		conn.Close()
+		return
+	}
+
+	if first[0] != packetStreamMarker {
+		select {
+		case t.streamCh <- &bufferedConn{Conn: conn, r: br}:
+		case <-t.shutdownCh:
+			conn.Close()
+		}
+		return
+	}
+
+	// Skip the marker we already peeked at.
+	br.Discard(1)
+	if err := t.ingestPackets(conn, br); err != nil && !errors.Is(err, io.EOF) {
+		t.logger.Debug("closing gossip packet stream", "from", conn.RemoteAddr().String(), "error", err)
+	}
+}
+
+// ingestPackets reads the length prefixed packets from the stream and feeds
+// them to the underlying transport until the stream is closed or idle.
+func (t *Transport) ingestPackets(conn net.Conn, r io.Reader) error {
+	defer conn.Close()
+
+	for {
+		select {
+		case <-t.shutdownCh:
+			return nil
+		default:
+		}
+
+		if err := conn.SetReadDeadline(time.Now().Add(PacketMaxIdleTime)); err != nil {
+			return err
+		}
+		var size uint32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return err
+		}
+		if err := conn.SetReadDeadline(time.Time{}); err != nil {
+			return err
+		}
+
+		// Avoid a memory exhaustion DOS vector here by capping how large
+		// this packet can be to something reasonable.
+		if size > PacketMaxByteSize {
+			return fmt.Errorf("gossip packet size %d exceeds threshold of %d", size, PacketMaxByteSize)
+		}
+
+		lc := &bufferedConn{Conn: conn, r: io.LimitReader(r, int64(size))}
+		if err := t.IngestionAwareTransport.IngestPacket(lc, conn.RemoteAddr(), time.Now(), false); err != nil {
+			return err
+		}
+	}
+}
+
+// bufferedConn is a net.Conn that reads from r instead of the connection.
+type bufferedConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
@@ -0,0 +1,149 @@
+package tcpgossip
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/sdk/freeport"
+)
+
+func newTestTransport(t *testing.T) (*Transport, string) {
+	t.Helper()
+
+	port := freeport.GetOne(t)
+	nt, err := memberlist.NewNetTransport(&memberlist.NetTransportConfig{
+		BindAddrs: []string{"127.0.0.1"},
+		BindPort:  port,
+	})
+	require.NoError(t, err)
+
+	tr, err := NewTransport(nt, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { tr.Shutdown() })
+
+	return tr, net.JoinHostPort("127.0.0.1", strconv.Itoa(nt.GetAutoBindPort()))
+}
+
+func TestTransport_Packets(t *testing.T) {
+	t1, _ := newTestTransport(t)
+	t2, addr2 := newTestTransport(t)
+
+	// Several packets are carried over the same stream.
+	for _, payload := range []string{"hello", "world"} {
+		_, err := t1.WriteTo([]byte(payload), addr2)
+		require.NoError(t, err)
+
+		select {
+		case p := <-t2.PacketCh():
+			require.Equal(t, payload, string(p.Buf))
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for packet %q", payload)
+		}
+	}
+	require.Len(t, t1.conns, 1)
+
+	// A failed stream is dialed again on the next write.
+	t1.conns[addr2].conn.Close()
+	_, _ = t1.WriteTo([]byte("lost"), addr2)
+	_, err := t1.WriteTo([]byte("again"), addr2)
+	require.NoError(t, err)
+
+	select {
+	case p := <-t2.PacketCh():
+		require.Equal(t, "again", string(p.Buf))
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for packet after redial")
+	}
+}
+
+func TestTransport_WriteTimeout(t *testing.T) {
+	t1, _ := newTestTransport(t)
+	t1.writeTimeout = 100 * time.Millisecond
+
+	// A peer which accepts the packet stream but never reads from it.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+
+	// Writes fail once the socket buffers are full instead of blocking.
+	payload := make([]byte, PacketMaxByteSize)
+	start := time.Now()
+	for i := 0; ; i++ {
+		require.Less(t, i, 64, "writes never timed out")
+		if _, err = t1.WriteTo(payload, ln.Addr().String()); err != nil {
+			break
+		}
+	}
+	require.Less(t, time.Since(start), 5*time.Second)
+
+	// The stream is discarded.
+	t1.mu.Lock()
+	defer t1.mu.Unlock()
+	require.Empty(t, t1.conns)
+}
+
+func TestTransport_Streams(t *testing.T) {
+	t1, _ := newTestTransport(t)
+	t2, addr2 := newTestTransport(t)
+
+	conn, err := t1.DialTimeout(addr2, time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// The first byte is a regular memberlist message type, so the stream
+	// must be handed to memberlist untouched.
+	_, err = conn.Write([]byte{6, 'a', 'b'})
+	require.NoError(t, err)
+
+	select {
+	case in := <-t2.StreamCh():
+		defer in.Close()
+		buf := make([]byte, 3)
+		_, err := io.ReadFull(in, buf)
+		require.NoError(t, err)
+		require.Equal(t, []byte{6, 'a', 'b'}, buf)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for stream")
+	}
+}
+
+func TestTransport_OversizedPacket(t *testing.T) {
+	_, addr2 := newTestTransport(t)
+
+	conn, err := net.Dial("tcp", addr2)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Announce a packet bigger than the limit; the stream gets closed.
+	_, err = conn.Write([]byte{packetStreamMarker, 0xff, 0xff, 0xff, 0xff})
+	require.NoError(t, err)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, err = conn.Read(make([]byte, 1))
+	require.Equal(t, io.EOF, err)
+}
+
+func TestTuneConfig(t *testing.T) {
+	conf := memberlist.DefaultLANConfig()
+	TuneConfig(conf)
+	require.True(t, conf.DisableTcpPings)
+	require.Equal(t, MinProbeTimeout, conf.ProbeTimeout)
+	require.Equal(t, MinProbeInterval, conf.ProbeInterval)
+
+	conf = memberlist.DefaultWANConfig()
+	TuneConfig(conf)
+	require.Equal(t, 3*time.Second, conf.ProbeTimeout)
+	require.Equal(t, 5*time.Second, conf.ProbeInterval)
+}
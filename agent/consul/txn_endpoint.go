@@ -73,7 +73,19 @@ func (t *Txn) preCheck(authorizer ACLResolveResult, ops structs.TxnOps) structs.
 					OpIndex: i,
 					What:    err.Error(),
 				})
+				break
 			}
+
+			// Only the servers can set the verified node metadata.
+			meta, err := t.srv.keepVerifiedNodeMeta(node.Node, node.Meta, node.GetEnterpriseMeta(), node.PeerName)
+			if err != nil {
+				errors = append(errors, &structs.TxnError{
+					OpIndex: i,
+					What:    err.Error(),
+				})
+				break
+			}
+			op.Node.Node.Meta = meta
 		case op.Service != nil:
 			// Skip the pre-apply checks if this is a GET.
 			if op.Service.Verb == api.ServiceGet {
@@ -87,13 +99,21 @@ func (t *Txn) preCheck(authorizer ACLResolveResult, ops structs.TxnOps) structs.
 					What:    err.Error(),
 				})
 			}
+			if op.Service.Verb == api.ServiceSet || op.Service.Verb == api.ServiceCAS {
+				if err := vetRegistrationPolicy(t.srv.fsm.State(), service); err != nil {
+					errors = append(errors, &structs.TxnError{
+						OpIndex: i,
+						What:    err.Error(),
+					})
+				}
+			}
 		case op.Check != nil:
 			// Skip the pre-apply checks if this is a GET.
 			if op.Check.Verb == api.CheckGet {
 				break
 			}
 
-			checkPreApply(&op.Check.Check)
+			checkPreApply(&op.Check.Check, t.srv.config.CatalogCheckOutputMaxSize)
 
 			// Check that the token has permissions for the given operation.
 			if err := vetCheckTxnOp(op.Check, authorizer); err != nil {
@@ -155,6 +175,11 @@ func (t *Txn) Apply(args *structs.TxnRequest, reply *structs.TxnResponse) error
 		return nil
 	}
 
+	// Record who removed nodes or services in the deregistration history,
+	// ignoring what the client may have set.
+	args.DeregisteredBy = authz.AccessorID()
+	args.DeregisteredAt = time.Now().UTC()
+
 	// Apply the update.
 	resp, err := t.srv.raftApply(structs.TxnRequestType, args)
 	if err != nil {
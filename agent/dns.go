@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net"
 	"regexp"
+	"sort"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -80,6 +81,7 @@ type dnsConfig struct {
 	NodeName         string
 	NodeTTL          time.Duration
 	OnlyPassing      bool
+	OnlyLocalSegment bool
 	RecursorStrategy agentdns.RecursorStrategy
 	RecursorTimeout  time.Duration
 	Recursors        []string
@@ -93,6 +95,17 @@ type dnsConfig struct {
 	// TTLStict sets TTLs to service by full name match. It Has higher priority than TTLRadix
 	TTLStrict          map[string]time.Duration
 	DisableCompression bool
+	PreferClientSubnet bool
+	// UDPAnswerLimitRadix and UDPAnswerLimitStrict override UDPAnswerLimit
+	// for services by prefix and by full name, like TTLRadix and TTLStrict
+	UDPAnswerLimitRadix  *radix.Tree
+	UDPAnswerLimitStrict map[string]int
+	// RecursorZones maps the lower case FQDN of zones to their recursors,
+	// which are used instead of Recursors for the names within the zones
+	RecursorZones map[string][]string
+	// AllowRecursionFrom restricts the recursive queries to the clients of
+	// the given networks, unless empty
+	AllowRecursionFrom []*net.IPNet
 
 	enterpriseDNSConfig
 }
@@ -156,6 +169,13 @@ func NewDNSServer(a *Agent) (*DNSServer, error) {
 	return srv, nil
 }
 
+// onlyLocalSegment returns true if lookups in the given datacenter should be
+// restricted to the nodes in the agent's own network segment. Segments are
+// local to a datacenter, so lookups in other datacenters are never filtered.
+func (cfg *dnsConfig) onlyLocalSegment(datacenter string) bool {
+	return cfg.OnlyLocalSegment && (datacenter == "" || datacenter == cfg.Datacenter)
+}
+
 // GetDNSConfig takes global config and creates the config used by DNS server
 func GetDNSConfig(conf *config.RuntimeConfig) (*dnsConfig, error) {
 	cfg := &dnsConfig{
@@ -167,8 +187,11 @@ func GetDNSConfig(conf *config.RuntimeConfig) (*dnsConfig, error) {
 		NodeName:           conf.NodeName,
 		NodeTTL:            conf.DNSNodeTTL,
 		OnlyPassing:        conf.DNSOnlyPassing,
+		OnlyLocalSegment:   conf.DNSOnlyLocalSegment,
+		PreferClientSubnet: conf.DNSPreferClientSubnet,
 		RecursorStrategy:   conf.DNSRecursorStrategy,
 		RecursorTimeout:    conf.DNSRecursorTimeout,
+		AllowRecursionFrom: conf.DNSAllowRecursionFrom,
 		SegmentName:        conf.SegmentName,
 		UDPAnswerLimit:     conf.DNSUDPAnswerLimit,
 		NodeMetaTXT:        conf.DNSNodeMetaTXT,
@@ -197,6 +220,18 @@ func GetDNSConfig(conf *config.RuntimeConfig) (*dnsConfig, error) {
 			}
 		}
 	}
+	if conf.DNSServiceUDPAnswerLimit != nil {
+		cfg.UDPAnswerLimitRadix = radix.New()
+		cfg.UDPAnswerLimitStrict = make(map[string]int)
+
+		for key, limit := range conf.DNSServiceUDPAnswerLimit {
+			if strings.HasSuffix(key, "*") {
+				cfg.UDPAnswerLimitRadix.Insert(key[:len(key)-1], limit)
+			} else {
+				cfg.UDPAnswerLimitStrict[key] = limit
+			}
+		}
+	}
 	for _, r := range conf.DNSRecursors {
 		ra, err := recursorAddr(r)
 		if err != nil {
@@ -204,10 +239,60 @@ func GetDNSConfig(conf *config.RuntimeConfig) (*dnsConfig, error) {
 		}
 		cfg.Recursors = append(cfg.Recursors, ra)
 	}
+	for zone, recursors := range conf.DNSRecursorZones {
+		if cfg.RecursorZones == nil {
+			cfg.RecursorZones = make(map[string][]string)
+		}
+		zone = dns.Fqdn(strings.ToLower(zone))
+		for _, r := range recursors {
+			ra, err := recursorAddr(r)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid recursor address for zone %q: %v", zone, err)
+			}
+			cfg.RecursorZones[zone] = append(cfg.RecursorZones[zone], ra)
+		}
+	}
 
 	return cfg, nil
 }
 
+// hasRecursors returns whether recursive queries can be resolved.
+func (cfg *dnsConfig) hasRecursors() bool {
+	return len(cfg.Recursors) > 0 || len(cfg.RecursorZones) > 0
+}
+
+// recursorsForName returns the recursors of the longest zone the name is
+// within, or the default recursors if it isn't within any zone.
+func (cfg *dnsConfig) recursorsForName(name string) []string {
+	if len(cfg.RecursorZones) > 0 {
+		name = dns.Fqdn(strings.ToLower(name))
+		for off, end := 0, false; !end; off, end = dns.NextLabel(name, off) {
+			if recursors, ok := cfg.RecursorZones[name[off:]]; ok {
+				return recursors
+			}
+		}
+	}
+	return cfg.Recursors
+}
+
+// recursionAllowed returns whether the client at the given address is
+// allowed to send recursive queries.
+func (cfg *dnsConfig) recursionAllowed(addr net.Addr) bool {
+	if len(cfg.AllowRecursionFrom) == 0 {
+		return true
+	}
+	ip := dnsRemoteIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range cfg.AllowRecursionFrom {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetTTLForService Find the TTL for a given service.
 // return ttl, true if found, 0, false otherwise
 func (cfg *dnsConfig) GetTTLForService(service string) (time.Duration, bool) {
@@ -226,6 +311,24 @@ func (cfg *dnsConfig) GetTTLForService(service string) (time.Duration, bool) {
 	return 0, false
 }
 
+// GetUDPAnswerLimitForService Find the UDP answer limit for a given service.
+// return limit, true if found, 0, false otherwise
+func (cfg *dnsConfig) GetUDPAnswerLimitForService(service string) (int, bool) {
+	if cfg.UDPAnswerLimitStrict != nil {
+		limit, ok := cfg.UDPAnswerLimitStrict[service]
+		if ok {
+			return limit, true
+		}
+	}
+	if cfg.UDPAnswerLimitRadix != nil {
+		_, limitRaw, ok := cfg.UDPAnswerLimitRadix.LongestPrefix(service)
+		if ok {
+			return limitRaw.(int), true
+		}
+	}
+	return 0, false
+}
+
 func (d *DNSServer) ListenAndServe(network, addr string, notif func()) error {
 	cfg := d.config.Load().(*dnsConfig)
 
@@ -257,7 +360,7 @@ func (d *DNSServer) ListenAndServe(network, addr string, notif func()) error {
 
 // toggleRecursorHandlerFromConfig enables or disables the recursor handler based on config idempotently
 func (d *DNSServer) toggleRecursorHandlerFromConfig(cfg *dnsConfig) {
-	shouldEnable := len(cfg.Recursors) > 0
+	shouldEnable := cfg.hasRecursors()
 
 	if shouldEnable && atomic.CompareAndSwapUint32(&d.recursorEnabled, 0, 1) {
 		d.mux.HandleFunc(".", d.handleRecurse)
@@ -392,7 +495,7 @@ func (d *DNSServer) handlePtr(resp dns.ResponseWriter, req *dns.Msg) {
 	m.SetReply(req)
 	m.Compress = !cfg.DisableCompression
 	m.Authoritative = true
-	m.RecursionAvailable = (len(cfg.Recursors) > 0)
+	m.RecursionAvailable = cfg.hasRecursors() && cfg.recursionAllowed(resp.RemoteAddr())
 
 	// Only add the SOA if requested
 	if req.Question[0].Qtype == dns.TypeSOA {
@@ -502,9 +605,10 @@ func (d *DNSServer) handleQuery(resp dns.ResponseWriter, req *dns.Msg) {
 	m.SetReply(req)
 	m.Compress = !cfg.DisableCompression
 	m.Authoritative = true
-	m.RecursionAvailable = (len(cfg.Recursors) > 0)
+	m.RecursionAvailable = cfg.hasRecursors() && cfg.recursionAllowed(resp.RemoteAddr())
 
 	var err error
+	udpAnswerLimit := cfg.UDPAnswerLimit
 
 	switch req.Question[0].Qtype {
 	case dns.TypeSOA:
@@ -524,7 +628,7 @@ func (d *DNSServer) handleQuery(resp dns.ResponseWriter, req *dns.Msg) {
 		m.SetRcode(req, dns.RcodeNotImplemented)
 
 	default:
-		err = d.dispatch(resp.RemoteAddr(), req, m, maxRecursionLevelDefault)
+		err = d.dispatch(resp.RemoteAddr(), req, m, maxRecursionLevelDefault, &udpAnswerLimit)
 		rCode := rCodeFromError(err)
 		if rCode == dns.RcodeNameError || errors.Is(err, errNoData) {
 			d.addSOA(cfg, m, q.Name)
@@ -534,7 +638,7 @@ func (d *DNSServer) handleQuery(resp dns.ResponseWriter, req *dns.Msg) {
 
 	setEDNS(req, m, !errors.Is(err, errECSNotGlobal))
 
-	d.trimDNSResponse(cfg, network, req, m)
+	d.trimDNSResponse(cfg, network, req, m, udpAnswerLimit)
 
 	if err := resp.WriteMsg(m); err != nil {
 		d.logger.Warn("failed to respond", "error", err)
@@ -678,7 +782,9 @@ func (e ecsNotGlobalError) Unwrap() error {
 
 // dispatch is used to parse a request and invoke the correct handler.
 // parameter maxRecursionLevel will handle whether recursive call can be performed
-func (d *DNSServer) dispatch(remoteAddr net.Addr, req, resp *dns.Msg, maxRecursionLevel int) error {
+// udpAnswerLimit is set to the UDP answer limit of the service queried, if
+// it overrides the one of the agent.
+func (d *DNSServer) dispatch(remoteAddr net.Addr, req, resp *dns.Msg, maxRecursionLevel int, udpAnswerLimit *int) error {
 	// By default the query is in the default datacenter
 	datacenter := d.agent.config.Datacenter
 
@@ -758,7 +864,7 @@ func (d *DNSServer) dispatch(remoteAddr net.Addr, req, resp *dns.Msg, maxRecursi
 			lookup.Tag = tag
 			lookup.Service = queryParts[0][1:]
 			// _name._tag.service.consul
-			return d.serviceLookup(cfg, lookup, req, resp)
+			return d.serviceLookup(cfg, lookup, req, resp, udpAnswerLimit)
 		}
 
 		// Consul 0.3 and prior format for SRV queries
@@ -772,7 +878,7 @@ func (d *DNSServer) dispatch(remoteAddr net.Addr, req, resp *dns.Msg, maxRecursi
 		lookup.Service = queryParts[n-1]
 
 		// tag[.tag].name.service.consul
-		return d.serviceLookup(cfg, lookup, req, resp)
+		return d.serviceLookup(cfg, lookup, req, resp, udpAnswerLimit)
 
 	case "connect":
 		if len(queryParts) < 1 {
@@ -792,7 +898,7 @@ func (d *DNSServer) dispatch(remoteAddr net.Addr, req, resp *dns.Msg, maxRecursi
 			EnterpriseMeta:    entMeta,
 		}
 		// name.connect.consul
-		return d.serviceLookup(cfg, lookup, req, resp)
+		return d.serviceLookup(cfg, lookup, req, resp, udpAnswerLimit)
 
 	case "virtual":
 		if len(queryParts) < 1 {
@@ -847,7 +953,7 @@ func (d *DNSServer) dispatch(remoteAddr net.Addr, req, resp *dns.Msg, maxRecursi
 			EnterpriseMeta:    entMeta,
 		}
 		// name.ingress.consul
-		return d.serviceLookup(cfg, lookup, req, resp)
+		return d.serviceLookup(cfg, lookup, req, resp, udpAnswerLimit)
 
 	case "node":
 		if len(queryParts) < 1 {
@@ -888,7 +994,7 @@ func (d *DNSServer) dispatch(remoteAddr net.Addr, req, resp *dns.Msg, maxRecursi
 
 		// Allow a "." in the query name, just join all the parts.
 		query := strings.Join(queryParts, ".")
-		err := d.preparedQueryLookup(cfg, datacenter, query, remoteAddr, req, resp, maxRecursionLevel)
+		err := d.preparedQueryLookup(cfg, datacenter, query, remoteAddr, req, resp, maxRecursionLevel, udpAnswerLimit)
 		return ecsNotGlobalError{error: err}
 
 	case "addr":
@@ -1008,6 +1114,13 @@ func (d *DNSServer) nodeLookup(cfg *dnsConfig, lookup nodeLookup, req, resp *dns
 		return errNameNotFound
 	}
 
+	// Nodes in other network segments are hidden when restricted to the
+	// local segment.
+	if cfg.onlyLocalSegment(lookup.Datacenter) &&
+		out.NodeServices.Node.Meta[structs.MetaSegmentKey] != cfg.SegmentName {
+		return errNameNotFound
+	}
+
 	// Add the node record
 	n := out.NodeServices.Node
 
@@ -1134,6 +1247,13 @@ func syncExtra(index map[string]dns.RR, resp *dns.Msg) {
 			}
 		}
 	}
+
+	// Keep the EDNS pseudo-record, it is not related to the answers.
+	for _, rr := range resp.Extra {
+		if rr.Header().Rrtype == dns.TypeOPT {
+			extra = append(extra, rr)
+		}
+	}
 	resp.Extra = extra
 }
 
@@ -1183,14 +1303,18 @@ func trimTCPResponse(req, resp *dns.Msg) (trimmed bool) {
 		// More than 1024 SRV records do not fit in 64k
 		truncateAt = 1024
 	}
+	truncated := false
 	if len(resp.Answer) > truncateAt {
 		resp.Answer = resp.Answer[:truncateAt]
+		truncated = true
 	}
 	if hasExtra {
 		index = make(map[string]dns.RR, len(resp.Extra))
 		indexRRs(resp.Extra, index)
+		if truncated {
+			syncExtra(index, resp)
+		}
 	}
-	truncated := false
 
 	// This enforces the given limit on 64k, the max limit for DNS messages
 	for len(resp.Answer) > 1 && resp.Len() > maxSize {
@@ -1281,12 +1405,12 @@ func trimUDPResponse(req, resp *dns.Msg, udpAnswerLimit int) (trimmed bool) {
 }
 
 // trimDNSResponse will trim the response for UDP and TCP
-func (d *DNSServer) trimDNSResponse(cfg *dnsConfig, network string, req, resp *dns.Msg) {
+func (d *DNSServer) trimDNSResponse(cfg *dnsConfig, network string, req, resp *dns.Msg, udpAnswerLimit int) {
 	var trimmed bool
 	originalSize := resp.Len()
 	originalNumRecords := len(resp.Answer)
 	if network != "tcp" {
-		trimmed = trimUDPResponse(req, resp, cfg.UDPAnswerLimit)
+		trimmed = trimUDPResponse(req, resp, udpAnswerLimit)
 	} else {
 		trimmed = trimTCPResponse(req, resp)
 	}
@@ -1326,6 +1450,9 @@ func (d *DNSServer) lookupServiceNodes(cfg *dnsConfig, lookup serviceLookup) (st
 		},
 		EnterpriseMeta: lookup.EnterpriseMeta,
 	}
+	if cfg.onlyLocalSegment(lookup.Datacenter) {
+		args.NodeMetaFilters = map[string]string{structs.MetaSegmentKey: cfg.SegmentName}
+	}
 
 	out, _, err := d.agent.rpcClientHealth.ServiceNodes(context.TODO(), args)
 	if err != nil {
@@ -1341,7 +1468,7 @@ func (d *DNSServer) lookupServiceNodes(cfg *dnsConfig, lookup serviceLookup) (st
 }
 
 // serviceLookup is used to handle a service query
-func (d *DNSServer) serviceLookup(cfg *dnsConfig, lookup serviceLookup, req, resp *dns.Msg) error {
+func (d *DNSServer) serviceLookup(cfg *dnsConfig, lookup serviceLookup, req, resp *dns.Msg, udpAnswerLimit *int) error {
 	out, err := d.lookupServiceNodes(cfg, lookup)
 	if err != nil {
 		return fmt.Errorf("rpc request failed: %w", err)
@@ -1355,6 +1482,16 @@ func (d *DNSServer) serviceLookup(cfg *dnsConfig, lookup serviceLookup, req, res
 	// Perform a random shuffle
 	out.Nodes.Shuffle()
 
+	// Return the instances within the client subnet first, the response is
+	// then only valid for that subnet.
+	var subnet *dns.EDNS0_SUBNET
+	if cfg.PreferClientSubnet {
+		subnet = ednsSubnetForRequest(req)
+		if subnet != nil {
+			sortByClientSubnet(subnet, out.Nodes)
+		}
+	}
+
 	// Determine the TTL
 	ttl, _ := cfg.GetTTLForService(lookup.Service)
 
@@ -1366,10 +1503,41 @@ func (d *DNSServer) serviceLookup(cfg *dnsConfig, lookup serviceLookup, req, res
 		d.serviceNodeRecords(cfg, lookup.Datacenter, out.Nodes, req, resp, ttl, lookup.MaxRecursionLevel)
 	}
 
+	err = nil
 	if len(resp.Answer) == 0 {
-		return errNoData
+		err = errNoData
 	}
-	return nil
+	if limit, ok := cfg.GetUDPAnswerLimitForService(lookup.Service); ok {
+		*udpAnswerLimit = limit
+	}
+	if subnet != nil {
+		err = ecsNotGlobalError{error: err}
+	}
+	return err
+}
+
+// sortByClientSubnet moves the service instances whose address is within the
+// given EDNS client subnet first, keeping the order of the others.
+func sortByClientSubnet(subnet *dns.EDNS0_SUBNET, nodes structs.CheckServiceNodes) {
+	bits := 32
+	if subnet.Family == 2 {
+		bits = 128
+	}
+	ipNet := net.IPNet{
+		IP:   subnet.Address,
+		Mask: net.CIDRMask(int(subnet.SourceNetmask), bits),
+	}
+	inSubnet := func(node structs.CheckServiceNode) bool {
+		addr := node.Service.Address
+		if addr == "" {
+			addr = node.Node.Address
+		}
+		ip := net.ParseIP(addr)
+		return ip != nil && ipNet.Contains(ip)
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return inSubnet(nodes[i]) && !inSubnet(nodes[j])
+	})
 }
 
 func ednsSubnetForRequest(req *dns.Msg) *dns.EDNS0_SUBNET {
@@ -1389,8 +1557,22 @@ func ednsSubnetForRequest(req *dns.Msg) *dns.EDNS0_SUBNET {
 	return nil
 }
 
+// dnsRemoteIP returns the IP address of the client of a query, or nil if it
+// is unknown such as for queries resolved internally.
+func dnsRemoteIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		return a.IP
+	case *net.TCPAddr:
+		return a.IP
+	case *net.IPAddr:
+		return a.IP
+	}
+	return nil
+}
+
 // preparedQueryLookup is used to handle a prepared query.
-func (d *DNSServer) preparedQueryLookup(cfg *dnsConfig, datacenter, query string, remoteAddr net.Addr, req, resp *dns.Msg, maxRecursionLevel int) error {
+func (d *DNSServer) preparedQueryLookup(cfg *dnsConfig, datacenter, query string, remoteAddr net.Addr, req, resp *dns.Msg, maxRecursionLevel int, udpAnswerLimit *int) error {
 	// Execute the prepared query.
 	args := structs.PreparedQueryExecuteRequest{
 		Datacenter:    datacenter,
@@ -1472,10 +1654,14 @@ func (d *DNSServer) preparedQueryLookup(cfg *dnsConfig, datacenter, query string
 		d.serviceNodeRecords(cfg, out.Datacenter, out.Nodes, req, resp, ttl, maxRecursionLevel)
 	}
 
+	err = nil
 	if len(resp.Answer) == 0 {
-		return errNoData
+		err = errNoData
 	}
-	return nil
+	if limit, ok := cfg.GetUDPAnswerLimitForService(out.Service); ok {
+		*udpAnswerLimit = limit
+	}
+	return err
 }
 
 func (d *DNSServer) lookupPreparedQuery(cfg *dnsConfig, args structs.PreparedQueryExecuteRequest) (*structs.PreparedQueryExecuteResponse, error) {
@@ -1942,13 +2128,35 @@ func (d *DNSServer) handleRecurse(resp dns.ResponseWriter, req *dns.Msg) {
 		network = "tcp"
 	}
 
+	// Refuse the queries of the clients which are not allowed to recurse,
+	// and the ones outside the zones when there are no default recursors,
+	// so that the agent isn't an open resolver.
+	recursors := cfg.recursorsForName(q.Name)
+	if !cfg.recursionAllowed(resp.RemoteAddr()) || len(recursors) == 0 {
+		d.logger.Debug("recursion refused for question from client",
+			"question", q,
+			"client", resp.RemoteAddr().String(),
+		)
+		m := &dns.Msg{}
+		m.SetReply(req)
+		m.Compress = !cfg.DisableCompression
+		m.SetRcode(req, dns.RcodeRefused)
+		if edns := req.IsEdns0(); edns != nil {
+			setEDNS(req, m, true)
+		}
+		if err := resp.WriteMsg(m); err != nil {
+			d.logger.Warn("failed to respond", "error", err)
+		}
+		return
+	}
+
 	// Recursively resolve
 	c := &dns.Client{Net: network, Timeout: cfg.RecursorTimeout}
 	var r *dns.Msg
 	var rtt time.Duration
 	var err error
-	for _, idx := range cfg.RecursorStrategy.Indexes(len(cfg.Recursors)) {
-		recursor := cfg.Recursors[idx]
+	for _, idx := range cfg.RecursorStrategy.Indexes(len(recursors)) {
+		recursor := recursors[idx]
 		r, rtt, err = c.Exchange(req, recursor)
 		// Check if the response is valid and has the desired Response code
 		if r != nil && (r.Rcode != dns.RcodeSuccess && r.Rcode != dns.RcodeNameError) {
@@ -2014,13 +2222,14 @@ func (d *DNSServer) resolveCNAME(cfg *dnsConfig, name string, maxRecursionLevel
 
 		req.SetQuestion(name, dns.TypeANY)
 		// TODO: handle error response
-		d.dispatch(nil, req, resp, maxRecursionLevel-1)
+		d.dispatch(nil, req, resp, maxRecursionLevel-1, new(int))
 
 		return resp.Answer
 	}
 
 	// Do nothing if we don't have a recursor
-	if len(cfg.Recursors) == 0 {
+	recursors := cfg.recursorsForName(name)
+	if len(recursors) == 0 {
 		return nil
 	}
 
@@ -2033,8 +2242,8 @@ func (d *DNSServer) resolveCNAME(cfg *dnsConfig, name string, maxRecursionLevel
 	var r *dns.Msg
 	var rtt time.Duration
 	var err error
-	for _, idx := range cfg.RecursorStrategy.Indexes(len(cfg.Recursors)) {
-		recursor := cfg.Recursors[idx]
+	for _, idx := range cfg.RecursorStrategy.Indexes(len(recursors)) {
+		recursor := recursors[idx]
 		r, rtt, err = c.Exchange(m, recursor)
 		if err == nil {
 			d.logger.Debug("cname recurse RTT for name",
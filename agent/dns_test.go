@@ -943,6 +943,131 @@ func TestDNS_EDNS0_ECS(t *testing.T) {
 	}
 }
 
+func TestDNS_EDNS0_ECS_PreferClientSubnet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, `
+		dns_config {
+			a_record_limit = 1
+			prefer_client_subnet = true
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	// Register a few instances, only one of them within the client subnet.
+	for i := 1; i <= 10; i++ {
+		args := &structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       fmt.Sprintf("foo%d", i),
+			Address:    fmt.Sprintf("127.0.0.%d", i),
+			Service: &structs.NodeService{
+				Service: "db",
+				Port:    12345,
+			},
+		}
+		if i == 10 {
+			args.Address = "198.18.0.10"
+		}
+
+		var out struct{}
+		require.NoError(t, a.RPC("Catalog.Register", args, &out))
+	}
+
+	for i := 0; i < 5; i++ {
+		m := new(dns.Msg)
+		m.SetQuestion("db.service.consul.", dns.TypeA)
+		m.SetEdns0(4096, false)
+		m.IsEdns0().Option = append(m.IsEdns0().Option, &dns.EDNS0_SUBNET{
+			Code:          dns.EDNS0SUBNET,
+			Family:        1,
+			SourceNetmask: 24,
+			Address:       net.ParseIP("198.18.0.0"),
+		})
+
+		in, _, err := new(dns.Client).Exchange(m, a.DNSAddr())
+		require.NoError(t, err)
+		require.Len(t, in.Answer, 1)
+		aRec, ok := in.Answer[0].(*dns.A)
+		require.True(t, ok)
+		require.Equal(t, "198.18.0.10", aRec.A.String())
+
+		// The response is only valid for the client subnet.
+		optRR := in.IsEdns0()
+		require.NotNil(t, optRR)
+		require.Len(t, optRR.Option, 1)
+		subnet, ok := optRR.Option[0].(*dns.EDNS0_SUBNET)
+		require.True(t, ok)
+		require.Equal(t, uint8(24), subnet.SourceScope)
+	}
+}
+
+func TestDNS_ServiceLookup_ServiceUDPAnswerLimit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, `
+		dns_config {
+			enable_truncate = true
+			udp_answer_limit = 2
+			service_udp_answer_limit = {
+				"db*" = 1
+				"web" = 5
+			}
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	for _, service := range []string{"api", "db-primary", "web"} {
+		for i := 1; i <= 6; i++ {
+			args := &structs.RegisterRequest{
+				Datacenter: "dc1",
+				Node:       fmt.Sprintf("%s-%d", service, i),
+				Address:    fmt.Sprintf("127.0.0.%d", i),
+				Service: &structs.NodeService{
+					Service: service,
+					Port:    12345,
+				},
+			}
+
+			var out struct{}
+			require.NoError(t, a.RPC("Catalog.Register", args, &out))
+		}
+	}
+
+	cases := map[string]int{
+		"api":        2,
+		"db-primary": 1,
+		"web":        5,
+	}
+	for service, expected := range cases {
+		t.Run(service, func(t *testing.T) {
+			m := new(dns.Msg)
+			m.SetQuestion(service+".service.consul.", dns.TypeA)
+
+			// UDP responses are limited and flagged as truncated.
+			c := &dns.Client{Net: "udp"}
+			in, _, err := c.Exchange(m, a.DNSAddr())
+			require.NoError(t, err)
+			require.Len(t, in.Answer, expected)
+			require.True(t, in.Truncated)
+
+			// TCP responses are complete.
+			c = &dns.Client{Net: "tcp"}
+			in, _, err = c.Exchange(m, a.DNSAddr())
+			require.NoError(t, err)
+			require.Len(t, in.Answer, 6)
+			require.False(t, in.Truncated)
+		})
+	}
+}
+
 func TestDNS_ReverseLookup(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -4126,6 +4251,87 @@ func TestDNS_Recurse(t *testing.T) {
 	}
 }
 
+func TestDNS_Recurse_Zones(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	recursor := makeRecursor(t, dns.Msg{
+		Answer: []dns.RR{dnsA("apple.com", "1.2.3.4")},
+	})
+	defer recursor.Shutdown()
+	zoneRecursor := makeRecursor(t, dns.Msg{
+		Answer: []dns.RR{dnsA("www.corp.example.com", "10.0.0.1")},
+	})
+	defer zoneRecursor.Shutdown()
+
+	a := NewTestAgent(t, `
+		recursors = ["`+recursor.Addr+`"]
+		dns_config {
+			recursor_zones = {
+				"Corp.Example.com" = ["`+zoneRecursor.Addr+`"]
+			}
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	cases := map[string]string{
+		"apple.com.":                "1.2.3.4",
+		"www.corp.example.com.":     "10.0.0.1",
+		"www.notcorp.example.com.":  "1.2.3.4",
+		"deep.www.CORP.example.com": "10.0.0.1",
+	}
+	for question, expected := range cases {
+		m := new(dns.Msg)
+		m.SetQuestion(dns.Fqdn(question), dns.TypeA)
+
+		in, _, err := new(dns.Client).Exchange(m, a.DNSAddr())
+		require.NoError(t, err)
+		require.Equal(t, dns.RcodeSuccess, in.Rcode, question)
+		require.Len(t, in.Answer, 1, question)
+		require.Equal(t, expected, in.Answer[0].(*dns.A).A.String(), question)
+	}
+}
+
+func TestDNS_Recurse_AllowRecursionFrom(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	recursor := makeRecursor(t, dns.Msg{
+		Answer: []dns.RR{dnsA("apple.com", "1.2.3.4")},
+	})
+	defer recursor.Shutdown()
+
+	a := NewTestAgent(t, `
+		recursors = ["`+recursor.Addr+`"]
+		dns_config {
+			allow_recursion_from = ["10.0.0.0/8"]
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	// Recursive queries from the loopback client are refused.
+	m := new(dns.Msg)
+	m.SetQuestion("apple.com.", dns.TypeA)
+	in, _, err := new(dns.Client).Exchange(m, a.DNSAddr())
+	require.NoError(t, err)
+	require.Equal(t, dns.RcodeRefused, in.Rcode)
+	require.Empty(t, in.Answer)
+
+	// Consul queries are still answered, without advertising recursion.
+	m = new(dns.Msg)
+	m.SetQuestion(a.Config.NodeName+".node.consul.", dns.TypeA)
+	in, _, err = new(dns.Client).Exchange(m, a.DNSAddr())
+	require.NoError(t, err)
+	require.Equal(t, dns.RcodeSuccess, in.Rcode)
+	require.False(t, in.RecursionAvailable)
+}
+
 func TestDNS_Recurse_Truncation(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -4498,6 +4704,63 @@ func TestDNS_ServiceLookup_OnlyFailing(t *testing.T) {
 	}
 }
 
+func TestDNS_OnlyLocalSegment(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, `
+		dns_config {
+			only_local_segment = true
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	// Register a node in the default segment and one in another segment.
+	for node, segment := range map[string]string{"foo": "", "bar": "alpha"} {
+		args := &structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       node,
+			Address:    "127.0.0.1",
+			NodeMeta:   map[string]string{structs.MetaSegmentKey: segment},
+			Service: &structs.NodeService{
+				Service: "db",
+				Port:    12345,
+			},
+		}
+
+		var out struct{}
+		require.NoError(t, a.RPC("Catalog.Register", args, &out))
+	}
+
+	lookup := func(question string, qType uint16) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetQuestion(question, qType)
+
+		c := new(dns.Client)
+		in, _, err := c.Exchange(m, a.DNSAddr())
+		require.NoError(t, err)
+		return in
+	}
+
+	// Only the node in the agent's segment is returned for the service.
+	in := lookup("db.service.consul.", dns.TypeSRV)
+	require.Len(t, in.Answer, 1)
+	srv, ok := in.Answer[0].(*dns.SRV)
+	require.True(t, ok)
+	require.Equal(t, "foo.node.dc1.consul.", srv.Target)
+
+	// Nodes in other segments can't be looked up directly either.
+	in = lookup("foo.node.consul.", dns.TypeA)
+	require.Len(t, in.Answer, 1)
+
+	in = lookup("bar.node.consul.", dns.TypeA)
+	require.Len(t, in.Answer, 0)
+	require.Equal(t, dns.RcodeNameError, in.Rcode)
+}
+
 func TestDNS_ServiceLookup_OnlyPassing(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -8144,3 +8407,172 @@ func TestECSNotGlobalError(t *testing.T) {
 		require.Equal(t, errNameNotFound, errors.Unwrap(e))
 	})
 }
+
+func TestDNS_GetUDPAnswerLimitForService(t *testing.T) {
+	t.Parallel()
+	rt := loadRuntimeConfig(t, `
+		node_name = "test" data_dir = "a" bind_addr = "127.0.0.1"
+		dns_config {
+			service_udp_answer_limit = {
+				"d*" = 4
+				"db" = 1
+				"db*" = 2
+			}
+		}
+	`)
+	cfg, err := GetDNSConfig(rt)
+	require.NoError(t, err)
+
+	cases := map[string]int{
+		"db":      1,
+		"db-2":    2,
+		"dns":     4,
+		"nothing": 0,
+	}
+	for service, expected := range cases {
+		limit, ok := cfg.GetUDPAnswerLimitForService(service)
+		require.Equal(t, expected != 0, ok, service)
+		require.Equal(t, expected, limit, service)
+	}
+}
+
+func TestDNS_trimUDPResponse_KeepEDNS(t *testing.T) {
+	t.Parallel()
+
+	req, resp := &dns.Msg{}, &dns.Msg{}
+	req.SetEdns0(1024, false)
+	for i := 0; i < 100; i++ {
+		target := fmt.Sprintf("ip-10-0-1-%d.node.dc1.consul.", 150+i)
+		resp.Answer = append(resp.Answer, &dns.SRV{
+			Hdr: dns.RR_Header{
+				Name:   "redis-cache-redis.service.consul.",
+				Rrtype: dns.TypeSRV,
+				Class:  dns.ClassINET,
+			},
+			Target: target,
+		})
+		resp.Extra = append(resp.Extra, &dns.A{
+			Hdr: dns.RR_Header{
+				Name:   target,
+				Rrtype: dns.TypeA,
+				Class:  dns.ClassINET,
+			},
+			A: net.ParseIP(fmt.Sprintf("10.0.1.%d", 150+i)),
+		})
+	}
+	setEDNS(req, resp, true)
+
+	require.True(t, trimUDPResponse(req, resp, 3))
+	require.LessOrEqual(t, resp.Len(), 1024)
+	require.NotNil(t, resp.IsEdns0())
+	require.Len(t, resp.Extra, len(resp.Answer)+1)
+}
+
+func TestDNS_trimTCPResponse_TrimAnswerCount(t *testing.T) {
+	t.Parallel()
+
+	req, resp := &dns.Msg{}, &dns.Msg{}
+	req.Question = append(req.Question, dns.Question{Qtype: dns.TypeSRV})
+	for i := 0; i < 2000; i++ {
+		resp.Answer = append(resp.Answer, &dns.SRV{
+			Hdr: dns.RR_Header{
+				Name:   "web.service.consul.",
+				Rrtype: dns.TypeSRV,
+				Class:  dns.ClassINET,
+			},
+			Target: "web.service.consul.",
+		})
+	}
+	resp.Compress = true
+
+	// The response fits in 64k but has too many records.
+	require.True(t, trimTCPResponse(req, resp))
+	require.Len(t, resp.Answer, 1024)
+}
+
+func TestDNS_sortByClientSubnet(t *testing.T) {
+	t.Parallel()
+
+	node := func(nodeAddr, serviceAddr string) structs.CheckServiceNode {
+		return structs.CheckServiceNode{
+			Node:    &structs.Node{Node: nodeAddr, Address: nodeAddr},
+			Service: &structs.NodeService{Service: "web", Address: serviceAddr},
+		}
+	}
+	nodes := structs.CheckServiceNodes{
+		node("10.0.0.1", ""),
+		node("10.0.1.1", ""),
+		node("10.0.0.2", "10.0.1.2"),
+		node("10.0.0.3", "web.example.com"),
+		node("10.0.1.3", ""),
+	}
+
+	subnet := &dns.EDNS0_SUBNET{
+		Family:        1,
+		SourceNetmask: 24,
+		Address:       net.ParseIP("10.0.1.0"),
+	}
+	sortByClientSubnet(subnet, nodes)
+
+	var order []string
+	for _, n := range nodes {
+		order = append(order, n.Node.Node)
+	}
+	require.Equal(t, []string{"10.0.1.1", "10.0.0.2", "10.0.1.3", "10.0.0.1", "10.0.0.3"}, order)
+}
+
+func TestDNS_recursorsForName(t *testing.T) {
+	t.Parallel()
+	rt := loadRuntimeConfig(t, `
+		node_name = "test" data_dir = "a" bind_addr = "127.0.0.1"
+		recursors = ["8.8.8.8"]
+		dns_config {
+			recursor_zones = {
+				"example.com" = ["10.0.0.1"]
+				"corp.example.com." = ["10.0.0.2", "10.0.0.3:5353"]
+			}
+		}
+	`)
+	cfg, err := GetDNSConfig(rt)
+	require.NoError(t, err)
+	require.True(t, cfg.hasRecursors())
+
+	cases := map[string][]string{
+		"apple.com.":            {"8.8.8.8:53"},
+		"example.com":           {"10.0.0.1:53"},
+		"www.example.com.":      {"10.0.0.1:53"},
+		"www.Corp.Example.com.": {"10.0.0.2:53", "10.0.0.3:5353"},
+		"corp.example.com.":     {"10.0.0.2:53", "10.0.0.3:5353"},
+		"notcorp.example.com.":  {"10.0.0.1:53"},
+		"example.org.":          {"8.8.8.8:53"},
+	}
+	for name, expected := range cases {
+		require.Equal(t, expected, cfg.recursorsForName(name), name)
+	}
+
+	// Without default recursors only the zones are resolved.
+	cfg.Recursors = nil
+	require.True(t, cfg.hasRecursors())
+	require.Empty(t, cfg.recursorsForName("apple.com."))
+}
+
+func TestDNS_recursionAllowed(t *testing.T) {
+	t.Parallel()
+	cfg := &dnsConfig{}
+	udp := func(ip string) net.Addr {
+		return &net.UDPAddr{IP: net.ParseIP(ip), Port: 5353}
+	}
+
+	// No restriction by default.
+	require.True(t, cfg.recursionAllowed(udp("192.168.0.1")))
+
+	_, n1, _ := net.ParseCIDR("10.0.0.0/8")
+	_, n2, _ := net.ParseCIDR("fd00::/8")
+	cfg.AllowRecursionFrom = []*net.IPNet{n1, n2}
+
+	require.True(t, cfg.recursionAllowed(udp("10.1.2.3")))
+	require.True(t, cfg.recursionAllowed(&net.TCPAddr{IP: net.ParseIP("fd00::1")}))
+	require.False(t, cfg.recursionAllowed(udp("192.168.0.1")))
+	require.False(t, cfg.recursionAllowed(udp("127.0.0.1")))
+	require.False(t, cfg.recursionAllowed(nil))
+}
@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/armon/circbuf"
+
+	"github.com/hashicorp/consul/agent/config"
+	"github.com/hashicorp/consul/agent/exec"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/types"
+)
+
+const (
+	// serviceDrainCheckPrefix is the prefix of the critical checks
+	// registered against the services of an agent while it drains them.
+	serviceDrainCheckPrefix = "_service_drain:"
+
+	// drainHookBufSize limits the output of a drain hook that is logged.
+	drainHookBufSize = 4 * 1024 // 4KB
+)
+
+func serviceDrainCheckID(serviceID structs.ServiceID) structs.CheckID {
+	cid := types.CheckID(serviceDrainCheckPrefix + serviceID.ID)
+	return structs.NewCheckID(cid, &serviceID.EnterpriseMeta)
+}
+
+// drainServices takes the local services out of rotation before the agent
+// leaves the cluster, as configured by graceful_shutdown. The services are
+// marked critical so that proxies and DNS stop returning them, and once the
+// drain delay has elapsed the drain hooks are run and the services are
+// deregistered if requested.
+//
+// The drained services are only removed from the local state and the catalog,
+// so they are restored from their definitions when the agent restarts.
+func (a *Agent) drainServices() {
+	cfg := a.config
+	if cfg.GracefulShutdownDrainDelay == 0 &&
+		len(cfg.GracefulShutdownDrainHooks) == 0 &&
+		!cfg.GracefulShutdownDeregisterServices {
+		return
+	}
+
+	services := a.State.AllServices()
+	a.logger.Info("Draining services",
+		"services", len(services),
+		"drain_delay", cfg.GracefulShutdownDrainDelay,
+	)
+
+	for sid, svc := range services {
+		checkID := serviceDrainCheckID(sid)
+		check := &structs.HealthCheck{
+			Node:           cfg.NodeName,
+			CheckID:        checkID.ID,
+			Name:           "Service Draining",
+			Notes:          "The agent is shutting down",
+			ServiceID:      svc.ID,
+			ServiceName:    svc.Service,
+			Status:         api.HealthCritical,
+			Type:           "drain",
+			EnterpriseMeta: checkID.EnterpriseMeta,
+		}
+		if err := a.State.AddCheck(check, a.State.ServiceToken(sid)); err != nil {
+			a.logger.Warn("Failed to mark service as draining", "service", sid.String(), "error", err)
+		}
+	}
+	if err := a.State.SyncChanges(); err != nil {
+		a.logger.Warn("Failed to sync draining services", "error", err)
+	}
+
+	if cfg.GracefulShutdownDrainDelay > 0 {
+		select {
+		case <-time.After(cfg.GracefulShutdownDrainDelay):
+		case <-a.shutdownCh:
+			return
+		}
+	}
+
+	for _, hook := range cfg.GracefulShutdownDrainHooks {
+		a.runDrainHook(hook)
+	}
+
+	if !cfg.GracefulShutdownDeregisterServices {
+		return
+	}
+	for sid := range services {
+		var checkIDs []structs.CheckID
+		for id := range a.State.ChecksForService(sid, false) {
+			checkIDs = append(checkIDs, id)
+		}
+		if err := a.State.RemoveServiceWithChecks(sid, checkIDs); err != nil {
+			a.logger.Warn("Failed to deregister drained service", "service", sid.String(), "error", err)
+		}
+	}
+	if err := a.State.SyncChanges(); err != nil {
+		a.logger.Warn("Failed to sync deregistered services", "error", err)
+	}
+}
+
+// runDrainHook runs a drain hook to completion, killing it once its timeout
+// has elapsed. Failures are logged since they must not prevent the agent from
+// leaving.
+func (a *Agent) runDrainHook(hook config.RuntimeDrainHook) {
+	cmd, err := exec.Subprocess(hook.Args)
+	if err != nil {
+		a.logger.Error("Failed to setup drain hook", "args", hook.Args, "error", err)
+		return
+	}
+	exec.SetSysProcAttr(cmd)
+	cmd.Env = append(os.Environ(), "CONSUL_NODE_NAME="+a.config.NodeName)
+
+	output, _ := circbuf.NewBuffer(drainHookBufSize)
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	if err := cmd.Start(); err != nil {
+		a.logger.Error("Failed to run drain hook", "args", hook.Args, "error", err)
+		return
+	}
+
+	waitCh := make(chan error, 1)
+	go func() {
+		waitCh <- cmd.Wait()
+	}()
+
+	select {
+	case <-time.After(hook.Timeout):
+		if err := exec.KillCommandSubtree(cmd); err != nil {
+			a.logger.Warn("Failed to kill drain hook after timeout", "args", hook.Args, "error", err)
+		}
+		<-waitCh
+		err = fmt.Errorf("timed out after %s", hook.Timeout)
+	case err = <-waitCh:
+	}
+
+	outputStr := string(output.Bytes())
+	if output.TotalWritten() > output.Size() {
+		outputStr = fmt.Sprintf("Captured %d of %d bytes\n...\n%s",
+			output.Size(), output.TotalWritten(), outputStr)
+	}
+	if err != nil {
+		a.logger.Error("Drain hook failed", "args", hook.Args, "error", err, "output", outputStr)
+		return
+	}
+	a.logger.Debug("Drain hook output", "args", hook.Args, "output", outputStr)
+}
@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+)
+
+func TestAgent_DrainServices(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	nodeChecks := func(t *testing.T, a *TestAgent) structs.HealthChecks {
+		args := structs.NodeSpecificRequest{
+			Datacenter: "dc1",
+			Node:       a.Config.NodeName,
+		}
+		var out structs.IndexedHealthChecks
+		require.NoError(t, a.RPC("Health.NodeChecks", &args, &out))
+		return out.HealthChecks
+	}
+	nodeServices := func(t *testing.T, a *TestAgent) []*structs.NodeService {
+		args := structs.NodeSpecificRequest{
+			Datacenter: "dc1",
+			Node:       a.Config.NodeName,
+		}
+		var out structs.IndexedNodeServiceList
+		require.NoError(t, a.RPC("Catalog.NodeServiceList", &args, &out))
+		return out.NodeServices.Services
+	}
+
+	t.Run("mark critical and run hooks", func(t *testing.T) {
+		hookOutput := filepath.Join(t.TempDir(), "hook")
+		a := NewTestAgent(t, `
+			services {
+				name = "web"
+				port = 8080
+			}
+			graceful_shutdown {
+				drain_delay = "10ms"
+				drain_hooks = [
+					{ args = ["sh", "-c", "echo -n $CONSUL_NODE_NAME > `+hookOutput+`"] }
+				]
+			}
+		`)
+		defer a.Shutdown()
+		testrpc.WaitForLeader(t, a.RPC, "dc1")
+		require.NoError(t, a.State.SyncFull())
+
+		a.drainServices()
+
+		var drain *structs.HealthCheck
+		for _, check := range nodeChecks(t, a) {
+			if check.CheckID == "_service_drain:web" {
+				drain = check
+			}
+		}
+		require.NotNil(t, drain)
+		require.Equal(t, api.HealthCritical, drain.Status)
+		require.Equal(t, "web", drain.ServiceID)
+		require.Len(t, nodeServices(t, a), 2) // web and consul
+
+		out, err := os.ReadFile(hookOutput)
+		require.NoError(t, err)
+		require.Equal(t, a.Config.NodeName, string(out))
+	})
+
+	t.Run("deregister services", func(t *testing.T) {
+		a := NewTestAgent(t, `
+			services {
+				name = "web"
+				port = 8080
+			}
+			graceful_shutdown {
+				deregister_services = true
+			}
+		`)
+		defer a.Shutdown()
+		testrpc.WaitForLeader(t, a.RPC, "dc1")
+		require.NoError(t, a.State.SyncFull())
+
+		a.drainServices()
+
+		for _, svc := range nodeServices(t, a) {
+			require.NotEqual(t, "web", svc.ID)
+		}
+		for _, check := range nodeChecks(t, a) {
+			require.NotEqual(t, "web", check.ServiceID)
+		}
+	})
+}
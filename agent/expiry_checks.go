@@ -0,0 +1,273 @@
+package agent
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/serf/serf"
+
+	cachetype "github.com/hashicorp/consul/agent/cache-types"
+	"github.com/hashicorp/consul/agent/connect"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/types"
+)
+
+const (
+	// tlsCertExpiryCheckID, caExpiryCheckID and keyringCheckID are the IDs of
+	// the checks registered on the node to report the state of the agent TLS
+	// certificate, the Connect CA and the gossip keyring.
+	tlsCertExpiryCheckID = "_agent_tls_cert_expiry"
+	caExpiryCheckID      = "_connect_ca_expiry"
+	keyringCheckID       = "_gossip_keyring"
+
+	// expiryCheckType is the type of the expiry checks.
+	expiryCheckType = "expiry"
+
+	// expiryCheckInterval is how often the expiry checks are updated.
+	expiryCheckInterval = time.Hour
+
+	// certExpiryWarning and certExpiryCritical are how long before a
+	// certificate expires its check turns warning and critical. For short
+	// lived certificates, certExpiryWarningRatio and certExpiryCriticalRatio
+	// of their lifetime is used instead if it is shorter, since they are
+	// expected to be renewed automatically.
+	certExpiryWarning       = 30 * 24 * time.Hour
+	certExpiryCritical      = 7 * 24 * time.Hour
+	certExpiryWarningRatio  = 0.2
+	certExpiryCriticalRatio = 0.1
+)
+
+// runExpiryChecks keeps the expiry checks up to date until the agent shuts
+// down.
+func (a *Agent) runExpiryChecks() {
+	ticker := time.NewTicker(expiryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		a.updateExpiryChecks()
+
+		select {
+		case <-a.shutdownCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// updateExpiryChecks registers, updates or removes each expiry check.
+func (a *Agent) updateExpiryChecks() {
+	a.setExpiryCheck(tlsCertExpiryCheckID, "Agent TLS Certificate Expiry", a.tlsCertExpiryStatus)
+	a.setExpiryCheck(caExpiryCheckID, "Connect CA Expiry", a.caExpiryStatus)
+	a.setExpiryCheck(keyringCheckID, "Gossip Keyring Rotation", a.keyringStatus)
+}
+
+// setExpiryCheck sets the status of the check to the one returned by
+// statusFn. The check is removed when statusFn returns false, and left
+// untouched when it returns an error.
+func (a *Agent) setExpiryCheck(id, name string, statusFn func() (string, string, bool, error)) {
+	checkID := structs.NewCheckID(types.CheckID(id), a.AgentEnterpriseMeta())
+	status, output, ok, err := statusFn()
+	switch {
+	case err != nil:
+		a.logger.Warn("failed to update expiry check", "check", id, "error", err)
+		return
+	case !ok:
+		if a.State.Check(checkID) != nil {
+			a.RemoveCheck(checkID, false)
+		}
+		return
+	}
+
+	if a.State.Check(checkID) != nil {
+		a.State.UpdateCheck(checkID, status, output)
+		return
+	}
+	check := &structs.HealthCheck{
+		Node:           a.config.NodeName,
+		CheckID:        types.CheckID(id),
+		Name:           name,
+		Status:         status,
+		Output:         output,
+		Type:           expiryCheckType,
+		EnterpriseMeta: *a.AgentEnterpriseMeta(),
+	}
+	if err := a.AddCheck(check, nil, false, "", ConfigSourceLocal); err != nil {
+		a.logger.Warn("failed to register expiry check", "check", id, "error", err)
+	}
+}
+
+// tlsCertExpiryStatus returns the status of the agent TLS certificate.
+func (a *Agent) tlsCertExpiryStatus() (string, string, bool, error) {
+	raw := a.tlsConfigurator.Cert()
+	if raw == nil || len(raw.Certificate) == 0 {
+		return "", "", false, nil
+	}
+	cert, err := x509.ParseCertificate(raw.Certificate[0])
+	if err != nil {
+		return "", "", false, fmt.Errorf("failed to parse agent tls cert: %w", err)
+	}
+	status, output := certExpiryStatus("Agent TLS certificate", cert.NotBefore, cert.NotAfter, time.Now())
+	return status, output, true, nil
+}
+
+// caExpiryStatus returns the status of the active Connect CA root and of the
+// intermediate used to sign leaf certificates, if any.
+func (a *Agent) caExpiryStatus() (string, string, bool, error) {
+	if !a.config.ConnectEnabled {
+		return "", "", false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	req := &structs.DCSpecificRequest{
+		Datacenter:   a.config.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: a.tokens.AgentToken()},
+	}
+	raw, _, err := a.cache.Get(ctx, cachetype.ConnectCARootName, req)
+	if err != nil {
+		return "", "", false, fmt.Errorf("failed to fetch the Connect CA roots: %w", err)
+	}
+	roots, ok := raw.(*structs.IndexedCARoots)
+	if !ok {
+		return "", "", false, fmt.Errorf("invalid type for roots response: %T", raw)
+	}
+
+	var active *structs.CARoot
+	for _, root := range roots.Roots {
+		if root.ID == roots.ActiveRootID {
+			active = root
+		}
+	}
+	if active == nil {
+		return "", "", false, nil
+	}
+
+	now := time.Now()
+	status, output := certExpiryStatus("Connect CA root certificate", active.NotBefore, active.NotAfter, now)
+	if len(active.IntermediateCerts) > 0 {
+		cert, err := connect.ParseCert(active.IntermediateCerts[len(active.IntermediateCerts)-1])
+		if err != nil {
+			return "", "", false, fmt.Errorf("failed to parse the Connect CA intermediate certificate: %w", err)
+		}
+		intStatus, intOutput := certExpiryStatus("Connect CA intermediate certificate", cert.NotBefore, cert.NotAfter, now)
+		output += "\n" + intOutput
+		if checkStatusSeverity(intStatus) > checkStatusSeverity(status) {
+			status = intStatus
+		}
+	}
+	return status, output, true, nil
+}
+
+// keyringStatus returns the status of the LAN gossip keyring. It is only
+// reported by the leader, since it queries every member of the pool.
+func (a *Agent) keyringStatus() (string, string, bool, error) {
+	if !a.config.ServerMode {
+		return "", "", false, nil
+	}
+	if a.config.EncryptKey == "" {
+		if _, err := os.Stat(filepath.Join(a.config.DataDir, SerfLANKeyring)); err != nil {
+			return "", "", false, nil
+		}
+	}
+	srv, ok := a.delegate.(interface {
+		IsLeader() bool
+		KeyManagerLAN() *serf.KeyManager
+	})
+	if !ok || !srv.IsLeader() {
+		return "", "", false, nil
+	}
+	resp, err := srv.KeyManagerLAN().ListKeys()
+	if err != nil && resp == nil {
+		return "", "", false, fmt.Errorf("failed to list the gossip keys: %w", err)
+	}
+	status, output := keyringRotationStatus(resp.Keys, resp.PrimaryKeys, resp.NumNodes)
+	return status, output, true, nil
+}
+
+// certExpiryStatus returns the status and output of the check of a
+// certificate valid from notBefore to notAfter.
+func certExpiryStatus(name string, notBefore, notAfter, now time.Time) (string, string) {
+	remaining := notAfter.Sub(now)
+	lifetime := notAfter.Sub(notBefore)
+
+	warning := certExpiryWarning
+	if d := time.Duration(float64(lifetime) * certExpiryWarningRatio); d < warning {
+		warning = d
+	}
+	critical := certExpiryCritical
+	if d := time.Duration(float64(lifetime) * certExpiryCriticalRatio); d < critical {
+		critical = d
+	}
+
+	expires := notAfter.UTC().Format(time.RFC3339)
+	switch {
+	case remaining <= 0:
+		return api.HealthCritical, fmt.Sprintf("%s expired at %s", name, expires)
+	case remaining < critical:
+		return api.HealthCritical, fmt.Sprintf("%s expires in %s, at %s", name, remaining.Round(time.Minute), expires)
+	case remaining < warning:
+		return api.HealthWarning, fmt.Sprintf("%s expires in %s, at %s", name, remaining.Round(time.Minute), expires)
+	default:
+		return api.HealthPassing, fmt.Sprintf("%s is valid until %s", name, expires)
+	}
+}
+
+// keyringRotationStatus returns the status and output of the check of a
+// gossip keyring where each key is installed on keys[key] of the numNodes
+// members, and used as primary key by primaryKeys[key] of them.
+//
+// A primary key that is not installed on every member partitions the pool
+// and is critical. Keys that are not installed everywhere or several primary
+// keys in use mean a rotation is in progress, which is a warning since using
+// such a key as primary would partition the pool.
+func keyringRotationStatus(keys, primaryKeys map[string]int, numNodes int) (string, string) {
+	var partial []string
+	for key, count := range keys {
+		if count < numNodes {
+			partial = append(partial, fmt.Sprintf("%s (%d/%d members)", redactKey(key), count, numNodes))
+		}
+	}
+	sort.Strings(partial)
+
+	for key := range primaryKeys {
+		if keys[key] < numNodes {
+			return api.HealthCritical, fmt.Sprintf("Primary gossip key %s is only installed on %d of the %d members",
+				redactKey(key), keys[key], numNodes)
+		}
+	}
+	if len(primaryKeys) > 1 {
+		return api.HealthWarning, fmt.Sprintf("%d primary gossip keys are in use, the key rotation is incomplete", len(primaryKeys))
+	}
+	if len(partial) > 0 {
+		return api.HealthWarning, "Gossip keys are not installed on every member: " + strings.Join(partial, ", ")
+	}
+	return api.HealthPassing, fmt.Sprintf("%d gossip keys are installed on the %d members", len(keys), numNodes)
+}
+
+// redactKey returns a prefix of a gossip key that identifies it without
+// disclosing it.
+func redactKey(key string) string {
+	if len(key) <= 4 {
+		return key
+	}
+	return key[:4] + "..."
+}
+
+// checkStatusSeverity orders check statuses from passing to critical.
+func checkStatusSeverity(status string) int {
+	switch status {
+	case api.HealthPassing:
+		return 0
+	case api.HealthWarning:
+		return 1
+	default:
+		return 2
+	}
+}
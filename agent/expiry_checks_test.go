@@ -0,0 +1,145 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/serf/serf"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/config"
+	"github.com/hashicorp/consul/api"
+)
+
+func TestCertExpiryStatus(t *testing.T) {
+	now := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	year := 365 * 24 * time.Hour
+
+	cases := map[string]struct {
+		notBefore time.Time
+		notAfter  time.Time
+		status    string
+		output    string
+	}{
+		"valid": {
+			notBefore: now.Add(-year),
+			notAfter:  now.Add(year),
+			status:    api.HealthPassing,
+			output:    "cert is valid until 2023-06-01T00:00:00Z",
+		},
+		"expires within a month": {
+			notBefore: now.Add(-year),
+			notAfter:  now.Add(20 * 24 * time.Hour),
+			status:    api.HealthWarning,
+			output:    "cert expires in 480h0m0s, at 2022-06-21T00:00:00Z",
+		},
+		"expires within a week": {
+			notBefore: now.Add(-year),
+			notAfter:  now.Add(48 * time.Hour),
+			status:    api.HealthCritical,
+			output:    "cert expires in 48h0m0s, at 2022-06-03T00:00:00Z",
+		},
+		"expired": {
+			notBefore: now.Add(-year),
+			notAfter:  now.Add(-time.Hour),
+			status:    api.HealthCritical,
+			output:    "cert expired at 2022-05-31T23:00:00Z",
+		},
+		"short lived": {
+			notBefore: now.Add(-24 * time.Hour),
+			notAfter:  now.Add(48 * time.Hour),
+			status:    api.HealthPassing,
+			output:    "cert is valid until 2022-06-03T00:00:00Z",
+		},
+		"short lived not renewed": {
+			notBefore: now.Add(-60 * time.Hour),
+			notAfter:  now.Add(12 * time.Hour),
+			status:    api.HealthWarning,
+			output:    "cert expires in 12h0m0s, at 2022-06-01T12:00:00Z",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			status, output := certExpiryStatus("cert", tc.notBefore, tc.notAfter, now)
+			require.Equal(t, tc.status, status)
+			require.Equal(t, tc.output, output)
+		})
+	}
+}
+
+func TestKeyringRotationStatus(t *testing.T) {
+	cases := map[string]struct {
+		keys        map[string]int
+		primaryKeys map[string]int
+		status      string
+		output      string
+	}{
+		"single key": {
+			keys:        map[string]int{"aaaaaaaa": 3},
+			primaryKeys: map[string]int{"aaaaaaaa": 3},
+			status:      api.HealthPassing,
+			output:      "1 gossip keys are installed on the 3 members",
+		},
+		"new key installed everywhere": {
+			keys:        map[string]int{"aaaaaaaa": 3, "bbbbbbbb": 3},
+			primaryKeys: map[string]int{"aaaaaaaa": 3},
+			status:      api.HealthPassing,
+			output:      "2 gossip keys are installed on the 3 members",
+		},
+		"new key partially installed": {
+			keys:        map[string]int{"aaaaaaaa": 3, "bbbbbbbb": 2},
+			primaryKeys: map[string]int{"aaaaaaaa": 3},
+			status:      api.HealthWarning,
+			output:      "Gossip keys are not installed on every member: bbbb... (2/3 members)",
+		},
+		"several primary keys": {
+			keys:        map[string]int{"aaaaaaaa": 3, "bbbbbbbb": 3},
+			primaryKeys: map[string]int{"aaaaaaaa": 1, "bbbbbbbb": 2},
+			status:      api.HealthWarning,
+			output:      "2 primary gossip keys are in use, the key rotation is incomplete",
+		},
+		"primary key partially installed": {
+			keys:        map[string]int{"aaaaaaaa": 2, "bbbbbbbb": 3},
+			primaryKeys: map[string]int{"aaaaaaaa": 2, "bbbbbbbb": 1},
+			status:      api.HealthCritical,
+			output:      "Primary gossip key aaaa... is only installed on 2 of the 3 members",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			status, output := keyringRotationStatus(tc.keys, tc.primaryKeys, 3)
+			require.Equal(t, tc.status, status)
+			require.Equal(t, tc.output, output)
+		})
+	}
+}
+
+type keyringDelegate struct {
+	delegate
+	leader bool
+}
+
+func (d keyringDelegate) IsLeader() bool {
+	return d.leader
+}
+
+func (d keyringDelegate) KeyManagerLAN() *serf.KeyManager {
+	return nil
+}
+
+func TestAgent_keyringStatus_NotLeader(t *testing.T) {
+	a := &Agent{
+		config: &config.RuntimeConfig{
+			ServerMode: true,
+			EncryptKey: "4EHVy9Tgcofte+WdAORnLg==",
+		},
+		delegate: keyringDelegate{leader: false},
+	}
+
+	// Only the leader lists the keys of every member of the pool.
+	_, _, ok, err := a.keyringStatus()
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -0,0 +1,43 @@
+package discovery
+
+import (
+	"google.golang.org/grpc"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-memdb"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto-public/pbdiscovery"
+)
+
+type Server struct {
+	Config
+}
+
+type Config struct {
+	GetStore    func() StateStore
+	Logger      hclog.Logger
+	ACLResolver ACLResolver
+}
+
+type StateStore interface {
+	AbandonCh() <-chan struct{}
+	CheckServiceNodes(memdb.WatchSet, string, *acl.EnterpriseMeta, string) (uint64, structs.CheckServiceNodes, error)
+	CheckConnectServiceNodes(memdb.WatchSet, string, *acl.EnterpriseMeta, string) (uint64, structs.CheckServiceNodes, error)
+}
+
+//go:generate mockery --name ACLResolver --inpackage
+type ACLResolver interface {
+	ResolveTokenAndDefaultMeta(string, *acl.EnterpriseMeta, *acl.AuthorizerContext) (acl.Authorizer, error)
+}
+
+func NewServer(cfg Config) *Server {
+	return &Server{cfg}
+}
+
+var _ pbdiscovery.DiscoveryServiceServer = (*Server)(nil)
+
+func (s *Server) Register(grpcServer *grpc.Server) {
+	pbdiscovery.RegisterDiscoveryServiceServer(grpcServer, s)
+}
@@ -0,0 +1,26 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/hashicorp/consul/agent/grpc/public/testutils"
+	"github.com/hashicorp/consul/proto-public/pbdiscovery"
+)
+
+func testClient(t *testing.T, server *Server) pbdiscovery.DiscoveryServiceClient {
+	t.Helper()
+
+	addr := testutils.RunTestServer(t, server)
+
+	conn, err := grpc.DialContext(context.Background(), addr.String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, conn.Close())
+	})
+
+	return pbdiscovery.NewDiscoveryServiceClient(conn)
+}
@@ -0,0 +1,189 @@
+package discovery
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/grpc/public"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/proto-public/pbdiscovery"
+)
+
+// reauthorizeInterval is how often the token of a stream is authorized again
+// when the endpoints don't change, so that the stream is closed soon after the
+// token is revoked.
+var reauthorizeInterval = 5 * time.Minute
+
+// WatchEndpoints provides a stream on which you can receive the endpoints of a
+// service. The current endpoints are sent immediately at the start of the
+// stream and the full set of endpoints is sent again whenever it changes.
+func (s *Server) WatchEndpoints(req *pbdiscovery.WatchEndpointsRequest, serverStream pbdiscovery.DiscoveryService_WatchEndpointsServer) error {
+	logger := s.Logger.Named("watch-endpoints").With("service", req.Service, "request_id", public.TraceID())
+
+	logger.Debug("starting stream")
+	defer logger.Trace("stream closed")
+
+	if req.Service == "" {
+		return status.Error(codes.InvalidArgument, "service is required")
+	}
+
+	token := public.TokenFromContext(serverStream.Context())
+	entMeta := acl.NewEnterpriseMetaWithPartition(req.Partition, req.Namespace)
+
+	var prev *pbdiscovery.WatchEndpointsResponse
+	for {
+		// The token is authorized again on every change, so that a revoked token
+		// can't be used to keep watching the service.
+		var authzContext acl.AuthorizerContext
+		authz, err := s.ACLResolver.ResolveTokenAndDefaultMeta(token, &entMeta, &authzContext)
+		if err != nil {
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+		if err := authz.ToAllowAuthorizer().ServiceReadAllowed(req.Service, &authzContext); err != nil {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+
+		store := s.GetStore()
+		ws := memdb.NewWatchSet()
+		ws.Add(store.AbandonCh())
+
+		var nodes structs.CheckServiceNodes
+		if req.Connect {
+			_, nodes, err = store.CheckConnectServiceNodes(ws, req.Service, &entMeta, structs.DefaultPeerKeyword)
+		} else {
+			_, nodes, err = store.CheckServiceNodes(ws, req.Service, &entMeta, structs.DefaultPeerKeyword)
+		}
+		if err != nil {
+			logger.Error("failed to look up the service endpoints", "error", err)
+			return status.Error(codes.Internal, "failed to look up the service endpoints")
+		}
+
+		// Only send the endpoints when they changed, as the watch set also
+		// fires for changes of the service which aren't reflected in them.
+		rsp := endpointsResponse(req, authz, nodes)
+		if prev == nil || !proto.Equal(rsp, prev) {
+			if err := serverStream.Send(rsp); err != nil {
+				logger.Error("failed to send response", "error", err)
+				return err
+			}
+			prev = rsp
+		}
+
+		ctx, cancel := context.WithTimeout(serverStream.Context(), reauthorizeInterval)
+		ws.WatchCtx(ctx)
+		cancel()
+		if serverStream.Context().Err() != nil {
+			return nil
+		}
+	}
+}
+
+func endpointsResponse(req *pbdiscovery.WatchEndpointsRequest, authz acl.Authorizer, nodes structs.CheckServiceNodes) *pbdiscovery.WatchEndpointsResponse {
+	rsp := &pbdiscovery.WatchEndpointsResponse{}
+	for _, csn := range nodes {
+		if csn.CanRead(authz) != acl.Allow || !hasTags(csn.Service.Tags, req.Tags) {
+			continue
+		}
+
+		health := endpointHealth(csn.Checks)
+		if health != pbdiscovery.Health_HEALTH_PASSING && !req.IncludeUnhealthy {
+			continue
+		}
+
+		_, addr, port := csn.BestAddress(false)
+		rsp.Endpoints = append(rsp.Endpoints, &pbdiscovery.Endpoint{
+			Id:      csn.Service.ID,
+			Node:    csn.Node.Node,
+			Address: addr,
+			Port:    uint32(port),
+			Health:  health,
+			Tags:    csn.Service.Tags,
+			Meta:    csn.Service.Meta,
+			Weight:  endpointWeight(csn.Service.Weights, health),
+		})
+	}
+
+	sort.Slice(rsp.Endpoints, func(i, j int) bool {
+		if rsp.Endpoints[i].Node == rsp.Endpoints[j].Node {
+			return rsp.Endpoints[i].Id < rsp.Endpoints[j].Id
+		}
+		return rsp.Endpoints[i].Node < rsp.Endpoints[j].Node
+	})
+	return rsp
+}
+
+// hasTags returns true if tags contains all the wanted tags, ignoring case
+// like the catalog does.
+func hasTags(tags, wanted []string) bool {
+	for _, w := range wanted {
+		found := false
+		for _, t := range tags {
+			if strings.EqualFold(t, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// endpointHealth aggregates the status of the health checks of an endpoint
+// using the following heuristic:
+//
+//	maintenance > critical > warning > passing
+func endpointHealth(checks structs.HealthChecks) pbdiscovery.Health {
+	var warning, critical, maintenance bool
+	for _, check := range checks {
+		if check.CheckID == structs.NodeMaint || strings.HasPrefix(string(check.CheckID), structs.ServiceMaintPrefix) {
+			maintenance = true
+			continue
+		}
+
+		switch check.Status {
+		case api.HealthPassing:
+		case api.HealthWarning:
+			warning = true
+		default:
+			critical = true
+		}
+	}
+
+	switch {
+	case maintenance:
+		return pbdiscovery.Health_HEALTH_MAINTENANCE
+	case critical:
+		return pbdiscovery.Health_HEALTH_CRITICAL
+	case warning:
+		return pbdiscovery.Health_HEALTH_WARNING
+	default:
+		return pbdiscovery.Health_HEALTH_PASSING
+	}
+}
+
+// endpointWeight returns the weight of an endpoint for its health. Critical
+// endpoints and endpoints in maintenance get no traffic.
+func endpointWeight(weights *structs.Weights, health pbdiscovery.Health) uint32 {
+	if weights == nil {
+		weights = &structs.Weights{Passing: 1, Warning: 1}
+	}
+	switch health {
+	case pbdiscovery.Health_HEALTH_PASSING:
+		return uint32(weights.Passing)
+	case pbdiscovery.Health_HEALTH_WARNING:
+		return uint32(weights.Warning)
+	default:
+		return 0
+	}
+}
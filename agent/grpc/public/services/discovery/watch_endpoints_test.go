@@ -0,0 +1,150 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/grpc/public"
+	"github.com/hashicorp/consul/agent/grpc/public/testutils"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/proto-public/pbdiscovery"
+	"github.com/hashicorp/consul/types"
+)
+
+const testToken = "acl-token-watch-endpoints"
+
+func testRegisterRequest(node, id, status string, tags ...string) *structs.RegisterRequest {
+	return &structs.RegisterRequest{
+		Node:    node,
+		Address: "10.0.0.1",
+		Service: &structs.NodeService{
+			ID:      id,
+			Service: "web",
+			Tags:    tags,
+			Port:    8080,
+			Weights: &structs.Weights{Passing: 3, Warning: 1},
+		},
+		Check: &structs.HealthCheck{
+			Node:      node,
+			CheckID:   types.CheckID(id + "-check"),
+			ServiceID: id,
+			Status:    status,
+		},
+	}
+}
+
+func TestWatchEndpoints(t *testing.T) {
+	store := testutils.TestStateStore(t, nil)
+	require.NoError(t, store.EnsureRegistration(1, testRegisterRequest("node-1", "web-1", api.HealthPassing, "primary")))
+	require.NoError(t, store.EnsureRegistration(2, testRegisterRequest("node-2", "web-2", api.HealthCritical, "primary")))
+	require.NoError(t, store.EnsureRegistration(3, testRegisterRequest("node-3", "web-3", api.HealthWarning)))
+
+	aclResolver := &MockACLResolver{}
+	aclResolver.On("ResolveTokenAndDefaultMeta", testToken, mock.Anything, mock.Anything).
+		Return(acl.ManageAll(), nil)
+
+	server := NewServer(Config{
+		GetStore:    func() StateStore { return store },
+		Logger:      hclog.NewNullLogger(),
+		ACLResolver: aclResolver,
+	})
+	client := testClient(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	ctx = public.ContextWithToken(ctx, testToken)
+
+	t.Run("passing only", func(t *testing.T) {
+		stream, err := client.WatchEndpoints(ctx, &pbdiscovery.WatchEndpointsRequest{Service: "web"})
+		require.NoError(t, err)
+
+		rsp, err := stream.Recv()
+		require.NoError(t, err)
+		require.Len(t, rsp.Endpoints, 1)
+		endpoint := rsp.Endpoints[0]
+		require.Equal(t, "web-1", endpoint.Id)
+		require.Equal(t, "node-1", endpoint.Node)
+		require.Equal(t, "10.0.0.1", endpoint.Address)
+		require.Equal(t, uint32(8080), endpoint.Port)
+		require.Equal(t, pbdiscovery.Health_HEALTH_PASSING, endpoint.Health)
+		require.Equal(t, uint32(3), endpoint.Weight)
+
+		// The endpoints are sent again once the critical instance recovers.
+		require.NoError(t, store.EnsureRegistration(4, testRegisterRequest("node-2", "web-2", api.HealthPassing, "primary")))
+		rsp, err = stream.Recv()
+		require.NoError(t, err)
+		require.Len(t, rsp.Endpoints, 2)
+		require.Equal(t, "web-1", rsp.Endpoints[0].Id)
+		require.Equal(t, "web-2", rsp.Endpoints[1].Id)
+	})
+
+	t.Run("unhealthy and tags", func(t *testing.T) {
+		stream, err := client.WatchEndpoints(ctx, &pbdiscovery.WatchEndpointsRequest{
+			Service:          "web",
+			IncludeUnhealthy: true,
+			Tags:             []string{"PRIMARY"},
+		})
+		require.NoError(t, err)
+
+		rsp, err := stream.Recv()
+		require.NoError(t, err)
+		require.Len(t, rsp.Endpoints, 2)
+
+		stream, err = client.WatchEndpoints(ctx, &pbdiscovery.WatchEndpointsRequest{Service: "web", IncludeUnhealthy: true})
+		require.NoError(t, err)
+
+		rsp, err = stream.Recv()
+		require.NoError(t, err)
+		require.Len(t, rsp.Endpoints, 3)
+		require.Equal(t, pbdiscovery.Health_HEALTH_WARNING, rsp.Endpoints[2].Health)
+		require.Equal(t, uint32(1), rsp.Endpoints[2].Weight)
+	})
+}
+
+func TestWatchEndpoints_ACLDenied(t *testing.T) {
+	store := testutils.TestStateStore(t, nil)
+
+	aclResolver := &MockACLResolver{}
+	aclResolver.On("ResolveTokenAndDefaultMeta", testToken, mock.Anything, mock.Anything).
+		Return(testutils.TestAuthorizerServiceRead(t, "db"), nil)
+
+	server := NewServer(Config{
+		GetStore:    func() StateStore { return store },
+		Logger:      hclog.NewNullLogger(),
+		ACLResolver: aclResolver,
+	})
+	client := testClient(t, server)
+
+	ctx := public.ContextWithToken(context.Background(), testToken)
+	stream, err := client.WatchEndpoints(ctx, &pbdiscovery.WatchEndpointsRequest{Service: "web"})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	require.Error(t, err)
+	require.Equal(t, codes.PermissionDenied.String(), status.Code(err).String())
+}
+
+func TestEndpointHealth(t *testing.T) {
+	checks := structs.HealthChecks{
+		{CheckID: "a", Status: api.HealthPassing},
+		{CheckID: "b", Status: api.HealthWarning},
+	}
+	require.Equal(t, pbdiscovery.Health_HEALTH_WARNING, endpointHealth(checks))
+
+	checks = append(checks, &structs.HealthCheck{CheckID: "c", Status: api.HealthCritical})
+	require.Equal(t, pbdiscovery.Health_HEALTH_CRITICAL, endpointHealth(checks))
+
+	checks = append(checks, &structs.HealthCheck{CheckID: structs.NodeMaint, Status: api.HealthCritical})
+	require.Equal(t, pbdiscovery.Health_HEALTH_MAINTENANCE, endpointHealth(checks))
+
+	require.Equal(t, pbdiscovery.Health_HEALTH_PASSING, endpointHealth(nil))
+}
@@ -59,7 +59,7 @@ RETRY_ONCE:
 			out.HealthChecks[i] = &clone
 		}
 	}
-	return out.HealthChecks, nil
+	return selectFields(req, out.HealthChecks)
 }
 
 func (s *HTTPHandlers) HealthNodeChecks(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
@@ -103,7 +103,7 @@ RETRY_ONCE:
 			out.HealthChecks[i] = &clone
 		}
 	}
-	return out.HealthChecks, nil
+	return selectFields(req, out.HealthChecks)
 }
 
 func (s *HTTPHandlers) HealthServiceChecks(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
@@ -149,7 +149,7 @@ RETRY_ONCE:
 			out.HealthChecks[i] = &clone
 		}
 	}
-	return out.HealthChecks, nil
+	return selectFields(req, out.HealthChecks)
 }
 
 // HealthIngressServiceNodes should return "all the healthy ingress gateway instances
@@ -196,6 +196,10 @@ func (s *HTTPHandlers) healthServiceNodes(resp http.ResponseWriter, req *http.Re
 		args.MergeCentralConfig = true
 	}
 
+	if _, ok := params["proxy-status"]; ok {
+		args.ProxyStatus = true
+	}
+
 	// Determine the prefix
 	var prefix string
 	switch healthType {
@@ -263,7 +267,7 @@ func (s *HTTPHandlers) healthServiceNodes(resp http.ResponseWriter, req *http.Re
 			out.Nodes[i].Service = &clone
 		}
 	}
-	return out.Nodes, nil
+	return selectFields(req, out.Nodes)
 }
 
 func getBoolQueryParam(params url.Values, key string) (bool, error) {
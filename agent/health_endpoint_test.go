@@ -1264,6 +1264,63 @@ func TestHealthServiceNodes_Filter(t *testing.T) {
 	require.Len(t, nodes[0].Checks, 1)
 }
 
+func TestHealthServiceNodes_Fields(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "test-health-node",
+		Address:    "127.0.0.2",
+		Service: &structs.NodeService{
+			ID:      "web",
+			Service: "web",
+			Address: "10.0.0.1",
+			Port:    8080,
+			Tags:    []string{"primary"},
+		},
+		Check: &structs.HealthCheck{
+			Node:      "test-health-node",
+			Name:      "web check",
+			ServiceID: "web",
+			Status:    api.HealthPassing,
+		},
+	}
+	var out struct{}
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+
+	req, _ := http.NewRequest("GET", "/v1/health/service/web?dc=dc1&fields="+url.QueryEscape("Service.Address,Service.Port,Checks.Status"), nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.HealthServiceNodes(resp, req)
+	require.NoError(t, err)
+	assertIndex(t, resp)
+
+	expected := []interface{}{
+		map[string]interface{}{
+			"Service": map[string]interface{}{
+				"Address": "10.0.0.1",
+				"Port":    float64(8080),
+			},
+			"Checks": []interface{}{
+				map[string]interface{}{"Status": api.HealthPassing},
+			},
+		},
+	}
+	require.Equal(t, expected, obj)
+
+	req, _ = http.NewRequest("GET", "/v1/health/service/web?dc=dc1&fields=Service..Port", nil)
+	resp = httptest.NewRecorder()
+	_, err = a.srv.HealthServiceNodes(resp, req)
+	require.Error(t, err)
+	require.True(t, isHTTPBadRequest(err))
+}
+
 func TestHealthServiceNodes_DistanceSort(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -1,8 +1,10 @@
 package agent
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"net"
 	"net/http"
@@ -78,6 +80,7 @@ func (h HTTPError) Error() string {
 type HTTPHandlers struct {
 	agent           *Agent
 	denylist        *Denylist
+	allowlist       *Allowlist
 	configReloaders []ConfigReloader
 	h               http.Handler
 	metricsProxyCfg atomic.Value
@@ -203,18 +206,11 @@ func (s *HTTPHandlers) handler(enableDebug bool) http.Handler {
 			}
 		}
 
-		var gzipHandler http.Handler
 		minSize := gziphandler.DefaultMinSize
 		if pattern == "/v1/agent/monitor" || pattern == "/v1/agent/metrics/stream" {
 			minSize = 0
 		}
-		gzipWrapper, err := gziphandler.GzipHandlerWithOpts(gziphandler.MinSize(minSize))
-		if err == nil {
-			gzipHandler = gzipWrapper(http.HandlerFunc(wrapper))
-		} else {
-			gzipHandler = gziphandler.GzipHandler(http.HandlerFunc(wrapper))
-		}
-		mux.Handle(pattern, gzipHandler)
+		mux.Handle(pattern, compressionHandler(http.HandlerFunc(wrapper), minSize))
 	}
 
 	// handlePProf takes the given pattern and pprof handler
@@ -309,6 +305,9 @@ func (s *HTTPHandlers) handler(enableDebug bool) http.Handler {
 		h = mux
 	}
 	h = s.enterpriseHandler(h)
+	if s.allowlist != nil {
+		h = s.allowlistHandler(h)
+	}
 	s.h = &wrappedMux{
 		mux:     mux,
 		handler: h,
@@ -316,6 +315,27 @@ func (s *HTTPHandlers) handler(enableDebug bool) http.Handler {
 	return s.h
 }
 
+// allowlistHandler wraps the given handler to only serve the endpoints of the
+// allowlist, which restricts the API served by some of the unix sockets.
+func (s *HTTPHandlers) allowlistHandler(h http.Handler) http.Handler {
+	httpLogger := s.agent.logger.Named(logging.HTTP)
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if !s.allowlist.Allow(req.URL.Path) {
+			errMsg := "Endpoint is not allowed on this listener"
+			httpLogger.Error("Request error",
+				"method", req.Method,
+				"url", req.URL.Path,
+				"from", req.RemoteAddr,
+				"error", errMsg,
+			)
+			resp.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(resp, errMsg)
+			return
+		}
+		h.ServeHTTP(resp, req)
+	})
+}
+
 // nodeName returns the node name of the agent
 func (s *HTTPHandlers) nodeName() string {
 	return s.agent.config.NodeName
@@ -428,10 +448,14 @@ func (s *HTTPHandlers) wrap(handler endpoint, methods []string) http.HandlerFunc
 					"error", err)
 			}
 
+			// Error messages can quote the request, make sure they don't
+			// echo any secret back.
+			body := s.agent.baseDeps.Redactor.Writer(resp)
+
 			switch {
 			case isForbidden(err):
 				resp.WriteHeader(http.StatusForbidden)
-				fmt.Fprint(resp, err.Error())
+				fmt.Fprint(body, err.Error())
 			case structs.IsErrRPCRateExceeded(err):
 				resp.WriteHeader(http.StatusTooManyRequests)
 			case isMethodNotAllowed(err):
@@ -441,7 +465,7 @@ func (s *HTTPHandlers) wrap(handler endpoint, methods []string) http.HandlerFunc
 				// https://www.w3.org/Protocols/rfc2616/rfc2616-sec10.html
 				addAllowHeader(err.(MethodNotAllowedError).Allow)
 				resp.WriteHeader(http.StatusMethodNotAllowed) // 405
-				fmt.Fprint(resp, err.Error())
+				fmt.Fprint(body, err.Error())
 			case isHTTPError(err):
 				err := err.(HTTPError)
 				code := http.StatusInternalServerError
@@ -453,13 +477,13 @@ func (s *HTTPHandlers) wrap(handler endpoint, methods []string) http.HandlerFunc
 					reason = err.Error()
 				}
 				resp.WriteHeader(code)
-				fmt.Fprint(resp, reason)
+				fmt.Fprint(body, reason)
 			case isTooManyRequests(err):
 				resp.WriteHeader(http.StatusTooManyRequests)
-				fmt.Fprint(resp, err.Error())
+				fmt.Fprint(body, err.Error())
 			default:
 				resp.WriteHeader(http.StatusInternalServerError)
-				fmt.Fprint(resp, err.Error())
+				fmt.Fprint(body, err.Error())
 			}
 		}
 
@@ -508,7 +532,11 @@ func (s *HTTPHandlers) wrap(handler endpoint, methods []string) http.HandlerFunc
 
 			if err == nil {
 				// Invoke the handler
-				obj, err = handler(resp, req)
+				if hash := req.URL.Query().Get("hash"); hash != "" && isReadMethod(req.Method) {
+					obj, err = s.hashBlockingQuery(resp, req, handler, hash)
+				} else {
+					obj, err = handler(resp, req)
+				}
 			}
 		}
 		contentType := "application/json"
@@ -544,12 +572,165 @@ func (s *HTTPHandlers) wrap(handler endpoint, methods []string) http.HandlerFunc
 				}
 			}
 		}
+		if httpCode == http.StatusOK && contentType == "application/json" {
+			// Endpoints blocking on their own content hash set it already.
+			if resp.Header().Get("X-Consul-Index") != "" && resp.Header().Get("X-Consul-ContentHash") == "" {
+				resp.Header().Set("X-Consul-ContentHash", contentHash(buf))
+			}
+			if etag := responseETag(resp, buf); etag != "" {
+				resp.Header().Set("ETag", etag)
+				if isReadMethod(req.Method) && etagMatches(req.Header.Get("If-None-Match"), etag) {
+					resp.WriteHeader(http.StatusNotModified)
+					return
+				}
+			}
+		}
 		resp.Header().Set("Content-Type", contentType)
 		resp.WriteHeader(httpCode)
 		resp.Write(buf)
 	}
 }
 
+// responseETag builds an entity tag for a read response from the Raft index
+// set by the endpoint and a hash of the encoded body. The body hash is needed
+// because the index alone doesn't capture differences in ACL filtering or
+// query parameters between two requests served at the same index. An empty
+// string is returned for endpoints which don't set X-Consul-Index.
+func responseETag(resp http.ResponseWriter, body []byte) string {
+	index := resp.Header().Get("X-Consul-Index")
+	if index == "" {
+		return ""
+	}
+	return fmt.Sprintf(`"%s-%s"`, index, contentHash(body))
+}
+
+// contentHash returns the X-Consul-ContentHash of the encoded body of a
+// response.
+func contentHash(body []byte) string {
+	h := fnv.New64a()
+	h.Write(body)
+	return fmt.Sprintf("%x", h.Sum64())
+}
+
+// hashBlockingQuery serves a read request with ?hash on an endpoint that
+// blocks on the Raft index. The handler is called again with the index of
+// its previous response until the hash of the response body differs from
+// the requested one, or until the wait time is over. Endpoints that block on
+// their own content hash, or don't block at all, don't return an index and
+// are served by the first call. Endpoints that return an index without
+// blocking on it, like prepared query executions, are served by the second
+// call, which returns the same index.
+//
+// Unlike indexes, hashes stay valid across restarts of the servers and only
+// depend on the content of the response, which makes them convenient for
+// clients that persist their state and for intermediary caches.
+func (s *HTTPHandlers) hashBlockingQuery(resp http.ResponseWriter, req *http.Request, handler endpoint, hash string) (interface{}, error) {
+	wait := s.agent.config.DefaultQueryTime
+	if v := req.URL.Query().Get("wait"); v != "" {
+		dur, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: "Invalid wait time"}
+		}
+		wait = dur
+	}
+	if maxWait := s.agent.config.MaxQueryTime; maxWait > 0 && wait > maxWait {
+		wait = maxWait
+	}
+	deadline := time.Now().Add(wait)
+
+	attempt := req
+	var lastIndex string
+	for {
+		w := newBufferedResponseWriter(resp.Header())
+		obj, err := handler(w, attempt)
+
+		index := w.Header().Get("X-Consul-Index")
+		done := err != nil || obj == nil || w.code != 0 || index == "" || index == lastIndex
+		if !done {
+			buf, err := s.marshalJSON(req, obj)
+			if err != nil {
+				return nil, err
+			}
+			done = contentHash(buf) != hash
+		}
+
+		remaining := time.Until(deadline)
+		if done || remaining <= 0 || req.Context().Err() != nil {
+			w.replay(resp)
+			return obj, err
+		}
+
+		// The content is the same, block until the index changes.
+		query := req.URL.Query()
+		query.Set("index", index)
+		query.Set("wait", remaining.String())
+		attempt = req.Clone(req.Context())
+		attempt.URL.RawQuery = query.Encode()
+		lastIndex = index
+	}
+}
+
+// bufferedResponseWriter keeps what a handler writes so that it is only sent
+// if the response is returned to the client.
+type bufferedResponseWriter struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func newBufferedResponseWriter(header http.Header) *bufferedResponseWriter {
+	return &bufferedResponseWriter{header: header.Clone()}
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferedResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+// replay writes the buffered response to resp.
+func (w *bufferedResponseWriter) replay(resp http.ResponseWriter) {
+	for k, v := range w.header {
+		resp.Header()[k] = v
+	}
+	if w.code != 0 {
+		resp.WriteHeader(w.code)
+	}
+	if w.body.Len() > 0 {
+		resp.Write(w.body.Bytes())
+	}
+}
+
+// etagMatches reports whether the given If-None-Match header value matches
+// etag, using the weak comparison defined in RFC 7232.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
 // marshalJSON marshals the object into JSON, respecting the user's pretty-ness
 // configuration.
 func (s *HTTPHandlers) marshalJSON(req *http.Request, obj interface{}) ([]byte, error) {
@@ -743,9 +924,29 @@ func setMeta(resp http.ResponseWriter, m *structs.QueryMeta) error {
 	setConsistency(resp, m.GetConsistencyLevel())
 	setQueryBackend(resp, m.GetBackend())
 	setResultsFilteredByACLs(resp, m.GetResultsFilteredByACLs())
+	setIndexLag(resp, m.IndexLag)
+	setNextToken(resp, m.NextToken)
 	return nil
 }
 
+// setNextToken sets an HTTP response header with the token to pass as
+// ?next_token to get the next page of results. It is omitted on the last
+// page.
+func setNextToken(resp http.ResponseWriter, token string) {
+	if token != "" {
+		resp.Header().Set("X-Consul-Next-Token", token)
+	}
+}
+
+// setIndexLag sets an HTTP response header with the number of entries of the
+// leader's Raft log the server answering the query had not applied yet. It is omitted
+// when the server had applied everything, or when the leader answered.
+func setIndexLag(resp http.ResponseWriter, lag uint64) {
+	if lag > 0 {
+		resp.Header().Set("X-Consul-Index-Lag", strconv.FormatUint(lag, 10))
+	}
+}
+
 func setQueryBackend(resp http.ResponseWriter, backend structs.QueryBackend) {
 	if b := backend.String(); b != "" {
 		resp.Header().Set("X-Consul-Query-Backend", b)
@@ -898,6 +1099,27 @@ func (s *HTTPHandlers) parseConsistency(resp http.ResponseWriter, req *http.Requ
 		b.SetUseCache(true)
 		defaults = false
 	}
+	if values, ok := query["bounded_stale"]; ok {
+		bounded, ok := b.(interface{ SetMaxIndexLag(uint64) })
+		if !ok {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(resp, "The bounded_stale consistency mode is not supported by this endpoint.")
+			return true
+		}
+		maxLag := uint64(s.agent.config.BoundedStaleMaxLag)
+		if values[0] != "" {
+			lag, err := strconv.ParseUint(values[0], 10, 64)
+			if err != nil || lag == 0 {
+				resp.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(resp, "Invalid bounded_stale value %q", values[0])
+				return true
+			}
+			maxLag = lag
+		}
+		b.SetAllowStale(true)
+		bounded.SetMaxIndexLag(maxLag)
+		defaults = false
+	}
 	if maxStale := query.Get("max_stale"); maxStale != "" {
 		dur, err := time.ParseDuration(maxStale)
 		if err != nil {
@@ -1067,17 +1289,26 @@ func (s *HTTPHandlers) parsePeerName(req *http.Request, args *structs.ServiceSpe
 }
 
 // parseMetaFilter is used to parse the ?node-meta=key:value query parameter, used for
-// filtering results to nodes with the given metadata key/value
+// filtering results to nodes with the given metadata key/value. The ?segment=name
+// query parameter is a shorthand for filtering on the node's network segment; an
+// empty name selects the nodes in the default segment.
 func (s *HTTPHandlers) parseMetaFilter(req *http.Request) map[string]string {
-	if filterList, ok := req.URL.Query()["node-meta"]; ok {
-		filters := make(map[string]string)
+	query := req.URL.Query()
+	var filters map[string]string
+	if filterList, ok := query["node-meta"]; ok {
+		filters = make(map[string]string)
 		for _, filter := range filterList {
 			key, value := parseMetaPair(filter)
 			filters[key] = value
 		}
-		return filters
 	}
-	return nil
+	if segment, ok := query["segment"]; ok {
+		if filters == nil {
+			filters = make(map[string]string)
+		}
+		filters[structs.MetaSegmentKey] = segment[0]
+	}
+	return filters
 }
 
 func parseMetaPair(raw string) (string, string) {
@@ -1139,6 +1370,26 @@ func (s *HTTPHandlers) parseFilter(req *http.Request, filter *string) {
 	}
 }
 
+// parsePagination is used to parse the ?per_page and ?next_token query
+// params of the list endpoints that support pagination.
+func parsePagination(req *http.Request, b *structs.QueryOptions) error {
+	query := req.URL.Query()
+	if perPage := query.Get("per_page"); perPage != "" {
+		n, err := strconv.Atoi(perPage)
+		if err != nil || n <= 0 {
+			return HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Invalid per_page value %q", perPage)}
+		}
+		b.PerPage = n
+	}
+	if token := query.Get("next_token"); token != "" {
+		if _, err := structs.DecodePageToken(token); err != nil {
+			return HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Invalid next_token value %q", token)}
+		}
+		b.NextToken = token
+	}
+	return nil
+}
+
 func setMetaProtobuf(resp http.ResponseWriter, queryMeta *pbcommon.QueryMeta) {
 	qm := new(structs.QueryMeta)
 	pbcommon.QueryMetaToStructs(queryMeta, qm)
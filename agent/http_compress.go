@@ -0,0 +1,176 @@
+package agent
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/NYTimes/gziphandler"
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstdEncoderPool holds zstd encoders for reuse across responses, since
+// creating a new encoder allocates its window and worker state.
+var zstdEncoderPool = sync.Pool{
+	New: func() interface{} {
+		enc, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+		return enc
+	},
+}
+
+// compressionHandler wraps h so that responses are compressed with zstd or
+// gzip depending on the encodings accepted by the client. zstd is preferred
+// when the client accepts both. Responses smaller than minSize bytes are sent
+// uncompressed.
+func compressionHandler(h http.Handler, minSize int) http.Handler {
+	gzipHandler := gziphandler.GzipHandler(h)
+	if gzipWrapper, err := gziphandler.GzipHandlerWithOpts(gziphandler.MinSize(minSize)); err == nil {
+		gzipHandler = gzipWrapper(h)
+	}
+
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodHead || !acceptsEncoding(req, "zstd") {
+			gzipHandler.ServeHTTP(resp, req)
+			return
+		}
+
+		resp.Header().Add("Vary", "Accept-Encoding")
+		zw := &zstdResponseWriter{ResponseWriter: resp, minSize: minSize}
+		defer zw.Close()
+		h.ServeHTTP(zw, req)
+	})
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header of req lists the
+// given content coding with a non-zero quality value.
+func acceptsEncoding(req *http.Request, coding string) bool {
+	for _, header := range req.Header.Values("Accept-Encoding") {
+		for _, part := range strings.Split(header, ",") {
+			name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			if !strings.EqualFold(strings.TrimSpace(name), coding) {
+				continue
+			}
+			params = strings.TrimSpace(params)
+			if !strings.HasPrefix(params, "q=") {
+				return true
+			}
+			q, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64)
+			return err == nil && q > 0
+		}
+	}
+	return false
+}
+
+// zstdResponseWriter buffers the response until at least minSize bytes have
+// been written and then switches to zstd compression. Responses that finish
+// or are flushed below the threshold are written uncompressed.
+type zstdResponseWriter struct {
+	http.ResponseWriter
+	minSize int
+
+	code    int
+	buf     []byte
+	decided bool
+	enc     *zstd.Encoder
+}
+
+func (w *zstdResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *zstdResponseWriter) Write(b []byte) (int, error) {
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	if w.decided {
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= w.minSize {
+		if err := w.startCompressed(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush implements http.Flusher so streaming endpoints keep working when
+// compressed.
+func (w *zstdResponseWriter) Flush() {
+	if !w.decided {
+		if len(w.buf) >= w.minSize {
+			w.startCompressed()
+		} else {
+			w.startPlain()
+		}
+	}
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close finishes the response, writing any buffered data and the final zstd
+// frame.
+func (w *zstdResponseWriter) Close() error {
+	if !w.decided {
+		return w.startPlain()
+	}
+	if w.enc == nil {
+		return nil
+	}
+	err := w.enc.Close()
+	w.enc.Reset(nil)
+	zstdEncoderPool.Put(w.enc)
+	w.enc = nil
+	return err
+}
+
+func (w *zstdResponseWriter) writeHeader() {
+	if w.code != 0 {
+		w.ResponseWriter.WriteHeader(w.code)
+	}
+}
+
+func (w *zstdResponseWriter) startPlain() error {
+	w.decided = true
+	w.writeHeader()
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+func (w *zstdResponseWriter) startCompressed() error {
+	header := w.Header()
+	// Don't double encode responses which the handler already encoded, and
+	// don't compress responses which can't have a body.
+	if header.Get("Content-Encoding") != "" || w.code == http.StatusNoContent || w.code == http.StatusNotModified {
+		return w.startPlain()
+	}
+
+	w.decided = true
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	header.Set("Content-Encoding", "zstd")
+	header.Del("Content-Length")
+	w.writeHeader()
+
+	w.enc = zstdEncoderPool.Get().(*zstd.Encoder)
+	w.enc.Reset(w.ResponseWriter)
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.enc.Write(w.buf)
+	w.buf = nil
+	return err
+}
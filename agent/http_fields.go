@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// selectFields applies the ?fields query parameter to the result of a read
+// endpoint. The parameter is a comma separated list of dotted selectors, such
+// as "Service.Address,Checks.Status", naming the JSON fields to keep in the
+// response. Selectors are applied to each element of lists, so selecting
+// "Checks.Status" on a CheckServiceNode keeps only the Status of every check.
+// Maps such as the Services of a node are JSON objects keyed by service ID, so
+// a segment selects a single key of them; the "*" segment selects every key,
+// as in "Services.*.Port". If the parameter is absent the object is returned
+// unchanged.
+func selectFields(req *http.Request, obj interface{}) (interface{}, error) {
+	paths, err := parseFields(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 || obj == nil {
+		return obj, nil
+	}
+
+	// Round trip through JSON so that the selectors match the encoded field
+	// names rather than the Go struct fields.
+	buf, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var raw interface{}
+	if err := json.Unmarshal(buf, &raw); err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return obj, nil
+	}
+	out, _ := projectFields(raw, paths)
+	return out, nil
+}
+
+// fieldsWildcard is the selector segment matching every key of an object.
+const fieldsWildcard = "*"
+
+// parseFields splits the ?fields query parameter into selector paths.
+func parseFields(req *http.Request) ([][]string, error) {
+	var paths [][]string
+	for _, value := range req.URL.Query()["fields"] {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			path := strings.Split(field, ".")
+			for _, segment := range path {
+				if segment == "" {
+					return nil, HTTPError{
+						StatusCode: http.StatusBadRequest,
+						Reason:     fmt.Sprintf("Invalid field selector %q", field),
+					}
+				}
+			}
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// projectFields returns the parts of v selected by paths. The boolean result
+// is false when none of the paths matched anything in v.
+func projectFields(v interface{}, paths [][]string) (interface{}, bool) {
+	switch val := v.(type) {
+	case []interface{}:
+		out := make([]interface{}, 0, len(val))
+		for _, elem := range val {
+			projected, _ := projectFields(elem, paths)
+			out = append(out, projected)
+		}
+		return out, true
+
+	case map[string]interface{}:
+		children := make(map[string][][]string)
+		whole := make(map[string]bool)
+		for _, path := range paths {
+			if len(path) == 1 {
+				whole[path[0]] = true
+				continue
+			}
+			children[path[0]] = append(children[path[0]], path[1:])
+		}
+
+		out := make(map[string]interface{})
+		for key, child := range val {
+			if whole[key] || whole[fieldsWildcard] {
+				out[key] = child
+				continue
+			}
+			var rest [][]string
+			rest = append(rest, children[key]...)
+			rest = append(rest, children[fieldsWildcard]...)
+			if len(rest) == 0 {
+				continue
+			}
+			if child == nil {
+				out[key] = nil
+				continue
+			}
+			if projected, ok := projectFields(child, rest); ok {
+				out[key] = projected
+			}
+		}
+		return out, true
+
+	default:
+		// Selecting into a scalar value doesn't match anything.
+		return nil, false
+	}
+}
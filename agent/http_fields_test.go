@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestSelectFields(t *testing.T) {
+	nodes := structs.CheckServiceNodes{
+		{
+			Node: &structs.Node{Node: "node1", Address: "127.0.0.1"},
+			Service: &structs.NodeService{
+				ID:      "web1",
+				Service: "web",
+				Port:    8080,
+				Meta:    map[string]string{"version": "1"},
+			},
+			Checks: structs.HealthChecks{
+				{Name: "check1", Status: "passing"},
+				{Name: "check2", Status: "critical"},
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		fields   string
+		expected interface{}
+	}{
+		"no fields": {
+			fields:   "",
+			expected: nodes,
+		},
+		"nested scalar": {
+			fields: "Service.Port",
+			expected: []interface{}{
+				map[string]interface{}{
+					"Service": map[string]interface{}{"Port": float64(8080)},
+				},
+			},
+		},
+		"whole object and list elements": {
+			fields: "Service.Meta, Checks.Status",
+			expected: []interface{}{
+				map[string]interface{}{
+					"Service": map[string]interface{}{
+						"Meta": map[string]interface{}{"version": "1"},
+					},
+					"Checks": []interface{}{
+						map[string]interface{}{"Status": "passing"},
+						map[string]interface{}{"Status": "critical"},
+					},
+				},
+			},
+		},
+		"unknown fields are omitted": {
+			fields: "Node.Node,Node.Nope,Service.Port.Nope",
+			expected: []interface{}{
+				map[string]interface{}{
+					"Node":    map[string]interface{}{"Node": "node1"},
+					"Service": map[string]interface{}{},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/v1/health/service/web?fields="+url.QueryEscape(tc.fields), nil)
+			out, err := selectFields(req, nodes)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, out)
+		})
+	}
+
+	t.Run("map keys", func(t *testing.T) {
+		ns := &structs.NodeServices{
+			Node: &structs.Node{Node: "node1"},
+			Services: map[string]*structs.NodeService{
+				"web1": {ID: "web1", Service: "web", Port: 8080},
+				"web2": {ID: "web2", Service: "web", Port: 8081},
+			},
+		}
+
+		req, _ := http.NewRequest("GET", "/v1/catalog/node/node1?fields=Services.web1.Port", nil)
+		out, err := selectFields(req, ns)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			"Services": map[string]interface{}{
+				"web1": map[string]interface{}{"Port": float64(8080)},
+			},
+		}, out)
+
+		req, _ = http.NewRequest("GET", "/v1/catalog/node/node1?fields=Services.*.Port", nil)
+		out, err = selectFields(req, ns)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			"Services": map[string]interface{}{
+				"web1": map[string]interface{}{"Port": float64(8080)},
+				"web2": map[string]interface{}{"Port": float64(8081)},
+			},
+		}, out)
+	})
+
+	t.Run("invalid selector", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/health/service/web?fields=.Port", nil)
+		_, err := selectFields(req, nodes)
+		require.True(t, isHTTPBadRequest(err))
+	})
+
+	t.Run("nil result", func(t *testing.T) {
+		var ns *structs.NodeServices
+		req, _ := http.NewRequest("GET", "/v1/catalog/node/foo?fields=Node", nil)
+		out, err := selectFields(req, ns)
+		require.NoError(t, err)
+		require.Equal(t, ns, out)
+	})
+}
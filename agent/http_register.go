@@ -9,6 +9,7 @@ func init() {
 	registerEndpoint("/v1/acl/policy", []string{"PUT"}, (*HTTPHandlers).ACLPolicyCreate)
 	registerEndpoint("/v1/acl/policy/", []string{"GET", "PUT", "DELETE"}, (*HTTPHandlers).ACLPolicyCRUD)
 	registerEndpoint("/v1/acl/policy/name/", []string{"GET"}, (*HTTPHandlers).ACLPolicyReadByName)
+	registerEndpoint("/v1/acl/policy/evaluate", []string{"POST"}, (*HTTPHandlers).ACLPolicyEvaluate)
 	registerEndpoint("/v1/acl/roles", []string{"GET"}, (*HTTPHandlers).ACLRoleList)
 	registerEndpoint("/v1/acl/role", []string{"PUT"}, (*HTTPHandlers).ACLRoleCreate)
 	registerEndpoint("/v1/acl/role/name/", []string{"GET"}, (*HTTPHandlers).ACLRoleReadByName)
@@ -26,9 +27,14 @@ func init() {
 	registerEndpoint("/v1/agent/token/", []string{"PUT"}, (*HTTPHandlers).AgentToken)
 	registerEndpoint("/v1/agent/self", []string{"GET"}, (*HTTPHandlers).AgentSelf)
 	registerEndpoint("/v1/agent/host", []string{"GET"}, (*HTTPHandlers).AgentHost)
+	registerEndpoint("/v1/agent/ready", []string{"GET"}, (*HTTPHandlers).AgentReady)
+	registerEndpoint("/v1/agent/live", []string{"GET"}, (*HTTPHandlers).AgentLive)
+	registerEndpoint("/v1/agent/cache", []string{"GET"}, (*HTTPHandlers).AgentCache)
+	registerEndpoint("/v1/agent/debug/proxies", []string{"GET"}, (*HTTPHandlers).AgentDebugProxies)
 	registerEndpoint("/v1/agent/maintenance", []string{"PUT"}, (*HTTPHandlers).AgentNodeMaintenance)
 	registerEndpoint("/v1/agent/reload", []string{"PUT"}, (*HTTPHandlers).AgentReload)
 	registerEndpoint("/v1/agent/monitor", []string{"GET"}, (*HTTPHandlers).AgentMonitor)
+	registerEndpoint("/v1/agent/loglevel", []string{"GET", "PUT"}, (*HTTPHandlers).AgentLogLevel)
 	registerEndpoint("/v1/agent/metrics", []string{"GET"}, (*HTTPHandlers).AgentMetrics)
 	registerEndpoint("/v1/agent/metrics/stream", []string{"GET"}, (*HTTPHandlers).AgentMetricsStream)
 	registerEndpoint("/v1/agent/services", []string{"GET"}, (*HTTPHandlers).AgentServices)
@@ -49,9 +55,12 @@ func init() {
 	registerEndpoint("/v1/agent/connect/authorize", []string{"POST"}, (*HTTPHandlers).AgentConnectAuthorize)
 	registerEndpoint("/v1/agent/connect/ca/roots", []string{"GET"}, (*HTTPHandlers).AgentConnectCARoots)
 	registerEndpoint("/v1/agent/connect/ca/leaf/", []string{"GET"}, (*HTTPHandlers).AgentConnectCALeafCert)
+	registerEndpoint("/v1/agent/connect/routing/", []string{"GET"}, (*HTTPHandlers).AgentConnectProxyRouting)
 	registerEndpoint("/v1/agent/service/register", []string{"PUT"}, (*HTTPHandlers).AgentRegisterService)
 	registerEndpoint("/v1/agent/service/deregister/", []string{"PUT"}, (*HTTPHandlers).AgentDeregisterService)
+	registerEndpoint("/v1/agent/service/batch", []string{"PUT"}, (*HTTPHandlers).AgentServiceBatch)
 	registerEndpoint("/v1/agent/service/maintenance/", []string{"PUT"}, (*HTTPHandlers).AgentServiceMaintenance)
+	registerEndpoint("/v1/agent/service/detected-protocol/", []string{"PUT"}, (*HTTPHandlers).AgentServiceDetectedProtocol)
 	registerEndpoint("/v1/catalog/register", []string{"PUT"}, (*HTTPHandlers).CatalogRegister)
 	registerEndpoint("/v1/catalog/connect/", []string{"GET"}, (*HTTPHandlers).CatalogConnectServiceNodes)
 	registerEndpoint("/v1/catalog/deregister", []string{"PUT"}, (*HTTPHandlers).CatalogDeregister)
@@ -64,11 +73,17 @@ func init() {
 	registerEndpoint("/v1/catalog/gateway-services/", []string{"GET"}, (*HTTPHandlers).CatalogGatewayServices)
 	registerEndpoint("/v1/config/", []string{"GET", "DELETE"}, (*HTTPHandlers).Config)
 	registerEndpoint("/v1/config", []string{"PUT"}, (*HTTPHandlers).ConfigApply)
+	registerEndpoint("/v1/config/resolved-service-config/", []string{"GET"}, (*HTTPHandlers).ConfigResolvedServiceConfig)
+	registerEndpoint("/v1/connect/ca/certificates", []string{"GET"}, (*HTTPHandlers).ConnectCACertificates)
 	registerEndpoint("/v1/connect/ca/configuration", []string{"GET", "PUT"}, (*HTTPHandlers).ConnectCAConfiguration)
 	registerEndpoint("/v1/connect/ca/roots", []string{"GET"}, (*HTTPHandlers).ConnectCARoots)
+	registerEndpoint("/v1/connect/ca/trust-domain", []string{"GET"}, (*HTTPHandlers).ConnectCATrustDomain)
 	registerEndpoint("/v1/connect/intentions", []string{"GET", "POST"}, (*HTTPHandlers).IntentionEndpoint) // POST is deprecated
 	registerEndpoint("/v1/connect/intentions/match", []string{"GET"}, (*HTTPHandlers).IntentionMatch)
 	registerEndpoint("/v1/connect/intentions/check", []string{"GET"}, (*HTTPHandlers).IntentionCheck)
+	registerEndpoint("/v1/connect/intentions/export", []string{"GET"}, (*HTTPHandlers).IntentionExport)
+	registerEndpoint("/v1/connect/intentions/simulate", []string{"POST"}, (*HTTPHandlers).IntentionSimulate)
+	registerEndpoint("/v1/connect/intentions/bulk", []string{"PUT"}, (*HTTPHandlers).IntentionBulkApply)
 	registerEndpoint("/v1/connect/intentions/exact", []string{"GET", "PUT", "DELETE"}, (*HTTPHandlers).IntentionExact)
 	registerEndpoint("/v1/connect/intentions/", []string{"GET", "PUT", "DELETE"}, (*HTTPHandlers).IntentionSpecific) // deprecated
 	registerEndpoint("/v1/coordinate/datacenters", []string{"GET"}, (*HTTPHandlers).CoordinateDatacenters)
@@ -95,14 +110,22 @@ func init() {
 	registerEndpoint("/v1/internal/ui/gateway-services-nodes/", []string{"GET"}, (*HTTPHandlers).UIGatewayServicesNodes)
 	registerEndpoint("/v1/internal/ui/gateway-intentions/", []string{"GET"}, (*HTTPHandlers).UIGatewayIntentions)
 	registerEndpoint("/v1/internal/ui/service-topology/", []string{"GET"}, (*HTTPHandlers).UIServiceTopology)
+	registerEndpoint("/v1/internal/ui/service-metrics/", []string{"GET"}, (*HTTPHandlers).UIServiceMetrics)
 	registerEndpoint("/v1/internal/acl/authorize", []string{"POST"}, (*HTTPHandlers).ACLAuthorize)
 	registerEndpoint("/v1/kv/", []string{"GET", "PUT", "DELETE"}, (*HTTPHandlers).KVSEndpoint)
 	registerEndpoint("/v1/operator/raft/configuration", []string{"GET"}, (*HTTPHandlers).OperatorRaftConfiguration)
+	registerEndpoint("/v1/operator/raft/entries", []string{"GET"}, (*HTTPHandlers).OperatorRaftEntries)
+	registerEndpoint("/v1/operator/raft/compaction", []string{"GET", "PUT"}, (*HTTPHandlers).OperatorRaftCompaction)
+	registerEndpoint("/v1/operator/raft/logs", []string{"GET"}, (*HTTPHandlers).OperatorRaftLogs)
+	registerEndpoint("/v1/operator/raft/logs/replay", []string{"GET"}, (*HTTPHandlers).OperatorRaftLogsReplay)
 	registerEndpoint("/v1/operator/raft/peer", []string{"DELETE"}, (*HTTPHandlers).OperatorRaftPeer)
+	registerEndpoint("/v1/operator/raft/transfer-leader", []string{"PUT"}, (*HTTPHandlers).OperatorRaftTransferLeader)
 	registerEndpoint("/v1/operator/keyring", []string{"GET", "POST", "PUT", "DELETE"}, (*HTTPHandlers).OperatorKeyringEndpoint)
 	registerEndpoint("/v1/operator/autopilot/configuration", []string{"GET", "PUT"}, (*HTTPHandlers).OperatorAutopilotConfiguration)
 	registerEndpoint("/v1/operator/autopilot/health", []string{"GET"}, (*HTTPHandlers).OperatorServerHealth)
 	registerEndpoint("/v1/operator/autopilot/state", []string{"GET"}, (*HTTPHandlers).OperatorAutopilotState)
+	registerEndpoint("/v1/operator/usage", []string{"GET"}, (*HTTPHandlers).OperatorUsage)
+	registerEndpoint("/v1/operator/deregistrations", []string{"GET"}, (*HTTPHandlers).OperatorDeregistrations)
 	registerEndpoint("/v1/peering/token", []string{"POST"}, (*HTTPHandlers).PeeringGenerateToken)
 	registerEndpoint("/v1/peering/establish", []string{"POST"}, (*HTTPHandlers).PeeringEstablish)
 	registerEndpoint("/v1/peering/", []string{"GET", "DELETE"}, (*HTTPHandlers).PeeringEndpoint)
@@ -120,6 +143,8 @@ func init() {
 	registerEndpoint("/v1/status/leader", []string{"GET"}, (*HTTPHandlers).StatusLeader)
 	registerEndpoint("/v1/status/peers", []string{"GET"}, (*HTTPHandlers).StatusPeers)
 	registerEndpoint("/v1/snapshot", []string{"GET", "PUT"}, (*HTTPHandlers).Snapshot)
+	registerEndpoint("/v1/traffic/shift", []string{"PUT"}, (*HTTPHandlers).TrafficShiftApply)
+	registerEndpoint("/v1/traffic/shift/", []string{"GET", "DELETE"}, (*HTTPHandlers).TrafficShiftSpecific)
 	registerEndpoint("/v1/txn", []string{"PUT"}, (*HTTPHandlers).Txn)
 
 	// Deprecated ACL endpoints, they do nothing but return an error
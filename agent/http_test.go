@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -22,6 +23,7 @@ import (
 	"github.com/NYTimes/gziphandler"
 	cleanhttp "github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/go-hclog"
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/http2"
@@ -30,6 +32,8 @@ import (
 	"github.com/hashicorp/consul/agent/structs"
 	tokenStore "github.com/hashicorp/consul/agent/token"
 	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/logging"
+	"github.com/hashicorp/consul/proto/pbcommon"
 	"github.com/hashicorp/consul/sdk/testutil"
 	"github.com/hashicorp/consul/sdk/testutil/retry"
 	"github.com/hashicorp/consul/testrpc"
@@ -97,6 +101,78 @@ func TestHTTPServer_UnixSocket(t *testing.T) {
 	}
 }
 
+func TestHTTPServer_UnixSocket_AllowEndpoints(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.SkipNow()
+	}
+
+	tempDir := testutil.TempDir(t, "consul")
+	operators := filepath.Join(tempDir, "operators.sock")
+	workloads := filepath.Join(tempDir, "workloads.sock")
+
+	a := NewTestAgent(t, `
+		unix_sockets {
+			mode = "0700"
+		}
+		http_config {
+			unix_sockets = [
+				{
+					path = "`+operators+`"
+				},
+				{
+					path = "`+workloads+`"
+					allow_endpoints = ["/v1/agent/service/register", "/v1/agent/service/deregister/"]
+					mode = "0777"
+				},
+			]
+		}
+	`)
+	defer a.Shutdown()
+
+	// Ensure each socket has its own mode.
+	fi, err := os.Stat(operators)
+	require.NoError(t, err)
+	require.Equal(t, "Srwx------", fi.Mode().String())
+	fi, err = os.Stat(workloads)
+	require.NoError(t, err)
+	require.Equal(t, "Srwxrwxrwx", fi.Mode().String())
+
+	clientFor := func(socket string) *http.Client {
+		trans := cleanhttp.DefaultTransport()
+		trans.DialContext = func(_ context.Context, _, _ string) (net.Conn, error) {
+			return net.Dial("unix", socket)
+		}
+		return &http.Client{Transport: trans}
+	}
+	get := func(socket, path string) int {
+		resp, err := clientFor(socket).Get("http://127.0.0.1" + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// The operators socket serves all the endpoints.
+	require.Equal(t, http.StatusOK, get(operators, "/v1/agent/self"))
+
+	// The workloads socket only serves the allowed ones.
+	require.Equal(t, http.StatusForbidden, get(workloads, "/v1/agent/self"))
+	require.Equal(t, http.StatusForbidden, get(workloads, "/v1/agent/service/register/../../self"))
+
+	body := strings.NewReader(`{"Name": "web", "Port": 8080}`)
+	req, err := http.NewRequest("PUT", "http://127.0.0.1/v1/agent/service/register", body)
+	require.NoError(t, err)
+	resp, err := clientFor(workloads).Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotNil(t, a.State.Service(structs.NewServiceID("web", nil)))
+}
+
 func TestHTTPServer_UnixSocket_FileExists(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -514,6 +590,257 @@ func TestHTTPAPI_DefaultACLPolicy(t *testing.T) {
 	}
 }
 
+func TestHTTPAPI_ETag(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+
+	body := &structs.DirEntry{Key: "key"}
+	handler := func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+		setIndex(resp, 42)
+		return body, nil
+	}
+	noIndexHandler := func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+		return body, nil
+	}
+
+	req, _ := http.NewRequest("GET", "/v1/kv/key", nil)
+	resp := httptest.NewRecorder()
+	a.srv.wrap(handler, []string{"GET"})(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+	etag := resp.Header().Get("ETag")
+	require.True(t, strings.HasPrefix(etag, `"42-`), "unexpected etag %q", etag)
+
+	t.Run("matching etag returns not modified", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/kv/key", nil)
+		req.Header.Set("If-None-Match", etag)
+		resp := httptest.NewRecorder()
+		a.srv.wrap(handler, []string{"GET"})(resp, req)
+		require.Equal(t, http.StatusNotModified, resp.Code)
+		require.Equal(t, etag, resp.Header().Get("ETag"))
+		require.Empty(t, resp.Body.Bytes())
+	})
+
+	t.Run("weak etag in list returns not modified", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/kv/key", nil)
+		req.Header.Set("If-None-Match", `"other", W/`+etag)
+		resp := httptest.NewRecorder()
+		a.srv.wrap(handler, []string{"GET"})(resp, req)
+		require.Equal(t, http.StatusNotModified, resp.Code)
+	})
+
+	t.Run("stale etag returns full response", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/kv/key", nil)
+		req.Header.Set("If-None-Match", `"41-abc"`)
+		resp := httptest.NewRecorder()
+		a.srv.wrap(handler, []string{"GET"})(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.NotEmpty(t, resp.Body.Bytes())
+	})
+
+	t.Run("changed body changes etag", func(t *testing.T) {
+		other := func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+			setIndex(resp, 42)
+			return &structs.DirEntry{Key: "other"}, nil
+		}
+		req, _ := http.NewRequest("GET", "/v1/kv/other", nil)
+		req.Header.Set("If-None-Match", etag)
+		resp := httptest.NewRecorder()
+		a.srv.wrap(other, []string{"GET"})(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.NotEqual(t, etag, resp.Header().Get("ETag"))
+	})
+
+	t.Run("endpoints without an index get no etag", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/agent/self", nil)
+		req.Header.Set("If-None-Match", "*")
+		resp := httptest.NewRecorder()
+		a.srv.wrap(noIndexHandler, []string{"GET"})(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Empty(t, resp.Header().Get("ETag"))
+	})
+
+	t.Run("writes are never short-circuited", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/v1/kv/key", nil)
+		req.Header.Set("If-None-Match", etag)
+		resp := httptest.NewRecorder()
+		a.srv.wrap(handler, []string{"PUT"})(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+	})
+}
+
+func TestHTTPAPI_HashBlockingQuery(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+
+	same := &structs.DirEntry{Key: "key"}
+	changed := &structs.DirEntry{Key: "key", Flags: 1}
+
+	req, _ := http.NewRequest("GET", "/v1/kv/key", nil)
+	resp := httptest.NewRecorder()
+	a.srv.wrap(func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+		setIndex(resp, 10)
+		return same, nil
+	}, []string{"GET"})(resp, req)
+	hash := resp.Header().Get("X-Consul-ContentHash")
+	require.NotEmpty(t, hash)
+
+	t.Run("blocks until the content changes", func(t *testing.T) {
+		var indexes []string
+		handler := func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+			indexes = append(indexes, req.URL.Query().Get("index"))
+			setIndex(resp, uint64(10+len(indexes)))
+			if len(indexes) < 3 {
+				return same, nil
+			}
+			return changed, nil
+		}
+
+		req, _ := http.NewRequest("GET", "/v1/kv/key?hash="+hash+"&wait=10s", nil)
+		resp := httptest.NewRecorder()
+		a.srv.wrap(handler, []string{"GET"})(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, []string{"", "11", "12"}, indexes)
+		require.Equal(t, "13", resp.Header().Get("X-Consul-Index"))
+		require.NotEqual(t, hash, resp.Header().Get("X-Consul-ContentHash"))
+		require.Contains(t, resp.Body.String(), `"Flags":1`)
+	})
+
+	t.Run("returns the same content after the wait time", func(t *testing.T) {
+		handler := func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+			// Block like the endpoints do when nothing changes.
+			if req.URL.Query().Get("index") != "" {
+				wait, err := time.ParseDuration(req.URL.Query().Get("wait"))
+				require.NoError(t, err)
+				time.Sleep(wait)
+			}
+			setIndex(resp, 10)
+			return same, nil
+		}
+
+		start := time.Now()
+		req, _ := http.NewRequest("GET", "/v1/kv/key?hash="+hash+"&wait=50ms", nil)
+		resp := httptest.NewRecorder()
+		a.srv.wrap(handler, []string{"GET"})(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+		require.Equal(t, hash, resp.Header().Get("X-Consul-ContentHash"))
+	})
+
+	t.Run("endpoints not blocking on the index are called twice", func(t *testing.T) {
+		var indexes []string
+		handler := func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+			indexes = append(indexes, req.URL.Query().Get("index"))
+			setIndex(resp, 10)
+			return same, nil
+		}
+
+		start := time.Now()
+		req, _ := http.NewRequest("GET", "/v1/query/web/execute?hash="+hash+"&wait=10s", nil)
+		resp := httptest.NewRecorder()
+		a.srv.wrap(handler, []string{"GET"})(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Less(t, time.Since(start), 5*time.Second)
+		require.Equal(t, []string{"", "10"}, indexes)
+		require.Equal(t, hash, resp.Header().Get("X-Consul-ContentHash"))
+	})
+
+	t.Run("returns errors and status codes immediately", func(t *testing.T) {
+		calls := 0
+		handler := func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+			calls++
+			setIndex(resp, 10)
+			resp.WriteHeader(http.StatusNotFound)
+			return nil, nil
+		}
+
+		req, _ := http.NewRequest("GET", "/v1/kv/key?hash="+hash, nil)
+		resp := httptest.NewRecorder()
+		a.srv.wrap(handler, []string{"GET"})(resp, req)
+		require.Equal(t, http.StatusNotFound, resp.Code)
+		require.Equal(t, "10", resp.Header().Get("X-Consul-Index"))
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("endpoints without an index are called once", func(t *testing.T) {
+		calls := 0
+		handler := func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+			calls++
+			require.Equal(t, hash, req.URL.Query().Get("hash"))
+			return same, nil
+		}
+
+		req, _ := http.NewRequest("GET", "/v1/agent/service/web?hash="+hash, nil)
+		resp := httptest.NewRecorder()
+		a.srv.wrap(handler, []string{"GET"})(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, 1, calls)
+		require.Empty(t, resp.Header().Get("X-Consul-ContentHash"))
+	})
+
+	t.Run("KV", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/v1/kv/hashed", bytes.NewBufferString("one"))
+		_, err := a.srv.KVSEndpoint(httptest.NewRecorder(), req)
+		require.NoError(t, err)
+
+		req, _ = http.NewRequest("GET", "/v1/kv/hashed", nil)
+		resp := httptest.NewRecorder()
+		a.srv.handler(true).ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		hash := resp.Header().Get("X-Consul-ContentHash")
+		require.NotEmpty(t, hash)
+
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			req, _ := http.NewRequest("PUT", "/v1/kv/hashed", bytes.NewBufferString("two"))
+			a.srv.KVSEndpoint(httptest.NewRecorder(), req)
+		}()
+
+		req, _ = http.NewRequest("GET", "/v1/kv/hashed?hash="+hash+"&wait=10s", nil)
+		resp = httptest.NewRecorder()
+		a.srv.handler(true).ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.NotEqual(t, hash, resp.Header().Get("X-Consul-ContentHash"))
+		require.Contains(t, resp.Body.String(), base64.StdEncoding.EncodeToString([]byte("two")))
+	})
+}
+
+func TestHTTPAPI_RedactErrors(t *testing.T) {
+	redactor, err := logging.NewRedactor(logging.Config{RedactSecrets: true})
+	require.NoError(t, err)
+
+	a := &Agent{
+		baseDeps: BaseDeps{Redactor: redactor},
+		config:   &config.RuntimeConfig{},
+		logger:   hclog.NewInterceptLogger(nil),
+	}
+	srv := &HTTPHandlers{agent: a, denylist: NewDenylist(nil)}
+
+	handler := func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+		return nil, fmt.Errorf("failed to parse token=f0a8b1c2-ee11-4a2c-8c8b-3a9d1e2f3a4b")
+	}
+	req, _ := http.NewRequest("GET", "/v1/kv/key", nil)
+	resp := httptest.NewRecorder()
+	srv.wrap(handler, []string{"GET"})(resp, req)
+	require.Equal(t, http.StatusInternalServerError, resp.Code)
+	require.Equal(t, "failed to parse token=hidden", resp.Body.String())
+
+	// Errors are returned as is when redaction is disabled.
+	a.baseDeps.Redactor = nil
+	resp = httptest.NewRecorder()
+	srv.wrap(handler, []string{"GET"})(resp, req)
+	require.Equal(t, "failed to parse token=f0a8b1c2-ee11-4a2c-8c8b-3a9d1e2f3a4b", resp.Body.String())
+}
+
 func TestHTTPAPIResponseHeaders(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -620,6 +947,59 @@ func TestAcceptEncodingGzip(t *testing.T) {
 	require.Equal(t, "gzip", resp.Header().Get("Content-Encoding"))
 }
 
+func TestAcceptEncodingZstd(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+
+	buf := bytes.NewBuffer([]byte("short"))
+	req, _ := http.NewRequest("PUT", "/v1/kv/short", buf)
+	resp := httptest.NewRecorder()
+	_, err := a.srv.KVSEndpoint(resp, req)
+	require.NoError(t, err)
+
+	long := fmt.Sprintf(fmt.Sprintf("%%0%dd", gziphandler.DefaultMinSize+1), 1)
+	buf = bytes.NewBuffer([]byte(long))
+	req, _ = http.NewRequest("PUT", "/v1/kv/long", buf)
+	resp = httptest.NewRecorder()
+	_, err = a.srv.KVSEndpoint(resp, req)
+	require.NoError(t, err)
+
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v1/kv/short?raw", nil)
+	req.Header["Accept-Encoding"] = []string{"gzip, zstd"}
+	a.srv.handler(true).ServeHTTP(resp, req)
+	require.Equal(t, 200, resp.Code)
+	require.Equal(t, "", resp.Header().Get("Content-Encoding"))
+	require.Equal(t, "short", resp.Body.String())
+
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v1/kv/long?raw", nil)
+	req.Header["Accept-Encoding"] = []string{"gzip, zstd"}
+	a.srv.handler(true).ServeHTTP(resp, req)
+	require.Equal(t, 200, resp.Code)
+	require.Equal(t, "zstd", resp.Header().Get("Content-Encoding"))
+
+	dec, err := zstd.NewReader(resp.Body)
+	require.NoError(t, err)
+	defer dec.Close()
+	body, err := ioutil.ReadAll(dec)
+	require.NoError(t, err)
+	require.Equal(t, long, string(body))
+
+	// zstd with a zero quality value is not acceptable.
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v1/kv/long?raw", nil)
+	req.Header["Accept-Encoding"] = []string{"gzip, zstd;q=0"}
+	a.srv.handler(true).ServeHTTP(resp, req)
+	require.Equal(t, 200, resp.Code)
+	require.Equal(t, "gzip", resp.Header().Get("Content-Encoding"))
+}
+
 func TestContentTypeIsJSON(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -1134,6 +1514,54 @@ func TestParseConsistency(t *testing.T) {
 	}
 }
 
+func TestParseConsistency_BoundedStale(t *testing.T) {
+	a := &Agent{config: &config.RuntimeConfig{BoundedStaleMaxLag: 100}}
+	srv := &HTTPHandlers{agent: a}
+
+	parse := func(t *testing.T, path string, b QueryOptionsCompat) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		srv.parseConsistency(resp, req, b)
+		return resp
+	}
+
+	t.Run("default lag", func(t *testing.T) {
+		var b structs.QueryOptions
+		resp := parse(t, "/v1/kv/foo?bounded_stale", &b)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.True(t, b.AllowStale)
+		require.Equal(t, uint64(100), b.MaxIndexLag)
+		require.Equal(t, "bounded_stale", b.ConsistencyLevel())
+	})
+
+	t.Run("explicit lag", func(t *testing.T) {
+		var b structs.QueryOptions
+		parse(t, "/v1/kv/foo?bounded_stale=5", &b)
+		require.True(t, b.AllowStale)
+		require.Equal(t, uint64(5), b.MaxIndexLag)
+	})
+
+	t.Run("invalid lag", func(t *testing.T) {
+		for _, lag := range []string{"0", "-1", "abc"} {
+			var b structs.QueryOptions
+			resp := parse(t, "/v1/kv/foo?bounded_stale="+lag, &b)
+			require.Equal(t, http.StatusBadRequest, resp.Code)
+		}
+	})
+
+	t.Run("conflicts with consistent", func(t *testing.T) {
+		var b structs.QueryOptions
+		resp := parse(t, "/v1/kv/foo?bounded_stale&consistent", &b)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		var b pbcommon.QueryOptions
+		resp := parse(t, "/v1/kv/foo?bounded_stale", &b)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
 // ensureConsistency check if consistency modes are correctly applied
 // if maxStale < 0 => stale, without MaxStaleDuration
 // if maxStale == 0 => no stale
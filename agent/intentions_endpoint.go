@@ -37,6 +37,9 @@ func (s *HTTPHandlers) IntentionList(resp http.ResponseWriter, req *http.Request
 	if err := s.parseEntMeta(req, &args.EnterpriseMeta); err != nil {
 		return nil, err
 	}
+	if err := parsePagination(req, &args.QueryOptions); err != nil {
+		return nil, err
+	}
 
 	var reply structs.IndexedIntentions
 	defer setMeta(resp, &reply.QueryMeta)
@@ -47,6 +50,94 @@ func (s *HTTPHandlers) IntentionList(resp http.ResponseWriter, req *http.Request
 	return reply.Intentions, nil
 }
 
+// GET /v1/connect/intentions/export
+func (s *HTTPHandlers) IntentionExport(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.IntentionListRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	if err := s.parseEntMeta(req, &args.EnterpriseMeta); err != nil {
+		return nil, err
+	}
+
+	var reply structs.IndexedIntentions
+	defer setMeta(resp, &reply.QueryMeta)
+	if err := s.agent.RPC("Intention.List", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return structs.ExportIntentions(reply.Intentions), nil
+}
+
+// POST /v1/connect/intentions/simulate
+func (s *HTTPHandlers) IntentionSimulate(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.IntentionSimulateRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	if err := decodeBody(req.Body, &args); err != nil {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Request decode failed: %v", err)}
+	}
+	for _, entry := range args.Entries {
+		if entry == nil {
+			return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: "Entries must not be null"}
+		}
+		entry.Kind = structs.ServiceIntentions
+	}
+
+	var reply structs.IntentionSimulateResponse
+	defer setMeta(resp, &reply.QueryMeta)
+	if err := s.agent.RPC("Intention.Simulate", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return &reply, nil
+}
+
+// PUT /v1/connect/intentions/bulk
+func (s *HTTPHandlers) IntentionBulkApply(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.IntentionBulkApplyRequest
+	s.parseDC(req, &args.Datacenter)
+	s.parseToken(req, &args.Token)
+	if _, ok := req.URL.Query()["preview"]; ok {
+		args.Preview = true
+	}
+
+	var raw map[string]interface{}
+	if err := decodeBodyDeprecated(req, &raw, nil); err != nil {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Request decoding failed: %v", err)}
+	}
+	if kind, _ := raw["Kind"].(string); kind == "" {
+		if kind, _ := raw["kind"].(string); kind == "" {
+			raw["Kind"] = structs.ServiceIntentions
+		}
+	}
+	entry, err := structs.DecodeConfigEntry(raw)
+	if err != nil {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Request decoding failed: %v", err)}
+	}
+	ixnEntry, ok := entry.(*structs.ServiceIntentionsConfigEntry)
+	if !ok {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Kind must be %q", structs.ServiceIntentions)}
+	}
+
+	var entMeta acl.EnterpriseMeta
+	if err := s.parseEntMetaNoWildcard(req, &entMeta); err != nil {
+		return nil, err
+	}
+	ixnEntry.EnterpriseMeta.Merge(&entMeta)
+	args.Entry = ixnEntry
+
+	var reply structs.IntentionBulkApplyResponse
+	if err := s.agent.RPC("Intention.BulkApply", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return &reply, nil
+}
+
 // IntentionCreate is used to create legacy intentions.
 // Deprecated: use IntentionPutExact.
 func (s *HTTPHandlers) IntentionCreate(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
@@ -252,6 +343,7 @@ func (s *HTTPHandlers) IntentionCheck(resp http.ResponseWriter, req *http.Reques
 	args.Check.DestinationPartition = ap
 	args.Check.DestinationNS = ns
 	args.Check.DestinationName = name
+	args.Check.DestinationPeer = q.Get("destination-peer")
 
 	var reply structs.IntentionQueryCheckResponse
 	if err := s.agent.RPC("Intention.Check", args, &reply); err != nil {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -90,6 +91,149 @@ func TestIntentionList(t *testing.T) {
 	})
 }
 
+func TestIntentionExport(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	for _, v := range []string{"*", "web"} {
+		req := structs.IntentionRequest{
+			Datacenter: "dc1",
+			Op:         structs.IntentionOpUpsert,
+			Intention:  structs.TestIntention(t),
+		}
+		req.Intention.SourceName = v
+		if v == "*" {
+			req.Intention.DestinationName = "*"
+			req.Intention.Action = structs.IntentionActionDeny
+		}
+
+		var reply string
+		require.NoError(t, a.RPC("Intention.Apply", &req, &reply))
+	}
+
+	req, err := http.NewRequest("GET", "/v1/connect/intentions/export", nil)
+	require.NoError(t, err)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.IntentionExport(resp, req)
+	require.NoError(t, err)
+
+	value := obj.(*structs.IntentionsExport)
+	require.Len(t, value.Intentions, 2)
+	require.Equal(t, "web", value.Intentions[0].SourceName)
+	require.Equal(t, structs.IntentionActionAllow, value.Intentions[0].Action)
+	require.Equal(t, "*", value.Intentions[1].SourceName)
+
+	require.Len(t, value.Resolution, 1)
+	require.Equal(t, "db", value.Resolution[0].DestinationName)
+	require.Equal(t, []string{value.Intentions[0].Key(), value.Intentions[1].Key()}, value.Resolution[0].Intentions)
+}
+
+func TestIntentionSimulate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	{
+		req := structs.IntentionRequest{
+			Datacenter: "dc1",
+			Op:         structs.IntentionOpUpsert,
+			Intention:  structs.TestIntention(t),
+		}
+		req.Intention.SourceName = "web"
+
+		var reply string
+		require.NoError(t, a.RPC("Intention.Apply", &req, &reply))
+	}
+
+	body := `{
+		"Flows": [
+			{"SourceName": "web", "DestinationName": "db"},
+			{"SourceName": "api", "DestinationName": "db", "Count": 3}
+		],
+		"Entries": [
+			{"Name": "db", "Sources": [{"Name": "api", "Action": "allow"}]}
+		],
+		"DefaultAllow": false
+	}`
+	req, err := http.NewRequest("POST", "/v1/connect/intentions/simulate", strings.NewReader(body))
+	require.NoError(t, err)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.IntentionSimulate(resp, req)
+	require.NoError(t, err)
+
+	value := obj.(*structs.IntentionSimulateResponse)
+	require.True(t, value.DefaultAllow)
+	require.False(t, value.ProposedDefaultAllow)
+	require.Equal(t, 1, value.Unchanged)
+	require.Empty(t, value.NewlyAllowed)
+	require.Len(t, value.NewlyDenied, 1)
+	require.Equal(t, "web", value.NewlyDenied[0].Flow.SourceName)
+}
+
+func TestIntentionBulkApply(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	{
+		req := structs.IntentionRequest{
+			Datacenter: "dc1",
+			Op:         structs.IntentionOpUpsert,
+			Intention:  structs.TestIntention(t),
+		}
+		req.Intention.SourceName = "web"
+		req.Intention.DestinationName = "db"
+
+		var reply string
+		require.NoError(t, a.RPC("Intention.Apply", &req, &reply))
+	}
+
+	apply := func(url string) *structs.IntentionBulkApplyResponse {
+		body := `{"Name": "db", "Sources": [{"Name": "api", "Action": "allow"}]}`
+		req, err := http.NewRequest("PUT", url, strings.NewReader(body))
+		require.NoError(t, err)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.IntentionBulkApply(resp, req)
+		require.NoError(t, err)
+		return obj.(*structs.IntentionBulkApplyResponse)
+	}
+
+	value := apply("/v1/connect/intentions/bulk?preview")
+	require.False(t, value.Applied)
+	require.Len(t, value.Created, 1)
+	require.Equal(t, "api", value.Created[0].SourceName)
+	require.Len(t, value.Deleted, 1)
+	require.Equal(t, "web", value.Deleted[0].SourceName)
+
+	value = apply("/v1/connect/intentions/bulk")
+	require.True(t, value.Applied)
+
+	req := structs.IntentionListRequest{Datacenter: "dc1"}
+	var resp structs.IndexedIntentions
+	require.NoError(t, a.RPC("Intention.List", &req, &resp))
+	require.Len(t, resp.Intentions, 1)
+	require.Equal(t, "api", resp.Intentions[0].SourceName)
+}
+
 func TestIntentionMatch(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
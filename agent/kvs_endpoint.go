@@ -65,6 +65,9 @@ func (s *HTTPHandlers) KVSGet(resp http.ResponseWriter, req *http.Request, args
 		if err := s.parseEntMeta(req, &args.EnterpriseMeta); err != nil {
 			return nil, err
 		}
+		if err := parsePagination(req, &args.QueryOptions); err != nil {
+			return nil, err
+		}
 	}
 
 	// Make the RPC
@@ -106,6 +109,9 @@ func (s *HTTPHandlers) KVSGetKeys(resp http.ResponseWriter, req *http.Request, a
 	if err := s.parseEntMeta(req, &args.EnterpriseMeta); err != nil {
 		return nil, err
 	}
+	if err := parsePagination(req, &args.QueryOptions); err != nil {
+		return nil, err
+	}
 
 	// Check for a separator, due to historic spelling error,
 	// we now are forced to check for both spellings
@@ -8,6 +8,8 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/hashicorp/consul/testrpc"
 
 	"github.com/hashicorp/consul/agent/structs"
@@ -359,6 +361,51 @@ func TestKVSEndpoint_ListKeys(t *testing.T) {
 	}
 }
 
+func TestKVSEndpoint_ListKeys_Paginated(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+
+	for _, key := range []string{"bar", "baz", "foo/sub1", "foo/sub2", "zip"} {
+		req, _ := http.NewRequest("PUT", "/v1/kv/"+key, bytes.NewBufferString("test"))
+		resp := httptest.NewRecorder()
+		_, err := a.srv.KVSEndpoint(resp, req)
+		require.NoError(t, err)
+	}
+
+	var pages [][]string
+	url := "/v1/kv/?keys&separator=/&per_page=3"
+	for {
+		req, _ := http.NewRequest("GET", url, nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		require.NoError(t, err)
+		assertIndex(t, resp)
+		pages = append(pages, obj.([]string))
+
+		token := resp.Header().Get("X-Consul-Next-Token")
+		if token == "" {
+			break
+		}
+		url = "/v1/kv/?keys&separator=/&per_page=3&next_token=" + token
+	}
+	require.Equal(t, [][]string{{"bar", "baz", "foo/"}, {"zip"}}, pages)
+
+	for _, query := range []string{"per_page=0", "per_page=abc", "next_token=bogus"} {
+		req, _ := http.NewRequest("GET", "/v1/kv/?keys&"+query, nil)
+		resp := httptest.NewRecorder()
+		_, err := a.srv.KVSEndpoint(resp, req)
+		require.Error(t, err, query)
+		httpErr, ok := err.(HTTPError)
+		require.True(t, ok, query)
+		require.Equal(t, http.StatusBadRequest, httpErr.StatusCode, query)
+	}
+}
+
 func TestKVSEndpoint_AcquireRelease(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
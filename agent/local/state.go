@@ -2,6 +2,7 @@ package local
 
 import (
 	"fmt"
+	"hash/fnv"
 	"reflect"
 	"strconv"
 	"strings"
@@ -50,20 +51,31 @@ var StateCounters = []prometheus.CounterDefinition{
 		Name: []string{"acl", "blocked", "node", "deregistration"},
 		Help: "Increments whenever a deregistration fails for a node (blocked by an ACL)",
 	},
+	{
+		Name: []string{"agent", "anti_entropy", "drift"},
+		Help: "Increments for each node info, service or check a full anti-entropy sync found out of sync with the catalog.",
+	},
 }
 
 const fullSyncReadMaxStale = 2 * time.Second
 
 // Config is the configuration for the State.
 type Config struct {
-	AdvertiseAddr       string
-	CheckUpdateInterval time.Duration
-	Datacenter          string
-	DiscardCheckOutput  bool
-	NodeID              types.NodeID
-	NodeName            string
-	Partition           string // this defaults if empty
-	TaggedAddresses     map[string]string
+	AdvertiseAddr          string
+	CheckSyncFlushInterval time.Duration
+	CheckUpdateInterval    time.Duration
+	CheckUpdateOutputHash  bool
+	CheckUpdateStatusOnly  bool
+	Datacenter             string
+	DiscardCheckOutput     bool
+	NodeID                 types.NodeID
+	NodeName               string
+	Partition              string // this defaults if empty
+	TaggedAddresses        map[string]string
+
+	// NodeIdentity is the node identity signed by the servers during
+	// auto-config, which attests the verified node metadata.
+	NodeIdentity string
 }
 
 // ServiceState describes the state of a service record.
@@ -129,6 +141,14 @@ type CheckState struct {
 	Deleted bool
 }
 
+// checkOutputHash returns the hash of the output of a check which is compared
+// to skip the syncs of unchanged outputs.
+func checkOutputHash(output string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(output))
+	return h.Sum64()
+}
+
 // Clone returns a shallow copy of the object.
 //
 // The defer timer still points to the original value and must not be modified.
@@ -194,9 +214,21 @@ type State struct {
 	checks       map[structs.CheckID]*CheckState
 	checkAliases map[structs.ServiceID]map[structs.CheckID]chan<- struct{}
 
+	// checkSyncTimer delays the sync of check updates by the configured
+	// flush interval, so that the updates of other checks made in the
+	// meantime are synced along.
+	checkSyncTimer *time.Timer
+
+	// checkSyncStopped is set once Stop is called so that no timer is
+	// started anymore.
+	checkSyncStopped bool
+
 	// metadata tracks the node metadata fields
 	metadata map[string]string
 
+	// verifiedMetadata is the node metadata attested by the node identity.
+	verifiedMetadata map[string]string
+
 	// discardCheckOutput stores whether the output of health checks
 	// is stored in the raft log.
 	discardCheckOutput atomic.Value // bool
@@ -226,6 +258,14 @@ func NewState(c Config, logger hclog.Logger, tokens *token.Store) *State {
 		agentEnterpriseMeta: *structs.NodeEnterpriseMetaInPartition(c.Partition),
 	}
 	l.SetDiscardCheckOutput(c.DiscardCheckOutput)
+	if c.NodeIdentity != "" {
+		if id, err := structs.ParseNodeIdentity(c.NodeIdentity); err != nil {
+			logger.Warn("ignoring invalid node identity", "error", err)
+			l.config.NodeIdentity = ""
+		} else {
+			l.verifiedMetadata = id.VerifiedMeta()
+		}
+	}
 	return l
 }
 
@@ -484,6 +524,41 @@ func (l *State) setServiceStateLocked(s *ServiceState) {
 	l.broadcastUpdateLocked()
 }
 
+// SetProxyStatus records the status of a connect proxy reported by the xDS
+// server in a check of the proxy service, which is synced to the catalog
+// without modifying the service itself. The status of services which aren't
+// registered locally is ignored.
+func (l *State) SetProxyStatus(id structs.ServiceID, status *structs.ProxyStatusReport) {
+	l.Lock()
+	defer l.Unlock()
+
+	s := l.services[id]
+	if s == nil || s.Deleted || s.Service.Kind != structs.ServiceKindConnectProxy {
+		return
+	}
+
+	check, err := status.HealthCheck(s.Service)
+	if err != nil {
+		l.logger.Warn("failed to encode proxy status", "service", id.String(), "error", err)
+		return
+	}
+	check.Node = l.config.NodeName
+	check.EnterpriseMeta = acl.NewEnterpriseMetaWithPartition(
+		l.agentEnterpriseMeta.PartitionOrEmpty(),
+		check.NamespaceOrEmpty(),
+	)
+	if c := l.checks[check.CompoundCheckID()]; c != nil && !c.Deleted && c.Check.IsSame(check) {
+		return
+	}
+
+	// The check is set directly rather than with addCheckLocked as its
+	// output must be kept even when the output of checks is discarded.
+	l.setCheckStateLocked(&CheckState{
+		Check: check,
+		Token: s.Token,
+	})
+}
+
 // ServiceStates returns a shallow copy of all service state records.
 // The service record still points to the original service record and
 // must not be modified.
@@ -691,13 +766,25 @@ func (l *State) UpdateCheck(id structs.CheckID, status, output string) {
 		l.checks[id] = c
 	}(c)
 
+	// Only keep a changed output locally when the servers are only updated
+	// on status changes. It is sent along with the next status change.
+	if l.config.CheckUpdateStatusOnly && c.Check.Status == status {
+		c.Check.Output = output
+		return
+	}
+
 	// Defer a sync if the output has changed. This is an optimization around
 	// frequent updates of output. Instead, we update the output internally,
 	// and periodically do a write-back to the servers. If there is a status
 	// change we do the write immediately.
 	if l.config.CheckUpdateInterval > 0 && c.Check.Status == status {
-		c.Check.Output = output
 		if c.DeferCheck == nil {
+			// When diffing hashes, remember the hash of the output the
+			// servers have so that the sync can be skipped if the output
+			// went back to it by the end of the interval.
+			hashed := l.config.CheckUpdateOutputHash && c.InSync
+			syncedHash := checkOutputHash(c.Check.Output)
+
 			d := l.config.CheckUpdateInterval
 			intv := time.Duration(uint64(d)/2) + lib.RandomStagger(d)
 			c.DeferCheck = time.AfterFunc(intv, func() {
@@ -712,10 +799,14 @@ func (l *State) UpdateCheck(id structs.CheckID, status, output string) {
 				if c.Deleted {
 					return
 				}
+				if hashed && c.InSync && checkOutputHash(c.Check.Output) == syncedHash {
+					return
+				}
 				c.InSync = false
-				l.TriggerSyncChanges()
+				l.triggerCheckSyncLocked()
 			})
 		}
+		c.Check.Output = output
 		return
 	}
 
@@ -726,7 +817,40 @@ func (l *State) UpdateCheck(id structs.CheckID, status, output string) {
 	c.Check.Status = status
 	c.Check.Output = output
 	c.InSync = false
-	l.TriggerSyncChanges()
+	l.triggerCheckSyncLocked()
+}
+
+// triggerCheckSyncLocked triggers a partial sync after a check update. With a
+// flush interval the sync is delayed, so that the checks updated in the
+// meantime are synced in the same requests.
+func (l *State) triggerCheckSyncLocked() {
+	d := l.config.CheckSyncFlushInterval
+	if d <= 0 {
+		l.TriggerSyncChanges()
+		return
+	}
+	if l.checkSyncTimer != nil || l.checkSyncStopped {
+		return
+	}
+	l.checkSyncTimer = time.AfterFunc(d, func() {
+		l.Lock()
+		defer l.Unlock()
+
+		l.checkSyncTimer = nil
+		l.TriggerSyncChanges()
+	})
+}
+
+// Stop stops the delayed sync of check updates when the agent shuts down.
+func (l *State) Stop() {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.checkSyncTimer != nil {
+		l.checkSyncTimer.Stop()
+		l.checkSyncTimer = nil
+	}
+	l.checkSyncStopped = true
 }
 
 // Check returns the locally registered check that the
@@ -1058,10 +1182,18 @@ func (l *State) updateSyncState() error {
 	l.Lock()
 	defer l.Unlock()
 
+	// Count the entries we believed to be in sync but which were changed
+	// or removed in the catalog, or only exist there.
+	var nodeDrift, serviceDrift, checkDrift int
+
 	// Check if node info needs syncing
 	if svcNode == nil || svcNode.ID != l.config.NodeID ||
 		!reflect.DeepEqual(svcNode.TaggedAddresses, l.config.TaggedAddresses) ||
-		!reflect.DeepEqual(svcNode.Meta, l.metadata) {
+		!reflect.DeepEqual(structs.WithVerifiedNodeMeta(svcNode.Meta, nil), l.metadata) ||
+		!reflect.DeepEqual(structs.VerifiedNodeMeta(svcNode.Meta), l.verifiedMetadata) {
+		if l.nodeInfoInSync {
+			nodeDrift++
+		}
 		l.nodeInfoInSync = false
 	}
 	// Check which services need syncing
@@ -1070,6 +1202,9 @@ func (l *State) updateSyncState() error {
 	// syncing so that they will be pushed to the server later
 	for id, s := range l.services {
 		if remoteServices[id] == nil {
+			if s.InSync {
+				serviceDrift++
+			}
 			s.InSync = false
 		}
 	}
@@ -1089,6 +1224,7 @@ func (l *State) updateSyncState() error {
 			// Mark a remote service that does not exist locally as deleted so
 			// that it will be removed on the server later.
 			l.services[id] = &ServiceState{Deleted: true}
+			serviceDrift++
 			continue
 		}
 
@@ -1131,7 +1267,11 @@ func (l *State) updateSyncState() error {
 		if changed {
 			ls.Service = &nextService
 		}
-		ls.InSync = ls.Service.IsSame(rs)
+		inSync := ls.Service.IsSame(rs)
+		if ls.InSync && !inSync {
+			serviceDrift++
+		}
+		ls.InSync = inSync
 	}
 
 	// Check which checks need syncing
@@ -1140,6 +1280,9 @@ func (l *State) updateSyncState() error {
 	// syncing so that they will be pushed to the server later
 	for id, c := range l.checks {
 		if remoteChecks[id] == nil {
+			if c.InSync {
+				checkDrift++
+			}
 			c.InSync = false
 		}
 	}
@@ -1158,9 +1301,16 @@ func (l *State) updateSyncState() error {
 				continue
 			}
 
+			// Maintenance window checks are registered and removed by the
+			// leader.
+			if structs.IsMaintenanceWindowCheckID(id.ID) {
+				continue
+			}
+
 			// Mark a remote check that does not exist locally as deleted so
 			// that it will be removed on the server later.
 			l.checks[id] = &CheckState{Deleted: true}
+			checkDrift++
 			continue
 		}
 
@@ -1169,12 +1319,6 @@ func (l *State) updateSyncState() error {
 			continue
 		}
 
-		// If our definition is different, we need to update it
-		if l.config.CheckUpdateInterval == 0 {
-			lc.InSync = lc.Check.IsSame(rc)
-			continue
-		}
-
 		// Copy the existing check before potentially modifying
 		// it before the compare operation.
 		lcCopy := lc.Check.Clone()
@@ -1183,6 +1327,12 @@ func (l *State) updateSyncState() error {
 		// in-memory RPCs will have side effects.
 		rcCopy := rc.Clone()
 
+		// The servers may have truncated the output to the size allowed
+		// in the catalog, which doesn't make the check out of sync.
+		if structs.IsTruncatedCheckOutput(lcCopy.Output, rcCopy.Output) {
+			rcCopy.Output = lcCopy.Output
+		}
+
 		// If there's a defer timer active then we've got a
 		// potentially spammy check so we don't sync the output
 		// during this sweep since the timer will mark the check
@@ -1190,11 +1340,25 @@ func (l *State) updateSyncState() error {
 		// output now. This is especially important for checks
 		// that don't change state after they are created, in
 		// which case we'd never see their output synced back ever.
-		if lc.DeferCheck != nil {
+		//
+		// Likewise the output doesn't matter when only status changes are
+		// sent to the servers.
+		if lc.DeferCheck != nil || l.config.CheckUpdateStatusOnly {
 			lcCopy.Output = ""
 			rcCopy.Output = ""
 		}
-		lc.InSync = lcCopy.IsSame(rcCopy)
+		inSync := lcCopy.IsSame(rcCopy)
+		if lc.InSync && !inSync {
+			checkDrift++
+		}
+		lc.InSync = inSync
+	}
+
+	for typ, drift := range map[string]int{"node": nodeDrift, "service": serviceDrift, "check": checkDrift} {
+		if drift > 0 {
+			metrics.IncrCounterWithLabels([]string{"agent", "anti_entropy", "drift"}, float32(drift),
+				[]metrics.Label{{Name: "type", Value: typ}})
+		}
 	}
 	return nil
 }
@@ -1252,29 +1416,56 @@ func (l *State) SyncChanges() error {
 		}
 	}
 
-	// Sync the checks
+	// Sync the checks. The checks with the same token are registered
+	// together, so that agents with many checks don't send one write per
+	// check.
 	// (logging happens in the helper methods)
+	batches := make(map[checkBatchKey][]structs.CheckID)
 	for id, c := range l.checks {
-		var err error
 		switch {
 		case c.Deleted:
-			err = l.deleteCheck(id)
+			if err := l.deleteCheck(id); err != nil {
+				return err
+			}
 		case !c.InSync:
 			if c.DeferCheck != nil {
 				c.DeferCheck.Stop()
 				c.DeferCheck = nil
 			}
-			err = l.syncCheck(id)
+			key := checkBatchKey{
+				partition: c.Check.PartitionOrDefault(),
+				token:     l.aclTokenForCheckSync(id, l.tokens.UserToken),
+			}
+			batches[key] = append(batches[key], id)
 		default:
 			l.logger.Debug("Check in sync", "check", id.String())
 		}
-		if err != nil {
-			return err
+	}
+	for _, ids := range batches {
+		for len(ids) > 0 {
+			n := len(ids)
+			if n > maxChecksPerSync {
+				n = maxChecksPerSync
+			}
+			if err := l.syncCheckBatch(ids[:n]); err != nil {
+				return err
+			}
+			ids = ids[n:]
 		}
 	}
 	return nil
 }
 
+// maxChecksPerSync is the maximum number of checks registered together by
+// SyncChanges, to bound the size of the resulting Raft entries.
+const maxChecksPerSync = 64
+
+// checkBatchKey groups the checks which can be registered together.
+type checkBatchKey struct {
+	partition string
+	token     string
+}
+
 // deleteService is used to delete a service from the server
 func (l *State) deleteService(key structs.ServiceID) error {
 	if key.ID == "" {
@@ -1403,6 +1594,7 @@ func (l *State) syncService(key structs.ServiceID) error {
 		Address:         l.config.AdvertiseAddr,
 		TaggedAddresses: l.config.TaggedAddresses,
 		NodeMeta:        l.metadata,
+		NodeIdentity:    l.config.NodeIdentity,
 		Service:         l.services[key].Service,
 		EnterpriseMeta:  key.EnterpriseMeta,
 		WriteRequest:    structs.WriteRequest{Token: st},
@@ -1444,6 +1636,17 @@ func (l *State) syncService(key structs.ServiceID) error {
 		metrics.IncrCounter([]string{"acl", "blocked", "service", "registration"}, 1)
 		return nil
 
+	case structs.IsErrServiceNameDenied(err):
+		// The registration policy won't allow the service until it is changed,
+		// so don't retry before the next full sync.
+		l.services[key].InSync = true
+		for _, check := range checks {
+			checkKey := structs.NewCheckID(check.CheckID, &check.EnterpriseMeta)
+			l.checks[checkKey].InSync = true
+		}
+		l.logger.Warn("Service registration denied by the registration policy", "service", key.String(), "error", err)
+		return nil
+
 	default:
 		l.logger.Warn("Syncing service failed.",
 			"service", key.String(),
@@ -1453,10 +1656,61 @@ func (l *State) syncService(key structs.ServiceID) error {
 	}
 }
 
-// syncCheck is used to sync a check to the server
-func (l *State) syncCheck(key structs.CheckID) error {
-	c := l.checks[key]
-	ct := l.aclTokenForCheckSync(key, l.tokens.UserToken)
+// syncCheckBatch is used to sync checks registered with the same token. The
+// checks of several services are first sent in a single request. If the ACLs
+// reject it, because the token can't write one of the services, the checks of
+// each service are synced on their own, so that one rejected check doesn't
+// hold back the others.
+func (l *State) syncCheckBatch(keys []structs.CheckID) error {
+	var services []structs.ServiceID
+	byService := make(map[structs.ServiceID][]structs.CheckID)
+	for _, key := range keys {
+		sid := l.checks[key].Check.CompoundServiceID()
+		if _, ok := byService[sid]; !ok {
+			services = append(services, sid)
+		}
+		byService[sid] = append(byService[sid], key)
+	}
+
+	if len(services) > 1 {
+		req := l.checksRegisterRequest(keys)
+		var out struct{}
+		err := l.Delegate.RPC("Catalog.Register", &req, &out)
+		if err == nil {
+			l.nodeInfoInSync = true
+			for _, key := range keys {
+				l.checks[key].InSync = true
+				l.logger.Info("Synced check", "check", key.String())
+			}
+			return nil
+		}
+		if !acl.IsErrPermissionDenied(err) && !acl.IsErrNotFound(err) {
+			for _, key := range keys {
+				l.logger.Warn("Syncing check failed.",
+					"check", key.String(),
+					"error", err,
+				)
+			}
+			return err
+		}
+		l.logger.Debug("Syncing the checks of several services together was blocked by ACLs, syncing them per service",
+			"checks", len(keys),
+			"error", err,
+		)
+	}
+
+	for _, sid := range services {
+		if err := l.syncChecks(byService[sid]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checksRegisterRequest returns the request registering the given checks,
+// which must have the same token.
+func (l *State) checksRegisterRequest(keys []structs.CheckID) structs.RegisterRequest {
+	first := l.checks[keys[0]].Check
 	req := structs.RegisterRequest{
 		Datacenter:      l.config.Datacenter,
 		ID:              l.config.NodeID,
@@ -1464,13 +1718,31 @@ func (l *State) syncCheck(key structs.CheckID) error {
 		Address:         l.config.AdvertiseAddr,
 		TaggedAddresses: l.config.TaggedAddresses,
 		NodeMeta:        l.metadata,
-		Check:           c.Check,
-		EnterpriseMeta:  c.Check.EnterpriseMeta,
-		WriteRequest:    structs.WriteRequest{Token: ct},
+		NodeIdentity:    l.config.NodeIdentity,
+		EnterpriseMeta:  first.EnterpriseMeta,
+		WriteRequest:    structs.WriteRequest{Token: l.aclTokenForCheckSync(keys[0], l.tokens.UserToken)},
 		SkipNodeUpdate:  l.nodeInfoInSync,
 	}
 
-	serviceKey := structs.NewServiceID(c.Check.ServiceID, &key.EnterpriseMeta)
+	// Backwards-compatibility for Consul < 0.5
+	if len(keys) == 1 {
+		req.Check = first
+	} else {
+		for _, key := range keys {
+			req.Checks = append(req.Checks, l.checks[key].Check)
+		}
+	}
+	return req
+}
+
+// syncChecks is used to sync checks of the same service, registered with the
+// same token, to the server in a single request.
+func (l *State) syncChecks(keys []structs.CheckID) error {
+	first := l.checks[keys[0]].Check
+	ct := l.aclTokenForCheckSync(keys[0], l.tokens.UserToken)
+	req := l.checksRegisterRequest(keys)
+
+	serviceKey := structs.NewServiceID(first.ServiceID, &keys[0].EnterpriseMeta)
 
 	// Pull in the associated service if any
 	s := l.services[serviceKey]
@@ -1482,27 +1754,33 @@ func (l *State) syncCheck(key structs.CheckID) error {
 	err := l.Delegate.RPC("Catalog.Register", &req, &out)
 	switch {
 	case err == nil:
-		l.checks[key].InSync = true
 		// Given how the register API works, this info is also updated
 		// every time we sync a check.
 		l.nodeInfoInSync = true
-		l.logger.Info("Synced check", "check", key.String())
+		for _, key := range keys {
+			l.checks[key].InSync = true
+			l.logger.Info("Synced check", "check", key.String())
+		}
 		return nil
 
 	case acl.IsErrPermissionDenied(err), acl.IsErrNotFound(err):
 		// todo(fs): mark the check to be in sync to prevent excessive retrying before next full sync
 		// todo(fs): some backoff strategy might be a better solution
-		l.checks[key].InSync = true
 		accessorID := l.aclAccessorID(ct)
-		l.logger.Warn("Check registration blocked by ACLs", "check", key.String(), "accessorID", accessorID)
-		metrics.IncrCounter([]string{"acl", "blocked", "check", "registration"}, 1)
+		for _, key := range keys {
+			l.checks[key].InSync = true
+			l.logger.Warn("Check registration blocked by ACLs", "check", key.String(), "accessorID", accessorID)
+		}
+		metrics.IncrCounter([]string{"acl", "blocked", "check", "registration"}, float32(len(keys)))
 		return nil
 
 	default:
-		l.logger.Warn("Syncing check failed.",
-			"check", key.String(),
-			"error", err,
-		)
+		for _, key := range keys {
+			l.logger.Warn("Syncing check failed.",
+				"check", key.String(),
+				"error", err,
+			)
+		}
 		return err
 	}
 }
@@ -1516,6 +1794,7 @@ func (l *State) syncNodeInfo() error {
 		Address:         l.config.AdvertiseAddr,
 		TaggedAddresses: l.config.TaggedAddresses,
 		NodeMeta:        l.metadata,
+		NodeIdentity:    l.config.NodeIdentity,
 		EnterpriseMeta:  l.agentEnterpriseMeta,
 		WriteRequest:    structs.WriteRequest{Token: at},
 	}
@@ -2396,18 +2396,298 @@ func TestState_SyncChanges_DuplicateAddServiceOnlySyncsOnce(t *testing.T) {
 	err := state.AddServiceWithChecks(srv, checks, tok)
 	require.NoError(t, err)
 	require.NoError(t, state.SyncChanges())
-	// 4 rpc calls, one node register, one service register, two checks
-	require.Len(t, rpc.calls, 4)
+	// 3 rpc calls, one node register, one service register, one for both checks
+	require.Len(t, rpc.calls, 3)
 
 	// adding the service again should not catalog register
 	err = state.AddServiceWithChecks(srv, checks, tok)
 	require.NoError(t, err)
 	require.NoError(t, state.SyncChanges())
+	require.Len(t, rpc.calls, 3)
+}
+
+func TestState_SetProxyStatus(t *testing.T) {
+	state := local.NewState(local.Config{}, hclog.New(nil), new(token.Store))
+	rpc := &fakeRPC{}
+	state.Delegate = rpc
+	state.TriggerSyncChanges = func() {}
+
+	proxy := &structs.NodeService{
+		Kind:    structs.ServiceKindConnectProxy,
+		ID:      "web-sidecar-proxy",
+		Service: "web-sidecar-proxy",
+		Port:    21000,
+		Proxy: structs.ConnectProxyConfig{
+			DestinationServiceName: "web",
+		},
+		EnterpriseMeta: *structs.DefaultEnterpriseMetaInDefaultPartition(),
+	}
+	web := &structs.NodeService{
+		ID:             "web",
+		Service:        "web",
+		EnterpriseMeta: *structs.DefaultEnterpriseMetaInDefaultPartition(),
+	}
+	require.NoError(t, state.AddService(proxy, ""))
+	require.NoError(t, state.AddService(web, ""))
+	require.NoError(t, state.SyncChanges())
+	// one node register, one register per service
+	require.Len(t, rpc.calls, 3)
+
+	// The status is synced in a check of the unchanged service.
+	status := &structs.ProxyStatusReport{XDSConnected: true, ConfigAccepted: true}
+	state.SetProxyStatus(proxy.CompoundServiceID(), status)
+	require.NoError(t, state.SyncChanges())
+	require.Len(t, rpc.calls, 4)
+	req := rpc.calls[3].args.(*structs.RegisterRequest)
+	require.True(t, proxy.IsSame(req.Service))
+	require.Equal(t, structs.ProxyStatusCheckID(proxy.ID), req.Check.CheckID)
+	require.Equal(t, api.HealthPassing, req.Check.Status)
+	require.Equal(t, status, structs.ProxyStatusReportFromChecks(proxy, structs.HealthChecks{req.Check}))
+
+	// The same status isn't synced again, nor is it lost when the proxy is
+	// registered again.
+	state.SetProxyStatus(proxy.CompoundServiceID(), &structs.ProxyStatusReport{XDSConnected: true, ConfigAccepted: true})
+	require.NoError(t, state.AddService(proxy, ""))
+	require.NoError(t, state.SyncChanges())
+	require.Len(t, rpc.calls, 4)
+
+	// Only the status of proxies is recorded.
+	state.SetProxyStatus(web.CompoundServiceID(), status)
+	state.SetProxyStatus(structs.NewServiceID("unknown", nil), status)
+	require.NoError(t, state.SyncChanges())
 	require.Len(t, rpc.calls, 4)
+	require.Nil(t, state.Check(structs.NewCheckID(structs.ProxyStatusCheckID(web.ID), nil)))
+}
+
+func TestState_SyncChanges_BatchesChecks(t *testing.T) {
+	state := local.NewState(local.Config{}, hclog.New(nil), new(token.Store))
+	rpc := &fakeRPC{}
+	state.Delegate = rpc
+	state.TriggerSyncChanges = func() {}
+
+	srv := &structs.NodeService{
+		Kind:           structs.ServiceKindTypical,
+		ID:             "web",
+		Service:        "web",
+		EnterpriseMeta: *structs.DefaultEnterpriseMetaInDefaultPartition(),
+	}
+	require.NoError(t, state.AddService(srv, "the-token"))
+	require.NoError(t, state.SyncChanges())
+	rpc.calls = nil
+
+	// The checks of the service are registered together, in batches.
+	for i := 0; i < 100; i++ {
+		check := &structs.HealthCheck{
+			Node:      "this-node",
+			CheckID:   types.CheckID(fmt.Sprintf("check-%d", i)),
+			ServiceID: "web",
+			Status:    api.HealthPassing,
+		}
+		require.NoError(t, state.AddCheck(check, "the-token"))
+	}
+	// A node check with another token is registered on its own.
+	require.NoError(t, state.AddCheck(&structs.HealthCheck{Node: "this-node", CheckID: "node", Status: api.HealthPassing}, "other-token"))
+
+	require.NoError(t, state.SyncChanges())
+	require.Len(t, rpc.calls, 3)
+
+	var registered int
+	for _, call := range rpc.calls {
+		require.Equal(t, "Catalog.Register", call.method)
+		req := call.args.(*structs.RegisterRequest)
+		if req.Check != nil {
+			require.Equal(t, types.CheckID("node"), req.Check.CheckID)
+			require.Equal(t, "other-token", req.Token)
+			continue
+		}
+		require.Equal(t, "web", req.Service.ID)
+		require.Equal(t, "the-token", req.Token)
+		registered += len(req.Checks)
+	}
+	require.Equal(t, 100, registered)
+
+	for id, c := range state.AllCheckStates() {
+		require.True(t, c.InSync, "check %s is not in sync", id)
+	}
+}
+
+func TestState_SyncChanges_BatchesChecksAcrossServices(t *testing.T) {
+	state := local.NewState(local.Config{}, hclog.New(nil), new(token.Store))
+	rpc := &fakeRPC{}
+	state.Delegate = rpc
+	state.TriggerSyncChanges = func() {}
+
+	var checks []*structs.HealthCheck
+	for _, name := range []string{"web", "api"} {
+		srv := &structs.NodeService{
+			ID:             name,
+			Service:        name,
+			EnterpriseMeta: *structs.DefaultEnterpriseMetaInDefaultPartition(),
+		}
+		check := &structs.HealthCheck{
+			Node:      "this-node",
+			CheckID:   types.CheckID(name + "-check"),
+			ServiceID: name,
+			Status:    api.HealthPassing,
+		}
+		require.NoError(t, state.AddServiceWithChecks(srv, []*structs.HealthCheck{check}, "the-token"))
+		checks = append(checks, check)
+	}
+	require.NoError(t, state.SyncChanges())
+	rpc.calls = nil
+
+	// The status changes of the checks of both services are sent together.
+	for _, check := range checks {
+		state.UpdateCheck(check.CompoundCheckID(), api.HealthCritical, "dependency down")
+	}
+	require.NoError(t, state.SyncChanges())
+	require.Len(t, rpc.calls, 1)
+	req := rpc.calls[0].args.(*structs.RegisterRequest)
+	require.Nil(t, req.Service)
+	require.Len(t, req.Checks, 2)
+	require.Equal(t, "the-token", req.Token)
+
+	// When the batched request is rejected, the checks of each service are
+	// synced on their own.
+	rpc.calls = nil
+	rpc.errFn = func(args interface{}) error {
+		if args.(*structs.RegisterRequest).Service == nil {
+			return acl.ErrPermissionDenied
+		}
+		return nil
+	}
+	for _, check := range checks {
+		state.UpdateCheck(check.CompoundCheckID(), api.HealthPassing, "")
+	}
+	require.NoError(t, state.SyncChanges())
+	require.Len(t, rpc.calls, 3)
+	for _, call := range rpc.calls[1:] {
+		req := call.args.(*structs.RegisterRequest)
+		require.NotNil(t, req.Service)
+		require.Equal(t, req.Service.ID, req.Check.ServiceID)
+	}
+	for id, c := range state.AllCheckStates() {
+		require.True(t, c.InSync, "check %s is not in sync", id)
+	}
+
+	// Other errors are returned rather than retried per service.
+	rpc.calls = nil
+	rpc.errFn = func(args interface{}) error {
+		return errors.New("no leader")
+	}
+	for _, check := range checks {
+		state.UpdateCheck(check.CompoundCheckID(), api.HealthCritical, "")
+	}
+	require.EqualError(t, state.SyncChanges(), "no leader")
+	require.Len(t, rpc.calls, 1)
+	for id, c := range state.AllCheckStates() {
+		require.False(t, c.InSync, "check %s is in sync", id)
+	}
+}
+
+func TestState_UpdateCheck_SyncFlushInterval(t *testing.T) {
+	state := local.NewState(local.Config{CheckSyncFlushInterval: 50 * time.Millisecond}, hclog.New(nil), new(token.Store))
+	state.Delegate = &fakeRPC{}
+	triggers := make(chan struct{}, 10)
+	state.TriggerSyncChanges = func() { triggers <- struct{}{} }
+
+	var checks []*structs.HealthCheck
+	for i := 0; i < 3; i++ {
+		check := &structs.HealthCheck{Node: "this-node", CheckID: types.CheckID(fmt.Sprintf("check-%d", i)), Status: api.HealthPassing}
+		require.NoError(t, state.AddCheck(check, ""))
+		checks = append(checks, check)
+	}
+	// Registrations are synced right away.
+	require.Len(t, triggers, 3)
+	require.NoError(t, state.SyncChanges())
+	for len(triggers) > 0 {
+		<-triggers
+	}
+
+	// The status changes made during the flush interval trigger a single sync
+	// once it has elapsed.
+	for _, check := range checks {
+		state.UpdateCheck(check.CompoundCheckID(), api.HealthCritical, "")
+	}
+	require.Len(t, triggers, 0)
+	select {
+	case <-triggers:
+	case <-time.After(time.Second):
+		t.Fatal("sync was not triggered")
+	}
+	time.Sleep(100 * time.Millisecond)
+	require.Len(t, triggers, 0)
+	for _, check := range checks {
+		require.False(t, state.CheckState(check.CompoundCheckID()).InSync)
+	}
+
+	// Once stopped, the pending sync is dropped and no other is scheduled.
+	state.UpdateCheck(checks[0].CompoundCheckID(), api.HealthPassing, "")
+	state.Stop()
+	state.UpdateCheck(checks[1].CompoundCheckID(), api.HealthPassing, "")
+	time.Sleep(100 * time.Millisecond)
+	require.Len(t, triggers, 0)
+}
+
+func TestState_UpdateCheck_StatusOnly(t *testing.T) {
+	state := local.NewState(local.Config{CheckUpdateStatusOnly: true}, hclog.New(nil), new(token.Store))
+	rpc := &fakeRPC{}
+	state.Delegate = rpc
+	state.TriggerSyncChanges = func() {}
+
+	check := &structs.HealthCheck{Node: "this-node", CheckID: "the-check", Status: api.HealthPassing}
+	require.NoError(t, state.AddCheck(check, ""))
+	require.NoError(t, state.SyncChanges())
+	rpc.calls = nil
+
+	// A change of the output alone is kept locally.
+	state.UpdateCheck(check.CompoundCheckID(), api.HealthPassing, "output 1")
+	require.True(t, state.CheckState(check.CompoundCheckID()).InSync)
+	require.Equal(t, "output 1", state.Check(check.CompoundCheckID()).Output)
+	require.NoError(t, state.SyncChanges())
+	require.Len(t, rpc.calls, 0)
+
+	// A change of the status is sent along with the latest output.
+	state.UpdateCheck(check.CompoundCheckID(), api.HealthCritical, "output 2")
+	require.False(t, state.CheckState(check.CompoundCheckID()).InSync)
+	require.NoError(t, state.SyncChanges())
+	require.Len(t, rpc.calls, 1)
+	req := rpc.calls[0].args.(*structs.RegisterRequest)
+	require.Equal(t, api.HealthCritical, req.Check.Status)
+	require.Equal(t, "output 2", req.Check.Output)
+}
+
+func TestState_UpdateCheck_OutputHash(t *testing.T) {
+	for _, hashed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("hashed=%v", hashed), func(t *testing.T) {
+			cfg := local.Config{CheckUpdateInterval: 10 * time.Millisecond, CheckUpdateOutputHash: hashed}
+			state := local.NewState(cfg, hclog.New(nil), new(token.Store))
+			state.Delegate = &fakeRPC{}
+			state.TriggerSyncChanges = func() {}
+
+			check := &structs.HealthCheck{Node: "this-node", CheckID: "the-check", Status: api.HealthPassing, Output: "a"}
+			require.NoError(t, state.AddCheck(check, ""))
+			require.NoError(t, state.SyncChanges())
+
+			// The output goes back to the one the servers have before the
+			// deferred sync.
+			state.UpdateCheck(check.CompoundCheckID(), api.HealthPassing, "b")
+			state.UpdateCheck(check.CompoundCheckID(), api.HealthPassing, "a")
+			retry.Run(t, func(r *retry.R) {
+				if state.CheckState(check.CompoundCheckID()).DeferCheck != nil {
+					r.Fatal("the sync is still deferred")
+				}
+			})
+			require.Equal(t, hashed, state.CheckState(check.CompoundCheckID()).InSync)
+		})
+	}
 }
 
 type fakeRPC struct {
 	calls []callRPC
+
+	// errFn, if set, returns the error of each call.
+	errFn func(args interface{}) error
 }
 
 type callRPC struct {
@@ -2418,6 +2698,9 @@ type callRPC struct {
 
 func (f *fakeRPC) RPC(method string, args interface{}, reply interface{}) error {
 	f.calls = append(f.calls, callRPC{method: method, args: args, reply: reply})
+	if f.errFn != nil {
+		return f.errFn(args)
+	}
 	return nil
 }
 
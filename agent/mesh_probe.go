@@ -0,0 +1,641 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/armon/go-metrics/prometheus"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/hashicorp/consul/agent/checks"
+	"github.com/hashicorp/consul/agent/connect"
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/lib/decode"
+	"github.com/hashicorp/consul/types"
+)
+
+const (
+	// meshProbeCheckType is the type of the synthetic check registered on
+	// connect proxies which opted into mesh probes when
+	// connect.enable_mesh_probes is set.
+	meshProbeCheckType = "mesh-probe"
+
+	meshProbeCheckName = "Connect Sidecar Mesh Probes"
+
+	// meshProbeConfigKey is the key of the proxy config designating a proxy to
+	// probe its upstreams.
+	meshProbeConfigKey = "mesh_probe"
+
+	defaultMeshProbeInterval   = 30 * time.Second
+	defaultMeshProbeTimeout    = 5 * time.Second
+	defaultMeshProbeSampleSize = 3
+)
+
+var MeshProbeCounters = []prometheus.CounterDefinition{
+	{
+		Name: []string{"mesh", "probe", "failure"},
+		Help: "Increments when a probe from a connect proxy to one of its upstreams fails.",
+	},
+}
+
+var MeshProbeSummaries = []prometheus.SummaryDefinition{
+	{
+		Name: []string{"mesh", "probe", "handshake"},
+		Help: "Measures the time taken by the mTLS handshake of a probe from a connect proxy to one of its upstreams.",
+	},
+	{
+		Name: []string{"mesh", "probe", "http"},
+		Help: "Measures the time taken by the HTTP ping of a probe from a connect proxy to one of its upstreams.",
+	},
+}
+
+// meshProbeCheckID returns the ID of the mesh probe check of a proxy.
+func meshProbeCheckID(proxyID structs.ServiceID) structs.CheckID {
+	return structs.NewCheckID(types.CheckID(meshProbeCheckType+":"+proxyID.ID), &proxyID.EnterpriseMeta)
+}
+
+// meshProbeConfig is the configuration of the mesh probes of a proxy, set
+// with the mesh_probe key of its proxy config.
+type meshProbeConfig struct {
+	// Interval is the time between two rounds of probes.
+	Interval time.Duration `mapstructure:"interval"`
+
+	// Timeout bounds each probe.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// SampleSize is the number of upstreams probed in each round.
+	SampleSize int `mapstructure:"sample_size"`
+
+	// HTTPPath, if set, is requested from the upstreams after the handshake.
+	HTTPPath string `mapstructure:"http_path"`
+}
+
+// parseMeshProbeConfig returns the mesh probe configuration of a proxy, or
+// nil if the proxy doesn't probe its upstreams.
+func parseMeshProbeConfig(proxyConfig map[string]interface{}) (*meshProbeConfig, error) {
+	raw, ok := proxyConfig[meshProbeConfigKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	var cfg meshProbeConfig
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			decode.HookWeakDecodeFromSlice,
+			mapstructure.StringToTimeDurationHookFunc(),
+		),
+		Result:           &cfg,
+		WeaklyTypedInput: true,
+		ErrorUnused:      true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(raw); err != nil {
+		return nil, fmt.Errorf("invalid %s proxy config: %w", meshProbeConfigKey, err)
+	}
+
+	if cfg.Interval == 0 {
+		cfg.Interval = defaultMeshProbeInterval
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultMeshProbeTimeout
+	}
+	if cfg.SampleSize == 0 {
+		cfg.SampleSize = defaultMeshProbeSampleSize
+	}
+
+	switch {
+	case cfg.Interval < checks.MinInterval:
+		return nil, fmt.Errorf("%s.interval must be at least %s", meshProbeConfigKey, checks.MinInterval)
+	case cfg.Timeout < 0:
+		return nil, fmt.Errorf("%s.timeout must not be negative", meshProbeConfigKey)
+	case cfg.SampleSize < 0:
+		return nil, fmt.Errorf("%s.sample_size must not be negative", meshProbeConfigKey)
+	case cfg.HTTPPath != "" && !strings.HasPrefix(cfg.HTTPPath, "/"):
+		return nil, fmt.Errorf("%s.http_path must start with a slash", meshProbeConfigKey)
+	}
+	return &cfg, nil
+}
+
+// meshProbeTarget is an upstream to probe, through the local listener of the
+// proxy when it has one and with a mTLS handshake to one of its instances
+// when it is in the local trust domain.
+type meshProbeTarget struct {
+	Upstream string
+	Service  string
+
+	// ListenerNetwork and ListenerAddress are the local listener of the
+	// upstream, empty if the proxy has none, like for transparent proxies.
+	ListenerNetwork string
+	ListenerAddress string
+
+	// InstanceAddress is the address of an instance of the upstream, empty if
+	// none is known or its certificates don't chain to the local roots.
+	InstanceAddress string
+}
+
+// Stages of a mesh probe, reported as the stage label of the failure metric.
+const (
+	meshProbeStageListener  = "listener"
+	meshProbeStageDial      = "dial"
+	meshProbeStageHandshake = "handshake"
+	meshProbeStageHTTP      = "http"
+)
+
+// meshProbeError is the failure of a stage of a probe.
+type meshProbeError struct {
+	Stage string
+	Err   error
+}
+
+func (e *meshProbeError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Stage, e.Err)
+}
+
+func (e *meshProbeError) Unwrap() error {
+	return e.Err
+}
+
+// meshProbeCheck periodically performs mTLS handshakes from the identity of a
+// local connect proxy to instances of a sample of its upstreams, and connects
+// through the upstream listeners of the proxy, optionally sending HTTP pings.
+// It catches data plane breakage, like a wrong trust bundle or an upstream
+// proxy which doesn't accept connections, that catalog health misses.
+type meshProbeCheck struct {
+	CheckID structs.CheckID
+	ProxyID proxycfg.ProxyID
+	Config  meshProbeConfig
+	Watcher proxyConfigWatcher
+	Notify  checks.CheckNotifier
+	Logger  hclog.Logger
+
+	// dial is used to connect to listeners and upstreams, tests override it.
+	dial func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	cancel context.CancelFunc
+	doneCh chan struct{}
+}
+
+// Start is used to start the check, runs until Stop() is called. Watcher
+// must be set.
+func (c *meshProbeCheck) Start() {
+	if c.dial == nil {
+		c.dial = (&net.Dialer{}).DialContext
+	}
+	var ctx context.Context
+	ctx, c.cancel = context.WithCancel(context.Background())
+	c.doneCh = make(chan struct{})
+	go c.run(ctx)
+}
+
+// Stop is used to stop the check. It is a no-op if the check was not started.
+func (c *meshProbeCheck) Stop() {
+	if c.cancel == nil {
+		return
+	}
+	c.cancel()
+	<-c.doneCh
+}
+
+func (c *meshProbeCheck) run(ctx context.Context) {
+	defer close(c.doneCh)
+
+	watchCh, cancel := c.Watcher.Watch(c.ProxyID)
+	defer cancel()
+
+	// Probes start once the configuration of the proxy is complete.
+	var snap *proxycfg.ConfigSnapshot
+	var tick <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case s, ok := <-watchCh:
+			if !ok {
+				return
+			}
+			if s == nil || !s.Valid() {
+				continue
+			}
+			if snap == nil {
+				ticker := time.NewTicker(c.Config.Interval)
+				defer ticker.Stop()
+				tick = ticker.C
+
+				snap = s
+				c.probe(ctx, snap)
+			}
+			snap = s
+
+		case <-tick:
+			c.probe(ctx, snap)
+		}
+	}
+}
+
+// probe runs a round of probes and updates the check with their results.
+func (c *meshProbeCheck) probe(ctx context.Context, snap *proxycfg.ConfigSnapshot) {
+	tlsCfg, err := meshProbeTLSConfig(snap)
+	if err != nil {
+		c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, fmt.Sprintf("Failed to prepare mesh probes: %s", err))
+		return
+	}
+
+	targets := sampleMeshProbeTargets(snap, c.Config.SampleSize)
+	if len(targets) == 0 {
+		c.Notify.UpdateCheck(c.CheckID, api.HealthPassing, "No upstreams to probe")
+		return
+	}
+
+	// The probes run concurrently so that an unresponsive upstream doesn't
+	// delay the others.
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target meshProbeTarget) {
+			defer wg.Done()
+			errs[i] = c.probeTarget(ctx, tlsCfg, target)
+		}(i, target)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return
+	}
+
+	var failures []*meshProbeError
+	var output []string
+	for i, target := range targets {
+		var perr *meshProbeError
+		if !errors.As(errs[i], &perr) {
+			continue
+		}
+		c.Logger.Debug("mesh probe failed",
+			"upstream", target.Upstream,
+			"stage", perr.Stage,
+			"error", perr.Err,
+		)
+		failures = append(failures, perr)
+		output = append(output, fmt.Sprintf("Probe of upstream %q failed at %s", target.Upstream, perr))
+	}
+
+	status := meshProbeStatus(failures)
+	if len(failures) == 0 {
+		c.Notify.UpdateCheck(c.CheckID, status, fmt.Sprintf("All %d mesh probes succeeded", len(targets)))
+		return
+	}
+	c.Notify.UpdateCheck(c.CheckID, status, strings.Join(output, "\n"))
+}
+
+// probeTarget performs a mTLS handshake with an instance of the upstream and
+// connects to it through the local listener of the proxy, as available. The
+// HTTP ping, if configured, goes through the listener, or over the mTLS
+// connection for upstreams without one.
+func (c *meshProbeCheck) probeTarget(ctx context.Context, tlsCfg *tls.Config, target meshProbeTarget) error {
+	labels := []metrics.Label{
+		{Name: "source", Value: c.ProxyID.ID},
+		{Name: "destination", Value: target.Upstream},
+	}
+	fail := func(stage string, err error) error {
+		metrics.IncrCounterWithLabels([]string{"mesh", "probe", "failure"}, 1,
+			append(labels, metrics.Label{Name: "stage", Value: stage}))
+		return &meshProbeError{Stage: stage, Err: err}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Config.Timeout)
+	defer cancel()
+
+	var pingConn net.Conn
+	if target.InstanceAddress != "" {
+		start := time.Now()
+		rawConn, err := c.dial(ctx, "tcp", target.InstanceAddress)
+		if err != nil {
+			return fail(meshProbeStageDial, err)
+		}
+		defer rawConn.Close()
+		if deadline, ok := ctx.Deadline(); ok {
+			rawConn.SetDeadline(deadline)
+		}
+
+		cfg := tlsCfg.Clone()
+		cfg.VerifyPeerCertificate = verifyMeshProbePeer(tlsCfg.RootCAs, target.Service)
+		conn := tls.Client(rawConn, cfg)
+		if err := conn.HandshakeContext(ctx); err != nil {
+			return fail(meshProbeStageHandshake, err)
+		}
+		metrics.MeasureSinceWithLabels([]string{"mesh", "probe", "handshake"}, start, labels)
+		pingConn = conn
+	}
+
+	if target.ListenerAddress != "" {
+		// The listener only tells whether the proxy accepts connections, Envoy
+		// connects to the upstream lazily so waiting for it to close the
+		// connection would hold every successful probe for the timeout.
+		conn, err := c.dial(ctx, target.ListenerNetwork, target.ListenerAddress)
+		if err != nil {
+			return fail(meshProbeStageListener, err)
+		}
+		defer conn.Close()
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+		pingConn = conn
+	}
+
+	if c.Config.HTTPPath == "" {
+		return nil
+	}
+
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Config.HTTPPath, nil)
+	if err != nil {
+		return fail(meshProbeStageHTTP, err)
+	}
+	req.Host = target.Service
+	req.Close = true
+	if err := req.Write(pingConn); err != nil {
+		return fail(meshProbeStageHTTP, err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(pingConn), req)
+	if err != nil {
+		return fail(meshProbeStageHTTP, err)
+	}
+	resp.Body.Close()
+	// The proxy answers with a 503 when it can't reach the upstream.
+	if resp.StatusCode >= 500 {
+		return fail(meshProbeStageHTTP, fmt.Errorf("HTTP ping returned %s", resp.Status))
+	}
+	metrics.MeasureSinceWithLabels([]string{"mesh", "probe", "http"}, start, labels)
+	return nil
+}
+
+// meshProbeStatus returns the status of the mesh probe check for the failures
+// of a round of probes. The check is critical when the proxy itself doesn't
+// accept connections on its upstream listeners. Failures to reach the
+// upstreams are only a warning, so that broken upstreams don't take the proxy
+// out of service discovery.
+func meshProbeStatus(failures []*meshProbeError) string {
+	if len(failures) == 0 {
+		return api.HealthPassing
+	}
+	for _, f := range failures {
+		if f.Stage == meshProbeStageListener {
+			return api.HealthCritical
+		}
+	}
+	return api.HealthWarning
+}
+
+// meshProbeTLSConfig returns the TLS configuration presenting the leaf
+// certificate of the proxy and trusting the roots of its snapshot.
+func meshProbeTLSConfig(snap *proxycfg.ConfigSnapshot) (*tls.Config, error) {
+	leaf := snap.Leaf()
+	if leaf == nil {
+		return nil, errors.New("proxy has no leaf certificate")
+	}
+	cert, err := tls.X509KeyPair([]byte(leaf.CertPEM), []byte(leaf.PrivateKeyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the leaf certificate of the proxy: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if snap.Roots != nil {
+		for _, root := range snap.Roots.Roots {
+			pool.AppendCertsFromPEM([]byte(root.RootCert))
+		}
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		// The peer is verified by VerifyPeerCertificate against the SPIFFE ID
+		// of the upstream rather than a hostname.
+		InsecureSkipVerify: true,
+	}, nil
+}
+
+// verifyMeshProbePeer returns a function verifying that the certificate of
+// the peer chains to the roots and identifies the expected service.
+func verifyMeshProbePeer(roots *x509.CertPool, service string) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("upstream presented no certificate")
+		}
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return fmt.Errorf("failed to parse the certificate of the upstream: %w", err)
+			}
+			certs[i] = cert
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to verify the certificate of the upstream: %w", err)
+		}
+
+		if len(certs[0].URIs) == 0 {
+			return errors.New("certificate of the upstream has no SPIFFE ID")
+		}
+		uri, err := connect.ParseCertURI(certs[0].URIs[0])
+		if err != nil {
+			return fmt.Errorf("invalid SPIFFE ID in the certificate of the upstream: %w", err)
+		}
+		svc, ok := uri.(*connect.SpiffeIDService)
+		if !ok || svc.Service != service {
+			return fmt.Errorf("certificate of the upstream is for %q, expected service %q", certs[0].URIs[0], service)
+		}
+		return nil
+	}
+}
+
+// sampleMeshProbeTargets picks up to size upstreams of the proxy at random,
+// including the ones of transparent proxies which have no local listener.
+// Instances of peered upstreams aren't handshaked with as their certificates
+// don't chain to the local roots.
+func sampleMeshProbeTargets(snap *proxycfg.ConfigSnapshot, size int) []meshProbeTarget {
+	if snap.Kind != structs.ServiceKindConnectProxy {
+		return nil
+	}
+
+	uids := make(map[proxycfg.UpstreamID]struct{})
+	for uid := range snap.ConnectProxy.UpstreamConfig {
+		uids[uid] = struct{}{}
+	}
+	for uid := range snap.ConnectProxy.WatchedUpstreamEndpoints {
+		uids[uid] = struct{}{}
+	}
+
+	var targets []meshProbeTarget
+	for uid := range uids {
+		if t, ok := meshProbeTargetFor(snap, uid); ok {
+			targets = append(targets, t)
+		}
+	}
+	// Sort before shuffling so the sample only depends on the random source.
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].Upstream < targets[j].Upstream
+	})
+	rand.Shuffle(len(targets), func(i, j int) {
+		targets[i], targets[j] = targets[j], targets[i]
+	})
+	if len(targets) > size {
+		targets = targets[:size]
+	}
+	return targets
+}
+
+// meshProbeTargetFor returns the target to probe for an upstream, or false if
+// the proxy has neither a listener nor a known instance for it.
+func meshProbeTargetFor(snap *proxycfg.ConfigSnapshot, uid proxycfg.UpstreamID) (meshProbeTarget, bool) {
+	target := meshProbeTarget{Upstream: uid.String()}
+
+	if u := snap.ConnectProxy.UpstreamConfig[uid]; u != nil && u.HasLocalPortOrSocket() {
+		target.Service = u.DestinationName
+		target.ListenerNetwork, target.ListenerAddress = meshProbeListener(u)
+	}
+
+	if uid.Peer == "" {
+		var candidates []structs.CheckServiceNode
+		for _, endpoints := range snap.ConnectProxy.WatchedUpstreamEndpoints[uid] {
+			for _, ep := range endpoints {
+				if meshProbeInstanceService(ep) != "" {
+					candidates = append(candidates, ep)
+				}
+			}
+		}
+		if len(candidates) > 0 {
+			ep := candidates[rand.Intn(len(candidates))]
+			_, addr, port := ep.BestAddress(false)
+			target.InstanceAddress = net.JoinHostPort(addr, strconv.Itoa(port))
+			if target.Service == "" {
+				target.Service = meshProbeInstanceService(ep)
+			}
+		}
+	}
+
+	return target, target.ListenerAddress != "" || target.InstanceAddress != ""
+}
+
+// meshProbeInstanceService returns the service identified by the certificate
+// of an upstream instance, or an empty string if it can't be handshaked with.
+func meshProbeInstanceService(ep structs.CheckServiceNode) string {
+	if ep.Service == nil || ep.Node == nil {
+		return ""
+	}
+	if _, addr, port := ep.BestAddress(false); addr == "" || port == 0 {
+		return ""
+	}
+	switch {
+	case ep.Service.Kind == structs.ServiceKindConnectProxy:
+		return ep.Service.Proxy.DestinationServiceName
+	case ep.Service.Connect.Native:
+		return ep.Service.Service
+	}
+	return ""
+}
+
+// meshProbeListener returns the network and address to reach the local
+// listener of an upstream.
+func meshProbeListener(u *structs.Upstream) (string, string) {
+	if u.LocalBindPort == 0 {
+		return "unix", u.LocalBindSocketPath
+	}
+
+	// Listeners bound to every interface are reached over the loopback.
+	addr := u.LocalBindAddress
+	if ip := net.ParseIP(addr); addr == "" || (ip != nil && ip.IsUnspecified() && ip.To4() != nil) {
+		addr = "127.0.0.1"
+	} else if ip != nil && ip.IsUnspecified() {
+		addr = "::1"
+	}
+	return "tcp", net.JoinHostPort(addr, strconv.Itoa(u.LocalBindPort))
+}
+
+// addMeshProbeCheckLocked registers the mesh probe check of a connect proxy
+// and starts probing its upstreams. Any previous check of the proxy is
+// replaced so that configuration changes are applied.
+//
+// This MUST be called with the a.stateLock held
+func (a *Agent) addMeshProbeCheckLocked(service *structs.NodeService, cfg meshProbeConfig, token string) error {
+	sid := service.CompoundServiceID()
+	cid := meshProbeCheckID(sid)
+	if existing, ok := a.checkMeshProbes[cid]; ok && existing.Config == cfg && a.State.Check(cid) != nil {
+		return nil
+	}
+
+	check := &structs.HealthCheck{
+		Node:           a.config.NodeName,
+		CheckID:        cid.ID,
+		Name:           meshProbeCheckName,
+		Status:         api.HealthWarning,
+		Output:         "No mesh probe has run yet",
+		ServiceID:      service.ID,
+		ServiceName:    service.Service,
+		ServiceTags:    service.Tags,
+		Type:           meshProbeCheckType,
+		Interval:       cfg.Interval.String(),
+		Timeout:        cfg.Timeout.String(),
+		EnterpriseMeta: service.EnterpriseMeta,
+	}
+	if err := a.State.AddCheck(check, token); err != nil {
+		return err
+	}
+
+	if existing, ok := a.checkMeshProbes[cid]; ok {
+		existing.Stop()
+	}
+	chk := &meshProbeCheck{
+		CheckID: cid,
+		// Local proxies are registered with the proxycfg manager without a
+		// token, see the local proxycfg source.
+		ProxyID: proxycfg.ProxyID{ServiceID: sid, NodeName: a.config.NodeName},
+		Config:  cfg,
+		Notify:  a.State,
+		Logger:  a.logger.With("check", cid.String()),
+	}
+	if a.proxyConfig != nil {
+		chk.Watcher = a.proxyConfig
+		chk.Start()
+	}
+	a.checkMeshProbes[cid] = chk
+	return nil
+}
+
+// startMeshProbeChecks starts the mesh probe checks registered before the
+// proxycfg manager was created. The caller must hold stateLock.
+func (a *Agent) startMeshProbeChecks() {
+	for _, chk := range a.checkMeshProbes {
+		if chk.Watcher == nil {
+			chk.Watcher = a.proxyConfig
+			chk.Start()
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package agent
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/connect"
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+)
+
+func TestParseMeshProbeConfig(t *testing.T) {
+	cfg, err := parseMeshProbeConfig(map[string]interface{}{"protocol": "http"})
+	require.NoError(t, err)
+	require.Nil(t, cfg)
+
+	cfg, err = parseMeshProbeConfig(map[string]interface{}{
+		meshProbeConfigKey: map[string]interface{}{},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &meshProbeConfig{
+		Interval:   defaultMeshProbeInterval,
+		Timeout:    defaultMeshProbeTimeout,
+		SampleSize: defaultMeshProbeSampleSize,
+	}, cfg)
+
+	// HCL decodes blocks as a slice of maps.
+	cfg, err = parseMeshProbeConfig(map[string]interface{}{
+		meshProbeConfigKey: []map[string]interface{}{
+			{"interval": "10s", "timeout": "2s", "sample_size": "5", "http_path": "/health"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &meshProbeConfig{
+		Interval:   10 * time.Second,
+		Timeout:    2 * time.Second,
+		SampleSize: 5,
+		HTTPPath:   "/health",
+	}, cfg)
+
+	for name, tc := range map[string]struct {
+		raw    map[string]interface{}
+		expect string
+	}{
+		"short interval": {
+			raw:    map[string]interface{}{"interval": "10ms"},
+			expect: "mesh_probe.interval must be at least 1s",
+		},
+		"relative path": {
+			raw:    map[string]interface{}{"http_path": "health"},
+			expect: "mesh_probe.http_path must start with a slash",
+		},
+		"unknown key": {
+			raw:    map[string]interface{}{"intervall": "10s"},
+			expect: "invalid mesh_probe proxy config",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseMeshProbeConfig(map[string]interface{}{meshProbeConfigKey: tc.raw})
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.expect)
+		})
+	}
+}
+
+func TestMeshProbeStatus(t *testing.T) {
+	require.Equal(t, api.HealthPassing, meshProbeStatus(nil))
+
+	require.Equal(t, api.HealthWarning, meshProbeStatus([]*meshProbeError{
+		{Stage: meshProbeStageHandshake},
+		{Stage: meshProbeStageHTTP},
+	}))
+
+	// The proxy not accepting connections is a failure of the proxy itself.
+	require.Equal(t, api.HealthCritical, meshProbeStatus([]*meshProbeError{
+		{Stage: meshProbeStageHTTP},
+		{Stage: meshProbeStageListener},
+	}))
+}
+
+func TestMeshProbeTargetFor(t *testing.T) {
+	uid := proxycfg.UpstreamIDFromString("db")
+	snap := testValidConfigSnapshot(t)
+	snap.ConnectProxy.UpstreamConfig = map[proxycfg.UpstreamID]*structs.Upstream{
+		uid: {DestinationName: "db"},
+	}
+	snap.ConnectProxy.WatchedUpstreamEndpoints = nil
+
+	_, ok := meshProbeTargetFor(snap, uid)
+	require.False(t, ok)
+
+	snap.ConnectProxy.UpstreamConfig[uid].LocalBindPort = 9191
+	target, ok := meshProbeTargetFor(snap, uid)
+	require.True(t, ok)
+	require.Equal(t, meshProbeTarget{Upstream: "db", Service: "db", ListenerNetwork: "tcp", ListenerAddress: "127.0.0.1:9191"}, target)
+
+	snap.ConnectProxy.UpstreamConfig[uid].LocalBindAddress = "::"
+	target, ok = meshProbeTargetFor(snap, uid)
+	require.True(t, ok)
+	require.Equal(t, "[::1]:9191", target.ListenerAddress)
+
+	snap.ConnectProxy.UpstreamConfig[uid] = &structs.Upstream{DestinationName: "db", LocalBindSocketPath: "/tmp/db.sock"}
+	target, ok = meshProbeTargetFor(snap, uid)
+	require.True(t, ok)
+	require.Equal(t, meshProbeTarget{Upstream: "db", Service: "db", ListenerNetwork: "unix", ListenerAddress: "/tmp/db.sock"}, target)
+
+	// Upstreams of transparent proxies are only handshaked with.
+	delete(snap.ConnectProxy.UpstreamConfig, uid)
+	testMeshProbeInstance(snap, "db", "10.0.0.1", 21000)
+	target, ok = meshProbeTargetFor(snap, uid)
+	require.True(t, ok)
+	require.Equal(t, meshProbeTarget{Upstream: "db", Service: "db", InstanceAddress: "10.0.0.1:21000"}, target)
+}
+
+// testMeshProbeInstance sets an instance of the db upstream proxying service
+// as the only endpoint of the upstreams of snap.
+func testMeshProbeInstance(snap *proxycfg.ConfigSnapshot, service, addr string, port int) {
+	snap.ConnectProxy.WatchedUpstreamEndpoints = map[proxycfg.UpstreamID]map[string]structs.CheckServiceNodes{
+		proxycfg.UpstreamIDFromString("db"): {
+			"db.default.default.dc1": {
+				{
+					Node: &structs.Node{Node: "node1", Address: addr},
+					Service: &structs.NodeService{
+						Kind:    structs.ServiceKindConnectProxy,
+						Service: service + "-sidecar-proxy",
+						Port:    port,
+						Proxy:   structs.ConnectProxyConfig{DestinationServiceName: service},
+					},
+				},
+			},
+		},
+	}
+}
+
+// testMeshProbeServe serves the connections accepted by ln with handle.
+func testMeshProbeServe(t *testing.T, ln net.Listener, handle func(net.Conn)) {
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+}
+
+// testMeshProbeListener starts a listener standing in for the upstream
+// listener of the proxy, which handles connections with handle, and sets it
+// as the only upstream of snap.
+func testMeshProbeListener(t *testing.T, snap *proxycfg.ConfigSnapshot, handle func(net.Conn)) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	testMeshProbeServe(t, ln, handle)
+
+	snap.ConnectProxy.UpstreamConfig = map[proxycfg.UpstreamID]*structs.Upstream{
+		proxycfg.UpstreamIDFromString("db"): {
+			DestinationName: "db",
+			LocalBindPort:   ln.Addr().(*net.TCPAddr).Port,
+		},
+	}
+	snap.ConnectProxy.WatchedUpstreamEndpoints = nil
+}
+
+// testMeshProbeUpstream starts a TLS server requiring client certificates
+// which presents a leaf certificate of service signed by the roots of snap
+// and handles connections with handle, and sets it as the only upstream of
+// snap, without a local listener like for transparent proxies.
+func testMeshProbeUpstream(t *testing.T, snap *proxycfg.ConfigSnapshot, service string, handle func(net.Conn)) {
+	certPEM, keyPEM := connect.TestLeaf(t, service, snap.Roots.Roots[0])
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM([]byte(snap.Roots.Roots[0].RootCert))
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	require.NoError(t, err)
+	testMeshProbeServe(t, ln, func(conn net.Conn) {
+		if conn.(*tls.Conn).Handshake() == nil {
+			handle(conn)
+		}
+	})
+
+	snap.ConnectProxy.UpstreamConfig = nil
+	testMeshProbeInstance(snap, service, "127.0.0.1", ln.Addr().(*net.TCPAddr).Port)
+}
+
+func TestMeshProbeCheck(t *testing.T) {
+	run := func(t *testing.T, httpPath string, setup func(*proxycfg.ConfigSnapshot)) [2]string {
+		watcher := &fakeProxyConfigWatcher{ch: make(chan *proxycfg.ConfigSnapshot, 1)}
+		notify := &fakeCheckNotifier{updates: make(chan [2]string, 1)}
+
+		snap := testValidConfigSnapshot(t)
+		setup(snap)
+
+		chk := &meshProbeCheck{
+			CheckID: structs.NewCheckID("mesh-probe:web-proxy", nil),
+			Config: meshProbeConfig{
+				Interval:   time.Minute,
+				Timeout:    5 * time.Second,
+				SampleSize: 1,
+				HTTPPath:   httpPath,
+			},
+			Watcher: watcher,
+			Notify:  notify,
+			Logger:  testutil.Logger(t),
+		}
+		chk.Start()
+		defer chk.Stop()
+
+		watcher.ch <- snap
+		return <-notify.updates
+	}
+
+	holdOpen := func(conn net.Conn) {
+		conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+		io.Copy(io.Discard, conn)
+	}
+	respond := func(status string) func(net.Conn) {
+		return func(conn net.Conn) {
+			http.ReadRequest(bufio.NewReader(conn))
+			fmt.Fprintf(conn, "HTTP/1.1 %s\r\nContent-Length: 0\r\n\r\n", status)
+		}
+	}
+
+	t.Run("listener accepts connections", func(t *testing.T) {
+		start := time.Now()
+		update := run(t, "", func(snap *proxycfg.ConfigSnapshot) {
+			testMeshProbeListener(t, snap, holdOpen)
+		})
+		require.Equal(t, api.HealthPassing, update[0])
+		require.Equal(t, "All 1 mesh probes succeeded", update[1])
+		// The probe doesn't wait for the timeout once connected.
+		require.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("listener refuses connections", func(t *testing.T) {
+		update := run(t, "", func(snap *proxycfg.ConfigSnapshot) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			port := ln.Addr().(*net.TCPAddr).Port
+			ln.Close()
+			snap.ConnectProxy.UpstreamConfig = map[proxycfg.UpstreamID]*structs.Upstream{
+				proxycfg.UpstreamIDFromString("db"): {DestinationName: "db", LocalBindPort: port},
+			}
+			snap.ConnectProxy.WatchedUpstreamEndpoints = nil
+		})
+		require.Equal(t, api.HealthCritical, update[0])
+		require.Contains(t, update[1], `Probe of upstream "db" failed at listener`)
+	})
+
+	t.Run("http ping", func(t *testing.T) {
+		update := run(t, "/health", func(snap *proxycfg.ConfigSnapshot) {
+			testMeshProbeListener(t, snap, respond("200 OK"))
+		})
+		require.Equal(t, api.HealthPassing, update[0])
+	})
+
+	t.Run("http ping through unreachable upstream", func(t *testing.T) {
+		update := run(t, "/health", func(snap *proxycfg.ConfigSnapshot) {
+			testMeshProbeListener(t, snap, respond("503 Service Unavailable"))
+		})
+		require.Equal(t, api.HealthWarning, update[0])
+		require.Contains(t, update[1], "HTTP ping returned 503 Service Unavailable")
+	})
+
+	t.Run("transparent upstream handshake", func(t *testing.T) {
+		update := run(t, "", func(snap *proxycfg.ConfigSnapshot) {
+			testMeshProbeUpstream(t, snap, "db", holdOpen)
+		})
+		require.Equal(t, api.HealthPassing, update[0])
+	})
+
+	t.Run("transparent upstream http ping", func(t *testing.T) {
+		update := run(t, "/health", func(snap *proxycfg.ConfigSnapshot) {
+			testMeshProbeUpstream(t, snap, "db", respond("200 OK"))
+		})
+		require.Equal(t, api.HealthPassing, update[0])
+	})
+
+	t.Run("upstream identity mismatch", func(t *testing.T) {
+		update := run(t, "", func(snap *proxycfg.ConfigSnapshot) {
+			testMeshProbeUpstream(t, snap, "not-db", holdOpen)
+			// The instance proxies db but presents the identity of not-db.
+			for _, endpoints := range snap.ConnectProxy.WatchedUpstreamEndpoints {
+				for _, ep := range endpoints {
+					ep[0].Service.Proxy.DestinationServiceName = "db"
+				}
+			}
+		})
+		require.Equal(t, api.HealthWarning, update[0])
+		require.Contains(t, update[1], `expected service "db"`)
+	})
+}
+
+func TestAgent_MeshProbeCheck(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, `
+		connect {
+			enabled = true
+			enable_mesh_probes = true
+		}
+	`)
+	defer a.Shutdown()
+
+	proxy := structs.TestNodeServiceProxy(t)
+	proxy.Proxy.Config = map[string]interface{}{
+		meshProbeConfigKey: map[string]interface{}{"interval": "1m"},
+	}
+	require.NoError(t, a.addServiceFromSource(proxy, nil, false, "", ConfigSourceLocal))
+
+	cid := meshProbeCheckID(proxy.CompoundServiceID())
+	retry.Run(t, func(r *retry.R) {
+		chk := a.State.Check(cid)
+		if chk == nil {
+			r.Fatalf("mesh probe check not registered")
+		}
+		if chk.Type != meshProbeCheckType {
+			r.Fatalf("bad check type: %q", chk.Type)
+		}
+	})
+
+	// Proxies without the mesh_probe config don't probe their upstreams.
+	proxy.Proxy.Config = nil
+	require.NoError(t, a.addServiceFromSource(proxy, nil, false, "", ConfigSourceLocal))
+	require.Nil(t, a.State.Check(cid))
+	require.NotContains(t, a.checkMeshProbes, cid)
+}
@@ -0,0 +1,185 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/consul/agent/checks"
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/types"
+)
+
+const (
+	// meshReadinessCheckType is the type of the synthetic check registered on
+	// connect proxies when connect.enable_mesh_readiness_check is set.
+	meshReadinessCheckType = "mesh-readiness"
+
+	meshReadinessCheckName = "Connect Sidecar Mesh Readiness"
+)
+
+// meshReadinessCheckID returns the ID of the mesh readiness check of a proxy.
+func meshReadinessCheckID(proxyID structs.ServiceID) structs.CheckID {
+	return structs.NewCheckID(types.CheckID(meshReadinessCheckType+":"+proxyID.ID), &proxyID.EnterpriseMeta)
+}
+
+// proxyConfigWatcher is the subset of proxycfg.Manager used by the mesh
+// readiness check.
+type proxyConfigWatcher interface {
+	Watch(id proxycfg.ProxyID) (<-chan *proxycfg.ConfigSnapshot, proxycfg.CancelFunc)
+}
+
+// meshReadinessCheck reflects whether a local connect proxy is able to route
+// traffic. It watches the proxy's configuration snapshot and is passing only
+// while the snapshot is complete and the proxy's leaf certificate is valid.
+type meshReadinessCheck struct {
+	CheckID structs.CheckID
+	ProxyID proxycfg.ProxyID
+	Watcher proxyConfigWatcher
+	Notify  checks.CheckNotifier
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// Start is used to start the check, runs until Stop() is called. Watcher
+// must be set.
+func (c *meshReadinessCheck) Start() {
+	c.stopCh = make(chan struct{})
+	c.doneCh = make(chan struct{})
+	go c.run()
+}
+
+// Stop is used to stop the check. It is a no-op if the check was not started.
+func (c *meshReadinessCheck) Stop() {
+	if c.stopCh == nil {
+		return
+	}
+	close(c.stopCh)
+	<-c.doneCh
+}
+
+func (c *meshReadinessCheck) run() {
+	defer close(c.doneCh)
+
+	watchCh, cancel := c.Watcher.Watch(c.ProxyID)
+	defer cancel()
+
+	expiry := time.NewTimer(0)
+	if !expiry.Stop() {
+		<-expiry.C
+	}
+	defer expiry.Stop()
+
+	for {
+		select {
+		case <-c.stopCh:
+			return
+
+		case snap, ok := <-watchCh:
+			if !ok {
+				return
+			}
+			now := time.Now()
+			status, output := meshReadinessStatus(snap, now)
+			c.Notify.UpdateCheck(c.CheckID, status, output)
+
+			if !expiry.Stop() {
+				select {
+				case <-expiry.C:
+				default:
+				}
+			}
+			if status == api.HealthPassing {
+				if leaf := snap.Leaf(); leaf != nil && !leaf.ValidBefore.IsZero() {
+					expiry.Reset(leaf.ValidBefore.Sub(now))
+				}
+			}
+
+		case <-expiry.C:
+			// The leaf certificate was not renewed in time.
+			c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, "Leaf certificate of the proxy has expired")
+		}
+	}
+}
+
+// meshReadinessStatus returns the status and output of the mesh readiness
+// check for a proxy configuration snapshot.
+func meshReadinessStatus(snap *proxycfg.ConfigSnapshot, now time.Time) (string, string) {
+	if snap == nil || !snap.Valid() {
+		return api.HealthCritical, "Proxy configuration is not yet complete"
+	}
+
+	leaf := snap.Leaf()
+	if leaf == nil {
+		return api.HealthPassing, "Proxy configuration is complete"
+	}
+	if !leaf.ValidAfter.IsZero() && now.Before(leaf.ValidAfter) {
+		return api.HealthCritical, fmt.Sprintf("Leaf certificate of the proxy is not valid until %s", leaf.ValidAfter.Format(time.RFC3339))
+	}
+	if !leaf.ValidBefore.IsZero() && !now.Before(leaf.ValidBefore) {
+		return api.HealthCritical, "Leaf certificate of the proxy has expired"
+	}
+	return api.HealthPassing, fmt.Sprintf("Proxy configuration is complete and the leaf certificate is valid until %s",
+		leaf.ValidBefore.Format(time.RFC3339))
+}
+
+// addMeshReadinessCheckLocked registers the mesh readiness check of a connect
+// proxy service and starts watching its configuration. It is a no-op if the
+// check is already running.
+//
+// This MUST be called with the a.stateLock held
+func (a *Agent) addMeshReadinessCheckLocked(service *structs.NodeService, token string) error {
+	sid := service.CompoundServiceID()
+	cid := meshReadinessCheckID(sid)
+	if _, ok := a.checkMeshReadiness[cid]; ok && a.State.Check(cid) != nil {
+		return nil
+	}
+
+	check := &structs.HealthCheck{
+		Node:           a.config.NodeName,
+		CheckID:        cid.ID,
+		Name:           meshReadinessCheckName,
+		Status:         api.HealthCritical,
+		Output:         "Proxy configuration is not yet complete",
+		ServiceID:      service.ID,
+		ServiceName:    service.Service,
+		ServiceTags:    service.Tags,
+		Type:           meshReadinessCheckType,
+		EnterpriseMeta: service.EnterpriseMeta,
+	}
+	if err := a.State.AddCheck(check, token); err != nil {
+		return err
+	}
+
+	if existing, ok := a.checkMeshReadiness[cid]; ok {
+		existing.Stop()
+	}
+	chk := &meshReadinessCheck{
+		CheckID: cid,
+		// Local proxies are registered with the proxycfg manager without a
+		// token, see the local proxycfg source.
+		ProxyID: proxycfg.ProxyID{ServiceID: sid, NodeName: a.config.NodeName},
+		Notify:  a.State,
+	}
+	// Services loaded during agent startup are registered before the proxycfg
+	// manager exists, their checks are started by startMeshReadinessChecks.
+	if a.proxyConfig != nil {
+		chk.Watcher = a.proxyConfig
+		chk.Start()
+	}
+	a.checkMeshReadiness[cid] = chk
+	return nil
+}
+
+// startMeshReadinessChecks starts the mesh readiness checks registered before
+// the proxycfg manager was created. The caller must hold stateLock.
+func (a *Agent) startMeshReadinessChecks() {
+	for _, chk := range a.checkMeshReadiness {
+		if chk.Watcher == nil {
+			chk.Watcher = a.proxyConfig
+			chk.Start()
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+)
+
+// testValidConfigSnapshot returns a connect proxy snapshot that is complete.
+func testValidConfigSnapshot(t *testing.T) *proxycfg.ConfigSnapshot {
+	snap := proxycfg.TestConfigSnapshot(t, nil, nil)
+	snap.ConnectProxy.IntentionsSet = true
+	snap.ConnectProxy.MeshConfigSet = true
+	require.True(t, snap.Valid())
+	return snap
+}
+
+func TestMeshReadinessStatus(t *testing.T) {
+	now := time.Now()
+
+	snap := testValidConfigSnapshot(t)
+	snap.ConnectProxy.Leaf.ValidAfter = now.Add(-time.Hour)
+	snap.ConnectProxy.Leaf.ValidBefore = now.Add(time.Hour)
+
+	status, _ := meshReadinessStatus(nil, now)
+	require.Equal(t, api.HealthCritical, status)
+
+	status, output := meshReadinessStatus(snap, now)
+	require.Equal(t, api.HealthPassing, status)
+	require.Contains(t, output, "leaf certificate is valid until")
+
+	status, output = meshReadinessStatus(snap, now.Add(2*time.Hour))
+	require.Equal(t, api.HealthCritical, status)
+	require.Equal(t, "Leaf certificate of the proxy has expired", output)
+
+	incomplete := testValidConfigSnapshot(t)
+	incomplete.ConnectProxy.IntentionsSet = false
+	status, output = meshReadinessStatus(incomplete, now)
+	require.Equal(t, api.HealthCritical, status)
+	require.Equal(t, "Proxy configuration is not yet complete", output)
+}
+
+type fakeProxyConfigWatcher struct {
+	ch chan *proxycfg.ConfigSnapshot
+}
+
+func (w *fakeProxyConfigWatcher) Watch(proxycfg.ProxyID) (<-chan *proxycfg.ConfigSnapshot, proxycfg.CancelFunc) {
+	return w.ch, func() {}
+}
+
+type fakeCheckNotifier struct {
+	updates chan [2]string
+}
+
+func (n *fakeCheckNotifier) UpdateCheck(_ structs.CheckID, status, output string) {
+	n.updates <- [2]string{status, output}
+}
+
+func (n *fakeCheckNotifier) ServiceExists(structs.ServiceID) bool { return true }
+
+func TestMeshReadinessCheck_LeafExpiry(t *testing.T) {
+	watcher := &fakeProxyConfigWatcher{ch: make(chan *proxycfg.ConfigSnapshot, 1)}
+	notify := &fakeCheckNotifier{updates: make(chan [2]string, 2)}
+
+	chk := &meshReadinessCheck{
+		CheckID: structs.NewCheckID("mesh-readiness:web-proxy", nil),
+		Watcher: watcher,
+		Notify:  notify,
+	}
+	chk.Start()
+	defer chk.Stop()
+
+	snap := testValidConfigSnapshot(t)
+	snap.ConnectProxy.Leaf.ValidAfter = time.Now().Add(-time.Hour)
+	snap.ConnectProxy.Leaf.ValidBefore = time.Now().Add(50 * time.Millisecond)
+	watcher.ch <- snap
+
+	update := <-notify.updates
+	require.Equal(t, api.HealthPassing, update[0])
+
+	// The check goes critical once the leaf certificate expires without a new
+	// snapshot being delivered.
+	update = <-notify.updates
+	require.Equal(t, api.HealthCritical, update[0])
+	require.Equal(t, "Leaf certificate of the proxy has expired", update[1])
+}
+
+func TestAgent_MeshReadinessCheck(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, `
+		connect {
+			enabled = true
+			enable_mesh_readiness_check = true
+		}
+	`)
+	defer a.Shutdown()
+
+	proxy := structs.TestNodeServiceProxy(t)
+	require.NoError(t, a.addServiceFromSource(proxy, nil, false, "", ConfigSourceLocal))
+
+	cid := meshReadinessCheckID(proxy.CompoundServiceID())
+	retry.Run(t, func(r *retry.R) {
+		chk := a.State.Check(cid)
+		if chk == nil {
+			r.Fatalf("mesh readiness check not registered")
+		}
+		if chk.Type != meshReadinessCheckType {
+			r.Fatalf("bad check type: %q", chk.Type)
+		}
+	})
+
+	require.NoError(t, a.RemoveService(proxy.CompoundServiceID()))
+	require.Nil(t, a.State.Check(cid))
+	require.NotContains(t, a.checkMeshReadiness, cid)
+}
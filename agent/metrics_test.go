@@ -12,8 +12,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/armon/go-metrics"
+
 	// "github.com/hashicorp/consul/agent/config"
 	"github.com/hashicorp/consul/agent/rpc/middleware"
+	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/consul/lib/retry"
 	"github.com/hashicorp/consul/sdk/testutil"
 	"github.com/hashicorp/consul/testrpc"
@@ -304,6 +307,53 @@ func TestHTTPHandlers_AgentMetrics_ConsulAutopilot_Prometheus(t *testing.T) {
 	})
 }
 
+func TestHTTPHandlers_AgentMetrics_PrometheusHistograms(t *testing.T) {
+	skipIfShortTesting(t)
+	// This test cannot use t.Parallel() since we modify global state, ie the global metrics instance
+
+	hcl := `
+	telemetry = {
+		prometheus_retention_time = "5s"
+		prometheus_histograms = true
+		prometheus_label_filter = ["-method"]
+		disable_hostname = true
+		metrics_prefix = "agent_histograms"
+	}
+	`
+
+	a := StartTestAgent(t, TestAgent{HCL: hcl})
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	metrics.IncrCounterWithLabels([]string{"test", "filtered"}, 1, []metrics.Label{
+		{Name: "method", Value: "GET"},
+		{Name: "path", Value: "/v1/test"},
+	})
+
+	respRec := httptest.NewRecorder()
+	recordPromMetrics(t, a, respRec)
+
+	assertMetricExists(t, respRec, "# HELP agent_histograms_catalog_register Measures the time it takes to complete a catalog register operation.")
+	assertMetricExists(t, respRec, "# TYPE agent_histograms_catalog_register histogram")
+	assertMetricExists(t, respRec, `agent_histograms_catalog_register_bucket{le="0.1"}`)
+
+	// The method label is dropped.
+	assertMetricExistsWithValue(t, respRec, `agent_histograms_test_filtered{path="/v1/test"}`, "1")
+}
+
+func TestGetPrometheusDefs_Help(t *testing.T) {
+	gauges, counters, summaries := getPrometheusDefs(lib.TelemetryConfig{}, true)
+	for _, def := range gauges {
+		require.NotEmpty(t, def.Help, "gauge %v has no help", def.Name)
+	}
+	for _, def := range counters {
+		require.NotEmpty(t, def.Help, "counter %v has no help", def.Name)
+	}
+	for _, def := range summaries {
+		require.NotEmpty(t, def.Help, "summary %v has no help", def.Name)
+	}
+}
+
 // TestHTTPHandlers_AgentMetrics_Disable1Dot9MetricsChange adds testing around the 1.9 style metrics
 // https://www.consul.io/docs/agent/options#telemetry-disable_compat_1.9
 func TestHTTPHandlers_AgentMetrics_Disable1Dot9MetricsChange(t *testing.T) {
@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package agent
+
+import (
+	"fmt"
+	"net"
+)
+
+// listenNamedPipe always fails, named pipes are only available on Windows.
+func listenNamedPipe(name string) (net.Listener, error) {
+	return nil, fmt.Errorf("cannot listen on named pipe %q: named pipes are only supported on Windows", name)
+}
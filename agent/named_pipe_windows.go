@@ -0,0 +1,16 @@
+//go:build windows
+// +build windows
+
+package agent
+
+import (
+	"net"
+
+	winio "github.com/Microsoft/go-winio"
+)
+
+// listenNamedPipe listens on the Windows named pipe with the given name, such
+// as \\.\pipe\consul-grpc.
+func listenNamedPipe(name string) (net.Listener, error) {
+	return winio.ListenPipe(name, nil)
+}
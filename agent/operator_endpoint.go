@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -31,6 +32,125 @@ func (s *HTTPHandlers) OperatorRaftConfiguration(resp http.ResponseWriter, req *
 	return reply, nil
 }
 
+// OperatorRaftEntries returns the largest Raft log entries recently applied
+// to the FSM. With the stale query mode, the server receiving the request
+// answers instead of the leader.
+func (s *HTTPHandlers) OperatorRaftEntries(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.RaftLargestEntriesRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	if limit := req.URL.Query().Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Invalid limit: %q", limit)}
+		}
+		args.Limit = n
+	}
+
+	var reply structs.RaftLargestEntriesResponse
+	if err := s.agent.RPC("Operator.RaftLargestEntries", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return reply, nil
+}
+
+// OperatorRaftCompaction reports on the Raft log and its snapshot policy with
+// a GET, and compacts the log of every server with a PUT. The stale query mode
+// reports on, or only compacts, the server receiving the request.
+func (s *HTTPHandlers) OperatorRaftCompaction(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.RaftCompactionRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	method := "Operator.RaftCompactionReport"
+	if req.Method == "PUT" {
+		method = "Operator.RaftCompact"
+	}
+
+	var reply structs.RaftCompactionReport
+	if err := s.agent.RPC(method, &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return reply, nil
+}
+
+// OperatorRaftLogs pages through the Raft log of a server, from the most
+// recent entries to the older ones. With the stale query mode, the server
+// receiving the request answers instead of the leader.
+func (s *HTTPHandlers) OperatorRaftLogs(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.RaftLogsRequest
+
+	// The index is checked first since parse would otherwise take it for the
+	// index of a blocking query. The endpoint doesn't block, so that's
+	// harmless once it's valid.
+	params := req.URL.Query()
+	if index := params.Get("index"); index != "" {
+		n, err := strconv.ParseUint(index, 10, 64)
+		if err != nil {
+			return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Invalid index: %q", index)}
+		}
+		args.Index = n
+	}
+
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Invalid limit: %q", limit)}
+		}
+		args.Limit = n
+	}
+
+	var reply structs.RaftLogsResponse
+	if err := s.agent.RPC("Operator.RaftLogs", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return reply, nil
+}
+
+// OperatorRaftLogsReplay replays a range of the Raft log of a server into a
+// scratch FSM. With the stale query mode, the server receiving the request
+// answers instead of the leader.
+func (s *HTTPHandlers) OperatorRaftLogsReplay(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.RaftLogsReplayRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	params := req.URL.Query()
+	start := params.Get("start")
+	if start == "" {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: "Missing start index"}
+	}
+	n, err := strconv.ParseUint(start, 10, 64)
+	if err != nil || n == 0 {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Invalid start index: %q", start)}
+	}
+	args.StartIndex = n
+	if end := params.Get("end"); end != "" {
+		n, err := strconv.ParseUint(end, 10, 64)
+		if err != nil {
+			return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Invalid end index: %q", end)}
+		}
+		args.EndIndex = n
+	}
+
+	var reply structs.RaftLogsReplayResponse
+	if err := s.agent.RPC("Operator.RaftLogsReplay", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return reply, nil
+}
+
 // OperatorRaftPeer supports actions on Raft peers. Currently we only support
 // removing peers by address.
 func (s *HTTPHandlers) OperatorRaftPeer(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
@@ -326,6 +446,47 @@ func (s *HTTPHandlers) OperatorAutopilotState(resp http.ResponseWriter, req *htt
 	return out, nil
 }
 
+// OperatorUsage reports the contents of the state store. With the detailed
+// parameter set it also reports the number of rows and estimated memory of
+// each table.
+func (s *HTTPHandlers) OperatorUsage(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.OperatorUsageRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+	if _, ok := req.URL.Query()["detailed"]; ok {
+		args.Detailed = req.URL.Query().Get("detailed") != "false"
+	}
+
+	var reply structs.OperatorUsageResponse
+	defer setMeta(resp, &reply.QueryMeta)
+	if err := s.agent.RPC("Operator.Usage", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return reply, nil
+}
+
+// OperatorDeregistrations is used to list the nodes and service instances
+// recently removed from the catalog.
+func (s *HTTPHandlers) OperatorDeregistrations(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.DeregistrationsRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var reply structs.IndexedDeregistrations
+	defer setMeta(resp, &reply.QueryMeta)
+	if err := s.agent.RPC("Operator.Deregistrations", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	if reply.Deregistrations == nil {
+		reply.Deregistrations = make(structs.Deregistrations, 0)
+	}
+	return reply.Deregistrations, nil
+}
+
 func stringIDs(ids []raft.ServerID) []string {
 	out := make([]string, len(ids))
 	for i, id := range ids {
@@ -373,3 +534,111 @@ func autopilotToAPIServer(srv *autopilot.ServerState) api.AutopilotServer {
 
 	return apiSrv
 }
+
+// raftTransferLeaderTimeout is how long OperatorRaftTransferLeader waits for
+// the new leader to be elected once the transfer started.
+var raftTransferLeaderTimeout = 10 * time.Second
+
+// OperatorRaftTransferLeader transfers the leadership of the cluster to the
+// server given by ?id, or to the best candidate otherwise, once the pre-flight
+// checks passed. The progress of the transfer is streamed as newline-delimited
+// JSON events.
+func (s *HTTPHandlers) OperatorRaftTransferLeader(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.RaftTransferLeaderRequest
+	s.parseDC(req, &args.Datacenter)
+	s.parseToken(req, &args.Token)
+
+	params := req.URL.Query()
+	args.ID = raft.ServerID(params.Get("id"))
+	_, dryRun := params["dry-run"]
+	_, args.Force = params["force"]
+
+	// Run the checks first, so that invalid requests get a regular error
+	// response.
+	args.DryRun = true
+	var checked structs.RaftTransferLeaderResponse
+	if err := s.agent.RPC("Operator.RaftTransferLeader", &args, &checked); err != nil {
+		return nil, err
+	}
+
+	flusher, ok := resp.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("streaming not supported")
+	}
+	resp.WriteHeader(http.StatusOK)
+
+	// 0 byte write is needed before the Flush call so that if we are using
+	// a gzip stream it will go ahead and write out the HTTP response header
+	resp.Write([]byte(""))
+	flusher.Flush()
+
+	enc := json.NewEncoder(resp)
+	emit := func(event structs.RaftTransferLeaderEvent) {
+		if err := enc.Encode(event); err != nil {
+			s.agent.logger.Debug("failed to write leadership transfer event", "error", err)
+			return
+		}
+		flusher.Flush()
+	}
+
+	emit(structs.RaftTransferLeaderEvent{
+		Status: api.RaftTransferLeaderChecked,
+		ID:     checked.ID,
+		Node:   checked.Node,
+		Checks: checked.Checks,
+	})
+	if !checked.Passed && !args.Force {
+		emit(structs.RaftTransferLeaderEvent{
+			Status: api.RaftTransferLeaderFailed,
+			Error:  "pre-flight checks failed, use ?force to transfer the leadership anyway",
+		})
+		return nil, nil
+	}
+	if dryRun {
+		return nil, nil
+	}
+
+	emit(structs.RaftTransferLeaderEvent{Status: api.RaftTransferLeaderTransferring, ID: checked.ID, Node: checked.Node})
+	args.ID = checked.ID
+	args.DryRun = false
+	var reply structs.RaftTransferLeaderResponse
+	if err := s.agent.RPC("Operator.RaftTransferLeader", &args, &reply); err != nil {
+		emit(structs.RaftTransferLeaderEvent{Status: api.RaftTransferLeaderFailed, Error: err.Error()})
+		return nil, nil
+	}
+
+	// Wait for the new leader to be known, asking any server since the
+	// cluster may be without a leader for a short while.
+	confArgs := structs.DCSpecificRequest{
+		Datacenter:   args.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: args.Token, AllowStale: true},
+	}
+	deadline := time.Now().Add(raftTransferLeaderTimeout)
+	for time.Now().Before(deadline) {
+		var conf structs.RaftConfigurationResponse
+		if err := s.agent.RPC("Operator.RaftGetConfiguration", &confArgs, &conf); err == nil {
+			for _, server := range conf.Servers {
+				if server.Leader && server.ID == checked.ID {
+					emit(structs.RaftTransferLeaderEvent{
+						Status: api.RaftTransferLeaderTransferred,
+						ID:     server.ID,
+						Node:   server.Node,
+						Leader: server.Node,
+					})
+					return nil, nil
+				}
+			}
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, nil
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	emit(structs.RaftTransferLeaderEvent{
+		Status: api.RaftTransferLeaderFailed,
+		Error:  fmt.Sprintf("server %q did not become the leader within %s", checked.Node, raftTransferLeaderTimeout),
+	})
+	return nil, nil
+}
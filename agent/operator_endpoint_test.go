@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -49,6 +50,156 @@ func TestOperator_RaftConfiguration(t *testing.T) {
 	}
 }
 
+func TestOperator_RaftEntries(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	t.Run("invalid limit", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/operator/raft/entries?limit=nope", nil)
+		resp := httptest.NewRecorder()
+		_, err := a.srv.OperatorRaftEntries(resp, req)
+		require.Error(t, err)
+		httpErr, ok := err.(HTTPError)
+		require.True(t, ok)
+		require.Equal(t, http.StatusBadRequest, httpErr.StatusCode)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/operator/raft/entries?limit=1", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.OperatorRaftEntries(resp, req)
+		require.NoError(t, err)
+		out, ok := obj.(structs.RaftLargestEntriesResponse)
+		require.True(t, ok, "unexpected: %T", obj)
+		require.Equal(t, a.Config.NodeName, out.Server)
+		require.Len(t, out.Entries, 1)
+	})
+}
+
+func TestOperator_RaftCompaction(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	req, _ := http.NewRequest("GET", "/v1/operator/raft/compaction", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.OperatorRaftCompaction(resp, req)
+	require.NoError(t, err)
+	report, ok := obj.(structs.RaftCompactionReport)
+	require.True(t, ok, "unexpected: %T", obj)
+	require.Equal(t, a.Config.NodeName, report.Server)
+	require.NotEmpty(t, report.Usage)
+
+	req, _ = http.NewRequest("PUT", "/v1/operator/raft/compaction", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.OperatorRaftCompaction(resp, req)
+	require.NoError(t, err)
+	report = obj.(structs.RaftCompactionReport)
+	require.Equal(t, report.LastIndex, report.LastSnapshotIndex)
+}
+
+func TestOperator_RaftLogs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	t.Run("invalid index", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/operator/raft/logs?index=nope", nil)
+		resp := httptest.NewRecorder()
+		_, err := a.srv.OperatorRaftLogs(resp, req)
+		require.Error(t, err)
+		httpErr, ok := err.(HTTPError)
+		require.True(t, ok)
+		require.Equal(t, http.StatusBadRequest, httpErr.StatusCode)
+	})
+
+	t.Run("page", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/operator/raft/logs?limit=2", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.OperatorRaftLogs(resp, req)
+		require.NoError(t, err)
+		out, ok := obj.(structs.RaftLogsResponse)
+		require.True(t, ok, "unexpected: %T", obj)
+		require.Equal(t, a.Config.NodeName, out.Server)
+		require.Len(t, out.Entries, 2)
+		require.Equal(t, out.Entries[1].Index-1, out.NextIndex)
+	})
+
+	t.Run("replay without start", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/operator/raft/logs/replay", nil)
+		resp := httptest.NewRecorder()
+		_, err := a.srv.OperatorRaftLogsReplay(resp, req)
+		require.Error(t, err)
+		httpErr, ok := err.(HTTPError)
+		require.True(t, ok)
+		require.Equal(t, http.StatusBadRequest, httpErr.StatusCode)
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/operator/raft/logs/replay?start=1", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.OperatorRaftLogsReplay(resp, req)
+		require.NoError(t, err)
+		out, ok := obj.(structs.RaftLogsReplayResponse)
+		require.True(t, ok, "unexpected: %T", obj)
+		require.Equal(t, uint64(1), out.StartIndex)
+		require.NotZero(t, out.Applied)
+		require.Equal(t, 1, out.Nodes)
+	})
+}
+
+func TestOperator_RaftTransferLeader(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// Wait for autopilot to know about the leader.
+	retry.Run(t, func(r *retry.R) {
+		req, _ := http.NewRequest("PUT", "/v1/operator/raft/transfer-leader?dry-run", nil)
+		resp := httptest.NewRecorder()
+		_, err := a.srv.OperatorRaftTransferLeader(resp, req)
+		require.Error(r, err)
+		require.NotContains(r, err.Error(), "try again later")
+	})
+
+	t.Run("unknown server", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/v1/operator/raft/transfer-leader?id=nope", nil)
+		resp := httptest.NewRecorder()
+		_, err := a.srv.OperatorRaftTransferLeader(resp, req)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not in the Raft configuration")
+	})
+
+	t.Run("no other voter", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/v1/operator/raft/transfer-leader?dry-run", nil)
+		resp := httptest.NewRecorder()
+		_, err := a.srv.OperatorRaftTransferLeader(resp, req)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "there is no other voter")
+	})
+}
+
 func TestOperator_RaftPeer(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -798,3 +949,70 @@ func TestAutopilotStateToAPIConversion(t *testing.T) {
 
 	require.Equal(t, &expected, autopilotToAPIState(&input))
 }
+
+func TestOperator_Usage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	req, _ := http.NewRequest("GET", "/v1/operator/usage", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.OperatorUsage(resp, req)
+	require.NoError(t, err)
+	usage, ok := obj.(structs.OperatorUsageResponse)
+	require.True(t, ok, "unexpected: %T", obj)
+	require.Equal(t, a.Config.NodeName, usage.Server)
+	require.Equal(t, 1, usage.Nodes)
+	require.Empty(t, usage.Tables)
+	require.NotEmpty(t, resp.Header().Get("X-Consul-Index"))
+
+	req, _ = http.NewRequest("GET", "/v1/operator/usage?detailed=true", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.OperatorUsage(resp, req)
+	require.NoError(t, err)
+	usage = obj.(structs.OperatorUsageResponse)
+	require.NotEmpty(t, usage.Tables)
+}
+
+func TestOperator_Deregistrations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	req, _ := http.NewRequest("GET", "/v1/operator/deregistrations", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.OperatorDeregistrations(resp, req)
+	require.NoError(t, err)
+	require.Equal(t, structs.Deregistrations{}, obj)
+
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Service:    &structs.NodeService{ID: "web1", Service: "web"},
+	}
+	var out struct{}
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+	dereg := &structs.DeregisterRequest{Datacenter: "dc1", Node: "foo"}
+	require.NoError(t, a.RPC("Catalog.Deregister", dereg, &out))
+
+	req, _ = http.NewRequest("GET", "/v1/operator/deregistrations?filter="+url.QueryEscape(`"web" in Services`), nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.OperatorDeregistrations(resp, req)
+	require.NoError(t, err)
+	deregistrations := obj.(structs.Deregistrations)
+	require.Len(t, deregistrations, 1)
+	require.Equal(t, "foo", deregistrations[0].Node)
+	require.Equal(t, []string{"web"}, deregistrations[0].Services)
+	require.NotEmpty(t, resp.Header().Get("X-Consul-Index"))
+}
@@ -73,6 +73,9 @@ const (
 	// wan federation additions
 	ALPN_WANGossipPacket = "consul/wan-gossip/packet"
 	ALPN_WANGossipStream = "consul/wan-gossip/stream"
+	// peering streams, which don't present a client certificate and so
+	// aren't part of RPCNextProtos
+	ALPN_RPCPeering = "consul/rpc-peering"
 )
 
 var RPCNextProtos = []string{
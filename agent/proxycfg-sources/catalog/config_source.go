@@ -88,6 +88,19 @@ func (m *ConfigSource) Watch(serviceID structs.ServiceID, nodeName string, token
 	return snapCh, cancel, nil
 }
 
+// DemandUpstreams wraps the underlying proxycfg.Manager to start watching the
+// upstreams demanded by a proxy in on-demand mode. It works for proxies
+// registered to the local agent too as they share the same Manager.
+func (m *ConfigSource) DemandUpstreams(id proxycfg.ProxyID, uids ...proxycfg.UpstreamID) {
+	m.Manager.DemandUpstreams(id, uids...)
+}
+
+// ReportUpstreamsActivity wraps the underlying proxycfg.Manager to record the
+// load reports of a proxy in on-demand mode.
+func (m *ConfigSource) ReportUpstreamsActivity(id proxycfg.ProxyID, uids ...proxycfg.UpstreamID) {
+	m.Manager.ReportUpstreamsActivity(id, uids...)
+}
+
 func (m *ConfigSource) Shutdown() {
 	close(m.shutdownCh)
 }
@@ -246,6 +259,8 @@ type ConfigManager interface {
 	Watch(req proxycfg.ProxyID) (<-chan *proxycfg.ConfigSnapshot, proxycfg.CancelFunc)
 	Register(proxyID proxycfg.ProxyID, service *structs.NodeService, source proxycfg.ProxySource, token string, overwrite bool) error
 	Deregister(proxyID proxycfg.ProxyID, source proxycfg.ProxySource)
+	DemandUpstreams(id proxycfg.ProxyID, uids ...proxycfg.UpstreamID)
+	ReportUpstreamsActivity(id proxycfg.ProxyID, uids ...proxycfg.UpstreamID)
 }
 
 type Store interface {
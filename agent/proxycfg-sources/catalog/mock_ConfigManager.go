@@ -16,6 +16,18 @@ type MockConfigManager struct {
 	mock.Mock
 }
 
+// DemandUpstreams provides a mock function with given fields: id, uids
+func (_m *MockConfigManager) DemandUpstreams(id proxycfg.ProxyID, uids ...proxycfg.UpstreamID) {
+	_va := make([]interface{}, len(uids))
+	for _i := range uids {
+		_va[_i] = uids[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, id)
+	_ca = append(_ca, _va...)
+	_m.Called(_ca...)
+}
+
 // Deregister provides a mock function with given fields: proxyID, source
 func (_m *MockConfigManager) Deregister(proxyID proxycfg.ProxyID, source proxycfg.ProxySource) {
 	_m.Called(proxyID, source)
@@ -35,6 +47,18 @@ func (_m *MockConfigManager) Register(proxyID proxycfg.ProxyID, service *structs
 	return r0
 }
 
+// ReportUpstreamsActivity provides a mock function with given fields: id, uids
+func (_m *MockConfigManager) ReportUpstreamsActivity(id proxycfg.ProxyID, uids ...proxycfg.UpstreamID) {
+	_va := make([]interface{}, len(uids))
+	for _i := range uids {
+		_va[_i] = uids[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, id)
+	_ca = append(_ca, _va...)
+	_m.Called(_ca...)
+}
+
 // Watch provides a mock function with given fields: req
 func (_m *MockConfigManager) Watch(req proxycfg.ProxyID) (<-chan *proxycfg.ConfigSnapshot, proxycfg.CancelFunc) {
 	ret := _m.Called(req)
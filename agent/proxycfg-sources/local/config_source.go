@@ -29,3 +29,15 @@ func (m *ConfigSource) Watch(serviceID structs.ServiceID, nodeName string, _ str
 	})
 	return watchCh, cancelWatch, nil
 }
+
+// DemandUpstreams wraps the underlying proxycfg.Manager to start watching the
+// upstreams demanded by a proxy in on-demand mode.
+func (m *ConfigSource) DemandUpstreams(id proxycfg.ProxyID, uids ...proxycfg.UpstreamID) {
+	m.manager.DemandUpstreams(id, uids...)
+}
+
+// ReportUpstreamsActivity wraps the underlying proxycfg.Manager to record the
+// load reports of a proxy in on-demand mode.
+func (m *ConfigSource) ReportUpstreamsActivity(id proxycfg.ProxyID, uids ...proxycfg.UpstreamID) {
+	m.manager.ReportUpstreamsActivity(id, uids...)
+}
@@ -122,4 +122,6 @@ type ConfigManager interface {
 	Register(proxyID proxycfg.ProxyID, service *structs.NodeService, source proxycfg.ProxySource, token string, overwrite bool) error
 	Deregister(proxyID proxycfg.ProxyID, source proxycfg.ProxySource)
 	RegisteredProxies(source proxycfg.ProxySource) []proxycfg.ProxyID
+	DemandUpstreams(id proxycfg.ProxyID, uids ...proxycfg.UpstreamID)
+	ReportUpstreamsActivity(id proxycfg.ProxyID, uids ...proxycfg.UpstreamID)
 }
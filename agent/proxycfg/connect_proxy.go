@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
 
 	cachetype "github.com/hashicorp/consul/agent/cache-types"
 	"github.com/hashicorp/consul/agent/structs"
@@ -33,6 +36,30 @@ func (s *handlerConnectProxy) initialize(ctx context.Context) (ConfigSnapshot, e
 	snap.ConnectProxy.PassthroughIndices = make(map[string]indexedTarget)
 	snap.ConnectProxy.PeerUpstreamEndpoints = make(map[UpstreamID]structs.CheckServiceNodes)
 	snap.ConnectProxy.PeerUpstreamEndpointsUseHostnames = make(map[UpstreamID]struct{})
+	snap.ConnectProxy.DemandedUpstreams = make(map[UpstreamID]time.Time)
+
+	if s.proxyCfg.Mode == structs.ProxyModeTransparent {
+		cfg, err := parseReducedProxyConfig(s.proxyCfg.Config)
+		if err != nil {
+			// Don't hard fail on a config typo, just warn and watch all the
+			// upstreams.
+			s.logger.Warn("failed to parse proxy config", "error", err)
+		}
+		snap.ConnectProxy.OnDemandUpstreams = cfg.OnDemandUpstreams
+
+		if cfg.OnDemandUpstreams && cfg.OnDemandUpstreamsTTL > 0 {
+			ttl := cfg.OnDemandUpstreamsTTL
+			if ttl < minOnDemandUpstreamsTTL {
+				s.logger.Warn("on_demand_upstreams_ttl is too short, using the minimum instead",
+					"ttl", ttl,
+					"minimum", minOnDemandUpstreamsTTL,
+				)
+				ttl = minOnDemandUpstreamsTTL
+			}
+			snap.ConnectProxy.OnDemandUpstreamsTTL = ttl
+			go s.notifyUpstreamsExpiry(ctx, ttl/2)
+		}
+	}
 
 	// Watch for root changes
 	err := s.dataSources.CARoots.Notify(ctx, &structs.DCSpecificRequest{
@@ -118,6 +145,8 @@ func (s *handlerConnectProxy) initialize(ctx context.Context) (ConfigSnapshot, e
 		}
 	}
 
+	upstreamDefaults := s.upstreamDefaults()
+
 	// Watch for updates to service endpoints for all upstreams
 	for i := range s.proxyCfg.Upstreams {
 		u := s.proxyCfg.Upstreams[i]
@@ -143,6 +172,8 @@ func (s *handlerConnectProxy) initialize(ctx context.Context) (ConfigSnapshot, e
 		}
 		if s.proxyCfg.Mode == structs.ProxyModeTransparent && (dc == "" || dc == s.source.Datacenter) {
 			// In transparent proxy mode, watches for upstreams in the local DC are handled by the IntentionUpstreams watch.
+			// The explicit definition only overrides the centralized defaults it would otherwise inherit.
+			snap.ConnectProxy.UpstreamConfig[uid] = mergeImplicitUpstream(upstreamDefaults, &u)
 			continue
 		}
 
@@ -302,61 +333,36 @@ func (s *handlerConnectProxy) handleUpdate(ctx context.Context, u UpdateEvent, s
 			return fmt.Errorf("invalid type for response %T", u.Result)
 		}
 
-		seenUpstreams := make(map[UpstreamID]struct{})
+		seenUpstreams := upstreamIDSets.get()
 		for _, svc := range resp.Services {
 			uid := NewUpstreamIDFromServiceName(svc)
 
 			seenUpstreams[uid] = struct{}{}
 
-			cfgMap := make(map[string]interface{})
-			u, ok := snap.ConnectProxy.UpstreamConfig[uid]
-			if ok {
-				cfgMap = u.Config
-			} else {
-				// Use the centralized upstream defaults if they exist and there isn't specific configuration for this upstream
-				// This is only relevant to upstreams from intentions because for explicit upstreams the defaulting is handled
-				// by the ResolveServiceConfig endpoint.
-				wildcardSID := structs.NewServiceID(structs.WildcardSpecifier, s.proxyID.WithWildcardNamespace())
-				wildcardUID := NewUpstreamIDFromServiceID(wildcardSID)
-				defaults, ok := snap.ConnectProxy.UpstreamConfig[wildcardUID]
-				if ok {
-					u = defaults
-					cfgMap = defaults.Config
-					snap.ConnectProxy.UpstreamConfig[uid] = defaults
+			// In on-demand mode the upstreams are only watched once the proxy
+			// requests them, see upstreamsDemandID.
+			if snap.ConnectProxy.OnDemandUpstreams {
+				if _, ok := snap.ConnectProxy.DemandedUpstreams[uid]; !ok {
+					continue
 				}
 			}
 
-			cfg, err := parseReducedUpstreamConfig(cfgMap)
-			if err != nil {
-				// Don't hard fail on a config typo, just warn. We'll fall back on
-				// the plain discovery chain if there is an error so it's safe to
-				// continue.
-				s.logger.Warn("failed to parse upstream config",
-					"upstream", uid,
-					"error", err,
-				)
+			if err := s.watchIntentionUpstream(ctx, snap, svc); err != nil {
+				return err
 			}
+		}
+		// Clones get their own copy of the set, so the previous one is only
+		// referenced by the snapshot of the handler.
+		upstreamIDSets.put(snap.ConnectProxy.IntentionUpstreams)
+		snap.ConnectProxy.IntentionUpstreams = seenUpstreams
 
-			meshGateway := s.proxyCfg.MeshGateway
-			if u != nil {
-				meshGateway = meshGateway.OverlayWith(u.MeshGateway)
-			}
-			watchOpts := discoveryChainWatchOpts{
-				id:          NewUpstreamIDFromServiceName(svc),
-				name:        svc.Name,
-				namespace:   svc.NamespaceOrDefault(),
-				partition:   svc.PartitionOrDefault(),
-				datacenter:  s.source.Datacenter,
-				cfg:         cfg,
-				meshGateway: meshGateway,
-			}
-			up := &handlerUpstreams{handlerState: s.handlerState}
-			err = up.watchDiscoveryChain(ctx, snap, watchOpts)
-			if err != nil {
-				return fmt.Errorf("failed to watch discovery chain for %s: %v", uid, err)
+		// Forget the demanded upstreams which are no longer allowed, so that
+		// they are only watched again once they are demanded again.
+		for uid := range snap.ConnectProxy.DemandedUpstreams {
+			if _, ok := seenUpstreams[uid]; !ok {
+				delete(snap.ConnectProxy.DemandedUpstreams, uid)
 			}
 		}
-		snap.ConnectProxy.IntentionUpstreams = seenUpstreams
 
 		// Clean up data from services that were not in the update
 		for uid, targets := range snap.ConnectProxy.WatchedUpstreams {
@@ -430,6 +436,78 @@ func (s *handlerConnectProxy) handleUpdate(ctx context.Context, u UpdateEvent, s
 			}
 		}
 
+	case u.CorrelationID == upstreamsDemandID:
+		uids, ok := u.Result.([]UpstreamID)
+		if !ok {
+			return fmt.Errorf("invalid type for response: %T", u.Result)
+		}
+		if !snap.ConnectProxy.OnDemandUpstreams {
+			return nil
+		}
+
+		now := time.Now()
+		for _, uid := range uids {
+			// Demanding an upstream again records its activity.
+			if _, ok := snap.ConnectProxy.DemandedUpstreams[uid]; ok {
+				snap.ConnectProxy.DemandedUpstreams[uid] = now
+				continue
+			}
+			// Only the upstreams allowed by intentions can be demanded.
+			if _, ok := snap.ConnectProxy.IntentionUpstreams[uid]; !ok {
+				s.logger.Debug("ignoring demand for an upstream not inferred from intentions", "upstream", uid)
+				continue
+			}
+
+			snap.ConnectProxy.DemandedUpstreams[uid] = now
+			svc := structs.NewServiceName(uid.Name, &uid.EnterpriseMeta)
+			if err := s.watchIntentionUpstream(ctx, snap, svc); err != nil {
+				return err
+			}
+		}
+
+	case u.CorrelationID == upstreamsActivityID:
+		activity, ok := u.Result.(upstreamsActivity)
+		if !ok {
+			return fmt.Errorf("invalid type for response: %T", u.Result)
+		}
+		ttl := snap.ConnectProxy.OnDemandUpstreamsTTL
+		if ttl == 0 {
+			return nil
+		}
+
+		// The reports of a stream which stopped reporting for a while, or
+		// reconnected, don't account for the activity in between.
+		if !loadReporting(snap, activity.reportedAt) {
+			snap.ConnectProxy.LoadReportingSince = activity.reportedAt
+		}
+		snap.ConnectProxy.LoadReportedAt = activity.reportedAt
+		for _, uid := range activity.uids {
+			if _, ok := snap.ConnectProxy.DemandedUpstreams[uid]; ok {
+				snap.ConnectProxy.DemandedUpstreams[uid] = activity.reportedAt
+			}
+		}
+
+	case u.CorrelationID == upstreamsExpiryID:
+		now, ok := u.Result.(time.Time)
+		if !ok {
+			return fmt.Errorf("invalid type for response: %T", u.Result)
+		}
+
+		// The activity of the upstreams is only known while the proxy
+		// reports its load, and was reporting it for at least the TTL.
+		ttl := snap.ConnectProxy.OnDemandUpstreamsTTL
+		if ttl == 0 || !loadReporting(snap, now) || now.Sub(snap.ConnectProxy.LoadReportingSince) < ttl {
+			return nil
+		}
+		for uid, lastActive := range snap.ConnectProxy.DemandedUpstreams {
+			if ttl == 0 || now.Sub(lastActive) < ttl {
+				continue
+			}
+			s.logger.Debug("pruning idle upstream", "upstream", uid, "last_active", lastActive)
+			s.stopWatchingIntentionUpstream(snap, uid)
+			delete(snap.ConnectProxy.DemandedUpstreams, uid)
+		}
+
 	case strings.HasPrefix(u.CorrelationID, "upstream:"+preparedQueryIDPrefix):
 		resp, ok := u.Result.(*structs.PreparedQueryExecuteResponse)
 		if !ok {
@@ -452,3 +530,156 @@ func (s *handlerConnectProxy) handleUpdate(ctx context.Context, u UpdateEvent, s
 	}
 	return nil
 }
+
+// upstreamDefaults returns the centralized defaults of the upstreams of the
+// proxy, which are stored as a synthetic upstream with a wildcard name.
+func (s *handlerConnectProxy) upstreamDefaults() *structs.Upstream {
+	for i := range s.proxyCfg.Upstreams {
+		if s.proxyCfg.Upstreams[i].DestinationName == structs.WildcardSpecifier {
+			return &s.proxyCfg.Upstreams[i]
+		}
+	}
+	return nil
+}
+
+// watchIntentionUpstream watches the discovery chain of an upstream inferred
+// from intentions.
+func (s *handlerConnectProxy) watchIntentionUpstream(ctx context.Context, snap *ConfigSnapshot, svc structs.ServiceName) error {
+	uid := NewUpstreamIDFromServiceName(svc)
+
+	cfgMap := make(map[string]interface{})
+	u, ok := snap.ConnectProxy.UpstreamConfig[uid]
+	if ok {
+		cfgMap = u.Config
+	} else {
+		// Use the centralized upstream defaults if they exist and there isn't specific configuration for this upstream
+		// This is only relevant to upstreams from intentions because for explicit upstreams the defaulting is handled
+		// by the ResolveServiceConfig endpoint.
+		wildcardSID := structs.NewServiceID(structs.WildcardSpecifier, s.proxyID.WithWildcardNamespace())
+		wildcardUID := NewUpstreamIDFromServiceID(wildcardSID)
+		defaults, ok := snap.ConnectProxy.UpstreamConfig[wildcardUID]
+		if ok {
+			u = defaults
+			cfgMap = defaults.Config
+			snap.ConnectProxy.UpstreamConfig[uid] = defaults
+		}
+	}
+
+	cfg, err := parseReducedUpstreamConfig(cfgMap)
+	if err != nil {
+		// Don't hard fail on a config typo, just warn. We'll fall back on
+		// the plain discovery chain if there is an error so it's safe to
+		// continue.
+		s.logger.Warn("failed to parse upstream config",
+			"upstream", uid,
+			"error", err,
+		)
+	}
+
+	meshGateway := s.proxyCfg.MeshGateway
+	if u != nil {
+		meshGateway = meshGateway.OverlayWith(u.MeshGateway)
+	}
+	watchOpts := discoveryChainWatchOpts{
+		id:          uid,
+		name:        svc.Name,
+		namespace:   svc.NamespaceOrDefault(),
+		partition:   svc.PartitionOrDefault(),
+		datacenter:  s.source.Datacenter,
+		cfg:         cfg,
+		meshGateway: meshGateway,
+	}
+	up := &handlerUpstreams{handlerState: s.handlerState}
+	err = up.watchDiscoveryChain(ctx, snap, watchOpts)
+	if err != nil {
+		return fmt.Errorf("failed to watch discovery chain for %s: %v", uid, err)
+	}
+	return nil
+}
+
+// stopWatchingIntentionUpstream cancels the watches of an upstream inferred
+// from intentions and removes its data from the snapshot, so that it is only
+// watched again once it is demanded again. The watches of explicit upstreams
+// are left as-is.
+func (s *handlerConnectProxy) stopWatchingIntentionUpstream(snap *ConfigSnapshot, uid UpstreamID) {
+	if u, ok := snap.ConnectProxy.UpstreamConfig[uid]; ok && !u.CentrallyConfigured {
+		return
+	}
+
+	for _, cancelFn := range snap.ConnectProxy.WatchedUpstreams[uid] {
+		cancelFn()
+	}
+	delete(snap.ConnectProxy.WatchedUpstreams, uid)
+	delete(snap.ConnectProxy.WatchedUpstreamEndpoints, uid)
+
+	for _, cancelFn := range snap.ConnectProxy.WatchedGateways[uid] {
+		cancelFn()
+	}
+	delete(snap.ConnectProxy.WatchedGateways, uid)
+	delete(snap.ConnectProxy.WatchedGatewayEndpoints, uid)
+
+	if cancelFn, ok := snap.ConnectProxy.WatchedDiscoveryChains[uid]; ok {
+		cancelFn()
+		delete(snap.ConnectProxy.WatchedDiscoveryChains, uid)
+	}
+	delete(snap.ConnectProxy.DiscoveryChain, uid)
+
+	delete(snap.ConnectProxy.PassthroughUpstreams, uid)
+	for addr, indexed := range snap.ConnectProxy.PassthroughIndices {
+		if indexed.upstreamID == uid {
+			delete(snap.ConnectProxy.PassthroughIndices, addr)
+		}
+	}
+}
+
+// loadReporting returns whether the proxy is reporting its load at the given
+// time, which is when its latest report is recent enough for the reports not
+// to have stopped. The proxy is asked to report at least every quarter of the
+// TTL.
+func loadReporting(snap *ConfigSnapshot, now time.Time) bool {
+	reportedAt := snap.ConnectProxy.LoadReportedAt
+	return !reportedAt.IsZero() && now.Sub(reportedAt) <= snap.ConnectProxy.OnDemandUpstreamsTTL/2
+}
+
+// notifyUpstreamsExpiry periodically asks the proxy in on-demand mode to prune
+// the upstreams without activity, until ctx is canceled.
+func (s *handlerConnectProxy) notifyUpstreamsExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			select {
+			case s.ch <- UpdateEvent{CorrelationID: upstreamsExpiryID, Result: now}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// reducedProxyConfig represents the opaque proxy config values that affect the
+// watches set up for a connect proxy.
+//
+// The full-blown config is agent/xds.ProxyConfig
+type reducedProxyConfig struct {
+	OnDemandUpstreams    bool          `mapstructure:"on_demand_upstreams"`
+	OnDemandUpstreamsTTL time.Duration `mapstructure:"on_demand_upstreams_ttl"`
+}
+
+func parseReducedProxyConfig(m map[string]interface{}) (reducedProxyConfig, error) {
+	var cfg reducedProxyConfig
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		Result:           &cfg,
+		WeaklyTypedInput: true,
+	})
+	if err != nil {
+		return cfg, err
+	}
+	err = decoder.Decode(m)
+	return cfg, err
+}
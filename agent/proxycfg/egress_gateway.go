@@ -0,0 +1,158 @@
+package proxycfg
+
+import (
+	"context"
+	"fmt"
+
+	cachetype "github.com/hashicorp/consul/agent/cache-types"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+type handlerEgressGateway struct {
+	handlerState
+}
+
+// initialize sets up the watches needed based on the current egress gateway
+// registration. Unlike terminating gateways, egress gateways are not linked to
+// the services using them, so only the gateway's own state is watched.
+func (s *handlerEgressGateway) initialize(ctx context.Context) (ConfigSnapshot, error) {
+	snap := newConfigSnapshotFromServiceInstance(s.serviceInstance, s.stateConfig)
+	// Watch for root changes
+	err := s.dataSources.CARoots.Notify(ctx, &structs.DCSpecificRequest{
+		Datacenter:   s.source.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: s.token},
+		Source:       *s.source,
+	}, rootsWatchID, s.ch)
+	if err != nil {
+		return snap, err
+	}
+
+	// Get information about the entire service mesh.
+	err = s.dataSources.ConfigEntry.Notify(ctx, &structs.ConfigEntryQuery{
+		Kind:           structs.MeshConfig,
+		Name:           structs.MeshConfigMesh,
+		Datacenter:     s.source.Datacenter,
+		QueryOptions:   structs.QueryOptions{Token: s.token},
+		EnterpriseMeta: *structs.DefaultEnterpriseMetaInPartition(s.proxyID.PartitionOrDefault()),
+	}, meshConfigEntryID, s.ch)
+	if err != nil {
+		return snap, err
+	}
+
+	// Watch this egress gateway's config entry
+	err = s.dataSources.ConfigEntry.Notify(ctx, &structs.ConfigEntryQuery{
+		Kind:           structs.EgressGateway,
+		Name:           s.service,
+		Datacenter:     s.source.Datacenter,
+		QueryOptions:   structs.QueryOptions{Token: s.token},
+		EnterpriseMeta: s.proxyID.EnterpriseMeta,
+	}, gatewayConfigWatchID, s.ch)
+	if err != nil {
+		return snap, err
+	}
+
+	// Watch the leaf cert presented to the services using the gateway
+	err = s.dataSources.LeafCertificate.Notify(ctx, &cachetype.ConnectCALeafRequest{
+		Datacenter:     s.source.Datacenter,
+		Token:          s.token,
+		Service:        s.service,
+		EnterpriseMeta: s.proxyID.EnterpriseMeta,
+	}, leafWatchID, s.ch)
+	if err != nil {
+		return snap, err
+	}
+
+	// Watch for the intentions authorizing services to use the gateway
+	err = s.dataSources.Intentions.Notify(ctx, &structs.IntentionQueryRequest{
+		Datacenter:   s.source.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: s.token},
+		Match: &structs.IntentionQueryMatch{
+			Type: structs.IntentionMatchDestination,
+			Entries: []structs.IntentionMatchEntry{
+				{
+					Namespace: s.proxyID.NamespaceOrDefault(),
+					Partition: s.proxyID.PartitionOrDefault(),
+					Name:      s.service,
+				},
+			},
+		},
+	}, intentionsWatchID, s.ch)
+	if err != nil {
+		return snap, err
+	}
+
+	return snap, nil
+}
+
+func (s *handlerEgressGateway) handleUpdate(_ context.Context, u UpdateEvent, snap *ConfigSnapshot) error {
+	if u.Err != nil {
+		return fmt.Errorf("error filling agent cache: %v", u.Err)
+	}
+
+	switch u.CorrelationID {
+	case rootsWatchID:
+		roots, ok := u.Result.(*structs.IndexedCARoots)
+		if !ok {
+			return fmt.Errorf("invalid type for response: %T", u.Result)
+		}
+		snap.Roots = roots
+
+	case meshConfigEntryID:
+		resp, ok := u.Result.(*structs.ConfigEntryResponse)
+		if !ok {
+			return fmt.Errorf("invalid type for response: %T", u.Result)
+		}
+
+		if resp.Entry != nil {
+			meshConf, ok := resp.Entry.(*structs.MeshConfigEntry)
+			if !ok {
+				return fmt.Errorf("invalid type for config entry: %T", resp.Entry)
+			}
+			snap.EgressGateway.MeshConfig = meshConf
+		} else {
+			snap.EgressGateway.MeshConfig = nil
+		}
+		snap.EgressGateway.MeshConfigSet = true
+
+	case gatewayConfigWatchID:
+		resp, ok := u.Result.(*structs.ConfigEntryResponse)
+		if !ok {
+			return fmt.Errorf("invalid type for response: %T", u.Result)
+		}
+
+		// A gateway without a config entry doesn't allow any destination.
+		if resp.Entry != nil {
+			gatewayConf, ok := resp.Entry.(*structs.EgressGatewayConfigEntry)
+			if !ok {
+				return fmt.Errorf("invalid type for config entry: %T", resp.Entry)
+			}
+			snap.EgressGateway.GatewayConfig = gatewayConf
+		} else {
+			snap.EgressGateway.GatewayConfig = nil
+		}
+		snap.EgressGateway.GatewayConfigLoaded = true
+
+	case leafWatchID:
+		leaf, ok := u.Result.(*structs.IssuedCert)
+		if !ok {
+			return fmt.Errorf("invalid type for response: %T", u.Result)
+		}
+		snap.EgressGateway.Leaf = leaf
+
+	case intentionsWatchID:
+		resp, ok := u.Result.(*structs.IndexedIntentionMatches)
+		if !ok {
+			return fmt.Errorf("invalid type for response: %T", u.Result)
+		}
+		if len(resp.Matches) > 0 {
+			// We only ever query the gateway itself, so pick the one result set.
+			snap.EgressGateway.Intentions = resp.Matches[0]
+		}
+		snap.EgressGateway.IntentionsSet = true
+
+	default:
+		// do nothing
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package proxycfg
+
+import (
+	"strings"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// mergeImplicitUpstream returns the effective definition of an upstream of a
+// transparent proxy which is both inferred from intentions and explicitly
+// defined in the proxy registration.
+//
+// Rather than fully shadowing the centralized upstream defaults applied to
+// implicit upstreams, the explicit definition only overrides the fields it
+// sets, with the following precedence from lowest to highest:
+//
+//  1. Centralized upstream defaults (upstream_config.defaults).
+//  2. Opaque config keys of the explicit upstream, such as its protocol or
+//     connection timeout.
+//  3. Each field of the explicit limits and passive health check, so that
+//     setting max_connections explicitly keeps the default max_pending_requests.
+//  4. The mesh gateway mode of the explicit upstream, unless left to default.
+//
+// Everything else, like the local bind address, comes from the explicit
+// upstream. The upstreams are not modified.
+func mergeImplicitUpstream(defaults, explicit *structs.Upstream) *structs.Upstream {
+	if defaults == nil || explicit == nil {
+		return explicit
+	}
+	if explicit.DestinationType != "" && explicit.DestinationType != structs.UpstreamDestTypeService {
+		return explicit
+	}
+
+	merged := *explicit
+	merged.MeshGateway = defaults.MeshGateway.OverlayWith(explicit.MeshGateway)
+
+	cfg := make(map[string]interface{}, len(defaults.Config)+len(explicit.Config))
+	for k, v := range defaults.Config {
+		cfg[k] = v
+	}
+	for k, v := range explicit.Config {
+		// Keys may be set with different casing, e.g. "Limits" and "limits".
+		deleteUpstreamConfigKey(cfg, k)
+		cfg[k] = v
+	}
+
+	// The mesh gateway mode is resolved above, like the service manager does for
+	// explicit upstreams.
+	deleteUpstreamConfigKey(cfg, "mesh_gateway")
+
+	// Opaque maps can't merge nested values, so merge the structured ones field
+	// by field. Parse errors are ignored: the maps are merged key by key already
+	// and will be reported when they're parsed to generate the proxy config.
+	defaultsCfg, err := structs.ParseUpstreamConfigNoDefaults(defaults.Config)
+	if err != nil {
+		return &merged
+	}
+	explicitCfg, err := structs.ParseUpstreamConfigNoDefaults(explicit.Config)
+	if err != nil {
+		return &merged
+	}
+	if limits := mergeUpstreamLimits(defaultsCfg.Limits, explicitCfg.Limits); limits != nil {
+		deleteUpstreamConfigKey(cfg, "limits")
+		cfg["limits"] = limits
+	}
+	if check := mergePassiveHealthCheck(defaultsCfg.PassiveHealthCheck, explicitCfg.PassiveHealthCheck); check != nil {
+		deleteUpstreamConfigKey(cfg, "passive_health_check")
+		cfg["passive_health_check"] = check
+	}
+
+	merged.Config = cfg
+	return &merged
+}
+
+func mergeUpstreamLimits(defaults, explicit *structs.UpstreamLimits) *structs.UpstreamLimits {
+	if defaults == nil || explicit == nil {
+		return nil
+	}
+	limits, overrides := defaults.Clone(), explicit.Clone()
+	if overrides.MaxConnections != nil {
+		limits.MaxConnections = overrides.MaxConnections
+	}
+	if overrides.MaxPendingRequests != nil {
+		limits.MaxPendingRequests = overrides.MaxPendingRequests
+	}
+	if overrides.MaxConcurrentRequests != nil {
+		limits.MaxConcurrentRequests = overrides.MaxConcurrentRequests
+	}
+	return limits
+}
+
+func mergePassiveHealthCheck(defaults, explicit *structs.PassiveHealthCheck) *structs.PassiveHealthCheck {
+	if defaults == nil || explicit == nil {
+		return nil
+	}
+	check := defaults.Clone()
+	if explicit.Interval != 0 {
+		check.Interval = explicit.Interval
+	}
+	if explicit.MaxFailures != 0 {
+		check.MaxFailures = explicit.MaxFailures
+	}
+	return check
+}
+
+// deleteUpstreamConfigKey deletes the given key from an opaque upstream config
+// regardless of its casing, e.g. "passive_health_check" also deletes
+// "PassiveHealthCheck".
+func deleteUpstreamConfigKey(cfg map[string]interface{}, key string) {
+	normalized := normalizeUpstreamConfigKey(key)
+	for k := range cfg {
+		if normalizeUpstreamConfigKey(k) == normalized {
+			delete(cfg, k)
+		}
+	}
+}
+
+func normalizeUpstreamConfigKey(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", ""))
+}
@@ -0,0 +1,143 @@
+package proxycfg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestMergeImplicitUpstream(t *testing.T) {
+	intPtr := func(i int) *int {
+		return &i
+	}
+
+	defaults := &structs.Upstream{
+		DestinationName:     structs.WildcardSpecifier,
+		CentrallyConfigured: true,
+		MeshGateway:         structs.MeshGatewayConfig{Mode: structs.MeshGatewayModeRemote},
+		Config: map[string]interface{}{
+			"protocol":           "http",
+			"connect_timeout_ms": 1000,
+			"mesh_gateway":       structs.MeshGatewayConfig{Mode: structs.MeshGatewayModeRemote},
+			"limits": &structs.UpstreamLimits{
+				MaxConnections:     intPtr(10),
+				MaxPendingRequests: intPtr(20),
+			},
+			"passive_health_check": &structs.PassiveHealthCheck{
+				Interval:    10 * time.Second,
+				MaxFailures: 3,
+			},
+		},
+	}
+
+	tt := map[string]struct {
+		defaults *structs.Upstream
+		explicit *structs.Upstream
+		want     *structs.Upstream
+	}{
+		"no defaults": {
+			explicit: &structs.Upstream{
+				DestinationName: "db",
+				LocalBindPort:   9191,
+				Config:          map[string]interface{}{"protocol": "grpc"},
+			},
+			want: &structs.Upstream{
+				DestinationName: "db",
+				LocalBindPort:   9191,
+				Config:          map[string]interface{}{"protocol": "grpc"},
+			},
+		},
+		"prepared query": {
+			defaults: defaults,
+			explicit: &structs.Upstream{
+				DestinationType: structs.UpstreamDestTypePreparedQuery,
+				DestinationName: "geo-db",
+				LocalBindPort:   9191,
+			},
+			want: &structs.Upstream{
+				DestinationType: structs.UpstreamDestTypePreparedQuery,
+				DestinationName: "geo-db",
+				LocalBindPort:   9191,
+			},
+		},
+		"inherit defaults": {
+			defaults: defaults,
+			explicit: &structs.Upstream{
+				DestinationName: "db",
+				LocalBindPort:   9191,
+			},
+			want: &structs.Upstream{
+				DestinationName: "db",
+				LocalBindPort:   9191,
+				MeshGateway:     structs.MeshGatewayConfig{Mode: structs.MeshGatewayModeRemote},
+				Config: map[string]interface{}{
+					"protocol":           "http",
+					"connect_timeout_ms": 1000,
+					"limits": &structs.UpstreamLimits{
+						MaxConnections:     intPtr(10),
+						MaxPendingRequests: intPtr(20),
+					},
+					"passive_health_check": &structs.PassiveHealthCheck{
+						Interval:    10 * time.Second,
+						MaxFailures: 3,
+					},
+				},
+			},
+		},
+		"override fields": {
+			defaults: defaults,
+			explicit: &structs.Upstream{
+				DestinationName: "db",
+				LocalBindPort:   9191,
+				MeshGateway:     structs.MeshGatewayConfig{Mode: structs.MeshGatewayModeLocal},
+				Config: map[string]interface{}{
+					"Protocol": "grpc",
+					"limits": map[string]interface{}{
+						"max_connections":         5,
+						"max_concurrent_requests": 50,
+					},
+					"PassiveHealthCheck": map[string]interface{}{
+						"max_failures": 5,
+					},
+				},
+			},
+			want: &structs.Upstream{
+				DestinationName: "db",
+				LocalBindPort:   9191,
+				MeshGateway:     structs.MeshGatewayConfig{Mode: structs.MeshGatewayModeLocal},
+				Config: map[string]interface{}{
+					"Protocol":           "grpc",
+					"connect_timeout_ms": 1000,
+					"limits": &structs.UpstreamLimits{
+						MaxConnections:        intPtr(5),
+						MaxPendingRequests:    intPtr(20),
+						MaxConcurrentRequests: intPtr(50),
+					},
+					"passive_health_check": &structs.PassiveHealthCheck{
+						Interval:    10 * time.Second,
+						MaxFailures: 5,
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range tt {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			got := mergeImplicitUpstream(tc.defaults, tc.explicit)
+			require.Equal(t, tc.want, got)
+
+			// The merged config must be usable to generate the proxy config.
+			_, err := structs.ParseUpstreamConfigNoDefaults(got.Config)
+			require.NoError(t, err)
+		})
+	}
+
+	// The defaults are left as-is.
+	require.Equal(t, "http", defaults.Config["protocol"])
+	require.Equal(t, 10, *defaults.Config["limits"].(*structs.UpstreamLimits).MaxConnections)
+}
@@ -3,9 +3,12 @@ package proxycfg
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	cachetype "github.com/hashicorp/consul/agent/cache-types"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/lib/stringslice"
 )
 
 type handlerIngressGateway struct {
@@ -112,9 +115,9 @@ func (s *handlerIngressGateway) handleUpdate(ctx context.Context, u UpdateEvent,
 		}
 
 		// Update our upstreams and watches.
-		var hosts []string
-		watchedSvcs := make(map[UpstreamID]struct{})
+		watchedSvcs := upstreamIDSets.get()
 		upstreamsMap := make(map[IngressListenerKey]structs.Upstreams)
+		boundHosts := make(map[IngressListenerKey][]string)
 		for _, service := range services.Services {
 			u := makeUpstream(service)
 
@@ -135,15 +138,19 @@ func (s *handlerIngressGateway) handleUpdate(ctx context.Context, u UpdateEvent,
 			}
 			watchedSvcs[uid] = struct{}{}
 
-			hosts = append(hosts, service.Hosts...)
-
 			id := IngressListenerKeyFromGWService(*service)
 			upstreamsMap[id] = append(upstreamsMap[id], u)
+			if len(service.Hosts) > 0 {
+				boundHosts[id] = append(boundHosts[id], service.Hosts...)
+			}
 		}
 
 		snap.IngressGateway.Upstreams = upstreamsMap
+		// Clones get their own copy of the set, so the previous one is only
+		// referenced by the snapshot of the handler.
+		upstreamIDSets.put(snap.IngressGateway.UpstreamsSet)
 		snap.IngressGateway.UpstreamsSet = watchedSvcs
-		snap.IngressGateway.Hosts = hosts
+		snap.IngressGateway.BoundHosts = boundHosts
 		snap.IngressGateway.HostsSet = true
 
 		for uid, cancelFn := range snap.IngressGateway.WatchedDiscoveryChains {
@@ -164,8 +171,8 @@ func (s *handlerIngressGateway) handleUpdate(ctx context.Context, u UpdateEvent,
 	return nil
 }
 
-// Note: Ingress gateways are always bound to ports and never unix sockets.
-// This means LocalBindPort is the only possibility
+// Note: The port still identifies the listeners of ingress gateways which are
+// bound to a unix socket, the socket is taken from the listener config.
 func makeUpstream(g *structs.GatewayService) structs.Upstream {
 	upstream := structs.Upstream{
 		DestinationName:      g.Service.Name,
@@ -190,16 +197,23 @@ func (s *handlerIngressGateway) watchIngressLeafCert(ctx context.Context, snap *
 		return nil
 	}
 
-	// Watch the leaf cert
+	// Routes are frequently rebound without changing their hosts, so only
+	// request a new leaf cert when the DNS SANs it needs change.
+	dnsSANs := s.generateIngressDNSSANs(snap)
 	if snap.IngressGateway.LeafCertWatchCancel != nil {
+		if stringslice.Equal(snap.IngressGateway.LeafCertDNSSANs, dnsSANs) {
+			return nil
+		}
 		snap.IngressGateway.LeafCertWatchCancel()
 	}
+
+	// Watch the leaf cert
 	ctx, cancel := context.WithCancel(ctx)
 	err := s.dataSources.LeafCertificate.Notify(ctx, &cachetype.ConnectCALeafRequest{
 		Datacenter:     s.source.Datacenter,
 		Token:          s.token,
 		Service:        s.service,
-		DNSSAN:         s.generateIngressDNSSANs(snap),
+		DNSSAN:         dnsSANs,
 		EnterpriseMeta: s.proxyID.EnterpriseMeta,
 	}, leafWatchID, s.ch)
 	if err != nil {
@@ -207,6 +221,7 @@ func (s *handlerIngressGateway) watchIngressLeafCert(ctx context.Context, snap *
 		return err
 	}
 	snap.IngressGateway.LeafCertWatchCancel = cancel
+	snap.IngressGateway.LeafCertDNSSANs = dnsSANs
 
 	return nil
 }
@@ -265,7 +280,59 @@ func (s *handlerIngressGateway) generateIngressDNSSANs(snap *ConfigSnapshot) []s
 		}
 	}
 
-	dnsNames = append(dnsNames, snap.IngressGateway.Hosts...)
+	// The hosts bound to the listeners are covered by the certificate as
+	// services are bound and unbound. Services without hosts are reached
+	// through the wildcards above.
+	for _, hosts := range snap.IngressGateway.BoundHosts {
+		dnsNames = append(dnsNames, hosts...)
+	}
 
-	return dnsNames
+	return collapseDNSSANs(dnsNames)
+}
+
+// collapseDNSSANs returns the given DNS names sorted and without duplicates,
+// dropping the names already covered by a wildcard of the list, e.g.
+// "api.example.com" when "*.example.com" is present, as well as the "*" host
+// which can't be used as a DNS SAN. Names are compared case-insensitively and
+// regardless of a trailing dot.
+func collapseDNSSANs(names []string) []string {
+	// Sort first so that the same spelling of a name is kept whatever the
+	// order of the listeners it's bound to.
+	names = append([]string(nil), names...)
+	sort.Strings(names)
+
+	normalize := func(name string) string {
+		return strings.TrimSuffix(strings.ToLower(name), ".")
+	}
+
+	wildcards := make(map[string]struct{})
+	for _, name := range names {
+		if n := normalize(name); strings.HasPrefix(n, "*.") {
+			wildcards[strings.TrimPrefix(n, "*.")] = struct{}{}
+		}
+	}
+
+	seen := make(map[string]struct{})
+	var collapsed []string
+	for _, name := range names {
+		n := normalize(name)
+		if n == "*" || n == "" {
+			continue
+		}
+		if _, ok := seen[n]; ok {
+			continue
+		}
+		seen[n] = struct{}{}
+
+		// A wildcard only matches a single label.
+		if !strings.HasPrefix(n, "*.") {
+			if i := strings.Index(n, "."); i > 0 {
+				if _, ok := wildcards[n[i+1:]]; ok {
+					continue
+				}
+			}
+		}
+		collapsed = append(collapsed, name)
+	}
+	return collapsed
 }
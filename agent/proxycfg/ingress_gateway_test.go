@@ -0,0 +1,72 @@
+package proxycfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestCollapseDNSSANs(t *testing.T) {
+	tt := map[string]struct {
+		names []string
+		want  []string
+	}{
+		"empty": {},
+		"sorted and deduplicated": {
+			names: []string{"web.example.com", "api.example.com", "web.example.com"},
+			want:  []string{"api.example.com", "web.example.com"},
+		},
+		"covered by wildcard": {
+			names: []string{"api.example.com", "*.example.com", "example.com", "v1.api.example.com"},
+			want:  []string{"*.example.com", "example.com", "v1.api.example.com"},
+		},
+		"covered by wildcard with trailing dot": {
+			names: []string{"api.ingress.consul", "*.ingress.consul."},
+			want:  []string{"*.ingress.consul."},
+		},
+		"case insensitive": {
+			names: []string{"API.example.com", "*.Example.com", "api.example.com"},
+			want:  []string{"*.Example.com"},
+		},
+		"any host dropped": {
+			names: []string{"*", "api.example.com"},
+			want:  []string{"api.example.com"},
+		},
+	}
+
+	for name, tc := range tt {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.want, collapseDNSSANs(tc.names))
+		})
+	}
+}
+
+func TestGenerateIngressDNSSANs_BoundHosts(t *testing.T) {
+	s := &handlerIngressGateway{handlerState: handlerState{stateConfig: stateConfig{
+		source:    &structs.QuerySource{Datacenter: "dc1"},
+		dnsConfig: DNSConfig{Domain: "consul."},
+	}}}
+
+	snap := &ConfigSnapshot{}
+	snap.IngressGateway.TLSConfig.Enabled = true
+	snap.IngressGateway.Upstreams = map[IngressListenerKey]structs.Upstreams{
+		{Protocol: "http", Port: 8080}: {{DestinationName: "web", DestinationNamespace: "default"}},
+	}
+	snap.IngressGateway.BoundHosts = map[IngressListenerKey][]string{
+		{Protocol: "http", Port: 8080}: {"web.example.com", "api.example.com"},
+		{Protocol: "http", Port: 8443}: {"*.example.com", "WEB.example.com"},
+	}
+
+	require.Equal(t, []string{
+		"*.example.com",
+		"*.ingress.consul.",
+		"*.ingress.dc1.consul.",
+	}, s.generateIngressDNSSANs(snap))
+
+	// Without TLS the leaf cert doesn't need any DNS SANs.
+	snap.IngressGateway.TLSConfig.Enabled = false
+	require.Nil(t, s.generateIngressDNSSANs(snap))
+}
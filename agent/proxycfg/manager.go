@@ -2,6 +2,9 @@ package proxycfg
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/hashicorp/go-hclog"
@@ -50,6 +53,7 @@ type Manager struct {
 	proxies    map[ProxyID]*state
 	watchers   map[ProxyID]map[uint64]chan *ConfigSnapshot
 	maxWatchID uint64
+	closed     bool
 }
 
 // ManagerConfig holds the required external dependencies for a Manager
@@ -186,6 +190,36 @@ func (m *Manager) Deregister(id ProxyID, source ProxySource) {
 	// cleaned up naturally.
 }
 
+// DemandUpstreams starts watching the given intention upstreams of a
+// transparent proxy in on-demand mode, as requested by its xDS stream, or
+// records the activity of the upstreams which are already watched. Unknown
+// proxies and upstreams which aren't allowed by intentions are ignored.
+func (m *Manager) DemandUpstreams(id ProxyID, uids ...UpstreamID) {
+	m.mu.Lock()
+	state, ok := m.proxies[id]
+	m.mu.Unlock()
+
+	if !ok || len(uids) == 0 {
+		return
+	}
+	state.DemandUpstreams(uids)
+}
+
+// ReportUpstreamsActivity records a load report of a transparent proxy in
+// on-demand mode sent on its LRS stream, with the upstreams which had traffic.
+// The idle upstreams are only pruned while the proxy reports its load. Unknown
+// proxies are ignored.
+func (m *Manager) ReportUpstreamsActivity(id ProxyID, uids ...UpstreamID) {
+	m.mu.Lock()
+	state, ok := m.proxies[id]
+	m.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	state.ReportUpstreamsActivity(uids)
+}
+
 func (m *Manager) notifyBroadcast(ch <-chan ConfigSnapshot) {
 	// Run until ch is closed
 	for snap := range ch {
@@ -244,6 +278,35 @@ OUTER:
 	}
 }
 
+// Ready returns an error if the manager was closed or if one of the
+// registered proxies doesn't have a complete configuration snapshot yet.
+func (m *Manager) Ready() error {
+	// Only hold the lock while listing the proxies, as getting their
+	// snapshots waits on their state goroutines.
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return errors.New("proxy configuration manager is closed")
+	}
+	states := make(map[ProxyID]*state, len(m.proxies))
+	for id, state := range m.proxies {
+		states[id] = state
+	}
+	m.mu.Unlock()
+
+	var pending []string
+	for id, state := range states {
+		if state.CurrentSnapshot() == nil {
+			pending = append(pending, id.String())
+		}
+	}
+	if len(pending) > 0 {
+		sort.Strings(pending)
+		return fmt.Errorf("configuration of proxies %s is not yet complete", strings.Join(pending, ", "))
+	}
+	return nil
+}
+
 // Watch registers a watch on a proxy. It might not exist yet in which case this
 // will not fail, but no updates will be delivered until the proxy is
 // registered. If there is already a valid snapshot in memory, it will be
@@ -300,6 +363,8 @@ func (m *Manager) Close() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.closed = true
+
 	// Close all current watchers first
 	for proxyID, watchers := range m.watchers {
 		for watchID := range watchers {
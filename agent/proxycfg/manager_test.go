@@ -246,6 +246,7 @@ func TestManager_BasicLifecycle(t *testing.T) {
 					WatchedServiceChecks:   map[structs.ServiceID][]structs.CheckType{},
 					Intentions:             TestIntentions().Matches[0],
 					IntentionsSet:          true,
+					DemandedUpstreams:      map[UpstreamID]time.Time{},
 				},
 				Datacenter: "dc1",
 				Locality:   GatewayKey{Datacenter: "dc1", Partition: acl.PartitionOrDefault("")},
@@ -307,6 +308,7 @@ func TestManager_BasicLifecycle(t *testing.T) {
 					WatchedServiceChecks:   map[structs.ServiceID][]structs.CheckType{},
 					Intentions:             TestIntentions().Matches[0],
 					IntentionsSet:          true,
+					DemandedUpstreams:      map[UpstreamID]time.Time{},
 				},
 				Datacenter: "dc1",
 				Locality:   GatewayKey{Datacenter: "dc1", Partition: acl.PartitionOrDefault("")},
@@ -481,6 +483,13 @@ func assertWatchChanRecvs(t *testing.T, ch <-chan *ConfigSnapshot, expect *Confi
 
 	select {
 	case got, ok := <-ch:
+		if expect != nil && got != nil {
+			// Revisions depend on the order in which updates were handled.
+			require.NotZero(t, got.Revisions.Source)
+			gotCopy := *got
+			gotCopy.Revisions = expect.Revisions
+			got = &gotCopy
+		}
 		require.Equal(t, expect, got)
 		if expect == nil {
 			require.False(t, ok, "watch chan should be closed")
@@ -582,6 +591,7 @@ func TestManager_SyncState_No_Notify(t *testing.T) {
 	}
 
 	require.NoError(t, m.Register(proxyID, srv, testSource, "", false))
+	require.EqualError(t, m.Ready(), "configuration of proxies web-sidecar-proxy is not yet complete")
 
 	watchCh, cancelWatch := m.Watch(proxyID)
 	t.Cleanup(cancelWatch)
@@ -652,4 +662,8 @@ func TestManager_SyncState_No_Notify(t *testing.T) {
 		t.Fatal("snap should be valid")
 
 	}
+	require.NoError(t, m.Ready())
+
+	require.NoError(t, m.Close())
+	require.EqualError(t, m.Ready(), "proxy configuration manager is closed")
 }
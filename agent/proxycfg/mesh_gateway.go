@@ -69,6 +69,21 @@ func (s *handlerMeshGateway) initialize(ctx context.Context) (ConfigSnapshot, er
 		return snap, err
 	}
 
+	if s.proxyID.InDefaultPartition() {
+		// Watch the mesh config entry to route the peering streams to the
+		// servers when they go through the mesh gateways.
+		err = s.dataSources.ConfigEntry.Notify(ctx, &structs.ConfigEntryQuery{
+			Kind:           structs.MeshConfig,
+			Name:           structs.MeshConfigMesh,
+			Datacenter:     s.source.Datacenter,
+			QueryOptions:   structs.QueryOptions{Token: s.token},
+			EnterpriseMeta: *structs.DefaultEnterpriseMetaInPartition(s.proxyID.PartitionOrDefault()),
+		}, meshConfigEntryID, s.ch)
+		if err != nil {
+			return snap, err
+		}
+	}
+
 	// Watch for all exported services from this mesh gateway's partition in any peering.
 	err = s.dataSources.ExportedPeeredServices.Notify(ctx, &structs.DCSpecificRequest{
 		Datacenter:     s.source.Datacenter,
@@ -175,7 +190,8 @@ func (s *handlerMeshGateway) handleUpdate(ctx context.Context, u UpdateEvent, sn
 			return fmt.Errorf("invalid type for response: %T", u.Result)
 		}
 
-		svcMap := make(map[structs.ServiceName]struct{})
+		svcMap := serviceNameSets.get()
+		defer serviceNameSets.put(svcMap)
 		for _, svc := range services.Services {
 			// Make sure to add every service to this map, we use it to cancel
 			// watches below.
@@ -311,6 +327,46 @@ func (s *handlerMeshGateway) handleUpdate(ctx context.Context, u UpdateEvent, sn
 
 		snap.MeshGateway.ConsulServers = resp.Nodes
 
+	case meshConfigEntryID:
+		resp, ok := u.Result.(*structs.ConfigEntryResponse)
+		if !ok {
+			return fmt.Errorf("invalid type for response: %T", u.Result)
+		}
+
+		var meshConf *structs.MeshConfigEntry
+		if resp.Entry != nil {
+			meshConf, ok = resp.Entry.(*structs.MeshConfigEntry)
+			if !ok {
+				return fmt.Errorf("invalid type for config entry: %T", resp.Entry)
+			}
+		}
+		snap.MeshGateway.MeshConfig = meshConf
+		snap.MeshGateway.MeshConfigSet = true
+
+		// The servers are already watched with WAN federation.
+		if s.meta[structs.MetaWANFederationKey] == "1" {
+			return nil
+		}
+		switch {
+		case meshConf.PeerThroughMeshGateways() && snap.MeshGateway.WatchedConsulServers == nil:
+			ctx, cancel := context.WithCancel(ctx)
+			err := s.dataSources.Health.Notify(ctx, &structs.ServiceSpecificRequest{
+				Datacenter:   s.source.Datacenter,
+				QueryOptions: structs.QueryOptions{Token: s.token},
+				ServiceName:  structs.ConsulServiceName,
+			}, consulServerListWatchID, s.ch)
+			if err != nil {
+				cancel()
+				return err
+			}
+			snap.MeshGateway.WatchedConsulServers = cancel
+
+		case !meshConf.PeerThroughMeshGateways() && snap.MeshGateway.WatchedConsulServers != nil:
+			snap.MeshGateway.WatchedConsulServers()
+			snap.MeshGateway.WatchedConsulServers = nil
+			snap.MeshGateway.ConsulServers = nil
+		}
+
 	case exportedServiceListWatchID:
 		exportedServices, ok := u.Result.(*structs.IndexedExportedServiceList)
 		if !ok {
@@ -0,0 +1,278 @@
+package proxycfg
+
+import (
+	"net"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// Kinds of the gateways traffic from a sidecar proxy to an upstream can
+// traverse.
+const (
+	RoutingHopLocalMeshGateway   = "local-mesh-gateway"
+	RoutingHopRemoteMeshGateway  = "remote-mesh-gateway"
+	RoutingHopPeerMeshGateway    = "peer-mesh-gateway"
+	RoutingHopTerminatingGateway = "terminating-gateway"
+)
+
+// UpstreamRouting describes how a sidecar proxy routes the traffic to one of
+// its upstreams.
+type UpstreamRouting struct {
+	// Upstream identifies the upstream, as in the names of its Envoy
+	// listeners and clusters.
+	Upstream string
+
+	// DestinationName is the name of the upstream service or prepared query.
+	DestinationName string
+
+	DestinationType string `json:",omitempty"`
+	DestinationPeer string `json:",omitempty"`
+
+	// Targets are the destinations the traffic to the upstream can be sent
+	// to, sorted by ID.
+	Targets []TargetRouting
+}
+
+// TargetRouting describes the path of the traffic from a sidecar proxy to one
+// of the targets of an upstream.
+type TargetRouting struct {
+	ID         string
+	Service    string
+	Namespace  string `json:",omitempty"`
+	Partition  string `json:",omitempty"`
+	Datacenter string `json:",omitempty"`
+	Peer       string `json:",omitempty"`
+
+	// Failover is true if the target only receives traffic when the primary
+	// targets of the upstream are unhealthy.
+	Failover bool `json:",omitempty"`
+
+	// Hops are the gateways the traffic traverses in order. It is sent
+	// directly to the instances of the target when there are none.
+	Hops []RoutingHop
+
+	// Pending is true while the proxy is still fetching the endpoints needed
+	// to route to the target, in which case Hops may be incomplete.
+	Pending bool `json:",omitempty"`
+}
+
+// RoutingHop is a gateway traversed by the traffic to an upstream target.
+type RoutingHop struct {
+	Kind       string
+	Datacenter string `json:",omitempty"`
+	Partition  string `json:",omitempty"`
+	Peer       string `json:",omitempty"`
+
+	// Addresses are the host:port addresses of the gateway instances the
+	// proxy connects to. They are only known for the first hop.
+	Addresses []string `json:",omitempty"`
+}
+
+// GatewayKeyForTarget returns the key of the mesh gateway a sidecar proxy in
+// localKey sends the traffic for target to, or an empty key if the traffic is
+// sent directly to the instances of the target.
+func GatewayKeyForTarget(target *structs.DiscoveryTarget, localKey GatewayKey) GatewayKey {
+	var key GatewayKey
+	switch target.MeshGateway.Mode {
+	case structs.MeshGatewayModeRemote:
+		key.Datacenter = target.Datacenter
+		key.Partition = target.Partition
+	case structs.MeshGatewayModeLocal:
+		key = localKey
+	}
+
+	// Gateways are not needed if the request isn't for a remote DC or partition.
+	if key.IsEmpty() || (acl.EqualPartitions(localKey.Partition, target.Partition) && localKey.Datacenter == target.Datacenter) {
+		return GatewayKey{}
+	}
+	return key
+}
+
+// UpstreamRouting returns how a sidecar proxy routes the traffic to its
+// upstreams, derived from the discovery chains and endpoints its xDS
+// resources are generated from. Upstreams are sorted by their identifier.
+func (s *ConfigSnapshot) UpstreamRouting() []UpstreamRouting {
+	if s.Kind != structs.ServiceKindConnectProxy {
+		return nil
+	}
+
+	var out []UpstreamRouting
+	for uid, chain := range s.ConnectProxy.DiscoveryChain {
+		if !s.isKnownUpstream(uid) {
+			continue
+		}
+		out = append(out, s.chainRouting(uid, chain))
+	}
+
+	for _, uid := range s.ConnectProxy.PeeredUpstreamIDs() {
+		if !s.isKnownUpstream(uid) {
+			continue
+		}
+		target := TargetRouting{
+			ID:        uid.String(),
+			Service:   uid.Name,
+			Namespace: uid.NamespaceOrEmpty(),
+			Partition: uid.PartitionOrEmpty(),
+			Peer:      uid.Peer,
+		}
+		endpoints, ok := s.ConnectProxy.PeerUpstreamEndpoints[uid]
+		target.Pending = !ok
+		// The endpoints of peered upstreams are the mesh gateways of the peer.
+		target.Hops = []RoutingHop{{
+			Kind:      RoutingHopPeerMeshGateway,
+			Peer:      uid.Peer,
+			Addresses: routingAddresses(endpoints, true),
+		}}
+		out = append(out, UpstreamRouting{
+			Upstream:        uid.EnvoyID(),
+			DestinationName: uid.Name,
+			DestinationPeer: uid.Peer,
+			Targets:         []TargetRouting{target},
+		})
+	}
+
+	for uid, u := range s.ConnectProxy.UpstreamConfig {
+		if u == nil || u.DestinationType != structs.UpstreamDestTypePreparedQuery {
+			continue
+		}
+		endpoints, ok := s.ConnectProxy.PreparedQueryEndpoints[uid]
+		target := TargetRouting{
+			ID:         uid.String(),
+			Service:    u.DestinationName,
+			Datacenter: u.Datacenter,
+			Pending:    !ok,
+		}
+		if hop, ok := terminatingGatewayHop(endpoints, u.Datacenter, ""); ok {
+			target.Hops = append(target.Hops, hop)
+		}
+		out = append(out, UpstreamRouting{
+			Upstream:        uid.EnvoyID(),
+			DestinationName: u.DestinationName,
+			DestinationType: structs.UpstreamDestTypePreparedQuery,
+			Targets:         []TargetRouting{target},
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Upstream < out[j].Upstream
+	})
+	return out
+}
+
+// isKnownUpstream returns whether xDS resources are generated for uid, that
+// is if it is an explicit upstream or one inferred from intentions.
+func (s *ConfigSnapshot) isKnownUpstream(uid UpstreamID) bool {
+	if _, ok := s.ConnectProxy.IntentionUpstreams[uid]; ok {
+		return true
+	}
+	return s.ConnectProxy.UpstreamConfig[uid].HasLocalPortOrSocket()
+}
+
+func (s *ConfigSnapshot) chainRouting(uid UpstreamID, chain *structs.CompiledDiscoveryChain) UpstreamRouting {
+	primary := make(map[string]struct{})
+	for _, node := range chain.Nodes {
+		if node.Type == structs.DiscoveryGraphNodeTypeResolver {
+			primary[node.Resolver.Target] = struct{}{}
+		}
+	}
+
+	out := UpstreamRouting{
+		Upstream:        uid.EnvoyID(),
+		DestinationName: uid.Name,
+	}
+	for id, target := range chain.Targets {
+		_, isPrimary := primary[id]
+		routing := TargetRouting{
+			ID:         id,
+			Service:    target.Service,
+			Namespace:  target.Namespace,
+			Partition:  target.Partition,
+			Datacenter: target.Datacenter,
+			Failover:   !isPrimary,
+		}
+
+		endpoints, ok := s.ConnectProxy.WatchedUpstreamEndpoints[uid][id]
+		routing.Pending = !ok
+
+		gatewayKey := GatewayKeyForTarget(target, s.Locality)
+		if !gatewayKey.IsEmpty() {
+			gateways, ok := s.ConnectProxy.WatchedGatewayEndpoints[uid][gatewayKey.String()]
+			routing.Pending = routing.Pending || !ok
+
+			if target.MeshGateway.Mode == structs.MeshGatewayModeLocal {
+				routing.Hops = append(routing.Hops, RoutingHop{
+					Kind:       RoutingHopLocalMeshGateway,
+					Datacenter: s.Locality.Datacenter,
+					Partition:  s.Locality.Partition,
+					Addresses:  routingAddresses(gateways, false),
+				})
+			}
+			hop := RoutingHop{
+				Kind:       RoutingHopRemoteMeshGateway,
+				Datacenter: target.Datacenter,
+				Partition:  target.Partition,
+			}
+			if len(routing.Hops) == 0 {
+				hop.Addresses = routingAddresses(gateways, true)
+			}
+			routing.Hops = append(routing.Hops, hop)
+		}
+
+		if hop, ok := terminatingGatewayHop(endpoints, target.Datacenter, target.Partition); ok {
+			if len(routing.Hops) > 0 {
+				// The proxy doesn't connect to the terminating gateway itself.
+				hop.Addresses = nil
+			}
+			routing.Hops = append(routing.Hops, hop)
+		}
+
+		out.Targets = append(out.Targets, routing)
+	}
+
+	sort.Slice(out.Targets, func(i, j int) bool {
+		return out.Targets[i].ID < out.Targets[j].ID
+	})
+	return out
+}
+
+// terminatingGatewayHop returns the hop through the terminating gateway
+// fronting a target, if its endpoints are terminating gateway instances.
+func terminatingGatewayHop(endpoints structs.CheckServiceNodes, dc, partition string) (RoutingHop, bool) {
+	if len(endpoints) == 0 {
+		return RoutingHop{}, false
+	}
+	for _, ep := range endpoints {
+		if ep.Service == nil || ep.Service.Kind != structs.ServiceKindTerminatingGateway {
+			return RoutingHop{}, false
+		}
+	}
+	return RoutingHop{
+		Kind:       RoutingHopTerminatingGateway,
+		Datacenter: dc,
+		Partition:  partition,
+		Addresses:  routingAddresses(endpoints, false),
+	}, true
+}
+
+// routingAddresses returns the sorted unique host:port addresses of endpoints.
+func routingAddresses(endpoints structs.CheckServiceNodes, wan bool) []string {
+	seen := make(map[string]struct{})
+	var out []string
+	for _, ep := range endpoints {
+		if ep.Node == nil || ep.Service == nil {
+			continue
+		}
+		_, addr, port := ep.BestAddress(wan)
+		hostPort := net.JoinHostPort(addr, strconv.Itoa(port))
+		if _, ok := seen[hostPort]; ok {
+			continue
+		}
+		seen[hostPort] = struct{}{}
+		out = append(out, hostPort)
+	}
+	sort.Strings(out)
+	return out
+}
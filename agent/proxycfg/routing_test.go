@@ -0,0 +1,132 @@
+package proxycfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestConfigSnapshot_UpstreamRouting(t *testing.T) {
+	geoCache := UpstreamRouting{
+		Upstream:        "prepared_query:geo-cache",
+		DestinationName: "geo-cache",
+		DestinationType: structs.UpstreamDestTypePreparedQuery,
+		Targets: []TargetRouting{{
+			ID:      "prepared_query:geo-cache",
+			Service: "geo-cache",
+		}},
+	}
+	dbTarget := func(dc string, failover bool, hops ...RoutingHop) TargetRouting {
+		return TargetRouting{
+			ID:         "db.default.default." + dc,
+			Service:    "db",
+			Namespace:  "default",
+			Partition:  "default",
+			Datacenter: dc,
+			Failover:   failover,
+			Hops:       hops,
+		}
+	}
+
+	terminatingGatewayNodes := TestUpstreamNodes(t, "tgw")
+	for _, node := range terminatingGatewayNodes {
+		node.Service.Kind = structs.ServiceKindTerminatingGateway
+	}
+
+	tests := map[string]struct {
+		snap   *ConfigSnapshot
+		expect []UpstreamRouting
+	}{
+		"direct": {
+			snap: TestConfigSnapshotDiscoveryChain(t, "simple", nil, nil),
+			expect: []UpstreamRouting{
+				{
+					Upstream:        "db",
+					DestinationName: "db",
+					Targets:         []TargetRouting{dbTarget("dc1", false)},
+				},
+				geoCache,
+			},
+		},
+		"terminating gateway": {
+			snap: TestConfigSnapshotDiscoveryChain(t, "simple", nil, []UpdateEvent{
+				{
+					CorrelationID: "upstream-target:db.default.default.dc1:db",
+					Result: &structs.IndexedCheckServiceNodes{
+						Nodes: terminatingGatewayNodes,
+					},
+				},
+			}),
+			expect: []UpstreamRouting{
+				{
+					Upstream:        "db",
+					DestinationName: "db",
+					Targets: []TargetRouting{dbTarget("dc1", false, RoutingHop{
+						Kind:       RoutingHopTerminatingGateway,
+						Datacenter: "dc1",
+						Partition:  "default",
+						Addresses:  []string{"10.10.1.1:8080", "10.10.1.2:8080"},
+					})},
+				},
+				geoCache,
+			},
+		},
+		"failover through remote gateway": {
+			snap: TestConfigSnapshotDiscoveryChain(t, "failover-through-remote-gateway", nil, nil),
+			expect: []UpstreamRouting{
+				{
+					Upstream:        "db",
+					DestinationName: "db",
+					Targets: []TargetRouting{
+						dbTarget("dc1", false),
+						dbTarget("dc2", true, RoutingHop{
+							Kind:       RoutingHopRemoteMeshGateway,
+							Datacenter: "dc2",
+							Partition:  "default",
+							Addresses:  []string{"198.18.1.1:443", "198.18.1.2:443"},
+						}),
+					},
+				},
+				geoCache,
+			},
+		},
+		"failover through local gateway": {
+			snap: TestConfigSnapshotDiscoveryChain(t, "failover-through-local-gateway", nil, nil),
+			expect: []UpstreamRouting{
+				{
+					Upstream:        "db",
+					DestinationName: "db",
+					Targets: []TargetRouting{
+						dbTarget("dc1", false),
+						dbTarget("dc2", true,
+							RoutingHop{
+								Kind:       RoutingHopLocalMeshGateway,
+								Datacenter: "dc1",
+								Partition:  "default",
+								Addresses:  []string{"10.10.1.1:8443", "10.10.1.2:8443"},
+							},
+							RoutingHop{
+								Kind:       RoutingHopRemoteMeshGateway,
+								Datacenter: "dc2",
+								Partition:  "default",
+							},
+						),
+					},
+				},
+				geoCache,
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expect, tc.snap.UpstreamRouting())
+		})
+	}
+
+	t.Run("not a connect proxy", func(t *testing.T) {
+		snap := TestConfigSnapshotMeshGateway(t, "default", nil, nil)
+		require.Nil(t, snap.UpstreamRouting())
+	})
+}
@@ -0,0 +1,152 @@
+package proxycfg
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sanitizeMaxDepth bounds how deep Sanitized descends into the snapshot, as a
+// guard against reference cycles.
+const sanitizeMaxDepth = 64
+
+// Snapshots returns the current snapshot of every proxy tracked by Manager
+// that has one ready, sorted by proxy ID. It is meant for debugging.
+func (m *Manager) Snapshots() []*ConfigSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snaps := make([]*ConfigSnapshot, 0, len(m.proxies))
+	for _, state := range m.proxies {
+		if snap := state.CurrentSnapshot(); snap != nil {
+			snaps = append(snaps, snap)
+		}
+	}
+	sort.Slice(snaps, func(i, j int) bool {
+		return snaps[i].ProxyID.String() < snaps[j].ProxyID.String()
+	})
+	return snaps
+}
+
+// Sanitized returns a JSON compatible copy of the snapshot with the private
+// keys and the ACL tokens redacted, for including in debug bundles. Maps keyed
+// by structured types are keyed by their string form instead.
+func (s *ConfigSnapshot) Sanitized() map[string]interface{} {
+	out, _ := sanitize("snap", reflect.ValueOf(s), 0).(map[string]interface{})
+	return out
+}
+
+// isSecret determines whether a field name represents a field which may
+// contain a secret.
+func isSecret(name string) bool {
+	name = strings.ToLower(name)
+	return name == "signingkey" ||
+		strings.Contains(name, "privatekey") ||
+		strings.Contains(name, "token") ||
+		strings.Contains(name, "secret")
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	durationType      = reflect.TypeOf(time.Duration(0))
+)
+
+func sanitize(name string, v reflect.Value, depth int) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if depth > sanitizeMaxDepth {
+		return "<max depth exceeded>"
+	}
+
+	typ := v.Type()
+	if typ == durationType {
+		return v.Interface().(time.Duration).String()
+	}
+
+	switch typ.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil
+
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return sanitize(name, v.Elem(), depth+1)
+
+	case reflect.String:
+		if isSecret(name) && v.Len() > 0 {
+			return "hidden"
+		}
+		return v.Interface()
+
+	case reflect.Struct:
+		// Types like time.Time know how to encode themselves.
+		if typ.Implements(jsonMarshalerType) || typ.Implements(textMarshalerType) {
+			return v.Interface()
+		}
+		m := make(map[string]interface{})
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if field.PkgPath != "" || field.Tag.Get("json") == "-" {
+				continue
+			}
+			value := sanitize(field.Name, v.Field(i), depth+1)
+			// Flatten the embedded structs like encoding/json does.
+			if embedded, ok := value.(map[string]interface{}); ok && field.Anonymous {
+				for k, v := range embedded {
+					m[k] = v
+				}
+				continue
+			}
+			m[field.Name] = value
+		}
+		return m
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := sanitizeMapKey(iter.Key())
+			m[key] = sanitize(name, iter.Value(), depth+1)
+		}
+		return m
+
+	case reflect.Slice, reflect.Array:
+		if typ.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if typ.Elem().Kind() == reflect.Uint8 {
+			if isSecret(name) {
+				return "hidden"
+			}
+			return v.Interface()
+		}
+		s := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			s = append(s, sanitize(name, v.Index(i), depth+1))
+		}
+		return s
+
+	default:
+		return v.Interface()
+	}
+}
+
+func sanitizeMapKey(v reflect.Value) string {
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%+v", v.Interface())
+}
@@ -0,0 +1,34 @@
+package proxycfg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSnapshot_Sanitized(t *testing.T) {
+	snaps := map[string]*ConfigSnapshot{
+		"connect-proxy":       TestConfigSnapshot(t, nil, nil),
+		"mesh-gateway":        TestConfigSnapshotMeshGateway(t, "default", nil, nil),
+		"ingress-gateway":     TestConfigSnapshotIngressGateway(t, true, "tcp", "default", nil, nil, nil),
+		"terminating-gateway": TestConfigSnapshotTerminatingGateway(t, true, nil, nil),
+	}
+	for name, snap := range snaps {
+		t.Run(name, func(t *testing.T) {
+			snap.ProxyID.Token = "the-token"
+
+			out, err := json.Marshal(snap.Sanitized())
+			require.NoError(t, err)
+
+			require.NotContains(t, string(out), "PRIVATE KEY")
+			require.NotContains(t, string(out), "the-token")
+			require.Contains(t, string(out), snap.Service)
+		})
+	}
+
+	sanitized := snaps["connect-proxy"].Sanitized()
+	leaf := sanitized["ConnectProxy"].(map[string]interface{})["Leaf"].(map[string]interface{})
+	require.Equal(t, "hidden", leaf["PrivateKeyPEM"])
+	require.Contains(t, leaf["CertPEM"], "CERTIFICATE")
+}
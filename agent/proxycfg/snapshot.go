@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/mitchellh/copystructure"
 
@@ -139,6 +140,28 @@ type configSnapshotConnectProxy struct {
 	// intentions.
 	Intentions    structs.Intentions
 	IntentionsSet bool
+
+	// OnDemandUpstreams is set for transparent proxies configured to only
+	// watch the upstreams inferred from intentions once they are demanded by
+	// the proxy, rather than all of them.
+	OnDemandUpstreams bool
+
+	// DemandedUpstreams is a map of UpstreamID -> time of the last activity
+	// of the upstreams inferred from intentions which were demanded by the
+	// proxy when OnDemandUpstreams is set.
+	DemandedUpstreams map[UpstreamID]time.Time
+
+	// OnDemandUpstreamsTTL is how long the demanded upstreams are watched
+	// without any activity before they are pruned. They are never pruned
+	// when it is zero.
+	OnDemandUpstreamsTTL time.Duration
+
+	// LoadReportedAt is the time of the latest load report of the proxy, and
+	// LoadReportingSince the time it has been reporting its load without
+	// interruption since. The demanded upstreams are only pruned while the
+	// proxy reports its load.
+	LoadReportedAt     time.Time
+	LoadReportingSince time.Time
 }
 
 // isEmpty is a test helper
@@ -161,6 +184,7 @@ func (c *configSnapshotConnectProxy) isEmpty() bool {
 		len(c.UpstreamConfig) == 0 &&
 		len(c.PassthroughUpstreams) == 0 &&
 		len(c.IntentionUpstreams) == 0 &&
+		len(c.DemandedUpstreams) == 0 &&
 		!c.PeeringTrustBundlesSet &&
 		!c.MeshConfigSet &&
 		len(c.PeerUpstreamEndpoints) == 0 &&
@@ -330,6 +354,15 @@ type configSnapshotMeshGateway struct {
 	// ConsulServers is the list of consul servers in this datacenter.
 	ConsulServers structs.CheckServiceNodes
 
+	// MeshConfig is the mesh config entry of the partition of the gateway.
+	MeshConfig    *structs.MeshConfigEntry
+	MeshConfigSet bool
+
+	// WatchedConsulServers cancels the watch of the consul servers when it
+	// was started for the peering streams to go through the gateway rather
+	// than for WAN federation.
+	WatchedConsulServers context.CancelFunc
+
 	// HostnameDatacenters is a map of datacenters to mesh gateway instances with a hostname as the address.
 	// If hostnames are configured they must be provided to Envoy via CDS not EDS.
 	HostnameDatacenters map[string]structs.CheckServiceNodes
@@ -353,6 +386,12 @@ type configSnapshotMeshGateway struct {
 	WatchedDiscoveryChains map[structs.ServiceName]context.CancelFunc
 }
 
+// PeerThroughMeshGateways returns whether the peering streams established
+// with the servers of this datacenter go through the mesh gateways.
+func (c *configSnapshotMeshGateway) PeerThroughMeshGateways() bool {
+	return c.MeshConfig.PeerThroughMeshGateways()
+}
+
 func (c *configSnapshotMeshGateway) IsServiceExported(svc structs.ServiceName) bool {
 	if c == nil || len(c.ExportedServicesWithPeers) == 0 {
 		return false
@@ -405,6 +444,9 @@ func (c *configSnapshotMeshGateway) isEmpty() bool {
 		len(c.GatewayGroups) == 0 &&
 		len(c.FedStateGateways) == 0 &&
 		len(c.ConsulServers) == 0 &&
+		c.MeshConfig == nil &&
+		!c.MeshConfigSet &&
+		c.WatchedConsulServers == nil &&
 		len(c.HostnameDatacenters) == 0 &&
 		c.isEmptyPeering()
 }
@@ -434,14 +476,19 @@ type configSnapshotIngressGateway struct {
 	// ingress-gateway config entry yet.
 	GatewayConfigLoaded bool
 
-	// Hosts is the list of extra host entries to add to our leaf cert's DNS SANs.
-	Hosts    []string
-	HostsSet bool
+	// BoundHosts are the hosts of the services bound to each listener, which
+	// are added to our leaf cert's DNS SANs.
+	BoundHosts map[IngressListenerKey][]string
+	HostsSet   bool
 
 	// LeafCertWatchCancel is a CancelFunc to use when refreshing this gateway's
 	// leaf cert watch with different parameters.
 	LeafCertWatchCancel context.CancelFunc
 
+	// LeafCertDNSSANs are the DNS SANs requested by the current leaf cert
+	// watch, so that it's only refreshed when the bound hosts change.
+	LeafCertDNSSANs []string
+
 	// Upstreams is a list of upstreams this ingress gateway should serve traffic
 	// to. This is constructed from the ingress-gateway config entry, and uses
 	// the GatewayServices RPC to retrieve them.
@@ -468,6 +515,42 @@ func (c *configSnapshotIngressGateway) isEmpty() bool {
 		!c.MeshConfigSet
 }
 
+type configSnapshotEgressGateway struct {
+	MeshConfig    *structs.MeshConfigEntry
+	MeshConfigSet bool
+
+	// GatewayConfig is the egress-gateway config entry of this gateway. It is
+	// nil until loaded, or when the gateway has no config entry, in which case
+	// it doesn't allow any destination.
+	GatewayConfig *structs.EgressGatewayConfigEntry
+
+	// GatewayConfigLoaded is used to determine if we have received the initial
+	// egress-gateway config entry yet.
+	GatewayConfigLoaded bool
+
+	// Leaf is the gateway's leaf cert, presented to the services connecting
+	// to it.
+	Leaf *structs.IssuedCert
+
+	// Intentions are the intentions with the gateway as their destination,
+	// which authorize the services allowed to use it.
+	Intentions    structs.Intentions
+	IntentionsSet bool
+}
+
+// isEmpty is a test helper
+func (c *configSnapshotEgressGateway) isEmpty() bool {
+	if c == nil {
+		return true
+	}
+	return c.GatewayConfig == nil &&
+		!c.GatewayConfigLoaded &&
+		c.Leaf == nil &&
+		len(c.Intentions) == 0 &&
+		!c.IntentionsSet &&
+		!c.MeshConfigSet
+}
+
 type IngressListenerKey struct {
 	Protocol string
 	Port     int
@@ -494,6 +577,7 @@ type ConfigSnapshot struct {
 	ProxyID               ProxyID
 	Address               string
 	Port                  int
+	SocketPath            string
 	ServiceMeta           map[string]string
 	TaggedAddresses       map[string]structs.ServiceAddress
 	Proxy                 structs.ConnectProxyConfig
@@ -501,7 +585,7 @@ type ConfigSnapshot struct {
 	IntentionDefaultAllow bool
 	Locality              GatewayKey
 
-	ServerSNIFn ServerSNIFunc
+	ServerSNIFn ServerSNIFunc `json:"-"`
 	Roots       *structs.IndexedCARoots
 
 	// connect-proxy specific
@@ -515,6 +599,45 @@ type ConfigSnapshot struct {
 
 	// ingress-gateway specific
 	IngressGateway configSnapshotIngressGateway
+
+	// egress-gateway specific
+	EgressGateway configSnapshotEgressGateway
+
+	// Revisions allow consumers to tell which parts of the snapshot changed
+	// since a snapshot they've seen before.
+	Revisions SnapshotRevisions `json:"-"`
+}
+
+// SnapshotRevisions count the updates applied to the snapshots of a proxy. The
+// revisions of two snapshots can only be compared if they have the same
+// non-zero Source.
+type SnapshotRevisions struct {
+	// Source identifies the proxy state which built the snapshot. It is zero
+	// for snapshots built outside of the manager, whose changes are unknown.
+	Source uint64
+
+	// TLS counts the updates of the CA roots and leaf certificate which
+	// didn't change the trust domain.
+	TLS uint64
+
+	// Config counts all the other updates.
+	Config uint64
+}
+
+func (s *ConfigSnapshot) trustDomain() string {
+	if s.Roots == nil {
+		return ""
+	}
+	return s.Roots.TrustDomain
+}
+
+// OnlyTLSChangedSince returns whether the snapshot only differs from prev in
+// its CA roots and leaf certificate.
+func (s *ConfigSnapshot) OnlyTLSChangedSince(prev *ConfigSnapshot) bool {
+	if prev == nil || s.Revisions.Source == 0 || s.Revisions.Source != prev.Revisions.Source {
+		return false
+	}
+	return s.Revisions.Config == prev.Revisions.Config
 }
 
 // Valid returns whether or not the snapshot has all required fields filled yet.
@@ -549,6 +672,13 @@ func (s *ConfigSnapshot) Valid() bool {
 			s.IngressGateway.GatewayConfigLoaded &&
 			s.IngressGateway.HostsSet &&
 			s.IngressGateway.MeshConfigSet
+
+	case structs.ServiceKindEgressGateway:
+		return s.Roots != nil &&
+			s.EgressGateway.Leaf != nil &&
+			s.EgressGateway.GatewayConfigLoaded &&
+			s.EgressGateway.IntentionsSet &&
+			s.EgressGateway.MeshConfigSet
 	default:
 		return false
 	}
@@ -556,13 +686,25 @@ func (s *ConfigSnapshot) Valid() bool {
 
 // Clone makes a deep copy of the snapshot we can send to other goroutines
 // without worrying that they will racily read or mutate shared maps etc.
+//
+// Endpoints are the exception: the clone gets its own maps of endpoints, but
+// shares the slices of endpoints with the snapshot (copy-on-write). They make
+// up most of a snapshot on dense agents, and deep copying them on every update
+// was the bulk of the garbage produced by the manager. This is safe because
+// they are the results of health watches, which are already shared with the
+// agent cache and are only ever replaced in the snapshot, never modified.
+// Handlers must keep it that way.
 func (s *ConfigSnapshot) Clone() (*ConfigSnapshot, error) {
-	snapCopy, err := copystructure.Copy(s)
+	withoutEndpoints := *s
+	withoutEndpoints.clearEndpoints()
+
+	snapCopy, err := copystructure.Copy(&withoutEndpoints)
 	if err != nil {
 		return nil, err
 	}
 
 	snap := snapCopy.(*ConfigSnapshot)
+	snap.cloneEndpointsFrom(s)
 
 	// nil these out as anything receiving one of these clones does not need them and should never "cancel" our watches
 	switch s.Kind {
@@ -581,6 +723,7 @@ func (s *ConfigSnapshot) Clone() (*ConfigSnapshot, error) {
 	case structs.ServiceKindMeshGateway:
 		snap.MeshGateway.WatchedGateways = nil
 		snap.MeshGateway.WatchedServices = nil
+		snap.MeshGateway.WatchedConsulServers = nil
 	case structs.ServiceKindIngressGateway:
 		// common with connect-proxy and ingress-gateway
 		snap.IngressGateway.WatchedUpstreams = nil
@@ -594,12 +737,82 @@ func (s *ConfigSnapshot) Clone() (*ConfigSnapshot, error) {
 	return snap, nil
 }
 
+// clearEndpoints removes the endpoints from the snapshot so they can be
+// cloned separately.
+func (s *ConfigSnapshot) clearEndpoints() {
+	s.ConnectProxy.ConfigSnapshotUpstreams.clearEndpoints()
+	s.ConnectProxy.PreparedQueryEndpoints = nil
+	s.IngressGateway.ConfigSnapshotUpstreams.clearEndpoints()
+
+	s.TerminatingGateway.ServiceGroups = nil
+	s.TerminatingGateway.HostnameServices = nil
+
+	s.MeshGateway.ServiceGroups = nil
+	s.MeshGateway.GatewayGroups = nil
+	s.MeshGateway.FedStateGateways = nil
+	s.MeshGateway.ConsulServers = nil
+	s.MeshGateway.HostnameDatacenters = nil
+}
+
+// cloneEndpointsFrom sets the endpoints of the snapshot to copies of the maps
+// of endpoints of src, sharing the endpoints themselves.
+func (s *ConfigSnapshot) cloneEndpointsFrom(src *ConfigSnapshot) {
+	s.ConnectProxy.ConfigSnapshotUpstreams.cloneEndpointsFrom(&src.ConnectProxy.ConfigSnapshotUpstreams)
+	s.ConnectProxy.PreparedQueryEndpoints = cloneEndpointsMap(src.ConnectProxy.PreparedQueryEndpoints)
+	s.IngressGateway.ConfigSnapshotUpstreams.cloneEndpointsFrom(&src.IngressGateway.ConfigSnapshotUpstreams)
+
+	s.TerminatingGateway.ServiceGroups = cloneEndpointsMap(src.TerminatingGateway.ServiceGroups)
+	s.TerminatingGateway.HostnameServices = cloneEndpointsMap(src.TerminatingGateway.HostnameServices)
+
+	s.MeshGateway.ServiceGroups = cloneEndpointsMap(src.MeshGateway.ServiceGroups)
+	s.MeshGateway.GatewayGroups = cloneEndpointsMap(src.MeshGateway.GatewayGroups)
+	s.MeshGateway.FedStateGateways = cloneEndpointsMap(src.MeshGateway.FedStateGateways)
+	s.MeshGateway.ConsulServers = src.MeshGateway.ConsulServers
+	s.MeshGateway.HostnameDatacenters = cloneEndpointsMap(src.MeshGateway.HostnameDatacenters)
+}
+
+func (u *ConfigSnapshotUpstreams) clearEndpoints() {
+	u.WatchedUpstreamEndpoints = nil
+	u.WatchedGatewayEndpoints = nil
+	u.PeerUpstreamEndpoints = nil
+}
+
+func (u *ConfigSnapshotUpstreams) cloneEndpointsFrom(src *ConfigSnapshotUpstreams) {
+	u.WatchedUpstreamEndpoints = cloneNestedEndpointsMap(src.WatchedUpstreamEndpoints)
+	u.WatchedGatewayEndpoints = cloneNestedEndpointsMap(src.WatchedGatewayEndpoints)
+	u.PeerUpstreamEndpoints = cloneEndpointsMap(src.PeerUpstreamEndpoints)
+}
+
+func cloneNestedEndpointsMap(m map[UpstreamID]map[string]structs.CheckServiceNodes) map[UpstreamID]map[string]structs.CheckServiceNodes {
+	if m == nil {
+		return nil
+	}
+	out := make(map[UpstreamID]map[string]structs.CheckServiceNodes, len(m))
+	for uid, targets := range m {
+		out[uid] = cloneEndpointsMap(targets)
+	}
+	return out
+}
+
+func cloneEndpointsMap[K comparable](m map[K]structs.CheckServiceNodes) map[K]structs.CheckServiceNodes {
+	if m == nil {
+		return nil
+	}
+	out := make(map[K]structs.CheckServiceNodes, len(m))
+	for k, nodes := range m {
+		out[k] = nodes
+	}
+	return out
+}
+
 func (s *ConfigSnapshot) Leaf() *structs.IssuedCert {
 	switch s.Kind {
 	case structs.ServiceKindConnectProxy:
 		return s.ConnectProxy.Leaf
 	case structs.ServiceKindIngressGateway:
 		return s.IngressGateway.Leaf
+	case structs.ServiceKindEgressGateway:
+		return s.EgressGateway.Leaf
 	default:
 		return nil
 	}
@@ -622,6 +835,8 @@ func (s *ConfigSnapshot) MeshConfig() *structs.MeshConfigEntry {
 		return s.IngressGateway.MeshConfig
 	case structs.ServiceKindTerminatingGateway:
 		return s.TerminatingGateway.MeshConfig
+	case structs.ServiceKindEgressGateway:
+		return s.EgressGateway.MeshConfig
 	default:
 		return nil
 	}
@@ -643,6 +858,54 @@ func (s *ConfigSnapshot) MeshConfigTLSOutgoing() *structs.MeshDirectionalTLSConf
 	return mesh.TLS.Outgoing
 }
 
+// TracingCollectorChain returns the discovery chain of the upstream a sidecar
+// proxy reports spans to, as configured by the tracing of the mesh config
+// entry. It returns nil without an error when tracing isn't configured or the
+// chain of the collector hasn't been fetched yet, and an error when the
+// collector isn't one of the upstreams of the proxy.
+func (s *ConfigSnapshot) TracingCollectorChain() (*structs.CompiledDiscoveryChain, error) {
+	if s.Kind != structs.ServiceKindConnectProxy {
+		return nil, nil
+	}
+	mesh := s.MeshConfig()
+	if mesh == nil || mesh.Tracing == nil {
+		return nil, nil
+	}
+
+	name := mesh.Tracing.CollectorUpstream
+	isCollector := func(uid UpstreamID) bool {
+		return uid.Name == name &&
+			uid.Type == "" &&
+			uid.Peer == "" &&
+			(uid.Datacenter == "" || uid.Datacenter == s.Datacenter) &&
+			uid.NamespaceOrDefault() == s.ProxyID.NamespaceOrDefault() &&
+			uid.PartitionOrDefault() == s.ProxyID.PartitionOrDefault()
+	}
+	isKnown := func(uid UpstreamID) bool {
+		if _, ok := s.ConnectProxy.IntentionUpstreams[uid]; ok {
+			return true
+		}
+		return s.ConnectProxy.UpstreamConfig[uid].HasLocalPortOrSocket()
+	}
+
+	for uid, chain := range s.ConnectProxy.DiscoveryChain {
+		if isCollector(uid) && isKnown(uid) {
+			return chain, nil
+		}
+	}
+	for uid := range s.ConnectProxy.UpstreamConfig {
+		if isCollector(uid) && isKnown(uid) {
+			return nil, nil
+		}
+	}
+	for uid := range s.ConnectProxy.IntentionUpstreams {
+		if isCollector(uid) {
+			return nil, nil
+		}
+	}
+	return nil, fmt.Errorf("tracing collector %q is not an upstream of the proxy", name)
+}
+
 func (u *ConfigSnapshotUpstreams) UpstreamPeerMeta(uid UpstreamID) structs.PeeringServiceMeta {
 	nodes := u.PeerUpstreamEndpoints[uid]
 	if len(nodes) == 0 {
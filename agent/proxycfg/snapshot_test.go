@@ -0,0 +1,120 @@
+package proxycfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestConfigSnapshot_Clone_SharesEndpoints(t *testing.T) {
+	snap := TestConfigSnapshotMeshGateway(t, "default", nil, nil)
+
+	sn := structs.NewServiceName("foo", nil)
+	nodes := snap.MeshGateway.ServiceGroups[sn]
+	require.NotEmpty(t, nodes)
+
+	clone, err := snap.Clone()
+	require.NoError(t, err)
+	require.Equal(t, snap.MeshGateway.ServiceGroups, clone.MeshGateway.ServiceGroups)
+
+	// The endpoints are shared with the clone.
+	cloned := clone.MeshGateway.ServiceGroups[sn]
+	require.Same(t, &nodes[0], &cloned[0])
+
+	// The maps holding them are not, so replacing endpoints in the snapshot
+	// doesn't affect the clone.
+	snap.MeshGateway.ServiceGroups[sn] = nil
+	require.Len(t, clone.MeshGateway.ServiceGroups[sn], len(nodes))
+}
+
+func TestSetPool(t *testing.T) {
+	var pool setPool[string]
+
+	set := pool.get()
+	require.Empty(t, set)
+	set["a"] = struct{}{}
+	pool.put(set)
+	pool.put(nil)
+
+	// Sets are emptied before they are reused.
+	for i := 0; i < 3; i++ {
+		require.Empty(t, pool.get())
+	}
+}
+
+func TestConfigSnapshot_OnlyTLSChangedSince(t *testing.T) {
+	prev := &ConfigSnapshot{Revisions: SnapshotRevisions{Source: 1}}
+
+	snap := *prev
+	snap.Revisions.applied(UpdateEvent{CorrelationID: rootsWatchID}, false)
+	snap.Revisions.applied(UpdateEvent{CorrelationID: leafWatchID}, false)
+	require.True(t, snap.OnlyTLSChangedSince(prev))
+
+	rotated := snap
+	rotated.Revisions.applied(UpdateEvent{CorrelationID: rootsWatchID}, true)
+	require.False(t, rotated.OnlyTLSChangedSince(prev))
+
+	updated := snap
+	updated.Revisions.applied(UpdateEvent{CorrelationID: intentionsWatchID}, false)
+	require.False(t, updated.OnlyTLSChangedSince(prev))
+
+	other := *prev
+	other.Revisions.Source = 2
+	require.False(t, other.OnlyTLSChangedSince(prev))
+
+	require.False(t, prev.OnlyTLSChangedSince(nil))
+	require.False(t, (&ConfigSnapshot{}).OnlyTLSChangedSince(&ConfigSnapshot{}))
+}
+
+func TestConfigSnapshot_TracingCollectorChain(t *testing.T) {
+	withCollector := func(snap *ConfigSnapshot, collector string) *ConfigSnapshot {
+		snap.ConnectProxy.MeshConfig = &structs.MeshConfigEntry{
+			Tracing: &structs.MeshTracingConfig{CollectorUpstream: collector},
+		}
+		return snap
+	}
+
+	t.Run("tracing not configured", func(t *testing.T) {
+		chain, err := TestConfigSnapshot(t, nil, nil).TracingCollectorChain()
+		require.NoError(t, err)
+		require.Nil(t, chain)
+	})
+
+	t.Run("explicit upstream", func(t *testing.T) {
+		snap := withCollector(TestConfigSnapshot(t, nil, nil), "db")
+		chain, err := snap.TracingCollectorChain()
+		require.NoError(t, err)
+		require.NotNil(t, chain)
+		require.Equal(t, "db", chain.ServiceName)
+	})
+
+	t.Run("implicit upstream", func(t *testing.T) {
+		snap := withCollector(TestConfigSnapshotTransparentProxy(t), "google")
+		chain, err := snap.TracingCollectorChain()
+		require.NoError(t, err)
+		require.NotNil(t, chain)
+		require.Equal(t, "google", chain.ServiceName)
+	})
+
+	t.Run("chain not fetched yet", func(t *testing.T) {
+		snap := withCollector(TestConfigSnapshot(t, nil, nil), "db")
+		snap.ConnectProxy.DiscoveryChain = nil
+		chain, err := snap.TracingCollectorChain()
+		require.NoError(t, err)
+		require.Nil(t, chain)
+	})
+
+	t.Run("prepared query upstream", func(t *testing.T) {
+		snap := withCollector(TestConfigSnapshot(t, nil, nil), "geo-cache")
+		_, err := snap.TracingCollectorChain()
+		require.EqualError(t, err, `tracing collector "geo-cache" is not an upstream of the proxy`)
+	})
+
+	t.Run("not an upstream", func(t *testing.T) {
+		snap := withCollector(TestConfigSnapshot(t, nil, nil), "zipkin")
+		_, err := snap.TracingCollectorChain()
+		require.EqualError(t, err, `tracing collector "zipkin" is not an upstream of the proxy`)
+	})
+}
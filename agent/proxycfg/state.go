@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
@@ -36,12 +38,16 @@ const (
 	serviceResolverIDPrefix            = "service-resolver:"
 	serviceIntentionsIDPrefix          = "service-intentions:"
 	intentionUpstreamsID               = "intention-upstreams"
+	upstreamsDemandID                  = "upstreams-demand"
+	upstreamsExpiryID                  = "upstreams-expiry"
+	upstreamsActivityID                = "upstreams-activity"
 	upstreamPeerWatchIDPrefix          = "upstream-peer:"
 	exportedServiceListWatchID         = "exported-service-list"
 	meshConfigEntryID                  = "mesh"
 	svcChecksWatchIDPrefix             = cachetype.ServiceHTTPChecksName + ":"
 	preparedQueryIDPrefix              = string(structs.UpstreamDestTypePreparedQuery) + ":"
 	defaultPreparedQueryPollInterval   = 30 * time.Second
+	minOnDemandUpstreamsTTL            = time.Minute
 )
 
 type stateConfig struct {
@@ -66,6 +72,10 @@ type state struct {
 	// in Watch.
 	cancel func()
 
+	// done is set by Watch and closed once the goroutine started in Watch
+	// stops handling updates.
+	done <-chan struct{}
+
 	ch     chan UpdateEvent
 	snapCh chan ConfigSnapshot
 	reqCh  chan chan *ConfigSnapshot
@@ -84,6 +94,7 @@ type serviceInstance struct {
 	proxyID         ProxyID
 	address         string
 	port            int
+	socketPath      string
 	meta            map[string]string
 	taggedAddresses map[string]structs.ServiceAddress
 	proxyCfg        structs.ConnectProxyConfig
@@ -185,8 +196,11 @@ func newKindHandler(config stateConfig, s serviceInstance, ch chan UpdateEvent)
 		handler = &handlerMeshGateway{handlerState: h}
 	case structs.ServiceKindIngressGateway:
 		handler = &handlerIngressGateway{handlerState: h}
+	case structs.ServiceKindEgressGateway:
+		h.stateConfig.logger = config.logger.Named(logging.EgressGateway)
+		handler = &handlerEgressGateway{handlerState: h}
 	default:
-		return nil, errors.New("not a connect-proxy, terminating-gateway, mesh-gateway, or ingress-gateway")
+		return nil, errors.New("not a connect-proxy, terminating-gateway, mesh-gateway, ingress-gateway, or egress-gateway")
 	}
 
 	return handler, nil
@@ -214,6 +228,7 @@ func newServiceInstanceFromNodeService(id ProxyID, ns *structs.NodeService, toke
 		proxyID:         id,
 		address:         ns.Address,
 		port:            ns.Port,
+		socketPath:      ns.SocketPath,
 		meta:            meta,
 		taggedAddresses: taggedAddresses,
 		proxyCfg:        proxyCfg,
@@ -233,6 +248,7 @@ type kindHandler interface {
 func (s *state) Watch() (<-chan ConfigSnapshot, error) {
 	var ctx context.Context
 	ctx, s.cancel = context.WithCancel(context.Background())
+	s.done = ctx.Done()
 
 	snap, err := s.handler.initialize(ctx)
 	if err != nil {
@@ -259,6 +275,38 @@ type handlerState struct {
 	ch              chan UpdateEvent
 }
 
+// setPool pools the sets the handlers build on every update of a list of
+// services or upstreams, so that their memory is reused across the updates of
+// all the proxies of the agent rather than left to the garbage collector.
+type setPool[K comparable] struct {
+	pool sync.Pool
+}
+
+var (
+	serviceNameSets setPool[structs.ServiceName]
+	upstreamIDSets  setPool[UpstreamID]
+)
+
+// get returns an empty set.
+func (p *setPool[K]) get() map[K]struct{} {
+	if set, ok := p.pool.Get().(map[K]struct{}); ok {
+		return set
+	}
+	return make(map[K]struct{})
+}
+
+// put empties the set and returns it to the pool. The set must not be used
+// afterwards.
+func (p *setPool[K]) put(set map[K]struct{}) {
+	if set == nil {
+		return
+	}
+	for k := range set {
+		delete(set, k)
+	}
+	p.pool.Put(set)
+}
+
 func newConfigSnapshotFromServiceInstance(s serviceInstance, config stateConfig) ConfigSnapshot {
 	// TODO: use serviceInstance type in ConfigSnapshot
 	return ConfigSnapshot{
@@ -267,6 +315,7 @@ func newConfigSnapshotFromServiceInstance(s serviceInstance, config stateConfig)
 		ProxyID:               s.proxyID,
 		Address:               s.address,
 		Port:                  s.port,
+		SocketPath:            s.socketPath,
 		ServiceMeta:           s.meta,
 		TaggedAddresses:       s.taggedAddresses,
 		Proxy:                 s.proxyCfg,
@@ -277,6 +326,22 @@ func newConfigSnapshotFromServiceInstance(s serviceInstance, config stateConfig)
 	}
 }
 
+// snapshotSources is the last Source assigned to the revisions of a proxy state.
+var snapshotSources uint64
+
+// applied counts an update applied to a snapshot.
+func (r *SnapshotRevisions) applied(u UpdateEvent, trustDomainChanged bool) {
+	switch {
+	case trustDomainChanged:
+		// The trust domain is part of the names of many resources.
+		r.Config++
+	case u.CorrelationID == rootsWatchID, u.CorrelationID == leafWatchID:
+		r.TLS++
+	default:
+		r.Config++
+	}
+}
+
 func (s *state) run(ctx context.Context, snap *ConfigSnapshot) {
 	// Close the channel we return from Watch when we stop so consumers can stop
 	// watching and clean up their goroutines. It's important we do this here and
@@ -291,6 +356,8 @@ func (s *state) run(ctx context.Context, snap *ConfigSnapshot) {
 	sendCh := make(chan struct{})
 	var coalesceTimer *time.Timer
 
+	snap.Revisions = SnapshotRevisions{Source: atomic.AddUint64(&snapshotSources, 1)}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -298,12 +365,14 @@ func (s *state) run(ctx context.Context, snap *ConfigSnapshot) {
 		case u := <-s.ch:
 			s.logger.Trace("A blocking query returned; handling snapshot update", "correlationID", u.CorrelationID)
 
+			trustDomain := snap.trustDomain()
 			if err := s.handler.handleUpdate(ctx, u, snap); err != nil {
 				s.logger.Error("Failed to handle update from watch",
 					"id", u.CorrelationID, "error", err,
 				)
 				continue
 			}
+			snap.Revisions.applied(u, trustDomain != snap.trustDomain())
 
 		case <-sendCh:
 			// Allow the next change to trigger a send
@@ -354,6 +423,8 @@ func (s *state) run(ctx context.Context, snap *ConfigSnapshot) {
 			snapCopy, err := snap.Clone()
 			if err != nil {
 				s.logger.Error("Failed to copy config snapshot for proxy", "error", err)
+				// Always reply so that the requester isn't blocked forever.
+				replyCh <- nil
 				continue
 			}
 			replyCh <- snapCopy
@@ -386,9 +457,45 @@ func (s *state) run(ctx context.Context, snap *ConfigSnapshot) {
 func (s *state) CurrentSnapshot() *ConfigSnapshot {
 	// Make a chan for the response to be sent on
 	ch := make(chan *ConfigSnapshot, 1)
-	s.reqCh <- ch
+	select {
+	case s.reqCh <- ch:
+	case <-s.done:
+		return nil
+	}
 	// Wait for the response
-	return <-ch
+	select {
+	case snap := <-ch:
+		return snap
+	case <-s.done:
+		return nil
+	}
+}
+
+// DemandUpstreams asks the proxy in on-demand mode to start watching the given
+// intention upstreams, or to record their activity. It blocks until the
+// request is queued or the state is closed.
+func (s *state) DemandUpstreams(uids []UpstreamID) {
+	select {
+	case s.ch <- UpdateEvent{CorrelationID: upstreamsDemandID, Result: uids}:
+	case <-s.done:
+	}
+}
+
+// upstreamsActivity is a load report of a proxy in on-demand mode, with the
+// demanded upstreams which had traffic.
+type upstreamsActivity struct {
+	uids       []UpstreamID
+	reportedAt time.Time
+}
+
+// ReportUpstreamsActivity records a load report of the proxy in on-demand
+// mode, with the upstreams which had traffic. It blocks until the report is
+// queued or the state is closed.
+func (s *state) ReportUpstreamsActivity(uids []UpstreamID) {
+	select {
+	case s.ch <- UpdateEvent{CorrelationID: upstreamsActivityID, Result: upstreamsActivity{uids: uids, reportedAt: time.Now()}}:
+	case <-s.done:
+	}
 }
 
 // Changed returns whether or not the passed NodeService has had any of the
@@ -407,6 +514,7 @@ func (s *state) Changed(ns *structs.NodeService, token string) bool {
 	return ns.Kind != i.kind ||
 		i.address != ns.Address ||
 		i.port != ns.Port ||
+		i.socketPath != ns.SocketPath ||
 		!reflect.DeepEqual(i.proxyCfg, proxyCfg) ||
 		i.token != token
 }
@@ -381,6 +381,25 @@ func upstreamIDForDC2(uid UpstreamID) UpstreamID {
 //    a. Ensure that the required watches are in place and validate they are correct
 //    b. Process a bunch of UpdateEvents by calling handleUpdate
 //    c. Validate that the ConfigSnapshot has been updated appropriately
+func TestState_CurrentSnapshot_Stopped(t *testing.T) {
+	// Once the state goroutine stopped nobody replies to the requests, which
+	// must not block the caller.
+	done := make(chan struct{})
+	close(done)
+	s := &state{reqCh: make(chan chan *ConfigSnapshot, 1), done: done}
+
+	result := make(chan *ConfigSnapshot)
+	go func() {
+		result <- s.CurrentSnapshot()
+	}()
+	select {
+	case snap := <-result:
+		require.Nil(t, snap)
+	case <-time.After(5 * time.Second):
+		t.Fatal("CurrentSnapshot blocked on a stopped state")
+	}
+}
+
 func TestState_WatchesAndUpdates(t *testing.T) {
 	t.Parallel()
 
@@ -998,7 +1017,7 @@ func TestState_WatchesAndUpdates(t *testing.T) {
 					verifySnapshot: func(t testing.TB, snap *ConfigSnapshot) {
 						require.False(t, snap.Valid(), "gateway without leaf is not valid")
 						require.True(t, snap.IngressGateway.HostsSet)
-						require.Len(t, snap.IngressGateway.Hosts, 0)
+						require.Empty(t, snap.IngressGateway.BoundHosts)
 						require.Len(t, snap.IngressGateway.Upstreams, 1)
 						key := IngressListenerKey{Protocol: "http", Port: 9999}
 						require.Equal(t, snap.IngressGateway.Upstreams[key], structs.Upstreams{
@@ -1151,7 +1170,7 @@ func TestState_WatchesAndUpdates(t *testing.T) {
 						require.True(t, snap.IngressGateway.GatewayConfigLoaded)
 						require.True(t, snap.IngressGateway.TLSConfig.Enabled)
 						require.True(t, snap.IngressGateway.HostsSet)
-						require.Len(t, snap.IngressGateway.Hosts, 1)
+						require.Equal(t, []string{"test.example.com"}, snap.IngressGateway.BoundHosts[IngressListenerKey{Port: 9999}])
 						require.Len(t, snap.IngressGateway.Upstreams, 1)
 						require.Len(t, snap.IngressGateway.WatchedDiscoveryChains, 1)
 						require.Contains(t, snap.IngressGateway.WatchedDiscoveryChains, apiUID)
@@ -1241,7 +1260,7 @@ func TestState_WatchesAndUpdates(t *testing.T) {
 						require.Nil(t, l.TLS)
 
 						require.True(t, snap.IngressGateway.HostsSet)
-						require.Len(t, snap.IngressGateway.Hosts, 1)
+						require.Equal(t, []string{"test.example.com"}, snap.IngressGateway.BoundHosts[IngressListenerKey{Port: 9999}])
 						require.Len(t, snap.IngressGateway.Upstreams, 1)
 						require.Len(t, snap.IngressGateway.WatchedDiscoveryChains, 1)
 						require.Contains(t, snap.IngressGateway.WatchedDiscoveryChains, apiUID)
@@ -1276,6 +1295,85 @@ func TestState_WatchesAndUpdates(t *testing.T) {
 				},
 			},
 		},
+		"egress-gateway": {
+			ns: structs.NodeService{
+				Kind:    structs.ServiceKindEgressGateway,
+				ID:      "egress-gateway",
+				Service: "egress-gateway",
+				Address: "10.0.1.1",
+			},
+			sourceDC: "dc1",
+			stages: []verificationStage{
+				{
+					requiredWatches: map[string]verifyWatchRequest{
+						meshConfigEntryID:    genVerifyMeshConfigWatch("dc1"),
+						gatewayConfigWatchID: genVerifyConfigEntryWatch(structs.EgressGateway, "egress-gateway", "dc1"),
+						rootsWatchID:         genVerifyDCSpecificWatch("dc1"),
+						leafWatchID:          genVerifyLeafWatch("egress-gateway", "dc1"),
+						intentionsWatchID:    genVerifyIntentionWatch("egress-gateway", "dc1"),
+					},
+					verifySnapshot: func(t testing.TB, snap *ConfigSnapshot) {
+						require.False(t, snap.Valid(), "gateway without root is not valid")
+						require.True(t, snap.EgressGateway.isEmpty())
+					},
+				},
+				{
+					events: []UpdateEvent{
+						rootWatchEvent(),
+						{
+							CorrelationID: meshConfigEntryID,
+							Result:        &structs.ConfigEntryResponse{},
+						},
+						{
+							CorrelationID: leafWatchID,
+							Result:        issuedCert,
+						},
+						{
+							CorrelationID: intentionsWatchID,
+							Result:        TestIntentions(),
+						},
+					},
+					verifySnapshot: func(t testing.TB, snap *ConfigSnapshot) {
+						require.False(t, snap.Valid(), "gateway without config entry is not valid")
+						require.Equal(t, indexedRoots, snap.Roots)
+						require.Equal(t, issuedCert, snap.Leaf())
+						require.True(t, snap.EgressGateway.IntentionsSet)
+					},
+				},
+				{
+					events: []UpdateEvent{
+						{
+							CorrelationID: gatewayConfigWatchID,
+							Result:        &structs.ConfigEntryResponse{},
+						},
+					},
+					verifySnapshot: func(t testing.TB, snap *ConfigSnapshot) {
+						require.True(t, snap.Valid(), "gateway without a config entry is valid")
+						require.Nil(t, snap.EgressGateway.GatewayConfig)
+					},
+				},
+				{
+					events: []UpdateEvent{
+						{
+							CorrelationID: gatewayConfigWatchID,
+							Result: &structs.ConfigEntryResponse{
+								Entry: &structs.EgressGatewayConfigEntry{
+									Kind: structs.EgressGateway,
+									Name: "egress-gateway",
+									Destinations: []structs.EgressGatewayDestination{
+										{Hostname: "api.example.com", Port: 443},
+									},
+								},
+							},
+						},
+					},
+					verifySnapshot: func(t testing.TB, snap *ConfigSnapshot) {
+						require.True(t, snap.Valid())
+						require.Len(t, snap.EgressGateway.GatewayConfig.Destinations, 1)
+					},
+				},
+			},
+		},
 		"terminating-gateway-initial": {
 			ns: structs.NodeService{
 				Kind:    structs.ServiceKindTerminatingGateway,
@@ -2307,6 +2405,234 @@ func TestState_WatchesAndUpdates(t *testing.T) {
 				},
 			},
 		},
+		// Only the upstreams demanded by the proxy should be watched
+		"transparent-proxy-on-demand": {
+			ns: structs.NodeService{
+				Kind:    structs.ServiceKindConnectProxy,
+				ID:      "api-proxy",
+				Service: "api-proxy",
+				Address: "10.0.1.1",
+				Proxy: structs.ConnectProxyConfig{
+					DestinationServiceName: "api",
+					Mode:                   structs.ProxyModeTransparent,
+					Config: map[string]interface{}{
+						"on_demand_upstreams": true,
+					},
+				},
+			},
+			sourceDC: "dc1",
+			stages: []verificationStage{
+				{
+					requiredWatches: map[string]verifyWatchRequest{
+						intentionsWatchID:    genVerifyIntentionWatch("api", "dc1"),
+						intentionUpstreamsID: genVerifyServiceSpecificRequest("api", "", "dc1", false),
+						meshConfigEntryID:    genVerifyMeshConfigWatch("dc1"),
+						rootsWatchID:         genVerifyDCSpecificWatch("dc1"),
+						leafWatchID:          genVerifyLeafWatch("api", "dc1"),
+					},
+					events: []UpdateEvent{
+						rootWatchEvent(),
+						{
+							CorrelationID: leafWatchID,
+							Result:        issuedCert,
+							Err:           nil,
+						},
+						{
+							CorrelationID: intentionsWatchID,
+							Result:        TestIntentions(),
+							Err:           nil,
+						},
+						{
+							CorrelationID: meshConfigEntryID,
+							Result:        &structs.ConfigEntryResponse{},
+							Err:           nil,
+						},
+					},
+					verifySnapshot: func(t testing.TB, snap *ConfigSnapshot) {
+						require.True(t, snap.Valid(), "proxy with roots/leaf/intentions is valid")
+						require.True(t, snap.ConnectProxy.OnDemandUpstreams)
+						require.Empty(t, snap.ConnectProxy.DemandedUpstreams)
+					},
+				},
+				// Upstreams inferred from intentions aren't watched until they are demanded
+				{
+					events: []UpdateEvent{
+						{
+							CorrelationID: intentionUpstreamsID,
+							Result: &structs.IndexedServiceList{
+								Services: structs.ServiceList{db, billing},
+							},
+							Err: nil,
+						},
+					},
+					verifySnapshot: func(t testing.TB, snap *ConfigSnapshot) {
+						billingUID := NewUpstreamIDFromServiceName(billing)
+						require.Equal(t, map[UpstreamID]struct{}{dbUID: {}, billingUID: {}}, snap.ConnectProxy.IntentionUpstreams)
+						require.Empty(t, snap.ConnectProxy.WatchedDiscoveryChains)
+					},
+				},
+				// Demanding an upstream starts watching its discovery chain, unless it isn't allowed by intentions
+				{
+					events: []UpdateEvent{
+						{
+							CorrelationID: upstreamsDemandID,
+							Result:        []UpstreamID{dbUID, apiUID},
+						},
+					},
+					verifySnapshot: func(t testing.TB, snap *ConfigSnapshot) {
+						require.Len(t, snap.ConnectProxy.DemandedUpstreams, 1)
+						require.Contains(t, snap.ConnectProxy.DemandedUpstreams, dbUID)
+						require.Len(t, snap.ConnectProxy.WatchedDiscoveryChains, 1)
+						require.Contains(t, snap.ConnectProxy.WatchedDiscoveryChains, dbUID)
+					},
+				},
+				// Upstreams which are no longer allowed are forgotten
+				{
+					requiredWatches: map[string]verifyWatchRequest{
+						"discovery-chain:" + dbUID.String(): genVerifyDiscoveryChainWatch(&structs.DiscoveryChainRequest{
+							Name:                 "db",
+							EvaluateInDatacenter: "dc1",
+							EvaluateInNamespace:  "default",
+							EvaluateInPartition:  "default",
+							Datacenter:           "dc1",
+						}),
+					},
+					events: []UpdateEvent{
+						{
+							CorrelationID: intentionUpstreamsID,
+							Result: &structs.IndexedServiceList{
+								Services: structs.ServiceList{billing},
+							},
+							Err: nil,
+						},
+					},
+					verifySnapshot: func(t testing.TB, snap *ConfigSnapshot) {
+						require.Empty(t, snap.ConnectProxy.DemandedUpstreams)
+						require.Empty(t, snap.ConnectProxy.WatchedDiscoveryChains)
+					},
+				},
+			},
+		},
+		// Demanded upstreams without activity should be pruned
+		"transparent-proxy-on-demand-ttl": {
+			ns: structs.NodeService{
+				Kind:    structs.ServiceKindConnectProxy,
+				ID:      "api-proxy",
+				Service: "api-proxy",
+				Address: "10.0.1.1",
+				Proxy: structs.ConnectProxyConfig{
+					DestinationServiceName: "api",
+					Mode:                   structs.ProxyModeTransparent,
+					Config: map[string]interface{}{
+						"on_demand_upstreams":     true,
+						"on_demand_upstreams_ttl": "10m",
+					},
+				},
+			},
+			sourceDC: "dc1",
+			stages: []verificationStage{
+				{
+					events: []UpdateEvent{
+						rootWatchEvent(),
+						{
+							CorrelationID: leafWatchID,
+							Result:        issuedCert,
+							Err:           nil,
+						},
+						{
+							CorrelationID: intentionsWatchID,
+							Result:        TestIntentions(),
+							Err:           nil,
+						},
+						{
+							CorrelationID: meshConfigEntryID,
+							Result:        &structs.ConfigEntryResponse{},
+							Err:           nil,
+						},
+						{
+							CorrelationID: intentionUpstreamsID,
+							Result: &structs.IndexedServiceList{
+								Services: structs.ServiceList{db},
+							},
+							Err: nil,
+						},
+						{
+							CorrelationID: upstreamsDemandID,
+							Result:        []UpstreamID{dbUID},
+						},
+						{
+							CorrelationID: "discovery-chain:" + dbUID.String(),
+							Result: &structs.DiscoveryChainResponse{
+								Chain: discoverychain.TestCompileConfigEntries(t, "db", "default", "default", "dc1", "trustdomain.consul", nil),
+							},
+							Err: nil,
+						},
+					},
+					verifySnapshot: func(t testing.TB, snap *ConfigSnapshot) {
+						require.True(t, snap.Valid(), "proxy with roots/leaf/intentions is valid")
+						require.Equal(t, 10*time.Minute, snap.ConnectProxy.OnDemandUpstreamsTTL)
+						require.Contains(t, snap.ConnectProxy.DemandedUpstreams, dbUID)
+						require.Contains(t, snap.ConnectProxy.WatchedDiscoveryChains, dbUID)
+						require.Len(t, snap.ConnectProxy.WatchedUpstreams[dbUID], 1)
+					},
+				},
+				// Idle upstreams are kept while the proxy doesn't report its load
+				{
+					events: []UpdateEvent{
+						{
+							CorrelationID: upstreamsExpiryID,
+							Result:        time.Now().Add(time.Hour),
+						},
+					},
+					verifySnapshot: func(t testing.TB, snap *ConfigSnapshot) {
+						require.Contains(t, snap.ConnectProxy.DemandedUpstreams, dbUID)
+						require.Contains(t, snap.ConnectProxy.WatchedDiscoveryChains, dbUID)
+					},
+				},
+				// Idle upstreams are kept until the proxy reported its load
+				// for the whole TTL
+				{
+					events: []UpdateEvent{
+						{
+							CorrelationID: upstreamsActivityID,
+							Result:        upstreamsActivity{reportedAt: time.Now().Add(50 * time.Minute)},
+						},
+						{
+							CorrelationID: upstreamsExpiryID,
+							Result:        time.Now().Add(55 * time.Minute),
+						},
+					},
+					verifySnapshot: func(t testing.TB, snap *ConfigSnapshot) {
+						require.Contains(t, snap.ConnectProxy.DemandedUpstreams, dbUID)
+						require.Contains(t, snap.ConnectProxy.WatchedDiscoveryChains, dbUID)
+					},
+				},
+				// Idle upstreams are no longer watched but are still allowed
+				{
+					events: []UpdateEvent{
+						{
+							CorrelationID: upstreamsActivityID,
+							Result:        upstreamsActivity{reportedAt: time.Now().Add(54 * time.Minute)},
+						},
+						{
+							CorrelationID: upstreamsActivityID,
+							Result:        upstreamsActivity{reportedAt: time.Now().Add(58 * time.Minute)},
+						},
+						{
+							CorrelationID: upstreamsExpiryID,
+							Result:        time.Now().Add(62 * time.Minute),
+						},
+					},
+					verifySnapshot: func(t testing.TB, snap *ConfigSnapshot) {
+						require.Empty(t, snap.ConnectProxy.DemandedUpstreams)
+						require.Empty(t, snap.ConnectProxy.WatchedDiscoveryChains)
+						require.Empty(t, snap.ConnectProxy.WatchedUpstreams)
+						require.Empty(t, snap.ConnectProxy.DiscoveryChain)
+						require.Contains(t, snap.ConnectProxy.IntentionUpstreams, dbUID)
+					},
+				},
+			},
+		},
 		// Receiving an empty upstreams from Intentions list shouldn't delete explicit upstream watches
 		"transparent-proxy-handle-update-explicit-cross-dc": {
 			ns: structs.NodeService{
@@ -105,7 +105,8 @@ func (s *handlerTerminatingGateway) handleUpdate(ctx context.Context, u UpdateEv
 			return fmt.Errorf("invalid type for response: %T", u.Result)
 		}
 
-		svcMap := make(map[structs.ServiceName]struct{})
+		svcMap := serviceNameSets.get()
+		defer serviceNameSets.put(svcMap)
 		for _, svc := range services.Services {
 			// Make sure to add every service to this map, we use it to cancel watches below.
 			svcMap[svc.Service] = struct{}{}
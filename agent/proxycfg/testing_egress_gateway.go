@@ -0,0 +1,95 @@
+package proxycfg
+
+import (
+	"time"
+
+	"github.com/mitchellh/go-testing-interface"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestConfigSnapshotEgressGateway(
+	t testing.T,
+	nsFn func(ns *structs.NodeService),
+	entryFn func(entry *structs.EgressGatewayConfigEntry),
+	extraUpdates []UpdateEvent,
+) *ConfigSnapshot {
+	roots, leaf := TestCerts(t)
+
+	entry := &structs.EgressGatewayConfigEntry{
+		Kind: structs.EgressGateway,
+		Name: "egress-gateway",
+		Destinations: []structs.EgressGatewayDestination{
+			{
+				Hostname: "api.example.com",
+				Port:     443,
+				TLS: &structs.EgressGatewayTLSConfig{
+					CAFile: "/etc/ssl/certs/ca.pem",
+				},
+			},
+			{
+				Hostname: "updates.example.org",
+				Port:     80,
+			},
+		},
+	}
+	if entryFn != nil {
+		entryFn(entry)
+	}
+
+	baseEvents := []UpdateEvent{
+		{
+			CorrelationID: rootsWatchID,
+			Result:        roots,
+		},
+		{
+			CorrelationID: leafWatchID,
+			Result:        leaf,
+		},
+		{
+			CorrelationID: meshConfigEntryID,
+			Result:        &structs.ConfigEntryResponse{},
+		},
+		{
+			CorrelationID: gatewayConfigWatchID,
+			Result:        &structs.ConfigEntryResponse{Entry: entry},
+		},
+		{
+			CorrelationID: intentionsWatchID,
+			Result: &structs.IndexedIntentionMatches{
+				Matches: []structs.Intentions{
+					{
+						{
+							ID:              "web-to-egress",
+							SourceNS:        structs.IntentionDefaultNamespace,
+							SourceName:      "web",
+							DestinationNS:   structs.IntentionDefaultNamespace,
+							DestinationName: "egress-gateway",
+							Action:          structs.IntentionActionAllow,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return testConfigSnapshotFixture(t, &structs.NodeService{
+		Kind:    structs.ServiceKindEgressGateway,
+		Service: "egress-gateway",
+		Address: "1.2.3.4",
+		Port:    8443,
+	}, nsFn, nil, testSpliceEvents(baseEvents, extraUpdates))
+}
+
+// TestConfigSnapshotEgressGatewayReputationCheck returns an egress gateway
+// snapshot which asks a reputation service to allow each request.
+func TestConfigSnapshotEgressGatewayReputationCheck(t testing.T) *ConfigSnapshot {
+	return TestConfigSnapshotEgressGateway(t, nil, func(entry *structs.EgressGatewayConfigEntry) {
+		entry.ReputationCheck = &structs.EgressGatewayReputationCheck{
+			Address:  "10.0.0.10",
+			Port:     9191,
+			Timeout:  250 * time.Millisecond,
+			FailOpen: true,
+		}
+	}, nil)
+}
@@ -1036,6 +1036,46 @@ func TestConfigSnapshotIngressGatewayWithChain(
 			}
 		}
 		populateServices = true
+	case "router-cors":
+		configFn = func(entry *structs.IngressGatewayConfigEntry) {
+			entry.Listeners = []structs.IngressListener{
+				{
+					Port:     8080,
+					Protocol: "http",
+					Services: []structs.IngressService{
+						{
+							Name: "db",
+							CORS: &structs.IngressCORSPolicy{
+								AllowOrigins:       []string{"https://www.example.com"},
+								AllowOriginRegexes: []string{`https://.*\.example\.org`},
+								AllowMethods:       []string{"GET", "POST"},
+								AllowHeaders:       []string{"content-type", "x-custom"},
+								ExposeHeaders:      []string{"x-request-id"},
+								MaxAge:             10 * time.Minute,
+								AllowCredentials:   true,
+							},
+						},
+					},
+				},
+			}
+		}
+		populateServices = true
+	case "router-max-request-body-bytes":
+		configFn = func(entry *structs.IngressGatewayConfigEntry) {
+			entry.Listeners = []structs.IngressListener{
+				{
+					Port:     8080,
+					Protocol: "http",
+					Services: []structs.IngressService{
+						{
+							Name:                "db",
+							MaxRequestBodyBytes: 4096,
+						},
+					},
+				},
+			}
+		}
+		populateServices = true
 	case "sds-listener-level":
 		// Listener-level SDS means all services share the default route.
 		useSDS = true
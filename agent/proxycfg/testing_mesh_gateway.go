@@ -19,6 +19,7 @@ func TestConfigSnapshotMeshGateway(t testing.T, variant string, nsFn func(ns *st
 		populateServices    = true
 		useFederationStates = false
 		deleteCrossDCEntry  = false
+		serverSNIFn         ServerSNIFunc
 	)
 
 	switch variant {
@@ -67,6 +68,48 @@ func TestConfigSnapshotMeshGateway(t testing.T, variant string, nsFn func(ns *st
 				},
 			},
 		)
+	case "peer-through-mesh-gateways":
+		serverSNIFn = func(dc, nodeName string) string {
+			if nodeName == "" {
+				return "server." + dc + ".consul"
+			}
+			return nodeName + ".server." + dc + ".consul"
+		}
+		extraUpdates = append(extraUpdates,
+			UpdateEvent{
+				CorrelationID: meshConfigEntryID,
+				Result: &structs.ConfigEntryResponse{
+					Entry: &structs.MeshConfigEntry{
+						Peering: &structs.PeeringMeshConfig{
+							PeerThroughMeshGateways: true,
+						},
+					},
+				},
+			},
+			UpdateEvent{
+				CorrelationID: consulServerListWatchID,
+				Result: &structs.IndexedCheckServiceNodes{
+					Nodes: structs.CheckServiceNodes{
+						{
+							Node: &structs.Node{Node: "node1", Datacenter: "dc1", Address: "10.0.2.1"},
+							Service: &structs.NodeService{
+								ID:      structs.ConsulServiceID,
+								Service: structs.ConsulServiceName,
+								Port:    8300,
+							},
+						},
+						{
+							Node: &structs.Node{Node: "node2", Datacenter: "dc1", Address: "10.0.2.2"},
+							Service: &structs.NodeService{
+								ID:      structs.ConsulServiceID,
+								Service: structs.ConsulServiceName,
+								Port:    8300,
+							},
+						},
+					},
+				},
+			},
+		)
 	case "federation-states":
 		populateServices = true
 		useFederationStates = true
@@ -490,5 +533,5 @@ func TestConfigSnapshotMeshGateway(t testing.T, variant string, nsFn func(ns *st
 				Port:    443,
 			},
 		},
-	}, nsFn, nil, testSpliceEvents(baseEvents, extraUpdates))
+	}, nsFn, serverSNIFn, testSpliceEvents(baseEvents, extraUpdates))
 }
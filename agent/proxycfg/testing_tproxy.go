@@ -522,3 +522,97 @@ func TestConfigSnapshotTransparentProxyTerminatingGatewayCatalogDestinationsOnly
 		},
 	})
 }
+
+func TestConfigSnapshotTransparentProxyOnDemand(t testing.T) *ConfigSnapshot {
+	// Only the upstreams demanded by the proxy are watched, the other
+	// upstreams inferred from intentions are routed by their host.
+	var (
+		google      = structs.NewServiceName("google", nil)
+		googleUID   = NewUpstreamIDFromServiceName(google)
+		googleChain = discoverychain.TestCompileConfigEntries(t, "google", "default", "default", "dc1", connect.TestClusterID+".consul", nil)
+
+		kafka = structs.NewServiceName("kafka", nil)
+	)
+
+	return TestConfigSnapshot(t, func(ns *structs.NodeService) {
+		ns.Proxy.Mode = structs.ProxyModeTransparent
+		ns.Proxy.Config = map[string]interface{}{"on_demand_upstreams": true}
+	}, []UpdateEvent{
+		{
+			CorrelationID: meshConfigEntryID,
+			Result: &structs.ConfigEntryResponse{
+				Entry: nil,
+			},
+		},
+		{
+			CorrelationID: intentionUpstreamsID,
+			Result: &structs.IndexedServiceList{
+				Services: structs.ServiceList{
+					google,
+					kafka,
+				},
+			},
+		},
+		{
+			CorrelationID: upstreamsDemandID,
+			Result:        []UpstreamID{googleUID},
+		},
+		{
+			CorrelationID: "discovery-chain:" + googleUID.String(),
+			Result: &structs.DiscoveryChainResponse{
+				Chain: googleChain,
+			},
+		},
+		{
+			CorrelationID: "upstream-target:google.default.default.dc1:" + googleUID.String(),
+			Result: &structs.IndexedCheckServiceNodes{
+				Nodes: []structs.CheckServiceNode{
+					{
+						Node: &structs.Node{
+							Address:    "8.8.8.8",
+							Datacenter: "dc1",
+						},
+						Service: &structs.NodeService{
+							Service: "google",
+							Address: "9.9.9.9",
+							Port:    9090,
+							TaggedAddresses: map[string]structs.ServiceAddress{
+								structs.TaggedAddressVirtualIP: {Address: "240.0.0.1"},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestConfigSnapshotTransparentProxyEgressDynamicForwardProxy(t testing.T) *ConfigSnapshot {
+	// The hostnames allowed by the mesh config entry are reached through the
+	// dynamic forward proxy, even though the proxy can't reach other
+	// destinations outside the mesh.
+	return TestConfigSnapshot(t, func(ns *structs.NodeService) {
+		ns.Proxy.Mode = structs.ProxyModeTransparent
+		ns.Proxy.Config = map[string]interface{}{"egress_dynamic_forward_proxy": true}
+	}, []UpdateEvent{
+		{
+			CorrelationID: meshConfigEntryID,
+			Result: &structs.ConfigEntryResponse{
+				Entry: &structs.MeshConfigEntry{
+					TransparentProxy: structs.TransparentProxyMeshConfig{
+						MeshDestinationsOnly: true,
+					},
+					Egress: &structs.MeshEgressConfig{
+						AllowedHostnames: []string{"api.example.com", "*.googleapis.com", "api.example.com"},
+					},
+				},
+			},
+		},
+		{
+			CorrelationID: intentionUpstreamsID,
+			Result: &structs.IndexedServiceList{
+				Services: structs.ServiceList{},
+			},
+		},
+	})
+}
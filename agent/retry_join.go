@@ -6,10 +6,9 @@ import (
 	"time"
 
 	discover "github.com/hashicorp/go-discover"
-	discoverk8s "github.com/hashicorp/go-discover/provider/k8s"
 	"github.com/hashicorp/go-hclog"
 
-	"github.com/hashicorp/consul/lib"
+	"github.com/hashicorp/consul/agent/retryjoin"
 )
 
 func (a *Agent) retryJoinLAN() {
@@ -109,16 +108,7 @@ func (a *Agent) refreshPrimaryGatewayFallbackAddresses() {
 }
 
 func newDiscover() (*discover.Discover, error) {
-	providers := make(map[string]discover.Provider)
-	for k, v := range discover.Providers {
-		providers[k] = v
-	}
-	providers["k8s"] = &discoverk8s.Provider{}
-
-	return discover.New(
-		discover.WithUserAgent(lib.UserAgent()),
-		discover.WithProviders(providers),
-	)
+	return retryjoin.New()
 }
 
 func retryJoinAddrs(disco *discover.Discover, variant, cluster string, retryJoin []string, logger hclog.Logger) []string {
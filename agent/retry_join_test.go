@@ -12,9 +12,9 @@ func TestAgentRetryNewDiscover(t *testing.T) {
 	d, err := newDiscover()
 	require.NoError(t, err)
 	expected := []string{
-		"aliyun", "aws", "azure", "digitalocean", "gce", "k8s", "linode",
-		"mdns", "os", "packet", "scaleway", "softlayer", "tencentcloud",
-		"triton", "vsphere",
+		"aliyun", "aws", "azure", "digitalocean", "dns", "gce", "http", "k8s",
+		"linode", "mdns", "os", "packet", "scaleway", "softlayer",
+		"tencentcloud", "triton", "vsphere",
 	}
 	require.Equal(t, expected, d.Names())
 }
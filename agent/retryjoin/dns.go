@@ -0,0 +1,72 @@
+package retryjoin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dnsProvider discovers addresses from the SRV records of a DNS name.
+type dnsProvider struct {
+	// lookupSRV is overridden in tests.
+	lookupSRV func(ctx context.Context, name string) ([]*net.SRV, error)
+}
+
+func (p *dnsProvider) Help() string {
+	return `DNS SRV:
+
+    provider:          "dns"
+    name:              The name of the SRV records, such as
+                       "_consul-server._tcp.example.com".
+    timeout:           The lookup timeout. Default "5s" (five seconds).
+`
+}
+
+func (p *dnsProvider) Addrs(args map[string]string, l *log.Logger) ([]string, error) {
+	name := args["name"]
+	if name == "" {
+		return nil, fmt.Errorf("discover-dns: name of the SRV records not provided")
+	}
+
+	timeout := 5 * time.Second
+	if v := args["timeout"]; v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("discover-dns: invalid timeout %q: %w", v, err)
+		}
+		timeout = d
+	}
+
+	lookupSRV := p.lookupSRV
+	if lookupSRV == nil {
+		lookupSRV = func(ctx context.Context, name string) ([]*net.SRV, error) {
+			_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+			return records, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if l != nil {
+		l.Printf("[DEBUG] discover-dns: Looking up SRV records of %s", name)
+	}
+	records, err := lookupSRV(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("discover-dns: %w", err)
+	}
+
+	addrs := make([]string, 0, len(records))
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+		addrs = append(addrs, net.JoinHostPort(host, strconv.Itoa(int(record.Port))))
+	}
+	if l != nil {
+		l.Printf("[DEBUG] discover-dns: Found addresses %v", addrs)
+	}
+	return addrs, nil
+}
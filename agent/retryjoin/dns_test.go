@@ -0,0 +1,37 @@
+package retryjoin
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSProvider_Addrs(t *testing.T) {
+	p := &dnsProvider{
+		lookupSRV: func(_ context.Context, name string) ([]*net.SRV, error) {
+			if name != "_consul-server._tcp.example.com" {
+				return nil, errors.New("no such host")
+			}
+			return []*net.SRV{
+				{Target: "server-1.example.com.", Port: 8301},
+				{Target: "10.0.0.2", Port: 8311},
+			}, nil
+		},
+	}
+
+	addrs, err := p.Addrs(map[string]string{"name": "_consul-server._tcp.example.com"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"server-1.example.com:8301", "10.0.0.2:8311"}, addrs)
+
+	_, err = p.Addrs(map[string]string{"name": "_other._tcp.example.com"}, nil)
+	require.EqualError(t, err, "discover-dns: no such host")
+
+	_, err = p.Addrs(map[string]string{}, nil)
+	require.EqualError(t, err, "discover-dns: name of the SRV records not provided")
+
+	_, err = p.Addrs(map[string]string{"name": "x", "timeout": "soon"}, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,105 @@
+package retryjoin
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+)
+
+// httpMaxResponseSize limits the size of the responses of the endpoints
+// queried by the HTTP provider.
+const httpMaxResponseSize = 1024 * 1024 // 1MB
+
+// httpProvider discovers addresses from an HTTP endpoint returning them
+// either as a JSON array of strings or as plain text, one per line.
+type httpProvider struct{}
+
+func (p *httpProvider) Help() string {
+	return `HTTP:
+
+    provider:          "http"
+    url:               The URL of the endpoint returning the addresses, either
+                       as a JSON array of strings or one address per line.
+    tls_skip_verify:   Don't verify the certificate of the endpoint when set to
+                       "true". Default "false".
+    timeout:           The request timeout. Default "10s" (ten seconds).
+`
+}
+
+func (p *httpProvider) Addrs(args map[string]string, l *log.Logger) ([]string, error) {
+	url := args["url"]
+	if url == "" {
+		return nil, fmt.Errorf("discover-http: url not provided")
+	}
+
+	timeout := 10 * time.Second
+	if v := args["timeout"]; v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("discover-http: invalid timeout %q: %w", v, err)
+		}
+		timeout = d
+	}
+
+	transport := cleanhttp.DefaultTransport()
+	if args["tls_skip_verify"] == "true" {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	client := &http.Client{Transport: transport, Timeout: timeout}
+
+	if l != nil {
+		l.Printf("[DEBUG] discover-http: Fetching addresses from %s", url)
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("discover-http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discover-http: unexpected response code %d from %s", resp.StatusCode, url)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, httpMaxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("discover-http: failed to read response: %w", err)
+	}
+
+	addrs, err := parseHTTPAddrs(resp.Header.Get("Content-Type"), body)
+	if err != nil {
+		return nil, fmt.Errorf("discover-http: %w", err)
+	}
+	if l != nil {
+		l.Printf("[DEBUG] discover-http: Found addresses %v", addrs)
+	}
+	return addrs, nil
+}
+
+func parseHTTPAddrs(contentType string, body []byte) ([]string, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/json" {
+		var addrs []string
+		if err := json.Unmarshal(body, &addrs); err != nil {
+			return nil, fmt.Errorf("failed to decode addresses: %w", err)
+		}
+		return addrs, nil
+	}
+
+	addrs := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		if addr := strings.TrimSpace(scanner.Text()); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs, scanner.Err()
+}
@@ -0,0 +1,42 @@
+package retryjoin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPProvider_Addrs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		fmt.Fprint(w, `["10.0.0.1", "10.0.0.2:8301"]`)
+	})
+	mux.HandleFunc("/text", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "10.0.0.1\n\n  server.example.com:8301 \n")
+	})
+	mux.HandleFunc("/error", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	p := &httpProvider{}
+
+	addrs, err := p.Addrs(map[string]string{"url": srv.URL + "/json"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1", "10.0.0.2:8301"}, addrs)
+
+	addrs, err = p.Addrs(map[string]string{"url": srv.URL + "/text"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1", "server.example.com:8301"}, addrs)
+
+	_, err = p.Addrs(map[string]string{"url": srv.URL + "/error"}, nil)
+	require.EqualError(t, err, fmt.Sprintf("discover-http: unexpected response code 500 from %s/error", srv.URL))
+
+	_, err = p.Addrs(map[string]string{}, nil)
+	require.EqualError(t, err, "discover-http: url not provided")
+}
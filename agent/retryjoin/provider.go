@@ -0,0 +1,75 @@
+// Package retryjoin holds the cloud auto-join providers used to resolve the
+// "provider=..." addresses of retry_join, retry_join_wan, primary_gateways and
+// auto_config.server_addresses.
+//
+// Besides the providers shipped with go-discover, custom providers can be
+// compiled into the agent by registering them from the init function of a
+// file added to the build:
+//
+//	func init() {
+//		retryjoin.Register("my-cloud", &myCloudProvider{})
+//	}
+package retryjoin
+
+import (
+	"fmt"
+	"sync"
+
+	discover "github.com/hashicorp/go-discover"
+	discoverk8s "github.com/hashicorp/go-discover/provider/k8s"
+
+	"github.com/hashicorp/consul/lib"
+)
+
+// Provider discovers the addresses of the agents or gateways to join. Args are
+// the key=value pairs of the address, including the provider name.
+type Provider = discover.Provider
+
+var (
+	providersLock sync.RWMutex
+	providers     = map[string]Provider{
+		"k8s":  &discoverk8s.Provider{},
+		"dns":  &dnsProvider{},
+		"http": &httpProvider{},
+	}
+)
+
+// Register makes a provider available under name. It panics if name is
+// already used by a go-discover provider or another registered provider, so
+// that a custom provider can't silently replace a built-in one.
+func Register(name string, p Provider) {
+	providersLock.Lock()
+	defer providersLock.Unlock()
+
+	if _, ok := discover.Providers[name]; ok {
+		panic(fmt.Sprintf("retryjoin: provider %q is already registered", name))
+	}
+	if _, ok := providers[name]; ok {
+		panic(fmt.Sprintf("retryjoin: provider %q is already registered", name))
+	}
+	providers[name] = p
+}
+
+// Providers returns all the available providers, keyed by name.
+func Providers() map[string]Provider {
+	providersLock.RLock()
+	defer providersLock.RUnlock()
+
+	out := make(map[string]Provider, len(discover.Providers)+len(providers))
+	for k, v := range discover.Providers {
+		out[k] = v
+	}
+	for k, v := range providers {
+		out[k] = v
+	}
+	return out
+}
+
+// New returns a resolver for the "provider=..." addresses, using all the
+// available providers.
+func New() (*discover.Discover, error) {
+	return discover.New(
+		discover.WithUserAgent(lib.UserAgent()),
+		discover.WithProviders(Providers()),
+	)
+}
@@ -0,0 +1,46 @@
+package retryjoin
+
+import (
+	"io"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type staticProvider struct {
+	addrs []string
+}
+
+func (p *staticProvider) Help() string { return "static" }
+
+func (p *staticProvider) Addrs(_ map[string]string, _ *log.Logger) ([]string, error) {
+	return p.addrs, nil
+}
+
+func TestRegister(t *testing.T) {
+	Register("test-static", &staticProvider{addrs: []string{"10.0.0.1", "10.0.0.2"}})
+	t.Cleanup(func() {
+		providersLock.Lock()
+		delete(providers, "test-static")
+		providersLock.Unlock()
+	})
+
+	require.Contains(t, Providers(), "test-static")
+
+	disco, err := New()
+	require.NoError(t, err)
+	addrs, err := disco.Addrs("provider=test-static", log.New(io.Discard, "", 0))
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, addrs)
+
+	require.Panics(t, func() {
+		Register("test-static", &staticProvider{})
+	})
+	require.Panics(t, func() {
+		Register("aws", &staticProvider{})
+	})
+	require.Panics(t, func() {
+		Register("k8s", &staticProvider{})
+	})
+}
@@ -77,6 +77,12 @@ type Manager struct {
 	// offline is used to indicate that there are no servers, or that all
 	// known servers have failed the ping test.
 	offline int32
+
+	// selection configures how the servers are ordered on each rebalance.
+	selection ServerSelection
+
+	// health tracks the latency and errors of the RPCs to each server.
+	health *serverHealth
 }
 
 // AddServer takes out an internal write lock and adds a new server.  If the
@@ -112,6 +118,7 @@ func (m *Manager) AddServer(s *metadata.Server) {
 		copy(newServers, l.servers)
 		newServers = append(newServers, s)
 		l.servers = newServers
+		m.health.add(s.Name, time.Now())
 	}
 
 	// Assume we are no longer offline since we've just seen a new server.
@@ -248,6 +255,8 @@ func New(logger hclog.Logger, shutdownCh chan struct{}, clusterInfo ManagerSerfC
 	m.shutdownCh = shutdownCh
 	m.rebalancer = rb
 	m.serverName = serverName
+	m.selection = ServerSelection{Policy: ServerSelectionRandom}
+	m.health = newServerHealth()
 	atomic.StoreInt32(&m.offline, 1)
 
 	l := serverList{}
@@ -256,9 +265,26 @@ func New(logger hclog.Logger, shutdownCh chan struct{}, clusterInfo ManagerSerfC
 	return m
 }
 
+// SetServerSelection configures how the servers are ordered on each
+// rebalance. It must be called before Run.
+func (m *Manager) SetServerSelection(selection ServerSelection) {
+	if selection.Policy == "" {
+		selection.Policy = ServerSelectionRandom
+	}
+	m.selection = selection
+}
+
+// NotifyServerRTT records the round trip time of a successful RPC to the
+// passed in server.
+func (m *Manager) NotifyServerRTT(s *metadata.Server, rtt time.Duration) {
+	m.health.observe(s.Name, rtt, false, time.Now())
+}
+
 // NotifyFailedServer marks the passed in server as "failed" by rotating it
 // to the end of the server list.
 func (m *Manager) NotifyFailedServer(s *metadata.Server) {
+	m.health.observe(s.Name, 0, true, time.Now())
+
 	l := m.getServerList()
 
 	// If the server being failed is not the first server on the list,
@@ -302,13 +328,16 @@ func (m *Manager) healthyServer(server *metadata.Server) bool {
 	if m.serverName != "" && server.Name == m.serverName {
 		return true
 	}
+	start := time.Now()
 	if ok, err := m.connPoolPinger.Ping(server.Datacenter, server.ShortName, server.Addr); !ok {
+		m.health.observe(server.Name, 0, true, time.Now())
 		m.logger.Debug("pinging server failed",
 			"server", server.String(),
 			"error", err,
 		)
 		return false
 	}
+	m.health.observe(server.Name, time.Since(start), false, time.Now())
 	return true
 }
 
@@ -328,8 +357,13 @@ func (m *Manager) RebalanceServers() {
 	// Obtain a copy of the current serverList
 	l := m.getServerList()
 
-	// Shuffle servers so we have a chance of picking a new one.
-	l.shuffleServers()
+	// Shuffle servers so we have a chance of picking a new one. With the
+	// health policy the healthiest servers are more likely to be picked.
+	if m.selection.Policy == ServerSelectionHealth {
+		l.weightedShuffleServers(m.health.weights(l.servers, m.selection.SlowStart, time.Now()))
+	} else {
+		l.shuffleServers()
+	}
 
 	// Iterate through the shuffled server list to find an assumed
 	// healthy server.  NOTE: Do not iterate on the list directly because
@@ -452,6 +486,8 @@ func (m *Manager) RemoveServer(s *metadata.Server) {
 	// Remove the server if known
 	for i := range l.servers {
 		if l.servers[i].Name == s.Name {
+			m.health.remove(s.Name)
+
 			newServers := make([]*metadata.Server, 0, len(l.servers)-1)
 			newServers = append(newServers, l.servers[:i]...)
 			newServers = append(newServers, l.servers[i+1:]...)
@@ -46,6 +46,9 @@ type Router struct {
 	// and has callbacks for adding or removing a server.
 	grpcServerTracker ServerTracker
 
+	// serverSelection configures how the managers order their servers.
+	serverSelection ServerSelection
+
 	// isShutdown prevents adding new routes to a router after it is shut
 	// down.
 	isShutdown bool
@@ -115,6 +118,14 @@ func NewRouter(logger hclog.Logger, localDatacenter, serverName string, tracker
 	return router
 }
 
+// SetServerSelection configures how the managers created afterwards order
+// their servers. It should be called before adding any area.
+func (r *Router) SetServerSelection(selection ServerSelection) {
+	r.Lock()
+	defer r.Unlock()
+	r.serverSelection = selection
+}
+
 // Shutdown removes all areas from the router, which stops all their respective
 // managers. No new areas can be added after the router is shut down.
 func (r *Router) Shutdown() {
@@ -262,6 +273,7 @@ func (r *Router) maybeInitializeManager(area *areaInfo, dc string) *Manager {
 	shutdownCh := make(chan struct{})
 	rb := r.grpcServerTracker.NewRebalancer(dc)
 	manager := New(r.logger, shutdownCh, area.cluster, area.pinger, r.serverName, rb)
+	manager.SetServerSelection(r.serverSelection)
 	info = &managerInfo{
 		manager:    manager,
 		shutdownCh: shutdownCh,
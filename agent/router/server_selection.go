@@ -0,0 +1,203 @@
+package router
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/agent/metadata"
+)
+
+const (
+	// ServerSelectionRandom randomly shuffles the servers on each rebalance.
+	ServerSelectionRandom = "random"
+
+	// ServerSelectionHealth favors the servers with the lowest RPC latency
+	// and error rate on each rebalance, and slowly ramps up the share of the
+	// newly seen servers.
+	ServerSelectionHealth = "health"
+)
+
+const (
+	// serverHealthDecay is the weight of a new observation in the moving
+	// averages of the latency and error rate of a server.
+	serverHealthDecay = 0.2
+
+	// serverErrorHalfLife is the time after which half of the recorded
+	// error rate of a server is forgotten, so that a server recovers its
+	// share of the RPCs once it stops failing.
+	serverErrorHalfLife = time.Minute
+
+	// minServerWeight is the smallest weight, relative to the best server,
+	// given to a server, so that all servers keep getting some RPCs.
+	minServerWeight = 0.01
+)
+
+// ServerSelection configures how a Manager picks the server it sends its RPCs
+// to.
+type ServerSelection struct {
+	// Policy is either ServerSelectionRandom or ServerSelectionHealth.
+	// Defaults to ServerSelectionRandom.
+	Policy string
+
+	// SlowStart is the duration over which the share of the RPCs sent to a
+	// newly seen server ramps up, with ServerSelectionHealth.
+	SlowStart time.Duration
+}
+
+// serverStats holds the health of a server as observed by a Manager.
+type serverStats struct {
+	// added is when the server was last added to the server list.
+	added time.Time
+
+	// latency is the moving average of the RPC latency, zero while unknown.
+	latency time.Duration
+
+	// errorRate is the moving average of the share of failed RPCs.
+	errorRate float64
+
+	// updated is when errorRate was last updated.
+	updated time.Time
+}
+
+// serverHealth tracks the health of the servers of a Manager.
+type serverHealth struct {
+	lock  sync.Mutex
+	stats map[string]*serverStats
+}
+
+func newServerHealth() *serverHealth {
+	return &serverHealth{stats: make(map[string]*serverStats)}
+}
+
+// add records that the server was added to the server list, starting its
+// slow start.
+func (h *serverHealth) add(name string, now time.Time) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.stats[name] = &serverStats{added: now, updated: now}
+}
+
+// remove forgets about the server.
+func (h *serverHealth) remove(name string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	delete(h.stats, name)
+}
+
+// observe records the outcome of an RPC to the server. The latency of failed
+// RPCs is ignored.
+func (h *serverHealth) observe(name string, rtt time.Duration, failed bool, now time.Time) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	s, ok := h.stats[name]
+	if !ok {
+		s = &serverStats{added: now, updated: now}
+		h.stats[name] = s
+	}
+
+	outcome := 0.0
+	if failed {
+		outcome = 1
+	}
+	s.errorRate = s.decayedErrorRate(now)*(1-serverHealthDecay) + outcome*serverHealthDecay
+	s.updated = now
+
+	if failed {
+		return
+	}
+	if s.latency == 0 {
+		s.latency = rtt
+	} else {
+		s.latency = time.Duration(float64(s.latency)*(1-serverHealthDecay) + float64(rtt)*serverHealthDecay)
+	}
+}
+
+// decayedErrorRate returns the error rate, decayed since its last update.
+func (s *serverStats) decayedErrorRate(now time.Time) float64 {
+	elapsed := now.Sub(s.updated)
+	if elapsed <= 0 {
+		return s.errorRate
+	}
+	return s.errorRate * math.Pow(0.5, float64(elapsed)/float64(serverErrorHalfLife))
+}
+
+// weights returns the relative weights of the servers, in the same order.
+// The weight of a server is inversely proportional to its latency, and
+// reduced by its error rate and while it is slow starting.
+func (h *serverHealth) weights(servers []*metadata.Server, slowStart time.Duration, now time.Time) []float64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	// Servers without a known latency are assumed to be average.
+	var total time.Duration
+	var known int
+	for _, srv := range servers {
+		if s, ok := h.stats[srv.Name]; ok && s.latency > 0 {
+			total += s.latency
+			known++
+		}
+	}
+	average := time.Millisecond
+	if known > 0 {
+		average = total / time.Duration(known)
+	}
+
+	weights := make([]float64, len(servers))
+	var max float64
+	for i, srv := range servers {
+		latency := average
+		weight := 1.0
+		if s, ok := h.stats[srv.Name]; ok {
+			if s.latency > 0 {
+				latency = s.latency
+			}
+			weight -= s.decayedErrorRate(now)
+			if elapsed := now.Sub(s.added); slowStart > 0 && elapsed < slowStart {
+				weight *= float64(elapsed) / float64(slowStart)
+			}
+		}
+		weights[i] = weight * float64(average) / float64(latency)
+		if weights[i] > max {
+			max = weights[i]
+		}
+	}
+
+	// When all the servers are failing they are used equally.
+	floor := max * minServerWeight
+	if max == 0 {
+		floor = 1
+	}
+	for i := range weights {
+		if weights[i] < floor {
+			weights[i] = floor
+		}
+	}
+	return weights
+}
+
+// weightedShuffleServers orders the server list in place by picking each
+// server at random with a probability proportional to its weight.
+func (l *serverList) weightedShuffleServers(weights []float64) {
+	for i := 0; i < len(l.servers)-1; i++ {
+		var total float64
+		for _, w := range weights[i:] {
+			total += w
+		}
+
+		pick := len(l.servers) - 1
+		r := rand.Float64() * total
+		for j := i; j < len(l.servers); j++ {
+			r -= weights[j]
+			if r < 0 {
+				pick = j
+				break
+			}
+		}
+
+		l.servers[i], l.servers[pick] = l.servers[pick], l.servers[i]
+		weights[i], weights[pick] = weights[pick], weights[i]
+	}
+}
@@ -0,0 +1,110 @@
+package router
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/metadata"
+)
+
+func TestServerHealth_weights(t *testing.T) {
+	servers := []*metadata.Server{{Name: "s1"}, {Name: "s2"}, {Name: "s3"}}
+	start := time.Now()
+
+	t.Run("unknown servers are used equally", func(t *testing.T) {
+		h := newServerHealth()
+		require.Equal(t, []float64{1, 1, 1}, h.weights(servers, 0, start))
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		h := newServerHealth()
+		h.observe("s1", 10*time.Millisecond, false, start)
+		h.observe("s2", 30*time.Millisecond, false, start)
+
+		w := h.weights(servers, 0, start)
+		require.InDelta(t, 3*w[1], w[0], 0.0001)
+		// s3 is assumed to be average
+		require.InDelta(t, 1, w[2], 0.0001)
+	})
+
+	t.Run("errors decay", func(t *testing.T) {
+		h := newServerHealth()
+		for _, srv := range servers {
+			h.observe(srv.Name, time.Millisecond, false, start)
+		}
+		for i := 0; i < 10; i++ {
+			h.observe("s2", 0, true, start)
+		}
+
+		w := h.weights(servers, 0, start)
+		require.Less(t, w[1], 0.2*w[0])
+
+		w = h.weights(servers, 0, start.Add(10*serverErrorHalfLife))
+		require.InDelta(t, w[0], w[1], 0.01)
+	})
+
+	t.Run("slow start", func(t *testing.T) {
+		h := newServerHealth()
+		h.add("s1", start.Add(-time.Hour))
+		h.add("s2", start.Add(-time.Hour))
+		h.add("s3", start)
+
+		w := h.weights(servers, time.Minute, start.Add(15*time.Second))
+		require.InDelta(t, 0.25, w[2], 0.0001)
+
+		w = h.weights(servers, time.Minute, start.Add(time.Minute))
+		require.Equal(t, []float64{1, 1, 1}, w)
+	})
+
+	t.Run("all servers failing", func(t *testing.T) {
+		h := newServerHealth()
+		for _, srv := range servers {
+			h.observe(srv.Name, 0, true, start)
+		}
+		// the error rate can't be worse than 1
+		for i := 0; i < 100; i++ {
+			h.observe("s1", 0, true, start)
+		}
+		w := h.weights(servers, 0, start)
+		require.Equal(t, w[1], w[2])
+		require.Greater(t, w[0], 0.0)
+	})
+}
+
+func TestServerList_weightedShuffleServers(t *testing.T) {
+	first := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		l := serverList{servers: []*metadata.Server{{Name: "s1"}, {Name: "s2"}, {Name: "s3"}}}
+		l.weightedShuffleServers([]float64{1, 98, 1})
+		require.Len(t, l.servers, 3)
+		first[l.servers[0].Name]++
+	}
+	require.Greater(t, first["s2"], 900)
+	require.Greater(t, first["s1"]+first["s3"], 0)
+}
+
+func TestManager_RebalanceServers_Health(t *testing.T) {
+	m := testManagerFailProb(0)
+	m.SetServerSelection(ServerSelection{Policy: ServerSelectionHealth})
+	for _, name := range []string{"s1", "s2", "s3"} {
+		m.AddServer(&metadata.Server{Name: name})
+	}
+
+	// s2 keeps failing
+	for i := 0; i < 20; i++ {
+		m.NotifyServerRTT(&metadata.Server{Name: "s1"}, time.Millisecond)
+		m.NotifyServerRTT(&metadata.Server{Name: "s3"}, time.Millisecond)
+		m.health.observe("s2", 0, true, time.Now())
+	}
+
+	var picked int
+	for i := 0; i < 100; i++ {
+		m.RebalanceServers()
+		if m.FindServer().Name == "s2" {
+			picked++
+		}
+	}
+	require.Less(t, picked, 10)
+}
@@ -110,6 +110,50 @@ func makeCARootsResponse(
 	return resp
 }
 
+// makeIntentionsResponse handles preparing the intentions of an exported
+// service for the peer cluster. Intentions without a service name are sent
+// when the service is no longer exported, and are considered a deletion.
+func makeIntentionsResponse(
+	logger hclog.Logger,
+	update cache.UpdateEvent,
+) *pbpeering.ReplicationMessage {
+	any, pi, err := marshalToProtoAny[*pbpeering.PeeringIntentions](update.Result)
+	if err != nil {
+		// Log the error and skip this response to avoid locking up peering due to a bad update event.
+		logger.Error("failed to marshal", "error", err)
+		return nil
+	}
+
+	serviceName := strings.TrimPrefix(update.CorrelationID, subExportedIntentions)
+
+	if pi.ServiceName == "" {
+		return &pbpeering.ReplicationMessage{
+			Payload: &pbpeering.ReplicationMessage_Response_{
+				Response: &pbpeering.ReplicationMessage_Response{
+					ResourceURL: pbpeering.TypeURLIntentions,
+					// TODO(peering): Nonce management
+					Nonce:      "",
+					ResourceID: serviceName,
+					Operation:  pbpeering.ReplicationMessage_Response_DELETE,
+				},
+			},
+		}
+	}
+
+	return &pbpeering.ReplicationMessage{
+		Payload: &pbpeering.ReplicationMessage_Response_{
+			Response: &pbpeering.ReplicationMessage_Response{
+				ResourceURL: pbpeering.TypeURLIntentions,
+				// TODO(peering): Nonce management
+				Nonce:      "",
+				ResourceID: serviceName,
+				Operation:  pbpeering.ReplicationMessage_Response_UPSERT,
+				Resource:   any,
+			},
+		},
+	}
+}
+
 // marshalToProtoAny takes any input and returns:
 // the protobuf.Any type, the asserted T type, and any errors
 // during marshalling or type assertion.
@@ -219,6 +263,17 @@ func (s *Service) handleUpsert(
 
 		return s.handleUpsertRoots(peerName, partition, roots)
 
+	case pbpeering.TypeURLIntentions:
+		sn := structs.ServiceNameFromString(resourceID)
+		sn.OverridePartition(partition)
+
+		pi := &pbpeering.PeeringIntentions{}
+		if err := ptypes.UnmarshalAny(resource, pi); err != nil {
+			return fmt.Errorf("failed to unmarshal resource: %w", err)
+		}
+
+		return s.handleUpsertIntentions(peerName, sn, pi)
+
 	default:
 		return fmt.Errorf("unexpected resourceURL: %s", resourceURL)
 	}
@@ -297,6 +352,24 @@ func (s *Service) handleUpsertRoots(
 	return s.Backend.Apply().PeeringTrustBundleWrite(req)
 }
 
+func (s *Service) handleUpsertIntentions(
+	peerName string,
+	sn structs.ServiceName,
+	intentions *pbpeering.PeeringIntentions,
+) error {
+	// We override the names so that the intentions get stored in the
+	// importing partition with a reference to the peer they were imported
+	// from, for the service they were sent for.
+	intentions.PeerName = peerName
+	intentions.Partition = sn.PartitionOrEmpty()
+	intentions.Namespace = sn.NamespaceOrEmpty()
+	intentions.ServiceName = sn.Name
+	req := &pbpeering.PeeringIntentionsWriteRequest{
+		PeeringIntentions: intentions,
+	}
+	return s.Backend.Apply().PeeringIntentionsWrite(req)
+}
+
 func (s *Service) handleDelete(
 	peerName string,
 	partition string,
@@ -309,6 +382,16 @@ func (s *Service) handleDelete(
 		sn.OverridePartition(partition)
 		return s.handleDeleteService(peerName, partition, sn)
 
+	case pbpeering.TypeURLIntentions:
+		sn := structs.ServiceNameFromString(resourceID)
+		sn.OverridePartition(partition)
+		return s.Backend.Apply().PeeringIntentionsDelete(&pbpeering.PeeringIntentionsDeleteRequest{
+			PeerName:    peerName,
+			Partition:   sn.PartitionOrEmpty(),
+			Namespace:   sn.NamespaceOrEmpty(),
+			ServiceName: sn.Name,
+		})
+
 	default:
 		return fmt.Errorf("unexpected resourceURL: %s", resourceURL)
 	}
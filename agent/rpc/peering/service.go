@@ -48,6 +48,10 @@ func (e *errPeeringInvalidServerAddress) Error() string {
 type Config struct {
 	Datacenter     string
 	ConnectEnabled bool
+
+	// IntentionDefaultAllow is the decision of the intentions for the calls
+	// which don't match any intention, as replicated to the peers.
+	IntentionDefaultAllow bool
 }
 
 // Service implements pbpeering.PeeringService to provide RPC operations for
@@ -132,6 +136,7 @@ type Store interface {
 	ServiceDump(ws memdb.WatchSet, kind structs.ServiceKind, useKind bool, entMeta *acl.EnterpriseMeta, peerName string) (uint64, structs.CheckServiceNodes, error)
 	CAConfig(ws memdb.WatchSet) (uint64, *structs.CAConfiguration, error)
 	TrustBundleListByService(ws memdb.WatchSet, service string, entMeta acl.EnterpriseMeta) (uint64, []*pbpeering.PeeringTrustBundle, error)
+	IntentionMatchOne(ws memdb.WatchSet, entry structs.IntentionMatchEntry, matchType structs.IntentionMatchType, destinationType structs.IntentionTargetType) (uint64, structs.Intentions, error)
 	AbandonCh() <-chan struct{}
 }
 
@@ -141,6 +146,8 @@ type Apply interface {
 	PeeringDelete(req *pbpeering.PeeringDeleteRequest) error
 	PeeringTerminateByID(req *pbpeering.PeeringTerminateByIDRequest) error
 	PeeringTrustBundleWrite(req *pbpeering.PeeringTrustBundleWriteRequest) error
+	PeeringIntentionsWrite(req *pbpeering.PeeringIntentionsWriteRequest) error
+	PeeringIntentionsDelete(req *pbpeering.PeeringIntentionsDeleteRequest) error
 	CatalogRegister(req *structs.RegisterRequest) error
 }
 
@@ -316,7 +323,7 @@ func (s *Service) PeeringRead(ctx context.Context, req *pbpeering.PeeringReadReq
 	if err != nil {
 		return nil, err
 	}
-	return &pbpeering.PeeringReadResponse{Peering: peering}, nil
+	return &pbpeering.PeeringReadResponse{Peering: s.reconcilePeering(peering)}, nil
 }
 
 func (s *Service) PeeringList(ctx context.Context, req *pbpeering.PeeringListRequest) (*pbpeering.PeeringListResponse, error) {
@@ -342,7 +349,37 @@ func (s *Service) PeeringList(ctx context.Context, req *pbpeering.PeeringListReq
 	if err != nil {
 		return nil, err
 	}
-	return &pbpeering.PeeringListResponse{Peerings: peerings}, nil
+
+	reconciled := make([]*pbpeering.Peering, 0, len(peerings))
+	for _, p := range peerings {
+		reconciled = append(reconciled, s.reconcilePeering(p))
+	}
+	return &pbpeering.PeeringListResponse{Peerings: reconciled}, nil
+}
+
+// reconcilePeering returns the peering with its state reflecting the health
+// of its replication stream. The stored state only tracks the lifecycle of the
+// peering, so a peering with a connected stream is reported as ACTIVE and one
+// whose stream was interrupted as FAILING.
+func (s *Service) reconcilePeering(peering *pbpeering.Peering) *pbpeering.Peering {
+	// Services which weren't created with NewService don't track streams.
+	if peering == nil || peering.State == pbpeering.PeeringState_TERMINATED || s.streams == nil {
+		return peering
+	}
+
+	status, found := s.streams.streamStatus(peering.ID)
+	if !found {
+		return peering
+	}
+
+	// Copy the peering so that the state store's value isn't modified.
+	cp := proto.Clone(peering).(*pbpeering.Peering)
+	if status.Connected {
+		cp.State = pbpeering.PeeringState_ACTIVE
+	} else {
+		cp.State = pbpeering.PeeringState_FAILING
+	}
+	return cp
 }
 
 // TODO(peering): As of writing, this method is only used in tests to set up Peerings in the state store.
@@ -735,6 +772,9 @@ func (s *Service) HandleStream(req HandleStreamRequest) error {
 			case strings.HasPrefix(update.CorrelationID, subExportedService):
 				resp = makeServiceResponse(logger, update)
 
+			case strings.HasPrefix(update.CorrelationID, subExportedIntentions):
+				resp = makeIntentionsResponse(logger, update)
+
 			case strings.HasPrefix(update.CorrelationID, subMeshGateway):
 				// TODO(Peering): figure out how to sync this separately
 
@@ -393,6 +393,10 @@ func TestStreamResources_Server_StreamTracker(t *testing.T) {
 			require.True(r, ok)
 			require.True(r, status.Connected)
 		})
+
+		// The stored peering is unchanged but reads report it as active.
+		require.Equal(t, pbpeering.PeeringState_ACTIVE, srv.reconcilePeering(p).State)
+		require.Equal(t, pbpeering.PeeringState_INITIAL, p.State)
 	})
 
 	var sequence uint64
@@ -614,9 +618,22 @@ func TestStreamResources_Server_StreamTracker(t *testing.T) {
 			require.True(r, ok)
 			require.Equal(r, expect, status)
 		})
+
+		require.Equal(t, pbpeering.PeeringState_FAILING, srv.reconcilePeering(p).State)
 	})
 }
 
+func TestService_ReconcilePeering_WithoutStreamTracker(t *testing.T) {
+	// A Service which wasn't created with NewService reports the stored state.
+	srv := &Service{}
+	p := &pbpeering.Peering{
+		ID:    "63b60245-c475-426b-b314-4588d210859d",
+		Name:  "my-peer",
+		State: pbpeering.PeeringState_INITIAL,
+	}
+	require.Equal(t, p, srv.reconcilePeering(p))
+}
+
 func TestStreamResources_Server_ServiceUpdates(t *testing.T) {
 	publisher := stream.NewEventPublisher(10 * time.Second)
 	store := newStateStore(t, publisher)
@@ -685,6 +702,22 @@ func TestStreamResources_Server_ServiceUpdates(t *testing.T) {
 				require.Equal(t, pbpeering.TypeURLRoots, msg.GetResponse().ResourceURL)
 				// Roots tested in TestStreamResources_Server_CARootUpdates
 			},
+			func(t *testing.T, msg *pbpeering.ReplicationMessage) {
+				// intentions are replicated even when no instances exist
+				require.Equal(t, pbpeering.TypeURLIntentions, msg.GetResponse().ResourceURL)
+				require.Equal(t, mongoSN, msg.GetResponse().ResourceID)
+				require.Equal(t, pbpeering.ReplicationMessage_Response_UPSERT, msg.GetResponse().Operation)
+
+				var pi pbpeering.PeeringIntentions
+				require.NoError(t, ptypes.UnmarshalAny(msg.GetResponse().Resource, &pi))
+				require.Equal(t, "mongo", pi.ServiceName)
+				require.Empty(t, pi.Sources)
+			},
+			func(t *testing.T, msg *pbpeering.ReplicationMessage) {
+				require.Equal(t, pbpeering.TypeURLIntentions, msg.GetResponse().ResourceURL)
+				require.Equal(t, mysqlSN, msg.GetResponse().ResourceID)
+				require.Equal(t, pbpeering.ReplicationMessage_Response_UPSERT, msg.GetResponse().Operation)
+			},
 			func(t *testing.T, msg *pbpeering.ReplicationMessage) {
 				// no mongo instances exist
 				require.Equal(t, pbpeering.TypeURLService, msg.GetResponse().ResourceURL)
@@ -11,6 +11,7 @@ import (
 	"github.com/hashicorp/consul/agent/cache"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/lib/retry"
+	"github.com/hashicorp/consul/proto/pbpeering"
 	"github.com/hashicorp/consul/proto/pbservice"
 )
 
@@ -32,6 +33,55 @@ func (m *subscriptionManager) notifyExportedServicesForPeerID(ctx context.Contex
 	}, subExportedServiceList, state.updateCh)
 }
 
+// notifyIntentionsForPeerID watches the intentions which decide whether the
+// services of the peer are allowed to call the services exported to it.
+func (m *subscriptionManager) notifyIntentionsForPeerID(ctx context.Context, state *subscriptionState, peerID string) {
+	m.syncViaBlockingQuery(ctx, "exported-intentions", func(ctx context.Context, store Store, ws memdb.WatchSet) (interface{}, error) {
+		_, list, err := store.ExportedServicesForPeer(ws, peerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to watch exported services for peer %q: %w", peerID, err)
+		}
+
+		result := make([]*pbpeering.PeeringIntentions, 0, len(list.Services))
+		for _, svc := range list.Services {
+			entry := structs.IntentionMatchEntry{
+				Partition: svc.PartitionOrDefault(),
+				Namespace: svc.NamespaceOrDefault(),
+				Name:      svc.Name,
+			}
+			_, intentions, err := store.IntentionMatchOne(ws, entry, structs.IntentionMatchDestination, structs.IntentionTargetService)
+			if err != nil {
+				return nil, fmt.Errorf("failed to watch intentions for service %q: %w", svc.String(), err)
+			}
+			result = append(result, makePeeringIntentions(svc, intentions, state.peerName, m.config.IntentionDefaultAllow))
+		}
+		return result, nil
+	}, subExportedIntentionsList, state.updateCh)
+}
+
+// makePeeringIntentions returns the intentions of an exported service whose
+// source is a service of the given peer, in precedence order.
+func makePeeringIntentions(svc structs.ServiceName, intentions structs.Intentions, peerName string, defaultAllow bool) *pbpeering.PeeringIntentions {
+	pi := &pbpeering.PeeringIntentions{
+		Namespace:    svc.NamespaceOrEmpty(),
+		ServiceName:  svc.Name,
+		DefaultAllow: defaultAllow,
+	}
+	for _, ixn := range intentions {
+		if ixn.SourcePeer != peerName {
+			continue
+		}
+		pi.Sources = append(pi.Sources, &pbpeering.PeeringIntentionSource{
+			Name:           ixn.SourceName,
+			Namespace:      ixn.SourceNS,
+			Action:         string(ixn.Action),
+			HasPermissions: len(ixn.Permissions) > 0,
+			Precedence:     int32(ixn.Precedence),
+		})
+	}
+	return pi
+}
+
 // TODO: add a new streaming subscription type to list-by-kind-and-partition since we're getting evictions
 func (m *subscriptionManager) notifyMeshGatewaysForPartition(ctx context.Context, state *subscriptionState, partition string) {
 	m.syncViaBlockingQuery(ctx, "mesh-gateways", func(ctx context.Context, store Store, ws memdb.WatchSet) (interface{}, error) {
@@ -76,6 +76,7 @@ func (m *subscriptionManager) subscribe(ctx context.Context, peerID, peerName, p
 	// Wrap our bare state store queries in goroutines that emit events.
 	go m.notifyExportedServicesForPeerID(ctx, state, peerID)
 	if m.config.ConnectEnabled {
+		go m.notifyIntentionsForPeerID(ctx, state, peerID)
 		go m.notifyMeshGatewaysForPartition(ctx, state, state.partition)
 		// If connect is enabled, watch for updates to CA roots.
 		go m.notifyRootCAUpdatesForPartition(ctx, state.updateCh, state.partition)
@@ -238,14 +239,24 @@ func (m *subscriptionManager) handleEvent(ctx context.Context, state *subscripti
 
 		if state.exportList != nil {
 			// Trigger public events for all synthetic discovery chain replies.
-			for chainName, protocol := range state.connectServices {
-				m.emitEventForDiscoveryChain(ctx, state, pending, chainName, protocol)
+			for chainName, info := range state.connectServices {
+				m.emitEventForDiscoveryChain(ctx, state, pending, chainName, info)
 			}
 		}
 
 		// TODO(peering): should we ship this down verbatim to the consumer?
 		state.sendPendingEvents(ctx, m.logger, pending)
 
+	case u.CorrelationID == subExportedIntentionsList:
+		list, ok := u.Result.([]*pbpeering.PeeringIntentions)
+		if !ok {
+			return fmt.Errorf("invalid type for response: %T", u.Result)
+		}
+
+		pending := &pendingPayload{}
+		m.syncIntentions(state, pending, list)
+		state.sendPendingEvents(ctx, m.logger, pending)
+
 	case u.CorrelationID == subCARoot:
 		roots, ok := u.Result.(*pbpeering.PeeringTrustBundle)
 		if !ok {
@@ -452,21 +463,57 @@ func (m *subscriptionManager) syncNormalServices(
 	}
 }
 
+// syncIntentions queues the intentions of the exported services, and an
+// empty update for the services which are no longer exported to trigger
+// sending a DELETE message.
+func (m *subscriptionManager) syncIntentions(
+	state *subscriptionState,
+	pending *pendingPayload,
+	list []*pbpeering.PeeringIntentions,
+) {
+	seen := make(map[structs.ServiceName]struct{})
+	for _, pi := range list {
+		sn := pi.PeeredServiceName().ServiceName
+		seen[sn] = struct{}{}
+		state.intentionServices[sn] = struct{}{}
+
+		if err := pending.Add(intentionsPayloadIDPrefix+sn.String(), subExportedIntentions+sn.String(), pi); err != nil {
+			m.logger.Error("failed to send event for intentions", "service", sn.String(), "error", err)
+		}
+	}
+
+	for sn := range state.intentionServices {
+		if _, ok := seen[sn]; ok {
+			continue
+		}
+		delete(state.intentionServices, sn)
+
+		err := pending.Add(
+			intentionsPayloadIDPrefix+sn.String(),
+			subExportedIntentions+sn.String(),
+			&pbpeering.PeeringIntentions{},
+		)
+		if err != nil {
+			m.logger.Error("failed to send event for intentions", "service", sn.String(), "error", err)
+		}
+	}
+}
+
 func (m *subscriptionManager) syncDiscoveryChains(
 	ctx context.Context,
 	state *subscriptionState,
 	pending *pendingPayload,
-	chainsByName map[structs.ServiceName]string, // TODO(peering):rename variable
+	chainsByName map[structs.ServiceName]structs.ExportedDiscoveryChainInfo,
 ) {
 	// if it was newly added, then try to emit an UPDATE event
-	for chainName, protocol := range chainsByName {
-		if oldProtocol, ok := state.connectServices[chainName]; ok && protocol == oldProtocol {
+	for chainName, info := range chainsByName {
+		if oldInfo, ok := state.connectServices[chainName]; ok && info == oldInfo {
 			continue
 		}
 
-		state.connectServices[chainName] = protocol
+		state.connectServices[chainName] = info
 
-		m.emitEventForDiscoveryChain(ctx, state, pending, chainName, protocol)
+		m.emitEventForDiscoveryChain(ctx, state, pending, chainName, info)
 	}
 
 	// if it was dropped, try to emit an DELETE event
@@ -498,7 +545,7 @@ func (m *subscriptionManager) emitEventForDiscoveryChain(
 	state *subscriptionState,
 	pending *pendingPayload,
 	chainName structs.ServiceName,
-	protocol string,
+	info structs.ExportedDiscoveryChainInfo,
 ) {
 	if _, ok := state.connectServices[chainName]; !ok {
 		return // not found
@@ -519,7 +566,7 @@ func (m *subscriptionManager) emitEventForDiscoveryChain(
 			m.config.Datacenter,
 			m.trustDomain,
 			chainName,
-			protocol,
+			info,
 			state.meshGateway,
 		),
 	)
@@ -532,7 +579,7 @@ func createDiscoChainHealth(
 	peerName string,
 	datacenter, trustDomain string,
 	sn structs.ServiceName,
-	protocol string,
+	info structs.ExportedDiscoveryChainInfo,
 	pb *pbservice.IndexedCheckServiceNodes,
 ) *pbservice.IndexedCheckServiceNodes {
 	fakeProxyName := sn.Name + syntheticProxyNameSuffix
@@ -559,9 +606,10 @@ func createDiscoChainHealth(
 		//
 		// TODO(peering): should this be replicated by service and not by instance?
 		peerMeta = &pbservice.PeeringServiceMeta{
-			SNI:      []string{sni},
-			SpiffeID: []string{spiffeID.URI().String()},
-			Protocol: protocol,
+			SNI:           []string{sni},
+			SpiffeID:      []string{spiffeID.URI().String()},
+			Protocol:      info.Protocol,
+			IgnoreWeights: info.IgnoreCatalogWeights,
 		}
 	}
 
@@ -652,9 +700,11 @@ func flattenChecks(
 }
 
 const (
-	subExportedServiceList = "exported-service-list"
-	subExportedService     = "exported-service:"
-	subMeshGateway         = "mesh-gateway:"
+	subExportedServiceList    = "exported-service-list"
+	subExportedService        = "exported-service:"
+	subExportedIntentionsList = "exported-intentions-list"
+	subExportedIntentions     = "exported-intentions:"
+	subMeshGateway            = "mesh-gateway:"
 )
 
 // NotifyStandardService will notify the given channel when there are updates
@@ -44,6 +44,8 @@ func TestSubscriptionManager_RegisterDeregister(t *testing.T) {
 		mysqlCorrID = subExportedService + structs.NewServiceName("mysql", nil).String()
 
 		mysqlProxyCorrID = subExportedService + structs.NewServiceName("mysql-sidecar-proxy", nil).String()
+
+		mysqlIntentionsCorrID = subExportedIntentions + structs.NewServiceName("mysql", nil).String()
 	)
 
 	// Expect just the empty mesh gateway event to replicate.
@@ -71,6 +73,9 @@ func TestSubscriptionManager_RegisterDeregister(t *testing.T) {
 		})
 
 		expectEvents(t, subCh,
+			func(t *testing.T, got cache.UpdateEvent) {
+				checkIntentionsEvent(t, got, mysqlIntentionsCorrID, 0)
+			},
 			func(t *testing.T, got cache.UpdateEvent) {
 				checkEvent(t, got, mysqlCorrID, 0)
 			},
@@ -357,6 +362,10 @@ func TestSubscriptionManager_InitialSnapshot(t *testing.T) {
 		mysqlProxyCorrID = subExportedService + structs.NewServiceName("mysql-sidecar-proxy", nil).String()
 		mongoProxyCorrID = subExportedService + structs.NewServiceName("mongo-sidecar-proxy", nil).String()
 		chainProxyCorrID = subExportedService + structs.NewServiceName("chain-sidecar-proxy", nil).String()
+
+		mysqlIntentionsCorrID = subExportedIntentions + structs.NewServiceName("mysql", nil).String()
+		mongoIntentionsCorrID = subExportedIntentions + structs.NewServiceName("mongo", nil).String()
+		chainIntentionsCorrID = subExportedIntentions + structs.NewServiceName("chain", nil).String()
 	)
 
 	// Expect just the empty mesh gateway event to replicate.
@@ -392,6 +401,15 @@ func TestSubscriptionManager_InitialSnapshot(t *testing.T) {
 		})
 
 		expectEvents(t, subCh,
+			func(t *testing.T, got cache.UpdateEvent) {
+				checkIntentionsEvent(t, got, chainIntentionsCorrID, 0)
+			},
+			func(t *testing.T, got cache.UpdateEvent) {
+				checkIntentionsEvent(t, got, mongoIntentionsCorrID, 0)
+			},
+			func(t *testing.T, got cache.UpdateEvent) {
+				checkIntentionsEvent(t, got, mysqlIntentionsCorrID, 0)
+			},
 			func(t *testing.T, got cache.UpdateEvent) {
 				checkEvent(t, got, chainCorrID, 0)
 			},
@@ -498,6 +516,128 @@ func TestSubscriptionManager_CARoots(t *testing.T) {
 	})
 }
 
+func TestSubscriptionManager_Intentions(t *testing.T) {
+	backend := newTestSubscriptionBackend(t)
+	backend.lastIdx++
+	require.NoError(t, backend.store.SystemMetadataSet(backend.lastIdx, &structs.SystemMetadataEntry{
+		Key:   structs.SystemMetadataIntentionFormatKey,
+		Value: structs.SystemMetadataIntentionFormatConfigValue,
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Create a peering
+	_, id := backend.ensurePeering(t, "my-peering")
+	partition := acl.DefaultEnterpriseMeta().PartitionOrEmpty()
+
+	mgr := newSubscriptionManager(ctx, testutil.Logger(t), Config{
+		Datacenter:            "dc1",
+		ConnectEnabled:        true,
+		IntentionDefaultAllow: true,
+	}, connect.TestTrustDomain, backend)
+	subCh := mgr.subscribe(ctx, id, "my-peering", partition)
+
+	var (
+		gatewayCorrID = subMeshGateway + partition
+
+		mysqlCorrID           = subExportedService + structs.NewServiceName("mysql", nil).String()
+		mysqlProxyCorrID      = subExportedService + structs.NewServiceName("mysql-sidecar-proxy", nil).String()
+		mysqlIntentionsCorrID = subExportedIntentions + structs.NewServiceName("mysql", nil).String()
+	)
+
+	// Expect just the empty mesh gateway event to replicate.
+	expectEvents(t, subCh, func(t *testing.T, got cache.UpdateEvent) {
+		checkEvent(t, got, gatewayCorrID, 0)
+	})
+
+	testutil.RunStep(t, "exporting a service replicates its default decision", func(t *testing.T) {
+		backend.ensureConfigEntry(t, &structs.ExportedServicesConfigEntry{
+			Name: "default",
+			Services: []structs.ExportedService{
+				{
+					Name: "mysql",
+					Consumers: []structs.ServiceConsumer{
+						{PeerName: "my-peering"},
+					},
+				},
+			},
+		})
+
+		expectEvents(t, subCh,
+			func(t *testing.T, got cache.UpdateEvent) {
+				pi := checkIntentionsEvent(t, got, mysqlIntentionsCorrID, 0)
+				require.Equal(t, "mysql", pi.ServiceName)
+				require.True(t, pi.DefaultAllow)
+			},
+			func(t *testing.T, got cache.UpdateEvent) {
+				checkEvent(t, got, mysqlCorrID, 0)
+			},
+			func(t *testing.T, got cache.UpdateEvent) {
+				checkEvent(t, got, mysqlProxyCorrID, 0)
+			},
+		)
+	})
+
+	testutil.RunStep(t, "intentions from the peer are replicated", func(t *testing.T) {
+		backend.ensureConfigEntry(t, &structs.ServiceIntentionsConfigEntry{
+			Kind: structs.ServiceIntentions,
+			Name: "mysql",
+			Sources: []*structs.SourceIntention{
+				{Name: "*", Peer: "my-peering", Action: structs.IntentionActionDeny},
+				{Name: "web", Peer: "my-peering", Action: structs.IntentionActionAllow},
+				{Name: "db", Peer: "other-peering", Action: structs.IntentionActionAllow},
+				{Name: "api", Action: structs.IntentionActionAllow},
+			},
+		})
+
+		expectEvents(t, subCh, func(t *testing.T, got cache.UpdateEvent) {
+			pi := checkIntentionsEvent(t, got, mysqlIntentionsCorrID, 2)
+
+			// Sources are sent in precedence order.
+			require.Equal(t, "web", pi.Sources[0].Name)
+			require.Equal(t, "allow", pi.Sources[0].Action)
+			require.Equal(t, "*", pi.Sources[1].Name)
+			require.Equal(t, "deny", pi.Sources[1].Action)
+		})
+	})
+
+	testutil.RunStep(t, "intentions not referencing the peer don't trigger updates", func(t *testing.T) {
+		backend.ensureConfigEntry(t, &structs.ServiceIntentionsConfigEntry{
+			Kind: structs.ServiceIntentions,
+			Name: "mysql",
+			Sources: []*structs.SourceIntention{
+				{Name: "*", Peer: "my-peering", Action: structs.IntentionActionDeny},
+				{Name: "web", Peer: "my-peering", Action: structs.IntentionActionAllow},
+				{Name: "api", Action: structs.IntentionActionDeny},
+			},
+		})
+
+		expectEvents(t, subCh)
+	})
+
+	testutil.RunStep(t, "un-exporting the service deletes its intentions", func(t *testing.T) {
+		backend.ensureConfigEntry(t, &structs.ExportedServicesConfigEntry{
+			Name: "default",
+			Services: []structs.ExportedService{
+				{
+					Name: "mysql",
+					Consumers: []structs.ServiceConsumer{
+						{PeerName: "my-other-peering"},
+					},
+				},
+			},
+		})
+
+		expectEvents(t, subCh,
+			func(t *testing.T, got cache.UpdateEvent) {
+				pi := checkIntentionsEvent(t, got, mysqlIntentionsCorrID, 0)
+				require.Empty(t, pi.ServiceName)
+			},
+		)
+	})
+}
+
 type testSubscriptionBackend struct {
 	state.EventPublisher
 	store *state.Store
@@ -696,6 +836,21 @@ func checkEvent(
 	}
 }
 
+func checkIntentionsEvent(
+	t *testing.T,
+	got cache.UpdateEvent,
+	correlationID string,
+	expectSources int,
+) *pbpeering.PeeringIntentions {
+	t.Helper()
+
+	require.Equal(t, correlationID, got.CorrelationID)
+
+	evt := got.Result.(*pbpeering.PeeringIntentions)
+	require.Len(t, evt.Sources, expectSources)
+	return evt
+}
+
 func pbNode(node, addr, partition string) *pbservice.Node {
 	return &pbservice.Node{Node: node, Partition: partition, Address: addr}
 }
@@ -26,7 +26,10 @@ type subscriptionState struct {
 	exportList *structs.ExportedServiceList
 
 	watchedServices map[structs.ServiceName]context.CancelFunc
-	connectServices map[structs.ServiceName]string // value:protocol
+	connectServices map[structs.ServiceName]structs.ExportedDiscoveryChainInfo
+
+	// intentionServices are the services whose intentions were sent.
+	intentionServices map[structs.ServiceName]struct{}
 
 	// eventVersions is a duplicate event suppression system keyed by the "id"
 	// not the "correlationID"
@@ -45,11 +48,12 @@ type subscriptionState struct {
 
 func newSubscriptionState(peerName, partition string) *subscriptionState {
 	return &subscriptionState{
-		peerName:        peerName,
-		partition:       partition,
-		watchedServices: make(map[structs.ServiceName]context.CancelFunc),
-		connectServices: make(map[structs.ServiceName]string),
-		eventVersions:   make(map[string]string),
+		peerName:          peerName,
+		partition:         partition,
+		watchedServices:   make(map[structs.ServiceName]context.CancelFunc),
+		connectServices:   make(map[structs.ServiceName]structs.ExportedDiscoveryChainInfo),
+		intentionServices: make(map[structs.ServiceName]struct{}),
+		eventVersions:     make(map[string]string),
 	}
 }
 
@@ -101,6 +105,14 @@ func (s *subscriptionState) cleanupEventVersions(logger hclog.Logger) {
 				keep = true
 			}
 
+		case strings.HasPrefix(id, intentionsPayloadIDPrefix):
+			name := strings.TrimPrefix(id, intentionsPayloadIDPrefix)
+			sn := structs.ServiceNameFromString(name)
+
+			if _, ok := s.intentionServices[sn]; ok {
+				keep = true
+			}
+
 		case strings.HasPrefix(id, discoveryChainPayloadIDPrefix):
 			name := strings.TrimPrefix(id, discoveryChainPayloadIDPrefix)
 			sn := structs.ServiceNameFromString(name)
@@ -133,6 +145,7 @@ const (
 	meshGatewayPayloadID          = "mesh-gateway"
 	servicePayloadIDPrefix        = "service:"
 	discoveryChainPayloadIDPrefix = "chain:"
+	intentionsPayloadIDPrefix     = "intentions:"
 )
 
 func (p *pendingPayload) Add(id string, correlationID string, raw interface{}) error {
@@ -18,6 +18,9 @@ type Client struct {
 	CacheName           string
 	UseStreamingBackend bool
 	QueryOptionDefaults func(options *structs.QueryOptions)
+
+	// AnonymousTokenScoped returns true if the anonymous token has scopes.
+	AnonymousTokenScoped func() bool
 }
 
 type NetRPC interface {
@@ -40,7 +43,10 @@ func (c *Client) ServiceNodes(
 ) (structs.IndexedCheckServiceNodes, cache.ResultMeta, error) {
 	// Note: if MergeCentralConfig is requested, default to using the RPC backend for now
 	// as the streaming backend and materializer does not have support for merging yet.
-	if c.useStreaming(req) && (req.QueryOptions.UseCache || req.QueryOptions.MinQueryIndex > 0) && !req.MergeCentralConfig {
+	// The same goes for bounded_stale reads, which the servers must check, and
+	// for the status of the proxies, which isn't part of the streamed events.
+	if c.useStreaming(req) && (req.QueryOptions.UseCache || req.QueryOptions.MinQueryIndex > 0) &&
+		!req.MergeCentralConfig && !req.ProxyStatus && req.QueryOptions.MaxIndexLag == 0 {
 		c.QueryOptionDefaults(&req.QueryOptions)
 
 		result, err := c.ViewStore.Get(ctx, c.newServiceRequest(req))
@@ -103,8 +109,14 @@ func (c *Client) Notify(
 	return c.Cache.NotifyCallback(ctx, c.CacheName, &req, correlationID, cb)
 }
 
+// useStreaming returns true if the request can use the streaming backend.
+// Queries without a token can't when the anonymous token has scopes, the
+// servers only apply the scopes to RPCs.
 func (c *Client) useStreaming(req structs.ServiceSpecificRequest) bool {
-	return c.UseStreamingBackend && !req.Ingress && req.Source.Node == ""
+	if !c.UseStreamingBackend || req.Ingress || req.Source.Node != "" {
+		return false
+	}
+	return req.Token != "" || c.AnonymousTokenScoped == nil || !c.AnonymousTokenScoped()
 }
 
 func (c *Client) newServiceRequest(req structs.ServiceSpecificRequest) serviceRequest {
@@ -313,7 +313,10 @@ func makeConfigRequest(bd BaseDeps, addReq AddServiceRequest) *structs.ServiceCo
 		name = ns.Service
 	)
 
-	var upstreams []structs.ServiceID
+	var (
+		upstreams       []structs.ServiceID
+		peeredUpstreams []structs.PeeredServiceName
+	)
 
 	// Note that only sidecar proxies should even make it here for now although
 	// later that will change to add the condition.
@@ -328,19 +331,27 @@ func makeConfigRequest(bd BaseDeps, addReq AddServiceRequest) *structs.ServiceCo
 			if us.DestinationType == "" || us.DestinationType == structs.UpstreamDestTypeService {
 				sid := us.DestinationID()
 				sid.EnterpriseMeta.Merge(&ns.EnterpriseMeta)
+				if us.DestinationPeer != "" {
+					peeredUpstreams = append(peeredUpstreams, structs.PeeredServiceName{
+						Peer:        us.DestinationPeer,
+						ServiceName: structs.NewServiceName(sid.ID, &sid.EnterpriseMeta),
+					})
+					continue
+				}
 				upstreams = append(upstreams, sid)
 			}
 		}
 	}
 
 	req := &structs.ServiceConfigRequest{
-		Name:           name,
-		Datacenter:     bd.RuntimeConfig.Datacenter,
-		QueryOptions:   structs.QueryOptions{Token: addReq.token},
-		MeshGateway:    ns.Proxy.MeshGateway,
-		Mode:           ns.Proxy.Mode,
-		UpstreamIDs:    upstreams,
-		EnterpriseMeta: ns.EnterpriseMeta,
+		Name:            name,
+		Datacenter:      bd.RuntimeConfig.Datacenter,
+		QueryOptions:    structs.QueryOptions{Token: addReq.token},
+		MeshGateway:     ns.Proxy.MeshGateway,
+		Mode:            ns.Proxy.Mode,
+		UpstreamIDs:     upstreams,
+		PeeredUpstreams: peeredUpstreams,
+		EnterpriseMeta:  ns.EnterpriseMeta,
 	}
 	if req.QueryOptions.Token == "" {
 		req.QueryOptions.Token = bd.Tokens.AgentToken()
@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/lib"
+)
+
+// serviceMetricsReportInterval is how often the agent sends the requests
+// reported by its connect proxies to the servers.
+const serviceMetricsReportInterval = 15 * time.Second
+
+// serviceMetricsPair identifies the requests from a service to an upstream.
+type serviceMetricsPair struct {
+	source      structs.ServiceName
+	destination structs.ServiceName
+}
+
+// serviceMetricsCollector accumulates the requests reported by the local
+// connect proxies with builtin metrics enabled until they are sent to the
+// servers.
+type serviceMetricsCollector struct {
+	lock     sync.Mutex
+	counters map[serviceMetricsPair]structs.ServiceMetricsCounters
+	// tokens are the tokens of the proxies of each source service, which the
+	// samples are sent with as reporting them requires service:write.
+	tokens map[structs.ServiceName]string
+	since  time.Time
+}
+
+func newServiceMetricsCollector() *serviceMetricsCollector {
+	return &serviceMetricsCollector{
+		counters: make(map[serviceMetricsPair]structs.ServiceMetricsCounters),
+		tokens:   make(map[structs.ServiceName]string),
+		since:    time.Now(),
+	}
+}
+
+// RecordServiceMetrics implements xds.ServiceMetricsRecorder.
+func (c *serviceMetricsCollector) RecordServiceMetrics(source structs.ServiceName, token string, upstreams map[structs.ServiceName]structs.ServiceMetricsCounters) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.tokens[source] = token
+	for destination, counters := range upstreams {
+		pair := serviceMetricsPair{source: source, destination: destination}
+		total := c.counters[pair]
+		total.Add(counters)
+		c.counters[pair] = total
+	}
+}
+
+// flush returns the samples accumulated since the previous flush, grouped by
+// the token of the proxies which reported them, and the time they cover. It
+// resets the counters.
+func (c *serviceMetricsCollector) flush(now time.Time) (map[string][]structs.ServiceMetricsSample, time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	interval := now.Sub(c.since)
+	c.since = now
+	if len(c.counters) == 0 {
+		return nil, interval
+	}
+
+	samples := make(map[string][]structs.ServiceMetricsSample)
+	for pair, counters := range c.counters {
+		token := c.tokens[pair.source]
+		samples[token] = append(samples[token], structs.ServiceMetricsSample{
+			Source:                 pair.source,
+			Destination:            pair.destination,
+			ServiceMetricsCounters: counters,
+		})
+	}
+	c.counters = make(map[serviceMetricsPair]structs.ServiceMetricsCounters)
+	c.tokens = make(map[structs.ServiceName]string)
+	return samples, interval
+}
+
+// sendServiceMetrics is a long running loop sending the requests reported by
+// the local connect proxies to the servers, with the tokens of the proxies.
+// Samples which fail to be sent are dropped rather than retried as they would
+// skew the summaries.
+func (a *Agent) sendServiceMetrics() {
+	for {
+		select {
+		case <-time.After(serviceMetricsReportInterval + lib.RandomStagger(serviceMetricsReportInterval/4)):
+			byToken, interval := a.serviceMetrics.flush(time.Now())
+			for token, samples := range byToken {
+				if token == "" {
+					token = a.tokens.UserToken()
+				}
+				req := structs.ServiceMetricsReportRequest{
+					Datacenter:     a.config.Datacenter,
+					Node:           a.config.NodeName,
+					Interval:       interval,
+					Samples:        samples,
+					EnterpriseMeta: *a.AgentEnterpriseMeta(),
+					WriteRequest:   structs.WriteRequest{Token: token},
+				}
+				var reply struct{}
+				if err := a.RPC("Internal.ServiceMetricsReport", &req, &reply); err != nil {
+					if acl.IsErrPermissionDenied(err) {
+						accessorID := a.aclAccessorID(token)
+						a.logger.Warn("Service metrics report blocked by ACLs", "accessorID", accessorID)
+					} else {
+						a.logger.Error("Service metrics report error", "error", err)
+					}
+				}
+			}
+		case <-a.shutdownCh:
+			return
+		}
+	}
+}
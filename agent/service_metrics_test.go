@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestServiceMetricsCollector(t *testing.T) {
+	web := structs.NewServiceName("web", nil)
+	api := structs.NewServiceName("api", nil)
+
+	c := newServiceMetricsCollector()
+	start := c.since
+
+	samples, _ := c.flush(start.Add(time.Second))
+	require.Empty(t, samples)
+
+	c.RecordServiceMetrics(web, "web-token", map[structs.ServiceName]structs.ServiceMetricsCounters{
+		api: {Requests: 10, Errors: 1},
+	})
+	c.RecordServiceMetrics(web, "web-token", map[structs.ServiceName]structs.ServiceMetricsCounters{
+		api: {Requests: 5, Duration: time.Second, DurationSamples: 5},
+	})
+	c.RecordServiceMetrics(api, "api-token", map[structs.ServiceName]structs.ServiceMetricsCounters{
+		web: {Requests: 1},
+	})
+
+	samples, interval := c.flush(start.Add(16 * time.Second))
+	require.Equal(t, 15*time.Second, interval)
+	require.Equal(t, map[string][]structs.ServiceMetricsSample{
+		"web-token": {{
+			Source:      web,
+			Destination: api,
+			ServiceMetricsCounters: structs.ServiceMetricsCounters{
+				Requests:        15,
+				Errors:          1,
+				Duration:        time.Second,
+				DurationSamples: 5,
+			},
+		}},
+		"api-token": {{
+			Source:                 api,
+			Destination:            web,
+			ServiceMetricsCounters: structs.ServiceMetricsCounters{Requests: 1},
+		}},
+	}, samples)
+
+	// The counters are reset by the flush.
+	samples, _ = c.flush(start.Add(31 * time.Second))
+	require.Empty(t, samples)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/hashicorp/go-hclog"
 	"google.golang.org/grpc/grpclog"
 
+	"github.com/hashicorp/consul/agent/ae"
 	autoconf "github.com/hashicorp/consul/agent/auto-config"
 	"github.com/hashicorp/consul/agent/cache"
 	"github.com/hashicorp/consul/agent/config"
@@ -44,6 +45,14 @@ type BaseDeps struct {
 	Cache         *cache.Cache
 	ViewStore     *submatview.Store
 	WatchedFiles  []string
+
+	// Redactor scrubs secrets from the HTTP API error messages and the logs
+	// streamed by the monitor endpoint. It is nil when redact_secrets is off.
+	Redactor *logging.Redactor
+
+	// LogLevels are the log levels of the agent and its subsystems, which can
+	// be changed at runtime.
+	LogLevels *logging.Levels
 }
 
 type ConfigLoader func(source config.Source) (config.LoadResult, error)
@@ -58,7 +67,11 @@ func NewBaseDeps(configLoader ConfigLoader, logOut io.Writer) (BaseDeps, error)
 	cfg := result.RuntimeConfig
 	logConf := cfg.Logging
 	logConf.Name = logging.Agent
-	d.Logger, err = logging.Setup(logConf, logOut)
+	d.Logger, d.LogLevels, err = logging.SetupWithLevels(logConf, logOut)
+	if err != nil {
+		return d, err
+	}
+	d.Redactor, err = logging.NewRedactor(logConf)
 	if err != nil {
 		return d, err
 	}
@@ -120,6 +133,10 @@ func NewBaseDeps(configLoader ConfigLoader, logOut io.Writer) (BaseDeps, error)
 	d.LeaderForwarder = builder
 
 	d.Router = router.NewRouter(d.Logger, cfg.Datacenter, fmt.Sprintf("%s.%s", cfg.NodeName, cfg.Datacenter), builder)
+	d.Router.SetServerSelection(router.ServerSelection{
+		Policy:    cfg.RPCServerSelection,
+		SlowStart: cfg.RPCServerSlowStart,
+	})
 
 	// this needs to happen prior to creating auto-config as some of the dependencies
 	// must also be passed to auto-config
@@ -218,6 +235,8 @@ func getPrometheusDefs(cfg lib.TelemetryConfig, isServer bool) ([]prometheus.Gau
 		xds.StatsGauges,
 		usagemetrics.Gauges,
 		consul.ReplicationGauges,
+		consul.SnapshotTunerGauges,
+		consul.TableUsageGauges,
 		CertExpirationGauges,
 		Gauges,
 		raftGauges,
@@ -264,6 +283,7 @@ func getPrometheusDefs(cfg lib.TelemetryConfig, isServer bool) ([]prometheus.Gau
 
 	var counters = [][]prometheus.CounterDefinition{
 		CatalogCounters,
+		MeshProbeCounters,
 		cache.Counters,
 		consul.ACLCounters,
 		consul.CatalogCounters,
@@ -309,6 +329,8 @@ func getPrometheusDefs(cfg lib.TelemetryConfig, isServer bool) ([]prometheus.Gau
 
 	var summaries = [][]prometheus.SummaryDefinition{
 		HTTPSummaries,
+		MeshProbeSummaries,
+		ae.Summaries,
 		consul.ACLSummaries,
 		consul.ACLEndpointSummaries,
 		consul.CatalogSummaries,
@@ -322,6 +344,7 @@ func getPrometheusDefs(cfg lib.TelemetryConfig, isServer bool) ([]prometheus.Gau
 		consul.SessionSummaries,
 		consul.SessionEndpointSummaries,
 		consul.TxnSummaries,
+		fsm.ApplySummaries,
 		fsm.CommandsSummaries,
 		fsm.SnapshotSummaries,
 		raftSummaries,
@@ -181,12 +181,18 @@ func (a *Agent) sidecarServiceFromNodeService(ns *structs.NodeService, token str
 
 	// Setup default check if none given
 	if len(checks) < 1 {
+		// The local service address is unset when the service listens on a
+		// unix socket, but the sidecar itself still listens on a TCP port.
+		checkAddr := sidecar.Proxy.LocalServiceAddress
+		if checkAddr == "" {
+			checkAddr = "127.0.0.1"
+		}
 		checks = []*structs.CheckType{
 			{
 				Name: "Connect Sidecar Listening",
 				// Default to localhost rather than agent/service public IP. The checks
 				// can always be overridden if a non-loopback IP is needed.
-				TCP:      ipaddr.FormatAddressPort(sidecar.Proxy.LocalServiceAddress, sidecar.Port),
+				TCP:      ipaddr.FormatAddressPort(checkAddr, sidecar.Port),
 				Interval: 10 * time.Second,
 			},
 			{
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"hash"
 	"hash/fnv"
+	"net"
 	"sort"
 	"strings"
 	"time"
@@ -262,6 +263,91 @@ func (ids ACLNodeIdentities) Deduplicate() ACLNodeIdentities {
 	return results
 }
 
+// ACLAnonymousScope grants the anonymous token different policies and roles
+// for requests without a token that come from some networks, or that are
+// received by agents in some network segments.
+type ACLAnonymousScope struct {
+	// Name identifies the scope.
+	Name string
+
+	// SourceCIDRs are the networks of the clients the scope applies to.
+	SourceCIDRs []string `json:",omitempty"`
+
+	// Segments are the network segments of the agents the scope applies to.
+	Segments []string `json:",omitempty"`
+
+	// Policies and Roles are used instead of the ones of the anonymous token
+	// for requests in this scope.
+	Policies []ACLTokenPolicyLink `json:",omitempty"`
+	Roles    []ACLTokenRoleLink   `json:",omitempty"`
+}
+
+func (s *ACLAnonymousScope) Clone() *ACLAnonymousScope {
+	s2 := *s
+	s2.SourceCIDRs = stringslice.CloneStringSlice(s.SourceCIDRs)
+	s2.Segments = stringslice.CloneStringSlice(s.Segments)
+	if len(s.Policies) > 0 {
+		s2.Policies = make([]ACLTokenPolicyLink, len(s.Policies))
+		copy(s2.Policies, s.Policies)
+	}
+	if len(s.Roles) > 0 {
+		s2.Roles = make([]ACLTokenRoleLink, len(s.Roles))
+		copy(s2.Roles, s.Roles)
+	}
+	return &s2
+}
+
+func (s *ACLAnonymousScope) AddToHash(h hash.Hash) {
+	h.Write([]byte(s.Name))
+	for _, cidr := range s.SourceCIDRs {
+		h.Write([]byte(cidr))
+	}
+	for _, segment := range s.Segments {
+		h.Write([]byte(segment))
+	}
+	for _, link := range s.Policies {
+		h.Write([]byte(link.ID))
+	}
+	for _, link := range s.Roles {
+		h.Write([]byte(link.ID))
+	}
+}
+
+func (s *ACLAnonymousScope) EstimateSize() int {
+	size := len(s.Name)
+	for _, cidr := range s.SourceCIDRs {
+		size += len(cidr)
+	}
+	for _, segment := range s.Segments {
+		size += len(segment)
+	}
+	for _, link := range s.Policies {
+		size += len(link.ID) + len(link.Name)
+	}
+	for _, link := range s.Roles {
+		size += len(link.ID) + len(link.Name)
+	}
+	return size
+}
+
+// Matches returns true if the scope applies to a request from the source
+// address received by an agent in the segment. The source may be nil when it
+// is unknown.
+func (s *ACLAnonymousScope) Matches(source net.IP, segment string) bool {
+	if stringslice.Contains(s.Segments, segment) {
+		return true
+	}
+	if source == nil {
+		return false
+	}
+	for _, cidr := range s.SourceCIDRs {
+		if _, network, err := net.ParseCIDR(cidr); err == nil && network.Contains(source) {
+			return true
+		}
+	}
+	return false
+}
+
 type ACLToken struct {
 	// This is the UUID used for tracking and management purposes
 	AccessorID string
@@ -288,6 +374,11 @@ type ACLToken struct {
 	// The node identities that this token should be allowed to manage.
 	NodeIdentities ACLNodeIdentities `json:",omitempty"`
 
+	// AnonymousScopes grant the anonymous token different policies and roles
+	// depending on where a request without a token came from. They may only
+	// be set on the anonymous token.
+	AnonymousScopes []*ACLAnonymousScope `json:",omitempty"`
+
 	// Type is the V1 Token Type
 	// DEPRECATED (ACL-Legacy-Compat) - remove once we no longer support v1 ACL compat
 	// Even though we are going to auto upgrade management tokens we still
@@ -336,6 +427,10 @@ type ACLToken struct {
 	// unnecessary calls to the authoritative DC
 	Hash []byte
 
+	// Usage is the usage of the token as seen by the server answering the
+	// request. It is populated on reads and never persisted.
+	Usage *ACLTokenUsage `json:",omitempty"`
+
 	// Embedded Enterprise Metadata
 	acl.EnterpriseMeta `mapstructure:",squash"`
 
@@ -373,12 +468,33 @@ func (t *ACLToken) UnmarshalJSON(data []byte) (err error) {
 	return nil
 }
 
+// AnonymousScopeToken returns the identity used for requests in the named
+// scope of the anonymous token: a copy of the token with the policies and
+// roles of the scope. The anonymous token itself is returned if it has no
+// such scope.
+func (t *ACLToken) AnonymousScopeToken(scope string) *ACLToken {
+	for _, s := range t.AnonymousScopes {
+		if s.Name != scope {
+			continue
+		}
+		t2 := t.Clone()
+		t2.Policies = s.Clone().Policies
+		t2.Roles = s.Clone().Roles
+		t2.ServiceIdentities = nil
+		t2.NodeIdentities = nil
+		t2.AnonymousScopes = nil
+		return t2
+	}
+	return t
+}
+
 func (t *ACLToken) Clone() *ACLToken {
 	t2 := *t
 	t2.Policies = nil
 	t2.Roles = nil
 	t2.ServiceIdentities = nil
 	t2.NodeIdentities = nil
+	t2.AnonymousScopes = nil
 
 	if len(t.Policies) > 0 {
 		t2.Policies = make([]ACLTokenPolicyLink, len(t.Policies))
@@ -400,6 +516,12 @@ func (t *ACLToken) Clone() *ACLToken {
 			t2.NodeIdentities[i] = n.Clone()
 		}
 	}
+	if len(t.AnonymousScopes) > 0 {
+		t2.AnonymousScopes = make([]*ACLAnonymousScope, len(t.AnonymousScopes))
+		for i, scope := range t.AnonymousScopes {
+			t2.AnonymousScopes[i] = scope.Clone()
+		}
+	}
 
 	return &t2
 }
@@ -510,6 +632,10 @@ func (t *ACLToken) SetHash(force bool) []byte {
 			nodeID.AddToHash(hash)
 		}
 
+		for _, scope := range t.AnonymousScopes {
+			scope.AddToHash(hash)
+		}
+
 		t.EnterpriseMeta.AddToHash(hash, false)
 
 		// Finalize the hash
@@ -536,6 +662,9 @@ func (t *ACLToken) EstimateSize() int {
 	for _, nodeID := range t.NodeIdentities {
 		size += nodeID.EstimateSize()
 	}
+	for _, scope := range t.AnonymousScopes {
+		size += scope.EstimateSize()
+	}
 	return size + t.EnterpriseMeta.EstimateSize()
 }
 
@@ -557,11 +686,109 @@ type ACLTokenListStub struct {
 	Hash              []byte
 	CreateIndex       uint64
 	ModifyIndex       uint64
-	Legacy            bool `json:",omitempty"`
+	Legacy            bool           `json:",omitempty"`
+	Usage             *ACLTokenUsage `json:",omitempty"`
 	acl.EnterpriseMeta
 	ACLAuthMethodEnterpriseMeta
 }
 
+// ACLTokenUsageMaxSources is the number of sources whose usage of a token is
+// kept. Beyond it the sources which least recently used the token are dropped.
+const ACLTokenUsageMaxSources = 16
+
+// ACLTokenUsage holds the usage of a token recorded by the servers of the
+// datacenter.
+type ACLTokenUsage struct {
+	// LastUsed is the last time a request made with the token was received.
+	LastUsed time.Time
+
+	// RequestCount is the number of requests made with the token.
+	RequestCount uint64
+
+	// Sources are the addresses the requests were received from, the most
+	// recent first.
+	Sources []ACLTokenUsageSource `json:",omitempty"`
+}
+
+// ACLTokenUsageSource is the usage of a token by the requests received from
+// an address.
+type ACLTokenUsageSource struct {
+	// Address is the IP address the requests were received from.
+	Address string
+
+	// LastUsed is the last time a request made with the token was received
+	// from the address.
+	LastUsed time.Time
+
+	// RequestCount is the number of requests made with the token from the
+	// address.
+	RequestCount uint64
+}
+
+// Merge adds the usage in other to u. The sources are capped to
+// ACLTokenUsageMaxSources.
+func (u *ACLTokenUsage) Merge(other ACLTokenUsage) {
+	if other.LastUsed.After(u.LastUsed) {
+		u.LastUsed = other.LastUsed
+	}
+	u.RequestCount += other.RequestCount
+
+	sources := make([]ACLTokenUsageSource, 0, len(u.Sources)+len(other.Sources))
+	sources = append(sources, u.Sources...)
+	for _, src := range other.Sources {
+		merged := false
+		for i := range sources {
+			if sources[i].Address != src.Address {
+				continue
+			}
+			if src.LastUsed.After(sources[i].LastUsed) {
+				sources[i].LastUsed = src.LastUsed
+			}
+			sources[i].RequestCount += src.RequestCount
+			merged = true
+			break
+		}
+		if !merged {
+			sources = append(sources, src)
+		}
+	}
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].LastUsed.After(sources[j].LastUsed)
+	})
+	if len(sources) > ACLTokenUsageMaxSources {
+		sources = sources[:ACLTokenUsageMaxSources]
+	}
+	u.Sources = sources
+}
+
+// ACLTokenUsageEntry is the usage of a token as stored in the state store.
+type ACLTokenUsageEntry struct {
+	AccessorID string
+	Usage      ACLTokenUsage
+
+	RaftIndex
+}
+
+// ACLTokenUsageUpdateRequest adds the usage of tokens recorded by a server
+// since its last update to the state store.
+type ACLTokenUsageUpdateRequest struct {
+	Datacenter string
+
+	// Usage is the usage recorded by the server, by token accessor ID.
+	Usage map[string]ACLTokenUsage
+
+	// FromAgent is set by the server receiving the request from anything
+	// other than a server of its datacenter. Only servers may update the
+	// usage of tokens.
+	FromAgent bool
+
+	WriteRequest
+}
+
+func (r *ACLTokenUsageUpdateRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
 type ACLTokenListStubs []*ACLTokenListStub
 
 func (token *ACLToken) Stub() *ACLTokenListStub {
@@ -581,6 +808,7 @@ func (token *ACLToken) Stub() *ACLTokenListStub {
 		CreateIndex:                 token.CreateIndex,
 		ModifyIndex:                 token.ModifyIndex,
 		Legacy:                      token.Rules != "",
+		Usage:                       token.Usage,
 		EnterpriseMeta:              token.EnterpriseMeta,
 		ACLAuthMethodEnterpriseMeta: token.ACLAuthMethodEnterpriseMeta,
 	}
@@ -1052,6 +1280,12 @@ type ACLBindingRule struct {
 	// upon the BindType.
 	BindName string
 
+	// BindNameExpression is a CEL expression computing the target of the
+	// binding from the projected variables of the verified identity, available
+	// as the map "vars". It is used instead of BindName when set, and the rule
+	// does not apply to identities for which it evaluates to an empty string.
+	BindNameExpression string `json:",omitempty"`
+
 	// Embedded Enterprise ACL metadata
 	acl.EnterpriseMeta `mapstructure:",squash"`
 
@@ -1790,6 +2024,228 @@ func CreateACLAuthorizationResponses(authz acl.Authorizer, requests []ACLAuthori
 	return responses, nil
 }
 
+// ACLPolicyEvaluateRulesName is the policy name reported by a policy
+// evaluation when the decision came from the Rules of the request.
+const ACLPolicyEvaluateRulesName = "request-rules"
+
+// ACLPolicyEvaluateRequest is used at the RPC layer to evaluate authorization
+// requests against the effective policy of a token, a set of existing
+// policies or policy rules that have not been written yet.
+type ACLPolicyEvaluateRequest struct {
+	SecretID   string               `json:",omitempty"` // secret of the token whose policies are evaluated
+	Policies   []ACLTokenPolicyLink `json:",omitempty"` // existing policies to evaluate, by ID or name
+	Rules      string               `json:",omitempty"` // policy rules to evaluate
+	Requests   []ACLAuthorizationRequest
+	Datacenter string // The datacenter to perform the request within
+	acl.EnterpriseMeta
+	QueryOptions
+}
+
+func (r *ACLPolicyEvaluateRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// ACLPolicyEvaluateResult is the outcome of a single authorization request of
+// a policy evaluation.
+type ACLPolicyEvaluateResult struct {
+	ACLAuthorizationRequest
+	Allow bool
+
+	// PolicyID and PolicyName identify the policy whose rule decided the
+	// request, and Rule is that rule, for example `key_prefix "web/"`.
+	PolicyID   string `json:",omitempty"`
+	PolicyName string `json:",omitempty"`
+	Rule       string `json:",omitempty"`
+
+	// DefaultPolicy is set when no rule matched the request and the decision
+	// was made by the ACL default policy.
+	DefaultPolicy bool `json:",omitempty"`
+}
+
+type ACLPolicyEvaluateResponse struct {
+	Results []ACLPolicyEvaluateResult
+	QueryMeta
+}
+
+// EvaluateACLPolicies evaluates the authorization requests against the
+// policies, falling back to defaultAuthz for requests not matched by any
+// rule. Each result reports the policy and rule that decided it.
+func EvaluateACLPolicies(policies ACLPolicies, defaultAuthz acl.Authorizer, entConf *acl.Config, requests []ACLAuthorizationRequest) ([]ACLPolicyEvaluateResult, error) {
+	authz, err := policies.Compile(nil, entConf)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compile each policy on its own to find which ones agree with the
+	// merged decision, and parse them to find their matching rules.
+	single := make([]acl.Authorizer, len(policies))
+	parsed := make([]*acl.Policy, len(policies))
+	for idx, policy := range policies {
+		single[idx], err = ACLPolicies{policy}.Compile(nil, entConf)
+		if err != nil {
+			return nil, err
+		}
+		parsed[idx], err = acl.NewPolicyFromSource(policy.Rules, policy.Syntax, entConf, policy.EnterprisePolicyMeta())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]ACLPolicyEvaluateResult, len(requests))
+	var ctx acl.AuthorizerContext
+
+	for idx, req := range requests {
+		req.FillAuthzContext(&ctx)
+		results[idx].ACLAuthorizationRequest = req
+
+		decision, err := acl.Enforce(authz, req.Resource, req.Segment, req.Access, &ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if decision == acl.Default {
+			results[idx].DefaultPolicy = true
+			decision, err = acl.Enforce(defaultAuthz, req.Resource, req.Segment, req.Access, &ctx)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			// When merging policies the most specific rule wins, so the
+			// decision belongs to the policy agreeing with it through the
+			// most specific rule. Rules equally specific are merged by
+			// precedence, which is the decision itself.
+			var best *aclRuleMatch
+			for i, policy := range policies {
+				d, err := acl.Enforce(single[i], req.Resource, req.Segment, req.Access, &ctx)
+				if err != nil {
+					return nil, err
+				}
+				if d != decision {
+					continue
+				}
+				match := matchACLRule(&parsed[i].PolicyRules, req.Resource, req.Segment)
+				if best == nil || match.moreSpecificThan(*best) {
+					best = &match
+					results[idx].PolicyID = policy.ID
+					results[idx].PolicyName = policy.Name
+					results[idx].Rule = match.rule
+				}
+			}
+		}
+
+		results[idx].Allow = decision == acl.Allow
+	}
+
+	return results, nil
+}
+
+// aclRuleMatch is the rule of a policy matching an authorization request.
+type aclRuleMatch struct {
+	rule  string
+	exact bool
+	size  int
+}
+
+func (m aclRuleMatch) moreSpecificThan(other aclRuleMatch) bool {
+	if m.exact != other.exact {
+		return m.exact
+	}
+	return m.size > other.size
+}
+
+// consider updates the match with the rule kind "name", or kind_prefix "name"
+// when prefix is set, if it applies to the segment and is more specific.
+func (m *aclRuleMatch) consider(kind, name, segment string, prefix bool) {
+	candidate := aclRuleMatch{rule: fmt.Sprintf("%s %q", kind, name), exact: true, size: len(name)}
+	if prefix {
+		if !strings.HasPrefix(segment, name) {
+			return
+		}
+		candidate = aclRuleMatch{rule: fmt.Sprintf("%s_prefix %q", kind, name), size: len(name)}
+	} else if name != segment {
+		return
+	}
+	if m.rule == "" || candidate.moreSpecificThan(*m) {
+		*m = candidate
+	}
+}
+
+// matchACLRule returns the most specific rule of the policy applying to the
+// resource and segment. The rule is empty if none applies.
+func matchACLRule(rules *acl.PolicyRules, resource acl.Resource, segment string) aclRuleMatch {
+	var m aclRuleMatch
+	switch resource {
+	case acl.ResourceACL, acl.ResourceKeyring, acl.ResourceOperator, acl.ResourceMesh:
+		values := map[acl.Resource]string{
+			acl.ResourceACL:      rules.ACL,
+			acl.ResourceKeyring:  rules.Keyring,
+			acl.ResourceOperator: rules.Operator,
+			acl.ResourceMesh:     rules.Mesh,
+		}
+		// The mesh rule defaults to the operator rule, which is then less
+		// specific than a mesh rule of another policy.
+		m.exact = true
+		if resource == acl.ResourceMesh && rules.Mesh == "" {
+			resource = acl.ResourceOperator
+			m.exact = false
+		}
+		if v := values[resource]; v != "" {
+			m.rule = fmt.Sprintf("%s = %q", resource, v)
+		}
+	case acl.ResourceAgent:
+		for _, r := range rules.Agents {
+			m.consider("agent", r.Node, segment, false)
+		}
+		for _, r := range rules.AgentPrefixes {
+			m.consider("agent", r.Node, segment, true)
+		}
+	case acl.ResourceEvent:
+		for _, r := range rules.Events {
+			m.consider("event", r.Event, segment, false)
+		}
+		for _, r := range rules.EventPrefixes {
+			m.consider("event", r.Event, segment, true)
+		}
+	case acl.ResourceKey:
+		for _, r := range rules.Keys {
+			m.consider("key", r.Prefix, segment, false)
+		}
+		for _, r := range rules.KeyPrefixes {
+			m.consider("key", r.Prefix, segment, true)
+		}
+	case acl.ResourceNode:
+		for _, r := range rules.Nodes {
+			m.consider("node", r.Name, segment, false)
+		}
+		for _, r := range rules.NodePrefixes {
+			m.consider("node", r.Name, segment, true)
+		}
+	case acl.ResourceQuery:
+		for _, r := range rules.PreparedQueries {
+			m.consider("query", r.Prefix, segment, false)
+		}
+		for _, r := range rules.PreparedQueryPrefixes {
+			m.consider("query", r.Prefix, segment, true)
+		}
+	case acl.ResourceService, acl.ResourceIntention:
+		// Intentions are governed by the rules of their destination service.
+		for _, r := range rules.Services {
+			m.consider("service", r.Name, segment, false)
+		}
+		for _, r := range rules.ServicePrefixes {
+			m.consider("service", r.Name, segment, true)
+		}
+	case acl.ResourceSession:
+		for _, r := range rules.Sessions {
+			m.consider("session", r.Node, segment, false)
+		}
+		for _, r := range rules.SessionPrefixes {
+			m.consider("session", r.Node, segment, true)
+		}
+	}
+	return m
+}
+
 type AgentRecoveryTokenIdentity struct {
 	agent    string
 	secretID string
@@ -2,8 +2,10 @@ package structs
 
 import (
 	"fmt"
+	"net"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/acl"
 
@@ -115,6 +117,49 @@ func TestStructs_ACLNodeIdentities_Deduplicate(t *testing.T) {
 	require.Len(t, identities, 3, "original slice shouldn't have been mutated")
 }
 
+func TestStructs_ACLAnonymousScope_Matches(t *testing.T) {
+	scope := &ACLAnonymousScope{
+		Name:        "lan",
+		SourceCIDRs: []string{"10.0.0.0/8", "fd00::/8"},
+		Segments:    []string{"alpha"},
+	}
+
+	require.True(t, scope.Matches(net.ParseIP("10.1.2.3"), ""))
+	require.True(t, scope.Matches(net.ParseIP("fd00::1"), ""))
+	require.True(t, scope.Matches(nil, "alpha"))
+	require.False(t, scope.Matches(net.ParseIP("192.168.0.1"), "beta"))
+	require.False(t, scope.Matches(nil, ""))
+}
+
+func TestStructs_ACLToken_AnonymousScopeToken(t *testing.T) {
+	token := &ACLToken{
+		AccessorID: ACLTokenAnonymousID,
+		SecretID:   "anonymous",
+		Policies:   []ACLTokenPolicyLink{{ID: "default"}},
+		AnonymousScopes: []*ACLAnonymousScope{
+			{
+				Name:        "lan",
+				SourceCIDRs: []string{"10.0.0.0/8"},
+				Policies:    []ACLTokenPolicyLink{{ID: "lan-policy"}},
+				Roles:       []ACLTokenRoleLink{{ID: "lan-role"}},
+			},
+		},
+	}
+
+	scoped := token.AnonymousScopeToken("lan")
+	require.Equal(t, ACLTokenAnonymousID, scoped.AccessorID)
+	require.Equal(t, "anonymous", scoped.SecretID)
+	require.Equal(t, []ACLTokenPolicyLink{{ID: "lan-policy"}}, scoped.Policies)
+	require.Equal(t, []ACLTokenRoleLink{{ID: "lan-role"}}, scoped.Roles)
+	require.Empty(t, scoped.AnonymousScopes)
+
+	// the anonymous token must not be modified
+	require.Equal(t, []ACLTokenPolicyLink{{ID: "default"}}, token.Policies)
+	require.Len(t, token.AnonymousScopes, 1)
+
+	require.Same(t, token, token.AnonymousScopeToken("unknown"))
+}
+
 func TestStructs_ACLToken_SetHash(t *testing.T) {
 
 	token := ACLToken{
@@ -575,3 +620,105 @@ func TestStructs_ACLPolicies_Compile(t *testing.T) {
 		require.Equal(t, acl.Deny, authz.ACLRead(nil))
 	})
 }
+
+func TestStructs_EvaluateACLPolicies(t *testing.T) {
+	policies := ACLPolicies{
+		&ACLPolicy{
+			ID:    "5d5653a1-2c2b-4b36-b083-fc9f1398eb7b",
+			Name:  "web-read",
+			Rules: `service_prefix "web" { policy = "read" }`,
+		},
+		&ACLPolicy{
+			ID:    "b35541f0-a88a-48da-bc66-43553c60b628",
+			Name:  "web-admin",
+			Rules: `service "web-admin" { policy = "deny" } key_prefix "web/" { policy = "write" }`,
+		},
+	}
+
+	requests := []ACLAuthorizationRequest{
+		{Resource: acl.ResourceService, Segment: "web-api", Access: "read"},
+		{Resource: acl.ResourceService, Segment: "web-api", Access: "write"},
+		{Resource: acl.ResourceService, Segment: "web-admin", Access: "read"},
+		{Resource: acl.ResourceKey, Segment: "web/config", Access: "write"},
+		{Resource: acl.ResourceNode, Segment: "node1", Access: "read"},
+	}
+
+	results, err := EvaluateACLPolicies(policies, acl.AllowAll(), nil, requests)
+	require.NoError(t, err)
+	require.Len(t, results, len(requests))
+
+	type outcome struct {
+		allow         bool
+		policyName    string
+		rule          string
+		defaultPolicy bool
+	}
+	expected := []outcome{
+		{allow: true, policyName: "web-read", rule: `service_prefix "web"`},
+		{allow: false, policyName: "web-read", rule: `service_prefix "web"`},
+		{allow: false, policyName: "web-admin", rule: `service "web-admin"`},
+		{allow: true, policyName: "web-admin", rule: `key_prefix "web/"`},
+		{allow: true, defaultPolicy: true},
+	}
+	for idx, res := range results {
+		require.Equal(t, requests[idx], res.ACLAuthorizationRequest)
+		require.Equal(t, expected[idx].allow, res.Allow, "request %d", idx)
+		require.Equal(t, expected[idx].policyName, res.PolicyName, "request %d", idx)
+		require.Equal(t, expected[idx].rule, res.Rule, "request %d", idx)
+		require.Equal(t, expected[idx].defaultPolicy, res.DefaultPolicy, "request %d", idx)
+	}
+
+	t.Run("most specific rule wins", func(t *testing.T) {
+		policies := ACLPolicies{
+			&ACLPolicy{Name: "read-all", Rules: `key_prefix "" { policy = "read" } operator = "read"`},
+			&ACLPolicy{Name: "read-app", Rules: `key_prefix "app/" { policy = "read" } mesh = "read"`},
+			&ACLPolicy{Name: "read-config", Rules: `key "app/config" { policy = "read" }`},
+		}
+		requests := []ACLAuthorizationRequest{
+			{Resource: acl.ResourceKey, Segment: "app/config", Access: "read"},
+			{Resource: acl.ResourceKey, Segment: "app/other", Access: "read"},
+			{Resource: acl.ResourceKey, Segment: "other", Access: "read"},
+			{Resource: acl.ResourceMesh, Access: "read"},
+		}
+		results, err := EvaluateACLPolicies(policies, acl.DenyAll(), nil, requests)
+		require.NoError(t, err)
+
+		expected := []outcome{
+			{allow: true, policyName: "read-config", rule: `key "app/config"`},
+			{allow: true, policyName: "read-app", rule: `key_prefix "app/"`},
+			{allow: true, policyName: "read-all", rule: `key_prefix ""`},
+			{allow: true, policyName: "read-app", rule: `mesh = "read"`},
+		}
+		for idx, res := range results {
+			require.Equal(t, expected[idx].allow, res.Allow, "request %d", idx)
+			require.Equal(t, expected[idx].policyName, res.PolicyName, "request %d", idx)
+			require.Equal(t, expected[idx].rule, res.Rule, "request %d", idx)
+		}
+	})
+
+	_, err = EvaluateACLPolicies(ACLPolicies{{Name: "bad", Rules: `service "web" { policy = "bogus" }`}}, acl.DenyAll(), nil, requests)
+	require.Error(t, err)
+}
+
+func TestACLTokenUsage_Merge(t *testing.T) {
+	now := time.Now()
+
+	var usage ACLTokenUsage
+	for i := 0; i < ACLTokenUsageMaxSources+2; i++ {
+		usage.Merge(ACLTokenUsage{
+			LastUsed:     now.Add(time.Duration(i) * time.Second),
+			RequestCount: 1,
+			Sources: []ACLTokenUsageSource{{
+				Address:      "10.0.0." + string(rune('a'+i)),
+				LastUsed:     now.Add(time.Duration(i) * time.Second),
+				RequestCount: 1,
+			}},
+		})
+	}
+
+	require.Equal(t, uint64(ACLTokenUsageMaxSources+2), usage.RequestCount)
+	require.Len(t, usage.Sources, ACLTokenUsageMaxSources)
+	// The sources which least recently used the token are dropped.
+	require.Equal(t, "10.0.0."+string(rune('a'+ACLTokenUsageMaxSources+1)), usage.Sources[0].Address)
+	require.Equal(t, "10.0.0.c", usage.Sources[len(usage.Sources)-1].Address)
+}
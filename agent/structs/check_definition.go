@@ -43,11 +43,16 @@ type CheckDefinition struct {
 	TLSSkipVerify                  bool
 	AliasNode                      string
 	AliasService                   string
+	AliasPropagationDelay          time.Duration
 	Timeout                        time.Duration
 	TTL                            time.Duration
 	SuccessBeforePassing           int
 	FailuresBeforeWarning          int
 	FailuresBeforeCritical         int
+	TTLExpiryDeregisterAfter       int
+	TTLExpiryWebhook               string
+	TTLExpiryMaintenance           bool
+	Templated                      bool
 	DeregisterCriticalServiceAfter time.Duration
 	OutputMaxSize                  int
 
@@ -62,6 +67,7 @@ func (t *CheckDefinition) UnmarshalJSON(data []byte) (err error) {
 		Timeout                        interface{}
 		TTL                            interface{}
 		DeregisterCriticalServiceAfter interface{}
+		AliasPropagationDelay          interface{}
 
 		// Translate fields
 
@@ -69,6 +75,7 @@ func (t *CheckDefinition) UnmarshalJSON(data []byte) (err error) {
 		Args                                []string    `json:"args"`
 		ScriptArgsSnake                     []string    `json:"script_args"`
 		DeregisterCriticalServiceAfterSnake interface{} `json:"deregister_critical_service_after"`
+		AliasPropagationDelaySnake          interface{} `json:"alias_propagation_delay"`
 		DockerContainerIDSnake              string      `json:"docker_container_id"`
 		TLSServerNameSnake                  string      `json:"tls_server_name"`
 		TLSSkipVerifySnake                  bool        `json:"tls_skip_verify"`
@@ -170,6 +177,19 @@ func (t *CheckDefinition) UnmarshalJSON(data []byte) (err error) {
 			t.DeregisterCriticalServiceAfter = time.Duration(v)
 		}
 	}
+	if aux.AliasPropagationDelay == nil {
+		aux.AliasPropagationDelay = aux.AliasPropagationDelaySnake
+	}
+	if aux.AliasPropagationDelay != nil {
+		switch v := aux.AliasPropagationDelay.(type) {
+		case string:
+			if t.AliasPropagationDelay, err = time.ParseDuration(v); err != nil {
+				return err
+			}
+		case float64:
+			t.AliasPropagationDelay = time.Duration(v)
+		}
+	}
 
 	return nil
 }
@@ -205,6 +225,7 @@ func (c *CheckDefinition) CheckType() *CheckType {
 		ScriptArgs:                     c.ScriptArgs,
 		AliasNode:                      c.AliasNode,
 		AliasService:                   c.AliasService,
+		AliasPropagationDelay:          c.AliasPropagationDelay,
 		HTTP:                           c.HTTP,
 		H2PING:                         c.H2PING,
 		H2PingUseTLS:                   c.H2PingUseTLS,
@@ -227,6 +248,10 @@ func (c *CheckDefinition) CheckType() *CheckType {
 		SuccessBeforePassing:           c.SuccessBeforePassing,
 		FailuresBeforeWarning:          c.FailuresBeforeWarning,
 		FailuresBeforeCritical:         c.FailuresBeforeCritical,
+		TTLExpiryDeregisterAfter:       c.TTLExpiryDeregisterAfter,
+		TTLExpiryWebhook:               c.TTLExpiryWebhook,
+		TTLExpiryMaintenance:           c.TTLExpiryMaintenance,
+		Templated:                      c.Templated,
 		DeregisterCriticalServiceAfter: c.DeregisterCriticalServiceAfter,
 	}
 }
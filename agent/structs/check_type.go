@@ -2,6 +2,7 @@ package structs
 
 import (
 	"fmt"
+	"net/url"
 	"reflect"
 	"time"
 
@@ -43,6 +44,7 @@ type CheckType struct {
 	Interval               time.Duration
 	AliasNode              string
 	AliasService           string
+	AliasPropagationDelay  time.Duration
 	DockerContainerID      string
 	Shell                  string
 	GRPC                   string
@@ -55,6 +57,17 @@ type CheckType struct {
 	FailuresBeforeWarning  int
 	FailuresBeforeCritical int
 
+	// TTLExpiry fields configure the actions taken when the TTL of a TTL
+	// check expires, in addition to making the check critical.
+	TTLExpiryDeregisterAfter int
+	TTLExpiryWebhook         string
+	TTLExpiryMaintenance     bool
+
+	// Templated enables the rendering of the templates found in the fields
+	// describing the target of a check bound to a service. It is cleared
+	// once the templates are rendered so that they are only rendered once.
+	Templated bool
+
 	// Definition fields used when exposing checks through a proxy
 	ProxyHTTP string
 	ProxyGRPC string
@@ -73,6 +86,7 @@ func (t *CheckType) UnmarshalJSON(data []byte) (err error) {
 		Timeout                        interface{}
 		TTL                            interface{}
 		DeregisterCriticalServiceAfter interface{}
+		AliasPropagationDelay          interface{}
 
 		// Translate fields
 
@@ -80,6 +94,7 @@ func (t *CheckType) UnmarshalJSON(data []byte) (err error) {
 		Args                                []string    `json:"args"`
 		ScriptArgsSnake                     []string    `json:"script_args"`
 		DeregisterCriticalServiceAfterSnake interface{} `json:"deregister_critical_service_after"`
+		AliasPropagationDelaySnake          interface{} `json:"alias_propagation_delay"`
 		DockerContainerIDSnake              string      `json:"docker_container_id"`
 		TLSServerNameSnake                  string      `json:"tls_server_name"`
 		TLSSkipVerifySnake                  bool        `json:"tls_skip_verify"`
@@ -170,6 +185,19 @@ func (t *CheckType) UnmarshalJSON(data []byte) (err error) {
 			t.DeregisterCriticalServiceAfter = time.Duration(v)
 		}
 	}
+	if aux.AliasPropagationDelay == nil {
+		aux.AliasPropagationDelay = aux.AliasPropagationDelaySnake
+	}
+	if aux.AliasPropagationDelay != nil {
+		switch v := aux.AliasPropagationDelay.(type) {
+		case string:
+			if t.AliasPropagationDelay, err = time.ParseDuration(v); err != nil {
+				return err
+			}
+		case float64:
+			t.AliasPropagationDelay = time.Duration(v)
+		}
+	}
 	if (aux.H2PING != "" && !aux.H2PingUseTLSSnake) || (aux.H2PING == "" && aux.H2PingUseTLSSnake) {
 		t.H2PingUseTLS = aux.H2PingUseTLSSnake
 	}
@@ -194,6 +222,12 @@ func (c *CheckType) Validate() error {
 	if c.IsAlias() && c.TTL > 0 {
 		return fmt.Errorf("TTL must be not be set for Alias checks")
 	}
+	if c.AliasPropagationDelay < 0 {
+		return fmt.Errorf("AliasPropagationDelay must be positive")
+	}
+	if c.AliasPropagationDelay > 0 && !c.IsAlias() {
+		return fmt.Errorf("AliasPropagationDelay can only be set for Alias checks")
+	}
 	if !intervalCheck && !c.IsAlias() && c.TTL <= 0 {
 		return fmt.Errorf("TTL must be > 0 for TTL checks")
 	}
@@ -203,6 +237,18 @@ func (c *CheckType) Validate() error {
 	if c.FailuresBeforeWarning > c.FailuresBeforeCritical {
 		return fmt.Errorf("FailuresBeforeWarning can't be higher than FailuresBeforeCritical")
 	}
+	if c.TTLExpiryDeregisterAfter < 0 {
+		return fmt.Errorf("TTLExpiryDeregisterAfter must be positive")
+	}
+	if c.hasTTLExpiryActions() && c.TTL <= 0 {
+		return fmt.Errorf("TTLExpiry actions can only be set for TTL checks")
+	}
+	if c.TTLExpiryWebhook != "" {
+		u, err := url.Parse(c.TTLExpiryWebhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("TTLExpiryWebhook must be an http or https URL")
+		}
+	}
 
 	return nil
 }
@@ -212,6 +258,10 @@ func (c *CheckType) Empty() bool {
 	return reflect.DeepEqual(c, &CheckType{})
 }
 
+func (c *CheckType) hasTTLExpiryActions() bool {
+	return c.TTLExpiryDeregisterAfter != 0 || c.TTLExpiryWebhook != "" || c.TTLExpiryMaintenance
+}
+
 // IsAlias checks if this is an alias check.
 func (c *CheckType) IsAlias() bool {
 	return c.AliasNode != "" || c.AliasService != ""
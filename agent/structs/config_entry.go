@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-multierror"
+	"github.com/miekg/dns"
 	"github.com/mitchellh/hashstructure"
 	"github.com/mitchellh/mapstructure"
 
@@ -31,9 +32,14 @@ const (
 	ServiceIntentions  string = "service-intentions"
 	MeshConfig         string = "mesh"
 	ExportedServices   string = "exported-services"
+	TrafficShift       string = "traffic-shift"
+	EgressGateway      string = "egress-gateway"
+	MaintenanceWindow  string = "maintenance-window"
+	RegistrationPolicy string = "registration-policy"
 
-	ProxyConfigGlobal string = "global"
-	MeshConfigMesh    string = "mesh"
+	ProxyConfigGlobal        string = "global"
+	MeshConfigMesh           string = "mesh"
+	RegistrationPolicyGlobal string = "global"
 
 	DefaultServiceProtocol = "tcp"
 )
@@ -49,6 +55,10 @@ var AllConfigEntryKinds = []string{
 	ServiceIntentions,
 	MeshConfig,
 	ExportedServices,
+	TrafficShift,
+	EgressGateway,
+	MaintenanceWindow,
+	RegistrationPolicy,
 }
 
 // ConfigEntry is the interface for centralized configuration stored in Raft.
@@ -96,17 +106,26 @@ type WarningConfigEntry interface {
 // ServiceConfiguration is the top-level struct for the configuration of a service
 // across the entire cluster.
 type ServiceConfigEntry struct {
-	Kind                  string
-	Name                  string
-	Protocol              string
-	Mode                  ProxyMode              `json:",omitempty"`
-	TransparentProxy      TransparentProxyConfig `json:",omitempty" alias:"transparent_proxy"`
-	MeshGateway           MeshGatewayConfig      `json:",omitempty" alias:"mesh_gateway"`
-	Expose                ExposeConfig           `json:",omitempty"`
-	ExternalSNI           string                 `json:",omitempty" alias:"external_sni"`
-	UpstreamConfig        *UpstreamConfiguration `json:",omitempty" alias:"upstream_config"`
-	Destination           *DestinationConfig     `json:",omitempty"`
-	MaxInboundConnections int                    `json:",omitempty" alias:"max_inbound_connections"`
+	Kind                              string
+	Name                              string
+	Protocol                          string
+	Mode                              ProxyMode                   `json:",omitempty"`
+	TransparentProxy                  TransparentProxyConfig      `json:",omitempty" alias:"transparent_proxy"`
+	MeshGateway                       MeshGatewayConfig           `json:",omitempty" alias:"mesh_gateway"`
+	Expose                            ExposeConfig                `json:",omitempty"`
+	ExternalSNI                       string                      `json:",omitempty" alias:"external_sni"`
+	UpstreamConfig                    *UpstreamConfiguration      `json:",omitempty" alias:"upstream_config"`
+	Destination                       *DestinationConfig          `json:",omitempty"`
+	MaxInboundConnections             int                         `json:",omitempty" alias:"max_inbound_connections"`
+	LocalAppHTTP2                     *LocalAppHTTP2Config        `json:",omitempty" alias:"local_app_http2"`
+	InboundConnectionBufferLimitBytes int                         `json:",omitempty" alias:"inbound_connection_buffer_limit_bytes"`
+	MaxRequestBodyBytes               int                         `json:",omitempty" alias:"max_request_body_bytes"`
+	RequestNormalization              *RequestNormalizationConfig `json:",omitempty" alias:"request_normalization"`
+
+	// IgnoreCatalogWeights makes sidecar proxies load balance evenly between
+	// the instances of the service rather than following the Weights of
+	// their catalog registrations. DNS is not affected.
+	IgnoreCatalogWeights bool `json:",omitempty" alias:"ignore_catalog_weights"`
 
 	Meta               map[string]string `json:",omitempty"`
 	acl.EnterpriseMeta `hcl:",squash" mapstructure:",squash"`
@@ -117,6 +136,11 @@ func (e *ServiceConfigEntry) Clone() *ServiceConfigEntry {
 	e2 := *e
 	e2.Expose = e.Expose.Clone()
 	e2.UpstreamConfig = e.UpstreamConfig.Clone()
+	if e.LocalAppHTTP2 != nil {
+		http2 := *e.LocalAppHTTP2
+		e2.LocalAppHTTP2 = &http2
+	}
+	e2.RequestNormalization = e.RequestNormalization.Clone()
 	return &e2
 }
 
@@ -210,6 +234,28 @@ func (e *ServiceConfigEntry) Validate() error {
 		}
 	}
 
+	if e.MaxInboundConnections < 0 {
+		validationErr = multierror.Append(validationErr, fmt.Errorf("MaxInboundConnections must be positive"))
+	}
+	if e.InboundConnectionBufferLimitBytes < 0 {
+		validationErr = multierror.Append(validationErr, fmt.Errorf("InboundConnectionBufferLimitBytes must be positive"))
+	}
+	if e.MaxRequestBodyBytes < 0 {
+		validationErr = multierror.Append(validationErr, fmt.Errorf("MaxRequestBodyBytes must be positive"))
+	}
+
+	if e.LocalAppHTTP2 != nil {
+		if err := e.LocalAppHTTP2.Validate(); err != nil {
+			validationErr = multierror.Append(validationErr, fmt.Errorf("LocalAppHTTP2 is invalid: %w", err))
+		}
+	}
+
+	if e.RequestNormalization != nil {
+		if err := e.RequestNormalization.Validate(); err != nil {
+			validationErr = multierror.Append(validationErr, fmt.Errorf("RequestNormalization is invalid: %w", err))
+		}
+	}
+
 	return validationErr
 }
 
@@ -301,6 +347,62 @@ type DestinationConfig struct {
 	Port int `json:",omitempty"`
 }
 
+// LocalAppHTTP2Config tunes the HTTP/2 connections made by sidecar proxies to
+// the local application instance. It only applies when the proxy speaks HTTP/2
+// to the application, which is the case for the http2 and grpc protocols or
+// when PriorKnowledge is set.
+type LocalAppHTTP2Config struct {
+	// PriorKnowledge makes the proxy use HTTP/2 without TLS or upgrade (h2c)
+	// even for services using the http protocol.
+	PriorKnowledge bool `json:",omitempty" alias:"prior_knowledge"`
+
+	// KeepaliveIntervalMs is the number of milliseconds between HTTP/2 PING
+	// frames sent on idle connections. Keepalives are disabled when unset.
+	KeepaliveIntervalMs int `json:",omitempty" alias:"keepalive_interval_ms"`
+
+	// KeepaliveTimeoutMs is the number of milliseconds to wait for a PING
+	// response before closing the connection. Defaults to 5 seconds.
+	KeepaliveTimeoutMs int `json:",omitempty" alias:"keepalive_timeout_ms"`
+
+	// MaxConcurrentStreams limits the number of concurrent streams on a single
+	// connection.
+	MaxConcurrentStreams int `json:",omitempty" alias:"max_concurrent_streams"`
+}
+
+func (c *LocalAppHTTP2Config) Validate() error {
+	if c.KeepaliveIntervalMs < 0 {
+		return fmt.Errorf("KeepaliveIntervalMs must be positive")
+	}
+	if c.KeepaliveTimeoutMs < 0 {
+		return fmt.Errorf("KeepaliveTimeoutMs must be positive")
+	}
+	if c.KeepaliveTimeoutMs > 0 && c.KeepaliveIntervalMs == 0 {
+		return fmt.Errorf("KeepaliveTimeoutMs requires KeepaliveIntervalMs")
+	}
+	if c.MaxConcurrentStreams < 0 {
+		return fmt.Errorf("MaxConcurrentStreams must be positive")
+	}
+	return nil
+}
+
+// ProxyConfig returns the sidecar proxy config keys equivalent to c.
+func (c *LocalAppHTTP2Config) ProxyConfig() map[string]interface{} {
+	cfg := make(map[string]interface{})
+	if c.PriorKnowledge {
+		cfg["local_app_http2_prior_knowledge"] = true
+	}
+	if c.KeepaliveIntervalMs > 0 {
+		cfg["local_app_http2_keepalive_interval_ms"] = c.KeepaliveIntervalMs
+	}
+	if c.KeepaliveTimeoutMs > 0 {
+		cfg["local_app_http2_keepalive_timeout_ms"] = c.KeepaliveTimeoutMs
+	}
+	if c.MaxConcurrentStreams > 0 {
+		cfg["local_app_http2_max_concurrent_streams"] = c.MaxConcurrentStreams
+	}
+	return cfg
+}
+
 // ProxyConfigEntry is the top-level struct for global proxy configuration defaults.
 type ProxyConfigEntry struct {
 	Kind             string
@@ -390,6 +492,81 @@ func (e *ProxyConfigEntry) GetEnterpriseMeta() *acl.EnterpriseMeta {
 	return &e.EnterpriseMeta
 }
 
+// MergeProxyConfigEntries layers proxy-defaults entries ordered from the
+// broadest to the narrowest scope, as returned by ProxyDefaultsScopes, into
+// the effective proxy-defaults of the narrowest scope. Nil layers are skipped.
+//
+// The keys of Config and Meta, Mode, MeshGateway.Mode,
+// TransparentProxy.OutboundListenerPort and Expose.Paths set in a narrower
+// layer take precedence over the ones of broader layers, while
+// TransparentProxy.DialedDirectly and Expose.Checks are enabled if any layer
+// enables them.
+func MergeProxyConfigEntries(layers ...*ProxyConfigEntry) *ProxyConfigEntry {
+	var present []*ProxyConfigEntry
+	for _, layer := range layers {
+		if layer != nil {
+			present = append(present, layer)
+		}
+	}
+	switch len(present) {
+	case 0:
+		return nil
+	case 1:
+		return present[0]
+	}
+
+	merged := *present[0]
+	merged.Config = mergeProxyConfigMaps(nil, merged.Config)
+	merged.Meta = mergeStringMaps(nil, merged.Meta)
+	for _, layer := range present[1:] {
+		merged.Config = mergeProxyConfigMaps(merged.Config, layer.Config)
+		merged.Meta = mergeStringMaps(merged.Meta, layer.Meta)
+		if layer.Mode != ProxyModeDefault {
+			merged.Mode = layer.Mode
+		}
+		if layer.MeshGateway.Mode != MeshGatewayModeDefault {
+			merged.MeshGateway.Mode = layer.MeshGateway.Mode
+		}
+		if layer.TransparentProxy.OutboundListenerPort != 0 {
+			merged.TransparentProxy.OutboundListenerPort = layer.TransparentProxy.OutboundListenerPort
+		}
+		merged.TransparentProxy.DialedDirectly = merged.TransparentProxy.DialedDirectly || layer.TransparentProxy.DialedDirectly
+		if len(layer.Expose.Paths) > 0 {
+			merged.Expose.Paths = layer.Expose.Paths
+		}
+		merged.Expose.Checks = merged.Expose.Checks || layer.Expose.Checks
+		merged.EnterpriseMeta = layer.EnterpriseMeta
+		merged.RaftIndex = layer.RaftIndex
+	}
+	return &merged
+}
+
+func mergeProxyConfigMaps(dst, src map[string]interface{}) map[string]interface{} {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]interface{}, len(src))
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+func mergeStringMaps(dst, src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]string, len(src))
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
 func (e *ProxyConfigEntry) MarshalBinary() (data []byte, err error) {
 	// We mainly want to implement the BinaryMarshaller interface so that
 	// we can fixup some msgpack types to coerce them into JSON compatible
@@ -593,6 +770,14 @@ func MakeConfigEntry(kind, name string) (ConfigEntry, error) {
 		return &MeshConfigEntry{}, nil
 	case ExportedServices:
 		return &ExportedServicesConfigEntry{Name: name}, nil
+	case TrafficShift:
+		return &TrafficShiftConfigEntry{Name: name}, nil
+	case MaintenanceWindow:
+		return &MaintenanceWindowConfigEntry{Name: name}, nil
+	case EgressGateway:
+		return &EgressGatewayConfigEntry{Name: name}, nil
+	case RegistrationPolicy:
+		return &RegistrationPolicyConfigEntry{Name: name}, nil
 	default:
 		return nil, fmt.Errorf("invalid config entry kind: %s", kind)
 	}
@@ -627,6 +812,8 @@ func (r *ConfigEntryQuery) CacheInfo() cache.RequestInfo {
 		r.Name,
 		r.Filter,
 		r.EnterpriseMeta,
+		r.PerPage,
+		r.NextToken,
 	}, nil)
 	if err == nil {
 		// If there is an error, we don't set the key. A blank key forces
@@ -672,6 +859,11 @@ type ServiceConfigRequest struct {
 
 	UpstreamIDs []ServiceID
 
+	// PeeredUpstreams are the upstreams imported from peers. They are kept
+	// apart from UpstreamIDs since they are only configured by the upstream
+	// overrides targeting the same peer.
+	PeeredUpstreams []PeeredServiceName `json:",omitempty"`
+
 	// DEPRECATED
 	// Upstreams is a list of upstream service names to use for resolving the service config
 	// UpstreamIDs should be used instead which can encode more than just the name to
@@ -704,8 +896,9 @@ func (r *ServiceConfigRequest) CacheInfo() cache.RequestInfo {
 	v, err := hashstructure.Hash(struct {
 		Name              string
 		EnterpriseMeta    acl.EnterpriseMeta
-		Upstreams         []string    `hash:"set"`
-		UpstreamIDs       []ServiceID `hash:"set"`
+		Upstreams         []string            `hash:"set"`
+		UpstreamIDs       []ServiceID         `hash:"set"`
+		PeeredUpstreams   []PeeredServiceName `hash:"set"`
 		MeshGatewayConfig MeshGatewayConfig
 		ProxyMode         ProxyMode
 		Filter            string
@@ -714,6 +907,7 @@ func (r *ServiceConfigRequest) CacheInfo() cache.RequestInfo {
 		EnterpriseMeta:    r.EnterpriseMeta,
 		Upstreams:         r.Upstreams,
 		UpstreamIDs:       r.UpstreamIDs,
+		PeeredUpstreams:   r.PeeredUpstreams,
 		ProxyMode:         r.Mode,
 		MeshGatewayConfig: r.MeshGateway,
 		Filter:            r.QueryOptions.Filter,
@@ -733,6 +927,10 @@ type UpstreamConfig struct {
 	Name string `json:",omitempty"`
 	// EnterpriseMeta is only accepted within a service-defaults config entry.
 	acl.EnterpriseMeta `hcl:",squash" mapstructure:",squash"`
+	// Peer is only accepted within a service-defaults config entry. It makes
+	// the override apply to the upstream imported from this peer rather than
+	// to the local service of the same name.
+	Peer string `json:",omitempty"`
 
 	// EnvoyListenerJSON is a complete override ("escape hatch") for the upstream's
 	// listener.
@@ -759,6 +957,16 @@ type UpstreamConfig struct {
 	// connection to this upstream. Defaults to 5000 (5 seconds) if not set.
 	ConnectTimeoutMs int `json:",omitempty" alias:"connect_timeout_ms"`
 
+	// RequestTimeoutMs is the number of milliseconds the proxy waits for a
+	// complete response to the HTTP requests to this upstream. It overrides
+	// the RequestTimeout of the service-router routes of the upstream, which
+	// is owned by the destination service, for the requests of this proxy.
+	RequestTimeoutMs int `json:",omitempty" alias:"request_timeout_ms"`
+
+	// RouteTimeouts override the request timeout of specific service-router
+	// routes of the upstream. They take precedence over RequestTimeoutMs.
+	RouteTimeouts []UpstreamRouteTimeout `json:",omitempty" alias:"route_timeouts"`
+
 	// Limits are the set of limits that are applied to the proxy for a specific upstream of a
 	// service instance.
 	Limits *UpstreamLimits `json:",omitempty"`
@@ -769,11 +977,59 @@ type UpstreamConfig struct {
 
 	// MeshGatewayConfig controls how Mesh Gateways are configured and used
 	MeshGateway MeshGatewayConfig `json:",omitempty" alias:"mesh_gateway" `
+
+	// SNI overrides the server name sent to the upstream during the TLS
+	// handshake. It is meant for peered upstreams and services fronted by a
+	// terminating gateway that present certificates not issued following
+	// Consul's naming conventions.
+	SNI string `json:",omitempty"`
+
+	// AcceptedSANs overrides the subject alternative names accepted in the
+	// certificate presented by the upstream, which default to the SPIFFE IDs
+	// of its targets.
+	AcceptedSANs []string `json:",omitempty" alias:"accepted_sans"`
+}
+
+// UpstreamRouteTimeout overrides the request timeout of the service-router
+// routes of an upstream matching the same path.
+type UpstreamRouteTimeout struct {
+	// PathExact selects the routes matching exactly this path.
+	PathExact string `json:",omitempty" alias:"path_exact"`
+
+	// PathPrefix selects the routes matching this path prefix.
+	PathPrefix string `json:",omitempty" alias:"path_prefix"`
+
+	// RequestTimeoutMs is the request timeout of the selected routes.
+	RequestTimeoutMs int `alias:"request_timeout_ms"`
+}
+
+func (t UpstreamRouteTimeout) Validate() error {
+	switch {
+	case t.PathExact == "" && t.PathPrefix == "":
+		return fmt.Errorf("one of PathExact or PathPrefix is required")
+	case t.PathExact != "" && t.PathPrefix != "":
+		return fmt.Errorf("only one of PathExact or PathPrefix may be set")
+	case t.PathExact != "" && !strings.HasPrefix(t.PathExact, "/"):
+		return fmt.Errorf("PathExact must start with a slash")
+	case t.PathPrefix != "" && !strings.HasPrefix(t.PathPrefix, "/"):
+		return fmt.Errorf("PathPrefix must start with a slash")
+	case t.RequestTimeoutMs <= 0:
+		return fmt.Errorf("RequestTimeoutMs must be positive")
+	}
+	return nil
 }
 
 func (cfg UpstreamConfig) Clone() UpstreamConfig {
 	cfg2 := cfg
 
+	if cfg.RouteTimeouts != nil {
+		cfg2.RouteTimeouts = make([]UpstreamRouteTimeout, len(cfg.RouteTimeouts))
+		copy(cfg2.RouteTimeouts, cfg.RouteTimeouts)
+	}
+	if cfg.AcceptedSANs != nil {
+		cfg2.AcceptedSANs = make([]string, len(cfg.AcceptedSANs))
+		copy(cfg2.AcceptedSANs, cfg.AcceptedSANs)
+	}
 	cfg2.Limits = cfg.Limits.Clone()
 	cfg2.PassiveHealthCheck = cfg.PassiveHealthCheck.Clone()
 
@@ -794,6 +1050,16 @@ func (cfg *UpstreamConfig) ServiceName() ServiceName {
 	return NewServiceName(cfg.Name, &cfg.EnterpriseMeta)
 }
 
+func (cfg *UpstreamConfig) PeeredServiceName() PeeredServiceName {
+	if cfg.Name == "" {
+		return PeeredServiceName{}
+	}
+	return PeeredServiceName{
+		Peer:        cfg.Peer,
+		ServiceName: cfg.ServiceName(),
+	}
+}
+
 func (cfg UpstreamConfig) MergeInto(dst map[string]interface{}) {
 	// Avoid storing empty values in the map, since these can act as overrides
 	if cfg.EnvoyListenerJSON != "" {
@@ -808,6 +1074,12 @@ func (cfg UpstreamConfig) MergeInto(dst map[string]interface{}) {
 	if cfg.ConnectTimeoutMs != 0 {
 		dst["connect_timeout_ms"] = cfg.ConnectTimeoutMs
 	}
+	if cfg.RequestTimeoutMs != 0 {
+		dst["request_timeout_ms"] = cfg.RequestTimeoutMs
+	}
+	if len(cfg.RouteTimeouts) > 0 {
+		dst["route_timeouts"] = cfg.RouteTimeouts
+	}
 	if !cfg.MeshGateway.IsZero() {
 		dst["mesh_gateway"] = cfg.MeshGateway
 	}
@@ -817,6 +1089,12 @@ func (cfg UpstreamConfig) MergeInto(dst map[string]interface{}) {
 	if cfg.PassiveHealthCheck != nil {
 		dst["passive_health_check"] = cfg.PassiveHealthCheck
 	}
+	if cfg.SNI != "" {
+		dst["sni"] = cfg.SNI
+	}
+	if len(cfg.AcceptedSANs) > 0 {
+		dst["accepted_sans"] = cfg.AcceptedSANs
+	}
 }
 
 func (cfg *UpstreamConfig) NormalizeWithoutName() error {
@@ -858,6 +1136,10 @@ func (cfg UpstreamConfig) validate(named bool) error {
 		if cfg.EnterpriseMeta.NamespaceOrDefault() == WildcardSpecifier {
 			return fmt.Errorf("Wildcard namespace is not supported")
 		}
+		// Imported upstreams are identified by their peer, not a partition.
+		if cfg.Peer != "" && cfg.EnterpriseMeta.PartitionOrEmpty() != "" {
+			return fmt.Errorf("cannot set Peer and Partition at the same time")
+		}
 	} else {
 		if cfg.Name != "" {
 			return fmt.Errorf("Name must be empty")
@@ -868,10 +1150,30 @@ func (cfg UpstreamConfig) validate(named bool) error {
 		if cfg.EnterpriseMeta.PartitionOrEmpty() != "" {
 			return fmt.Errorf("Partition must be empty")
 		}
+		if cfg.Peer != "" {
+			return fmt.Errorf("Peer must be empty")
+		}
+		// The expected SNI and SANs are specific to one upstream.
+		if cfg.SNI != "" {
+			return fmt.Errorf("SNI must be empty")
+		}
+		if len(cfg.AcceptedSANs) > 0 {
+			return fmt.Errorf("AcceptedSANs must be empty")
+		}
 	}
 
 	var validationErr error
 
+	if cfg.RequestTimeoutMs < 0 {
+		validationErr = multierror.Append(validationErr, fmt.Errorf("RequestTimeoutMs must be positive"))
+	}
+
+	for i, t := range cfg.RouteTimeouts {
+		if err := t.Validate(); err != nil {
+			validationErr = multierror.Append(validationErr, fmt.Errorf("RouteTimeouts[%d]: %v", i, err))
+		}
+	}
+
 	if cfg.PassiveHealthCheck != nil {
 		err := cfg.PassiveHealthCheck.Validate()
 		if err != nil {
@@ -886,6 +1188,18 @@ func (cfg UpstreamConfig) validate(named bool) error {
 		}
 	}
 
+	if cfg.SNI != "" {
+		if _, ok := dns.IsDomainName(cfg.SNI); !ok || strings.ContainsAny(cfg.SNI, "*:/") {
+			validationErr = multierror.Append(validationErr, fmt.Errorf("SNI %q must be a valid DNS hostname", cfg.SNI))
+		}
+	}
+
+	for i, san := range cfg.AcceptedSANs {
+		if strings.TrimSpace(san) == "" {
+			validationErr = multierror.Append(validationErr, fmt.Errorf("AcceptedSANs[%d] must not be empty", i))
+		}
+	}
+
 	return validationErr
 }
 
@@ -1020,14 +1334,18 @@ func (ul UpstreamLimits) Validate() error {
 
 type OpaqueUpstreamConfig struct {
 	Upstream ServiceID
-	Config   map[string]interface{}
+	// Peer is set when the upstream is imported from a peer.
+	Peer   string `json:",omitempty"`
+	Config map[string]interface{}
 }
 
 type OpaqueUpstreamConfigs []OpaqueUpstreamConfig
 
+// GetUpstreamConfig returns the config of a local upstream. The configs of
+// the upstreams imported from peers are skipped.
 func (configs OpaqueUpstreamConfigs) GetUpstreamConfig(sid ServiceID) (config map[string]interface{}, found bool) {
 	for _, usconf := range configs {
-		if usconf.Upstream.Matches(sid) {
+		if usconf.Peer == "" && usconf.Upstream.Matches(sid) {
 			return usconf.Config, true
 		}
 	}
@@ -788,6 +788,14 @@ type ServiceResolverConfigEntry struct {
 	// will be usable.
 	Subsets map[string]ServiceResolverSubset `json:",omitempty"`
 
+	// DynamicSubsets, if set, makes every value of a service instance
+	// metadata key addressable as a subset without listing it in Subsets.
+	// Explicitly defined subsets take precedence over dynamic ones. The
+	// values registered by the instances of the service are discovered and
+	// compiled into the discovery chain, and any value can be referenced by
+	// name from the other config entries.
+	DynamicSubsets *ServiceResolverDynamicSubsets `json:",omitempty" alias:"dynamic_subsets"`
+
 	// Redirect is a service/subset/datacenter/namespace to resolve
 	// instead of the requested service (optional).
 	//
@@ -860,16 +868,28 @@ func (e *ServiceResolverConfigEntry) SubsetExists(name string) bool {
 	if name == "" {
 		return true
 	}
-	if len(e.Subsets) == 0 {
-		return false
-	}
-	_, ok := e.Subsets[name]
+	_, ok := e.GetSubset(name)
 	return ok
 }
 
+// GetSubset returns the definition of the named subset. Subsets listed in
+// Subsets are returned as is, otherwise if DynamicSubsets is configured a
+// subset selecting instances whose metadata value matches the name is
+// synthesized.
+func (e *ServiceResolverConfigEntry) GetSubset(name string) (ServiceResolverSubset, bool) {
+	if subset, ok := e.Subsets[name]; ok {
+		return subset, true
+	}
+	if e.DynamicSubsets == nil || validateServiceSubset(name) != nil {
+		return ServiceResolverSubset{}, false
+	}
+	return e.DynamicSubsets.subset(name), true
+}
+
 func (e *ServiceResolverConfigEntry) IsDefault() bool {
 	return e.DefaultSubset == "" &&
 		len(e.Subsets) == 0 &&
+		e.DynamicSubsets == nil &&
 		e.Redirect == nil &&
 		len(e.Failover) == 0 &&
 		e.ConnectTimeout == 0 &&
@@ -932,12 +952,15 @@ func (e *ServiceResolverConfigEntry) Validate() error {
 		}
 	}
 
-	isSubset := func(subset string) bool {
-		if len(e.Subsets) > 0 {
-			_, ok := e.Subsets[subset]
-			return ok
+	if e.DynamicSubsets != nil {
+		if err := e.DynamicSubsets.validate(); err != nil {
+			return fmt.Errorf("Bad DynamicSubsets: %v", err)
 		}
-		return false
+	}
+
+	isSubset := func(subset string) bool {
+		_, ok := e.GetSubset(subset)
+		return ok
 	}
 
 	if e.DefaultSubset != "" && !isSubset(e.DefaultSubset) {
@@ -1031,6 +1054,20 @@ func (e *ServiceResolverConfigEntry) Validate() error {
 			return fmt.Errorf("Bad LoadBalancer configuration. "+
 				"LeastRequestConfig specified for incompatible load balancing policy %q", lb.Policy)
 		}
+		if lb.SlowStartConfig != nil {
+			switch lb.Policy {
+			case "", LBPolicyRoundRobin, LBPolicyLeastRequest:
+			default:
+				return fmt.Errorf("Bad LoadBalancer configuration. "+
+					"SlowStartConfig specified for incompatible load balancing policy %q", lb.Policy)
+			}
+			if lb.SlowStartConfig.Window <= 0 {
+				return fmt.Errorf("Bad LoadBalancer configuration: SlowStartConfig.Window must be greater than 0")
+			}
+			if lb.SlowStartConfig.Aggression < 0 {
+				return fmt.Errorf("Bad LoadBalancer configuration: SlowStartConfig.Aggression must be positive")
+			}
+		}
 		if !lb.IsHashBased() && len(lb.HashPolicies) > 0 {
 			return fmt.Errorf("Bad LoadBalancer configuration: "+
 				"HashPolicies specified for non-hash-based Policy: %q", lb.Policy)
@@ -1147,6 +1184,37 @@ type ServiceResolverSubset struct {
 	OnlyPassing bool `json:",omitempty" alias:"only_passing"`
 }
 
+// ServiceResolverDynamicSubsets defines subsets of a service by the values of
+// a service instance metadata key. Each distinct value which is a valid subset
+// name can be used as a subset, selecting the instances with that value.
+type ServiceResolverDynamicSubsets struct {
+	// MetaKey is the service instance metadata key whose values name the
+	// subsets, such as "version".
+	MetaKey string `json:",omitempty" alias:"meta_key"`
+
+	// OnlyPassing is applied to every dynamic subset and behaves like the
+	// field of the same name on ServiceResolverSubset.
+	OnlyPassing bool `json:",omitempty" alias:"only_passing"`
+}
+
+func (d *ServiceResolverDynamicSubsets) validate() error {
+	if d.MetaKey == "" {
+		return fmt.Errorf("MetaKey is required")
+	}
+	if _, err := bexpr.CreateEvaluator(d.subset("v1").Filter, nil); err != nil {
+		return fmt.Errorf("MetaKey %q cannot be used in a filter expression: %v", d.MetaKey, err)
+	}
+	return nil
+}
+
+// subset returns the subset definition for the given metadata value.
+func (d *ServiceResolverDynamicSubsets) subset(value string) ServiceResolverSubset {
+	return ServiceResolverSubset{
+		Filter:      fmt.Sprintf("Service.Meta[%s] == %s", strconv.Quote(d.MetaKey), strconv.Quote(value)),
+		OnlyPassing: d.OnlyPassing,
+	}
+}
+
 type ServiceResolverRedirect struct {
 	// Service is a service to resolve instead of the current service
 	// (optional).
@@ -1219,6 +1287,11 @@ type LoadBalancer struct {
 	// LeastRequestConfig contains configuration for the "least_request" policy type
 	LeastRequestConfig *LeastRequestConfig `json:",omitempty" alias:"least_request_config"`
 
+	// SlowStartConfig contains configuration for gradually increasing the
+	// traffic sent to newly added endpoints. Only supported by the
+	// "round_robin" and "least_request" policy types.
+	SlowStartConfig *SlowStartConfig `json:",omitempty" alias:"slow_start_config"`
+
 	// HashPolicies is a list of hash policies to use for hashing load balancing algorithms.
 	// Hash policies are evaluated individually and combined such that identical lists
 	// result in the same hash.
@@ -1242,6 +1315,20 @@ type LeastRequestConfig struct {
 	ChoiceCount uint32 `json:",omitempty" alias:"choice_count"`
 }
 
+// SlowStartConfig contains configuration for the slow start mode of the
+// "round_robin" and "least_request" policy types. Newly added endpoints receive
+// a share of traffic that grows during Window, which gives them time to warm
+// up their caches and connection pools after a deploy.
+type SlowStartConfig struct {
+	// Window is the duration of the slow start window of a new endpoint.
+	Window time.Duration `json:",omitempty"`
+
+	// Aggression controls the rate at which traffic ramps up during Window.
+	// 1.0 increases traffic linearly, higher values make the ramp up slower
+	// at first and faster at the end. Defaults to 1.0.
+	Aggression float64 `json:",omitempty"`
+}
+
 // HashPolicy defines which attributes will be hashed by hash-based LB algorithms
 type HashPolicy struct {
 	// Field is the attribute type to hash on.
@@ -1449,6 +1536,56 @@ func defaultIfEmpty(val, defaultVal string) string {
 	return defaultVal
 }
 
+// IsDetectableProtocol reports whether protocol is one of the protocols a
+// sidecar proxy can report as detected on inbound traffic.
+func IsDetectableProtocol(protocol string) bool {
+	switch protocol {
+	case "tcp", "http", "http2", "grpc":
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseDetectedUpstreamProtocols parses the value of the
+// MetaDetectedUpstreamProtocols metadata of a connect proxy into the detected
+// protocols keyed by the upstream service name. Malformed entries are skipped.
+func ParseDetectedUpstreamProtocols(value string) map[string]string {
+	protocols := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		name, protocol, ok := strings.Cut(entry, "=")
+		if !ok || name == "" || !IsDetectableProtocol(protocol) {
+			continue
+		}
+		protocols[name] = protocol
+	}
+	return protocols
+}
+
+// FormatDetectedUpstreamProtocols is the inverse of
+// ParseDetectedUpstreamProtocols. Upstreams are sorted by name and those that
+// would make the value longer than allowed for metadata are left out.
+func FormatDetectedUpstreamProtocols(protocols map[string]string) string {
+	names := make([]string, 0, len(protocols))
+	for name := range protocols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		entry := name + "=" + protocols[name]
+		if b.Len() > 0 {
+			entry = "," + entry
+		}
+		if b.Len()+len(entry) > metaValueMaxLength {
+			continue
+		}
+		b.WriteString(entry)
+	}
+	return b.String()
+}
+
 func IsProtocolHTTPLike(protocol string) bool {
 	switch protocol {
 	case "http", "http2", "grpc":
@@ -586,6 +586,47 @@ func TestServiceResolverConfigEntry(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "dynamic subsets without meta key",
+			entry: &ServiceResolverConfigEntry{
+				Kind:           ServiceResolver,
+				Name:           "test",
+				DynamicSubsets: &ServiceResolverDynamicSubsets{},
+			},
+			validateErr: "Bad DynamicSubsets: MetaKey is required",
+		},
+		{
+			name: "default subset from dynamic subsets",
+			entry: &ServiceResolverConfigEntry{
+				Kind:          ServiceResolver,
+				Name:          "test",
+				DefaultSubset: "v2",
+				DynamicSubsets: &ServiceResolverDynamicSubsets{
+					MetaKey: "version",
+				},
+			},
+			check: func(t *testing.T, entry *ServiceResolverConfigEntry) {
+				subset, ok := entry.GetSubset("v2")
+				require.True(t, ok)
+				require.Equal(t, `Service.Meta["version"] == "v2"`, subset.Filter)
+
+				_, ok = entry.GetSubset("Not_A_Subset")
+				require.False(t, ok)
+			},
+		},
+		{
+			name: "failover for dynamic subset",
+			entry: &ServiceResolverConfigEntry{
+				Kind: ServiceResolver,
+				Name: "test",
+				DynamicSubsets: &ServiceResolverDynamicSubsets{
+					MetaKey: "app-version",
+				},
+				Failover: map[string]ServiceResolverFailover{
+					"v2": {ServiceSubset: "v1"},
+				},
+			},
+		},
 		{
 			name: "empty redirect",
 			entry: &ServiceResolverConfigEntry{
@@ -868,6 +909,40 @@ func TestServiceResolverConfigEntry_LoadBalancer(t *testing.T) {
 			},
 			validateErr: `LeastRequestConfig specified for incompatible load balancing policy`,
 		},
+		{
+			name: "good slow start config",
+			entry: &ServiceResolverConfigEntry{
+				Kind: ServiceResolver,
+				Name: "test",
+				LoadBalancer: &LoadBalancer{
+					Policy:          LBPolicyLeastRequest,
+					SlowStartConfig: &SlowStartConfig{Window: 30 * time.Second, Aggression: 2},
+				},
+			},
+		},
+		{
+			name: "bad policy for slow start config",
+			entry: &ServiceResolverConfigEntry{
+				Kind: ServiceResolver,
+				Name: "test",
+				LoadBalancer: &LoadBalancer{
+					Policy:          LBPolicyMaglev,
+					SlowStartConfig: &SlowStartConfig{Window: 30 * time.Second},
+				},
+			},
+			validateErr: `SlowStartConfig specified for incompatible load balancing policy`,
+		},
+		{
+			name: "slow start config without window",
+			entry: &ServiceResolverConfigEntry{
+				Kind: ServiceResolver,
+				Name: "test",
+				LoadBalancer: &LoadBalancer{
+					SlowStartConfig: &SlowStartConfig{Aggression: 2},
+				},
+			},
+			validateErr: `SlowStartConfig.Window must be greater than 0`,
+		},
 		{
 			name: "bad policy for ring hash config",
 			entry: &ServiceResolverConfigEntry{
@@ -1947,3 +2022,14 @@ func TestIsProtocolHTTPLike(t *testing.T) {
 	assert.True(t, IsProtocolHTTPLike("http2"))
 	assert.True(t, IsProtocolHTTPLike("grpc"))
 }
+
+func TestDetectedUpstreamProtocols(t *testing.T) {
+	protocols := ParseDetectedUpstreamProtocols("web=http,api=grpc,bad,db=smtp,=tcp")
+	require.Equal(t, map[string]string{"web": "http", "api": "grpc"}, protocols)
+	require.Equal(t, "api=grpc,web=http", FormatDetectedUpstreamProtocols(protocols))
+	require.Empty(t, ParseDetectedUpstreamProtocols(""))
+
+	// Upstreams that don't fit in a metadata value are left out.
+	long := strings.Repeat("a", metaValueMaxLength-len("=http"))
+	require.Equal(t, long+"=http", FormatDetectedUpstreamProtocols(map[string]string{long: "http", "z": "tcp"}))
+}
@@ -0,0 +1,221 @@
+package structs
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/hashicorp/consul/acl"
+)
+
+const (
+	defaultEgressDestinationPort    = 80
+	defaultEgressDestinationTLSPort = 443
+
+	// defaultEgressReputationCheckTimeout is how long an egress gateway waits
+	// for the reputation check of a request by default.
+	defaultEgressReputationCheckTimeout = 200 * time.Millisecond
+)
+
+// EgressGatewayConfigEntry manages the configuration of an egress gateway
+// with the same name. An egress gateway centralizes the traffic leaving the
+// mesh: services reach the external hostnames it allows by using the gateway
+// as an upstream, rather than linking every service to a terminating gateway.
+type EgressGatewayConfigEntry struct {
+	Kind string
+
+	// Name is the name of the egress gateway service.
+	Name string
+
+	// Destinations are the external hosts reachable through the gateway.
+	// Requests to any other host are rejected.
+	Destinations []EgressGatewayDestination `json:",omitempty"`
+
+	// ReputationCheck configures an external service asked to allow each
+	// request before it leaves the mesh.
+	ReputationCheck *EgressGatewayReputationCheck `json:",omitempty" alias:"reputation_check"`
+
+	Meta               map[string]string `json:",omitempty"`
+	acl.EnterpriseMeta `hcl:",squash" mapstructure:",squash"`
+	RaftIndex
+}
+
+// EgressGatewayDestination is an external host reachable through an egress
+// gateway. Requests are matched to a destination by their host.
+type EgressGatewayDestination struct {
+	// Hostname is the DNS name of the destination.
+	Hostname string
+
+	// Port is the port of the destination. Defaults to 443 when the gateway
+	// originates TLS and 80 otherwise.
+	Port int `json:",omitempty"`
+
+	// TLS makes the gateway originate TLS connections to the destination.
+	TLS *EgressGatewayTLSConfig `json:",omitempty"`
+}
+
+// EgressGatewayTLSConfig configures the TLS connections originated by an
+// egress gateway to a destination.
+type EgressGatewayTLSConfig struct {
+	// CAFile is the path to the CA certificates used to verify the
+	// destination.
+	CAFile string `alias:"ca_file"`
+
+	// CertFile and KeyFile are the optional paths to a client certificate and
+	// its private key presented to the destination.
+	CertFile string `json:",omitempty" alias:"cert_file"`
+	KeyFile  string `json:",omitempty" alias:"key_file"`
+
+	// SNI is the name sent during the TLS handshake. Defaults to the hostname
+	// of the destination.
+	SNI string `json:",omitempty"`
+}
+
+// EgressGatewayReputationCheck configures a gRPC service implementing Envoy's
+// external authorization API, which an egress gateway asks to allow each
+// request based on its source, host and path. It is typically backed by an
+// IP or domain reputation feed.
+type EgressGatewayReputationCheck struct {
+	// Address and Port locate the service.
+	Address string
+	Port    int
+
+	// Timeout is how long the gateway waits for a check. Defaults to 200ms.
+	Timeout time.Duration `json:",omitempty"`
+
+	// FailOpen allows requests when the service can't be reached, rather
+	// than rejecting them.
+	FailOpen bool `json:",omitempty" alias:"fail_open"`
+}
+
+func (e *EgressGatewayConfigEntry) GetKind() string {
+	return EgressGateway
+}
+
+func (e *EgressGatewayConfigEntry) GetName() string {
+	if e == nil {
+		return ""
+	}
+
+	return e.Name
+}
+
+func (e *EgressGatewayConfigEntry) GetMeta() map[string]string {
+	if e == nil {
+		return nil
+	}
+	return e.Meta
+}
+
+func (e *EgressGatewayConfigEntry) Normalize() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+
+	e.Kind = EgressGateway
+	e.EnterpriseMeta.Normalize()
+
+	for i := range e.Destinations {
+		dest := &e.Destinations[i]
+		dest.Hostname = strings.ToLower(dest.Hostname)
+		if dest.Port == 0 {
+			dest.Port = defaultEgressDestinationPort
+			if dest.TLS != nil {
+				dest.Port = defaultEgressDestinationTLSPort
+			}
+		}
+	}
+
+	if e.ReputationCheck != nil && e.ReputationCheck.Timeout == 0 {
+		e.ReputationCheck.Timeout = defaultEgressReputationCheckTimeout
+	}
+
+	return nil
+}
+
+func (e *EgressGatewayConfigEntry) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("Name is required")
+	}
+	if e.Name == WildcardSpecifier {
+		return fmt.Errorf("Egress gateway name cannot be '*'")
+	}
+
+	if err := validateConfigEntryMeta(e.Meta); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, dest := range e.Destinations {
+		if dest.Hostname == "" {
+			return fmt.Errorf("Destination hostname cannot be blank")
+		}
+		if _, ok := dns.IsDomainName(dest.Hostname); !ok || strings.ContainsAny(dest.Hostname, "*:/") {
+			return fmt.Errorf("Destination hostname %q must be a valid DNS hostname", dest.Hostname)
+		}
+
+		// Requests are routed by their host, so each hostname can only be
+		// reached on a single port.
+		if seen[dest.Hostname] {
+			return fmt.Errorf("Destination hostname %q was specified more than once", dest.Hostname)
+		}
+		seen[dest.Hostname] = true
+
+		if dest.Port < 0 || dest.Port > 65535 {
+			return fmt.Errorf("Destination %q has an invalid port: %d", dest.Hostname, dest.Port)
+		}
+
+		if dest.TLS != nil {
+			if dest.TLS.CAFile == "" {
+				return fmt.Errorf("Destination %q must have a CAFile specified for TLS origination", dest.Hostname)
+			}
+			if (dest.TLS.CertFile == "") != (dest.TLS.KeyFile == "") {
+				return fmt.Errorf("Destination %q must have both a CertFile and KeyFile specified for a client certificate", dest.Hostname)
+			}
+		}
+	}
+
+	if check := e.ReputationCheck; check != nil {
+		if check.Address == "" {
+			return fmt.Errorf("ReputationCheck address cannot be blank")
+		}
+		if check.Port <= 0 || check.Port > 65535 {
+			return fmt.Errorf("ReputationCheck has an invalid port: %d", check.Port)
+		}
+		if check.Timeout < 0 {
+			return fmt.Errorf("ReputationCheck timeout must be positive")
+		}
+	}
+
+	return nil
+}
+
+func (e *EgressGatewayConfigEntry) CanRead(authz acl.Authorizer) error {
+	var authzContext acl.AuthorizerContext
+	e.FillAuthzContext(&authzContext)
+	return authz.ToAllowAuthorizer().ServiceReadAllowed(e.Name, &authzContext)
+}
+
+func (e *EgressGatewayConfigEntry) CanWrite(authz acl.Authorizer) error {
+	var authzContext acl.AuthorizerContext
+	e.FillAuthzContext(&authzContext)
+	return authz.ToAllowAuthorizer().MeshWriteAllowed(&authzContext)
+}
+
+func (e *EgressGatewayConfigEntry) GetRaftIndex() *RaftIndex {
+	if e == nil {
+		return &RaftIndex{}
+	}
+
+	return &e.RaftIndex
+}
+
+func (e *EgressGatewayConfigEntry) GetEnterpriseMeta() *acl.EnterpriseMeta {
+	if e == nil {
+		return nil
+	}
+
+	return &e.EnterpriseMeta
+}
@@ -0,0 +1,86 @@
+package structs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEgressGatewayConfigEntry(t *testing.T) {
+	cases := map[string]configEntryTestcase{
+		"normalize: defaults": {
+			entry: &EgressGatewayConfigEntry{
+				Name: "egress",
+				Destinations: []EgressGatewayDestination{
+					{Hostname: "API.example.com"},
+					{Hostname: "secure.example.com", TLS: &EgressGatewayTLSConfig{CAFile: "/etc/ssl/ca.pem"}},
+				},
+				ReputationCheck: &EgressGatewayReputationCheck{
+					Address: "127.0.0.1",
+					Port:    9191,
+				},
+			},
+			expected: &EgressGatewayConfigEntry{
+				Kind: EgressGateway,
+				Name: "egress",
+				Destinations: []EgressGatewayDestination{
+					{Hostname: "api.example.com", Port: 80},
+					{Hostname: "secure.example.com", Port: 443, TLS: &EgressGatewayTLSConfig{CAFile: "/etc/ssl/ca.pem"}},
+				},
+				ReputationCheck: &EgressGatewayReputationCheck{
+					Address: "127.0.0.1",
+					Port:    9191,
+					Timeout: 200 * time.Millisecond,
+				},
+				EnterpriseMeta: *DefaultEnterpriseMetaInDefaultPartition(),
+			},
+		},
+		"validate: missing name": {
+			entry:       &EgressGatewayConfigEntry{},
+			validateErr: "Name is required",
+		},
+		"validate: wildcard hostname": {
+			entry: &EgressGatewayConfigEntry{
+				Name:         "egress",
+				Destinations: []EgressGatewayDestination{{Hostname: "*.example.com"}},
+			},
+			validateErr: `Destination hostname "*.example.com" must be a valid DNS hostname`,
+		},
+		"validate: duplicate hostname": {
+			entry: &EgressGatewayConfigEntry{
+				Name: "egress",
+				Destinations: []EgressGatewayDestination{
+					{Hostname: "api.example.com", Port: 80},
+					{Hostname: "api.example.com", Port: 8080},
+				},
+			},
+			validateErr: `Destination hostname "api.example.com" was specified more than once`,
+		},
+		"validate: tls without ca": {
+			entry: &EgressGatewayConfigEntry{
+				Name: "egress",
+				Destinations: []EgressGatewayDestination{
+					{Hostname: "api.example.com", TLS: &EgressGatewayTLSConfig{SNI: "api.example.com"}},
+				},
+			},
+			validateErr: `Destination "api.example.com" must have a CAFile specified for TLS origination`,
+		},
+		"validate: client cert without key": {
+			entry: &EgressGatewayConfigEntry{
+				Name: "egress",
+				Destinations: []EgressGatewayDestination{
+					{Hostname: "api.example.com", TLS: &EgressGatewayTLSConfig{CAFile: "ca.pem", CertFile: "cert.pem"}},
+				},
+			},
+			validateErr: "must have both a CertFile and KeyFile specified",
+		},
+		"validate: reputation check without address": {
+			entry: &EgressGatewayConfigEntry{
+				Name:            "egress",
+				ReputationCheck: &EgressGatewayReputationCheck{Port: 9191},
+			},
+			validateErr: "ReputationCheck address cannot be blank",
+		},
+	}
+
+	testConfigEntryNormalizeAndValidate(t, cases)
+}
@@ -2,8 +2,10 @@ package structs
 
 import (
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/miekg/dns"
 
@@ -55,6 +57,19 @@ type IngressListener struct {
 	// For "tcp" protocol listeners, only a single service is allowed.
 	// For "http" listeners, multiple services can be declared.
 	Services []IngressService
+
+	// SecurityHeaders configures security headers that are added to the
+	// responses of every service on this listener. Only allowed on layer 7
+	// protocols.
+	SecurityHeaders *HTTPSecurityHeaders `json:",omitempty" alias:"security_headers"`
+
+	// SocketPath binds the listener to a Unix domain socket instead of Port,
+	// for clients sharing the host of the gateway. Port still identifies the
+	// listener.
+	SocketPath string `json:",omitempty" alias:"socket_path"`
+
+	// SocketMode is the octal permissions of the socket, such as 0600.
+	SocketMode string `json:",omitempty" alias:"socket_mode"`
 }
 
 type IngressService struct {
@@ -90,10 +105,183 @@ type IngressService struct {
 	RequestHeaders  *HTTPHeaderModifiers `json:",omitempty" alias:"request_headers"`
 	ResponseHeaders *HTTPHeaderModifiers `json:",omitempty" alias:"response_headers"`
 
+	// SessionAffinity configures Envoy to generate a cookie on the first
+	// request from a client and to route subsequent requests carrying that
+	// cookie to the same upstream endpoint. Only allowed on layer 7 protocols.
+	SessionAffinity *IngressSessionAffinity `json:",omitempty" alias:"session_affinity"`
+
+	// CORS configures Envoy to answer preflight requests and to add CORS
+	// headers to responses for browser clients. Only allowed on layer 7
+	// protocols.
+	CORS *IngressCORSPolicy `json:",omitempty" alias:"cors"`
+
+	// SecurityHeaders overrides the security headers of the listener for this
+	// service. Only allowed on layer 7 protocols.
+	SecurityHeaders *HTTPSecurityHeaders `json:",omitempty" alias:"security_headers"`
+
+	// MaxRequestBodyBytes is the maximum size of the body of a request to this
+	// service. Larger requests are rejected with a 413 status. Only allowed on
+	// layer 7 protocols.
+	MaxRequestBodyBytes int `json:",omitempty" alias:"max_request_body_bytes"`
+
 	Meta               map[string]string `json:",omitempty"`
 	acl.EnterpriseMeta `hcl:",squash" mapstructure:",squash"`
 }
 
+// IngressSessionAffinity configures sticky sessions for an ingress service
+// using a cookie generated by Envoy. The cookie is hashed by the same
+// machinery as service-resolver hash policies. Upstream clusters that don't
+// already use a hash-based load balancing policy ("ring_hash" or "maglev")
+// are switched to "ring_hash", as Envoy ignores hash policies otherwise.
+type IngressSessionAffinity struct {
+	// CookieName is the name of the cookie that Envoy generates.
+	CookieName string `json:",omitempty" alias:"cookie_name"`
+
+	// TTL for the generated cookie. When zero a session cookie with no
+	// expiration is generated.
+	TTL time.Duration `json:",omitempty"`
+
+	// Path to set for the generated cookie.
+	Path string `json:",omitempty"`
+}
+
+// HashPolicy returns the cookie hash policy implementing the session affinity.
+func (a *IngressSessionAffinity) HashPolicy() HashPolicy {
+	return HashPolicy{
+		Field:      HashPolicyCookie,
+		FieldValue: a.CookieName,
+		CookieConfig: &CookieConfig{
+			Session: a.TTL == 0,
+			TTL:     a.TTL,
+			Path:    a.Path,
+		},
+		Terminal: true,
+	}
+}
+
+func (a *IngressSessionAffinity) Validate(protocol string) error {
+	if a == nil {
+		return nil
+	}
+	if !IsProtocolHTTPLike(protocol) {
+		return fmt.Errorf("only valid for http, http2 and grpc protocols")
+	}
+	if a.CookieName == "" {
+		return fmt.Errorf("CookieName is required")
+	}
+	if a.TTL < 0 {
+		return fmt.Errorf("TTL must be non-negative")
+	}
+	return nil
+}
+
+// IngressCORSPolicy is the cross-origin resource sharing policy of an
+// ingress service.
+type IngressCORSPolicy struct {
+	// AllowOrigins is a list of origins that are allowed to make requests,
+	// matched exactly. "*" allows any origin.
+	AllowOrigins []string `json:",omitempty" alias:"allow_origins"`
+
+	// AllowOriginRegexes is a list of RE2 regular expressions matching the
+	// origins that are allowed to make requests.
+	AllowOriginRegexes []string `json:",omitempty" alias:"allow_origin_regexes"`
+
+	// AllowMethods is the list of methods returned in the
+	// Access-Control-Allow-Methods header.
+	AllowMethods []string `json:",omitempty" alias:"allow_methods"`
+
+	// AllowHeaders is the list of headers returned in the
+	// Access-Control-Allow-Headers header.
+	AllowHeaders []string `json:",omitempty" alias:"allow_headers"`
+
+	// ExposeHeaders is the list of headers returned in the
+	// Access-Control-Expose-Headers header.
+	ExposeHeaders []string `json:",omitempty" alias:"expose_headers"`
+
+	// MaxAge is how long the results of a preflight request can be cached.
+	MaxAge time.Duration `json:",omitempty" alias:"max_age"`
+
+	// AllowCredentials indicates whether the response to the request can be
+	// exposed when the credentials flag is true.
+	AllowCredentials bool `json:",omitempty" alias:"allow_credentials"`
+}
+
+func (p *IngressCORSPolicy) Validate(protocol string) error {
+	if p == nil {
+		return nil
+	}
+	if !IsProtocolHTTPLike(protocol) {
+		return fmt.Errorf("only valid for http, http2 and grpc protocols")
+	}
+	if len(p.AllowOrigins) == 0 && len(p.AllowOriginRegexes) == 0 {
+		return fmt.Errorf("at least one of AllowOrigins or AllowOriginRegexes is required")
+	}
+	for _, r := range p.AllowOriginRegexes {
+		if _, err := regexp.Compile(r); err != nil {
+			return fmt.Errorf("AllowOriginRegexes contains an invalid regex %q: %v", r, err)
+		}
+	}
+	if p.MaxAge < 0 {
+		return fmt.Errorf("MaxAge must be non-negative")
+	}
+	return nil
+}
+
+// HTTPSecurityHeaders configures standard security headers that are added to
+// every response served by an ingress listener.
+type HTTPSecurityHeaders struct {
+	// Disabled turns off the security headers inherited from the listener.
+	// Only allowed on a service.
+	Disabled bool `json:",omitempty"`
+
+	// HSTSMaxAge, when positive, adds a Strict-Transport-Security header with
+	// the given max-age.
+	HSTSMaxAge time.Duration `json:",omitempty" alias:"hsts_max_age"`
+
+	// HSTSIncludeSubdomains adds the includeSubDomains directive to the
+	// Strict-Transport-Security header.
+	HSTSIncludeSubdomains bool `json:",omitempty" alias:"hsts_include_subdomains"`
+
+	// ContentTypeNosniff adds the "X-Content-Type-Options: nosniff" header.
+	ContentTypeNosniff bool `json:",omitempty" alias:"content_type_nosniff"`
+
+	// FrameOptions is the value of the X-Frame-Options header, such as "DENY"
+	// or "SAMEORIGIN".
+	FrameOptions string `json:",omitempty" alias:"frame_options"`
+
+	// ContentSecurityPolicy is the value of the Content-Security-Policy header.
+	ContentSecurityPolicy string `json:",omitempty" alias:"content_security_policy"`
+}
+
+func (h *HTTPSecurityHeaders) Validate(protocol string) error {
+	if h == nil {
+		return nil
+	}
+	if !IsProtocolHTTPLike(protocol) {
+		return fmt.Errorf("only valid for http, http2 and grpc protocols")
+	}
+	if h.HSTSMaxAge < 0 {
+		return fmt.Errorf("HSTSMaxAge must be non-negative")
+	}
+	if h.HSTSIncludeSubdomains && h.HSTSMaxAge == 0 {
+		return fmt.Errorf("HSTSIncludeSubdomains requires HSTSMaxAge")
+	}
+	return nil
+}
+
+// ResolveSecurityHeaders returns the security headers to add to the responses
+// of svc, with the service configuration replacing the listener's.
+func (l *IngressListener) ResolveSecurityHeaders(svc *IngressService) *HTTPSecurityHeaders {
+	headers := l.SecurityHeaders
+	if svc.SecurityHeaders != nil {
+		headers = svc.SecurityHeaders
+	}
+	if headers == nil || headers.Disabled {
+		return nil
+	}
+	return headers
+}
+
 type GatewayTLSConfig struct {
 	// Indicates that TLS should be enabled for this gateway or listener
 	Enabled bool
@@ -259,6 +447,7 @@ func (e *IngressGatewayConfigEntry) Validate() error {
 		"grpc":  true,
 	}
 	declaredPorts := make(map[int]bool)
+	declaredSockets := make(map[string]bool)
 
 	for _, listener := range e.Listeners {
 		if _, ok := declaredPorts[listener.Port]; ok {
@@ -266,6 +455,21 @@ func (e *IngressGatewayConfigEntry) Validate() error {
 		}
 		declaredPorts[listener.Port] = true
 
+		if listener.SocketPath != "" {
+			if declaredSockets[listener.SocketPath] {
+				return fmt.Errorf("socket path %q declared on two listeners", listener.SocketPath)
+			}
+			declaredSockets[listener.SocketPath] = true
+		}
+		if listener.SocketMode != "" {
+			if listener.SocketPath == "" {
+				return fmt.Errorf("SocketMode requires a SocketPath (listener on port %d)", listener.Port)
+			}
+			if err := ValidateSocketMode(listener.SocketMode); err != nil {
+				return fmt.Errorf("%s (listener on port %d)", err, listener.Port)
+			}
+		}
+
 		if _, ok := validProtocols[listener.Protocol]; !ok {
 			return fmt.Errorf("protocol must be 'tcp', 'http', 'http2', or 'grpc'. '%s' is an unsupported protocol", listener.Protocol)
 		}
@@ -286,6 +490,13 @@ func (e *IngressGatewayConfigEntry) Validate() error {
 			}
 		}
 
+		if err := listener.SecurityHeaders.Validate(listener.Protocol); err != nil {
+			return fmt.Errorf("security headers %s (listener on port %d)", err, listener.Port)
+		}
+		if listener.SecurityHeaders != nil && listener.SecurityHeaders.Disabled {
+			return fmt.Errorf("security headers Disabled is only valid on a service (listener on port %d)", listener.Port)
+		}
+
 		declaredHosts := make(map[string]bool)
 		serviceNames := make(map[ServiceID]struct{})
 		for _, s := range listener.Services {
@@ -296,6 +507,21 @@ func (e *IngressGatewayConfigEntry) Validate() error {
 			if err := s.ResponseHeaders.Validate(listener.Protocol); err != nil {
 				return fmt.Errorf("response headers %s (service %q on listener on port %d)", err, sn.String(), listener.Port)
 			}
+			if err := s.SessionAffinity.Validate(listener.Protocol); err != nil {
+				return fmt.Errorf("session affinity %s (service %q on listener on port %d)", err, sn.String(), listener.Port)
+			}
+			if err := s.CORS.Validate(listener.Protocol); err != nil {
+				return fmt.Errorf("CORS %s (service %q on listener on port %d)", err, sn.String(), listener.Port)
+			}
+			if err := s.SecurityHeaders.Validate(listener.Protocol); err != nil {
+				return fmt.Errorf("security headers %s (service %q on listener on port %d)", err, sn.String(), listener.Port)
+			}
+			if s.MaxRequestBodyBytes < 0 {
+				return fmt.Errorf("MaxRequestBodyBytes must be positive (service %q on listener on port %d)", sn.String(), listener.Port)
+			}
+			if s.MaxRequestBodyBytes > 0 && !IsProtocolHTTPLike(listener.Protocol) {
+				return fmt.Errorf("MaxRequestBodyBytes only valid for http, http2 and grpc protocols (service %q on listener on port %d)", sn.String(), listener.Port)
+			}
 
 			if listener.Protocol == "tcp" {
 				if s.Name == WildcardSpecifier {
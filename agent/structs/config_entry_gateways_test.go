@@ -2,6 +2,7 @@ package structs
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -99,6 +100,75 @@ func TestIngressGatewayConfigEntry(t *testing.T) {
 			},
 			validateErr: "port 1111 declared on two listeners",
 		},
+		"socket path conflict": {
+			entry: &IngressGatewayConfigEntry{
+				Kind: "ingress-gateway",
+				Name: "ingress-web",
+				Listeners: []IngressListener{
+					{
+						Port:       1111,
+						Protocol:   "tcp",
+						SocketPath: "/tmp/ingress.sock",
+						SocketMode: "0660",
+						Services: []IngressService{
+							{
+								Name: "mysql",
+							},
+						},
+					},
+					{
+						Port:       2222,
+						Protocol:   "tcp",
+						SocketPath: "/tmp/ingress.sock",
+						Services: []IngressService{
+							{
+								Name: "postgres",
+							},
+						},
+					},
+				},
+			},
+			validateErr: `socket path "/tmp/ingress.sock" declared on two listeners`,
+		},
+		"socket mode without socket path": {
+			entry: &IngressGatewayConfigEntry{
+				Kind: "ingress-gateway",
+				Name: "ingress-web",
+				Listeners: []IngressListener{
+					{
+						Port:       1111,
+						Protocol:   "tcp",
+						SocketMode: "0660",
+						Services: []IngressService{
+							{
+								Name: "mysql",
+							},
+						},
+					},
+				},
+			},
+			validateErr: "SocketMode requires a SocketPath (listener on port 1111)",
+		},
+		"invalid socket mode": {
+			entry: &IngressGatewayConfigEntry{
+				Kind: "ingress-gateway",
+				Name: "ingress-web",
+				Listeners: []IngressListener{
+					{
+						Port:       1111,
+						Protocol:   "tcp",
+						SocketPath: "/tmp/ingress.sock",
+						SocketMode: "660",
+						Services: []IngressService{
+							{
+								Name: "mysql",
+							},
+						},
+					},
+				},
+			},
+			validateErr: "invalid socket mode",
+		},
 		"http features: wildcard": {
 			entry: &IngressGatewayConfigEntry{
 				Kind: "ingress-gateway",
@@ -501,6 +571,158 @@ func TestIngressGatewayConfigEntry(t *testing.T) {
 			},
 			validateErr: "response headers only valid for http",
 		},
+		"session affinity not allowed for non-http protocol": {
+			entry: &IngressGatewayConfigEntry{
+				Kind: "ingress-gateway",
+				Name: "ingress-web",
+				Listeners: []IngressListener{
+					{
+						Port:     1111,
+						Protocol: "tcp",
+						Services: []IngressService{
+							{
+								Name:            "db",
+								SessionAffinity: &IngressSessionAffinity{CookieName: "sticky"},
+							},
+						},
+					},
+				},
+			},
+			validateErr: "session affinity only valid for http",
+		},
+		"session affinity requires a cookie name": {
+			entry: &IngressGatewayConfigEntry{
+				Kind: "ingress-gateway",
+				Name: "ingress-web",
+				Listeners: []IngressListener{
+					{
+						Port:     1111,
+						Protocol: "http",
+						Services: []IngressService{
+							{
+								Name:            "web",
+								SessionAffinity: &IngressSessionAffinity{TTL: time.Hour},
+							},
+						},
+					},
+				},
+			},
+			validateErr: "session affinity CookieName is required",
+		},
+		"CORS requires an origin": {
+			entry: &IngressGatewayConfigEntry{
+				Kind: "ingress-gateway",
+				Name: "ingress-web",
+				Listeners: []IngressListener{
+					{
+						Port:     1111,
+						Protocol: "http",
+						Services: []IngressService{
+							{
+								Name: "web",
+								CORS: &IngressCORSPolicy{AllowMethods: []string{"GET"}},
+							},
+						},
+					},
+				},
+			},
+			validateErr: "CORS at least one of AllowOrigins or AllowOriginRegexes is required",
+		},
+		"CORS invalid origin regex": {
+			entry: &IngressGatewayConfigEntry{
+				Kind: "ingress-gateway",
+				Name: "ingress-web",
+				Listeners: []IngressListener{
+					{
+						Port:     1111,
+						Protocol: "http",
+						Services: []IngressService{
+							{
+								Name: "web",
+								CORS: &IngressCORSPolicy{AllowOriginRegexes: []string{"https://(.*"}},
+							},
+						},
+					},
+				},
+			},
+			validateErr: "CORS AllowOriginRegexes contains an invalid regex",
+		},
+		"security headers not allowed for non-http protocol": {
+			entry: &IngressGatewayConfigEntry{
+				Kind: "ingress-gateway",
+				Name: "ingress-web",
+				Listeners: []IngressListener{
+					{
+						Port:            1111,
+						Protocol:        "tcp",
+						SecurityHeaders: &HTTPSecurityHeaders{ContentTypeNosniff: true},
+						Services: []IngressService{
+							{
+								Name: "db",
+							},
+						},
+					},
+				},
+			},
+			validateErr: "security headers only valid for http",
+		},
+		"security headers disabled not allowed on listener": {
+			entry: &IngressGatewayConfigEntry{
+				Kind: "ingress-gateway",
+				Name: "ingress-web",
+				Listeners: []IngressListener{
+					{
+						Port:            1111,
+						Protocol:        "http",
+						SecurityHeaders: &HTTPSecurityHeaders{Disabled: true},
+						Services: []IngressService{
+							{
+								Name: "web",
+							},
+						},
+					},
+				},
+			},
+			validateErr: "security headers Disabled is only valid on a service",
+		},
+		"security headers include subdomains requires max age": {
+			entry: &IngressGatewayConfigEntry{
+				Kind: "ingress-gateway",
+				Name: "ingress-web",
+				Listeners: []IngressListener{
+					{
+						Port:     1111,
+						Protocol: "http",
+						Services: []IngressService{
+							{
+								Name:            "web",
+								SecurityHeaders: &HTTPSecurityHeaders{HSTSIncludeSubdomains: true},
+							},
+						},
+					},
+				},
+			},
+			validateErr: "security headers HSTSIncludeSubdomains requires HSTSMaxAge",
+		},
+		"max request body bytes not allowed for non-http protocol": {
+			entry: &IngressGatewayConfigEntry{
+				Kind: "ingress-gateway",
+				Name: "ingress-web",
+				Listeners: []IngressListener{
+					{
+						Port:     1111,
+						Protocol: "tcp",
+						Services: []IngressService{
+							{
+								Name:                "db",
+								MaxRequestBodyBytes: 1024,
+							},
+						},
+					},
+				},
+			},
+			validateErr: "MaxRequestBodyBytes only valid for http",
+		},
 		"duplicate services not allowed": {
 			entry: &IngressGatewayConfigEntry{
 				Kind: "ingress-gateway",
@@ -1123,3 +1345,20 @@ func TestGatewayService_Addresses(t *testing.T) {
 		})
 	}
 }
+
+func TestIngressListener_ResolveSecurityHeaders(t *testing.T) {
+	listenerHeaders := &HTTPSecurityHeaders{ContentTypeNosniff: true}
+	serviceHeaders := &HTTPSecurityHeaders{FrameOptions: "DENY"}
+	listener := IngressListener{SecurityHeaders: listenerHeaders}
+
+	require.Equal(t, listenerHeaders, listener.ResolveSecurityHeaders(&IngressService{Name: "web"}))
+	require.Equal(t, serviceHeaders, listener.ResolveSecurityHeaders(&IngressService{
+		Name:            "web",
+		SecurityHeaders: serviceHeaders,
+	}))
+	require.Nil(t, listener.ResolveSecurityHeaders(&IngressService{
+		Name:            "web",
+		SecurityHeaders: &HTTPSecurityHeaders{Disabled: true},
+	}))
+	require.Nil(t, (&IngressListener{}).ResolveSecurityHeaders(&IngressService{Name: "web"}))
+}
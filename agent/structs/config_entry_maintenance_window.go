@@ -0,0 +1,265 @@
+package structs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/lib"
+	"github.com/hashicorp/consul/types"
+)
+
+const defaultMaintenanceWindowReason = "Scheduled maintenance window is in effect, " +
+	"but no reason was provided. This is a default message."
+
+// MaintenanceWindowConfigEntry schedules maintenance for a node or one of its
+// service instances. While the window is in effect the leader registers a
+// critical health check against the node or service, which takes it out of
+// DNS and service discovery results exactly as maintenance mode does, and
+// removes the check once the window ends.
+type MaintenanceWindowConfigEntry struct {
+	Kind string
+
+	// Name identifies the window.
+	Name string
+
+	// Datacenter is the datacenter of Node. Config entries are replicated to
+	// all the datacenters, only the leader of this one applies the window.
+	Datacenter string
+
+	// Node is the node the maintenance applies to.
+	Node string
+
+	// ServiceID restricts the maintenance to one service instance registered
+	// on Node. The whole node is put in maintenance when it is empty.
+	ServiceID string `json:",omitempty" alias:"service_id"`
+
+	// Service is the name of the service of the instance with ServiceID. It
+	// is required with ServiceID so that ACLs can be enforced on the service.
+	Service string `json:",omitempty"`
+
+	// Reason is recorded in the notes of the maintenance check.
+	Reason string `json:",omitempty"`
+
+	// Start and End bound the first occurrence of the window.
+	Start time.Time
+	End   time.Time
+
+	// Repeat is the time between the starts of two occurrences of the
+	// window. The window only occurs once when it is zero.
+	Repeat time.Duration `json:",omitempty"`
+
+	Meta               map[string]string `json:",omitempty"`
+	acl.EnterpriseMeta `hcl:",squash" mapstructure:",squash"`
+	RaftIndex
+}
+
+// IsMaintenanceWindowCheckID returns whether id is the ID of a check managed
+// by the leader on behalf of a maintenance-window config entry.
+func IsMaintenanceWindowCheckID(id types.CheckID) bool {
+	return strings.HasPrefix(string(id), MaintenanceWindowPrefix)
+}
+
+func (e *MaintenanceWindowConfigEntry) GetKind() string {
+	return MaintenanceWindow
+}
+
+func (e *MaintenanceWindowConfigEntry) GetName() string {
+	if e == nil {
+		return ""
+	}
+
+	return e.Name
+}
+
+func (e *MaintenanceWindowConfigEntry) GetMeta() map[string]string {
+	if e == nil {
+		return nil
+	}
+	return e.Meta
+}
+
+func (e *MaintenanceWindowConfigEntry) Normalize() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+
+	e.Kind = MaintenanceWindow
+	e.EnterpriseMeta.Normalize()
+
+	return nil
+}
+
+func (e *MaintenanceWindowConfigEntry) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("Name is required")
+	}
+	if e.Datacenter == "" {
+		return fmt.Errorf("Datacenter is required")
+	}
+	if e.Node == "" {
+		return fmt.Errorf("Node is required")
+	}
+	if e.ServiceID != "" && e.Service == "" {
+		return fmt.Errorf("Service is required when ServiceID is set")
+	}
+	if e.ServiceID == "" && e.Service != "" {
+		return fmt.Errorf("Service can only be set with ServiceID")
+	}
+
+	if e.Start.IsZero() {
+		return fmt.Errorf("Start is required")
+	}
+	if !e.End.After(e.Start) {
+		return fmt.Errorf("End must be after Start")
+	}
+	if e.Repeat < 0 {
+		return fmt.Errorf("Repeat must be positive")
+	}
+	if e.Repeat > 0 && e.Repeat < e.End.Sub(e.Start) {
+		return fmt.Errorf("Repeat must be longer than the window, occurrences can't overlap")
+	}
+
+	if err := validateConfigEntryMeta(e.Meta); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ActiveAt reports whether an occurrence of the window is in effect at t.
+func (e *MaintenanceWindowConfigEntry) ActiveAt(t time.Time) bool {
+	if t.Before(e.Start) {
+		return false
+	}
+	offset := t.Sub(e.Start)
+	if e.Repeat > 0 {
+		offset %= e.Repeat
+	}
+	return offset < e.End.Sub(e.Start)
+}
+
+// NextTransition returns the first time after t at which an occurrence of the
+// window starts or ends, or the zero time if the window has ended for good.
+func (e *MaintenanceWindowConfigEntry) NextTransition(t time.Time) time.Time {
+	if t.Before(e.Start) {
+		return e.Start
+	}
+	offset := t.Sub(e.Start)
+	if e.Repeat > 0 {
+		offset %= e.Repeat
+	}
+	occurrence := t.Add(-offset)
+	switch {
+	case offset < e.End.Sub(e.Start):
+		return occurrence.Add(e.End.Sub(e.Start))
+	case e.Repeat > 0:
+		return occurrence.Add(e.Repeat)
+	default:
+		return time.Time{}
+	}
+}
+
+// HealthCheck returns the critical check the leader registers while the
+// window is in effect.
+func (e *MaintenanceWindowConfigEntry) HealthCheck() *HealthCheck {
+	reason := e.Reason
+	if reason == "" {
+		reason = defaultMaintenanceWindowReason
+	}
+
+	check := &HealthCheck{
+		Node:           e.Node,
+		CheckID:        types.CheckID(MaintenanceWindowPrefix + e.Name),
+		Name:           "Scheduled Maintenance",
+		Notes:          reason,
+		Status:         api.HealthCritical,
+		Type:           "maintenance",
+		EnterpriseMeta: *NodeEnterpriseMetaInPartition(e.PartitionOrDefault()),
+	}
+	if e.ServiceID != "" {
+		check.ServiceID = e.ServiceID
+		check.ServiceName = e.Service
+		check.EnterpriseMeta = e.EnterpriseMeta
+	}
+	return check
+}
+
+func (e *MaintenanceWindowConfigEntry) CanRead(authz acl.Authorizer) error {
+	var authzContext acl.AuthorizerContext
+	e.FillAuthzContext(&authzContext)
+	if err := authz.ToAllowAuthorizer().NodeReadAllowed(e.Node, &authzContext); err != nil {
+		return err
+	}
+	if e.ServiceID != "" {
+		return authz.ToAllowAuthorizer().ServiceReadAllowed(e.Service, &authzContext)
+	}
+	return nil
+}
+
+func (e *MaintenanceWindowConfigEntry) CanWrite(authz acl.Authorizer) error {
+	var authzContext acl.AuthorizerContext
+	e.FillAuthzContext(&authzContext)
+	if err := authz.ToAllowAuthorizer().NodeWriteAllowed(e.Node, &authzContext); err != nil {
+		return err
+	}
+	if e.ServiceID != "" {
+		return authz.ToAllowAuthorizer().ServiceWriteAllowed(e.Service, &authzContext)
+	}
+	return nil
+}
+
+func (e *MaintenanceWindowConfigEntry) GetRaftIndex() *RaftIndex {
+	if e == nil {
+		return &RaftIndex{}
+	}
+
+	return &e.RaftIndex
+}
+
+func (e *MaintenanceWindowConfigEntry) GetEnterpriseMeta() *acl.EnterpriseMeta {
+	if e == nil {
+		return nil
+	}
+
+	return &e.EnterpriseMeta
+}
+
+func (e *MaintenanceWindowConfigEntry) MarshalJSON() ([]byte, error) {
+	type Alias MaintenanceWindowConfigEntry
+	exported := &struct {
+		Repeat string `json:",omitempty"`
+		*Alias
+	}{
+		Repeat: e.Repeat.String(),
+		Alias:  (*Alias)(e),
+	}
+	if e.Repeat == 0 {
+		exported.Repeat = ""
+	}
+
+	return json.Marshal(exported)
+}
+
+func (e *MaintenanceWindowConfigEntry) UnmarshalJSON(data []byte) error {
+	type Alias MaintenanceWindowConfigEntry
+	aux := &struct {
+		Repeat string
+		*Alias
+	}{
+		Alias: (*Alias)(e),
+	}
+	if err := lib.UnmarshalJSON(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	if aux.Repeat != "" {
+		if e.Repeat, err = time.ParseDuration(aux.Repeat); err != nil {
+			return err
+		}
+	}
+	return nil
+}
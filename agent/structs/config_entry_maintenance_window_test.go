@@ -0,0 +1,183 @@
+package structs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/acl"
+)
+
+func TestMaintenanceWindowConfigEntry(t *testing.T) {
+	start := time.Date(2022, 6, 1, 2, 0, 0, 0, time.UTC)
+
+	cases := map[string]configEntryTestcase{
+		"normalize: kind": {
+			entry: &MaintenanceWindowConfigEntry{
+				Name:       "patching",
+				Datacenter: "dc1",
+				Node:       "node1",
+				Start:      start,
+				End:        start.Add(time.Hour),
+			},
+			expected: &MaintenanceWindowConfigEntry{
+				Kind:           MaintenanceWindow,
+				Name:           "patching",
+				Datacenter:     "dc1",
+				Node:           "node1",
+				Start:          start,
+				End:            start.Add(time.Hour),
+				EnterpriseMeta: *DefaultEnterpriseMetaInDefaultPartition(),
+			},
+		},
+		"validate: missing datacenter": {
+			entry: &MaintenanceWindowConfigEntry{
+				Name:  "patching",
+				Node:  "node1",
+				Start: start,
+				End:   start.Add(time.Hour),
+			},
+			validateErr: "Datacenter is required",
+		},
+		"validate: missing node": {
+			entry: &MaintenanceWindowConfigEntry{
+				Name:       "patching",
+				Datacenter: "dc1",
+				Start:      start,
+				End:        start.Add(time.Hour),
+			},
+			validateErr: "Node is required",
+		},
+		"validate: service ID without service": {
+			entry: &MaintenanceWindowConfigEntry{
+				Name:       "patching",
+				Datacenter: "dc1",
+				Node:       "node1",
+				ServiceID:  "web-1",
+				Start:      start,
+				End:        start.Add(time.Hour),
+			},
+			validateErr: "Service is required when ServiceID is set",
+		},
+		"validate: missing start": {
+			entry: &MaintenanceWindowConfigEntry{
+				Name:       "patching",
+				Datacenter: "dc1",
+				Node:       "node1",
+				End:        start,
+			},
+			validateErr: "Start is required",
+		},
+		"validate: end before start": {
+			entry: &MaintenanceWindowConfigEntry{
+				Name:       "patching",
+				Datacenter: "dc1",
+				Node:       "node1",
+				Start:      start,
+				End:        start.Add(-time.Hour),
+			},
+			validateErr: "End must be after Start",
+		},
+		"validate: overlapping occurrences": {
+			entry: &MaintenanceWindowConfigEntry{
+				Name:       "patching",
+				Datacenter: "dc1",
+				Node:       "node1",
+				Start:      start,
+				End:        start.Add(2 * time.Hour),
+				Repeat:     time.Hour,
+			},
+			validateErr: "occurrences can't overlap",
+		},
+	}
+
+	testConfigEntryNormalizeAndValidate(t, cases)
+}
+
+func TestMaintenanceWindowConfigEntry_ActiveAt(t *testing.T) {
+	start := time.Date(2022, 6, 1, 2, 0, 0, 0, time.UTC)
+
+	once := &MaintenanceWindowConfigEntry{Start: start, End: start.Add(time.Hour)}
+	require.False(t, once.ActiveAt(start.Add(-time.Second)))
+	require.True(t, once.ActiveAt(start))
+	require.True(t, once.ActiveAt(start.Add(59*time.Minute)))
+	require.False(t, once.ActiveAt(start.Add(time.Hour)))
+	require.False(t, once.ActiveAt(start.Add(24*time.Hour)))
+
+	daily := &MaintenanceWindowConfigEntry{Start: start, End: start.Add(time.Hour), Repeat: 24 * time.Hour}
+	require.False(t, daily.ActiveAt(start.Add(-time.Second)))
+	require.True(t, daily.ActiveAt(start.Add(24*time.Hour+30*time.Minute)))
+	require.False(t, daily.ActiveAt(start.Add(25*time.Hour)))
+	require.True(t, daily.ActiveAt(start.Add(30*24*time.Hour)))
+}
+
+func TestMaintenanceWindowConfigEntry_NextTransition(t *testing.T) {
+	start := time.Date(2022, 6, 1, 2, 0, 0, 0, time.UTC)
+
+	once := &MaintenanceWindowConfigEntry{Start: start, End: start.Add(time.Hour)}
+	require.Equal(t, start, once.NextTransition(start.Add(-time.Hour)))
+	require.Equal(t, start.Add(time.Hour), once.NextTransition(start))
+	require.Equal(t, start.Add(time.Hour), once.NextTransition(start.Add(59*time.Minute)))
+	require.True(t, once.NextTransition(start.Add(time.Hour)).IsZero())
+
+	daily := &MaintenanceWindowConfigEntry{Start: start, End: start.Add(time.Hour), Repeat: 24 * time.Hour}
+	require.Equal(t, start.Add(24*time.Hour), daily.NextTransition(start.Add(time.Hour)))
+	require.Equal(t, start.Add(25*time.Hour), daily.NextTransition(start.Add(24*time.Hour+30*time.Minute)))
+	require.Equal(t, start.Add(48*time.Hour), daily.NextTransition(start.Add(30*time.Hour)))
+}
+
+func TestMaintenanceWindowConfigEntry_HealthCheck(t *testing.T) {
+	entry := &MaintenanceWindowConfigEntry{
+		Name:      "patching",
+		Node:      "node1",
+		ServiceID: "web-1",
+		Service:   "web",
+	}
+
+	check := entry.HealthCheck()
+	require.Equal(t, "_maintenance_window:patching", string(check.CheckID))
+	require.True(t, IsMaintenanceWindowCheckID(check.CheckID))
+	require.Equal(t, "web-1", check.ServiceID)
+	require.Equal(t, "web", check.ServiceName)
+	require.Equal(t, defaultMaintenanceWindowReason, check.Notes)
+	require.Equal(t, "critical", check.Status)
+
+	entry.ServiceID = ""
+	entry.Service = ""
+	entry.Reason = "kernel upgrade"
+	check = entry.HealthCheck()
+	require.Empty(t, check.ServiceID)
+	require.Equal(t, "kernel upgrade", check.Notes)
+}
+
+func TestMaintenanceWindowConfigEntry_ACLs(t *testing.T) {
+	newAuthz := func(t *testing.T, src string) acl.Authorizer {
+		policy, err := acl.NewPolicyFromSource(src, acl.SyntaxCurrent, nil, nil)
+		require.NoError(t, err)
+
+		authorizer, err := acl.NewPolicyAuthorizerWithDefaults(acl.DenyAll(), []*acl.Policy{policy}, nil)
+		require.NoError(t, err)
+		return authorizer
+	}
+
+	nodeEntry := &MaintenanceWindowConfigEntry{Name: "patching", Node: "node1"}
+	serviceEntry := &MaintenanceWindowConfigEntry{Name: "migration", Node: "node1", ServiceID: "web-1", Service: "web"}
+
+	nodeWrite := newAuthz(t, `node "node1" { policy = "write" }`)
+	require.NoError(t, nodeEntry.CanRead(nodeWrite))
+	require.NoError(t, nodeEntry.CanWrite(nodeWrite))
+	require.True(t, acl.IsErrPermissionDenied(serviceEntry.CanRead(nodeWrite)))
+	require.True(t, acl.IsErrPermissionDenied(serviceEntry.CanWrite(nodeWrite)))
+
+	serviceRead := newAuthz(t, `node "node1" { policy = "write" } service "web" { policy = "read" }`)
+	require.NoError(t, serviceEntry.CanRead(serviceRead))
+	require.True(t, acl.IsErrPermissionDenied(serviceEntry.CanWrite(serviceRead)))
+
+	serviceWrite := newAuthz(t, `node "node1" { policy = "write" } service "web" { policy = "write" }`)
+	require.NoError(t, serviceEntry.CanWrite(serviceWrite))
+
+	nodeRead := newAuthz(t, `node "node1" { policy = "read" } service "web" { policy = "write" }`)
+	require.NoError(t, serviceEntry.CanRead(nodeRead))
+	require.True(t, acl.IsErrPermissionDenied(serviceEntry.CanWrite(nodeRead)))
+}
@@ -3,8 +3,14 @@ package structs
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/http/httpguts"
 
 	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/lib/stringslice"
 	"github.com/hashicorp/consul/types"
 )
 
@@ -17,6 +23,18 @@ type MeshConfigEntry struct {
 
 	HTTP *MeshHTTPConfig `json:",omitempty"`
 
+	ProtocolDetection *MeshProtocolDetectionConfig `json:",omitempty" alias:"protocol_detection"`
+
+	Egress *MeshEgressConfig `json:",omitempty"`
+
+	// Tracing configures the sidecar proxies of the mesh to report the spans
+	// of the HTTP requests they handle to a collector.
+	Tracing *MeshTracingConfig `json:",omitempty"`
+
+	// Peering configures how the servers establish peerings with other
+	// clusters.
+	Peering *PeeringMeshConfig `json:",omitempty"`
+
 	Meta               map[string]string `json:",omitempty"`
 	acl.EnterpriseMeta `hcl:",squash" mapstructure:",squash"`
 	RaftIndex
@@ -46,6 +64,418 @@ type MeshDirectionalTLSConfig struct {
 
 type MeshHTTPConfig struct {
 	SanitizeXForwardedClientCert bool `alias:"sanitize_x_forwarded_client_cert"`
+
+	// Incoming configures the HTTP requests received by the proxies of the mesh
+	// from other services.
+	Incoming *MeshDirectionalHTTPConfig `json:",omitempty"`
+}
+
+type MeshDirectionalHTTPConfig struct {
+	// RequestNormalization configures how requests are normalized and
+	// validated by all the inbound HTTP listeners of the mesh, before they are
+	// authorized by intentions. Services can override it in their
+	// service-defaults.
+	RequestNormalization *RequestNormalizationConfig `json:",omitempty" alias:"request_normalization"`
+
+	// ObservabilityHeaders configures the request ID, trace context and static
+	// headers set by the inbound HTTP listeners of the sidecar proxies and the
+	// HTTP listeners of the ingress gateways, so that the logs of applications
+	// without a tracing SDK can still be correlated. Proxies can override it
+	// in their configuration, for example in proxy-defaults.
+	ObservabilityHeaders *ObservabilityHeadersConfig `json:",omitempty" alias:"observability_headers"`
+}
+
+const (
+	HeaderKeyFormatLowercase  = "lowercase"
+	HeaderKeyFormatProperCase = "proper_case"
+)
+
+// RequestNormalizationConfig configures how the inbound HTTP listeners of
+// proxies normalize and validate requests. Unset fields are inherited: from
+// Envoy's defaults in the mesh config entry, and from the mesh config entry in
+// service-defaults.
+type RequestNormalizationConfig struct {
+	// NormalizePath normalizes the path of requests according to RFC 3986,
+	// for example "/a/../b" becomes "/b", so that intentions match the path
+	// seen by the application.
+	NormalizePath *bool `json:",omitempty" alias:"normalize_path"`
+
+	// MergeSlashes merges adjacent slashes in the path of requests, for
+	// example "//a///b" becomes "/a/b".
+	MergeSlashes *bool `json:",omitempty" alias:"merge_slashes"`
+
+	// PathWithEscapedSlashesAction is the action taken on requests whose path
+	// contains escaped slashes ("%2F" or "%5C"). One of KEEP_UNCHANGED,
+	// REJECT_REQUEST, UNESCAPE_AND_REDIRECT or UNESCAPE_AND_FORWARD.
+	PathWithEscapedSlashesAction string `json:",omitempty" alias:"path_with_escaped_slashes_action"`
+
+	// HeadersWithUnderscoresAction is the action taken on requests with
+	// headers whose name contains underscores, which some applications treat
+	// like dashes. One of ALLOW, REJECT_REQUEST or DROP_HEADER.
+	HeadersWithUnderscoresAction string `json:",omitempty" alias:"headers_with_underscores_action"`
+
+	// HeaderKeyFormat is the casing of the header names of HTTP/1.1 requests
+	// and responses, which are lowercased by default. One of lowercase or
+	// proper_case.
+	HeaderKeyFormat string `json:",omitempty" alias:"header_key_format"`
+}
+
+func (c *RequestNormalizationConfig) Validate() error {
+	switch c.PathWithEscapedSlashesAction {
+	case "", "KEEP_UNCHANGED", "REJECT_REQUEST", "UNESCAPE_AND_REDIRECT", "UNESCAPE_AND_FORWARD":
+	default:
+		return fmt.Errorf("PathWithEscapedSlashesAction must be one of KEEP_UNCHANGED, REJECT_REQUEST, UNESCAPE_AND_REDIRECT or UNESCAPE_AND_FORWARD")
+	}
+	switch c.HeadersWithUnderscoresAction {
+	case "", "ALLOW", "REJECT_REQUEST", "DROP_HEADER":
+	default:
+		return fmt.Errorf("HeadersWithUnderscoresAction must be one of ALLOW, REJECT_REQUEST or DROP_HEADER")
+	}
+	switch c.HeaderKeyFormat {
+	case "", HeaderKeyFormatLowercase, HeaderKeyFormatProperCase:
+	default:
+		return fmt.Errorf("HeaderKeyFormat must be one of %s or %s", HeaderKeyFormatLowercase, HeaderKeyFormatProperCase)
+	}
+	return nil
+}
+
+func (c *RequestNormalizationConfig) Clone() *RequestNormalizationConfig {
+	if c == nil {
+		return nil
+	}
+	c2 := *c
+	if c.NormalizePath != nil {
+		v := *c.NormalizePath
+		c2.NormalizePath = &v
+	}
+	if c.MergeSlashes != nil {
+		v := *c.MergeSlashes
+		c2.MergeSlashes = &v
+	}
+	return &c2
+}
+
+// OverlayWith returns a copy of c with the fields set in overlay overridden.
+// Either of them may be nil.
+func (c *RequestNormalizationConfig) OverlayWith(overlay *RequestNormalizationConfig) *RequestNormalizationConfig {
+	if c == nil {
+		return overlay.Clone()
+	}
+	out := c.Clone()
+	if overlay == nil {
+		return out
+	}
+	if overlay.NormalizePath != nil {
+		v := *overlay.NormalizePath
+		out.NormalizePath = &v
+	}
+	if overlay.MergeSlashes != nil {
+		v := *overlay.MergeSlashes
+		out.MergeSlashes = &v
+	}
+	if overlay.PathWithEscapedSlashesAction != "" {
+		out.PathWithEscapedSlashesAction = overlay.PathWithEscapedSlashesAction
+	}
+	if overlay.HeadersWithUnderscoresAction != "" {
+		out.HeadersWithUnderscoresAction = overlay.HeadersWithUnderscoresAction
+	}
+	if overlay.HeaderKeyFormat != "" {
+		out.HeaderKeyFormat = overlay.HeaderKeyFormat
+	}
+	return out
+}
+
+// ProxyConfig returns the sidecar proxy config keys equivalent to c.
+func (c *RequestNormalizationConfig) ProxyConfig() map[string]interface{} {
+	cfg := make(map[string]interface{})
+	if c.NormalizePath != nil {
+		cfg["normalize_path"] = *c.NormalizePath
+	}
+	if c.MergeSlashes != nil {
+		cfg["merge_slashes"] = *c.MergeSlashes
+	}
+	if c.PathWithEscapedSlashesAction != "" {
+		cfg["path_with_escaped_slashes_action"] = c.PathWithEscapedSlashesAction
+	}
+	if c.HeadersWithUnderscoresAction != "" {
+		cfg["headers_with_underscores_action"] = c.HeadersWithUnderscoresAction
+	}
+	if c.HeaderKeyFormat != "" {
+		cfg["header_key_format"] = c.HeaderKeyFormat
+	}
+	return cfg
+}
+
+const (
+	TraceContextPolicyPassthrough = "passthrough"
+	TraceContextPolicyStrip       = "strip"
+)
+
+// TraceContextHeaders are the W3C trace context headers affected by
+// ObservabilityHeadersConfig.TraceContextPolicy.
+var TraceContextHeaders = []string{"traceparent", "tracestate"}
+
+// RequestIDHeader is the header generated and preserved according to
+// ObservabilityHeadersConfig.GenerateRequestID and PreserveRequestID.
+const RequestIDHeader = "x-request-id"
+
+// ObservabilityHeaderVariables are the variables that can be referenced as
+// ${name} in the values of ObservabilityHeadersConfig.RequestHeaders. They are
+// replaced with the identity of the proxy setting the header.
+var ObservabilityHeaderVariables = []string{"service", "node", "datacenter", "namespace", "partition"}
+
+var observabilityHeaderVariableRe = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// ObservabilityHeadersConfig configures the headers proxies set on the HTTP
+// requests they forward to correlate them. Unset fields are inherited: from
+// Envoy's defaults in the mesh config entry, and from the mesh config entry in
+// the proxy configuration.
+type ObservabilityHeadersConfig struct {
+	// GenerateRequestID makes the proxies generate an x-request-id header for
+	// the requests without one. Envoy generates them by default.
+	GenerateRequestID *bool `json:",omitempty" alias:"generate_request_id"`
+
+	// PreserveRequestID keeps the x-request-id header of the requests rather
+	// than replacing it with a new ID, so that a request keeps the same ID
+	// across the services it goes through.
+	PreserveRequestID *bool `json:",omitempty" alias:"preserve_request_id"`
+
+	// TraceContextPolicy is what is done with the W3C trace context headers
+	// (traceparent and tracestate) of requests. One of passthrough, the
+	// default, which forwards them unchanged, or strip which removes them,
+	// for example at the edge of the mesh.
+	TraceContextPolicy string `json:",omitempty" alias:"trace_context_policy"`
+
+	// RequestHeaders are static headers set on requests, replacing the ones
+	// sent by the client. Their values may reference the identity of the
+	// proxy with ${service}, ${node}, ${datacenter}, ${namespace} and
+	// ${partition}.
+	RequestHeaders map[string]string `json:",omitempty" alias:"request_headers"`
+}
+
+func (c *ObservabilityHeadersConfig) Validate() error {
+	switch c.TraceContextPolicy {
+	case "", TraceContextPolicyPassthrough, TraceContextPolicyStrip:
+	default:
+		return fmt.Errorf("TraceContextPolicy must be one of %s or %s", TraceContextPolicyPassthrough, TraceContextPolicyStrip)
+	}
+	for name, value := range c.RequestHeaders {
+		if name == "" || strings.HasPrefix(name, ":") || strings.EqualFold(name, "host") {
+			return fmt.Errorf("RequestHeaders name %q is not allowed", name)
+		}
+		if strings.EqualFold(name, RequestIDHeader) || stringslice.Contains(TraceContextHeaders, strings.ToLower(name)) {
+			return fmt.Errorf("RequestHeaders name %q is not allowed, it is managed by GenerateRequestID, PreserveRequestID and TraceContextPolicy", name)
+		}
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("RequestHeaders name %q is not a valid HTTP header name", name)
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("RequestHeaders[%q] must not contain control characters such as CR or LF", name)
+		}
+		for _, m := range observabilityHeaderVariableRe.FindAllStringSubmatch(value, -1) {
+			if !stringslice.Contains(ObservabilityHeaderVariables, m[1]) {
+				return fmt.Errorf("RequestHeaders[%q] references the unknown variable %q, it must be one of %s",
+					name, m[1], strings.Join(ObservabilityHeaderVariables, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+func (c *ObservabilityHeadersConfig) Clone() *ObservabilityHeadersConfig {
+	if c == nil {
+		return nil
+	}
+	c2 := *c
+	if c.GenerateRequestID != nil {
+		v := *c.GenerateRequestID
+		c2.GenerateRequestID = &v
+	}
+	if c.PreserveRequestID != nil {
+		v := *c.PreserveRequestID
+		c2.PreserveRequestID = &v
+	}
+	if c.RequestHeaders != nil {
+		c2.RequestHeaders = make(map[string]string, len(c.RequestHeaders))
+		for k, v := range c.RequestHeaders {
+			c2.RequestHeaders[k] = v
+		}
+	}
+	return &c2
+}
+
+// OverlayWith returns a copy of c with the fields set in overlay overridden.
+// The request headers are merged. Either of them may be nil.
+func (c *ObservabilityHeadersConfig) OverlayWith(overlay *ObservabilityHeadersConfig) *ObservabilityHeadersConfig {
+	if c == nil {
+		return overlay.Clone()
+	}
+	out := c.Clone()
+	if overlay == nil {
+		return out
+	}
+	if overlay.GenerateRequestID != nil {
+		v := *overlay.GenerateRequestID
+		out.GenerateRequestID = &v
+	}
+	if overlay.PreserveRequestID != nil {
+		v := *overlay.PreserveRequestID
+		out.PreserveRequestID = &v
+	}
+	if overlay.TraceContextPolicy != "" {
+		out.TraceContextPolicy = overlay.TraceContextPolicy
+	}
+	if len(overlay.RequestHeaders) > 0 && out.RequestHeaders == nil {
+		out.RequestHeaders = make(map[string]string, len(overlay.RequestHeaders))
+	}
+	for k, v := range overlay.RequestHeaders {
+		out.RequestHeaders[k] = v
+	}
+	return out
+}
+
+// ExpandRequestHeaders returns the request headers with the variables they
+// reference replaced by their value in vars.
+func (c *ObservabilityHeadersConfig) ExpandRequestHeaders(vars map[string]string) map[string]string {
+	if len(c.RequestHeaders) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(c.RequestHeaders))
+	for name, value := range c.RequestHeaders {
+		out[name] = observabilityHeaderVariableRe.ReplaceAllStringFunc(value, func(ref string) string {
+			return vars[ref[2:len(ref)-1]]
+		})
+	}
+	return out
+}
+
+// MeshEgressConfig controls the traffic from the mesh to destinations outside
+// of it.
+type MeshEgressConfig struct {
+	// AllowedHostnames are the external hostnames that transparent proxies with
+	// the dynamic forward proxy enabled resolve and connect to on behalf of
+	// their service. A hostname may start with a "*." wildcard to allow all of
+	// its subdomains.
+	AllowedHostnames []string `json:",omitempty" alias:"allowed_hostnames"`
+}
+
+const (
+	TracingProviderZipkin        = "zipkin"
+	TracingProviderOpenTelemetry = "opentelemetry"
+
+	defaultTracingZipkinCollectorPath = "/api/v2/spans"
+)
+
+// MeshTracingConfig configures the tracer of the HTTP connection managers of
+// sidecar proxies.
+type MeshTracingConfig struct {
+	// Provider is the tracer spans are reported with: zipkin, the default, or
+	// opentelemetry to export them over OTLP/gRPC.
+	Provider string `json:",omitempty"`
+
+	// CollectorUpstream is the name of the service spans are reported to.
+	// Proxies only report spans once it is one of their upstreams, either
+	// explicitly or through transparent proxy, and its discovery chain
+	// resolves to a single target. With the opentelemetry provider its
+	// protocol must be grpc.
+	CollectorUpstream string `alias:"collector_upstream"`
+
+	// CollectorPath is the HTTP path spans are posted to by the zipkin
+	// provider. Defaults to /api/v2/spans.
+	CollectorPath string `json:",omitempty" alias:"collector_path"`
+
+	// SamplingPercentage is the percentage of the requests without a tracing
+	// decision that are sampled. Requests whose trace headers carry a
+	// decision always follow it.
+	SamplingPercentage float64 `json:",omitempty" alias:"sampling_percentage"`
+
+	// CustomTags are the tags added to every span from the headers of the
+	// request.
+	CustomTags []MeshTracingCustomTag `json:",omitempty" alias:"custom_tags"`
+}
+
+// MeshTracingCustomTag is a span tag whose value is read from a request
+// header.
+type MeshTracingCustomTag struct {
+	Tag           string
+	RequestHeader string `alias:"request_header"`
+
+	// DefaultValue is used when the request doesn't have the header. Spans
+	// don't have the tag if it is empty.
+	DefaultValue string `json:",omitempty" alias:"default_value"`
+}
+
+func (c *MeshTracingConfig) Validate() error {
+	switch c.Provider {
+	case "", TracingProviderZipkin, TracingProviderOpenTelemetry:
+	default:
+		return fmt.Errorf("Provider must be one of %s or %s", TracingProviderZipkin, TracingProviderOpenTelemetry)
+	}
+	if c.CollectorUpstream == "" {
+		return fmt.Errorf("CollectorUpstream is required")
+	}
+	if c.CollectorPath != "" {
+		if c.Provider == TracingProviderOpenTelemetry {
+			return fmt.Errorf("CollectorPath is not supported by the %s provider", TracingProviderOpenTelemetry)
+		}
+		if !strings.HasPrefix(c.CollectorPath, "/") {
+			return fmt.Errorf("CollectorPath must start with a slash")
+		}
+	}
+	if c.SamplingPercentage < 0 || c.SamplingPercentage > 100 {
+		return fmt.Errorf("SamplingPercentage must be between 0 and 100")
+	}
+	seen := make(map[string]struct{})
+	for i, tag := range c.CustomTags {
+		if tag.Tag == "" {
+			return fmt.Errorf("CustomTags[%d].Tag is required", i)
+		}
+		if tag.RequestHeader == "" {
+			return fmt.Errorf("CustomTags[%d].RequestHeader is required", i)
+		}
+		if _, ok := seen[tag.Tag]; ok {
+			return fmt.Errorf("CustomTags[%d].Tag %q is duplicated", i, tag.Tag)
+		}
+		seen[tag.Tag] = struct{}{}
+	}
+	return nil
+}
+
+// GetCollectorPath returns the HTTP path spans are posted to, with the
+// default applied.
+func (c *MeshTracingConfig) GetCollectorPath() string {
+	if c.CollectorPath == "" {
+		return defaultTracingZipkinCollectorPath
+	}
+	return c.CollectorPath
+}
+
+// MeshProtocolDetectionConfig controls how the protocols detected by sidecar
+// proxies are used.
+type MeshProtocolDetectionConfig struct {
+	// AutoPopulate allows the leader to write a service-defaults protocol for
+	// services without a configured protocol once all of their instances
+	// agree on the detected protocol.
+	AutoPopulate bool `alias:"auto_populate"`
+}
+
+// PeeringMeshConfig configures how the servers establish peerings with other
+// clusters.
+type PeeringMeshConfig struct {
+	// PeerThroughMeshGateways makes the peering tokens generated by this
+	// cluster advertise the addresses of its mesh gateways rather than the
+	// ones of its servers, so that peers establish their control-plane
+	// streams through the mesh gateways.
+	PeerThroughMeshGateways bool `json:",omitempty" alias:"peer_through_mesh_gateways"`
+}
+
+// PeerThroughMeshGateways returns whether the peering control-plane streams
+// go through the mesh gateways.
+func (e *MeshConfigEntry) PeerThroughMeshGateways() bool {
+	if e == nil || e.Peering == nil {
+		return false
+	}
+	return e.Peering.PeerThroughMeshGateways
 }
 
 func (e *MeshConfigEntry) GetKind() string {
@@ -98,6 +528,32 @@ func (e *MeshConfigEntry) Validate() error {
 		}
 	}
 
+	if e.Egress != nil {
+		for _, hostname := range e.Egress.AllowedHostnames {
+			if err := validateEgressHostname(hostname); err != nil {
+				return fmt.Errorf("error in egress configuration: %v", err)
+			}
+		}
+	}
+
+	if e.HTTP != nil && e.HTTP.Incoming != nil && e.HTTP.Incoming.RequestNormalization != nil {
+		if err := e.HTTP.Incoming.RequestNormalization.Validate(); err != nil {
+			return fmt.Errorf("error in incoming HTTP request normalization: %v", err)
+		}
+	}
+
+	if e.HTTP != nil && e.HTTP.Incoming != nil && e.HTTP.Incoming.ObservabilityHeaders != nil {
+		if err := e.HTTP.Incoming.ObservabilityHeaders.Validate(); err != nil {
+			return fmt.Errorf("error in incoming HTTP observability headers: %v", err)
+		}
+	}
+
+	if e.Tracing != nil {
+		if err := e.Tracing.Validate(); err != nil {
+			return fmt.Errorf("error in tracing configuration: %v", err)
+		}
+	}
+
 	return e.validateEnterpriseMeta()
 }
 
@@ -150,6 +606,21 @@ func validateMeshDirectionalTLSConfig(cfg *MeshDirectionalTLSConfig) error {
 	return validateTLSConfig(cfg.TLSMinVersion, cfg.TLSMaxVersion, cfg.CipherSuites)
 }
 
+// validateEgressHostname checks that an allowed egress hostname is a DNS name,
+// optionally prefixed with a "*." wildcard.
+func validateEgressHostname(hostname string) error {
+	if hostname == "" || hostname == "*" {
+		return fmt.Errorf("allowed hostname %q must be a valid DNS hostname", hostname)
+	}
+	if _, ok := dns.IsDomainName(hostname); !ok || strings.ContainsAny(hostname, ":/") {
+		return fmt.Errorf("allowed hostname %q must be a valid DNS hostname", hostname)
+	}
+	if strings.ContainsRune(strings.TrimPrefix(hostname, "*."), '*') {
+		return fmt.Errorf("allowed hostname %q is not valid, a wildcard specifier is only allowed as the leftmost label", hostname)
+	}
+	return nil
+}
+
 func validateTLSConfig(
 	tlsMinVersion types.TLSVersion,
 	tlsMaxVersion types.TLSVersion,
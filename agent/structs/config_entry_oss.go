@@ -16,6 +16,13 @@ func (e *ProxyConfigEntry) validateEnterpriseMeta() error {
 	return nil
 }
 
+// ProxyDefaultsScopes returns the scopes of the proxy-defaults entries which
+// are layered into the proxy-defaults of entMeta, from the broadest to the
+// narrowest. There is a single scope without partitions and namespaces.
+func ProxyDefaultsScopes(_ *acl.EnterpriseMeta) []*acl.EnterpriseMeta {
+	return []*acl.EnterpriseMeta{DefaultEnterpriseMetaInDefaultPartition()}
+}
+
 func validateUnusedKeys(unused []string) error {
 	var err error
 
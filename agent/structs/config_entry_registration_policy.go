@@ -0,0 +1,146 @@
+package structs
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/consul/acl"
+)
+
+// RegistrationPolicyConfigEntry denies the registration of services whose
+// name or namespace matches one of its glob patterns, so that critical names
+// such as `consul` or the names of internal gateways can't be shadowed by
+// mistake. There is a single entry per partition, named "global".
+type RegistrationPolicyConfigEntry struct {
+	Kind string
+	Name string
+
+	// DeniedServiceNames are the glob patterns, as understood by path.Match,
+	// of the service names that can't be registered. Matching is case
+	// insensitive.
+	DeniedServiceNames []string `json:",omitempty" alias:"denied_service_names"`
+
+	// DeniedNamespaces are the glob patterns of the namespaces services can't
+	// be registered in. Namespacing is a Consul Enterprise feature.
+	DeniedNamespaces []string `json:",omitempty" alias:"denied_namespaces"`
+
+	Meta               map[string]string `json:",omitempty"`
+	acl.EnterpriseMeta `hcl:",squash" mapstructure:",squash"`
+	RaftIndex
+}
+
+func (e *RegistrationPolicyConfigEntry) GetKind() string {
+	return RegistrationPolicy
+}
+
+func (e *RegistrationPolicyConfigEntry) GetName() string {
+	if e == nil {
+		return ""
+	}
+
+	return e.Name
+}
+
+func (e *RegistrationPolicyConfigEntry) GetMeta() map[string]string {
+	if e == nil {
+		return nil
+	}
+	return e.Meta
+}
+
+func (e *RegistrationPolicyConfigEntry) Normalize() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+
+	e.Kind = RegistrationPolicy
+	e.EnterpriseMeta.Normalize()
+
+	return nil
+}
+
+func (e *RegistrationPolicyConfigEntry) Validate() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+
+	if e.Name != RegistrationPolicyGlobal {
+		return fmt.Errorf("invalid name (%q), only %q is supported", e.Name, RegistrationPolicyGlobal)
+	}
+
+	for _, pattern := range e.DeniedServiceNames {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("invalid denied service name pattern %q", pattern)
+		}
+	}
+	for _, pattern := range e.DeniedNamespaces {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("invalid denied namespace pattern %q", pattern)
+		}
+	}
+
+	return validateConfigEntryMeta(e.Meta)
+}
+
+// DeniedPattern returns the pattern denying the registration of the service
+// with the given name in the given namespace, or "" when it is allowed.
+func (e *RegistrationPolicyConfigEntry) DeniedPattern(service, namespace string) string {
+	if e == nil {
+		return ""
+	}
+
+	service = strings.ToLower(service)
+	for _, pattern := range e.DeniedServiceNames {
+		if ok, _ := path.Match(strings.ToLower(pattern), service); ok {
+			return pattern
+		}
+	}
+	for _, pattern := range e.DeniedNamespaces {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return pattern
+		}
+	}
+	return ""
+}
+
+func (e *RegistrationPolicyConfigEntry) CanRead(authz acl.Authorizer) error {
+	return nil
+}
+
+func (e *RegistrationPolicyConfigEntry) CanWrite(authz acl.Authorizer) error {
+	var authzContext acl.AuthorizerContext
+	e.FillAuthzContext(&authzContext)
+	return authz.ToAllowAuthorizer().OperatorWriteAllowed(&authzContext)
+}
+
+func (e *RegistrationPolicyConfigEntry) GetRaftIndex() *RaftIndex {
+	if e == nil {
+		return &RaftIndex{}
+	}
+
+	return &e.RaftIndex
+}
+
+func (e *RegistrationPolicyConfigEntry) GetEnterpriseMeta() *acl.EnterpriseMeta {
+	if e == nil {
+		return nil
+	}
+
+	return &e.EnterpriseMeta
+}
+
+// MarshalJSON adds the Kind field so that the JSON can be decoded back into the
+// correct type.
+func (e *RegistrationPolicyConfigEntry) MarshalJSON() ([]byte, error) {
+	type Alias RegistrationPolicyConfigEntry
+	source := &struct {
+		Kind string
+		*Alias
+	}{
+		Kind:  RegistrationPolicy,
+		Alias: (*Alias)(e),
+	}
+	return json.Marshal(source)
+}
@@ -0,0 +1,70 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistrationPolicyConfigEntry(t *testing.T) {
+	cases := map[string]configEntryTestcase{
+		"normalize: kind": {
+			entry: &RegistrationPolicyConfigEntry{
+				Name:               RegistrationPolicyGlobal,
+				DeniedServiceNames: []string{"consul"},
+			},
+			expected: &RegistrationPolicyConfigEntry{
+				Kind:               RegistrationPolicy,
+				Name:               RegistrationPolicyGlobal,
+				DeniedServiceNames: []string{"consul"},
+				EnterpriseMeta:     *DefaultEnterpriseMetaInDefaultPartition(),
+			},
+		},
+		"validate: missing name": {
+			entry: &RegistrationPolicyConfigEntry{
+				DeniedServiceNames: []string{"consul"},
+			},
+			validateErr: `invalid name (""), only "global" is supported`,
+		},
+		"validate: wrong name": {
+			entry: &RegistrationPolicyConfigEntry{
+				Name:               "web",
+				DeniedServiceNames: []string{"consul"},
+			},
+			validateErr: `invalid name ("web"), only "global" is supported`,
+		},
+		"validate: bad service name pattern": {
+			entry: &RegistrationPolicyConfigEntry{
+				Name:               RegistrationPolicyGlobal,
+				DeniedServiceNames: []string{"web-[a"},
+			},
+			validateErr: `invalid denied service name pattern "web-[a"`,
+		},
+		"validate: empty namespace pattern": {
+			entry: &RegistrationPolicyConfigEntry{
+				Name:             RegistrationPolicyGlobal,
+				DeniedNamespaces: []string{""},
+			},
+			validateErr: `invalid denied namespace pattern ""`,
+		},
+	}
+
+	testConfigEntryNormalizeAndValidate(t, cases)
+}
+
+func TestRegistrationPolicyConfigEntry_DeniedPattern(t *testing.T) {
+	var nilEntry *RegistrationPolicyConfigEntry
+	require.Empty(t, nilEntry.DeniedPattern("consul", "default"))
+
+	entry := &RegistrationPolicyConfigEntry{
+		DeniedServiceNames: []string{"consul", "*-gateway-internal"},
+		DeniedNamespaces:   []string{"system-*"},
+	}
+
+	require.Equal(t, "consul", entry.DeniedPattern("consul", "default"))
+	require.Equal(t, "consul", entry.DeniedPattern("Consul", "default"))
+	require.Equal(t, "*-gateway-internal", entry.DeniedPattern("mesh-gateway-internal", "default"))
+	require.Equal(t, "system-*", entry.DeniedPattern("web", "system-apps"))
+	require.Empty(t, entry.DeniedPattern("web", "default"))
+	require.Empty(t, entry.DeniedPattern("consul-esm", "default"))
+}
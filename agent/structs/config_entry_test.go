@@ -332,6 +332,11 @@ func TestDecodeConfigEntry(t *testing.T) {
 								mode = "remote"
 							}
 						},
+						{
+							name = "redis"
+							peer = "cluster-02"
+							connect_timeout_ms = 10000
+						},
 					]
 					defaults {
 						connect_timeout_ms = 5
@@ -373,6 +378,11 @@ func TestDecodeConfigEntry(t *testing.T) {
 								Mode = "remote"
 							}
 						},
+						{
+							Name = "redis"
+							Peer = "cluster-02"
+							ConnectTimeoutMs = 10000
+						},
 					]
 					Defaults {
 						EnvoyListenerJSON = "foo"
@@ -412,6 +422,11 @@ func TestDecodeConfigEntry(t *testing.T) {
 							Name:        "finance--billing",
 							MeshGateway: MeshGatewayConfig{Mode: MeshGatewayModeRemote},
 						},
+						{
+							Name:             "redis",
+							Peer:             "cluster-02",
+							ConnectTimeoutMs: 10000,
+						},
 					},
 					Defaults: &UpstreamConfig{
 						EnvoyListenerJSON: "foo",
@@ -1427,6 +1442,84 @@ func TestDecodeConfigEntry(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "egress-gateway: kitchen sink",
+			snake: `
+				kind = "egress-gateway"
+				name = "egress"
+				meta {
+					"foo" = "bar"
+				}
+				destinations = [
+					{
+						hostname = "api.example.com"
+						port = 443
+						tls {
+							ca_file = "/etc/ssl/ca.pem"
+							cert_file = "/etc/ssl/cert.pem"
+							key_file = "/etc/ssl/tls.key"
+							sni = "example.com"
+						}
+					},
+				]
+				reputation_check {
+					address = "127.0.0.1"
+					port = 9191
+					timeout = "1s"
+					fail_open = true
+				}
+			`,
+			camel: `
+				Kind = "egress-gateway"
+				Name = "egress"
+				Meta {
+					"foo" = "bar"
+				}
+				Destinations = [
+					{
+						Hostname = "api.example.com"
+						Port = 443
+						TLS {
+							CAFile = "/etc/ssl/ca.pem"
+							CertFile = "/etc/ssl/cert.pem"
+							KeyFile = "/etc/ssl/tls.key"
+							SNI = "example.com"
+						}
+					},
+				]
+				ReputationCheck {
+					Address = "127.0.0.1"
+					Port = 9191
+					Timeout = "1s"
+					FailOpen = true
+				}
+			`,
+			expect: &EgressGatewayConfigEntry{
+				Kind: "egress-gateway",
+				Name: "egress",
+				Meta: map[string]string{
+					"foo": "bar",
+				},
+				Destinations: []EgressGatewayDestination{
+					{
+						Hostname: "api.example.com",
+						Port:     443,
+						TLS: &EgressGatewayTLSConfig{
+							CAFile:   "/etc/ssl/ca.pem",
+							CertFile: "/etc/ssl/cert.pem",
+							KeyFile:  "/etc/ssl/tls.key",
+							SNI:      "example.com",
+						},
+					},
+				},
+				ReputationCheck: &EgressGatewayReputationCheck{
+					Address:  "127.0.0.1",
+					Port:     9191,
+					Timeout:  time.Second,
+					FailOpen: true,
+				},
+			},
+		},
 		{
 			name: "service-intentions: kitchen sink",
 			snake: `
@@ -1726,6 +1819,41 @@ func TestDecodeConfigEntry(t *testing.T) {
 				}
 				http {
 					sanitize_x_forwarded_client_cert = true
+					incoming {
+						request_normalization {
+							normalize_path = true
+							merge_slashes = false
+							path_with_escaped_slashes_action = "REJECT_REQUEST"
+							headers_with_underscores_action = "DROP_HEADER"
+							header_key_format = "proper_case"
+						}
+						observability_headers {
+							preserve_request_id = true
+							trace_context_policy = "strip"
+							request_headers {
+								x-consul-node = "${node}"
+							}
+						}
+					}
+				}
+				egress {
+					allowed_hostnames = ["api.example.com", "*.googleapis.com"]
+				}
+				tracing {
+					provider = "zipkin"
+					collector_upstream = "jaeger"
+					collector_path = "/spans"
+					sampling_percentage = 12.5
+					custom_tags = [
+						{
+							tag = "tenant"
+							request_header = "x-tenant"
+							default_value = "none"
+						}
+					]
+				}
+				peering {
+					peer_through_mesh_gateways = true
 				}
 			`,
 			camel: `
@@ -1757,7 +1885,42 @@ func TestDecodeConfigEntry(t *testing.T) {
 				}
 				HTTP {
 					SanitizeXForwardedClientCert = true
-				}	
+					Incoming {
+						RequestNormalization {
+							NormalizePath = true
+							MergeSlashes = false
+							PathWithEscapedSlashesAction = "REJECT_REQUEST"
+							HeadersWithUnderscoresAction = "DROP_HEADER"
+							HeaderKeyFormat = "proper_case"
+						}
+						ObservabilityHeaders {
+							PreserveRequestID = true
+							TraceContextPolicy = "strip"
+							RequestHeaders {
+								x-consul-node = "${node}"
+							}
+						}
+					}
+				}
+				Egress {
+					AllowedHostnames = ["api.example.com", "*.googleapis.com"]
+				}
+				Tracing {
+					Provider = "zipkin"
+					CollectorUpstream = "jaeger"
+					CollectorPath = "/spans"
+					SamplingPercentage = 12.5
+					CustomTags = [
+						{
+							Tag = "tenant"
+							RequestHeader = "x-tenant"
+							DefaultValue = "none"
+						}
+					]
+				}
+				Peering {
+					PeerThroughMeshGateways = true
+				}
 			`,
 			expect: &MeshConfigEntry{
 				Meta: map[string]string{
@@ -1787,6 +1950,35 @@ func TestDecodeConfigEntry(t *testing.T) {
 				},
 				HTTP: &MeshHTTPConfig{
 					SanitizeXForwardedClientCert: true,
+					Incoming: &MeshDirectionalHTTPConfig{
+						RequestNormalization: &RequestNormalizationConfig{
+							NormalizePath:                boolPointer(true),
+							MergeSlashes:                 boolPointer(false),
+							PathWithEscapedSlashesAction: "REJECT_REQUEST",
+							HeadersWithUnderscoresAction: "DROP_HEADER",
+							HeaderKeyFormat:              HeaderKeyFormatProperCase,
+						},
+						ObservabilityHeaders: &ObservabilityHeadersConfig{
+							PreserveRequestID:  boolPointer(true),
+							TraceContextPolicy: TraceContextPolicyStrip,
+							RequestHeaders:     map[string]string{"x-consul-node": "${node}"},
+						},
+					},
+				},
+				Egress: &MeshEgressConfig{
+					AllowedHostnames: []string{"api.example.com", "*.googleapis.com"},
+				},
+				Tracing: &MeshTracingConfig{
+					Provider:           TracingProviderZipkin,
+					CollectorUpstream:  "jaeger",
+					CollectorPath:      "/spans",
+					SamplingPercentage: 12.5,
+					CustomTags: []MeshTracingCustomTag{
+						{Tag: "tenant", RequestHeader: "x-tenant", DefaultValue: "none"},
+					},
+				},
+				Peering: &PeeringMeshConfig{
+					PeerThroughMeshGateways: true,
 				},
 			},
 		},
@@ -2380,6 +2572,132 @@ func TestServiceConfigEntry(t *testing.T) {
 			},
 			validateErr: `error in upstream defaults: Name must be empty`,
 		},
+		"upstream config defaults with peer": {
+			entry: &ServiceConfigEntry{
+				Name: "web",
+				UpstreamConfig: &UpstreamConfiguration{
+					Defaults: &UpstreamConfig{
+						Peer:     "cluster-02",
+						Protocol: "http",
+					},
+				},
+			},
+			validateErr: `error in upstream defaults: Peer must be empty`,
+		},
+		"upstream config overrides for a local and a peered upstream": {
+			entry: &ServiceConfigEntry{
+				Kind: ServiceDefaults,
+				Name: "web",
+				UpstreamConfig: &UpstreamConfiguration{
+					Overrides: []*UpstreamConfig{
+						{
+							Name:             "db",
+							ConnectTimeoutMs: 1000,
+						},
+						{
+							Name:             "db",
+							Peer:             "cluster-02",
+							ConnectTimeoutMs: 10000,
+						},
+					},
+				},
+			},
+		},
+		"upstream config defaults with route timeouts": {
+			entry: &ServiceConfigEntry{
+				Kind: ServiceDefaults,
+				Name: "web",
+				UpstreamConfig: &UpstreamConfiguration{
+					Defaults: &UpstreamConfig{
+						RequestTimeoutMs: 2000,
+						RouteTimeouts: []UpstreamRouteTimeout{
+							{PathPrefix: "/reports", RequestTimeoutMs: 30000},
+						},
+					},
+				},
+			},
+		},
+		"upstream config override with route timeout without path": {
+			entry: &ServiceConfigEntry{
+				Kind: ServiceDefaults,
+				Name: "web",
+				UpstreamConfig: &UpstreamConfiguration{
+					Overrides: []*UpstreamConfig{
+						{
+							Name:          "reports",
+							RouteTimeouts: []UpstreamRouteTimeout{{RequestTimeoutMs: 30000}},
+						},
+					},
+				},
+			},
+			validateErr: "RouteTimeouts[0]: one of PathExact or PathPrefix is required",
+		},
+		"upstream config override with tls validation overrides": {
+			entry: &ServiceConfigEntry{
+				Kind: ServiceDefaults,
+				Name: "web",
+				UpstreamConfig: &UpstreamConfiguration{
+					Overrides: []*UpstreamConfig{
+						{
+							Name:         "legacy",
+							Peer:         "cluster-02",
+							SNI:          "legacy.internal.example.com",
+							AcceptedSANs: []string{"legacy.internal.example.com", "spiffe://example.com/legacy"},
+						},
+					},
+				},
+			},
+		},
+		"upstream config defaults with sni": {
+			entry: &ServiceConfigEntry{
+				Kind: ServiceDefaults,
+				Name: "web",
+				UpstreamConfig: &UpstreamConfiguration{
+					Defaults: &UpstreamConfig{SNI: "legacy.example.com"},
+				},
+			},
+			validateErr: "SNI must be empty",
+		},
+		"upstream config override with invalid sni": {
+			entry: &ServiceConfigEntry{
+				Kind: ServiceDefaults,
+				Name: "web",
+				UpstreamConfig: &UpstreamConfiguration{
+					Overrides: []*UpstreamConfig{
+						{
+							Name: "legacy",
+							SNI:  "*.example.com",
+						},
+					},
+				},
+			},
+			validateErr: `SNI "*.example.com" must be a valid DNS hostname`,
+		},
+		"upstream config override with empty accepted san": {
+			entry: &ServiceConfigEntry{
+				Kind: ServiceDefaults,
+				Name: "web",
+				UpstreamConfig: &UpstreamConfiguration{
+					Overrides: []*UpstreamConfig{
+						{
+							Name:         "legacy",
+							AcceptedSANs: []string{"legacy.example.com", ""},
+						},
+					},
+				},
+			},
+			validateErr: "AcceptedSANs[1] must not be empty",
+		},
+		"upstream config defaults with negative request timeout": {
+			entry: &ServiceConfigEntry{
+				Kind: ServiceDefaults,
+				Name: "web",
+				UpstreamConfig: &UpstreamConfiguration{
+					Defaults: &UpstreamConfig{RequestTimeoutMs: -1},
+				},
+			},
+			validateErr: "RequestTimeoutMs must be positive",
+		},
 		"connect-kitchen-sink": {
 			entry: &ServiceConfigEntry{
 				Kind: ServiceDefaults,
@@ -2534,6 +2852,88 @@ func TestServiceConfigEntry(t *testing.T) {
 				},
 			},
 		},
+		"validate: negative inbound connection buffer limit": {
+			entry: &ServiceConfigEntry{
+				Kind:                              ServiceDefaults,
+				Name:                              "web",
+				InboundConnectionBufferLimitBytes: -1,
+			},
+			validateErr: "InboundConnectionBufferLimitBytes must be positive",
+		},
+		"validate: negative max request body bytes": {
+			entry: &ServiceConfigEntry{
+				Kind:                ServiceDefaults,
+				Name:                "web",
+				MaxRequestBodyBytes: -1,
+			},
+			validateErr: "MaxRequestBodyBytes must be positive",
+		},
+		"validate: local app http2": {
+			entry: &ServiceConfigEntry{
+				Kind:     ServiceDefaults,
+				Name:     "web",
+				Protocol: "grpc",
+				LocalAppHTTP2: &LocalAppHTTP2Config{
+					KeepaliveIntervalMs:  30000,
+					KeepaliveTimeoutMs:   2000,
+					MaxConcurrentStreams: 100,
+				},
+			},
+		},
+		"validate: local app http2 keepalive timeout without interval": {
+			entry: &ServiceConfigEntry{
+				Kind:     ServiceDefaults,
+				Name:     "web",
+				Protocol: "grpc",
+				LocalAppHTTP2: &LocalAppHTTP2Config{
+					KeepaliveTimeoutMs: 2000,
+				},
+			},
+			validateErr: "KeepaliveTimeoutMs requires KeepaliveIntervalMs",
+		},
+		"validate: local app http2 negative max concurrent streams": {
+			entry: &ServiceConfigEntry{
+				Kind:     ServiceDefaults,
+				Name:     "web",
+				Protocol: "grpc",
+				LocalAppHTTP2: &LocalAppHTTP2Config{
+					MaxConcurrentStreams: -1,
+				},
+			},
+			validateErr: "MaxConcurrentStreams must be positive",
+		},
+		"validate: request normalization": {
+			entry: &ServiceConfigEntry{
+				Kind:     ServiceDefaults,
+				Name:     "web",
+				Protocol: "http",
+				RequestNormalization: &RequestNormalizationConfig{
+					MergeSlashes:                 boolPointer(false),
+					HeadersWithUnderscoresAction: "ALLOW",
+					HeaderKeyFormat:              HeaderKeyFormatLowercase,
+				},
+			},
+		},
+		"validate: request normalization invalid escaped slashes action": {
+			entry: &ServiceConfigEntry{
+				Kind: ServiceDefaults,
+				Name: "web",
+				RequestNormalization: &RequestNormalizationConfig{
+					PathWithEscapedSlashesAction: "DROP",
+				},
+			},
+			validateErr: "PathWithEscapedSlashesAction must be one of",
+		},
+		"validate: request normalization invalid header key format": {
+			entry: &ServiceConfigEntry{
+				Kind: ServiceDefaults,
+				Name: "web",
+				RequestNormalization: &RequestNormalizationConfig{
+					HeaderKeyFormat: "preserve",
+				},
+			},
+			validateErr: "HeaderKeyFormat must be one of",
+		},
 	}
 	testConfigEntryNormalizeAndValidate(t, cases)
 }
@@ -2551,7 +2951,11 @@ func TestUpstreamConfig_MergeInto(t *testing.T) {
 				EnvoyListenerJSON: "foo",
 				EnvoyClusterJSON:  "bar",
 				ConnectTimeoutMs:  5,
-				Protocol:          "http",
+				RequestTimeoutMs:  6,
+				RouteTimeouts: []UpstreamRouteTimeout{
+					{PathPrefix: "/slow", RequestTimeoutMs: 7},
+				},
+				Protocol: "http",
 				Limits: &UpstreamLimits{
 					MaxConnections:        intPointer(3),
 					MaxPendingRequests:    intPointer(4),
@@ -2561,14 +2965,20 @@ func TestUpstreamConfig_MergeInto(t *testing.T) {
 					MaxFailures: 3,
 					Interval:    2 * time.Second,
 				},
-				MeshGateway: MeshGatewayConfig{Mode: MeshGatewayModeRemote},
+				MeshGateway:  MeshGatewayConfig{Mode: MeshGatewayModeRemote},
+				SNI:          "legacy.example.com",
+				AcceptedSANs: []string{"legacy.example.com"},
 			},
 			destination: make(map[string]interface{}),
 			want: map[string]interface{}{
 				"envoy_listener_json": "foo",
 				"envoy_cluster_json":  "bar",
 				"connect_timeout_ms":  5,
-				"protocol":            "http",
+				"request_timeout_ms":  6,
+				"route_timeouts": []UpstreamRouteTimeout{
+					{PathPrefix: "/slow", RequestTimeoutMs: 7},
+				},
+				"protocol": "http",
 				"limits": &UpstreamLimits{
 					MaxConnections:        intPointer(3),
 					MaxPendingRequests:    intPointer(4),
@@ -2578,7 +2988,9 @@ func TestUpstreamConfig_MergeInto(t *testing.T) {
 					MaxFailures: 3,
 					Interval:    2 * time.Second,
 				},
-				"mesh_gateway": MeshGatewayConfig{Mode: MeshGatewayModeRemote},
+				"mesh_gateway":  MeshGatewayConfig{Mode: MeshGatewayModeRemote},
+				"sni":           "legacy.example.com",
+				"accepted_sans": []string{"legacy.example.com"},
 			},
 		},
 		{
@@ -2811,6 +3223,38 @@ func TestParseUpstreamConfig(t *testing.T) {
 				Protocol: "tcp",
 			},
 		},
+		{
+			name: "request timeouts",
+			input: map[string]interface{}{
+				"request_timeout_ms": "3000",
+				"route_timeouts": []map[string]interface{}{
+					{"path_prefix": "/reports", "request_timeout_ms": 30000},
+					{"path_exact": "/healthz", "request_timeout_ms": 500},
+				},
+			},
+			want: UpstreamConfig{
+				ConnectTimeoutMs: 5000,
+				RequestTimeoutMs: 3000,
+				RouteTimeouts: []UpstreamRouteTimeout{
+					{PathPrefix: "/reports", RequestTimeoutMs: 30000},
+					{PathExact: "/healthz", RequestTimeoutMs: 500},
+				},
+				Protocol: "tcp",
+			},
+		},
+		{
+			name: "tls validation overrides",
+			input: map[string]interface{}{
+				"sni":           "legacy.example.com",
+				"accepted_sans": []interface{}{"legacy.example.com", "spiffe://example.com/legacy"},
+			},
+			want: UpstreamConfig{
+				ConnectTimeoutMs: 5000,
+				SNI:              "legacy.example.com",
+				AcceptedSANs:     []string{"legacy.example.com", "spiffe://example.com/legacy"},
+				Protocol:         "tcp",
+			},
+		},
 		{
 			name: "mesh gateway map",
 			input: map[string]interface{}{
@@ -2845,6 +3289,119 @@ func intPointer(i int) *int {
 	return &i
 }
 
+func boolPointer(b bool) *bool {
+	return &b
+}
+
+func TestRequestNormalizationConfig_OverlayWith(t *testing.T) {
+	mesh := &RequestNormalizationConfig{
+		NormalizePath:                boolPointer(true),
+		MergeSlashes:                 boolPointer(true),
+		PathWithEscapedSlashesAction: "REJECT_REQUEST",
+		HeaderKeyFormat:              HeaderKeyFormatProperCase,
+	}
+
+	require.Nil(t, (*RequestNormalizationConfig)(nil).OverlayWith(nil))
+	require.Equal(t, mesh, mesh.OverlayWith(nil))
+	require.Equal(t, mesh, (*RequestNormalizationConfig)(nil).OverlayWith(mesh))
+
+	got := mesh.OverlayWith(&RequestNormalizationConfig{
+		MergeSlashes:                 boolPointer(false),
+		HeadersWithUnderscoresAction: "DROP_HEADER",
+		HeaderKeyFormat:              HeaderKeyFormatLowercase,
+	})
+	require.Equal(t, &RequestNormalizationConfig{
+		NormalizePath:                boolPointer(true),
+		MergeSlashes:                 boolPointer(false),
+		PathWithEscapedSlashesAction: "REJECT_REQUEST",
+		HeadersWithUnderscoresAction: "DROP_HEADER",
+		HeaderKeyFormat:              HeaderKeyFormatLowercase,
+	}, got)
+
+	// The overlaid config is left as-is.
+	require.True(t, *mesh.MergeSlashes)
+}
+
+func TestMergeProxyConfigEntries(t *testing.T) {
+	global := &ProxyConfigEntry{
+		Kind: ProxyDefaults,
+		Name: ProxyConfigGlobal,
+		Config: map[string]interface{}{
+			"protocol":                 "http",
+			"local_connect_timeout_ms": 1000,
+		},
+		Mode: ProxyModeTransparent,
+		MeshGateway: MeshGatewayConfig{
+			Mode: MeshGatewayModeRemote,
+		},
+		TransparentProxy: TransparentProxyConfig{
+			OutboundListenerPort: 15001,
+		},
+		Expose: ExposeConfig{
+			Checks: true,
+		},
+		Meta:      map[string]string{"owner": "platform", "tier": "global"},
+		RaftIndex: RaftIndex{CreateIndex: 1, ModifyIndex: 1},
+	}
+	scoped := &ProxyConfigEntry{
+		Kind: ProxyDefaults,
+		Name: ProxyConfigGlobal,
+		Config: map[string]interface{}{
+			"protocol": "grpc",
+		},
+		MeshGateway: MeshGatewayConfig{
+			Mode: MeshGatewayModeLocal,
+		},
+		TransparentProxy: TransparentProxyConfig{
+			DialedDirectly: true,
+		},
+		Expose: ExposeConfig{
+			Paths: []ExposePath{{Path: "/health", LocalPathPort: 8080, ListenerPort: 21500}},
+		},
+		Meta:      map[string]string{"tier": "team"},
+		RaftIndex: RaftIndex{CreateIndex: 2, ModifyIndex: 3},
+	}
+
+	t.Run("no layers", func(t *testing.T) {
+		require.Nil(t, MergeProxyConfigEntries())
+		require.Nil(t, MergeProxyConfigEntries(nil, nil))
+	})
+
+	t.Run("single layer", func(t *testing.T) {
+		require.Same(t, global, MergeProxyConfigEntries(nil, global, nil))
+	})
+
+	t.Run("narrower layer takes precedence", func(t *testing.T) {
+		merged := MergeProxyConfigEntries(global, nil, scoped)
+		require.Equal(t, &ProxyConfigEntry{
+			Kind: ProxyDefaults,
+			Name: ProxyConfigGlobal,
+			Config: map[string]interface{}{
+				"protocol":                 "grpc",
+				"local_connect_timeout_ms": 1000,
+			},
+			Mode: ProxyModeTransparent,
+			MeshGateway: MeshGatewayConfig{
+				Mode: MeshGatewayModeLocal,
+			},
+			TransparentProxy: TransparentProxyConfig{
+				OutboundListenerPort: 15001,
+				DialedDirectly:       true,
+			},
+			Expose: ExposeConfig{
+				Checks: true,
+				Paths:  []ExposePath{{Path: "/health", LocalPathPort: 8080, ListenerPort: 21500}},
+			},
+			Meta:      map[string]string{"owner": "platform", "tier": "team"},
+			RaftIndex: RaftIndex{CreateIndex: 2, ModifyIndex: 3},
+		}, merged)
+
+		// The layers are left untouched.
+		require.Equal(t, "http", global.Config["protocol"])
+		require.Equal(t, "global", global.Meta["tier"])
+	})
+}
+
 func TestConfigEntryQuery_CacheInfoKey(t *testing.T) {
 	assertCacheInfoKeyIsComplete(t, &ConfigEntryQuery{})
 }
@@ -2938,3 +3495,267 @@ func testConfigEntryNormalizeAndValidate(t *testing.T, cases map[string]configEn
 		})
 	}
 }
+
+func TestMeshConfigEntry_ValidateEgress(t *testing.T) {
+	tt := map[string]struct {
+		hostname string
+		wantErr  string
+	}{
+		"hostname":            {hostname: "api.example.com"},
+		"wildcard":            {hostname: "*.example.com"},
+		"empty":               {hostname: "", wantErr: "must be a valid DNS hostname"},
+		"any host":            {hostname: "*", wantErr: "must be a valid DNS hostname"},
+		"port":                {hostname: "api.example.com:443", wantErr: "must be a valid DNS hostname"},
+		"scheme":              {hostname: "https://api.example.com", wantErr: "must be a valid DNS hostname"},
+		"wildcard in the mid": {hostname: "api.*.example.com", wantErr: "only allowed as the leftmost label"},
+	}
+
+	for name, tc := range tt {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			entry := &MeshConfigEntry{
+				Egress: &MeshEgressConfig{
+					AllowedHostnames: []string{tc.hostname},
+				},
+			}
+			err := entry.Validate()
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestMeshConfigEntry_ValidateObservabilityHeaders(t *testing.T) {
+	tt := map[string]struct {
+		headers ObservabilityHeadersConfig
+		wantErr string
+	}{
+		"empty": {},
+		"full": {
+			headers: ObservabilityHeadersConfig{
+				GenerateRequestID:  boolPointer(true),
+				PreserveRequestID:  boolPointer(true),
+				TraceContextPolicy: TraceContextPolicyPassthrough,
+				RequestHeaders: map[string]string{
+					"x-consul-source": "${service}.${namespace}.${partition}.${datacenter}",
+					"x-consul-node":   "${node}",
+					"x-team":          "payments",
+				},
+			},
+		},
+		"unknown trace context policy": {
+			headers: ObservabilityHeadersConfig{TraceContextPolicy: "drop"},
+			wantErr: "TraceContextPolicy must be one of passthrough or strip",
+		},
+		"empty header name": {
+			headers: ObservabilityHeadersConfig{RequestHeaders: map[string]string{"": "foo"}},
+			wantErr: `RequestHeaders name "" is not allowed`,
+		},
+		"pseudo header": {
+			headers: ObservabilityHeadersConfig{RequestHeaders: map[string]string{":path": "/"}},
+			wantErr: `RequestHeaders name ":path" is not allowed`,
+		},
+		"request id header": {
+			headers: ObservabilityHeadersConfig{RequestHeaders: map[string]string{"X-Request-ID": "foo"}},
+			wantErr: `RequestHeaders name "X-Request-ID" is not allowed, it is managed by`,
+		},
+		"trace context header": {
+			headers: ObservabilityHeadersConfig{RequestHeaders: map[string]string{"traceparent": "foo"}},
+			wantErr: `RequestHeaders name "traceparent" is not allowed, it is managed by`,
+		},
+		"invalid header name": {
+			headers: ObservabilityHeadersConfig{RequestHeaders: map[string]string{"x team": "payments"}},
+			wantErr: `RequestHeaders name "x team" is not a valid HTTP header name`,
+		},
+		"header value with CRLF": {
+			headers: ObservabilityHeadersConfig{RequestHeaders: map[string]string{"x-team": "payments\r\nx-admin: true"}},
+			wantErr: `RequestHeaders["x-team"] must not contain control characters`,
+		},
+		"header value with control character": {
+			headers: ObservabilityHeadersConfig{RequestHeaders: map[string]string{"x-team": "pay\x00ments"}},
+			wantErr: `RequestHeaders["x-team"] must not contain control characters`,
+		},
+		"unknown variable": {
+			headers: ObservabilityHeadersConfig{RequestHeaders: map[string]string{"x-host": "${hostname}"}},
+			wantErr: `RequestHeaders["x-host"] references the unknown variable "hostname"`,
+		},
+	}
+
+	for name, tc := range tt {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			entry := &MeshConfigEntry{
+				HTTP: &MeshHTTPConfig{
+					Incoming: &MeshDirectionalHTTPConfig{ObservabilityHeaders: &tc.headers},
+				},
+			}
+			err := entry.Validate()
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestObservabilityHeadersConfig_OverlayWith(t *testing.T) {
+	mesh := &ObservabilityHeadersConfig{
+		GenerateRequestID:  boolPointer(true),
+		TraceContextPolicy: TraceContextPolicyStrip,
+		RequestHeaders:     map[string]string{"x-team": "payments", "x-node": "${node}"},
+	}
+
+	require.Nil(t, (*ObservabilityHeadersConfig)(nil).OverlayWith(nil))
+	require.Equal(t, mesh, mesh.OverlayWith(nil))
+	require.Equal(t, mesh, (*ObservabilityHeadersConfig)(nil).OverlayWith(mesh))
+
+	got := mesh.OverlayWith(&ObservabilityHeadersConfig{
+		PreserveRequestID:  boolPointer(true),
+		TraceContextPolicy: TraceContextPolicyPassthrough,
+		RequestHeaders:     map[string]string{"x-team": "billing"},
+	})
+	require.Equal(t, &ObservabilityHeadersConfig{
+		GenerateRequestID:  boolPointer(true),
+		PreserveRequestID:  boolPointer(true),
+		TraceContextPolicy: TraceContextPolicyPassthrough,
+		RequestHeaders:     map[string]string{"x-team": "billing", "x-node": "${node}"},
+	}, got)
+
+	// The overlaid config is left as-is.
+	require.Equal(t, "payments", mesh.RequestHeaders["x-team"])
+}
+
+func TestObservabilityHeadersConfig_ExpandRequestHeaders(t *testing.T) {
+	cfg := &ObservabilityHeadersConfig{
+		RequestHeaders: map[string]string{
+			"x-consul-source": "${service}.${namespace}.${partition}.${datacenter}",
+			"x-consul-node":   "${node}",
+			"x-team":          "payments",
+		},
+	}
+	got := cfg.ExpandRequestHeaders(map[string]string{
+		"service":    "web",
+		"node":       "node-1",
+		"datacenter": "dc1",
+		"namespace":  "default",
+		"partition":  "default",
+	})
+	require.Equal(t, map[string]string{
+		"x-consul-source": "web.default.default.dc1",
+		"x-consul-node":   "node-1",
+		"x-team":          "payments",
+	}, got)
+	require.Equal(t, "${node}", cfg.RequestHeaders["x-consul-node"])
+	require.Nil(t, (&ObservabilityHeadersConfig{}).ExpandRequestHeaders(nil))
+}
+
+func TestMeshConfigEntry_ValidateTracing(t *testing.T) {
+	tt := map[string]struct {
+		tracing MeshTracingConfig
+		wantErr string
+	}{
+		"minimal": {
+			tracing: MeshTracingConfig{CollectorUpstream: "zipkin"},
+		},
+		"full": {
+			tracing: MeshTracingConfig{
+				Provider:           TracingProviderZipkin,
+				CollectorUpstream:  "zipkin",
+				CollectorPath:      "/api/v2/spans",
+				SamplingPercentage: 100,
+				CustomTags: []MeshTracingCustomTag{
+					{Tag: "tenant", RequestHeader: "x-tenant"},
+				},
+			},
+		},
+		"opentelemetry": {
+			tracing: MeshTracingConfig{
+				Provider:          TracingProviderOpenTelemetry,
+				CollectorUpstream: "otel-collector",
+			},
+		},
+		"unknown provider": {
+			tracing: MeshTracingConfig{Provider: "xray", CollectorUpstream: "xray"},
+			wantErr: "Provider must be one of zipkin or opentelemetry",
+		},
+		"opentelemetry with path": {
+			tracing: MeshTracingConfig{
+				Provider:          TracingProviderOpenTelemetry,
+				CollectorUpstream: "otel-collector",
+				CollectorPath:     "/v1/traces",
+			},
+			wantErr: "CollectorPath is not supported by the opentelemetry provider",
+		},
+		"no collector": {
+			tracing: MeshTracingConfig{},
+			wantErr: "CollectorUpstream is required",
+		},
+		"relative path": {
+			tracing: MeshTracingConfig{CollectorUpstream: "zipkin", CollectorPath: "spans"},
+			wantErr: "CollectorPath must start with a slash",
+		},
+		"sampling above 100": {
+			tracing: MeshTracingConfig{CollectorUpstream: "zipkin", SamplingPercentage: 101},
+			wantErr: "SamplingPercentage must be between 0 and 100",
+		},
+		"tag without header": {
+			tracing: MeshTracingConfig{
+				CollectorUpstream: "zipkin",
+				CustomTags:        []MeshTracingCustomTag{{Tag: "tenant"}},
+			},
+			wantErr: "CustomTags[0].RequestHeader is required",
+		},
+		"duplicate tag": {
+			tracing: MeshTracingConfig{
+				CollectorUpstream: "zipkin",
+				CustomTags: []MeshTracingCustomTag{
+					{Tag: "tenant", RequestHeader: "x-tenant"},
+					{Tag: "tenant", RequestHeader: "x-org"},
+				},
+			},
+			wantErr: `CustomTags[1].Tag "tenant" is duplicated`,
+		},
+	}
+
+	for name, tc := range tt {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			entry := &MeshConfigEntry{Tracing: &tc.tracing}
+			err := entry.Validate()
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestOpaqueUpstreamConfigs_GetUpstreamConfig(t *testing.T) {
+	configs := OpaqueUpstreamConfigs{
+		{
+			Upstream: NewServiceID("db", nil),
+			Peer:     "cluster-02",
+			Config:   map[string]interface{}{"protocol": "grpc"},
+		},
+		{
+			Upstream: NewServiceID("db", nil),
+			Config:   map[string]interface{}{"protocol": "http"},
+		},
+	}
+
+	config, found := configs.GetUpstreamConfig(NewServiceID("db", nil))
+	require.True(t, found)
+	require.Equal(t, map[string]interface{}{"protocol": "http"}, config)
+
+	// The config of the imported upstream isn't returned for the local one.
+	_, found = configs[:1].GetUpstreamConfig(NewServiceID("db", nil))
+	require.False(t, found)
+}
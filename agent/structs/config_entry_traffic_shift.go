@@ -0,0 +1,398 @@
+package structs
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"text/template"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/lib"
+)
+
+const (
+	// TrafficShiftStateRunning is set while the leader is ramping traffic
+	// from the FromSubset to the ToSubset.
+	TrafficShiftStateRunning = "running"
+
+	// TrafficShiftStateCompleted is set once all traffic has been shifted to
+	// the ToSubset.
+	TrafficShiftStateCompleted = "completed"
+
+	// TrafficShiftStateRolledBack is set when a check failed and all traffic
+	// was sent back to the FromSubset.
+	TrafficShiftStateRolledBack = "rolled-back"
+
+	// TrafficShiftCheckHealth fails a step when any instance in the ToSubset
+	// has a critical health check.
+	TrafficShiftCheckHealth = "health"
+
+	// TrafficShiftCheckPrometheus fails a step when the result of a query
+	// against a Prometheus compatible API is outside of the configured
+	// bounds.
+	TrafficShiftCheckPrometheus = "prometheus"
+
+	// DefaultTrafficShiftPrometheusTimeout and MaxTrafficShiftPrometheusTimeout
+	// are the default and maximum Timeout of the prometheus checks.
+	DefaultTrafficShiftPrometheusTimeout = 10 * time.Second
+	MaxTrafficShiftPrometheusTimeout     = time.Minute
+
+	defaultTrafficShiftStepWeight   float32 = 10
+	defaultTrafficShiftStepInterval         = 10 * time.Minute
+)
+
+// TrafficShiftConfigEntry describes a scheduled shift of traffic for a service
+// from one service-resolver subset to another. The leader periodically writes
+// a service-splitter for the service that moves StepWeight percent of traffic
+// every StepInterval, running the configured Checks before every step and
+// sending all traffic back to FromSubset if any of them fail.
+type TrafficShiftConfigEntry struct {
+	Kind string
+
+	// Name is the name of the service whose traffic is being shifted.
+	Name string
+
+	// FromSubset is the subset currently receiving traffic. Traffic is
+	// returned to it on rollback.
+	FromSubset string `alias:"from_subset"`
+
+	// ToSubset is the subset traffic is being shifted to.
+	ToSubset string `alias:"to_subset"`
+
+	// StepWeight is the percentage of traffic moved to ToSubset on every step.
+	StepWeight float32 `json:",omitempty" alias:"step_weight"`
+
+	// StepInterval is the time between two steps.
+	StepInterval time.Duration `json:",omitempty" alias:"step_interval"`
+
+	// Checks are run before every step. If any of them fails the shift is
+	// rolled back.
+	Checks []TrafficShiftCheck `json:",omitempty"`
+
+	// Status is maintained by the leader and reports the progress of the
+	// shift. It can't be set by users, writing the entry resets it and starts
+	// the shift over.
+	Status *TrafficShiftStatus `json:",omitempty"`
+
+	Meta               map[string]string `json:",omitempty"`
+	acl.EnterpriseMeta `hcl:",squash" mapstructure:",squash"`
+	RaftIndex
+}
+
+// TrafficShiftCheck configures a check run between the steps of a traffic
+// shift. Type selects the implementation and Config holds its opaque,
+// type-specific options.
+type TrafficShiftCheck struct {
+	Type   string
+	Config map[string]interface{} `json:",omitempty"`
+}
+
+// TrafficShiftHealthCheckConfig is the Config of the checks of type health.
+type TrafficShiftHealthCheckConfig struct {
+	// OnlyPassing also fails the check on instances with warning checks.
+	OnlyPassing bool
+}
+
+// TrafficShiftPrometheusCheckConfig is the Config of the checks of type
+// prometheus.
+type TrafficShiftPrometheusCheckConfig struct {
+	// Address is the base URL of the Prometheus HTTP API. It must also be one
+	// of the connect.traffic_shift_prometheus_addresses of the servers.
+	Address string
+
+	// Query is the PromQL query to run. It is rendered as a Go template.
+	Query string
+
+	// Min and Max bound the acceptable values of the query result. At least
+	// one of them must be set.
+	Min *float64
+	Max *float64
+
+	// Timeout bounds the time taken by the query.
+	Timeout time.Duration
+}
+
+// HealthConfig decodes and validates the Config of a health check.
+func (c *TrafficShiftCheck) HealthConfig() (*TrafficShiftHealthCheckConfig, error) {
+	var config TrafficShiftHealthCheckConfig
+	if err := decodeTrafficShiftCheckConfig(c.Config, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// PrometheusConfig decodes and validates the Config of a prometheus check.
+func (c *TrafficShiftCheck) PrometheusConfig() (*TrafficShiftPrometheusCheckConfig, error) {
+	config := TrafficShiftPrometheusCheckConfig{Timeout: DefaultTrafficShiftPrometheusTimeout}
+	if err := decodeTrafficShiftCheckConfig(c.Config, &config); err != nil {
+		return nil, err
+	}
+
+	if config.Address == "" {
+		return nil, fmt.Errorf("Address is required")
+	}
+	u, err := url.Parse(config.Address)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("Address %q must be an http or https URL", config.Address)
+	}
+	if config.Timeout <= 0 || config.Timeout > MaxTrafficShiftPrometheusTimeout {
+		return nil, fmt.Errorf("Timeout must be positive and at most %s", MaxTrafficShiftPrometheusTimeout)
+	}
+	if config.Query == "" {
+		return nil, fmt.Errorf("Query is required")
+	}
+	if _, err := config.QueryTemplate(); err != nil {
+		return nil, fmt.Errorf("invalid Query: %w", err)
+	}
+	if config.Min == nil && config.Max == nil {
+		return nil, fmt.Errorf("at least one of Min or Max is required")
+	}
+	for _, bound := range []*float64{config.Min, config.Max} {
+		if bound != nil && (math.IsNaN(*bound) || math.IsInf(*bound, 0)) {
+			return nil, fmt.Errorf("Min and Max must be finite numbers")
+		}
+	}
+	if config.Min != nil && config.Max != nil && *config.Min > *config.Max {
+		return nil, fmt.Errorf("Min must not be greater than Max")
+	}
+	return &config, nil
+}
+
+// QueryTemplate parses the Query of the check.
+func (c *TrafficShiftPrometheusCheckConfig) QueryTemplate() (*template.Template, error) {
+	return template.New("query").Option("missingkey=error").Parse(c.Query)
+}
+
+// validate checks that the type of the check is known and that its Config
+// is valid for that type.
+func (c *TrafficShiftCheck) validate() error {
+	var err error
+	switch c.Type {
+	case "":
+		return fmt.Errorf("Type is required")
+	case TrafficShiftCheckHealth:
+		_, err = c.HealthConfig()
+	case TrafficShiftCheckPrometheus:
+		_, err = c.PrometheusConfig()
+	default:
+		return fmt.Errorf("unknown Type %q", c.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s check: %w", c.Type, err)
+	}
+	return nil
+}
+
+// decodeTrafficShiftCheckConfig decodes the opaque Config of a check, which
+// must not have any unknown keys.
+func decodeTrafficShiftCheckConfig(config map[string]interface{}, out interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		ErrorUnused:      true,
+		Result:           out,
+		WeaklyTypedInput: true,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(config)
+}
+
+// TrafficShiftStatus reports the progress of a traffic shift.
+type TrafficShiftStatus struct {
+	// State is one of running, completed or rolled-back.
+	State string
+
+	// Weight is the percentage of traffic currently sent to the ToSubset.
+	Weight float32
+
+	// LastStep is the time the splitter was last updated.
+	LastStep time.Time
+
+	// Reason describes why the shift was rolled back or could not proceed.
+	Reason string `json:",omitempty"`
+}
+
+// validate checks the status written by the leader.
+func (s *TrafficShiftStatus) validate() error {
+	if s == nil {
+		return nil
+	}
+	switch s.State {
+	case TrafficShiftStateRunning, TrafficShiftStateCompleted, TrafficShiftStateRolledBack:
+	default:
+		return fmt.Errorf("unknown State %q", s.State)
+	}
+	if math.IsNaN(float64(s.Weight)) || s.Weight < 0 || s.Weight > 100 {
+		return fmt.Errorf("Weight must be between 0 and 100")
+	}
+	return nil
+}
+
+// Done reports whether the shift has finished, successfully or not.
+func (s *TrafficShiftStatus) Done() bool {
+	return s != nil && (s.State == TrafficShiftStateCompleted || s.State == TrafficShiftStateRolledBack)
+}
+
+func (e *TrafficShiftConfigEntry) GetKind() string {
+	return TrafficShift
+}
+
+func (e *TrafficShiftConfigEntry) GetName() string {
+	if e == nil {
+		return ""
+	}
+
+	return e.Name
+}
+
+func (e *TrafficShiftConfigEntry) GetMeta() map[string]string {
+	if e == nil {
+		return nil
+	}
+	return e.Meta
+}
+
+func (e *TrafficShiftConfigEntry) Normalize() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+
+	e.Kind = TrafficShift
+	e.EnterpriseMeta.Normalize()
+
+	if e.StepWeight == 0 {
+		e.StepWeight = defaultTrafficShiftStepWeight
+	}
+	e.StepWeight = NormalizeServiceSplitWeight(e.StepWeight)
+	if e.StepInterval == 0 {
+		e.StepInterval = defaultTrafficShiftStepInterval
+	}
+
+	return nil
+}
+
+func (e *TrafficShiftConfigEntry) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("Name is required")
+	}
+
+	if err := validateServiceSubset(e.FromSubset); err != nil {
+		return fmt.Errorf("FromSubset %q is invalid: %v", e.FromSubset, err)
+	}
+	if err := validateServiceSubset(e.ToSubset); err != nil {
+		return fmt.Errorf("ToSubset %q is invalid: %v", e.ToSubset, err)
+	}
+	if e.FromSubset == e.ToSubset {
+		return fmt.Errorf("FromSubset and ToSubset must be different")
+	}
+
+	if math.IsNaN(float64(e.StepWeight)) || e.StepWeight <= 0 || e.StepWeight > 100 {
+		return fmt.Errorf("StepWeight must be between 0 and 100")
+	}
+	if e.StepInterval <= 0 {
+		return fmt.Errorf("StepInterval must be positive")
+	}
+
+	for i, check := range e.Checks {
+		if err := check.validate(); err != nil {
+			return fmt.Errorf("Checks[%d]: %w", i, err)
+		}
+	}
+
+	if err := e.Status.validate(); err != nil {
+		return fmt.Errorf("Status: %w", err)
+	}
+
+	if err := validateConfigEntryMeta(e.Meta); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Splitter returns the service-splitter sending weight percent of traffic to
+// the ToSubset and the rest to the FromSubset.
+func (e *TrafficShiftConfigEntry) Splitter(weight float32) *ServiceSplitterConfigEntry {
+	weight = NormalizeServiceSplitWeight(weight)
+	return &ServiceSplitterConfigEntry{
+		Kind: ServiceSplitter,
+		Name: e.Name,
+		Splits: []ServiceSplit{
+			{Weight: 100 - weight, ServiceSubset: e.FromSubset},
+			{Weight: weight, ServiceSubset: e.ToSubset},
+		},
+		Meta: map[string]string{
+			"consul-traffic-shift": e.Name,
+		},
+		EnterpriseMeta: e.EnterpriseMeta,
+	}
+}
+
+func (e *TrafficShiftConfigEntry) ListRelatedServices() []ServiceID {
+	return nil
+}
+
+func (e *TrafficShiftConfigEntry) CanRead(authz acl.Authorizer) error {
+	return canReadDiscoveryChain(e, authz)
+}
+
+func (e *TrafficShiftConfigEntry) CanWrite(authz acl.Authorizer) error {
+	return canWriteDiscoveryChain(e, authz)
+}
+
+func (e *TrafficShiftConfigEntry) GetRaftIndex() *RaftIndex {
+	if e == nil {
+		return &RaftIndex{}
+	}
+
+	return &e.RaftIndex
+}
+
+func (e *TrafficShiftConfigEntry) GetEnterpriseMeta() *acl.EnterpriseMeta {
+	if e == nil {
+		return nil
+	}
+
+	return &e.EnterpriseMeta
+}
+
+func (e *TrafficShiftConfigEntry) MarshalJSON() ([]byte, error) {
+	type Alias TrafficShiftConfigEntry
+	exported := &struct {
+		StepInterval string `json:",omitempty"`
+		*Alias
+	}{
+		StepInterval: e.StepInterval.String(),
+		Alias:        (*Alias)(e),
+	}
+	if e.StepInterval == 0 {
+		exported.StepInterval = ""
+	}
+
+	return json.Marshal(exported)
+}
+
+func (e *TrafficShiftConfigEntry) UnmarshalJSON(data []byte) error {
+	type Alias TrafficShiftConfigEntry
+	aux := &struct {
+		StepInterval string
+		*Alias
+	}{
+		Alias: (*Alias)(e),
+	}
+	if err := lib.UnmarshalJSON(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	if aux.StepInterval != "" {
+		if e.StepInterval, err = time.ParseDuration(aux.StepInterval); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package structs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrafficShiftConfigEntry(t *testing.T) {
+	cases := map[string]configEntryTestcase{
+		"normalize: defaults": {
+			entry: &TrafficShiftConfigEntry{
+				Name:       "web",
+				FromSubset: "v1",
+				ToSubset:   "v2",
+			},
+			expected: &TrafficShiftConfigEntry{
+				Kind:           TrafficShift,
+				Name:           "web",
+				FromSubset:     "v1",
+				ToSubset:       "v2",
+				StepWeight:     10,
+				StepInterval:   10 * time.Minute,
+				EnterpriseMeta: *DefaultEnterpriseMetaInDefaultPartition(),
+			},
+		},
+		"validate: missing name": {
+			entry: &TrafficShiftConfigEntry{
+				FromSubset: "v1",
+				ToSubset:   "v2",
+			},
+			validateErr: "Name is required",
+		},
+		"validate: invalid subset": {
+			entry: &TrafficShiftConfigEntry{
+				Name:       "web",
+				FromSubset: "v1",
+				ToSubset:   "V2!",
+			},
+			validateErr: `ToSubset "V2!" is invalid`,
+		},
+		"validate: same subsets": {
+			entry: &TrafficShiftConfigEntry{
+				Name:       "web",
+				FromSubset: "v1",
+				ToSubset:   "v1",
+			},
+			validateErr: "FromSubset and ToSubset must be different",
+		},
+		"validate: weight out of range": {
+			entry: &TrafficShiftConfigEntry{
+				Name:       "web",
+				FromSubset: "v1",
+				ToSubset:   "v2",
+				StepWeight: 101,
+			},
+			validateErr: "StepWeight must be between 0 and 100",
+		},
+		"validate: check without type": {
+			entry: &TrafficShiftConfigEntry{
+				Name:       "web",
+				FromSubset: "v1",
+				ToSubset:   "v2",
+				Checks:     []TrafficShiftCheck{{}},
+			},
+			validateErr: "Checks[0]: Type is required",
+		},
+		"validate: unknown check type": {
+			entry: &TrafficShiftConfigEntry{
+				Name:       "web",
+				FromSubset: "v1",
+				ToSubset:   "v2",
+				Checks:     []TrafficShiftCheck{{Type: "latency"}},
+			},
+			validateErr: `Checks[0]: unknown Type "latency"`,
+		},
+		"validate: unknown health check config": {
+			entry: &TrafficShiftConfigEntry{
+				Name:       "web",
+				FromSubset: "v1",
+				ToSubset:   "v2",
+				Checks: []TrafficShiftCheck{{
+					Type:   TrafficShiftCheckHealth,
+					Config: map[string]interface{}{"OnlyPasing": true},
+				}},
+			},
+			validateErr: "Checks[0]: invalid health check",
+		},
+		"validate: prometheus check without bounds": {
+			entry: &TrafficShiftConfigEntry{
+				Name:       "web",
+				FromSubset: "v1",
+				ToSubset:   "v2",
+				Checks: []TrafficShiftCheck{{
+					Type:   TrafficShiftCheckPrometheus,
+					Config: map[string]interface{}{"Address": "http://prometheus:9090", "Query": "up"},
+				}},
+			},
+			validateErr: "Checks[0]: invalid prometheus check: at least one of Min or Max is required",
+		},
+		"validate: prometheus check with invalid address": {
+			entry: &TrafficShiftConfigEntry{
+				Name:       "web",
+				FromSubset: "v1",
+				ToSubset:   "v2",
+				Checks: []TrafficShiftCheck{{
+					Type:   TrafficShiftCheckPrometheus,
+					Config: map[string]interface{}{"Address": "file:///etc/passwd", "Query": "up", "Max": 1},
+				}},
+			},
+			validateErr: `Checks[0]: invalid prometheus check: Address "file:///etc/passwd" must be an http or https URL`,
+		},
+		"validate: prometheus check with invalid query": {
+			entry: &TrafficShiftConfigEntry{
+				Name:       "web",
+				FromSubset: "v1",
+				ToSubset:   "v2",
+				Checks: []TrafficShiftCheck{{
+					Type:   TrafficShiftCheckPrometheus,
+					Config: map[string]interface{}{"Address": "http://prometheus:9090", "Query": "{{.Service", "Max": 1},
+				}},
+			},
+			validateErr: "Checks[0]: invalid prometheus check: invalid Query",
+		},
+		"validate: prometheus check with inverted bounds": {
+			entry: &TrafficShiftConfigEntry{
+				Name:       "web",
+				FromSubset: "v1",
+				ToSubset:   "v2",
+				Checks: []TrafficShiftCheck{{
+					Type:   TrafficShiftCheckPrometheus,
+					Config: map[string]interface{}{"Address": "http://prometheus:9090", "Query": "up", "Min": 2, "Max": 1},
+				}},
+			},
+			validateErr: "Checks[0]: invalid prometheus check: Min must not be greater than Max",
+		},
+		"validate: valid checks": {
+			entry: &TrafficShiftConfigEntry{
+				Name:       "web",
+				FromSubset: "v1",
+				ToSubset:   "v2",
+				Checks: []TrafficShiftCheck{
+					{Type: TrafficShiftCheckHealth, Config: map[string]interface{}{"OnlyPassing": true}},
+					{
+						Type:   TrafficShiftCheckPrometheus,
+						Config: map[string]interface{}{"Address": "http://prometheus:9090", "Query": "up", "Min": 1, "Timeout": "5s"},
+					},
+				},
+			},
+		},
+		"validate: negative step interval": {
+			entry: &TrafficShiftConfigEntry{
+				Name:         "web",
+				FromSubset:   "v1",
+				ToSubset:     "v2",
+				StepInterval: -time.Minute,
+			},
+			validateErr: "StepInterval must be positive",
+		},
+		"validate: invalid status": {
+			entry: &TrafficShiftConfigEntry{
+				Name:       "web",
+				FromSubset: "v1",
+				ToSubset:   "v2",
+				Status:     &TrafficShiftStatus{State: "paused"},
+			},
+			validateErr: `Status: unknown State "paused"`,
+		},
+	}
+
+	testConfigEntryNormalizeAndValidate(t, cases)
+}
+
+func TestTrafficShiftConfigEntry_Splitter(t *testing.T) {
+	entry := &TrafficShiftConfigEntry{
+		Name:       "web",
+		FromSubset: "v1",
+		ToSubset:   "v2",
+	}
+
+	splitter := entry.Splitter(33.333)
+	require.NoError(t, splitter.Normalize())
+	require.NoError(t, splitter.Validate())
+	require.Equal(t, []ServiceSplit{
+		{Weight: 66.67, ServiceSubset: "v1"},
+		{Weight: 33.33, ServiceSubset: "v2"},
+	}, splitter.Splits)
+}
@@ -53,6 +53,13 @@ type IndexedCARoots struct {
 	// Roots is a list of root CA certs to trust.
 	Roots []*CARoot
 
+	// LeafRotationWindow is the time over which clients should spread the
+	// renewal of their leaf certificates after a root rotation, so that the
+	// servers can sign them all within their CSR rate limit. It's set by the
+	// servers from the number of Connect service instances in the datacenter
+	// and is zero if they don't limit the rate of signing requests.
+	LeafRotationWindow time.Duration `json:"-"`
+
 	// QueryMeta contains the meta sent via a header. We ignore for JSON
 	// so this whole structure can be returned.
 	QueryMeta `json:"-"`
@@ -180,6 +187,49 @@ func (c CARoots) Active() *CARoot {
 	return nil
 }
 
+// ConnectTrustDomain describes the identity of the cluster in the
+// certificates signed by the Connect CA.
+type ConnectTrustDomain struct {
+	// TrustDomain is the SPIFFE trust domain of the cluster.
+	TrustDomain string
+
+	// ClusterID is the unique ID of the cluster the trust domain is derived
+	// from.
+	ClusterID string
+
+	// SpiffeIDFormat is the format of the SPIFFE IDs of services.
+	SpiffeIDFormat string
+}
+
+// CARootsChange describes a change to the certificates trusted by the Connect
+// CA, so that systems pinning them can update their trust stores.
+type CARootsChange struct {
+	// Index is the Raft index of the change.
+	Index uint64
+
+	// ActiveRootID is the ID of the active root after the change.
+	ActiveRootID string
+
+	// Added and Removed are the root and intermediate certificates which
+	// became and stopped being trusted.
+	Added   []CACertFingerprint
+	Removed []CACertFingerprint
+}
+
+// CACertFingerprint identifies a root or intermediate certificate of the
+// Connect CA.
+type CACertFingerprint struct {
+	// RootID is the ID of the root the certificate belongs to.
+	RootID string
+
+	// Intermediate is true for the intermediate certificates of the root.
+	Intermediate bool `json:",omitempty"`
+
+	// SHA256 is the SHA-256 fingerprint of the certificate, as hex encoded
+	// bytes separated by colons.
+	SHA256 string
+}
+
 // CASignRequest is the request for signing a service certificate.
 type CASignRequest struct {
 	// Datacenter is the target for this request.
@@ -235,6 +285,49 @@ type IssuedCert struct {
 	RaftIndex
 }
 
+// IssuedCertRecord is the metadata of a leaf certificate signed by the CA.
+// It is stored until the certificate expires so the mesh identities can be
+// inventoried. The certificate itself and its private key are never stored.
+type IssuedCertRecord struct {
+	// SerialNumber is the unique serial number for this certificate.
+	// This is encoded in standard hex separated by :.
+	SerialNumber string
+
+	// Service is the name of the service for which the cert was issued.
+	// ServiceURI is the cert URI value, which is its SPIFFE ID.
+	Service    string `json:",omitempty"`
+	ServiceURI string `json:",omitempty"`
+
+	// Agent is the name of the node for which the cert was issued.
+	// AgentURI is the cert URI value.
+	Agent    string `json:",omitempty"`
+	AgentURI string `json:",omitempty"`
+
+	// RootID is the ID of the CA root the certificate chains to, and
+	// SigningKeyID identifies the key of the CA which signed it.
+	RootID       string
+	SigningKeyID string
+
+	// ValidAfter and ValidBefore are the validity periods for the
+	// certificate.
+	ValidAfter  time.Time
+	ValidBefore time.Time
+
+	// EnterpriseMeta is the Consul Enterprise specific metadata
+	acl.EnterpriseMeta
+
+	RaftIndex
+}
+
+type IssuedCertRecords []*IssuedCertRecord
+
+// IndexedIssuedCertRecords is the list of the unexpired leaf certificates
+// issued by the CA.
+type IndexedIssuedCertRecords struct {
+	Certs IssuedCertRecords
+	QueryMeta
+}
+
 // CAOp is the operation for a request related to intentions.
 type CAOp string
 
@@ -391,6 +484,13 @@ type CommonCAProviderConfig struct {
 	// consume a subset of the server's cores.
 	CSRMaxPerSecond float32
 
+	// CSRMaxBurst is the number of Certificate Signing Requests the servers
+	// will accept at once above CSRMaxPerSecond, as long as they stayed below
+	// it before. Signing requests are forwarded to the leader, so together with
+	// CSRMaxPerSecond it forms a token bucket budget for the datacenter. 0 or 1
+	// allows no burst. This is ignored if CSRMaxPerSecond is zero.
+	CSRMaxBurst int
+
 	// CSRMaxConcurrent is a limit on how many concurrent CSR signing requests
 	// will be processed in parallel. New incoming signing requests will try for
 	// `consul.csrSemaphoreWait` (currently 500ms) for a slot before being
@@ -565,6 +665,10 @@ type CALeafRequest struct {
 	// Datacenter is the target for this request.
 	Datacenter string
 
+	// IssuedCert is the record of the leaf certificate whose signature
+	// incremented the index, if any.
+	IssuedCert *IssuedCertRecord `json:",omitempty"`
+
 	// WriteRequest is a common struct containing ACL tokens and other
 	// write-related common elements for requests.
 	WriteRequest
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"strconv"
+	"strings"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/lib"
@@ -182,7 +184,8 @@ type ConnectProxyConfig struct {
 	LocalServicePort int `json:",omitempty" alias:"local_service_port"`
 
 	// LocalServiceSocketPath is the socket of the local service instance. It is optional
-	// and should only be specified for "side-car" style proxies.
+	// and should only be specified for "side-car" style proxies. On Windows it
+	// can be the name of a named pipe, such as \\.\pipe\web.
 	LocalServiceSocketPath string `json:",omitempty" alias:"local_service_socket_path"`
 
 	// Mode represents how the proxy's inbound and upstream listeners are dialed.
@@ -314,6 +317,17 @@ func (us Upstreams) ToAPI() []api.Upstream {
 	return a
 }
 
+// ValidateLocalBindSockets checks the Unix socket settings of every upstream
+// with ValidateLocalBindSocket.
+func (us Upstreams) ValidateLocalBindSockets() error {
+	for _, u := range us {
+		if err := u.ValidateLocalBindSocket(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // UpstreamsFromAPI is a helper for converting api.Upstream to Upstream.
 func UpstreamsFromAPI(us []api.Upstream) Upstreams {
 	a := make([]Upstream, len(us))
@@ -354,7 +368,9 @@ type Upstream struct {
 	// destined for this upstream service. Required.
 	LocalBindPort int `json:",omitempty" alias:"local_bind_port"`
 
-	// These are exclusive with LocalBindAddress/LocalBindPort
+	// These are exclusive with LocalBindAddress/LocalBindPort. On Windows the
+	// socket path can be the name of a named pipe, such as \\.\pipe\db, in
+	// which case the socket mode must be empty.
 	LocalBindSocketPath string `json:",omitempty" alias:"local_bind_socket_path"`
 	// This might be represented as an int, but because it's octal outputs can be a bit strange.
 	LocalBindSocketMode string `json:",omitempty" alias:"local_bind_socket_mode"`
@@ -466,6 +482,36 @@ func (u *Upstream) Validate() error {
 	return nil
 }
 
+// ValidateLocalBindSocket checks the Unix socket settings of the upstream. It
+// is not part of Validate because it is only enforced for new registrations,
+// the upstreams registered before it existed must keep syncing to the catalog.
+func (u *Upstream) ValidateLocalBindSocket() error {
+	if u.LocalBindSocketPath != "" && u.LocalBindAddress != "" && !u.CentrallyConfigured {
+		return fmt.Errorf("upstream local bind address cannot be defined with a local socket path")
+	}
+	if u.LocalBindSocketMode == "" {
+		return nil
+	}
+	if u.LocalBindSocketPath == "" {
+		return fmt.Errorf("upstream local socket mode requires a local socket path")
+	}
+	if IsNamedPipe(u.LocalBindSocketPath) {
+		return fmt.Errorf("upstream local socket mode cannot be set for the named pipe %q", u.LocalBindSocketPath)
+	}
+	return ValidateSocketMode(u.LocalBindSocketMode)
+}
+
+// ValidateSocketMode checks that mode is octal file permissions with a leading
+// 0, such as 0600.
+func ValidateSocketMode(mode string) error {
+	if strings.HasPrefix(mode, "0") {
+		if m, err := strconv.ParseUint(mode, 8, 32); err == nil && m <= 0777 {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid socket mode %q, must be octal permissions with a leading 0 such as 0600", mode)
+}
+
 // ToAPI returns the api structs with the same fields. We have duplicates to
 // avoid the api package depending on this one which imports a ton of Consul's
 // core which you don't want if you are just trying to use our client in your
@@ -511,6 +557,12 @@ func (u *Upstream) HasLocalPortOrSocket() bool {
 	return (u.LocalBindPort != 0 || u.LocalBindSocketPath != "")
 }
 
+// IsNamedPipe returns true if the socket path is the name of a Windows named
+// pipe, which is accessed through the \\.\pipe\ namespace.
+func IsNamedPipe(path string) bool {
+	return strings.HasPrefix(strings.ToLower(path), `\\.\pipe\`)
+}
+
 func (u *Upstream) UpstreamIsUnixSocket() bool {
 	if u == nil {
 		return false
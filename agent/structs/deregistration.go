@@ -0,0 +1,111 @@
+package structs
+
+import (
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/api"
+)
+
+// Deregistration records the removal of a node or of a service instance from
+// the catalog. The servers keep a bounded history of the most recent ones so
+// that operators can find out why something disappeared from the catalog.
+type Deregistration struct {
+	// ID is the Raft index at which the node or service was removed. When a
+	// transaction removes several nodes or services, the following ones get
+	// the next IDs so that they stay unique.
+	ID uint64
+
+	Node string
+
+	// ServiceID and ServiceName are set when a service instance was removed,
+	// and are empty when the whole node was.
+	ServiceID   string `json:",omitempty"`
+	ServiceName string `json:",omitempty"`
+
+	// Services are the names of the services removed along with the node.
+	Services []string `json:",omitempty"`
+
+	// DeregisteredBy is the accessor ID of the token used to deregister the
+	// node or service. It is empty when ACLs are disabled or when the servers
+	// removed the node on their own, in which case Reason tells why.
+	DeregisteredBy string `json:",omitempty"`
+	Reason         string `json:",omitempty"`
+	DeregisteredAt time.Time
+
+	// Status is the aggregated status of Checks, the last known checks of the
+	// node or service instance.
+	Status string
+	Checks HealthChecks `json:",omitempty"`
+
+	acl.EnterpriseMeta `hcl:",squash" mapstructure:",squash" bexpr:"-"`
+}
+
+// Deregistrations is a list of deregistrations, most recent first.
+type Deregistrations []*Deregistration
+
+// Deregistration returns the record of the deregistration requested by r,
+// leaving the fields describing what was removed to the state store.
+func (r *DeregisterRequest) Deregistration() *Deregistration {
+	return &Deregistration{
+		Node:           r.Node,
+		DeregisteredBy: r.DeregisteredBy,
+		Reason:         r.DeregisterReason,
+		DeregisteredAt: r.DeregisteredAt,
+		EnterpriseMeta: r.EnterpriseMeta,
+	}
+}
+
+// AggregatedStatus returns the status representing the whole list of checks,
+// following the same heuristic as the API client:
+//
+//	maintenance > critical > warning > passing
+func (c HealthChecks) AggregatedStatus() string {
+	var warning, critical, maintenance bool
+	for _, check := range c {
+		if check.CheckID == NodeMaint ||
+			strings.HasPrefix(string(check.CheckID), ServiceMaintPrefix) ||
+			IsMaintenanceWindowCheckID(check.CheckID) {
+			maintenance = true
+			continue
+		}
+		switch check.Status {
+		case api.HealthWarning:
+			warning = true
+		case api.HealthCritical:
+			critical = true
+		}
+	}
+
+	switch {
+	case maintenance:
+		return api.HealthMaint
+	case critical:
+		return api.HealthCritical
+	case warning:
+		return api.HealthWarning
+	default:
+		return api.HealthPassing
+	}
+}
+
+// DeregistrationsRequest is used by the Operator endpoint to list the recent
+// deregistrations of nodes and services.
+type DeregistrationsRequest struct {
+	// Datacenter is the target this request is intended for.
+	Datacenter string
+
+	QueryOptions
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (r *DeregistrationsRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// IndexedDeregistrations is the response to a DeregistrationsRequest.
+type IndexedDeregistrations struct {
+	Deregistrations Deregistrations
+	QueryMeta
+}
@@ -213,6 +213,10 @@ type DiscoveryTarget struct {
 	// External is true if this target is outside of this consul cluster.
 	External bool `json:",omitempty"`
 
+	// IgnoreWeights is true if the catalog Weights of the instances of this
+	// target must not be used to load balance between them.
+	IgnoreWeights bool `json:",omitempty"`
+
 	// SNI is the sni field to use when connecting to this set of endpoints
 	// over TLS.
 	SNI string `json:",omitempty"`
@@ -13,7 +13,9 @@ const (
 	errNotReadyForConsistentReads = "Not ready to serve consistent reads"
 	errSegmentsNotSupported       = "Network segments are not supported in this version of Consul"
 	errRPCRateExceeded            = "RPC rate limit exceeded"
+	errRPCSourceLimitExceeded     = "RPC limit for source exceeded"
 	errServiceNotFound            = "Service not found: "
+	errServiceNameDenied          = "Service registration denied by the registration policy"
 	errQueryNotFound              = "Query not found"
 	errLeaderNotTracked           = "Raft leader not found in server lookup mapping"
 )
@@ -25,6 +27,8 @@ var (
 	ErrNotReadyForConsistentReads = errors.New(errNotReadyForConsistentReads)
 	ErrSegmentsNotSupported       = errors.New(errSegmentsNotSupported)
 	ErrRPCRateExceeded            = errors.New(errRPCRateExceeded)
+	ErrRPCSourceLimitExceeded     = errors.New(errRPCSourceLimitExceeded)
+	ErrServiceNameDenied          = errors.New(errServiceNameDenied)
 	ErrDCNotAvailable             = errors.New(errDCNotAvailable)
 	ErrQueryNotFound              = errors.New(errQueryNotFound)
 	ErrLeaderNotTracked           = errors.New(errLeaderNotTracked)
@@ -46,6 +50,14 @@ func IsErrRPCRateExceeded(err error) bool {
 	return err != nil && strings.Contains(err.Error(), errRPCRateExceeded)
 }
 
+func IsErrRPCSourceLimitExceeded(err error) bool {
+	return err != nil && strings.Contains(err.Error(), errRPCSourceLimitExceeded)
+}
+
+func IsErrServiceNameDenied(err error) bool {
+	return err != nil && strings.Contains(err.Error(), errServiceNameDenied)
+}
+
 func IsErrServiceNotFound(err error) bool {
 	return err != nil && strings.Contains(err.Error(), errServiceNotFound)
 }
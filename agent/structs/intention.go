@@ -691,6 +691,10 @@ type IntentionQueryCheck struct {
 
 	// SourceType is the type of the value for the source.
 	SourceType IntentionSourceType
+
+	// DestinationPeer is set when the destination is imported from a peer.
+	// The check is then answered from the intentions replicated by that peer.
+	DestinationPeer string `json:",omitempty"`
 }
 
 // GetACLPrefix returns the prefix to look up the ACL policy for this
@@ -809,3 +813,259 @@ func (s IntentionPrecedenceSorter) Less(i, j int) bool {
 	}
 	return a.DestinationName < b.DestinationName
 }
+
+// ExportedIntention is the normalized form of an intention returned by
+// /v1/connect/intentions/export. It only holds the fields that affect which
+// connections are authorized, so exports can be compared between clusters.
+type ExportedIntention struct {
+	SourcePeer           string `json:",omitempty"`
+	SourcePartition      string `json:",omitempty"`
+	SourceNS             string
+	SourceName           string
+	DestinationPartition string `json:",omitempty"`
+	DestinationNS        string
+	DestinationName      string
+
+	Action      IntentionAction        `json:",omitempty"`
+	Permissions []*IntentionPermission `json:",omitempty"`
+	Precedence  int
+}
+
+// IntentionResolution lists the intentions applying to a destination, in the
+// order they are evaluated: the first one matching a source decides whether
+// the connection is authorized.
+type IntentionResolution struct {
+	DestinationPartition string `json:",omitempty"`
+	DestinationNS        string
+	DestinationName      string
+
+	// Intentions are the IDs of the exported intentions, as returned by
+	// ExportedIntention.Key.
+	Intentions []string
+}
+
+// IntentionsExport is the result of /v1/connect/intentions/export.
+type IntentionsExport struct {
+	// Intentions are all the intentions, sorted by precedence.
+	Intentions []*ExportedIntention
+
+	// Resolution lists the intentions applying to each destination that is
+	// not a wildcard, sorted by destination.
+	Resolution []*IntentionResolution
+}
+
+// NewExportedIntention returns the normalized form of the intention.
+func NewExportedIntention(ixn *Intention) *ExportedIntention {
+	exported := &ExportedIntention{
+		SourcePeer:           ixn.SourcePeer,
+		SourcePartition:      ixn.SourcePartition,
+		SourceNS:             ixn.SourceNS,
+		SourceName:           ixn.SourceName,
+		DestinationPartition: ixn.DestinationPartition,
+		DestinationNS:        ixn.DestinationNS,
+		DestinationName:      ixn.DestinationName,
+		Precedence:           ixn.Precedence,
+	}
+	if len(ixn.Permissions) > 0 {
+		exported.Permissions = ixn.Permissions
+	} else {
+		exported.Action = ixn.Action
+	}
+	return exported
+}
+
+// Key identifies an exported intention by its source and destination.
+func (x *ExportedIntention) Key() string {
+	src := x.SourceNS + "/" + x.SourceName
+	if x.SourcePartition != "" {
+		src = x.SourcePartition + "/" + src
+	}
+	if x.SourcePeer != "" {
+		src = "peer(" + x.SourcePeer + ")/" + src
+	}
+	dst := x.DestinationNS + "/" + x.DestinationName
+	if x.DestinationPartition != "" {
+		dst = x.DestinationPartition + "/" + dst
+	}
+	return src + " => " + dst
+}
+
+// appliesTo returns whether the intention applies to connections to the
+// given destination.
+func (x *ExportedIntention) appliesTo(partition, ns, name string) bool {
+	if x.DestinationPartition != partition {
+		return false
+	}
+	if x.DestinationNS != WildcardSpecifier && x.DestinationNS != ns {
+		return false
+	}
+	return x.DestinationName == WildcardSpecifier || x.DestinationName == name
+}
+
+// ExportIntentions normalizes the intentions and resolves, for each
+// destination they explicitly name, the order in which they are evaluated.
+func ExportIntentions(ixns Intentions) *IntentionsExport {
+	sorted := make(Intentions, len(ixns))
+	copy(sorted, ixns)
+	sort.Sort(IntentionPrecedenceSorter(sorted))
+
+	export := &IntentionsExport{
+		Intentions: make([]*ExportedIntention, 0, len(sorted)),
+		Resolution: []*IntentionResolution{},
+	}
+	for _, ixn := range sorted {
+		export.Intentions = append(export.Intentions, NewExportedIntention(ixn))
+	}
+
+	seen := make(map[string]struct{})
+	for _, dst := range export.Intentions {
+		if dst.DestinationNS == WildcardSpecifier || dst.DestinationName == WildcardSpecifier {
+			continue
+		}
+		key := dst.DestinationPartition + "/" + dst.DestinationNS + "/" + dst.DestinationName
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		res := &IntentionResolution{
+			DestinationPartition: dst.DestinationPartition,
+			DestinationNS:        dst.DestinationNS,
+			DestinationName:      dst.DestinationName,
+		}
+		for _, ixn := range export.Intentions {
+			if ixn.appliesTo(dst.DestinationPartition, dst.DestinationNS, dst.DestinationName) {
+				res.Intentions = append(res.Intentions, ixn.Key())
+			}
+		}
+		export.Resolution = append(export.Resolution, res)
+	}
+	sort.Slice(export.Resolution, func(i, j int) bool {
+		a, b := export.Resolution[i], export.Resolution[j]
+		if a.DestinationPartition != b.DestinationPartition {
+			return a.DestinationPartition < b.DestinationPartition
+		}
+		if a.DestinationNS != b.DestinationNS {
+			return a.DestinationNS < b.DestinationNS
+		}
+		return a.DestinationName < b.DestinationName
+	})
+
+	return export
+}
+
+// IntentionSimulateRequest is used to simulate a change of the intentions
+// against a sample of the traffic, to find the connections it would deny or
+// allow before applying it.
+type IntentionSimulateRequest struct {
+	Datacenter string
+
+	// Flows is the sample of the traffic, for example gathered from the
+	// access logs of the proxies.
+	Flows []IntentionSimulateFlow
+
+	// Entries are the proposed service-intentions config entries. Each one
+	// replaces the intentions of its destination, so an entry without
+	// sources removes them.
+	Entries []*ServiceIntentionsConfigEntry
+
+	// DefaultAllow is the proposed default decision for connections that
+	// match no intention. The current one is kept when it is nil.
+	DefaultAllow *bool
+
+	QueryOptions
+}
+
+func (r *IntentionSimulateRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// IntentionSimulateFlow is a connection observed between two services.
+type IntentionSimulateFlow struct {
+	SourcePeer           string `json:",omitempty"`
+	SourcePartition      string `json:",omitempty"`
+	SourceNS             string `json:",omitempty"`
+	SourceName           string
+	DestinationPartition string `json:",omitempty"`
+	DestinationNS        string `json:",omitempty"`
+	DestinationName      string
+
+	// Count is how many times the connection was observed. It is only
+	// reported back to rank the results.
+	Count uint64 `json:",omitempty"`
+}
+
+// IntentionSimulateResult is the decision for a flow with the current and
+// the proposed intentions.
+type IntentionSimulateResult struct {
+	Flow IntentionSimulateFlow
+
+	// Allowed and ProposedAllowed are whether the connection is allowed by
+	// the current and the proposed intentions. Connections matching an
+	// intention with L7 permissions are allowed, since the requests are
+	// authorized by the permissions.
+	Allowed         bool
+	ProposedAllowed bool
+
+	// Intention and ProposedIntention are the intentions deciding whether
+	// the connection is allowed, or nil if the default decision applies.
+	Intention         *ExportedIntention `json:",omitempty"`
+	ProposedIntention *ExportedIntention `json:",omitempty"`
+}
+
+// IntentionSimulateResponse reports the flows whose decision changes with
+// the proposed intentions.
+type IntentionSimulateResponse struct {
+	// NewlyDenied and NewlyAllowed are the flows the proposed intentions
+	// would deny and allow, sorted by decreasing count.
+	NewlyDenied  []*IntentionSimulateResult
+	NewlyAllowed []*IntentionSimulateResult
+
+	// Unchanged is the number of flows whose decision doesn't change.
+	Unchanged int
+
+	// DefaultAllow and ProposedDefaultAllow are the current and proposed
+	// default decisions.
+	DefaultAllow         bool
+	ProposedDefaultAllow bool
+
+	QueryMeta
+}
+
+// IntentionBulkApplyRequest replaces all the intentions of a destination at
+// once, so that a set of intentions can be reconciled without going through
+// intermediate states.
+type IntentionBulkApplyRequest struct {
+	Datacenter string
+
+	// Entry holds the complete set of intentions of its destination. The
+	// intentions of the destination missing from it are deleted, so an entry
+	// without sources deletes all of them.
+	Entry *ServiceIntentionsConfigEntry
+
+	// Preview only computes the changes, without applying them.
+	Preview bool
+
+	WriteRequest
+}
+
+func (r *IntentionBulkApplyRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// IntentionBulkApplyResponse reports the changes made, or that would be made
+// in preview mode, to the intentions of a destination.
+type IntentionBulkApplyResponse struct {
+	// Created, Updated and Deleted are the intentions created, modified and
+	// removed by the request.
+	Created Intentions
+	Updated Intentions
+	Deleted Intentions
+
+	// Unchanged is the number of intentions left as they were.
+	Unchanged int
+
+	// Applied is whether the changes were applied. It is false in preview
+	// mode and when there is nothing to change.
+	Applied bool
+}
@@ -363,6 +363,59 @@ func TestIntentionPrecedenceSorter(t *testing.T) {
 	}
 }
 
+func TestExportIntentions(t *testing.T) {
+	ixn := func(src, dst string, action IntentionAction) *Intention {
+		x := &Intention{
+			SourceNS:        "default",
+			SourceName:      src,
+			DestinationNS:   "default",
+			DestinationName: dst,
+			Action:          action,
+		}
+		x.UpdatePrecedence()
+		return x
+	}
+	l7 := ixn("api", "db", "")
+	l7.Permissions = []*IntentionPermission{{Action: IntentionActionAllow, HTTP: &IntentionHTTPPermission{PathPrefix: "/v1"}}}
+
+	export := ExportIntentions(Intentions{
+		ixn("*", "*", IntentionActionDeny),
+		ixn("web", "*", IntentionActionAllow),
+		ixn("*", "db", IntentionActionDeny),
+		ixn("web", "db", IntentionActionAllow),
+		l7,
+	})
+
+	var keys []string
+	for _, x := range export.Intentions {
+		keys = append(keys, x.Key())
+	}
+	require.Equal(t, []string{
+		"default/api => default/db",
+		"default/web => default/db",
+		"default/* => default/db",
+		"default/web => default/*",
+		"default/* => default/*",
+	}, keys)
+	require.Empty(t, export.Intentions[0].Action)
+	require.Len(t, export.Intentions[0].Permissions, 1)
+	require.Equal(t, IntentionActionAllow, export.Intentions[1].Action)
+
+	require.Equal(t, []*IntentionResolution{
+		{
+			DestinationNS:   "default",
+			DestinationName: "db",
+			Intentions: []string{
+				"default/api => default/db",
+				"default/web => default/db",
+				"default/* => default/db",
+				"default/web => default/*",
+				"default/* => default/*",
+			},
+		},
+	}, export.Resolution)
+}
+
 func TestIntention_SetHash(t *testing.T) {
 	i := Intention{
 		ID:              "the-id",
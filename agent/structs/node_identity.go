@@ -0,0 +1,134 @@
+package structs
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/acl"
+)
+
+// NodeIdentity is the node metadata the servers verified when configuring an
+// agent with auto-config, for instance the cloud instance ID or zone found in
+// the claims of its intro token. The servers sign it so that the agent can
+// present it when registering its node, and the servers then store the
+// metadata under keys prefixed with MetaVerifiedPrefix.
+type NodeIdentity struct {
+	Datacenter string
+	Partition  string `json:",omitempty"`
+	Node       string
+
+	// Meta is the verified metadata, without MetaVerifiedPrefix.
+	Meta map[string]string
+
+	IssuedAt time.Time
+}
+
+// PartitionOrDefault returns the partition of the node.
+func (n *NodeIdentity) PartitionOrDefault() string {
+	return acl.PartitionOrDefault(n.Partition)
+}
+
+// VerifiedMeta returns the metadata as stored on the node.
+func (n *NodeIdentity) VerifiedMeta() map[string]string {
+	meta := make(map[string]string, len(n.Meta))
+	for k, v := range n.Meta {
+		meta[MetaVerifiedPrefix+k] = v
+	}
+	return meta
+}
+
+// SignNodeIdentity encodes the node identity and signs it with key.
+func SignNodeIdentity(key []byte, id *NodeIdentity) (string, error) {
+	payload, err := json.Marshal(id)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(signNodeIdentity(key, encoded)), nil
+}
+
+// ParseNodeIdentity decodes a signed node identity without verifying its
+// signature, which is left to the servers.
+func ParseNodeIdentity(signed string) (*NodeIdentity, error) {
+	encoded, _, ok := strings.Cut(signed, ".")
+	if !ok {
+		return nil, errors.New("malformed node identity")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("malformed node identity: %w", err)
+	}
+	var id NodeIdentity
+	if err := json.Unmarshal(payload, &id); err != nil {
+		return nil, fmt.Errorf("malformed node identity: %w", err)
+	}
+	return &id, nil
+}
+
+// VerifyNodeIdentity checks that signed was signed with key and decodes it.
+func VerifyNodeIdentity(key []byte, signed string) (*NodeIdentity, error) {
+	encoded, sig, ok := strings.Cut(signed, ".")
+	if !ok {
+		return nil, errors.New("malformed node identity")
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return nil, fmt.Errorf("malformed node identity: %w", err)
+	}
+	if !hmac.Equal(decoded, signNodeIdentity(key, encoded)) {
+		return nil, errors.New("invalid node identity signature")
+	}
+	return ParseNodeIdentity(signed)
+}
+
+func signNodeIdentity(key []byte, encoded string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+// IsVerifiedNodeMetaKey returns whether key holds verified node metadata.
+func IsVerifiedNodeMetaKey(key string) bool {
+	return strings.HasPrefix(key, MetaVerifiedPrefix)
+}
+
+// WithVerifiedNodeMeta returns a copy of meta where the verified metadata is
+// replaced by verified.
+func WithVerifiedNodeMeta(meta, verified map[string]string) map[string]string {
+	if meta == nil && len(verified) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(meta)+len(verified))
+	for k, v := range meta {
+		if !IsVerifiedNodeMetaKey(k) {
+			out[k] = v
+		}
+	}
+	for k, v := range verified {
+		if IsVerifiedNodeMetaKey(k) {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// VerifiedNodeMeta returns the verified metadata held in meta.
+func VerifiedNodeMeta(meta map[string]string) map[string]string {
+	var out map[string]string
+	for k, v := range meta {
+		if !IsVerifiedNodeMetaKey(k) {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string)
+		}
+		out[k] = v
+	}
+	return out
+}
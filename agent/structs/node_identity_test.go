@@ -0,0 +1,50 @@
+package structs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeIdentity_SignAndVerify(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	id := &NodeIdentity{
+		Datacenter: "dc1",
+		Node:       "node1",
+		Meta:       map[string]string{"zone": "us-east-1a"},
+	}
+
+	signed, err := SignNodeIdentity(key, id)
+	require.NoError(t, err)
+
+	verified, err := VerifyNodeIdentity(key, signed)
+	require.NoError(t, err)
+	require.Equal(t, id, verified)
+	require.Equal(t, map[string]string{"consul-verified-zone": "us-east-1a"}, verified.VerifiedMeta())
+
+	_, err = VerifyNodeIdentity([]byte("another key"), signed)
+	require.Error(t, err)
+
+	tampered, err := SignNodeIdentity([]byte("another key"), &NodeIdentity{Datacenter: "dc1", Node: "node2"})
+	require.NoError(t, err)
+	payload, _, _ := strings.Cut(tampered, ".")
+	_, sig, _ := strings.Cut(signed, ".")
+	_, err = VerifyNodeIdentity(key, payload+"."+sig)
+	require.Error(t, err)
+
+	_, err = VerifyNodeIdentity(key, "garbage")
+	require.Error(t, err)
+}
+
+func TestWithVerifiedNodeMeta(t *testing.T) {
+	meta := map[string]string{"rack": "1", "consul-verified-zone": "spoofed"}
+
+	require.Equal(t, map[string]string{"rack": "1"}, WithVerifiedNodeMeta(meta, nil))
+	require.Equal(t,
+		map[string]string{"rack": "1", "consul-verified-zone": "us-east-1a"},
+		WithVerifiedNodeMeta(meta, map[string]string{"consul-verified-zone": "us-east-1a", "rack": "2"}),
+	)
+	require.Nil(t, WithVerifiedNodeMeta(nil, nil))
+	require.Equal(t, map[string]string{"consul-verified-zone": "spoofed"}, VerifiedNodeMeta(meta))
+}
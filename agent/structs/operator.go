@@ -2,6 +2,7 @@ package structs
 
 import (
 	"net"
+	"time"
 
 	"github.com/hashicorp/raft"
 )
@@ -64,6 +65,402 @@ func (op *RaftRemovePeerRequest) RequestDatacenter() string {
 	return op.Datacenter
 }
 
+// RaftEntry describes a Raft log entry that was applied to the FSM.
+type RaftEntry struct {
+	// Index is the Raft index of the entry.
+	Index uint64
+
+	// Type is the message type of the entry, such as "Register" or "KVS".
+	Type string
+
+	// Size is the size of the entry in bytes.
+	Size int
+
+	// ApplyTime is how long the FSM took to apply the entry.
+	ApplyTime time.Duration
+
+	// AppliedAt is when the FSM applied the entry.
+	AppliedAt time.Time
+}
+
+// RaftLargestEntriesRequest is used by the Operator endpoint to query the
+// largest Raft log entries recently applied to the FSM of a server.
+type RaftLargestEntriesRequest struct {
+	// Datacenter is the target this request is intended for.
+	Datacenter string
+
+	// Limit is the maximum number of entries to return.
+	Limit int
+
+	// QueryOptions holds the ACL token to go along with this request. With
+	// AllowStale set the request is answered by the server receiving it
+	// instead of the leader.
+	QueryOptions
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (op *RaftLargestEntriesRequest) RequestDatacenter() string {
+	return op.Datacenter
+}
+
+// RaftLargestEntriesResponse is returned when querying for the largest Raft
+// log entries recently applied to the FSM of a server.
+type RaftLargestEntriesResponse struct {
+	// Server is the node name of the server that answered the request.
+	Server string
+
+	// Entries are the largest entries, sorted by decreasing size.
+	Entries []RaftEntry
+}
+
+// RaftCompactionRequest is used by the Operator endpoint to report on the
+// Raft log of a server, or to compact it.
+type RaftCompactionRequest struct {
+	// Datacenter is the target this request is intended for.
+	Datacenter string
+
+	// QueryOptions holds the ACL token to go along with this request. With
+	// AllowStale set a report is answered, or a compaction run, by the server
+	// receiving it instead of the leader. The leader runs compactions on every
+	// server.
+	QueryOptions
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (op *RaftCompactionRequest) RequestDatacenter() string {
+	return op.Datacenter
+}
+
+// RaftLogTypeUsage describes the share of the Raft log taken by one type of
+// entry.
+type RaftLogTypeUsage struct {
+	// Type is the message type of the entries, such as "Register" or "KVS".
+	// Entries that aren't FSM commands are reported by their Raft log type,
+	// and the parts of a chunked command as "Chunk".
+	Type string
+
+	// Entries is the number of entries of this type in the log.
+	Entries int
+
+	// Bytes is the total size of the entries of this type.
+	Bytes int64
+}
+
+// RaftCompactionReport describes the Raft log of a server and the snapshot
+// policy used to compact it.
+type RaftCompactionReport struct {
+	// Server is the node name of the server that answered the request.
+	Server string
+
+	// FirstIndex and LastIndex are the bounds of the log on disk.
+	FirstIndex uint64
+	LastIndex  uint64
+
+	// LastSnapshotIndex is the index of the last snapshot taken.
+	LastSnapshotIndex uint64
+
+	// AutoTune is true when the snapshot threshold and interval are tuned
+	// automatically.
+	AutoTune bool
+
+	// SnapshotThreshold and SnapshotInterval are the values currently used
+	// by Raft, which differ from the configured ones when AutoTune is set.
+	SnapshotThreshold uint64
+	SnapshotInterval  time.Duration
+
+	// TrailingLogs is the number of entries kept in the log after a
+	// snapshot.
+	TrailingLogs uint64
+
+	// LogGrowthRate is the recent number of entries appended to the log per
+	// second.
+	LogGrowthRate float64
+
+	// LastSnapshotBytes and LastSnapshotDuration describe the last snapshot
+	// written by this server since it started, if any.
+	LastSnapshotBytes    int64
+	LastSnapshotDuration time.Duration
+
+	// UsageFirstIndex is the first index of the entries Usage is computed
+	// from. Only the most recent entries of long logs are scanned.
+	UsageFirstIndex uint64
+
+	// Usage is the share of the log taken by each type of entry, sorted by
+	// decreasing size.
+	Usage []RaftLogTypeUsage
+
+	// Servers are the reports of the other servers compacted along with the
+	// leader, sorted by name.
+	Servers []RaftCompactionReport `json:",omitempty"`
+
+	// Error is why the server couldn't be compacted.
+	Error string `json:",omitempty"`
+}
+
+// RaftLogsRequest is used by the Operator endpoint to page through the Raft
+// log of a server.
+type RaftLogsRequest struct {
+	// Datacenter is the target this request is intended for.
+	Datacenter string
+
+	// Index is the highest index of the page of entries to return. Zero
+	// starts from the last entry applied to the FSM.
+	Index uint64
+
+	// Limit is the maximum number of entries to return.
+	Limit int
+
+	// QueryOptions holds the ACL token to go along with this request. With
+	// AllowStale set the request is answered by the server receiving it
+	// instead of the leader.
+	QueryOptions
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (op *RaftLogsRequest) RequestDatacenter() string {
+	return op.Datacenter
+}
+
+// RaftLogEntry describes an entry of the Raft log.
+type RaftLogEntry struct {
+	// Index and Term are the Raft index and term of the entry.
+	Index uint64
+	Term  uint64
+
+	// Type is the message type of the entry, such as "Register" or "KVS".
+	// Entries that aren't FSM commands are reported by their Raft log type,
+	// and the parts of a chunked command as "Chunk".
+	Type string
+
+	// Size is the size of the entry in bytes.
+	Size int
+
+	// Summary is a short description of the entry, such as the key of a
+	// KVS entry. It is empty for the types that aren't summarized, and
+	// unless the token has operator:write access and can read the resources
+	// of the entry.
+	Summary string
+
+	// AppendedAt is when the leader appended the entry to its log, if known.
+	AppendedAt time.Time
+}
+
+// RaftLogsResponse is returned when paging through the Raft log of a server.
+type RaftLogsResponse struct {
+	// Server is the node name of the server that answered the request.
+	Server string
+
+	// FirstIndex is the first index of the log on disk, and AppliedIndex
+	// the index of the last entry applied to the FSM.
+	FirstIndex   uint64
+	AppliedIndex uint64
+
+	// Entries are the entries of the page, sorted by decreasing index.
+	Entries []RaftLogEntry
+
+	// NextIndex is the index to request the next page of older entries
+	// with, or zero when the first entry on disk was reached.
+	NextIndex uint64
+}
+
+// RaftLogsReplayRequest is used by the Operator endpoint to replay a range
+// of the Raft log of a server into a scratch FSM.
+type RaftLogsReplayRequest struct {
+	// Datacenter is the target this request is intended for.
+	Datacenter string
+
+	// StartIndex and EndIndex are the bounds of the range to replay. A zero
+	// EndIndex replays up to the last entry applied to the FSM.
+	StartIndex uint64
+	EndIndex   uint64
+
+	// QueryOptions holds the ACL token to go along with this request. With
+	// AllowStale set the request is answered by the server receiving it
+	// instead of the leader.
+	QueryOptions
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (op *RaftLogsReplayRequest) RequestDatacenter() string {
+	return op.Datacenter
+}
+
+// RaftLogReplayError describes an entry that failed to apply during a
+// replay.
+type RaftLogReplayError struct {
+	// Index is the Raft index of the entry.
+	Index uint64
+
+	// Type is the message type of the entry.
+	Type string
+
+	// Error is the error returned by the FSM.
+	Error string
+}
+
+// RaftLogsReplayResponse is returned when replaying a range of the Raft log
+// of a server into a scratch FSM. Comparing the responses of several servers
+// for the same range helps finding where their states diverged.
+type RaftLogsReplayResponse struct {
+	// Server is the node name of the server that answered the request.
+	Server string
+
+	// StartIndex and EndIndex are the bounds of the replayed range.
+	StartIndex uint64
+	EndIndex   uint64
+
+	// Applied is the number of commands applied to the scratch FSM.
+	Applied int
+
+	// Errors are the entries which failed to apply.
+	Errors []RaftLogReplayError
+
+	// Indexes is the index of the last change of each table of the scratch
+	// state store.
+	Indexes map[string]uint64
+
+	// Nodes, Services, ServiceInstances and KVEntries count the rows of the
+	// scratch state store.
+	Nodes            int
+	Services         int
+	ServiceInstances int
+	KVEntries        int
+}
+
+// RaftTransferLeaderRequest is used by the Operator endpoint to transfer the
+// leadership of the cluster to another server.
+type RaftTransferLeaderRequest struct {
+	// Datacenter is the target this request is intended for.
+	Datacenter string
+
+	// ID is the ID of the server to transfer the leadership to. When empty,
+	// the most up-to-date voter passing the pre-flight checks is chosen.
+	ID raft.ServerID
+
+	// DryRun only runs the pre-flight checks.
+	DryRun bool
+
+	// Force transfers the leadership even when the pre-flight checks fail.
+	Force bool
+
+	// WriteRequest holds the ACL token to go along with this request.
+	WriteRequest
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (op *RaftTransferLeaderRequest) RequestDatacenter() string {
+	return op.Datacenter
+}
+
+// RaftTransferCheck is the result of a pre-flight check of a leadership
+// transfer.
+type RaftTransferCheck struct {
+	// Name is the name of the check, such as "Health", "Replication" or
+	// "Version".
+	Name string
+
+	// Passed is true when the candidate passed the check.
+	Passed bool
+
+	// Output describes the result of the check.
+	Output string
+}
+
+// RaftTransferLeaderResponse is returned when transferring the leadership of
+// the cluster.
+type RaftTransferLeaderResponse struct {
+	// ID, Node and Address describe the server chosen to become the leader.
+	ID      raft.ServerID
+	Node    string
+	Address raft.ServerAddress
+
+	// Checks are the results of the pre-flight checks of the candidate, and
+	// Passed is true when all of them passed.
+	Checks []RaftTransferCheck
+	Passed bool
+
+	// Transferred is true when the candidate took over the leadership.
+	Transferred bool
+}
+
+// RaftTransferLeaderEvent is an update of the status of a leadership transfer,
+// streamed by the HTTP API.
+type RaftTransferLeaderEvent struct {
+	// Status is one of "checked", "transferring", "transferred" or
+	// "failed".
+	Status string
+
+	// ID and Node describe the server chosen to become the leader.
+	ID   raft.ServerID `json:",omitempty"`
+	Node string        `json:",omitempty"`
+
+	// Checks are the results of the pre-flight checks, sent with the
+	// "checked" status.
+	Checks []RaftTransferCheck `json:",omitempty"`
+
+	// Leader is the node name of the new leader, sent with the "transferred"
+	// status.
+	Leader string `json:",omitempty"`
+
+	// Error is why the transfer failed, sent with the "failed" status.
+	Error string `json:",omitempty"`
+}
+
+// OperatorUsageRequest is used by the Operator endpoint to report on the
+// contents of the state store.
+type OperatorUsageRequest struct {
+	// Datacenter is the target this request is intended for.
+	Datacenter string
+
+	// Detailed requests the number of rows and estimated memory of each
+	// table of the state store.
+	Detailed bool
+
+	// QueryOptions holds the ACL token to go along with this request.
+	QueryOptions
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (op *OperatorUsageRequest) RequestDatacenter() string {
+	return op.Datacenter
+}
+
+// TableUsage describes the rows of a state store table.
+type TableUsage struct {
+	// Table is the name of the memdb table.
+	Table string
+
+	// Rows is the number of rows in the table.
+	Rows int
+
+	// Bytes is an estimate of the memory used by the rows of the table. It
+	// doesn't include the memory used by the indexes.
+	Bytes int64
+}
+
+// OperatorUsageResponse is returned when querying for the contents of the
+// state store.
+type OperatorUsageResponse struct {
+	// Server is the node name of the server that answered the request.
+	Server string
+
+	Nodes            int
+	Services         int
+	ServiceInstances int
+	KVEntries        int
+
+	// ConfigEntries is the number of config entries of each kind.
+	ConfigEntries map[string]int
+
+	// Tables is the usage of each table of the state store, sorted by name.
+	// It is only set for detailed requests, and is computed periodically,
+	// TablesUpdatedAt being the last time it was.
+	Tables          []TableUsage
+	TablesUpdatedAt time.Time
+
+	QueryMeta
+}
+
 // AutopilotSetConfigRequest is used by the Operator endpoint to update the
 // current Autopilot configuration of the cluster.
 type AutopilotSetConfigRequest struct {
@@ -85,7 +482,7 @@ func (op *AutopilotSetConfigRequest) RequestDatacenter() string {
 	return op.Datacenter
 }
 
-// (Enterprise-only) NetworkSegment is the configuration for a network segment, which is an
+// NetworkSegment is the configuration for a network segment, which is an
 // isolated serf group on the LAN.
 type NetworkSegment struct {
 	// Name is the name of the segment.
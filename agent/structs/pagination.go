@@ -0,0 +1,38 @@
+package structs
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidNextToken is returned when QueryOptions.NextToken was not
+// generated by a list endpoint.
+var ErrInvalidNextToken = errors.New("invalid next token")
+
+// EncodePageToken returns the opaque token pointing right after the result
+// identified by key. The key is made of the fields the results of a list
+// endpoint are ordered by.
+func EncodePageToken(key []string) string {
+	buf, err := json.Marshal(key)
+	if err != nil {
+		// Marshaling a slice of strings can't fail.
+		panic(fmt.Sprintf("failed to encode the page token: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// DecodePageToken returns the key of the last result of the page the token
+// was generated for.
+func DecodePageToken(token string) ([]string, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidNextToken
+	}
+	var key []string
+	if err := json.Unmarshal(buf, &key); err != nil || len(key) == 0 {
+		return nil, ErrInvalidNextToken
+	}
+	return key, nil
+}
@@ -24,21 +24,39 @@ type ExportedServiceList struct {
 
 	// TODO(peering): reduce duplication here in the response
 	ConnectProtocol map[ServiceName]string
+
+	// IgnoreCatalogWeights holds the exported services whose service-defaults
+	// entry sets IgnoreCatalogWeights.
+	IgnoreCatalogWeights map[ServiceName]bool
+}
+
+// ExportedDiscoveryChainInfo is the information replicated to the peer
+// alongside an exported discovery chain.
+type ExportedDiscoveryChainInfo struct {
+	Protocol             string
+	IgnoreCatalogWeights bool
 }
 
 // ListAllDiscoveryChains returns all discovery chains (union of Services and
 // DiscoChains).
-func (list *ExportedServiceList) ListAllDiscoveryChains() map[ServiceName]string {
-	chainsByName := make(map[ServiceName]string)
+func (list *ExportedServiceList) ListAllDiscoveryChains() map[ServiceName]ExportedDiscoveryChainInfo {
+	chainsByName := make(map[ServiceName]ExportedDiscoveryChainInfo)
 	if list == nil {
 		return chainsByName
 	}
 
 	for _, svc := range list.Services {
-		chainsByName[svc] = list.ConnectProtocol[svc]
+		chainsByName[svc] = list.chainInfo(svc)
 	}
 	for _, chainName := range list.DiscoChains {
-		chainsByName[chainName] = list.ConnectProtocol[chainName]
+		chainsByName[chainName] = list.chainInfo(chainName)
 	}
 	return chainsByName
 }
+
+func (list *ExportedServiceList) chainInfo(sn ServiceName) ExportedDiscoveryChainInfo {
+	return ExportedDiscoveryChainInfo{
+		Protocol:             list.ConnectProtocol[sn],
+		IgnoreCatalogWeights: list.IgnoreCatalogWeights[sn],
+	}
+}
@@ -21,6 +21,31 @@ type QueryDatacenterOptions struct {
 	// never try a datacenter multiple times, so those are subtracted from
 	// this list before proceeding.
 	Datacenters []string
+
+	// Targets is a fixed list of datacenters, partitions and cluster peers
+	// to try in order. It cannot be combined with NearestN or Datacenters.
+	Targets []QueryFailoverTarget `json:",omitempty"`
+}
+
+// QueryFailoverTarget is a place a query fails over to. The service is
+// looked up in a remote datacenter when Datacenter is set, among the services
+// imported from a cluster peer when Peer is set, and in the local datacenter
+// otherwise.
+type QueryFailoverTarget struct {
+	// Peer is the name of the cluster peer the service is imported from.
+	Peer string `json:",omitempty"`
+
+	// Datacenter is the name of the remote datacenter to query.
+	Datacenter string `json:",omitempty"`
+
+	// Partition is the admin partition to query, or the one the service is
+	// imported into when Peer is set. If empty the partition of the query
+	// is used.
+	Partition string `json:",omitempty"`
+
+	// Namespace is the namespace to query. If empty the namespace of the
+	// query is used.
+	Namespace string `json:",omitempty"`
 }
 
 // QueryDNSOptions controls settings when query results are served over DNS.
@@ -0,0 +1,14 @@
+//go:build !consulent
+// +build !consulent
+
+package structs
+
+import (
+	"github.com/hashicorp/consul/acl"
+)
+
+// GetEnterpriseMeta is used to synthesize the EnterpriseMeta struct from
+// fields in the QueryFailoverTarget
+func (target *QueryFailoverTarget) GetEnterpriseMeta(_ *acl.EnterpriseMeta) *acl.EnterpriseMeta {
+	return DefaultEnterpriseMetaInDefaultPartition()
+}
@@ -60,6 +60,15 @@ func (m *QueryOptions) GetMaxStaleDuration() (time.Duration, error) {
 	return 0, nil
 }
 
+// GetMaxIndexLag returns the lag allowed by the bounded_stale consistency
+// mode, zero if it isn't used.
+func (m *QueryOptions) GetMaxIndexLag() uint64 {
+	if m != nil {
+		return m.MaxIndexLag
+	}
+	return 0
+}
+
 // GetMaxAge helps implement the QueryOptionsCompat interface
 func (m *QueryOptions) GetMaxAge() (time.Duration, error) {
 	if m != nil {
@@ -127,6 +136,11 @@ func (q *QueryOptions) SetMaxStaleDuration(maxStaleDuration time.Duration) {
 	q.MaxStaleDuration = maxStaleDuration
 }
 
+// SetMaxIndexLag sets the lag allowed by the bounded_stale consistency mode.
+func (q *QueryOptions) SetMaxIndexLag(maxIndexLag uint64) {
+	q.MaxIndexLag = maxIndexLag
+}
+
 // SetMaxAge is needed to implement the structs.QueryOptionsCompat interface
 func (q *QueryOptions) SetMaxAge(maxAge time.Duration) {
 	q.MaxAge = maxAge
@@ -184,6 +198,12 @@ func (q *QueryMeta) SetLastContact(lastContact time.Duration) {
 	q.LastContact = lastContact
 }
 
+// SetIndexLag sets the number of Raft log entries the server answering the
+// query had not applied yet.
+func (q *QueryMeta) SetIndexLag(indexLag uint64) {
+	q.IndexLag = indexLag
+}
+
 // SetKnownLeader is needed to implement the structs.QueryMetaCompat interface
 func (q *QueryMeta) SetKnownLeader(knownLeader bool) {
 	q.KnownLeader = knownLeader
@@ -0,0 +1,120 @@
+package structs
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/types"
+)
+
+// ProxyStatusCheckType is the type of the checks carrying the status of the
+// connect proxies.
+const ProxyStatusCheckType = "proxy-status"
+
+// ProxyStatusReport is the state of a connect proxy as seen by the agent
+// serving its xDS stream. Agents sync it to the catalog in a check of the
+// proxy service rather than in the service itself, so that reports don't
+// modify the service, and the readiness of the proxy is derived from it at
+// query time.
+type ProxyStatusReport struct {
+	// XDSConnected is whether the proxy has an xDS stream open with its agent.
+	XDSConnected bool
+
+	// ConfigAccepted is whether the proxy acknowledged the configuration
+	// sent to it, and didn't reject any update since.
+	ConfigAccepted bool
+
+	// CertValidBefore is the expiry of the leaf certificate in the latest
+	// configuration of the proxy, zero when there is none.
+	CertValidBefore time.Time
+}
+
+// IsSame returns whether both reports are equal. The expiry of the
+// certificates is compared with time.Equal as reports are round-tripped
+// through the catalog.
+func (r *ProxyStatusReport) IsSame(other *ProxyStatusReport) bool {
+	if r == nil || other == nil {
+		return r == other
+	}
+	return r.XDSConnected == other.XDSConnected &&
+		r.ConfigAccepted == other.ConfigAccepted &&
+		r.CertValidBefore.Equal(other.CertValidBefore)
+}
+
+// ProxyStatusCheckID returns the ID of the check carrying the status of the
+// proxy with the given service ID.
+func ProxyStatusCheckID(serviceID string) types.CheckID {
+	return types.CheckID(ProxyStatusCheckPrefix + serviceID)
+}
+
+// HealthCheck returns the check carrying the report for the given proxy
+// service. The report is encoded in the output of the check, which is always
+// passing so that it doesn't change the health of the proxy.
+func (r *ProxyStatusReport) HealthCheck(svc *NodeService) (*HealthCheck, error) {
+	output, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return &HealthCheck{
+		CheckID:        ProxyStatusCheckID(svc.ID),
+		Name:           "Proxy Status",
+		Notes:          "Status of the proxy reported by the agent serving its xDS stream.",
+		Status:         api.HealthPassing,
+		Output:         string(output),
+		Type:           ProxyStatusCheckType,
+		ServiceID:      svc.ID,
+		ServiceName:    svc.Service,
+		EnterpriseMeta: svc.EnterpriseMeta,
+	}, nil
+}
+
+// ProxyStatusReportFromChecks returns the report carried by the checks of the
+// given proxy service, or nil when the agent of the proxy didn't report any.
+func ProxyStatusReportFromChecks(svc *NodeService, checks HealthChecks) *ProxyStatusReport {
+	id := ProxyStatusCheckID(svc.ID)
+	for _, check := range checks {
+		if check.CheckID != id || check.Type != ProxyStatusCheckType {
+			continue
+		}
+		var report ProxyStatusReport
+		if err := json.Unmarshal([]byte(check.Output), &report); err != nil {
+			return nil
+		}
+		return &report
+	}
+	return nil
+}
+
+// Status derives the status of the proxy at the given time. A proxy which
+// never reported its status is not ready.
+func (r *ProxyStatusReport) Status(now time.Time) *ProxyStatus {
+	if r == nil {
+		return &ProxyStatus{}
+	}
+
+	status := &ProxyStatus{
+		XDSConnected:   r.XDSConnected,
+		ConfigAccepted: r.ConfigAccepted,
+		CertValid:      !r.CertValidBefore.IsZero() && now.Before(r.CertValidBefore),
+	}
+	status.Ready = status.XDSConnected && status.ConfigAccepted && status.CertValid
+	return status
+}
+
+// ProxyStatus is the readiness of a connect proxy instance returned by the
+// health endpoints when requested.
+type ProxyStatus struct {
+	// XDSConnected is whether the proxy is connected to its agent.
+	XDSConnected bool
+
+	// ConfigAccepted is whether the proxy accepted its latest configuration.
+	ConfigAccepted bool
+
+	// CertValid is whether the leaf certificate of the proxy is valid.
+	CertValid bool
+
+	// Ready is whether the proxy is ready to receive traffic, which is when
+	// all the above are true.
+	Ready bool
+}
@@ -0,0 +1,46 @@
+package structs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyStatusReport_Status(t *testing.T) {
+	now := time.Now()
+
+	require.Equal(t, &ProxyStatus{}, (*ProxyStatusReport)(nil).Status(now))
+
+	report := &ProxyStatusReport{
+		XDSConnected:    true,
+		ConfigAccepted:  true,
+		CertValidBefore: now.Add(time.Hour),
+	}
+	require.Equal(t, &ProxyStatus{
+		XDSConnected:   true,
+		ConfigAccepted: true,
+		CertValid:      true,
+		Ready:          true,
+	}, report.Status(now))
+
+	// The certificate expired since it was reported.
+	require.Equal(t, &ProxyStatus{
+		XDSConnected:   true,
+		ConfigAccepted: true,
+	}, report.Status(now.Add(2*time.Hour)))
+
+	report.ConfigAccepted = false
+	require.False(t, report.Status(now).Ready)
+}
+
+func TestProxyStatusReport_IsSame(t *testing.T) {
+	now := time.Now()
+	report := &ProxyStatusReport{XDSConnected: true, CertValidBefore: now}
+
+	require.True(t, (*ProxyStatusReport)(nil).IsSame(nil))
+	require.False(t, report.IsSame(nil))
+	require.False(t, (*ProxyStatusReport)(nil).IsSame(report))
+	require.True(t, report.IsSame(&ProxyStatusReport{XDSConnected: true, CertValidBefore: now.UTC()}))
+	require.False(t, report.IsSame(&ProxyStatusReport{XDSConnected: true, ConfigAccepted: true, CertValidBefore: now}))
+}
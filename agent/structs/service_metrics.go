@@ -0,0 +1,93 @@
+package structs
+
+import (
+	"time"
+
+	"github.com/hashicorp/consul/acl"
+)
+
+// ServiceMetricsCounters are the requests between a pair of services
+// reported by the connect proxies of the source service.
+type ServiceMetricsCounters struct {
+	// Requests is the number of requests which completed, with or without
+	// an error.
+	Requests uint64
+
+	// Errors is the number of requests which failed, including the requests
+	// dropped by the proxy.
+	Errors uint64
+
+	// Duration is the total time taken by DurationSamples requests. Proxies
+	// report durations separately from the requests, so DurationSamples may
+	// differ from Requests over a given interval.
+	Duration        time.Duration
+	DurationSamples uint64
+}
+
+// Add adds the given counters to c.
+func (c *ServiceMetricsCounters) Add(other ServiceMetricsCounters) {
+	c.Requests += other.Requests
+	c.Errors += other.Errors
+	c.Duration += other.Duration
+	c.DurationSamples += other.DurationSamples
+}
+
+// ServiceMetricsSample are the requests from Source to Destination over the
+// interval of a ServiceMetricsReportRequest.
+type ServiceMetricsSample struct {
+	Source      ServiceName
+	Destination ServiceName
+	ServiceMetricsCounters
+}
+
+// ServiceMetricsReportRequest is used by agents to report the requests seen
+// by their local connect proxies since their previous report.
+type ServiceMetricsReportRequest struct {
+	Datacenter string
+	Node       string
+
+	// Interval is the time covered by the samples.
+	Interval time.Duration
+
+	Samples []ServiceMetricsSample
+
+	acl.EnterpriseMeta `hcl:",squash" mapstructure:",squash"`
+	WriteRequest
+}
+
+func (r *ServiceMetricsReportRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// ServiceMetricsSummary summarizes the requests from Source to Destination
+// over the window of an IndexedServiceMetrics.
+type ServiceMetricsSummary struct {
+	Source      ServiceName
+	Destination ServiceName
+	ServiceMetricsCounters
+
+	// RequestsPerSecond is the rate of the requests over the time covered
+	// by the reports, which is at most the window.
+	RequestsPerSecond float64
+
+	// ErrorRate is the ratio of the requests which failed, between 0 and 1.
+	ErrorRate float64
+
+	// MeanDuration is the average time taken by the requests.
+	MeanDuration time.Duration
+}
+
+// IndexedServiceMetrics is the traffic of a service with its upstreams and
+// downstreams, as reported by their connect proxies.
+type IndexedServiceMetrics struct {
+	// Window is the period the summaries are computed over.
+	Window time.Duration
+
+	// Upstreams are the requests from the service to its upstreams.
+	Upstreams []ServiceMetricsSummary
+
+	// Downstreams are the requests to the service from its downstreams.
+	Downstreams []ServiceMetricsSummary
+
+	QueryMeta
+}
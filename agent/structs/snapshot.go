@@ -7,6 +7,10 @@ const (
 	SnapshotRestore
 )
 
+// SnapshotCompressionZstd is the value of SnapshotRequest.Compression to
+// compress the streams of the request with zstd.
+const SnapshotCompressionZstd = "zstd"
+
 // SnapshotReplyFn gets a peek at the reply before the snapshot streams, which
 // is useful for setting headers.
 type SnapshotReplyFn func(reply *SnapshotResponse) error
@@ -29,6 +33,13 @@ type SnapshotRequest struct {
 
 	// Op is the operation code for the RPC.
 	Op SnapshotOp
+
+	// Compression is the encoding of the streaming data sent after the
+	// request and the response headers, in both directions. It only applies
+	// to a single hop: it is set by the caller when the server it connects
+	// to advertises support for it, and reset by servers forwarding the
+	// request. One of "" or SnapshotCompressionZstd.
+	Compression string
 }
 
 // SnapshotResponse is used header for a snapshot RPC response. This will
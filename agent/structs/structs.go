@@ -2,10 +2,12 @@ package structs
 
 import (
 	"bytes"
+	"compress/gzip"
 	"crypto/md5"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math/rand"
 	"reflect"
 	"regexp"
@@ -13,6 +15,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/duration"
@@ -84,6 +87,11 @@ const (
 	PeeringTerminateByIDType                    = 37
 	PeeringTrustBundleWriteType                 = 38
 	PeeringTrustBundleDeleteType                = 39
+	ConnectCAIssuedCertType                     = 40 // FSM snapshots only.
+	DeregistrationType                          = 41 // FSM snapshots only.
+	PeeringIntentionsWriteType                  = 42
+	PeeringIntentionsDeleteType                 = 43
+	ACLTokenUsageUpdateType                     = 44
 )
 
 const (
@@ -149,6 +157,11 @@ var requestTypeStrings = map[MessageType]string{
 	PeeringDeleteType:               "PeeringDelete",
 	PeeringTrustBundleWriteType:     "PeeringTrustBundle",
 	PeeringTrustBundleDeleteType:    "PeeringTrustBundleDelete",
+	ConnectCAIssuedCertType:         "ConnectCAIssuedCert", // FSM snapshots only.
+	DeregistrationType:              "Deregistration",      // FSM snapshots only.
+	PeeringIntentionsWriteType:      "PeeringIntentions",
+	PeeringIntentionsDeleteType:     "PeeringIntentionsDelete",
+	ACLTokenUsageUpdateType:         "ACLTokenUsageUpdate",
 }
 
 const (
@@ -165,6 +178,14 @@ const (
 	// ServiceMaintPrefix is the prefix for a service in maintenance mode.
 	ServiceMaintPrefix = "_service_maintenance:"
 
+	// MaintenanceWindowPrefix is the prefix of the checks registered by the
+	// leader while a maintenance-window config entry is in effect.
+	MaintenanceWindowPrefix = "_maintenance_window:"
+
+	// ProxyStatusCheckPrefix is the prefix of the checks carrying the status
+	// of the connect proxies reported by their agents.
+	ProxyStatusCheckPrefix = "_proxy_status:"
+
 	// The meta key prefix reserved for Consul's internal use
 	MetaKeyReservedPrefix = "consul-"
 
@@ -180,6 +201,11 @@ const (
 	// MetaSegmentKey is the node metadata key used to store the node's network segment
 	MetaSegmentKey = "consul-network-segment"
 
+	// MetaVerifiedPrefix is the prefix of the node metadata keys holding the
+	// values attested by the servers, as opposed to the ones reported by the
+	// agent itself. Only the servers set these keys.
+	MetaVerifiedPrefix = "consul-verified-"
+
 	// MetaWANFederationKey is the mesh gateway metadata key that indicates a
 	// mesh gateway is usable for wan federation.
 	MetaWANFederationKey = "consul-wan-federation"
@@ -187,6 +213,14 @@ const (
 	// MetaExternalSource is the metadata key used when a resource is managed by a source outside Consul like nomad/k8s
 	MetaExternalSource = "external-source"
 
+	// MetaDetectedProtocol is the service metadata key used to store the
+	// protocol a sidecar proxy detected on the inbound traffic of the service.
+	MetaDetectedProtocol = "consul-detected-protocol"
+
+	// MetaDetectedUpstreamProtocols is the connect proxy metadata key used to
+	// store the protocols the proxy detected on the traffic to its upstreams.
+	MetaDetectedUpstreamProtocols = "consul-detected-upstream-protocols"
+
 	// TaggedAddressVirtualIP is the key used to store tagged virtual IPs generated by Consul.
 	TaggedAddressVirtualIP = "consul-virtual"
 
@@ -215,6 +249,11 @@ const (
 
 var allowedConsulMetaKeysForMeshGateway = map[string]struct{}{MetaWANFederationKey: {}}
 
+var allowedConsulMetaKeysForServices = map[string]struct{}{
+	MetaDetectedProtocol:          {},
+	MetaDetectedUpstreamProtocols: {},
+}
+
 var (
 	NodeMaintCheckID = NewCheckID(NodeMaint, nil)
 )
@@ -281,6 +320,13 @@ type QueryOptions struct {
 	// read is older than value.
 	MaxStaleDuration time.Duration
 
+	// If set and AllowStale is true, the bounded_stale consistency mode is
+	// used: a follower only services the request if it heard from the leader
+	// within the Raft heartbeat timeout and the entries of the leader's log it
+	// hasn't applied yet are within MaxIndexLag, otherwise the request is
+	// forwarded to the leader.
+	MaxIndexLag uint64
+
 	// MaxAge limits how old a cached value will be returned if UseCache is true.
 	// If there is a cached response that is older than the MaxAge, it is treated
 	// as a cache miss and a new fetch invoked. If the fetch fails, the error is
@@ -312,6 +358,19 @@ type QueryOptions struct {
 	// QueryMeta.Index, the response can be left empty and QueryMeta.NotModified
 	// will be set to true to indicate the result of the query has not changed.
 	AllowNotModifiedResponse bool
+
+	// PerPage limits the number of results returned by list endpoints that
+	// support pagination. Zero returns every result.
+	PerPage int
+
+	// NextToken is the QueryMeta.NextToken of the previous page. If set, the
+	// results start right after the last one of that page.
+	NextToken string
+
+	// AnonymousScope is the name of the scope of the anonymous token used for
+	// the query when Token is empty. Servers set it from the source of the
+	// request, the value sent by other callers is replaced.
+	AnonymousScope string
 }
 
 // IsRead is always true for QueryOption.
@@ -323,6 +382,8 @@ func (q QueryOptions) IsRead() bool {
 func (q QueryOptions) ConsistencyLevel() string {
 	if q.RequireConsistent {
 		return "consistent"
+	} else if q.AllowStale && q.MaxIndexLag > 0 {
+		return "bounded_stale"
 	} else if q.AllowStale {
 		return "stale"
 	} else {
@@ -342,6 +403,16 @@ func (q *QueryOptions) SetTokenSecret(s string) {
 	q.Token = s
 }
 
+// GetAnonymousScope returns the scope of the anonymous token used for the
+// query when it has no token.
+func (q QueryOptions) GetAnonymousScope() string {
+	return q.AnonymousScope
+}
+
+func (q *QueryOptions) SetAnonymousScope(scope string) {
+	q.AnonymousScope = scope
+}
+
 func (q QueryOptions) Timeout(rpcHoldTimeout, maxQueryTime, defaultQueryTime time.Duration) time.Duration {
 	// Match logic in Server.blockingQuery.
 	if q.MinQueryIndex > 0 {
@@ -426,6 +497,11 @@ type QueryMeta struct {
 	// can be used to gauge staleness.
 	LastContact time.Duration
 
+	// IndexLag is the number of entries of the leader's log that the server
+	// answering the query had not applied yet. It is always zero when the
+	// leader answered.
+	IndexLag uint64
+
 	// Used to indicate if there is a known leader node
 	KnownLeader bool
 
@@ -447,6 +523,11 @@ type QueryMeta struct {
 	// filtered out by enforcing ACLs. It may be false because nothing was
 	// removed, or because the endpoint does not yet support this flag.
 	ResultsFilteredByACLs bool
+
+	// NextToken is set when QueryOptions.PerPage is used and there are more
+	// results to return. It must be passed as QueryOptions.NextToken to get
+	// the next page.
+	NextToken string
 }
 
 // RegisterRequest is used for the Catalog.Register endpoint
@@ -472,6 +553,16 @@ type RegisterRequest struct {
 
 	PeerName string
 
+	// NodeIdentity is the node identity attestation the agent received from
+	// the servers through auto-config. The servers replace the verified node
+	// metadata with the one it attests, see MetaVerifiedPrefix.
+	NodeIdentity string `json:",omitempty"`
+
+	// CompressedCheckOutputs holds the gzipped outputs of the checks which
+	// the servers moved out of Checks to shrink the Raft log, by check ID.
+	// See CompressCheckOutputs.
+	CompressedCheckOutputs map[types.CheckID][]byte `json:",omitempty"`
+
 	// EnterpriseMeta is the embedded enterprise metadata
 	acl.EnterpriseMeta `hcl:",squash" mapstructure:",squash"`
 
@@ -519,11 +610,20 @@ func (r *RegisterRequest) ChangesNode(node *Node) bool {
 // If a ServiceID is provided, any associated Checks with that service
 // are also deregistered.
 type DeregisterRequest struct {
-	Datacenter         string
-	Node               string
-	ServiceID          string
-	CheckID            types.CheckID
-	PeerName           string
+	Datacenter string
+	Node       string
+	ServiceID  string
+	CheckID    types.CheckID
+	PeerName   string
+
+	// DeregisteredBy, DeregisterReason and DeregisteredAt are set by the
+	// servers and recorded in the deregistration history. DeregisteredBy is
+	// the accessor ID of the token used, and DeregisterReason is set when the
+	// servers deregister a node on their own.
+	DeregisteredBy   string
+	DeregisterReason string
+	DeregisteredAt   time.Time
+
 	acl.EnterpriseMeta `hcl:",squash" mapstructure:",squash"`
 	WriteRequest
 }
@@ -607,13 +707,15 @@ func (r *DCSpecificRequest) CacheInfo() cache.RequestInfo {
 		MustRevalidate: r.MustRevalidate,
 	}
 
-	// To calculate the cache key we only hash the node meta filters and the bexpr filter.
-	// The datacenter is handled by the cache framework. The other fields are
-	// not, but should not be used in any cache types.
+	// To calculate the cache key we only hash the node meta filters, the bexpr
+	// filter and the page. The datacenter is handled by the cache framework.
+	// The other fields are not, but should not be used in any cache types.
 	v, err := hashstructure.Hash([]interface{}{
 		r.NodeMetaFilters,
 		r.Filter,
 		r.EnterpriseMeta,
+		r.PerPage,
+		r.NextToken,
 	}, nil)
 	if err == nil {
 		// If there is an error, we don't set the key. A blank key forces
@@ -712,6 +814,11 @@ type ServiceSpecificRequest struct {
 	// especially when the service might not be written into the catalog that way.
 	MergeCentralConfig bool
 
+	// ProxyStatus when set to true returns the status of the connect proxy
+	// instances, derived from the reports of the agents serving their xDS
+	// streams.
+	ProxyStatus bool
+
 	acl.EnterpriseMeta `hcl:",squash" mapstructure:",squash"`
 	QueryOptions
 }
@@ -759,6 +866,7 @@ func (r *ServiceSpecificRequest) CacheInfo() cache.RequestInfo {
 		r.Ingress,
 		r.ServiceKind,
 		r.MergeCentralConfig,
+		r.ProxyStatus,
 	}, nil)
 	if err == nil {
 		// If there is an error, we don't set the key. A blank key forces
@@ -905,7 +1013,7 @@ func ValidateServiceMetadata(kind ServiceKind, meta map[string]string, allowCons
 	case ServiceKindMeshGateway:
 		return validateMetadata(meta, allowConsulPrefix, allowedConsulMetaKeysForMeshGateway)
 	default:
-		return validateMetadata(meta, allowConsulPrefix, nil)
+		return validateMetadata(meta, allowConsulPrefix, allowedConsulMetaKeysForServices)
 	}
 }
 
@@ -1147,7 +1255,8 @@ func (k ServiceKind) IsProxy() bool {
 	case ServiceKindConnectProxy,
 		ServiceKindMeshGateway,
 		ServiceKindTerminatingGateway,
-		ServiceKindIngressGateway:
+		ServiceKindIngressGateway,
+		ServiceKindEgressGateway:
 		return true
 	}
 	return false
@@ -1179,6 +1288,11 @@ const (
 	// centralized configuration.
 	ServiceKindIngressGateway ServiceKind = "ingress-gateway"
 
+	// ServiceKindEgressGateway is an Egress Gateway for the Connect feature.
+	// This service allows traffic to leave the mesh to the external hostnames
+	// allowed by the egress-gateway config entry.
+	ServiceKindEgressGateway ServiceKind = "egress-gateway"
+
 	// ServiceKindDestination is a Destination  for the Connect feature.
 	// This service allows external traffic to exit the mesh through a terminating gateway
 	// based on centralized configuration.
@@ -1256,6 +1370,10 @@ type PeeringServiceMeta struct {
 	SNI      []string `json:",omitempty"`
 	SpiffeID []string `json:",omitempty"`
 	Protocol string   `json:",omitempty"`
+
+	// IgnoreWeights is set when the exported service's service-defaults
+	// entry sets IgnoreCatalogWeights.
+	IgnoreWeights bool `json:",omitempty"`
 }
 
 func (m *PeeringServiceMeta) PrimarySNI() string {
@@ -1368,7 +1486,8 @@ func (s *NodeService) IsSidecarProxy() bool {
 func (s *NodeService) IsGateway() bool {
 	return s.Kind == ServiceKindMeshGateway ||
 		s.Kind == ServiceKindTerminatingGateway ||
-		s.Kind == ServiceKindIngressGateway
+		s.Kind == ServiceKindIngressGateway ||
+		s.Kind == ServiceKindEgressGateway
 }
 
 // Validate validates the node service configuration.
@@ -1865,6 +1984,100 @@ func (c *HealthCheck) IsSame(other *HealthCheck) bool {
 	return true
 }
 
+// CheckOutputTruncatedSuffix is appended by the servers to the output of the
+// checks they truncated before storing them in the catalog.
+const CheckOutputTruncatedSuffix = "\n... (output truncated)"
+
+// TruncateCheckOutput truncates the output of a check to at most maxSize
+// bytes, including CheckOutputTruncatedSuffix, without splitting a UTF-8
+// character. The suffix is left out when maxSize is too small to hold it. The
+// output is returned as is when maxSize is zero.
+func TruncateCheckOutput(output string, maxSize int) string {
+	if maxSize <= 0 || len(output) <= maxSize {
+		return output
+	}
+	suffix := CheckOutputTruncatedSuffix
+	if maxSize < len(suffix) {
+		suffix = ""
+	}
+	n := maxSize - len(suffix)
+	for n > 0 && !utf8.RuneStart(output[n]) {
+		n--
+	}
+	return output[:n] + suffix
+}
+
+// CheckOutputCompressMinSize is the size from which the outputs of the checks
+// are compressed by CompressCheckOutputs.
+const CheckOutputCompressMinSize = 1024
+
+// CompressCheckOutputs moves the outputs of at least
+// CheckOutputCompressMinSize bytes of the checks of the request to
+// CompressedCheckOutputs, gzipped, unless they don't compress. The checks are
+// copied rather than modified.
+func (r *RegisterRequest) CompressCheckOutputs() error {
+	for i, check := range r.Checks {
+		if len(check.Output) < CheckOutputCompressMinSize {
+			continue
+		}
+
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write([]byte(check.Output)); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		if buf.Len() >= len(check.Output) {
+			continue
+		}
+
+		if r.CompressedCheckOutputs == nil {
+			r.CompressedCheckOutputs = make(map[types.CheckID][]byte)
+		}
+		r.CompressedCheckOutputs[check.CheckID] = buf.Bytes()
+		check = check.Clone()
+		check.Output = ""
+		r.Checks[i] = check
+	}
+	return nil
+}
+
+// DecompressCheckOutputs moves the outputs compressed by CompressCheckOutputs
+// back to the checks of the request.
+func (r *RegisterRequest) DecompressCheckOutputs() error {
+	if len(r.CompressedCheckOutputs) == 0 {
+		return nil
+	}
+	for _, check := range r.Checks {
+		data, ok := r.CompressedCheckOutputs[check.CheckID]
+		if !ok {
+			continue
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("failed to decompress the output of check %q: %w", check.CheckID, err)
+		}
+		output, err := io.ReadAll(zr)
+		if err != nil {
+			return fmt.Errorf("failed to decompress the output of check %q: %w", check.CheckID, err)
+		}
+		check.Output = string(output)
+	}
+	r.CompressedCheckOutputs = nil
+	return nil
+}
+
+// IsTruncatedCheckOutput returns true if stored is output truncated by
+// TruncateCheckOutput.
+func IsTruncatedCheckOutput(output, stored string) bool {
+	if !strings.HasSuffix(stored, CheckOutputTruncatedSuffix) || len(output) < len(stored) {
+		return false
+	}
+	return strings.HasPrefix(output, strings.TrimSuffix(stored, CheckOutputTruncatedSuffix))
+}
+
 // Clone returns a distinct clone of the HealthCheck. Note that the
 // "ServiceTags" and "Definition.Header" field are not deep copied.
 func (c *HealthCheck) Clone() *HealthCheck {
@@ -1914,6 +2127,10 @@ type CheckServiceNode struct {
 	Node    *Node
 	Service *NodeService
 	Checks  HealthChecks
+
+	// ProxyStatus is the status of a connect proxy instance, only set when
+	// requested.
+	ProxyStatus *ProxyStatus `json:",omitempty" bexpr:"-"`
 }
 
 func (csn *CheckServiceNode) BestAddress(wan bool) (uint64, string, int) {
@@ -2160,6 +2377,15 @@ type PeeredServiceName struct {
 	Peer        string
 }
 
+// String returns a key for the service name which is unique across peers.
+// Services which are not imported from a peer use the plain service name key.
+func (n PeeredServiceName) String() string {
+	if n.Peer == "" || n.Peer == LocalPeerKeyword {
+		return n.ServiceName.String()
+	}
+	return "peer:" + n.Peer + "/" + n.ServiceName.String()
+}
+
 type ServiceName struct {
 	Name string
 	acl.EnterpriseMeta
@@ -2283,6 +2509,14 @@ type ServiceTopology struct {
 	// specific, wildcard, or default allow.
 	UpstreamSources   map[string]string
 	DownstreamSources map[string]string
+
+	// UpstreamProtocols is a map of the protocol used to reach each upstream.
+	UpstreamProtocols map[string]string
+
+	// UpstreamGateways is a map of upstreams which are not backed by service
+	// instances in the mesh, but are reached through a terminating gateway,
+	// to the name of that gateway.
+	UpstreamGateways map[string]ServiceName
 }
 
 // IndexedConfigEntries has its own encoding logic which differs from
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -935,6 +936,18 @@ func TestStructs_NodeService_ValidateConnectProxy(t *testing.T) {
 			},
 			"only one of upstream local bind port or local socket path can be defined and nonzero",
 		},
+		{
+			"connect-proxy: upstream socket mode without leading zero is kept",
+			func(x *NodeService) {
+				x.Proxy.Upstreams = Upstreams{{
+					DestinationType:     UpstreamDestTypeService,
+					DestinationName:     "foo",
+					LocalBindSocketPath: "/tmp/socket",
+					LocalBindSocketMode: "600",
+				}}
+			},
+			"",
+		},
 		{
 			"connect-proxy: Upstreams almost-but-not-quite-duplicated in various ways",
 			func(x *NodeService) {
@@ -1157,6 +1170,72 @@ func TestStructs_NodeService_ValidateConnectProxy(t *testing.T) {
 	}
 }
 
+func TestUpstream_ValidateLocalBindSocket(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Upstream Upstream
+		Err      string
+	}{
+		{
+			"socket path",
+			Upstream{LocalBindSocketPath: "/tmp/socket", LocalBindSocketMode: "0600"},
+			"",
+		},
+		{
+			"bind port",
+			Upstream{LocalBindPort: 1},
+			"",
+		},
+		{
+			"bind address and path defined",
+			Upstream{LocalBindAddress: "127.0.0.1", LocalBindSocketPath: "/tmp/socket"},
+			"upstream local bind address cannot be defined with a local socket path",
+		},
+		{
+			"socket mode without path",
+			Upstream{LocalBindPort: 1, LocalBindSocketMode: "0600"},
+			"upstream local socket mode requires a local socket path",
+		},
+		{
+			"invalid socket mode",
+			Upstream{LocalBindSocketPath: "/tmp/socket", LocalBindSocketMode: "0999"},
+			"invalid socket mode",
+		},
+		{
+			"socket mode above permissions",
+			Upstream{LocalBindSocketPath: "/tmp/socket", LocalBindSocketMode: "01777"},
+			"invalid socket mode",
+		},
+		{
+			"socket mode without leading zero",
+			Upstream{LocalBindSocketPath: "/tmp/socket", LocalBindSocketMode: "600"},
+			"invalid socket mode",
+		},
+		{
+			"hexadecimal socket mode",
+			Upstream{LocalBindSocketPath: "/tmp/socket", LocalBindSocketMode: "0x180"},
+			"invalid socket mode",
+		},
+		{
+			"named pipe with socket mode",
+			Upstream{LocalBindSocketPath: `\\.\pipe\foo`, LocalBindSocketMode: "0600"},
+			"upstream local socket mode cannot be set for the named pipe",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Upstream.ValidateLocalBindSocket()
+			if tc.Err == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.Err)
+			}
+		})
+	}
+}
+
 func TestStructs_NodeService_ValidateConnectProxy_In_Partition(t *testing.T) {
 	cases := []struct {
 		Name   string
@@ -1568,6 +1647,58 @@ func TestStructs_HealthCheck_Clone(t *testing.T) {
 	}
 }
 
+func TestTruncateCheckOutput(t *testing.T) {
+	require.Equal(t, "short", TruncateCheckOutput("short", 0))
+	require.Equal(t, "short", TruncateCheckOutput("short", 5))
+
+	output := strings.Repeat("a", 100)
+	truncated := TruncateCheckOutput(output, 50)
+	require.Len(t, truncated, 50)
+	require.True(t, strings.HasSuffix(truncated, CheckOutputTruncatedSuffix))
+	require.True(t, IsTruncatedCheckOutput(output, truncated))
+	require.False(t, IsTruncatedCheckOutput(strings.Repeat("b", 100), truncated))
+	require.False(t, IsTruncatedCheckOutput(output, output))
+
+	// A multi-byte character is not split.
+	output = strings.Repeat("é", 50)
+	truncated = TruncateCheckOutput(output, 50)
+	require.Equal(t, strings.Repeat("é", 13)+CheckOutputTruncatedSuffix, truncated)
+	require.True(t, IsTruncatedCheckOutput(output, truncated))
+
+	// The suffix is left out when it doesn't fit.
+	for _, maxSize := range []int{1, 10, len(CheckOutputTruncatedSuffix) - 1, len(CheckOutputTruncatedSuffix)} {
+		truncated = TruncateCheckOutput(output, maxSize)
+		require.LessOrEqual(t, len(truncated), maxSize)
+		require.True(t, utf8.ValidString(truncated))
+	}
+	require.Equal(t, "éé", TruncateCheckOutput(output, 5))
+	require.Equal(t, CheckOutputTruncatedSuffix, TruncateCheckOutput(output, len(CheckOutputTruncatedSuffix)))
+}
+
+func TestRegisterRequest_CompressCheckOutputs(t *testing.T) {
+	long := &HealthCheck{CheckID: "long", Output: strings.Repeat("a", CheckOutputCompressMinSize)}
+	short := &HealthCheck{CheckID: "short", Output: "ok"}
+	req := &RegisterRequest{Checks: HealthChecks{long, short}}
+
+	require.NoError(t, req.CompressCheckOutputs())
+	require.Len(t, req.CompressedCheckOutputs, 1)
+	require.Empty(t, req.Checks[0].Output)
+	require.Equal(t, "ok", req.Checks[1].Output)
+
+	// The checks of the caller are left untouched.
+	require.Len(t, long.Output, CheckOutputCompressMinSize)
+
+	// The outputs survive the encoding of the request in the Raft log.
+	buf, err := Encode(RegisterRequestType, req)
+	require.NoError(t, err)
+	var decoded RegisterRequest
+	require.NoError(t, Decode(buf[1:], &decoded))
+	require.NoError(t, decoded.DecompressCheckOutputs())
+	require.Nil(t, decoded.CompressedCheckOutputs)
+	require.Equal(t, long.Output, decoded.Checks[0].Output)
+	require.Equal(t, "ok", decoded.Checks[1].Output)
+}
+
 func TestCheckServiceNodes_Shuffle(t *testing.T) {
 	// Make a huge list of nodes.
 	var nodes CheckServiceNodes
@@ -6,6 +6,10 @@ type SystemMetadataOp string
 const (
 	SystemMetadataUpsert SystemMetadataOp = "upsert"
 	SystemMetadataDelete SystemMetadataOp = "delete"
+
+	// SystemMetadataCAS upserts the entry only if its ModifyIndex matches
+	// the existing one, or if it doesn't exist yet when the ModifyIndex is 0.
+	SystemMetadataCAS SystemMetadataOp = "cas"
 )
 
 // SystemMetadataRequest is used to upsert and delete system metadata.
@@ -30,6 +34,8 @@ const (
 	SystemMetadataIntentionFormatLegacyValue   = "legacy"
 	SystemMetadataVirtualIPsEnabled            = "virtual-ips"
 	SystemMetadataTermGatewayVirtualIPsEnabled = "virtual-ips-term-gateway"
+	SystemMetadataNodeIdentityKey              = "node-identity-signing-key"
+	SystemMetadataCARootsWebhookCursorKey      = "ca-roots-webhook-cursor"
 )
 
 type SystemMetadataEntry struct {
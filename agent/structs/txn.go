@@ -3,6 +3,7 @@ package structs
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 	multierror "github.com/hashicorp/go-multierror"
@@ -98,6 +99,13 @@ type TxnOps []*TxnOp
 type TxnRequest struct {
 	Datacenter string
 	Ops        TxnOps
+
+	// DeregisteredBy and DeregisteredAt are set by the servers and recorded
+	// in the deregistration history for the nodes and services deleted by
+	// the transaction.
+	DeregisteredBy string
+	DeregisteredAt time.Time
+
 	WriteRequest
 }
 
@@ -105,6 +113,16 @@ func (r *TxnRequest) RequestDatacenter() string {
 	return r.Datacenter
 }
 
+// Deregistration returns the record of the nodes and services deleted by the
+// transaction, leaving the fields describing what was removed to the state
+// store.
+func (r *TxnRequest) Deregistration() *Deregistration {
+	return &Deregistration{
+		DeregisteredBy: r.DeregisteredBy,
+		DeregisteredAt: r.DeregisteredAt,
+	}
+}
+
 // TxnReadRequest is used as a fast path for read-only transactions that don't
 // modify the state store.
 type TxnReadRequest struct {
@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// TrafficShiftApply starts, or restarts, shifting the traffic of a service
+// from one subset to another. PUT /v1/traffic/shift
+func (s *HTTPHandlers) TrafficShiftApply(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	args := structs.ConfigEntryRequest{
+		Op: structs.ConfigEntryUpsert,
+	}
+	s.parseDC(req, &args.Datacenter)
+	s.parseToken(req, &args.Token)
+
+	var raw map[string]interface{}
+	if err := decodeBodyDeprecated(req, &raw, nil); err != nil {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Request decoding failed: %v", err)}
+	}
+	if raw == nil {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: "Request body is required"}
+	}
+
+	// The body names the service being shifted rather than the config entry
+	// and never carries a status, so that every write starts a new shift.
+	for _, key := range []string{"Service", "service"} {
+		if service, ok := raw[key]; ok {
+			raw["Name"] = service
+			delete(raw, key)
+		}
+	}
+	for _, key := range []string{"Kind", "kind", "Status", "status"} {
+		delete(raw, key)
+	}
+	raw["Kind"] = structs.TrafficShift
+
+	entry, err := structs.DecodeConfigEntry(raw)
+	if err != nil {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: fmt.Sprintf("Request decoding failed: %v", err)}
+	}
+	args.Entry = entry
+
+	var meta acl.EnterpriseMeta
+	if err := s.parseEntMetaNoWildcard(req, &meta); err != nil {
+		return nil, err
+	}
+	args.Entry.GetEnterpriseMeta().Merge(&meta)
+
+	var reply bool
+	if err := s.agent.RPC("ConfigEntry.Apply", &args, &reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// TrafficShiftSpecific reads or cancels the traffic shift of a single service.
+// GET, DELETE /v1/traffic/shift/:service
+func (s *HTTPHandlers) TrafficShiftSpecific(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	name := strings.TrimPrefix(req.URL.Path, "/v1/traffic/shift/")
+	if name == "" {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: "Missing service name"}
+	}
+
+	switch req.Method {
+	case "GET":
+		var args structs.ConfigEntryQuery
+		if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+			return nil, nil
+		}
+		if err := s.parseEntMetaNoWildcard(req, &args.EnterpriseMeta); err != nil {
+			return nil, err
+		}
+		args.Kind = structs.TrafficShift
+		args.Name = name
+
+		var reply structs.ConfigEntryResponse
+		if err := s.agent.RPC("ConfigEntry.Get", &args, &reply); err != nil {
+			return nil, err
+		}
+		setMeta(resp, &reply.QueryMeta)
+
+		if reply.Entry == nil {
+			return nil, HTTPError{StatusCode: http.StatusNotFound, Reason: fmt.Sprintf("No traffic shift for service %q", name)}
+		}
+		return reply.Entry, nil
+
+	case "DELETE":
+		args := structs.ConfigEntryRequest{
+			Op:    structs.ConfigEntryDelete,
+			Entry: &structs.TrafficShiftConfigEntry{Name: name},
+		}
+		s.parseDC(req, &args.Datacenter)
+		s.parseToken(req, &args.Token)
+		if err := s.parseEntMetaNoWildcard(req, args.Entry.GetEnterpriseMeta()); err != nil {
+			return nil, err
+		}
+
+		var reply structs.ConfigEntryDeleteResponse
+		if err := s.agent.RPC("ConfigEntry.Delete", &args, &reply); err != nil {
+			return nil, err
+		}
+		return true, nil
+
+	default:
+		return nil, MethodNotAllowedError{req.Method, []string{"GET", "DELETE"}}
+	}
+}
@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+)
+
+func TestTrafficShift_ApplyGetDelete(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	body := bytes.NewBuffer([]byte(`
+	{
+		"Service": "web",
+		"FromSubset": "v1",
+		"ToSubset": "v2",
+		"StepWeight": 5,
+		"StepInterval": "10m",
+		"Checks": [{"Type": "health"}],
+		"Status": {"State": "completed"}
+	}`))
+	req, _ := http.NewRequest("PUT", "/v1/traffic/shift", body)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.TrafficShiftApply(resp, req)
+	require.NoError(t, err)
+	require.Equal(t, true, obj)
+
+	req, _ = http.NewRequest("GET", "/v1/traffic/shift/web", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.TrafficShiftSpecific(resp, req)
+	require.NoError(t, err)
+	entry, ok := obj.(*structs.TrafficShiftConfigEntry)
+	require.True(t, ok)
+	require.Equal(t, "web", entry.Name)
+	require.Equal(t, "v1", entry.FromSubset)
+	require.Equal(t, "v2", entry.ToSubset)
+	require.Equal(t, float32(5), entry.StepWeight)
+	require.Equal(t, 10*time.Minute, entry.StepInterval)
+	require.Equal(t, []structs.TrafficShiftCheck{{Type: structs.TrafficShiftCheckHealth}}, entry.Checks)
+	if entry.Status != nil {
+		// The status supplied in the request is ignored.
+		require.NotEqual(t, structs.TrafficShiftStateCompleted, entry.Status.State)
+	}
+
+	req, _ = http.NewRequest("DELETE", "/v1/traffic/shift/web", nil)
+	resp = httptest.NewRecorder()
+	_, err = a.srv.TrafficShiftSpecific(resp, req)
+	require.NoError(t, err)
+
+	req, _ = http.NewRequest("GET", "/v1/traffic/shift/web", nil)
+	resp = httptest.NewRecorder()
+	_, err = a.srv.TrafficShiftSpecific(resp, req)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `No traffic shift for service "web"`)
+
+	t.Run("invalid", func(t *testing.T) {
+		body := bytes.NewBuffer([]byte(`{"Service": "web", "FromSubset": "v1", "ToSubset": "v1"}`))
+		req, _ := http.NewRequest("PUT", "/v1/traffic/shift", body)
+		resp := httptest.NewRecorder()
+		_, err := a.srv.TrafficShiftApply(resp, req)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "FromSubset and ToSubset must be different")
+	})
+}
@@ -0,0 +1,173 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// ttlExpiryWebhookTimeout bounds the requests made to the TTL expiry webhooks.
+const ttlExpiryWebhookTimeout = 10 * time.Second
+
+// ttlExpiryEvent is the body of the requests made to the TTL expiry webhooks.
+type ttlExpiryEvent struct {
+	Node        string
+	CheckID     string
+	ServiceID   string `json:",omitempty"`
+	Expirations int
+	Output      string
+}
+
+// ttlExpiryMaintReason returns the reason of the maintenance a TTL check
+// enables when it expires. It identifies the maintenance enabled by the check
+// so that it is only disabled by the check itself.
+func ttlExpiryMaintReason(checkID structs.CheckID) string {
+	return fmt.Sprintf("TTL of check %q expired", checkID.ID)
+}
+
+// ttlExpiryHandlers returns the functions run by a TTL check when its TTL
+// expires and when it is updated again, or nil when chkType doesn't configure
+// any action.
+func (a *Agent) ttlExpiryHandlers(check *structs.HealthCheck, chkType *structs.CheckType, token string) (expired func(int), renewed func()) {
+	if chkType.TTLExpiryDeregisterAfter == 0 && chkType.TTLExpiryWebhook == "" && !chkType.TTLExpiryMaintenance {
+		return nil, nil
+	}
+
+	cid := check.CompoundCheckID()
+	sid := check.CompoundServiceID()
+	reason := ttlExpiryMaintReason(cid)
+
+	expired = func(expirations int) {
+		// The handlers take the state lock, which may be held by the caller
+		// stopping this check.
+		go a.handleTTLExpired(check, chkType, token, expirations, reason)
+	}
+	if chkType.TTLExpiryMaintenance {
+		renewed = func() {
+			go func() {
+				if sid.ID == "" {
+					if c := a.State.Check(structs.NodeMaintCheckID); c != nil && c.Notes == reason {
+						a.DisableNodeMaintenance()
+					}
+					return
+				}
+				if c := a.State.Check(serviceMaintCheckID(sid)); c != nil && c.Notes == reason {
+					if err := a.DisableServiceMaintenance(sid); err != nil {
+						a.logger.Warn("failed to disable maintenance after TTL check renewal",
+							"check", cid.String(),
+							"error", err,
+						)
+					}
+				}
+			}()
+		}
+	}
+	return expired, renewed
+}
+
+func (a *Agent) handleTTLExpired(check *structs.HealthCheck, chkType *structs.CheckType, token string, expirations int, reason string) {
+	cid := check.CompoundCheckID()
+	sid := check.CompoundServiceID()
+
+	if chkType.TTLExpiryWebhook != "" {
+		a.callTTLExpiryWebhook(chkType.TTLExpiryWebhook, ttlExpiryEvent{
+			Node:        a.config.NodeName,
+			CheckID:     string(cid.ID),
+			ServiceID:   sid.ID,
+			Expirations: expirations,
+			Output:      a.ttlExpiryOutput(cid),
+		})
+	}
+
+	if n := chkType.TTLExpiryDeregisterAfter; n > 0 && expirations >= n {
+		// Nothing else to do once the check or service is gone.
+		if sid.ID == "" {
+			if err := a.RemoveCheck(cid, true); err != nil {
+				a.logger.Error("failed to deregister check after TTL expirations",
+					"check", cid.String(),
+					"expirations", expirations,
+					"error", err,
+				)
+				return
+			}
+			a.logger.Info("deregistered check after TTL expirations",
+				"check", cid.String(),
+				"expirations", expirations,
+			)
+			return
+		}
+		if err := a.RemoveService(sid); err != nil {
+			a.logger.Error("failed to deregister service after TTL expirations",
+				"service", sid.String(),
+				"check", cid.String(),
+				"expirations", expirations,
+				"error", err,
+			)
+			return
+		}
+		a.logger.Info("deregistered service after TTL expirations",
+			"service", sid.String(),
+			"check", cid.String(),
+			"expirations", expirations,
+		)
+		return
+	}
+
+	if chkType.TTLExpiryMaintenance {
+		if sid.ID == "" {
+			a.EnableNodeMaintenance(reason, token)
+			return
+		}
+		if err := a.EnableServiceMaintenance(sid, reason, token); err != nil {
+			a.logger.Warn("failed to enable maintenance after TTL expiration",
+				"check", cid.String(),
+				"error", err,
+			)
+		}
+	}
+}
+
+func (a *Agent) ttlExpiryOutput(cid structs.CheckID) string {
+	if c := a.State.Check(cid); c != nil {
+		return c.Output
+	}
+	return ""
+}
+
+func (a *Agent) callTTLExpiryWebhook(url string, event ttlExpiryEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		a.logger.Error("failed to encode TTL expiry webhook request", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ttlExpiryWebhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		a.logger.Error("failed to setup TTL expiry webhook request", "check", event.CheckID, "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := cleanhttp.DefaultClient().Do(req)
+	if err != nil {
+		a.logger.Warn("failed to invoke TTL expiry webhook", "check", event.CheckID, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		a.logger.Warn("TTL expiry webhook returned an unexpected status",
+			"check", event.CheckID,
+			"status", resp.StatusCode,
+		)
+	}
+}
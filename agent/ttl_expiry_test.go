@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	"github.com/hashicorp/consul/testrpc"
+)
+
+func TestAgent_TTLExpiry(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	var lock sync.Mutex
+	var events []ttlExpiryEvent
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event ttlExpiryEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		lock.Lock()
+		events = append(events, event)
+		lock.Unlock()
+	}))
+	defer webhook.Close()
+
+	sid := structs.NewServiceID("web", nil)
+	cid := structs.NewCheckID("web-ttl", nil)
+	srv := &structs.NodeService{ID: "web", Service: "web", Port: 8080}
+	chkType := &structs.CheckType{
+		CheckID:              "web-ttl",
+		TTL:                  200 * time.Millisecond,
+		TTLExpiryWebhook:     webhook.URL,
+		TTLExpiryMaintenance: true,
+	}
+	require.NoError(t, a.addServiceFromSource(srv, []*structs.CheckType{chkType}, false, "", ConfigSourceLocal))
+
+	retry.Run(t, func(r *retry.R) {
+		maint := a.State.Check(serviceMaintCheckID(sid))
+		if maint == nil {
+			r.Fatalf("service is not in maintenance")
+		}
+		require.Equal(r, `TTL of check "web-ttl" expired`, maint.Notes)
+
+		lock.Lock()
+		defer lock.Unlock()
+		if len(events) == 0 {
+			r.Fatalf("webhook was not called")
+		}
+		require.Equal(r, a.config.NodeName, events[0].Node)
+		require.Equal(r, "web-ttl", events[0].CheckID)
+		require.Equal(r, "web", events[0].ServiceID)
+		require.Equal(r, 1, events[0].Expirations)
+	})
+
+	// Updating the check takes the service out of maintenance.
+	require.NoError(t, a.updateTTLCheck(cid, api.HealthPassing, "ok"))
+	retry.Run(t, func(r *retry.R) {
+		if a.State.Check(serviceMaintCheckID(sid)) != nil {
+			r.Fatalf("service is still in maintenance")
+		}
+	})
+
+	t.Run("deregister", func(t *testing.T) {
+		chkType := &structs.CheckType{
+			CheckID:                  "api-ttl",
+			TTL:                      100 * time.Millisecond,
+			TTLExpiryDeregisterAfter: 2,
+		}
+		srv := &structs.NodeService{ID: "api", Service: "api", Port: 8081}
+		require.NoError(t, a.addServiceFromSource(srv, []*structs.CheckType{chkType}, false, "", ConfigSourceLocal))
+
+		retry.Run(t, func(r *retry.R) {
+			if a.State.Service(structs.NewServiceID("api", nil)) != nil {
+				r.Fatalf("service is still registered")
+			}
+		})
+	})
+}
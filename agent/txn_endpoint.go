@@ -18,6 +18,11 @@ const (
 	// inside a transaction. If there are more operations than this, then the
 	// client is likely abusing transactions.
 	maxTxnOps = 64
+
+	// maxCatalogTxnOps is the upper limit on the number of operations inside
+	// a transaction which only holds catalog operations, so that orchestrators
+	// can register or deregister many services and checks at once.
+	maxCatalogTxnOps = 512
 )
 
 // decodeValue decodes the value member of the given operation.
@@ -60,6 +65,17 @@ func isWrite(op api.KVOp) bool {
 	return false
 }
 
+// isCatalogTxn returns true if the transaction only holds node, service and
+// check operations.
+func isCatalogTxn(ops api.TxnOps) bool {
+	for _, op := range ops {
+		if op.Node == nil && op.Service == nil && op.Check == nil {
+			return false
+		}
+	}
+	return true
+}
+
 // convertOps takes the incoming body in API format and converts it to the
 // internal RPC format. This returns a count of the number of write ops, and
 // a boolean, that if false means an error response has been generated and
@@ -116,10 +132,14 @@ func (s *HTTPHandlers) convertOps(resp http.ResponseWriter, req *http.Request) (
 
 	// Enforce a reasonable upper limit on the number of operations in a
 	// transaction in order to curb abuse.
-	if size := len(ops); size > maxTxnOps {
+	limit := maxTxnOps
+	if isCatalogTxn(ops) {
+		limit = maxCatalogTxnOps
+	}
+	if size := len(ops); size > limit {
 		return nil, 0, HTTPError{
 			StatusCode: http.StatusRequestEntityTooLarge,
-			Reason:     fmt.Sprintf("Transaction contains too many operations (%d > %d)", size, maxTxnOps),
+			Reason:     fmt.Sprintf("Transaction contains too many operations (%d > %d)", size, limit),
 		}
 	}
 
@@ -229,6 +229,42 @@ func TestTxnEndpoint_Bad_Size_Ops(t *testing.T) {
 	}
 }
 
+func TestTxnEndpoint_Catalog_TooManyOps(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	op := func(i int) string {
+		return fmt.Sprintf(`{ "Service": { "Verb": "set", "Node": %q, "Service": { "ID": "web-%d", "Service": "web" } } },`, a.Config.NodeName, i)
+	}
+	txn := func(n int) (*httptest.ResponseRecorder, error) {
+		var ops strings.Builder
+		for i := 0; i < n; i++ {
+			ops.WriteString(op(i))
+		}
+		body := fmt.Sprintf(`[ %s { "Node": { "Verb": "get", "Node": { "Node": %q } } } ]`, ops.String(), a.Config.NodeName)
+		req, _ := http.NewRequest("PUT", "/v1/txn", strings.NewReader(body))
+		resp := httptest.NewRecorder()
+		_, err := a.srv.Txn(resp, req)
+		return resp, err
+	}
+
+	// Catalog transactions may hold more operations than the KV ones.
+	resp, err := txn(2 * maxTxnOps)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	_, err = txn(maxCatalogTxnOps)
+	httpErr, ok := err.(HTTPError)
+	require.True(t, ok, "expected HTTP error but got %v", err)
+	require.Equal(t, http.StatusRequestEntityTooLarge, httpErr.StatusCode)
+}
+
 func TestTxnEndpoint_KV_Actions(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -27,6 +27,8 @@ type ServiceSummary struct {
 	Nodes               []string
 	ExternalSources     []string
 	externalSourceSet   map[string]struct{} // internal to track uniqueness
+	DetectedProtocols   []string            `json:",omitempty"`
+	detectedProtocolSet map[string]struct{} // internal to track uniqueness
 	checks              map[string]*structs.HealthCheck
 	InstanceCount       int
 	ChecksPassing       int
@@ -36,6 +38,7 @@ type ServiceSummary struct {
 	TransparentProxy    bool
 	transparentProxySet bool
 	ConnectNative       bool
+	PeerName            string `json:",omitempty"`
 
 	acl.EnterpriseMeta
 }
@@ -67,6 +70,13 @@ type ServiceTopologySummary struct {
 
 	Source    string
 	Intention structs.IntentionDecisionSummary
+
+	// Protocol is the protocol used for traffic on this edge of the topology.
+	Protocol string `json:",omitempty"`
+
+	// Gateway is the terminating gateway that traffic to this upstream is
+	// routed through, if any.
+	Gateway *structs.ServiceName `json:",omitempty"`
 }
 
 type ServiceTopology struct {
@@ -333,7 +343,18 @@ RPC:
 		return nil, err
 	}
 
-	upstreams, _ := summarizeServices(out.ServiceTopology.Upstreams.ToServiceDump(), nil, "")
+	// Upstreams imported from different peers may share a name with each
+	// other and with local services, so they are summarized separately.
+	upstreamsByPeer := make(map[string]structs.CheckServiceNodes)
+	for _, csn := range out.ServiceTopology.Upstreams {
+		upstreamsByPeer[csn.Service.PeerName] = append(upstreamsByPeer[csn.Service.PeerName], csn)
+	}
+	peers := make([]string, 0, len(upstreamsByPeer))
+	for peer := range upstreamsByPeer {
+		peers = append(peers, peer)
+	}
+	sort.Strings(peers)
+
 	downstreams, _ := summarizeServices(out.ServiceTopology.Downstreams.ToServiceDump(), nil, "")
 
 	var (
@@ -342,31 +363,47 @@ RPC:
 	)
 
 	// Sort and attach intention data for upstreams and downstreams
-	sortedUpstreams := prepSummaryOutput(upstreams, true)
-	for _, svc := range sortedUpstreams {
-		sn := structs.NewServiceName(svc.Name, &svc.EnterpriseMeta)
-		sum := ServiceTopologySummary{
-			ServiceSummary: *svc,
-			Intention:      out.ServiceTopology.UpstreamDecisions[sn.String()],
-			Source:         out.ServiceTopology.UpstreamSources[sn.String()],
-		}
-		upstreamResp = append(upstreamResp, &sum)
-	}
-	for k, v := range out.ServiceTopology.UpstreamSources {
-		if v == structs.TopologySourceRoutingConfig {
-			sn := structs.ServiceNameFromString(k)
+	for _, peer := range peers {
+		upstreams, _ := summarizeServices(upstreamsByPeer[peer].ToServiceDump(), nil, "")
+
+		sortedUpstreams := prepSummaryOutput(upstreams, true)
+		for _, svc := range sortedUpstreams {
+			svc.PeerName = peer
+			psn := structs.PeeredServiceName{
+				ServiceName: structs.NewServiceName(svc.Name, &svc.EnterpriseMeta),
+				Peer:        peer,
+			}
 			sum := ServiceTopologySummary{
-				ServiceSummary: ServiceSummary{
-					Datacenter:     args.Datacenter,
-					Name:           sn.Name,
-					EnterpriseMeta: sn.EnterpriseMeta,
-				},
-				Intention: out.ServiceTopology.UpstreamDecisions[sn.String()],
-				Source:    out.ServiceTopology.UpstreamSources[sn.String()],
+				ServiceSummary: *svc,
+				Intention:      out.ServiceTopology.UpstreamDecisions[psn.String()],
+				Source:         out.ServiceTopology.UpstreamSources[psn.String()],
+				Protocol:       out.ServiceTopology.UpstreamProtocols[psn.String()],
 			}
 			upstreamResp = append(upstreamResp, &sum)
 		}
 	}
+	for k, v := range out.ServiceTopology.UpstreamSources {
+		gateway, viaGateway := out.ServiceTopology.UpstreamGateways[k]
+		if v != structs.TopologySourceRoutingConfig && !viaGateway {
+			continue
+		}
+
+		sn := structs.ServiceNameFromString(k)
+		sum := ServiceTopologySummary{
+			ServiceSummary: ServiceSummary{
+				Datacenter:     args.Datacenter,
+				Name:           sn.Name,
+				EnterpriseMeta: sn.EnterpriseMeta,
+			},
+			Intention: out.ServiceTopology.UpstreamDecisions[sn.String()],
+			Source:    out.ServiceTopology.UpstreamSources[sn.String()],
+			Protocol:  out.ServiceTopology.UpstreamProtocols[sn.String()],
+		}
+		if viaGateway {
+			sum.Gateway = &gateway
+		}
+		upstreamResp = append(upstreamResp, &sum)
+	}
 
 	sortedDownstreams := prepSummaryOutput(downstreams, true)
 	for _, svc := range sortedDownstreams {
@@ -375,6 +412,9 @@ RPC:
 			ServiceSummary: *svc,
 			Intention:      out.ServiceTopology.DownstreamDecisions[sn.String()],
 			Source:         out.ServiceTopology.DownstreamSources[sn.String()],
+			// Traffic from downstreams is sent with the protocol of the
+			// service being queried.
+			Protocol: out.ServiceTopology.MetricsProtocol,
 		}
 		downstreamResp = append(downstreamResp, &sum)
 	}
@@ -389,6 +429,41 @@ RPC:
 	return topo, nil
 }
 
+// UIServiceMetrics returns a summary of the requests between a service and
+// its upstreams and downstreams over the last minute, as reported by the
+// connect proxies with builtin metrics enabled.
+func (s *HTTPHandlers) UIServiceMetrics(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	// Parse arguments
+	args := structs.ServiceSpecificRequest{}
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+	if err := s.parseEntMeta(req, &args.EnterpriseMeta); err != nil {
+		return nil, err
+	}
+
+	args.ServiceName = strings.TrimPrefix(req.URL.Path, "/v1/internal/ui/service-metrics/")
+	if args.ServiceName == "" {
+		return nil, HTTPError{StatusCode: http.StatusBadRequest, Reason: "Missing service name"}
+	}
+
+	// Make the RPC request
+	var out structs.IndexedServiceMetrics
+	defer setMeta(resp, &out.QueryMeta)
+	if err := s.agent.RPC("Internal.ServiceMetrics", &args, &out); err != nil {
+		return nil, err
+	}
+
+	// Use empty lists instead of null
+	if out.Upstreams == nil {
+		out.Upstreams = make([]structs.ServiceMetricsSummary, 0)
+	}
+	if out.Downstreams == nil {
+		out.Downstreams = make([]structs.ServiceMetricsSummary, 0)
+	}
+	return out, nil
+}
+
 func summarizeServices(dump structs.ServiceDump, cfg *config.RuntimeConfig, dc string) (map[structs.ServiceName]*ServiceSummary, map[structs.ServiceName]bool) {
 	var (
 		summary  = make(map[structs.ServiceName]*ServiceSummary)
@@ -483,6 +558,18 @@ func summarizeServices(dump structs.ServiceDump, cfg *config.RuntimeConfig, dc s
 			}
 		}
 
+		// Protocols detected by sidecar proxies are surfaced so that they can
+		// be suggested for services without a configured protocol.
+		if protocol := svc.Meta[structs.MetaDetectedProtocol]; protocol != "" {
+			if sum.detectedProtocolSet == nil {
+				sum.detectedProtocolSet = make(map[string]struct{})
+			}
+			if _, ok := sum.detectedProtocolSet[protocol]; !ok {
+				sum.detectedProtocolSet[protocol] = struct{}{}
+				sum.DetectedProtocols = append(sum.DetectedProtocols, protocol)
+			}
+		}
+
 		for _, check := range csn.Checks {
 			cid := structs.NewCheckID(check.CheckID, &check.EnterpriseMeta)
 			uid := structs.UniqueID(csn.Node.Node, cid.String())
@@ -1635,7 +1635,8 @@ func TestUIServiceTopology(t *testing.T) {
 							HasPermissions: false,
 							HasExact:       true,
 						},
-						Source: structs.TopologySourceRegistration,
+						Source:   structs.TopologySourceRegistration,
+						Protocol: "tcp",
 					},
 				},
 				Downstreams:    []*ServiceTopologySummary{},
@@ -1669,7 +1670,8 @@ func TestUIServiceTopology(t *testing.T) {
 							HasPermissions: false,
 							HasExact:       true,
 						},
-						Source: structs.TopologySourceRegistration,
+						Source:   structs.TopologySourceRegistration,
+						Protocol: "tcp",
 					},
 				},
 				Upstreams: []*ServiceTopologySummary{
@@ -1691,7 +1693,8 @@ func TestUIServiceTopology(t *testing.T) {
 							HasPermissions: false,
 							HasExact:       true,
 						},
-						Source: structs.TopologySourceSpecificIntention,
+						Source:   structs.TopologySourceSpecificIntention,
+						Protocol: "http",
 					},
 				},
 				FilteredByACLs: false,
@@ -1724,7 +1727,8 @@ func TestUIServiceTopology(t *testing.T) {
 							HasPermissions: true,
 							HasExact:       true,
 						},
-						Source: structs.TopologySourceRegistration,
+						Source:   structs.TopologySourceRegistration,
+						Protocol: "http",
 					},
 				},
 				Downstreams: []*ServiceTopologySummary{
@@ -1744,7 +1748,8 @@ func TestUIServiceTopology(t *testing.T) {
 							HasPermissions: false,
 							HasExact:       true,
 						},
-						Source: structs.TopologySourceSpecificIntention,
+						Source:   structs.TopologySourceSpecificIntention,
+						Protocol: "http",
 					},
 				},
 				FilteredByACLs: false,
@@ -1778,7 +1783,8 @@ func TestUIServiceTopology(t *testing.T) {
 							HasPermissions: true,
 							HasExact:       true,
 						},
-						Source: structs.TopologySourceRegistration,
+						Source:   structs.TopologySourceRegistration,
+						Protocol: "http",
 					},
 				},
 				FilteredByACLs: false,
@@ -1811,7 +1817,8 @@ func TestUIServiceTopology(t *testing.T) {
 							HasPermissions: false,
 							HasExact:       true,
 						},
-						Source: structs.TopologySourceSpecificIntention,
+						Source:   structs.TopologySourceSpecificIntention,
+						Protocol: "http",
 					},
 				},
 				Downstreams: []*ServiceTopologySummary{
@@ -1832,7 +1839,8 @@ func TestUIServiceTopology(t *testing.T) {
 							HasPermissions: false,
 							HasExact:       true,
 						},
-						Source: structs.TopologySourceRegistration,
+						Source:   structs.TopologySourceRegistration,
+						Protocol: "http",
 					},
 				},
 				FilteredByACLs: false,
@@ -2118,7 +2126,8 @@ func TestUIServiceTopology_RoutingConfigs(t *testing.T) {
 							DefaultAllow: true,
 							Allowed:      true,
 						},
-						Source: structs.TopologySourceRoutingConfig,
+						Source:   structs.TopologySourceRoutingConfig,
+						Protocol: "http",
 					},
 				},
 			},
@@ -2142,7 +2151,8 @@ func TestUIServiceTopology_RoutingConfigs(t *testing.T) {
 							InstanceCount:  1,
 							ChecksPassing:  1,
 						},
-						Source: "proxy-registration",
+						Source:   "proxy-registration",
+						Protocol: "http",
 						Intention: structs.IntentionDecisionSummary{
 							Allowed:      true,
 							DefaultAllow: true,
@@ -27,6 +27,10 @@ import (
 	"github.com/hashicorp/consul/agent/structs"
 )
 
+// slowStartAggressionRuntimeKey is the Envoy runtime key that can be used to
+// override the aggression of the slow start mode of upstream clusters.
+const slowStartAggressionRuntimeKey = "upstream.slow_start_aggression"
+
 // clustersFromSnapshot returns the xDS API representation of the "clusters" in the snapshot.
 func (s *ResourceGenerator) clustersFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
 	if cfgSnap == nil {
@@ -54,6 +58,8 @@ func (s *ResourceGenerator) clustersFromSnapshot(cfgSnap *proxycfg.ConfigSnapsho
 			return nil, err
 		}
 		return res, nil
+	case structs.ServiceKindEgressGateway:
+		return s.clustersFromSnapshotEgressGateway(cfgSnap)
 	default:
 		return nil, fmt.Errorf("Invalid service kind: %v", cfgSnap.Kind)
 	}
@@ -78,6 +84,14 @@ func (s *ResourceGenerator) clustersFromSnapshotConnectProxy(cfgSnap *proxycfg.C
 			return nil, fmt.Errorf("failed to make passthrough clusters for transparent proxy: %v", err)
 		}
 		clusters = append(clusters, passthroughs...)
+
+		if len(s.egressHostnames(cfgSnap)) > 0 {
+			dynamicForwardProxy, err := makeDynamicForwardProxyCluster()
+			if err != nil {
+				return nil, fmt.Errorf("failed to make dynamic forward proxy cluster for transparent proxy: %v", err)
+			}
+			clusters = append(clusters, dynamicForwardProxy)
+		}
 	}
 
 	// NOTE: Any time we skip a chain below we MUST also skip that discovery chain in endpoints.go
@@ -159,10 +173,15 @@ func (s *ResourceGenerator) clustersFromSnapshotConnectProxy(cfgSnap *proxycfg.C
 	}
 
 	// Create a new cluster if we need to expose a port that is different from the service port
+	exposedPorts := make(map[int]struct{})
 	for _, path := range paths {
 		if path.LocalPathPort == cfgSnap.Proxy.LocalServicePort {
 			continue
 		}
+		if _, ok := exposedPorts[path.LocalPathPort]; ok {
+			continue
+		}
+		exposedPorts[path.LocalPathPort] = struct{}{}
 		c, err := s.makeAppCluster(cfgSnap, makeExposeClusterName(path.LocalPathPort), path.Protocol, path.LocalPathPort)
 		if err != nil {
 			s.Logger.Warn("failed to make local cluster", "path", path.Path, "error", err)
@@ -317,6 +336,15 @@ func (s *ResourceGenerator) clustersFromSnapshotMeshGateway(cfgSnap *proxycfg.Co
 		}
 	}
 
+	if meshGatewayRoutesPeeringToServers(cfgSnap) {
+		// Peers dial any server in this datacenter without knowing its name.
+		opts := gatewayClusterOpts{
+			name: cfgSnap.ServerSNIFn(cfgSnap.Datacenter, ""),
+		}
+		cluster := s.makeGatewayCluster(cfgSnap, opts)
+		clusters = append(clusters, cluster)
+	}
+
 	// generate the per-service/subset clusters
 	c, err := s.makeGatewayServiceClusters(cfgSnap, cfgSnap.MeshGateway.ServiceGroups, cfgSnap.MeshGateway.ServiceResolvers)
 	if err != nil {
@@ -327,6 +355,16 @@ func (s *ResourceGenerator) clustersFromSnapshotMeshGateway(cfgSnap *proxycfg.Co
 	return clusters, nil
 }
 
+// meshGatewayRoutesPeeringToServers returns whether the mesh gateway routes
+// the peering streams to the servers of its datacenter by themselves, rather
+// than along with the WAN federation traffic.
+func meshGatewayRoutesPeeringToServers(cfgSnap *proxycfg.ConfigSnapshot) bool {
+	return cfgSnap.ProxyID.InDefaultPartition() &&
+		cfgSnap.ServiceMeta[structs.MetaWANFederationKey] != "1" &&
+		cfgSnap.ServerSNIFn != nil &&
+		cfgSnap.MeshGateway.PeerThroughMeshGateways()
+}
+
 func (s *ResourceGenerator) makeGatewayServiceClusters(
 	cfgSnap *proxycfg.ConfigSnapshot,
 	services map[structs.ServiceName]structs.CheckServiceNodes,
@@ -439,6 +477,24 @@ func (s *ResourceGenerator) injectGatewayServiceAddons(cfgSnap *proxycfg.ConfigS
 			return fmt.Errorf("failed to apply load balancer configuration to cluster %q: %v", c.Name, err)
 		}
 
+		// Send the request headers to the service in the same casing as the
+		// responses sent by the listener of the gateway.
+		if svcConfig, ok := cfgSnap.TerminatingGateway.ServiceConfigs[svc]; ok {
+			cfg, err := ParseProxyConfig(svcConfig.ProxyConfig)
+			if err != nil {
+				// Don't hard fail on a config typo, just warn. The parse func returns
+				// default config if there is an error so it's safe to continue.
+				s.Logger.Warn("failed to parse Connect.Proxy.Config for linked service",
+					"service", svc.String(),
+					"error", err,
+				)
+			}
+			if cfg.Protocol == "http" && cfg.RequestNormalization(cfgSnap.MeshConfig()).HeaderKeyFormat == structs.HeaderKeyFormatProperCase {
+				if err := setHttp1ProtocolOptionsWith(c, makeProperCaseHttp1ProtocolOptions()); err != nil {
+					return err
+				}
+			}
+		}
 	}
 	return nil
 }
@@ -446,6 +502,7 @@ func (s *ResourceGenerator) injectGatewayServiceAddons(cfgSnap *proxycfg.ConfigS
 func (s *ResourceGenerator) clustersFromSnapshotIngressGateway(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
 	var clusters []proto.Message
 	createdClusters := make(map[proxycfg.UpstreamID]bool)
+	sessionAffinity := ingressSessionAffinityUpstreams(cfgSnap)
 	for _, upstreams := range cfgSnap.IngressGateway.Upstreams {
 		for _, u := range upstreams {
 			uid := proxycfg.NewUpstreamID(&u)
@@ -474,6 +531,9 @@ func (s *ResourceGenerator) clustersFromSnapshotIngressGateway(cfgSnap *proxycfg
 			}
 
 			for _, c := range upstreamClusters {
+				if sessionAffinity[uid] {
+					injectSessionAffinityLBToCluster(c)
+				}
 				clusters = append(clusters, c)
 			}
 			createdClusters[uid] = true
@@ -482,6 +542,39 @@ func (s *ResourceGenerator) clustersFromSnapshotIngressGateway(cfgSnap *proxycfg
 	return clusters, nil
 }
 
+// ingressSessionAffinityUpstreams returns the upstreams of the ingress gateway
+// that have session affinity enabled on any of the listeners routing to them.
+func ingressSessionAffinityUpstreams(cfgSnap *proxycfg.ConfigSnapshot) map[proxycfg.UpstreamID]bool {
+	result := make(map[proxycfg.UpstreamID]bool)
+	for listenerKey, upstreams := range cfgSnap.IngressGateway.Upstreams {
+		lCfg, ok := cfgSnap.IngressGateway.Listeners[listenerKey]
+		if !ok {
+			continue
+		}
+		for _, u := range upstreams {
+			svc := findIngressServiceMatchingUpstream(lCfg, u)
+			if svc != nil && svc.SessionAffinity != nil {
+				result[proxycfg.NewUpstreamID(&u)] = true
+			}
+		}
+	}
+	return result
+}
+
+// injectSessionAffinityLBToCluster switches a cluster to ring hash load
+// balancing unless it already uses a hash-based policy, since Envoy ignores
+// the cookie hash policy of a session affinity otherwise. Aggregate failover
+// clusters are left alone, their policy is provided by the clusters they
+// aggregate.
+func injectSessionAffinityLBToCluster(c *envoy_cluster_v3.Cluster) {
+	switch c.LbPolicy {
+	case envoy_cluster_v3.Cluster_RING_HASH, envoy_cluster_v3.Cluster_MAGLEV, envoy_cluster_v3.Cluster_CLUSTER_PROVIDED:
+		return
+	}
+	c.LbPolicy = envoy_cluster_v3.Cluster_RING_HASH
+	c.LbConfig = nil
+}
+
 func (s *ResourceGenerator) makeAppCluster(cfgSnap *proxycfg.ConfigSnapshot, name, pathProtocol string, port int) (*envoy_cluster_v3.Cluster, error) {
 	var c *envoy_cluster_v3.Cluster
 	var err error
@@ -498,8 +591,10 @@ func (s *ResourceGenerator) makeAppCluster(cfgSnap *proxycfg.ConfigSnapshot, nam
 		return makeClusterFromUserConfig(cfg.LocalClusterJSON)
 	}
 
+	// The socket path only replaces the address of the local service itself,
+	// the exposed paths are still served on their own port.
 	var endpoint *envoy_endpoint_v3.LbEndpoint
-	if cfgSnap.Proxy.LocalServiceSocketPath != "" {
+	if cfgSnap.Proxy.LocalServiceSocketPath != "" && name == LocalAppClusterName {
 		endpoint = makePipeEndpoint(cfgSnap.Proxy.LocalServiceSocketPath)
 	} else {
 		addr := cfgSnap.Proxy.LocalServiceAddress
@@ -528,8 +623,14 @@ func (s *ResourceGenerator) makeAppCluster(cfgSnap *proxycfg.ConfigSnapshot, nam
 	if protocol == "" {
 		protocol = cfg.Protocol
 	}
-	if protocol == "http2" || protocol == "grpc" {
-		if err := s.setHttp2ProtocolOptions(c); err != nil {
+	if cfg.LocalAppHTTP2(protocol) {
+		if err := setHttp2ProtocolOptionsWith(c, makeLocalAppHttp2ProtocolOptions(cfg)); err != nil {
+			return c, err
+		}
+	} else if protocol == "http" && cfg.RequestNormalization(cfgSnap.MeshConfig()).HeaderKeyFormat == structs.HeaderKeyFormatProperCase {
+		// Send the request headers to the application in the same casing as
+		// the responses sent by the public listener.
+		if err := setHttp1ProtocolOptionsWith(c, makeProperCaseHttp1ProtocolOptions()); err != nil {
 			return c, err
 		}
 	}
@@ -637,14 +738,15 @@ func (s *ResourceGenerator) makeUpstreamClusterForPeerService(
 		rootPEMs,
 		makeTLSParametersFromProxyTLSConfig(cfgSnap.MeshConfigTLSOutgoing()),
 	)
-	err = injectSANMatcher(commonTLSContext, peerMeta.SpiffeID...)
+	sni, sans := upstreamTLSValidation(cfg, peerMeta.PrimarySNI(), peerMeta.SpiffeID)
+	err = injectSANMatcher(commonTLSContext, sans...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to inject SAN matcher rules for cluster %q: %v", clusterName, err)
 	}
 
 	tlsContext := &envoy_tls_v3.UpstreamTlsContext{
 		CommonTlsContext: commonTLSContext,
-		Sni:              peerMeta.PrimarySNI(),
+		Sni:              sni,
 	}
 
 	transportSocket, err := makeUpstreamTLSTransportSocket(tlsContext)
@@ -739,14 +841,16 @@ func (s *ResourceGenerator) makeUpstreamClusterForPreparedQuery(upstream structs
 		cfgSnap.RootPEMs(),
 		makeTLSParametersFromProxyTLSConfig(cfgSnap.MeshConfigTLSOutgoing()),
 	)
-	err = injectSANMatcher(commonTLSContext, spiffeIDs...)
+	// The cluster keeps the name derived from the default SNI.
+	tlsSNI, sans := upstreamTLSValidation(cfg, sni, spiffeIDs)
+	err = injectSANMatcher(commonTLSContext, sans...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to inject SAN matcher rules for cluster %q: %v", sni, err)
 	}
 
 	tlsContext := &envoy_tls_v3.UpstreamTlsContext{
 		CommonTlsContext: commonTLSContext,
-		Sni:              sni,
+		Sni:              tlsSNI,
 	}
 
 	transportSocket, err := makeUpstreamTLSTransportSocket(tlsContext)
@@ -940,7 +1044,14 @@ func (s *ResourceGenerator) makeUpstreamClustersForDiscoveryChain(
 			makeTLSParametersFromProxyTLSConfig(cfgSnap.MeshConfigTLSOutgoing()),
 		)
 
-		err = injectSANMatcher(commonTLSContext, spiffeIDs...)
+		// The TLS overrides of the upstream config only apply to the service of
+		// the upstream itself, not to the other services or datacenters the
+		// chain may route or fail over to.
+		sans := spiffeIDs
+		if isChainServiceTarget(chain, target) {
+			sni, sans = upstreamTLSValidation(cfg, sni, spiffeIDs)
+		}
+		err = injectSANMatcher(commonTLSContext, sans...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to inject SAN matcher rules for cluster %q: %v", sni, err)
 		}
@@ -973,6 +1084,28 @@ func (s *ResourceGenerator) makeUpstreamClustersForDiscoveryChain(
 	return out, nil
 }
 
+// isChainServiceTarget returns whether the target is the service the chain
+// was compiled for, in the datacenter of the chain.
+func isChainServiceTarget(chain *structs.CompiledDiscoveryChain, target *structs.DiscoveryTarget) bool {
+	return target.Service == chain.ServiceName &&
+		target.Namespace == chain.Namespace &&
+		target.Partition == chain.Partition &&
+		target.Datacenter == chain.Datacenter
+}
+
+// upstreamTLSValidation returns the SNI to send to the upstream and the SANs
+// to accept from its certificate, applying the overrides of the upstream
+// config to the defaults derived from Consul's SPIFFE conventions.
+func upstreamTLSValidation(cfg structs.UpstreamConfig, sni string, sans []string) (string, []string) {
+	if cfg.SNI != "" {
+		sni = cfg.SNI
+	}
+	if len(cfg.AcceptedSANs) > 0 {
+		sans = cfg.AcceptedSANs
+	}
+	return sni, sans
+}
+
 // injectSANMatcher updates a TLS context so that it verifies the upstream SAN.
 func injectSANMatcher(tlsContext *envoy_tls_v3.CommonTlsContext, matchStrings ...string) error {
 	validationCtx, ok := tlsContext.ValidationContextType.(*envoy_tls_v3.CommonTlsContext_ValidationContext)
@@ -1215,20 +1348,32 @@ func injectLBToCluster(ec *structs.LoadBalancer, c *envoy_cluster_v3.Cluster) er
 
 	switch ec.Policy {
 	case "":
+		// Envoy defaults to round robin.
+		if ec.SlowStartConfig != nil {
+			c.LbConfig = makeRoundRobinLbConfig(ec.SlowStartConfig)
+		}
 		return nil
 	case structs.LBPolicyLeastRequest:
 		c.LbPolicy = envoy_cluster_v3.Cluster_LEAST_REQUEST
 
-		if ec.LeastRequestConfig != nil {
+		if ec.LeastRequestConfig != nil || ec.SlowStartConfig != nil {
+			lbConfig := &envoy_cluster_v3.Cluster_LeastRequestLbConfig{
+				SlowStartConfig: makeSlowStartConfig(ec.SlowStartConfig),
+			}
+			if ec.LeastRequestConfig != nil {
+				lbConfig.ChoiceCount = &wrappers.UInt32Value{Value: ec.LeastRequestConfig.ChoiceCount}
+			}
 			c.LbConfig = &envoy_cluster_v3.Cluster_LeastRequestLbConfig_{
-				LeastRequestLbConfig: &envoy_cluster_v3.Cluster_LeastRequestLbConfig{
-					ChoiceCount: &wrappers.UInt32Value{Value: ec.LeastRequestConfig.ChoiceCount},
-				},
+				LeastRequestLbConfig: lbConfig,
 			}
 		}
 	case structs.LBPolicyRoundRobin:
 		c.LbPolicy = envoy_cluster_v3.Cluster_ROUND_ROBIN
 
+		if ec.SlowStartConfig != nil {
+			c.LbConfig = makeRoundRobinLbConfig(ec.SlowStartConfig)
+		}
+
 	case structs.LBPolicyRandom:
 		c.LbPolicy = envoy_cluster_v3.Cluster_RANDOM
 
@@ -1252,12 +1397,40 @@ func injectLBToCluster(ec *structs.LoadBalancer, c *envoy_cluster_v3.Cluster) er
 	return nil
 }
 
+func makeRoundRobinLbConfig(cfg *structs.SlowStartConfig) *envoy_cluster_v3.Cluster_RoundRobinLbConfig_ {
+	return &envoy_cluster_v3.Cluster_RoundRobinLbConfig_{
+		RoundRobinLbConfig: &envoy_cluster_v3.Cluster_RoundRobinLbConfig{
+			SlowStartConfig: makeSlowStartConfig(cfg),
+		},
+	}
+}
+
+func makeSlowStartConfig(cfg *structs.SlowStartConfig) *envoy_cluster_v3.Cluster_SlowStartConfig {
+	if cfg == nil {
+		return nil
+	}
+	slowStart := &envoy_cluster_v3.Cluster_SlowStartConfig{
+		SlowStartWindow: durationpb.New(cfg.Window),
+	}
+	if cfg.Aggression > 0 {
+		slowStart.Aggression = &envoy_core_v3.RuntimeDouble{
+			DefaultValue: cfg.Aggression,
+			RuntimeKey:   slowStartAggressionRuntimeKey,
+		}
+	}
+	return slowStart
+}
+
 func (s *ResourceGenerator) setHttp2ProtocolOptions(c *envoy_cluster_v3.Cluster) error {
+	return setHttp2ProtocolOptionsWith(c, &envoy_core_v3.Http2ProtocolOptions{})
+}
+
+func setHttp2ProtocolOptionsWith(c *envoy_cluster_v3.Cluster, opts *envoy_core_v3.Http2ProtocolOptions) error {
 	cfg := &envoy_upstreams_v3.HttpProtocolOptions{
 		UpstreamProtocolOptions: &envoy_upstreams_v3.HttpProtocolOptions_ExplicitHttpConfig_{
 			ExplicitHttpConfig: &envoy_upstreams_v3.HttpProtocolOptions_ExplicitHttpConfig{
 				ProtocolConfig: &envoy_upstreams_v3.HttpProtocolOptions_ExplicitHttpConfig_Http2ProtocolOptions{
-					Http2ProtocolOptions: &envoy_core_v3.Http2ProtocolOptions{},
+					Http2ProtocolOptions: opts,
 				},
 			},
 		},
@@ -1272,3 +1445,45 @@ func (s *ResourceGenerator) setHttp2ProtocolOptions(c *envoy_cluster_v3.Cluster)
 
 	return nil
 }
+
+func setHttp1ProtocolOptionsWith(c *envoy_cluster_v3.Cluster, opts *envoy_core_v3.Http1ProtocolOptions) error {
+	cfg := &envoy_upstreams_v3.HttpProtocolOptions{
+		UpstreamProtocolOptions: &envoy_upstreams_v3.HttpProtocolOptions_ExplicitHttpConfig_{
+			ExplicitHttpConfig: &envoy_upstreams_v3.HttpProtocolOptions_ExplicitHttpConfig{
+				ProtocolConfig: &envoy_upstreams_v3.HttpProtocolOptions_ExplicitHttpConfig_HttpProtocolOptions{
+					HttpProtocolOptions: opts,
+				},
+			},
+		},
+	}
+	any, err := anypb.New(cfg)
+	if err != nil {
+		return err
+	}
+	c.TypedExtensionProtocolOptions = map[string]*anypb.Any{
+		"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": any,
+	}
+
+	return nil
+}
+
+// makeLocalAppHttp2ProtocolOptions returns the HTTP/2 options used to talk to
+// the local app instance. Keepalive pings stop idle connections from being
+// silently dropped, which gRPC servers typically answer with a GOAWAY.
+func makeLocalAppHttp2ProtocolOptions(cfg ProxyConfig) *envoy_core_v3.Http2ProtocolOptions {
+	opts := &envoy_core_v3.Http2ProtocolOptions{}
+	if cfg.LocalAppHTTP2MaxConcurrentStreams > 0 {
+		opts.MaxConcurrentStreams = makeUint32Value(cfg.LocalAppHTTP2MaxConcurrentStreams)
+	}
+	if cfg.LocalAppHTTP2KeepaliveIntervalMs > 0 {
+		timeoutMs := cfg.LocalAppHTTP2KeepaliveTimeoutMs
+		if timeoutMs < 1 {
+			timeoutMs = 5000
+		}
+		opts.ConnectionKeepalive = &envoy_core_v3.KeepaliveSettings{
+			Interval: durationpb.New(time.Duration(cfg.LocalAppHTTP2KeepaliveIntervalMs) * time.Millisecond),
+			Timeout:  durationpb.New(time.Duration(timeoutMs) * time.Millisecond),
+		}
+	}
+	return opts
+}
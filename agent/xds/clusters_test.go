@@ -6,12 +6,15 @@ import (
 	"sort"
 	"testing"
 	"text/template"
+	"time"
 
 	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 
 	"github.com/golang/protobuf/ptypes/wrappers"
 	testinf "github.com/mitchellh/go-testing-interface"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/hashicorp/consul/agent/proxycfg"
 	"github.com/hashicorp/consul/agent/structs"
@@ -176,6 +179,51 @@ func TestClustersFromSnapshot(t *testing.T) {
 				}, nil)
 			},
 		},
+		{
+			name: "custom-upstream-tls-validation",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshot(t, func(ns *structs.NodeService) {
+					ns.Proxy.Upstreams[0].Config["sni"] = "db.legacy.example.com"
+					ns.Proxy.Upstreams[0].Config["accepted_sans"] = []string{"db.legacy.example.com"}
+				}, nil)
+			},
+		},
+		{
+			name: "custom-upstream-tls-validation-prepared-query",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshot(t, func(ns *structs.NodeService) {
+					ns.Proxy.Upstreams[1].Config = map[string]interface{}{
+						"sni":           "geo-cache.legacy.example.com",
+						"accepted_sans": []string{"geo-cache.legacy.example.com"},
+					}
+				}, nil)
+			},
+		},
+		{
+			// The overrides only apply to the db targets in dc1.
+			name: "custom-upstream-tls-validation-multidc-chain",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshotDiscoveryChain(t, "splitter-with-resolver-redirect-multidc", func(ns *structs.NodeService) {
+					ns.Proxy.Upstreams[0].Config["sni"] = "db.legacy.example.com"
+					ns.Proxy.Upstreams[0].Config["accepted_sans"] = []string{"db.legacy.example.com"}
+				}, nil)
+			},
+		},
+		{
+			name: "connect-proxy-with-peered-upstreams-tls-validation",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				snap := proxycfg.TestConfigSnapshotPeering(t)
+				for _, u := range snap.ConnectProxy.UpstreamConfig {
+					if u.DestinationName == "payments" {
+						u.Config = map[string]interface{}{
+							"sni":           "payments.legacy.example.com",
+							"accepted_sans": []string{"payments.legacy.example.com"},
+						}
+					}
+				}
+				return snap
+			},
+		},
 		{
 			name: "custom-max-inbound-connections",
 			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
@@ -184,6 +232,50 @@ func TestClustersFromSnapshot(t *testing.T) {
 				}, nil)
 			},
 		},
+		{
+			name: "custom-local-app-http2-keepalive",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshot(t, func(ns *structs.NodeService) {
+					ns.Proxy.Config["protocol"] = "grpc"
+					ns.Proxy.Config["local_app_http2_keepalive_interval_ms"] = 30000
+					ns.Proxy.Config["local_app_http2_max_concurrent_streams"] = 100
+				}, nil)
+			},
+		},
+		{
+			name: "custom-local-app-proper-case-headers",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshot(t, func(ns *structs.NodeService) {
+					ns.Proxy.Config["protocol"] = "http"
+				}, []proxycfg.UpdateEvent{
+					{
+						CorrelationID: "mesh",
+						Result: &structs.ConfigEntryResponse{
+							Entry: &structs.MeshConfigEntry{
+								HTTP: &structs.MeshHTTPConfig{
+									Incoming: &structs.MeshDirectionalHTTPConfig{
+										RequestNormalization: &structs.RequestNormalizationConfig{
+											HeaderKeyFormat: structs.HeaderKeyFormatProperCase,
+										},
+									},
+								},
+							},
+						},
+					},
+				})
+			},
+		},
+		{
+			name: "custom-local-app-http2-prior-knowledge",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshot(t, func(ns *structs.NodeService) {
+					ns.Proxy.Config["protocol"] = "http"
+					ns.Proxy.Config["local_app_http2_prior_knowledge"] = true
+					ns.Proxy.Config["local_app_http2_keepalive_interval_ms"] = 30000
+					ns.Proxy.Config["local_app_http2_keepalive_timeout_ms"] = 1000
+				}, nil)
+			},
+		},
 		{
 			name: "custom-limits-max-connections-only",
 			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
@@ -342,6 +434,28 @@ func TestClustersFromSnapshot(t *testing.T) {
 				}, nil)
 			},
 		},
+		{
+			name: "downstream-service-with-named-pipe",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshot(t, func(ns *structs.NodeService) {
+					ns.Address = ""
+					ns.Port = 0
+					ns.Proxy.LocalServiceAddress = ""
+					ns.Proxy.LocalServicePort = 0
+					ns.Proxy.LocalServiceSocketPath = `\\.\pipe\web`
+				}, nil)
+			},
+		},
+		{
+			name: "expose-paths-downstream-service-with-unix-sockets",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshotExposeConfig(t, func(ns *structs.NodeService) {
+					ns.Proxy.LocalServiceAddress = ""
+					ns.Proxy.LocalServicePort = 0
+					ns.Proxy.LocalServiceSocketPath = "/tmp/downstream_proxy.sock"
+				})
+			},
+		},
 		{
 			name: "expose-paths-new-cluster-http2",
 			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
@@ -371,6 +485,12 @@ func TestClustersFromSnapshot(t *testing.T) {
 				return proxycfg.TestConfigSnapshotMeshGateway(t, "federation-states", nil, nil)
 			},
 		},
+		{
+			name: "mesh-gateway-peer-through-mesh-gateways",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshotMeshGateway(t, "peer-through-mesh-gateways", nil, nil)
+			},
+		},
 		{
 			name: "mesh-gateway-no-services",
 			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
@@ -578,6 +698,25 @@ func TestClustersFromSnapshot(t *testing.T) {
 				return proxycfg.TestConfigSnapshotTerminatingGateway(t, true, nil, nil)
 			},
 		},
+		{
+			name: "terminating-gateway-request-normalization",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshotTerminatingGateway(t, true, nil, []proxycfg.UpdateEvent{
+					{
+						CorrelationID: "service-config:" + structs.NewServiceName("web", nil).String(),
+						Result: &structs.ServiceConfigResponse{
+							ProxyConfig: map[string]interface{}{
+								"protocol":          "http",
+								"merge_slashes":     true,
+								"header_key_format": "proper_case",
+								// Invalid overrides are ignored.
+								"headers_with_underscores_action": "DROP",
+							},
+						},
+					},
+				})
+			},
+		},
 		{
 			name: "terminating-gateway-no-services",
 			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
@@ -612,6 +751,24 @@ func TestClustersFromSnapshot(t *testing.T) {
 			name:   "transparent-proxy",
 			create: proxycfg.TestConfigSnapshotTransparentProxy,
 		},
+		{
+			name:   "transparent-proxy-on-demand",
+			create: proxycfg.TestConfigSnapshotTransparentProxyOnDemand,
+		},
+		{
+			name:   "transparent-proxy-egress-dynamic-forward-proxy",
+			create: proxycfg.TestConfigSnapshotTransparentProxyEgressDynamicForwardProxy,
+		},
+		{
+			name: "egress-gateway",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshotEgressGateway(t, nil, nil, nil)
+			},
+		},
+		{
+			name:   "egress-gateway-reputation-check",
+			create: proxycfg.TestConfigSnapshotEgressGatewayReputationCheck,
+		},
 		{
 			name:   "transparent-proxy-catalog-destinations-only",
 			create: proxycfg.TestConfigSnapshotTransparentProxyCatalogDestinationsOnly,
@@ -725,6 +882,9 @@ func setupTLSRootsAndLeaf(t *testing.T, snap *proxycfg.ConfigSnapshot) {
 		case structs.ServiceKindIngressGateway:
 			snap.IngressGateway.Leaf.CertPEM = loadTestResource(t, "test-leaf-cert")
 			snap.IngressGateway.Leaf.PrivateKeyPEM = loadTestResource(t, "test-leaf-key")
+		case structs.ServiceKindEgressGateway:
+			snap.EgressGateway.Leaf.CertPEM = loadTestResource(t, "test-leaf-cert")
+			snap.EgressGateway.Leaf.PrivateKeyPEM = loadTestResource(t, "test-leaf-key")
 		}
 	}
 	if snap.Roots != nil {
@@ -802,6 +962,47 @@ func TestEnvoyLBConfig_InjectToCluster(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "default policy with slow start",
+			lb: &structs.LoadBalancer{
+				SlowStartConfig: &structs.SlowStartConfig{
+					Window: 30 * time.Second,
+				},
+			},
+			expected: &envoy_cluster_v3.Cluster{
+				LbConfig: &envoy_cluster_v3.Cluster_RoundRobinLbConfig_{
+					RoundRobinLbConfig: &envoy_cluster_v3.Cluster_RoundRobinLbConfig{
+						SlowStartConfig: &envoy_cluster_v3.Cluster_SlowStartConfig{
+							SlowStartWindow: durationpb.New(30 * time.Second),
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "least_request with slow start",
+			lb: &structs.LoadBalancer{
+				Policy: structs.LBPolicyLeastRequest,
+				SlowStartConfig: &structs.SlowStartConfig{
+					Window:     time.Minute,
+					Aggression: 1.5,
+				},
+			},
+			expected: &envoy_cluster_v3.Cluster{
+				LbPolicy: envoy_cluster_v3.Cluster_LEAST_REQUEST,
+				LbConfig: &envoy_cluster_v3.Cluster_LeastRequestLbConfig_{
+					LeastRequestLbConfig: &envoy_cluster_v3.Cluster_LeastRequestLbConfig{
+						SlowStartConfig: &envoy_cluster_v3.Cluster_SlowStartConfig{
+							SlowStartWindow: durationpb.New(time.Minute),
+							Aggression: &envoy_core_v3.RuntimeDouble{
+								DefaultValue: 1.5,
+								RuntimeKey:   slowStartAggressionRuntimeKey,
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -815,6 +1016,68 @@ func TestEnvoyLBConfig_InjectToCluster(t *testing.T) {
 	}
 }
 
+func TestInjectSessionAffinityLBToCluster(t *testing.T) {
+	var tests = []struct {
+		name     string
+		cluster  *envoy_cluster_v3.Cluster
+		expected *envoy_cluster_v3.Cluster
+	}{
+		{
+			name:     "default policy is switched to ring hash",
+			cluster:  &envoy_cluster_v3.Cluster{},
+			expected: &envoy_cluster_v3.Cluster{LbPolicy: envoy_cluster_v3.Cluster_RING_HASH},
+		},
+		{
+			name: "least request is switched to ring hash",
+			cluster: &envoy_cluster_v3.Cluster{
+				LbPolicy: envoy_cluster_v3.Cluster_LEAST_REQUEST,
+				LbConfig: &envoy_cluster_v3.Cluster_LeastRequestLbConfig_{
+					LeastRequestLbConfig: &envoy_cluster_v3.Cluster_LeastRequestLbConfig{
+						ChoiceCount: &wrappers.UInt32Value{Value: 3},
+					},
+				},
+			},
+			expected: &envoy_cluster_v3.Cluster{LbPolicy: envoy_cluster_v3.Cluster_RING_HASH},
+		},
+		{
+			name: "ring hash is kept",
+			cluster: &envoy_cluster_v3.Cluster{
+				LbPolicy: envoy_cluster_v3.Cluster_RING_HASH,
+				LbConfig: &envoy_cluster_v3.Cluster_RingHashLbConfig_{
+					RingHashLbConfig: &envoy_cluster_v3.Cluster_RingHashLbConfig{
+						MinimumRingSize: &wrappers.UInt64Value{Value: 3},
+					},
+				},
+			},
+			expected: &envoy_cluster_v3.Cluster{
+				LbPolicy: envoy_cluster_v3.Cluster_RING_HASH,
+				LbConfig: &envoy_cluster_v3.Cluster_RingHashLbConfig_{
+					RingHashLbConfig: &envoy_cluster_v3.Cluster_RingHashLbConfig{
+						MinimumRingSize: &wrappers.UInt64Value{Value: 3},
+					},
+				},
+			},
+		},
+		{
+			name:     "maglev is kept",
+			cluster:  &envoy_cluster_v3.Cluster{LbPolicy: envoy_cluster_v3.Cluster_MAGLEV},
+			expected: &envoy_cluster_v3.Cluster{LbPolicy: envoy_cluster_v3.Cluster_MAGLEV},
+		},
+		{
+			name:     "aggregate cluster is kept",
+			cluster:  &envoy_cluster_v3.Cluster{LbPolicy: envoy_cluster_v3.Cluster_CLUSTER_PROVIDED},
+			expected: &envoy_cluster_v3.Cluster{LbPolicy: envoy_cluster_v3.Cluster_CLUSTER_PROVIDED},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			injectSessionAffinityLBToCluster(tc.cluster)
+			require.Equal(t, tc.expected, tc.cluster)
+		})
+	}
+}
+
 // UID is just a convenience function to aid in writing tests less verbosely.
 func UID(input string) proxycfg.UpstreamID {
 	return proxycfg.UpstreamIDFromString(input)
@@ -1,6 +1,8 @@
 package xds
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 
 	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
@@ -62,6 +64,127 @@ type ProxyConfig struct {
 	// MaxInboundConnections is the maximum number of inbound connections to
 	// the proxy. If not set, the default is 0 (no limit).
 	MaxInboundConnections int `mapstructure:"max_inbound_connections"`
+
+	// InboundConnectionBufferLimitBytes is the soft limit on the size of the
+	// read and write buffers of every connection to the public listener. If
+	// not set, Envoy defaults are respected (1MiB).
+	InboundConnectionBufferLimitBytes int `mapstructure:"inbound_connection_buffer_limit_bytes"`
+
+	// MaxRequestBodyBytes is the maximum size of the body of a request to the
+	// public listener of an HTTP service. Larger requests are rejected with a
+	// 413 status. If not set, the default is 0 (no limit).
+	MaxRequestBodyBytes int `mapstructure:"max_request_body_bytes"`
+
+	// OnDemandUpstreams makes a transparent proxy only watch the upstreams
+	// inferred from intentions once Envoy demands them. It is handled by
+	// proxycfg which decides what to watch.
+	OnDemandUpstreams bool `mapstructure:"on_demand_upstreams"`
+
+	// OnDemandUpstreamsTTL is how long the upstreams demanded by a proxy in
+	// on-demand mode are watched without traffic, as a duration string. It is
+	// handled by proxycfg which prunes the idle upstreams.
+	OnDemandUpstreamsTTL string `mapstructure:"on_demand_upstreams_ttl"`
+
+	// BuiltinMetrics makes a connect proxy report the requests to its
+	// upstreams to the local agent, which forwards them to the servers to
+	// summarize the traffic between services without a metrics provider.
+	BuiltinMetrics bool `mapstructure:"builtin_metrics"`
+
+	// DetectUpstreamProtocols makes a connect proxy detect whether the
+	// traffic to its upstreams without a configured protocol is HTTP, and
+	// report the detected protocols to the local agent.
+	DetectUpstreamProtocols bool `mapstructure:"detect_upstream_protocols"`
+
+	// EgressDynamicForwardProxy makes a transparent proxy resolve and connect
+	// to the hostnames allowed by the egress configuration of the mesh config
+	// entry with Envoy's dynamic forward proxy, rather than sending the
+	// traffic to its original destination.
+	EgressDynamicForwardProxy bool `mapstructure:"egress_dynamic_forward_proxy"`
+
+	// LocalAppHTTP2PriorKnowledge forces the proxy to speak HTTP/2 without
+	// TLS or upgrade (h2c) to the local app instance, even when Protocol is
+	// "http". The local app cluster always uses HTTP/2 for the "http2" and
+	// "grpc" protocols.
+	LocalAppHTTP2PriorKnowledge bool `mapstructure:"local_app_http2_prior_knowledge"`
+
+	// LocalAppHTTP2KeepaliveIntervalMs is the number of milliseconds between
+	// the HTTP/2 PING frames sent to the local app instance to keep idle
+	// connections alive. If not set, no keepalive pings are sent.
+	LocalAppHTTP2KeepaliveIntervalMs int `mapstructure:"local_app_http2_keepalive_interval_ms"`
+
+	// LocalAppHTTP2KeepaliveTimeoutMs is the number of milliseconds to wait
+	// for the response to a keepalive ping before closing the connection to
+	// the local app instance. Defaults to 5000 (5 seconds) if not set.
+	LocalAppHTTP2KeepaliveTimeoutMs int `mapstructure:"local_app_http2_keepalive_timeout_ms"`
+
+	// LocalAppHTTP2MaxConcurrentStreams is the maximum number of concurrent
+	// HTTP/2 streams on a single connection to the local app instance. If not
+	// set, Envoy defaults are respected.
+	LocalAppHTTP2MaxConcurrentStreams int `mapstructure:"local_app_http2_max_concurrent_streams"`
+
+	// NormalizePath, MergeSlashes, PathWithEscapedSlashesAction,
+	// HeadersWithUnderscoresAction and HeaderKeyFormat override the request
+	// normalization options of the mesh config entry for the public listener
+	// of the proxy. See structs.RequestNormalizationConfig.
+	NormalizePath                *bool  `mapstructure:"normalize_path"`
+	MergeSlashes                 *bool  `mapstructure:"merge_slashes"`
+	PathWithEscapedSlashesAction string `mapstructure:"path_with_escaped_slashes_action"`
+	HeadersWithUnderscoresAction string `mapstructure:"headers_with_underscores_action"`
+	HeaderKeyFormat              string `mapstructure:"header_key_format"`
+
+	// GenerateRequestID, PreserveRequestID, TraceContextPolicy and
+	// ObservabilityRequestHeaders override the observability headers options
+	// of the mesh config entry for the public listener of the proxy, or the
+	// HTTP listeners of an ingress gateway. See
+	// structs.ObservabilityHeadersConfig.
+	GenerateRequestID           *bool             `mapstructure:"generate_request_id"`
+	PreserveRequestID           *bool             `mapstructure:"preserve_request_id"`
+	TraceContextPolicy          string            `mapstructure:"trace_context_policy"`
+	ObservabilityRequestHeaders map[string]string `mapstructure:"observability_request_headers"`
+
+	// RemoteGateways configures the order in which the proxy uses the mesh
+	// gateways of remote datacenters or partitions to reach its upstreams.
+	// See GatewayConfig.RemoteGateways.
+	RemoteGateways map[string][]RemoteGatewayGroup `mapstructure:"envoy_mesh_gateway_remote_gateways"`
+}
+
+// LocalAppHTTP2 reports whether the local app cluster speaks HTTP/2 for the
+// given protocol.
+func (c ProxyConfig) LocalAppHTTP2(protocol string) bool {
+	return protocol == "http2" || protocol == "grpc" || (protocol == "http" && c.LocalAppHTTP2PriorKnowledge)
+}
+
+// RequestNormalization returns the request normalization options of the mesh
+// config entry overridden by the ones of c.
+func (c ProxyConfig) RequestNormalization(mesh *structs.MeshConfigEntry) *structs.RequestNormalizationConfig {
+	var meshCfg *structs.RequestNormalizationConfig
+	if mesh != nil && mesh.HTTP != nil && mesh.HTTP.Incoming != nil {
+		meshCfg = mesh.HTTP.Incoming.RequestNormalization
+	}
+
+	return meshCfg.OverlayWith(&structs.RequestNormalizationConfig{
+		NormalizePath:                c.NormalizePath,
+		MergeSlashes:                 c.MergeSlashes,
+		PathWithEscapedSlashesAction: c.PathWithEscapedSlashesAction,
+		HeadersWithUnderscoresAction: c.HeadersWithUnderscoresAction,
+		HeaderKeyFormat:              c.HeaderKeyFormat,
+	})
+}
+
+// ObservabilityHeaders returns the observability headers options of the mesh
+// config entry overridden by the ones of c.
+func (c ProxyConfig) ObservabilityHeaders(mesh *structs.MeshConfigEntry) *structs.ObservabilityHeadersConfig {
+	var meshCfg *structs.ObservabilityHeadersConfig
+	if mesh != nil && mesh.HTTP != nil && mesh.HTTP.Incoming != nil {
+		meshCfg = mesh.HTTP.Incoming.ObservabilityHeaders
+	}
+
+	return meshCfg.OverlayWith(&structs.ObservabilityHeadersConfig{
+		GenerateRequestID:  c.GenerateRequestID,
+		PreserveRequestID:  c.PreserveRequestID,
+		TraceContextPolicy: c.TraceContextPolicy,
+		RequestHeaders:     c.ObservabilityRequestHeaders,
+	})
 }
 
 // ParseProxyConfig returns the ProxyConfig parsed from the an opaque map. If an
@@ -95,9 +218,69 @@ func ParseProxyConfig(m map[string]interface{}) (ProxyConfig, error) {
 		cfg.LocalConnectTimeoutMs = 5000
 	}
 
+	err = cfg.dropInvalidRequestNormalization()
+	if obsErr := cfg.dropInvalidObservabilityHeaders(); obsErr != nil && err == nil {
+		err = obsErr
+	}
+	if rgErr := validateRemoteGateways(cfg.RemoteGateways); rgErr != nil {
+		cfg.RemoteGateways = nil
+		if err == nil {
+			err = rgErr
+		}
+	}
 	return cfg, err
 }
 
+// dropInvalidRequestNormalization clears the request normalization overrides
+// which are not one of the values accepted by Envoy, so that the ones of the
+// mesh config entry apply instead. It returns an error describing the first
+// one.
+func (c *ProxyConfig) dropInvalidRequestNormalization() error {
+	var firstErr error
+	check := func(value *string, override structs.RequestNormalizationConfig) {
+		if err := override.Validate(); err != nil {
+			*value = ""
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	check(&c.PathWithEscapedSlashesAction, structs.RequestNormalizationConfig{PathWithEscapedSlashesAction: c.PathWithEscapedSlashesAction})
+	check(&c.HeadersWithUnderscoresAction, structs.RequestNormalizationConfig{HeadersWithUnderscoresAction: c.HeadersWithUnderscoresAction})
+	check(&c.HeaderKeyFormat, structs.RequestNormalizationConfig{HeaderKeyFormat: c.HeaderKeyFormat})
+	return firstErr
+}
+
+// dropInvalidObservabilityHeaders clears the trace context policy override and
+// removes the request headers which aren't valid, so that only those are
+// ignored rather than all the observability headers options. It returns an
+// error describing the first one.
+func (c *ProxyConfig) dropInvalidObservabilityHeaders() error {
+	var firstErr error
+	if err := (&structs.ObservabilityHeadersConfig{TraceContextPolicy: c.TraceContextPolicy}).Validate(); err != nil {
+		c.TraceContextPolicy = ""
+		firstErr = err
+	}
+
+	names := make([]string, 0, len(c.ObservabilityRequestHeaders))
+	for name := range c.ObservabilityRequestHeaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		header := &structs.ObservabilityHeadersConfig{
+			RequestHeaders: map[string]string{name: c.ObservabilityRequestHeaders[name]},
+		}
+		if err := header.Validate(); err != nil {
+			delete(c.ObservabilityRequestHeaders, name)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
 type GatewayConfig struct {
 	// BindTaggedAddresses when set will cause all of the services tagged
 	// addresses to have listeners bound to them in addition to the main service
@@ -122,6 +305,25 @@ type GatewayConfig struct {
 	// ConnectTimeoutMs is the number of milliseconds to timeout making a new
 	// connection to this upstream. Defaults to 5000 (5 seconds) if not set.
 	ConnectTimeoutMs int `mapstructure:"connect_timeout_ms"`
+
+	// RemoteGateways configures the order in which a mesh gateway uses the
+	// gateways of remote datacenters or partitions. It is keyed by datacenter,
+	// or by "<partition>.<datacenter>" for non-default partitions. Gateways
+	// that aren't listed are used after all the groups, and all the gateways
+	// are used in turn when it isn't set.
+	RemoteGateways map[string][]RemoteGatewayGroup `mapstructure:"envoy_mesh_gateway_remote_gateways"`
+}
+
+// RemoteGatewayGroup is a set of remote gateways used together. A group only
+// receives traffic when none of the gateways of the groups before it are
+// healthy.
+type RemoteGatewayGroup struct {
+	// Nodes are the names of the nodes of the gateways in the group.
+	Nodes []string `mapstructure:"nodes"`
+
+	// Weight is the load balancing weight of the gateways of the group.
+	// Defaults to the weight of the gateway services.
+	Weight int `mapstructure:"weight"`
 }
 
 // ParseGatewayConfig returns the GatewayConfig parsed from an opaque map. If an
@@ -150,9 +352,25 @@ func ParseGatewayConfig(m map[string]interface{}) (GatewayConfig, error) {
 
 	cfg.DNSDiscoveryType = strings.ToLower(cfg.DNSDiscoveryType)
 
+	if err := validateRemoteGateways(cfg.RemoteGateways); err != nil {
+		cfg.RemoteGateways = nil
+		return cfg, err
+	}
+
 	return cfg, err
 }
 
+func validateRemoteGateways(remoteGateways map[string][]RemoteGatewayGroup) error {
+	for key, groups := range remoteGateways {
+		for _, group := range groups {
+			if group.Weight < 0 {
+				return fmt.Errorf("remote gateways of %q: weight must not be negative", key)
+			}
+		}
+	}
+	return nil
+}
+
 // Return an envoy.OutlierDetection populated by the values from this struct.
 // If all values are zero a default empty OutlierDetection will be returned to
 // enable outlier detection with default values.
@@ -170,3 +388,12 @@ func ToOutlierDetection(p *structs.PassiveHealthCheck) *envoy_cluster_v3.Outlier
 	}
 	return od
 }
+
+// EgressHostnames returns the external hostnames the proxy reaches through
+// the dynamic forward proxy, or nil if it isn't enabled.
+func (c ProxyConfig) EgressHostnames(mesh *structs.MeshConfigEntry) []string {
+	if !c.EgressDynamicForwardProxy || mesh == nil || mesh.Egress == nil {
+		return nil
+	}
+	return mesh.Egress.AllowedHostnames
+}
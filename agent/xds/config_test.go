@@ -9,6 +9,8 @@ import (
 )
 
 func TestParseProxyConfig(t *testing.T) {
+	enabled, disabled := true, false
+
 	tests := []struct {
 		name  string
 		input map[string]interface{}
@@ -157,6 +159,63 @@ func TestParseProxyConfig(t *testing.T) {
 				Protocol:              "tcp",
 			},
 		},
+		{
+			name: "local app http2 options",
+			input: map[string]interface{}{
+				"local_app_http2_prior_knowledge":        true,
+				"local_app_http2_keepalive_interval_ms":  "30000",
+				"local_app_http2_keepalive_timeout_ms":   float64(2000),
+				"local_app_http2_max_concurrent_streams": 100,
+			},
+			want: ProxyConfig{
+				LocalConnectTimeoutMs:             5000,
+				Protocol:                          "tcp",
+				LocalAppHTTP2PriorKnowledge:       true,
+				LocalAppHTTP2KeepaliveIntervalMs:  30000,
+				LocalAppHTTP2KeepaliveTimeoutMs:   2000,
+				LocalAppHTTP2MaxConcurrentStreams: 100,
+			},
+		},
+		{
+			name: "request normalization options",
+			input: map[string]interface{}{
+				"normalize_path":                   true,
+				"merge_slashes":                    "false",
+				"path_with_escaped_slashes_action": "REJECT_REQUEST",
+				"headers_with_underscores_action":  "DROP_HEADER",
+				"header_key_format":                "proper_case",
+			},
+			want: ProxyConfig{
+				LocalConnectTimeoutMs:        5000,
+				Protocol:                     "tcp",
+				NormalizePath:                &enabled,
+				MergeSlashes:                 &disabled,
+				PathWithEscapedSlashesAction: "REJECT_REQUEST",
+				HeadersWithUnderscoresAction: "DROP_HEADER",
+				HeaderKeyFormat:              "proper_case",
+			},
+		},
+		{
+			name: "remote gateways",
+			input: map[string]interface{}{
+				"envoy_mesh_gateway_remote_gateways": map[string]interface{}{
+					"dc2": []interface{}{
+						map[string]interface{}{"nodes": []interface{}{"gw-a"}, "weight": 5},
+						map[string]interface{}{"nodes": []interface{}{"gw-b"}},
+					},
+				},
+			},
+			want: ProxyConfig{
+				LocalConnectTimeoutMs: 5000,
+				Protocol:              "tcp",
+				RemoteGateways: map[string][]RemoteGatewayGroup{
+					"dc2": {
+						{Nodes: []string{"gw-a"}, Weight: 5},
+						{Nodes: []string{"gw-b"}},
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -214,6 +273,32 @@ func TestParseGatewayConfig(t *testing.T) {
 				DNSDiscoveryType:    "strict_dns",
 			},
 		},
+		{
+			name: "remote gateways",
+			input: map[string]interface{}{
+				"envoy_mesh_gateway_remote_gateways": map[string]interface{}{
+					"dc2": []interface{}{
+						map[string]interface{}{"nodes": []interface{}{"gw-a", "gw-b"}},
+						map[string]interface{}{"nodes": []interface{}{"gw-c"}, "weight": "5"},
+					},
+					"part1.dc3": []interface{}{
+						map[string]interface{}{"nodes": []interface{}{"gw-d"}},
+					},
+				},
+			},
+			want: GatewayConfig{
+				ConnectTimeoutMs: 5000,
+				RemoteGateways: map[string][]RemoteGatewayGroup{
+					"dc2": {
+						{Nodes: []string{"gw-a", "gw-b"}},
+						{Nodes: []string{"gw-c"}, Weight: 5},
+					},
+					"part1.dc3": {
+						{Nodes: []string{"gw-d"}},
+					},
+				},
+			},
+		},
 		{
 			name: "deprecated kitchen sink",
 			input: map[string]interface{}{
@@ -259,6 +344,71 @@ func TestParseGatewayConfig(t *testing.T) {
 	}
 }
 
+func TestParseProxyConfig_InvalidRequestNormalization(t *testing.T) {
+	enabled := true
+	got, err := ParseProxyConfig(map[string]interface{}{
+		"merge_slashes":                    true,
+		"path_with_escaped_slashes_action": "REJECT_REQUEST\nfoo",
+		"headers_with_underscores_action":  "DROP_HEADER",
+		"header_key_format":                "preserve",
+	})
+	require.EqualError(t, err, "PathWithEscapedSlashesAction must be one of KEEP_UNCHANGED, REJECT_REQUEST, UNESCAPE_AND_REDIRECT or UNESCAPE_AND_FORWARD")
+
+	// Only the invalid overrides are dropped.
+	require.Equal(t, ProxyConfig{
+		LocalConnectTimeoutMs:        5000,
+		Protocol:                     "tcp",
+		MergeSlashes:                 &enabled,
+		HeadersWithUnderscoresAction: "DROP_HEADER",
+	}, got)
+}
+
+func TestParseProxyConfig_InvalidObservabilityHeaders(t *testing.T) {
+	enabled := true
+	got, err := ParseProxyConfig(map[string]interface{}{
+		"generate_request_id":  true,
+		"trace_context_policy": "forward",
+		"observability_request_headers": map[string]interface{}{
+			"x-service": "${service}",
+			"x-unknown": "${unknown}",
+			"host":      "example.com",
+		},
+	})
+	require.EqualError(t, err, "TraceContextPolicy must be one of passthrough or strip")
+
+	// Only the invalid overrides are dropped.
+	require.Equal(t, ProxyConfig{
+		LocalConnectTimeoutMs:       5000,
+		Protocol:                    "tcp",
+		GenerateRequestID:           &enabled,
+		ObservabilityRequestHeaders: map[string]string{"x-service": "${service}"},
+	}, got)
+}
+
+func TestParseGatewayConfig_InvalidRemoteGatewayWeight(t *testing.T) {
+	got, err := ParseGatewayConfig(map[string]interface{}{
+		"connect_timeout_ms": 10,
+		"envoy_mesh_gateway_remote_gateways": map[string]interface{}{
+			"dc2": []interface{}{
+				map[string]interface{}{"nodes": []interface{}{"gw-a"}, "weight": -1},
+			},
+		},
+	})
+	require.Error(t, err)
+	require.Equal(t, GatewayConfig{ConnectTimeoutMs: 10}, got)
+
+	proxyCfg, err := ParseProxyConfig(map[string]interface{}{
+		"protocol": "http",
+		"envoy_mesh_gateway_remote_gateways": map[string]interface{}{
+			"dc2": []interface{}{
+				map[string]interface{}{"nodes": []interface{}{"gw-a"}, "weight": -1},
+			},
+		},
+	})
+	require.Error(t, err)
+	require.Equal(t, ProxyConfig{Protocol: "http", LocalConnectTimeoutMs: 5000}, proxyCfg)
+}
+
 func intPointer(i int) *int {
 	return &i
 }
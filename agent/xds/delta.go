@@ -90,6 +90,10 @@ func (s *Server) processDelta(stream ADSDeltaStream, reqCh <-chan *envoy_discove
 		proxyID     structs.ServiceID
 		nonce       uint64 // xDS requires a unique nonce to correlate response/request pairs
 		ready       bool   // set to true after the first snapshot arrives
+
+		// debugProxyID and envoyVersion are only recorded in the stream state.
+		debugProxyID string
+		envoyVersion string
 	)
 
 	var (
@@ -102,6 +106,12 @@ func (s *Server) processDelta(stream ADSDeltaStream, reqCh <-chan *envoy_discove
 		//
 		// type => name => version (as consul knows right now)
 		currentVersions = make(map[string]map[string]string)
+
+		// generated are the resources last generated, from generatedSnap.
+		//
+		// type => resources
+		generated     map[string][]proto.Message
+		generatedSnap *proxycfg.ConfigSnapshot
 	)
 
 	generator := newResourceGenerator(
@@ -109,6 +119,10 @@ func (s *Server) processDelta(stream ADSDeltaStream, reqCh <-chan *envoy_discove
 		s.CfgFetcher,
 		true,
 	)
+	// Resources can't be shared if they may be mutated once generated.
+	if s.ResourceMapMutateFn == nil && !s.serverlessPluginEnabled {
+		generator.Shared = s.sharedResources
+	}
 
 	// need to run a small state machine to get through initial authentication.
 	var state = stateDeltaInit
@@ -122,11 +136,12 @@ func (s *Server) processDelta(stream ADSDeltaStream, reqCh <-chan *envoy_discove
 			return cfgSnap.Kind == structs.ServiceKindIngressGateway
 		}),
 		xdscommon.ClusterType: newDeltaType(generator, stream, xdscommon.ClusterType, func(kind structs.ServiceKind) bool {
-			// Mesh, Ingress, and Terminating gateways are allowed to inform CDS of
-			// no clusters.
+			// Mesh, Ingress, Terminating, and Egress gateways are allowed to inform
+			// CDS of no clusters.
 			return cfgSnap.Kind == structs.ServiceKindMeshGateway ||
 				cfgSnap.Kind == structs.ServiceKindTerminatingGateway ||
-				cfgSnap.Kind == structs.ServiceKindIngressGateway
+				cfgSnap.Kind == structs.ServiceKindIngressGateway ||
+				cfgSnap.Kind == structs.ServiceKindEgressGateway
 		}),
 		xdscommon.EndpointType: newDeltaType(generator, stream, xdscommon.EndpointType, nil),
 	}
@@ -152,7 +167,23 @@ func (s *Server) processDelta(stream ADSDeltaStream, reqCh <-chan *envoy_discove
 		return s.authorize(stream.Context(), cfgSnap)
 	}
 
+	streamID := s.streams.open()
+	defer s.streams.close(streamID)
+
+	// proxyStatus is the status of the proxy last reported to the agent.
+	var proxyStatus *structs.ProxyStatusReport
+	defer func() {
+		if proxyStatus != nil {
+			s.reportProxyDisconnected(streamID, proxyID)
+		}
+	}()
+
 	for {
+		s.streams.update(streamID, streamState(debugProxyID, envoyVersion, currentVersions, handlers))
+		if state == stateDeltaRunning {
+			proxyStatus = s.reportProxyStatus(streamID, proxyID, cfgSnap, handlers, proxyStatus)
+		}
+
 		select {
 		case <-authTimer:
 			// It's been too long since a Discovery{Request,Response} so recheck ACLs.
@@ -183,6 +214,19 @@ func (s *Server) processDelta(stream ADSDeltaStream, reqCh <-chan *envoy_discove
 				if err != nil {
 					return status.Errorf(codes.InvalidArgument, err.Error())
 				}
+				if v := determineEnvoyVersionFromNode(req.Node); v != nil {
+					envoyVersion = v.String()
+				}
+			}
+
+			// Envoy requests the clusters of the upstreams of a proxy in on-demand
+			// mode when it first sends traffic to them, which is when they start
+			// being watched.
+			if req.TypeUrl == xdscommon.ClusterType && cfgSnap != nil && cfgSnap.Kind == structs.ServiceKindConnectProxy {
+				if uids := demandedUpstreams(cfgSnap, req.ResourceNamesSubscribe); len(uids) > 0 {
+					generator.Logger.Debug("upstreams demanded by the proxy", "upstreams", uids)
+					s.CfgSrc.DemandUpstreams(cfgSnap.ProxyID, uids...)
+				}
 			}
 
 			if handler, ok := handlers[req.TypeUrl]; ok {
@@ -201,7 +245,19 @@ func (s *Server) processDelta(stream ADSDeltaStream, reqCh <-chan *envoy_discove
 			}
 
 		case cfgSnap = <-stateCh:
-			newRes, err := generator.allResourcesFromSnapshot(cfgSnap)
+			// When only the certificates changed since the previous snapshot, as
+			// happens to every proxy during a CA rotation, the resources which
+			// don't depend on them are reused along with their versions. They
+			// can't be if they may have been mutated once generated.
+			var reuse map[string][]proto.Message
+			if s.ResourceMapMutateFn == nil && !s.serverlessPluginEnabled && cfgSnap.OnlyTLSChangedSince(generatedSnap) {
+				reuse = make(map[string][]proto.Message)
+				for typeUrl := range tlsIndependentTypes {
+					reuse[typeUrl] = generated[typeUrl]
+				}
+			}
+
+			newRes, err := generator.allResourcesFromSnapshotReusing(cfgSnap, reuse)
 			if err != nil {
 				return status.Errorf(codes.Unavailable, "failed to generate all xDS resources from the snapshot: %v", err)
 			}
@@ -224,13 +280,15 @@ func (s *Server) processDelta(stream ADSDeltaStream, reqCh <-chan *envoy_discove
 				return status.Errorf(codes.Unavailable, "failed to index xDS resource versions: %v", err)
 			}
 
-			newVersions, err := computeResourceVersions(newResourceMap)
+			newVersions, err := computeResourceVersions(newResourceMap, currentVersions, reuse, generator.Shared)
 			if err != nil {
 				return status.Errorf(codes.Unavailable, "failed to compute xDS resource versions: %v", err)
 			}
 
 			resourceMap = newResourceMap
 			currentVersions = newVersions
+			generated = newRes
+			generatedSnap = cfgSnap
 			ready = true
 		}
 
@@ -250,6 +308,7 @@ func (s *Server) processDelta(stream ADSDeltaStream, reqCh <-chan *envoy_discove
 
 			// Start authentication process, we need the proxyID
 			proxyID = structs.NewServiceID(node.Id, parseEnterpriseMeta(node))
+			debugProxyID = proxyID.String()
 
 			// Start watching config for that proxy
 			var err error
@@ -288,6 +347,8 @@ func (s *Server) processDelta(stream ADSDeltaStream, reqCh <-chan *envoy_discove
 				generator.Logger = generator.Logger.Named(logging.MeshGateway)
 			case structs.ServiceKindIngressGateway:
 				generator.Logger = generator.Logger.Named(logging.IngressGateway)
+			case structs.ServiceKindEgressGateway:
+				generator.Logger = generator.Logger.Named(logging.EgressGateway)
 			}
 
 			generator.Logger.Trace("Got initial config snapshot")
@@ -415,6 +476,10 @@ type xDSDeltaType struct {
 	// sentToEnvoyOnce is true after we've sent one response to envoy.
 	sentToEnvoyOnce bool
 
+	// rejected is true when envoy rejected the latest response it replied
+	// to.
+	rejected bool
+
 	// subscriptions is the set of currently subscribed envoy resources.
 	// If wildcard == true, this will be empty.
 	subscriptions map[string]struct{}
@@ -627,10 +692,14 @@ func (t *xDSDeltaType) ack(nonce string) {
 		}
 	}
 	t.sentToEnvoyOnce = true
+	t.rejected = false
 	delete(t.pendingUpdates, nonce)
 }
 
 func (t *xDSDeltaType) nack(nonce string) {
+	if _, ok := t.pendingUpdates[nonce]; ok {
+		t.rejected = true
+	}
 	delete(t.pendingUpdates, nonce)
 }
 
@@ -806,10 +875,22 @@ func (t *xDSDeltaType) createDeltaResponse(
 	return resp, realUpdates, nil
 }
 
-func computeResourceVersions(resourceMap *xdscommon.IndexedResources) (map[string]map[string]string, error) {
+// computeResourceVersions hashes the resources of every type, except for the
+// types which were reused whose versions are taken from prevVersions. The
+// versions of shared resources are only computed once.
+func computeResourceVersions(
+	resourceMap *xdscommon.IndexedResources,
+	prevVersions map[string]map[string]string,
+	reused map[string][]proto.Message,
+	shared *sharedResources,
+) (map[string]map[string]string, error) {
 	out := make(map[string]map[string]string)
 	for typeUrl, resources := range resourceMap.Index {
-		m, err := hashResourceMap(resources)
+		if _, ok := reused[typeUrl]; ok {
+			out[typeUrl] = prevVersions[typeUrl]
+			continue
+		}
+		m, err := hashResourceMap(resources, shared)
 		if err != nil {
 			return nil, fmt.Errorf("failed to hash resources for %q: %v", typeUrl, err)
 		}
@@ -870,10 +951,10 @@ func getResourceName(res proto.Message) string {
 	}
 }
 
-func hashResourceMap(resources map[string]proto.Message) (map[string]string, error) {
+func hashResourceMap(resources map[string]proto.Message, shared *sharedResources) (map[string]string, error) {
 	m := make(map[string]string)
 	for name, res := range resources {
-		h, err := hashResource(res)
+		h, err := shared.hashResource(res)
 		if err != nil {
 			return nil, fmt.Errorf("failed to hash resource %q: %v", name, err)
 		}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -21,6 +22,7 @@ import (
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/agent/xds/xdscommon"
 	"github.com/hashicorp/consul/sdk/testutil"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
 )
 
 // NOTE: For these tests, prefer not using xDS protobuf "factory" methods if
@@ -1368,6 +1370,207 @@ func TestServer_DeltaAggregatedResources_v3_IngressEmptyResponse(t *testing.T) {
 	}
 }
 
+func TestServer_DeltaAggregatedResources_v3_OnDemandUpstreams(t *testing.T) {
+	aclResolve := func(id string) (acl.Authorizer, error) {
+		// Allow all
+		return acl.RootAuthorizer("manage"), nil
+	}
+	scenario := newTestServerDeltaScenario(t, aclResolve, "web-sidecar-proxy", "", 0, false)
+	mgr, errCh, envoy := scenario.mgr, scenario.errCh, scenario.envoy
+
+	sid := structs.NewServiceID("web-sidecar-proxy", nil)
+
+	// Register the proxy to create state needed to Watch() on
+	mgr.RegisterProxy(t, sid)
+
+	// Send initial cluster discover
+	envoy.SendDeltaReq(t, xdscommon.ClusterType, nil)
+
+	// Deliver a snapshot where only google was demanded
+	snap := proxycfg.TestConfigSnapshotTransparentProxyOnDemand(t)
+	mgr.DeliverConfig(t, sid, snap)
+
+	// RESP: clusters
+	select {
+	case <-envoy.deltaStream.sendCh:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatalf("timed out waiting for the clusters")
+	}
+	envoy.SendDeltaReqACK(t, xdscommon.ClusterType, 1)
+
+	// Envoy requests the clusters of kafka and google with on-demand cluster
+	// discovery, only kafka wasn't demanded yet.
+	envoy.SendDeltaReq(t, xdscommon.ClusterType, &envoy_discovery_v3.DeltaDiscoveryRequest{
+		ResourceNamesSubscribe: []string{
+			"kafka.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul",
+			"google.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul",
+		},
+	})
+
+	select {
+	case uids := <-mgr.demands:
+		require.Equal(t, []proxycfg.UpstreamID{
+			proxycfg.NewUpstreamIDFromServiceName(structs.NewServiceName("kafka", nil)),
+		}, uids)
+	case <-time.After(50 * time.Millisecond):
+		t.Fatalf("timed out waiting for the upstreams to be demanded")
+	}
+
+	envoy.Close()
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(50 * time.Millisecond):
+		t.Fatalf("timed out waiting for handler to finish")
+	}
+}
+
+func TestServer_DeltaAggregatedResources_v3_StreamStates(t *testing.T) {
+	aclResolve := func(id string) (acl.Authorizer, error) {
+		// Allow all
+		return acl.RootAuthorizer("manage"), nil
+	}
+	scenario := newTestServerDeltaScenario(t, aclResolve, "web-sidecar-proxy", "", 0, false)
+	mgr, errCh, envoy := scenario.mgr, scenario.errCh, scenario.envoy
+
+	sid := structs.NewServiceID("web-sidecar-proxy", nil)
+
+	// Register the proxy to create state needed to Watch() on
+	mgr.RegisterProxy(t, sid)
+
+	// Send initial cluster discover
+	envoy.SendDeltaReq(t, xdscommon.ClusterType, nil)
+
+	snap := newTestSnapshot(t, nil, "")
+	mgr.DeliverConfig(t, sid, snap)
+
+	assertDeltaResponseSent(t, envoy.deltaStream.sendCh, &envoy_discovery_v3.DeltaDiscoveryResponse{
+		TypeUrl: xdscommon.ClusterType,
+		Nonce:   hexString(1),
+		Resources: makeTestResources(t,
+			makeTestCluster(t, snap, "tcp:local_app"),
+			makeTestCluster(t, snap, "tcp:db"),
+			makeTestCluster(t, snap, "tcp:geo-cache"),
+		),
+	})
+
+	// The response is pending until Envoy ACKs it.
+	retry.Run(t, func(r *retry.R) {
+		states := scenario.server.StreamStates()
+		require.Len(r, states, 1)
+		require.Equal(r, sid.String(), states[0].ProxyID)
+		require.Contains(r, states[0].Versions[xdscommon.ClusterType], "local_app")
+		require.Equal(r, 1, states[0].PendingUpdates[xdscommon.ClusterType])
+		require.Empty(r, states[0].AckedVersions[xdscommon.ClusterType])
+	})
+
+	envoy.SendDeltaReqACK(t, xdscommon.ClusterType, 1)
+
+	retry.Run(t, func(r *retry.R) {
+		states := scenario.server.StreamStates()
+		require.Len(r, states, 1)
+		require.Equal(r, 0, states[0].PendingUpdates[xdscommon.ClusterType])
+		require.Equal(r, states[0].Versions[xdscommon.ClusterType], states[0].AckedVersions[xdscommon.ClusterType])
+	})
+
+	envoy.Close()
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(50 * time.Millisecond):
+		t.Fatalf("timed out waiting for handler to finish")
+	}
+	require.Empty(t, scenario.server.StreamStates())
+}
+
+func TestServer_DeltaAggregatedResources_v3_ProxyStatus(t *testing.T) {
+	aclResolve := func(id string) (acl.Authorizer, error) {
+		// Allow all
+		return acl.RootAuthorizer("manage"), nil
+	}
+	scenario := newTestServerDeltaScenario(t, aclResolve, "web-sidecar-proxy", "", 0, false)
+	mgr, errCh, envoy := scenario.mgr, scenario.errCh, scenario.envoy
+	reporter := &testProxyStatusReporter{}
+	scenario.server.StatusReporter = reporter
+
+	sid := structs.NewServiceID("web-sidecar-proxy", nil)
+	mgr.RegisterProxy(t, sid)
+
+	envoy.SendDeltaReq(t, xdscommon.ClusterType, nil)
+
+	snap := newTestSnapshot(t, nil, "")
+	mgr.DeliverConfig(t, sid, snap)
+
+	assertDeltaResponseSent(t, envoy.deltaStream.sendCh, &envoy_discovery_v3.DeltaDiscoveryResponse{
+		TypeUrl: xdscommon.ClusterType,
+		Nonce:   hexString(1),
+		Resources: makeTestResources(t,
+			makeTestCluster(t, snap, "tcp:local_app"),
+			makeTestCluster(t, snap, "tcp:db"),
+			makeTestCluster(t, snap, "tcp:geo-cache"),
+		),
+	})
+
+	// The configuration isn't accepted until Envoy ACKs it.
+	retry.Run(t, func(r *retry.R) {
+		status := reporter.get(sid)
+		require.NotNil(r, status)
+		require.True(r, status.XDSConnected)
+		require.False(r, status.ConfigAccepted)
+		require.True(r, status.CertValidBefore.Equal(snap.Leaf().ValidBefore))
+	})
+
+	envoy.SendDeltaReqACK(t, xdscommon.ClusterType, 1)
+	retry.Run(t, func(r *retry.R) {
+		require.True(r, reporter.get(sid).ConfigAccepted)
+	})
+
+	// Rejecting an update withdraws the acceptance until the next ACK.
+	snap = newTestSnapshot(t, snap, "")
+	snap.Proxy.LocalServicePort = 9090
+	mgr.DeliverConfig(t, sid, snap)
+	select {
+	case <-envoy.deltaStream.sendCh:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatalf("timed out waiting for the cluster update")
+	}
+	envoy.SendDeltaReqNACK(t, xdscommon.ClusterType, 2, &rpcstatus.Status{})
+	retry.Run(t, func(r *retry.R) {
+		status := reporter.get(sid)
+		require.True(r, status.XDSConnected)
+		require.False(r, status.ConfigAccepted)
+	})
+
+	envoy.Close()
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(50 * time.Millisecond):
+		t.Fatalf("timed out waiting for handler to finish")
+	}
+	require.Equal(t, &structs.ProxyStatusReport{}, reporter.get(sid))
+}
+
+type testProxyStatusReporter struct {
+	mu       sync.Mutex
+	statuses map[structs.ServiceID]*structs.ProxyStatusReport
+}
+
+func (r *testProxyStatusReporter) SetProxyStatus(id structs.ServiceID, status *structs.ProxyStatusReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.statuses == nil {
+		r.statuses = make(map[structs.ServiceID]*structs.ProxyStatusReport)
+	}
+	r.statuses[id] = status
+}
+
+func (r *testProxyStatusReporter) get(id structs.ServiceID) *structs.ProxyStatusReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.statuses[id]
+}
+
 func assertDeltaChanBlocked(t *testing.T, ch chan *envoy_discovery_v3.DeltaDiscoveryResponse) {
 	t.Helper()
 	select {
@@ -0,0 +1,128 @@
+package xds
+
+import (
+	"sort"
+	"time"
+
+	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_dfp_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/clusters/dynamic_forward_proxy/v3"
+	envoy_dfp_common_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/dynamic_forward_proxy/v3"
+	envoy_http_dfp_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/dynamic_forward_proxy/v3"
+	envoy_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_sni_dfp_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/sni_dynamic_forward_proxy/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+const (
+	// egressFilterName is the name of the filter chains of the outbound
+	// listener sending the traffic to the allowed external hostnames through
+	// the dynamic forward proxy.
+	egressFilterName = "egress"
+
+	// dynamicForwardProxyDNSCacheName is the name of the DNS cache shared by
+	// the dynamic forward proxy cluster and filters. Envoy requires them to
+	// use the same cache configuration.
+	dynamicForwardProxyDNSCacheName = "dynamic_forward_proxy_cache"
+
+	// egressTLSPort is the port of the external hostnames reached with TLS,
+	// whose port can't be read from the SNI.
+	egressTLSPort = 443
+)
+
+// egressHostnames returns the external hostnames a transparent proxy reaches
+// through the dynamic forward proxy, sorted and without duplicates.
+func (s *ResourceGenerator) egressHostnames(cfgSnap *proxycfg.ConfigSnapshot) []string {
+	if cfgSnap.Kind != structs.ServiceKindConnectProxy || cfgSnap.Proxy.Mode != structs.ProxyModeTransparent {
+		return nil
+	}
+
+	cfg, err := ParseProxyConfig(cfgSnap.Proxy.Config)
+	if err != nil {
+		// Don't hard fail on a config typo, just warn. The parse func returns
+		// default config if there is an error so it's safe to continue.
+		s.Logger.Warn("failed to parse Connect.Proxy.Config", "error", err)
+	}
+
+	seen := make(map[string]struct{})
+	var hostnames []string
+	for _, hostname := range cfg.EgressHostnames(cfgSnap.MeshConfig()) {
+		if _, ok := seen[hostname]; ok {
+			continue
+		}
+		seen[hostname] = struct{}{}
+		hostnames = append(hostnames, hostname)
+	}
+	sort.Strings(hostnames)
+	return hostnames
+}
+
+func makeDynamicForwardProxyDNSCacheConfig() *envoy_dfp_common_v3.DnsCacheConfig {
+	return &envoy_dfp_common_v3.DnsCacheConfig{
+		Name:            dynamicForwardProxyDNSCacheName,
+		DnsLookupFamily: envoy_cluster_v3.Cluster_V4_ONLY,
+	}
+}
+
+// makeDynamicForwardProxyCluster returns the cluster connecting to the
+// external hosts resolved by the dynamic forward proxy filters.
+func makeDynamicForwardProxyCluster() (*envoy_cluster_v3.Cluster, error) {
+	cfg, err := anypb.New(&envoy_dfp_cluster_v3.ClusterConfig{
+		DnsCacheConfig: makeDynamicForwardProxyDNSCacheConfig(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &envoy_cluster_v3.Cluster{
+		Name: DynamicForwardProxyClusterName,
+		ClusterDiscoveryType: &envoy_cluster_v3.Cluster_ClusterType{
+			ClusterType: &envoy_cluster_v3.Cluster_CustomClusterType{
+				Name:        "envoy.clusters.dynamic_forward_proxy",
+				TypedConfig: cfg,
+			},
+		},
+		LbPolicy:       envoy_cluster_v3.Cluster_CLUSTER_PROVIDED,
+		ConnectTimeout: durationpb.New(5 * time.Second),
+	}, nil
+}
+
+// makeDynamicForwardProxyHTTPFilter returns the HTTP filter resolving the
+// host of the requests routed to the dynamic forward proxy cluster.
+func makeDynamicForwardProxyHTTPFilter() (*envoy_http_v3.HttpFilter, error) {
+	return makeEnvoyHTTPFilter("envoy.filters.http.dynamic_forward_proxy", &envoy_http_dfp_v3.FilterConfig{
+		DnsCacheConfig: makeDynamicForwardProxyDNSCacheConfig(),
+	})
+}
+
+// makeEgressTLSFilterChain returns the filter chain of the outbound listener
+// forwarding the TLS connections to the given hostnames, as read from their
+// SNI, through the dynamic forward proxy. The connections are not terminated.
+func (s *ResourceGenerator) makeEgressTLSFilterChain(hostnames []string) (*envoy_listener_v3.FilterChain, error) {
+	sniProxy, err := makeFilter("envoy.filters.network.sni_dynamic_forward_proxy", &envoy_sni_dfp_v3.FilterConfig{
+		DnsCacheConfig: makeDynamicForwardProxyDNSCacheConfig(),
+		PortSpecifier: &envoy_sni_dfp_v3.FilterConfig_PortValue{
+			PortValue: egressTLSPort,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	tcpProxy, err := makeTCPProxyFilter(egressFilterName, DynamicForwardProxyClusterName, "upstream.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &envoy_listener_v3.FilterChain{
+		Name: egressFilterName + "~tls",
+		FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+			ServerNames:       hostnames,
+			TransportProtocol: "tls",
+		},
+		Filters: []*envoy_listener_v3.Filter{sniProxy, tcpProxy},
+	}, nil
+}
@@ -0,0 +1,249 @@
+package xds
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_http_ext_authz_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_authz/v3"
+	envoy_http_router_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
+	envoy_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+const (
+	// egressGatewayFilterName is the name of the HTTP connection manager of
+	// an egress gateway listener.
+	egressGatewayFilterName = "egress_gateway"
+
+	// egressGatewayReputationCheckClusterName is the name of the cluster of
+	// the service asked to allow each request leaving the mesh.
+	egressGatewayReputationCheckClusterName = "egress_reputation_check"
+)
+
+// egressGatewayDestinationClusterName returns the name of the cluster
+// connecting an egress gateway to an external destination.
+func egressGatewayDestinationClusterName(dest structs.EgressGatewayDestination) string {
+	return fmt.Sprintf("egress~%s~%d", dest.Hostname, dest.Port)
+}
+
+// makeEgressGatewayListener returns the listener of an egress gateway. Services
+// connect to it with mTLS like to any other upstream, and their requests are
+// routed by host to the destinations of the gateway config entry once allowed
+// by the gateway's intentions and the optional reputation check.
+func (s *ResourceGenerator) makeEgressGatewayListener(
+	cfgSnap *proxycfg.ConfigSnapshot,
+	name, addr string,
+	port int,
+) (*envoy_listener_v3.Listener, error) {
+	l := makePortListener(name, addr, port, envoy_core_v3.TrafficDirection_OUTBOUND)
+
+	tlsContext := &envoy_tls_v3.DownstreamTlsContext{
+		CommonTlsContext: makeCommonTLSContext(
+			cfgSnap.EgressGateway.Leaf,
+			cfgSnap.RootPEMs(),
+			makeTLSParametersFromProxyTLSConfig(cfgSnap.MeshConfigTLSIncoming()),
+		),
+		RequireClientCertificate: &wrappers.BoolValue{Value: true},
+	}
+	transportSocket, err := makeDownstreamTLSTransportSocket(tlsContext)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := s.makeEgressGatewayHTTPFilter(cfgSnap)
+	if err != nil {
+		return nil, err
+	}
+
+	l.FilterChains = []*envoy_listener_v3.FilterChain{
+		{
+			Filters:         []*envoy_listener_v3.Filter{filter},
+			TransportSocket: transportSocket,
+		},
+	}
+	return l, nil
+}
+
+func (s *ResourceGenerator) makeEgressGatewayHTTPFilter(cfgSnap *proxycfg.ConfigSnapshot) (*envoy_listener_v3.Filter, error) {
+	// Intentions are enforced first so that the reputation check only sees
+	// the requests of authorized services.
+	rbac, err := makeRBACHTTPFilter(cfgSnap.EgressGateway.Intentions, cfgSnap.IntentionDefaultAllow, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpFilters := []*envoy_http_v3.HttpFilter{rbac}
+
+	entry := cfgSnap.EgressGateway.GatewayConfig
+	if entry != nil && entry.ReputationCheck != nil {
+		authz, err := makeEgressGatewayReputationCheckHTTPFilter(entry.ReputationCheck)
+		if err != nil {
+			return nil, err
+		}
+		httpFilters = append(httpFilters, authz)
+	}
+
+	router, err := makeEnvoyHTTPFilter("envoy.filters.http.router", &envoy_http_router_v3.Router{})
+	if err != nil {
+		return nil, err
+	}
+	httpFilters = append(httpFilters, router)
+
+	cfg := &envoy_http_v3.HttpConnectionManager{
+		StatPrefix:  makeStatPrefix("egress.", egressGatewayFilterName),
+		CodecType:   envoy_http_v3.HttpConnectionManager_AUTO,
+		HttpFilters: httpFilters,
+		Tracing: &envoy_http_v3.HttpConnectionManager_Tracing{
+			// Don't trace any requests by default unless the client application
+			// explicitly propagates trace headers that indicate this should be
+			// sampled.
+			RandomSampling: &envoy_type_v3.Percent{Value: 0.0},
+		},
+		// Destinations are matched on their hostname whichever port the
+		// service sent in the host header.
+		StripPortMode: &envoy_http_v3.HttpConnectionManager_StripAnyHostPort{
+			StripAnyHostPort: true,
+		},
+		RouteSpecifier: &envoy_http_v3.HttpConnectionManager_RouteConfig{
+			RouteConfig: makeEgressGatewayRouteConfig(entry),
+		},
+	}
+	return makeFilter("envoy.filters.network.http_connection_manager", cfg)
+}
+
+// makeEgressGatewayRouteConfig returns the routes of an egress gateway, with a
+// virtual host per destination. Requests to any other host don't match a
+// virtual host and are rejected by Envoy.
+func makeEgressGatewayRouteConfig(entry *structs.EgressGatewayConfigEntry) *envoy_route_v3.RouteConfiguration {
+	rc := &envoy_route_v3.RouteConfiguration{
+		Name: egressGatewayFilterName,
+	}
+	if entry == nil {
+		return rc
+	}
+
+	for _, dest := range entry.Destinations {
+		rc.VirtualHosts = append(rc.VirtualHosts, &envoy_route_v3.VirtualHost{
+			Name:    dest.Hostname,
+			Domains: []string{dest.Hostname},
+			Routes: []*envoy_route_v3.Route{
+				{
+					Match: &envoy_route_v3.RouteMatch{
+						PathSpecifier: &envoy_route_v3.RouteMatch_Prefix{
+							Prefix: "/",
+						},
+					},
+					Action: &envoy_route_v3.Route_Route{
+						Route: &envoy_route_v3.RouteAction{
+							ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{
+								Cluster: egressGatewayDestinationClusterName(dest),
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+	return rc
+}
+
+// makeEgressGatewayReputationCheckHTTPFilter returns the external
+// authorization filter asking the reputation service to allow each request.
+func makeEgressGatewayReputationCheckHTTPFilter(check *structs.EgressGatewayReputationCheck) (*envoy_http_v3.HttpFilter, error) {
+	return makeEnvoyHTTPFilter("envoy.filters.http.ext_authz", &envoy_http_ext_authz_v3.ExtAuthz{
+		Services: &envoy_http_ext_authz_v3.ExtAuthz_GrpcService{
+			GrpcService: &envoy_core_v3.GrpcService{
+				TargetSpecifier: &envoy_core_v3.GrpcService_EnvoyGrpc_{
+					EnvoyGrpc: &envoy_core_v3.GrpcService_EnvoyGrpc{
+						ClusterName: egressGatewayReputationCheckClusterName,
+					},
+				},
+				Timeout: durationpb.New(check.Timeout),
+			},
+		},
+		TransportApiVersion: envoy_core_v3.ApiVersion_V3,
+		FailureModeAllow:    check.FailOpen,
+	})
+}
+
+// clustersFromSnapshotEgressGateway returns the clusters of an egress gateway:
+// one per destination, and one for the reputation service if configured.
+func (s *ResourceGenerator) clustersFromSnapshotEgressGateway(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
+	entry := cfgSnap.EgressGateway.GatewayConfig
+	if entry == nil {
+		return nil, nil
+	}
+
+	clusters := make([]proto.Message, 0, len(entry.Destinations)+1)
+	for _, dest := range entry.Destinations {
+		c := makeEgressGatewayHostCluster(egressGatewayDestinationClusterName(dest), dest.Hostname, dest.Port)
+
+		if dest.TLS != nil {
+			sni := dest.TLS.SNI
+			if sni == "" {
+				sni = dest.Hostname
+			}
+			transportSocket, err := makeUpstreamTLSTransportSocket(&envoy_tls_v3.UpstreamTlsContext{
+				CommonTlsContext: makeCommonTLSContextFromFiles(dest.TLS.CAFile, dest.TLS.CertFile, dest.TLS.KeyFile),
+				Sni:              sni,
+			})
+			if err != nil {
+				return nil, err
+			}
+			c.TransportSocket = transportSocket
+		}
+		clusters = append(clusters, c)
+	}
+
+	if check := entry.ReputationCheck; check != nil {
+		c := makeEgressGatewayHostCluster(egressGatewayReputationCheckClusterName, check.Address, check.Port)
+		if err := s.setHttp2ProtocolOptions(c); err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, c)
+	}
+
+	return clusters, nil
+}
+
+// makeEgressGatewayHostCluster returns a cluster connecting to the given host,
+// which Envoy resolves unless it's an IP address.
+func makeEgressGatewayHostCluster(name, host string, port int) *envoy_cluster_v3.Cluster {
+	c := &envoy_cluster_v3.Cluster{
+		Name:           name,
+		ConnectTimeout: durationpb.New(5 * time.Second),
+		ClusterDiscoveryType: &envoy_cluster_v3.Cluster_Type{
+			Type: envoy_cluster_v3.Cluster_STATIC,
+		},
+		LoadAssignment: &envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: name,
+			Endpoints: []*envoy_endpoint_v3.LocalityLbEndpoints{
+				{
+					LbEndpoints: []*envoy_endpoint_v3.LbEndpoint{
+						makeEndpoint(host, port),
+					},
+				},
+			},
+		},
+	}
+	if net.ParseIP(host) == nil {
+		c.ClusterDiscoveryType = &envoy_cluster_v3.Cluster_Type{
+			Type: envoy_cluster_v3.Cluster_LOGICAL_DNS,
+		}
+		c.DnsLookupFamily = envoy_cluster_v3.Cluster_V4_ONLY
+	}
+	return c
+}
@@ -11,7 +11,6 @@ import (
 	"github.com/golang/protobuf/proto"
 	bexpr "github.com/hashicorp/go-bexpr"
 
-	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/proxycfg"
 	"github.com/hashicorp/consul/agent/structs"
@@ -37,6 +36,8 @@ func (s *ResourceGenerator) endpointsFromSnapshot(cfgSnap *proxycfg.ConfigSnapsh
 		return s.endpointsFromSnapshotMeshGateway(cfgSnap)
 	case structs.ServiceKindIngressGateway:
 		return s.endpointsFromSnapshotIngressGateway(cfgSnap)
+	case structs.ServiceKindEgressGateway:
+		return nil, nil // egress gateway clusters don't use EDS
 	default:
 		return nil, fmt.Errorf("Invalid service kind: %v", cfgSnap.Kind)
 	}
@@ -51,6 +52,13 @@ func (s *ResourceGenerator) endpointsFromSnapshotConnectProxy(cfgSnap *proxycfg.
 			len(cfgSnap.ConnectProxy.PeerUpstreamEndpoints)+
 			len(cfgSnap.ConnectProxy.WatchedUpstreamEndpoints))
 
+	cfg, err := ParseProxyConfig(cfgSnap.Proxy.Config)
+	if err != nil {
+		// Don't hard fail on a config typo, just warn. The parse func returns
+		// default config if there is an error so it's safe to continue.
+		s.Logger.Warn("failed to parse Connect.Proxy.Config", "error", err)
+	}
+
 	// NOTE: Any time we skip a chain below we MUST also skip that discovery chain in clusters.go
 	// so that the sets of endpoints generated matches the sets of clusters.
 	for uid, chain := range cfgSnap.ConnectProxy.DiscoveryChain {
@@ -69,6 +77,7 @@ func (s *ResourceGenerator) endpointsFromSnapshotConnectProxy(cfgSnap *proxycfg.
 			upstreamCfg,
 			cfgSnap.ConnectProxy.WatchedUpstreamEndpoints[uid],
 			cfgSnap.ConnectProxy.WatchedGatewayEndpoints[uid],
+			cfg.RemoteGateways,
 		)
 		resources = append(resources, es...)
 	}
@@ -104,10 +113,10 @@ func (s *ResourceGenerator) endpointsFromSnapshotConnectProxy(cfgSnap *proxycfg.
 
 		endpoints, ok := cfgSnap.ConnectProxy.PeerUpstreamEndpoints[uid]
 		if ok {
-			la := makeLoadAssignment(
+			la := s.Shared.loadAssignment(
 				clusterName,
 				[]loadAssignmentEndpointGroup{
-					{Endpoints: endpoints},
+					{Endpoints: endpoints, IgnoreWeights: peerMeta.IgnoreWeights},
 				},
 				proxycfg.GatewayKey{ /*empty so it never matches*/ },
 			)
@@ -130,7 +139,7 @@ func (s *ResourceGenerator) endpointsFromSnapshotConnectProxy(cfgSnap *proxycfg.
 
 		endpoints, ok := cfgSnap.ConnectProxy.PreparedQueryEndpoints[uid]
 		if ok {
-			la := makeLoadAssignment(
+			la := s.Shared.loadAssignment(
 				clusterName,
 				[]loadAssignmentEndpointGroup{
 					{Endpoints: endpoints},
@@ -166,6 +175,13 @@ func (s *ResourceGenerator) endpointsFromSnapshotTerminatingGateway(cfgSnap *pro
 }
 
 func (s *ResourceGenerator) endpointsFromSnapshotMeshGateway(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
+	cfg, err := ParseGatewayConfig(cfgSnap.Proxy.Config)
+	if err != nil {
+		// Don't hard fail on a config typo, just warn. The parse func returns
+		// default config if there is an error so it's safe to continue.
+		s.Logger.Warn("failed to parse gateway config", "error", err)
+	}
+
 	keys := cfgSnap.MeshGateway.GatewayKeys()
 	resources := make([]proto.Message, 0, len(keys)+len(cfgSnap.MeshGateway.ServiceGroups))
 
@@ -231,14 +247,14 @@ func (s *ResourceGenerator) endpointsFromSnapshotMeshGateway(cfgSnap *proxycfg.C
 			continue
 		}
 
+		endpointGroups := remoteGatewayEndpointGroups(cfg.RemoteGateways[key.String()], endpoints)
+
 		{ // standard connect
 			clusterName := connect.GatewaySNI(key.Datacenter, key.Partition, cfgSnap.Roots.TrustDomain)
 
-			la := makeLoadAssignment(
+			la := s.Shared.loadAssignment(
 				clusterName,
-				[]loadAssignmentEndpointGroup{
-					{Endpoints: endpoints},
-				},
+				endpointGroups,
 				cfgSnap.Locality,
 			)
 			resources = append(resources, la)
@@ -249,11 +265,9 @@ func (s *ResourceGenerator) endpointsFromSnapshotMeshGateway(cfgSnap *proxycfg.C
 			cfgSnap.ServerSNIFn != nil {
 
 			clusterName := cfgSnap.ServerSNIFn(key.Datacenter, "")
-			la := makeLoadAssignment(
+			la := s.Shared.loadAssignment(
 				clusterName,
-				[]loadAssignmentEndpointGroup{
-					{Endpoints: endpoints},
-				},
+				endpointGroups,
 				cfgSnap.Locality,
 			)
 			resources = append(resources, la)
@@ -301,6 +315,28 @@ func (s *ResourceGenerator) endpointsFromSnapshotMeshGateway(cfgSnap *proxycfg.C
 		})
 	}
 
+	// generate the endpoints of any server for the peering streams
+	if meshGatewayRoutesPeeringToServers(cfgSnap) {
+		var lbEndpoints []*envoy_endpoint_v3.LbEndpoint
+		for _, srv := range cfgSnap.MeshGateway.ConsulServers {
+			_, addr, port := srv.BestAddress(false /*wan*/)
+			lbEndpoints = append(lbEndpoints, &envoy_endpoint_v3.LbEndpoint{
+				HostIdentifier: &envoy_endpoint_v3.LbEndpoint_Endpoint{
+					Endpoint: &envoy_endpoint_v3.Endpoint{
+						Address: makeAddress(addr, port),
+					},
+				},
+				HealthStatus: envoy_core_v3.HealthStatus_UNKNOWN,
+			})
+		}
+		resources = append(resources, &envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: cfgSnap.ServerSNIFn(cfgSnap.Datacenter, ""),
+			Endpoints: []*envoy_endpoint_v3.LocalityLbEndpoints{{
+				LbEndpoints: lbEndpoints,
+			}},
+		})
+	}
+
 	// Generate the endpoints for each service and its subsets
 	e, err := s.endpointsFromServicesAndResolvers(cfgSnap, cfgSnap.MeshGateway.ServiceGroups, cfgSnap.MeshGateway.ServiceResolvers)
 	if err != nil {
@@ -352,7 +388,7 @@ func (s *ResourceGenerator) endpointsFromServicesAndResolvers(
 		// now generate the load assignment for all subsets
 		for subsetName, groups := range clusterEndpoints {
 			clusterName := connect.ServiceSNI(svc.Name, subsetName, svc.NamespaceOrDefault(), svc.PartitionOrDefault(), cfgSnap.Datacenter, cfgSnap.Roots.TrustDomain)
-			la := makeLoadAssignment(
+			la := s.Shared.loadAssignment(
 				clusterName,
 				groups,
 				cfgSnap.Locality,
@@ -365,6 +401,13 @@ func (s *ResourceGenerator) endpointsFromServicesAndResolvers(
 }
 
 func (s *ResourceGenerator) endpointsFromSnapshotIngressGateway(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
+	cfg, err := ParseGatewayConfig(cfgSnap.Proxy.Config)
+	if err != nil {
+		// Don't hard fail on a config typo, just warn. The parse func returns
+		// default config if there is an error so it's safe to continue.
+		s.Logger.Warn("failed to parse gateway config", "error", err)
+	}
+
 	var resources []proto.Message
 	createdClusters := make(map[proxycfg.UpstreamID]bool)
 	for _, upstreams := range cfgSnap.IngressGateway.Upstreams {
@@ -384,6 +427,7 @@ func (s *ResourceGenerator) endpointsFromSnapshotIngressGateway(cfgSnap *proxycf
 				&u,
 				cfgSnap.IngressGateway.WatchedUpstreamEndpoints[uid],
 				cfgSnap.IngressGateway.WatchedGatewayEndpoints[uid],
+				cfg.RemoteGateways,
 			)
 			resources = append(resources, es...)
 			createdClusters[uid] = true
@@ -420,6 +464,7 @@ func (s *ResourceGenerator) endpointsFromDiscoveryChain(
 	upstream *structs.Upstream,
 	upstreamEndpoints map[string]structs.CheckServiceNodes,
 	gatewayEndpoints map[string]structs.CheckServiceNodes,
+	remoteGateways map[string][]RemoteGatewayGroup,
 ) []proto.Message {
 	var resources []proto.Message
 
@@ -488,10 +533,11 @@ func (s *ResourceGenerator) endpointsFromDiscoveryChain(
 			failover = nil
 		}
 
-		primaryGroup, valid := makeLoadAssignmentEndpointGroup(
+		primaryGroups, valid := makeLoadAssignmentEndpointGroups(
 			chain.Targets,
 			upstreamEndpoints,
 			gatewayEndpoints,
+			remoteGateways,
 			targetID,
 			gatewayKey,
 		)
@@ -502,28 +548,29 @@ func (s *ResourceGenerator) endpointsFromDiscoveryChain(
 		var endpointGroups []loadAssignmentEndpointGroup
 
 		if failover != nil && len(failover.Targets) > 0 {
-			endpointGroups = make([]loadAssignmentEndpointGroup, 0, len(failover.Targets)+1)
+			endpointGroups = make([]loadAssignmentEndpointGroup, 0, len(failover.Targets)+len(primaryGroups))
 
-			endpointGroups = append(endpointGroups, primaryGroup)
+			endpointGroups = append(endpointGroups, primaryGroups...)
 
 			for _, failTargetID := range failover.Targets {
-				failoverGroup, valid := makeLoadAssignmentEndpointGroup(
+				failoverGroups, valid := makeLoadAssignmentEndpointGroups(
 					chain.Targets,
 					upstreamEndpoints,
 					gatewayEndpoints,
+					remoteGateways,
 					failTargetID,
 					gatewayKey,
 				)
 				if !valid {
 					continue // skip the failover target if we're still populating the snapshot
 				}
-				endpointGroups = append(endpointGroups, failoverGroup)
+				endpointGroups = append(endpointGroups, failoverGroups...)
 			}
 		} else {
-			endpointGroups = append(endpointGroups, primaryGroup)
+			endpointGroups = append(endpointGroups, primaryGroups...)
 		}
 
-		la := makeLoadAssignment(
+		la := s.Shared.loadAssignment(
 			clusterName,
 			endpointGroups,
 			gatewayKey,
@@ -538,6 +585,52 @@ type loadAssignmentEndpointGroup struct {
 	Endpoints      structs.CheckServiceNodes
 	OnlyPassing    bool
 	OverrideHealth envoy_core_v3.HealthStatus
+	IgnoreWeights  bool
+	// OverrideWeight, if > 0, is used as the weight of all the endpoints.
+	OverrideWeight int
+}
+
+// remoteGatewayEndpointGroups splits the gateways of a remote datacenter or
+// partition into the groups configured for it, each group becoming a priority
+// level. Gateways that aren't part of a group are used last.
+func remoteGatewayEndpointGroups(groups []RemoteGatewayGroup, endpoints structs.CheckServiceNodes) []loadAssignmentEndpointGroup {
+	if len(groups) == 0 {
+		return []loadAssignmentEndpointGroup{{Endpoints: endpoints}}
+	}
+
+	// A gateway belongs to the first group listing its node.
+	groupOf := make(map[string]int)
+	for i := len(groups) - 1; i >= 0; i-- {
+		for _, node := range groups[i].Nodes {
+			groupOf[node] = i
+		}
+	}
+
+	grouped := make([]structs.CheckServiceNodes, len(groups))
+	var rest structs.CheckServiceNodes
+	for _, ep := range endpoints {
+		i, ok := groupOf[ep.Node.Node]
+		if !ok {
+			rest = append(rest, ep)
+			continue
+		}
+		grouped[i] = append(grouped[i], ep)
+	}
+
+	result := make([]loadAssignmentEndpointGroup, 0, len(groups)+1)
+	for i, eps := range grouped {
+		if len(eps) == 0 {
+			continue
+		}
+		result = append(result, loadAssignmentEndpointGroup{
+			Endpoints:      eps,
+			OverrideWeight: groups[i].Weight,
+		})
+	}
+	if len(rest) > 0 {
+		result = append(result, loadAssignmentEndpointGroup{Endpoints: rest})
+	}
+	return result
 }
 
 func makeLoadAssignment(clusterName string, endpointGroups []loadAssignmentEndpointGroup, localKey proxycfg.GatewayKey) *envoy_endpoint_v3.ClusterLoadAssignment {
@@ -561,16 +654,29 @@ func makeLoadAssignment(clusterName string, endpointGroups []loadAssignmentEndpo
 		for _, ep := range endpoints {
 			// TODO (mesh-gateway) - should we respect the translate_wan_addrs configuration here or just always use the wan for cross-dc?
 			_, addr, port := ep.BestAddress(!localKey.Matches(ep.Node.Datacenter, ep.Node.PartitionOrDefault()))
+			if endpointGroup.IgnoreWeights {
+				ep = withoutWeights(ep)
+			}
 			healthStatus, weight := calculateEndpointHealthAndWeight(ep, endpointGroup.OnlyPassing)
+			if endpointGroup.OverrideWeight > 0 {
+				weight = endpointGroup.OverrideWeight
+			}
 
 			if endpointGroup.OverrideHealth != envoy_core_v3.HealthStatus_UNKNOWN {
 				healthStatus = endpointGroup.OverrideHealth
 			}
 
+			address := makeAddress(addr, port)
+			if port == 0 && ep.Service.SocketPath != "" {
+				// Instances registered with a socket path instead of a port
+				// are reached through it, which requires sharing their host.
+				address = makePipeAddress(ep.Service.SocketPath, 0)
+			}
+
 			es = append(es, &envoy_endpoint_v3.LbEndpoint{
 				HostIdentifier: &envoy_endpoint_v3.LbEndpoint_Endpoint{
 					Endpoint: &envoy_endpoint_v3.Endpoint{
-						Address: makeAddress(addr, port),
+						Address: address,
 					},
 				},
 				HealthStatus:        healthStatus,
@@ -587,44 +693,39 @@ func makeLoadAssignment(clusterName string, endpointGroups []loadAssignmentEndpo
 	return cla
 }
 
-func makeLoadAssignmentEndpointGroup(
+// makeLoadAssignmentEndpointGroups returns the endpoint groups of a target,
+// which are the remote mesh gateways in the order configured for them when the
+// target is reached through mesh gateways.
+func makeLoadAssignmentEndpointGroups(
 	targets map[string]*structs.DiscoveryTarget,
 	targetHealth map[string]structs.CheckServiceNodes,
 	gatewayHealth map[string]structs.CheckServiceNodes,
+	remoteGateways map[string][]RemoteGatewayGroup,
 	targetID string,
 	localKey proxycfg.GatewayKey,
-) (loadAssignmentEndpointGroup, bool) {
+) ([]loadAssignmentEndpointGroup, bool) {
 	realEndpoints, ok := targetHealth[targetID]
 	if !ok {
 		// skip the cluster if we're still populating the snapshot
-		return loadAssignmentEndpointGroup{}, false
+		return nil, false
 	}
 	target := targets[targetID]
 
-	var gatewayKey proxycfg.GatewayKey
-
-	switch target.MeshGateway.Mode {
-	case structs.MeshGatewayModeRemote:
-		gatewayKey.Datacenter = target.Datacenter
-		gatewayKey.Partition = target.Partition
-
-	case structs.MeshGatewayModeLocal:
-		gatewayKey = localKey
-	}
-
-	if gatewayKey.IsEmpty() || (acl.EqualPartitions(localKey.Partition, target.Partition) && localKey.Datacenter == target.Datacenter) {
+	gatewayKey := proxycfg.GatewayKeyForTarget(target, localKey)
+	if gatewayKey.IsEmpty() {
 		// Gateways are not needed if the request isn't for a remote DC or partition.
-		return loadAssignmentEndpointGroup{
-			Endpoints:   realEndpoints,
-			OnlyPassing: target.Subset.OnlyPassing,
-		}, true
+		return []loadAssignmentEndpointGroup{{
+			Endpoints:     realEndpoints,
+			OnlyPassing:   target.Subset.OnlyPassing,
+			IgnoreWeights: target.IgnoreWeights,
+		}}, true
 	}
 
 	// If using a mesh gateway we need to pull those endpoints instead.
 	gatewayEndpoints, ok := gatewayHealth[gatewayKey.String()]
 	if !ok {
 		// skip the cluster if we're still populating the snapshot
-		return loadAssignmentEndpointGroup{}, false
+		return nil, false
 	}
 
 	// But we will use the health from the actual backend service.
@@ -637,10 +738,28 @@ func makeLoadAssignmentEndpointGroup(
 		}
 	}
 
-	return loadAssignmentEndpointGroup{
-		Endpoints:      gatewayEndpoints,
-		OverrideHealth: overallHealth,
-	}, true
+	groups := remoteGatewayEndpointGroups(remoteGateways[gatewayKey.String()], gatewayEndpoints)
+	for i := range groups {
+		groups[i].IgnoreWeights = target.IgnoreWeights
+		// When the gateways are ordered, the groups fail over based on the
+		// health of the gateways themselves as long as the backend is healthy.
+		if len(groups) == 1 || overallHealth != envoy_core_v3.HealthStatus_HEALTHY {
+			groups[i].OverrideHealth = overallHealth
+		}
+	}
+	return groups, true
+}
+
+// withoutWeights returns a copy of ep whose service has no Weights so that it
+// is treated like any other instance.
+func withoutWeights(ep structs.CheckServiceNode) structs.CheckServiceNode {
+	if ep.Service == nil || ep.Service.Weights == nil {
+		return ep
+	}
+	svc := *ep.Service
+	svc.Weights = nil
+	ep.Service = &svc
+	return ep
 }
 
 func calculateEndpointHealthAndWeight(
@@ -16,6 +16,7 @@ import (
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/agent/xds/proxysupport"
 	"github.com/hashicorp/consul/agent/xds/xdscommon"
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/sdk/testutil"
 )
 
@@ -94,6 +95,13 @@ func Test_makeLoadAssignment(t *testing.T) {
 	testWarningCheckServiceNodes[0].Checks[0].Status = "warning"
 	testWarningCheckServiceNodes[1].Checks[0].Status = "warning"
 
+	testSocketCheckServiceNodesRaw, err := copystructure.Copy(testCheckServiceNodes)
+	require.NoError(t, err)
+	testSocketCheckServiceNodes := testSocketCheckServiceNodesRaw.(structs.CheckServiceNodes)
+
+	testSocketCheckServiceNodes[0].Service.Port = 0
+	testSocketCheckServiceNodes[0].Service.SocketPath = "/tmp/web.sock"
+
 	// TODO(rb): test onlypassing
 	tests := []struct {
 		name        string
@@ -204,6 +212,66 @@ func Test_makeLoadAssignment(t *testing.T) {
 				}},
 			},
 		},
+		{
+			name:        "instances, socket path",
+			clusterName: "service:test",
+			endpoints: []loadAssignmentEndpointGroup{
+				{Endpoints: testSocketCheckServiceNodes},
+			},
+			want: &envoy_endpoint_v3.ClusterLoadAssignment{
+				ClusterName: "service:test",
+				Endpoints: []*envoy_endpoint_v3.LocalityLbEndpoints{{
+					LbEndpoints: []*envoy_endpoint_v3.LbEndpoint{
+						{
+							HostIdentifier: &envoy_endpoint_v3.LbEndpoint_Endpoint{
+								Endpoint: &envoy_endpoint_v3.Endpoint{
+									Address: makePipeAddress("/tmp/web.sock", 0),
+								}},
+							HealthStatus:        envoy_core_v3.HealthStatus_HEALTHY,
+							LoadBalancingWeight: makeUint32Value(1),
+						},
+						{
+							HostIdentifier: &envoy_endpoint_v3.LbEndpoint_Endpoint{
+								Endpoint: &envoy_endpoint_v3.Endpoint{
+									Address: makeAddress("10.10.10.20", 1234),
+								}},
+							HealthStatus:        envoy_core_v3.HealthStatus_HEALTHY,
+							LoadBalancingWeight: makeUint32Value(1),
+						},
+					},
+				}},
+			},
+		},
+		{
+			name:        "instances, ignored weights",
+			clusterName: "service:test",
+			endpoints: []loadAssignmentEndpointGroup{
+				{Endpoints: testWarningCheckServiceNodes, IgnoreWeights: true},
+			},
+			want: &envoy_endpoint_v3.ClusterLoadAssignment{
+				ClusterName: "service:test",
+				Endpoints: []*envoy_endpoint_v3.LocalityLbEndpoints{{
+					LbEndpoints: []*envoy_endpoint_v3.LbEndpoint{
+						{
+							HostIdentifier: &envoy_endpoint_v3.LbEndpoint_Endpoint{
+								Endpoint: &envoy_endpoint_v3.Endpoint{
+									Address: makeAddress("10.10.10.10", 1234),
+								}},
+							HealthStatus:        envoy_core_v3.HealthStatus_HEALTHY,
+							LoadBalancingWeight: makeUint32Value(1),
+						},
+						{
+							HostIdentifier: &envoy_endpoint_v3.LbEndpoint_Endpoint{
+								Endpoint: &envoy_endpoint_v3.Endpoint{
+									Address: makeAddress("10.10.10.20", 1234),
+								}},
+							HealthStatus:        envoy_core_v3.HealthStatus_HEALTHY,
+							LoadBalancingWeight: makeUint32Value(1),
+						},
+					},
+				}},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -217,6 +285,132 @@ func Test_makeLoadAssignment(t *testing.T) {
 	}
 }
 
+func Test_remoteGatewayEndpointGroups(t *testing.T) {
+	gateway := func(node string) structs.CheckServiceNode {
+		return structs.CheckServiceNode{
+			Node:    &structs.Node{Node: node, Datacenter: "dc2"},
+			Service: &structs.NodeService{Service: "mesh-gateway"},
+		}
+	}
+	nodes := func(group loadAssignmentEndpointGroup) []string {
+		var names []string
+		for _, ep := range group.Endpoints {
+			names = append(names, ep.Node.Node)
+		}
+		return names
+	}
+
+	endpoints := structs.CheckServiceNodes{gateway("a"), gateway("b"), gateway("c"), gateway("d")}
+
+	t.Run("not configured", func(t *testing.T) {
+		groups := remoteGatewayEndpointGroups(nil, endpoints)
+		require.Len(t, groups, 1)
+		require.Equal(t, []string{"a", "b", "c", "d"}, nodes(groups[0]))
+	})
+
+	t.Run("ordered groups", func(t *testing.T) {
+		groups := remoteGatewayEndpointGroups([]RemoteGatewayGroup{
+			{Nodes: []string{"c", "missing"}},
+			{Nodes: []string{"missing"}},
+			{Nodes: []string{"a", "c"}, Weight: 10},
+		}, endpoints)
+		require.Len(t, groups, 3)
+		require.Equal(t, []string{"c"}, nodes(groups[0]))
+		require.Equal(t, []string{"a"}, nodes(groups[1]))
+		require.Equal(t, 10, groups[1].OverrideWeight)
+		require.Equal(t, []string{"b", "d"}, nodes(groups[2]))
+		require.Zero(t, groups[2].OverrideWeight)
+
+		cla := makeLoadAssignment("dc2", groups, proxycfg.GatewayKey{Datacenter: "dc1"})
+		require.Len(t, cla.Endpoints, 3)
+		require.Equal(t, uint32(1), cla.Endpoints[1].Priority)
+		require.Equal(t, uint32(10), cla.Endpoints[1].LbEndpoints[0].LoadBalancingWeight.GetValue())
+	})
+}
+
+func Test_makeLoadAssignmentEndpointGroup_IgnoreWeights(t *testing.T) {
+	target := structs.NewDiscoveryTarget("web", "", "default", "default", "dc2")
+	target.IgnoreWeights = true
+	targets := map[string]*structs.DiscoveryTarget{target.ID: target}
+
+	backend := structs.CheckServiceNode{
+		Node:    &structs.Node{Node: "web", Datacenter: "dc2"},
+		Service: &structs.NodeService{Service: "web"},
+	}
+	gateway := structs.CheckServiceNode{
+		Node: &structs.Node{Node: "gw", Datacenter: "dc2"},
+		Service: &structs.NodeService{
+			Service: "mesh-gateway",
+			Weights: &structs.Weights{Passing: 5, Warning: 1},
+		},
+	}
+
+	groups, ok := makeLoadAssignmentEndpointGroups(
+		targets,
+		map[string]structs.CheckServiceNodes{target.ID: {backend}},
+		map[string]structs.CheckServiceNodes{"dc2": {gateway}},
+		nil,
+		target.ID,
+		proxycfg.GatewayKey{Datacenter: "dc1"},
+	)
+	require.True(t, ok)
+	require.Len(t, groups, 1)
+	require.True(t, groups[0].IgnoreWeights)
+
+	cla := makeLoadAssignment("web", groups, proxycfg.GatewayKey{Datacenter: "dc1"})
+	require.Equal(t, uint32(1), cla.Endpoints[0].LbEndpoints[0].LoadBalancingWeight.GetValue())
+}
+
+func Test_makeLoadAssignmentEndpointGroups_RemoteGateways(t *testing.T) {
+	target := structs.NewDiscoveryTarget("web", "", "default", "default", "dc2")
+	target.MeshGateway.Mode = structs.MeshGatewayModeRemote
+	targets := map[string]*structs.DiscoveryTarget{target.ID: target}
+
+	backend := structs.CheckServiceNode{
+		Node:    &structs.Node{Node: "web", Datacenter: "dc2"},
+		Service: &structs.NodeService{Service: "web"},
+	}
+	gateway := func(node string) structs.CheckServiceNode {
+		return structs.CheckServiceNode{
+			Node:    &structs.Node{Node: node, Datacenter: "dc2"},
+			Service: &structs.NodeService{Service: "mesh-gateway"},
+		}
+	}
+	remoteGateways := map[string][]RemoteGatewayGroup{
+		"dc2": {{Nodes: []string{"gw-b"}, Weight: 10}},
+	}
+
+	makeGroups := func(backendHealth string) []loadAssignmentEndpointGroup {
+		backend := backend
+		backend.Checks = structs.HealthChecks{{Status: backendHealth}}
+		groups, ok := makeLoadAssignmentEndpointGroups(
+			targets,
+			map[string]structs.CheckServiceNodes{target.ID: {backend}},
+			map[string]structs.CheckServiceNodes{"dc2": {gateway("gw-a"), gateway("gw-b")}},
+			remoteGateways,
+			target.ID,
+			proxycfg.GatewayKey{Datacenter: "dc1"},
+		)
+		require.True(t, ok)
+		return groups
+	}
+
+	// The configured gateways are used first, based on their own health.
+	groups := makeGroups(api.HealthPassing)
+	require.Len(t, groups, 2)
+	require.Equal(t, "gw-b", groups[0].Endpoints[0].Node.Node)
+	require.Equal(t, 10, groups[0].OverrideWeight)
+	require.Equal(t, "gw-a", groups[1].Endpoints[0].Node.Node)
+	for _, group := range groups {
+		require.Equal(t, envoy_core_v3.HealthStatus_UNKNOWN, group.OverrideHealth)
+	}
+
+	// None of the gateways are used when the backend is unhealthy.
+	for _, group := range makeGroups(api.HealthCritical) {
+		require.Equal(t, envoy_core_v3.HealthStatus_UNHEALTHY, group.OverrideHealth)
+	}
+}
+
 func TestEndpointsFromSnapshot(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -245,6 +439,12 @@ func TestEndpointsFromSnapshot(t *testing.T) {
 				return proxycfg.TestConfigSnapshotMeshGateway(t, "federation-states", nil, nil)
 			},
 		},
+		{
+			name: "mesh-gateway-peer-through-mesh-gateways",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshotMeshGateway(t, "peer-through-mesh-gateways", nil, nil)
+			},
+		},
 		{
 			name: "mesh-gateway-newer-information-in-federation-states",
 			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
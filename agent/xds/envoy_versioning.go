@@ -13,6 +13,10 @@ var (
 	// the zero'th point release of the last element of proxysupport.EnvoyVersions.
 	minSupportedVersion = version.Must(version.NewVersion("1.19.0"))
 
+	// minOpenTelemetryTracerVersion is the first version with the
+	// envoy.tracers.opentelemetry tracer.
+	minOpenTelemetryTracerVersion = version.Must(version.NewVersion("1.22.0"))
+
 	specificUnsupportedVersions = []unsupportedVersion{}
 )
 
@@ -25,6 +29,10 @@ type unsupportedVersion struct {
 type supportedProxyFeatures struct {
 	// Put feature switches here when necessary. For reference, The most recent remove of a feature flag was removed in
 	// <insert PR here>.
+
+	// NoOpenTelemetryTracer is set for versions of Envoy without the
+	// OpenTelemetry tracer, which don't report spans with it.
+	NoOpenTelemetryTracer bool
 }
 
 func determineSupportedProxyFeatures(node *envoy_core_v3.Node) (supportedProxyFeatures, error) {
@@ -63,6 +71,7 @@ func determineSupportedProxyFeaturesFromVersion(version *version.Version) (suppo
 	sf := supportedProxyFeatures{}
 
 	// when feature flags necessary, populate here by calling version.LessThan(...)
+	sf.NoOpenTelemetryTracer = version.LessThan(minOpenTelemetryTracerVersion)
 
 	return sf, nil
 }
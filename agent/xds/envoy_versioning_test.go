@@ -137,6 +137,12 @@ func TestDetermineSupportedProxyFeaturesFromString(t *testing.T) {
 		"1.19.0", "1.19.1", "1.19.2", "1.19.3",
 		"1.20.0", "1.20.1", "1.20.2",
 		"1.21.1",
+	} {
+		cases[v] = testcase{expect: supportedProxyFeatures{
+			NoOpenTelemetryTracer: true,
+		}}
+	}
+	for _, v := range []string{
 		"1.22.0",
 	} {
 		cases[v] = testcase{expect: supportedProxyFeatures{}}
@@ -14,6 +14,8 @@ import (
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_http_buffer_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/buffer/v3"
+	envoy_http_cors_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/cors/v3"
 	envoy_grpc_http1_bridge_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/grpc_http1_bridge/v3"
 	envoy_grpc_stats_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/grpc_stats/v3"
 	envoy_http_router_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
@@ -24,7 +26,6 @@ import (
 	envoy_sni_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/sni_cluster/v3"
 	envoy_tcp_proxy_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
 	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
-	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
@@ -61,6 +62,8 @@ func (s *ResourceGenerator) listenersFromSnapshot(cfgSnap *proxycfg.ConfigSnapsh
 		return s.listenersFromSnapshotGateway(cfgSnap)
 	case structs.ServiceKindIngressGateway:
 		return s.listenersFromSnapshotGateway(cfgSnap)
+	case structs.ServiceKindEgressGateway:
+		return s.listenersFromSnapshotGateway(cfgSnap)
 	default:
 		return nil, fmt.Errorf("Invalid service kind: %v", cfgSnap.Kind)
 	}
@@ -71,8 +74,13 @@ func (s *ResourceGenerator) listenersFromSnapshotConnectProxy(cfgSnap *proxycfg.
 	resources := make([]proto.Message, 1)
 	var err error
 
+	tracing, err := s.makeTracing(cfgSnap)
+	if err != nil {
+		return nil, err
+	}
+
 	// Configure inbound listener.
-	resources[0], err = s.makeInboundListener(cfgSnap, PublicListenerName)
+	resources[0], err = s.makeInboundListener(cfgSnap, PublicListenerName, tracing)
 	if err != nil {
 		return nil, err
 	}
@@ -128,7 +136,10 @@ func (s *ResourceGenerator) listenersFromSnapshotConnectProxy(cfgSnap *proxycfg.
 		// RDS, Envoy's Route Discovery Service, is only used for HTTP services with a customized discovery chain.
 		useRDS := chain.Protocol != "tcp" && !chain.Default
 
-		var clusterName string
+		var (
+			clusterName            string
+			inlineRequestTimeoutMs *int
+		)
 		if !useRDS {
 			// When not using RDS we must generate a cluster name to attach to the filter chain.
 			// With RDS, cluster names get attached to the dynamic routes instead.
@@ -137,18 +148,21 @@ func (s *ResourceGenerator) listenersFromSnapshotConnectProxy(cfgSnap *proxycfg.
 				return nil, err
 			}
 			clusterName = CustomizeClusterName(target.Name, chain)
+			inlineRequestTimeoutMs = makeInlineRequestTimeoutMs(cfg)
 		}
 
-		filterName := fmt.Sprintf("%s.%s.%s.%s", chain.ServiceName, chain.Namespace, chain.Partition, chain.Datacenter)
+		filterName := upstreamFilterName(chain)
 
 		// Generate the upstream listeners for when they are explicitly set with a local bind port or socket path
 		if upstreamCfg != nil && upstreamCfg.HasLocalPortOrSocket() {
 			filterChain, err := s.makeUpstreamFilterChain(filterChainOpts{
-				routeName:   uid.EnvoyID(),
-				clusterName: clusterName,
-				filterName:  filterName,
-				protocol:    cfg.Protocol,
-				useRDS:      useRDS,
+				routeName:        uid.EnvoyID(),
+				clusterName:      clusterName,
+				filterName:       filterName,
+				protocol:         cfg.Protocol,
+				useRDS:           useRDS,
+				tracing:          tracing,
+				requestTimeoutMs: inlineRequestTimeoutMs,
 			})
 			if err != nil {
 				return nil, err
@@ -158,6 +172,11 @@ func (s *ResourceGenerator) listenersFromSnapshotConnectProxy(cfgSnap *proxycfg.
 			upstreamListener.FilterChains = []*envoy_listener_v3.FilterChain{
 				filterChain,
 			}
+			if upstreamProtocolDetectionEnabled(cfgSnap, uid, chain) {
+				if err := injectProtocolDetection(upstreamListener, clusterName, filterName); err != nil {
+					return nil, err
+				}
+			}
 			resources = append(resources, upstreamListener)
 
 			// Avoid creating filter chains below for upstreams that have dedicated listeners
@@ -169,11 +188,13 @@ func (s *ResourceGenerator) listenersFromSnapshotConnectProxy(cfgSnap *proxycfg.
 		// as we do for explicit upstreams above.
 
 		filterChain, err := s.makeUpstreamFilterChain(filterChainOpts{
-			routeName:   uid.EnvoyID(),
-			clusterName: clusterName,
-			filterName:  filterName,
-			protocol:    cfg.Protocol,
-			useRDS:      useRDS,
+			routeName:        uid.EnvoyID(),
+			clusterName:      clusterName,
+			filterName:       filterName,
+			protocol:         cfg.Protocol,
+			useRDS:           useRDS,
+			tracing:          tracing,
+			requestTimeoutMs: inlineRequestTimeoutMs,
 		})
 		if err != nil {
 			return nil, err
@@ -268,6 +289,7 @@ func (s *ResourceGenerator) listenersFromSnapshotConnectProxy(cfgSnap *proxycfg.
 				routeName:   uid.EnvoyID(),
 				protocol:    cfg.Protocol,
 				useRDS:      false,
+				tracing:     tracing,
 			})
 			if err != nil {
 				return nil, err
@@ -327,9 +349,46 @@ func (s *ResourceGenerator) listenersFromSnapshotConnectProxy(cfgSnap *proxycfg.
 				outboundListener.FilterChains[j].FilterChainMatch.PrefixRanges[0].AddressPrefix
 		})
 
+		meshConf := cfgSnap.MeshConfig()
+		passthrough := meshConf == nil || !meshConf.TransparentProxy.MeshDestinationsOnly
+
+		// In on-demand mode, the HTTP requests to the upstreams whose virtual IPs
+		// aren't known yet are routed by their host. So are the requests to the
+		// external hostnames reached through the dynamic forward proxy.
+		uids := onDemandUpstreams(cfgSnap)
+		egressHostnames := s.egressHostnames(cfgSnap)
+		if len(uids) > 0 || len(egressHostnames) > 0 {
+			httpInspector, err := makeHTTPInspectorListenerFilter()
+			if err != nil {
+				return nil, err
+			}
+			outboundListener.ListenerFilters = append(outboundListener.ListenerFilters, httpInspector)
+
+			filterChain, err := s.makeOnDemandFilterChain(cfgSnap, uids, egressHostnames, passthrough)
+			if err != nil {
+				return nil, err
+			}
+			outboundListener.FilterChains = append(outboundListener.FilterChains, filterChain)
+		}
+
+		// The TLS connections to the external hostnames are forwarded according
+		// to their SNI.
+		if len(egressHostnames) > 0 {
+			tlsInspector, err := makeTLSInspectorListenerFilter()
+			if err != nil {
+				return nil, err
+			}
+			outboundListener.ListenerFilters = append(outboundListener.ListenerFilters, tlsInspector)
+
+			filterChain, err := s.makeEgressTLSFilterChain(egressHostnames)
+			if err != nil {
+				return nil, err
+			}
+			outboundListener.FilterChains = append(outboundListener.FilterChains, filterChain)
+		}
+
 		// Add a catch-all filter chain that acts as a TCP proxy to destinations outside the mesh
-		if meshConf := cfgSnap.MeshConfig(); meshConf == nil ||
-			!meshConf.TransparentProxy.MeshDestinationsOnly {
+		if passthrough {
 
 			filterChain, err := s.makeUpstreamFilterChain(filterChainOpts{
 				clusterName: OriginalDestinationClusterName,
@@ -378,10 +437,12 @@ func (s *ResourceGenerator) listenersFromSnapshotConnectProxy(cfgSnap *proxycfg.
 
 		filterChain, err := s.makeUpstreamFilterChain(filterChainOpts{
 			// TODO (SNI partition) add partition for upstream SNI
-			clusterName: connect.UpstreamSNI(u, "", cfgSnap.Datacenter, cfgSnap.Roots.TrustDomain),
-			filterName:  uid.EnvoyID(),
-			routeName:   uid.EnvoyID(),
-			protocol:    cfg.Protocol,
+			clusterName:      connect.UpstreamSNI(u, "", cfgSnap.Datacenter, cfgSnap.Roots.TrustDomain),
+			filterName:       uid.EnvoyID(),
+			routeName:        uid.EnvoyID(),
+			protocol:         cfg.Protocol,
+			tracing:          tracing,
+			requestTimeoutMs: makeInlineRequestTimeoutMs(cfg),
 		})
 		if err != nil {
 			return nil, err
@@ -584,6 +645,8 @@ func (s *ResourceGenerator) listenersFromSnapshotGateway(cfgSnap *proxycfg.Confi
 
 	// Make listeners and deduplicate on the fly.
 	seen := make(map[structs.ServiceAddress]bool)
+	// The ingress listeners bound to a socket are the same for every address.
+	seenIngress := make(map[string]bool)
 	for _, a := range addrs {
 		if seen[a.ServiceAddress] {
 			continue
@@ -603,12 +666,23 @@ func (s *ResourceGenerator) listenersFromSnapshotGateway(cfgSnap *proxycfg.Confi
 			if err != nil {
 				return nil, err
 			}
-			resources = append(resources, listeners...)
+			for _, l := range listeners {
+				name := l.(*envoy_listener_v3.Listener).Name
+				if !seenIngress[name] {
+					seenIngress[name] = true
+					resources = append(resources, l)
+				}
+			}
 		case structs.ServiceKindMeshGateway:
 			l, err = s.makeMeshGatewayListener(a.name, a.Address, a.Port, cfgSnap)
 			if err != nil {
 				return nil, err
 			}
+		case structs.ServiceKindEgressGateway:
+			l, err = s.makeEgressGatewayListener(cfgSnap, a.name, a.Address, a.Port)
+			if err != nil {
+				return nil, err
+			}
 		}
 		if l != nil {
 			resources = append(resources, l)
@@ -938,7 +1012,7 @@ func makeSpiffeValidatorConfig(trustDomain, roots string, peerBundles []*pbpeeri
 	return ptypes.MarshalAny(cfg)
 }
 
-func (s *ResourceGenerator) makeInboundListener(cfgSnap *proxycfg.ConfigSnapshot, name string) (proto.Message, error) {
+func (s *ResourceGenerator) makeInboundListener(cfgSnap *proxycfg.ConfigSnapshot, name string, tracing *envoy_http_v3.HttpConnectionManager_Tracing) (proto.Message, error) {
 	var l *envoy_listener_v3.Listener
 	var err error
 
@@ -1008,7 +1082,12 @@ func (s *ResourceGenerator) makeInboundListener(cfgSnap *proxycfg.ConfigSnapshot
 		port = cfg.BindPort
 	}
 
-	l = makePortListener(name, addr, port, envoy_core_v3.TrafficDirection_INBOUND)
+	// Proxies registered with a socket path instead of a port listen on it.
+	if port == 0 && cfgSnap.SocketPath != "" {
+		l = makePipeListener(name, cfgSnap.SocketPath, "", envoy_core_v3.TrafficDirection_INBOUND)
+	} else {
+		l = makePortListener(name, addr, port, envoy_core_v3.TrafficDirection_INBOUND)
+	}
 
 	filterOpts := listenerFilterOpts{
 		protocol:         cfg.Protocol,
@@ -1016,6 +1095,8 @@ func (s *ResourceGenerator) makeInboundListener(cfgSnap *proxycfg.ConfigSnapshot
 		routeName:        name,
 		cluster:          LocalAppClusterName,
 		requestTimeoutMs: cfg.LocalRequestTimeoutMs,
+		maxRequestBytes:  cfg.MaxRequestBodyBytes,
+		tracing:          tracing,
 	}
 	if useHTTPFilter {
 		filterOpts.httpAuthzFilter, err = makeRBACHTTPFilter(
@@ -1030,6 +1111,8 @@ func (s *ResourceGenerator) makeInboundListener(cfgSnap *proxycfg.ConfigSnapshot
 			filterOpts.forwardClientDetails = true
 			filterOpts.forwardClientPolicy = envoy_http_v3.HttpConnectionManager_APPEND_FORWARD
 		}
+		filterOpts.requestNormalization = cfg.RequestNormalization(cfgSnap.MeshConfig())
+		filterOpts.observabilityHeaders = s.observabilityHeaders(cfgSnap, cfg)
 	}
 	filter, err := makeListenerFilter(filterOpts)
 	if err != nil {
@@ -1067,16 +1150,21 @@ func (s *ResourceGenerator) finalizePublicListenerFromConfig(l *envoy_listener_v
 	}
 
 	// If an inbound connect limit is set, inject a connection limit filter on each chain.
+	// It must come before the terminal filter of the chain.
 	if proxyCfg.MaxInboundConnections > 0 {
 		filter, err := makeConnectionLimitFilter(proxyCfg.MaxInboundConnections)
 		if err != nil {
 			return nil
 		}
 		for idx := range l.FilterChains {
-			l.FilterChains[idx].Filters = append(l.FilterChains[idx].Filters, filter)
+			l.FilterChains[idx].Filters = append([]*envoy_listener_v3.Filter{filter}, l.FilterChains[idx].Filters...)
 		}
 	}
 
+	if proxyCfg.InboundConnectionBufferLimitBytes > 0 {
+		l.PerConnectionBufferLimitBytes = makeUint32Value(proxyCfg.InboundConnectionBufferLimitBytes)
+	}
+
 	return nil
 }
 
@@ -1203,7 +1291,7 @@ func (s *ResourceGenerator) makeTerminatingGatewayListener(
 			clusterName,
 			svc,
 			intentions,
-			cfg.Protocol,
+			cfg,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to make filter chain for cluster %q: %v", clusterName, err)
@@ -1221,7 +1309,7 @@ func (s *ResourceGenerator) makeTerminatingGatewayListener(
 					subsetClusterName,
 					svc,
 					intentions,
-					cfg.Protocol,
+					cfg,
 				)
 				if err != nil {
 					return nil, fmt.Errorf("failed to make filter chain for cluster %q: %v", subsetClusterName, err)
@@ -1269,8 +1357,9 @@ func (s *ResourceGenerator) makeFilterChainTerminatingGateway(
 	cluster string,
 	service structs.ServiceName,
 	intentions structs.Intentions,
-	protocol string,
+	cfg ProxyConfig,
 ) (*envoy_listener_v3.FilterChain, error) {
+	protocol := cfg.Protocol
 	tlsContext := &envoy_tls_v3.DownstreamTlsContext{
 		CommonTlsContext: makeCommonTLSContext(
 			cfgSnap.TerminatingGateway.ServiceLeaves[service],
@@ -1337,6 +1426,7 @@ func (s *ResourceGenerator) makeFilterChainTerminatingGateway(
 			// This assumes that we have a client cert (mTLS) (implied by the context of this function)
 			opts.forwardClientPolicy = envoy_http_v3.HttpConnectionManager_APPEND_FORWARD
 		}
+		opts.requestNormalization = cfg.RequestNormalization(cfgSnap.MeshConfig())
 	}
 
 	filter, err := makeListenerFilter(opts)
@@ -1513,16 +1603,22 @@ type filterChainOpts struct {
 	protocol    string
 	useRDS      bool
 	tlsContext  *envoy_tls_v3.DownstreamTlsContext
+	tracing     *envoy_http_v3.HttpConnectionManager_Tracing
+	// requestTimeoutMs overrides the request timeout of the route of upstreams
+	// that don't use RDS.
+	requestTimeoutMs *int
 }
 
 func (s *ResourceGenerator) makeUpstreamFilterChain(opts filterChainOpts) (*envoy_listener_v3.FilterChain, error) {
 	filter, err := makeListenerFilter(listenerFilterOpts{
-		useRDS:     opts.useRDS,
-		protocol:   opts.protocol,
-		filterName: opts.filterName,
-		routeName:  opts.routeName,
-		cluster:    opts.clusterName,
-		statPrefix: "upstream.",
+		useRDS:           opts.useRDS,
+		protocol:         opts.protocol,
+		filterName:       opts.filterName,
+		routeName:        opts.routeName,
+		cluster:          opts.clusterName,
+		statPrefix:       "upstream.",
+		tracing:          opts.tracing,
+		requestTimeoutMs: opts.requestTimeoutMs,
 	})
 	if err != nil {
 		return nil, err
@@ -1540,6 +1636,12 @@ func (s *ResourceGenerator) makeUpstreamFilterChain(opts filterChainOpts) (*envo
 	}, nil
 }
 
+// upstreamFilterName returns the name of the filters of the upstream listener
+// of a discovery chain, which their stats are named after.
+func upstreamFilterName(chain *structs.CompiledDiscoveryChain) string {
+	return fmt.Sprintf("%s.%s.%s.%s", chain.ServiceName, chain.Namespace, chain.Partition, chain.Datacenter)
+}
+
 // simpleChainTarget returns the discovery target for a chain with a single node.
 // A chain can have a single target if it is for a TCP service or an HTTP service without
 // multiple splits/routes/failovers.
@@ -1555,6 +1657,17 @@ func simpleChainTarget(chain *structs.CompiledDiscoveryChain) (*structs.Discover
 	return chain.Targets[targetID], nil
 }
 
+// makeInlineRequestTimeoutMs returns the request timeout of the single route
+// of an upstream listener that doesn't use RDS, if its upstream config
+// overrides it.
+func makeInlineRequestTimeoutMs(cfg structs.UpstreamConfig) *int {
+	timeoutMs := upstreamRouteTimeoutMs(cfg, makeDefaultRouteMatch())
+	if timeoutMs <= 0 {
+		return nil
+	}
+	return &timeoutMs
+}
+
 func (s *ResourceGenerator) getAndModifyUpstreamConfigForListener(
 	uid proxycfg.UpstreamID,
 	u *structs.Upstream,
@@ -1659,9 +1772,14 @@ type listenerFilterOpts struct {
 	routePath            string
 	requestTimeoutMs     *int
 	ingressGateway       bool
+	corsFilter           bool
+	maxRequestBytes      int
 	httpAuthzFilter      *envoy_http_v3.HttpFilter
 	forwardClientDetails bool
 	forwardClientPolicy  envoy_http_v3.HttpConnectionManager_ForwardClientCertDetails
+	requestNormalization *structs.RequestNormalizationConfig
+	observabilityHeaders *structs.ObservabilityHeadersConfig
+	tracing              *envoy_http_v3.HttpConnectionManager_Tracing
 }
 
 func makeListenerFilter(opts listenerFilterOpts) (*envoy_listener_v3.Filter, error) {
@@ -1716,6 +1834,103 @@ func makeStatPrefix(prefix, filterName string) string {
 	return fmt.Sprintf("%s%s", prefix, strings.Replace(filterName, ":", "_", -1))
 }
 
+// setRequestNormalization configures how the HTTP connection manager
+// normalizes and validates requests before they reach its filters, so that
+// the RBAC filter sees the same path as the application.
+func setRequestNormalization(cfg *envoy_http_v3.HttpConnectionManager, norm *structs.RequestNormalizationConfig) {
+	if norm.NormalizePath != nil && *norm.NormalizePath {
+		cfg.NormalizePath = makeBoolValue(true)
+	}
+	if norm.MergeSlashes != nil {
+		cfg.MergeSlashes = *norm.MergeSlashes
+	}
+	if action, ok := envoy_http_v3.HttpConnectionManager_PathWithEscapedSlashesAction_value[norm.PathWithEscapedSlashesAction]; ok {
+		cfg.PathWithEscapedSlashesAction = envoy_http_v3.HttpConnectionManager_PathWithEscapedSlashesAction(action)
+	}
+	if action, ok := envoy_core_v3.HttpProtocolOptions_HeadersWithUnderscoresAction_value[norm.HeadersWithUnderscoresAction]; ok {
+		cfg.CommonHttpProtocolOptions = &envoy_core_v3.HttpProtocolOptions{
+			HeadersWithUnderscoresAction: envoy_core_v3.HttpProtocolOptions_HeadersWithUnderscoresAction(action),
+		}
+	}
+	if norm.HeaderKeyFormat == structs.HeaderKeyFormatProperCase {
+		cfg.HttpProtocolOptions = makeProperCaseHttp1ProtocolOptions()
+	}
+}
+
+// observabilityHeaders returns the observability headers options of the
+// proxy with the variables of the request headers replaced by its identity.
+// The invalid options of the proxy configuration were already dropped by
+// ParseProxyConfig, and the mesh config entry is validated.
+func (s *ResourceGenerator) observabilityHeaders(cfgSnap *proxycfg.ConfigSnapshot, cfg ProxyConfig) *structs.ObservabilityHeadersConfig {
+	obs := cfg.ObservabilityHeaders(cfgSnap.MeshConfig())
+
+	service := cfgSnap.Service
+	if cfgSnap.Kind == structs.ServiceKindConnectProxy {
+		service = cfgSnap.Proxy.DestinationServiceName
+	}
+	obs.RequestHeaders = obs.ExpandRequestHeaders(map[string]string{
+		"service":    service,
+		"node":       cfgSnap.ProxyID.NodeName,
+		"datacenter": cfgSnap.Datacenter,
+		"namespace":  cfgSnap.ProxyID.NamespaceOrDefault(),
+		"partition":  cfgSnap.ProxyID.PartitionOrDefault(),
+	})
+	return obs
+}
+
+// setObservabilityHeaders configures how the HTTP connection manager
+// generates the x-request-id header of requests.
+func setObservabilityHeaders(cfg *envoy_http_v3.HttpConnectionManager, obs *structs.ObservabilityHeadersConfig) {
+	if obs.GenerateRequestID != nil {
+		cfg.GenerateRequestId = makeBoolValue(*obs.GenerateRequestID)
+	}
+	if obs.PreserveRequestID != nil {
+		cfg.PreserveExternalRequestId = *obs.PreserveRequestID
+	}
+}
+
+// injectObservabilityHeadersToRouteConfig sets the static request headers and
+// applies the trace context policy to all the virtual hosts of the route
+// configuration.
+func injectObservabilityHeadersToRouteConfig(obs *structs.ObservabilityHeadersConfig, rc *envoy_route_v3.RouteConfiguration) {
+	if obs == nil {
+		return
+	}
+
+	names := make([]string, 0, len(obs.RequestHeaders))
+	for name := range obs.RequestHeaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rc.RequestHeadersToAdd = append(rc.RequestHeadersToAdd, &envoy_core_v3.HeaderValueOption{
+			Header: &envoy_core_v3.HeaderValue{
+				Key: name,
+				// The values are static, escape them so Envoy doesn't
+				// interpret them as command operators.
+				Value: strings.ReplaceAll(obs.RequestHeaders[name], "%", "%%"),
+			},
+			Append: makeBoolValue(false),
+		})
+	}
+
+	if obs.TraceContextPolicy == structs.TraceContextPolicyStrip {
+		rc.RequestHeadersToRemove = append(rc.RequestHeadersToRemove, structs.TraceContextHeaders...)
+	}
+}
+
+// makeProperCaseHttp1ProtocolOptions returns the HTTP/1.1 options to send the
+// header names in proper case, e.g. "Content-Type", rather than lowercase.
+func makeProperCaseHttp1ProtocolOptions() *envoy_core_v3.Http1ProtocolOptions {
+	return &envoy_core_v3.Http1ProtocolOptions{
+		HeaderKeyFormat: &envoy_core_v3.Http1ProtocolOptions_HeaderKeyFormat{
+			HeaderFormat: &envoy_core_v3.Http1ProtocolOptions_HeaderKeyFormat_ProperCaseWords_{
+				ProperCaseWords: &envoy_core_v3.Http1ProtocolOptions_HeaderKeyFormat_ProperCaseWords{},
+			},
+		},
+	}
+}
+
 func makeHTTPFilter(opts listenerFilterOpts) (*envoy_listener_v3.Filter, error) {
 	router, err := makeEnvoyHTTPFilter("envoy.filters.http.router", &envoy_http_router_v3.Router{})
 	if err != nil {
@@ -1728,12 +1943,10 @@ func makeHTTPFilter(opts listenerFilterOpts) (*envoy_listener_v3.Filter, error)
 		HttpFilters: []*envoy_http_v3.HttpFilter{
 			router,
 		},
-		Tracing: &envoy_http_v3.HttpConnectionManager_Tracing{
-			// Don't trace any requests by default unless the client application
-			// explicitly propagates trace headers that indicate this should be
-			// sampled.
-			RandomSampling: &envoy_type_v3.Percent{Value: 0.0},
-		},
+		Tracing: opts.tracing,
+	}
+	if cfg.Tracing == nil {
+		cfg.Tracing = makeDefaultTracing()
 	}
 
 	if opts.useRDS {
@@ -1786,26 +1999,37 @@ func makeHTTPFilter(opts listenerFilterOpts) (*envoy_listener_v3.Filter, error)
 			route.Match.PathSpecifier = &envoy_route_v3.RouteMatch_Path{Path: opts.routePath}
 		}
 
-		cfg.RouteSpecifier = &envoy_http_v3.HttpConnectionManager_RouteConfig{
-			RouteConfig: &envoy_route_v3.RouteConfiguration{
-				Name: opts.routeName,
-				VirtualHosts: []*envoy_route_v3.VirtualHost{
-					{
-						Name:    opts.filterName,
-						Domains: []string{"*"},
-						Routes: []*envoy_route_v3.Route{
-							route,
-						},
+		routeConfig := &envoy_route_v3.RouteConfiguration{
+			Name: opts.routeName,
+			VirtualHosts: []*envoy_route_v3.VirtualHost{
+				{
+					Name:    opts.filterName,
+					Domains: []string{"*"},
+					Routes: []*envoy_route_v3.Route{
+						route,
 					},
 				},
 			},
 		}
+		injectObservabilityHeadersToRouteConfig(opts.observabilityHeaders, routeConfig)
+
+		cfg.RouteSpecifier = &envoy_http_v3.HttpConnectionManager_RouteConfig{
+			RouteConfig: routeConfig,
+		}
 	}
 
 	if opts.protocol == "http2" || opts.protocol == "grpc" {
 		cfg.Http2ProtocolOptions = &envoy_core_v3.Http2ProtocolOptions{}
 	}
 
+	if opts.requestNormalization != nil {
+		setRequestNormalization(cfg, opts.requestNormalization)
+	}
+
+	if opts.observabilityHeaders != nil {
+		setObservabilityHeaders(cfg, opts.observabilityHeaders)
+	}
+
 	// Note the default leads to setting HttpConnectionManager_SANITIZE
 	if opts.forwardClientDetails {
 		cfg.ForwardClientCertDetails = opts.forwardClientPolicy
@@ -1818,6 +2042,26 @@ func makeHTTPFilter(opts listenerFilterOpts) (*envoy_listener_v3.Filter, error)
 		}
 	}
 
+	// The CORS filter enforces the CORS policies of the routes so it must be
+	// placed before the router.
+	if opts.corsFilter {
+		cors, err := makeEnvoyHTTPFilter("envoy.filters.http.cors", &envoy_http_cors_v3.Cors{})
+		if err != nil {
+			return nil, err
+		}
+		cfg.HttpFilters = append([]*envoy_http_v3.HttpFilter{cors}, cfg.HttpFilters...)
+	}
+
+	// The buffer filter rejects requests with a body larger than the limit
+	// before they are proxied.
+	if opts.maxRequestBytes > 0 {
+		buffer, err := makeBufferHTTPFilter(opts.maxRequestBytes)
+		if err != nil {
+			return nil, err
+		}
+		cfg.HttpFilters = append([]*envoy_http_v3.HttpFilter{buffer}, cfg.HttpFilters...)
+	}
+
 	// Like injectConnectFilters for L4, here we ensure that the first filter
 	// (other than the "envoy.grpc_http1_bridge" filter) in the http filter
 	// chain of a public listener is the authz filter to prevent unauthorized
@@ -1860,6 +2104,12 @@ func makeHTTPFilter(opts listenerFilterOpts) (*envoy_listener_v3.Filter, error)
 	return makeFilter("envoy.filters.network.http_connection_manager", cfg)
 }
 
+func makeBufferHTTPFilter(maxRequestBytes int) (*envoy_http_v3.HttpFilter, error) {
+	return makeEnvoyHTTPFilter("envoy.filters.http.buffer", &envoy_http_buffer_v3.Buffer{
+		MaxRequestBytes: makeUint32Value(maxRequestBytes),
+	})
+}
+
 func makeEnvoyListenerFilter(name string, cfg proto.Message) (*envoy_listener_v3.ListenerFilter, error) {
 	any, err := ptypes.MarshalAny(cfg)
 	if err != nil {
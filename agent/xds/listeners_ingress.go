@@ -19,6 +19,14 @@ import (
 func (s *ResourceGenerator) makeIngressGatewayListeners(address string, cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
 	var resources []proto.Message
 
+	proxyCfg, err := ParseProxyConfig(cfgSnap.Proxy.Config)
+	if err != nil {
+		// Don't hard fail on a config typo, just warn. The parse func returns
+		// default config if there is an error so it's safe to continue.
+		s.Logger.Warn("failed to parse Connect.Proxy.Config", "error", err)
+	}
+	observabilityHeaders := s.observabilityHeaders(cfgSnap, proxyCfg)
+
 	for listenerKey, upstreams := range cfgSnap.IngressGateway.Upstreams {
 		listenerCfg, ok := cfgSnap.IngressGateway.Listeners[listenerKey]
 		if !ok {
@@ -63,7 +71,12 @@ func (s *ResourceGenerator) makeIngressGatewayListeners(address string, cfgSnap
 
 			filterName := fmt.Sprintf("%s.%s.%s.%s", chain.ServiceName, chain.Namespace, chain.Partition, chain.Datacenter)
 
-			l := makePortListenerWithDefault(uid.EnvoyID(), address, u.LocalBindPort, envoy_core_v3.TrafficDirection_OUTBOUND)
+			var l *envoy_listener_v3.Listener
+			if listenerCfg.SocketPath != "" {
+				l = makePipeListener(uid.EnvoyID(), listenerCfg.SocketPath, listenerCfg.SocketMode, envoy_core_v3.TrafficDirection_OUTBOUND)
+			} else {
+				l = makePortListenerWithDefault(uid.EnvoyID(), address, u.LocalBindPort, envoy_core_v3.TrafficDirection_OUTBOUND)
+			}
 			filterChain, err := s.makeUpstreamFilterChain(filterChainOpts{
 				routeName:   uid.EnvoyID(),
 				useRDS:      useRDS,
@@ -82,7 +95,12 @@ func (s *ResourceGenerator) makeIngressGatewayListeners(address string, cfgSnap
 
 		} else {
 			// If multiple upstreams share this port, make a special listener for the protocol.
-			listener := makePortListener(listenerKey.Protocol, address, listenerKey.Port, envoy_core_v3.TrafficDirection_OUTBOUND)
+			var listener *envoy_listener_v3.Listener
+			if listenerCfg.SocketPath != "" {
+				listener = makePipeListener(listenerKey.Protocol, listenerCfg.SocketPath, listenerCfg.SocketMode, envoy_core_v3.TrafficDirection_OUTBOUND)
+			} else {
+				listener = makePortListener(listenerKey.Protocol, address, listenerKey.Port, envoy_core_v3.TrafficDirection_OUTBOUND)
+			}
 			opts := listenerFilterOpts{
 				useRDS:          true,
 				protocol:        listenerKey.Protocol,
@@ -92,6 +110,10 @@ func (s *ResourceGenerator) makeIngressGatewayListeners(address string, cfgSnap
 				statPrefix:      "ingress_upstream_",
 				routePath:       "",
 				httpAuthzFilter: nil,
+				corsFilter:      listenerHasCORSPolicy(listenerCfg),
+				maxRequestBytes: listenerMaxRequestBodyBytes(listenerCfg),
+
+				observabilityHeaders: observabilityHeaders,
 			}
 
 			// Generate any filter chains needed for services with custom TLS certs
@@ -140,6 +162,30 @@ func (s *ResourceGenerator) makeIngressGatewayListeners(address string, cfgSnap
 	return resources, nil
 }
 
+// listenerHasCORSPolicy returns true if any service of the listener has a CORS
+// policy, in which case the CORS HTTP filter must be added to the listener.
+func listenerHasCORSPolicy(listenerCfg structs.IngressListener) bool {
+	for _, svc := range listenerCfg.Services {
+		if svc.CORS != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// listenerMaxRequestBodyBytes returns the largest request body limit of the
+// services of the listener. It is the default limit of the listener's buffer
+// filter, which every virtual host then overrides with its own limit.
+func listenerMaxRequestBodyBytes(listenerCfg structs.IngressListener) int {
+	var max int
+	for _, svc := range listenerCfg.Services {
+		if svc.MaxRequestBodyBytes > max {
+			max = svc.MaxRequestBodyBytes
+		}
+	}
+	return max
+}
+
 func makeDownstreamTLSContextFromSnapshotListenerConfig(cfgSnap *proxycfg.ConfigSnapshot, listenerCfg structs.IngressListener) (*envoy_tls_v3.DownstreamTlsContext, error) {
 	var downstreamContext *envoy_tls_v3.DownstreamTlsContext
 
@@ -170,6 +170,37 @@ func TestListenersFromSnapshot(t *testing.T) {
 				}, nil)
 			},
 		},
+		{
+			name: "listener-public-unix-domain-socket",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshot(t, func(ns *structs.NodeService) {
+					ns.Port = 0
+					ns.SocketPath = "/tmp/service-mesh/web-sidecar-proxy"
+				}, nil)
+			},
+		},
+		{
+			name: "transparent-proxy-unix-domain-socket-upstream",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshot(t, func(ns *structs.NodeService) {
+					ns.Proxy.Mode = structs.ProxyModeTransparent
+					ns.Proxy.Upstreams[0].LocalBindAddress = ""
+					ns.Proxy.Upstreams[0].LocalBindPort = 0
+					ns.Proxy.Upstreams[0].LocalBindSocketPath = "/tmp/service-mesh/client-1/db"
+					ns.Proxy.Upstreams[0].LocalBindSocketMode = "0640"
+				}, nil)
+			},
+		},
+		{
+			name: "listener-named-pipe",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshot(t, func(ns *structs.NodeService) {
+					ns.Proxy.Upstreams[0].LocalBindAddress = ""
+					ns.Proxy.Upstreams[0].LocalBindPort = 0
+					ns.Proxy.Upstreams[0].LocalBindSocketPath = `\\.\pipe\db`
+				}, nil)
+			},
+		},
 		{
 			name: "listener-max-inbound-connections",
 			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
@@ -178,6 +209,25 @@ func TestListenersFromSnapshot(t *testing.T) {
 				}, nil)
 			},
 		},
+		{
+			name: "listener-inbound-connection-buffer-limit",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshot(t, func(ns *structs.NodeService) {
+					ns.Proxy.Config["protocol"] = "http"
+					ns.Proxy.Config["max_inbound_connections"] = 222
+					ns.Proxy.Config["inbound_connection_buffer_limit_bytes"] = 32768
+				}, nil)
+			},
+		},
+		{
+			name: "listener-max-request-body-bytes",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshot(t, func(ns *structs.NodeService) {
+					ns.Proxy.Config["protocol"] = "http"
+					ns.Proxy.Config["max_request_body_bytes"] = 1048576
+				}, nil)
+			},
+		},
 		{
 			name: "http-public-listener",
 			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
@@ -207,6 +257,79 @@ func TestListenersFromSnapshot(t *testing.T) {
 					})
 			},
 		},
+		{
+			name: "http-public-listener-request-normalization",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				enabled := true
+				return proxycfg.TestConfigSnapshot(t,
+					func(ns *structs.NodeService) {
+						ns.Proxy.Config["protocol"] = "http"
+						// Override some of the mesh-wide options.
+						ns.Proxy.Config["merge_slashes"] = false
+						ns.Proxy.Config["headers_with_underscores_action"] = "DROP_HEADER"
+					},
+					[]proxycfg.UpdateEvent{
+						{
+							CorrelationID: "mesh",
+							Result: &structs.ConfigEntryResponse{
+								Entry: &structs.MeshConfigEntry{
+									HTTP: &structs.MeshHTTPConfig{
+										Incoming: &structs.MeshDirectionalHTTPConfig{
+											RequestNormalization: &structs.RequestNormalizationConfig{
+												NormalizePath:                &enabled,
+												MergeSlashes:                 &enabled,
+												PathWithEscapedSlashesAction: "REJECT_REQUEST",
+												HeadersWithUnderscoresAction: "REJECT_REQUEST",
+												HeaderKeyFormat:              structs.HeaderKeyFormatProperCase,
+											},
+										},
+									},
+								},
+							},
+						},
+					})
+			},
+		},
+		{
+			name: "http-public-listener-observability-headers",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshot(t,
+					func(ns *structs.NodeService) {
+						ns.Proxy.Config["protocol"] = "http"
+						// Override some of the mesh-wide options.
+						ns.Proxy.Config["trace_context_policy"] = "passthrough"
+						ns.Proxy.Config["observability_request_headers"] = map[string]interface{}{
+							"x-team": "payments",
+						}
+					},
+					[]proxycfg.UpdateEvent{testObservabilityHeadersMeshEvent()})
+			},
+		},
+		{
+			name: "http-public-listener-tracing",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshot(t,
+					func(ns *structs.NodeService) {
+						ns.Proxy.Config["protocol"] = "http"
+					},
+					[]proxycfg.UpdateEvent{
+						{
+							CorrelationID: "mesh",
+							Result: &structs.ConfigEntryResponse{
+								Entry: &structs.MeshConfigEntry{
+									Tracing: &structs.MeshTracingConfig{
+										CollectorUpstream:  "db",
+										SamplingPercentage: 10,
+										CustomTags: []structs.MeshTracingCustomTag{
+											{Tag: "tenant", RequestHeader: "x-tenant", DefaultValue: "none"},
+										},
+									},
+								},
+							},
+						},
+					})
+			},
+		},
 		{
 			name: "http-listener-with-timeouts",
 			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
@@ -225,6 +348,15 @@ func TestListenersFromSnapshot(t *testing.T) {
 				}, nil)
 			},
 		},
+		{
+			name: "http-upstream-request-timeout",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshot(t, func(ns *structs.NodeService) {
+					ns.Proxy.Upstreams[0].Config["protocol"] = "http"
+					ns.Proxy.Upstreams[0].Config["request_timeout_ms"] = 2000
+				}, nil)
+			},
+		},
 		{
 			name: "custom-public-listener",
 			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
@@ -470,6 +602,12 @@ func TestListenersFromSnapshot(t *testing.T) {
 				return proxycfg.TestConfigSnapshotMeshGateway(t, "federation-states", nil, nil)
 			},
 		},
+		{
+			name: "mesh-gateway-peer-through-mesh-gateways",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshotMeshGateway(t, "peer-through-mesh-gateways", nil, nil)
+			},
+		},
 		{
 			name: "mesh-gateway-no-services",
 			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
@@ -555,6 +693,25 @@ func TestListenersFromSnapshot(t *testing.T) {
 					"external-sni", nil, nil, nil)
 			},
 		},
+		{
+			name: "ingress-tcp-listener-unix-domain-socket",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshotIngressGateway(t, true, "tcp", "simple", nil,
+					func(entry *structs.IngressGatewayConfigEntry) {
+						entry.Listeners[0].SocketPath = "/tmp/service-mesh/ingress-db"
+						entry.Listeners[0].SocketMode = "0660"
+					}, nil)
+			},
+		},
+		{
+			name: "ingress-http-listener-unix-domain-socket",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshotIngressGateway(t, true, "http", "default", nil,
+					func(entry *structs.IngressGatewayConfigEntry) {
+						entry.Listeners[0].SocketPath = "/tmp/service-mesh/ingress-http"
+					}, nil)
+			},
+		},
 		{
 			name: "ingress-with-tcp-chain-failover-through-remote-gateway",
 			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
@@ -576,12 +733,47 @@ func TestListenersFromSnapshot(t *testing.T) {
 					"splitter-with-resolver-redirect-multidc", nil, nil, nil)
 			},
 		},
+		{
+			name: "ingress-with-chain-and-router-cors",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshotIngressGatewayWithChain(t, "router-cors", nil, nil)
+			},
+		},
+		{
+			name: "ingress-with-chain-and-router-max-request-body-bytes",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshotIngressGatewayWithChain(t, "router-max-request-body-bytes", nil, nil)
+			},
+		},
+		{
+			name:   "ingress-with-chain-and-router-observability-headers",
+			create: testConfigSnapshotIngressObservabilityHeaders,
+		},
 		{
 			name: "terminating-gateway",
 			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
 				return proxycfg.TestConfigSnapshotTerminatingGateway(t, true, nil, nil)
 			},
 		},
+		{
+			name: "terminating-gateway-request-normalization",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshotTerminatingGateway(t, true, nil, []proxycfg.UpdateEvent{
+					{
+						CorrelationID: "service-config:" + structs.NewServiceName("web", nil).String(),
+						Result: &structs.ServiceConfigResponse{
+							ProxyConfig: map[string]interface{}{
+								"protocol":          "http",
+								"merge_slashes":     true,
+								"header_key_format": "proper_case",
+								// Invalid overrides are ignored.
+								"headers_with_underscores_action": "DROP",
+							},
+						},
+					},
+				})
+			},
+		},
 		{
 			name: "terminating-gateway-with-tls-incoming-min-version",
 			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
@@ -788,6 +980,24 @@ func TestListenersFromSnapshot(t *testing.T) {
 			name:   "transparent-proxy-http-upstream",
 			create: proxycfg.TestConfigSnapshotTransparentProxyHTTPUpstream,
 		},
+		{
+			name:   "transparent-proxy-on-demand",
+			create: proxycfg.TestConfigSnapshotTransparentProxyOnDemand,
+		},
+		{
+			name:   "transparent-proxy-egress-dynamic-forward-proxy",
+			create: proxycfg.TestConfigSnapshotTransparentProxyEgressDynamicForwardProxy,
+		},
+		{
+			name: "egress-gateway",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshotEgressGateway(t, nil, nil, nil)
+			},
+		},
+		{
+			name:   "egress-gateway-reputation-check",
+			create: proxycfg.TestConfigSnapshotEgressGatewayReputationCheck,
+		},
 		{
 			name:   "transparent-proxy-catalog-destinations-only",
 			create: proxycfg.TestConfigSnapshotTransparentProxyCatalogDestinationsOnly,
@@ -1140,3 +1350,38 @@ func TestResolveListenerSDSConfig(t *testing.T) {
 	}
 
 }
+
+// testObservabilityHeadersMeshEvent returns the update of a mesh config entry
+// with observability headers enabled.
+func testObservabilityHeadersMeshEvent() proxycfg.UpdateEvent {
+	enabled := true
+	return proxycfg.UpdateEvent{
+		CorrelationID: "mesh",
+		Result: &structs.ConfigEntryResponse{
+			Entry: &structs.MeshConfigEntry{
+				HTTP: &structs.MeshHTTPConfig{
+					Incoming: &structs.MeshDirectionalHTTPConfig{
+						ObservabilityHeaders: &structs.ObservabilityHeadersConfig{
+							PreserveRequestID:  &enabled,
+							TraceContextPolicy: structs.TraceContextPolicyStrip,
+							RequestHeaders: map[string]string{
+								"x-consul-source": "${service}.${namespace}.${partition}.${datacenter}",
+								"x-consul-node":   "${node}",
+								"x-ratio":         "100%",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func testConfigSnapshotIngressObservabilityHeaders(t testinf.T) *proxycfg.ConfigSnapshot {
+	return proxycfg.TestConfigSnapshotIngressGateway(t, true, "http", "chain-and-router",
+		func(ns *structs.NodeService) {
+			ns.Proxy.Config = map[string]interface{}{
+				"generate_request_id": false,
+			}
+		}, nil, []proxycfg.UpdateEvent{testObservabilityHeadersMeshEvent()})
+}
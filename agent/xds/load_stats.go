@@ -0,0 +1,284 @@
+package xds
+
+import (
+	"sync/atomic"
+	"time"
+
+	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	envoy_load_stats_v3 "github.com/envoyproxy/go-control-plane/envoy/service/load_stats/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/hashicorp/consul/agent/grpc/public"
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+const (
+	// minLoadReportingInterval and maxLoadReportingInterval bound how often
+	// Envoy reports the load of its clusters.
+	minLoadReportingInterval = 10 * time.Second
+	maxLoadReportingInterval = 5 * time.Minute
+
+	// builtinMetricsReportingInterval is how often the proxies with builtin
+	// metrics enabled report the load of their clusters.
+	builtinMetricsReportingInterval = minLoadReportingInterval
+)
+
+// LoadStatsStream is a shorter way of referring to this thing...
+type LoadStatsStream = envoy_load_stats_v3.LoadReportingService_StreamLoadStatsServer
+
+// StreamLoadStats implements envoy_load_stats_v3.LoadReportingServiceServer.
+// The load reported by Envoy is used to record the activity of the upstreams
+// of transparent proxies in on-demand mode, so that the upstreams which saw
+// no traffic for a while can be pruned from their snapshot, and to record the
+// requests to the upstreams of proxies with builtin metrics enabled.
+func (s *Server) StreamLoadStats(stream LoadStatsStream) error {
+	// a channel for receiving incoming requests
+	reqCh := make(chan *envoy_load_stats_v3.LoadStatsRequest)
+	reqStop := int32(0)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if atomic.LoadInt32(&reqStop) != 0 {
+				return
+			}
+			if err != nil {
+				close(reqCh)
+				return
+			}
+			reqCh <- req
+		}
+	}()
+
+	err := s.processLoadStats(stream, reqCh)
+	if err != nil {
+		s.Logger.Error("Error handling load stats stream", "error", err)
+	}
+
+	// prevents writing to a closed channel if send failed on blocked recv
+	atomic.StoreInt32(&reqStop, 1)
+
+	return err
+}
+
+func (s *Server) processLoadStats(stream LoadStatsStream, reqCh <-chan *envoy_load_stats_v3.LoadStatsRequest) error {
+	var req *envoy_load_stats_v3.LoadStatsRequest
+	select {
+	case <-stream.Context().Done():
+		return nil
+	case r, ok := <-reqCh:
+		if !ok {
+			return nil
+		}
+		req = r
+	}
+
+	node := req.GetNode()
+	if node == nil {
+		return status.Errorf(codes.InvalidArgument, "missing node in initial load stats request")
+	}
+
+	nodeName := node.GetMetadata().GetFields()["node_name"].GetStringValue()
+	if nodeName == "" {
+		nodeName = s.NodeName
+	}
+	proxyID := structs.NewServiceID(node.Id, parseEnterpriseMeta(node))
+
+	stateCh, watchCancel, err := s.CfgSrc.Watch(proxyID, nodeName, public.TokenFromContext(stream.Context()))
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to watch proxy service: %s", err)
+	}
+	defer watchCancel()
+
+	var (
+		cfgSnap  *proxycfg.ConfigSnapshot
+		interval time.Duration
+	)
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case r, ok := <-reqCh:
+			if !ok {
+				// reqCh is closed when stream.Recv errors which is how we detect client
+				// going away.
+				return nil
+			}
+			if cfgSnap == nil {
+				// Nothing to record until we get the initial config.
+				continue
+			}
+			// Every report is recorded, with or without activity, as the
+			// idle upstreams are only pruned while the proxy reports its load.
+			if loadReportingInterval(cfgSnap) > 0 {
+				s.CfgSrc.ReportUpstreamsActivity(cfgSnap.ProxyID, activeUpstreams(cfgSnap, r.ClusterStats)...)
+			}
+			if s.MetricsRecorder != nil && builtinMetricsEnabled(cfgSnap) {
+				if upstreams := upstreamRequests(cfgSnap, r.ClusterStats); len(upstreams) > 0 {
+					source := structs.NewServiceName(cfgSnap.Proxy.DestinationServiceName, &cfgSnap.ProxyID.EnterpriseMeta)
+					s.MetricsRecorder.RecordServiceMetrics(source, public.TokenFromContext(stream.Context()), upstreams)
+				}
+			}
+		case snap, ok := <-stateCh:
+			if !ok {
+				return status.Errorf(codes.Aborted, "load stats stream terminated due to config change")
+			}
+			if err := s.authorize(stream.Context(), snap); err != nil {
+				return err
+			}
+			cfgSnap = snap
+
+			// Ask Envoy to report the load of all its clusters often enough for
+			// active upstreams not to expire, or to stop reporting altogether.
+			resp := &envoy_load_stats_v3.LoadStatsResponse{}
+			newInterval := s.reportingInterval(cfgSnap)
+			if newInterval == interval {
+				continue
+			}
+			if newInterval > 0 {
+				resp.SendAllClusters = true
+				resp.LoadReportingInterval = durationpb.New(newInterval)
+			} else {
+				resp.LoadReportingInterval = durationpb.New(maxLoadReportingInterval)
+			}
+			if err := stream.Send(resp); err != nil {
+				return status.Errorf(codes.Unavailable, "failed to send load stats response: %v", err)
+			}
+			interval = newInterval
+		}
+	}
+}
+
+// loadReportingInterval returns how often the proxy should report the load
+// of its clusters, or zero when its upstreams never expire.
+func loadReportingInterval(cfgSnap *proxycfg.ConfigSnapshot) time.Duration {
+	if cfgSnap.Kind != structs.ServiceKindConnectProxy || !cfgSnap.ConnectProxy.OnDemandUpstreams {
+		return 0
+	}
+	ttl := cfgSnap.ConnectProxy.OnDemandUpstreamsTTL
+	if ttl <= 0 {
+		return 0
+	}
+
+	interval := ttl / 4
+	if interval < minLoadReportingInterval {
+		interval = minLoadReportingInterval
+	}
+	if interval > maxLoadReportingInterval {
+		interval = maxLoadReportingInterval
+	}
+	return interval
+}
+
+// reportingInterval returns how often the proxy should report the load of
+// its clusters, accounting for builtin metrics, or zero when it shouldn't.
+func (s *Server) reportingInterval(cfgSnap *proxycfg.ConfigSnapshot) time.Duration {
+	interval := loadReportingInterval(cfgSnap)
+	if s.MetricsRecorder == nil || !builtinMetricsEnabled(cfgSnap) {
+		return interval
+	}
+	if interval == 0 || interval > builtinMetricsReportingInterval {
+		interval = builtinMetricsReportingInterval
+	}
+	return interval
+}
+
+// builtinMetricsEnabled returns whether the proxy reports the requests to
+// its upstreams for the builtin metrics.
+func builtinMetricsEnabled(cfgSnap *proxycfg.ConfigSnapshot) bool {
+	if cfgSnap.Kind != structs.ServiceKindConnectProxy {
+		return false
+	}
+	cfg, err := ParseProxyConfig(cfgSnap.Proxy.Config)
+	return err == nil && cfg.BuiltinMetrics
+}
+
+// activeUpstreams returns the demanded upstreams of a proxy in on-demand mode
+// which had traffic on any of their clusters according to the given stats.
+func activeUpstreams(cfgSnap *proxycfg.ConfigSnapshot, stats []*envoy_endpoint_v3.ClusterStats) []proxycfg.UpstreamID {
+	if loadReportingInterval(cfgSnap) == 0 || len(stats) == 0 {
+		return nil
+	}
+
+	byClusterName := make(map[string]proxycfg.UpstreamID)
+	for uid := range cfgSnap.ConnectProxy.DemandedUpstreams {
+		chain := cfgSnap.ConnectProxy.DiscoveryChain[uid]
+		if chain == nil {
+			continue
+		}
+		for _, target := range chain.Targets {
+			byClusterName[CustomizeClusterName(target.Name, chain)] = uid
+		}
+	}
+
+	seen := make(map[proxycfg.UpstreamID]struct{})
+	var uids []proxycfg.UpstreamID
+	for _, cs := range stats {
+		uid, ok := byClusterName[cs.ClusterName]
+		if !ok || !hasLoad(cs) {
+			continue
+		}
+		if _, ok := seen[uid]; ok {
+			continue
+		}
+		seen[uid] = struct{}{}
+		uids = append(uids, uid)
+	}
+	return uids
+}
+
+func hasLoad(cs *envoy_endpoint_v3.ClusterStats) bool {
+	if cs.TotalDroppedRequests > 0 {
+		return true
+	}
+	for _, ls := range cs.UpstreamLocalityStats {
+		if ls.TotalIssuedRequests > 0 ||
+			ls.TotalRequestsInProgress > 0 ||
+			ls.TotalSuccessfulRequests > 0 ||
+			ls.TotalErrorRequests > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// upstreamRequests returns the requests of a connect proxy to each of its
+// upstream services according to the given stats. Requests dropped by the
+// proxy are counted as errors.
+func upstreamRequests(cfgSnap *proxycfg.ConfigSnapshot, stats []*envoy_endpoint_v3.ClusterStats) map[structs.ServiceName]structs.ServiceMetricsCounters {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	byClusterName := make(map[string]structs.ServiceName)
+	for _, chain := range cfgSnap.ConnectProxy.DiscoveryChain {
+		for _, target := range chain.Targets {
+			byClusterName[CustomizeClusterName(target.Name, chain)] = structs.NewServiceName(target.Service, target.GetEnterpriseMetadata())
+		}
+	}
+
+	upstreams := make(map[structs.ServiceName]structs.ServiceMetricsCounters)
+	for _, cs := range stats {
+		sn, ok := byClusterName[cs.ClusterName]
+		if !ok {
+			continue
+		}
+		counters := structs.ServiceMetricsCounters{
+			Requests: cs.TotalDroppedRequests,
+			Errors:   cs.TotalDroppedRequests,
+		}
+		for _, ls := range cs.UpstreamLocalityStats {
+			counters.Requests += ls.TotalSuccessfulRequests + ls.TotalErrorRequests
+			counters.Errors += ls.TotalErrorRequests
+		}
+		if counters.Requests == 0 {
+			continue
+		}
+		total := upstreams[sn]
+		total.Add(counters)
+		upstreams[sn] = total
+	}
+	return upstreams
+}
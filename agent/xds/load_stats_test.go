@@ -0,0 +1,109 @@
+package xds
+
+import (
+	"testing"
+	"time"
+
+	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestLoadReportingInterval(t *testing.T) {
+	snap := proxycfg.TestConfigSnapshotTransparentProxyOnDemand(t)
+	require.Equal(t, time.Duration(0), loadReportingInterval(snap))
+
+	snap.ConnectProxy.OnDemandUpstreamsTTL = time.Minute
+	require.Equal(t, 15*time.Second, loadReportingInterval(snap))
+
+	snap.ConnectProxy.OnDemandUpstreamsTTL = time.Hour
+	require.Equal(t, maxLoadReportingInterval, loadReportingInterval(snap))
+
+	snap.ConnectProxy.OnDemandUpstreams = false
+	require.Equal(t, time.Duration(0), loadReportingInterval(snap))
+}
+
+func TestActiveUpstreams(t *testing.T) {
+	snap := proxycfg.TestConfigSnapshotTransparentProxyOnDemand(t)
+	snap.ConnectProxy.OnDemandUpstreamsTTL = time.Hour
+
+	google := proxycfg.NewUpstreamIDFromServiceName(structs.NewServiceName("google", nil))
+	googleCluster := "google.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul"
+
+	stats := func(name string, issued uint64) *envoy_endpoint_v3.ClusterStats {
+		return &envoy_endpoint_v3.ClusterStats{
+			ClusterName: name,
+			UpstreamLocalityStats: []*envoy_endpoint_v3.UpstreamLocalityStats{
+				{TotalIssuedRequests: issued},
+			},
+		}
+	}
+
+	t.Run("no traffic", func(t *testing.T) {
+		require.Empty(t, activeUpstreams(snap, []*envoy_endpoint_v3.ClusterStats{stats(googleCluster, 0)}))
+	})
+
+	t.Run("traffic", func(t *testing.T) {
+		uids := activeUpstreams(snap, []*envoy_endpoint_v3.ClusterStats{
+			stats(googleCluster, 3),
+			stats(googleCluster, 1),
+			stats("local_app", 5),
+			stats(OriginalDestinationClusterName, 5),
+		})
+		require.Equal(t, []proxycfg.UpstreamID{google}, uids)
+	})
+
+	t.Run("without ttl", func(t *testing.T) {
+		snap.ConnectProxy.OnDemandUpstreamsTTL = 0
+		require.Empty(t, activeUpstreams(snap, []*envoy_endpoint_v3.ClusterStats{stats(googleCluster, 3)}))
+	})
+}
+
+func TestReportingInterval_BuiltinMetrics(t *testing.T) {
+	snap := proxycfg.TestConfigSnapshotTransparentProxyOnDemand(t)
+	s := &Server{}
+	require.Equal(t, time.Duration(0), s.reportingInterval(snap))
+
+	snap.Proxy.Config = map[string]interface{}{"builtin_metrics": true}
+	require.Equal(t, time.Duration(0), s.reportingInterval(snap), "no recorder")
+
+	s.MetricsRecorder = &testServiceMetricsRecorder{}
+	require.Equal(t, builtinMetricsReportingInterval, s.reportingInterval(snap))
+
+	snap.ConnectProxy.OnDemandUpstreamsTTL = time.Hour
+	require.Equal(t, builtinMetricsReportingInterval, s.reportingInterval(snap))
+}
+
+func TestUpstreamRequests(t *testing.T) {
+	snap := proxycfg.TestConfigSnapshotTransparentProxyOnDemand(t)
+	googleCluster := "google.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul"
+
+	stats := []*envoy_endpoint_v3.ClusterStats{
+		{
+			ClusterName:          googleCluster,
+			TotalDroppedRequests: 1,
+			UpstreamLocalityStats: []*envoy_endpoint_v3.UpstreamLocalityStats{
+				{TotalSuccessfulRequests: 8, TotalErrorRequests: 2, TotalIssuedRequests: 12},
+				{TotalSuccessfulRequests: 4},
+			},
+		},
+		{
+			ClusterName: "local_app",
+			UpstreamLocalityStats: []*envoy_endpoint_v3.UpstreamLocalityStats{
+				{TotalSuccessfulRequests: 5},
+			},
+		},
+	}
+	require.Equal(t, map[structs.ServiceName]structs.ServiceMetricsCounters{
+		structs.NewServiceName("google", nil): {Requests: 15, Errors: 3},
+	}, upstreamRequests(snap, stats))
+
+	require.Empty(t, upstreamRequests(snap, nil))
+}
+
+type testServiceMetricsRecorder struct{}
+
+func (r *testServiceMetricsRecorder) RecordServiceMetrics(structs.ServiceName, string, map[structs.ServiceName]structs.ServiceMetricsCounters) {
+}
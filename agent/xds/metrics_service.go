@@ -0,0 +1,235 @@
+package xds
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	envoy_metrics_v3 "github.com/envoyproxy/go-control-plane/envoy/service/metrics/v3"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/consul/agent/grpc/public"
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// MetricsStream is a shorter way of referring to this thing...
+type MetricsStream = envoy_metrics_v3.MetricsService_StreamMetricsServer
+
+// StreamMetrics implements envoy_metrics_v3.MetricsServiceServer. Proxies
+// with builtin metrics enabled flush their stats to it, from which the time
+// taken by the requests to their upstreams is recorded. The load stats don't
+// carry durations.
+func (s *Server) StreamMetrics(stream MetricsStream) error {
+	// a channel for receiving incoming messages
+	msgCh := make(chan *envoy_metrics_v3.StreamMetricsMessage)
+	msgStop := int32(0)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if atomic.LoadInt32(&msgStop) != 0 {
+				return
+			}
+			if err != nil {
+				close(msgCh)
+				return
+			}
+			msgCh <- msg
+		}
+	}()
+
+	err := s.processMetrics(stream, msgCh)
+	if err != nil {
+		s.Logger.Error("Error handling metrics stream", "error", err)
+	}
+
+	// prevents writing to a closed channel if send failed on blocked recv
+	atomic.StoreInt32(&msgStop, 1)
+
+	return err
+}
+
+func (s *Server) processMetrics(stream MetricsStream, msgCh <-chan *envoy_metrics_v3.StreamMetricsMessage) error {
+	var msg *envoy_metrics_v3.StreamMetricsMessage
+	select {
+	case <-stream.Context().Done():
+		return nil
+	case m, ok := <-msgCh:
+		if !ok {
+			return nil
+		}
+		msg = m
+	}
+
+	// Envoy only identifies itself in the first message of the stream.
+	node := msg.GetIdentifier().GetNode()
+	if node == nil {
+		return status.Errorf(codes.InvalidArgument, "missing node in initial metrics message")
+	}
+
+	nodeName := node.GetMetadata().GetFields()["node_name"].GetStringValue()
+	if nodeName == "" {
+		nodeName = s.NodeName
+	}
+	proxyID := structs.NewServiceID(node.Id, parseEnterpriseMeta(node))
+
+	token := public.TokenFromContext(stream.Context())
+	stateCh, watchCancel, err := s.CfgSrc.Watch(proxyID, nodeName, token)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to watch proxy service: %s", err)
+	}
+	defer watchCancel()
+
+	var cfgSnap *proxycfg.ConfigSnapshot
+	// The request times are cumulative, so the durations are recorded as the
+	// difference with the previous message. The first message of the stream
+	// only sets the baseline.
+	var last map[string]requestTimes
+	// The protocols already recorded, to only record the changes.
+	recorded := make(map[structs.ServiceName]string)
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case m, ok := <-msgCh:
+			if !ok {
+				// msgCh is closed when stream.Recv errors which is how we detect client
+				// going away.
+				return nil
+			}
+			msg = m
+		case snap, ok := <-stateCh:
+			if !ok {
+				return status.Errorf(codes.Aborted, "metrics stream terminated due to config change")
+			}
+			if err := s.authorize(stream.Context(), snap); err != nil {
+				return err
+			}
+			cfgSnap = snap
+			continue
+		}
+
+		if cfgSnap == nil {
+			// Nothing to record until we get the initial config.
+			continue
+		}
+
+		s.recordDetectedProtocols(cfgSnap, msg.GetEnvoyMetrics(), recorded)
+
+		if s.MetricsRecorder == nil || !builtinMetricsEnabled(cfgSnap) {
+			continue
+		}
+
+		current := clusterRequestTimes(msg.GetEnvoyMetrics())
+		if last != nil {
+			if upstreams := upstreamDurations(cfgSnap, last, current); len(upstreams) > 0 {
+				source := structs.NewServiceName(cfgSnap.Proxy.DestinationServiceName, &cfgSnap.ProxyID.EnterpriseMeta)
+				s.MetricsRecorder.RecordServiceMetrics(source, token, upstreams)
+			}
+		}
+		last = current
+	}
+}
+
+// recordDetectedProtocols records the protocols the proxy detected on the
+// traffic to its upstreams that changed since they were last recorded.
+func (s *Server) recordDetectedProtocols(cfgSnap *proxycfg.ConfigSnapshot, families []*dto.MetricFamily, recorded map[structs.ServiceName]string) {
+	// Only the proxies registered with the local agent can be updated.
+	if s.ProtocolRecorder == nil || cfgSnap.Kind != structs.ServiceKindConnectProxy || cfgSnap.ProxyID.NodeName != s.NodeName {
+		return
+	}
+	for sn, protocol := range detectedUpstreamProtocols(cfgSnap, families) {
+		if recorded[sn] == protocol {
+			continue
+		}
+		if err := s.ProtocolRecorder.SetUpstreamDetectedProtocol(cfgSnap.ProxyID.ServiceID, sn, protocol); err != nil {
+			s.Logger.Warn("failed to record detected upstream protocol",
+				"proxy", cfgSnap.ProxyID.String(), "upstream", sn.String(), "error", err)
+			continue
+		}
+		recorded[sn] = protocol
+	}
+}
+
+// requestTimes are the cumulative statistics of the upstream_rq_time
+// histogram of a cluster.
+type requestTimes struct {
+	// sum is in milliseconds, like the histogram.
+	sum   float64
+	count uint64
+}
+
+// clusterRequestTimes returns the request times of each cluster in the stats
+// flushed by Envoy, which reports histograms as summaries or histograms
+// depending on its version.
+func clusterRequestTimes(families []*dto.MetricFamily) map[string]requestTimes {
+	const (
+		prefix = "cluster."
+		suffix = ".upstream_rq_time"
+	)
+
+	times := make(map[string]requestTimes)
+	for _, family := range families {
+		name := family.GetName()
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) || len(name) <= len(prefix)+len(suffix) {
+			continue
+		}
+		cluster := name[len(prefix) : len(name)-len(suffix)]
+		for _, metric := range family.GetMetric() {
+			switch {
+			case metric.GetSummary() != nil:
+				times[cluster] = requestTimes{
+					sum:   metric.GetSummary().GetSampleSum(),
+					count: metric.GetSummary().GetSampleCount(),
+				}
+			case metric.GetHistogram() != nil:
+				times[cluster] = requestTimes{
+					sum:   metric.GetHistogram().GetSampleSum(),
+					count: metric.GetHistogram().GetSampleCount(),
+				}
+			}
+		}
+	}
+	return times
+}
+
+// upstreamDurations returns the time taken by the requests of a connect proxy
+// to each of its upstream services between two flushes of its stats.
+func upstreamDurations(cfgSnap *proxycfg.ConfigSnapshot, last, current map[string]requestTimes) map[structs.ServiceName]structs.ServiceMetricsCounters {
+	if len(current) == 0 {
+		return nil
+	}
+
+	byClusterName := make(map[string]structs.ServiceName)
+	for _, chain := range cfgSnap.ConnectProxy.DiscoveryChain {
+		for _, target := range chain.Targets {
+			byClusterName[CustomizeClusterName(target.Name, chain)] = structs.NewServiceName(target.Service, target.GetEnterpriseMetadata())
+		}
+	}
+
+	upstreams := make(map[structs.ServiceName]structs.ServiceMetricsCounters)
+	for cluster, times := range current {
+		sn, ok := byClusterName[cluster]
+		if !ok {
+			continue
+		}
+		// The stats start over when Envoy restarts or the cluster is
+		// recreated.
+		if prev, ok := last[cluster]; ok && prev.count <= times.count {
+			times.count -= prev.count
+			times.sum -= prev.sum
+		}
+		if times.count == 0 || times.sum < 0 {
+			continue
+		}
+		total := upstreams[sn]
+		total.Add(structs.ServiceMetricsCounters{
+			Duration:        time.Duration(times.sum * float64(time.Millisecond)),
+			DurationSamples: times.count,
+		})
+		upstreams[sn] = total
+	}
+	return upstreams
+}
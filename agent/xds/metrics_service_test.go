@@ -0,0 +1,81 @@
+package xds
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestClusterRequestTimes(t *testing.T) {
+	googleCluster := "google.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul"
+
+	families := []*dto.MetricFamily{
+		{
+			Name: proto.String("cluster." + googleCluster + ".upstream_rq_time"),
+			Type: dto.MetricType_SUMMARY.Enum(),
+			Metric: []*dto.Metric{{
+				Summary: &dto.Summary{SampleCount: proto.Uint64(4), SampleSum: proto.Float64(100)},
+			}},
+		},
+		{
+			Name: proto.String("cluster.local_app.upstream_rq_time"),
+			Type: dto.MetricType_HISTOGRAM.Enum(),
+			Metric: []*dto.Metric{{
+				Histogram: &dto.Histogram{SampleCount: proto.Uint64(2), SampleSum: proto.Float64(3)},
+			}},
+		},
+		{
+			Name: proto.String("cluster." + googleCluster + ".upstream_rq_total"),
+			Type: dto.MetricType_COUNTER.Enum(),
+			Metric: []*dto.Metric{{
+				Counter: &dto.Counter{Value: proto.Float64(4)},
+			}},
+		},
+		{
+			Name: proto.String("cluster..upstream_rq_time"),
+		},
+	}
+	require.Equal(t, map[string]requestTimes{
+		googleCluster: {sum: 100, count: 4},
+		"local_app":   {sum: 3, count: 2},
+	}, clusterRequestTimes(families))
+}
+
+func TestUpstreamDurations(t *testing.T) {
+	snap := proxycfg.TestConfigSnapshotTransparentProxyOnDemand(t)
+	googleCluster := "google.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul"
+	google := structs.NewServiceName("google", nil)
+
+	last := map[string]requestTimes{
+		googleCluster: {sum: 100, count: 4},
+	}
+
+	t.Run("delta", func(t *testing.T) {
+		current := map[string]requestTimes{
+			googleCluster: {sum: 160, count: 6},
+			"local_app":   {sum: 3, count: 2},
+		}
+		require.Equal(t, map[structs.ServiceName]structs.ServiceMetricsCounters{
+			google: {Duration: 60 * time.Millisecond, DurationSamples: 2},
+		}, upstreamDurations(snap, last, current))
+	})
+
+	t.Run("no new requests", func(t *testing.T) {
+		require.Empty(t, upstreamDurations(snap, last, last))
+	})
+
+	t.Run("stats reset", func(t *testing.T) {
+		current := map[string]requestTimes{
+			googleCluster: {sum: 10, count: 1},
+		}
+		require.Equal(t, map[structs.ServiceName]structs.ServiceMetricsCounters{
+			google: {Duration: 10 * time.Millisecond, DurationSamples: 1},
+		}, upstreamDurations(snap, last, current))
+	})
+}
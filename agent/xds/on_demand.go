@@ -0,0 +1,239 @@
+package xds
+
+import (
+	"sort"
+
+	udpa_type_v1 "github.com/cncf/xds/go/udpa/type/v1"
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_http_router_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
+	envoy_http_inspector_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/listener/http_inspector/v3"
+	envoy_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/hashicorp/consul/agent/connect"
+	"github.com/hashicorp/consul/agent/proxycfg"
+)
+
+const (
+	// onDemandFilterName is the name of the filter chain and route of the
+	// outbound listener sending HTTP requests to the upstreams which haven't
+	// been demanded yet.
+	onDemandFilterName = "on_demand"
+
+	// onDemandTypeURL is the type of the configuration of Envoy's on_demand
+	// HTTP filter. The version of go-control-plane we use doesn't know about
+	// its on-demand cluster discovery (ODCDS) settings so the configuration is
+	// sent as a TypedStruct.
+	onDemandTypeURL = "type.googleapis.com/envoy.extensions.filters.http.on_demand.v3.OnDemand"
+
+	// onDemandClusterTimeout is how long Envoy waits for a demanded cluster
+	// before failing the request.
+	onDemandClusterTimeout = "5s"
+)
+
+// onDemandUpstreams returns the upstreams inferred from intentions of a proxy
+// in on-demand mode, sorted by ID.
+func onDemandUpstreams(cfgSnap *proxycfg.ConfigSnapshot) []proxycfg.UpstreamID {
+	if !cfgSnap.ConnectProxy.OnDemandUpstreams {
+		return nil
+	}
+
+	uids := make([]proxycfg.UpstreamID, 0, len(cfgSnap.ConnectProxy.IntentionUpstreams))
+	for uid := range cfgSnap.ConnectProxy.IntentionUpstreams {
+		uids = append(uids, uid)
+	}
+	sort.Slice(uids, func(i, j int) bool {
+		return uids[i].String() < uids[j].String()
+	})
+	return uids
+}
+
+// onDemandClusterName returns the name of the cluster of the target of an
+// upstream, which Envoy requests when the upstream is demanded.
+//
+// The discovery chain of an upstream is only known once it is watched, so
+// until then the name of the cluster of its default target is used. Once the
+// chain is known, the requests are routed to the cluster of its actual target
+// instead, such as when a service-resolver redirects the upstream.
+func onDemandClusterName(cfgSnap *proxycfg.ConfigSnapshot, uid proxycfg.UpstreamID) string {
+	if chain := cfgSnap.ConnectProxy.DiscoveryChain[uid]; chain != nil {
+		if target, err := simpleChainTarget(chain); err == nil && target != nil {
+			return CustomizeClusterName(target.Name, chain)
+		}
+	}
+	return connect.ServiceSNI(uid.Name, "", uid.NamespaceOrDefault(), uid.PartitionOrDefault(), cfgSnap.Datacenter, cfgSnap.Roots.TrustDomain)
+}
+
+// demandedUpstreams returns the upstreams of a proxy in on-demand mode whose
+// clusters are requested by Envoy for the first time.
+func demandedUpstreams(cfgSnap *proxycfg.ConfigSnapshot, clusterNames []string) []proxycfg.UpstreamID {
+	if len(clusterNames) == 0 || cfgSnap.Roots == nil {
+		return nil
+	}
+
+	byClusterName := make(map[string]proxycfg.UpstreamID)
+	for _, uid := range onDemandUpstreams(cfgSnap) {
+		if _, ok := cfgSnap.ConnectProxy.DemandedUpstreams[uid]; ok {
+			continue
+		}
+		byClusterName[onDemandClusterName(cfgSnap, uid)] = uid
+	}
+
+	var uids []proxycfg.UpstreamID
+	for _, name := range clusterNames {
+		if uid, ok := byClusterName[name]; ok {
+			uids = append(uids, uid)
+		}
+	}
+	return uids
+}
+
+// makeOnDemandFilterChain returns the filter chain of the outbound listener
+// routing the HTTP requests to the upstreams of a proxy in on-demand mode by
+// their host. The clusters of the upstreams which weren't demanded yet are
+// requested from Consul with on-demand cluster discovery, which makes the
+// proxy watch them. Once an upstream is watched its virtual IPs get their own
+// filter chain like for any other upstream.
+//
+// Requests to the given egress hostnames are sent to the dynamic forward
+// proxy, which resolves their host. Requests to other hosts are sent to their
+// original destination, unless the proxy can only reach destinations in the
+// mesh.
+func (s *ResourceGenerator) makeOnDemandFilterChain(cfgSnap *proxycfg.ConfigSnapshot, uids []proxycfg.UpstreamID, egressHostnames []string, passthrough bool) (*envoy_listener_v3.FilterChain, error) {
+	name := onDemandFilterName
+	if len(uids) == 0 {
+		name = egressFilterName
+	}
+	routeConfig := &envoy_route_v3.RouteConfiguration{Name: name}
+
+	seen := make(map[string]struct{})
+	for _, uid := range uids {
+		// Envoy rejects domains matched by several virtual hosts, so services
+		// with the same name in other namespaces are only reachable through
+		// their virtual IPs once they have been demanded.
+		if _, ok := seen[uid.Name]; ok {
+			continue
+		}
+		seen[uid.Name] = struct{}{}
+
+		routeConfig.VirtualHosts = append(routeConfig.VirtualHosts, &envoy_route_v3.VirtualHost{
+			Name:    uid.EnvoyID(),
+			Domains: []string{uid.Name, uid.Name + ".*"},
+			Routes:  []*envoy_route_v3.Route{makeOnDemandRoute(onDemandClusterName(cfgSnap, uid))},
+		})
+	}
+	var egressDomains []string
+	for _, hostname := range egressHostnames {
+		if _, ok := seen[hostname]; ok {
+			continue
+		}
+		seen[hostname] = struct{}{}
+		egressDomains = append(egressDomains, hostname)
+	}
+	if len(egressDomains) > 0 {
+		routeConfig.VirtualHosts = append(routeConfig.VirtualHosts, &envoy_route_v3.VirtualHost{
+			Name:    DynamicForwardProxyClusterName,
+			Domains: egressDomains,
+			Routes:  []*envoy_route_v3.Route{makeOnDemandRoute(DynamicForwardProxyClusterName)},
+		})
+	}
+	if passthrough {
+		routeConfig.VirtualHosts = append(routeConfig.VirtualHosts, &envoy_route_v3.VirtualHost{
+			Name:    OriginalDestinationClusterName,
+			Domains: []string{"*"},
+			Routes:  []*envoy_route_v3.Route{makeOnDemandRoute(OriginalDestinationClusterName)},
+		})
+	}
+
+	var httpFilters []*envoy_http_v3.HttpFilter
+	if len(uids) > 0 {
+		onDemand, err := makeOnDemandHTTPFilter()
+		if err != nil {
+			return nil, err
+		}
+		httpFilters = append(httpFilters, onDemand)
+	}
+	if len(egressDomains) > 0 {
+		dynamicForwardProxy, err := makeDynamicForwardProxyHTTPFilter()
+		if err != nil {
+			return nil, err
+		}
+		httpFilters = append(httpFilters, dynamicForwardProxy)
+	}
+	router, err := makeEnvoyHTTPFilter("envoy.filters.http.router", &envoy_http_router_v3.Router{})
+	if err != nil {
+		return nil, err
+	}
+	httpFilters = append(httpFilters, router)
+
+	filter, err := makeFilter("envoy.filters.network.http_connection_manager", &envoy_http_v3.HttpConnectionManager{
+		StatPrefix:  makeStatPrefix("upstream.", name),
+		CodecType:   envoy_http_v3.HttpConnectionManager_AUTO,
+		HttpFilters: httpFilters,
+		RouteSpecifier: &envoy_http_v3.HttpConnectionManager_RouteConfig{
+			RouteConfig: routeConfig,
+		},
+		// The virtual hosts match the host of the upstreams on any port.
+		StripPortMode: &envoy_http_v3.HttpConnectionManager_StripAnyHostPort{
+			StripAnyHostPort: true,
+		},
+		Tracing: &envoy_http_v3.HttpConnectionManager_Tracing{
+			// Don't trace any requests by default unless the client application
+			// explicitly propagates trace headers that indicate this should be
+			// sampled.
+			RandomSampling: &envoy_type_v3.Percent{Value: 0.0},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &envoy_listener_v3.FilterChain{
+		Name: name,
+		// The HTTP inspector detects plain text HTTP connections, the other
+		// connections are handled by the catch-all filter chain.
+		FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+			ApplicationProtocols: []string{"http/1.1", "h2c"},
+		},
+		Filters: []*envoy_listener_v3.Filter{filter},
+	}, nil
+}
+
+func makeOnDemandHTTPFilter() (*envoy_http_v3.HttpFilter, error) {
+	cfg, err := structpb.NewStruct(map[string]interface{}{
+		"odcds": map[string]interface{}{
+			"source": map[string]interface{}{
+				"ads":                  map[string]interface{}{},
+				"resource_api_version": envoy_core_v3.ApiVersion_V3.String(),
+			},
+			"timeout": onDemandClusterTimeout,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return makeEnvoyHTTPFilter("envoy.filters.http.on_demand", &udpa_type_v1.TypedStruct{
+		TypeUrl: onDemandTypeURL,
+		Value:   cfg,
+	})
+}
+
+func makeOnDemandRoute(cluster string) *envoy_route_v3.Route {
+	return &envoy_route_v3.Route{
+		Match: &envoy_route_v3.RouteMatch{
+			PathSpecifier: &envoy_route_v3.RouteMatch_Prefix{Prefix: "/"},
+		},
+		Action: &envoy_route_v3.Route_Route{
+			Route: &envoy_route_v3.RouteAction{
+				ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{Cluster: cluster},
+			},
+		},
+	}
+}
+
+func makeHTTPInspectorListenerFilter() (*envoy_listener_v3.ListenerFilter, error) {
+	return makeEnvoyListenerFilter("envoy.filters.listener.http_inspector", &envoy_http_inspector_v3.HttpInspector{})
+}
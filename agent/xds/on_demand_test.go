@@ -0,0 +1,40 @@
+package xds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/connect"
+	"github.com/hashicorp/consul/agent/consul/discoverychain"
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestOnDemandClusterName(t *testing.T) {
+	snap := proxycfg.TestConfigSnapshotTransparentProxyOnDemand(t)
+
+	googleUID := proxycfg.NewUpstreamIDFromServiceName(structs.NewServiceName("google", nil))
+	kafkaUID := proxycfg.NewUpstreamIDFromServiceName(structs.NewServiceName("kafka", nil))
+
+	// The chain of kafka isn't known until it is demanded.
+	require.Equal(t, "kafka.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul", onDemandClusterName(snap, kafkaUID))
+	require.Equal(t, "google.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul", onDemandClusterName(snap, googleUID))
+
+	// Once known, the cluster of the actual target of the chain is used.
+	snap.ConnectProxy.DiscoveryChain[googleUID] = discoverychain.TestCompileConfigEntries(t, "google", "default", "default", "dc1", connect.TestClusterID+".consul", nil,
+		&structs.ServiceResolverConfigEntry{
+			Kind: structs.ServiceResolver,
+			Name: "google",
+			Redirect: &structs.ServiceResolverRedirect{
+				Datacenter: "dc2",
+			},
+		},
+	)
+	require.Equal(t, "google.default.dc2.internal.11111111-2222-3333-4444-555555555555.consul", onDemandClusterName(snap, googleUID))
+
+	require.Equal(t, []proxycfg.UpstreamID{kafkaUID}, demandedUpstreams(snap, []string{
+		"kafka.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul",
+		"google.default.dc2.internal.11111111-2222-3333-4444-555555555555.consul",
+	}))
+}
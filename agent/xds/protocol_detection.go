@@ -0,0 +1,128 @@
+package xds
+
+import (
+	"strings"
+	"time"
+
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+const (
+	// detectedProtocolStatPrefix prefixes the stats of the filter chains
+	// forwarding the upstream connections whose protocol Envoy detected. It is
+	// followed by the detected protocol and the name of the filter.
+	detectedProtocolStatPrefix = "detected_protocol."
+
+	// detectedProtocolListenerFiltersTimeout bounds how long Envoy's HTTP
+	// inspector waits for the first bytes of a connection. Connections on
+	// which the application waits for the upstream to speak first are
+	// forwarded without detection once it expires.
+	detectedProtocolListenerFiltersTimeout = 500 * time.Millisecond
+)
+
+// detectedProtocolFilterChains are the application protocols Envoy's HTTP
+// inspector sets on the connections it detected as HTTP, and the protocols
+// they are reported as. HTTP/2 and gRPC can't be told apart at this level.
+var detectedProtocolFilterChains = []struct {
+	protocol             string
+	applicationProtocols []string
+}{
+	{protocol: "http", applicationProtocols: []string{"http/1.0", "http/1.1"}},
+	{protocol: "http2", applicationProtocols: []string{"h2c"}},
+}
+
+// upstreamProtocolDetectionEnabled returns whether the upstream listener of a
+// discovery chain detects the protocol of its traffic. The protocol of the
+// upstreams imported from peers is configured by the exporting peer.
+func upstreamProtocolDetectionEnabled(cfgSnap *proxycfg.ConfigSnapshot, uid proxycfg.UpstreamID, chain *structs.CompiledDiscoveryChain) bool {
+	if uid.Peer != "" || chain.Protocol != "tcp" {
+		return false
+	}
+	cfg, err := ParseProxyConfig(cfgSnap.Proxy.Config)
+	return err == nil && cfg.DetectUpstreamProtocols
+}
+
+// injectProtocolDetection adds Envoy's HTTP inspector to the listener of a tcp
+// upstream, along with filter chains matching the connections it detected as
+// HTTP. They forward the connections to the same cluster as the default
+// filter chain, under stats named after the detected protocol.
+func injectProtocolDetection(l *envoy_listener_v3.Listener, clusterName, filterName string) error {
+	httpInspector, err := makeHTTPInspectorListenerFilter()
+	if err != nil {
+		return err
+	}
+	l.ListenerFilters = append(l.ListenerFilters, httpInspector)
+	l.ListenerFiltersTimeout = durationpb.New(detectedProtocolListenerFiltersTimeout)
+	l.ContinueOnListenerFiltersTimeout = true
+
+	for _, fc := range detectedProtocolFilterChains {
+		filter, err := makeTCPProxyFilter(filterName, clusterName, detectedProtocolStatPrefix+fc.protocol+".")
+		if err != nil {
+			return err
+		}
+		l.FilterChains = append(l.FilterChains, &envoy_listener_v3.FilterChain{
+			FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+				ApplicationProtocols: fc.applicationProtocols,
+			},
+			Filters: []*envoy_listener_v3.Filter{filter},
+		})
+	}
+	return nil
+}
+
+// detectedUpstreamProtocols returns the protocols a connect proxy detected on
+// the traffic to its upstreams according to the stats it flushed. Upstreams
+// on which several protocols were detected are left out.
+func detectedUpstreamProtocols(cfgSnap *proxycfg.ConfigSnapshot, families []*dto.MetricFamily) map[structs.ServiceName]string {
+	const (
+		prefix = "tcp." + detectedProtocolStatPrefix
+		suffix = ".downstream_cx_total"
+	)
+
+	byFilterName := make(map[string]structs.ServiceName)
+	for uid, chain := range cfgSnap.ConnectProxy.DiscoveryChain {
+		if uid.Peer != "" {
+			continue
+		}
+		byFilterName[makeStatPrefix("", upstreamFilterName(chain))] = chain.CompoundServiceName()
+	}
+
+	detected := make(map[structs.ServiceName]string)
+	conflicts := make(map[structs.ServiceName]bool)
+	for _, family := range families {
+		name := family.GetName()
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) || len(name) <= len(prefix)+len(suffix) {
+			continue
+		}
+		protocol, filterName, ok := strings.Cut(name[len(prefix):len(name)-len(suffix)], ".")
+		if !ok || !structs.IsDetectableProtocol(protocol) {
+			continue
+		}
+		sn, ok := byFilterName[filterName]
+		if !ok || !hasPositiveCounter(family) {
+			continue
+		}
+		if prev, ok := detected[sn]; ok && prev != protocol {
+			conflicts[sn] = true
+		}
+		detected[sn] = protocol
+	}
+	for sn := range conflicts {
+		delete(detected, sn)
+	}
+	return detected
+}
+
+func hasPositiveCounter(family *dto.MetricFamily) bool {
+	for _, metric := range family.GetMetric() {
+		if metric.GetCounter().GetValue() > 0 {
+			return true
+		}
+	}
+	return false
+}
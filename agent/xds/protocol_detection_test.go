@@ -0,0 +1,77 @@
+package xds
+
+import (
+	"testing"
+
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	"github.com/golang/protobuf/proto"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/sdk/testutil"
+)
+
+func TestListenersFromSnapshot_DetectUpstreamProtocols(t *testing.T) {
+	snap := proxycfg.TestConfigSnapshot(t, func(ns *structs.NodeService) {
+		ns.Proxy.Config["detect_upstream_protocols"] = true
+	}, nil)
+
+	g := newResourceGenerator(testutil.Logger(t), nil, false)
+	g.ProxyFeatures = supportedProxyFeatures{}
+	listeners, err := g.listenersFromSnapshot(snap)
+	require.NoError(t, err)
+
+	var db *envoy_listener_v3.Listener
+	for _, msg := range listeners {
+		if l := msg.(*envoy_listener_v3.Listener); l.Name == "db:127.0.0.1:9191" {
+			db = l
+		}
+	}
+	require.NotNil(t, db)
+
+	require.Len(t, db.ListenerFilters, 1)
+	require.Equal(t, "envoy.filters.listener.http_inspector", db.ListenerFilters[0].Name)
+	require.True(t, db.ContinueOnListenerFiltersTimeout)
+
+	require.Len(t, db.FilterChains, 3)
+	require.Nil(t, db.FilterChains[0].FilterChainMatch)
+	require.Equal(t, []string{"http/1.0", "http/1.1"}, db.FilterChains[1].FilterChainMatch.ApplicationProtocols)
+	require.Equal(t, []string{"h2c"}, db.FilterChains[2].FilterChainMatch.ApplicationProtocols)
+}
+
+func TestDetectedUpstreamProtocols(t *testing.T) {
+	snap := proxycfg.TestConfigSnapshot(t, nil, nil)
+
+	counter := func(name string, value float64) *dto.MetricFamily {
+		return &dto.MetricFamily{
+			Name: proto.String(name),
+			Type: dto.MetricType_COUNTER.Enum(),
+			Metric: []*dto.Metric{{
+				Counter: &dto.Counter{Value: proto.Float64(value)},
+			}},
+		}
+	}
+
+	t.Run("detected", func(t *testing.T) {
+		families := []*dto.MetricFamily{
+			counter("tcp.detected_protocol.http.db.default.default.dc1.downstream_cx_total", 2),
+			counter("tcp.detected_protocol.http2.db.default.default.dc1.downstream_cx_total", 0),
+			counter("tcp.upstream.db.default.default.dc1.downstream_cx_total", 3),
+			// Not an upstream of the proxy.
+			counter("tcp.detected_protocol.http.api.default.default.dc1.downstream_cx_total", 1),
+		}
+		require.Equal(t, map[structs.ServiceName]string{
+			structs.NewServiceName("db", nil): "http",
+		}, detectedUpstreamProtocols(snap, families))
+	})
+
+	t.Run("several protocols", func(t *testing.T) {
+		families := []*dto.MetricFamily{
+			counter("tcp.detected_protocol.http.db.default.default.dc1.downstream_cx_total", 2),
+			counter("tcp.detected_protocol.http2.db.default.default.dc1.downstream_cx_total", 1),
+		}
+		require.Empty(t, detectedUpstreamProtocols(snap, families))
+	})
+}
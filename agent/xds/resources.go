@@ -2,6 +2,7 @@ package xds
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hashicorp/go-hclog"
@@ -18,6 +19,10 @@ type ResourceGenerator struct {
 	IncrementalXDS bool
 
 	ProxyFeatures supportedProxyFeatures
+
+	// Shared holds the resources shared with the other proxies of the
+	// server. It is nil when resources aren't shared.
+	Shared *sharedResources
 }
 
 func newResourceGenerator(
@@ -32,14 +37,51 @@ func newResourceGenerator(
 	}
 }
 
+// tlsIndependentTypes are the types of resources which don't depend on the CA
+// roots and leaf certificates of a snapshot, other than its trust domain.
+var tlsIndependentTypes = map[string]bool{
+	xdscommon.RouteType:    true,
+	xdscommon.EndpointType: true,
+}
+
 func (g *ResourceGenerator) allResourcesFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot) (map[string][]proto.Message, error) {
+	return g.allResourcesFromSnapshotReusing(cfgSnap, nil)
+}
+
+// allResourcesFromSnapshotReusing generates the resources of every type from
+// the snapshot, except for the types in reuse whose resources are taken as is.
+// Each type is generated concurrently as they only read the snapshot.
+func (g *ResourceGenerator) allResourcesFromSnapshotReusing(
+	cfgSnap *proxycfg.ConfigSnapshot,
+	reuse map[string][]proto.Message,
+) (map[string][]proto.Message, error) {
+	typeUrls := []string{xdscommon.ListenerType, xdscommon.RouteType, xdscommon.ClusterType, xdscommon.EndpointType}
+
+	var (
+		wg      sync.WaitGroup
+		results = make([][]proto.Message, len(typeUrls))
+		errs    = make([]error, len(typeUrls))
+	)
+	for i, typeUrl := range typeUrls {
+		if res, ok := reuse[typeUrl]; ok {
+			results[i] = res
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, typeUrl string) {
+			defer wg.Done()
+			results[i], errs[i] = g.resourcesFromSnapshot(typeUrl, cfgSnap)
+		}(i, typeUrl)
+	}
+	wg.Wait()
+
 	all := make(map[string][]proto.Message)
-	for _, typeUrl := range []string{xdscommon.ListenerType, xdscommon.RouteType, xdscommon.ClusterType, xdscommon.EndpointType} {
-		res, err := g.resourcesFromSnapshot(typeUrl, cfgSnap)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate xDS resources for %q: %v", typeUrl, err)
+	for i, typeUrl := range typeUrls {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("failed to generate xDS resources for %q: %v", typeUrl, errs[i])
 		}
-		all[typeUrl] = res
+		all[typeUrl] = results[i]
 	}
 	return all, nil
 }
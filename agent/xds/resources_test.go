@@ -0,0 +1,47 @@
+package xds
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/xds/xdscommon"
+	"github.com/hashicorp/consul/sdk/testutil"
+)
+
+func TestAllResourcesFromSnapshotReusing(t *testing.T) {
+	snap := proxycfg.TestConfigSnapshot(t, nil, nil)
+
+	g := newResourceGenerator(testutil.Logger(t), nil, false)
+
+	all, err := g.allResourcesFromSnapshot(snap)
+	require.NoError(t, err)
+	require.NotEmpty(t, all[xdscommon.EndpointType])
+	allVersions, err := computeResourceVersions(indexResources(g.Logger, all), nil, nil, nil)
+	require.NoError(t, err)
+
+	reuse := map[string][]proto.Message{
+		xdscommon.EndpointType: all[xdscommon.EndpointType],
+	}
+	reused, err := g.allResourcesFromSnapshotReusing(snap, reuse)
+	require.NoError(t, err)
+
+	require.Len(t, reused, len(all))
+	for typeUrl, res := range all {
+		require.Len(t, reused[typeUrl], len(res), typeUrl)
+	}
+	require.Same(t, all[xdscommon.EndpointType][0], reused[xdscommon.EndpointType][0])
+	require.NotSame(t, all[xdscommon.ClusterType][0], reused[xdscommon.ClusterType][0])
+
+	// The versions of the reused resources are not computed again.
+	prevVersions := map[string]map[string]string{
+		xdscommon.EndpointType: {"prev": "1"},
+	}
+	versions, err := computeResourceVersions(indexResources(g.Logger, reused), prevVersions, reuse, nil)
+	require.NoError(t, err)
+	require.Equal(t, prevVersions[xdscommon.EndpointType], versions[xdscommon.EndpointType])
+	require.Equal(t, allVersions[xdscommon.ClusterType], versions[xdscommon.ClusterType])
+	require.Equal(t, allVersions[xdscommon.ListenerType], versions[xdscommon.ListenerType])
+}
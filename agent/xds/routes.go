@@ -4,12 +4,15 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"time"
 
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_http_buffer_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/buffer/v3"
 	envoy_matcher_v3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/golang/protobuf/proto"
@@ -35,6 +38,8 @@ func (s *ResourceGenerator) routesFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot)
 		return s.routesFromSnapshotTerminatingGateway(cfgSnap)
 	case structs.ServiceKindMeshGateway:
 		return nil, nil // mesh gateways will never have routes
+	case structs.ServiceKindEgressGateway:
+		return nil, nil // egress gateway routes are inlined in their listener
 	default:
 		return nil, fmt.Errorf("Invalid service kind: %v", cfgSnap.Kind)
 	}
@@ -60,6 +65,16 @@ func (s *ResourceGenerator) routesForConnectProxy(cfgSnap *proxycfg.ConfigSnapsh
 			return nil, err
 		}
 
+		if u := cfgSnap.ConnectProxy.UpstreamConfig[uid]; u != nil {
+			cfg, err := structs.ParseUpstreamConfigNoDefaults(u.Config)
+			if err != nil {
+				// Don't hard fail on a config typo, just warn. The parse func returns
+				// default config if there is an error so it's safe to continue.
+				s.Logger.Warn("failed to parse", "upstream", uid, "error", err)
+			}
+			injectUpstreamTimeoutsToVirtualHost(cfg, virtualHost)
+		}
+
 		route := &envoy_route_v3.RouteConfiguration{
 			Name:         uid.EnvoyID(),
 			VirtualHosts: []*envoy_route_v3.VirtualHost{virtualHost},
@@ -167,6 +182,14 @@ func makeNamedDefaultRouteWithLB(clusterName string, lb *structs.LoadBalancer, a
 // routesForIngressGateway returns the xDS API representation of the
 // "routes" in the snapshot.
 func (s *ResourceGenerator) routesForIngressGateway(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
+	proxyCfg, err := ParseProxyConfig(cfgSnap.Proxy.Config)
+	if err != nil {
+		// Don't hard fail on a config typo, just warn. The parse func returns
+		// default config if there is an error so it's safe to continue.
+		s.Logger.Warn("failed to parse Connect.Proxy.Config", "error", err)
+	}
+	observabilityHeaders := s.observabilityHeaders(cfgSnap, proxyCfg)
+
 	var result []proto.Message
 	for listenerKey, upstreams := range cfgSnap.IngressGateway.Upstreams {
 		// Do not create any route configuration for TCP listeners
@@ -215,6 +238,20 @@ func (s *ResourceGenerator) routesForIngressGateway(cfgSnap *proxycfg.ConfigSnap
 				return nil, err
 			}
 
+			injectSecurityHeadersToVirtualHost(lCfg.ResolveSecurityHeaders(svc), virtualHost)
+
+			if listenerMaxRequestBodyBytes(lCfg) > 0 {
+				if err := injectMaxRequestBodyBytesToVirtualHost(svc, virtualHost); err != nil {
+					return nil, err
+				}
+			}
+
+			if err := injectSessionAffinityToVirtualHost(svc, virtualHost); err != nil {
+				return nil, err
+			}
+
+			injectCORSToVirtualHost(svc, virtualHost)
+
 			// See if this upstream has its own route/filter chain
 			svcRouteName := routeNameForUpstream(lCfg, *svc)
 
@@ -228,11 +265,13 @@ func (s *ResourceGenerator) routesForIngressGateway(cfgSnap *proxycfg.ConfigSnap
 					ValidateClusters: makeBoolValue(true),
 					VirtualHosts:     []*envoy_route_v3.VirtualHost{virtualHost},
 				}
+				injectObservabilityHeadersToRouteConfig(observabilityHeaders, svcRoute)
 				result = append(result, svcRoute)
 			}
 		}
 
 		if len(defaultRoute.VirtualHosts) > 0 {
+			injectObservabilityHeadersToRouteConfig(observabilityHeaders, defaultRoute)
 			result = append(result, defaultRoute)
 		}
 	}
@@ -240,6 +279,40 @@ func (s *ResourceGenerator) routesForIngressGateway(cfgSnap *proxycfg.ConfigSnap
 	return result, nil
 }
 
+// injectUpstreamTimeoutsToVirtualHost overrides the request timeout of the
+// routes of an upstream with the timeouts of its upstream config, which take
+// precedence over the service-router of the upstream.
+func injectUpstreamTimeoutsToVirtualHost(cfg structs.UpstreamConfig, vh *envoy_route_v3.VirtualHost) {
+	for _, r := range vh.Routes {
+		action, ok := r.Action.(*envoy_route_v3.Route_Route)
+		if !ok {
+			continue
+		}
+		if timeoutMs := upstreamRouteTimeoutMs(cfg, r.Match); timeoutMs > 0 {
+			action.Route.Timeout = durationpb.New(time.Duration(timeoutMs) * time.Millisecond)
+		}
+	}
+}
+
+// upstreamRouteTimeoutMs returns the request timeout the upstream config sets
+// for the route with the given match, or 0 if it doesn't override it. A route
+// timeout applies to the routes matching the same exact path or path prefix.
+func upstreamRouteTimeoutMs(cfg structs.UpstreamConfig, match *envoy_route_v3.RouteMatch) int {
+	for _, t := range cfg.RouteTimeouts {
+		switch spec := match.GetPathSpecifier().(type) {
+		case *envoy_route_v3.RouteMatch_Path:
+			if t.PathExact != "" && t.PathExact == spec.Path {
+				return t.RequestTimeoutMs
+			}
+		case *envoy_route_v3.RouteMatch_Prefix:
+			if t.PathPrefix != "" && t.PathPrefix == spec.Prefix {
+				return t.RequestTimeoutMs
+			}
+		}
+	}
+	return cfg.RequestTimeoutMs
+}
+
 func makeHeadersValueOptions(vals map[string]string, add bool) []*envoy_core_v3.HeaderValueOption {
 	opts := make([]*envoy_core_v3.HeaderValueOption, 0, len(vals))
 	for k, v := range vals {
@@ -666,8 +739,74 @@ func injectLBToRouteAction(lb *structs.LoadBalancer, action *envoy_route_v3.Rout
 		return nil
 	}
 
-	result := make([]*envoy_route_v3.RouteAction_HashPolicy, 0, len(lb.HashPolicies))
-	for _, policy := range lb.HashPolicies {
+	result, err := makeHashPolicies(lb.HashPolicies)
+	if err != nil {
+		return err
+	}
+	action.HashPolicy = result
+	return nil
+}
+
+// injectSessionAffinityToVirtualHost prepends the cookie hash policy of an
+// ingress service's session affinity to every route of the virtual host. The
+// policy is terminal so that requests carrying the cookie are always routed
+// to the same endpoint, regardless of any resolver hash policies.
+func injectSessionAffinityToVirtualHost(svc *structs.IngressService, vh *envoy_route_v3.VirtualHost) error {
+	if svc.SessionAffinity == nil {
+		return nil
+	}
+
+	policies, err := makeHashPolicies([]structs.HashPolicy{svc.SessionAffinity.HashPolicy()})
+	if err != nil {
+		return err
+	}
+
+	for _, route := range vh.Routes {
+		action, ok := route.Action.(*envoy_route_v3.Route_Route)
+		if !ok {
+			continue
+		}
+		action.Route.HashPolicy = append(policies, action.Route.HashPolicy...)
+	}
+	return nil
+}
+
+// injectCORSToVirtualHost sets the CORS policy of an ingress service on its
+// virtual host. The policy is only enforced if the listener's HTTP filter
+// chain contains the CORS filter.
+func injectCORSToVirtualHost(svc *structs.IngressService, vh *envoy_route_v3.VirtualHost) {
+	cors := svc.CORS
+	if cors == nil {
+		return
+	}
+
+	policy := &envoy_route_v3.CorsPolicy{
+		AllowMethods:  strings.Join(cors.AllowMethods, ","),
+		AllowHeaders:  strings.Join(cors.AllowHeaders, ","),
+		ExposeHeaders: strings.Join(cors.ExposeHeaders, ","),
+	}
+	for _, origin := range cors.AllowOrigins {
+		policy.AllowOriginStringMatch = append(policy.AllowOriginStringMatch, &envoy_matcher_v3.StringMatcher{
+			MatchPattern: &envoy_matcher_v3.StringMatcher_Exact{Exact: origin},
+		})
+	}
+	for _, regex := range cors.AllowOriginRegexes {
+		policy.AllowOriginStringMatch = append(policy.AllowOriginStringMatch, &envoy_matcher_v3.StringMatcher{
+			MatchPattern: &envoy_matcher_v3.StringMatcher_SafeRegex{SafeRegex: makeEnvoyRegexMatch(regex)},
+		})
+	}
+	if cors.MaxAge > 0 {
+		policy.MaxAge = strconv.Itoa(int(cors.MaxAge.Seconds()))
+	}
+	if cors.AllowCredentials {
+		policy.AllowCredentials = makeBoolValue(true)
+	}
+	vh.Cors = policy
+}
+
+func makeHashPolicies(policies []structs.HashPolicy) ([]*envoy_route_v3.RouteAction_HashPolicy, error) {
+	result := make([]*envoy_route_v3.RouteAction_HashPolicy, 0, len(policies))
+	for _, policy := range policies {
 		if policy.SourceIP {
 			result = append(result, &envoy_route_v3.RouteAction_HashPolicy{
 				PolicySpecifier: &envoy_route_v3.RouteAction_HashPolicy_ConnectionProperties_{
@@ -723,11 +862,10 @@ func injectLBToRouteAction(lb *structs.LoadBalancer, action *envoy_route_v3.Rout
 				Terminal: policy.Terminal,
 			})
 		default:
-			return fmt.Errorf("unsupported load balancer hash policy field: %v", policy.Field)
+			return nil, fmt.Errorf("unsupported load balancer hash policy field: %v", policy.Field)
 		}
 	}
-	action.HashPolicy = result
-	return nil
+	return result, nil
 }
 
 func injectHeaderManipToRoute(dest *structs.ServiceRouteDestination, r *envoy_route_v3.Route) error {
@@ -794,6 +932,69 @@ func injectHeaderManipToVirtualHost(dest *structs.IngressService, vh *envoy_rout
 	return nil
 }
 
+// injectSecurityHeadersToVirtualHost adds the configured security headers to
+// the responses of the virtual host. They are placed before any existing
+// response headers so that the service's own ResponseHeaders can override
+// them.
+func injectSecurityHeadersToVirtualHost(headers *structs.HTTPSecurityHeaders, vh *envoy_route_v3.VirtualHost) {
+	if headers == nil {
+		return
+	}
+
+	var opts []*envoy_core_v3.HeaderValueOption
+	add := func(k, v string) {
+		opts = append(opts, &envoy_core_v3.HeaderValueOption{
+			Header: &envoy_core_v3.HeaderValue{Key: k, Value: v},
+			Append: makeBoolValue(false),
+		})
+	}
+
+	if headers.HSTSMaxAge > 0 {
+		hsts := fmt.Sprintf("max-age=%d", int64(headers.HSTSMaxAge.Seconds()))
+		if headers.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		add("Strict-Transport-Security", hsts)
+	}
+	if headers.ContentTypeNosniff {
+		add("X-Content-Type-Options", "nosniff")
+	}
+	if headers.FrameOptions != "" {
+		add("X-Frame-Options", headers.FrameOptions)
+	}
+	if headers.ContentSecurityPolicy != "" {
+		add("Content-Security-Policy", headers.ContentSecurityPolicy)
+	}
+
+	vh.ResponseHeadersToAdd = append(opts, vh.ResponseHeadersToAdd...)
+}
+
+// injectMaxRequestBodyBytesToVirtualHost configures the buffer filter of the
+// listener for the virtual host of an ingress service. Services without a
+// request body limit have the filter disabled.
+func injectMaxRequestBodyBytesToVirtualHost(svc *structs.IngressService, vh *envoy_route_v3.VirtualHost) error {
+	perRoute := &envoy_http_buffer_v3.BufferPerRoute{
+		Override: &envoy_http_buffer_v3.BufferPerRoute_Disabled{Disabled: true},
+	}
+	if svc.MaxRequestBodyBytes > 0 {
+		perRoute.Override = &envoy_http_buffer_v3.BufferPerRoute_Buffer{
+			Buffer: &envoy_http_buffer_v3.Buffer{
+				MaxRequestBytes: makeUint32Value(svc.MaxRequestBodyBytes),
+			},
+		}
+	}
+
+	cfg, err := anypb.New(perRoute)
+	if err != nil {
+		return err
+	}
+	if vh.TypedPerFilterConfig == nil {
+		vh.TypedPerFilterConfig = make(map[string]*anypb.Any)
+	}
+	vh.TypedPerFilterConfig["envoy.filters.http.buffer"] = cfg
+	return nil
+}
+
 func injectHeaderManipToWeightedCluster(split *structs.ServiceSplit, c *envoy_route_v3.WeightedCluster_ClusterWeight) error {
 	if !split.RequestHeaders.IsZero() {
 		c.RequestHeadersToAdd = append(
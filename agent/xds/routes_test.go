@@ -74,6 +74,18 @@ func TestRoutesFromSnapshot(t *testing.T) {
 				return proxycfg.TestConfigSnapshotDiscoveryChain(t, "chain-and-router", nil, nil)
 			},
 		},
+		{
+			name: "connect-proxy-with-chain-and-router-upstream-timeouts",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshotDiscoveryChain(t, "chain-and-router", func(ns *structs.NodeService) {
+					ns.Proxy.Upstreams[0].Config["request_timeout_ms"] = 2000
+					ns.Proxy.Upstreams[0].Config["route_timeouts"] = []map[string]interface{}{
+						{"path_prefix": "/prefix", "request_timeout_ms": 30000},
+						{"path_exact": "/exact", "request_timeout_ms": 500},
+					}
+				}, nil)
+			},
+		},
 		{
 			name: "connect-proxy-lb-in-resolver",
 			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
@@ -138,6 +150,10 @@ func TestRoutesFromSnapshot(t *testing.T) {
 					"lb-resolver", nil, nil, nil)
 			},
 		},
+		{
+			name:   "ingress-with-chain-and-router-observability-headers",
+			create: testConfigSnapshotIngressObservabilityHeaders,
+		},
 		{
 			name:   "ingress-http-multiple-services",
 			create: proxycfg.TestConfigSnapshotIngress_HTTPMultipleServices,
@@ -152,6 +168,18 @@ func TestRoutesFromSnapshot(t *testing.T) {
 				return proxycfg.TestConfigSnapshotIngressGatewayWithChain(t, "router-header-manip", nil, nil)
 			},
 		},
+		{
+			name: "ingress-with-chain-and-router-cors",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshotIngressGatewayWithChain(t, "router-cors", nil, nil)
+			},
+		},
+		{
+			name: "ingress-with-chain-and-router-max-request-body-bytes",
+			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
+				return proxycfg.TestConfigSnapshotIngressGatewayWithChain(t, "router-max-request-body-bytes", nil, nil)
+			},
+		},
 		{
 			name: "ingress-with-sds-listener-level",
 			create: func(t testinf.T) *proxycfg.ConfigSnapshot {
@@ -478,3 +506,85 @@ func TestEnvoyLBConfig_InjectToRouteAction(t *testing.T) {
 		})
 	}
 }
+
+func TestInjectSessionAffinityToVirtualHost(t *testing.T) {
+	svc := &structs.IngressService{
+		Name: "web",
+		SessionAffinity: &structs.IngressSessionAffinity{
+			CookieName: "sticky",
+			TTL:        time.Hour,
+			Path:       "/",
+		},
+	}
+	vh := &envoy_route_v3.VirtualHost{
+		Routes: []*envoy_route_v3.Route{
+			{
+				Action: &envoy_route_v3.Route_Route{
+					Route: &envoy_route_v3.RouteAction{
+						HashPolicy: []*envoy_route_v3.RouteAction_HashPolicy{
+							{
+								PolicySpecifier: &envoy_route_v3.RouteAction_HashPolicy_Header_{
+									Header: &envoy_route_v3.RouteAction_HashPolicy_Header{
+										HeaderName: "x-user-id",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	require.NoError(t, injectSessionAffinityToVirtualHost(svc, vh))
+
+	expected := []*envoy_route_v3.RouteAction_HashPolicy{
+		{
+			PolicySpecifier: &envoy_route_v3.RouteAction_HashPolicy_Cookie_{
+				Cookie: &envoy_route_v3.RouteAction_HashPolicy_Cookie{
+					Name: "sticky",
+					Ttl:  durationpb.New(time.Hour),
+					Path: "/",
+				},
+			},
+			Terminal: true,
+		},
+		{
+			PolicySpecifier: &envoy_route_v3.RouteAction_HashPolicy_Header_{
+				Header: &envoy_route_v3.RouteAction_HashPolicy_Header{
+					HeaderName: "x-user-id",
+				},
+			},
+		},
+	}
+	require.Equal(t, expected, vh.Routes[0].GetRoute().HashPolicy)
+}
+
+func TestInjectSecurityHeadersToVirtualHost(t *testing.T) {
+	vh := &envoy_route_v3.VirtualHost{
+		ResponseHeadersToAdd: makeHeadersValueOptions(map[string]string{"X-Frame-Options": "SAMEORIGIN"}, false),
+	}
+
+	injectSecurityHeadersToVirtualHost(&structs.HTTPSecurityHeaders{
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		HSTSIncludeSubdomains: true,
+		ContentTypeNosniff:    true,
+		FrameOptions:          "DENY",
+		ContentSecurityPolicy: "default-src 'self'",
+	}, vh)
+
+	var got [][2]string
+	for _, h := range vh.ResponseHeadersToAdd {
+		require.False(t, h.Append.GetValue())
+		got = append(got, [2]string{h.Header.Key, h.Header.Value})
+	}
+	expected := [][2]string{
+		{"Strict-Transport-Security", "max-age=31536000; includeSubDomains"},
+		{"X-Content-Type-Options", "nosniff"},
+		{"X-Frame-Options", "DENY"},
+		{"Content-Security-Policy", "default-src 'self'"},
+		// The service's own response headers come last so they take precedence.
+		{"X-Frame-Options", "SAMEORIGIN"},
+	}
+	require.Equal(t, expected, got)
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	envoy_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	envoy_load_stats_v3 "github.com/envoyproxy/go-control-plane/envoy/service/load_stats/v3"
+	envoy_metrics_v3 "github.com/envoyproxy/go-control-plane/envoy/service/metrics/v3"
 
 	"github.com/armon/go-metrics"
 	"github.com/armon/go-metrics/prometheus"
@@ -74,6 +76,12 @@ const (
 	// proxy mode.
 	OriginalDestinationClusterName = "original-destination"
 
+	// DynamicForwardProxyClusterName is the name we give to the cluster which
+	// resolves the hostnames of the destinations outside the mesh allowed by
+	// the mesh config entry and connects to them, when the dynamic forward
+	// proxy is enabled for a transparent proxy.
+	DynamicForwardProxyClusterName = "dynamic-forward-proxy"
+
 	// DefaultAuthCheckFrequency is the default value for
 	// Server.AuthCheckFrequency to use when the zero value is provided.
 	DefaultAuthCheckFrequency = 5 * time.Minute
@@ -91,10 +99,38 @@ type ConfigFetcher interface {
 	AdvertiseAddrLAN() string
 }
 
+// ProxyStatusReporter is the interface the agent exposes for the xDS server to
+// report the status of the connect proxies connected to it.
+type ProxyStatusReporter interface {
+	SetProxyStatus(id structs.ServiceID, status *structs.ProxyStatusReport)
+}
+
+// ServiceMetricsRecorder is the interface the agent exposes for the xDS server
+// to record the requests from the connect proxies to their upstreams. The
+// token is the one the proxy authenticated its stream with.
+type ServiceMetricsRecorder interface {
+	RecordServiceMetrics(source structs.ServiceName, token string, upstreams map[structs.ServiceName]structs.ServiceMetricsCounters)
+}
+
+// UpstreamProtocolRecorder is the interface the agent exposes for the xDS
+// server to record the protocols the connect proxies detected on the traffic
+// to their upstreams.
+type UpstreamProtocolRecorder interface {
+	SetUpstreamDetectedProtocol(proxyID structs.ServiceID, upstream structs.ServiceName, protocol string) error
+}
+
 // ProxyConfigSource is the interface xds.Server requires to consume proxy
 // config updates.
 type ProxyConfigSource interface {
 	Watch(id structs.ServiceID, nodeName string, token string) (<-chan *proxycfg.ConfigSnapshot, proxycfg.CancelFunc, error)
+
+	// DemandUpstreams starts watching the given upstreams of a proxy in
+	// on-demand mode, identified by the ProxyID of its snapshots.
+	DemandUpstreams(id proxycfg.ProxyID, uids ...proxycfg.UpstreamID)
+
+	// ReportUpstreamsActivity records a load report of a proxy in on-demand
+	// mode, with the upstreams which had traffic.
+	ReportUpstreamsActivity(id proxycfg.ProxyID, uids ...proxycfg.UpstreamID)
 }
 
 // Server represents a gRPC server that can handle xDS requests from Envoy. All
@@ -109,6 +145,18 @@ type Server struct {
 	ResolveToken ACLResolverFunc
 	CfgFetcher   ConfigFetcher
 
+	// StatusReporter receives the status of the connect proxies connected to
+	// the server. It is optional.
+	StatusReporter ProxyStatusReporter
+
+	// MetricsRecorder receives the requests reported by the connect proxies
+	// with builtin metrics enabled. It is optional.
+	MetricsRecorder ServiceMetricsRecorder
+
+	// ProtocolRecorder receives the protocols detected by the connect proxies
+	// on the traffic to their upstreams. It is optional.
+	ProtocolRecorder UpstreamProtocolRecorder
+
 	// AuthCheckFrequency is how often we should re-check the credentials used
 	// during a long-lived gRPC Stream after it has been initially established.
 	// This is only used during idle periods of stream interactions (i.e. when
@@ -120,6 +168,13 @@ type Server struct {
 
 	activeStreams           *activeStreamCounters
 	serverlessPluginEnabled bool
+
+	// sharedResources holds the resources shared by the proxies of the
+	// server.
+	sharedResources *sharedResources
+
+	// streams tracks the state of the open streams for debugging.
+	streams streamTracker
 }
 
 // activeStreamCounters simply encapsulates two counters accessed atomically to
@@ -169,6 +224,7 @@ func NewServer(
 		AuthCheckFrequency:      DefaultAuthCheckFrequency,
 		activeStreams:           &activeStreamCounters{},
 		serverlessPluginEnabled: serverlessPluginEnabled,
+		sharedResources:         newSharedResources(),
 	}
 }
 
@@ -184,6 +240,8 @@ func (s *Server) StreamAggregatedResources(stream ADSStream) error {
 // Register the XDS server handlers to the given gRPC server.
 func (s *Server) Register(srv *grpc.Server) {
 	envoy_discovery_v3.RegisterAggregatedDiscoveryServiceServer(srv, s)
+	envoy_load_stats_v3.RegisterLoadReportingServiceServer(srv, s)
+	envoy_metrics_v3.RegisterMetricsServiceServer(srv, s)
 }
 
 // authorize the xDS request using the token stored in ctx. This authorization is
@@ -217,7 +275,7 @@ func (s *Server) authorize(ctx context.Context, cfgSnap *proxycfg.ConfigSnapshot
 		if err := authz.ToAllowAuthorizer().ServiceWriteAllowed(cfgSnap.Proxy.DestinationServiceName, &authzContext); err != nil {
 			return status.Errorf(codes.PermissionDenied, err.Error())
 		}
-	case structs.ServiceKindMeshGateway, structs.ServiceKindTerminatingGateway, structs.ServiceKindIngressGateway:
+	case structs.ServiceKindMeshGateway, structs.ServiceKindTerminatingGateway, structs.ServiceKindIngressGateway, structs.ServiceKindEgressGateway:
 		cfgSnap.ProxyID.EnterpriseMeta.FillAuthzContext(&authzContext)
 		if err := authz.ToAllowAuthorizer().ServiceWriteAllowed(cfgSnap.Service, &authzContext); err != nil {
 			return status.Errorf(codes.PermissionDenied, err.Error())
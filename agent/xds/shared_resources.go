@@ -0,0 +1,117 @@
+package xds
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	"github.com/golang/protobuf/proto"
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/hashicorp/consul/agent/proxycfg"
+)
+
+// sharedLoadAssignmentsSize bounds the number of load assignments shared
+// between the proxies of a server.
+const sharedLoadAssignmentsSize = 4096
+
+// sharedResources holds the resources generated for a proxy which can be
+// reused as is by the other proxies of the server. Load assignments are
+// shared between the proxies whose snapshots hold the same endpoints, as
+// happens in homogeneous fleets where the proxies watch the same upstreams
+// through the same cache entries. Their versions are only computed once.
+//
+// Shared resources must not be mutated once generated.
+type sharedResources struct {
+	// loadAssignments holds the *sharedLoadAssignment by loadAssignmentKey.
+	loadAssignments *lru.Cache
+
+	// byMessage holds the *sharedLoadAssignment by load assignment, to look
+	// up their versions.
+	byMessage *lru.Cache
+}
+
+type sharedLoadAssignment struct {
+	// groups are the endpoints the load assignment was made from. They are
+	// kept so that their addresses, part of the key, aren't reused while the
+	// load assignment is shared.
+	groups []loadAssignmentEndpointGroup
+	cla    *envoy_endpoint_v3.ClusterLoadAssignment
+
+	versionOnce sync.Once
+	version     string
+	versionErr  error
+}
+
+func newSharedResources() *sharedResources {
+	loadAssignments, err := lru.New(sharedLoadAssignmentsSize)
+	if err != nil {
+		panic(err) // only returned for a negative size
+	}
+	byMessage, err := lru.New(sharedLoadAssignmentsSize)
+	if err != nil {
+		panic(err)
+	}
+	return &sharedResources{loadAssignments: loadAssignments, byMessage: byMessage}
+}
+
+// loadAssignment returns the load assignment of the cluster made from the
+// endpoint groups, reusing the one made for another proxy from the same
+// endpoints if any.
+func (r *sharedResources) loadAssignment(
+	clusterName string,
+	endpointGroups []loadAssignmentEndpointGroup,
+	localKey proxycfg.GatewayKey,
+) *envoy_endpoint_v3.ClusterLoadAssignment {
+	if r == nil {
+		return makeLoadAssignment(clusterName, endpointGroups, localKey)
+	}
+
+	key := loadAssignmentKey(clusterName, endpointGroups, localKey)
+	if raw, ok := r.loadAssignments.Get(key); ok {
+		return raw.(*sharedLoadAssignment).cla
+	}
+
+	shared := &sharedLoadAssignment{
+		groups: endpointGroups,
+		cla:    makeLoadAssignment(clusterName, endpointGroups, localKey),
+	}
+	r.loadAssignments.Add(key, shared)
+	r.byMessage.Add(shared.cla, shared)
+	return shared.cla
+}
+
+// loadAssignmentKey identifies the inputs of makeLoadAssignment. Endpoints
+// are identified by the address of their backing array, which is the same for
+// all the snapshots built from the same watch results.
+func loadAssignmentKey(clusterName string, endpointGroups []loadAssignmentEndpointGroup, localKey proxycfg.GatewayKey) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s/%s/%s", clusterName, localKey.Partition, localKey.Datacenter)
+	for _, g := range endpointGroups {
+		var endpoints interface{}
+		if len(g.Endpoints) > 0 {
+			endpoints = &g.Endpoints[0]
+		}
+		fmt.Fprintf(&b, "/%p:%d:%t:%t:%d:%d",
+			endpoints, len(g.Endpoints), g.OnlyPassing, g.IgnoreWeights, g.OverrideWeight, g.OverrideHealth)
+	}
+	return b.String()
+}
+
+// hashResource returns the version of the resource, computing it only once
+// for shared resources.
+func (r *sharedResources) hashResource(res proto.Message) (string, error) {
+	if r == nil {
+		return hashResource(res)
+	}
+	raw, ok := r.byMessage.Get(res)
+	if !ok {
+		return hashResource(res)
+	}
+	shared := raw.(*sharedLoadAssignment)
+	shared.versionOnce.Do(func() {
+		shared.version, shared.versionErr = hashResource(res)
+	})
+	return shared.version, shared.versionErr
+}
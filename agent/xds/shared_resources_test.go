@@ -0,0 +1,73 @@
+package xds
+
+import (
+	"testing"
+
+	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/sdk/testutil"
+)
+
+func TestSharedResources_LoadAssignment(t *testing.T) {
+	shared := newSharedResources()
+	localKey := proxycfg.GatewayKey{Datacenter: "dc1"}
+
+	endpoints := proxycfg.TestUpstreamNodes(t, "db")
+	groups := []loadAssignmentEndpointGroup{{Endpoints: endpoints}}
+
+	la := shared.loadAssignment("db", groups, localKey)
+	require.Equal(t, makeLoadAssignment("db", groups, localKey), la)
+
+	// The same endpoints share the load assignment.
+	require.Same(t, la, shared.loadAssignment("db", []loadAssignmentEndpointGroup{{Endpoints: endpoints}}, localKey))
+
+	// Anything else makes a new one.
+	require.NotSame(t, la, shared.loadAssignment("db2", groups, localKey))
+	require.NotSame(t, la, shared.loadAssignment("db", []loadAssignmentEndpointGroup{{Endpoints: endpoints, OnlyPassing: true}}, localKey))
+	copied := append(structs.CheckServiceNodes(nil), endpoints...)
+	require.NotSame(t, la, shared.loadAssignment("db", []loadAssignmentEndpointGroup{{Endpoints: copied}}, localKey))
+
+	// The version of shared load assignments is computed once.
+	expected, err := hashResource(la)
+	require.NoError(t, err)
+	version, err := shared.hashResource(la)
+	require.NoError(t, err)
+	require.Equal(t, expected, version)
+	raw, ok := shared.byMessage.Get(la)
+	require.True(t, ok)
+	require.Equal(t, expected, raw.(*sharedLoadAssignment).version)
+
+	// Without sharing, load assignments are made every time.
+	var disabled *sharedResources
+	require.NotSame(t, disabled.loadAssignment("db", groups, localKey), disabled.loadAssignment("db", groups, localKey))
+	version, err = disabled.hashResource(la)
+	require.NoError(t, err)
+	require.Equal(t, expected, version)
+}
+
+func TestEndpointsFromSnapshot_SharedResources(t *testing.T) {
+	snap := proxycfg.TestConfigSnapshot(t, nil, nil)
+
+	g := newResourceGenerator(testutil.Logger(t), nil, false)
+	g.ProxyFeatures = supportedProxyFeatures{}
+	g.Shared = newSharedResources()
+
+	first, err := g.endpointsFromSnapshot(snap)
+	require.NoError(t, err)
+	require.NotEmpty(t, first)
+
+	// Another proxy with the same upstream endpoints reuses the load
+	// assignments.
+	other := newResourceGenerator(testutil.Logger(t), nil, false)
+	other.ProxyFeatures = supportedProxyFeatures{}
+	other.Shared = g.Shared
+	second, err := other.endpointsFromSnapshot(snap)
+	require.NoError(t, err)
+	require.Len(t, second, len(first))
+	for i := range first {
+		require.Same(t, first[i].(*envoy_endpoint_v3.ClusterLoadAssignment), second[i].(*envoy_endpoint_v3.ClusterLoadAssignment))
+	}
+}
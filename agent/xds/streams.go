@@ -0,0 +1,208 @@
+package xds
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// StreamState describes an open xDS stream. It is exposed for debugging.
+type StreamState struct {
+	// ProxyID is the ID of the proxy service on the stream, it is empty until
+	// the proxy has identified itself.
+	ProxyID string
+
+	// EnvoyVersion is the version reported by the proxy, if any.
+	EnvoyVersion string
+
+	// ConnectedAt is when the stream was opened.
+	ConnectedAt time.Time
+
+	// Versions are the versions of the resources generated from the latest
+	// proxycfg snapshot.
+	//
+	// type => name => version
+	Versions map[string]map[string]string
+
+	// AckedVersions are the versions of the resources acknowledged by the
+	// proxy.
+	//
+	// type => name => version
+	AckedVersions map[string]map[string]string
+
+	// PendingUpdates is the number of responses sent to the proxy that were
+	// not acknowledged yet.
+	//
+	// type => count
+	PendingUpdates map[string]int
+}
+
+// streamTracker keeps the state of the open xDS streams.
+type streamTracker struct {
+	mu      sync.Mutex
+	nextID  uint64
+	streams map[uint64]StreamState
+
+	// proxies is the stream each proxy last reported its status on, so that
+	// a stream closing after the proxy reconnected doesn't report it as
+	// disconnected.
+	proxies map[structs.ServiceID]uint64
+}
+
+// open registers a new stream and returns its ID.
+func (t *streamTracker) open() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.streams == nil {
+		t.streams = make(map[uint64]StreamState)
+	}
+	id := t.nextID
+	t.nextID++
+	t.streams[id] = StreamState{ConnectedAt: time.Now()}
+	return id
+}
+
+// update replaces the state of a stream, keeping its connection time. The
+// maps in state must not be modified afterwards.
+func (t *streamTracker) update(id uint64, state StreamState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	existing, ok := t.streams[id]
+	if !ok {
+		return
+	}
+	state.ConnectedAt = existing.ConnectedAt
+	t.streams[id] = state
+}
+
+func (t *streamTracker) close(id uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.streams, id)
+}
+
+// claimProxy records that the stream reports the status of the proxy.
+func (t *streamTracker) claimProxy(id uint64, proxyID structs.ServiceID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.proxies == nil {
+		t.proxies = make(map[structs.ServiceID]uint64)
+	}
+	t.proxies[proxyID] = id
+}
+
+// releaseProxy returns whether the stream was the last to report the status
+// of the proxy, and forgets about it if so.
+func (t *streamTracker) releaseProxy(id uint64, proxyID structs.ServiceID) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if owner, ok := t.proxies[proxyID]; !ok || owner != id {
+		return false
+	}
+	delete(t.proxies, proxyID)
+	return true
+}
+
+// reportProxyStatus reports the status of the connect proxy on the stream to
+// the StatusReporter, unless it is the same as the previously reported one
+// which is passed as prev. It returns the latest reported status.
+func (s *Server) reportProxyStatus(
+	streamID uint64,
+	proxyID structs.ServiceID,
+	cfgSnap *proxycfg.ConfigSnapshot,
+	handlers map[string]*xDSDeltaType,
+	prev *structs.ProxyStatusReport,
+) *structs.ProxyStatusReport {
+	if s.StatusReporter == nil || cfgSnap == nil || cfgSnap.Kind != structs.ServiceKindConnectProxy {
+		return prev
+	}
+
+	status := proxyStatusReport(cfgSnap, handlers)
+	if status.IsSame(prev) {
+		return prev
+	}
+	s.streams.claimProxy(streamID, proxyID)
+	s.StatusReporter.SetProxyStatus(proxyID, status)
+	return status
+}
+
+// reportProxyDisconnected reports the proxy as disconnected when the stream
+// was the last one it reported its status on.
+func (s *Server) reportProxyDisconnected(streamID uint64, proxyID structs.ServiceID) {
+	if s.StatusReporter == nil || !s.streams.releaseProxy(streamID, proxyID) {
+		return
+	}
+	s.StatusReporter.SetProxyStatus(proxyID, &structs.ProxyStatusReport{})
+}
+
+// proxyStatusReport builds the status of a proxy from the state of its
+// stream. The configuration is accepted once the proxy acknowledged a
+// response, and as long as it didn't reject any update since.
+func proxyStatusReport(cfgSnap *proxycfg.ConfigSnapshot, handlers map[string]*xDSDeltaType) *structs.ProxyStatusReport {
+	var acked, rejected bool
+	for _, handler := range handlers {
+		if !handler.registered {
+			continue
+		}
+		acked = acked || handler.sentToEnvoyOnce
+		rejected = rejected || handler.rejected
+	}
+
+	status := &structs.ProxyStatusReport{
+		XDSConnected:   true,
+		ConfigAccepted: acked && !rejected,
+	}
+	if leaf := cfgSnap.Leaf(); leaf != nil {
+		status.CertValidBefore = leaf.ValidBefore
+	}
+	return status
+}
+
+// StreamStates returns the state of the xDS streams currently open on the
+// server, sorted by proxy ID.
+func (s *Server) StreamStates() []StreamState {
+	s.streams.mu.Lock()
+	defer s.streams.mu.Unlock()
+
+	states := make([]StreamState, 0, len(s.streams.streams))
+	for _, state := range s.streams.streams {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].ProxyID == states[j].ProxyID {
+			return states[i].ConnectedAt.Before(states[j].ConnectedAt)
+		}
+		return states[i].ProxyID < states[j].ProxyID
+	})
+	return states
+}
+
+// streamState builds the StreamState of a delta stream from its handlers.
+func streamState(proxyID, envoyVersion string, currentVersions map[string]map[string]string, handlers map[string]*xDSDeltaType) StreamState {
+	state := StreamState{
+		ProxyID:        proxyID,
+		EnvoyVersion:   envoyVersion,
+		Versions:       currentVersions,
+		AckedVersions:  make(map[string]map[string]string, len(handlers)),
+		PendingUpdates: make(map[string]int, len(handlers)),
+	}
+	for typeURL, handler := range handlers {
+		if !handler.registered {
+			continue
+		}
+		acked := make(map[string]string, len(handler.resourceVersions))
+		for name, version := range handler.resourceVersions {
+			acked[name] = version
+		}
+		state.AckedVersions[typeURL] = acked
+		state.PendingUpdates[typeURL] = len(handler.pendingUpdates)
+	}
+	return state
+}
@@ -0,0 +1,140 @@
+package xds
+
+import (
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_trace_v3 "github.com/envoyproxy/go-control-plane/envoy/config/trace/v3"
+	envoy_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_tracing_v3 "github.com/envoyproxy/go-control-plane/envoy/type/tracing/v3"
+	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// openTelemetryConfigTypeURL is the type of the config of the OpenTelemetry
+// tracer, which is newer than the go-control-plane we build against.
+const openTelemetryConfigTypeURL = "type.googleapis.com/envoy.config.trace.v3.OpenTelemetryConfig"
+
+// makeDefaultTracing returns the tracing of HTTP connection managers without
+// a tracing collector.
+func makeDefaultTracing() *envoy_http_v3.HttpConnectionManager_Tracing {
+	return &envoy_http_v3.HttpConnectionManager_Tracing{
+		// Don't trace any requests by default unless the client application
+		// explicitly propagates trace headers that indicate this should be
+		// sampled.
+		RandomSampling: &envoy_type_v3.Percent{Value: 0.0},
+	}
+}
+
+// makeTracing returns the tracing of the HTTP connection managers of a sidecar
+// proxy, which report spans to the collector upstream of the mesh config entry
+// once its cluster is known. A misconfigured collector is logged rather than
+// returned so that it doesn't prevent the proxy from being configured.
+func (s *ResourceGenerator) makeTracing(cfgSnap *proxycfg.ConfigSnapshot) (*envoy_http_v3.HttpConnectionManager_Tracing, error) {
+	chain, err := cfgSnap.TracingCollectorChain()
+	if err != nil {
+		s.Logger.Warn("not reporting spans", "error", err)
+		return makeDefaultTracing(), nil
+	}
+	if chain == nil {
+		return makeDefaultTracing(), nil
+	}
+
+	target, err := simpleChainTarget(chain)
+	if err != nil {
+		s.Logger.Warn("not reporting spans, the tracing collector must resolve to a single target",
+			"collector", chain.ServiceName,
+			"error", err,
+		)
+		return makeDefaultTracing(), nil
+	}
+
+	cfg := cfgSnap.MeshConfig().Tracing
+	clusterName := CustomizeClusterName(target.Name, chain)
+
+	var provider *envoy_trace_v3.Tracing_Http
+	switch cfg.Provider {
+	case structs.TracingProviderOpenTelemetry:
+		if s.ProxyFeatures.NoOpenTelemetryTracer {
+			s.Logger.Warn("not reporting spans, the OpenTelemetry tracer requires Envoy 1.22 or later")
+			return makeDefaultTracing(), nil
+		}
+		// OTLP is exported over gRPC, which needs an HTTP/2 cluster.
+		if chain.Protocol != "grpc" && chain.Protocol != "http2" {
+			s.Logger.Warn("not reporting spans, the OpenTelemetry collector must use the grpc protocol",
+				"collector", chain.ServiceName,
+				"protocol", chain.Protocol,
+			)
+			return makeDefaultTracing(), nil
+		}
+		otel, err := makeOpenTelemetryConfig(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		provider = &envoy_trace_v3.Tracing_Http{
+			Name: "envoy.tracers.opentelemetry",
+			ConfigType: &envoy_trace_v3.Tracing_Http_TypedConfig{
+				TypedConfig: otel,
+			},
+		}
+
+	default:
+		zipkin, err := ptypes.MarshalAny(&envoy_trace_v3.ZipkinConfig{
+			CollectorCluster:         clusterName,
+			CollectorEndpoint:        cfg.GetCollectorPath(),
+			CollectorEndpointVersion: envoy_trace_v3.ZipkinConfig_HTTP_JSON,
+			SharedSpanContext:        &wrappers.BoolValue{Value: false},
+		})
+		if err != nil {
+			return nil, err
+		}
+		provider = &envoy_trace_v3.Tracing_Http{
+			Name: "envoy.tracers.zipkin",
+			ConfigType: &envoy_trace_v3.Tracing_Http_TypedConfig{
+				TypedConfig: zipkin,
+			},
+		}
+	}
+
+	tracing := &envoy_http_v3.HttpConnectionManager_Tracing{
+		RandomSampling: &envoy_type_v3.Percent{Value: cfg.SamplingPercentage},
+		Provider:       provider,
+	}
+	for _, tag := range cfg.CustomTags {
+		tracing.CustomTags = append(tracing.CustomTags, &envoy_tracing_v3.CustomTag{
+			Tag: tag.Tag,
+			Type: &envoy_tracing_v3.CustomTag_RequestHeader{
+				RequestHeader: &envoy_tracing_v3.CustomTag_Header{
+					Name:         tag.RequestHeader,
+					DefaultValue: tag.DefaultValue,
+				},
+			},
+		})
+	}
+	return tracing, nil
+}
+
+// makeOpenTelemetryConfig returns the config of the OpenTelemetry tracer
+// exporting spans to the cluster. Its only field is the gRPC service of the
+// collector, which is encoded by hand as its message isn't generated in our
+// go-control-plane.
+func makeOpenTelemetryConfig(clusterName string) (*any.Any, error) {
+	grpcService, err := proto.Marshal(&envoy_core_v3.GrpcService{
+		TargetSpecifier: &envoy_core_v3.GrpcService_EnvoyGrpc_{
+			EnvoyGrpc: &envoy_core_v3.GrpcService_EnvoyGrpc{ClusterName: clusterName},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// OpenTelemetryConfig.grpc_service is field 1.
+	value := protowire.AppendTag(nil, 1, protowire.BytesType)
+	value = protowire.AppendBytes(value, grpcService)
+	return &any.Any{TypeUrl: openTelemetryConfigTypeURL, Value: value}, nil
+}
@@ -0,0 +1,79 @@
+package xds
+
+import (
+	"testing"
+
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/hashicorp/consul/agent/connect"
+	"github.com/hashicorp/consul/agent/consul/discoverychain"
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/sdk/testutil"
+)
+
+func TestMakeTracing_OpenTelemetry(t *testing.T) {
+	snapshot := func(protocol string) *proxycfg.ConfigSnapshot {
+		dbUID := proxycfg.NewUpstreamID(&structs.TestUpstreams(t)[0])
+		return proxycfg.TestConfigSnapshot(t, nil, []proxycfg.UpdateEvent{
+			{
+				CorrelationID: "discovery-chain:" + dbUID.String(),
+				Result: &structs.DiscoveryChainResponse{
+					Chain: discoverychain.TestCompileConfigEntries(t, "db", "default", "default", "dc1", connect.TestClusterID+".consul", nil,
+						&structs.ServiceConfigEntry{
+							Kind:     structs.ServiceDefaults,
+							Name:     "db",
+							Protocol: protocol,
+						},
+					),
+				},
+			},
+			{
+				CorrelationID: "mesh",
+				Result: &structs.ConfigEntryResponse{
+					Entry: &structs.MeshConfigEntry{
+						Tracing: &structs.MeshTracingConfig{
+							Provider:           structs.TracingProviderOpenTelemetry,
+							CollectorUpstream:  "db",
+							SamplingPercentage: 10,
+						},
+					},
+				},
+			},
+		})
+	}
+
+	g := newResourceGenerator(testutil.Logger(t), nil, false)
+
+	tracing, err := g.makeTracing(snapshot("grpc"))
+	require.NoError(t, err)
+	require.Equal(t, 10.0, tracing.RandomSampling.Value)
+	require.Equal(t, "envoy.tracers.opentelemetry", tracing.Provider.Name)
+
+	// The config only holds the gRPC service of the collector.
+	typedConfig := tracing.Provider.GetTypedConfig()
+	require.Equal(t, openTelemetryConfigTypeURL, typedConfig.TypeUrl)
+	num, typ, n := protowire.ConsumeTag(typedConfig.Value)
+	require.Equal(t, protowire.Number(1), num)
+	require.Equal(t, protowire.BytesType, typ)
+	raw, m := protowire.ConsumeBytes(typedConfig.Value[n:])
+	require.Equal(t, len(typedConfig.Value), n+m)
+
+	var grpcService envoy_core_v3.GrpcService
+	require.NoError(t, proto.Unmarshal(raw, &grpcService))
+	require.Equal(t, "db.default.dc1.internal.11111111-2222-3333-4444-555555555555.consul", grpcService.GetEnvoyGrpc().ClusterName)
+
+	// Collectors which don't speak gRPC can't receive the spans.
+	tracing, err = g.makeTracing(snapshot("http"))
+	require.NoError(t, err)
+	require.Equal(t, makeDefaultTracing(), tracing)
+
+	// Nor can proxies without the OpenTelemetry tracer export them.
+	g.ProxyFeatures = supportedProxyFeatures{NoOpenTelemetryTracer: true}
+	tracing, err = g.makeTracing(snapshot("grpc"))
+	require.NoError(t, err)
+	require.Equal(t, makeDefaultTracing(), tracing)
+}
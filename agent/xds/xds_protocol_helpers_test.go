@@ -68,12 +68,14 @@ type testManager struct {
 	sync.Mutex
 	chans   map[structs.ServiceID]chan *proxycfg.ConfigSnapshot
 	cancels chan structs.ServiceID
+	demands chan []proxycfg.UpstreamID
 }
 
 func newTestManager(t *testing.T) *testManager {
 	return &testManager{
 		chans:   map[structs.ServiceID]chan *proxycfg.ConfigSnapshot{},
 		cancels: make(chan structs.ServiceID, 10),
+		demands: make(chan []proxycfg.UpstreamID, 10),
 	}
 }
 
@@ -106,6 +108,14 @@ func (m *testManager) Watch(proxyID structs.ServiceID, _ string, _ string) (<-ch
 	}, nil
 }
 
+// DemandUpstreams implements ConfigManager
+func (m *testManager) DemandUpstreams(_ proxycfg.ProxyID, uids ...proxycfg.UpstreamID) {
+	m.demands <- uids
+}
+
+// ReportUpstreamsActivity implements ConfigManager
+func (m *testManager) ReportUpstreamsActivity(_ proxycfg.ProxyID, _ ...proxycfg.UpstreamID) {}
+
 // AssertWatchCancelled checks that the most recent call to a Watch cancel func
 // was from the specified proxyID and that one is made in a short time. This
 // probably won't work if you are running multiple Watches in parallel on
@@ -45,6 +45,15 @@ type ACLToken struct {
 	CreateTime        time.Time     `json:",omitempty"`
 	Hash              []byte        `json:",omitempty"`
 
+	// Usage is the usage of the token recorded by the servers of the
+	// datacenter. It is nil if the token has not been used.
+	Usage *ACLTokenUsage `json:",omitempty"`
+
+	// AnonymousScopes grant requests without a token different policies and
+	// roles depending on their source. They can only be set on the anonymous
+	// token.
+	AnonymousScopes []*ACLAnonymousScope `json:",omitempty"`
+
 	// DEPRECATED (ACL-Legacy-Compat)
 	// Rules will only be present for legacy tokens returned via the new APIs
 	Rules string `json:",omitempty"`
@@ -62,6 +71,34 @@ type ACLToken struct {
 	AuthMethodNamespace string `json:",omitempty"`
 }
 
+// ACLTokenUsage is the usage of a token recorded by the servers.
+type ACLTokenUsage struct {
+	LastUsed     time.Time
+	RequestCount uint64
+
+	// Sources are the addresses the token was used from, the most recent
+	// first.
+	Sources []ACLTokenUsageSource `json:",omitempty"`
+}
+
+// ACLTokenUsageSource is the usage of a token from an address.
+type ACLTokenUsageSource struct {
+	Address      string
+	LastUsed     time.Time
+	RequestCount uint64
+}
+
+// ACLAnonymousScope replaces the policies and roles of the anonymous token for
+// the requests made from one of SourceCIDRs or received by an agent in one of
+// Segments.
+type ACLAnonymousScope struct {
+	Name        string
+	SourceCIDRs []string              `json:",omitempty"`
+	Segments    []string              `json:",omitempty"`
+	Policies    []*ACLTokenPolicyLink `json:",omitempty"`
+	Roles       []*ACLTokenRoleLink   `json:",omitempty"`
+}
+
 type ACLTokenExpanded struct {
 	ExpandedPolicies []ACLPolicy
 	ExpandedRoles    []ACLRole
@@ -92,6 +129,7 @@ type ACLTokenListEntry struct {
 	CreateTime        time.Time
 	Hash              []byte
 	Legacy            bool
+	Usage             *ACLTokenUsage `json:",omitempty"`
 
 	// Namespace is the namespace the ACLTokenListEntry is associated with.
 	// Namespacing is a Consul Enterprise feature.
@@ -166,6 +204,41 @@ type ACLPolicy struct {
 	Partition string `json:",omitempty"`
 }
 
+// ACLPolicyEvaluateRequest is used to evaluate authorization requests against
+// the policies of a token, a set of existing policies or policy rules that
+// have not been written yet. At least one of SecretID, Policies or Rules must
+// be set.
+type ACLPolicyEvaluateRequest struct {
+	SecretID string                `json:",omitempty"`
+	Policies []*ACLTokenPolicyLink `json:",omitempty"`
+	Rules    string                `json:",omitempty"`
+	Requests []*ACLAuthorizationRequest
+}
+
+// ACLAuthorizationRequest is a single resource and access level to evaluate.
+type ACLAuthorizationRequest struct {
+	Resource string
+	Segment  string `json:",omitempty"`
+	Access   string
+
+	// Namespace and Partition are Consul Enterprise features.
+	Namespace string `json:",omitempty"`
+	Partition string `json:",omitempty"`
+}
+
+// ACLPolicyEvaluateResult is the outcome of a single authorization request.
+// PolicyID and PolicyName identify the policy whose rule decided the request
+// and Rule is that rule. DefaultPolicy is set when no rule matched and the ACL
+// default policy applied.
+type ACLPolicyEvaluateResult struct {
+	ACLAuthorizationRequest
+	Allow         bool
+	PolicyID      string `json:",omitempty"`
+	PolicyName    string `json:",omitempty"`
+	Rule          string `json:",omitempty"`
+	DefaultPolicy bool   `json:",omitempty"`
+}
+
 type ACLPolicyListEntry struct {
 	ID          string
 	Name        string
@@ -226,6 +299,11 @@ type ACLBindingRule struct {
 	BindType    BindingRuleBindType
 	BindName    string
 
+	// BindNameExpression is a CEL expression computing the name to bind from
+	// the projected variables of the verified identity, available as the map
+	// "vars". It is used instead of BindName when set.
+	BindNameExpression string `json:",omitempty"`
+
 	CreateIndex uint64
 	ModifyIndex uint64
 
@@ -1002,6 +1080,31 @@ func (a *ACL) PolicyReadByName(policyName string, q *QueryOptions) (*ACLPolicy,
 	return &out, qm, nil
 }
 
+// PolicyEvaluate evaluates authorization requests without performing them,
+// reporting for each one whether it is allowed and the policy that decided it.
+func (a *ACL) PolicyEvaluate(req *ACLPolicyEvaluateRequest, q *QueryOptions) ([]*ACLPolicyEvaluateResult, *QueryMeta, error) {
+	r := a.c.newRequest("POST", "/v1/acl/policy/evaluate")
+	r.setQueryOptions(q)
+	r.obj = req
+	rtt, resp, err := a.c.doRequest(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, nil, err
+	}
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out []*ACLPolicyEvaluateResult
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return out, qm, nil
+}
+
 // PolicyList retrieves a listing of all policies. The listing does not include the
 // rules for any policy as those should be retrieved by subsequent calls to PolicyRead.
 func (a *ACL) PolicyList(q *QueryOptions) ([]*ACLPolicyListEntry, *QueryMeta, error) {
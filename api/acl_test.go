@@ -146,6 +146,39 @@ func TestAPI_ACLPolicy_CreateUpdate(t *testing.T) {
 	require.Equal(t, updated, updated_read)
 }
 
+func TestAPI_ACLPolicy_Evaluate(t *testing.T) {
+	t.Parallel()
+	c, s := makeACLClient(t)
+	defer s.Stop()
+
+	acl := c.ACL()
+
+	created, _, err := acl.PolicyCreate(&ACLPolicy{
+		Name:  "node-read",
+		Rules: `node_prefix "" { policy = "read" }`,
+	}, nil)
+	require.NoError(t, err)
+
+	results, _, err := acl.PolicyEvaluate(&ACLPolicyEvaluateRequest{
+		Policies: []*ACLTokenPolicyLink{{Name: "node-read"}},
+		Rules:    `service "web" { policy = "write" }`,
+		Requests: []*ACLAuthorizationRequest{
+			{Resource: "node", Segment: "node1", Access: "read"},
+			{Resource: "service", Segment: "web", Access: "write"},
+			{Resource: "key", Segment: "config", Access: "read"},
+		},
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	require.True(t, results[0].Allow)
+	require.Equal(t, created.ID, results[0].PolicyID)
+	require.True(t, results[1].Allow)
+	require.Equal(t, "request-rules", results[1].PolicyName)
+	require.False(t, results[2].Allow)
+	require.True(t, results[2].DefaultPolicy)
+}
+
 func TestAPI_ACLPolicy_List(t *testing.T) {
 	t.Parallel()
 	c, s := makeACLClient(t)
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 )
 
 // ServiceKind is the kind of service being registered.
@@ -37,6 +38,11 @@ const (
 	// This service will ingress connections based of configuration defined in
 	// the ingress-gateway config entry.
 	ServiceKindIngressGateway ServiceKind = "ingress-gateway"
+
+	// ServiceKindEgressGateway is an Egress Gateway for the Connect feature.
+	// This service will proxy connections to the external hosts defined in
+	// the egress-gateway config entry.
+	ServiceKindEgressGateway ServiceKind = "egress-gateway"
 )
 
 // UpstreamDestType is the type of upstream discovery mechanism.
@@ -109,6 +115,21 @@ type AgentServiceChecksInfo struct {
 	Checks           HealthChecks
 }
 
+// AgentProbe is the result of the readiness or liveness probe of an agent.
+type AgentProbe struct {
+	// Status is HealthPassing when all the criteria of the probe are met and
+	// HealthCritical otherwise.
+	Status   string
+	Criteria []AgentProbeCriterion
+}
+
+// AgentProbeCriterion is the result of one of the criteria of an agent probe.
+type AgentProbeCriterion struct {
+	Name    string
+	Passing bool
+	Output  string `json:",omitempty"`
+}
+
 // AgentServiceConnect represents the Connect configuration of a service.
 type AgentServiceConnect struct {
 	Native         bool                      `json:",omitempty"`
@@ -207,6 +228,23 @@ const (
 	ACLModeUnknown MemberACLMode = "3"
 )
 
+// AgentCacheTypeStats summarizes the entries of a type of the agent cache
+// and how they were used since the agent started.
+type AgentCacheTypeStats struct {
+	Type           string
+	Entries        int
+	ValidEntries   int
+	Hits           uint64
+	Misses         uint64
+	HitRate        float64
+	FetchSuccesses uint64
+	FetchErrors    uint64
+	FetchErrorRate float64
+	Evictions      uint64
+	LastGetTTL     time.Duration
+	MaxEntries     int
+}
+
 // AgentMember represents a cluster member known to the agent
 type AgentMember struct {
 	Name string
@@ -344,10 +382,21 @@ type AgentServiceCheck struct {
 	H2PingUseTLS           bool                `json:",omitempty"`
 	AliasNode              string              `json:",omitempty"`
 	AliasService           string              `json:",omitempty"`
+	AliasPropagationDelay  string              `json:",omitempty"`
 	SuccessBeforePassing   int                 `json:",omitempty"`
 	FailuresBeforeWarning  int                 `json:",omitempty"`
 	FailuresBeforeCritical int                 `json:",omitempty"`
 
+	// TTLExpiry fields configure the actions taken when the TTL of a TTL
+	// check expires.
+	TTLExpiryDeregisterAfter int    `json:",omitempty"`
+	TTLExpiryWebhook         string `json:",omitempty"`
+	TTLExpiryMaintenance     bool   `json:",omitempty"`
+
+	// Templated enables the rendering of the templates in the fields
+	// describing the target of a check bound to a service.
+	Templated bool `json:",omitempty"`
+
 	// In Consul 0.7 and later, checks that are associated with a service
 	// may also contain this optional DeregisterCriticalServiceAfter field,
 	// which is a timeout in the same Go time format as Interval and TTL. If
@@ -412,6 +461,48 @@ type AgentAuthorize struct {
 	Reason     string
 }
 
+// Kinds of the gateways the traffic from a sidecar proxy to an upstream can
+// traverse.
+const (
+	RoutingHopLocalMeshGateway   = "local-mesh-gateway"
+	RoutingHopRemoteMeshGateway  = "remote-mesh-gateway"
+	RoutingHopPeerMeshGateway    = "peer-mesh-gateway"
+	RoutingHopTerminatingGateway = "terminating-gateway"
+)
+
+// AgentUpstreamRouting describes how a sidecar proxy routes the traffic to
+// one of its upstreams.
+type AgentUpstreamRouting struct {
+	Upstream        string
+	DestinationName string
+	DestinationType UpstreamDestType `json:",omitempty"`
+	DestinationPeer string           `json:",omitempty"`
+	Targets         []AgentTargetRouting
+}
+
+// AgentTargetRouting describes the path of the traffic from a sidecar proxy
+// to one of the targets of an upstream.
+type AgentTargetRouting struct {
+	ID         string
+	Service    string
+	Namespace  string `json:",omitempty"`
+	Partition  string `json:",omitempty"`
+	Datacenter string `json:",omitempty"`
+	Peer       string `json:",omitempty"`
+	Failover   bool   `json:",omitempty"`
+	Hops       []AgentRoutingHop
+	Pending    bool `json:",omitempty"`
+}
+
+// AgentRoutingHop is a gateway traversed by the traffic to an upstream target.
+type AgentRoutingHop struct {
+	Kind       string
+	Datacenter string   `json:",omitempty"`
+	Partition  string   `json:",omitempty"`
+	Peer       string   `json:",omitempty"`
+	Addresses  []string `json:",omitempty"`
+}
+
 // ConnectProxyConfig is the response structure for agent-local proxy
 // configuration.
 type ConnectProxyConfig struct {
@@ -493,6 +584,45 @@ func (a *Agent) Host() (map[string]interface{}, error) {
 	return out, nil
 }
 
+// Cache is used to retrieve a summary of the entries of the agent cache and
+// how they are used, for each cache type. Requires an agent:read ACL token.
+func (a *Agent) Cache() ([]*AgentCacheTypeStats, error) {
+	r := a.c.newRequest("GET", "/v1/agent/cache")
+	_, resp, err := a.c.doRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, err
+	}
+	var out []*AgentCacheTypeStats
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DebugProxies is used to retrieve the current configuration snapshots of
+// the proxies served by the agent, with the secrets redacted, along with the
+// state of their xDS streams. Requires a operator:read ACL token.
+func (a *Agent) DebugProxies() (map[string]interface{}, error) {
+	r := a.c.newRequest("GET", "/v1/agent/debug/proxies")
+	_, resp, err := a.c.doRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Metrics is used to query the agent we are speaking to for
 // its current internal metric data
 func (a *Agent) Metrics() (*MetricsInfo, error) {
@@ -516,8 +646,18 @@ func (a *Agent) Metrics() (*MetricsInfo, error) {
 // until the context is cancelled. The metrics are json encoded.
 // The caller is responsible for closing the returned io.ReadCloser.
 func (a *Agent) MetricsStream(ctx context.Context) (io.ReadCloser, error) {
+	return a.MetricsStreamWindow(ctx, 0)
+}
+
+// MetricsStreamWindow is like MetricsStream, but each emitted summary
+// aggregates the metrics over the given sliding window instead of only the
+// last second. A zero window uses the agent default.
+func (a *Agent) MetricsStreamWindow(ctx context.Context, window time.Duration) (io.ReadCloser, error) {
 	r := a.c.newRequest("GET", "/v1/agent/metrics/stream")
 	r.ctx = ctx
+	if window > 0 {
+		r.params.Set("window", window.String())
+	}
 	_, resp, err := a.c.doRequest(r)
 	if err != nil {
 		return nil, err
@@ -661,6 +801,37 @@ func (a *Agent) AgentHealthServiceByIDOpts(serviceID string, q *QueryOptions) (s
 	return HealthCritical, out, fmt.Errorf("Unexpected Error Code %v for %s", resp.StatusCode, path)
 }
 
+// Ready returns the result of the readiness probe of the agent. The Status
+// of the probe is HealthCritical when the agent is not ready.
+func (a *Agent) Ready() (*AgentProbe, error) {
+	return a.probe("/v1/agent/ready")
+}
+
+// Live returns the result of the liveness probe of the agent. The Status of
+// the probe is HealthCritical when the agent is not live.
+func (a *Agent) Live() (*AgentProbe, error) {
+	return a.probe("/v1/agent/live")
+}
+
+func (a *Agent) probe(path string) (*AgentProbe, error) {
+	r := a.c.newRequest("GET", path)
+	_, resp, err := a.c.doRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponseBody(resp)
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusServiceUnavailable:
+	default:
+		return nil, generateUnexpectedResponseCodeError(resp)
+	}
+	var out AgentProbe
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 // AgentHealthServiceByName returns for a given service name: the aggregated health status for all services
 // having the specified name.
 // - If no service is not found, will return status (critical, [], nil)
@@ -842,6 +1013,76 @@ func (a *Agent) ServiceDeregisterOpts(serviceID string, q *QueryOptions) error {
 	return nil
 }
 
+const (
+	// AgentServiceBatchRegister and AgentServiceBatchDeregister are the
+	// operations of a batch of services.
+	AgentServiceBatchRegister   = "register"
+	AgentServiceBatchDeregister = "deregister"
+)
+
+// AgentServiceBatch is a batch of services to register and deregister with
+// the local agent at once.
+type AgentServiceBatch struct {
+	// Register holds the services to register.
+	Register []*AgentServiceRegistration `json:",omitempty"`
+
+	// Deregister holds the IDs of the services to deregister.
+	Deregister []string `json:",omitempty"`
+}
+
+// AgentServiceBatchError is an operation of a batch of services which failed.
+type AgentServiceBatchError struct {
+	// Op is either AgentServiceBatchRegister or AgentServiceBatchDeregister,
+	// and OpIndex the index of the operation in the Register or Deregister
+	// list of the batch.
+	Op      string
+	OpIndex int
+
+	// ServiceID is the ID of the service of the operation.
+	ServiceID string
+
+	// What is why the operation failed.
+	What string
+}
+
+// AgentServiceBatchResponse is the result of a batch of services.
+type AgentServiceBatchResponse struct {
+	// Registered and Deregistered are the IDs of the services which were
+	// registered and deregistered. Registered includes the sidecar services.
+	Registered   []string
+	Deregistered []string
+
+	// Errors are the operations which failed.
+	Errors []*AgentServiceBatchError
+}
+
+// ServiceBatch registers and deregisters many services with the local agent
+// at once. All the operations are validated first, and none of them is
+// applied if any of them fails. The returned boolean is false when an
+// operation failed, in which case the response holds the errors.
+func (a *Agent) ServiceBatch(batch *AgentServiceBatch, opts ServiceRegisterOpts) (bool, *AgentServiceBatchResponse, error) {
+	r := a.c.newRequest("PUT", "/v1/agent/service/batch")
+	r.obj = batch
+	r.ctx = opts.ctx
+	if opts.ReplaceExistingChecks {
+		r.params.Set("replace-existing-checks", "true")
+	}
+	_, resp, err := a.c.doRequest(r)
+	if err != nil {
+		return false, nil, err
+	}
+	defer closeResponseBody(resp)
+
+	if err := requireHttpCodes(resp, http.StatusOK, http.StatusConflict); err != nil {
+		return false, nil, err
+	}
+	var out AgentServiceBatchResponse
+	if err := decodeBody(resp, &out); err != nil {
+		return false, nil, err
+	}
+	return resp.StatusCode == http.StatusOK, &out, nil
+}
+
 // PassTTL is used to set a TTL check to the passing state.
 //
 // DEPRECATION NOTICE: This interface is deprecated in favor of UpdateTTL().
@@ -1131,6 +1372,34 @@ func (a *Agent) ConnectCALeaf(serviceID string, q *QueryOptions) (*LeafCert, *Qu
 	return &out, qm, nil
 }
 
+// ConnectProxyRouting returns the gateways the traffic from the local sidecar
+// proxy with the given service ID traverses to reach each of its upstreams. An
+// upstream name can be given to only return the routing of that upstream.
+func (a *Agent) ConnectProxyRouting(proxyID, upstream string, q *QueryOptions) ([]*AgentUpstreamRouting, *QueryMeta, error) {
+	r := a.c.newRequest("GET", "/v1/agent/connect/routing/"+proxyID)
+	r.setQueryOptions(q)
+	if upstream != "" {
+		r.params.Set("upstream", upstream)
+	}
+	rtt, resp, err := a.c.doRequest(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, nil, err
+	}
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out []*AgentUpstreamRouting
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return out, qm, nil
+}
+
 // EnableServiceMaintenance toggles service maintenance mode on
 // for the given service ID.
 func (a *Agent) EnableServiceMaintenance(serviceID, reason string) error {
@@ -1153,6 +1422,28 @@ func (a *Agent) EnableServiceMaintenanceOpts(serviceID, reason string, q *QueryO
 	return nil
 }
 
+// ServiceDetectedProtocol reports the protocol detected on the inbound
+// traffic of the given service ID. Sidecar proxies use this so that the
+// servers can suggest or populate the protocol of the service.
+func (a *Agent) ServiceDetectedProtocol(serviceID, protocol string) error {
+	return a.ServiceDetectedProtocolOpts(serviceID, protocol, nil)
+}
+
+func (a *Agent) ServiceDetectedProtocolOpts(serviceID, protocol string, q *QueryOptions) error {
+	r := a.c.newRequest("PUT", "/v1/agent/service/detected-protocol/"+serviceID)
+	r.setQueryOptions(q)
+	r.obj = map[string]string{"Protocol": protocol}
+	_, resp, err := a.c.doRequest(r)
+	if err != nil {
+		return err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return err
+	}
+	return nil
+}
+
 // DisableServiceMaintenance toggles service maintenance mode off
 // for the given service ID.
 func (a *Agent) DisableServiceMaintenance(serviceID string) error {
@@ -1212,23 +1503,42 @@ func (a *Agent) DisableNodeMaintenance() error {
 // log stream. An empty string will be sent down the given channel when there's
 // nothing left to stream, after which the caller should close the stopCh.
 func (a *Agent) Monitor(loglevel string, stopCh <-chan struct{}, q *QueryOptions) (chan string, error) {
-	return a.monitor(loglevel, false, stopCh, q)
+	return a.MonitorWithOptions(MonitorOptions{LogLevel: loglevel}, stopCh, q)
 }
 
 // MonitorJSON is like Monitor except it returns logs in JSON format.
 func (a *Agent) MonitorJSON(loglevel string, stopCh <-chan struct{}, q *QueryOptions) (chan string, error) {
-	return a.monitor(loglevel, true, stopCh, q)
+	return a.MonitorWithOptions(MonitorOptions{LogLevel: loglevel, LogJSON: true}, stopCh, q)
+}
+
+// MonitorOptions are the options of MonitorWithOptions.
+type MonitorOptions struct {
+	// LogLevel is the minimum level of the logs streamed, info by default.
+	LogLevel string
+
+	// LogJSON streams the logs in JSON format, one object per line.
+	LogJSON bool
+
+	// Loggers filters the logs by the name of their logger. A log is
+	// streamed when one of the loggers matches dot-separated segments of
+	// the name of its logger, so proxycfg matches agent.proxycfg.
+	Loggers []string
 }
 
-func (a *Agent) monitor(loglevel string, logJSON bool, stopCh <-chan struct{}, q *QueryOptions) (chan string, error) {
+// MonitorWithOptions is like Monitor except it streams the logs according to
+// the given options.
+func (a *Agent) MonitorWithOptions(opts MonitorOptions, stopCh <-chan struct{}, q *QueryOptions) (chan string, error) {
 	r := a.c.newRequest("GET", "/v1/agent/monitor")
 	r.setQueryOptions(q)
-	if loglevel != "" {
-		r.params.Add("loglevel", loglevel)
+	if opts.LogLevel != "" {
+		r.params.Add("loglevel", opts.LogLevel)
 	}
-	if logJSON {
+	if opts.LogJSON {
 		r.params.Set("logjson", "true")
 	}
+	for _, logger := range opts.Loggers {
+		r.params.Add("logger", logger)
+	}
 	_, resp, err := a.c.doRequest(r)
 	if err != nil {
 		return nil, err
@@ -83,6 +83,26 @@ func TestAPI_AgentHost(t *testing.T) {
 	})
 }
 
+func TestAPI_AgentCache(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	agent := c.Agent()
+	retry.Run(t, func(r *retry.R) {
+		stats, err := agent.Cache()
+		if err != nil {
+			r.Fatalf("err: %v", err)
+		}
+		for _, typ := range stats {
+			if typ.Type == "connect-ca-root" {
+				return
+			}
+		}
+		r.Fatalf("missing connect-ca-root cache type: %v", stats)
+	})
+}
+
 func TestAPI_AgentReload(t *testing.T) {
 	t.Parallel()
 
@@ -264,6 +284,43 @@ func TestAgent_ServiceRegisterOpts_WithContextTimeout(t *testing.T) {
 	require.True(t, errors.Is(err, context.DeadlineExceeded), "expected timeout")
 }
 
+func TestAPI_AgentServiceBatch(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	agent := c.Agent()
+	s.WaitForSerfCheck(t)
+
+	batch := &AgentServiceBatch{
+		Register: []*AgentServiceRegistration{
+			{ID: "web-1", Name: "web", Port: 8080},
+			{ID: "web-2", Name: "web", Port: 8081},
+		},
+	}
+	ok, resp, err := agent.ServiceBatch(batch, ServiceRegisterOpts{})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.ElementsMatch(t, []string{"web-1", "web-2"}, resp.Registered)
+
+	// An invalid operation fails the whole batch.
+	batch = &AgentServiceBatch{
+		Register:   []*AgentServiceRegistration{{ID: "web-3", Name: "web"}},
+		Deregister: []string{"web-1", "nope"},
+	}
+	ok, resp, err = agent.ServiceBatch(batch, ServiceRegisterOpts{})
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Len(t, resp.Errors, 1)
+	require.Equal(t, AgentServiceBatchDeregister, resp.Errors[0].Op)
+	require.Equal(t, "nope", resp.Errors[0].ServiceID)
+
+	services, err := agent.Services()
+	require.NoError(t, err)
+	require.Contains(t, services, "web-1")
+	require.NotContains(t, services, "web-3")
+}
+
 func TestAPI_AgentServices(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
@@ -1366,6 +1423,47 @@ func TestAPI_AgentMonitorJSON(t *testing.T) {
 	})
 }
 
+func TestAPI_AgentMonitorWithOptions(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	agent := c.Agent()
+
+	logCh, err := agent.MonitorWithOptions(MonitorOptions{
+		LogLevel: "debug",
+		LogJSON:  true,
+		Loggers:  []string{"agent"},
+	}, nil, nil)
+	require.NoError(t, err)
+
+	retry.Run(t, func(r *retry.R) {
+		{
+			// Register a service to be sure something happens in secs
+			serviceReg := &AgentServiceRegistration{
+				Name: "redis",
+			}
+			if err := agent.ServiceRegister(serviceReg); err != nil {
+				r.Fatalf("err: %v", err)
+			}
+		}
+		// Wait for the first log message and validate it has the fields of
+		// the structured logs
+		select {
+		case log := <-logCh:
+			var output map[string]interface{}
+			if err := json.Unmarshal([]byte(log), &output); err != nil {
+				r.Fatalf("log output was not JSON: %q", log)
+			}
+			if _, ok := output["@subsystem"]; !ok {
+				r.Fatalf("log output has no subsystem: %q", log)
+			}
+		case <-time.After(10 * time.Second):
+			r.Fatalf("failed to get a log message")
+		}
+	})
+}
+
 func TestAPI_ServiceMaintenanceOpts(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
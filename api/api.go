@@ -120,6 +120,16 @@ type QueryOptions struct {
 	// read.
 	RequireConsistent bool
 
+	// BoundedStale allows a Consul server (non-leader) to service a read
+	// only if it heard from the leader recently and has at most MaxIndexLag
+	// entries of the leader's log left to apply. Otherwise the leader services
+	// it.
+	BoundedStale bool
+
+	// MaxIndexLag is the lag allowed by BoundedStale. The agent's
+	// bounded_stale_max_lag is used when it is zero.
+	MaxIndexLag uint64
+
 	// UseCache requests that the agent cache results locally. See
 	// https://www.consul.io/api/features/caching.html for more details on the
 	// semantics.
@@ -145,10 +155,13 @@ type QueryOptions struct {
 	// until the timeout or the next index is reached
 	WaitIndex uint64
 
-	// WaitHash is used by some endpoints instead of WaitIndex to perform blocking
-	// on state based on a hash of the response rather than a monotonic index.
-	// This is required when the state being blocked on is not stored in Raft, for
-	// example agent-local proxy configuration.
+	// WaitHash is used instead of WaitIndex to perform blocking on state based
+	// on a hash of the response rather than a monotonic index. This is
+	// required when the state being blocked on is not stored in Raft, for
+	// example agent-local proxy configuration. Endpoints blocking on a Raft
+	// index support it too: the request returns as soon as the content of the
+	// response differs from the hash, which stays valid across restarts of the
+	// servers unlike an index.
 	WaitHash string
 
 	// WaitTime is used to bound the duration of a wait.
@@ -191,11 +204,25 @@ type QueryOptions struct {
 	// is a go-bexpr compatible expression.
 	Filter string
 
+	// PerPage limits the number of results returned by the list endpoints
+	// that support pagination. QueryMeta.NextToken is set when there are
+	// more results.
+	PerPage int
+
+	// NextToken is the QueryMeta.NextToken of the previous page, to get the
+	// results that follow it.
+	NextToken string
+
 	// MergeCentralConfig returns a service definition merged with the
 	// proxy-defaults/global and service-defaults/:service config entries.
 	// This can be used to ensure a full service definition is returned in the response
 	// especially when the service might not be written into the catalog that way.
 	MergeCentralConfig bool
+
+	// ProxyStatus returns the readiness of the connect proxy instances, as
+	// reported by the agents serving their xDS streams. It is only supported
+	// by the health service endpoints.
+	ProxyStatus bool
 }
 
 func (o *QueryOptions) Context() context.Context {
@@ -265,14 +292,18 @@ type QueryMeta struct {
 	LastIndex uint64
 
 	// LastContentHash. This can be used as a WaitHash to perform a blocking query
-	// for endpoints that support hash-based blocking. Endpoints that do not
-	// support it will return an empty hash.
+	// for endpoints that support blocking. Endpoints that do not support it
+	// will return an empty hash.
 	LastContentHash string
 
 	// Time of last contact from the leader for the
 	// server servicing the request
 	LastContact time.Duration
 
+	// Number of entries of the leader's Raft log the server servicing the
+	// request had not applied yet
+	IndexLag uint64
+
 	// Is there a known leader
 	KnownLeader bool
 
@@ -301,6 +332,10 @@ type QueryMeta struct {
 	// filtered out by enforcing ACLs. It may be false because nothing was
 	// removed, or because the endpoint does not yet support this flag.
 	ResultsFilteredByACLs bool
+
+	// NextToken is set when QueryOptions.PerPage was used and there are more
+	// results. Pass it as QueryOptions.NextToken to get the next page.
+	NextToken string
 }
 
 // WriteMeta is used to return meta data about a write
@@ -818,6 +853,13 @@ func (r *request) setQueryOptions(q *QueryOptions) {
 	if q.RequireConsistent {
 		r.params.Set("consistent", "")
 	}
+	if q.BoundedStale {
+		if q.MaxIndexLag != 0 {
+			r.params.Set("bounded_stale", strconv.FormatUint(q.MaxIndexLag, 10))
+		} else {
+			r.params.Set("bounded_stale", "")
+		}
+	}
 	if q.WaitIndex != 0 {
 		r.params.Set("index", strconv.FormatUint(q.WaitIndex, 10))
 	}
@@ -836,6 +878,12 @@ func (r *request) setQueryOptions(q *QueryOptions) {
 	if q.Filter != "" {
 		r.params.Set("filter", q.Filter)
 	}
+	if q.PerPage != 0 {
+		r.params.Set("per_page", strconv.Itoa(q.PerPage))
+	}
+	if q.NextToken != "" {
+		r.params.Set("next_token", q.NextToken)
+	}
 	if len(q.NodeMeta) > 0 {
 		for key, value := range q.NodeMeta {
 			r.params.Add("node-meta", key+":"+value)
@@ -867,6 +915,9 @@ func (r *request) setQueryOptions(q *QueryOptions) {
 	if q.MergeCentralConfig {
 		r.params.Set("merge-central-config", "")
 	}
+	if q.ProxyStatus {
+		r.params.Set("proxy-status", "")
+	}
 
 	r.ctx = q.ctx
 }
@@ -1089,6 +1140,15 @@ func parseQueryMeta(resp *http.Response, q *QueryMeta) error {
 	}
 	q.LastContact = time.Duration(last) * time.Millisecond
 
+	// Parse the X-Consul-Index-Lag, only set when there is one
+	if lagStr := header.Get("X-Consul-Index-Lag"); lagStr != "" {
+		lag, err := strconv.ParseUint(lagStr, 10, 64)
+		if err != nil {
+			return fmt.Errorf("Failed to parse X-Consul-Index-Lag: %v", err)
+		}
+		q.IndexLag = lag
+	}
+
 	// Parse the X-Consul-KnownLeader
 	switch header.Get("X-Consul-KnownLeader") {
 	case "true":
@@ -1119,6 +1179,9 @@ func parseQueryMeta(resp *http.Response, q *QueryMeta) error {
 		q.ResultsFilteredByACLs = false
 	}
 
+	// Parse the X-Consul-Next-Token, only set when there are more results
+	q.NextToken = header.Get("X-Consul-Next-Token")
+
 	// Parse Cache info
 	if cacheStr := header.Get("X-Cache"); cacheStr != "" {
 		q.CacheHit = strings.EqualFold(cacheStr, "HIT")
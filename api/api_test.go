@@ -805,6 +805,18 @@ func TestAPI_SetQueryOptions(t *testing.T) {
 	}
 	assert.Equal(t, "", r.header.Get("Cache-Control"))
 
+	r = c.newRequest("GET", "/v1/kv/foo")
+	r.setQueryOptions(&QueryOptions{BoundedStale: true})
+	if v, ok := r.params["bounded_stale"]; !ok || v[0] != "" {
+		t.Fatalf("bad: %v", r.params)
+	}
+
+	r = c.newRequest("GET", "/v1/kv/foo")
+	r.setQueryOptions(&QueryOptions{BoundedStale: true, MaxIndexLag: 50})
+	if r.params.Get("bounded_stale") != "50" {
+		t.Fatalf("bad: %v", r.params)
+	}
+
 	r = c.newRequest("GET", "/v1/kv/foo")
 	q = &QueryOptions{
 		UseCache:     true,
@@ -952,6 +964,7 @@ func TestAPI_ParseQueryMeta(t *testing.T) {
 	resp.Header.Set("X-Consul-Translate-Addresses", "true")
 	resp.Header.Set("X-Consul-Default-ACL-Policy", "deny")
 	resp.Header.Set("X-Consul-Results-Filtered-By-ACLs", "true")
+	resp.Header.Set("X-Consul-Index-Lag", "7")
 
 	qm := &QueryMeta{}
 	if err := parseQueryMeta(resp, qm); err != nil {
@@ -976,6 +989,9 @@ func TestAPI_ParseQueryMeta(t *testing.T) {
 	if !qm.ResultsFilteredByACLs {
 		t.Fatalf("Bad: %v", qm)
 	}
+	if qm.IndexLag != 7 {
+		t.Fatalf("Bad: %v", qm)
+	}
 }
 
 func TestAPI_UnixSocket(t *testing.T) {
@@ -20,12 +20,17 @@ const (
 	ServiceResolver    string = "service-resolver"
 	IngressGateway     string = "ingress-gateway"
 	TerminatingGateway string = "terminating-gateway"
+	EgressGateway      string = "egress-gateway"
 	ServiceIntentions  string = "service-intentions"
 	MeshConfig         string = "mesh"
 	ExportedServices   string = "exported-services"
+	TrafficShift       string = "traffic-shift"
+	MaintenanceWindow  string = "maintenance-window"
+	RegistrationPolicy string = "registration-policy"
 
-	ProxyConfigGlobal string = "global"
-	MeshConfigMesh    string = "mesh"
+	ProxyConfigGlobal        string = "global"
+	MeshConfigMesh           string = "mesh"
+	RegistrationPolicyGlobal string = "global"
 )
 
 type ConfigEntry interface {
@@ -142,6 +147,11 @@ type UpstreamConfig struct {
 	// Namespace is only accepted within a service-defaults config entry.
 	Namespace string `json:",omitempty"`
 
+	// Peer is only accepted within a service-defaults config entry. It makes
+	// the override apply to the upstream imported from this peer rather than
+	// to the local service of the same name.
+	Peer string `json:",omitempty"`
+
 	// EnvoyListenerJSON is a complete override ("escape hatch") for the upstream's
 	// listener.
 	//
@@ -167,6 +177,15 @@ type UpstreamConfig struct {
 	// connection to this upstream. Defaults to 5000 (5 seconds) if not set.
 	ConnectTimeoutMs int `json:",omitempty" alias:"connect_timeout_ms"`
 
+	// RequestTimeoutMs is the number of milliseconds the proxy waits for a
+	// complete response to the HTTP requests to this upstream. It overrides
+	// the RequestTimeout of the service-router routes of the upstream.
+	RequestTimeoutMs int `json:",omitempty" alias:"request_timeout_ms"`
+
+	// RouteTimeouts override the request timeout of specific service-router
+	// routes of the upstream. They take precedence over RequestTimeoutMs.
+	RouteTimeouts []UpstreamRouteTimeout `json:",omitempty" alias:"route_timeouts"`
+
 	// Limits are the set of limits that are applied to the proxy for a specific upstream of a
 	// service instance.
 	Limits *UpstreamLimits `json:",omitempty"`
@@ -177,6 +196,14 @@ type UpstreamConfig struct {
 
 	// MeshGatewayConfig controls how Mesh Gateways are configured and used
 	MeshGateway MeshGatewayConfig `json:",omitempty" alias:"mesh_gateway" `
+
+	// SNI overrides the server name sent to the upstream during the TLS
+	// handshake.
+	SNI string `json:",omitempty"`
+
+	// AcceptedSANs overrides the subject alternative names accepted in the
+	// certificate presented by the upstream.
+	AcceptedSANs []string `json:",omitempty" alias:"accepted_sans"`
 }
 
 // DestinationConfig represents a virtual service, i.e. one that is external to Consul
@@ -188,6 +215,26 @@ type DestinationConfig struct {
 	Port int `json:",omitempty"`
 }
 
+// LocalAppHTTP2Config tunes the HTTP/2 connections made by sidecar proxies to
+// the local application instance.
+type LocalAppHTTP2Config struct {
+	// PriorKnowledge makes the proxy use HTTP/2 without TLS or upgrade (h2c)
+	// even for services using the http protocol.
+	PriorKnowledge bool `json:",omitempty" alias:"prior_knowledge"`
+
+	// KeepaliveIntervalMs is the number of milliseconds between HTTP/2 PING
+	// frames sent on idle connections.
+	KeepaliveIntervalMs int `json:",omitempty" alias:"keepalive_interval_ms"`
+
+	// KeepaliveTimeoutMs is the number of milliseconds to wait for a PING
+	// response before closing the connection.
+	KeepaliveTimeoutMs int `json:",omitempty" alias:"keepalive_timeout_ms"`
+
+	// MaxConcurrentStreams limits the number of concurrent streams on a single
+	// connection.
+	MaxConcurrentStreams int `json:",omitempty" alias:"max_concurrent_streams"`
+}
+
 type PassiveHealthCheck struct {
 	// Interval between health check analysis sweeps. Each sweep may remove
 	// hosts or return hosts to the pool.
@@ -198,6 +245,19 @@ type PassiveHealthCheck struct {
 	MaxFailures uint32 `alias:"max_failures"`
 }
 
+// UpstreamRouteTimeout overrides the request timeout of the service-router
+// routes of an upstream matching the same path.
+type UpstreamRouteTimeout struct {
+	// PathExact selects the routes matching exactly this path.
+	PathExact string `json:",omitempty" alias:"path_exact"`
+
+	// PathPrefix selects the routes matching this path prefix.
+	PathPrefix string `json:",omitempty" alias:"path_prefix"`
+
+	// RequestTimeoutMs is the request timeout of the selected routes.
+	RequestTimeoutMs int `alias:"request_timeout_ms"`
+}
+
 // UpstreamLimits describes the limits that are associated with a specific
 // upstream of a service instance.
 type UpstreamLimits struct {
@@ -218,21 +278,27 @@ type UpstreamLimits struct {
 }
 
 type ServiceConfigEntry struct {
-	Kind             string
-	Name             string
-	Partition        string                  `json:",omitempty"`
-	Namespace        string                  `json:",omitempty"`
-	Protocol         string                  `json:",omitempty"`
-	Mode             ProxyMode               `json:",omitempty"`
-	TransparentProxy *TransparentProxyConfig `json:",omitempty" alias:"transparent_proxy"`
-	MeshGateway      MeshGatewayConfig       `json:",omitempty" alias:"mesh_gateway"`
-	Expose           ExposeConfig            `json:",omitempty"`
-	ExternalSNI      string                  `json:",omitempty" alias:"external_sni"`
-	UpstreamConfig   *UpstreamConfiguration  `json:",omitempty" alias:"upstream_config"`
-	Destination      *DestinationConfig      `json:",omitempty"`
-	Meta             map[string]string       `json:",omitempty"`
-	CreateIndex      uint64
-	ModifyIndex      uint64
+	Kind                              string
+	Name                              string
+	Partition                         string                      `json:",omitempty"`
+	Namespace                         string                      `json:",omitempty"`
+	Protocol                          string                      `json:",omitempty"`
+	Mode                              ProxyMode                   `json:",omitempty"`
+	TransparentProxy                  *TransparentProxyConfig     `json:",omitempty" alias:"transparent_proxy"`
+	MeshGateway                       MeshGatewayConfig           `json:",omitempty" alias:"mesh_gateway"`
+	Expose                            ExposeConfig                `json:",omitempty"`
+	ExternalSNI                       string                      `json:",omitempty" alias:"external_sni"`
+	UpstreamConfig                    *UpstreamConfiguration      `json:",omitempty" alias:"upstream_config"`
+	Destination                       *DestinationConfig          `json:",omitempty"`
+	LocalAppHTTP2                     *LocalAppHTTP2Config        `json:",omitempty" alias:"local_app_http2"`
+	RequestNormalization              *RequestNormalizationConfig `json:",omitempty" alias:"request_normalization"`
+	MaxInboundConnections             int                         `json:",omitempty" alias:"max_inbound_connections"`
+	InboundConnectionBufferLimitBytes int                         `json:",omitempty" alias:"inbound_connection_buffer_limit_bytes"`
+	MaxRequestBodyBytes               int                         `json:",omitempty" alias:"max_request_body_bytes"`
+	IgnoreCatalogWeights              bool                        `json:",omitempty" alias:"ignore_catalog_weights"`
+	Meta                              map[string]string           `json:",omitempty"`
+	CreateIndex                       uint64
+	ModifyIndex                       uint64
 }
 
 func (s *ServiceConfigEntry) GetKind() string            { return s.Kind }
@@ -283,12 +349,20 @@ func makeConfigEntry(kind, name string) (ConfigEntry, error) {
 		return &IngressGatewayConfigEntry{Kind: kind, Name: name}, nil
 	case TerminatingGateway:
 		return &TerminatingGatewayConfigEntry{Kind: kind, Name: name}, nil
+	case EgressGateway:
+		return &EgressGatewayConfigEntry{Kind: kind, Name: name}, nil
 	case ServiceIntentions:
 		return &ServiceIntentionsConfigEntry{Kind: kind, Name: name}, nil
 	case MeshConfig:
 		return &MeshConfigEntry{}, nil
 	case ExportedServices:
 		return &ExportedServicesConfigEntry{Name: name}, nil
+	case TrafficShift:
+		return &TrafficShiftConfigEntry{Kind: kind, Name: name}, nil
+	case MaintenanceWindow:
+		return &MaintenanceWindowConfigEntry{Kind: kind, Name: name}, nil
+	case RegistrationPolicy:
+		return &RegistrationPolicyConfigEntry{Kind: kind, Name: name}, nil
 	default:
 		return nil, fmt.Errorf("invalid config entry kind: %s", kind)
 	}
@@ -410,6 +484,66 @@ func (conf *ConfigEntries) Get(kind string, name string, q *QueryOptions) (Confi
 	return entry, qm, nil
 }
 
+// ResolvedServiceConfig is the configuration of a service merged from the
+// proxy-defaults and service-defaults config entries.
+type ResolvedServiceConfig struct {
+	ProxyConfig       map[string]interface{}
+	UpstreamIDConfigs []ResolvedUpstreamConfig
+	MeshGateway       MeshGatewayConfig      `json:",omitempty"`
+	Expose            ExposeConfig           `json:",omitempty"`
+	TransparentProxy  TransparentProxyConfig `json:",omitempty"`
+	Mode              ProxyMode              `json:",omitempty"`
+	Meta              map[string]string      `json:",omitempty"`
+}
+
+// ResolvedUpstreamConfig is the configuration of one of the upstreams of a
+// ResolvedServiceConfig.
+type ResolvedUpstreamConfig struct {
+	Upstream ResolvedUpstreamID
+	Config   map[string]interface{}
+}
+
+// ResolvedUpstreamID identifies the upstream of a ResolvedUpstreamConfig.
+type ResolvedUpstreamID struct {
+	ID        string
+	Namespace string `json:",omitempty"`
+	Partition string `json:",omitempty"`
+}
+
+// ResolvedServiceConfig returns the configuration of the named service merged
+// from its config entries. If source is not empty, the configuration of the
+// source service is returned instead, including the configuration of the named
+// service as its upstream.
+func (conf *ConfigEntries) ResolvedServiceConfig(service, source string, q *QueryOptions) (*ResolvedServiceConfig, *QueryMeta, error) {
+	if service == "" {
+		return nil, nil, fmt.Errorf("The service parameter must not be empty")
+	}
+
+	r := conf.c.newRequest("GET", "/v1/config/resolved-service-config/"+service)
+	r.setQueryOptions(q)
+	if source != "" {
+		r.params.Set("source", source)
+	}
+	rtt, resp, err := conf.c.doRequest(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, nil, err
+	}
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out ResolvedServiceConfig
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out, qm, nil
+}
+
 func (conf *ConfigEntries) List(kind string, q *QueryOptions) ([]ConfigEntry, *QueryMeta, error) {
 	if kind == "" {
 		return nil, nil, fmt.Errorf("The kind parameter must not be empty")
@@ -151,6 +151,7 @@ type ServiceResolverConfigEntry struct {
 
 	DefaultSubset  string                             `json:",omitempty" alias:"default_subset"`
 	Subsets        map[string]ServiceResolverSubset   `json:",omitempty"`
+	DynamicSubsets *ServiceResolverDynamicSubsets     `json:",omitempty" alias:"dynamic_subsets"`
 	Redirect       *ServiceResolverRedirect           `json:",omitempty"`
 	Failover       map[string]ServiceResolverFailover `json:",omitempty"`
 	ConnectTimeout time.Duration                      `json:",omitempty" alias:"connect_timeout"`
@@ -213,6 +214,13 @@ type ServiceResolverSubset struct {
 	OnlyPassing bool   `json:",omitempty" alias:"only_passing"`
 }
 
+// ServiceResolverDynamicSubsets makes every value of a service instance
+// metadata key addressable as a subset of the same name.
+type ServiceResolverDynamicSubsets struct {
+	MetaKey     string `json:",omitempty" alias:"meta_key"`
+	OnlyPassing bool   `json:",omitempty" alias:"only_passing"`
+}
+
 type ServiceResolverRedirect struct {
 	Service       string `json:",omitempty"`
 	ServiceSubset string `json:",omitempty" alias:"service_subset"`
@@ -241,6 +249,10 @@ type LoadBalancer struct {
 	// LeastRequestConfig contains configuration for the "least_request" policy type
 	LeastRequestConfig *LeastRequestConfig `json:",omitempty" alias:"least_request_config"`
 
+	// SlowStartConfig contains configuration for gradually increasing the
+	// traffic sent to newly added endpoints.
+	SlowStartConfig *SlowStartConfig `json:",omitempty" alias:"slow_start_config"`
+
 	// HashPolicies is a list of hash policies to use for hashing load balancing algorithms.
 	// Hash policies are evaluated individually and combined such that identical lists
 	// result in the same hash.
@@ -264,6 +276,16 @@ type LeastRequestConfig struct {
 	ChoiceCount uint32 `json:",omitempty" alias:"choice_count"`
 }
 
+// SlowStartConfig contains configuration for the slow start mode of the
+// "round_robin" and "least_request" policy types.
+type SlowStartConfig struct {
+	// Window is the duration of the slow start window of a new endpoint.
+	Window time.Duration `json:",omitempty"`
+
+	// Aggression controls the rate at which traffic ramps up during Window.
+	Aggression float64 `json:",omitempty"`
+}
+
 // HashPolicy defines which attributes will be hashed by hash-based LB algorithms
 type HashPolicy struct {
 	// Field is the attribute type to hash on.
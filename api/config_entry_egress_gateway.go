@@ -0,0 +1,95 @@
+package api
+
+import "time"
+
+// EgressGatewayConfigEntry manages the configuration for an egress gateway
+// with the given name.
+type EgressGatewayConfigEntry struct {
+	// Kind of the config entry. This should be set to api.EgressGateway.
+	Kind string
+
+	// Name is used to match the config entry with its associated egress gateway
+	// service. This should match the name provided in the service definition.
+	Name string
+
+	// Destinations are the external hosts reachable through the gateway.
+	// Requests to any other host are rejected.
+	Destinations []EgressGatewayDestination `json:",omitempty"`
+
+	// ReputationCheck configures an external service asked to allow each
+	// request before it leaves the mesh.
+	ReputationCheck *EgressGatewayReputationCheck `json:",omitempty" alias:"reputation_check"`
+
+	Meta map[string]string `json:",omitempty"`
+
+	// CreateIndex is the Raft index this entry was created at. This is a
+	// read-only field.
+	CreateIndex uint64
+
+	// ModifyIndex is used for the Check-And-Set operations and can also be fed
+	// back into the WaitIndex of the QueryOptions in order to perform blocking
+	// queries.
+	ModifyIndex uint64
+
+	// Partition is the partition the config entry is associated with.
+	// Partitioning is a Consul Enterprise feature.
+	Partition string `json:",omitempty"`
+
+	// Namespace is the namespace the config entry is associated with.
+	// Namespacing is a Consul Enterprise feature.
+	Namespace string `json:",omitempty"`
+}
+
+// EgressGatewayDestination is an external host reachable through an egress
+// gateway.
+type EgressGatewayDestination struct {
+	// Hostname is the DNS name of the destination.
+	Hostname string
+
+	// Port is the port of the destination. Defaults to 443 when the gateway
+	// originates TLS and 80 otherwise.
+	Port int `json:",omitempty"`
+
+	// TLS makes the gateway originate TLS connections to the destination.
+	TLS *EgressGatewayTLSConfig `json:",omitempty"`
+}
+
+// EgressGatewayTLSConfig configures the TLS connections originated by an
+// egress gateway to a destination.
+type EgressGatewayTLSConfig struct {
+	// CAFile is the path to the CA certificates used to verify the destination.
+	CAFile string `alias:"ca_file"`
+
+	// CertFile is the optional path to a client certificate presented to the
+	// destination.
+	CertFile string `json:",omitempty" alias:"cert_file"`
+
+	// KeyFile is the optional path to the private key of the client certificate.
+	KeyFile string `json:",omitempty" alias:"key_file"`
+
+	// SNI is the name sent during the TLS handshake. Defaults to the hostname
+	// of the destination.
+	SNI string `json:",omitempty"`
+}
+
+// EgressGatewayReputationCheck configures a gRPC service implementing Envoy's
+// external authorization API, asked to allow each request leaving the mesh.
+type EgressGatewayReputationCheck struct {
+	Address string
+	Port    int
+
+	// Timeout is how long the gateway waits for a check. Defaults to 200ms.
+	Timeout time.Duration `json:",omitempty"`
+
+	// FailOpen allows requests when the service can't be reached, rather than
+	// rejecting them.
+	FailOpen bool `json:",omitempty" alias:"fail_open"`
+}
+
+func (g *EgressGatewayConfigEntry) GetKind() string            { return g.Kind }
+func (g *EgressGatewayConfigEntry) GetName() string            { return g.Name }
+func (g *EgressGatewayConfigEntry) GetPartition() string       { return g.Partition }
+func (g *EgressGatewayConfigEntry) GetNamespace() string       { return g.Namespace }
+func (g *EgressGatewayConfigEntry) GetMeta() map[string]string { return g.Meta }
+func (g *EgressGatewayConfigEntry) GetCreateIndex() uint64     { return g.CreateIndex }
+func (g *EgressGatewayConfigEntry) GetModifyIndex() uint64     { return g.ModifyIndex }
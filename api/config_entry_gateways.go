@@ -1,5 +1,7 @@
 package api
 
+import "time"
+
 // IngressGatewayConfigEntry manages the configuration for an ingress service
 // with the given name.
 type IngressGatewayConfigEntry struct {
@@ -82,6 +84,17 @@ type IngressListener struct {
 
 	// TLS allows specifying some TLS configuration per listener.
 	TLS *GatewayTLSConfig `json:",omitempty"`
+
+	// SecurityHeaders configures security headers that are added to the
+	// responses of every service on this listener.
+	SecurityHeaders *HTTPSecurityHeaders `json:",omitempty" alias:"security_headers"`
+
+	// SocketPath binds the listener to a Unix domain socket instead of Port,
+	// which still identifies the listener.
+	SocketPath string `json:",omitempty" alias:"socket_path"`
+
+	// SocketMode is the octal permissions of the socket, such as 0600.
+	SocketMode string `json:",omitempty" alias:"socket_mode"`
 }
 
 // IngressService manages configuration for services that are exposed to
@@ -124,6 +137,91 @@ type IngressService struct {
 	// Allow HTTP header manipulation to be configured.
 	RequestHeaders  *HTTPHeaderModifiers `json:",omitempty" alias:"request_headers"`
 	ResponseHeaders *HTTPHeaderModifiers `json:",omitempty" alias:"response_headers"`
+
+	// SessionAffinity configures sticky sessions using a cookie generated by
+	// Envoy on the first request from a client.
+	SessionAffinity *IngressSessionAffinity `json:",omitempty" alias:"session_affinity"`
+
+	// CORS configures the cross-origin resource sharing policy of the service.
+	CORS *IngressCORSPolicy `json:",omitempty" alias:"cors"`
+
+	// SecurityHeaders overrides the security headers of the listener for this
+	// service.
+	SecurityHeaders *HTTPSecurityHeaders `json:",omitempty" alias:"security_headers"`
+
+	// MaxRequestBodyBytes is the maximum size of the body of a request to this
+	// service. Larger requests are rejected with a 413 status.
+	MaxRequestBodyBytes int `json:",omitempty" alias:"max_request_body_bytes"`
+}
+
+// HTTPSecurityHeaders configures standard security headers that are added to
+// every response served by an ingress listener.
+type HTTPSecurityHeaders struct {
+	// Disabled turns off the security headers inherited from the listener.
+	// Only allowed on a service.
+	Disabled bool `json:",omitempty"`
+
+	// HSTSMaxAge, when positive, adds a Strict-Transport-Security header with
+	// the given max-age.
+	HSTSMaxAge time.Duration `json:",omitempty" alias:"hsts_max_age"`
+
+	// HSTSIncludeSubdomains adds the includeSubDomains directive to the
+	// Strict-Transport-Security header.
+	HSTSIncludeSubdomains bool `json:",omitempty" alias:"hsts_include_subdomains"`
+
+	// ContentTypeNosniff adds the "X-Content-Type-Options: nosniff" header.
+	ContentTypeNosniff bool `json:",omitempty" alias:"content_type_nosniff"`
+
+	// FrameOptions is the value of the X-Frame-Options header, such as "DENY"
+	// or "SAMEORIGIN".
+	FrameOptions string `json:",omitempty" alias:"frame_options"`
+
+	// ContentSecurityPolicy is the value of the Content-Security-Policy header.
+	ContentSecurityPolicy string `json:",omitempty" alias:"content_security_policy"`
+}
+
+// IngressCORSPolicy is the cross-origin resource sharing policy of an
+// ingress service.
+type IngressCORSPolicy struct {
+	// AllowOrigins is a list of origins that are allowed to make requests,
+	// matched exactly. "*" allows any origin.
+	AllowOrigins []string `json:",omitempty" alias:"allow_origins"`
+
+	// AllowOriginRegexes is a list of RE2 regular expressions matching the
+	// origins that are allowed to make requests.
+	AllowOriginRegexes []string `json:",omitempty" alias:"allow_origin_regexes"`
+
+	// AllowMethods is the list of methods returned in the
+	// Access-Control-Allow-Methods header.
+	AllowMethods []string `json:",omitempty" alias:"allow_methods"`
+
+	// AllowHeaders is the list of headers returned in the
+	// Access-Control-Allow-Headers header.
+	AllowHeaders []string `json:",omitempty" alias:"allow_headers"`
+
+	// ExposeHeaders is the list of headers returned in the
+	// Access-Control-Expose-Headers header.
+	ExposeHeaders []string `json:",omitempty" alias:"expose_headers"`
+
+	// MaxAge is how long the results of a preflight request can be cached.
+	MaxAge time.Duration `json:",omitempty" alias:"max_age"`
+
+	// AllowCredentials indicates whether the response to the request can be
+	// exposed when the credentials flag is true.
+	AllowCredentials bool `json:",omitempty" alias:"allow_credentials"`
+}
+
+// IngressSessionAffinity configures sticky sessions for an ingress service.
+type IngressSessionAffinity struct {
+	// CookieName is the name of the cookie that Envoy generates.
+	CookieName string `json:",omitempty" alias:"cookie_name"`
+
+	// TTL for the generated cookie. When zero a session cookie with no
+	// expiration is generated.
+	TTL time.Duration `json:",omitempty"`
+
+	// Path to set for the generated cookie.
+	Path string `json:",omitempty"`
 }
 
 func (i *IngressGatewayConfigEntry) GetKind() string            { return i.Kind }
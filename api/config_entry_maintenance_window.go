@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// MaintenanceWindowConfigEntry schedules maintenance for a node or one of its
+// service instances. The leader puts the node or service in maintenance while
+// the window is in effect and takes it out once the window ends.
+type MaintenanceWindowConfigEntry struct {
+	Kind      string
+	Name      string
+	Partition string `json:",omitempty"`
+	Namespace string `json:",omitempty"`
+
+	Datacenter string
+	Node       string
+	ServiceID  string `json:",omitempty" alias:"service_id"`
+	Service    string `json:",omitempty"`
+	Reason     string `json:",omitempty"`
+	Start      time.Time
+	End        time.Time
+	Repeat     time.Duration `json:",omitempty"`
+
+	Meta        map[string]string `json:",omitempty"`
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+func (e *MaintenanceWindowConfigEntry) MarshalJSON() ([]byte, error) {
+	type Alias MaintenanceWindowConfigEntry
+	exported := &struct {
+		Repeat string `json:",omitempty"`
+		*Alias
+	}{
+		Repeat: e.Repeat.String(),
+		Alias:  (*Alias)(e),
+	}
+	if e.Repeat == 0 {
+		exported.Repeat = ""
+	}
+
+	return json.Marshal(exported)
+}
+
+func (e *MaintenanceWindowConfigEntry) UnmarshalJSON(data []byte) error {
+	type Alias MaintenanceWindowConfigEntry
+	aux := &struct {
+		Repeat string
+		*Alias
+	}{
+		Alias: (*Alias)(e),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	if aux.Repeat != "" {
+		if e.Repeat, err = time.ParseDuration(aux.Repeat); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *MaintenanceWindowConfigEntry) GetKind() string            { return e.Kind }
+func (e *MaintenanceWindowConfigEntry) GetName() string            { return e.Name }
+func (e *MaintenanceWindowConfigEntry) GetPartition() string       { return e.Partition }
+func (e *MaintenanceWindowConfigEntry) GetNamespace() string       { return e.Namespace }
+func (e *MaintenanceWindowConfigEntry) GetMeta() map[string]string { return e.Meta }
+func (e *MaintenanceWindowConfigEntry) GetCreateIndex() uint64     { return e.CreateIndex }
+func (e *MaintenanceWindowConfigEntry) GetModifyIndex() uint64     { return e.ModifyIndex }
@@ -23,6 +23,18 @@ type MeshConfigEntry struct {
 
 	HTTP *MeshHTTPConfig `json:",omitempty"`
 
+	ProtocolDetection *MeshProtocolDetectionConfig `json:",omitempty" alias:"protocol_detection"`
+
+	Egress *MeshEgressConfig `json:",omitempty"`
+
+	// Tracing configures the sidecar proxies of the mesh to report the spans
+	// of the HTTP requests they handle to a collector.
+	Tracing *MeshTracingConfig `json:",omitempty"`
+
+	// Peering configures how the servers establish peerings with other
+	// clusters.
+	Peering *PeeringMeshConfig `json:",omitempty"`
+
 	Meta map[string]string `json:",omitempty"`
 
 	// CreateIndex is the Raft index this entry was created at. This is a
@@ -52,6 +64,113 @@ type MeshDirectionalTLSConfig struct {
 
 type MeshHTTPConfig struct {
 	SanitizeXForwardedClientCert bool `alias:"sanitize_x_forwarded_client_cert"`
+
+	// Incoming configures the HTTP requests received by the proxies of the mesh
+	// from other services.
+	Incoming *MeshDirectionalHTTPConfig `json:",omitempty"`
+}
+
+type MeshDirectionalHTTPConfig struct {
+	RequestNormalization *RequestNormalizationConfig `json:",omitempty" alias:"request_normalization"`
+	ObservabilityHeaders *ObservabilityHeadersConfig `json:",omitempty" alias:"observability_headers"`
+}
+
+// RequestNormalizationConfig configures how the inbound HTTP listeners of
+// proxies normalize and validate requests.
+type RequestNormalizationConfig struct {
+	// NormalizePath normalizes the path of requests according to RFC 3986.
+	NormalizePath *bool `json:",omitempty" alias:"normalize_path"`
+
+	// MergeSlashes merges adjacent slashes in the path of requests.
+	MergeSlashes *bool `json:",omitempty" alias:"merge_slashes"`
+
+	// PathWithEscapedSlashesAction is the action taken on requests whose path
+	// contains escaped slashes. One of KEEP_UNCHANGED, REJECT_REQUEST,
+	// UNESCAPE_AND_REDIRECT or UNESCAPE_AND_FORWARD.
+	PathWithEscapedSlashesAction string `json:",omitempty" alias:"path_with_escaped_slashes_action"`
+
+	// HeadersWithUnderscoresAction is the action taken on requests with
+	// headers whose name contains underscores. One of ALLOW, REJECT_REQUEST or
+	// DROP_HEADER.
+	HeadersWithUnderscoresAction string `json:",omitempty" alias:"headers_with_underscores_action"`
+
+	// HeaderKeyFormat is the casing of the header names of HTTP/1.1 requests
+	// and responses. One of lowercase or proper_case.
+	HeaderKeyFormat string `json:",omitempty" alias:"header_key_format"`
+}
+
+// ObservabilityHeadersConfig configures the request ID, trace context and
+// static headers proxies set on the HTTP requests they receive.
+type ObservabilityHeadersConfig struct {
+	// GenerateRequestID generates an x-request-id header for the requests
+	// without one.
+	GenerateRequestID *bool `json:",omitempty" alias:"generate_request_id"`
+
+	// PreserveRequestID keeps the x-request-id header of the requests rather
+	// than replacing it.
+	PreserveRequestID *bool `json:",omitempty" alias:"preserve_request_id"`
+
+	// TraceContextPolicy is what is done with the traceparent and tracestate
+	// headers of requests. One of passthrough or strip.
+	TraceContextPolicy string `json:",omitempty" alias:"trace_context_policy"`
+
+	// RequestHeaders are static headers set on requests. Their values may
+	// reference ${service}, ${node}, ${datacenter}, ${namespace} and
+	// ${partition}.
+	RequestHeaders map[string]string `json:",omitempty" alias:"request_headers"`
+}
+
+// MeshEgressConfig controls the traffic from the mesh to destinations outside
+// of it.
+type MeshEgressConfig struct {
+	// AllowedHostnames are the external hostnames that transparent proxies with
+	// the dynamic forward proxy enabled resolve and connect to on behalf of
+	// their service. A hostname may start with a "*." wildcard.
+	AllowedHostnames []string `json:",omitempty" alias:"allowed_hostnames"`
+}
+
+// PeeringMeshConfig configures how the servers establish peerings with other
+// clusters.
+type PeeringMeshConfig struct {
+	// PeerThroughMeshGateways makes the peering tokens advertise the
+	// addresses of the mesh gateways rather than the ones of the servers.
+	PeerThroughMeshGateways bool `json:",omitempty" alias:"peer_through_mesh_gateways"`
+}
+
+// MeshTracingConfig configures the tracer of the HTTP connection managers of
+// sidecar proxies.
+type MeshTracingConfig struct {
+	// Provider is the tracer spans are reported with: zipkin, the default, or
+	// opentelemetry to export them over OTLP/gRPC.
+	Provider string `json:",omitempty"`
+
+	// CollectorUpstream is the name of the upstream service spans are
+	// reported to.
+	CollectorUpstream string `alias:"collector_upstream"`
+
+	// CollectorPath is the HTTP path spans are posted to by the zipkin
+	// provider. Defaults to /api/v2/spans.
+	CollectorPath string `json:",omitempty" alias:"collector_path"`
+
+	// SamplingPercentage is the percentage of the requests without a tracing
+	// decision that are sampled.
+	SamplingPercentage float64 `json:",omitempty" alias:"sampling_percentage"`
+
+	// CustomTags are the tags added to every span from the headers of the
+	// request.
+	CustomTags []MeshTracingCustomTag `json:",omitempty" alias:"custom_tags"`
+}
+
+// MeshTracingCustomTag is a span tag whose value is read from a request
+// header.
+type MeshTracingCustomTag struct {
+	Tag           string
+	RequestHeader string `alias:"request_header"`
+	DefaultValue  string `json:",omitempty" alias:"default_value"`
+}
+
+type MeshProtocolDetectionConfig struct {
+	AutoPopulate bool `alias:"auto_populate"`
 }
 
 func (e *MeshConfigEntry) GetKind() string            { return MeshConfig }
@@ -0,0 +1,31 @@
+package api
+
+// RegistrationPolicyConfigEntry denies the registration of services whose
+// name or namespace matches one of its glob patterns. There is a single entry
+// per partition, named "global".
+type RegistrationPolicyConfigEntry struct {
+	Kind      string
+	Name      string
+	Partition string `json:",omitempty"`
+	Namespace string `json:",omitempty"`
+
+	// DeniedServiceNames are the glob patterns of the service names that
+	// can't be registered. Matching is case insensitive.
+	DeniedServiceNames []string `json:",omitempty" alias:"denied_service_names"`
+
+	// DeniedNamespaces are the glob patterns of the namespaces services can't
+	// be registered in. Namespacing is a Consul Enterprise feature.
+	DeniedNamespaces []string `json:",omitempty" alias:"denied_namespaces"`
+
+	Meta        map[string]string `json:",omitempty"`
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+func (e *RegistrationPolicyConfigEntry) GetKind() string            { return e.Kind }
+func (e *RegistrationPolicyConfigEntry) GetName() string            { return e.Name }
+func (e *RegistrationPolicyConfigEntry) GetPartition() string       { return e.Partition }
+func (e *RegistrationPolicyConfigEntry) GetNamespace() string       { return e.Namespace }
+func (e *RegistrationPolicyConfigEntry) GetMeta() map[string]string { return e.Meta }
+func (e *RegistrationPolicyConfigEntry) GetCreateIndex() uint64     { return e.CreateIndex }
+func (e *RegistrationPolicyConfigEntry) GetModifyIndex() uint64     { return e.ModifyIndex }
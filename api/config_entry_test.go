@@ -999,7 +999,8 @@ func TestDecodeConfigEntry(t *testing.T) {
 								"Partition": "bar"
 							},
 							{
-								"Name": "db"
+								"Name": "db",
+								"MaxRequestBodyBytes": 1048576
 							}
 						]
 					},
@@ -1042,7 +1043,8 @@ func TestDecodeConfigEntry(t *testing.T) {
 								Partition: "bar",
 							},
 							{
-								Name: "db",
+								Name:                "db",
+								MaxRequestBodyBytes: 1048576,
 							},
 						},
 					},
@@ -1112,6 +1114,56 @@ func TestDecodeConfigEntry(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "egress-gateway",
+			body: `
+			{
+				"Kind": "egress-gateway",
+				"Name": "egress",
+				"Destinations": [
+					{
+						"Hostname": "api.example.com",
+						"Port": 443,
+						"TLS": {
+							"CAFile": "/etc/ca.pem",
+							"SNI": "example.com"
+						}
+					},
+					{
+						"Hostname": "updates.example.org"
+					}
+				],
+				"ReputationCheck": {
+					"Address": "10.0.0.10",
+					"Port": 9191,
+					"Timeout": "250ms",
+					"FailOpen": true
+				}
+			}`,
+			expect: &EgressGatewayConfigEntry{
+				Kind: "egress-gateway",
+				Name: "egress",
+				Destinations: []EgressGatewayDestination{
+					{
+						Hostname: "api.example.com",
+						Port:     443,
+						TLS: &EgressGatewayTLSConfig{
+							CAFile: "/etc/ca.pem",
+							SNI:    "example.com",
+						},
+					},
+					{
+						Hostname: "updates.example.org",
+					},
+				},
+				ReputationCheck: &EgressGatewayReputationCheck{
+					Address:  "10.0.0.10",
+					Port:     9191,
+					Timeout:  250 * time.Millisecond,
+					FailOpen: true,
+				},
+			},
+		},
 		{
 			name: "service-intentions: kitchen sink",
 			body: `
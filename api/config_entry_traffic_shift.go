@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	TrafficShiftStateRunning    = "running"
+	TrafficShiftStateCompleted  = "completed"
+	TrafficShiftStateRolledBack = "rolled-back"
+)
+
+// TrafficShiftConfigEntry describes a scheduled shift of traffic for a service
+// from one service-resolver subset to another. The leader writes the
+// service-splitter for the service as the shift progresses.
+type TrafficShiftConfigEntry struct {
+	Kind      string
+	Name      string
+	Partition string `json:",omitempty"`
+	Namespace string `json:",omitempty"`
+
+	FromSubset   string              `alias:"from_subset"`
+	ToSubset     string              `alias:"to_subset"`
+	StepWeight   float32             `json:",omitempty" alias:"step_weight"`
+	StepInterval time.Duration       `json:",omitempty" alias:"step_interval"`
+	Checks       []TrafficShiftCheck `json:",omitempty"`
+
+	// Status is maintained by the leader. This is a read-only field.
+	Status *TrafficShiftStatus `json:",omitempty"`
+
+	Meta        map[string]string `json:",omitempty"`
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// TrafficShiftCheck configures a check run between the steps of a traffic
+// shift.
+type TrafficShiftCheck struct {
+	Type   string
+	Config map[string]interface{} `json:",omitempty"`
+}
+
+// TrafficShiftStatus reports the progress of a traffic shift.
+type TrafficShiftStatus struct {
+	State    string
+	Weight   float32
+	LastStep time.Time
+	Reason   string `json:",omitempty"`
+}
+
+func (e *TrafficShiftConfigEntry) MarshalJSON() ([]byte, error) {
+	type Alias TrafficShiftConfigEntry
+	exported := &struct {
+		StepInterval string `json:",omitempty"`
+		*Alias
+	}{
+		StepInterval: e.StepInterval.String(),
+		Alias:        (*Alias)(e),
+	}
+	if e.StepInterval == 0 {
+		exported.StepInterval = ""
+	}
+
+	return json.Marshal(exported)
+}
+
+func (e *TrafficShiftConfigEntry) UnmarshalJSON(data []byte) error {
+	type Alias TrafficShiftConfigEntry
+	aux := &struct {
+		StepInterval string
+		*Alias
+	}{
+		Alias: (*Alias)(e),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	if aux.StepInterval != "" {
+		if e.StepInterval, err = time.ParseDuration(aux.StepInterval); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *TrafficShiftConfigEntry) GetKind() string            { return e.Kind }
+func (e *TrafficShiftConfigEntry) GetName() string            { return e.Name }
+func (e *TrafficShiftConfigEntry) GetPartition() string       { return e.Partition }
+func (e *TrafficShiftConfigEntry) GetNamespace() string       { return e.Namespace }
+func (e *TrafficShiftConfigEntry) GetMeta() map[string]string { return e.Meta }
+func (e *TrafficShiftConfigEntry) GetCreateIndex() uint64     { return e.CreateIndex }
+func (e *TrafficShiftConfigEntry) GetModifyIndex() uint64     { return e.ModifyIndex }
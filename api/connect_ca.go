@@ -104,6 +104,20 @@ type CARoot struct {
 	ModifyIndex uint64
 }
 
+// CATrustDomain describes the identity of the cluster in the certificates
+// signed by the Connect CA.
+type CATrustDomain struct {
+	// TrustDomain is the SPIFFE trust domain of the cluster.
+	TrustDomain string
+
+	// ClusterID is the unique ID of the cluster the trust domain is derived
+	// from.
+	ClusterID string
+
+	// SpiffeIDFormat is the format of the SPIFFE IDs of services.
+	SpiffeIDFormat string
+}
+
 // LeafCert is a certificate that has been issued by a Connect CA.
 type LeafCert struct {
 	// SerialNumber is the unique serial number for this certificate.
@@ -130,6 +144,40 @@ type LeafCert struct {
 	ModifyIndex uint64
 }
 
+// IssuedCert is the record of a leaf certificate issued by the Connect CA
+// which has not expired yet.
+type IssuedCert struct {
+	// SerialNumber is the unique serial number for this certificate.
+	// This is encoded in standard hex separated by :.
+	SerialNumber string
+
+	// Service is the name of the service for which the cert was issued.
+	// ServiceURI is the cert URI value, which is its SPIFFE ID.
+	Service    string `json:",omitempty"`
+	ServiceURI string `json:",omitempty"`
+
+	// Agent is the name of the node for which the cert was issued.
+	// AgentURI is the cert URI value.
+	Agent    string `json:",omitempty"`
+	AgentURI string `json:",omitempty"`
+
+	// RootID is the ID of the CA root the certificate chains to, and
+	// SigningKeyID identifies the key of the CA which signed it.
+	RootID       string
+	SigningKeyID string
+
+	// ValidAfter and ValidBefore are the validity periods for the
+	// certificate.
+	ValidAfter  time.Time
+	ValidBefore time.Time
+
+	Namespace string `json:",omitempty"`
+	Partition string `json:",omitempty"`
+
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
 // CARoots queries the list of available roots.
 func (h *Connect) CARoots(q *QueryOptions) (*CARootList, *QueryMeta, error) {
 	r := h.c.newRequest("GET", "/v1/connect/ca/roots")
@@ -154,6 +202,55 @@ func (h *Connect) CARoots(q *QueryOptions) (*CARootList, *QueryMeta, error) {
 	return &out, qm, nil
 }
 
+// CATrustDomain queries the trust domain and cluster ID of the Connect CA.
+func (h *Connect) CATrustDomain(q *QueryOptions) (*CATrustDomain, *QueryMeta, error) {
+	r := h.c.newRequest("GET", "/v1/connect/ca/trust-domain")
+	r.setQueryOptions(q)
+	rtt, resp, err := h.c.doRequest(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, nil, err
+	}
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out CATrustDomain
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out, qm, nil
+}
+
+// CACertificates lists the leaf certificates issued by the CA which have not
+// expired yet.
+func (h *Connect) CACertificates(q *QueryOptions) ([]*IssuedCert, *QueryMeta, error) {
+	r := h.c.newRequest("GET", "/v1/connect/ca/certificates")
+	r.setQueryOptions(q)
+	rtt, resp, err := h.c.doRequest(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, nil, err
+	}
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out []*IssuedCert
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return out, qm, nil
+}
+
 // CAGetConfig returns the current CA configuration.
 func (h *Connect) CAGetConfig(q *QueryOptions) (*CAConfig, *QueryMeta, error) {
 	r := h.c.newRequest("GET", "/v1/connect/ca/configuration")
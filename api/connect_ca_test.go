@@ -54,6 +54,47 @@ func TestAPI_ConnectCARoots_list(t *testing.T) {
 
 }
 
+func TestAPI_ConnectCATrustDomain(t *testing.T) {
+	t.Parallel()
+
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	retry.Run(t, func(r *retry.R) {
+		td, meta, err := c.Connect().CATrustDomain(nil)
+		r.Check(err)
+		if meta.LastIndex == 0 {
+			r.Fatalf("expected roots raft index to be > 0")
+		}
+		// connect.TestClusterID causes import cycle so hard code it
+		if td.ClusterID != "11111111-2222-3333-4444-555555555555" {
+			r.Fatalf("expected fixed cluster ID got '%s'", td.ClusterID)
+		}
+		if td.TrustDomain != td.ClusterID+".consul" {
+			r.Fatalf("unexpected trust domain '%s'", td.TrustDomain)
+		}
+	})
+}
+
+func TestAPI_ConnectCACertificates(t *testing.T) {
+	t.Parallel()
+
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForActiveCARoot(t)
+
+	leaf, _, err := c.Agent().ConnectCALeaf("web", nil)
+	require.NoError(t, err)
+
+	certs, _, err := c.Connect().CACertificates(&QueryOptions{Filter: `Service == "web"`})
+	require.NoError(t, err)
+	require.Len(t, certs, 1)
+	require.Equal(t, leaf.SerialNumber, certs[0].SerialNumber)
+	require.Equal(t, leaf.ServiceURI, certs[0].ServiceURI)
+	require.NotEmpty(t, certs[0].RootID)
+}
+
 func TestAPI_ConnectCAConfig_get_set(t *testing.T) {
 	t.Parallel()
 
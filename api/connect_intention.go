@@ -170,6 +170,115 @@ type IntentionCheck struct {
 
 	// SourceType is the type of the value for the source.
 	SourceType IntentionSourceType
+
+	// DestinationPeer is the name of the peer the destination is imported
+	// from, if any.
+	DestinationPeer string
+}
+
+// IntentionsExport is the normalized export of the intentions returned by
+// Connect.IntentionsExport.
+type IntentionsExport struct {
+	// Intentions are all the intentions, sorted by precedence.
+	Intentions []*ExportedIntention
+
+	// Resolution lists the intentions applying to each destination that is
+	// not a wildcard.
+	Resolution []*IntentionResolution
+}
+
+// ExportedIntention holds the fields of an intention that affect which
+// connections are authorized.
+type ExportedIntention struct {
+	SourcePeer           string `json:",omitempty"`
+	SourcePartition      string `json:",omitempty"`
+	SourceNS             string
+	SourceName           string
+	DestinationPartition string `json:",omitempty"`
+	DestinationNS        string
+	DestinationName      string
+
+	Action      IntentionAction        `json:",omitempty"`
+	Permissions []*IntentionPermission `json:",omitempty"`
+	Precedence  int
+}
+
+// IntentionResolution lists the intentions applying to a destination, in the
+// order they are evaluated.
+type IntentionResolution struct {
+	DestinationPartition string `json:",omitempty"`
+	DestinationNS        string
+	DestinationName      string
+
+	// Intentions are identified by "<source> => <destination>".
+	Intentions []string
+}
+
+// IntentionSimulateRequest describes a proposed change of the intentions and
+// the sample of the traffic it is simulated against.
+type IntentionSimulateRequest struct {
+	// Flows is the sample of the traffic.
+	Flows []IntentionSimulateFlow
+
+	// Entries are the proposed service-intentions config entries. Each one
+	// replaces the intentions of its destination.
+	Entries []*ServiceIntentionsConfigEntry `json:",omitempty"`
+
+	// DefaultAllow is the proposed default decision for connections that
+	// match no intention. The current one is kept when it is nil.
+	DefaultAllow *bool `json:",omitempty"`
+}
+
+// IntentionSimulateFlow is a connection observed between two services.
+type IntentionSimulateFlow struct {
+	SourcePeer           string `json:",omitempty"`
+	SourcePartition      string `json:",omitempty"`
+	SourceNS             string `json:",omitempty"`
+	SourceName           string
+	DestinationPartition string `json:",omitempty"`
+	DestinationNS        string `json:",omitempty"`
+	DestinationName      string
+
+	// Count is how many times the connection was observed.
+	Count uint64 `json:",omitempty"`
+}
+
+// IntentionSimulateResult is the decision for a flow with the current and
+// the proposed intentions.
+type IntentionSimulateResult struct {
+	Flow IntentionSimulateFlow
+
+	Allowed         bool
+	ProposedAllowed bool
+
+	// Intention and ProposedIntention are the intentions deciding whether
+	// the connection is allowed, or nil if the default decision applies.
+	Intention         *ExportedIntention
+	ProposedIntention *ExportedIntention
+}
+
+// IntentionSimulateResponse reports the flows whose decision changes with
+// the proposed intentions.
+type IntentionSimulateResponse struct {
+	NewlyDenied  []*IntentionSimulateResult
+	NewlyAllowed []*IntentionSimulateResult
+	Unchanged    int
+
+	DefaultAllow         bool
+	ProposedDefaultAllow bool
+}
+
+// IntentionBulkApplyResponse reports the changes made, or that would be made
+// in preview mode, to the intentions of a destination.
+type IntentionBulkApplyResponse struct {
+	Created   []*Intention
+	Updated   []*Intention
+	Deleted   []*Intention
+	Unchanged int
+
+	// Applied is whether the changes were applied. It is false in preview
+	// mode and when there is nothing to change.
+	Applied bool
 }
 
 // Intentions returns the list of intentions.
@@ -196,6 +305,86 @@ func (h *Connect) Intentions(q *QueryOptions) ([]*Intention, *QueryMeta, error)
 	return out, qm, nil
 }
 
+// IntentionsExport returns the intentions normalized and sorted by
+// precedence, along with the order they are evaluated in for each
+// destination.
+func (h *Connect) IntentionsExport(q *QueryOptions) (*IntentionsExport, *QueryMeta, error) {
+	r := h.c.newRequest("GET", "/v1/connect/intentions/export")
+	r.setQueryOptions(q)
+	rtt, resp, err := h.c.doRequest(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, nil, err
+	}
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out IntentionsExport
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out, qm, nil
+}
+
+// IntentionSimulate reports the flows that a proposed change of the
+// intentions would deny or allow, without applying it.
+func (h *Connect) IntentionSimulate(req *IntentionSimulateRequest, q *QueryOptions) (*IntentionSimulateResponse, *QueryMeta, error) {
+	r := h.c.newRequest("POST", "/v1/connect/intentions/simulate")
+	r.setQueryOptions(q)
+	r.obj = req
+	rtt, resp, err := h.c.doRequest(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, nil, err
+	}
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out IntentionSimulateResponse
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out, qm, nil
+}
+
+// IntentionBulkApply replaces all the intentions of the destination of entry
+// with its sources in a single write. Intentions of the destination missing
+// from entry are deleted. With preview set the changes are only reported.
+func (h *Connect) IntentionBulkApply(entry *ServiceIntentionsConfigEntry, preview bool, q *WriteOptions) (*IntentionBulkApplyResponse, *WriteMeta, error) {
+	r := h.c.newRequest("PUT", "/v1/connect/intentions/bulk")
+	r.setWriteOptions(q)
+	if preview {
+		r.params.Set("preview", "")
+	}
+	r.obj = entry
+	rtt, resp, err := h.c.doRequest(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, nil, err
+	}
+
+	wm := &WriteMeta{RequestTime: rtt}
+
+	var out IntentionBulkApplyResponse
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out, wm, nil
+}
+
 // IntentionGetExact retrieves a single intention by its unique name instead of
 // its ID.
 func (h *Connect) IntentionGetExact(source, destination string, q *QueryOptions) (*Intention, *QueryMeta, error) {
@@ -348,6 +537,9 @@ func (h *Connect) IntentionCheck(args *IntentionCheck, q *QueryOptions) (bool, *
 	if args.SourceType != "" {
 		r.params.Set("source-type", string(args.SourceType))
 	}
+	if args.DestinationPeer != "" {
+		r.params.Set("destination-peer", args.DestinationPeer)
+	}
 	rtt, resp, err := h.c.doRequest(r)
 	if err != nil {
 		return false, nil, err
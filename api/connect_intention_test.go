@@ -182,6 +182,82 @@ func TestAPI_ConnectIntentionCheck(t *testing.T) {
 	}
 }
 
+func TestAPI_ConnectIntentionSimulate(t *testing.T) {
+	t.Parallel()
+
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForServiceIntentions(t)
+
+	connect := c.Connect()
+
+	ixn := testIntention()
+	ixn.SourceName = "web"
+	_, err := connect.IntentionUpsert(ixn, nil)
+	require.NoError(t, err)
+
+	defaultAllow := false
+	result, _, err := connect.IntentionSimulate(&IntentionSimulateRequest{
+		Flows: []IntentionSimulateFlow{
+			{SourceName: "web", DestinationName: "db"},
+			{SourceName: "api", DestinationName: "db"},
+		},
+		DefaultAllow: &defaultAllow,
+	}, nil)
+	require.NoError(t, err)
+	require.True(t, result.DefaultAllow)
+	require.False(t, result.ProposedDefaultAllow)
+	require.Equal(t, 1, result.Unchanged)
+	require.Len(t, result.NewlyDenied, 1)
+	require.Equal(t, "api", result.NewlyDenied[0].Flow.SourceName)
+	require.Empty(t, result.NewlyAllowed)
+
+	export, _, err := connect.IntentionsExport(nil)
+	require.NoError(t, err)
+	require.Len(t, export.Intentions, 1)
+	require.Equal(t, "default/web => default/db", export.Resolution[0].Intentions[0])
+}
+
+func TestAPI_ConnectIntentionBulkApply(t *testing.T) {
+	t.Parallel()
+
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForServiceIntentions(t)
+
+	connect := c.Connect()
+
+	ixn := testIntention()
+	ixn.SourceName = "web"
+	_, err := connect.IntentionUpsert(ixn, nil)
+	require.NoError(t, err)
+
+	entry := &ServiceIntentionsConfigEntry{
+		Name: "db",
+		Sources: []*SourceIntention{
+			{Name: "web", Action: IntentionActionAllow},
+			{Name: "batch", Action: IntentionActionDeny},
+		},
+	}
+
+	result, _, err := connect.IntentionBulkApply(entry, true, nil)
+	require.NoError(t, err)
+	require.False(t, result.Applied)
+	require.Len(t, result.Created, 1)
+	require.Equal(t, "batch", result.Created[0].SourceName)
+	require.Equal(t, 1, result.Unchanged)
+
+	result, _, err = connect.IntentionBulkApply(entry, false, nil)
+	require.NoError(t, err)
+	require.True(t, result.Applied)
+
+	ixns, _, err := connect.Intentions(nil)
+	require.NoError(t, err)
+	require.Len(t, ixns, 2)
+}
+
 func testIntention() *Intention {
 	return &Intention{
 		SourceNS:        "default",
@@ -238,6 +238,7 @@ type DiscoveryTarget struct {
 	Subset         ServiceResolverSubset
 	ConnectTimeout time.Duration
 	External       bool
+	IgnoreWeights  bool
 	SNI            string
 	Name           string
 }
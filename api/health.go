@@ -218,6 +218,26 @@ type ServiceEntry struct {
 	Node    *Node
 	Service *AgentService
 	Checks  HealthChecks
+
+	// ProxyStatus is the readiness of a connect proxy instance, only set
+	// when requested with QueryOptions.ProxyStatus.
+	ProxyStatus *ProxyStatus `json:",omitempty"`
+}
+
+// ProxyStatus is the readiness of a connect proxy instance.
+type ProxyStatus struct {
+	// XDSConnected is whether the proxy is connected to its agent.
+	XDSConnected bool
+
+	// ConfigAccepted is whether the proxy accepted its latest configuration.
+	ConfigAccepted bool
+
+	// CertValid is whether the leaf certificate of the proxy is valid.
+	CertValid bool
+
+	// Ready is whether the proxy is ready to receive traffic, which is when
+	// all the above are true.
+	Ready bool
 }
 
 // Health can be used to query the Health endpoints
@@ -0,0 +1,61 @@
+package api
+
+import "time"
+
+// Deregistration records the removal of a node or of a service instance from
+// the catalog.
+type Deregistration struct {
+	// ID is the Raft index at which the node or service was removed.
+	ID uint64
+
+	Node string
+
+	// ServiceID and ServiceName are set when a service instance was removed,
+	// and are empty when the whole node was.
+	ServiceID   string `json:",omitempty"`
+	ServiceName string `json:",omitempty"`
+
+	// Services are the names of the services removed along with the node.
+	Services []string `json:",omitempty"`
+
+	// DeregisteredBy is the accessor ID of the token used to deregister the
+	// node or service. It is empty when ACLs are disabled or when the servers
+	// removed the node on their own, in which case Reason tells why.
+	DeregisteredBy string `json:",omitempty"`
+	Reason         string `json:",omitempty"`
+	DeregisteredAt time.Time
+
+	// Status is the aggregated status of Checks, the last known checks of the
+	// node or service instance.
+	Status string
+	Checks HealthChecks `json:",omitempty"`
+
+	Partition string `json:",omitempty"`
+	Namespace string `json:",omitempty"`
+}
+
+// Deregistrations returns the nodes and service instances recently removed
+// from the catalog, most recent first. The servers only keep a bounded
+// history of them.
+func (op *Operator) Deregistrations(q *QueryOptions) ([]*Deregistration, *QueryMeta, error) {
+	r := op.c.newRequest("GET", "/v1/operator/deregistrations")
+	r.setQueryOptions(q)
+	rtt, resp, err := op.c.doRequest(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, nil, err
+	}
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out []*Deregistration
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return out, qm, nil
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_OperatorDeregistrations(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+	s.WaitForSerfCheck(t)
+
+	catalog := c.Catalog()
+	_, err := catalog.Register(&CatalogRegistration{
+		Node:    "foo",
+		Address: "127.0.0.1",
+		Service: &AgentService{ID: "redis1", Service: "redis"},
+	}, nil)
+	require.NoError(t, err)
+	_, err = catalog.Deregister(&CatalogDeregistration{Node: "foo", ServiceID: "redis1"}, nil)
+	require.NoError(t, err)
+
+	deregistrations, qm, err := c.Operator().Deregistrations(nil)
+	require.NoError(t, err)
+	require.NotZero(t, qm.LastIndex)
+	require.Len(t, deregistrations, 1)
+	require.Equal(t, "foo", deregistrations[0].Node)
+	require.Equal(t, "redis1", deregistrations[0].ServiceID)
+	require.Equal(t, "redis", deregistrations[0].ServiceName)
+	require.Equal(t, HealthPassing, deregistrations[0].Status)
+	require.False(t, deregistrations[0].DeregisteredAt.IsZero())
+}
@@ -1,5 +1,13 @@
 package api
 
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
 // RaftServer has information about a server in the Raft configuration.
 type RaftServer struct {
 	// ID is the unique ID for the server. These are currently the same
@@ -56,6 +64,305 @@ func (op *Operator) RaftGetConfiguration(q *QueryOptions) (*RaftConfiguration, e
 	return &out, nil
 }
 
+// RaftEntry describes a Raft log entry that was applied to the FSM.
+type RaftEntry struct {
+	// Index is the Raft index of the entry.
+	Index uint64
+
+	// Type is the message type of the entry, such as "Register" or "KVS".
+	Type string
+
+	// Size is the size of the entry in bytes.
+	Size int
+
+	// ApplyTime is how long the FSM took to apply the entry.
+	ApplyTime time.Duration
+
+	// AppliedAt is when the FSM applied the entry.
+	AppliedAt time.Time
+}
+
+// RaftLargestEntries is returned when querying for the largest Raft log
+// entries recently applied to the FSM of a server.
+type RaftLargestEntries struct {
+	// Server is the node name of the server that answered the request.
+	Server string
+
+	// Entries are the largest entries, sorted by decreasing size.
+	Entries []RaftEntry
+}
+
+// RaftGetLargestEntries is used to query the largest Raft log entries recently
+// applied to the FSM of the leader, or of the server receiving the request
+// when AllowStale is set. A limit of 0 uses the server default.
+func (op *Operator) RaftGetLargestEntries(limit int, q *QueryOptions) (*RaftLargestEntries, error) {
+	r := op.c.newRequest("GET", "/v1/operator/raft/entries")
+	r.setQueryOptions(q)
+	if limit > 0 {
+		r.params.Set("limit", strconv.Itoa(limit))
+	}
+	_, resp, err := op.c.doRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, err
+	}
+
+	var out RaftLargestEntries
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RaftLogTypeUsage describes the share of the Raft log taken by one type of
+// entry.
+type RaftLogTypeUsage struct {
+	// Type is the message type of the entries, such as "Register" or "KVS".
+	Type string
+
+	// Entries is the number of entries of this type in the log.
+	Entries int
+
+	// Bytes is the total size of the entries of this type.
+	Bytes int64
+}
+
+// RaftCompactionReport describes the Raft log of a server and the snapshot
+// policy used to compact it.
+type RaftCompactionReport struct {
+	// Server is the node name of the server that answered the request.
+	Server string
+
+	// FirstIndex and LastIndex are the bounds of the log on disk.
+	FirstIndex uint64
+	LastIndex  uint64
+
+	// LastSnapshotIndex is the index of the last snapshot taken.
+	LastSnapshotIndex uint64
+
+	// AutoTune is true when the snapshot threshold and interval are tuned
+	// automatically.
+	AutoTune bool
+
+	// SnapshotThreshold and SnapshotInterval are the values currently used
+	// by Raft.
+	SnapshotThreshold uint64
+	SnapshotInterval  time.Duration
+
+	// TrailingLogs is the number of entries kept in the log after a
+	// snapshot.
+	TrailingLogs uint64
+
+	// LogGrowthRate is the recent number of entries appended to the log per
+	// second.
+	LogGrowthRate float64
+
+	// LastSnapshotBytes and LastSnapshotDuration describe the last snapshot
+	// written by the server since it started, if any.
+	LastSnapshotBytes    int64
+	LastSnapshotDuration time.Duration
+
+	// UsageFirstIndex is the first index of the entries Usage is computed
+	// from. Only the most recent entries of long logs are scanned.
+	UsageFirstIndex uint64
+
+	// Usage is the share of the log taken by each type of entry, sorted by
+	// decreasing size.
+	Usage []RaftLogTypeUsage
+
+	// Servers are the reports of the other servers compacted along with the
+	// leader, sorted by name.
+	Servers []RaftCompactionReport `json:",omitempty"`
+
+	// Error is why the server couldn't be compacted.
+	Error string `json:",omitempty"`
+}
+
+// RaftGetCompactionReport is used to report on the Raft log of the leader, or
+// of the server receiving the request when AllowStale is set.
+func (op *Operator) RaftGetCompactionReport(q *QueryOptions) (*RaftCompactionReport, error) {
+	r := op.c.newRequest("GET", "/v1/operator/raft/compaction")
+	r.setQueryOptions(q)
+	_, resp, err := op.c.doRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, err
+	}
+
+	var out RaftCompactionReport
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RaftCompact is used to take a snapshot on every server, compacting their Raft
+// log down to the trailing logs. It returns the report on the log of the leader
+// afterwards, along with those of the other servers.
+func (op *Operator) RaftCompact(q *WriteOptions) (*RaftCompactionReport, error) {
+	r := op.c.newRequest("PUT", "/v1/operator/raft/compaction")
+	r.setWriteOptions(q)
+	_, resp, err := op.c.doRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, err
+	}
+
+	var out RaftCompactionReport
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RaftLogEntry describes an entry of the Raft log.
+type RaftLogEntry struct {
+	// Index and Term are the Raft index and term of the entry.
+	Index uint64
+	Term  uint64
+
+	// Type is the message type of the entry, such as "Register" or "KVS".
+	// Entries that aren't FSM commands are reported by their Raft log type,
+	// and the parts of a chunked command as "Chunk".
+	Type string
+
+	// Size is the size of the entry in bytes.
+	Size int
+
+	// Summary is a short description of the entry, such as the key of a
+	// KVS entry. It is empty for the types that aren't summarized, and
+	// unless the token has operator:write access and can read the resources
+	// of the entry.
+	Summary string
+
+	// AppendedAt is when the leader appended the entry to its log, if known.
+	AppendedAt time.Time
+}
+
+// RaftLogs is a page of the Raft log of a server.
+type RaftLogs struct {
+	// Server is the node name of the server that answered the request.
+	Server string
+
+	// FirstIndex is the first index of the log on disk, and AppliedIndex
+	// the index of the last entry applied to the FSM.
+	FirstIndex   uint64
+	AppliedIndex uint64
+
+	// Entries are the entries of the page, sorted by decreasing index.
+	Entries []RaftLogEntry
+
+	// NextIndex is the index to request the next page of older entries
+	// with, or zero when the first entry on disk was reached.
+	NextIndex uint64
+}
+
+// RaftGetLogs is used to page through the Raft log of the leader, or of the
+// server receiving the request when AllowStale is set. The page ends at the
+// given index, or at the last entry applied to the FSM when it is 0. A limit
+// of 0 uses the server default.
+func (op *Operator) RaftGetLogs(index uint64, limit int, q *QueryOptions) (*RaftLogs, error) {
+	r := op.c.newRequest("GET", "/v1/operator/raft/logs")
+	r.setQueryOptions(q)
+	if index > 0 {
+		r.params.Set("index", strconv.FormatUint(index, 10))
+	}
+	if limit > 0 {
+		r.params.Set("limit", strconv.Itoa(limit))
+	}
+	_, resp, err := op.c.doRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, err
+	}
+
+	var out RaftLogs
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RaftLogReplayError describes an entry that failed to apply during a
+// replay.
+type RaftLogReplayError struct {
+	// Index is the Raft index of the entry.
+	Index uint64
+
+	// Type is the message type of the entry.
+	Type string
+
+	// Error is the error returned by the FSM.
+	Error string
+}
+
+// RaftLogsReplay is the result of replaying a range of the Raft log of a
+// server into a scratch FSM.
+type RaftLogsReplay struct {
+	// Server is the node name of the server that answered the request.
+	Server string
+
+	// StartIndex and EndIndex are the bounds of the replayed range.
+	StartIndex uint64
+	EndIndex   uint64
+
+	// Applied is the number of commands applied to the scratch FSM.
+	Applied int
+
+	// Errors are the entries which failed to apply.
+	Errors []RaftLogReplayError
+
+	// Indexes is the index of the last change of each table of the scratch
+	// state store.
+	Indexes map[string]uint64
+
+	// Nodes, Services, ServiceInstances and KVEntries count the rows of the
+	// scratch state store.
+	Nodes            int
+	Services         int
+	ServiceInstances int
+	KVEntries        int
+}
+
+// RaftReplayLogs is used to replay the Raft log of a follower, or of the
+// server receiving the request when AllowStale is set, between start and end
+// into a scratch FSM. An end of 0 replays up to the last entry applied to the
+// FSM. The state of the server is left untouched.
+func (op *Operator) RaftReplayLogs(start, end uint64, q *QueryOptions) (*RaftLogsReplay, error) {
+	r := op.c.newRequest("GET", "/v1/operator/raft/logs/replay")
+	r.setQueryOptions(q)
+	r.params.Set("start", strconv.FormatUint(start, 10))
+	if end > 0 {
+		r.params.Set("end", strconv.FormatUint(end, 10))
+	}
+	_, resp, err := op.c.doRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, err
+	}
+
+	var out RaftLogsReplay
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 // RaftRemovePeerByAddress is used to kick a stale peer (one that it in the Raft
 // quorum but no longer known to Serf or the catalog) by address in the form of
 // "IP:port".
@@ -94,3 +401,110 @@ func (op *Operator) RaftRemovePeerByID(id string, q *WriteOptions) error {
 	}
 	return nil
 }
+
+const (
+	// RaftTransferLeaderChecked is the status of the event holding the
+	// results of the pre-flight checks of a leadership transfer.
+	RaftTransferLeaderChecked = "checked"
+
+	// RaftTransferLeaderTransferring is the status of the event sent when
+	// the leadership transfer starts.
+	RaftTransferLeaderTransferring = "transferring"
+
+	// RaftTransferLeaderTransferred is the status of the event sent once the
+	// candidate became the leader.
+	RaftTransferLeaderTransferred = "transferred"
+
+	// RaftTransferLeaderFailed is the status of the event sent when the
+	// pre-flight checks or the transfer failed.
+	RaftTransferLeaderFailed = "failed"
+)
+
+// RaftTransferCheck is the result of a pre-flight check of a leadership
+// transfer.
+type RaftTransferCheck struct {
+	// Name is the name of the check, such as "Health", "Replication" or
+	// "Version".
+	Name string
+
+	// Passed is true when the candidate passed the check.
+	Passed bool
+
+	// Output describes the result of the check.
+	Output string
+}
+
+// RaftTransferLeaderEvent is an update of the status of a leadership
+// transfer.
+type RaftTransferLeaderEvent struct {
+	// Status is one of the RaftTransferLeader* constants.
+	Status string
+
+	// ID and Node describe the server chosen to become the leader.
+	ID   string
+	Node string
+
+	// Checks are the results of the pre-flight checks, sent with the
+	// "checked" status.
+	Checks []RaftTransferCheck
+
+	// Leader is the node name of the new leader, sent with the "transferred"
+	// status.
+	Leader string
+
+	// Error is why the transfer failed, sent with the "failed" status.
+	Error string
+}
+
+// RaftTransferLeaderOptions are the options of a leadership transfer.
+type RaftTransferLeaderOptions struct {
+	// DryRun only runs the pre-flight checks.
+	DryRun bool
+
+	// Force transfers the leadership even when the pre-flight checks fail.
+	Force bool
+}
+
+// RaftTransferLeader transfers the leadership of the cluster to the server
+// with the given ID, or to the best candidate when id is empty, once the
+// pre-flight checks passed. It returns the events of the transfer, and an
+// error when it failed.
+func (op *Operator) RaftTransferLeader(id string, opts *RaftTransferLeaderOptions, q *WriteOptions) ([]*RaftTransferLeaderEvent, error) {
+	r := op.c.newRequest("PUT", "/v1/operator/raft/transfer-leader")
+	r.setWriteOptions(q)
+	if id != "" {
+		r.params.Set("id", id)
+	}
+	if opts != nil && opts.DryRun {
+		r.params.Set("dry-run", "")
+	}
+	if opts != nil && opts.Force {
+		r.params.Set("force", "")
+	}
+
+	_, resp, err := op.c.doRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, err
+	}
+
+	var events []*RaftTransferLeaderEvent
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var event RaftTransferLeaderEvent
+		if err := dec.Decode(&event); err == io.EOF {
+			break
+		} else if err != nil {
+			return events, err
+		}
+		events = append(events, &event)
+	}
+
+	if len(events) > 0 && events[len(events)-1].Status == RaftTransferLeaderFailed {
+		return events, fmt.Errorf("leadership transfer failed: %s", events[len(events)-1].Error)
+	}
+	return events, nil
+}
@@ -22,6 +22,80 @@ func TestAPI_OperatorRaftGetConfiguration(t *testing.T) {
 	}
 }
 
+func TestAPI_OperatorRaftGetLargestEntries(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	operator := c.Operator()
+	out, err := operator.RaftGetLargestEntries(1, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Server == "" || len(out.Entries) != 1 || out.Entries[0].Size == 0 {
+		t.Fatalf("bad: %v", out)
+	}
+}
+
+func TestAPI_OperatorRaftCompaction(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	operator := c.Operator()
+	report, err := operator.RaftGetCompactionReport(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if report.Server == "" || report.LastIndex == 0 || len(report.Usage) == 0 {
+		t.Fatalf("bad: %v", report)
+	}
+
+	report, err = operator.RaftCompact(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if report.LastSnapshotIndex != report.LastIndex {
+		t.Fatalf("bad: %v", report)
+	}
+}
+
+func TestAPI_OperatorRaftLogs(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	operator := c.Operator()
+	logs, err := operator.RaftGetLogs(0, 2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if logs.Server == "" || len(logs.Entries) != 2 || logs.Entries[0].Index != logs.AppliedIndex {
+		t.Fatalf("bad: %v", logs)
+	}
+
+	replay, err := operator.RaftReplayLogs(logs.FirstIndex, 0, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if replay.StartIndex != logs.FirstIndex || replay.Applied == 0 {
+		t.Fatalf("bad: %v", replay)
+	}
+}
+
+func TestAPI_OperatorRaftTransferLeader(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	// A single server has no one to transfer the leadership to.
+	operator := c.Operator()
+	_, err := operator.RaftTransferLeader("", &RaftTransferLeaderOptions{DryRun: true}, nil)
+	if err == nil || !strings.Contains(err.Error(), "there is no other voter") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestAPI_OperatorRaftRemovePeerByAddress(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
@@ -0,0 +1,65 @@
+package api
+
+import "time"
+
+// OperatorUsage is the contents of the state store of a server.
+type OperatorUsage struct {
+	// Server is the node name of the server that answered the request.
+	Server string
+
+	Nodes            int
+	Services         int
+	ServiceInstances int
+	KVEntries        int
+
+	// ConfigEntries is the number of config entries of each kind.
+	ConfigEntries map[string]int
+
+	// Tables is the usage of each table of the state store, sorted by name.
+	// It is only set for detailed requests, and is computed periodically,
+	// TablesUpdatedAt being the last time it was.
+	Tables          []TableUsage
+	TablesUpdatedAt time.Time
+}
+
+// TableUsage describes the rows of a state store table.
+type TableUsage struct {
+	// Table is the name of the table.
+	Table string
+
+	// Rows is the number of rows in the table.
+	Rows int
+
+	// Bytes is an estimate of the memory used by the rows of the table. It
+	// doesn't include the memory used by the indexes.
+	Bytes int64
+}
+
+// Usage is used to report the contents of the state store. When detailed is
+// set the number of rows and estimated memory of each table are also
+// reported.
+func (op *Operator) Usage(detailed bool, q *QueryOptions) (*OperatorUsage, *QueryMeta, error) {
+	r := op.c.newRequest("GET", "/v1/operator/usage")
+	r.setQueryOptions(q)
+	if detailed {
+		r.params.Set("detailed", "true")
+	}
+	rtt, resp, err := op.c.doRequest(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, nil, err
+	}
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out OperatorUsage
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out, qm, nil
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_OperatorUsage(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+	s.WaitForSerfCheck(t)
+
+	operator := c.Operator()
+	usage, qm, err := operator.Usage(false, nil)
+	require.NoError(t, err)
+	require.NotZero(t, qm.LastIndex)
+	require.Equal(t, 1, usage.Nodes)
+	require.Empty(t, usage.Tables)
+
+	usage, _, err = operator.Usage(true, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, usage.Tables)
+	require.False(t, usage.TablesUpdatedAt.IsZero())
+}
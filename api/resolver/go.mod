@@ -0,0 +1,23 @@
+module github.com/hashicorp/consul/api/resolver
+
+go 1.17
+
+replace github.com/hashicorp/consul/proto-public => ../../proto-public
+
+require (
+	github.com/hashicorp/consul/proto-public v0.1.0
+	github.com/stretchr/testify v1.7.0
+	google.golang.org/grpc v1.37.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/golang/protobuf v1.5.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.0.0-20211216030914-fe4d6282115f // indirect
+	golang.org/x/sys v0.0.0-20220412211240-33da011f77ad // indirect
+	golang.org/x/text v0.3.6 // indirect
+	google.golang.org/genproto v0.0.0-20201102152239-715cce707fb0 // indirect
+	google.golang.org/protobuf v1.27.1 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
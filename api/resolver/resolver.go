@@ -0,0 +1,253 @@
+// Package resolver provides a gRPC resolver watching the endpoints of services
+// registered in Consul, so that gRPC clients can balance their requests over
+// the healthy instances of a service without relying on DNS.
+//
+// The resolver resolves the targets with the "consul" scheme, naming the
+// service to watch and optionally filtering its instances:
+//
+//	builder, err := resolver.NewBuilder(resolver.Config{
+//		Address: "consul-server:8502",
+//		Token:   token,
+//	})
+//	...
+//	conn, err := grpc.Dial("consul:///web?tag=primary",
+//		grpc.WithResolvers(builder),
+//		grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy": "round_robin"}`),
+//	)
+//
+// The supported query parameters are "tag", which can be repeated,
+// "namespace", "partition", "connect" and "include-unhealthy".
+//
+// The weight of each endpoint is set as its weightedroundrobin.AddrInfo, for
+// the balancers supporting it. The round_robin balancer ignores it.
+package resolver
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer/weightedroundrobin"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	grpcresolver "google.golang.org/grpc/resolver"
+
+	"github.com/hashicorp/consul/proto-public/pbdiscovery"
+)
+
+// Scheme is the scheme of the targets resolved by the Builder.
+const Scheme = "consul"
+
+const (
+	// metadataKeyToken is the gRPC metadata holding the ACL token.
+	metadataKeyToken = "x-consul-token"
+
+	minRetryWait = 1 * time.Second
+	maxRetryWait = 30 * time.Second
+)
+
+// Config is the configuration of a Builder.
+type Config struct {
+	// Address is the address of the gRPC port of the Consul servers.
+	Address string
+
+	// Token is the ACL token used to watch the services. It needs read
+	// access to the services and to the nodes they are registered on.
+	Token string
+
+	// TLSConfig is used to connect to Consul over TLS. The connection is
+	// not encrypted when it is nil.
+	TLSConfig *tls.Config
+
+	// Namespace and Partition are the default namespace and admin partition
+	// of the services (Consul Enterprise only).
+	Namespace string
+	Partition string
+
+	// DialOptions are added to the options used to connect to Consul.
+	DialOptions []grpc.DialOption
+}
+
+// Builder builds the resolvers of the targets with the "consul" scheme. All
+// its resolvers share a single connection to Consul.
+type Builder struct {
+	config Config
+	conn   *grpc.ClientConn
+	client pbdiscovery.DiscoveryServiceClient
+}
+
+// NewBuilder returns a Builder connecting to Consul with the given
+// configuration. It must be closed once the connections using it are.
+func NewBuilder(config Config) (*Builder, error) {
+	if config.Address == "" {
+		return nil, fmt.Errorf("the address of Consul is required")
+	}
+
+	opts := []grpc.DialOption{grpc.WithInsecure()}
+	if config.TLSConfig != nil {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(config.TLSConfig))}
+	}
+	conn, err := grpc.Dial(config.Address, append(opts, config.DialOptions...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Consul: %w", err)
+	}
+
+	return &Builder{
+		config: config,
+		conn:   conn,
+		client: pbdiscovery.NewDiscoveryServiceClient(conn),
+	}, nil
+}
+
+// Scheme implements resolver.Builder.
+func (b *Builder) Scheme() string {
+	return Scheme
+}
+
+// Build implements resolver.Builder.
+func (b *Builder) Build(target grpcresolver.Target, cc grpcresolver.ClientConn, _ grpcresolver.BuildOptions) (grpcresolver.Resolver, error) {
+	req, err := b.parseTarget(target.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if b.config.Token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, metadataKeyToken, b.config.Token)
+	}
+	r := &endpointsResolver{
+		client: b.client,
+		req:    req,
+		cc:     cc,
+		cancel: cancel,
+	}
+	r.wg.Add(1)
+	go r.watch(ctx)
+	return r, nil
+}
+
+// Close closes the connection to Consul.
+func (b *Builder) Close() error {
+	return b.conn.Close()
+}
+
+// parseTarget returns the request watching the endpoints of a target, as in
+// "web?tag=primary&include-unhealthy=true".
+func (b *Builder) parseTarget(endpoint string) (*pbdiscovery.WatchEndpointsRequest, error) {
+	service, rawQuery := endpoint, ""
+	if i := strings.Index(endpoint, "?"); i >= 0 {
+		service, rawQuery = endpoint[:i], endpoint[i+1:]
+	}
+	if service == "" {
+		return nil, fmt.Errorf("the target must name a service, as in %s:///web", Scheme)
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target query %q: %w", rawQuery, err)
+	}
+
+	req := &pbdiscovery.WatchEndpointsRequest{
+		Service:   service,
+		Namespace: b.config.Namespace,
+		Partition: b.config.Partition,
+		Tags:      query["tag"],
+	}
+	for key, values := range query {
+		value := values[len(values)-1]
+		switch key {
+		case "tag":
+		case "namespace":
+			req.Namespace = value
+		case "partition":
+			req.Partition = value
+		case "connect":
+			if req.Connect, err = strconv.ParseBool(value); err != nil {
+				return nil, fmt.Errorf("invalid value %q for connect: %w", value, err)
+			}
+		case "include-unhealthy":
+			if req.IncludeUnhealthy, err = strconv.ParseBool(value); err != nil {
+				return nil, fmt.Errorf("invalid value %q for include-unhealthy: %w", value, err)
+			}
+		default:
+			return nil, fmt.Errorf("unknown target parameter %q", key)
+		}
+	}
+	return req, nil
+}
+
+// endpointsResolver updates the addresses of a gRPC connection with the
+// endpoints of a service.
+type endpointsResolver struct {
+	client pbdiscovery.DiscoveryServiceClient
+	req    *pbdiscovery.WatchEndpointsRequest
+	cc     grpcresolver.ClientConn
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// watch streams the endpoints until ctx is canceled, connecting again with a
+// backoff when the stream fails.
+func (r *endpointsResolver) watch(ctx context.Context) {
+	defer r.wg.Done()
+
+	wait := minRetryWait
+	for {
+		err := r.stream(ctx, func() { wait = minRetryWait })
+		if ctx.Err() != nil {
+			return
+		}
+		r.cc.ReportError(fmt.Errorf("failed to watch the endpoints of %q: %w", r.req.Service, err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > maxRetryWait {
+			wait = maxRetryWait
+		}
+	}
+}
+
+// stream updates the connection with the endpoints received on a stream until
+// it fails, calling received on every update.
+func (r *endpointsResolver) stream(ctx context.Context, received func()) error {
+	stream, err := r.client.WatchEndpoints(ctx, r.req)
+	if err != nil {
+		return err
+	}
+	for {
+		rsp, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		received()
+
+		addrs := make([]grpcresolver.Address, 0, len(rsp.Endpoints))
+		for _, e := range rsp.Endpoints {
+			addr := grpcresolver.Address{
+				Addr: net.JoinHostPort(e.Address, strconv.Itoa(int(e.Port))),
+			}
+			addrs = append(addrs, weightedroundrobin.SetAddrInfo(addr, weightedroundrobin.AddrInfo{Weight: e.Weight}))
+		}
+		r.cc.UpdateState(grpcresolver.State{Addresses: addrs})
+	}
+}
+
+// ResolveNow implements resolver.Resolver. The endpoints are always up to date
+// so there is nothing to do.
+func (r *endpointsResolver) ResolveNow(grpcresolver.ResolveNowOptions) {}
+
+// Close implements resolver.Resolver.
+func (r *endpointsResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
@@ -0,0 +1,126 @@
+package resolver
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer/weightedroundrobin"
+	"google.golang.org/grpc/metadata"
+	grpcresolver "google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/serviceconfig"
+
+	"github.com/hashicorp/consul/proto-public/pbdiscovery"
+)
+
+type fakeDiscoveryServer struct {
+	reqs   chan *pbdiscovery.WatchEndpointsRequest
+	tokens chan string
+	rsps   chan *pbdiscovery.WatchEndpointsResponse
+}
+
+func (s *fakeDiscoveryServer) WatchEndpoints(req *pbdiscovery.WatchEndpointsRequest, stream pbdiscovery.DiscoveryService_WatchEndpointsServer) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	s.tokens <- md.Get(metadataKeyToken)[0]
+	s.reqs <- req
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case rsp := <-s.rsps:
+			if err := stream.Send(rsp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+type fakeClientConn struct {
+	states chan grpcresolver.State
+}
+
+func (cc *fakeClientConn) UpdateState(state grpcresolver.State) { cc.states <- state }
+func (cc *fakeClientConn) ReportError(error)                    {}
+func (cc *fakeClientConn) NewAddress([]grpcresolver.Address)    {}
+func (cc *fakeClientConn) NewServiceConfig(string)              {}
+func (cc *fakeClientConn) ParseServiceConfig(string) *serviceconfig.ParseResult {
+	return nil
+}
+
+func TestBuilder(t *testing.T) {
+	fake := &fakeDiscoveryServer{
+		reqs:   make(chan *pbdiscovery.WatchEndpointsRequest, 1),
+		tokens: make(chan string, 1),
+		rsps:   make(chan *pbdiscovery.WatchEndpointsResponse),
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	pbdiscovery.RegisterDiscoveryServiceServer(srv, fake)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	builder, err := NewBuilder(Config{Address: lis.Addr().String(), Token: "the-token", Namespace: "default"})
+	require.NoError(t, err)
+	t.Cleanup(func() { builder.Close() })
+	require.Equal(t, "consul", builder.Scheme())
+
+	cc := &fakeClientConn{states: make(chan grpcresolver.State, 1)}
+	r, err := builder.Build(grpcresolver.Target{
+		Scheme:   Scheme,
+		Endpoint: "web?tag=primary&tag=v2&connect=true",
+	}, cc, grpcresolver.BuildOptions{})
+	require.NoError(t, err)
+	t.Cleanup(r.Close)
+
+	select {
+	case req := <-fake.reqs:
+		require.Equal(t, "web", req.Service)
+		require.Equal(t, "default", req.Namespace)
+		require.Equal(t, []string{"primary", "v2"}, req.Tags)
+		require.True(t, req.Connect)
+		require.False(t, req.IncludeUnhealthy)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the endpoints were not watched")
+	}
+	require.Equal(t, "the-token", <-fake.tokens)
+
+	fake.rsps <- &pbdiscovery.WatchEndpointsResponse{
+		Endpoints: []*pbdiscovery.Endpoint{
+			{Address: "10.0.0.1", Port: 8080, Weight: 3},
+			{Address: "::1", Port: 8081, Weight: 1},
+		},
+	}
+	select {
+	case state := <-cc.states:
+		require.Len(t, state.Addresses, 2)
+		require.Equal(t, "10.0.0.1:8080", state.Addresses[0].Addr)
+		require.Equal(t, uint32(3), weightedroundrobin.GetAddrInfo(state.Addresses[0]).Weight)
+		require.Equal(t, "[::1]:8081", state.Addresses[1].Addr)
+		require.Equal(t, uint32(1), weightedroundrobin.GetAddrInfo(state.Addresses[1]).Weight)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the addresses were not updated")
+	}
+}
+
+func TestBuilder_parseTarget(t *testing.T) {
+	b := &Builder{config: Config{Partition: "default"}}
+
+	req, err := b.parseTarget("web?namespace=ns&include-unhealthy=true")
+	require.NoError(t, err)
+	require.Equal(t, "web", req.Service)
+	require.Equal(t, "ns", req.Namespace)
+	require.Equal(t, "default", req.Partition)
+	require.True(t, req.IncludeUnhealthy)
+
+	_, err = b.parseTarget("?tag=primary")
+	require.EqualError(t, err, "the target must name a service, as in consul:///web")
+
+	_, err = b.parseTarget("web?connect=maybe")
+	require.Error(t, err)
+
+	_, err = b.parseTarget("web?dc=dc2")
+	require.EqualError(t, err, `unknown target parameter "dc"`)
+}
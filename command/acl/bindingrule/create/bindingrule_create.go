@@ -23,11 +23,12 @@ type cmd struct {
 	http  *flags.HTTPFlags
 	help  string
 
-	authMethodName string
-	description    string
-	selector       string
-	bindType       string
-	bindName       string
+	authMethodName     string
+	description        string
+	selector           string
+	bindType           string
+	bindName           string
+	bindNameExpression string
 
 	showMeta bool
 	format   string
@@ -75,7 +76,15 @@ func (c *cmd) init() {
 		"bind-name",
 		"",
 		"Name to bind on match. Can use ${var} interpolation. "+
-			"This flag is required.",
+			"This flag or -bind-name-expression is required.",
+	)
+	c.flags.StringVar(
+		&c.bindNameExpression,
+		"bind-name-expression",
+		"",
+		"CEL expression computing the name to bind on match from the map of "+
+			"projected variables named \"vars\". The binding is skipped when it "+
+			"evaluates to an empty string. Cannot be used with -bind-name.",
 	)
 	c.flags.StringVar(
 		&c.format,
@@ -104,18 +113,19 @@ func (c *cmd) Run(args []string) int {
 		c.UI.Error(fmt.Sprintf("Missing required '-bind-type' flag"))
 		c.UI.Error(c.Help())
 		return 1
-	} else if c.bindName == "" {
-		c.UI.Error(fmt.Sprintf("Missing required '-bind-name' flag"))
+	} else if c.bindName == "" && c.bindNameExpression == "" {
+		c.UI.Error(fmt.Sprintf("Missing required '-bind-name' or '-bind-name-expression' flag"))
 		c.UI.Error(c.Help())
 		return 1
 	}
 
 	newRule := &api.ACLBindingRule{
-		Description: c.description,
-		AuthMethod:  c.authMethodName,
-		BindType:    api.BindingRuleBindType(c.bindType),
-		BindName:    c.bindName,
-		Selector:    c.selector,
+		Description:        c.description,
+		AuthMethod:         c.authMethodName,
+		BindType:           api.BindingRuleBindType(c.bindType),
+		BindName:           c.bindName,
+		BindNameExpression: c.bindNameExpression,
+		Selector:           c.selector,
 	}
 
 	client, err := c.http.APIClient()
@@ -168,4 +178,11 @@ Usage: consul acl binding-rule create [options]
           -bind-type=service \
           -bind-name='k8s-${serviceaccount.name}' \
           -selector='serviceaccount.namespace==default and serviceaccount.name==web'
+
+  Create a new binding rule computing the bind name with a CEL expression:
+
+    $ consul acl binding-rule create \
+          -method=minikube \
+          -bind-type=service \
+          -bind-name-expression='vars["serviceaccount.name"].lowerAscii()'
 `
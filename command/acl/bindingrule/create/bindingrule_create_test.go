@@ -100,7 +100,7 @@ func TestBindingRuleCreateCommand(t *testing.T) {
 
 		code := cmd.Run(args)
 		require.Equal(t, code, 1)
-		require.Contains(t, ui.ErrorWriter.String(), "Missing required '-bind-name' flag")
+		require.Contains(t, ui.ErrorWriter.String(), "Missing required '-bind-name' or '-bind-name-expression' flag")
 	})
 
 	t.Run("must use roughly valid selector", func(t *testing.T) {
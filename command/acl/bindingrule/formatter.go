@@ -60,6 +60,9 @@ func (f *prettyFormatter) FormatBindingRule(rule *api.ACLBindingRule) (string, e
 	buffer.WriteString(fmt.Sprintf("Description:  %s\n", rule.Description))
 	buffer.WriteString(fmt.Sprintf("BindType:     %s\n", rule.BindType))
 	buffer.WriteString(fmt.Sprintf("BindName:     %s\n", rule.BindName))
+	if rule.BindNameExpression != "" {
+		buffer.WriteString(fmt.Sprintf("BindNameExpression: %s\n", rule.BindNameExpression))
+	}
 	buffer.WriteString(fmt.Sprintf("Selector:     %s\n", rule.Selector))
 	if f.showMeta {
 		buffer.WriteString(fmt.Sprintf("Create Index: %d\n", rule.CreateIndex))
@@ -93,6 +96,9 @@ func (f *prettyFormatter) formatBindingRuleListEntry(rule *api.ACLBindingRule) s
 	buffer.WriteString(fmt.Sprintf("   Description:  %s\n", rule.Description))
 	buffer.WriteString(fmt.Sprintf("   BindType:     %s\n", rule.BindType))
 	buffer.WriteString(fmt.Sprintf("   BindName:     %s\n", rule.BindName))
+	if rule.BindNameExpression != "" {
+		buffer.WriteString(fmt.Sprintf("   BindNameExpression: %s\n", rule.BindNameExpression))
+	}
 	buffer.WriteString(fmt.Sprintf("   Selector:     %s\n", rule.Selector))
 	if f.showMeta {
 		buffer.WriteString(fmt.Sprintf("   Create Index: %d\n", rule.CreateIndex))
@@ -27,10 +27,11 @@ type cmd struct {
 
 	ruleID string
 
-	description string
-	selector    string
-	bindType    string
-	bindName    string
+	description        string
+	selector           string
+	bindType           string
+	bindName           string
+	bindNameExpression string
 
 	noMerge  bool
 	showMeta bool
@@ -80,7 +81,15 @@ func (c *cmd) init() {
 		"bind-name",
 		"",
 		"Name to bind on match. Can use ${var} interpolation. "+
-			"This flag is required.",
+			"This flag or -bind-name-expression is required.",
+	)
+	c.flags.StringVar(
+		&c.bindNameExpression,
+		"bind-name-expression",
+		"",
+		"CEL expression computing the name to bind on match from the map of "+
+			"projected variables named \"vars\". The binding is skipped when it "+
+			"evaluates to an empty string. Cannot be used with -bind-name.",
 	)
 
 	c.flags.BoolVar(
@@ -144,19 +153,20 @@ func (c *cmd) Run(args []string) int {
 			c.UI.Error(fmt.Sprintf("Missing required '-bind-type' flag"))
 			c.UI.Error(c.Help())
 			return 1
-		} else if c.bindName == "" {
-			c.UI.Error(fmt.Sprintf("Missing required '-bind-name' flag"))
+		} else if c.bindName == "" && c.bindNameExpression == "" {
+			c.UI.Error(fmt.Sprintf("Missing required '-bind-name' or '-bind-name-expression' flag"))
 			c.UI.Error(c.Help())
 			return 1
 		}
 
 		rule = &api.ACLBindingRule{
-			ID:          ruleID,
-			AuthMethod:  currentRule.AuthMethod, // immutable
-			Description: c.description,
-			BindType:    api.BindingRuleBindType(c.bindType),
-			BindName:    c.bindName,
-			Selector:    c.selector,
+			ID:                 ruleID,
+			AuthMethod:         currentRule.AuthMethod, // immutable
+			Description:        c.description,
+			BindType:           api.BindingRuleBindType(c.bindType),
+			BindName:           c.bindName,
+			BindNameExpression: c.bindNameExpression,
+			Selector:           c.selector,
 		}
 
 	} else {
@@ -168,8 +178,15 @@ func (c *cmd) Run(args []string) int {
 		if c.bindType != "" {
 			rule.BindType = api.BindingRuleBindType(c.bindType)
 		}
+		// The bind name and its expression are exclusive, setting one clears
+		// the other.
 		if c.bindName != "" {
 			rule.BindName = c.bindName
+			rule.BindNameExpression = ""
+		}
+		if c.bindNameExpression != "" {
+			rule.BindNameExpression = c.bindNameExpression
+			rule.BindName = ""
 		}
 		if isFlagSet(c.flags, "selector") {
 			rule.Selector = c.selector // empty is valid
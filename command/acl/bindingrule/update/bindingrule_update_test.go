@@ -766,7 +766,7 @@ func TestBindingRuleUpdateCommand_noMerge(t *testing.T) {
 
 		code := cmd.Run(args)
 		require.Equal(t, code, 1)
-		require.Contains(t, ui.ErrorWriter.String(), "Missing required '-bind-name' flag")
+		require.Contains(t, ui.ErrorWriter.String(), "Missing required '-bind-name' or '-bind-name-expression' flag")
 	})
 
 	t.Run("update all fields but selector", func(t *testing.T) {
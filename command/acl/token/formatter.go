@@ -73,6 +73,16 @@ func (f *prettyFormatter) FormatToken(token *api.ACLToken) (string, error) {
 	if token.ExpirationTime != nil && !token.ExpirationTime.IsZero() {
 		buffer.WriteString(fmt.Sprintf("Expiration Time:  %v\n", *token.ExpirationTime))
 	}
+	if token.Usage != nil {
+		buffer.WriteString(fmt.Sprintf("Last Used:        %v\n", token.Usage.LastUsed))
+		buffer.WriteString(fmt.Sprintf("Request Count:    %d\n", token.Usage.RequestCount))
+		if len(token.Usage.Sources) > 0 {
+			buffer.WriteString(fmt.Sprintln("Used From:"))
+			for _, src := range token.Usage.Sources {
+				buffer.WriteString(fmt.Sprintf("   %s - %d requests, last at %v\n", src.Address, src.RequestCount, src.LastUsed))
+			}
+		}
+	}
 	if f.showMeta {
 		buffer.WriteString(fmt.Sprintf("Hash:             %x\n", token.Hash))
 		buffer.WriteString(fmt.Sprintf("Create Index:     %d\n", token.CreateIndex))
@@ -134,6 +144,16 @@ func (f *prettyFormatter) FormatTokenExpanded(token *api.ACLTokenExpanded) (stri
 	if token.ExpirationTime != nil && !token.ExpirationTime.IsZero() {
 		buffer.WriteString(fmt.Sprintf("Expiration Time:  %v\n", *token.ExpirationTime))
 	}
+	if token.Usage != nil {
+		buffer.WriteString(fmt.Sprintf("Last Used:        %v\n", token.Usage.LastUsed))
+		buffer.WriteString(fmt.Sprintf("Request Count:    %d\n", token.Usage.RequestCount))
+		if len(token.Usage.Sources) > 0 {
+			buffer.WriteString(fmt.Sprintln("Used From:"))
+			for _, src := range token.Usage.Sources {
+				buffer.WriteString(fmt.Sprintf("   %s - %d requests, last at %v\n", src.Address, src.RequestCount, src.LastUsed))
+			}
+		}
+	}
 	if f.showMeta {
 		buffer.WriteString(fmt.Sprintf("Hash:             %x\n", token.Hash))
 		buffer.WriteString(fmt.Sprintf("Create Index:     %d\n", token.CreateIndex))
@@ -303,6 +323,10 @@ func (f *prettyFormatter) formatTokenListEntry(token *api.ACLTokenListEntry) str
 	if token.ExpirationTime != nil && !token.ExpirationTime.IsZero() {
 		buffer.WriteString(fmt.Sprintf("Expiration Time:  %v\n", *token.ExpirationTime))
 	}
+	if token.Usage != nil {
+		buffer.WriteString(fmt.Sprintf("Last Used:        %v\n", token.Usage.LastUsed))
+		buffer.WriteString(fmt.Sprintf("Request Count:    %d\n", token.Usage.RequestCount))
+	}
 	buffer.WriteString(fmt.Sprintf("Legacy:           %t\n", token.Legacy))
 	if f.showMeta {
 		buffer.WriteString(fmt.Sprintf("Hash:             %x\n", token.Hash))
@@ -64,6 +64,26 @@ func TestFormatToken(t *testing.T) {
 				Rules:       `operator = "read"`,
 			},
 		},
+		"usage": {
+			token: api.ACLToken{
+				AccessorID:  "fbd2447f-7479-4329-ad13-b021d74f86ba",
+				SecretID:    "869c6e91-4de9-4dab-b56e-87548435f9c6",
+				Description: "test token",
+				Local:       false,
+				CreateTime:  time.Date(2020, 5, 22, 18, 52, 31, 0, time.UTC),
+				Hash:        []byte{'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h'},
+				CreateIndex: 42,
+				ModifyIndex: 100,
+				Usage: &api.ACLTokenUsage{
+					LastUsed:     time.Date(2020, 6, 1, 9, 12, 45, 0, time.UTC),
+					RequestCount: 1234,
+					Sources: []api.ACLTokenUsageSource{
+						{Address: "10.0.0.1", LastUsed: time.Date(2020, 6, 1, 9, 12, 45, 0, time.UTC), RequestCount: 1200},
+						{Address: "10.0.0.2", LastUsed: time.Date(2020, 5, 30, 17, 2, 3, 0, time.UTC), RequestCount: 34},
+					},
+				},
+			},
+		},
 		"complex": {
 			token: api.ACLToken{
 				AccessorID:          "fbd2447f-7479-4329-ad13-b021d74f86ba",
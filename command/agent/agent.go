@@ -329,7 +329,11 @@ func (c *cmd) run(args []string) int {
 				close(gracefulCh)
 			}()
 
-			gracefulTimeout := 15 * time.Second
+			// Leave room for draining the local services.
+			gracefulTimeout := 15*time.Second + config.GracefulShutdownDrainDelay
+			for _, hook := range config.GracefulShutdownDrainHooks {
+				gracefulTimeout += hook.Timeout
+			}
 			select {
 			case <-signalCh:
 				c.logger.Info("Caught second signal, Exiting", "signal", sig)
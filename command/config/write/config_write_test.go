@@ -3171,7 +3171,7 @@ func TestParseConfigEntry(t *testing.T) {
 
 		testbody := func(t *testing.T, body string, expect api.ConfigEntry) {
 			t.Helper()
-			got, err := parseConfigEntry(body)
+			got, err := ParseConfigEntry(body)
 			if tc.expectErr != "" {
 				require.Nil(t, got)
 				require.Error(t, err)
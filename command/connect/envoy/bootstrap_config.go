@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -149,8 +150,67 @@ type BootstrapConfig struct {
 	// the bootstrap config. It's format may vary based on Envoy version used.
 	// See https://www.envoyproxy.io/docs/envoy/v1.9.0/api-v2/config/trace/v2/trace.proto.
 	TracingConfigJSON string `mapstructure:"envoy_tracing_json"`
+
+	// OnDemandUpstreamsTTL is how long the upstreams of a transparent proxy in
+	// on-demand mode are kept without traffic. When set, Envoy is configured to
+	// report the load of its clusters to the local agent so that the activity
+	// of the upstreams can be tracked.
+	OnDemandUpstreamsTTL string `mapstructure:"on_demand_upstreams_ttl"`
+
+	// BuiltinMetrics makes the proxy report the requests to its upstreams to
+	// the local agent. When set, Envoy is configured to report the load of its
+	// clusters like for OnDemandUpstreamsTTL, and to flush its stats to the
+	// agent for the time taken by the requests.
+	BuiltinMetrics bool `mapstructure:"builtin_metrics"`
+
+	// DetectUpstreamProtocols makes the proxy detect whether the traffic to
+	// its upstreams without a configured protocol is HTTP. When set, Envoy is
+	// configured to flush its stats to the agent, which reads the detected
+	// protocols from them.
+	DetectUpstreamProtocols bool `mapstructure:"detect_upstream_protocols"`
+
+	// AdminAccessLogPath is the path to write the access log of the Envoy
+	// admin server to. The -admin-access-log-path flag takes precedence when
+	// it is set.
+	AdminAccessLogPath string `mapstructure:"envoy_admin_access_log_path"`
+
+	// StaticRuntime sets runtime keys to the given string, number or boolean
+	// values. They are rendered as a static layer of Envoy's layered runtime,
+	// followed by an admin layer so they can still be changed at runtime.
+	//
+	// See https://www.envoyproxy.io/docs/envoy/latest/configuration/operations/runtime.
+	StaticRuntime map[string]interface{} `mapstructure:"envoy_static_runtime"`
+
+	// TracingCollectorAddress is the host:port of a tracing collector. A
+	// static cluster named TracingCollectorCluster is generated for it, so
+	// that TracingConfigJSON only needs to reference the cluster. Hostnames
+	// are resolved with DNS.
+	TracingCollectorAddress string `mapstructure:"envoy_tracing_collector_address"`
+
+	// TracingCollectorCluster is the name of the cluster generated for
+	// TracingCollectorAddress. Defaults to "tracing_collector".
+	TracingCollectorCluster string `mapstructure:"envoy_tracing_collector_cluster"`
+
+	// StatsSinks configures any number of statsd or DogStatsD sinks. They are
+	// added to the ones configured with StatsdURL, DogstatsdURL and
+	// StatsSinksJSON.
+	StatsSinks []StatsSinkConfig `mapstructure:"envoy_stats_sinks"`
+}
+
+// StatsSinkConfig is a typed stats sink of the bootstrap config.
+type StatsSinkConfig struct {
+	// Type is the type of the sink, either "statsd" or "dogstatsd".
+	Type string `mapstructure:"type"`
+
+	// Address is the address of the sink, in the same forms as StatsdURL.
+	Address string `mapstructure:"address"`
+
+	// Prefix, if set, replaces the default "envoy" prefix of the stats.
+	Prefix string `mapstructure:"prefix"`
 }
 
+const defaultTracingCollectorCluster = "tracing_collector"
+
 // Template returns the bootstrap template to use as a base.
 func (c *BootstrapConfig) Template() string {
 	if c.OverrideJSONTpl != "" {
@@ -211,6 +271,11 @@ func (c *BootstrapConfig) ConfigureArgs(args *BootstrapTplArgs, omitDeprecatedTa
 	if c.StaticListenersJSON != "" {
 		args.StaticListenersJSON = c.StaticListenersJSON
 	}
+	if c.TracingCollectorAddress != "" {
+		if err := c.generateTracingCollectorCluster(args); err != nil {
+			return err
+		}
+	}
 	// Setup prometheus if needed. This MUST happen after the Static*JSON is set above
 	if c.PrometheusBindAddr != "" {
 		if err := c.generateListenerConfig(args, c.PrometheusBindAddr, "envoy_prometheus_metrics", "path", args.PrometheusScrapePath, "/stats/prometheus", args.PrometheusBackendPort); err != nil {
@@ -238,6 +303,22 @@ func (c *BootstrapConfig) ConfigureArgs(args *BootstrapTplArgs, omitDeprecatedTa
 		args.StatsFlushInterval = c.StatsFlushInterval
 	}
 
+	if c.OnDemandUpstreamsTTL != "" || c.BuiltinMetrics {
+		args.LoadStatsEnabled = true
+	}
+
+	if c.AdminAccessLogPath != "" {
+		args.AdminAccessLogPath = jsonStringContent(c.AdminAccessLogPath)
+	}
+
+	if len(c.StaticRuntime) > 0 {
+		runtimeJSON, err := generateLayeredRuntimeJSON(c.StaticRuntime)
+		if err != nil {
+			return err
+		}
+		args.LayeredRuntimeJSON = runtimeJSON
+	}
+
 	return nil
 }
 
@@ -249,6 +330,7 @@ func (c *BootstrapConfig) generateStatsSinks(args *BootstrapTplArgs) error {
 			"envoy.stat_sinks.statsd",
 			"type.googleapis.com/envoy.config.metrics.v3.StatsdSink",
 			c.StatsdURL,
+			"",
 		)
 		if err != nil {
 			return err
@@ -260,15 +342,41 @@ func (c *BootstrapConfig) generateStatsSinks(args *BootstrapTplArgs) error {
 			"envoy.stat_sinks.dog_statsd",
 			"type.googleapis.com/envoy.config.metrics.v3.DogStatsdSink",
 			c.DogstatsdURL,
+			"",
 		)
 		if err != nil {
 			return err
 		}
 		stats_sinks = append(stats_sinks, sinkJSON)
 	}
+	for _, sink := range c.StatsSinks {
+		var name, typeName string
+		switch sink.Type {
+		case "statsd":
+			name = "envoy.stat_sinks.statsd"
+			typeName = "type.googleapis.com/envoy.config.metrics.v3.StatsdSink"
+		case "dogstatsd":
+			name = "envoy.stat_sinks.dog_statsd"
+			typeName = "type.googleapis.com/envoy.config.metrics.v3.DogStatsdSink"
+		default:
+			return fmt.Errorf("unsupported stats sink type %q, must be one of statsd or dogstatsd", sink.Type)
+		}
+		sinkJSON, err := c.generateStatsSinkJSON(name, typeName, sink.Address, sink.Prefix)
+		if err != nil {
+			return err
+		}
+		stats_sinks = append(stats_sinks, sinkJSON)
+	}
 	if c.StatsSinksJSON != "" {
 		stats_sinks = append(stats_sinks, c.StatsSinksJSON)
 	}
+	if c.BuiltinMetrics || c.DetectUpstreamProtocols {
+		sinkJSON, err := generateMetricsServiceSinkJSON(args)
+		if err != nil {
+			return err
+		}
+		stats_sinks = append(stats_sinks, sinkJSON)
+	}
 
 	if len(stats_sinks) > 0 {
 		args.StatsSinksJSON = "[\n" + strings.Join(stats_sinks, ",\n") + "\n]"
@@ -276,7 +384,7 @@ func (c *BootstrapConfig) generateStatsSinks(args *BootstrapTplArgs) error {
 	return nil
 }
 
-func (c *BootstrapConfig) generateStatsSinkJSON(name string, typeName string, addr string) (string, error) {
+func (c *BootstrapConfig) generateStatsSinkJSON(name string, typeName string, addr string, prefix string) (string, error) {
 	// Resolve address ENV var
 	if len(addr) > 2 && addr[0] == '$' {
 		addr = os.Getenv(addr[1:])
@@ -309,17 +417,153 @@ func (c *BootstrapConfig) generateStatsSinkJSON(name string, typeName string, ad
 			u.Scheme, name)
 	}
 
+	var prefixJSON string
+	if prefix != "" {
+		b, err := json.Marshal(prefix)
+		if err != nil {
+			return "", err
+		}
+		prefixJSON = `,
+			"prefix": ` + string(b)
+	}
+
 	return `{
 		"name": "` + name + `",
 		"typedConfig": {
 			"@type": "` + typeName + `",
 			"address": {
 				` + addrJSON + `
+			}` + prefixJSON + `
+		}
+	}`, nil
+}
+
+// generateMetricsServiceSinkJSON returns the sink flushing the stats of Envoy
+// to the metrics service of the local agent.
+func generateMetricsServiceSinkJSON(args *BootstrapTplArgs) (string, error) {
+	token, err := json.Marshal(args.Token)
+	if err != nil {
+		return "", err
+	}
+	cluster, err := json.Marshal(args.LocalAgentClusterName)
+	if err != nil {
+		return "", err
+	}
+
+	return `{
+		"name": "envoy.stat_sinks.metrics_service",
+		"typedConfig": {
+			"@type": "type.googleapis.com/envoy.config.metrics.v3.MetricsServiceConfig",
+			"transport_api_version": "V3",
+			"grpc_service": {
+				"initial_metadata": [
+					{
+						"key": "x-consul-token",
+						"value": ` + string(token) + `
+					}
+				],
+				"envoy_grpc": {
+					"cluster_name": ` + string(cluster) + `
+				}
 			}
 		}
 	}`, nil
 }
 
+// generateLayeredRuntimeJSON returns the layered runtime rendering the given
+// keys as a static layer, followed by an admin layer.
+func generateLayeredRuntimeJSON(values map[string]interface{}) (string, error) {
+	for k, v := range values {
+		switch v.(type) {
+		case string, bool, int, int64, uint64, float32, float64:
+		default:
+			return "", fmt.Errorf("invalid value of runtime key %q: must be a string, number or boolean, got %T", k, v)
+		}
+	}
+
+	b, err := json.Marshal(map[string]interface{}{
+		"layers": []interface{}{
+			map[string]interface{}{
+				"name":         "consul_static_layer",
+				"static_layer": values,
+			},
+			map[string]interface{}{
+				"name":        "admin_layer",
+				"admin_layer": map[string]interface{}{},
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// generateTracingCollectorCluster appends the static cluster of the tracing
+// collector to the static clusters.
+func (c *BootstrapConfig) generateTracingCollectorCluster(args *BootstrapTplArgs) error {
+	name := c.TracingCollectorCluster
+	if name == "" {
+		name = defaultTracingCollectorCluster
+	}
+
+	host, portStr, err := net.SplitHostPort(c.TracingCollectorAddress)
+	if err != nil {
+		return fmt.Errorf("invalid tracing collector address: %s", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("invalid tracing collector address: invalid port %q", portStr)
+	}
+
+	exists, err := containsStaticCluster(args.StaticClustersJSON, name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("a static cluster named %q already exists, set envoy_tracing_collector_cluster to another name", name)
+	}
+
+	discoveryType := "STRICT_DNS"
+	if net.ParseIP(host) != nil {
+		discoveryType = "STATIC"
+	}
+	b, err := json.Marshal(map[string]interface{}{
+		"name":            name,
+		"connect_timeout": "5s",
+		"type":            discoveryType,
+		"load_assignment": map[string]interface{}{
+			"cluster_name": name,
+			"endpoints": []interface{}{
+				map[string]interface{}{
+					"lb_endpoints": []interface{}{
+						map[string]interface{}{
+							"endpoint": map[string]interface{}{
+								"address": map[string]interface{}{
+									"socket_address": map[string]interface{}{
+										"address":    host,
+										"port_value": port,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if args.StaticClustersJSON == "" {
+		args.StaticClustersJSON = string(b)
+	} else {
+		args.StaticClustersJSON += ",\n" + string(b)
+	}
+	return nil
+}
+
 func statsSinkEnvMapping(s string) string {
 	allowedStatsSinkEnvVars := map[string]bool{
 		"HOST_IP": true,
@@ -720,6 +964,12 @@ func (c *BootstrapConfig) generateListenerConfig(args *BootstrapTplArgs, bindAdd
 }
 
 func containsSelfAdminCluster(clustersJSON string) (bool, error) {
+	return containsStaticCluster(clustersJSON, selfAdminName)
+}
+
+// containsStaticCluster returns whether a cluster of the given name is in the
+// comma separated list of clusters.
+func containsStaticCluster(clustersJSON, name string) (bool, error) {
 	clusterNames := []struct {
 		Name string
 	}{}
@@ -732,7 +982,7 @@ func containsSelfAdminCluster(clustersJSON string) (bool, error) {
 	}
 
 	for _, cluster := range clusterNames {
-		if cluster.Name == selfAdminName {
+		if cluster.Name == name {
 			return true, nil
 		}
 	}
@@ -813,6 +813,90 @@ func TestBootstrapConfig_ConfigureArgs(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "on-demand-upstreams-ttl",
+			input: BootstrapConfig{
+				OnDemandUpstreamsTTL: `10m`,
+			},
+			wantArgs: BootstrapTplArgs{
+				StatsConfigJSON:  defaultStatsConfigJSON,
+				LoadStatsEnabled: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "builtin-metrics",
+			input: BootstrapConfig{
+				BuiltinMetrics: true,
+			},
+			baseArgs: BootstrapTplArgs{
+				Token:                 "abc",
+				LocalAgentClusterName: "local_agent",
+			},
+			wantArgs: BootstrapTplArgs{
+				Token:                 "abc",
+				LocalAgentClusterName: "local_agent",
+				StatsConfigJSON:       defaultStatsConfigJSON,
+				StatsSinksJSON: `[
+{
+		"name": "envoy.stat_sinks.metrics_service",
+		"typedConfig": {
+			"@type": "type.googleapis.com/envoy.config.metrics.v3.MetricsServiceConfig",
+			"transport_api_version": "V3",
+			"grpc_service": {
+				"initial_metadata": [
+					{
+						"key": "x-consul-token",
+						"value": "abc"
+					}
+				],
+				"envoy_grpc": {
+					"cluster_name": "local_agent"
+				}
+			}
+		}
+	}
+]`,
+				LoadStatsEnabled: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "detect-upstream-protocols",
+			input: BootstrapConfig{
+				DetectUpstreamProtocols: true,
+			},
+			baseArgs: BootstrapTplArgs{
+				Token:                 "abc",
+				LocalAgentClusterName: "local_agent",
+			},
+			wantArgs: BootstrapTplArgs{
+				Token:                 "abc",
+				LocalAgentClusterName: "local_agent",
+				StatsConfigJSON:       defaultStatsConfigJSON,
+				StatsSinksJSON: `[
+{
+		"name": "envoy.stat_sinks.metrics_service",
+		"typedConfig": {
+			"@type": "type.googleapis.com/envoy.config.metrics.v3.MetricsServiceConfig",
+			"transport_api_version": "V3",
+			"grpc_service": {
+				"initial_metadata": [
+					{
+						"key": "x-consul-token",
+						"value": "abc"
+					}
+				],
+				"envoy_grpc": {
+					"cluster_name": "local_agent"
+				}
+			}
+		}
+	}
+]`,
+			},
+			wantErr: false,
+		},
 		{
 			name: "override-tracing",
 			input: BootstrapConfig{
@@ -824,6 +908,161 @@ func TestBootstrapConfig_ConfigureArgs(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "admin-access-log-path",
+			input: BootstrapConfig{
+				AdminAccessLogPath: "/var/log/envoy/admin.log",
+			},
+			baseArgs: BootstrapTplArgs{
+				AdminAccessLogPath: "/dev/null",
+			},
+			wantArgs: BootstrapTplArgs{
+				StatsConfigJSON:    defaultStatsConfigJSON,
+				AdminAccessLogPath: "/var/log/envoy/admin.log",
+			},
+		},
+		{
+			name: "admin-access-log-path-escaped",
+			input: BootstrapConfig{
+				AdminAccessLogPath: `C:\envoy\"admin".log`,
+			},
+			wantArgs: BootstrapTplArgs{
+				StatsConfigJSON:    defaultStatsConfigJSON,
+				AdminAccessLogPath: `C:\\envoy\\\"admin\".log`,
+			},
+		},
+		{
+			name: "static-runtime",
+			input: BootstrapConfig{
+				StaticRuntime: map[string]interface{}{
+					"overload.global_downstream_max_connections": 50000,
+					"envoy.reloadable_features.some_feature":     false,
+				},
+			},
+			wantArgs: BootstrapTplArgs{
+				StatsConfigJSON: defaultStatsConfigJSON,
+				LayeredRuntimeJSON: `{
+					"layers": [
+						{
+							"name": "consul_static_layer",
+							"static_layer": {
+								"overload.global_downstream_max_connections": 50000,
+								"envoy.reloadable_features.some_feature": false
+							}
+						},
+						{
+							"name": "admin_layer",
+							"admin_layer": {}
+						}
+					]
+				}`,
+			},
+		},
+		{
+			name: "err-static-runtime-not-scalar",
+			input: BootstrapConfig{
+				StaticRuntime: map[string]interface{}{
+					"foo": map[string]interface{}{"bar": 1},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "tracing-collector-cluster",
+			input: BootstrapConfig{
+				StaticClustersJSON:      `{"name": "foo"}`,
+				TracingCollectorAddress: "127.0.0.1:9411",
+			},
+			wantArgs: BootstrapTplArgs{
+				StatsConfigJSON: defaultStatsConfigJSON,
+				StaticClustersJSON: `{"name": "foo"},
+				{
+					"name": "tracing_collector",
+					"connect_timeout": "5s",
+					"type": "STATIC",
+					"load_assignment": {
+						"cluster_name": "tracing_collector",
+						"endpoints": [
+							{
+								"lb_endpoints": [
+									{
+										"endpoint": {
+											"address": {
+												"socket_address": {
+													"address": "127.0.0.1",
+													"port_value": 9411
+												}
+											}
+										}
+									}
+								]
+							}
+						]
+					}
+				}`,
+			},
+		},
+		{
+			name: "err-tracing-collector-cluster-exists",
+			input: BootstrapConfig{
+				StaticClustersJSON:      `{"name": "zipkin"}`,
+				TracingCollectorAddress: "zipkin:9411",
+				TracingCollectorCluster: "zipkin",
+			},
+			wantErr: true,
+		},
+		{
+			name: "err-bad-tracing-collector-addr",
+			input: BootstrapConfig{
+				TracingCollectorAddress: "zipkin",
+			},
+			wantErr: true,
+		},
+		{
+			name: "typed-stats-sinks",
+			input: BootstrapConfig{
+				StatsSinks: []StatsSinkConfig{
+					{Type: "statsd", Address: "udp://127.0.0.1:9125", Prefix: "sidecar"},
+					{Type: "dogstatsd", Address: "unix:///var/run/dogstatsd.sock"},
+				},
+			},
+			wantArgs: BootstrapTplArgs{
+				StatsConfigJSON: defaultStatsConfigJSON,
+				StatsSinksJSON: `[{
+					"name": "envoy.stat_sinks.statsd",
+					"typedConfig": {
+						"@type": "type.googleapis.com/envoy.config.metrics.v3.StatsdSink",
+						"address": {
+							"socket_address": {
+								"address": "127.0.0.1",
+								"port_value": 9125
+							}
+						},
+						"prefix": "sidecar"
+					}
+				},
+				{
+					"name": "envoy.stat_sinks.dog_statsd",
+					"typedConfig": {
+						"@type": "type.googleapis.com/envoy.config.metrics.v3.DogStatsdSink",
+						"address": {
+							"pipe": {
+								"path": "/var/run/dogstatsd.sock"
+							}
+						}
+					}
+				}]`,
+			},
+		},
+		{
+			name: "err-bad-stats-sink-type",
+			input: BootstrapConfig{
+				StatsSinks: []StatsSinkConfig{
+					{Type: "prometheus", Address: "udp://127.0.0.1:9125"},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "err-bad-prometheus-addr",
 			input: BootstrapConfig{
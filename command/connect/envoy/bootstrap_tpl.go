@@ -106,6 +106,16 @@ type BootstrapTplArgs struct {
 	// PrometheusScrapePath will configure the path where metrics are exposed on
 	// the envoy_prometheus_bind_addr listener.
 	PrometheusScrapePath string
+
+	// LoadStatsEnabled configures Envoy to report the load of its clusters to
+	// the local agent, which tracks the activity of on-demand upstreams and
+	// records the builtin metrics.
+	LoadStatsEnabled bool
+
+	// LayeredRuntimeJSON is a JSON string containing an object in the right
+	// format to be rendered as the body of the `layered_runtime` field at the
+	// top level of the bootstrap config.
+	LayeredRuntimeJSON string
 }
 
 // GRPC settings used in the bootstrap template.
@@ -121,9 +131,9 @@ type GRPC struct {
 	// TLS.
 	AgentTLS bool
 
-	// AgentSocket is the path to a Unix Socket for communicating with the
-	// local agent's gRPC endpoint. Disabled if the empty (the default),
-	// but overrides AgentAddress and AgentPort if set.
+	// AgentSocket is the path to a Unix Socket or the name of a Windows named
+	// pipe for communicating with the local agent's gRPC endpoint. Disabled if
+	// the empty (the default), but overrides AgentAddress and AgentPort if set.
 	AgentSocket string
 }
 
@@ -222,6 +232,28 @@ const bootstrapTemplate = `{
   {{- if .TracingConfigJSON }}
   "tracing": {{ .TracingConfigJSON }},
   {{- end }}
+  {{- if .LayeredRuntimeJSON }}
+  "layered_runtime": {{ .LayeredRuntimeJSON }},
+  {{- end }}
+  {{- if .LoadStatsEnabled }}
+  "cluster_manager": {
+    "load_stats_config": {
+      "api_type": "GRPC",
+      "transport_api_version": "V3",
+      "grpc_services": {
+        "initial_metadata": [
+          {
+            "key": "x-consul-token",
+            "value": "{{ .Token }}"
+          }
+        ],
+        "envoy_grpc": {
+          "cluster_name": "{{ .LocalAgentClusterName }}"
+        }
+      }
+    }
+  },
+  {{- end }}
   "dynamic_resources": {
     "lds_config": {
       "ads": {},
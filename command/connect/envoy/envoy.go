@@ -1,6 +1,7 @@
 package envoy
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -74,6 +75,7 @@ var supportedGateways = map[string]api.ServiceKind{
 	"mesh":        api.ServiceKindMeshGateway,
 	"terminating": api.ServiceKindTerminatingGateway,
 	"ingress":     api.ServiceKindIngressGateway,
+	"egress":      api.ServiceKindEgressGateway,
 }
 
 func (c *cmd) init() {
@@ -90,7 +92,7 @@ func (c *cmd) init() {
 		"Configure Envoy as a Mesh Gateway.")
 
 	c.flags.StringVar(&c.gateway, "gateway", "",
-		"The type of gateway to register. One of: terminating, ingress, or mesh")
+		"The type of gateway to register. One of: terminating, ingress, egress, or mesh")
 
 	c.flags.StringVar(&c.sidecarFor, "sidecar-for", os.Getenv("CONNECT_SIDECAR_FOR"),
 		"The ID of a service instance on the local agent that this proxy should "+
@@ -123,7 +125,8 @@ func (c *cmd) init() {
 			"command attempting to resolve config from the local agent.")
 
 	c.flags.StringVar(&c.grpcAddr, "grpc-addr", os.Getenv(api.GRPCAddrEnvName),
-		"Set the agent's gRPC address and port (in http(s)://host:port format). "+
+		"Set the agent's gRPC address and port (in http(s)://host:port format), "+
+			"Unix socket (in unix:///path format) or Windows named pipe (in npipe://\\\\.\\pipe\\name format). "+
 			"Alternatively, you can specify CONSUL_GRPC_ADDR in ENV.")
 
 	// Deprecated, no longer needed, keeping it around to not break back compat
@@ -266,7 +269,7 @@ func (c *cmd) run(args []string) int {
 	if c.gateway != "" {
 		kind, ok := supportedGateways[c.gateway]
 		if !ok {
-			c.UI.Error("Gateway must be one of: terminating, mesh, or ingress")
+			c.UI.Error("Gateway must be one of: terminating, mesh, ingress, or egress")
 			return 1
 		}
 		c.gatewayKind = kind
@@ -495,7 +498,7 @@ func (c *cmd) templateArgs() (*BootstrapTplArgs, error) {
 		NodeName:              c.nodeName,
 		ProxySourceService:    proxySourceService,
 		AgentCAPEM:            caPEM,
-		AdminAccessLogPath:    adminAccessLogPath,
+		AdminAccessLogPath:    jsonStringContent(adminAccessLogPath),
 		AdminBindAddress:      adminBindIP.String(),
 		AdminBindPort:         adminPort,
 		Token:                 httpCfg.Token,
@@ -593,6 +596,13 @@ func (c *cmd) generateConfig() ([]byte, error) {
 		if err := mapstructure.WeakDecode(svcProxyConfig.Config, &bsCfg); err != nil {
 			return nil, fmt.Errorf("failed parsing Proxy.Config: %s", err)
 		}
+
+		// An access log path given on the command line takes precedence.
+		c.flags.Visit(func(f *flag.Flag) {
+			if f.Name == "admin-access-log-path" {
+				bsCfg.AdminAccessLogPath = ""
+			}
+		})
 	}
 
 	return bsCfg.GenerateJSON(args, c.omitDeprecatedTags)
@@ -631,10 +641,14 @@ func (c *cmd) xdsAddress(httpCfg *api.Config) (GRPC, error) {
 	// We want to allow grpcAddr set as host:port with no scheme but if the host
 	// is an IP this will fail to parse as a URL with "parse 127.0.0.1:8500: first
 	// path segment in URL cannot contain colon". On the other hand we also
-	// support both http(s)://host:port and unix:///path/to/file.
+	// support http(s)://host:port, unix:///path/to/file and
+	// npipe://\\.\pipe\name.
 	if grpcAddr := strings.TrimPrefix(addr, "unix://"); grpcAddr != addr {
 		// Path to unix socket
-		g.AgentSocket = grpcAddr
+		g.AgentSocket = jsonStringContent(grpcAddr)
+	} else if grpcAddr := strings.TrimPrefix(addr, "npipe://"); grpcAddr != addr {
+		// Windows named pipe
+		g.AgentSocket = jsonStringContent(grpcAddr)
 	} else {
 		// Parse as host:port with option http prefix
 		grpcAddr = strings.TrimPrefix(addr, "http://")
@@ -662,6 +676,13 @@ func (c *cmd) xdsAddress(httpCfg *api.Config) (GRPC, error) {
 	return g, nil
 }
 
+// jsonStringContent returns s encoded as the content of a JSON string, so that
+// it can be rendered between the quotes of the bootstrap template.
+func jsonStringContent(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b[1 : len(b)-1])
+}
+
 func (c *cmd) lookupXDSPort() (int, error) {
 	self, err := c.client.Agent().Self()
 	if err != nil {
@@ -380,6 +380,26 @@ func TestGenerateConfig(t *testing.T) {
 				PrometheusScrapePath:  "/metrics",
 			},
 		},
+		{
+			Name: "grpc-addr-npipe",
+			Flags: []string{"-proxy-id", "test-proxy",
+				"-grpc-addr", `npipe://\\.\pipe\consul-grpc`},
+			WantArgs: BootstrapTplArgs{
+				ProxyCluster: "test-proxy",
+				ProxyID:      "test-proxy",
+				// We don't know this til after the lookup so it will be empty in the
+				// initial args call we are testing here.
+				ProxySourceService: "",
+				GRPC: GRPC{
+					AgentSocket: `\\\\.\\pipe\\consul-grpc`,
+				},
+				AdminAccessLogPath:    "/dev/null",
+				AdminBindAddress:      "127.0.0.1",
+				AdminBindPort:         "19000",
+				LocalAgentClusterName: xds.LocalAgentClusterName,
+				PrometheusScrapePath:  "/metrics",
+			},
+		},
 		{
 			Name:    "xds-addr-config",
 			Flags:   []string{"-proxy-id", "test-proxy"},
@@ -694,6 +714,46 @@ func TestGenerateConfig(t *testing.T) {
 				PrometheusScrapePath:  "/metrics",
 			},
 		},
+		{
+			Name:  "typed-bootstrap-overrides",
+			Flags: []string{"-proxy-id", "test-proxy"},
+			ProxyConfig: map[string]interface{}{
+				"envoy_admin_access_log_path": "/var/log/envoy/admin.log",
+				"envoy_static_runtime": map[string]interface{}{
+					"overload.global_downstream_max_connections":               50000,
+					"envoy.reloadable_features.http_reject_path_with_fragment": false,
+				},
+				"envoy_tracing_collector_address": "zipkin.service.consul:9411",
+				"envoy_tracing_collector_cluster": "zipkin",
+				"envoy_stats_sinks": []interface{}{
+					map[string]interface{}{
+						"type":    "statsd",
+						"address": "udp://127.0.0.1:9125",
+						"prefix":  "sidecar",
+					},
+					map[string]interface{}{
+						"type":    "dogstatsd",
+						"address": "unix:///var/run/dogstatsd.sock",
+					},
+				},
+			},
+			WantArgs: BootstrapTplArgs{
+				ProxyCluster: "test-proxy",
+				ProxyID:      "test-proxy",
+				// We don't know this til after the lookup so it will be empty in the
+				// initial args call we are testing here.
+				ProxySourceService: "",
+				GRPC: GRPC{
+					AgentAddress: "127.0.0.1",
+					AgentPort:    "8502",
+				},
+				AdminAccessLogPath:    "/dev/null",
+				AdminBindAddress:      "127.0.0.1",
+				AdminBindPort:         "19000",
+				LocalAgentClusterName: xds.LocalAgentClusterName,
+				PrometheusScrapePath:  "/metrics",
+			},
+		},
 		{
 			Name:  "zipkin-tracing-config",
 			Flags: []string{"-proxy-id", "test-proxy"},
@@ -753,6 +813,54 @@ func TestGenerateConfig(t *testing.T) {
 				PrometheusScrapePath:  "/metrics",
 			},
 		},
+		{
+			Name:  "on-demand-upstreams-ttl",
+			Flags: []string{"-proxy-id", "test-proxy"},
+			ProxyConfig: map[string]interface{}{
+				"on_demand_upstreams":     true,
+				"on_demand_upstreams_ttl": "10m",
+			},
+			WantArgs: BootstrapTplArgs{
+				ProxyCluster: "test-proxy",
+				ProxyID:      "test-proxy",
+				// We don't know this til after the lookup so it will be empty in the
+				// initial args call we are testing here.
+				ProxySourceService: "",
+				GRPC: GRPC{
+					AgentAddress: "127.0.0.1",
+					AgentPort:    "8502",
+				},
+				AdminAccessLogPath:    "/dev/null",
+				AdminBindAddress:      "127.0.0.1",
+				AdminBindPort:         "19000",
+				LocalAgentClusterName: xds.LocalAgentClusterName,
+				PrometheusScrapePath:  "/metrics",
+			},
+		},
+		{
+			Name:  "builtin-metrics",
+			Flags: []string{"-proxy-id", "test-proxy", "-token", "c9a52720-bf6c-4aa6-b8bc-66881a5ade95"},
+			ProxyConfig: map[string]interface{}{
+				"builtin_metrics": true,
+			},
+			WantArgs: BootstrapTplArgs{
+				ProxyCluster: "test-proxy",
+				ProxyID:      "test-proxy",
+				// We don't know this til after the lookup so it will be empty in the
+				// initial args call we are testing here.
+				ProxySourceService: "",
+				GRPC: GRPC{
+					AgentAddress: "127.0.0.1",
+					AgentPort:    "8502",
+				},
+				AdminAccessLogPath:    "/dev/null",
+				AdminBindAddress:      "127.0.0.1",
+				AdminBindPort:         "19000",
+				LocalAgentClusterName: xds.LocalAgentClusterName,
+				PrometheusScrapePath:  "/metrics",
+				Token:                 "c9a52720-bf6c-4aa6-b8bc-66881a5ade95",
+			},
+		},
 		{
 			Name:  "CONSUL_HTTP_ADDR-with-https-scheme-enables-tls",
 			Flags: []string{"-proxy-id", "test-proxy"},
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -24,6 +25,7 @@ import (
 
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/command/flags"
+	"github.com/hashicorp/consul/logging"
 )
 
 const (
@@ -50,6 +52,10 @@ const (
 	// debugArchiveExtension is the extension for archive files
 	debugArchiveExtension = ".tar.gz"
 
+	// envoyAdminTimeout bounds how long fetching the config dump of an Envoy
+	// admin API can take.
+	envoyAdminTimeout = 10 * time.Second
+
 	// debugProtocolVersion is the version of the package that is
 	// generated. If this format changes interface, this version
 	// can be incremented so clients can selectively support packages
@@ -80,8 +86,12 @@ type cmd struct {
 	duration time.Duration
 	output   string
 	archive  bool
+	redact   bool
 	capture  []string
 	client   *api.Client
+	// envoyAdmin are the addresses of the Envoy admin APIs to collect the
+	// config dumps from when capturing proxies.
+	envoyAdmin []string
 	// validateTiming can be used to skip validation of interval, duration. This
 	// is primarily useful for testing
 	validateTiming bool
@@ -120,7 +130,15 @@ func (c *cmd) init() {
 		fmt.Sprintf("One or more types of information to capture. This can be used "+
 			"to capture a subset of information, and defaults to capturing "+
 			"everything available. Possible information for capture: %s. "+
-			"This can be repeated multiple times.", strings.Join(defaultTargets, ", ")))
+			"The %s target is only captured when requested. "+
+			"This can be repeated multiple times.",
+			strings.Join(append(defaultTargets, optionalTargets...), ", "),
+			strings.Join(optionalTargets, ", ")))
+	c.flags.Var((*flags.AppendSliceValue)(&c.envoyAdmin), "envoy-admin-addr",
+		fmt.Sprintf("The address of an Envoy admin API to collect the config dump "+
+			"from when capturing %s. This can be repeated multiple times. "+
+			"Defaults to %s, which is skipped if it is not reachable.",
+			targetProxies, defaultEnvoyAdminAddr))
 	c.flags.DurationVar(&c.interval, "interval", debugInterval,
 		fmt.Sprintf("The interval in which to capture dynamic information such as "+
 			"telemetry, and profiling. Defaults to %s.", debugInterval))
@@ -130,6 +148,10 @@ func (c *cmd) init() {
 	c.flags.BoolVar(&c.archive, "archive", true, "Boolean value for if the files "+
 		"should be archived and compressed. Setting this to false will skip the "+
 		"archive step and leave the directory of information on the current path.")
+	c.flags.BoolVar(&c.redact, "redact", true, "Boolean value for if secrets "+
+		"such as ACL tokens, private keys and gossip keys should be scrubbed from "+
+		"the captured logs, configuration and proxy data. Setting this to false "+
+		"keeps the data as returned by the agent.")
 	c.flags.StringVar(&c.output, "output", defaultFilename, "The path "+
 		"to the compressed archive that will be created with the "+
 		"information after collection.")
@@ -220,7 +242,7 @@ func (c *cmd) Run(args []string) int {
 		Duration:     c.duration.String(),
 		Targets:      c.capture,
 	}
-	if err := writeJSONFile(filepath.Join(c.output, "index.json"), index); err != nil {
+	if err := c.writeJSONFile(filepath.Join(c.output, "index.json"), index); err != nil {
 		c.UI.Error(fmt.Sprintf("Error creating index document: %v", err))
 		return 1
 	}
@@ -302,7 +324,7 @@ func (c *cmd) captureStatic() error {
 		if err != nil {
 			errs = multierror.Append(errs, err)
 		}
-		if err := writeJSONFile(filepath.Join(c.output, targetHost+".json"), host); err != nil {
+		if err := c.writeJSONFile(filepath.Join(c.output, targetHost+".json"), host); err != nil {
 			errs = multierror.Append(errs, err)
 		}
 	}
@@ -312,7 +334,7 @@ func (c *cmd) captureStatic() error {
 		if err != nil {
 			errs = multierror.Append(errs, err)
 		}
-		if err := writeJSONFile(filepath.Join(c.output, targetAgent+".json"), agent); err != nil {
+		if err := c.writeJSONFile(filepath.Join(c.output, targetAgent+".json"), agent); err != nil {
 			errs = multierror.Append(errs, err)
 		}
 	}
@@ -322,19 +344,104 @@ func (c *cmd) captureStatic() error {
 		if err != nil {
 			errs = multierror.Append(errs, err)
 		}
-		if err := writeJSONFile(filepath.Join(c.output, targetMembers+".json"), members); err != nil {
+		if err := c.writeJSONFile(filepath.Join(c.output, targetMembers+".json"), members); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+
+	if c.captureTarget(targetProxies) {
+		proxies, err := c.client.Agent().DebugProxies()
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		}
+		if err := c.writeJSONFile(filepath.Join(c.output, targetProxies+".json"), proxies); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+		if err := c.captureEnvoyConfigDumps(); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+// captureEnvoyConfigDumps writes the config dump of each configured Envoy
+// admin API to the envoy directory. When no address was given, the default
+// admin address is tried and silently skipped if nothing listens on it.
+func (c *cmd) captureEnvoyConfigDumps() error {
+	addrs := c.envoyAdmin
+	optional := false
+	if len(addrs) == 0 {
+		addrs = []string{defaultEnvoyAdminAddr}
+		optional = true
+	}
+
+	var errs error
+	for _, addr := range addrs {
+		dump, err := fetchEnvoyConfigDump(addr)
+		if err != nil {
+			if !optional {
+				errs = multierror.Append(errs, fmt.Errorf("failed to collect Envoy config dump from %s: %w", addr, err))
+			}
+			continue
+		}
+
+		dir := filepath.Join(c.output, "envoy")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory %v: %w", dir, err)
+		}
+		name := "config_dump-" + strings.NewReplacer(":", "-", "/", "-").Replace(addr) + ".json"
+		if err := ioutil.WriteFile(filepath.Join(dir, name), c.redactBytes(dump), 0644); err != nil {
 			errs = multierror.Append(errs, err)
 		}
 	}
 	return errs
 }
 
-func writeJSONFile(filename string, content interface{}) error {
+// fetchEnvoyConfigDump returns the config dump of the Envoy admin API at addr.
+func fetchEnvoyConfigDump(addr string) ([]byte, error) {
+	client := &http.Client{Timeout: envoyAdminTimeout}
+	resp, err := client.Get("http://" + addr + "/config_dump")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response code %d", resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// secretsRedactor scrubs the secrets from the captured data when the redact
+// flag is set.
+var secretsRedactor = func() *logging.Redactor {
+	r, err := logging.NewRedactorWithPatterns(logging.DefaultRedactPatterns)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}()
+
+// redactBytes scrubs the secrets from b, unless redaction was disabled.
+// Envoy and the agent already redact the fields they know to be sensitive,
+// this catches the secrets that were inlined elsewhere.
+func (c *cmd) redactBytes(b []byte) []byte {
+	if !c.redact {
+		return b
+	}
+	return secretsRedactor.RedactBytes(b)
+}
+
+func (c *cmd) writeJSONFile(filename string, content interface{}) error {
 	marshaled, err := json.MarshalIndent(content, "", "\t")
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(filename, marshaled, 0644)
+	return ioutil.WriteFile(filename, c.redactBytes(marshaled), 0644)
 }
 
 // captureInterval blocks for the duration of the command
@@ -501,7 +608,7 @@ func (c *cmd) captureLogs(ctx context.Context) error {
 			if log == "" {
 				return nil
 			}
-			if _, err = f.WriteString(log + "\n"); err != nil {
+			if _, err = f.Write(c.redactBytes([]byte(log + "\n"))); err != nil {
 				return err
 			}
 		case <-ctx.Done():
@@ -539,6 +646,11 @@ func allowedTarget(target string) bool {
 			return true
 		}
 	}
+	for _, t := range optionalTargets {
+		if t == target {
+			return true
+		}
+	}
 	for _, t := range deprecatedTargets {
 		if t == target {
 			return true
@@ -684,8 +796,12 @@ const (
 	targetHost     = "host"
 	targetAgent    = "agent"
 	targetMembers  = "members"
+	targetProxies  = "proxies"
 	// targetCluster is the now deprecated name for targetMembers
 	targetCluster = "cluster"
+
+	// defaultEnvoyAdminAddr is the default address of the Envoy admin API.
+	defaultEnvoyAdminAddr = "127.0.0.1:19000"
 )
 
 // defaultTargets specifies the list of targets that will be captured by default
@@ -698,6 +814,10 @@ var defaultTargets = []string{
 	targetMembers,
 }
 
+// optionalTargets specifies the list of targets that are only captured when
+// requested explicitly
+var optionalTargets = []string{targetProxies}
+
 var deprecatedTargets = []string{targetCluster}
 
 func (c *cmd) Synopsis() string {
@@ -739,6 +859,12 @@ Usage: consul debug [options]
 
       $ consul debug -capture metrics -capture agent
 
+  The proxies target collects the configuration snapshots of the proxies
+  served by the agent, the state of their xDS streams and the config dump
+  of the local Envoy admin APIs. It is not captured by default.
+
+      $ consul debug -capture proxies -envoy-admin-addr=127.0.0.1:19000
+
   By default, the archive containing the debugging information is
   saved to the current directory as a .tar.gz file. The
   output path can be specified, as well as an option to disable
@@ -747,10 +873,10 @@ Usage: consul debug [options]
       $ consul debug -output=/foo/bar/my-debugging -archive=false
 
   Note: Information collected by this command has the potential
-  to be highly sensitive. Sensitive material such as ACL tokens and
-  other commonly secret material are redacted automatically, but we
-  strongly recommend review of the data within the archive prior to
-  transmitting it.
+  to be highly sensitive. Sensitive material such as ACL tokens, private
+  keys and gossip keys are redacted automatically, unless -redact=false
+  is set, but we strongly recommend review of the data within the
+  archive prior to transmitting it.
 
   For a full list of options and examples, please see the Consul
   documentation.
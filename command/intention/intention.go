@@ -48,5 +48,9 @@ Usage: consul intention <subcommand> [options] [args]
 
       $ consul intention match db
 
+  Validate the intentions against the policy rules of a directory:
+
+      $ consul intention validate -against policies/
+
   For more examples, ask for subcommand help or view the documentation.
 `
@@ -0,0 +1,308 @@
+package validate
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/mitchellh/cli"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/config/write"
+	"github.com/hashicorp/consul/command/flags"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+
+	// flags
+	flagAgainst string
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.StringVar(&c.flagAgainst, "against", "",
+		"Directory of the HCL or JSON rule files the intentions are validated against.")
+
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	flags.Merge(c.flags, c.http.MultiTenancyFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if c.flagAgainst == "" {
+		c.UI.Error("Must specify the -against flag")
+		return 1
+	}
+
+	rules, err := loadRules(c.flagAgainst)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to load the rules: %s", err))
+		return 1
+	}
+
+	var ixns []*api.ExportedIntention
+	if paths := c.flags.Args(); len(paths) > 0 {
+		ixns, err = ixnsFromFiles(paths)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to load the intentions: %s", err))
+			return 1
+		}
+	} else {
+		client, err := c.http.APIClient()
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+			return 1
+		}
+
+		export, _, err := client.Connect().IntentionsExport(nil)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to export the intentions: %s", err))
+			return 1
+		}
+		ixns = export.Intentions
+	}
+
+	violations := 0
+	for _, ixn := range ixns {
+		for _, rule := range rules {
+			if !rule.Matches(ixn) {
+				continue
+			}
+			violations++
+			msg := fmt.Sprintf("%s violates rule %q", ixnString(ixn), rule.Name)
+			if rule.Description != "" {
+				msg += ": " + rule.Description
+			}
+			c.UI.Error(msg)
+		}
+	}
+
+	if violations > 0 {
+		c.UI.Error(fmt.Sprintf("%d of the %d intentions violate the rules", violations, len(ixns)))
+		return 1
+	}
+	c.UI.Output(fmt.Sprintf("The %d intentions are valid against the %d rules", len(ixns), len(rules)))
+	return 0
+}
+
+// Rule forbids the intentions matching all of its non-empty fields.
+type Rule struct {
+	Name        string `hcl:",key"`
+	Description string `hcl:"description"`
+
+	SourcePeer           string `hcl:"source_peer"`
+	SourcePartition      string `hcl:"source_partition"`
+	SourceNamespace      string `hcl:"source_namespace"`
+	SourceName           string `hcl:"source_name"`
+	DestinationPartition string `hcl:"destination_partition"`
+	DestinationNamespace string `hcl:"destination_namespace"`
+	DestinationName      string `hcl:"destination_name"`
+
+	// Action is "allow" or "deny". Intentions with L7 permissions match
+	// "allow" when any of their permissions allows requests.
+	Action string `hcl:"action"`
+}
+
+// Matches returns whether the intention is forbidden by the rule.
+func (r *Rule) Matches(ixn *api.ExportedIntention) bool {
+	return matchField(r.SourcePeer, ixn.SourcePeer, false) &&
+		matchField(r.SourcePartition, ixn.SourcePartition, true) &&
+		matchField(r.SourceNamespace, ixn.SourceNS, true) &&
+		matchField(r.SourceName, ixn.SourceName, false) &&
+		matchField(r.DestinationPartition, ixn.DestinationPartition, true) &&
+		matchField(r.DestinationNamespace, ixn.DestinationNS, true) &&
+		matchField(r.DestinationName, ixn.DestinationName, false) &&
+		matchField(r.Action, string(ixnAction(ixn)), false)
+}
+
+// matchField returns whether the value of an intention field matches the
+// one of a rule. Empty rule fields match anything, and empty partitions and
+// namespaces are the default ones.
+func matchField(rule, value string, orDefault bool) bool {
+	if rule == "" {
+		return true
+	}
+	if orDefault && value == "" {
+		value = "default"
+	}
+	return strings.EqualFold(rule, value)
+}
+
+// ixnAction returns the action of the intention, or allow if it has L7
+// permissions that allow some requests.
+func ixnAction(ixn *api.ExportedIntention) api.IntentionAction {
+	if len(ixn.Permissions) == 0 {
+		return ixn.Action
+	}
+	for _, perm := range ixn.Permissions {
+		if perm.Action == api.IntentionActionAllow {
+			return api.IntentionActionAllow
+		}
+	}
+	return api.IntentionActionDeny
+}
+
+func ixnString(ixn *api.ExportedIntention) string {
+	src := ixn.SourceName
+	if ixn.SourceNS != "" {
+		src = ixn.SourceNS + "/" + src
+	}
+	if ixn.SourcePartition != "" {
+		src = ixn.SourcePartition + "/" + src
+	}
+	if ixn.SourcePeer != "" {
+		src = "peer(" + ixn.SourcePeer + ")/" + src
+	}
+	dst := ixn.DestinationName
+	if ixn.DestinationNS != "" {
+		dst = ixn.DestinationNS + "/" + dst
+	}
+	if ixn.DestinationPartition != "" {
+		dst = ixn.DestinationPartition + "/" + dst
+	}
+	return fmt.Sprintf("Intention %s => %s (%s)", src, dst, ixnAction(ixn))
+}
+
+// loadRules reads the rules of the .hcl and .json files of dir.
+func loadRules(dir string) ([]*Rule, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []*Rule
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".hcl" && ext != ".json") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var file struct {
+			Rule []*Rule `hcl:"rule"`
+		}
+		if err := hcl.Decode(&file, string(data)); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %v", path, err)
+		}
+		for _, rule := range file.Rule {
+			if rule.Name == "" {
+				return nil, fmt.Errorf("rule without a name in %s", path)
+			}
+			switch rule.Action {
+			case "", string(api.IntentionActionAllow), string(api.IntentionActionDeny):
+			default:
+				return nil, fmt.Errorf("rule %q in %s: invalid action %q", rule.Name, path, rule.Action)
+			}
+		}
+		rules = append(rules, file.Rule...)
+	}
+
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("no rules found in %s", dir)
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Name < rules[j].Name
+	})
+	return rules, nil
+}
+
+// ixnsFromFiles reads the intentions of the service-intentions config
+// entries stored in the given files.
+func ixnsFromFiles(paths []string) ([]*api.ExportedIntention, error) {
+	var result []*api.ExportedIntention
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		entry, err := write.ParseConfigEntry(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %v", path, err)
+		}
+		ixnEntry, ok := entry.(*api.ServiceIntentionsConfigEntry)
+		if !ok {
+			return nil, fmt.Errorf("%s is a %s config entry, not a %s one", path, entry.GetKind(), api.ServiceIntentions)
+		}
+
+		for _, src := range ixnEntry.Sources {
+			result = append(result, &api.ExportedIntention{
+				SourcePeer:           src.Peer,
+				SourcePartition:      src.Partition,
+				SourceNS:             src.Namespace,
+				SourceName:           src.Name,
+				DestinationPartition: ixnEntry.Partition,
+				DestinationNS:        ixnEntry.Namespace,
+				DestinationName:      ixnEntry.Name,
+				Action:               src.Action,
+				Permissions:          src.Permissions,
+			})
+		}
+	}
+	return result, nil
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const (
+	synopsis = "Validate intentions against policy rules."
+	help     = `
+Usage: consul intention validate [options] -against <dir> [FILE...]
+
+  Validate intentions against the rules of the HCL or JSON files of a
+  directory, and exit with an error if any intention violates a rule. The
+  intentions are read from the given service-intentions config entry files,
+  or exported from the cluster when no file is given.
+
+  A rule forbids the intentions matching all of its fields. For example, to
+  forbid allowing any source to connect to a service:
+
+      rule "no-wildcard-source" {
+        description = "Sources must be named explicitly"
+        source_name = "*"
+        action      = "allow"
+      }
+
+  The fields are source_peer, source_partition, source_namespace,
+  source_name, destination_partition, destination_namespace,
+  destination_name and action.
+
+  Validate the config entries of a pull request:
+
+      $ consul intention validate -against policies/ intentions/*.hcl
+
+  Validate the intentions of the cluster:
+
+      $ consul intention validate -against policies/
+`
+)
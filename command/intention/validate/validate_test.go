@@ -0,0 +1,118 @@
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntentionValidateCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(cli.NewMockUi()).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestIntentionValidateCommand(t *testing.T) {
+	t.Parallel()
+
+	rules := t.TempDir()
+	writeFile(t, filepath.Join(rules, "wildcard.hcl"), `
+rule "no-wildcard-source" {
+  description = "Sources must be named explicitly"
+  source_name = "*"
+  action      = "allow"
+}
+`)
+	writeFile(t, filepath.Join(rules, "db.json"), `{
+  "rule": {
+    "no-legacy-to-db": {
+      "source_name": "legacy",
+      "destination_name": "db"
+    }
+  }
+}`)
+	writeFile(t, filepath.Join(rules, "README.md"), `not a rule`)
+
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "web.hcl")
+	writeFile(t, valid, `
+Kind = "service-intentions"
+Name = "web"
+Sources = [
+  {
+    Name   = "*"
+    Action = "deny"
+  },
+  {
+    Name   = "frontend"
+    Action = "allow"
+  },
+]
+`)
+	invalid := filepath.Join(dir, "db.hcl")
+	writeFile(t, invalid, `
+Kind = "service-intentions"
+Name = "db"
+Sources = [
+  {
+    Name = "*"
+    Permissions = [
+      {
+        Action = "allow"
+        HTTP {
+          PathPrefix = "/v1"
+        }
+      },
+    ]
+  },
+  {
+    Name   = "legacy"
+    Action = "deny"
+  },
+]
+`)
+
+	t.Run("valid", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := New(ui)
+		require.Equal(t, 0, c.Run([]string{"-against", rules, valid}), ui.ErrorWriter.String())
+		require.Contains(t, ui.OutputWriter.String(), "The 2 intentions are valid against the 2 rules")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := New(ui)
+		require.Equal(t, 1, c.Run([]string{"-against", rules, valid, invalid}))
+		output := ui.ErrorWriter.String()
+		require.Contains(t, output, `Intention * => db (allow) violates rule "no-wildcard-source": Sources must be named explicitly`)
+		require.Contains(t, output, `Intention legacy => db (deny) violates rule "no-legacy-to-db"`)
+		require.Contains(t, output, "2 of the 4 intentions violate the rules")
+	})
+
+	t.Run("no rules", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := New(ui)
+		require.Equal(t, 1, c.Run([]string{"-against", dir, valid}))
+		require.Contains(t, ui.ErrorWriter.String(), "Failed to load the rules")
+	})
+
+	t.Run("not service intentions", func(t *testing.T) {
+		other := filepath.Join(dir, "defaults.json")
+		writeFile(t, other, `{"Kind": "service-defaults", "Name": "web"}`)
+
+		ui := cli.NewMockUi()
+		c := New(ui)
+		require.Equal(t, 1, c.Run([]string{"-against", rules, other}))
+		require.Contains(t, ui.ErrorWriter.String(), "not a service-intentions one")
+	})
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+}
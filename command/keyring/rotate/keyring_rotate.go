@@ -0,0 +1,360 @@
+package rotate
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"sort"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/flags"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+
+	// flags
+	key   string
+	relay int
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.StringVar(&c.key, "key", "",
+		"The new encryption key to rotate to. A new 32-byte key is generated "+
+			"when it is not set.")
+	c.flags.IntVar(&c.relay, "relay-factor", 0,
+		"Setting this to a non-zero value will cause nodes to relay their response "+
+			"to the operation through this many randomly-chosen other nodes in the "+
+			"cluster. The maximum allowed value is 5.")
+
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	c.UI = &cli.PrefixedUi{
+		OutputPrefix: "",
+		InfoPrefix:   "==> ",
+		ErrorPrefix:  "",
+		Ui:           c.UI,
+	}
+
+	relayFactor, err := agent.ParseRelayFactor(c.relay)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error parsing relay factor: %s", err))
+		return 1
+	}
+
+	key := c.key
+	if key == "" {
+		if key, err = generateKey(); err != nil {
+			c.UI.Error(fmt.Sprintf("Error generating encryption key: %s", err))
+			return 1
+		}
+	} else if err := validateKey(key); err != nil {
+		c.UI.Error(fmt.Sprintf("Invalid encryption key: %s", err))
+		return 1
+	}
+
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	r := &rotation{
+		ui:       c.UI,
+		operator: client.Operator(),
+		relay:    relayFactor,
+	}
+	if err := r.Rotate(key); err != nil {
+		c.UI.Error(fmt.Sprintf("error: %s", err))
+		return 1
+	}
+
+	c.UI.Output(fmt.Sprintf("Rotated the gossip encryption key to %s", key))
+	return 0
+}
+
+// generateKey returns a new 32-byte encryption key, like consul keygen.
+func generateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+func validateKey(key string) error {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("key is not base64 encoded: %w", err)
+	}
+	switch len(raw) {
+	case 16, 24, 32:
+		return nil
+	default:
+		return fmt.Errorf("key must be 16, 24 or 32 bytes long, got %d", len(raw))
+	}
+}
+
+// keyringOperator is the subset of the operator API used to rotate the key.
+type keyringOperator interface {
+	KeyringList(q *api.QueryOptions) ([]*api.KeyringResponse, error)
+	KeyringInstall(key string, q *api.WriteOptions) error
+	KeyringUse(key string, q *api.WriteOptions) error
+	KeyringRemove(key string, q *api.WriteOptions) error
+}
+
+// rotation replaces the primary gossip encryption key of every keyring of the
+// cluster. Each step is verified against the keyrings of all the nodes before
+// moving to the next one, and the completed steps are undone when one fails.
+type rotation struct {
+	ui       cli.Ui
+	operator keyringOperator
+	relay    uint8
+
+	// undo holds the operations reverting the steps run so far, in the order
+	// they were run.
+	undo []rotationStep
+}
+
+type rotationStep struct {
+	desc  string
+	apply func() error
+}
+
+// Rotate installs the key, makes it the primary key and removes the previous
+// primary key.
+func (r *rotation) Rotate(key string) error {
+	oldKey, err := r.currentPrimaryKey()
+	if err != nil {
+		return err
+	}
+	if oldKey == key {
+		return fmt.Errorf("the key is already the primary encryption key")
+	}
+
+	installed, err := r.installedOnAnyNode(key)
+	if err != nil {
+		return err
+	}
+	if installed {
+		return fmt.Errorf("the key is already installed, remove it or choose another key")
+	}
+
+	steps := []struct {
+		do     rotationStep
+		verify func() error
+		undo   rotationStep
+	}{
+		{
+			do: rotationStep{"Installing new gossip encryption key...", func() error {
+				return r.operator.KeyringInstall(key, r.writeOptions())
+			}},
+			verify: func() error { return r.verify(key, installedKeys, true) },
+			undo: rotationStep{"Removing new gossip encryption key...", func() error {
+				return r.operator.KeyringRemove(key, r.writeOptions())
+			}},
+		},
+		{
+			do: rotationStep{"Changing primary gossip encryption key...", func() error {
+				return r.operator.KeyringUse(key, r.writeOptions())
+			}},
+			verify: func() error { return r.verify(key, primaryKeys, true) },
+			undo: rotationStep{"Restoring previous primary gossip encryption key...", func() error {
+				return r.operator.KeyringUse(oldKey, r.writeOptions())
+			}},
+		},
+		{
+			do: rotationStep{"Removing previous gossip encryption key...", func() error {
+				return r.operator.KeyringRemove(oldKey, r.writeOptions())
+			}},
+			verify: func() error { return r.verify(oldKey, installedKeys, false) },
+			undo: rotationStep{"Reinstalling previous gossip encryption key...", func() error {
+				return r.operator.KeyringInstall(oldKey, r.writeOptions())
+			}},
+		},
+	}
+
+	for _, step := range steps {
+		// A failed step may have been applied on some of the nodes, it is
+		// undone along with the previous ones.
+		r.undo = append(r.undo, step.undo)
+
+		r.ui.Info(step.do.desc)
+		err := step.do.apply()
+		if err == nil {
+			err = step.verify()
+		}
+		if err != nil {
+			if rollbackErr := r.rollback(); rollbackErr != nil {
+				return fmt.Errorf("%v, and the rollback failed: %v", err, rollbackErr)
+			}
+			return fmt.Errorf("%v, the rotation was rolled back", err)
+		}
+	}
+	return nil
+}
+
+// rollback undoes the steps run so far, most recent first.
+func (r *rotation) rollback() error {
+	r.ui.Info("Rolling back the key rotation...")
+	for i := len(r.undo) - 1; i >= 0; i-- {
+		step := r.undo[i]
+		r.ui.Info(step.desc)
+		if err := step.apply(); err != nil {
+			return err
+		}
+	}
+	r.undo = nil
+	return nil
+}
+
+func (r *rotation) writeOptions() *api.WriteOptions {
+	return &api.WriteOptions{RelayFactor: r.relay}
+}
+
+func (r *rotation) list() ([]*api.KeyringResponse, error) {
+	responses, err := r.operator.KeyringList(&api.QueryOptions{RelayFactor: r.relay})
+	if err != nil {
+		return nil, err
+	}
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("no keyring was returned by the cluster")
+	}
+	return responses, nil
+}
+
+// currentPrimaryKey returns the primary key shared by all the nodes of all
+// the keyrings. The rotation cannot be done safely without one.
+func (r *rotation) currentPrimaryKey() (string, error) {
+	responses, err := r.list()
+	if err != nil {
+		return "", err
+	}
+
+	var primary string
+	for _, resp := range responses {
+		for key, num := range resp.PrimaryKeys {
+			if num != resp.NumNodes || (primary != "" && primary != key) {
+				return "", fmt.Errorf("the nodes of the %s keyring do not share a single primary key: %s",
+					keyringName(resp), formatKeys(resp.PrimaryKeys, resp.NumNodes))
+			}
+			primary = key
+		}
+	}
+	if primary == "" {
+		return "", fmt.Errorf("no primary encryption key is in use, gossip encryption is not enabled")
+	}
+	return primary, nil
+}
+
+func (r *rotation) installedOnAnyNode(key string) (bool, error) {
+	responses, err := r.list()
+	if err != nil {
+		return false, err
+	}
+	for _, resp := range responses {
+		if resp.Keys[key] > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func installedKeys(resp *api.KeyringResponse) map[string]int { return resp.Keys }
+func primaryKeys(resp *api.KeyringResponse) map[string]int   { return resp.PrimaryKeys }
+
+// verify checks that the key is present on all the nodes of every keyring if
+// present is true, or on none of them otherwise.
+func (r *rotation) verify(key string, keys func(*api.KeyringResponse) map[string]int, present bool) error {
+	responses, err := r.list()
+	if err != nil {
+		return fmt.Errorf("failed to verify the keyrings: %w", err)
+	}
+
+	for _, resp := range responses {
+		num := keys(resp)[key]
+		switch {
+		case present && num != resp.NumNodes:
+			return fmt.Errorf("the key is only present on %d/%d nodes of the %s keyring", num, resp.NumNodes, keyringName(resp))
+		case !present && num != 0:
+			return fmt.Errorf("the key is still present on %d/%d nodes of the %s keyring", num, resp.NumNodes, keyringName(resp))
+		}
+	}
+	return nil
+}
+
+func keyringName(resp *api.KeyringResponse) string {
+	name := fmt.Sprintf("%s LAN", resp.Datacenter)
+	if resp.WAN {
+		name = "WAN"
+	}
+	if resp.Segment != "" {
+		name += fmt.Sprintf(" [%s]", resp.Segment)
+	} else if !acl.IsDefaultPartition(resp.Partition) {
+		name += fmt.Sprintf(" [partition: %s]", resp.Partition)
+	}
+	return name
+}
+
+func formatKeys(keys map[string]int, total int) string {
+	sorted := make([]string, 0, len(keys))
+	for key, num := range keys {
+		sorted = append(sorted, fmt.Sprintf("%s [%d/%d]", key, num, total))
+	}
+	sort.Strings(sorted)
+	return fmt.Sprintf("%v", sorted)
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const synopsis = "Rotates the gossip encryption key of the cluster"
+const help = `
+Usage: consul keyring rotate [options]
+
+  Rotates the primary gossip encryption key of all the LAN and WAN keyrings of
+  the cluster in a single operation. It installs the new key, makes it the
+  primary key and removes the previous primary key, verifying with a keyring
+  list that every node applied a step before running the next one.
+
+  If a step fails or is not applied by every node, the steps already run are
+  undone so the cluster keeps using the previous key.
+
+  Rotate to a newly generated key:
+
+      $ consul keyring rotate
+
+  Rotate to a given key:
+
+      $ consul keyring rotate -key=kZyFABeAmc64UMTrm9XuKA==
+
+  The command returns 0 once the rotation completed, and 1 otherwise.
+`
@@ -0,0 +1,198 @@
+package rotate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+)
+
+func TestKeyringRotateCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(cli.NewMockUi()).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestKeyringRotateCommand_invalidKey(t *testing.T) {
+	t.Parallel()
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	code := c.Run([]string{"-key=not-a-key", "-http-addr=127.0.0.1:0"})
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), "Invalid encryption key")
+}
+
+const (
+	key1 = "HS5lJ+XuTlYKWaeGYyG+/A=="
+	key2 = "kZyFABeAmc64UMTrm9XuKA=="
+)
+
+// fakeKeyring is a keyring of a pool of nodes. The operations are only applied
+// to the first applyOn nodes when it is set, to simulate partial failures.
+type fakeKeyring struct {
+	wan     bool
+	nodes   []*fakeNode
+	applyOn int
+}
+
+type fakeNode struct {
+	keys    map[string]bool
+	primary string
+}
+
+func (k *fakeKeyring) apply(fn func(n *fakeNode)) {
+	for i, n := range k.nodes {
+		if k.applyOn > 0 && i >= k.applyOn {
+			break
+		}
+		fn(n)
+	}
+}
+
+type fakeOperator struct {
+	keyrings []*fakeKeyring
+	calls    []string
+
+	// failUse is a key that fails to be made the primary key.
+	failUse string
+}
+
+func newFakeOperator(numNodes int) *fakeOperator {
+	op := &fakeOperator{}
+	for _, wan := range []bool{false, true} {
+		k := &fakeKeyring{wan: wan}
+		for i := 0; i < numNodes; i++ {
+			k.nodes = append(k.nodes, &fakeNode{keys: map[string]bool{key1: true}, primary: key1})
+		}
+		op.keyrings = append(op.keyrings, k)
+	}
+	return op
+}
+
+func (o *fakeOperator) KeyringList(*api.QueryOptions) ([]*api.KeyringResponse, error) {
+	var responses []*api.KeyringResponse
+	for _, k := range o.keyrings {
+		resp := &api.KeyringResponse{
+			WAN:         k.wan,
+			Datacenter:  "dc1",
+			Keys:        make(map[string]int),
+			PrimaryKeys: make(map[string]int),
+			NumNodes:    len(k.nodes),
+		}
+		for _, n := range k.nodes {
+			for key := range n.keys {
+				resp.Keys[key]++
+			}
+			resp.PrimaryKeys[n.primary]++
+		}
+		responses = append(responses, resp)
+	}
+	return responses, nil
+}
+
+func (o *fakeOperator) KeyringInstall(key string, _ *api.WriteOptions) error {
+	o.calls = append(o.calls, "install "+key)
+	for _, k := range o.keyrings {
+		k.apply(func(n *fakeNode) { n.keys[key] = true })
+	}
+	return nil
+}
+
+func (o *fakeOperator) KeyringUse(key string, _ *api.WriteOptions) error {
+	o.calls = append(o.calls, "use "+key)
+	if key == o.failUse {
+		return errors.New("use failed")
+	}
+	for _, k := range o.keyrings {
+		k.apply(func(n *fakeNode) { n.primary = key })
+	}
+	return nil
+}
+
+func (o *fakeOperator) KeyringRemove(key string, _ *api.WriteOptions) error {
+	o.calls = append(o.calls, "remove "+key)
+	for _, k := range o.keyrings {
+		k.apply(func(n *fakeNode) {
+			if n.primary != key {
+				delete(n.keys, key)
+			}
+		})
+	}
+	return nil
+}
+
+func requireKeyrings(t *testing.T, op *fakeOperator, keys []string, primary string) {
+	t.Helper()
+	for _, k := range op.keyrings {
+		for _, n := range k.nodes {
+			require.Len(t, n.keys, len(keys))
+			for _, key := range keys {
+				require.True(t, n.keys[key], "key %s is missing", key)
+			}
+			require.Equal(t, primary, n.primary)
+		}
+	}
+}
+
+func TestRotation_Rotate(t *testing.T) {
+	op := newFakeOperator(3)
+	r := &rotation{ui: cli.NewMockUi(), operator: op}
+
+	require.NoError(t, r.Rotate(key2))
+	require.Equal(t, []string{"install " + key2, "use " + key2, "remove " + key1}, op.calls)
+	requireKeyrings(t, op, []string{key2}, key2)
+}
+
+func TestRotation_Rotate_Validation(t *testing.T) {
+	op := newFakeOperator(3)
+	r := &rotation{ui: cli.NewMockUi(), operator: op}
+
+	err := r.Rotate(key1)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "already the primary encryption key")
+
+	op.keyrings[0].nodes[0].keys[key2] = true
+	err = r.Rotate(key2)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "already installed")
+
+	op.keyrings[1].nodes[2].primary = key2
+	err = r.Rotate(key2)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "do not share a single primary key")
+
+	require.Empty(t, op.calls)
+}
+
+func TestRotation_Rotate_RollbackPartialInstall(t *testing.T) {
+	op := newFakeOperator(3)
+	op.keyrings[1].applyOn = 2
+	r := &rotation{ui: cli.NewMockUi(), operator: op}
+
+	err := r.Rotate(key2)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "only present on 2/3 nodes of the WAN keyring")
+	require.Contains(t, err.Error(), "rolled back")
+
+	require.Equal(t, []string{"install " + key2, "remove " + key2}, op.calls)
+	requireKeyrings(t, op, []string{key1}, key1)
+}
+
+func TestRotation_Rotate_RollbackFailedUse(t *testing.T) {
+	op := newFakeOperator(3)
+	op.failUse = key2
+	r := &rotation{ui: cli.NewMockUi(), operator: op}
+
+	err := r.Rotate(key2)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "use failed")
+
+	require.Equal(t, []string{"install " + key2, "use " + key2, "use " + key1, "remove " + key2}, op.calls)
+	requireKeyrings(t, op, []string{key1}, key1)
+}
@@ -26,6 +26,7 @@ type cmd struct {
 	// flags
 	logLevel string
 	logJSON  bool
+	loggers  flags.AppendSliceValue
 }
 
 func New(ui cli.Ui, shutdownCh <-chan struct{}) *cmd {
@@ -39,7 +40,13 @@ func (c *cmd) init() {
 	c.flags.StringVar(&c.logLevel, "log-level", "INFO",
 		"Log level of the agent.")
 	c.flags.BoolVar(&c.logJSON, "log-json", false,
-		"Output logs in JSON format.")
+		"Output logs in JSON format, one object per line, with the subsystem "+
+			"and event fields.")
+	c.flags.Var(&c.loggers, "logger",
+		"Only output the logs of the loggers with the given name, such as "+
+			"proxycfg or envoy.xds. The name is matched against the dot-separated "+
+			"parts of the name of the loggers, so proxycfg matches agent.proxycfg. "+
+			"This flag may be specified multiple times.")
 
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
@@ -62,18 +69,19 @@ func (c *cmd) Run(args []string) int {
 	}
 
 	eventDoneCh := make(chan struct{})
-	if c.logJSON {
-		logCh, err = client.Agent().MonitorJSON(c.logLevel, eventDoneCh, nil)
-		if err != nil {
+	opts := api.MonitorOptions{
+		LogLevel: c.logLevel,
+		LogJSON:  c.logJSON,
+		Loggers:  c.loggers,
+	}
+	logCh, err = client.Agent().MonitorWithOptions(opts, eventDoneCh, nil)
+	if err != nil {
+		if c.logJSON {
 			c.UI.Error(fmt.Sprintf("Error starting JSON monitor: %s", err))
-			return 1
-		}
-	} else {
-		logCh, err = client.Agent().Monitor(c.logLevel, eventDoneCh, nil)
-		if err != nil {
+		} else {
 			c.UI.Error(fmt.Sprintf("Error starting monitor: %s", err))
-			return 1
 		}
+		return 1
 	}
 
 	go func() {
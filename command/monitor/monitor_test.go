@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
 	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMonitorCommand_exitsOnSignalBeforeLinesArrive(t *testing.T) {
@@ -185,3 +187,48 @@ func TestMonitorCommand_LogJSONValidFormat(t *testing.T) {
 		t.Fatal("timed out waiting for exit")
 	}
 }
+
+func TestMonitorCommand_Logger(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := agent.StartTestAgent(t, agent.TestAgent{})
+	defer a.Shutdown()
+
+	shutdownCh := make(chan struct{})
+
+	ui := cli.NewMockUi()
+	c := New(ui, shutdownCh)
+	args := []string{"-http-addr=" + a.HTTPAddr(), "-log-json", "-log-level=debug", "-logger=proxycfg"}
+
+	exitCode := make(chan int, 1)
+	go func() {
+		exitCode <- c.Run(args)
+	}()
+
+	// Registering a service logs lines from other loggers which must be
+	// filtered out.
+	time.Sleep(200 * time.Millisecond)
+	require.NoError(t, a.Client().Agent().ServiceRegister(&api.AgentServiceRegistration{Name: "web"}))
+	time.Sleep(time.Second)
+	shutdownCh <- struct{}{}
+
+	select {
+	case ret := <-exitCode:
+		require.Equal(t, 0, ret)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for exit")
+	}
+
+	for _, output := range strings.Split(ui.OutputWriter.String(), "\n") {
+		if output == "" {
+			continue
+		}
+		var jsonLog map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(output), &jsonLog))
+		require.Contains(t, jsonLog["@module"], "proxycfg")
+		require.Equal(t, "proxycfg", jsonLog["@subsystem"])
+	}
+}
@@ -0,0 +1,170 @@
+package logs
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/flags"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+
+	// flags
+	index       uint64
+	limit       int
+	replayStart uint64
+	replayEnd   uint64
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Uint64Var(&c.index, "index", 0,
+		"Index of the most recent entry to display. Defaults to the last entry "+
+			"applied to the FSM.")
+	c.flags.IntVar(&c.limit, "limit", 0,
+		"Maximum number of entries to display. Defaults to 100.")
+	c.flags.Uint64Var(&c.replayStart, "replay-start", 0,
+		"Replay the entries from this index into a scratch FSM instead of "+
+			"displaying them, and report on the resulting state.")
+	c.flags.Uint64Var(&c.replayEnd, "replay-end", 0,
+		"Index of the last entry to replay. Defaults to the last entry applied "+
+			"to the FSM.")
+
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		c.UI.Error(fmt.Sprintf("Failed to parse args: %v", err))
+		return 1
+	}
+
+	if c.replayStart == 0 && c.replayEnd > 0 {
+		c.UI.Error("The -replay-end flag requires -replay-start")
+		return 1
+	}
+
+	// Set up a client.
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	q := &api.QueryOptions{
+		AllowStale: c.http.Stale(),
+	}
+	if c.replayStart > 0 {
+		replay, err := client.Operator().RaftReplayLogs(c.replayStart, c.replayEnd, q)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error replaying Raft log: %v", err))
+			return 1
+		}
+		c.UI.Output(formatReplay(replay))
+		return 0
+	}
+
+	logs, err := client.Operator().RaftGetLogs(c.index, c.limit, q)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error getting Raft log: %v", err))
+		return 1
+	}
+	c.UI.Output(formatLogs(logs))
+	if logs.NextIndex > 0 {
+		c.UI.Output(fmt.Sprintf("\nRun with -index=%d to display older entries.", logs.NextIndex))
+	}
+	return 0
+}
+
+func formatLogs(logs *api.RaftLogs) string {
+	result := []string{"Index\x1fTerm\x1fType\x1fSize\x1fSummary"}
+	for _, e := range logs.Entries {
+		result = append(result, fmt.Sprintf("%d\x1f%d\x1f%s\x1f%d\x1f%s",
+			e.Index, e.Term, e.Type, e.Size, e.Summary))
+	}
+	return columnize.Format(result, &columnize.Config{Delim: string([]byte{0x1f})})
+}
+
+func formatReplay(replay *api.RaftLogsReplay) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Server:            %s\n", replay.Server)
+	fmt.Fprintf(&b, "Replayed:          %d to %d (%d commands)\n", replay.StartIndex, replay.EndIndex, replay.Applied)
+	fmt.Fprintf(&b, "Nodes:             %d\n", replay.Nodes)
+	fmt.Fprintf(&b, "Services:          %d\n", replay.Services)
+	fmt.Fprintf(&b, "Service Instances: %d\n", replay.ServiceInstances)
+	fmt.Fprintf(&b, "KV Entries:        %d\n", replay.KVEntries)
+
+	tables := make([]string, 0, len(replay.Indexes))
+	for table := range replay.Indexes {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	result := []string{"Table\x1fIndex"}
+	for _, table := range tables {
+		result = append(result, fmt.Sprintf("%s\x1f%d", table, replay.Indexes[table]))
+	}
+	b.WriteString("\n")
+	b.WriteString(columnize.Format(result, &columnize.Config{Delim: string([]byte{0x1f})}))
+
+	if len(replay.Errors) > 0 {
+		result = []string{"Index\x1fType\x1fError"}
+		for _, e := range replay.Errors {
+			result = append(result, fmt.Sprintf("%d\x1f%s\x1f%s", e.Index, e.Type, e.Error))
+		}
+		fmt.Fprintf(&b, "\n\n%d entries failed to apply:\n", len(replay.Errors))
+		b.WriteString(columnize.Format(result, &columnize.Config{Delim: string([]byte{0x1f})}))
+	}
+	return b.String()
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const synopsis = "Inspect or replay the Raft log of a server"
+const help = `
+Usage: consul operator raft logs [options]
+
+  Pages through the Raft log on the disk of the leader, from the most recent
+  entries applied to the FSM to the older ones. With -stale, the log of the
+  server receiving the request is inspected instead, which can be used to
+  inspect a given server by pointing -http-addr at it:
+
+      $ consul operator raft logs -stale -http-addr=server-2:8500 -limit=20
+
+  With -replay-start, a range of the log is replayed into a scratch FSM
+  instead, without affecting the state of the server, and the resulting
+  state is reported. Replaying the same range on several servers helps
+  finding where their states diverged:
+
+      $ consul operator raft logs -stale -replay-start=1200 -replay-end=1500
+
+  Entries before the start of the range are not replayed, so commands
+  depending on them may fail to apply.
+`
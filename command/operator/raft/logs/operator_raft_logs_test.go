@@ -0,0 +1,63 @@
+package logs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+)
+
+func TestOperatorRaftLogsCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(cli.NewMockUi()).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestOperatorRaftLogsCommand(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := agent.NewTestAgent(t, ``)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	_, err := a.Client().KV().Put(&api.KVPair{Key: "foo", Value: []byte("bar")}, nil)
+	require.NoError(t, err)
+
+	t.Run("page", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := New(ui)
+		code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-limit=1"})
+		require.Equal(t, 0, code, ui.ErrorWriter.String())
+		output := ui.OutputWriter.String()
+		require.Contains(t, output, "KVS")
+		require.Contains(t, output, "op=set key=foo")
+		require.Contains(t, output, "to display older entries")
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := New(ui)
+		code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-replay-start=1"})
+		require.Equal(t, 0, code, ui.ErrorWriter.String())
+		output := ui.OutputWriter.String()
+		require.Contains(t, output, "KV Entries:        1")
+		require.Contains(t, output, "kvs")
+	})
+
+	t.Run("replay end without start", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := New(ui)
+		code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-replay-end=10"})
+		require.Equal(t, 1, code)
+		require.Contains(t, ui.ErrorWriter.String(), "requires -replay-start")
+	})
+}
@@ -0,0 +1,222 @@
+package establish
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/flags"
+)
+
+const (
+	defaultTimeout      = time.Minute
+	defaultPollInterval = time.Second
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+
+	from      string
+	fromName  string
+	fromToken string
+	to        string
+	toName    string
+	toToken   string
+	meta      map[string]string
+	timeout   time.Duration
+
+	// pollInterval is how often the state of the peering is read while
+	// waiting for its stream to become healthy.
+	pollInterval time.Duration
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.StringVar(&c.from, "from", "",
+		"The `address` of the HTTP API of the cluster generating the peering token. "+
+			"It accepts the stream opened by the -to cluster. This flag is required.")
+	c.flags.StringVar(&c.fromName, "from-name", "",
+		"The name of the -from cluster. The -to cluster names its peering after it. "+
+			"This flag is required.")
+	c.flags.StringVar(&c.fromToken, "from-token", "",
+		"ACL token to use in the requests to the -from cluster. Defaults to the "+
+			"token given with -token.")
+	c.flags.StringVar(&c.to, "to", "",
+		"The `address` of the HTTP API of the cluster establishing the peering with "+
+			"the token. It dials the -from cluster. This flag is required.")
+	c.flags.StringVar(&c.toName, "to-name", "",
+		"The name of the -to cluster. The -from cluster names its peering after it. "+
+			"This flag is required.")
+	c.flags.StringVar(&c.toToken, "to-token", "",
+		"ACL token to use in the requests to the -to cluster. Defaults to the "+
+			"token given with -token.")
+	c.flags.Var((*flags.FlagMapValue)(&c.meta), "meta",
+		"Metadata to set on the peering in both clusters, formatted as key=value. "+
+			"This flag may be specified multiple times to set multiple meta fields.")
+	c.flags.DurationVar(&c.timeout, "timeout", defaultTimeout,
+		"How long to wait for the replication stream between the clusters to be "+
+			"healthy before failing.")
+
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	c.help = flags.Usage(help, c.flags)
+
+	c.pollInterval = defaultPollInterval
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	switch {
+	case c.from == "":
+		c.UI.Error("Missing the required -from flag")
+		return 1
+	case c.fromName == "":
+		c.UI.Error("Missing the required -from-name flag")
+		return 1
+	case c.to == "":
+		c.UI.Error("Missing the required -to flag")
+		return 1
+	case c.toName == "":
+		c.UI.Error("Missing the required -to-name flag")
+		return 1
+	}
+
+	fromClient, err := c.apiClient(c.from, c.fromToken)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to the -from cluster: %s", err))
+		return 1
+	}
+	toClient, err := c.apiClient(c.to, c.toToken)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to the -to cluster: %s", err))
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	tokenResp, _, err := fromClient.Peerings().GenerateToken(ctx, api.PeeringGenerateTokenRequest{
+		PeerName: c.toName,
+		Meta:     c.meta,
+	}, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error generating the peering token on %s: %s", c.from, err))
+		return 1
+	}
+	c.UI.Info(fmt.Sprintf("Generated a peering token for %q on %s", c.toName, c.from))
+
+	_, _, err = toClient.Peerings().Establish(ctx, api.PeeringEstablishRequest{
+		PeerName:     c.fromName,
+		PeeringToken: tokenResp.PeeringToken,
+		Meta:         c.meta,
+	}, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error establishing the peering on %s: %s", c.to, err))
+		return 1
+	}
+	c.UI.Info(fmt.Sprintf("Established the peering with %q on %s", c.fromName, c.to))
+
+	// The peerings are written before the stream is opened, so wait for both
+	// clusters to report it as connected.
+	if err := c.waitForActive(ctx, toClient, c.fromName, c.to); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if err := c.waitForActive(ctx, fromClient, c.toName, c.from); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	c.UI.Output(fmt.Sprintf("Peering between %q and %q is active", c.fromName, c.toName))
+	return 0
+}
+
+// apiClient returns a client for the cluster at addr, using the TLS settings
+// of the HTTP flags and the given token if set.
+func (c *cmd) apiClient(addr, token string) (*api.Client, error) {
+	conf := api.DefaultConfig()
+	c.http.MergeOntoConfig(conf)
+	conf.Address = addr
+	if token != "" {
+		conf.Token = token
+		conf.TokenFile = ""
+	}
+	return api.NewClient(conf)
+}
+
+// waitForActive reads the peering named name until its state is active, or
+// until the context is done.
+func (c *cmd) waitForActive(ctx context.Context, client *api.Client, name, addr string) error {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	var lastState api.PeeringState
+	for {
+		peering, _, err := client.Peerings().Read(ctx, name, nil)
+		switch {
+		case err == nil && peering == nil:
+			return fmt.Errorf("Error reading the peering %q on %s: peering not found", name, addr)
+		case err == nil && peering.State == api.PeeringStateActive:
+			return nil
+		case err == nil && peering.State == api.PeeringStateTerminated:
+			return fmt.Errorf("Peering %q on %s was terminated", name, addr)
+		case err == nil:
+			lastState = peering.State
+		case ctx.Err() == nil:
+			return fmt.Errorf("Error reading the peering %q on %s: %s", name, addr, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Timed out waiting for the peering %q on %s to be active, its state is %s", name, addr, lastState)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const (
+	synopsis = "Establish a peering between two clusters"
+	help     = `
+Usage: consul peering establish [options] -from <addr> -from-name <name> -to <addr> -to-name <name>
+
+  Establish a peering between two clusters in a single step. A peering token
+  is generated by the -from cluster and used by the -to cluster to establish
+  the peering. The command then waits for both clusters to report the
+  replication stream between them as active.
+
+  Peer the cluster at dc1.example.com with the one at dc2.example.com:
+
+      $ consul peering establish \
+          -from https://dc1.example.com:8501 -from-name dc1 \
+          -to https://dc2.example.com:8501 -to-name dc2
+
+  The TLS flags apply to both clusters. Use -from-token and -to-token when
+  the clusters require different ACL tokens:
+
+      $ consul peering establish -from-token <token> -to-token <token> ...
+`
+)
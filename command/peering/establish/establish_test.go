@@ -0,0 +1,107 @@
+package establish
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+)
+
+func TestEstablishCommand_noTabs(t *testing.T) {
+	t.Parallel()
+
+	if strings.ContainsRune(New(cli.NewMockUi()).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestEstablishCommand_Validation(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		args   []string
+		output string
+	}{
+		"no from": {
+			args:   []string{"-from-name=dc1", "-to=127.0.0.1:8500", "-to-name=dc2"},
+			output: "Missing the required -from flag",
+		},
+		"no from name": {
+			args:   []string{"-from=127.0.0.1:8500", "-to=127.0.0.1:8500", "-to-name=dc2"},
+			output: "Missing the required -from-name flag",
+		},
+		"no to": {
+			args:   []string{"-from=127.0.0.1:8500", "-from-name=dc1", "-to-name=dc2"},
+			output: "Missing the required -to flag",
+		},
+		"no to name": {
+			args:   []string{"-from=127.0.0.1:8500", "-from-name=dc1", "-to=127.0.0.1:8500"},
+			output: "Missing the required -to-name flag",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			cmd := New(ui)
+
+			require.Equal(t, 1, cmd.Run(tc.args))
+			require.Contains(t, ui.ErrorWriter.String(), tc.output)
+		})
+	}
+}
+
+func TestEstablishCommand(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	acceptor := agent.NewTestAgent(t, ``)
+	t.Cleanup(func() { _ = acceptor.Shutdown() })
+
+	dialer := agent.NewTestAgent(t, `datacenter = "dc2"`)
+	t.Cleanup(func() { _ = dialer.Shutdown() })
+
+	testrpc.WaitForTestAgent(t, acceptor.RPC, "dc1")
+	testrpc.WaitForTestAgent(t, dialer.RPC, "dc2")
+
+	ui := cli.NewMockUi()
+	cmd := New(ui)
+	cmd.pollInterval = 100 * time.Millisecond
+
+	args := []string{
+		"-from=" + acceptor.HTTPAddr(),
+		"-from-name=dc1",
+		"-to=" + dialer.HTTPAddr(),
+		"-to-name=dc2",
+		"-meta=env=test",
+		"-timeout=30s",
+	}
+	require.Equal(t, 0, cmd.Run(args), ui.ErrorWriter.String())
+	require.Contains(t, ui.OutputWriter.String(), `Peering between "dc1" and "dc2" is active`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The dialing cluster names its peering after the accepting one and the
+	// other way around.
+	peering, _, err := dialer.Client().Peerings().Read(ctx, "dc1", nil)
+	require.NoError(t, err)
+	require.NotNil(t, peering)
+	require.Equal(t, api.PeeringStateActive, peering.State)
+	require.Equal(t, map[string]string{"env": "test"}, peering.Meta)
+
+	peering, _, err = acceptor.Client().Peerings().Read(ctx, "dc2", nil)
+	require.NoError(t, err)
+	require.NotNil(t, peering)
+	require.Equal(t, api.PeeringStateActive, peering.State)
+}
@@ -0,0 +1,41 @@
+package peering
+
+import (
+	"github.com/mitchellh/cli"
+
+	"github.com/hashicorp/consul/command/flags"
+)
+
+func New() *cmd {
+	return &cmd{}
+}
+
+type cmd struct{}
+
+func (c *cmd) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return flags.Usage(help, nil)
+}
+
+const synopsis = "Interact with cluster peerings"
+const help = `
+Usage: consul peering <subcommand> [options] [args]
+
+  This command has subcommands for interacting with cluster peerings. A
+  peering connects two Consul clusters so that they can export services to
+  each other.
+
+  Establish a peering between the clusters at two addresses:
+
+      $ consul peering establish -from https://dc1.example.com:8501 \
+          -from-name dc1 -to https://dc2.example.com:8501 -to-name dc2
+
+  For more examples, ask for subcommand help or view the documentation.
+`
@@ -72,9 +72,11 @@ import (
 	ixnget "github.com/hashicorp/consul/command/intention/get"
 	ixnlist "github.com/hashicorp/consul/command/intention/list"
 	ixnmatch "github.com/hashicorp/consul/command/intention/match"
+	ixnvalidate "github.com/hashicorp/consul/command/intention/validate"
 	"github.com/hashicorp/consul/command/join"
 	"github.com/hashicorp/consul/command/keygen"
 	"github.com/hashicorp/consul/command/keyring"
+	keyringrotate "github.com/hashicorp/consul/command/keyring/rotate"
 	"github.com/hashicorp/consul/command/kv"
 	kvdel "github.com/hashicorp/consul/command/kv/del"
 	kvexp "github.com/hashicorp/consul/command/kv/exp"
@@ -95,7 +97,10 @@ import (
 	operautostate "github.com/hashicorp/consul/command/operator/autopilot/state"
 	operraft "github.com/hashicorp/consul/command/operator/raft"
 	operraftlist "github.com/hashicorp/consul/command/operator/raft/listpeers"
+	operraftlogs "github.com/hashicorp/consul/command/operator/raft/logs"
 	operraftremove "github.com/hashicorp/consul/command/operator/raft/removepeer"
+	"github.com/hashicorp/consul/command/peering"
+	peeringestablish "github.com/hashicorp/consul/command/peering/establish"
 	"github.com/hashicorp/consul/command/reload"
 	"github.com/hashicorp/consul/command/rtt"
 	"github.com/hashicorp/consul/command/services"
@@ -190,9 +195,11 @@ func RegisteredCommands(ui cli.Ui) map[string]mcli.CommandFactory {
 		entry{"intention get", func(ui cli.Ui) (cli.Command, error) { return ixnget.New(ui), nil }},
 		entry{"intention list", func(ui cli.Ui) (cli.Command, error) { return ixnlist.New(ui), nil }},
 		entry{"intention match", func(ui cli.Ui) (cli.Command, error) { return ixnmatch.New(ui), nil }},
+		entry{"intention validate", func(ui cli.Ui) (cli.Command, error) { return ixnvalidate.New(ui), nil }},
 		entry{"join", func(ui cli.Ui) (cli.Command, error) { return join.New(ui), nil }},
 		entry{"keygen", func(ui cli.Ui) (cli.Command, error) { return keygen.New(ui), nil }},
 		entry{"keyring", func(ui cli.Ui) (cli.Command, error) { return keyring.New(ui), nil }},
+		entry{"keyring rotate", func(ui cli.Ui) (cli.Command, error) { return keyringrotate.New(ui), nil }},
 		entry{"kv", func(cli.Ui) (cli.Command, error) { return kv.New(), nil }},
 		entry{"kv delete", func(ui cli.Ui) (cli.Command, error) { return kvdel.New(ui), nil }},
 		entry{"kv export", func(ui cli.Ui) (cli.Command, error) { return kvexp.New(ui), nil }},
@@ -213,7 +220,10 @@ func RegisteredCommands(ui cli.Ui) map[string]mcli.CommandFactory {
 		entry{"operator autopilot state", func(ui cli.Ui) (cli.Command, error) { return operautostate.New(ui), nil }},
 		entry{"operator raft", func(cli.Ui) (cli.Command, error) { return operraft.New(), nil }},
 		entry{"operator raft list-peers", func(ui cli.Ui) (cli.Command, error) { return operraftlist.New(ui), nil }},
+		entry{"operator raft logs", func(ui cli.Ui) (cli.Command, error) { return operraftlogs.New(ui), nil }},
 		entry{"operator raft remove-peer", func(ui cli.Ui) (cli.Command, error) { return operraftremove.New(ui), nil }},
+		entry{"peering", func(cli.Ui) (cli.Command, error) { return peering.New(), nil }},
+		entry{"peering establish", func(ui cli.Ui) (cli.Command, error) { return peeringestablish.New(ui), nil }},
 		entry{"reload", func(ui cli.Ui) (cli.Command, error) { return reload.New(ui), nil }},
 		entry{"rtt", func(ui cli.Ui) (cli.Command, error) { return rtt.New(ui), nil }},
 		entry{"services", func(cli.Ui) (cli.Command, error) { return services.New(), nil }},
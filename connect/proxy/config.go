@@ -32,6 +32,10 @@ type Config struct {
 	ProxiedServiceName      string `json:"proxied_service_name" hcl:"proxied_service_name"`
 	ProxiedServiceNamespace string `json:"proxied_service_namespace" hcl:"proxied_service_namespace"`
 
+	// ProxiedServiceID is the ID of the local service instance this proxy is
+	// representing, if any. Detected protocols are reported against it.
+	ProxiedServiceID string `json:"proxied_service_id" hcl:"proxied_service_id"`
+
 	// PublicListener configures the mTLS listener.
 	PublicListener PublicListenerConfig `json:"public_listener" hcl:"public_listener"`
 
@@ -70,6 +74,11 @@ type PublicListenerConfig struct {
 	// handshake. Setting this low avoids DOS by malicious clients holding
 	// resources open. Defaults to 10000 (10s).
 	HandshakeTimeoutMs int `json:"handshake_timeout_ms" hcl:"handshake_timeout_ms" mapstructure:"handshake_timeout_ms"`
+
+	// DetectProtocol enables detection of the protocol used by inbound
+	// connections. The detected protocol is reported to the local agent for
+	// the proxied service.
+	DetectProtocol bool `json:"detect_protocol" hcl:"detect_protocol" mapstructure:"detect_protocol"`
 }
 
 // applyDefaults sets zero-valued params to a reasonable default.
@@ -236,6 +245,7 @@ func (w *AgentConfigWatcher) handler(blockVal watch.BlockingParamVal,
 		// Token should be already setup in the client
 		ProxiedServiceName:      resp.Proxy.DestinationServiceName,
 		ProxiedServiceNamespace: "default",
+		ProxiedServiceID:        resp.Proxy.DestinationServiceID,
 	}
 
 	if tRaw, ok := resp.Proxy.Config["telemetry"]; ok {
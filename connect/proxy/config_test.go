@@ -102,6 +102,7 @@ func TestAgentConfigWatcherSidecarProxy(t *testing.T) {
 				Proxy: &api.AgentServiceConnectProxyConfig{
 					Config: map[string]interface{}{
 						"handshake_timeout_ms": 999,
+						"detect_protocol":      true,
 					},
 					Upstreams: []api.Upstream{
 						{
@@ -125,12 +126,14 @@ func TestAgentConfigWatcherSidecarProxy(t *testing.T) {
 	expectCfg := &Config{
 		ProxiedServiceName:      "web",
 		ProxiedServiceNamespace: "default",
+		ProxiedServiceID:        "web",
 		PublicListener: PublicListenerConfig{
 			BindAddress:           "0.0.0.0",
 			BindPort:              21000,
 			LocalServiceAddress:   "127.0.0.1:8080",
 			HandshakeTimeoutMs:    999,
 			LocalConnectTimeoutMs: 1000, // from applyDefaults
+			DetectProtocol:        true,
 		},
 		Upstreams: []UpstreamConfig{
 			{
@@ -52,6 +52,10 @@ type Listener struct {
 
 	logger hclog.Logger
 
+	// protocolDetected, when set, is called with the protocol detected on
+	// each accepted connection.
+	protocolDetected func(protocol string)
+
 	// Gauge to track current open connections
 	activeConns  int32
 	connWG       sync.WaitGroup
@@ -170,6 +174,10 @@ func (l *Listener) handleConn(src net.Conn) {
 	// Make sure Listener.Close waits for this conn to be cleaned up.
 	defer l.connWG.Done()
 
+	if l.protocolDetected != nil {
+		src = newSniffConn(src, l.protocolDetected)
+	}
+
 	dst, err := l.dialFunc()
 	if err != nil {
 		l.logger.Error("failed to dial", "error", err)
@@ -82,6 +82,9 @@ func (p *Proxy) Serve() error {
 					if newCfg.PublicListener.BindPort != 0 {
 						newCfg.PublicListener.applyDefaults()
 						l := NewPublicListener(p.service, newCfg.PublicListener, p.logger)
+						if newCfg.PublicListener.DetectProtocol && newCfg.ProxiedServiceID != "" {
+							l.protocolDetected = newProtocolReporter(p.client, newCfg.ProxiedServiceID, p.logger).Detected
+						}
 						err = p.startListener("public listener", l)
 						if err != nil {
 							// This should probably be fatal.
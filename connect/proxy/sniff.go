@@ -0,0 +1,161 @@
+package proxy
+
+import (
+	"bytes"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+
+	"github.com/hashicorp/consul/api"
+)
+
+const (
+	// maxSniffBytes is the maximum number of bytes inspected on a connection
+	// before giving up on refining the detected protocol.
+	maxSniffBytes = 16 * 1024
+)
+
+// http1RequestLine matches the request line of an HTTP/1.x request.
+var http1RequestLine = regexp.MustCompile(`^[A-Z]+ \S+ HTTP/1\.[01]\r\n`)
+
+// sniffConn passively inspects the first bytes read from the wrapped
+// connection to detect the application protocol in use. The bytes are passed
+// through unchanged, so detection never delays the connection, and server
+// first protocols are unaffected.
+type sniffConn struct {
+	net.Conn
+
+	buf      []byte
+	done     bool
+	detected func(protocol string)
+}
+
+func newSniffConn(conn net.Conn, detected func(protocol string)) *sniffConn {
+	return &sniffConn{Conn: conn, detected: detected}
+}
+
+func (c *sniffConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 && !c.done {
+		c.buf = append(c.buf, b[:n]...)
+		if protocol, ok := sniffProtocol(c.buf, len(c.buf) >= maxSniffBytes); ok {
+			c.done = true
+			c.buf = nil
+			c.detected(protocol)
+		}
+	}
+	return n, err
+}
+
+// sniffProtocol detects the protocol used by a client from the first bytes it
+// sent. It returns false when more bytes are needed to decide, unless final
+// is set in which case the best guess is returned.
+func sniffProtocol(buf []byte, final bool) (string, bool) {
+	preface := []byte(http2.ClientPreface)
+	if len(buf) < len(preface) && bytes.HasPrefix(preface, buf) && !final {
+		return "", false
+	}
+	if bytes.HasPrefix(buf, preface) {
+		return sniffHTTP2(buf[len(preface):], final)
+	}
+
+	line := buf
+	if i := bytes.Index(buf, []byte("\r\n")); i >= 0 {
+		line = buf[:i+2]
+	} else if !final && len(buf) < maxSniffBytes && isRequestLinePrefix(buf) {
+		return "", false
+	}
+	if http1RequestLine.Match(line) {
+		return "http", true
+	}
+	return "tcp", true
+}
+
+// isRequestLinePrefix reports whether buf could still be the start of an
+// HTTP/1.x request line.
+func isRequestLinePrefix(buf []byte) bool {
+	for i, c := range buf {
+		if c == ' ' {
+			return i > 0 && !bytes.ContainsAny(buf[i+1:], "\r\n")
+		}
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// sniffHTTP2 distinguishes gRPC from other HTTP/2 traffic using the
+// content-type of the first request.
+func sniffHTTP2(frames []byte, final bool) (string, bool) {
+	framer := http2.NewFramer(nil, bytes.NewReader(frames))
+	framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	for {
+		f, err := framer.ReadFrame()
+		if err != nil {
+			// Either the frames are incomplete or malformed. Wait for more
+			// bytes in the former case.
+			if final {
+				return "http2", true
+			}
+			return "", false
+		}
+		headers, ok := f.(*http2.MetaHeadersFrame)
+		if !ok {
+			continue
+		}
+		for _, field := range headers.RegularFields() {
+			if field.Name == "content-type" && strings.HasPrefix(field.Value, "application/grpc") {
+				return "grpc", true
+			}
+		}
+		return "http2", true
+	}
+}
+
+// protocolReporter reports the protocols detected on the public listener to
+// the local agent, which records them on the proxied service.
+type protocolReporter struct {
+	client    *api.Client
+	serviceID string
+	logger    hclog.Logger
+
+	mu   sync.Mutex
+	last string
+}
+
+func newProtocolReporter(client *api.Client, serviceID string, logger hclog.Logger) *protocolReporter {
+	return &protocolReporter{
+		client:    client,
+		serviceID: serviceID,
+		logger:    logger.Named("protocol-detection"),
+	}
+}
+
+// Detected records that a connection using protocol was seen. The agent is
+// only updated when the protocol changes.
+func (r *protocolReporter) Detected(protocol string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if protocol == r.last {
+		return
+	}
+	r.last = protocol
+
+	go func() {
+		if err := r.client.Agent().ServiceDetectedProtocol(r.serviceID, protocol); err != nil {
+			r.logger.Warn("failed to report detected protocol", "protocol", protocol, "error", err)
+
+			r.mu.Lock()
+			if r.last == protocol {
+				r.last = ""
+			}
+			r.mu.Unlock()
+		}
+	}()
+}
@@ -0,0 +1,119 @@
+package proxy
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+func TestSniffProtocol(t *testing.T) {
+	http2Request := func(contentType string) []byte {
+		var headers bytes.Buffer
+		enc := hpack.NewEncoder(&headers)
+		enc.WriteField(hpack.HeaderField{Name: ":method", Value: "POST"})
+		enc.WriteField(hpack.HeaderField{Name: ":path", Value: "/pkg.Service/Method"})
+		enc.WriteField(hpack.HeaderField{Name: ":scheme", Value: "http"})
+		enc.WriteField(hpack.HeaderField{Name: ":authority", Value: "web"})
+		enc.WriteField(hpack.HeaderField{Name: "content-type", Value: contentType})
+
+		var buf bytes.Buffer
+		buf.WriteString(http2.ClientPreface)
+		framer := http2.NewFramer(&buf, nil)
+		require.NoError(t, framer.WriteSettings())
+		require.NoError(t, framer.WriteHeaders(http2.HeadersFrameParam{
+			StreamID:      1,
+			BlockFragment: headers.Bytes(),
+			EndHeaders:    true,
+		}))
+		return buf.Bytes()
+	}
+
+	cases := map[string]struct {
+		input    []byte
+		final    bool
+		protocol string
+		ok       bool
+	}{
+		"http/1.1": {
+			input:    []byte("GET /health HTTP/1.1\r\nHost: web\r\n\r\n"),
+			protocol: "http",
+			ok:       true,
+		},
+		"partial request line": {
+			input: []byte("GET /hea"),
+		},
+		"partial preface": {
+			input: []byte("PRI * HTTP/2"),
+		},
+		"http2": {
+			input:    http2Request("application/json"),
+			protocol: "http2",
+			ok:       true,
+		},
+		"grpc": {
+			input:    http2Request("application/grpc+proto"),
+			protocol: "grpc",
+			ok:       true,
+		},
+		"http2 without headers yet": {
+			input: []byte(http2.ClientPreface),
+		},
+		"http2 without headers on close": {
+			input:    []byte(http2.ClientPreface),
+			final:    true,
+			protocol: "http2",
+			ok:       true,
+		},
+		"binary": {
+			input:    []byte{0x16, 0x03, 0x01, 0x02, 0x00},
+			protocol: "tcp",
+			ok:       true,
+		},
+		"lower case text": {
+			input:    []byte("hello\r\n"),
+			protocol: "tcp",
+			ok:       true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			protocol, ok := sniffProtocol(tc.input, tc.final)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.protocol, protocol)
+		})
+	}
+}
+
+func TestSniffConn(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	var detected []string
+	conn := newSniffConn(server, func(protocol string) {
+		detected = append(detected, protocol)
+	})
+
+	go func() {
+		client.Write([]byte("GET / HTT"))
+		client.Write([]byte("P/1.1\r\n\r\n"))
+		client.Write([]byte("more"))
+	}()
+
+	var out []byte
+	buf := make([]byte, 64)
+	for len(out) < len("GET / HTTP/1.1\r\n\r\nmore") {
+		n, err := conn.Read(buf)
+		require.NoError(t, err)
+		out = append(out, buf[:n]...)
+	}
+
+	// The bytes are passed through unchanged and the protocol is only
+	// reported once.
+	require.Equal(t, "GET / HTTP/1.1\r\n\r\nmore", string(out))
+	require.Equal(t, []string{"http"}, detected)
+}
@@ -210,6 +210,30 @@ type TelemetryConfig struct {
 	//
 	// hcl: telemetry { prometheus_retention_time = "duration" }
 	PrometheusOpts prometheus.PrometheusOpts
+
+	// PrometheusHistograms records the samples exported to Prometheus as
+	// classic histograms with fixed exponential buckets rather than summaries,
+	// so that they can be aggregated across agents. Native histograms are not
+	// supported.
+	//
+	// hcl: telemetry { prometheus_histograms = (true|false) }
+	PrometheusHistograms bool `json:"prometheus_histograms,omitempty" mapstructure:"prometheus_histograms"`
+
+	// PrometheusNativeHistograms adds Prometheus native histogram buckets to
+	// the histograms, which are exported along with the classic buckets when
+	// scraped with the protobuf format. It implies PrometheusHistograms.
+	//
+	// hcl: telemetry { prometheus_native_histograms = (true|false) }
+	PrometheusNativeHistograms bool `json:"prometheus_native_histograms,omitempty" mapstructure:"prometheus_native_histograms"`
+
+	// PrometheusAllowedLabels and PrometheusBlockedLabels are the names of the
+	// labels kept and dropped from the metrics exported to Prometheus, to
+	// control the cardinality of the exposition. When labels are allowed, all
+	// the others are dropped.
+	//
+	// hcl: telemetry { prometheus_label_filter = ["+label", "-label"] }
+	PrometheusAllowedLabels []string `json:"prometheus_allowed_labels,omitempty" mapstructure:"prometheus_allowed_labels"`
+	PrometheusBlockedLabels []string `json:"prometheus_blocked_labels,omitempty" mapstructure:"prometheus_blocked_labels"`
 }
 
 // MetricsHandler provides an http.Handler for displaying metrics.
@@ -219,7 +243,12 @@ type MetricsHandler interface {
 }
 
 type MetricsConfig struct {
-	Handler  MetricsHandler
+	Handler MetricsHandler
+
+	// Live collects the metrics at a short interval for streaming. It is nil
+	// when only the Handler is available.
+	Live *LiveMetrics
+
 	mu       sync.Mutex
 	cancelFn context.CancelFunc
 }
@@ -268,7 +297,7 @@ func prometheusSink(cfg TelemetryConfig, hostname string) (metrics.MetricSink, e
 		return nil, nil
 	}
 
-	sink, err := prometheus.NewPrometheusSinkFrom(cfg.PrometheusOpts)
+	sink, err := newPrometheusFilteredSink(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -366,8 +395,12 @@ func InitTelemetry(cfg TelemetryConfig, logger hclog.Logger) (*MetricsConfig, er
 	memSink := metrics.NewInmemSink(10*time.Second, time.Minute)
 	metrics.DefaultInmemSignal(memSink)
 
+	liveSink := NewLiveMetrics(LiveMetricsInterval)
+	inmemSinks := metrics.FanoutSink{memSink, liveSink}
+
 	metricsConfig := &MetricsConfig{
 		Handler: memSink,
+		Live:    liveSink,
 	}
 
 	var cancel context.CancelFunc
@@ -378,7 +411,7 @@ func InitTelemetry(cfg TelemetryConfig, logger hclog.Logger) (*MetricsConfig, er
 		}
 		for {
 			logger.Warn("retrying configure metric sinks", "retries", waiter.Failures())
-			_, err := configureSinks(cfg, memSink)
+			_, err := configureSinks(cfg, inmemSinks)
 			if err == nil {
 				logger.Info("successfully configured metrics sinks")
 				return
@@ -391,7 +424,7 @@ func InitTelemetry(cfg TelemetryConfig, logger hclog.Logger) (*MetricsConfig, er
 		}
 	}
 
-	if _, errs := configureSinks(cfg, memSink); errs != nil {
+	if _, errs := configureSinks(cfg, inmemSinks); errs != nil {
 		if isRetriableError(errs) && cfg.RetryFailedConfiguration {
 			logger.Warn("failed configure sinks", "error", multierror.Flatten(errs))
 			ctx, cancel = context.WithCancel(context.Background())
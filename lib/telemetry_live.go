@@ -0,0 +1,199 @@
+package lib
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/armon/go-metrics"
+)
+
+const (
+	// LiveMetricsInterval is the resolution of the live metrics stream.
+	LiveMetricsInterval = time.Second
+
+	// LiveMetricsMaxWindow is the largest aggregation window that can be
+	// requested from the live metrics stream.
+	LiveMetricsMaxWindow = time.Minute
+)
+
+// LiveMetricsSummary holds a roll-up of the metrics over an aggregation
+// window ending at Timestamp.
+type LiveMetricsSummary struct {
+	Timestamp string
+	Window    string
+	Gauges    []metrics.GaugeValue
+	Counters  []metrics.SampledValue
+	Samples   []metrics.SampledValue
+}
+
+// LiveMetrics is an in-memory sink with a short interval that keeps enough
+// intervals around to aggregate them over sliding windows. Counters and
+// samples are summed over the window, and their rate is computed for the
+// whole window, so consumers of the stream do not need to diff anything.
+type LiveMetrics struct {
+	*metrics.InmemSink
+	interval time.Duration
+}
+
+// NewLiveMetrics returns a LiveMetrics collecting intervals of the given
+// length, usually LiveMetricsInterval.
+func NewLiveMetrics(interval time.Duration) *LiveMetrics {
+	// Keep a couple more intervals than needed for the largest window, since
+	// the last one is always the interval that is still in progress.
+	retain := time.Duration(LiveMetricsMaxWindow/interval+2) * interval
+	return &LiveMetrics{
+		InmemSink: metrics.NewInmemSink(interval, retain),
+		interval:  interval,
+	}
+}
+
+// NormalizeWindow rounds the window up to a whole number of intervals and
+// clamps it to [interval, LiveMetricsMaxWindow].
+func (l *LiveMetrics) NormalizeWindow(window time.Duration) time.Duration {
+	if window <= l.interval {
+		return l.interval
+	}
+	if window > LiveMetricsMaxWindow {
+		window = LiveMetricsMaxWindow
+	}
+	if rem := window % l.interval; rem != 0 {
+		window += l.interval - rem
+	}
+	return window
+}
+
+// StreamWindow writes a summary of the metrics aggregated over window using
+// encoder.Encode each time an interval ends. Runs until ctx is cancelled, or
+// the encoder returns an error.
+func (l *LiveMetrics) StreamWindow(ctx context.Context, window time.Duration, encoder metrics.Encoder) {
+	window = l.NormalizeWindow(window)
+	l.InmemSink.Stream(ctx, encoderFunc(func(interface{}) error {
+		summary, ok := l.Summary(window)
+		if !ok {
+			return nil
+		}
+		return encoder.Encode(summary)
+	}))
+}
+
+type encoderFunc func(interface{}) error
+
+func (f encoderFunc) Encode(v interface{}) error {
+	return f(v)
+}
+
+// Summary aggregates the finished intervals over the given window, ending
+// with the most recent one. It returns false if no interval has finished
+// yet.
+func (l *LiveMetrics) Summary(window time.Duration) (LiveMetricsSummary, bool) {
+	window = l.NormalizeWindow(window)
+
+	data := l.InmemSink.Data()
+	finished := data[:len(data)-1]
+	if len(finished) == 0 {
+		return LiveMetricsSummary{}, false
+	}
+
+	end := finished[len(finished)-1].Interval.Add(l.interval)
+	start := end.Add(-window)
+
+	gauges := make(map[string]metrics.GaugeValue)
+	counters := make(map[string]metrics.SampledValue)
+	samples := make(map[string]metrics.SampledValue)
+	for _, interval := range finished {
+		if interval.Interval.Before(start) {
+			continue
+		}
+
+		interval.RLock()
+		for hash, value := range interval.Gauges {
+			// Intervals are in order so the last value wins.
+			gauges[hash] = value
+		}
+		mergeSampledValues(counters, interval.Counters)
+		mergeSampledValues(samples, interval.Samples)
+		interval.RUnlock()
+	}
+
+	summary := LiveMetricsSummary{
+		Timestamp: end.UTC().String(),
+		Window:    window.String(),
+		Gauges:    make([]metrics.GaugeValue, 0, len(gauges)),
+		Counters:  formatLiveSamples(counters, window),
+		Samples:   formatLiveSamples(samples, window),
+	}
+
+	for hash, value := range gauges {
+		value.Hash = hash
+		value.DisplayLabels = displayLabels(value.Labels)
+		value.Labels = nil
+		summary.Gauges = append(summary.Gauges, value)
+	}
+	sort.Slice(summary.Gauges, func(i, j int) bool {
+		return summary.Gauges[i].Hash < summary.Gauges[j].Hash
+	})
+
+	return summary, true
+}
+
+// mergeSampledValues adds the aggregates in src to the ones in dst.
+func mergeSampledValues(dst, src map[string]metrics.SampledValue) {
+	for hash, value := range src {
+		if value.AggregateSample == nil {
+			continue
+		}
+
+		existing, ok := dst[hash]
+		if !ok {
+			agg := *value.AggregateSample
+			value.AggregateSample = &agg
+			dst[hash] = value
+			continue
+		}
+
+		agg := existing.AggregateSample
+		if value.Min < agg.Min {
+			agg.Min = value.Min
+		}
+		if value.Max > agg.Max {
+			agg.Max = value.Max
+		}
+		agg.Count += value.Count
+		agg.Sum += value.Sum
+		agg.SumSq += value.SumSq
+		if value.LastUpdated.After(agg.LastUpdated) {
+			agg.LastUpdated = value.LastUpdated
+		}
+	}
+}
+
+func formatLiveSamples(source map[string]metrics.SampledValue, window time.Duration) []metrics.SampledValue {
+	output := make([]metrics.SampledValue, 0, len(source))
+	for hash, sample := range source {
+		// The rate is per second over the whole window.
+		sample.Rate = sample.Sum / window.Seconds()
+
+		output = append(output, metrics.SampledValue{
+			Name:            sample.Name,
+			Hash:            hash,
+			AggregateSample: sample.AggregateSample,
+			Mean:            sample.AggregateSample.Mean(),
+			Stddev:          sample.AggregateSample.Stddev(),
+			DisplayLabels:   displayLabels(sample.Labels),
+		})
+	}
+	sort.Slice(output, func(i, j int) bool {
+		return output[i].Hash < output[j].Hash
+	})
+
+	return output
+}
+
+func displayLabels(labels []metrics.Label) map[string]string {
+	out := make(map[string]string, len(labels))
+	for _, label := range labels {
+		out[label.Name] = label.Value
+	}
+	return out
+}
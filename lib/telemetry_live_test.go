@@ -0,0 +1,103 @@
+package lib
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiveMetrics_NormalizeWindow(t *testing.T) {
+	l := NewLiveMetrics(LiveMetricsInterval)
+
+	cases := map[time.Duration]time.Duration{
+		0:                       LiveMetricsInterval,
+		-time.Second:            LiveMetricsInterval,
+		500 * time.Millisecond:  LiveMetricsInterval,
+		10 * time.Second:        10 * time.Second,
+		1500 * time.Millisecond: 2 * time.Second,
+		time.Hour:               LiveMetricsMaxWindow,
+	}
+	for in, expected := range cases {
+		require.Equal(t, expected, l.NormalizeWindow(in), "window %s", in)
+	}
+}
+
+func TestLiveMetrics_Summary(t *testing.T) {
+	l := NewLiveMetrics(20 * time.Millisecond)
+
+	_, ok := l.Summary(time.Second)
+	require.False(t, ok)
+
+	// Spread the same metrics over several intervals.
+	for i := 0; i < 3; i++ {
+		l.SetGauge([]string{"gauge"}, float32(i))
+		l.IncrCounterWithLabels([]string{"counter"}, 2, []metrics.Label{{Name: "l", Value: "v"}})
+		l.AddSample([]string{"sample"}, float32(i+1))
+		time.Sleep(25 * time.Millisecond)
+	}
+	// Make sure the last interval is finished.
+	time.Sleep(25 * time.Millisecond)
+	l.IncrCounter([]string{"other"}, 1)
+
+	summary, ok := l.Summary(time.Second)
+	require.True(t, ok)
+	require.Equal(t, "1s", summary.Window)
+
+	require.Equal(t, []metrics.GaugeValue{
+		{Name: "gauge", Hash: "gauge", Value: 2, DisplayLabels: map[string]string{}},
+	}, summary.Gauges)
+
+	require.Len(t, summary.Counters, 1)
+	counter := summary.Counters[0]
+	require.Equal(t, "counter", counter.Name)
+	require.Equal(t, map[string]string{"l": "v"}, counter.DisplayLabels)
+	require.Equal(t, 3, counter.Count)
+	require.Equal(t, 6.0, counter.Sum)
+	require.Equal(t, 6.0, counter.Rate)
+
+	require.Len(t, summary.Samples, 1)
+	sample := summary.Samples[0]
+	require.Equal(t, 3, sample.Count)
+	require.Equal(t, 1.0, sample.Min)
+	require.Equal(t, 3.0, sample.Max)
+	require.Equal(t, 2.0, sample.Mean)
+
+	// A window of a single interval only covers the last one.
+	summary, ok = l.Summary(0)
+	require.True(t, ok)
+	require.Len(t, summary.Counters, 1)
+	require.Equal(t, 1, summary.Counters[0].Count)
+}
+
+func TestLiveMetrics_StreamWindow(t *testing.T) {
+	l := NewLiveMetrics(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// produce some metrics
+	go func() {
+		for ctx.Err() == nil {
+			l.IncrCounter([]string{"counter"}, 1)
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+
+	var summaries []LiveMetricsSummary
+	l.StreamWindow(ctx, time.Second, encoderFunc(func(v interface{}) error {
+		summaries = append(summaries, v.(LiveMetricsSummary))
+		return nil
+	}))
+
+	require.GreaterOrEqual(t, len(summaries), 2)
+	for _, summary := range summaries {
+		require.Equal(t, "1s", summary.Window)
+		require.Len(t, summary.Counters, 1)
+	}
+	// The counters accumulate over the window.
+	first, last := summaries[0], summaries[len(summaries)-1]
+	require.Greater(t, last.Counters[0].Count, first.Counters[0].Count)
+}
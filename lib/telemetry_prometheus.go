@@ -0,0 +1,255 @@
+package lib
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/armon/go-metrics/prometheus"
+	promclient "github.com/prometheus/client_golang/prometheus"
+)
+
+// prometheusHistogramBuckets are the upper bounds of the buckets of the
+// histograms recorded in place of summaries. Samples are mostly durations
+// in milliseconds, so the bounds grow exponentially from 0.1ms to about 52s.
+var prometheusHistogramBuckets = promclient.ExponentialBuckets(0.1, 2, 20)
+
+const (
+	// prometheusNativeHistogramBucketFactor bounds the growth of the native
+	// histogram buckets, which are about 10% wider than the previous one.
+	prometheusNativeHistogramBucketFactor = 1.1
+
+	// prometheusNativeHistogramMaxBuckets bounds the number of buckets of a
+	// native histogram. Their resolution is reduced when it's reached.
+	prometheusNativeHistogramMaxBuckets = 100
+)
+
+// prometheusFilteredSink wraps the Prometheus sink of go-metrics to drop the
+// labels which aren't allowed, so that operators can control the cardinality
+// of the exposition, and to record the samples as histograms rather than
+// summaries when enabled.
+type prometheusFilteredSink struct {
+	*prometheus.PrometheusSink
+
+	allowedLabels map[string]bool
+	blockedLabels map[string]bool
+
+	// histograms records the samples when not nil.
+	histograms *prometheusHistograms
+}
+
+func newPrometheusFilteredSink(cfg TelemetryConfig) (*prometheusFilteredSink, error) {
+	opts := cfg.PrometheusOpts
+	s := &prometheusFilteredSink{
+		allowedLabels: stringSet(cfg.PrometheusAllowedLabels),
+		blockedLabels: stringSet(cfg.PrometheusBlockedLabels),
+	}
+
+	if cfg.PrometheusHistograms || cfg.PrometheusNativeHistograms {
+		histograms, err := newPrometheusHistograms(opts, cfg.PrometheusNativeHistograms)
+		if err != nil {
+			return nil, err
+		}
+		s.histograms = histograms
+
+		// The summaries would clash with the histograms of the same name.
+		opts.SummaryDefinitions = nil
+	}
+
+	sink, err := prometheus.NewPrometheusSinkFrom(opts)
+	if err != nil {
+		return nil, err
+	}
+	s.PrometheusSink = sink
+	return s, nil
+}
+
+func stringSet(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+// filterLabels returns the labels which are allowed and not blocked.
+func (s *prometheusFilteredSink) filterLabels(labels []metrics.Label) []metrics.Label {
+	if len(labels) == 0 || (s.allowedLabels == nil && s.blockedLabels == nil) {
+		return labels
+	}
+
+	filtered := make([]metrics.Label, 0, len(labels))
+	for _, label := range labels {
+		if s.blockedLabels[label.Name] {
+			continue
+		}
+		if s.allowedLabels != nil && !s.allowedLabels[label.Name] {
+			continue
+		}
+		filtered = append(filtered, label)
+	}
+	return filtered
+}
+
+func (s *prometheusFilteredSink) SetGauge(key []string, val float32) {
+	s.SetGaugeWithLabels(key, val, nil)
+}
+
+func (s *prometheusFilteredSink) SetGaugeWithLabels(key []string, val float32, labels []metrics.Label) {
+	s.PrometheusSink.SetGaugeWithLabels(key, val, s.filterLabels(labels))
+}
+
+func (s *prometheusFilteredSink) IncrCounter(key []string, val float32) {
+	s.IncrCounterWithLabels(key, val, nil)
+}
+
+func (s *prometheusFilteredSink) IncrCounterWithLabels(key []string, val float32, labels []metrics.Label) {
+	s.PrometheusSink.IncrCounterWithLabels(key, val, s.filterLabels(labels))
+}
+
+func (s *prometheusFilteredSink) AddSample(key []string, val float32) {
+	s.AddSampleWithLabels(key, val, nil)
+}
+
+func (s *prometheusFilteredSink) AddSampleWithLabels(key []string, val float32, labels []metrics.Label) {
+	labels = s.filterLabels(labels)
+	if s.histograms != nil {
+		s.histograms.observe(key, val, labels)
+		return
+	}
+	s.PrometheusSink.AddSampleWithLabels(key, val, labels)
+}
+
+// prometheusHistograms is a Prometheus collector of the samples recorded as
+// histograms. Like the go-metrics sink, the histograms which aren't defined
+// are dropped once they haven't been updated for the retention time.
+type prometheusHistograms struct {
+	name       string
+	expiration time.Duration
+	native     bool
+
+	// help is the help text of the defined metrics by name.
+	help map[string]string
+
+	lock       sync.Mutex
+	histograms map[string]*prometheusHistogram
+}
+
+type prometheusHistogram struct {
+	promclient.Histogram
+	updatedAt time.Time
+	canDelete bool
+}
+
+func newPrometheusHistograms(opts prometheus.PrometheusOpts, native bool) (*prometheusHistograms, error) {
+	h := &prometheusHistograms{
+		name:       opts.Name + "_histograms",
+		expiration: opts.Expiration,
+		native:     native,
+		help:       make(map[string]string),
+		histograms: make(map[string]*prometheusHistogram),
+	}
+	if opts.Name == "" {
+		h.name = "default_prometheus_histograms"
+	}
+
+	for _, def := range opts.SummaryDefinitions {
+		name, hash := flattenPrometheusKey(def.Name, def.ConstLabels)
+		h.help[name] = def.Help
+		h.histograms[hash] = &prometheusHistogram{
+			Histogram: h.newHistogram(name, def.ConstLabels),
+		}
+	}
+
+	reg := opts.Registerer
+	if reg == nil {
+		reg = promclient.DefaultRegisterer
+	}
+	return h, reg.Register(h)
+}
+
+func (h *prometheusHistograms) newHistogram(name string, labels []metrics.Label) promclient.Histogram {
+	help, ok := h.help[name]
+	if !ok {
+		help = name
+	}
+	constLabels := make(promclient.Labels, len(labels))
+	for _, label := range labels {
+		constLabels[label.Name] = label.Value
+	}
+	histogramOpts := promclient.HistogramOpts{
+		Name:        name,
+		Help:        help,
+		ConstLabels: constLabels,
+		Buckets:     prometheusHistogramBuckets,
+	}
+	if h.native {
+		histogramOpts.NativeHistogramBucketFactor = prometheusNativeHistogramBucketFactor
+		histogramOpts.NativeHistogramMaxBucketNumber = prometheusNativeHistogramMaxBuckets
+		histogramOpts.NativeHistogramMinResetDuration = time.Hour
+	}
+	return promclient.NewHistogram(histogramOpts)
+}
+
+func (h *prometheusHistograms) observe(key []string, val float32, labels []metrics.Label) {
+	name, hash := flattenPrometheusKey(key, labels)
+
+	h.lock.Lock()
+	histogram, ok := h.histograms[hash]
+	if !ok {
+		histogram = &prometheusHistogram{
+			Histogram: h.newHistogram(name, labels),
+			canDelete: true,
+		}
+		h.histograms[hash] = histogram
+	}
+	histogram.updatedAt = time.Now()
+	h.lock.Unlock()
+
+	histogram.Observe(float64(val))
+}
+
+// Describe implements promclient.Collector. The histograms can't be
+// described ahead of time as they are created as samples are recorded, so a
+// placeholder is described for the collector to be registered.
+func (h *prometheusHistograms) Describe(c chan<- *promclient.Desc) {
+	promclient.NewGauge(promclient.GaugeOpts{Name: h.name, Help: h.name}).Describe(c)
+}
+
+// Collect implements promclient.Collector.
+func (h *prometheusHistograms) Collect(c chan<- promclient.Metric) {
+	h.collectAtTime(c, time.Now())
+}
+
+func (h *prometheusHistograms) collectAtTime(c chan<- promclient.Metric, now time.Time) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for hash, histogram := range h.histograms {
+		if h.expiration != 0 && histogram.canDelete && histogram.updatedAt.Add(h.expiration).Before(now) {
+			delete(h.histograms, hash)
+			continue
+		}
+		histogram.Collect(c)
+	}
+}
+
+var prometheusForbiddenChars = regexp.MustCompile("[ .=\\-/]")
+
+// flattenPrometheusKey returns the name of a metric and the key identifying
+// it with its labels, the same way as the go-metrics sink does.
+func flattenPrometheusKey(parts []string, labels []metrics.Label) (string, string) {
+	name := prometheusForbiddenChars.ReplaceAllString(strings.Join(parts, "_"), "_")
+
+	hash := name
+	for _, label := range labels {
+		hash += fmt.Sprintf(";%s=%s", label.Name, label.Value)
+	}
+	return name, hash
+}
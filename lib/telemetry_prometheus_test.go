@@ -0,0 +1,151 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/armon/go-metrics/prometheus"
+	promclient "github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPrometheusSink(t *testing.T, cfg TelemetryConfig) (*prometheusFilteredSink, *promclient.Registry) {
+	t.Helper()
+	reg := promclient.NewRegistry()
+	cfg.PrometheusOpts.Registerer = reg
+	cfg.PrometheusOpts.Expiration = time.Minute
+	cfg.PrometheusOpts.Name = "consul"
+	sink, err := newPrometheusFilteredSink(cfg)
+	require.NoError(t, err)
+	return sink, reg
+}
+
+func gatherFamilies(t *testing.T, reg *promclient.Registry) map[string]*dto.MetricFamily {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	byName := make(map[string]*dto.MetricFamily)
+	for _, f := range families {
+		byName[f.GetName()] = f
+	}
+	return byName
+}
+
+func metricLabels(m *dto.Metric) map[string]string {
+	labels := make(map[string]string)
+	for _, l := range m.GetLabel() {
+		labels[l.GetName()] = l.GetValue()
+	}
+	return labels
+}
+
+func TestPrometheusFilteredSink_Labels(t *testing.T) {
+	sink, reg := newTestPrometheusSink(t, TelemetryConfig{
+		PrometheusBlockedLabels: []string{"peer"},
+	})
+
+	labels := []metrics.Label{{Name: "service", Value: "web"}, {Name: "peer", Value: "p1"}}
+	sink.IncrCounterWithLabels([]string{"consul", "requests"}, 1, labels)
+	sink.IncrCounterWithLabels([]string{"consul", "requests"}, 2, []metrics.Label{{Name: "service", Value: "web"}, {Name: "peer", Value: "p2"}})
+	sink.SetGaugeWithLabels([]string{"consul", "members"}, 3, labels)
+
+	families := gatherFamilies(t, reg)
+
+	// The series only differing by the blocked label are merged.
+	requests := families["consul_requests"]
+	require.NotNil(t, requests)
+	require.Len(t, requests.GetMetric(), 1)
+	require.Equal(t, map[string]string{"service": "web"}, metricLabels(requests.GetMetric()[0]))
+	require.Equal(t, 3.0, requests.GetMetric()[0].GetCounter().GetValue())
+
+	members := families["consul_members"]
+	require.NotNil(t, members)
+	require.Equal(t, map[string]string{"service": "web"}, metricLabels(members.GetMetric()[0]))
+
+	t.Run("allowed labels", func(t *testing.T) {
+		sink, reg := newTestPrometheusSink(t, TelemetryConfig{
+			PrometheusAllowedLabels: []string{"peer"},
+		})
+		sink.IncrCounterWithLabels([]string{"consul", "requests"}, 1, labels)
+
+		requests := gatherFamilies(t, reg)["consul_requests"]
+		require.NotNil(t, requests)
+		require.Equal(t, map[string]string{"peer": "p1"}, metricLabels(requests.GetMetric()[0]))
+	})
+}
+
+func TestPrometheusFilteredSink_Histograms(t *testing.T) {
+	sink, reg := newTestPrometheusSink(t, TelemetryConfig{
+		PrometheusHistograms: true,
+		PrometheusOpts: prometheus.PrometheusOpts{
+			SummaryDefinitions: []prometheus.SummaryDefinition{
+				{Name: []string{"consul", "raft", "apply"}, Help: "Measures the time to apply a raft log."},
+			},
+		},
+	})
+
+	// Defined histograms are exported before any sample is recorded.
+	apply := gatherFamilies(t, reg)["consul_raft_apply"]
+	require.NotNil(t, apply)
+	require.Equal(t, dto.MetricType_HISTOGRAM, apply.GetType())
+	require.Equal(t, "Measures the time to apply a raft log.", apply.GetHelp())
+
+	sink.AddSample([]string{"consul", "raft", "apply"}, 3)
+	sink.AddSampleWithLabels([]string{"consul", "rpc", "request"}, 0.2, []metrics.Label{{Name: "method", Value: "Catalog.Register"}})
+
+	families := gatherFamilies(t, reg)
+	apply = families["consul_raft_apply"]
+	histogram := apply.GetMetric()[0].GetHistogram()
+	require.Equal(t, uint64(1), histogram.GetSampleCount())
+	require.Equal(t, 3.0, histogram.GetSampleSum())
+	require.Len(t, histogram.GetBucket(), len(prometheusHistogramBuckets))
+
+	request := families["consul_rpc_request"]
+	require.NotNil(t, request)
+	require.Equal(t, dto.MetricType_HISTOGRAM, request.GetType())
+	require.Equal(t, "consul_rpc_request", request.GetHelp())
+	require.Equal(t, map[string]string{"method": "Catalog.Register"}, metricLabels(request.GetMetric()[0]))
+
+	t.Run("expiration", func(t *testing.T) {
+		ch := make(chan promclient.Metric, 10)
+		sink.histograms.collectAtTime(ch, time.Now().Add(time.Hour))
+		close(ch)
+
+		// Only the defined histogram is kept.
+		var n int
+		for range ch {
+			n++
+		}
+		require.Equal(t, 1, n)
+	})
+}
+
+func TestPrometheusFilteredSink_NativeHistograms(t *testing.T) {
+	sink, reg := newTestPrometheusSink(t, TelemetryConfig{
+		PrometheusNativeHistograms: true,
+	})
+	sink.AddSample([]string{"consul", "raft", "apply"}, 3)
+
+	apply := gatherFamilies(t, reg)["consul_raft_apply"]
+	require.NotNil(t, apply)
+	require.Equal(t, dto.MetricType_HISTOGRAM, apply.GetType())
+
+	// The native buckets are recorded along with the classic ones.
+	histogram := apply.GetMetric()[0].GetHistogram()
+	require.Len(t, histogram.GetBucket(), len(prometheusHistogramBuckets))
+	require.Equal(t, int32(3), histogram.GetSchema())
+	require.Len(t, histogram.GetPositiveSpan(), 1)
+	require.Equal(t, []int64{1}, histogram.GetPositiveDelta())
+}
+
+func TestPrometheusFilteredSink_Summaries(t *testing.T) {
+	sink, reg := newTestPrometheusSink(t, TelemetryConfig{})
+	sink.AddSample([]string{"consul", "raft", "apply"}, 3)
+
+	apply := gatherFamilies(t, reg)["consul_raft_apply"]
+	require.NotNil(t, apply)
+	require.Equal(t, dto.MetricType_SUMMARY, apply.GetType())
+	require.Nil(t, sink.histograms)
+}
@@ -35,6 +35,11 @@ func (e *Entry) Key() string {
 	return e.key
 }
 
+// Expiry returns the time at which the entry expires.
+func (e *Entry) Expiry() time.Time {
+	return e.expiry
+}
+
 // ExpiryHeap is a heap that is ordered by the expiry time of entries. It may
 // be used by a cache or storage to expiry items after a TTL.
 //
@@ -76,6 +81,23 @@ func (h *ExpiryHeap) Add(key string, expiry time.Duration) *Entry {
 	return entry
 }
 
+// Len returns the number of entries in the heap.
+//
+// Must be synchronized by the caller.
+func (h *ExpiryHeap) Len() int {
+	return len(h.entries)
+}
+
+// First returns the entry which expires first, or nil if the heap is empty.
+//
+// Must be synchronized by the caller.
+func (h *ExpiryHeap) First() *Entry {
+	if len(h.entries) == 0 {
+		return nil
+	}
+	return h.entries[0]
+}
+
 // Update the entry that is currently at idx with the new expiry time, if the new
 // expiry time is further into the future. The heap will be rebalanced after the
 // entry is updated. If the new expiry time is earlier than the existing expiry
@@ -38,6 +38,8 @@ func TestExpiryHeap(t *testing.T) {
 	testutil.RunStep(t, "add a third entry at the end", func(t *testing.T) {
 		entry3 = h.Add("baz", 1000*time.Millisecond)
 		assert.Equal(t, 2, entry3.heapIndex)
+		assert.Equal(t, 3, h.Len())
+		assert.Equal(t, entry2, h.First())
 		testNoMessage(t, ch) // no notify cause index 0 stayed the same
 	})
 
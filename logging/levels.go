@@ -0,0 +1,349 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// subsystemLoggers are the subsystems whose log level can be set apart from
+// the rest of the agent, with the names of their loggers. A logger belongs to
+// a subsystem when any segment of its name is one of those.
+var subsystemLoggers = map[string][]string{
+	"dns":      {DNS},
+	"proxycfg": {ProxyConfig},
+	"raft":     {Raft},
+	"serf":     {Serf, Memberlist},
+	"xds":      {Envoy, XDS},
+}
+
+// Subsystems returns the names of the subsystems whose log level can be set
+// apart from the rest of the agent.
+func Subsystems() []string {
+	names := make([]string, 0, len(subsystemLoggers))
+	for name := range subsystemLoggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateSubsystem verifies that the log level of a subsystem can be set.
+func ValidateSubsystem(subsystem string) error {
+	if _, ok := subsystemLoggers[subsystem]; !ok {
+		return fmt.Errorf("Unknown log subsystem %q, must be one of %v", subsystem, Subsystems())
+	}
+	return nil
+}
+
+// subsystemOf returns the subsystem of the logger with the given name, or ""
+// when it doesn't belong to any.
+func subsystemOf(name string) string {
+	for _, segment := range strings.Split(name, ".") {
+		for subsystem, names := range subsystemLoggers {
+			for _, n := range names {
+				if segment == n {
+					return subsystem
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// Levels are the log levels of the agent and its subsystems. They can be
+// changed at runtime.
+//
+// All the loggers derived from the same root logger share its level, so the
+// root logger is set to the most verbose of the levels and the lines below
+// the level of their subsystem are dropped when they are written.
+type Levels struct {
+	lock       sync.RWMutex
+	logger     hclog.Logger
+	base       hclog.Level
+	subsystems map[string]hclog.Level
+}
+
+func newLevels(base hclog.Level) *Levels {
+	return &Levels{
+		base:       base,
+		subsystems: make(map[string]hclog.Level),
+	}
+}
+
+// NewLevels returns the Levels of a logger which wasn't created by
+// SetupWithLevels, such as the loggers of the tests. The lines of such a
+// logger are not filtered by subsystem, so setting the level of a subsystem
+// only lowers the level of the logger.
+func NewLevels(logger hclog.Logger, base hclog.Level) *Levels {
+	l := newLevels(base)
+	l.logger = logger
+	l.updateLocked()
+	return l
+}
+
+// Base returns the level of the loggers which don't belong to a subsystem
+// with its own level.
+func (l *Levels) Base() hclog.Level {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.base
+}
+
+// SetBase sets the level of the loggers which don't belong to a subsystem
+// with its own level.
+func (l *Levels) SetBase(level hclog.Level) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.base = level
+	l.updateLocked()
+}
+
+// Subsystems returns the levels of the subsystems which have their own.
+func (l *Levels) Subsystems() map[string]hclog.Level {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	levels := make(map[string]hclog.Level, len(l.subsystems))
+	for subsystem, level := range l.subsystems {
+		levels[subsystem] = level
+	}
+	return levels
+}
+
+// SetSubsystem sets the level of a subsystem. hclog.NoLevel resets it to the
+// base level.
+func (l *Levels) SetSubsystem(subsystem string, level hclog.Level) error {
+	if err := ValidateSubsystem(subsystem); err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if level == hclog.NoLevel {
+		delete(l.subsystems, subsystem)
+	} else {
+		l.subsystems[subsystem] = level
+	}
+	l.updateLocked()
+	return nil
+}
+
+// SetSubsystems replaces the levels of all the subsystems.
+func (l *Levels) SetSubsystems(levels map[string]string) error {
+	parsed, err := parseSubsystemLevels(levels)
+	if err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.subsystems = parsed
+	l.updateLocked()
+	return nil
+}
+
+func parseSubsystemLevels(levels map[string]string) (map[string]hclog.Level, error) {
+	parsed := make(map[string]hclog.Level, len(levels))
+	for subsystem, level := range levels {
+		if err := ValidateSubsystem(subsystem); err != nil {
+			return nil, err
+		}
+		if !ValidateLogLevel(level) {
+			return nil, fmt.Errorf("Invalid log level %q for subsystem %q. Valid log levels are: %v", level, subsystem, allowedLogLevels)
+		}
+		parsed[subsystem] = LevelFromString(level)
+	}
+	return parsed, nil
+}
+
+// updateLocked sets the level of the root logger to the most verbose level.
+func (l *Levels) updateLocked() {
+	if l.logger == nil {
+		return
+	}
+	min := l.base
+	for _, level := range l.subsystems {
+		if level < min {
+			min = level
+		}
+	}
+	l.logger.SetLevel(min)
+}
+
+// levelOf returns the level of the logger with the given name.
+func (l *Levels) levelOf(name string) hclog.Level {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	if len(l.subsystems) == 0 {
+		return l.base
+	}
+	if level, ok := l.subsystems[subsystemOf(name)]; ok {
+		return level
+	}
+	return l.base
+}
+
+// hasSubsystems returns whether any subsystem has its own level.
+func (l *Levels) hasSubsystems() bool {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return len(l.subsystems) > 0
+}
+
+// levelsWriter drops the log lines below the level of their logger, and adds
+// the subsystem and event fields to the JSON log lines.
+type levelsWriter struct {
+	levels *Levels
+	json   bool
+	w      io.Writer
+}
+
+// Write implements io.Writer. The lines written without a level are passed
+// through as is.
+func (w *levelsWriter) Write(p []byte) (int, error) {
+	return w.w.Write(p)
+}
+
+// LevelWrite implements hclog.LevelWriter.
+func (w *levelsWriter) LevelWrite(level hclog.Level, p []byte) (int, error) {
+	if w.json {
+		return w.writeJSON(level, p)
+	}
+
+	if w.levels.hasSubsystems() && level < w.levels.levelOf(LoggerName(p)) {
+		return len(p), nil
+	}
+	return w.w.Write(p)
+}
+
+func (w *levelsWriter) writeJSON(level hclog.Level, p []byte) (int, error) {
+	vals, ok := decodeJSONLine(p)
+	if !ok {
+		return w.w.Write(p)
+	}
+
+	name, _ := vals["@module"].(string)
+	if level < w.levels.levelOf(name) {
+		return len(p), nil
+	}
+
+	line, ok := encodeJSONLine(vals)
+	if !ok {
+		return w.w.Write(p)
+	}
+	if _, err := w.w.Write(line); err != nil {
+		return 0, err
+	}
+	// The caller only knows about the bytes it wrote.
+	return len(p), nil
+}
+
+// DecorateJSON adds the @subsystem and @event fields to a log line in the
+// JSON format, and returns it with the name of its logger. The line is
+// returned as is when it can't be decoded.
+func DecorateJSON(p []byte) ([]byte, string) {
+	vals, ok := decodeJSONLine(p)
+	if !ok {
+		return p, ""
+	}
+	name, _ := vals["@module"].(string)
+	line, ok := encodeJSONLine(vals)
+	if !ok {
+		return p, name
+	}
+	return line, name
+}
+
+func decodeJSONLine(p []byte) (map[string]interface{}, bool) {
+	vals := make(map[string]interface{})
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	if err := dec.Decode(&vals); err != nil {
+		return nil, false
+	}
+	return vals, true
+}
+
+// encodeJSONLine encodes a log line with the @subsystem and @event fields.
+func encodeJSONLine(vals map[string]interface{}) ([]byte, bool) {
+	name, _ := vals["@module"].(string)
+	subsystem := subsystemOf(name)
+	if subsystem == "" {
+		subsystem = Agent
+	}
+	vals["@subsystem"] = subsystem
+	if msg, ok := vals["@message"].(string); ok {
+		vals["@event"] = eventOf(msg)
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(vals); err != nil {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+// MatchLoggerName returns whether the logger with the given name matches one
+// of the filters. The filters are matched against the dot-separated segments
+// of the name, so proxycfg matches agent.proxycfg and envoy.xds matches
+// agent.envoy.xds.v3. All the loggers match when there are no filters.
+func MatchLoggerName(name string, filters []string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	name = "." + name + "."
+	for _, filter := range filters {
+		if strings.Contains(name, "."+strings.Trim(filter, ".")+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// LoggerName returns the name of the logger of a log line in the plain
+// format, which looks like "<time> [LEVEL] <name>: <message>".
+func LoggerName(p []byte) string {
+	i := bytes.IndexByte(p, ']')
+	if i < 0 {
+		return ""
+	}
+	rest := bytes.TrimLeft(p[i+1:], " ")
+	j := bytes.Index(rest, []byte(": "))
+	if j < 0 {
+		return ""
+	}
+	name := rest[:j]
+	if bytes.ContainsAny(name, " \n") {
+		// This is the message of a logger without a name.
+		return ""
+	}
+	return string(name)
+}
+
+// eventOf returns a stable identifier of a log message, made of its words
+// in lower case joined by underscores. Log messages are constant, the values
+// being passed as separate fields, so the identifier is the same for all the
+// lines of a given log call.
+func eventOf(msg string) string {
+	var b strings.Builder
+	sep := false
+	for _, r := range msg {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if sep && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			sep = false
+		} else {
+			sep = true
+		}
+	}
+	return b.String()
+}
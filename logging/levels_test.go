@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevels_SubsystemLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger, levels, err := SetupWithLevels(Config{
+		LogLevel:  "INFO",
+		LogLevels: map[string]string{"xds": "DEBUG"},
+	}, &buf)
+	require.NoError(t, err)
+
+	logger.Debug("agent debug")
+	logger.Named(Envoy).Debug("envoy debug")
+	logger.Named(Envoy).Named(XDS).Trace("xds trace")
+	logger.Named(Raft).Debug("raft debug")
+	logger.Named(Raft).Info("raft info")
+
+	out := buf.String()
+	require.NotContains(t, out, "agent debug")
+	require.Contains(t, out, "envoy debug")
+	require.NotContains(t, out, "xds trace")
+	require.NotContains(t, out, "raft debug")
+	require.Contains(t, out, "raft info")
+
+	// Lower the level of raft and reset the one of xds.
+	buf.Reset()
+	require.NoError(t, levels.SetSubsystem("raft", hclog.Trace))
+	require.NoError(t, levels.SetSubsystem("xds", hclog.NoLevel))
+	require.Equal(t, map[string]hclog.Level{"raft": hclog.Trace}, levels.Subsystems())
+
+	logger.Named(Envoy).Debug("envoy debug")
+	logger.Named(Raft).Trace("raft trace")
+
+	out = buf.String()
+	require.NotContains(t, out, "envoy debug")
+	require.Contains(t, out, "raft trace")
+
+	// Raising the base level leaves the subsystems alone.
+	buf.Reset()
+	levels.SetBase(hclog.Error)
+	logger.Warn("agent warn")
+	logger.Named(Raft).Debug("raft debug")
+
+	out = buf.String()
+	require.NotContains(t, out, "agent warn")
+	require.Contains(t, out, "raft debug")
+}
+
+func TestLevels_SetSubsystemsInvalid(t *testing.T) {
+	_, levels, err := SetupWithLevels(Config{LogLevel: "INFO"}, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	err = levels.SetSubsystems(map[string]string{"http": "DEBUG"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `Unknown log subsystem "http"`)
+
+	err = levels.SetSubsystems(map[string]string{"dns": "LOUD"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `Invalid log level "LOUD"`)
+
+	_, _, err = SetupWithLevels(Config{LogLevel: "INFO", LogLevels: map[string]string{"http": "DEBUG"}}, &bytes.Buffer{})
+	require.Error(t, err)
+}
+
+func TestLevels_JSONFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _, err := SetupWithLevels(Config{
+		LogLevel:  "INFO",
+		LogLevels: map[string]string{"serf": "WARN"},
+		LogJSON:   true,
+	}, &buf)
+	require.NoError(t, err)
+
+	logger.Named(Serf).Info("member joined", "member", "node1")
+	logger.Named(Memberlist).Warn("Was able to connect to node2 but other probes failed, network may be misconfigured")
+	logger.Named(Connect).Info("Updated CA roots", "count", 2)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var memberlist map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &memberlist))
+	require.Equal(t, "serf", memberlist["@subsystem"])
+	require.Equal(t, "was_able_to_connect_to_node2_but_other_probes_failed_network_may_be_misconfigured", memberlist["@event"])
+	require.Equal(t, "memberlist", memberlist["@module"])
+
+	var connect map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &connect))
+	require.Equal(t, "agent", connect["@subsystem"])
+	require.Equal(t, "updated_ca_roots", connect["@event"])
+	require.Equal(t, float64(2), connect["count"])
+}
+
+func TestLoggerName(t *testing.T) {
+	cases := map[string]string{
+		"2021-01-01T00:00:00.000Z [INFO]  agent.server.raft: entering follower state: follower=x\n": "agent.server.raft",
+		"2021-01-01T00:00:00.000Z [INFO]  agent: Started DNS server: address=127.0.0.1:8600\n":      "agent",
+		"2021-01-01T00:00:00.000Z [INFO]  Synced node info\n":                                       "",
+		"2021-01-01T00:00:00.000Z [INFO]  Joining cluster: nodes=3\n":                               "",
+	}
+	for line, expected := range cases {
+		require.Equal(t, expected, LoggerName([]byte(line)), line)
+	}
+}
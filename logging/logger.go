@@ -15,6 +15,10 @@ type Config struct {
 	// LogLevel is the minimum level to be logged.
 	LogLevel string
 
+	// LogLevels are the minimum levels to be logged by subsystem, overriding
+	// LogLevel for the loggers of these subsystems.
+	LogLevels map[string]string
+
 	// LogJSON controls outputing logs in a JSON format.
 	LogJSON bool
 
@@ -38,6 +42,14 @@ type Config struct {
 
 	// LogRotateMaxFiles is the maximum number of past archived log files to keep
 	LogRotateMaxFiles int
+
+	// RedactSecrets controls scrubbing secrets such as ACL tokens, private keys
+	// and gossip keys from the log lines and the HTTP API error messages.
+	RedactSecrets bool
+
+	// RedactPatterns are additional regular expressions whose matches are
+	// scrubbed when RedactSecrets is set.
+	RedactPatterns []string
 }
 
 // defaultRotateDuration is the default time taken by the agent to rotate logs
@@ -60,12 +72,31 @@ func (w noErrorWriter) Write(p []byte) (n int, err error) {
 //
 // Logs may be written to out, and optionally to syslog, and a file.
 func Setup(config Config, out io.Writer) (hclog.InterceptLogger, error) {
+	logger, _, err := SetupWithLevels(config, out)
+	return logger, err
+}
+
+// SetupWithLevels is like Setup but also returns the Levels of the logger,
+// which can be used to change the level of the subsystems at runtime.
+func SetupWithLevels(config Config, out io.Writer) (hclog.InterceptLogger, *Levels, error) {
 	if !ValidateLogLevel(config.LogLevel) {
-		return nil, fmt.Errorf("Invalid log level: %s. Valid log levels are: %v",
+		return nil, nil, fmt.Errorf("Invalid log level: %s. Valid log levels are: %v",
 			config.LogLevel,
 			allowedLogLevels)
 	}
 
+	levels := newLevels(LevelFromString(config.LogLevel))
+	subsystems, err := parseSubsystemLevels(config.LogLevels)
+	if err != nil {
+		return nil, nil, err
+	}
+	levels.subsystems = subsystems
+
+	redactor, err := NewRedactor(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// If out is os.Stdout and Consul is being run as a Windows Service, writes will
 	// fail silently, which may inadvertently prevent writes to other writers.
 	// noErrorWriter is used as a wrapper to suppress any errors when writing to out.
@@ -83,7 +114,7 @@ func Setup(config Config, out io.Writer) (hclog.InterceptLogger, error) {
 
 			if i == retries {
 				timeout := time.Duration(retries) * delay
-				return nil, fmt.Errorf("Syslog setup did not succeed within timeout (%s).", timeout.String())
+				return nil, nil, fmt.Errorf("Syslog setup did not succeed within timeout (%s).", timeout.String())
 			}
 
 			time.Sleep(delay)
@@ -107,19 +138,25 @@ func Setup(config Config, out io.Writer) (hclog.InterceptLogger, error) {
 			MaxFiles: config.LogRotateMaxFiles,
 		}
 		if err := logFile.pruneFiles(); err != nil {
-			return nil, fmt.Errorf("Failed to prune log files: %w", err)
+			return nil, nil, fmt.Errorf("Failed to prune log files: %w", err)
 		}
 		if err := logFile.openNew(); err != nil {
-			return nil, fmt.Errorf("Failed to setup logging: %w", err)
+			return nil, nil, fmt.Errorf("Failed to setup logging: %w", err)
 		}
 		writers = append(writers, logFile)
 	}
 
 	logger := hclog.NewInterceptLogger(&hclog.LoggerOptions{
-		Level:      LevelFromString(config.LogLevel),
-		Name:       config.Name,
-		Output:     io.MultiWriter(writers...),
+		Level: LevelFromString(config.LogLevel),
+		Name:  config.Name,
+		Output: &levelsWriter{
+			levels: levels,
+			json:   config.LogJSON,
+			w:      redactor.Writer(io.MultiWriter(writers...)),
+		},
 		JSONFormat: config.LogJSON,
 	})
-	return logger, nil
+	levels.logger = logger
+	levels.updateLocked()
+	return logger, levels, nil
 }
@@ -5,6 +5,8 @@ import (
 	"sync"
 
 	log "github.com/hashicorp/go-hclog"
+
+	"github.com/hashicorp/consul/logging"
 )
 
 // Monitor provides a mechanism to stream logs using go-hclog
@@ -40,12 +42,23 @@ type monitor struct {
 
 	// Defaults to 512.
 	bufSize int
+
+	// json is whether the logs are in the JSON format.
+	json bool
+
+	// loggers filters the logs by the name of their logger.
+	loggers []string
 }
 
 type Config struct {
 	BufferSize    int
 	Logger        log.InterceptLogger
 	LoggerOptions *log.LoggerOptions
+
+	// Loggers filters the logs streamed by the name of their logger, as
+	// matched by logging.MatchLoggerName. All the logs are streamed when
+	// empty.
+	Loggers []string
 }
 
 // New creates a new Monitor. Start must be called in order to actually start
@@ -61,6 +74,8 @@ func New(cfg Config) Monitor {
 		logCh:   make(chan []byte, bufSize),
 		doneCh:  make(chan struct{}, 1),
 		bufSize: bufSize,
+		json:    cfg.LoggerOptions.JSONFormat,
+		loggers: cfg.Loggers,
 	}
 
 	cfg.LoggerOptions.Output = sw
@@ -120,8 +135,19 @@ func (d *monitor) Write(p []byte) (int, error) {
 	default:
 	}
 
-	bytes := make([]byte, len(p))
-	copy(bytes, p)
+	// JSON logs get the same fields as the ones written by the agent.
+	line, name := p, ""
+	if d.json {
+		line, name = logging.DecorateJSON(p)
+	} else if len(d.loggers) > 0 {
+		name = logging.LoggerName(p)
+	}
+	if !logging.MatchLoggerName(name, d.loggers) {
+		return len(p), nil
+	}
+
+	bytes := make([]byte, len(line))
+	copy(bytes, line)
 
 	select {
 	case d.logCh <- bytes:
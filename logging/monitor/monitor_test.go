@@ -178,3 +178,53 @@ func TestMonitor_WriteStopped(t *testing.T) {
 	require.Equal(t, n, 0)
 	require.EqualError(t, err, "monitor stopped")
 }
+
+func TestMonitor_Loggers(t *testing.T) {
+	logger := log.NewInterceptLogger(&log.LoggerOptions{
+		Name:  "agent",
+		Level: log.Error,
+	})
+
+	cases := map[string]bool{
+		"plain": false,
+		"json":  true,
+	}
+	for desc, json := range cases {
+		t.Run(desc, func(t *testing.T) {
+			m := New(Config{
+				BufferSize: 512,
+				Logger:     logger,
+				LoggerOptions: &log.LoggerOptions{
+					Level:      log.Debug,
+					JSONFormat: json,
+				},
+				Loggers: []string{"proxycfg", "envoy.xds"},
+			})
+			logCh := m.Start()
+			defer m.Stop()
+
+			logger.Debug("agent log")
+			logger.Named("proxycfg").Debug("proxycfg log")
+			logger.Named("envoy").Debug("envoy log")
+			logger.Named("envoy").Named("xds").Debug("xds log")
+
+			var logs []string
+			for len(logs) < 2 {
+				select {
+				case log := <-logCh:
+					logs = append(logs, string(log))
+				case <-time.After(3 * time.Second):
+					t.Fatalf("Expected to receive from log channel, got %q", logs)
+				}
+			}
+			require.Contains(t, logs[0], "proxycfg log")
+			require.Contains(t, logs[1], "xds log")
+			require.Len(t, logCh, 0)
+
+			if json {
+				require.Contains(t, logs[0], `"@subsystem":"proxycfg"`)
+				require.Contains(t, logs[1], `"@event":"xds_log"`)
+			}
+		})
+	}
+}
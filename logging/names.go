@@ -19,6 +19,7 @@ const (
 	ConsulServer       string = "server"
 	Coordinate         string = "coordinate"
 	DNS                string = "dns"
+	EgressGateway      string = "egress_gateway"
 	Envoy              string = "envoy"
 	FederationState    string = "federation_state"
 	FSM                string = "fsm"
@@ -50,9 +51,11 @@ const (
 	Sentinel           string = "sentinel"
 	Snapshot           string = "snapshot"
 	Partition          string = "partition"
+	MaintenanceWindow  string = "maintenance_window"
 	Peering            string = "peering"
 	TerminatingGateway string = "terminating_gateway"
 	TLSUtil            string = "tlsutil"
+	TrafficShift       string = "traffic_shift"
 	Transaction        string = "txn"
 	UsageMetrics       string = "usage_metrics"
 	UIServer           string = "ui_server"
@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// redactedValue replaces the secrets matched by a Redactor.
+const redactedValue = "hidden"
+
+// DefaultRedactPatterns match the secrets that commonly leak into log lines
+// and error messages. The text matched by the groups named prefix and suffix,
+// if any, is kept around the redacted value.
+var DefaultRedactPatterns = []string{
+	// PEM encoded private keys, including when the newlines are escaped.
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+
+	// ACL tokens and other secrets assigned in query parameters, log fields
+	// and configuration, such as token=... or encrypt = "...".
+	`(?i)(?P<prefix>\b[a-z_]*(?:token|secret_?id|secret|encrypt)\s*=\s*"?)[^\s"&,;}<]+`,
+
+	// The same secrets as JSON fields, such as "SecretID": "...".
+	`(?i)(?P<prefix>"[a-z_]*(?:token|secret_?id|secret|encrypt)"\s*:\s*")[^"]+`,
+
+	// ACL tokens in HTTP headers.
+	`(?i)(?P<prefix>\b(?:x-consul-token|authorization)\s*:\s*(?:bearer\s+)?)[^\s",;]+`,
+
+	// Base64 encoded 16, 24 and 32 byte gossip encryption keys assigned to a
+	// key field. Any base64 looking word isn't redacted since identifiers of
+	// the same length are common.
+	`(?i)(?P<prefix>\b[a-z_]*key"?\s*[=:]\s*"?)(?:[A-Za-z0-9+/]{22}==|[A-Za-z0-9+/]{32}|[A-Za-z0-9+/]{43}=)(?P<suffix>[^A-Za-z0-9+/=]|$)`,
+}
+
+// Redactor scrubs secrets from text. A nil Redactor leaves the text unchanged,
+// so callers don't need to check whether redaction is enabled.
+type Redactor struct {
+	patterns []redactPattern
+}
+
+type redactPattern struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// NewRedactor returns a Redactor for the redaction settings of the logging
+// Config. It returns nil if redaction is disabled.
+func NewRedactor(config Config) (*Redactor, error) {
+	if !config.RedactSecrets {
+		return nil, nil
+	}
+	patterns := make([]string, 0, len(DefaultRedactPatterns)+len(config.RedactPatterns))
+	patterns = append(patterns, DefaultRedactPatterns...)
+	patterns = append(patterns, config.RedactPatterns...)
+	return NewRedactorWithPatterns(patterns)
+}
+
+// NewRedactorWithPatterns returns a Redactor replacing the matches of the given
+// regular expressions.
+func NewRedactorWithPatterns(patterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", p, err)
+		}
+		replacement := redactedValue
+		if re.SubexpIndex("prefix") > 0 {
+			replacement = "${prefix}" + replacement
+		}
+		if re.SubexpIndex("suffix") > 0 {
+			replacement += "${suffix}"
+		}
+		r.patterns = append(r.patterns, redactPattern{re: re, replacement: replacement})
+	}
+	return r, nil
+}
+
+// Redact returns s with the secrets replaced.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, p := range r.patterns {
+		s = p.re.ReplaceAllString(s, p.replacement)
+	}
+	return s
+}
+
+// RedactBytes returns b with the secrets replaced.
+func (r *Redactor) RedactBytes(b []byte) []byte {
+	if r == nil {
+		return b
+	}
+	for _, p := range r.patterns {
+		b = p.re.ReplaceAll(b, []byte(p.replacement))
+	}
+	return b
+}
+
+// Writer returns a writer that redacts each write before passing it on to w.
+// The writes are expected to hold whole lines, like the ones of hclog.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	if r == nil {
+		return w
+	}
+	return &redactWriter{r: r, w: w}
+}
+
+type redactWriter struct {
+	r *Redactor
+	w io.Writer
+}
+
+func (w *redactWriter) Write(p []byte) (int, error) {
+	if _, err := w.w.Write(w.r.RedactBytes(p)); err != nil {
+		return 0, err
+	}
+	// The caller only knows about the bytes it wrote.
+	return len(p), nil
+}
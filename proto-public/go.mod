@@ -0,0 +1,16 @@
+module github.com/hashicorp/consul/proto-public
+
+go 1.17
+
+require (
+	github.com/golang/protobuf v1.5.0
+	google.golang.org/grpc v1.37.1
+	google.golang.org/protobuf v1.27.1
+)
+
+require (
+	golang.org/x/net v0.0.0-20211216030914-fe4d6282115f // indirect
+	golang.org/x/sys v0.0.0-20220412211240-33da011f77ad // indirect
+	golang.org/x/text v0.3.6 // indirect
+	google.golang.org/genproto v0.0.0-20201102152239-715cce707fb0 // indirect
+)
@@ -0,0 +1,138 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: proto-public/pbdiscovery/discovery.proto
+
+package pbdiscovery
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// DiscoveryServiceClient is the client API for DiscoveryService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DiscoveryServiceClient interface {
+	// WatchEndpoints will stream back the endpoints of a service as they change,
+	// such as when instances are registered, deregistered or change health. The
+	// full set of endpoints is sent immediately at the start of the stream, and
+	// again whenever it changes.
+	WatchEndpoints(ctx context.Context, in *WatchEndpointsRequest, opts ...grpc.CallOption) (DiscoveryService_WatchEndpointsClient, error)
+}
+
+type discoveryServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDiscoveryServiceClient(cc grpc.ClientConnInterface) DiscoveryServiceClient {
+	return &discoveryServiceClient{cc}
+}
+
+func (c *discoveryServiceClient) WatchEndpoints(ctx context.Context, in *WatchEndpointsRequest, opts ...grpc.CallOption) (DiscoveryService_WatchEndpointsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DiscoveryService_ServiceDesc.Streams[0], "/consul.discovery.v1.DiscoveryService/WatchEndpoints", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &discoveryServiceWatchEndpointsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DiscoveryService_WatchEndpointsClient interface {
+	Recv() (*WatchEndpointsResponse, error)
+	grpc.ClientStream
+}
+
+type discoveryServiceWatchEndpointsClient struct {
+	grpc.ClientStream
+}
+
+func (x *discoveryServiceWatchEndpointsClient) Recv() (*WatchEndpointsResponse, error) {
+	m := new(WatchEndpointsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DiscoveryServiceServer is the server API for DiscoveryService service.
+// All implementations should embed UnimplementedDiscoveryServiceServer
+// for forward compatibility
+type DiscoveryServiceServer interface {
+	// WatchEndpoints will stream back the endpoints of a service as they change,
+	// such as when instances are registered, deregistered or change health. The
+	// full set of endpoints is sent immediately at the start of the stream, and
+	// again whenever it changes.
+	WatchEndpoints(*WatchEndpointsRequest, DiscoveryService_WatchEndpointsServer) error
+}
+
+// UnimplementedDiscoveryServiceServer should be embedded to have forward compatible implementations.
+type UnimplementedDiscoveryServiceServer struct {
+}
+
+func (UnimplementedDiscoveryServiceServer) WatchEndpoints(*WatchEndpointsRequest, DiscoveryService_WatchEndpointsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEndpoints not implemented")
+}
+
+// UnsafeDiscoveryServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DiscoveryServiceServer will
+// result in compilation errors.
+type UnsafeDiscoveryServiceServer interface {
+	mustEmbedUnimplementedDiscoveryServiceServer()
+}
+
+func RegisterDiscoveryServiceServer(s grpc.ServiceRegistrar, srv DiscoveryServiceServer) {
+	s.RegisterService(&DiscoveryService_ServiceDesc, srv)
+}
+
+func _DiscoveryService_WatchEndpoints_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEndpointsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DiscoveryServiceServer).WatchEndpoints(m, &discoveryServiceWatchEndpointsServer{stream})
+}
+
+type DiscoveryService_WatchEndpointsServer interface {
+	Send(*WatchEndpointsResponse) error
+	grpc.ServerStream
+}
+
+type discoveryServiceWatchEndpointsServer struct {
+	grpc.ServerStream
+}
+
+func (x *discoveryServiceWatchEndpointsServer) Send(m *WatchEndpointsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// DiscoveryService_ServiceDesc is the grpc.ServiceDesc for DiscoveryService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DiscoveryService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "consul.discovery.v1.DiscoveryService",
+	HandlerType: (*DiscoveryServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEndpoints",
+			Handler:       _DiscoveryService_WatchEndpoints_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto-public/pbdiscovery/discovery.proto",
+}
@@ -26,3 +26,13 @@ func (msg *AutoConfigResponse) MarshalBinary() ([]byte, error) {
 func (msg *AutoConfigResponse) UnmarshalBinary(b []byte) error {
 	return proto.Unmarshal(b, msg)
 }
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (msg *ConfigSnippet) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(msg)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (msg *ConfigSnippet) UnmarshalBinary(b []byte) error {
+	return proto.Unmarshal(b, msg)
+}
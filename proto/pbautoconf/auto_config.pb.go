@@ -147,6 +147,13 @@ type AutoConfigResponse struct {
 	// ExtraCACertificates holds non-Connect certificates that may be necessary
 	// to verify TLS connections with the Consul servers
 	ExtraCACertificates []string `protobuf:"bytes,4,rep,name=ExtraCACertificates,proto3" json:"ExtraCACertificates,omitempty"`
+	// ConfigSnippets are the additional configuration fragments the servers
+	// distribute to the agent. The agent only applies the snippets accepted by
+	// its auto_config.accept_snippet_keys setting.
+	ConfigSnippets []*ConfigSnippet `protobuf:"bytes,5,rep,name=ConfigSnippets,proto3" json:"ConfigSnippets,omitempty"`
+	// NodeIdentity is the node metadata verified by the servers, signed so
+	// that the agent can present it when registering its node.
+	NodeIdentity string `protobuf:"bytes,6,opt,name=NodeIdentity,proto3" json:"NodeIdentity,omitempty"`
 }
 
 func (x *AutoConfigResponse) Reset() {
@@ -209,6 +216,78 @@ func (x *AutoConfigResponse) GetExtraCACertificates() []string {
 	return nil
 }
 
+func (x *AutoConfigResponse) GetConfigSnippets() []*ConfigSnippet {
+	if x != nil {
+		return x.ConfigSnippets
+	}
+	return nil
+}
+
+func (x *AutoConfigResponse) GetNodeIdentity() string {
+	if x != nil {
+		return x.NodeIdentity
+	}
+	return ""
+}
+
+// ConfigSnippet is a named fragment of agent configuration in HCL or JSON
+type ConfigSnippet struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Name is the name of the snippet in the server configuration
+	Name string `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	// Config is the HCL or JSON agent configuration of the snippet
+	Config string `protobuf:"bytes,2,opt,name=Config,proto3" json:"Config,omitempty"`
+}
+
+func (x *ConfigSnippet) Reset() {
+	*x = ConfigSnippet{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_pbautoconf_auto_config_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ConfigSnippet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigSnippet) ProtoMessage() {}
+
+func (x *ConfigSnippet) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_pbautoconf_auto_config_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigSnippet.ProtoReflect.Descriptor instead.
+func (*ConfigSnippet) Descriptor() ([]byte, []int) {
+	return file_proto_pbautoconf_auto_config_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ConfigSnippet) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ConfigSnippet) GetConfig() string {
+	if x != nil {
+		return x.Config
+	}
+	return ""
+}
+
 var File_proto_pbautoconf_auto_config_proto protoreflect.FileDescriptor
 
 var file_proto_pbautoconf_auto_config_proto_rawDesc = []byte{
@@ -231,7 +310,7 @@ var file_proto_pbautoconf_auto_config_proto_rawDesc = []byte{
 	0x0a, 0x0b, 0x43, 0x6f, 0x6e, 0x73, 0x75, 0x6c, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x06, 0x20,
 	0x01, 0x28, 0x09, 0x52, 0x0b, 0x43, 0x6f, 0x6e, 0x73, 0x75, 0x6c, 0x54, 0x6f, 0x6b, 0x65, 0x6e,
 	0x12, 0x10, 0x0a, 0x03, 0x43, 0x53, 0x52, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x43,
-	0x53, 0x52, 0x22, 0xd1, 0x01, 0x0a, 0x12, 0x41, 0x75, 0x74, 0x6f, 0x43, 0x6f, 0x6e, 0x66, 0x69,
+	0x53, 0x52, 0x22, 0xb6, 0x02, 0x0a, 0x12, 0x41, 0x75, 0x74, 0x6f, 0x43, 0x6f, 0x6e, 0x66, 0x69,
 	0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x26, 0x0a, 0x06, 0x43, 0x6f, 0x6e,
 	0x66, 0x69, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x63, 0x6f, 0x6e, 0x66,
 	0x69, 0x67, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x06, 0x43, 0x6f, 0x6e, 0x66, 0x69,
@@ -244,16 +323,26 @@ var file_proto_pbautoconf_auto_config_proto_rawDesc = []byte{
 	0x63, 0x61, 0x74, 0x65, 0x12, 0x30, 0x0a, 0x13, 0x45, 0x78, 0x74, 0x72, 0x61, 0x43, 0x41, 0x43,
 	0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28,
 	0x09, 0x52, 0x13, 0x45, 0x78, 0x74, 0x72, 0x61, 0x43, 0x41, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66,
-	0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x42, 0x8d, 0x01, 0x0a, 0x0c, 0x63, 0x6f, 0x6d, 0x2e, 0x61,
-	0x75, 0x74, 0x6f, 0x63, 0x6f, 0x6e, 0x66, 0x42, 0x0f, 0x41, 0x75, 0x74, 0x6f, 0x43, 0x6f, 0x6e,
-	0x66, 0x69, 0x67, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x2c, 0x67, 0x69, 0x74, 0x68,
-	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x68, 0x61, 0x73, 0x68, 0x69, 0x63, 0x6f, 0x72, 0x70,
-	0x2f, 0x63, 0x6f, 0x6e, 0x73, 0x75, 0x6c, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x70, 0x62,
-	0x61, 0x75, 0x74, 0x6f, 0x63, 0x6f, 0x6e, 0x66, 0xa2, 0x02, 0x03, 0x41, 0x58, 0x58, 0xaa, 0x02,
-	0x08, 0x41, 0x75, 0x74, 0x6f, 0x63, 0x6f, 0x6e, 0x66, 0xca, 0x02, 0x08, 0x41, 0x75, 0x74, 0x6f,
-	0x63, 0x6f, 0x6e, 0x66, 0xe2, 0x02, 0x14, 0x41, 0x75, 0x74, 0x6f, 0x63, 0x6f, 0x6e, 0x66, 0x5c,
-	0x47, 0x50, 0x42, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0xea, 0x02, 0x08, 0x41, 0x75,
-	0x74, 0x6f, 0x63, 0x6f, 0x6e, 0x66, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x12, 0x3f, 0x0a, 0x0e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x53, 0x6e, 0x69, 0x70, 0x70, 0x65, 0x74, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17,
+	0x2e, 0x61, 0x75, 0x74, 0x6f, 0x63, 0x6f, 0x6e, 0x66, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x53, 0x6e, 0x69, 0x70, 0x70, 0x65, 0x74, 0x52, 0x0e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x53,
+	0x6e, 0x69, 0x70, 0x70, 0x65, 0x74, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x4e, 0x6f, 0x64, 0x65, 0x49,
+	0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x4e,
+	0x6f, 0x64, 0x65, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x22, 0x3b, 0x0a, 0x0d, 0x43,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x53, 0x6e, 0x69, 0x70, 0x70, 0x65, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65,
+	0x12, 0x16, 0x0a, 0x06, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x42, 0x8d, 0x01, 0x0a, 0x0c, 0x63, 0x6f, 0x6d,
+	0x2e, 0x61, 0x75, 0x74, 0x6f, 0x63, 0x6f, 0x6e, 0x66, 0x42, 0x0f, 0x41, 0x75, 0x74, 0x6f, 0x43,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x2c, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x68, 0x61, 0x73, 0x68, 0x69, 0x63, 0x6f,
+	0x72, 0x70, 0x2f, 0x63, 0x6f, 0x6e, 0x73, 0x75, 0x6c, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f,
+	0x70, 0x62, 0x61, 0x75, 0x74, 0x6f, 0x63, 0x6f, 0x6e, 0x66, 0xa2, 0x02, 0x03, 0x41, 0x58, 0x58,
+	0xaa, 0x02, 0x08, 0x41, 0x75, 0x74, 0x6f, 0x63, 0x6f, 0x6e, 0x66, 0xca, 0x02, 0x08, 0x41, 0x75,
+	0x74, 0x6f, 0x63, 0x6f, 0x6e, 0x66, 0xe2, 0x02, 0x14, 0x41, 0x75, 0x74, 0x6f, 0x63, 0x6f, 0x6e,
+	0x66, 0x5c, 0x47, 0x50, 0x42, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0xea, 0x02, 0x08,
+	0x41, 0x75, 0x74, 0x6f, 0x63, 0x6f, 0x6e, 0x66, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -268,23 +357,25 @@ func file_proto_pbautoconf_auto_config_proto_rawDescGZIP() []byte {
 	return file_proto_pbautoconf_auto_config_proto_rawDescData
 }
 
-var file_proto_pbautoconf_auto_config_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_pbautoconf_auto_config_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_proto_pbautoconf_auto_config_proto_goTypes = []interface{}{
 	(*AutoConfigRequest)(nil),    // 0: autoconf.AutoConfigRequest
 	(*AutoConfigResponse)(nil),   // 1: autoconf.AutoConfigResponse
-	(*pbconfig.Config)(nil),      // 2: config.Config
-	(*pbconnect.CARoots)(nil),    // 3: connect.CARoots
-	(*pbconnect.IssuedCert)(nil), // 4: connect.IssuedCert
+	(*ConfigSnippet)(nil),        // 2: autoconf.ConfigSnippet
+	(*pbconfig.Config)(nil),      // 3: config.Config
+	(*pbconnect.CARoots)(nil),    // 4: connect.CARoots
+	(*pbconnect.IssuedCert)(nil), // 5: connect.IssuedCert
 }
 var file_proto_pbautoconf_auto_config_proto_depIdxs = []int32{
-	2, // 0: autoconf.AutoConfigResponse.Config:type_name -> config.Config
-	3, // 1: autoconf.AutoConfigResponse.CARoots:type_name -> connect.CARoots
-	4, // 2: autoconf.AutoConfigResponse.Certificate:type_name -> connect.IssuedCert
-	3, // [3:3] is the sub-list for method output_type
-	3, // [3:3] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	3, // 0: autoconf.AutoConfigResponse.Config:type_name -> config.Config
+	4, // 1: autoconf.AutoConfigResponse.CARoots:type_name -> connect.CARoots
+	5, // 2: autoconf.AutoConfigResponse.Certificate:type_name -> connect.IssuedCert
+	2, // 3: autoconf.AutoConfigResponse.ConfigSnippets:type_name -> autoconf.ConfigSnippet
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_proto_pbautoconf_auto_config_proto_init() }
@@ -317,6 +408,18 @@ func file_proto_pbautoconf_auto_config_proto_init() {
 				return nil
 			}
 		}
+		file_proto_pbautoconf_auto_config_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ConfigSnippet); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -324,7 +427,7 @@ func file_proto_pbautoconf_auto_config_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_proto_pbautoconf_auto_config_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   3,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
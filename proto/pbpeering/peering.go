@@ -6,7 +6,9 @@ import (
 
 	"github.com/mitchellh/hashstructure"
 
+	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/lib"
 )
@@ -139,6 +141,58 @@ func (b *PeeringTrustBundle) ConcatenatedRootPEMs() string {
 	return rootPEMs
 }
 
+// PeeredServiceName returns the name of the imported service the intentions
+// are for.
+func (p *PeeringIntentions) PeeredServiceName() structs.PeeredServiceName {
+	entMeta := acl.NewEnterpriseMetaWithPartition(p.Partition, p.Namespace)
+	return structs.PeeredServiceName{
+		ServiceName: structs.NewServiceName(p.ServiceName, &entMeta),
+		Peer:        p.PeerName,
+	}
+}
+
+// Decision returns whether the peer allows the local service with the given
+// name and namespace to call the imported service. The highest precedence
+// source matching the service decides, or else the default of the peer. When
+// the matching intention has L7 permissions, the calls are only allowed if
+// allowPermissions is true, as the permissions are enforced by the peer.
+func (p *PeeringIntentions) Decision(name, namespace string, allowPermissions bool) (allowed, hasPermissions bool) {
+	summary := p.DecisionSummary(name, namespace, allowPermissions)
+	return summary.Allowed, summary.HasPermissions
+}
+
+// DecisionSummary is like Decision, but also tells whether the decision
+// comes from an intention for this exact source or from the default of the
+// peer.
+func (p *PeeringIntentions) DecisionSummary(name, namespace string, allowPermissions bool) structs.IntentionDecisionSummary {
+	for _, src := range p.Sources {
+		if !matchIntentionSource(src.Name, name) || !matchIntentionSource(defaultNamespace(src.Namespace), defaultNamespace(namespace)) {
+			continue
+		}
+		summary := structs.IntentionDecisionSummary{
+			Allowed:  src.Action == "allow",
+			HasExact: src.Name == name && defaultNamespace(src.Namespace) == defaultNamespace(namespace),
+		}
+		if src.HasPermissions {
+			summary.Allowed = allowPermissions
+			summary.HasPermissions = true
+		}
+		return summary
+	}
+	return structs.IntentionDecisionSummary{Allowed: p.DefaultAllow, DefaultAllow: p.DefaultAllow}
+}
+
+func matchIntentionSource(pattern, value string) bool {
+	return pattern == "*" || pattern == value
+}
+
+func defaultNamespace(ns string) string {
+	if ns == "" {
+		return "default"
+	}
+	return ns
+}
+
 // enumcover:PeeringState
 func PeeringStateToAPI(s PeeringState) api.PeeringState {
 	switch s {
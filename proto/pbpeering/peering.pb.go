@@ -137,7 +137,7 @@ func (x ReplicationMessage_Response_Operation) Number() protoreflect.EnumNumber
 
 // Deprecated: Use ReplicationMessage_Response_Operation.Descriptor instead.
 func (ReplicationMessage_Response_Operation) EnumDescriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{24, 1, 0}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{30, 1, 0}
 }
 
 // Peering defines a peering relationship between two disparate Consul clusters
@@ -394,6 +394,210 @@ func (x *PeeringTrustBundle) GetModifyIndex() uint64 {
 	return 0
 }
 
+// PeeringIntentions holds the intentions of a peer which decide whether the
+// services of the local partition are allowed to call one of its exported
+// services.
+type PeeringIntentions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// PeerName associates the intentions with a peer.
+	PeerName string `protobuf:"bytes,1,opt,name=PeerName,proto3" json:"PeerName,omitempty"`
+	// Partition is the local partition the service is imported into.
+	Partition string `protobuf:"bytes,2,opt,name=Partition,proto3" json:"Partition,omitempty"`
+	// Namespace is the namespace of the imported service.
+	Namespace string `protobuf:"bytes,3,opt,name=Namespace,proto3" json:"Namespace,omitempty"`
+	// ServiceName is the name of the imported service.
+	ServiceName string `protobuf:"bytes,4,opt,name=ServiceName,proto3" json:"ServiceName,omitempty"`
+	// DefaultAllow is whether the peer allows the calls which don't match
+	// any of the sources.
+	DefaultAllow bool `protobuf:"varint,5,opt,name=DefaultAllow,proto3" json:"DefaultAllow,omitempty"`
+	// Sources are the sources of the intentions of the peer for the service
+	// which reference this cluster, in precedence order.
+	Sources []*PeeringIntentionSource `protobuf:"bytes,6,rep,name=Sources,proto3" json:"Sources,omitempty"`
+	// CreateIndex is the Raft index at which the intentions were created.
+	CreateIndex uint64 `protobuf:"varint,7,opt,name=CreateIndex,proto3" json:"CreateIndex,omitempty"`
+	// ModifyIndex is the latest Raft index at which the intentions were modified.
+	ModifyIndex uint64 `protobuf:"varint,8,opt,name=ModifyIndex,proto3" json:"ModifyIndex,omitempty"`
+}
+
+func (x *PeeringIntentions) Reset() {
+	*x = PeeringIntentions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PeeringIntentions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeeringIntentions) ProtoMessage() {}
+
+func (x *PeeringIntentions) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeeringIntentions.ProtoReflect.Descriptor instead.
+func (*PeeringIntentions) Descriptor() ([]byte, []int) {
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PeeringIntentions) GetPeerName() string {
+	if x != nil {
+		return x.PeerName
+	}
+	return ""
+}
+
+func (x *PeeringIntentions) GetPartition() string {
+	if x != nil {
+		return x.Partition
+	}
+	return ""
+}
+
+func (x *PeeringIntentions) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *PeeringIntentions) GetServiceName() string {
+	if x != nil {
+		return x.ServiceName
+	}
+	return ""
+}
+
+func (x *PeeringIntentions) GetDefaultAllow() bool {
+	if x != nil {
+		return x.DefaultAllow
+	}
+	return false
+}
+
+func (x *PeeringIntentions) GetSources() []*PeeringIntentionSource {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+func (x *PeeringIntentions) GetCreateIndex() uint64 {
+	if x != nil {
+		return x.CreateIndex
+	}
+	return 0
+}
+
+func (x *PeeringIntentions) GetModifyIndex() uint64 {
+	if x != nil {
+		return x.ModifyIndex
+	}
+	return 0
+}
+
+// PeeringIntentionSource is a source of an intention of a peer, which refers
+// to services of the local partition.
+type PeeringIntentionSource struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Name is the name of the source service, or "*" for all of them.
+	Name string `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	// Namespace is the namespace of the source service, or "*" for all of them.
+	Namespace string `protobuf:"bytes,2,opt,name=Namespace,proto3" json:"Namespace,omitempty"`
+	// Action is either "allow" or "deny".
+	Action string `protobuf:"bytes,3,opt,name=Action,proto3" json:"Action,omitempty"`
+	// HasPermissions is whether the intention has L7 permissions. They are
+	// enforced by the peer, so the calls matching this source may or may not
+	// be allowed.
+	HasPermissions bool `protobuf:"varint,4,opt,name=HasPermissions,proto3" json:"HasPermissions,omitempty"`
+	// Precedence is the precedence of the intention on the peer.
+	Precedence int32 `protobuf:"varint,5,opt,name=Precedence,proto3" json:"Precedence,omitempty"`
+}
+
+func (x *PeeringIntentionSource) Reset() {
+	*x = PeeringIntentionSource{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PeeringIntentionSource) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeeringIntentionSource) ProtoMessage() {}
+
+func (x *PeeringIntentionSource) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeeringIntentionSource.ProtoReflect.Descriptor instead.
+func (*PeeringIntentionSource) Descriptor() ([]byte, []int) {
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PeeringIntentionSource) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PeeringIntentionSource) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *PeeringIntentionSource) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *PeeringIntentionSource) GetHasPermissions() bool {
+	if x != nil {
+		return x.HasPermissions
+	}
+	return false
+}
+
+func (x *PeeringIntentionSource) GetPrecedence() int32 {
+	if x != nil {
+		return x.Precedence
+	}
+	return 0
+}
+
 // @consul-rpc-glue: Datacenter,ReadTODO
 type PeeringReadRequest struct {
 	state         protoimpl.MessageState
@@ -408,7 +612,7 @@ type PeeringReadRequest struct {
 func (x *PeeringReadRequest) Reset() {
 	*x = PeeringReadRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[2]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -421,7 +625,7 @@ func (x *PeeringReadRequest) String() string {
 func (*PeeringReadRequest) ProtoMessage() {}
 
 func (x *PeeringReadRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[2]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -434,7 +638,7 @@ func (x *PeeringReadRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PeeringReadRequest.ProtoReflect.Descriptor instead.
 func (*PeeringReadRequest) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{2}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *PeeringReadRequest) GetName() string {
@@ -469,7 +673,7 @@ type PeeringReadResponse struct {
 func (x *PeeringReadResponse) Reset() {
 	*x = PeeringReadResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[3]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -482,7 +686,7 @@ func (x *PeeringReadResponse) String() string {
 func (*PeeringReadResponse) ProtoMessage() {}
 
 func (x *PeeringReadResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[3]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -495,7 +699,7 @@ func (x *PeeringReadResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PeeringReadResponse.ProtoReflect.Descriptor instead.
 func (*PeeringReadResponse) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{3}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *PeeringReadResponse) GetPeering() *Peering {
@@ -518,7 +722,7 @@ type PeeringListRequest struct {
 func (x *PeeringListRequest) Reset() {
 	*x = PeeringListRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[4]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -531,7 +735,7 @@ func (x *PeeringListRequest) String() string {
 func (*PeeringListRequest) ProtoMessage() {}
 
 func (x *PeeringListRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[4]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -544,7 +748,7 @@ func (x *PeeringListRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PeeringListRequest.ProtoReflect.Descriptor instead.
 func (*PeeringListRequest) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{4}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *PeeringListRequest) GetPartition() string {
@@ -572,7 +776,7 @@ type PeeringListResponse struct {
 func (x *PeeringListResponse) Reset() {
 	*x = PeeringListResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[5]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -585,7 +789,7 @@ func (x *PeeringListResponse) String() string {
 func (*PeeringListResponse) ProtoMessage() {}
 
 func (x *PeeringListResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[5]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -598,7 +802,7 @@ func (x *PeeringListResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PeeringListResponse.ProtoReflect.Descriptor instead.
 func (*PeeringListResponse) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{5}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *PeeringListResponse) GetPeerings() []*Peering {
@@ -624,7 +828,7 @@ type PeeringWriteRequest struct {
 func (x *PeeringWriteRequest) Reset() {
 	*x = PeeringWriteRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[6]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -637,7 +841,7 @@ func (x *PeeringWriteRequest) String() string {
 func (*PeeringWriteRequest) ProtoMessage() {}
 
 func (x *PeeringWriteRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[6]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -650,7 +854,7 @@ func (x *PeeringWriteRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PeeringWriteRequest.ProtoReflect.Descriptor instead.
 func (*PeeringWriteRequest) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{6}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *PeeringWriteRequest) GetPeering() *Peering {
@@ -684,7 +888,7 @@ type PeeringWriteResponse struct {
 func (x *PeeringWriteResponse) Reset() {
 	*x = PeeringWriteResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[7]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -697,7 +901,7 @@ func (x *PeeringWriteResponse) String() string {
 func (*PeeringWriteResponse) ProtoMessage() {}
 
 func (x *PeeringWriteResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[7]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -710,7 +914,7 @@ func (x *PeeringWriteResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PeeringWriteResponse.ProtoReflect.Descriptor instead.
 func (*PeeringWriteResponse) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{7}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{9}
 }
 
 // @consul-rpc-glue: Datacenter,WriteTODO
@@ -728,7 +932,7 @@ type PeeringDeleteRequest struct {
 func (x *PeeringDeleteRequest) Reset() {
 	*x = PeeringDeleteRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[8]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -741,7 +945,7 @@ func (x *PeeringDeleteRequest) String() string {
 func (*PeeringDeleteRequest) ProtoMessage() {}
 
 func (x *PeeringDeleteRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[8]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -754,7 +958,7 @@ func (x *PeeringDeleteRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PeeringDeleteRequest.ProtoReflect.Descriptor instead.
 func (*PeeringDeleteRequest) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{8}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *PeeringDeleteRequest) GetName() string {
@@ -787,7 +991,7 @@ type PeeringDeleteResponse struct {
 func (x *PeeringDeleteResponse) Reset() {
 	*x = PeeringDeleteResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[9]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -800,7 +1004,7 @@ func (x *PeeringDeleteResponse) String() string {
 func (*PeeringDeleteResponse) ProtoMessage() {}
 
 func (x *PeeringDeleteResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[9]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -813,7 +1017,7 @@ func (x *PeeringDeleteResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PeeringDeleteResponse.ProtoReflect.Descriptor instead.
 func (*PeeringDeleteResponse) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{9}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{11}
 }
 
 // @consul-rpc-glue: Datacenter,ReadTODO
@@ -833,7 +1037,7 @@ type TrustBundleListByServiceRequest struct {
 func (x *TrustBundleListByServiceRequest) Reset() {
 	*x = TrustBundleListByServiceRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[10]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -846,7 +1050,7 @@ func (x *TrustBundleListByServiceRequest) String() string {
 func (*TrustBundleListByServiceRequest) ProtoMessage() {}
 
 func (x *TrustBundleListByServiceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[10]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -859,7 +1063,7 @@ func (x *TrustBundleListByServiceRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TrustBundleListByServiceRequest.ProtoReflect.Descriptor instead.
 func (*TrustBundleListByServiceRequest) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{10}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *TrustBundleListByServiceRequest) GetServiceName() string {
@@ -902,7 +1106,7 @@ type TrustBundleListByServiceResponse struct {
 func (x *TrustBundleListByServiceResponse) Reset() {
 	*x = TrustBundleListByServiceResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[11]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -915,7 +1119,7 @@ func (x *TrustBundleListByServiceResponse) String() string {
 func (*TrustBundleListByServiceResponse) ProtoMessage() {}
 
 func (x *TrustBundleListByServiceResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[11]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -928,7 +1132,7 @@ func (x *TrustBundleListByServiceResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TrustBundleListByServiceResponse.ProtoReflect.Descriptor instead.
 func (*TrustBundleListByServiceResponse) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{11}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *TrustBundleListByServiceResponse) GetIndex() uint64 {
@@ -961,7 +1165,7 @@ type TrustBundleReadRequest struct {
 func (x *TrustBundleReadRequest) Reset() {
 	*x = TrustBundleReadRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[12]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[14]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -974,7 +1178,7 @@ func (x *TrustBundleReadRequest) String() string {
 func (*TrustBundleReadRequest) ProtoMessage() {}
 
 func (x *TrustBundleReadRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[12]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[14]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -987,7 +1191,7 @@ func (x *TrustBundleReadRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TrustBundleReadRequest.ProtoReflect.Descriptor instead.
 func (*TrustBundleReadRequest) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{12}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *TrustBundleReadRequest) GetName() string {
@@ -1023,7 +1227,7 @@ type TrustBundleReadResponse struct {
 func (x *TrustBundleReadResponse) Reset() {
 	*x = TrustBundleReadResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[13]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1036,7 +1240,7 @@ func (x *TrustBundleReadResponse) String() string {
 func (*TrustBundleReadResponse) ProtoMessage() {}
 
 func (x *TrustBundleReadResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[13]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1049,7 +1253,7 @@ func (x *TrustBundleReadResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TrustBundleReadResponse.ProtoReflect.Descriptor instead.
 func (*TrustBundleReadResponse) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{13}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *TrustBundleReadResponse) GetIndex() uint64 {
@@ -1077,7 +1281,7 @@ type PeeringTerminateByIDRequest struct {
 func (x *PeeringTerminateByIDRequest) Reset() {
 	*x = PeeringTerminateByIDRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[14]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[16]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1090,7 +1294,7 @@ func (x *PeeringTerminateByIDRequest) String() string {
 func (*PeeringTerminateByIDRequest) ProtoMessage() {}
 
 func (x *PeeringTerminateByIDRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[14]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[16]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1103,7 +1307,7 @@ func (x *PeeringTerminateByIDRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PeeringTerminateByIDRequest.ProtoReflect.Descriptor instead.
 func (*PeeringTerminateByIDRequest) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{14}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *PeeringTerminateByIDRequest) GetID() string {
@@ -1113,29 +1317,227 @@ func (x *PeeringTerminateByIDRequest) GetID() string {
 	return ""
 }
 
-type PeeringTerminateByIDResponse struct {
+type PeeringTerminateByIDResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *PeeringTerminateByIDResponse) Reset() {
+	*x = PeeringTerminateByIDResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PeeringTerminateByIDResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeeringTerminateByIDResponse) ProtoMessage() {}
+
+func (x *PeeringTerminateByIDResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeeringTerminateByIDResponse.ProtoReflect.Descriptor instead.
+func (*PeeringTerminateByIDResponse) Descriptor() ([]byte, []int) {
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{17}
+}
+
+// @consul-rpc-glue: Datacenter
+type PeeringTrustBundleWriteRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PeeringTrustBundle *PeeringTrustBundle `protobuf:"bytes,1,opt,name=PeeringTrustBundle,proto3" json:"PeeringTrustBundle,omitempty"`
+	//TODO(peering): what to do with embedded write request?
+	Datacenter string `protobuf:"bytes,2,opt,name=Datacenter,proto3" json:"Datacenter,omitempty"`
+}
+
+func (x *PeeringTrustBundleWriteRequest) Reset() {
+	*x = PeeringTrustBundleWriteRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PeeringTrustBundleWriteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeeringTrustBundleWriteRequest) ProtoMessage() {}
+
+func (x *PeeringTrustBundleWriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeeringTrustBundleWriteRequest.ProtoReflect.Descriptor instead.
+func (*PeeringTrustBundleWriteRequest) Descriptor() ([]byte, []int) {
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *PeeringTrustBundleWriteRequest) GetPeeringTrustBundle() *PeeringTrustBundle {
+	if x != nil {
+		return x.PeeringTrustBundle
+	}
+	return nil
+}
+
+func (x *PeeringTrustBundleWriteRequest) GetDatacenter() string {
+	if x != nil {
+		return x.Datacenter
+	}
+	return ""
+}
+
+type PeeringTrustBundleWriteResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *PeeringTrustBundleWriteResponse) Reset() {
+	*x = PeeringTrustBundleWriteResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PeeringTrustBundleWriteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeeringTrustBundleWriteResponse) ProtoMessage() {}
+
+func (x *PeeringTrustBundleWriteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeeringTrustBundleWriteResponse.ProtoReflect.Descriptor instead.
+func (*PeeringTrustBundleWriteResponse) Descriptor() ([]byte, []int) {
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{19}
+}
+
+// @consul-rpc-glue: Datacenter
+type PeeringTrustBundleDeleteRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name      string `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	Partition string `protobuf:"bytes,2,opt,name=Partition,proto3" json:"Partition,omitempty"`
+	//TODO(peering): what to do with embedded write request?
+	Datacenter string `protobuf:"bytes,3,opt,name=Datacenter,proto3" json:"Datacenter,omitempty"`
+}
+
+func (x *PeeringTrustBundleDeleteRequest) Reset() {
+	*x = PeeringTrustBundleDeleteRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PeeringTrustBundleDeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeeringTrustBundleDeleteRequest) ProtoMessage() {}
+
+func (x *PeeringTrustBundleDeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeeringTrustBundleDeleteRequest.ProtoReflect.Descriptor instead.
+func (*PeeringTrustBundleDeleteRequest) Descriptor() ([]byte, []int) {
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *PeeringTrustBundleDeleteRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PeeringTrustBundleDeleteRequest) GetPartition() string {
+	if x != nil {
+		return x.Partition
+	}
+	return ""
+}
+
+func (x *PeeringTrustBundleDeleteRequest) GetDatacenter() string {
+	if x != nil {
+		return x.Datacenter
+	}
+	return ""
+}
+
+type PeeringTrustBundleDeleteResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 }
 
-func (x *PeeringTerminateByIDResponse) Reset() {
-	*x = PeeringTerminateByIDResponse{}
+func (x *PeeringTrustBundleDeleteResponse) Reset() {
+	*x = PeeringTrustBundleDeleteResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[15]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[21]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *PeeringTerminateByIDResponse) String() string {
+func (x *PeeringTrustBundleDeleteResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PeeringTerminateByIDResponse) ProtoMessage() {}
+func (*PeeringTrustBundleDeleteResponse) ProtoMessage() {}
 
-func (x *PeeringTerminateByIDResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[15]
+func (x *PeeringTrustBundleDeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[21]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1146,39 +1548,36 @@ func (x *PeeringTerminateByIDResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PeeringTerminateByIDResponse.ProtoReflect.Descriptor instead.
-func (*PeeringTerminateByIDResponse) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use PeeringTrustBundleDeleteResponse.ProtoReflect.Descriptor instead.
+func (*PeeringTrustBundleDeleteResponse) Descriptor() ([]byte, []int) {
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{21}
 }
 
-// @consul-rpc-glue: Datacenter
-type PeeringTrustBundleWriteRequest struct {
+type PeeringIntentionsWriteRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	PeeringTrustBundle *PeeringTrustBundle `protobuf:"bytes,1,opt,name=PeeringTrustBundle,proto3" json:"PeeringTrustBundle,omitempty"`
-	//TODO(peering): what to do with embedded write request?
-	Datacenter string `protobuf:"bytes,2,opt,name=Datacenter,proto3" json:"Datacenter,omitempty"`
+	PeeringIntentions *PeeringIntentions `protobuf:"bytes,1,opt,name=PeeringIntentions,proto3" json:"PeeringIntentions,omitempty"`
 }
 
-func (x *PeeringTrustBundleWriteRequest) Reset() {
-	*x = PeeringTrustBundleWriteRequest{}
+func (x *PeeringIntentionsWriteRequest) Reset() {
+	*x = PeeringIntentionsWriteRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[16]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[22]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *PeeringTrustBundleWriteRequest) String() string {
+func (x *PeeringIntentionsWriteRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PeeringTrustBundleWriteRequest) ProtoMessage() {}
+func (*PeeringIntentionsWriteRequest) ProtoMessage() {}
 
-func (x *PeeringTrustBundleWriteRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[16]
+func (x *PeeringIntentionsWriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[22]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1189,48 +1588,41 @@ func (x *PeeringTrustBundleWriteRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PeeringTrustBundleWriteRequest.ProtoReflect.Descriptor instead.
-func (*PeeringTrustBundleWriteRequest) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{16}
+// Deprecated: Use PeeringIntentionsWriteRequest.ProtoReflect.Descriptor instead.
+func (*PeeringIntentionsWriteRequest) Descriptor() ([]byte, []int) {
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{22}
 }
 
-func (x *PeeringTrustBundleWriteRequest) GetPeeringTrustBundle() *PeeringTrustBundle {
+func (x *PeeringIntentionsWriteRequest) GetPeeringIntentions() *PeeringIntentions {
 	if x != nil {
-		return x.PeeringTrustBundle
+		return x.PeeringIntentions
 	}
 	return nil
 }
 
-func (x *PeeringTrustBundleWriteRequest) GetDatacenter() string {
-	if x != nil {
-		return x.Datacenter
-	}
-	return ""
-}
-
-type PeeringTrustBundleWriteResponse struct {
+type PeeringIntentionsWriteResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 }
 
-func (x *PeeringTrustBundleWriteResponse) Reset() {
-	*x = PeeringTrustBundleWriteResponse{}
+func (x *PeeringIntentionsWriteResponse) Reset() {
+	*x = PeeringIntentionsWriteResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[17]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[23]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *PeeringTrustBundleWriteResponse) String() string {
+func (x *PeeringIntentionsWriteResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PeeringTrustBundleWriteResponse) ProtoMessage() {}
+func (*PeeringIntentionsWriteResponse) ProtoMessage() {}
 
-func (x *PeeringTrustBundleWriteResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[17]
+func (x *PeeringIntentionsWriteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[23]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1241,40 +1633,39 @@ func (x *PeeringTrustBundleWriteResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PeeringTrustBundleWriteResponse.ProtoReflect.Descriptor instead.
-func (*PeeringTrustBundleWriteResponse) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{17}
+// Deprecated: Use PeeringIntentionsWriteResponse.ProtoReflect.Descriptor instead.
+func (*PeeringIntentionsWriteResponse) Descriptor() ([]byte, []int) {
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{23}
 }
 
-// @consul-rpc-glue: Datacenter
-type PeeringTrustBundleDeleteRequest struct {
+type PeeringIntentionsDeleteRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Name      string `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
-	Partition string `protobuf:"bytes,2,opt,name=Partition,proto3" json:"Partition,omitempty"`
-	//TODO(peering): what to do with embedded write request?
-	Datacenter string `protobuf:"bytes,3,opt,name=Datacenter,proto3" json:"Datacenter,omitempty"`
+	PeerName    string `protobuf:"bytes,1,opt,name=PeerName,proto3" json:"PeerName,omitempty"`
+	Partition   string `protobuf:"bytes,2,opt,name=Partition,proto3" json:"Partition,omitempty"`
+	Namespace   string `protobuf:"bytes,3,opt,name=Namespace,proto3" json:"Namespace,omitempty"`
+	ServiceName string `protobuf:"bytes,4,opt,name=ServiceName,proto3" json:"ServiceName,omitempty"`
 }
 
-func (x *PeeringTrustBundleDeleteRequest) Reset() {
-	*x = PeeringTrustBundleDeleteRequest{}
+func (x *PeeringIntentionsDeleteRequest) Reset() {
+	*x = PeeringIntentionsDeleteRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[18]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[24]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *PeeringTrustBundleDeleteRequest) String() string {
+func (x *PeeringIntentionsDeleteRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PeeringTrustBundleDeleteRequest) ProtoMessage() {}
+func (*PeeringIntentionsDeleteRequest) ProtoMessage() {}
 
-func (x *PeeringTrustBundleDeleteRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[18]
+func (x *PeeringIntentionsDeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[24]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1285,55 +1676,62 @@ func (x *PeeringTrustBundleDeleteRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PeeringTrustBundleDeleteRequest.ProtoReflect.Descriptor instead.
-func (*PeeringTrustBundleDeleteRequest) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{18}
+// Deprecated: Use PeeringIntentionsDeleteRequest.ProtoReflect.Descriptor instead.
+func (*PeeringIntentionsDeleteRequest) Descriptor() ([]byte, []int) {
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{24}
 }
 
-func (x *PeeringTrustBundleDeleteRequest) GetName() string {
+func (x *PeeringIntentionsDeleteRequest) GetPeerName() string {
 	if x != nil {
-		return x.Name
+		return x.PeerName
 	}
 	return ""
 }
 
-func (x *PeeringTrustBundleDeleteRequest) GetPartition() string {
+func (x *PeeringIntentionsDeleteRequest) GetPartition() string {
 	if x != nil {
 		return x.Partition
 	}
 	return ""
 }
 
-func (x *PeeringTrustBundleDeleteRequest) GetDatacenter() string {
+func (x *PeeringIntentionsDeleteRequest) GetNamespace() string {
 	if x != nil {
-		return x.Datacenter
+		return x.Namespace
 	}
 	return ""
 }
 
-type PeeringTrustBundleDeleteResponse struct {
+func (x *PeeringIntentionsDeleteRequest) GetServiceName() string {
+	if x != nil {
+		return x.ServiceName
+	}
+	return ""
+}
+
+type PeeringIntentionsDeleteResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 }
 
-func (x *PeeringTrustBundleDeleteResponse) Reset() {
-	*x = PeeringTrustBundleDeleteResponse{}
+func (x *PeeringIntentionsDeleteResponse) Reset() {
+	*x = PeeringIntentionsDeleteResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[19]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[25]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *PeeringTrustBundleDeleteResponse) String() string {
+func (x *PeeringIntentionsDeleteResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PeeringTrustBundleDeleteResponse) ProtoMessage() {}
+func (*PeeringIntentionsDeleteResponse) ProtoMessage() {}
 
-func (x *PeeringTrustBundleDeleteResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[19]
+func (x *PeeringIntentionsDeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[25]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1344,9 +1742,9 @@ func (x *PeeringTrustBundleDeleteResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PeeringTrustBundleDeleteResponse.ProtoReflect.Descriptor instead.
-func (*PeeringTrustBundleDeleteResponse) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{19}
+// Deprecated: Use PeeringIntentionsDeleteResponse.ProtoReflect.Descriptor instead.
+func (*PeeringIntentionsDeleteResponse) Descriptor() ([]byte, []int) {
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{25}
 }
 
 // mog annotation:
@@ -1374,7 +1772,7 @@ type GenerateTokenRequest struct {
 func (x *GenerateTokenRequest) Reset() {
 	*x = GenerateTokenRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[20]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[26]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1387,7 +1785,7 @@ func (x *GenerateTokenRequest) String() string {
 func (*GenerateTokenRequest) ProtoMessage() {}
 
 func (x *GenerateTokenRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[20]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[26]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1400,7 +1798,7 @@ func (x *GenerateTokenRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GenerateTokenRequest.ProtoReflect.Descriptor instead.
 func (*GenerateTokenRequest) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{20}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{26}
 }
 
 func (x *GenerateTokenRequest) GetPeerName() string {
@@ -1456,7 +1854,7 @@ type GenerateTokenResponse struct {
 func (x *GenerateTokenResponse) Reset() {
 	*x = GenerateTokenResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[21]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[27]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1469,7 +1867,7 @@ func (x *GenerateTokenResponse) String() string {
 func (*GenerateTokenResponse) ProtoMessage() {}
 
 func (x *GenerateTokenResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[21]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[27]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1482,7 +1880,7 @@ func (x *GenerateTokenResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GenerateTokenResponse.ProtoReflect.Descriptor instead.
 func (*GenerateTokenResponse) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{21}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{27}
 }
 
 func (x *GenerateTokenResponse) GetPeeringToken() string {
@@ -1521,7 +1919,7 @@ type EstablishRequest struct {
 func (x *EstablishRequest) Reset() {
 	*x = EstablishRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[22]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[28]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1534,7 +1932,7 @@ func (x *EstablishRequest) String() string {
 func (*EstablishRequest) ProtoMessage() {}
 
 func (x *EstablishRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[22]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[28]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1547,7 +1945,7 @@ func (x *EstablishRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EstablishRequest.ProtoReflect.Descriptor instead.
 func (*EstablishRequest) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{22}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *EstablishRequest) GetPeerName() string {
@@ -1592,7 +1990,6 @@ func (x *EstablishRequest) GetMeta() map[string]string {
 	return nil
 }
 
-//
 // mog annotation:
 //
 // target=github.com/hashicorp/consul/api.PeeringEstablishResponse
@@ -1607,7 +2004,7 @@ type EstablishResponse struct {
 func (x *EstablishResponse) Reset() {
 	*x = EstablishResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[23]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[29]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1620,7 +2017,7 @@ func (x *EstablishResponse) String() string {
 func (*EstablishResponse) ProtoMessage() {}
 
 func (x *EstablishResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[23]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[29]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1633,7 +2030,7 @@ func (x *EstablishResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EstablishResponse.ProtoReflect.Descriptor instead.
 func (*EstablishResponse) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{23}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{29}
 }
 
 type ReplicationMessage struct {
@@ -1651,7 +2048,7 @@ type ReplicationMessage struct {
 func (x *ReplicationMessage) Reset() {
 	*x = ReplicationMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[24]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[30]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1664,7 +2061,7 @@ func (x *ReplicationMessage) String() string {
 func (*ReplicationMessage) ProtoMessage() {}
 
 func (x *ReplicationMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[24]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[30]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1677,7 +2074,7 @@ func (x *ReplicationMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReplicationMessage.ProtoReflect.Descriptor instead.
 func (*ReplicationMessage) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{24}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{30}
 }
 
 func (m *ReplicationMessage) GetPayload() isReplicationMessage_Payload {
@@ -1744,7 +2141,7 @@ type LeaderAddress struct {
 func (x *LeaderAddress) Reset() {
 	*x = LeaderAddress{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[25]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[31]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1757,7 +2154,7 @@ func (x *LeaderAddress) String() string {
 func (*LeaderAddress) ProtoMessage() {}
 
 func (x *LeaderAddress) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[25]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[31]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1770,7 +2167,7 @@ func (x *LeaderAddress) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LeaderAddress.ProtoReflect.Descriptor instead.
 func (*LeaderAddress) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{25}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *LeaderAddress) GetAddress() string {
@@ -1803,7 +2200,7 @@ type ReplicationMessage_Request struct {
 func (x *ReplicationMessage_Request) Reset() {
 	*x = ReplicationMessage_Request{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[30]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[36]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1816,7 +2213,7 @@ func (x *ReplicationMessage_Request) String() string {
 func (*ReplicationMessage_Request) ProtoMessage() {}
 
 func (x *ReplicationMessage_Request) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[30]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[36]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1829,7 +2226,7 @@ func (x *ReplicationMessage_Request) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReplicationMessage_Request.ProtoReflect.Descriptor instead.
 func (*ReplicationMessage_Request) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{24, 0}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{30, 0}
 }
 
 func (x *ReplicationMessage_Request) GetPeerID() string {
@@ -1882,7 +2279,7 @@ type ReplicationMessage_Response struct {
 func (x *ReplicationMessage_Response) Reset() {
 	*x = ReplicationMessage_Response{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[31]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[37]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1895,7 +2292,7 @@ func (x *ReplicationMessage_Response) String() string {
 func (*ReplicationMessage_Response) ProtoMessage() {}
 
 func (x *ReplicationMessage_Response) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[31]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[37]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1908,7 +2305,7 @@ func (x *ReplicationMessage_Response) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReplicationMessage_Response.ProtoReflect.Descriptor instead.
 func (*ReplicationMessage_Response) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{24, 1}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{30, 1}
 }
 
 func (x *ReplicationMessage_Response) GetNonce() string {
@@ -1957,7 +2354,7 @@ type ReplicationMessage_Terminated struct {
 func (x *ReplicationMessage_Terminated) Reset() {
 	*x = ReplicationMessage_Terminated{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_pbpeering_peering_proto_msgTypes[32]
+		mi := &file_proto_pbpeering_peering_proto_msgTypes[38]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1970,7 +2367,7 @@ func (x *ReplicationMessage_Terminated) String() string {
 func (*ReplicationMessage_Terminated) ProtoMessage() {}
 
 func (x *ReplicationMessage_Terminated) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_pbpeering_peering_proto_msgTypes[32]
+	mi := &file_proto_pbpeering_peering_proto_msgTypes[38]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1983,7 +2380,7 @@ func (x *ReplicationMessage_Terminated) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReplicationMessage_Terminated.ProtoReflect.Descriptor instead.
 func (*ReplicationMessage_Terminated) Descriptor() ([]byte, []int) {
-	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{24, 2}
+	return file_proto_pbpeering_peering_proto_rawDescGZIP(), []int{30, 2}
 }
 
 var File_proto_pbpeering_peering_proto protoreflect.FileDescriptor
@@ -2038,7 +2435,37 @@ var file_proto_pbpeering_peering_proto_rawDesc = []byte{
 	0x65, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x43, 0x72,
 	0x65, 0x61, 0x74, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x20, 0x0a, 0x0b, 0x4d, 0x6f, 0x64,
 	0x69, 0x66, 0x79, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b,
-	0x4d, 0x6f, 0x64, 0x69, 0x66, 0x79, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x22, 0x66, 0x0a, 0x12, 0x50,
+	0x4d, 0x6f, 0x64, 0x69, 0x66, 0x79, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x22, 0xb0, 0x02, 0x0a, 0x11,
+	0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x65, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x50, 0x65, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1c, 0x0a,
+	0x09, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x4e,
+	0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x53, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x44,
+	0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x0c, 0x44, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x12,
+	0x39, 0x0a, 0x07, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x1f, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x69,
+	0x6e, 0x67, 0x49, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x6f, 0x75, 0x72, 0x63,
+	0x65, 0x52, 0x07, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x43, 0x72,
+	0x65, 0x61, 0x74, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x0b, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x20, 0x0a, 0x0b,
+	0x4d, 0x6f, 0x64, 0x69, 0x66, 0x79, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x0b, 0x4d, 0x6f, 0x64, 0x69, 0x66, 0x79, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x22, 0xaa,
+	0x01, 0x0a, 0x16, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x74, 0x65, 0x6e, 0x74,
+	0x69, 0x6f, 0x6e, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1c, 0x0a,
+	0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x41,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x41, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x26, 0x0a, 0x0e, 0x48, 0x61, 0x73, 0x50, 0x65, 0x72, 0x6d, 0x69, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x48, 0x61, 0x73,
+	0x50, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x50,
+	0x72, 0x65, 0x63, 0x65, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x0a, 0x50, 0x72, 0x65, 0x63, 0x65, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x22, 0x66, 0x0a, 0x12, 0x50,
 	0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x61, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
 	0x74, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
 	0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69,
@@ -2138,151 +2565,172 @@ var file_proto_pbpeering_peering_proto_rawDesc = []byte{
 	0x01, 0x28, 0x09, 0x52, 0x0a, 0x44, 0x61, 0x74, 0x61, 0x63, 0x65, 0x6e, 0x74, 0x65, 0x72, 0x22,
 	0x22, 0x0a, 0x20, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x54, 0x72, 0x75, 0x73, 0x74, 0x42,
 	0x75, 0x6e, 0x64, 0x6c, 0x65, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x22, 0xfc, 0x01, 0x0a, 0x14, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65,
-	0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08,
-	0x50, 0x65, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
-	0x50, 0x65, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x50, 0x61, 0x72, 0x74,
-	0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x50, 0x61, 0x72,
-	0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x44, 0x61, 0x74, 0x61, 0x63, 0x65,
-	0x6e, 0x74, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x44, 0x61, 0x74, 0x61,
-	0x63, 0x65, 0x6e, 0x74, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18,
-	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x3b, 0x0a, 0x04,
-	0x4d, 0x65, 0x74, 0x61, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x27, 0x2e, 0x70, 0x65, 0x65,
-	0x72, 0x69, 0x6e, 0x67, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x54, 0x6f, 0x6b,
-	0x65, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x4d, 0x65, 0x74, 0x61, 0x45, 0x6e,
-	0x74, 0x72, 0x79, 0x52, 0x04, 0x4d, 0x65, 0x74, 0x61, 0x1a, 0x37, 0x0a, 0x09, 0x4d, 0x65, 0x74,
-	0x61, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
-	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02,
-	0x38, 0x01, 0x22, 0x3b, 0x0a, 0x15, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x54, 0x6f,
-	0x6b, 0x65, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x50,
-	0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x0c, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22,
-	0x98, 0x02, 0x0a, 0x10, 0x45, 0x73, 0x74, 0x61, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x65, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x50, 0x65, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65,
-	0x12, 0x22, 0x0a, 0x0c, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x54, 0x6f, 0x6b, 0x65, 0x6e,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x54,
-	0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f,
-	0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69,
-	0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x44, 0x61, 0x74, 0x61, 0x63, 0x65, 0x6e, 0x74, 0x65, 0x72,
-	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x44, 0x61, 0x74, 0x61, 0x63, 0x65, 0x6e, 0x74,
-	0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x37, 0x0a, 0x04, 0x4d, 0x65, 0x74, 0x61,
-	0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67,
-	0x2e, 0x45, 0x73, 0x74, 0x61, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x2e, 0x4d, 0x65, 0x74, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x04, 0x4d, 0x65, 0x74,
-	0x61, 0x1a, 0x37, 0x0a, 0x09, 0x4d, 0x65, 0x74, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10,
-	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79,
-	0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x13, 0x0a, 0x11, 0x45, 0x73,
-	0x74, 0x61, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
-	0x94, 0x05, 0x0a, 0x12, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d,
-	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x3f, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e,
-	0x67, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x65, 0x73,
-	0x73, 0x61, 0x67, 0x65, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x07,
-	0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x42, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x70, 0x65, 0x65, 0x72,
-	0x69, 0x6e, 0x67, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d,
-	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48,
-	0x00, 0x52, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x48, 0x0a, 0x0a, 0x74,
-	0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x26, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x54, 0x65, 0x72,
-	0x6d, 0x69, 0x6e, 0x61, 0x74, 0x65, 0x64, 0x48, 0x00, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69,
-	0x6e, 0x61, 0x74, 0x65, 0x64, 0x1a, 0x7f, 0x0a, 0x07, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x12, 0x16, 0x0a, 0x06, 0x50, 0x65, 0x65, 0x72, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x06, 0x50, 0x65, 0x65, 0x72, 0x49, 0x44, 0x12, 0x14, 0x0a, 0x05, 0x4e, 0x6f, 0x6e, 0x63,
-	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x4e, 0x6f, 0x6e, 0x63, 0x65, 0x12, 0x20,
-	0x0a, 0x0b, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x55, 0x52, 0x4c, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x0b, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x55, 0x52, 0x4c,
-	0x12, 0x24, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x0e, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52,
-	0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x1a, 0x94, 0x02, 0x0a, 0x08, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x4e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x05, 0x4e, 0x6f, 0x6e, 0x63, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x52, 0x65, 0x73,
-	0x6f, 0x75, 0x72, 0x63, 0x65, 0x55, 0x52, 0x4c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
-	0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x55, 0x52, 0x4c, 0x12, 0x1e, 0x0a, 0x0a, 0x52,
-	0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x49, 0x44, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x0a, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x49, 0x44, 0x12, 0x30, 0x0a, 0x08, 0x52,
-	0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e,
-	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
-	0x41, 0x6e, 0x79, 0x52, 0x08, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x4c, 0x0a,
-	0x09, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e,
-	0x32, 0x2e, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69,
-	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
-	0x52, 0x09, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x30, 0x0a, 0x09, 0x4f,
-	0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x6e, 0x6b, 0x6e,
-	0x6f, 0x77, 0x6e, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x55, 0x50, 0x53, 0x45, 0x52, 0x54, 0x10,
-	0x01, 0x12, 0x0a, 0x0a, 0x06, 0x44, 0x45, 0x4c, 0x45, 0x54, 0x45, 0x10, 0x02, 0x1a, 0x0c, 0x0a,
-	0x0a, 0x54, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x74, 0x65, 0x64, 0x42, 0x09, 0x0a, 0x07, 0x50,
-	0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x29, 0x0a, 0x0d, 0x4c, 0x65, 0x61, 0x64, 0x65, 0x72,
-	0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65,
-	0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73,
-	0x73, 0x2a, 0x53, 0x0a, 0x0c, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x53, 0x74, 0x61, 0x74,
-	0x65, 0x12, 0x0d, 0x0a, 0x09, 0x55, 0x4e, 0x44, 0x45, 0x46, 0x49, 0x4e, 0x45, 0x44, 0x10, 0x00,
-	0x12, 0x0b, 0x0a, 0x07, 0x49, 0x4e, 0x49, 0x54, 0x49, 0x41, 0x4c, 0x10, 0x01, 0x12, 0x0a, 0x0a,
-	0x06, 0x41, 0x43, 0x54, 0x49, 0x56, 0x45, 0x10, 0x02, 0x12, 0x0b, 0x0a, 0x07, 0x46, 0x41, 0x49,
-	0x4c, 0x49, 0x4e, 0x47, 0x10, 0x03, 0x12, 0x0e, 0x0a, 0x0a, 0x54, 0x45, 0x52, 0x4d, 0x49, 0x4e,
-	0x41, 0x54, 0x45, 0x44, 0x10, 0x04, 0x32, 0xed, 0x05, 0x0a, 0x0e, 0x50, 0x65, 0x65, 0x72, 0x69,
-	0x6e, 0x67, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x4e, 0x0a, 0x0d, 0x47, 0x65, 0x6e,
-	0x65, 0x72, 0x61, 0x74, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x1d, 0x2e, 0x70, 0x65, 0x65,
-	0x72, 0x69, 0x6e, 0x67, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x54, 0x6f, 0x6b,
-	0x65, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x70, 0x65, 0x65, 0x72,
-	0x69, 0x6e, 0x67, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x54, 0x6f, 0x6b, 0x65,
-	0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x42, 0x0a, 0x09, 0x45, 0x73, 0x74,
-	0x61, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x12, 0x19, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67,
-	0x2e, 0x45, 0x73, 0x74, 0x61, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x1a, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x45, 0x73, 0x74, 0x61,
-	0x62, 0x6c, 0x69, 0x73, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x48, 0x0a,
-	0x0b, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x61, 0x64, 0x12, 0x1b, 0x2e, 0x70,
-	0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x52, 0x65,
-	0x61, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x70, 0x65, 0x65, 0x72,
+	0x6e, 0x73, 0x65, 0x22, 0x69, 0x0a, 0x1d, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x49, 0x6e,
+	0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x57, 0x72, 0x69, 0x74, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x48, 0x0a, 0x11, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x49,
+	0x6e, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e,
+	0x67, 0x49, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x11, 0x50, 0x65, 0x65,
+	0x72, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x20,
+	0x0a, 0x1e, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x57, 0x72, 0x69, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x22, 0x9a, 0x01, 0x0a, 0x1e, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x74, 0x65,
+	0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x65, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x50, 0x65, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x1c, 0x0a, 0x09, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a,
+	0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x53,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0b, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x21, 0x0a,
+	0x1f, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x22, 0xfc, 0x01, 0x0a, 0x14, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x54, 0x6f, 0x6b,
+	0x65, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x65, 0x65,
+	0x72, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x50, 0x65, 0x65,
+	0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x44, 0x61, 0x74, 0x61, 0x63, 0x65, 0x6e, 0x74, 0x65,
+	0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x44, 0x61, 0x74, 0x61, 0x63, 0x65, 0x6e,
+	0x74, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x3b, 0x0a, 0x04, 0x4d, 0x65, 0x74,
+	0x61, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x27, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e,
+	0x67, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x4d, 0x65, 0x74, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x52, 0x04, 0x4d, 0x65, 0x74, 0x61, 0x1a, 0x37, 0x0a, 0x09, 0x4d, 0x65, 0x74, 0x61, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22,
+	0x3b, 0x0a, 0x15, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x50, 0x65, 0x65, 0x72,
+	0x69, 0x6e, 0x67, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x98, 0x02, 0x0a,
+	0x10, 0x45, 0x73, 0x74, 0x61, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x65, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x50, 0x65, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x22, 0x0a,
+	0x0c, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0c, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x54, 0x6f, 0x6b, 0x65,
+	0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x1e, 0x0a, 0x0a, 0x44, 0x61, 0x74, 0x61, 0x63, 0x65, 0x6e, 0x74, 0x65, 0x72, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0a, 0x44, 0x61, 0x74, 0x61, 0x63, 0x65, 0x6e, 0x74, 0x65, 0x72, 0x12,
+	0x14, 0x0a, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x37, 0x0a, 0x04, 0x4d, 0x65, 0x74, 0x61, 0x18, 0x06, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x45, 0x73,
+	0x74, 0x61, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x4d,
+	0x65, 0x74, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x04, 0x4d, 0x65, 0x74, 0x61, 0x1a, 0x37,
+	0x0a, 0x09, 0x4d, 0x65, 0x74, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x13, 0x0a, 0x11, 0x45, 0x73, 0x74, 0x61, 0x62,
+	0x6c, 0x69, 0x73, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x94, 0x05, 0x0a,
+	0x12, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x12, 0x3f, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x52,
+	0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x07, 0x72, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x42, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67,
+	0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x08,
+	0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x48, 0x0a, 0x0a, 0x74, 0x65, 0x72, 0x6d,
+	0x69, 0x6e, 0x61, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x70,
+	0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x54, 0x65, 0x72, 0x6d, 0x69, 0x6e,
+	0x61, 0x74, 0x65, 0x64, 0x48, 0x00, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x74,
+	0x65, 0x64, 0x1a, 0x7f, 0x0a, 0x07, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a,
+	0x06, 0x50, 0x65, 0x65, 0x72, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x50,
+	0x65, 0x65, 0x72, 0x49, 0x44, 0x12, 0x14, 0x0a, 0x05, 0x4e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x4e, 0x6f, 0x6e, 0x63, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x52,
+	0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x55, 0x52, 0x4c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0b, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x55, 0x52, 0x4c, 0x12, 0x24, 0x0a,
+	0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x73,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x05, 0x45, 0x72,
+	0x72, 0x6f, 0x72, 0x1a, 0x94, 0x02, 0x0a, 0x08, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x14, 0x0a, 0x05, 0x4e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x4e, 0x6f, 0x6e, 0x63, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72,
+	0x63, 0x65, 0x55, 0x52, 0x4c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x52, 0x65, 0x73,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x55, 0x52, 0x4c, 0x12, 0x1e, 0x0a, 0x0a, 0x52, 0x65, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x49, 0x44, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x52, 0x65,
+	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x49, 0x44, 0x12, 0x30, 0x0a, 0x08, 0x52, 0x65, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x41, 0x6e, 0x79,
+	0x52, 0x08, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x4c, 0x0a, 0x09, 0x6f, 0x70,
+	0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x2e, 0x2e,
+	0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x2e, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x09, 0x6f,
+	0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x30, 0x0a, 0x09, 0x4f, 0x70, 0x65, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x6e, 0x6b, 0x6e, 0x6f, 0x77, 0x6e,
+	0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x55, 0x50, 0x53, 0x45, 0x52, 0x54, 0x10, 0x01, 0x12, 0x0a,
+	0x0a, 0x06, 0x44, 0x45, 0x4c, 0x45, 0x54, 0x45, 0x10, 0x02, 0x1a, 0x0c, 0x0a, 0x0a, 0x54, 0x65,
+	0x72, 0x6d, 0x69, 0x6e, 0x61, 0x74, 0x65, 0x64, 0x42, 0x09, 0x0a, 0x07, 0x50, 0x61, 0x79, 0x6c,
+	0x6f, 0x61, 0x64, 0x22, 0x29, 0x0a, 0x0d, 0x4c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x41, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x2a, 0x53,
+	0x0a, 0x0c, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x0d,
+	0x0a, 0x09, 0x55, 0x4e, 0x44, 0x45, 0x46, 0x49, 0x4e, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0b, 0x0a,
+	0x07, 0x49, 0x4e, 0x49, 0x54, 0x49, 0x41, 0x4c, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x41, 0x43,
+	0x54, 0x49, 0x56, 0x45, 0x10, 0x02, 0x12, 0x0b, 0x0a, 0x07, 0x46, 0x41, 0x49, 0x4c, 0x49, 0x4e,
+	0x47, 0x10, 0x03, 0x12, 0x0e, 0x0a, 0x0a, 0x54, 0x45, 0x52, 0x4d, 0x49, 0x4e, 0x41, 0x54, 0x45,
+	0x44, 0x10, 0x04, 0x32, 0xed, 0x05, 0x0a, 0x0e, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x53,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x4e, 0x0a, 0x0d, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61,
+	0x74, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x1d, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e,
+	0x67, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67,
+	0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x42, 0x0a, 0x09, 0x45, 0x73, 0x74, 0x61, 0x62, 0x6c,
+	0x69, 0x73, 0x68, 0x12, 0x19, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x45, 0x73,
+	0x74, 0x61, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a,
+	0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x45, 0x73, 0x74, 0x61, 0x62, 0x6c, 0x69,
+	0x73, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x48, 0x0a, 0x0b, 0x50, 0x65,
+	0x65, 0x72, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x61, 0x64, 0x12, 0x1b, 0x2e, 0x70, 0x65, 0x65, 0x72,
 	0x69, 0x6e, 0x67, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x61, 0x64, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x48, 0x0a, 0x0b, 0x50, 0x65, 0x65, 0x72, 0x69,
-	0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x1b, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67,
-	0x2e, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x50, 0x65,
-	0x65, 0x72, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x4e, 0x0a, 0x0d, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x44, 0x65, 0x6c, 0x65,
-	0x74, 0x65, 0x12, 0x1d, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x50, 0x65, 0x65,
-	0x72, 0x69, 0x6e, 0x67, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x1e, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x50, 0x65, 0x65, 0x72,
-	0x69, 0x6e, 0x67, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x4b, 0x0a, 0x0c, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x57, 0x72, 0x69, 0x74,
-	0x65, 0x12, 0x1c, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x50, 0x65, 0x65, 0x72,
-	0x69, 0x6e, 0x67, 0x57, 0x72, 0x69, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67,
+	0x2e, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x61, 0x64, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x48, 0x0a, 0x0b, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x4c,
+	0x69, 0x73, 0x74, 0x12, 0x1b, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x50, 0x65,
+	0x65, 0x72, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x1c, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x69,
+	0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4e,
+	0x0a, 0x0d, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12,
 	0x1d, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e,
-	0x67, 0x57, 0x72, 0x69, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x6f,
-	0x0a, 0x18, 0x54, 0x72, 0x75, 0x73, 0x74, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x4c, 0x69, 0x73,
-	0x74, 0x42, 0x79, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x28, 0x2e, 0x70, 0x65, 0x65,
-	0x72, 0x69, 0x6e, 0x67, 0x2e, 0x54, 0x72, 0x75, 0x73, 0x74, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65,
-	0x4c, 0x69, 0x73, 0x74, 0x42, 0x79, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x1a, 0x29, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x54,
+	0x67, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e,
+	0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67,
+	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4b,
+	0x0a, 0x0c, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x57, 0x72, 0x69, 0x74, 0x65, 0x12, 0x1c,
+	0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67,
+	0x57, 0x72, 0x69, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x70,
+	0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x57, 0x72,
+	0x69, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x6f, 0x0a, 0x18, 0x54,
 	0x72, 0x75, 0x73, 0x74, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x79,
-	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
-	0x54, 0x0a, 0x0f, 0x54, 0x72, 0x75, 0x73, 0x74, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x52, 0x65,
-	0x61, 0x64, 0x12, 0x1f, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x54, 0x72, 0x75,
-	0x73, 0x74, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x52, 0x65, 0x61, 0x64, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x54, 0x72,
-	0x75, 0x73, 0x74, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x52, 0x65, 0x61, 0x64, 0x52, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4f, 0x0a, 0x0f, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52,
-	0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x73, 0x12, 0x1b, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69,
-	0x6e, 0x67, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x65,
-	0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x1b, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x28, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e,
+	0x67, 0x2e, 0x54, 0x72, 0x75, 0x73, 0x74, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x4c, 0x69, 0x73,
+	0x74, 0x42, 0x79, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x29, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x54, 0x72, 0x75, 0x73,
+	0x74, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x79, 0x53, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x54, 0x0a, 0x0f,
+	0x54, 0x72, 0x75, 0x73, 0x74, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x52, 0x65, 0x61, 0x64, 0x12,
+	0x1f, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x54, 0x72, 0x75, 0x73, 0x74, 0x42,
+	0x75, 0x6e, 0x64, 0x6c, 0x65, 0x52, 0x65, 0x61, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x20, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x54, 0x72, 0x75, 0x73, 0x74,
+	0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x52, 0x65, 0x61, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x4f, 0x0a, 0x0f, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x73, 0x12, 0x1b, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e,
 	0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x65, 0x73, 0x73, 0x61,
-	0x67, 0x65, 0x28, 0x01, 0x30, 0x01, 0x42, 0x84, 0x01, 0x0a, 0x0b, 0x63, 0x6f, 0x6d, 0x2e, 0x70,
-	0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x42, 0x0c, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x50,
-	0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x2b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
-	0x6f, 0x6d, 0x2f, 0x68, 0x61, 0x73, 0x68, 0x69, 0x63, 0x6f, 0x72, 0x70, 0x2f, 0x63, 0x6f, 0x6e,
-	0x73, 0x75, 0x6c, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x70, 0x62, 0x70, 0x65, 0x65, 0x72,
-	0x69, 0x6e, 0x67, 0xa2, 0x02, 0x03, 0x50, 0x58, 0x58, 0xaa, 0x02, 0x07, 0x50, 0x65, 0x65, 0x72,
-	0x69, 0x6e, 0x67, 0xca, 0x02, 0x07, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0xe2, 0x02, 0x13,
-	0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x5c, 0x47, 0x50, 0x42, 0x4d, 0x65, 0x74, 0x61, 0x64,
-	0x61, 0x74, 0x61, 0xea, 0x02, 0x07, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x62, 0x06, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x67, 0x65, 0x1a, 0x1b, 0x2e, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x2e, 0x52, 0x65, 0x70,
+	0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x28,
+	0x01, 0x30, 0x01, 0x42, 0x84, 0x01, 0x0a, 0x0b, 0x63, 0x6f, 0x6d, 0x2e, 0x70, 0x65, 0x65, 0x72,
+	0x69, 0x6e, 0x67, 0x42, 0x0c, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x50, 0x72, 0x6f, 0x74,
+	0x6f, 0x50, 0x01, 0x5a, 0x2b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x68, 0x61, 0x73, 0x68, 0x69, 0x63, 0x6f, 0x72, 0x70, 0x2f, 0x63, 0x6f, 0x6e, 0x73, 0x75, 0x6c,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x70, 0x62, 0x70, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67,
+	0xa2, 0x02, 0x03, 0x50, 0x58, 0x58, 0xaa, 0x02, 0x07, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67,
+	0xca, 0x02, 0x07, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0xe2, 0x02, 0x13, 0x50, 0x65, 0x65,
+	0x72, 0x69, 0x6e, 0x67, 0x5c, 0x47, 0x50, 0x42, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0xea, 0x02, 0x07, 0x50, 0x65, 0x65, 0x72, 0x69, 0x6e, 0x67, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
 }
 
 var (
@@ -2298,87 +2746,95 @@ func file_proto_pbpeering_peering_proto_rawDescGZIP() []byte {
 }
 
 var file_proto_pbpeering_peering_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_proto_pbpeering_peering_proto_msgTypes = make([]protoimpl.MessageInfo, 33)
+var file_proto_pbpeering_peering_proto_msgTypes = make([]protoimpl.MessageInfo, 39)
 var file_proto_pbpeering_peering_proto_goTypes = []interface{}{
 	(PeeringState)(0),                          // 0: peering.PeeringState
 	(ReplicationMessage_Response_Operation)(0), // 1: peering.ReplicationMessage.Response.Operation
 	(*Peering)(nil),                            // 2: peering.Peering
 	(*PeeringTrustBundle)(nil),                 // 3: peering.PeeringTrustBundle
-	(*PeeringReadRequest)(nil),                 // 4: peering.PeeringReadRequest
-	(*PeeringReadResponse)(nil),                // 5: peering.PeeringReadResponse
-	(*PeeringListRequest)(nil),                 // 6: peering.PeeringListRequest
-	(*PeeringListResponse)(nil),                // 7: peering.PeeringListResponse
-	(*PeeringWriteRequest)(nil),                // 8: peering.PeeringWriteRequest
-	(*PeeringWriteResponse)(nil),               // 9: peering.PeeringWriteResponse
-	(*PeeringDeleteRequest)(nil),               // 10: peering.PeeringDeleteRequest
-	(*PeeringDeleteResponse)(nil),              // 11: peering.PeeringDeleteResponse
-	(*TrustBundleListByServiceRequest)(nil),    // 12: peering.TrustBundleListByServiceRequest
-	(*TrustBundleListByServiceResponse)(nil),   // 13: peering.TrustBundleListByServiceResponse
-	(*TrustBundleReadRequest)(nil),             // 14: peering.TrustBundleReadRequest
-	(*TrustBundleReadResponse)(nil),            // 15: peering.TrustBundleReadResponse
-	(*PeeringTerminateByIDRequest)(nil),        // 16: peering.PeeringTerminateByIDRequest
-	(*PeeringTerminateByIDResponse)(nil),       // 17: peering.PeeringTerminateByIDResponse
-	(*PeeringTrustBundleWriteRequest)(nil),     // 18: peering.PeeringTrustBundleWriteRequest
-	(*PeeringTrustBundleWriteResponse)(nil),    // 19: peering.PeeringTrustBundleWriteResponse
-	(*PeeringTrustBundleDeleteRequest)(nil),    // 20: peering.PeeringTrustBundleDeleteRequest
-	(*PeeringTrustBundleDeleteResponse)(nil),   // 21: peering.PeeringTrustBundleDeleteResponse
-	(*GenerateTokenRequest)(nil),               // 22: peering.GenerateTokenRequest
-	(*GenerateTokenResponse)(nil),              // 23: peering.GenerateTokenResponse
-	(*EstablishRequest)(nil),                   // 24: peering.EstablishRequest
-	(*EstablishResponse)(nil),                  // 25: peering.EstablishResponse
-	(*ReplicationMessage)(nil),                 // 26: peering.ReplicationMessage
-	(*LeaderAddress)(nil),                      // 27: peering.LeaderAddress
-	nil,                                        // 28: peering.Peering.MetaEntry
-	nil,                                        // 29: peering.PeeringWriteRequest.MetaEntry
-	nil,                                        // 30: peering.GenerateTokenRequest.MetaEntry
-	nil,                                        // 31: peering.EstablishRequest.MetaEntry
-	(*ReplicationMessage_Request)(nil),         // 32: peering.ReplicationMessage.Request
-	(*ReplicationMessage_Response)(nil),        // 33: peering.ReplicationMessage.Response
-	(*ReplicationMessage_Terminated)(nil),      // 34: peering.ReplicationMessage.Terminated
-	(*pbstatus.Status)(nil),                    // 35: status.Status
-	(*anypb.Any)(nil),                          // 36: google.protobuf.Any
+	(*PeeringIntentions)(nil),                  // 4: peering.PeeringIntentions
+	(*PeeringIntentionSource)(nil),             // 5: peering.PeeringIntentionSource
+	(*PeeringReadRequest)(nil),                 // 6: peering.PeeringReadRequest
+	(*PeeringReadResponse)(nil),                // 7: peering.PeeringReadResponse
+	(*PeeringListRequest)(nil),                 // 8: peering.PeeringListRequest
+	(*PeeringListResponse)(nil),                // 9: peering.PeeringListResponse
+	(*PeeringWriteRequest)(nil),                // 10: peering.PeeringWriteRequest
+	(*PeeringWriteResponse)(nil),               // 11: peering.PeeringWriteResponse
+	(*PeeringDeleteRequest)(nil),               // 12: peering.PeeringDeleteRequest
+	(*PeeringDeleteResponse)(nil),              // 13: peering.PeeringDeleteResponse
+	(*TrustBundleListByServiceRequest)(nil),    // 14: peering.TrustBundleListByServiceRequest
+	(*TrustBundleListByServiceResponse)(nil),   // 15: peering.TrustBundleListByServiceResponse
+	(*TrustBundleReadRequest)(nil),             // 16: peering.TrustBundleReadRequest
+	(*TrustBundleReadResponse)(nil),            // 17: peering.TrustBundleReadResponse
+	(*PeeringTerminateByIDRequest)(nil),        // 18: peering.PeeringTerminateByIDRequest
+	(*PeeringTerminateByIDResponse)(nil),       // 19: peering.PeeringTerminateByIDResponse
+	(*PeeringTrustBundleWriteRequest)(nil),     // 20: peering.PeeringTrustBundleWriteRequest
+	(*PeeringTrustBundleWriteResponse)(nil),    // 21: peering.PeeringTrustBundleWriteResponse
+	(*PeeringTrustBundleDeleteRequest)(nil),    // 22: peering.PeeringTrustBundleDeleteRequest
+	(*PeeringTrustBundleDeleteResponse)(nil),   // 23: peering.PeeringTrustBundleDeleteResponse
+	(*PeeringIntentionsWriteRequest)(nil),      // 24: peering.PeeringIntentionsWriteRequest
+	(*PeeringIntentionsWriteResponse)(nil),     // 25: peering.PeeringIntentionsWriteResponse
+	(*PeeringIntentionsDeleteRequest)(nil),     // 26: peering.PeeringIntentionsDeleteRequest
+	(*PeeringIntentionsDeleteResponse)(nil),    // 27: peering.PeeringIntentionsDeleteResponse
+	(*GenerateTokenRequest)(nil),               // 28: peering.GenerateTokenRequest
+	(*GenerateTokenResponse)(nil),              // 29: peering.GenerateTokenResponse
+	(*EstablishRequest)(nil),                   // 30: peering.EstablishRequest
+	(*EstablishResponse)(nil),                  // 31: peering.EstablishResponse
+	(*ReplicationMessage)(nil),                 // 32: peering.ReplicationMessage
+	(*LeaderAddress)(nil),                      // 33: peering.LeaderAddress
+	nil,                                        // 34: peering.Peering.MetaEntry
+	nil,                                        // 35: peering.PeeringWriteRequest.MetaEntry
+	nil,                                        // 36: peering.GenerateTokenRequest.MetaEntry
+	nil,                                        // 37: peering.EstablishRequest.MetaEntry
+	(*ReplicationMessage_Request)(nil),         // 38: peering.ReplicationMessage.Request
+	(*ReplicationMessage_Response)(nil),        // 39: peering.ReplicationMessage.Response
+	(*ReplicationMessage_Terminated)(nil),      // 40: peering.ReplicationMessage.Terminated
+	(*pbstatus.Status)(nil),                    // 41: status.Status
+	(*anypb.Any)(nil),                          // 42: google.protobuf.Any
 }
 var file_proto_pbpeering_peering_proto_depIdxs = []int32{
-	28, // 0: peering.Peering.Meta:type_name -> peering.Peering.MetaEntry
+	34, // 0: peering.Peering.Meta:type_name -> peering.Peering.MetaEntry
 	0,  // 1: peering.Peering.State:type_name -> peering.PeeringState
-	2,  // 2: peering.PeeringReadResponse.Peering:type_name -> peering.Peering
-	2,  // 3: peering.PeeringListResponse.Peerings:type_name -> peering.Peering
-	2,  // 4: peering.PeeringWriteRequest.Peering:type_name -> peering.Peering
-	29, // 5: peering.PeeringWriteRequest.Meta:type_name -> peering.PeeringWriteRequest.MetaEntry
-	3,  // 6: peering.TrustBundleListByServiceResponse.Bundles:type_name -> peering.PeeringTrustBundle
-	3,  // 7: peering.TrustBundleReadResponse.Bundle:type_name -> peering.PeeringTrustBundle
-	3,  // 8: peering.PeeringTrustBundleWriteRequest.PeeringTrustBundle:type_name -> peering.PeeringTrustBundle
-	30, // 9: peering.GenerateTokenRequest.Meta:type_name -> peering.GenerateTokenRequest.MetaEntry
-	31, // 10: peering.EstablishRequest.Meta:type_name -> peering.EstablishRequest.MetaEntry
-	32, // 11: peering.ReplicationMessage.request:type_name -> peering.ReplicationMessage.Request
-	33, // 12: peering.ReplicationMessage.response:type_name -> peering.ReplicationMessage.Response
-	34, // 13: peering.ReplicationMessage.terminated:type_name -> peering.ReplicationMessage.Terminated
-	35, // 14: peering.ReplicationMessage.Request.Error:type_name -> status.Status
-	36, // 15: peering.ReplicationMessage.Response.Resource:type_name -> google.protobuf.Any
-	1,  // 16: peering.ReplicationMessage.Response.operation:type_name -> peering.ReplicationMessage.Response.Operation
-	22, // 17: peering.PeeringService.GenerateToken:input_type -> peering.GenerateTokenRequest
-	24, // 18: peering.PeeringService.Establish:input_type -> peering.EstablishRequest
-	4,  // 19: peering.PeeringService.PeeringRead:input_type -> peering.PeeringReadRequest
-	6,  // 20: peering.PeeringService.PeeringList:input_type -> peering.PeeringListRequest
-	10, // 21: peering.PeeringService.PeeringDelete:input_type -> peering.PeeringDeleteRequest
-	8,  // 22: peering.PeeringService.PeeringWrite:input_type -> peering.PeeringWriteRequest
-	12, // 23: peering.PeeringService.TrustBundleListByService:input_type -> peering.TrustBundleListByServiceRequest
-	14, // 24: peering.PeeringService.TrustBundleRead:input_type -> peering.TrustBundleReadRequest
-	26, // 25: peering.PeeringService.StreamResources:input_type -> peering.ReplicationMessage
-	23, // 26: peering.PeeringService.GenerateToken:output_type -> peering.GenerateTokenResponse
-	25, // 27: peering.PeeringService.Establish:output_type -> peering.EstablishResponse
-	5,  // 28: peering.PeeringService.PeeringRead:output_type -> peering.PeeringReadResponse
-	7,  // 29: peering.PeeringService.PeeringList:output_type -> peering.PeeringListResponse
-	11, // 30: peering.PeeringService.PeeringDelete:output_type -> peering.PeeringDeleteResponse
-	9,  // 31: peering.PeeringService.PeeringWrite:output_type -> peering.PeeringWriteResponse
-	13, // 32: peering.PeeringService.TrustBundleListByService:output_type -> peering.TrustBundleListByServiceResponse
-	15, // 33: peering.PeeringService.TrustBundleRead:output_type -> peering.TrustBundleReadResponse
-	26, // 34: peering.PeeringService.StreamResources:output_type -> peering.ReplicationMessage
-	26, // [26:35] is the sub-list for method output_type
-	17, // [17:26] is the sub-list for method input_type
-	17, // [17:17] is the sub-list for extension type_name
-	17, // [17:17] is the sub-list for extension extendee
-	0,  // [0:17] is the sub-list for field type_name
+	5,  // 2: peering.PeeringIntentions.Sources:type_name -> peering.PeeringIntentionSource
+	2,  // 3: peering.PeeringReadResponse.Peering:type_name -> peering.Peering
+	2,  // 4: peering.PeeringListResponse.Peerings:type_name -> peering.Peering
+	2,  // 5: peering.PeeringWriteRequest.Peering:type_name -> peering.Peering
+	35, // 6: peering.PeeringWriteRequest.Meta:type_name -> peering.PeeringWriteRequest.MetaEntry
+	3,  // 7: peering.TrustBundleListByServiceResponse.Bundles:type_name -> peering.PeeringTrustBundle
+	3,  // 8: peering.TrustBundleReadResponse.Bundle:type_name -> peering.PeeringTrustBundle
+	3,  // 9: peering.PeeringTrustBundleWriteRequest.PeeringTrustBundle:type_name -> peering.PeeringTrustBundle
+	4,  // 10: peering.PeeringIntentionsWriteRequest.PeeringIntentions:type_name -> peering.PeeringIntentions
+	36, // 11: peering.GenerateTokenRequest.Meta:type_name -> peering.GenerateTokenRequest.MetaEntry
+	37, // 12: peering.EstablishRequest.Meta:type_name -> peering.EstablishRequest.MetaEntry
+	38, // 13: peering.ReplicationMessage.request:type_name -> peering.ReplicationMessage.Request
+	39, // 14: peering.ReplicationMessage.response:type_name -> peering.ReplicationMessage.Response
+	40, // 15: peering.ReplicationMessage.terminated:type_name -> peering.ReplicationMessage.Terminated
+	41, // 16: peering.ReplicationMessage.Request.Error:type_name -> status.Status
+	42, // 17: peering.ReplicationMessage.Response.Resource:type_name -> google.protobuf.Any
+	1,  // 18: peering.ReplicationMessage.Response.operation:type_name -> peering.ReplicationMessage.Response.Operation
+	28, // 19: peering.PeeringService.GenerateToken:input_type -> peering.GenerateTokenRequest
+	30, // 20: peering.PeeringService.Establish:input_type -> peering.EstablishRequest
+	6,  // 21: peering.PeeringService.PeeringRead:input_type -> peering.PeeringReadRequest
+	8,  // 22: peering.PeeringService.PeeringList:input_type -> peering.PeeringListRequest
+	12, // 23: peering.PeeringService.PeeringDelete:input_type -> peering.PeeringDeleteRequest
+	10, // 24: peering.PeeringService.PeeringWrite:input_type -> peering.PeeringWriteRequest
+	14, // 25: peering.PeeringService.TrustBundleListByService:input_type -> peering.TrustBundleListByServiceRequest
+	16, // 26: peering.PeeringService.TrustBundleRead:input_type -> peering.TrustBundleReadRequest
+	32, // 27: peering.PeeringService.StreamResources:input_type -> peering.ReplicationMessage
+	29, // 28: peering.PeeringService.GenerateToken:output_type -> peering.GenerateTokenResponse
+	31, // 29: peering.PeeringService.Establish:output_type -> peering.EstablishResponse
+	7,  // 30: peering.PeeringService.PeeringRead:output_type -> peering.PeeringReadResponse
+	9,  // 31: peering.PeeringService.PeeringList:output_type -> peering.PeeringListResponse
+	13, // 32: peering.PeeringService.PeeringDelete:output_type -> peering.PeeringDeleteResponse
+	11, // 33: peering.PeeringService.PeeringWrite:output_type -> peering.PeeringWriteResponse
+	15, // 34: peering.PeeringService.TrustBundleListByService:output_type -> peering.TrustBundleListByServiceResponse
+	17, // 35: peering.PeeringService.TrustBundleRead:output_type -> peering.TrustBundleReadResponse
+	32, // 36: peering.PeeringService.StreamResources:output_type -> peering.ReplicationMessage
+	28, // [28:37] is the sub-list for method output_type
+	19, // [19:28] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
 }
 
 func init() { file_proto_pbpeering_peering_proto_init() }
@@ -2412,7 +2868,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PeeringReadRequest); i {
+			switch v := v.(*PeeringIntentions); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2424,7 +2880,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PeeringReadResponse); i {
+			switch v := v.(*PeeringIntentionSource); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2436,7 +2892,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PeeringListRequest); i {
+			switch v := v.(*PeeringReadRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2448,7 +2904,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PeeringListResponse); i {
+			switch v := v.(*PeeringReadResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2460,7 +2916,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PeeringWriteRequest); i {
+			switch v := v.(*PeeringListRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2472,7 +2928,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PeeringWriteResponse); i {
+			switch v := v.(*PeeringListResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2484,7 +2940,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PeeringDeleteRequest); i {
+			switch v := v.(*PeeringWriteRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2496,7 +2952,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PeeringDeleteResponse); i {
+			switch v := v.(*PeeringWriteResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2508,7 +2964,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TrustBundleListByServiceRequest); i {
+			switch v := v.(*PeeringDeleteRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2520,7 +2976,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TrustBundleListByServiceResponse); i {
+			switch v := v.(*PeeringDeleteResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2532,7 +2988,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TrustBundleReadRequest); i {
+			switch v := v.(*TrustBundleListByServiceRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2544,7 +3000,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TrustBundleReadResponse); i {
+			switch v := v.(*TrustBundleListByServiceResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2556,7 +3012,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PeeringTerminateByIDRequest); i {
+			switch v := v.(*TrustBundleReadRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2568,7 +3024,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PeeringTerminateByIDResponse); i {
+			switch v := v.(*TrustBundleReadResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2580,7 +3036,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PeeringTrustBundleWriteRequest); i {
+			switch v := v.(*PeeringTerminateByIDRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2592,7 +3048,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PeeringTrustBundleWriteResponse); i {
+			switch v := v.(*PeeringTerminateByIDResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2604,7 +3060,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PeeringTrustBundleDeleteRequest); i {
+			switch v := v.(*PeeringTrustBundleWriteRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2616,7 +3072,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PeeringTrustBundleDeleteResponse); i {
+			switch v := v.(*PeeringTrustBundleWriteResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2628,7 +3084,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GenerateTokenRequest); i {
+			switch v := v.(*PeeringTrustBundleDeleteRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2640,7 +3096,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GenerateTokenResponse); i {
+			switch v := v.(*PeeringTrustBundleDeleteResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2652,7 +3108,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*EstablishRequest); i {
+			switch v := v.(*PeeringIntentionsWriteRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2664,7 +3120,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*EstablishResponse); i {
+			switch v := v.(*PeeringIntentionsWriteResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2676,7 +3132,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ReplicationMessage); i {
+			switch v := v.(*PeeringIntentionsDeleteRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2688,7 +3144,55 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*LeaderAddress); i {
+			switch v := v.(*PeeringIntentionsDeleteResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_pbpeering_peering_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GenerateTokenRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_pbpeering_peering_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GenerateTokenResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_pbpeering_peering_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EstablishRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_pbpeering_peering_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EstablishResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2700,7 +3204,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ReplicationMessage_Request); i {
+			switch v := v.(*ReplicationMessage); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2712,6 +3216,30 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 		file_proto_pbpeering_peering_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LeaderAddress); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_pbpeering_peering_proto_msgTypes[36].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReplicationMessage_Request); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_pbpeering_peering_proto_msgTypes[37].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ReplicationMessage_Response); i {
 			case 0:
 				return &v.state
@@ -2723,7 +3251,7 @@ func file_proto_pbpeering_peering_proto_init() {
 				return nil
 			}
 		}
-		file_proto_pbpeering_peering_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
+		file_proto_pbpeering_peering_proto_msgTypes[38].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ReplicationMessage_Terminated); i {
 			case 0:
 				return &v.state
@@ -2736,7 +3264,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			}
 		}
 	}
-	file_proto_pbpeering_peering_proto_msgTypes[24].OneofWrappers = []interface{}{
+	file_proto_pbpeering_peering_proto_msgTypes[30].OneofWrappers = []interface{}{
 		(*ReplicationMessage_Request_)(nil),
 		(*ReplicationMessage_Response_)(nil),
 		(*ReplicationMessage_Terminated_)(nil),
@@ -2747,7 +3275,7 @@ func file_proto_pbpeering_peering_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_proto_pbpeering_peering_proto_rawDesc,
 			NumEnums:      2,
-			NumMessages:   33,
+			NumMessages:   39,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
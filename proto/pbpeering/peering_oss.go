@@ -14,3 +14,7 @@ func (p *Peering) PartitionOrDefault() string {
 func (ptb *PeeringTrustBundle) PartitionOrDefault() string {
 	return ""
 }
+
+func (pi *PeeringIntentions) PartitionOrDefault() string {
+	return ""
+}
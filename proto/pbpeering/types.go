@@ -1,10 +1,11 @@
 package pbpeering
 
 const (
-	TypeURLService = "type.googleapis.com/consul.api.Service"
-	TypeURLRoots   = "type.googleapis.com/consul.api.CARoots"
+	TypeURLService    = "type.googleapis.com/consul.api.Service"
+	TypeURLRoots      = "type.googleapis.com/consul.api.CARoots"
+	TypeURLIntentions = "type.googleapis.com/consul.api.Intentions"
 )
 
 func KnownTypeURL(s string) bool {
-	return s == TypeURLService || s == TypeURLRoots
+	return s == TypeURLService || s == TypeURLRoots || s == TypeURLIntentions
 }
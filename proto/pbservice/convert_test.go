@@ -16,7 +16,8 @@ import (
 
 func TestNewCheckServiceNodeFromStructs_RoundTrip(t *testing.T) {
 	repeat(t, func(t *testing.T, fuzzer *fuzz.Fuzzer) {
-		fuzzer.Funcs(randInt32, randUint32, randInterface, randStructsUpstream, randEnterpriseMeta)
+		fuzzer.Funcs(randInt32, randUint32, randInterface, randStructsUpstream, randEnterpriseMeta,
+			randStructsCheckServiceNode)
 		var target structs.CheckServiceNode
 		fuzzer.Fuzz(&target)
 
@@ -87,6 +88,20 @@ func randStructsUpstream(u *structs.Upstream, c fuzz.Continue) {
 	}
 }
 
+// randStructsCheckServiceNode is a custom fuzzer function which skips
+// generating values for the fields derived at query time, which are not
+// converted.
+func randStructsCheckServiceNode(csn *structs.CheckServiceNode, c fuzz.Continue) {
+	v := reflect.ValueOf(csn).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		switch v.Type().Field(i).Name {
+		case "ProxyStatus":
+			continue
+		}
+		c.Fuzz(v.Field(i).Addr().Interface())
+	}
+}
+
 // randInterface is a custom fuzzer function which generates random data for
 // interface{} (most likely used in a map[string]interface{}).
 // The random data does not contain any ints (or float32) because protobuf
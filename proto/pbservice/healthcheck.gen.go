@@ -40,6 +40,11 @@ func CheckTypeToStructs(s *CheckType, t *structs.CheckType) {
 	t.ProxyGRPC = s.ProxyGRPC
 	t.DeregisterCriticalServiceAfter = structs.DurationFromProto(s.DeregisterCriticalServiceAfter)
 	t.OutputMaxSize = int(s.OutputMaxSize)
+	t.AliasPropagationDelay = structs.DurationFromProto(s.AliasPropagationDelay)
+	t.TTLExpiryDeregisterAfter = int(s.TTLExpiryDeregisterAfter)
+	t.TTLExpiryWebhook = s.TTLExpiryWebhook
+	t.TTLExpiryMaintenance = s.TTLExpiryMaintenance
+	t.Templated = s.Templated
 }
 func CheckTypeFromStructs(t *structs.CheckType, s *CheckType) {
 	if s == nil {
@@ -77,6 +82,11 @@ func CheckTypeFromStructs(t *structs.CheckType, s *CheckType) {
 	s.ProxyGRPC = t.ProxyGRPC
 	s.DeregisterCriticalServiceAfter = structs.DurationToProto(t.DeregisterCriticalServiceAfter)
 	s.OutputMaxSize = int32(t.OutputMaxSize)
+	s.AliasPropagationDelay = structs.DurationToProto(t.AliasPropagationDelay)
+	s.TTLExpiryDeregisterAfter = int32(t.TTLExpiryDeregisterAfter)
+	s.TTLExpiryWebhook = t.TTLExpiryWebhook
+	s.TTLExpiryMaintenance = t.TTLExpiryMaintenance
+	s.Templated = t.Templated
 }
 func HealthCheckToStructs(s *HealthCheck, t *structs.HealthCheck) {
 	if s == nil {
@@ -107,6 +107,7 @@ func PeeringServiceMetaToStructs(s *PeeringServiceMeta, t *structs.PeeringServic
 	t.SNI = s.SNI
 	t.SpiffeID = s.SpiffeID
 	t.Protocol = s.Protocol
+	t.IgnoreWeights = s.IgnoreWeights
 }
 func PeeringServiceMetaFromStructs(t *structs.PeeringServiceMeta, s *PeeringServiceMeta) {
 	if s == nil {
@@ -115,6 +116,7 @@ func PeeringServiceMetaFromStructs(t *structs.PeeringServiceMeta, s *PeeringServ
 	s.SNI = t.SNI
 	s.SpiffeID = t.SpiffeID
 	s.Protocol = t.Protocol
+	s.IgnoreWeights = t.IgnoreWeights
 }
 func ServiceConnectToStructs(s *ServiceConnect, t *structs.ServiceConnect) {
 	if s == nil {
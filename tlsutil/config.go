@@ -102,6 +102,15 @@ type ProtocolConfig struct {
 	//
 	// Note: this setting only applies to the Internal RPC configuration.
 	VerifyServerHostname bool
+
+	// ServerSPIFFEIDs are the SPIFFE IDs authorized to make server to server
+	// RPC connections. When set, AuthorizeServerConn checks the URI SAN of the
+	// certificate presented by the peer against these IDs instead of checking
+	// its DNS SAN against the server name. An ID ending with "/*" matches all
+	// the IDs with that path prefix.
+	//
+	// Note: this setting only applies to the Internal RPC configuration.
+	ServerSPIFFEIDs []string
 }
 
 // Config configures the Configurator.
@@ -135,6 +144,10 @@ type Config struct {
 	// AutoTLS opts the agent into provisioning agent
 	// TLS certificates.
 	AutoTLS bool
+
+	// AutoReloadCertificates makes the agent watch the certificate, key and CA
+	// files of all protocols and reload them when they change.
+	AutoReloadCertificates bool
 }
 
 // SpecificDC is used to invoke a static datacenter
@@ -240,6 +253,21 @@ func (c *Configurator) Update(config Config) error {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
+	return c.update(config)
+}
+
+// ReloadCertificates reads the certificates, keys and CAs of the current
+// configuration from disk again, so the files can be replaced without
+// updating the configuration.
+func (c *Configurator) ReloadCertificates() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.update(*c.base)
+}
+
+// update MUST be called with the write lock held.
+func (c *Configurator) update(config Config) error {
 	grpc, err := c.loadProtocolConfig(config, config.GRPC)
 	if err != nil {
 		return err
@@ -768,6 +796,20 @@ func (c *Configurator) IncomingALPNRPCConfig(alpnProtos []string) *tls.Config {
 	return config
 }
 
+// IncomingPeeringConfig is used by the servers for the peering streams
+// established over native TLS, such as through mesh gateways. Peers don't
+// have a client certificate signed by the CA of this cluster, so incoming
+// connections are not verified.
+func (c *Configurator) IncomingPeeringConfig(alpnProtos []string) *tls.Config {
+	c.log("IncomingPeeringConfig")
+	config := c.internalRPCTLSConfig(false)
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		return c.IncomingPeeringConfig(alpnProtos), nil
+	}
+	config.NextProtos = alpnProtos
+	return config
+}
+
 // IncomingInsecureRPCConfig means that it doesn't verify incoming even thought
 // it might have been configured. This is only supposed to be used by the
 // servers for the insecure RPC server. At the time of writing only the
@@ -1020,17 +1062,29 @@ type TLSConn interface {
 // presented is signed by the Agent TLS CA, and has a DNSName that matches the
 // local ServerSNI name.
 //
-// Note this check is only performed if VerifyServerHostname and VerifyIncomingRPC
-// are both enabled, otherwise it does no authorization.
+// When ServerSPIFFEIDs are configured for the internal RPC protocol, the
+// certificate must instead have a URI SAN matching one of them.
+//
+// Note this check is only performed if VerifyIncomingRPC and either
+// VerifyServerHostname or ServerSPIFFEIDs are enabled, otherwise it does no
+// authorization.
 func (c *Configurator) AuthorizeServerConn(dc string, conn TLSConn) error {
-	if !c.VerifyIncomingRPC() || !c.VerifyServerHostname() {
+	if !c.VerifyIncomingRPC() {
 		return nil
 	}
 
 	c.lock.RLock()
 	caPool := c.internalRPC.manualCAPool
+	spiffeIDs := c.base.InternalRPC.ServerSPIFFEIDs
 	c.lock.RUnlock()
 
+	if len(spiffeIDs) > 0 {
+		return authorizeServerSPIFFEID(conn, caPool, spiffeIDs)
+	}
+	if !c.VerifyServerHostname() {
+		return nil
+	}
+
 	expected := c.ServerSNI(dc, "")
 	cs := conn.ConnectionState()
 	var errs error
@@ -1060,6 +1114,57 @@ func (c *Configurator) AuthorizeServerConn(dc string, conn TLSConn) error {
 
 }
 
+// authorizeServerSPIFFEID checks that the certificate presented on the
+// connection is signed by the Agent TLS CA and has a URI SAN matching one of
+// the authorized SPIFFE IDs.
+func authorizeServerSPIFFEID(conn TLSConn, caPool *x509.CertPool, spiffeIDs []string) error {
+	cs := conn.ConnectionState()
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("AuthorizeServerConn failed: no peer certificate")
+	}
+
+	opts := x509.VerifyOptions{
+		Intermediates: x509.NewCertPool(),
+		Roots:         caPool,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	leaf := cs.PeerCertificates[0]
+	if _, err := leaf.Verify(opts); err != nil {
+		return fmt.Errorf("AuthorizeServerConn failed certificate validation: %w", err)
+	}
+
+	var presented []string
+	for _, uri := range leaf.URIs {
+		if uri.Scheme != "spiffe" {
+			continue
+		}
+		id := uri.String()
+		if spiffeIDMatches(spiffeIDs, id) {
+			return nil
+		}
+		presented = append(presented, id)
+	}
+	return fmt.Errorf("AuthorizeServerConn failed: certificate with SPIFFE IDs %v is not authorized", presented)
+}
+
+func spiffeIDMatches(authorized []string, id string) bool {
+	for _, pattern := range authorized {
+		if prefix := strings.TrimSuffix(pattern, "*"); prefix != pattern {
+			if strings.HasSuffix(prefix, "/") && strings.HasPrefix(id, prefix) {
+				return true
+			}
+			continue
+		}
+		if pattern == id {
+			return true
+		}
+	}
+	return false
+}
+
 // NOTE: any new cipher suites will also need to be added in types/tls.go
 // TODO: should this be moved into types/tls.go? Would importing Go's tls
 // package in there be acceptable?
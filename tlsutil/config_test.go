@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
@@ -1348,10 +1349,10 @@ func TestConfigurator_AuthorizeInternalRPCServerConn(t *testing.T) {
 		CA:     caPEM,
 	})
 	require.NoError(t, err)
-	certFile := filepath.Join("cert.pem")
+	certFile := filepath.Join(dir, "cert.pem")
 	err = ioutil.WriteFile(certFile, []byte(pub), 0600)
 	require.NoError(t, err)
-	keyFile := filepath.Join("cert.key")
+	keyFile := filepath.Join(dir, "cert.key")
 	err = ioutil.WriteFile(keyFile, []byte(pk), 0600)
 	require.NoError(t, err)
 
@@ -1465,6 +1466,94 @@ func TestConfigurator_AuthorizeInternalRPCServerConn(t *testing.T) {
 	})
 }
 
+func TestConfigurator_AuthorizeInternalRPCServerConn_SPIFFE(t *testing.T) {
+	caPEM, caPK, err := GenerateCA(CAOpts{Days: 5, Domain: "consul"})
+	require.NoError(t, err)
+
+	dir := testutil.TempDir(t, "ca")
+	caPath := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caPath, []byte(caPEM), 0600))
+
+	signer, err := ParseSigner(caPK)
+	require.NoError(t, err)
+
+	c := makeConfigurator(t, Config{
+		InternalRPC: ProtocolConfig{
+			VerifyIncoming:  true,
+			CAFile:          caPath,
+			ServerSPIFFEIDs: []string{"spiffe://example.org/consul/dc1/server", "spiffe://example.org/servers/*"},
+		},
+		AutoTLS: true,
+		Domain:  "consul",
+	})
+
+	connWithURI := func(t *testing.T, uri string) fakeTLSConn {
+		u, err := url.Parse(uri)
+		require.NoError(t, err)
+
+		pem, _, err := GenerateCert(CertOpts{
+			Signer: signer,
+			CA:     caPEM,
+			Name:   "server",
+			Days:   5,
+			// The DNS SAN is not checked when SPIFFE IDs are configured.
+			DNSNames:    []string{"this-name-is-wrong"},
+			URIs:        []*url.URL{u},
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		})
+		require.NoError(t, err)
+
+		return fakeTLSConn{
+			state: tls.ConnectionState{
+				VerifiedChains:   [][]*x509.Certificate{certChain(t, pem, caPEM)},
+				PeerCertificates: certChain(t, pem, caPEM),
+			},
+		}
+	}
+
+	require.NoError(t, c.AuthorizeServerConn("dc1", connWithURI(t, "spiffe://example.org/consul/dc1/server")))
+	require.NoError(t, c.AuthorizeServerConn("dc1", connWithURI(t, "spiffe://example.org/servers/a")))
+
+	err = c.AuthorizeServerConn("dc1", connWithURI(t, "spiffe://example.org/consul/dc1/client"))
+	testutil.RequireErrorContains(t, err, "is not authorized")
+
+	err = c.AuthorizeServerConn("dc1", connWithURI(t, "spiffe://example.org/serversevil"))
+	testutil.RequireErrorContains(t, err, "is not authorized")
+
+	err = c.AuthorizeServerConn("dc1", fakeTLSConn{})
+	testutil.RequireErrorContains(t, err, "no peer certificate")
+}
+
+func TestConfigurator_ReloadCertificates(t *testing.T) {
+	dir := testutil.TempDir(t, "certs")
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "cert.key")
+
+	caPEM, caPK, err := GenerateCA(CAOpts{Days: 5, Domain: "consul"})
+	require.NoError(t, err)
+	signer, err := ParseSigner(caPK)
+	require.NoError(t, err)
+
+	writeCert := func(t *testing.T) {
+		pub, pk, err := GenerateCert(CertOpts{Signer: signer, CA: caPEM, Name: "server", Days: 5})
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(certFile, []byte(pub), 0600))
+		require.NoError(t, ioutil.WriteFile(keyFile, []byte(pk), 0600))
+	}
+
+	writeCert(t)
+	c := makeConfigurator(t, Config{
+		InternalRPC: ProtocolConfig{CertFile: certFile, KeyFile: keyFile},
+	})
+	before := c.Cert()
+
+	writeCert(t)
+	require.Equal(t, before, c.Cert(), "the certificate must not change before the reload")
+
+	require.NoError(t, c.ReloadCertificates())
+	require.NotEqual(t, before.Certificate, c.Cert().Certificate)
+}
+
 func TestConfigurator_GRPCTLSConfigured(t *testing.T) {
 	t.Run("certificate manually configured", func(t *testing.T) {
 		c := makeConfigurator(t, Config{
@@ -53,6 +53,7 @@ type CertOpts struct {
 	Days        int
 	DNSNames    []string
 	IPAddresses []net.IP
+	URIs        []*url.URL
 	ExtKeyUsage []x509.ExtKeyUsage
 	IsCA        bool
 }
@@ -177,6 +178,7 @@ func GenerateCert(opts CertOpts) (string, string, error) {
 		SubjectKeyId:          id,
 		DNSNames:              opts.DNSNames,
 		IPAddresses:           opts.IPAddresses,
+		URIs:                  opts.URIs,
 	}
 	if opts.IsCA {
 		template.IsCA = true